@@ -0,0 +1,99 @@
+package assets
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+const (
+	// ArmoryConfigFileName - File listing the configured armories
+	ArmoryConfigFileName = "armories.json"
+	// ArmoryCacheDirName - Directory caching armory indexes and packages
+	ArmoryCacheDirName = "armory-cache"
+	// ExtensionsDirName - Directory containing installed extensions
+	ExtensionsDirName = "extensions"
+	// AliasesDirName - Directory containing installed aliases
+	AliasesDirName = "aliases"
+)
+
+// ArmoryConfig - An armory index and the public key its packages are signed with
+type ArmoryConfig struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	PublicKey string `json:"public_key"` // Base64 encoded ed25519 public key
+}
+
+// GetArmoryConfigs - Returns the configured armories
+func GetArmoryConfigs() []*ArmoryConfig {
+	configPath := path.Join(GetRootAppDir(), ArmoryConfigFileName)
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return []*ArmoryConfig{}
+	}
+	armories := []*ArmoryConfig{}
+	err = json.Unmarshal(data, &armories)
+	if err != nil {
+		log.Printf("Failed to parse armory config %v", err)
+		return []*ArmoryConfig{}
+	}
+	return armories
+}
+
+// SaveArmoryConfigs - Save the configured armories to disk
+func SaveArmoryConfigs(armories []*ArmoryConfig) error {
+	configPath := path.Join(GetRootAppDir(), ArmoryConfigFileName)
+	data, err := json.MarshalIndent(armories, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, data, 0600)
+}
+
+// GetArmoryCacheDir - Returns the path to the armory cache dir
+func GetArmoryCacheDir() string {
+	return getAppSubDir(ArmoryCacheDirName)
+}
+
+// GetExtensionsDir - Returns the path to the installed extensions dir
+func GetExtensionsDir() string {
+	return getAppSubDir(ExtensionsDirName)
+}
+
+// GetAliasesDir - Returns the path to the installed aliases dir
+func GetAliasesDir() string {
+	return getAppSubDir(AliasesDirName)
+}
+
+func getAppSubDir(name string) string {
+	rootDir, _ := filepath.Abs(GetRootAppDir())
+	dir := path.Join(rootDir, name)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	return dir
+}
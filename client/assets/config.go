@@ -28,6 +28,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -43,6 +44,9 @@ type ClientConfig struct {
 	CACertificate string `json:"ca_certificate"`
 	PrivateKey    string `json:"private_key"`
 	Certificate   string `json:"certificate"`
+
+	// Profile - The config file's name without extension, set when it's read from the configs dir
+	Profile string `json:"-"`
 }
 
 // GetConfigDir - Returns the path to the config dir
@@ -76,12 +80,23 @@ func GetConfigs() map[string]*ClientConfig {
 		if err != nil {
 			continue
 		}
+		conf.Profile = strings.TrimSuffix(confFile.Name(), filepath.Ext(confFile.Name()))
 		digest := sha256.Sum256([]byte(conf.Certificate))
 		confs[fmt.Sprintf("%s@%s (%x)", conf.Operator, conf.LHost, digest[:8])] = conf
 	}
 	return confs
 }
 
+// GetProfile - A config from the configs dir by profile name, nil if there isn't one
+func GetProfile(name string) *ClientConfig {
+	for _, conf := range GetConfigs() {
+		if conf.Profile == name {
+			return conf
+		}
+	}
+	return nil
+}
+
 // ReadConfig - Load config into struct
 func ReadConfig(confFilePath string) (*ClientConfig, error) {
 	confFile, err := os.Open(confFilePath)
@@ -119,5 +134,6 @@ func SaveConfig(config *ClientConfig) error {
 		return err
 	}
 	log.Printf("Saved new client config to: %s", saveTo)
+	config.Profile = strings.TrimSuffix(filename, filepath.Ext(filename))
 	return nil
 }
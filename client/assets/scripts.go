@@ -0,0 +1,54 @@
+package assets
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// ScriptsDirName - Directory containing the operator's console scripts
+	ScriptsDirName = "scripts"
+	// ScriptFileExt - Extension of console scripts, may be omitted when running one
+	ScriptFileExt = ".sliver"
+)
+
+// GetScriptsDir - Returns the path to the console scripts dir
+func GetScriptsDir() string {
+	return getAppSubDir(ScriptsDirName)
+}
+
+// GetScripts - Returns the names of the scripts in the scripts dir
+func GetScripts() []string {
+	scripts := []string{}
+	files, err := ioutil.ReadDir(GetScriptsDir())
+	if err != nil {
+		return scripts
+	}
+	for _, file := range files {
+		if !file.IsDir() && filepath.Ext(file.Name()) == ScriptFileExt {
+			scripts = append(scripts, strings.TrimSuffix(file.Name(), ScriptFileExt))
+		}
+	}
+	sort.Strings(scripts)
+	return scripts
+}
@@ -0,0 +1,71 @@
+package assets
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"path"
+)
+
+const (
+	// SettingsFileName - Console preferences of the operator
+	SettingsFileName = "settings.json"
+	// HistoryFileName - Console command history, one command per line
+	HistoryFileName = "history"
+)
+
+// ClientSettings - Console preferences, kept across runs
+type ClientSettings struct {
+	Theme   string          `json:"theme"`
+	Timeout int             `json:"timeout,omitempty"` // Seconds, used by commands run without --timeout
+	Notify  *NotifySettings `json:"notify,omitempty"`
+}
+
+// NotifySettings - Local notifications for server events, off unless Bell or Command is set
+type NotifySettings struct {
+	Bell    bool     `json:"bell,omitempty"`
+	Command string   `json:"command,omitempty"` // Run with the title and message as the last two arguments
+	Events  []string `json:"events,omitempty"`  // Event types, empty for the defaults
+}
+
+// GetSettings - Returns the saved console preferences, or the defaults
+func GetSettings() *ClientSettings {
+	settings := &ClientSettings{}
+	data, err := ioutil.ReadFile(path.Join(GetRootAppDir(), SettingsFileName))
+	if err != nil {
+		return settings
+	}
+	err = json.Unmarshal(data, settings)
+	if err != nil {
+		log.Printf("Failed to parse settings %v", err)
+		return &ClientSettings{}
+	}
+	return settings
+}
+
+// SaveSettings - Save the console preferences to disk
+func SaveSettings(settings *ClientSettings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(GetRootAppDir(), SettingsFileName), data, 0600)
+}
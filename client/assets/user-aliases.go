@@ -0,0 +1,73 @@
+package assets
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"path"
+)
+
+const (
+	// UserAliasesFileName - File containing the operator's own command aliases
+	UserAliasesFileName = "user-aliases.json"
+)
+
+// UserAlias - A shorthand for one or more console commands, each command is
+// stored pre-split into its arguments
+type UserAlias struct {
+	Name     string     `json:"name"`
+	Help     string     `json:"help"`
+	Commands [][]string `json:"commands"`
+}
+
+// GetUserAliases - Returns the operator's command aliases
+func GetUserAliases() []*UserAlias {
+	return ReadUserAliases(path.Join(GetRootAppDir(), UserAliasesFileName))
+}
+
+// ReadUserAliases - Load command aliases from a file, e.g. one shared by another operator
+func ReadUserAliases(aliasesPath string) []*UserAlias {
+	data, err := ioutil.ReadFile(aliasesPath)
+	if err != nil {
+		return []*UserAlias{}
+	}
+	aliases := []*UserAlias{}
+	err = json.Unmarshal(data, &aliases)
+	if err != nil {
+		log.Printf("Failed to parse aliases %v", err)
+		return []*UserAlias{}
+	}
+	return aliases
+}
+
+// SaveUserAliases - Save the operator's command aliases to disk
+func SaveUserAliases(aliases []*UserAlias) error {
+	return WriteUserAliases(path.Join(GetRootAppDir(), UserAliasesFileName), aliases)
+}
+
+// WriteUserAliases - Write command aliases to a file that can be shared and imported
+func WriteUserAliases(aliasesPath string, aliases []*UserAlias) error {
+	data, err := json.MarshalIndent(aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(aliasesPath, data, 0600)
+}
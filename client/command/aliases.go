@@ -0,0 +1,80 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/desertbit/grumble"
+)
+
+const (
+	// aliasManifestFileName - Manifest of an alias package
+	aliasManifestFileName = "alias.json"
+	// aliasDirVar - Replaced by the alias directory in the alias arguments
+	aliasDirVar = "$ALIAS_DIR"
+)
+
+// alias - A console command that runs another command with preset arguments
+type alias struct {
+	Name    string   `json:"name"`
+	Help    string   `json:"help"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// loadAlias - Load the alias in aliasPath and add its command to the app
+func loadAlias(app *grumble.App, aliasPath string) error {
+	data, err := ioutil.ReadFile(filepath.Join(aliasPath, aliasManifestFileName))
+	if err != nil {
+		return err
+	}
+	a := &alias{}
+	err = json.Unmarshal(data, a)
+	if err != nil {
+		return err
+	}
+	if a.Name == "" || a.Command == "" {
+		return fmt.Errorf("alias manifest is missing a name or command")
+	}
+	if cmdExists(a.Name, app) {
+		return fmt.Errorf("%s command already exists", a.Name)
+	}
+	aliasDir, _ := filepath.Abs(aliasPath)
+	args := []string{a.Command}
+	for _, arg := range a.Args {
+		args = append(args, strings.Replace(arg, aliasDirVar, aliasDir, -1))
+	}
+	app.AddCommand(&grumble.Command{
+		Name:      a.Name,
+		Help:      fmt.Sprintf("[alias] %s", a.Help),
+		LongHelp:  fmt.Sprintf("Alias for: %s", strings.Join(args, " ")),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			return ctx.App.RunCommand(append(append([]string{}, args...), ctx.Args...))
+		},
+		HelpGroup: consts.ExtensionHelpGroup,
+	})
+	return nil
+}
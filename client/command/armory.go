@@ -0,0 +1,470 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/desertbit/grumble"
+)
+
+const (
+	armoryIndexCacheTTL      = time.Hour
+	armoryPackageMetaName    = ".armory.json"
+	armoryExtensionType      = "extension"
+	armoryAliasType          = "alias"
+	armoryMaxPackageFileSize = 256 * 1024 * 1024
+)
+
+// armoryIndex - The package index served by an armory
+type armoryIndex struct {
+	Packages []*armoryPackage `json:"packages"`
+}
+
+// armoryPackage - An extension or alias package, the signature is a base64
+// encoded ed25519 signature of the package archive (.tar.gz)
+type armoryPackage struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Type      string `json:"type"`
+	Help      string `json:"help"`
+	URL       string `json:"url"`
+	Signature string `json:"signature"`
+	Armory    string `json:"armory"`
+}
+
+func armory(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listArmoryPackages(ctx)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listArmoryPackages(ctx)
+	case "install":
+		installArmoryPackage(ctx, rpc)
+	case "uninstall":
+		uninstallArmoryPackage(ctx)
+	case "add":
+		addArmory(ctx)
+	case "rm":
+		removeArmory(ctx)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help armory'")
+	}
+}
+
+func listArmoryPackages(ctx *grumble.Context) {
+	armories := assets.GetArmoryConfigs()
+	if len(armories) == 0 {
+		fmt.Printf(Info + "No armories configured, see 'help armory'\n")
+		return
+	}
+	packages := fetchArmoryPackages(ctx, armories)
+	if len(packages) == 0 {
+		fmt.Printf(Info + "No packages\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Armory\tName\tVersion\tType\tInstalled\tHelp\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Armory")),
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Version")),
+		strings.Repeat("=", len("Type")),
+		strings.Repeat("=", len("Installed")),
+		strings.Repeat("=", len("Help")))
+	for _, pkg := range packages {
+		installed := ""
+		if meta := installedArmoryPackage(pkg.Name); meta != nil {
+			installed = meta.Version
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			pkg.Armory, pkg.Name, pkg.Version, pkg.Type, installed, pkg.Help)
+	}
+	table.Flush()
+}
+
+func installArmoryPackage(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing package name, see 'help armory'")
+		return
+	}
+	armories := assets.GetArmoryConfigs()
+	var pkg *armoryPackage
+	for _, armoryPkg := range fetchArmoryPackages(ctx, armories) {
+		if armoryPkg.Name == ctx.Args[1] {
+			pkg = armoryPkg
+			break
+		}
+	}
+	if pkg == nil {
+		fmt.Printf(Warn+"No package named %s\n", ctx.Args[1])
+		return
+	}
+	var armoryConfig *assets.ArmoryConfig
+	for _, config := range armories {
+		if config.Name == pkg.Armory {
+			armoryConfig = config
+		}
+	}
+
+	fmt.Printf(Info+"Installing %s %s from %s ...\n", pkg.Name, pkg.Version, pkg.Armory)
+	data, err := downloadArmoryPackage(armoryHTTPClient(ctx), armoryConfig, pkg)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to download package: %s\n", err)
+		return
+	}
+	installPath, err := armoryInstallPath(pkg)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	os.RemoveAll(installPath)
+	err = extractArmoryPackage(data, installPath)
+	if err == nil {
+		var meta []byte
+		meta, _ = json.Marshal(pkg)
+		err = ioutil.WriteFile(filepath.Join(installPath, armoryPackageMetaName), meta, 0600)
+	}
+	if err != nil {
+		os.RemoveAll(installPath)
+		fmt.Printf(Warn+"Failed to install package: %s\n", err)
+		return
+	}
+
+	if pkg.Type == armoryAliasType {
+		err = loadAlias(ctx.App, installPath)
+	} else {
+		err = loadExtension(ctx.App, rpc, installPath)
+	}
+	if err != nil {
+		fmt.Printf(Warn+"Package installed, restart the console to use it (%s)\n", err)
+		return
+	}
+	wrapOutputRedirects(ctx.App, rpc)
+	fmt.Printf(Info+"Installed %s %s\n", pkg.Name, pkg.Version)
+}
+
+func uninstallArmoryPackage(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing package name, see 'help armory'")
+		return
+	}
+	meta := installedArmoryPackage(ctx.Args[1])
+	if meta == nil {
+		fmt.Printf(Warn+"Package %s is not installed\n", ctx.Args[1])
+		return
+	}
+	installPath, err := armoryInstallPath(meta)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	err = os.RemoveAll(installPath)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to uninstall package: %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Uninstalled %s, its commands are available until the console is restarted\n", meta.Name)
+}
+
+func addArmory(ctx *grumble.Context) {
+	if len(ctx.Args) < 4 {
+		fmt.Println(Warn + "Missing armory name, url or public key, see 'help armory'")
+		return
+	}
+	config := &assets.ArmoryConfig{
+		Name:      ctx.Args[1],
+		URL:       ctx.Args[2],
+		PublicKey: ctx.Args[3],
+	}
+	if _, err := armoryPublicKey(config); err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	armories := []*assets.ArmoryConfig{}
+	for _, armory := range assets.GetArmoryConfigs() {
+		if armory.Name != config.Name {
+			armories = append(armories, armory)
+		}
+	}
+	err := assets.SaveArmoryConfigs(append(armories, config))
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save armory config: %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Added armory %s\n", config.Name)
+}
+
+func removeArmory(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing armory name, see 'help armory'")
+		return
+	}
+	armories := []*assets.ArmoryConfig{}
+	for _, armory := range assets.GetArmoryConfigs() {
+		if armory.Name != ctx.Args[1] {
+			armories = append(armories, armory)
+		}
+	}
+	err := assets.SaveArmoryConfigs(armories)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save armory config: %s\n", err)
+		return
+	}
+	os.Remove(armoryIndexCachePath(ctx.Args[1]))
+	fmt.Printf(Info+"Removed armory %s\n", ctx.Args[1])
+}
+
+// loadArmoryPackages - Load the installed extensions and aliases
+func loadArmoryPackages(app *grumble.App, rpc rpcpb.SliverRPCClient) {
+	extensions, _ := ioutil.ReadDir(assets.GetExtensionsDir())
+	for _, ext := range extensions {
+		if !ext.IsDir() {
+			continue
+		}
+		err := loadExtension(app, rpc, filepath.Join(assets.GetExtensionsDir(), ext.Name()))
+		if err != nil {
+			fmt.Printf(Warn+"Failed to load extension %s: %s\n", ext.Name(), err)
+		}
+	}
+	aliases, _ := ioutil.ReadDir(assets.GetAliasesDir())
+	for _, alias := range aliases {
+		if !alias.IsDir() {
+			continue
+		}
+		err := loadAlias(app, filepath.Join(assets.GetAliasesDir(), alias.Name()))
+		if err != nil {
+			fmt.Printf(Warn+"Failed to load alias %s: %s\n", alias.Name(), err)
+		}
+	}
+}
+
+func armoryHTTPClient(ctx *grumble.Context) *http.Client {
+	timeout := time.Duration(ctx.Flags.Int("timeout")) * time.Second
+	var proxyURL *url.URL
+	if proxy := ctx.Flags.String("proxy"); proxy != "" {
+		var err error
+		proxyURL, err = url.Parse(proxy)
+		if err != nil {
+			fmt.Printf(Warn+"Invalid proxy url %s\n", err)
+		}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Dial: (&net.Dialer{
+				Timeout: timeout,
+			}).Dial,
+			TLSHandshakeTimeout: timeout,
+			Proxy:               http.ProxyURL(proxyURL),
+		},
+	}
+}
+
+// fetchArmoryPackages - Get the packages of each armory, indexes are cached
+// and the cache is used when an armory cannot be reached
+func fetchArmoryPackages(ctx *grumble.Context, armories []*assets.ArmoryConfig) []*armoryPackage {
+	client := armoryHTTPClient(ctx)
+	refresh := ctx.Flags.Bool("refresh")
+	packages := []*armoryPackage{}
+	for _, armory := range armories {
+		cachePath := armoryIndexCachePath(armory.Name)
+		var data []byte
+		fi, err := os.Stat(cachePath)
+		if err == nil && !refresh && time.Since(fi.ModTime()) < armoryIndexCacheTTL {
+			data, err = ioutil.ReadFile(cachePath)
+		} else {
+			data, err = httpGet(client, armory.URL)
+			if err == nil {
+				ioutil.WriteFile(cachePath, data, 0600)
+			} else if cached, cacheErr := ioutil.ReadFile(cachePath); cacheErr == nil {
+				fmt.Printf(Warn+"Failed to fetch %s index, using cache (%s)\n", armory.Name, err)
+				data, err = cached, nil
+			}
+		}
+		if err != nil {
+			fmt.Printf(Warn+"Failed to fetch %s index: %s\n", armory.Name, err)
+			continue
+		}
+		index := &armoryIndex{}
+		err = json.Unmarshal(data, index)
+		if err != nil {
+			fmt.Printf(Warn+"Failed to parse %s index: %s\n", armory.Name, err)
+			continue
+		}
+		indexURL, _ := url.Parse(armory.URL)
+		for _, pkg := range index.Packages {
+			pkg.Armory = armory.Name
+			if pkgURL, err := url.Parse(pkg.URL); err == nil && indexURL != nil {
+				pkg.URL = indexURL.ResolveReference(pkgURL).String()
+			}
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// downloadArmoryPackage - Get the package archive from the cache or the armory,
+// and verify its signature
+func downloadArmoryPackage(client *http.Client, armory *assets.ArmoryConfig, pkg *armoryPackage) ([]byte, error) {
+	publicKey, err := armoryPublicKey(armory)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(pkg.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package signature %s", err)
+	}
+	cachePath := filepath.Join(assets.GetArmoryCacheDir(),
+		fmt.Sprintf("%s_%s_%s.tar.gz", filepath.Base(pkg.Armory), filepath.Base(pkg.Name), filepath.Base(pkg.Version)))
+	if data, err := ioutil.ReadFile(cachePath); err == nil && ed25519.Verify(publicKey, data, signature) {
+		return data, nil
+	}
+	data, err := httpGet(client, pkg.URL)
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(publicKey, data, signature) {
+		return nil, errors.New("invalid package signature")
+	}
+	err = ioutil.WriteFile(cachePath, data, 0600)
+	if err != nil {
+		log.Printf("Failed to cache package %s", err)
+	}
+	return data, nil
+}
+
+// extractArmoryPackage - Extract a package archive into installPath
+func extractArmoryPackage(data []byte, installPath string) error {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(installPath, filepath.FromSlash(header.Name))
+		if dst != installPath && !strings.HasPrefix(dst, installPath+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in package %s", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dst, 0700)
+		case tar.TypeReg:
+			if armoryMaxPackageFileSize < header.Size {
+				return fmt.Errorf("%s is too large", header.Name)
+			}
+			err = os.MkdirAll(filepath.Dir(dst), 0700)
+			if err == nil {
+				var fileData []byte
+				fileData, err = ioutil.ReadAll(tarReader)
+				if err == nil {
+					err = ioutil.WriteFile(dst, fileData, 0600)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// installedArmoryPackage - Get the metadata of an installed package, nil if
+// the package is not installed
+func installedArmoryPackage(name string) *armoryPackage {
+	for _, dir := range []string{assets.GetExtensionsDir(), assets.GetAliasesDir()} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, filepath.Base(name), armoryPackageMetaName))
+		if err != nil {
+			continue
+		}
+		pkg := &armoryPackage{}
+		if json.Unmarshal(data, pkg) == nil {
+			return pkg
+		}
+	}
+	return nil
+}
+
+func armoryInstallPath(pkg *armoryPackage) (string, error) {
+	if pkg.Name == "" || pkg.Name != filepath.Base(pkg.Name) || pkg.Name == ".." {
+		return "", fmt.Errorf("invalid package name %s", pkg.Name)
+	}
+	switch pkg.Type {
+	case armoryExtensionType:
+		return filepath.Join(assets.GetExtensionsDir(), pkg.Name), nil
+	case armoryAliasType:
+		return filepath.Join(assets.GetAliasesDir(), pkg.Name), nil
+	}
+	return "", fmt.Errorf("unknown package type %s", pkg.Type)
+}
+
+func armoryIndexCachePath(name string) string {
+	return filepath.Join(assets.GetArmoryCacheDir(), fmt.Sprintf("%s_index.json", filepath.Base(name)))
+}
+
+func armoryPublicKey(armory *assets.ArmoryConfig) (ed25519.PublicKey, error) {
+	if armory == nil {
+		return nil, errors.New("unknown armory")
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(armory.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key for armory %s", armory.Name)
+	}
+	return ed25519.PublicKey(publicKey), nil
+}
+
+func httpGet(client *http.Client, target string) ([]byte, error) {
+	resp, err := client.Get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, armoryMaxPackageFileSize))
+}
@@ -0,0 +1,82 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/desertbit/grumble"
+)
+
+func arp(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	arpTable, err := rpc.Arp(context.Background(), &sliverpb.ArpReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if arpTable.Response != nil && arpTable.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", arpTable.Response.Err)
+		return
+	}
+
+	ifaceFilter := ctx.Flags.String("interface")
+	showAll := ctx.Flags.Bool("all")
+	entries := []*sliverpb.ArpEntry{}
+	for _, entry := range arpTable.Entries {
+		if ifaceFilter != "" && entry.Interface != ifaceFilter {
+			continue
+		}
+		if !showAll && (entry.MAC == "" || entry.State == "failed") {
+			continue
+		}
+		if ctx.Flags.Bool("ip4") && strings.Contains(entry.IP, ":") {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		fmt.Printf(Info + "No neighbors found\n")
+		return
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "IP Address\tMAC Address\tInterface\tState\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("IP Address")),
+		strings.Repeat("=", len("MAC Address")),
+		strings.Repeat("=", len("Interface")),
+		strings.Repeat("=", len("State")),
+	)
+	for _, entry := range entries {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n", entry.IP, entry.MAC, entry.Interface, entry.State)
+	}
+	table.Flush()
+}
@@ -0,0 +1,290 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+	"github.com/golang/protobuf/proto"
+)
+
+func beacons(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if 0 < len(ctx.Args) && strings.ToLower(ctx.Args[0]) == "rm" {
+		removeBeacon(ctx, rpc)
+		return
+	}
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	beacons, err := rpc.GetBeacons(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if format != tableFormat {
+		filter, err := newListingFilter(ctx)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		printFormatted(format, beaconsTable(beacons.Beacons, filter))
+		return
+	}
+	if len(beacons.Beacons) == 0 {
+		fmt.Printf(Info + "No beacons 🙁\n")
+		return
+	}
+	filter, err := newListingFilter(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	matched := []*clientpb.Beacon{}
+	for _, beacon := range beacons.Beacons {
+		if filter.Match(beaconListingTarget(beacon)) {
+			matched = append(matched, beacon)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Printf(Info+"No beacons match the filters (%d total)\n", len(beacons.Beacons))
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tName\tTransport\tHostname\tUsername\tOperating System\tInterval\tTasks\tLast Check-in\tNext Check-in\tTags\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Transport")),
+		strings.Repeat("=", len("Hostname")),
+		strings.Repeat("=", len("Username")),
+		strings.Repeat("=", len("Operating System")),
+		strings.Repeat("=", len("Interval")),
+		strings.Repeat("=", len("Tasks")),
+		strings.Repeat("=", len("Last Check-in")),
+		strings.Repeat("=", len("Next Check-in")),
+		strings.Repeat("=", len("Tags")))
+	for _, beacon := range matched {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			beacon.ID,
+			beacon.Name,
+			beacon.Transport,
+			beacon.Hostname,
+			beacon.Username,
+			fmt.Sprintf("%s/%s", beacon.OS, beacon.Arch),
+			fmt.Sprintf("%ds ±%ds", beacon.Interval, beacon.Jitter),
+			fmt.Sprintf("%d/%d", beacon.TasksCountCompleted, beacon.TasksCount),
+			beacon.LastCheckin,
+			beacon.NextCheckin,
+			strings.Join(beacon.Tags, ","),
+		)
+	}
+	table.Flush()
+}
+
+func removeBeacon(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing beacon id, see `help beacons`\n")
+		return
+	}
+	beacon := GetBeacon(ctx.Args[1], rpc)
+	if beacon == nil {
+		fmt.Printf(Warn+"Invalid beacon id '%s'\n", ctx.Args[1])
+		return
+	}
+	_, err := rpc.RmBeacon(context.Background(), beacon)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	activeBeacon := ActiveSession.GetBeacon()
+	if activeBeacon != nil && activeBeacon.ID == beacon.ID {
+		ActiveSession.Background()
+	}
+	fmt.Printf(Info+"Removed beacon %s (%s)\n", beacon.Name, beacon.ID)
+}
+
+func tasks(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	beacon := ActiveSession.GetBeacon()
+	if beacon == nil {
+		fmt.Printf(Warn + "Please select an active beacon via `use`\n")
+		return
+	}
+	if 0 < len(ctx.Args) {
+		switch strings.ToLower(ctx.Args[0]) {
+		case "fetch":
+			fetchBeaconTask(ctx, beacon, rpc)
+			return
+		case "queue":
+			beaconTaskQueue(ctx, beacon, rpc)
+			return
+		case "cancel":
+			cancelBeaconTask(ctx, beacon, rpc)
+			return
+		case "move":
+			moveBeaconTask(ctx, beacon, rpc)
+			return
+		}
+	}
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if format != tableFormat {
+		table, err := exportTasks(beacon.ID, &listingFilter{}, rpc)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		printFormatted(format, table)
+		return
+	}
+	beaconTasks, err := rpc.GetBeaconTasks(context.Background(), beacon)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(beaconTasks.Tasks) == 0 {
+		fmt.Printf(Info + "No tasks\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tState\tTask\tCreated\tCompleted\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("State")),
+		strings.Repeat("=", len("Task")),
+		strings.Repeat("=", len("Created")),
+		strings.Repeat("=", len("Completed")))
+	for _, task := range beaconTasks.Tasks {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+			task.ID, task.State, task.Description, task.CreatedAt, task.CompletedAt)
+	}
+	table.Flush()
+}
+
+func fetchBeaconTask(ctx *grumble.Context, beacon *clientpb.Beacon, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing task id, see `help tasks`\n")
+		return
+	}
+	task, err := rpc.GetBeaconTaskContent(context.Background(), &clientpb.BeaconTask{
+		ID:       ctx.Args[1],
+		BeaconID: beacon.ID,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if task.State != "completed" {
+		fmt.Printf(Info+"Task %s is %s\n", task.ID, task.State)
+		return
+	}
+	if task.Err != "" {
+		fmt.Printf(Warn+"%s\n", task.Err)
+		return
+	}
+	resp := taskResponseMessage(task.Description)
+	if resp == nil || proto.Unmarshal(task.Response, resp) != nil {
+		fmt.Printf(Info+"Task %s returned %d byte(s)\n", task.ID, len(task.Response))
+		return
+	}
+	if generic, ok := resp.(interface{ GetResponse() *commonpb.Response }); ok {
+		if generic.GetResponse().GetErr() != "" {
+			fmt.Printf(Warn+"%s\n", generic.GetResponse().GetErr())
+			return
+		}
+	}
+	if ls, ok := resp.(*sliverpb.Ls); ok && ls.Exists {
+		// Beacons are never tasked by the completer, so remember what they sent back
+		DirCache.Put(0, beacon.ID, ls.Path, ls)
+	}
+	fmt.Printf(Info+"Task %s (%s) completed at %s\n\n", task.ID, task.Description, task.CompletedAt)
+	fmt.Println(proto.MarshalTextString(resp))
+}
+
+// taskResponseMessage - Responses are named after their request, e.g. LsReq -> Ls
+func taskResponseMessage(description string) proto.Message {
+	msgType := proto.MessageType("sliverpb." + strings.TrimSuffix(description, "Req"))
+	if msgType == nil {
+		return nil
+	}
+	msg, ok := reflect.New(msgType.Elem()).Interface().(proto.Message)
+	if !ok {
+		return nil
+	}
+	return msg
+}
+
+func reconfig(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	sleepObfuscation := sliverpb.ReconfigReq_UNCHANGED
+	switch strings.ToLower(ctx.Flags.String("sleep-obfuscation")) {
+	case "":
+	case "on":
+		sleepObfuscation = sliverpb.ReconfigReq_ENABLE
+	case "off":
+		sleepObfuscation = sliverpb.ReconfigReq_DISABLE
+	default:
+		fmt.Printf(Warn + "Sleep obfuscation must be 'on' or 'off'\n")
+		return
+	}
+	var bandwidth int64
+	switch strings.ToLower(ctx.Flags.String("bandwidth")) {
+	case "":
+	case "off":
+		bandwidth = -1
+	default:
+		var err error
+		bandwidth, err = parseBandwidth(ctx.Flags.String("bandwidth"))
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+	}
+	_, err := rpc.Reconfig(context.Background(), &sliverpb.ReconfigReq{
+		ReconnectInterval: int64(ctx.Flags.Int("reconnect")),
+		BeaconInterval:    int64(ctx.Flags.Int("beacon-interval")),
+		BeaconJitter:      int64(ctx.Flags.Int("beacon-jitter")),
+		SleepObfuscation:  sleepObfuscation,
+		BandwidthLimit:    bandwidth,
+		Request:           ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info + "Reconfigured implant\n")
+}
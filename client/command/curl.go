@@ -0,0 +1,127 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/desertbit/grumble"
+)
+
+func curl(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Missing URL, see `help curl`\n")
+		return
+	}
+	url := ctx.Args[0]
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+
+	headers := []*sliverpb.HTTPHeader{}
+	for _, arg := range ctx.Args[1:] {
+		parts := strings.SplitN(arg, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			fmt.Printf(Warn+"Invalid header '%s', expected 'Name: value'\n", arg)
+			return
+		}
+		headers = append(headers, &sliverpb.HTTPHeader{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	var body []byte
+	if dataFile := ctx.Flags.String("data-file"); dataFile != "" {
+		var err error
+		body, err = ioutil.ReadFile(dataFile)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+	} else if data := ctx.Flags.String("data"); data != "" {
+		body = []byte(data)
+	}
+
+	method := ctx.Flags.String("method")
+	if ctx.Flags.Bool("head") {
+		method = "HEAD"
+	} else if method == "" && 0 < len(body) {
+		method = "POST"
+	}
+
+	result, err := rpc.Curl(context.Background(), &sliverpb.CurlReq{
+		URL:             url,
+		Method:          method,
+		Headers:         headers,
+		Body:            body,
+		Insecure:        ctx.Flags.Bool("insecure"),
+		FollowRedirects: ctx.Flags.Bool("location"),
+		MaxBodySize:     ctx.Flags.Int64("max-size"),
+		Timeout:         int64(ctx.Flags.Int("request-timeout")),
+		Request:         ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		if result.StatusCode == 0 {
+			return
+		}
+	}
+
+	if ctx.Flags.Bool("include") || ctx.Flags.Bool("head") {
+		fmt.Printf("%s %s\n", result.Proto, result.Status)
+		for _, header := range result.Headers {
+			fmt.Printf("%s: %s\n", header.Name, header.Value)
+		}
+		fmt.Println()
+	} else {
+		fmt.Printf(Info+"%s %s%s%s\n", result.URL, bold, result.Status, normal)
+	}
+
+	if output := ctx.Flags.String("output"); output != "" {
+		err = ioutil.WriteFile(output, result.Body, 0600)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		fmt.Printf(Info+"Wrote %d bytes to %s\n", len(result.Body), output)
+	} else if 0 < len(result.Body) {
+		os.Stdout.Write(result.Body)
+		if !strings.HasSuffix(string(result.Body), "\n") {
+			fmt.Println()
+		}
+	}
+	if result.Truncated {
+		fmt.Printf(Warn+"Body truncated to %d bytes (see --max-size)\n", len(result.Body))
+	}
+}
@@ -0,0 +1,121 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/desertbit/grumble"
+)
+
+func getEnv(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	name := ""
+	if 0 < len(ctx.Args) {
+		name = ctx.Args[0]
+	}
+	envInfo, err := rpc.GetEnv(context.Background(), &sliverpb.EnvReq{
+		Name:    name,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if envInfo.Response != nil && envInfo.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", envInfo.Response.Err)
+		return
+	}
+	if len(envInfo.Variables) == 0 {
+		if name != "" {
+			fmt.Printf(Info+"%s is not set\n", name)
+		} else {
+			fmt.Printf(Info + "Environment is empty\n")
+		}
+		return
+	}
+	sort.Slice(envInfo.Variables, func(i, j int) bool {
+		return envInfo.Variables[i].Key < envInfo.Variables[j].Key
+	})
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	for _, variable := range envInfo.Variables {
+		fmt.Fprintf(table, "%s\t%s\n", variable.Key, variable.Value)
+	}
+	table.Flush()
+}
+
+func setEnv(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) != 2 {
+		fmt.Printf(Warn + "Usage: setenv <name> <value>\n")
+		return
+	}
+
+	setEnv, err := rpc.SetEnv(context.Background(), &sliverpb.SetEnvReq{
+		Variable: &sliverpb.EnvVar{Key: ctx.Args[0], Value: ctx.Args[1]},
+		Request:  ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if setEnv.Response != nil && setEnv.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", setEnv.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Set %s=%s\n", ctx.Args[0], ctx.Args[1])
+}
+
+func unsetEnv(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) != 1 {
+		fmt.Printf(Warn + "Usage: unsetenv <name>\n")
+		return
+	}
+
+	unsetEnv, err := rpc.UnsetEnv(context.Background(), &sliverpb.UnsetEnvReq{
+		Name:    ctx.Args[0],
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if unsetEnv.Response != nil && unsetEnv.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", unsetEnv.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Unset %s\n", ctx.Args[0])
+}
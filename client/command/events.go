@@ -0,0 +1,69 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+var (
+	// ConsoleEvents - Server events received by the console, see AddListener
+	ConsoleEvents = &consoleEvents{
+		listeners: map[int]EventListener{},
+	}
+)
+
+// EventListener - A function to call for each server event
+type EventListener func(*clientpb.Event)
+
+type consoleEvents struct {
+	listeners  map[int]EventListener
+	listenerID int
+	mutex      sync.Mutex
+}
+
+// AddListener - Listeners are called from the event loop, they must not block
+func (e *consoleEvents) AddListener(listener EventListener) int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.listenerID++
+	e.listeners[e.listenerID] = listener
+	return e.listenerID
+}
+
+func (e *consoleEvents) RemoveListener(listenerID int) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.listeners, listenerID)
+}
+
+// Publish - Called by the console for every event it receives
+func (e *consoleEvents) Publish(event *clientpb.Event) {
+	e.mutex.Lock()
+	listeners := []EventListener{}
+	for _, listener := range e.listeners {
+		listeners = append(listeners, listener)
+	}
+	e.mutex.Unlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
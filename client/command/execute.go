@@ -18,7 +18,11 @@ package command
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
 
+	"github.com/bishopfox/sliver/client/core"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/desertbit/grumble"
@@ -41,6 +45,15 @@ func execute(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		args = ctx.Args[1:]
 	}
 	output := ctx.Flags.Bool("silent")
+	if ctx.Flags.Bool("stream") || ctx.Flags.Bool("stdin") {
+		executeStream(ctx, rpc, &sliverpb.ExecuteReq{
+			Request: ActiveSession.Request(ctx),
+			Path:    cmdPath,
+			Args:    args,
+			Output:  true,
+		})
+		return
+	}
 	exec, err := rpc.Execute(context.Background(), &sliverpb.ExecuteReq{
 		Request: ActiveSession.Request(ctx),
 		Path:    cmdPath,
@@ -53,3 +66,77 @@ func execute(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Info+"Output:\n%s\n", exec.Result)
 	}
 }
+
+// executeStream - Print the output as it comes over a tunnel, Ctrl-C kills the
+// process. With --stdin our stdin is sent to the process until Ctrl-D (EOF).
+func executeStream(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, execReq *sliverpb.ExecuteReq) {
+	session := ActiveSession.Get()
+	rpcTunnel, err := rpc.CreateTunnel(context.Background(), &sliverpb.Tunnel{
+		SessionID: session.ID,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	tunnel := core.Tunnels.Start(rpcTunnel.TunnelID, rpcTunnel.SessionID)
+	execReq.TunnelID = tunnel.ID
+	exec, err := rpc.Execute(context.Background(), execReq)
+	if err == nil && exec.Response != nil && exec.Response.Err != "" {
+		err = fmt.Errorf("%s", exec.Response.Err)
+	}
+	if err != nil {
+		core.Tunnels.Close(tunnel.ID)
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	sendSignal := func(name string) {
+		resp, err := rpc.ExecuteSignal(context.Background(), &sliverpb.ExecuteSignalReq{
+			TunnelID: tunnel.ID,
+			Signal:   name,
+			Request:  ActiveSession.Request(ctx),
+		})
+		if err == nil && resp.Response != nil && resp.Response.Err != "" {
+			err = fmt.Errorf("%s", resp.Response.Err)
+		}
+		if err != nil {
+			fmt.Printf(Warn+"Failed to send %s: %s\n", name, err)
+		}
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	done := make(chan bool)
+	go func() {
+		io.Copy(os.Stdout, tunnel)
+		close(done)
+	}()
+	go func() {
+		for {
+			select {
+			case <-interrupts:
+				fmt.Printf("\n" + Info + "Killing process ...\n")
+				sendSignal("kill")
+			case <-done:
+				return
+			}
+		}
+	}()
+	if ctx.Flags.Bool("stdin") {
+		fmt.Printf(Info+"Started process %d (Ctrl-D to close stdin, Ctrl-C to kill)\n\n", exec.Pid)
+	} else {
+		fmt.Printf(Info+"Started process %d (Ctrl-C to kill)\n\n", exec.Pid)
+	}
+
+	if !ctx.Flags.Bool("stdin") {
+		sendSignal("eof")
+		<-done
+		return
+	}
+	// Returns on EOF, or on the first write after the tunnel closes
+	_, err = io.Copy(tunnel, os.Stdin)
+	if err == nil {
+		sendSignal("eof")
+		<-done
+	}
+}
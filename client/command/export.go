@@ -0,0 +1,286 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/desertbit/grumble"
+)
+
+const (
+	exportJSONFormat = "json"
+	exportCSVFormat  = "csv"
+)
+
+// exportTable - Rows are written as JSON objects or CSV records with the columns in order
+type exportTable struct {
+	Columns []string
+	Rows    []map[string]interface{}
+}
+
+// Add - Append a row, values are given in column order
+func (t *exportTable) Add(values ...interface{}) {
+	row := map[string]interface{}{}
+	for index, column := range t.Columns {
+		row[column] = values[index]
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+func (t *exportTable) WriteJSON(w io.Writer) error {
+	rows := t.Rows
+	if rows == nil {
+		rows = []map[string]interface{}{} // [] rather than null
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func (t *exportTable) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write(t.Columns)
+	for _, row := range t.Rows {
+		record := []string{}
+		for _, column := range t.Columns {
+			record = append(record, exportCSVValue(row[column]))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func exportCSVValue(value interface{}) string {
+	switch value := value.(type) {
+	case []string:
+		return strings.Join(value, ",")
+	case json.RawMessage:
+		return string(value)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// export - Dump sessions, beacons, beacon tasks, or the console history for reports
+func export(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Missing data to export, see 'help export'\n")
+		return
+	}
+	format := strings.ToLower(ctx.Flags.String("format"))
+	if format != exportJSONFormat && format != exportCSVFormat {
+		fmt.Printf(Warn+"Invalid format %s, must be %s or %s\n", format, exportJSONFormat, exportCSVFormat)
+		return
+	}
+	filter, err := newListingFilter(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+
+	var table *exportTable
+	switch strings.ToLower(ctx.Args[0]) {
+	case "sessions":
+		table, err = exportSessions(filter, rpc)
+	case "beacons":
+		table, err = exportBeacons(filter, rpc)
+	case "tasks":
+		table, err = exportTasks(ctx.Flags.String("beacon"), filter, rpc)
+	case "history":
+		table, err = exportHistory()
+	default:
+		fmt.Println(Warn + "Invalid data to export, see 'help export'")
+		return
+	}
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if format == exportCSVFormat {
+		err = table.WriteCSV(buf)
+	} else {
+		err = table.WriteJSON(buf)
+	}
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	saveTo := ctx.Flags.String("save")
+	if saveTo == "" {
+		fmt.Print(buf.String())
+		return
+	}
+	err = ioutil.WriteFile(saveTo, buf.Bytes(), 0600)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to write data %v\n", err)
+		return
+	}
+	fmt.Printf(Info+"Exported %d %s to %s\n", len(table.Rows), strings.ToLower(ctx.Args[0]), saveTo)
+}
+
+func exportSessions(filter *listingFilter, rpc rpcpb.SliverRPCClient) (*exportTable, error) {
+	sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return sessionsTable(sessions.Sessions, filter), nil
+}
+
+func sessionsTable(sessions []*clientpb.Session, filter *listingFilter) *exportTable {
+	table := &exportTable{Columns: []string{
+		"ID", "Name", "Hostname", "Username", "UID", "GID", "OS", "Arch", "Version", "Transport",
+		"RemoteAddress", "PID", "Filename", "ActiveC2", "LastCheckin", "Tags",
+		"Health", "Requests", "Errors", "AvgRTT", "Compression", "UncompressedBytes", "CompressedBytes",
+		"SendQueueDepth", "SendQueueSize", "SendQueuePolicy", "SendQueueDropped",
+	}}
+	for _, s := range sessions {
+		if !filter.Match(sessionListingTarget(s)) {
+			continue
+		}
+		health := s.Health
+		if health == nil {
+			health = &clientpb.SessionHealth{}
+		}
+		table.Add(s.ID, s.Name, s.Hostname, s.Username, s.UID, s.GID, s.OS, s.Arch, s.Version, s.Transport,
+			s.RemoteAddress, s.PID, s.Filename, s.ActiveC2, s.LastCheckin, s.Tags,
+			sessionHealthStatus(s), health.Requests, health.Errors, health.AvgRTT,
+			s.Compression, s.UncompressedBytes, s.CompressedBytes,
+			s.SendQueueDepth, s.SendQueueSize, s.SendQueuePolicy, s.SendQueueDropped)
+	}
+	return table
+}
+
+func exportBeacons(filter *listingFilter, rpc rpcpb.SliverRPCClient) (*exportTable, error) {
+	beacons, err := rpc.GetBeacons(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return beaconsTable(beacons.Beacons, filter), nil
+}
+
+func beaconsTable(beacons []*clientpb.Beacon, filter *listingFilter) *exportTable {
+	table := &exportTable{Columns: []string{
+		"ID", "Name", "Hostname", "Username", "UID", "GID", "OS", "Arch", "Version", "Transport",
+		"RemoteAddress", "PID", "Filename", "ActiveC2", "Interval", "Jitter", "LastCheckin", "NextCheckin",
+		"TasksCount", "TasksCountCompleted", "Tags",
+	}}
+	for _, b := range beacons {
+		if !filter.Match(beaconListingTarget(b)) {
+			continue
+		}
+		table.Add(b.ID, b.Name, b.Hostname, b.Username, b.UID, b.GID, b.OS, b.Arch, b.Version, b.Transport,
+			b.RemoteAddress, b.PID, b.Filename, b.ActiveC2, b.Interval, b.Jitter, b.LastCheckin, b.NextCheckin,
+			b.TasksCount, b.TasksCountCompleted, b.Tags)
+	}
+	return table
+}
+
+// exportTasks - The tasks of one beacon, or of every beacon passing the filter, completed
+// tasks include their decoded result
+func exportTasks(beaconID string, filter *listingFilter, rpc rpcpb.SliverRPCClient) (*exportTable, error) {
+	beacons, err := rpc.GetBeacons(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	if beaconID == "" {
+		if beacon := ActiveSession.GetBeacon(); beacon != nil {
+			beaconID = beacon.ID
+		}
+	}
+	table := &exportTable{Columns: []string{
+		"Beacon", "BeaconName", "ID", "State", "Description", "CreatedAt", "SentAt", "CompletedAt", "Err", "Result",
+	}}
+	for _, beacon := range beacons.Beacons {
+		if beaconID != "" && beacon.ID != beaconID {
+			continue
+		}
+		if !filter.Match(beaconListingTarget(beacon)) {
+			continue
+		}
+		tasks, err := rpc.GetBeaconTasks(context.Background(), beacon)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks.Tasks {
+			var result json.RawMessage
+			if task.State == "completed" {
+				result = exportTaskResult(task, rpc)
+			}
+			table.Add(beacon.ID, beacon.Name, task.ID, task.State, task.Description, task.CreatedAt, task.SentAt,
+				task.CompletedAt, task.Err, result)
+		}
+	}
+	return table, nil
+}
+
+// exportTaskResult - The task's response as JSON, nil if it can't be decoded
+func exportTaskResult(task *clientpb.BeaconTask, rpc rpcpb.SliverRPCClient) json.RawMessage {
+	content, err := rpc.GetBeaconTaskContent(context.Background(), task)
+	if err != nil {
+		return nil
+	}
+	resp := taskResponseMessage(content.Description)
+	if resp == nil || proto.Unmarshal(content.Response, resp) != nil {
+		return nil
+	}
+	result, err := (&jsonpb.Marshaler{}).MarshalToString(resp)
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(result)
+}
+
+// exportHistory - The operator's console history, oldest first
+func exportHistory() (*exportTable, error) {
+	data, err := ioutil.ReadFile(path.Join(assets.GetRootAppDir(), assets.HistoryFileName))
+	if err != nil {
+		return nil, err
+	}
+	table := &exportTable{Columns: []string{"Index", "Command"}}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			table.Add(len(table.Rows)+1, line)
+		}
+	}
+	return table, nil
+}
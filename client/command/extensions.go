@@ -21,6 +21,7 @@ package command
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -58,16 +59,80 @@ type extFile struct {
 	Files binFiles `json:"files"`
 }
 
+// extensionArgument - An argument of a command provided by an in-memory
+// extension, packed the same way as BOF arguments
+type extensionArgument struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Desc     string `json:"desc"`
+	Optional bool   `json:"optional"`
+}
+
+// extensionArgTypes - Argument types and their BOF format characters
+var extensionArgTypes = map[string]byte{
+	"file":    'b',
+	"int":     'i',
+	"short":   's',
+	"string":  'z',
+	"wstring": 'Z',
+}
+
 type extensionCommand struct {
-	Name           string    `json:"name"`
-	Entrypoint     string    `json:"entrypoint"`
-	Help           string    `json:"help"`
-	LongHelp       string    `json:"longHelp"`
-	AllowArgs      bool      `json:"allowArgs"`
-	DefaultArgs    string    `json:"defaultArgs"`
-	ExtensionFiles []extFile `json:"extFiles"`
-	IsReflective   bool      `json:"isReflective"`
-	IsAssembly     bool      `json:"IsAssembly"`
+	Name           string              `json:"name"`
+	Entrypoint     string              `json:"entrypoint"`
+	Help           string              `json:"help"`
+	LongHelp       string              `json:"longHelp"`
+	AllowArgs      bool                `json:"allowArgs"`
+	DefaultArgs    string              `json:"defaultArgs"`
+	ExtensionFiles []extFile           `json:"extFiles"`
+	IsReflective   bool                `json:"isReflective"`
+	IsAssembly     bool                `json:"IsAssembly"`
+	IsExtension    bool                `json:"isExtension"`
+	Init           string              `json:"init"`
+	Arguments      []extensionArgument `json:"arguments"`
+}
+
+// usage - Describe the arguments of the command
+func (ec *extensionCommand) usage() string {
+	if len(ec.Arguments) == 0 {
+		return ""
+	}
+	usage := "\n\n[[.Bold]]Arguments:[[.Normal]]\n"
+	for _, arg := range ec.Arguments {
+		optional := ""
+		if arg.Optional {
+			optional = ", optional"
+		}
+		usage += fmt.Sprintf("\t%s (%s%s) - %s\n", arg.Name, arg.Type, optional, arg.Desc)
+	}
+	return usage
+}
+
+// packArgs - Pack args according to the argument schema of the command,
+// missing optional arguments are packed as zero values
+func (ec *extensionCommand) packArgs(args []string) ([]byte, error) {
+	if len(ec.Arguments) < len(args) {
+		return nil, fmt.Errorf("Too many arguments, expected at most %d", len(ec.Arguments))
+	}
+	format := ""
+	values := []string{}
+	for index, arg := range ec.Arguments {
+		format += string(extensionArgTypes[arg.Type])
+		if index < len(args) {
+			values = append(values, args[index])
+			continue
+		}
+		if !arg.Optional {
+			return nil, fmt.Errorf("Missing argument %s", arg.Name)
+		}
+		switch arg.Type {
+		case "int", "short":
+			values = append(values, "0")
+		default:
+			values = append(values, "")
+		}
+	}
+	return packBOFArgs(format, values)
 }
 
 func (ec *extensionCommand) getDefaultProcess(targetOS string) (proc string, err error) {
@@ -90,7 +155,7 @@ func (e *extension) getFileForTarget(cmdName string, targetOS string, targetArch
 			for _, ef := range c.ExtensionFiles {
 				if targetOS == ef.OS {
 					switch targetArch {
-					case "x86":
+					case "x86", "386":
 						filePath = fmt.Sprintf("%s/%s", e.Path, ef.Files.Ext32Path)
 					case "x64":
 						filePath = fmt.Sprintf("%s/%s", e.Path, ef.Files.Ext64Path)
@@ -125,27 +190,38 @@ func load(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn + "Please provide an extension path\n")
 		return
 	}
+	err := loadExtension(ctx.App, rpc, ctx.Args[0])
+	if err != nil {
+		fmt.Printf(Warn+"Error loading extension: %v\n", err)
+	}
+	wrapOutputRedirects(ctx.App, rpc)
+}
 
+// loadExtension - Load the extension in extPath and add its commands to the app
+func loadExtension(app *grumble.App, rpc rpcpb.SliverRPCClient, extPath string) error {
 	// retrieve extension manifest
-	manifestPath := fmt.Sprintf("%s/%s", ctx.Args[0], "manifest.json")
+	manifestPath := fmt.Sprintf("%s/%s", extPath, "manifest.json")
 	jsonBytes, err := ioutil.ReadFile(manifestPath)
 	if err != nil {
-		fmt.Printf(Warn+"%v", err)
+		return err
 	}
 	// parse it
 	ext := &extension{}
 	err = json.Unmarshal(jsonBytes, ext)
 	if err != nil {
-		fmt.Printf(Warn+"error loading extension: %v", err)
-		return
+		return err
 	}
-	ext.Path = ctx.Args[0]
+	ext.Path = extPath
 	// for each extension command, add a new app command
 	for _, extCmd := range ext.Commands {
 		// do not add if the command already exists
-		if cmdExists(extCmd.Name, ctx.App) {
-			fmt.Printf(Warn+"%s command already exists\n", extCmd.Name)
-			return
+		if cmdExists(extCmd.Name, app) {
+			return fmt.Errorf("%s command already exists", extCmd.Name)
+		}
+		for _, arg := range extCmd.Arguments {
+			if _, ok := extensionArgTypes[arg.Type]; !ok {
+				return fmt.Errorf("%s argument %s has an unknown type %s", extCmd.Name, arg.Name, arg.Type)
+			}
 		}
 		fmt.Printf(Info+"Adding %s command: %s\n", extCmd.Name, extCmd.Help)
 
@@ -153,11 +229,11 @@ func load(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		// either by value or by ref fucks things up
 		commandMap[extCmd.Name] = *ext
 		helpMsg := fmt.Sprintf("[%s] %s", ext.Name, extCmd.Help)
-		ctx.App.AddCommand(&grumble.Command{
+		app.AddCommand(&grumble.Command{
 			Name:      extCmd.Name,
 			Help:      helpMsg,
-			LongHelp:  help.FormatHelpTmpl(extCmd.LongHelp),
-			AllowArgs: extCmd.AllowArgs,
+			LongHelp:  help.FormatHelpTmpl(extCmd.LongHelp + extCmd.usage()),
+			AllowArgs: extCmd.AllowArgs || 0 < len(extCmd.Arguments),
 			Run: func(extCtx *grumble.Context) error {
 				fmt.Println()
 				runExtensionCommand(extCtx, rpc)
@@ -177,6 +253,7 @@ func load(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		})
 	}
 	fmt.Printf(Info+"%s extension has been loaded\n", ext.Name)
+	return nil
 }
 
 func runExtensionCommand(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
@@ -232,7 +309,18 @@ func runExtensionCommand(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		outFile := path.Base(fmt.Sprintf("%s_%s*.log", ctx.Command.Name, session.GetHostname()))
 		outFilePath, err = ioutil.TempFile("", outFile)
 	}
-	if c.IsAssembly {
+	if c.IsExtension {
+		output, err := callMemoryExtension(ctx, rpc, ext, c, binPath, binData)
+		if err != nil {
+			fmt.Printf(Warn+"Error: %v\n", err)
+			return
+		}
+		fmt.Printf(Info+"Output:\n%s", string(output))
+		if outFilePath != nil {
+			outFilePath.Write(output)
+			fmt.Printf(Info+"Output saved to %s\n", outFilePath.Name())
+		}
+	} else if c.IsAssembly {
 		ctrl := make(chan bool)
 		msg := fmt.Sprintf("Executing %s %s ...", ctx.Command.Name, args)
 		go spin.Until(msg, ctrl)
@@ -311,6 +399,83 @@ func runExtensionCommand(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 }
 
+// callMemoryExtension - Load the extension into the implant unless it is
+// already loaded, then call the export of the command
+func callMemoryExtension(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, ext extension, c *extensionCommand, binPath string, binData []byte) ([]byte, error) {
+	session := ActiveSession.Get()
+	if session.GetOS() != "windows" {
+		return nil, fmt.Errorf("In-memory extensions are not supported on %s", session.GetOS())
+	}
+	var args []byte
+	var err error
+	if 0 < len(c.Arguments) {
+		args, err = c.packArgs(ctx.Args)
+		if err != nil {
+			return nil, err
+		}
+	} else if 0 < len(ctx.Args) {
+		args = []byte(strings.Join(ctx.Args, " "))
+	} else {
+		args = []byte(c.DefaultArgs)
+	}
+
+	name := fmt.Sprintf("%s/%s", ext.Name, path.Base(binPath))
+	extensions, err := rpc.ListExtensions(context.Background(), &sliverpb.ListExtensionsReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if extensions.GetResponse().GetErr() != "" {
+		return nil, errors.New(extensions.GetResponse().GetErr())
+	}
+	loaded := false
+	for _, loadedName := range extensions.GetNames() {
+		if loadedName == name {
+			loaded = true
+		}
+	}
+	if !loaded {
+		ctrl := make(chan bool)
+		go spin.Until(fmt.Sprintf("Loading %s ...", name), ctrl)
+		register, err := rpc.RegisterExtension(context.Background(), &sliverpb.RegisterExtensionReq{
+			Request: ActiveSession.Request(ctx),
+			Name:    name,
+			Data:    binData,
+			Init:    c.Init,
+		})
+		ctrl <- true
+		<-ctrl
+		if err != nil {
+			return nil, err
+		}
+		if register.GetResponse().GetErr() != "" {
+			return nil, errors.New(register.GetResponse().GetErr())
+		}
+	}
+
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Executing %s ...", ctx.Command.Name), ctrl)
+	call, err := rpc.CallExtension(context.Background(), &sliverpb.CallExtensionReq{
+		Request: ActiveSession.Request(ctx),
+		Name:    name,
+		Export:  c.Entrypoint,
+		Args:    args,
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		return nil, err
+	}
+	if call.GetResponse().GetErr() != "" {
+		if 0 < len(call.GetOutput()) {
+			fmt.Printf(Info+"Output:\n%s\n", string(call.GetOutput()))
+		}
+		return nil, errors.New(call.GetResponse().GetErr())
+	}
+	return call.GetOutput(), nil
+}
+
 func cmdExists(name string, app *grumble.App) bool {
 	for _, c := range app.Commands().All() {
 		if name == c.Name {
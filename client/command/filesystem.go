@@ -25,8 +25,10 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/alecthomas/chroma/formatters"
 	"github.com/alecthomas/chroma/lexers"
@@ -50,6 +52,11 @@ func ls(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	if len(ctx.Args) < 1 {
 		ctx.Args = append(ctx.Args, ".")
 	}
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
 
 	ls, err := rpc.Ls(context.Background(), &sliverpb.LsReq{
 		Request: ActiveSession.Request(ctx),
@@ -58,6 +65,18 @@ func ls(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 	} else {
+		if ls.Exists {
+			DirCache.Put(session.ID, "", ctx.Args[0], ls)
+			DirCache.Put(session.ID, "", ls.Path, ls)
+		}
+		if format != tableFormat {
+			table := &exportTable{Columns: []string{"Path", "Name", "IsDir", "Size"}}
+			for _, fileInfo := range ls.Files {
+				table.Add(ls.Path, fileInfo.Name, fileInfo.IsDir, fileInfo.Size)
+			}
+			printFormatted(format, table)
+			return
+		}
 		printDirList(ls)
 	}
 }
@@ -77,6 +96,191 @@ func printDirList(dirList *sliverpb.Ls) {
 	table.Flush()
 }
 
+func search(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	if len(ctx.Args) < 1 {
+		ctx.Args = append(ctx.Args, ".")
+	}
+	nameGlob := ctx.Flags.String("name")
+	contentRegex := ctx.Flags.String("content")
+	if nameGlob == "" && contentRegex == "" {
+		fmt.Printf(Warn + "Please specify a --name glob and/or a --content regex\n")
+		return
+	}
+
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Searching %s ...", ctx.Args[0]), ctrl)
+	results, err := rpc.Search(context.Background(), &sliverpb.SearchReq{
+		Request:      ActiveSession.Request(ctx),
+		Path:         ctx.Args[0],
+		NameGlob:     nameGlob,
+		ContentRegex: contentRegex,
+		IgnoreCase:   ctx.Flags.Bool("ignore-case"),
+		MaxDepth:     int32(ctx.Flags.Int("depth")),
+		MaxFileSize:  int64(ctx.Flags.Int("max-size")) * 1024 * 1024,
+		MaxResults:   int32(ctx.Flags.Int("max-results")),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if results.Response != nil && results.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", results.Response.Err)
+		return
+	}
+	printSearchResults(results)
+}
+
+func printSearchResults(results *sliverpb.Search) {
+	fmt.Printf("%s\n", results.Path)
+	fmt.Printf("%s\n", strings.Repeat("=", len(results.Path)))
+
+	for _, result := range results.Results {
+		modTime := time.Unix(result.ModTime, 0).Format(time.RFC1123)
+		if result.IsDir {
+			fmt.Printf("%s  <dir>  %s\n", result.Path, modTime)
+		} else {
+			fmt.Printf("%s  %s  %s\n", result.Path, util.ByteCountBinary(result.Size), modTime)
+		}
+		for _, match := range result.Matches {
+			fmt.Printf("    %d: %s\n", match.Line, match.Text)
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf(Info+"%d result(s)\n", len(results.Results))
+	if results.Truncated {
+		fmt.Printf(Warn + "Search stopped at the max number of results\n")
+	}
+	if 0 < results.Skipped {
+		fmt.Printf(Warn+"%d file(s) or directories could not be read\n", results.Skipped)
+	}
+}
+
+func hash(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Missing parameter: file or directory path\n")
+		return
+	}
+	algorithms := []string{}
+	for _, algorithm := range strings.Split(ctx.Flags.String("algorithms"), ",") {
+		if algorithm = strings.TrimSpace(algorithm); algorithm != "" {
+			algorithms = append(algorithms, strings.ToLower(algorithm))
+		}
+	}
+	match := []string{}
+	for _, value := range strings.Split(ctx.Flags.String("match"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			match = append(match, value)
+		}
+	}
+	if matchFile := ctx.Flags.String("match-file"); matchFile != "" {
+		data, err := ioutil.ReadFile(matchFile)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			// Accept "<hash>  <name>" lines as written by sha256sum and friends
+			if fields := strings.Fields(line); 0 < len(fields) && !strings.HasPrefix(fields[0], "#") {
+				match = append(match, fields[0])
+			}
+		}
+	}
+
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Hashing %s ...", ctx.Args[0]), ctrl)
+	result, err := rpc.Hash(context.Background(), &sliverpb.HashReq{
+		Request:     ActiveSession.Request(ctx),
+		Path:        ctx.Args[0],
+		Algorithms:  algorithms,
+		Recursive:   ctx.Flags.Bool("recursive"),
+		NameGlob:    ctx.Flags.String("name"),
+		MaxDepth:    int32(ctx.Flags.Int("depth")),
+		MaxFileSize: int64(ctx.Flags.Int("max-size")) * 1024 * 1024,
+		MaxFiles:    int32(ctx.Flags.Int("max-files")),
+		Match:       match,
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	printHashes(result)
+	fmt.Println()
+	if 0 < len(match) {
+		fmt.Printf(Info+"%d of %d hashed file(s) matched\n", len(result.Files), result.Hashed)
+	} else {
+		fmt.Printf(Info+"%d file(s) hashed\n", result.Hashed)
+	}
+	if result.Truncated {
+		fmt.Printf(Warn + "Stopped at the max number of files\n")
+	}
+	if 0 < result.Skipped {
+		fmt.Printf(Warn+"%d file(s) were skipped, unreadable or over the max size\n", result.Skipped)
+	}
+}
+
+func printHashes(result *sliverpb.Hash) {
+	if len(result.Files) == 0 {
+		return
+	}
+	// Only show the algorithms that were requested
+	first := result.Files[0]
+	columns := []string{}
+	if first.MD5 != "" {
+		columns = append(columns, "MD5")
+	}
+	if first.SHA1 != "" {
+		columns = append(columns, "SHA1")
+	}
+	if first.SHA256 != "" {
+		columns = append(columns, "SHA256")
+	}
+	columns = append(columns, "Size", "Path")
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	underlines := []string{}
+	for _, column := range columns {
+		underlines = append(underlines, strings.Repeat("=", len(column)))
+	}
+	fmt.Fprintf(table, "%s\t\n", strings.Join(columns, "\t"))
+	fmt.Fprintf(table, "%s\t\n", strings.Join(underlines, "\t"))
+	for _, file := range result.Files {
+		row := []string{}
+		for _, column := range columns {
+			switch column {
+			case "MD5":
+				row = append(row, file.MD5)
+			case "SHA1":
+				row = append(row, file.SHA1)
+			case "SHA256":
+				row = append(row, file.SHA256)
+			case "Size":
+				row = append(row, util.ByteCountBinary(file.Size))
+			case "Path":
+				row = append(row, file.Path)
+			}
+		}
+		fmt.Fprintf(table, "%s\t\n", strings.Join(row, "\t"))
+	}
+	table.Flush()
+}
+
 func rm(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.GetInteractive()
 	if session == nil {
@@ -123,6 +327,146 @@ func mkdir(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 }
 
+func chmod(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	if len(ctx.Args) != 2 {
+		fmt.Printf(Warn + "Please specify a mode and a remote path\n")
+		return
+	}
+	mode, err := strconv.ParseUint(ctx.Args[0], 8, 32)
+	if err != nil {
+		fmt.Printf(Warn+"Invalid mode '%s', the mode must be in octal (e.g. 0644)\n", ctx.Args[0])
+		return
+	}
+
+	chmod, err := rpc.Chmod(context.Background(), &sliverpb.ChmodReq{
+		Request:   ActiveSession.Request(ctx),
+		Path:      ctx.Args[1],
+		FileMode:  uint32(mode),
+		Recursive: ctx.Flags.Bool("recursive"),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if chmod.Response != nil && chmod.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", chmod.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"%s -> %04o\n", chmod.Path, mode)
+}
+
+func chown(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	if len(ctx.Args) != 2 {
+		fmt.Printf(Warn + "Please specify an owner (user[:group]) and a remote path\n")
+		return
+	}
+	owner := strings.SplitN(ctx.Args[0], ":", 2)
+	uid, gid := owner[0], ""
+	if len(owner) == 2 {
+		gid = owner[1]
+	}
+	if uid == "" && gid == "" {
+		fmt.Printf(Warn + "Please specify a user and/or group\n")
+		return
+	}
+
+	chown, err := rpc.Chown(context.Background(), &sliverpb.ChownReq{
+		Request:   ActiveSession.Request(ctx),
+		Path:      ctx.Args[1],
+		Uid:       uid,
+		Gid:       gid,
+		Recursive: ctx.Flags.Bool("recursive"),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if chown.Response != nil && chown.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", chown.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"%s -> %s\n", chown.Path, ctx.Args[0])
+}
+
+func timestomp(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	if len(ctx.Args) != 1 {
+		fmt.Printf(Warn + "Please specify a remote path\n")
+		return
+	}
+	req := &sliverpb.TimestompReq{
+		Request:   ActiveSession.Request(ctx),
+		Path:      ctx.Args[0],
+		Reference: ctx.Flags.String("reference"),
+	}
+	flags := []struct {
+		name  string
+		value *int64
+	}{
+		{"access", &req.AccessTime},
+		{"modify", &req.ModifyTime},
+		{"create", &req.CreateTime},
+	}
+	for _, flag := range flags {
+		timestamp, err := parseTimestamp(ctx.Flags.String(flag.name))
+		if err != nil {
+			fmt.Printf(Warn+"Invalid --%s time: %s\n", flag.name, err)
+			return
+		}
+		if !timestamp.IsZero() {
+			*flag.value = timestamp.UnixNano()
+		}
+	}
+	if req.Reference == "" && req.AccessTime == 0 && req.ModifyTime == 0 && req.CreateTime == 0 {
+		fmt.Printf(Warn + "Please specify a reference file and/or the times to set\n")
+		return
+	}
+
+	stomp, err := rpc.Timestomp(context.Background(), req)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if stomp.Response != nil && stomp.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", stomp.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"%s\n", stomp.Path)
+	fmt.Printf("    Accessed: %s\n", time.Unix(0, stomp.AccessTime).UTC().Format(time.RFC3339))
+	fmt.Printf("    Modified: %s\n", time.Unix(0, stomp.ModifyTime).UTC().Format(time.RFC3339))
+	if stomp.CreateTime != 0 {
+		fmt.Printf("     Created: %s\n", time.Unix(0, stomp.CreateTime).UTC().Format(time.RFC3339))
+	}
+}
+
+// parseTimestamp - Accept RFC3339, or a date with an optional time (UTC)
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		timestamp, err := time.Parse(layout, value)
+		if err == nil {
+			return timestamp, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not RFC3339, YYYY-MM-DD or 'YYYY-MM-DD HH:MM:SS'", value)
+}
+
 func cd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.GetInteractive()
 	if session == nil {
@@ -260,14 +604,24 @@ func download(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		}
 	}
 
+	bandwidth, err := parseBandwidth(ctx.Flags.String("bandwidth"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	req := ActiveSession.Request(ctx)
+	req.BandwidthLimit = bandwidth
+
+	progress := watchTransfers(session.ID, "recv")
 	ctrl := make(chan bool)
-	go spin.Until(fmt.Sprintf("%s -> %s", fileName, dst), ctrl)
+	go spin.UntilWithStatus(fmt.Sprintf("%s -> %s", fileName, dst), ctrl, progress.Status)
 	download, err := rpc.Download(context.Background(), &sliverpb.DownloadReq{
-		Request: ActiveSession.Request(ctx),
+		Request: req,
 		Path:    ctx.Args[0],
 	})
 	ctrl <- true
 	<-ctrl
+	progress.Stop()
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 		return
@@ -318,22 +672,114 @@ func upload(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 	dst := ctx.Args[1]
 
+	bandwidth, err := parseBandwidth(ctx.Flags.String("bandwidth"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	req := ActiveSession.Request(ctx)
+	req.BandwidthLimit = bandwidth
+
 	fileBuf, err := ioutil.ReadFile(src)
 	uploadGzip := new(encoders.Gzip).Encode(fileBuf)
 
+	progress := watchTransfers(session.ID, "send")
 	ctrl := make(chan bool)
-	go spin.Until(fmt.Sprintf("%s -> %s", src, dst), ctrl)
+	go spin.UntilWithStatus(fmt.Sprintf("%s -> %s", src, dst), ctrl, progress.Status)
 	upload, err := rpc.Upload(context.Background(), &sliverpb.UploadReq{
-		Request: ActiveSession.Request(ctx),
+		Request: req,
 		Path:    dst,
 		Data:    uploadGzip,
 		Encoder: "gzip",
 	})
 	ctrl <- true
 	<-ctrl
+	progress.Stop()
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 	} else {
 		fmt.Printf(clearln+Info+"Wrote file to %s\n", upload.Path)
 	}
 }
+
+// parseBandwidth - Parse a throughput in bytes per second, with an optional k/m suffix (e.g. 16k)
+func parseBandwidth(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(value, "k"):
+		unit = 1024
+	case strings.HasSuffix(value, "m"):
+		unit = 1024 * 1024
+	}
+	if unit != 1 {
+		value = value[:len(value)-1]
+	}
+	rate, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || rate < 1 {
+		return 0, fmt.Errorf("Invalid bandwidth %#v, expected bytes per second (e.g. 512, 16k, 1m)", value)
+	}
+	return rate * unit, nil
+}
+
+func drives(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	result, err := rpc.Drives(context.Background(), &sliverpb.DrivesReq{
+		Request: ActiveSession.Request(ctx),
+		All:     ctx.Flags.Bool("all"),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if result.Response != nil && result.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", result.Response.Err)
+		return
+	}
+	if len(result.Drives) == 0 {
+		fmt.Printf(Info + "No drives found\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Path\tType\tFilesystem\tSize\tUsed\tFree\tUse%%\tDevice / Target\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Path")),
+		strings.Repeat("=", len("Type")),
+		strings.Repeat("=", len("Filesystem")),
+		strings.Repeat("=", len("Size")),
+		strings.Repeat("=", len("Used")),
+		strings.Repeat("=", len("Free")),
+		strings.Repeat("=", len("Use%")),
+		strings.Repeat("=", len("Device / Target")),
+	)
+	for _, drive := range result.Drives {
+		fsType := drive.FsType
+		if drive.ReadOnly {
+			fsType += " (ro)"
+		}
+		if drive.Label != "" {
+			fsType += fmt.Sprintf(" [%s]", drive.Label)
+		}
+		target := drive.Device
+		if drive.RemotePath != "" {
+			target = drive.RemotePath
+		}
+		size, used, free, usage := "", "", "", ""
+		if 0 < drive.Total {
+			usedBytes := drive.Total - drive.Free
+			size = util.ByteCountBinary(int64(drive.Total))
+			used = util.ByteCountBinary(int64(usedBytes))
+			free = util.ByteCountBinary(int64(drive.Free))
+			usage = fmt.Sprintf("%d%%", usedBytes*100/drive.Total)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			drive.Path, drive.Type, fsType, size, used, free, usage, target)
+	}
+	table.Flush()
+}
@@ -0,0 +1,197 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+// listingFilter - Narrows down session and beacon listings, empty fields match anything.
+// Text fields match case insensitive substrings, tags must match exactly.
+type listingFilter struct {
+	Hostname  string
+	Username  string
+	OS        string
+	Transport string
+	Tag       string
+	Search    string
+	MaxAge    time.Duration
+}
+
+// listingTarget - The fields of a session or beacon that can be filtered on
+type listingTarget struct {
+	ID            string
+	Name          string
+	Hostname      string
+	Username      string
+	OS            string
+	Arch          string
+	Transport     string
+	RemoteAddress string
+	LastCheckin   string
+	Tags          []string
+}
+
+// bindListingFilterFlags - Flags shared by `sessions` and `beacons`
+func bindListingFilterFlags(f *grumble.Flags) {
+	f.String("H", "host", "", "only list hostnames containing this")
+	f.String("u", "user", "", "only list usernames containing this")
+	f.String("o", "os", "", "only list this operating system")
+	f.String("T", "transport", "", "only list this transport")
+	f.String("g", "tag", "", "only list this tag")
+	f.String("a", "max-age", "", "only list check-ins within this duration (e.g. 10m)")
+	f.String("s", "search", "", "only list entries with any field containing this")
+}
+
+func newListingFilter(ctx *grumble.Context) (*listingFilter, error) {
+	filter := &listingFilter{
+		Hostname:  strings.ToLower(ctx.Flags.String("host")),
+		Username:  strings.ToLower(ctx.Flags.String("user")),
+		OS:        strings.ToLower(ctx.Flags.String("os")),
+		Transport: strings.ToLower(ctx.Flags.String("transport")),
+		Tag:       strings.ToLower(ctx.Flags.String("tag")),
+		Search:    strings.ToLower(ctx.Flags.String("search")),
+	}
+	if maxAge := ctx.Flags.String("max-age"); maxAge != "" {
+		duration, err := time.ParseDuration(maxAge)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid max age %s", err)
+		}
+		filter.MaxAge = duration
+	}
+	return filter, nil
+}
+
+// IsEmpty - No filter flags were set
+func (f *listingFilter) IsEmpty() bool {
+	return *f == listingFilter{}
+}
+
+// Match - Does the target pass every filter
+func (f *listingFilter) Match(target *listingTarget) bool {
+	if !containsFold(target.Hostname, f.Hostname) || !containsFold(target.Username, f.Username) {
+		return false
+	}
+	if !containsFold(target.OS, f.OS) || !containsFold(target.Transport, f.Transport) {
+		return false
+	}
+	if f.Tag != "" && !hasTag(target.Tags, f.Tag) {
+		return false
+	}
+	if f.MaxAge != 0 {
+		lastCheckin, err := time.Parse(time.RFC1123, target.LastCheckin)
+		if err != nil || f.MaxAge < time.Since(lastCheckin) {
+			return false
+		}
+	}
+	if f.Search != "" {
+		fields := []string{target.ID, target.Name, target.Hostname, target.Username, target.OS,
+			target.Arch, target.Transport, target.RemoteAddress}
+		fields = append(fields, target.Tags...)
+		for _, field := range fields {
+			if containsFold(field, f.Search) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func sessionListingTarget(session *clientpb.Session) *listingTarget {
+	return &listingTarget{
+		ID:            fmt.Sprintf("%d", session.ID),
+		Name:          session.Name,
+		Hostname:      session.Hostname,
+		Username:      session.Username,
+		OS:            session.OS,
+		Arch:          session.Arch,
+		Transport:     session.Transport,
+		RemoteAddress: session.RemoteAddress,
+		LastCheckin:   session.LastCheckin,
+		Tags:          session.Tags,
+	}
+}
+
+func beaconListingTarget(beacon *clientpb.Beacon) *listingTarget {
+	return &listingTarget{
+		ID:            beacon.ID,
+		Name:          beacon.Name,
+		Hostname:      beacon.Hostname,
+		Username:      beacon.Username,
+		OS:            beacon.OS,
+		Arch:          beacon.Arch,
+		Transport:     beacon.Transport,
+		RemoteAddress: beacon.RemoteAddress,
+		LastCheckin:   beacon.LastCheckin,
+		Tags:          beacon.Tags,
+	}
+}
+
+// containsFold - Case insensitive strings.Contains, substr must already be lower case
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), substr)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tag - Add or remove tags of the active session or beacon
+func tag(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Missing tag(s), see 'help tag'\n")
+		return
+	}
+	req := &clientpb.TagReq{
+		SessionID: session.ID,
+		Tags:      ctx.Args,
+		Remove:    ctx.Flags.Bool("remove"),
+	}
+	if beacon := ActiveSession.GetBeacon(); beacon != nil {
+		req.BeaconID = beacon.ID
+	}
+	_, err := rpc.TagSession(context.Background(), req)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if req.Remove {
+		fmt.Printf(Info+"Removed tag(s) %s from %s\n", strings.Join(ctx.Args, ", "), session.Name)
+	} else {
+		fmt.Printf(Info+"Tagged %s with %s\n", session.Name, strings.Join(ctx.Args, ", "))
+	}
+}
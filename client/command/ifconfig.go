@@ -18,6 +18,12 @@ func ifconfig(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+
 	ifconfig, err := rpc.Ifconfig(context.Background(), &sliverpb.IfconfigReq{
 		Request: ActiveSession.Request(ctx),
 	})
@@ -25,6 +31,14 @@ func ifconfig(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"%s\n", err)
 		return
 	}
+	if format != tableFormat {
+		table := &exportTable{Columns: []string{"Index", "Name", "MAC", "IPAddresses"}}
+		for ifaceIndex, iface := range ifconfig.NetInterfaces {
+			table.Add(ifaceIndex, iface.Name, iface.MAC, iface.IPAddresses)
+		}
+		printFormatted(format, table)
+		return
+	}
 
 	for ifaceIndex, iface := range ifconfig.NetInterfaces {
 		fmt.Printf("%s%s%s (%d)\n", bold, iface.Name, normal, ifaceIndex)
@@ -21,7 +21,10 @@ package command
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 	"text/tabwriter"
 
@@ -50,37 +53,112 @@ func displayAllImplantBuilds(configs map[string]*clientpb.ImplantConfig) {
 	outputBuf := bytes.NewBufferString("")
 	table := tabwriter.NewWriter(outputBuf, 0, 2, 2, ' ', 0)
 
-	fmt.Fprintf(table, "Name\tOS/Arch\tDebug\tFormat\tCommand & Control\t\n")
-	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+	fmt.Fprintf(table, "Name\tOS/Arch\tDebug\tOperational\tFormat\tCommand & Control\tObfuscation Seed\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
 		strings.Repeat("=", len("Name")),
 		strings.Repeat("=", len("OS/Arch")),
 		strings.Repeat("=", len("Debug")),
+		strings.Repeat("=", len("Operational")),
 		strings.Repeat("=", len("Format")),
 		strings.Repeat("=", len("Command & Control")),
+		strings.Repeat("=", len("Obfuscation Seed")),
 	)
 
 	for sliverName, config := range configs {
 		if 0 < len(config.C2) {
-			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
 				sliverName,
 				fmt.Sprintf("%s/%s", config.GOOS, config.GOARCH),
 				fmt.Sprintf("%v", config.Debug),
+				fmt.Sprintf("%v", config.Operational),
 				config.Format,
 				fmt.Sprintf("[1] %s", config.C2[0].URL),
+				config.ObfuscationSeed,
 			)
 		}
 		if 1 < len(config.C2) {
 			for index, c2 := range config.C2[1:] {
-				fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+				fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+					"",
 					"",
 					"",
 					"",
 					"",
 					fmt.Sprintf("[%d] %s", index+2, c2.URL),
+					"",
 				)
 			}
 		}
-		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n", "", "", "", "", "")
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", "", "", "", "", "", "", "")
+	}
+	table.Flush()
+	fmt.Printf(outputBuf.String())
+}
+
+func implantOperational(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Must specify an implant name\n")
+		return
+	}
+	config, err := rpc.ImplantOperational(context.Background(), &clientpb.OperationalReq{
+		ImplantName: ctx.Args[0],
+		Operational: !ctx.Flags.Bool("off"),
+		AllowDebug:  ctx.Flags.Bool("allow-debug"),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if config.Operational {
+		fmt.Printf(Info+"Implant %s is operational\n", config.Name)
+	} else {
+		fmt.Printf(Info+"Implant %s is not operational\n", config.Name)
+	}
+}
+
+func findBuild(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Must specify a sha256 or a file\n")
+		return
+	}
+	digest := ctx.Args[0]
+	if _, err := os.Stat(digest); err == nil {
+		data, err := ioutil.ReadFile(digest)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		digest = fmt.Sprintf("%x", sha256.Sum256(data))
+	}
+	config, err := rpc.ImplantByHash(context.Background(), &clientpb.ImplantHashReq{
+		SHA256: digest,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"No build with sha256 %s\n", digest)
+		return
+	}
+
+	outputBuf := bytes.NewBufferString("")
+	table := tabwriter.NewWriter(outputBuf, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name:\t%s\n", config.Name)
+	fmt.Fprintf(table, "Operator:\t%s\n", config.Operator)
+	if config.PatchedFrom != "" {
+		fmt.Fprintf(table, "Patched From:\t%s\n", config.PatchedFrom)
+	}
+	fmt.Fprintf(table, "OS/Arch:\t%s/%s\n", config.GOOS, config.GOARCH)
+	fmt.Fprintf(table, "Format:\t%s\n", config.Format)
+	fmt.Fprintf(table, "Debug:\t%v\n", config.Debug)
+	fmt.Fprintf(table, "Operational:\t%v\n", config.Operational)
+	fmt.Fprintf(table, "Toolchain:\t%s\n", config.GoVersion)
+	fmt.Fprintf(table, "Latest SHA256:\t%s\n", config.SHA256)
+	if config.Encoder != clientpb.ImplantConfig_NONE {
+		fmt.Fprintf(table, "Encoder:\t%s (seed %d)\n", config.Encoder, config.EncoderSeed)
+	}
+	for index, c2 := range config.C2 {
+		fmt.Fprintf(table, "C2 [%d]:\t%s\n", index+1, c2.URL)
+	}
+	for _, canary := range config.Canaries {
+		fmt.Fprintf(table, "Canary:\t%s\n", canary)
 	}
 	table.Flush()
 	fmt.Printf(outputBuf.String())
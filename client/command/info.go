@@ -21,6 +21,10 @@ package command
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	insecureRand "math/rand"
 
@@ -28,15 +32,26 @@ import (
 	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/util"
 
 	"github.com/desertbit/grumble"
 )
 
 func info(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 
+	if beacon := ActiveSession.GetBeacon(); beacon != nil {
+		// Refresh from the server for the latest check-in
+		if refreshed := GetBeacon(beacon.ID, rpc); refreshed != nil {
+			beacon = refreshed
+		}
+		printBeaconInfo(beacon)
+		return
+	}
+
 	var session *clientpb.Session
 	if ActiveSession.GetInteractive() != nil {
-		session = ActiveSession.GetInteractive()
+		// Refresh from the server, the effective user may have changed
+		session = GetSession(fmt.Sprintf("%d", ActiveSession.GetInteractive().ID), rpc)
 	} else if 0 < len(ctx.Args) {
 		session = GetSession(ctx.Args[0], rpc)
 	}
@@ -46,18 +61,121 @@ func info(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(bold+"          Name: %s%s\n", normal, session.Name)
 		fmt.Printf(bold+"      Hostname: %s%s\n", normal, session.Hostname)
 		fmt.Printf(bold+"      Username: %s%s\n", normal, session.Username)
+		if session.EffectiveUser != "" {
+			fmt.Printf(bold+"Effective User: %s%s\n", normal, session.EffectiveUser)
+		}
 		fmt.Printf(bold+"           UID: %s%s\n", normal, session.UID)
 		fmt.Printf(bold+"           GID: %s%s\n", normal, session.GID)
+		if 0 < len(session.Groups) {
+			fmt.Printf(bold+"        Groups: %s%s\n", normal, strings.Join(session.Groups, ", "))
+		}
+		if 0 < len(session.Privileges) {
+			fmt.Printf(bold+"    Privileges: %s%s\n", normal, strings.Join(session.Privileges, ", "))
+		}
 		fmt.Printf(bold+"           PID: %s%d\n", normal, session.PID)
 		fmt.Printf(bold+"            OS: %s%s\n", normal, session.OS)
 		fmt.Printf(bold+"       Version: %s%s\n", normal, session.Version)
 		fmt.Printf(bold+"          Arch: %s%s\n", normal, session.Arch)
+		fmt.Printf(bold+"      Protocol: %s%s\n", normal, protocolVersionStr(session.ProtocolVersion))
 		fmt.Printf(bold+"Remote Address: %s%s\n", normal, session.RemoteAddress)
+		fmt.Printf(bold+"        Health: %s%s\n", normal, healthDetails(session))
+		if session.Compression != "" {
+			fmt.Printf(bold+"   Compression: %s%s\n", normal, compressionDetails(session))
+		}
+		if session.SendQueueSize != 0 {
+			fmt.Printf(bold+"    Send Queue: %s%s\n", normal, sendQueueDetails(session))
+		}
+		if session.HostInfo != nil {
+			fmt.Println()
+			printHostInfo(session.HostInfo)
+		}
 	} else {
 		fmt.Printf(Warn+"No target session, see `help %s`\n", consts.InfoStr)
 	}
 }
 
+func printBeaconInfo(beacon *clientpb.Beacon) {
+	fmt.Printf(bold+"     Beacon ID: %s%s\n", normal, beacon.ID)
+	fmt.Printf(bold+"          Name: %s%s\n", normal, beacon.Name)
+	fmt.Printf(bold+"      Hostname: %s%s\n", normal, beacon.Hostname)
+	fmt.Printf(bold+"      Username: %s%s\n", normal, beacon.Username)
+	fmt.Printf(bold+"           UID: %s%s\n", normal, beacon.UID)
+	fmt.Printf(bold+"           GID: %s%s\n", normal, beacon.GID)
+	if 0 < len(beacon.Groups) {
+		fmt.Printf(bold+"        Groups: %s%s\n", normal, strings.Join(beacon.Groups, ", "))
+	}
+	if 0 < len(beacon.Privileges) {
+		fmt.Printf(bold+"    Privileges: %s%s\n", normal, strings.Join(beacon.Privileges, ", "))
+	}
+	fmt.Printf(bold+"           PID: %s%d\n", normal, beacon.PID)
+	fmt.Printf(bold+"            OS: %s%s\n", normal, beacon.OS)
+	fmt.Printf(bold+"       Version: %s%s\n", normal, beacon.Version)
+	fmt.Printf(bold+"          Arch: %s%s\n", normal, beacon.Arch)
+	fmt.Printf(bold+"      Protocol: %s%s\n", normal, protocolVersionStr(beacon.ProtocolVersion))
+	fmt.Printf(bold+"Remote Address: %s%s\n", normal, beacon.RemoteAddress)
+	fmt.Printf(bold+"      Interval: %s%ds ±%ds\n", normal, beacon.Interval, beacon.Jitter)
+	fmt.Printf(bold+"  Last Checkin: %s%s\n", normal, beacon.LastCheckin)
+	fmt.Printf(bold+"  Next Checkin: %s%s\n", normal, beacon.NextCheckin)
+	if beacon.HostInfo != nil {
+		fmt.Println()
+		printHostInfo(beacon.HostInfo)
+	}
+}
+
+func hostInfo(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	hostInfo, err := rpc.HostInfo(context.Background(), &sliverpb.HostInfoReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if hostInfo.Response != nil && hostInfo.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", hostInfo.Response.Err)
+		return
+	}
+	printHostInfo(hostInfo)
+}
+
+func printHostInfo(hostInfo *sliverpb.HostInfo) {
+	osName := hostInfo.OS
+	if hostInfo.Version != "" {
+		osName += " " + hostInfo.Version
+	}
+	if hostInfo.Build != "" {
+		osName += fmt.Sprintf(" (build %s)", hostInfo.Build)
+	}
+	fmt.Printf(bold+"       Host OS: %s%s\n", normal, osName)
+	if hostInfo.Kernel != "" {
+		fmt.Printf(bold+"        Kernel: %s%s\n", normal, hostInfo.Kernel)
+	}
+	fmt.Printf(bold+"          Arch: %s%s\n", normal, hostInfo.Arch)
+	if 0 < hostInfo.BootTime {
+		bootTime := time.Unix(hostInfo.BootTime, 0)
+		uptime := time.Since(bootTime).Truncate(time.Minute)
+		fmt.Printf(bold+"        Uptime: %s%s (booted %s)\n", normal, uptime, bootTime.Format(time.RFC1123))
+	}
+	if hostInfo.Locale != "" {
+		fmt.Printf(bold+"        Locale: %s%s\n", normal, hostInfo.Locale)
+	}
+	fmt.Printf(bold+"      Timezone: %s%s\n", normal, hostInfo.Timezone)
+	if hostInfo.DomainJoined {
+		fmt.Printf(bold+"        Domain: %s%s\n", normal, hostInfo.Domain)
+	} else if hostInfo.Domain != "" {
+		fmt.Printf(bold+"     Workgroup: %s%s\n", normal, hostInfo.Domain)
+	}
+	if 0 < len(hostInfo.Virtualization) {
+		fmt.Printf(bold+"   Virtualized: %s%s\n", normal, strings.Join(hostInfo.Virtualization, ", "))
+	}
+	if hostInfo.HostUUID != "" {
+		fmt.Printf(bold+"     Host UUID: %s%s\n", normal, hostInfo.HostUUID)
+	}
+}
+
 func ping(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.GetInteractive()
 	if session == nil {
@@ -85,25 +203,135 @@ func getPID(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 }
 
 func getUID(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
-	session := ActiveSession.GetInteractive()
-	if session == nil {
+	whoami := requestWhoami(ctx, rpc)
+	if whoami == nil {
 		return
 	}
-	fmt.Printf("%s\n", session.UID)
+	fmt.Printf("%s\n", whoami.UID)
 }
 
 func getGID(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
-	session := ActiveSession.GetInteractive()
-	if session == nil {
+	whoami := requestWhoami(ctx, rpc)
+	if whoami == nil {
 		return
 	}
-	fmt.Printf("%s\n", session.GID)
+	fmt.Printf("%s\n", whoami.GID)
 }
 
 func whoami(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	whoami := requestWhoami(ctx, rpc)
+	if whoami == nil {
+		return
+	}
+	fmt.Printf(bold+"Username: %s%s\n", normal, whoami.Username)
+	fmt.Printf(bold+"     UID: %s%s\n", normal, whoami.UID)
+	fmt.Printf(bold+"     GID: %s%s\n", normal, whoami.GID)
+	if whoami.IntegrityLevel != "" {
+		fmt.Printf(bold+"   Level: %s%s\n", normal, whoami.IntegrityLevel)
+	}
+	if 0 < len(whoami.Groups) {
+		fmt.Printf(bold+"  Groups: %s%s\n", normal, whoami.Groups[0])
+		for _, group := range whoami.Groups[1:] {
+			fmt.Printf("          %s\n", group)
+		}
+	}
+}
+
+func getPrivs(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.GetInteractive()
 	if session == nil {
 		return
 	}
-	fmt.Printf("%s\n", session.Username)
+	if session.OS != "windows" {
+		fmt.Printf(Warn+"Token privileges are only available on Windows, see '%s' for groups\n", consts.WhoamiStr)
+		return
+	}
+	whoami := requestWhoami(ctx, rpc)
+	if whoami == nil {
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tEnabled\tEnabled By Default\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Enabled")),
+		strings.Repeat("=", len("Enabled By Default")),
+	)
+	for _, privilege := range whoami.Privileges {
+		fmt.Fprintf(table, "%s\t%v\t%v\t\n", privilege.Name, privilege.Enabled, privilege.EnabledByDefault)
+	}
+	table.Flush()
+}
+
+// protocolVersionStr - The negotiated protocol version, flagging implants that
+// are too old for some commands
+func protocolVersionStr(version uint32) string {
+	if version == 0 {
+		return "legacy (rebuild the implant for newer commands)"
+	}
+	if version < sliverpb.ProtocolVersion {
+		return fmt.Sprintf("v%d (older than v%d, rebuild the implant for newer commands)", version, sliverpb.ProtocolVersion)
+	}
+	return fmt.Sprintf("v%d", version)
+}
+
+// requestWhoami - Ask the implant who it is, the server caches the groups and
+// privileges in the session info
+func requestWhoami(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) *sliverpb.Whoami {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return nil
+	}
+	whoami, err := rpc.Whoami(context.Background(), &sliverpb.WhoamiReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return nil
+	}
+	if whoami.Response != nil && whoami.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", whoami.Response.Err)
+		return nil
+	}
+	return whoami
+}
+
+// healthDetails - Status and what it was computed from
+func healthDetails(session *clientpb.Session) string {
+	status := sessionHealthStatus(session)
+	health := session.Health
+	if health == nil {
+		return status
+	}
+	details := fmt.Sprintf("%s%s%s (%d/%d recent requests failed", healthColor(status), status, normal, health.Errors, health.Requests)
+	if 0 < health.AvgRTT {
+		details += fmt.Sprintf(", avg RTT %s", time.Duration(health.AvgRTT)*time.Millisecond)
+	}
+	if 0 < health.LastCheckin {
+		since := time.Since(time.Unix(health.LastCheckin, 0)).Round(time.Second)
+		details += fmt.Sprintf(", checked in %s ago", since)
+		if 0 < health.CheckinInterval {
+			details += fmt.Sprintf(", expected every %s", time.Duration(health.CheckinInterval)*time.Second)
+		}
+	}
+	return details + ")"
+}
+
+// compressionDetails - e.g. deflate, 1.2 MiB sent as 400 KiB (33%)
+func compressionDetails(session *clientpb.Session) string {
+	if session.UncompressedBytes == 0 {
+		return session.Compression + ", nothing compressed yet"
+	}
+	return fmt.Sprintf("%s, %s sent as %s (%d%%)", session.Compression,
+		util.ByteCountBinary(int64(session.UncompressedBytes)), util.ByteCountBinary(int64(session.CompressedBytes)),
+		session.CompressedBytes*100/session.UncompressedBytes)
+}
+
+// sendQueueDetails - e.g. 3/16 queued (drop-oldest), 2 dropped
+func sendQueueDetails(session *clientpb.Session) string {
+	details := fmt.Sprintf("%d/%d queued (%s)", session.SendQueueDepth, session.SendQueueSize, session.SendQueuePolicy)
+	if 0 < session.SendQueueDropped {
+		details += fmt.Sprintf(", %d dropped", session.SendQueueDropped)
+	}
+	return details
 }
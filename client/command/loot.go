@@ -0,0 +1,176 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func loot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listLoot(ctx, rpc)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listLoot(ctx, rpc)
+	case "fetch":
+		fetchLoot(ctx, rpc)
+	case "add":
+		addLoot(ctx, rpc)
+	case "rm":
+		removeLoot(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help loot'")
+	}
+}
+
+func listLoot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	allLoot, err := rpc.LootAll(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"Failed to list loot %s\n", err)
+		return
+	}
+	filter := ctx.Flags.String("type")
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if format != tableFormat {
+		table := &exportTable{Columns: []string{"ID", "Type", "Name", "FileName", "SessionName", "SessionID", "Size", "CreatedAt"}}
+		for _, item := range allLoot.Loot {
+			if filter == "" || item.Type == filter {
+				table.Add(item.ID, item.Type, item.Name, item.FileName, item.SessionName, item.SessionID, item.Size, item.CreatedAt)
+			}
+		}
+		printFormatted(format, table)
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tType\tName\tSession\tSize\tCreated\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Type")),
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Session")),
+		strings.Repeat("=", len("Size")),
+		strings.Repeat("=", len("Created")))
+	count := 0
+	for _, item := range allLoot.Loot {
+		if filter != "" && item.Type != filter {
+			continue
+		}
+		session := ""
+		if item.SessionName != "" {
+			session = fmt.Sprintf("%s (%d)", item.SessionName, item.SessionID)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%d\t%s\t\n",
+			item.ID, item.Type, item.Name, session, item.Size, item.CreatedAt)
+		count++
+	}
+	if count == 0 {
+		fmt.Printf(Info + "No loot\n")
+		return
+	}
+	table.Flush()
+}
+
+func fetchLoot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing loot id, see 'help loot'")
+		return
+	}
+	item, err := rpc.LootContent(context.Background(), &clientpb.Loot{ID: ctx.Args[1]})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	saveTo := ctx.Flags.String("save")
+	if saveTo == "" {
+		saveTo = filepath.Base(item.FileName)
+	}
+	if fi, err := os.Stat(saveTo); err == nil && fi.IsDir() {
+		saveTo = filepath.Join(saveTo, filepath.Base(item.FileName))
+	}
+	err = ioutil.WriteFile(saveTo, item.Data, 0600)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to write data %v\n", err)
+		return
+	}
+	fmt.Printf(Info+"Wrote %d bytes to %s\n", len(item.Data), saveTo)
+}
+
+func addLoot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing local file path, see 'help loot'")
+		return
+	}
+	localPath := ctx.Args[1]
+	data, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	lootType := ctx.Flags.String("type")
+	if lootType == "" {
+		lootType = "file"
+	}
+	item := &clientpb.Loot{
+		Name:     ctx.Flags.String("name"),
+		Type:     lootType,
+		FileName: filepath.Base(localPath),
+		Data:     data,
+	}
+	if session := ActiveSession.Get(); session != nil {
+		item.SessionName = session.Name
+		item.SessionID = session.ID
+	}
+	item, err = rpc.LootAdd(context.Background(), item)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Saved %s to loot (%s)\n", item.Name, item.ID)
+}
+
+func removeLoot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing loot id, see 'help loot'")
+		return
+	}
+	_, err := rpc.LootRm(context.Background(), &clientpb.Loot{ID: ctx.Args[1]})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Removed loot %s\n", ctx.Args[1])
+}
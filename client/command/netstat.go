@@ -22,6 +22,11 @@ func netstat(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	ip6 := ctx.Flags.Bool("ip6")
 	tcp := ctx.Flags.Bool("tcp")
 	udp := ctx.Flags.Bool("udp")
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
 
 	netstat, err := rpc.Netstat(context.Background(), &sliverpb.NetstatReq{
 		Request:   ActiveSession.Request(ctx),
@@ -35,6 +40,20 @@ func netstat(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"%s\n", err)
 		return
 	}
+	if format != tableFormat {
+		table := &exportTable{Columns: []string{"Protocol", "LocalAddr", "LocalPort", "RemoteAddr", "RemotePort", "State", "PID", "Executable"}}
+		for _, e := range netstat.Entries {
+			var pid int32
+			var executable string
+			if e.Process != nil {
+				pid, executable = e.Process.Pid, e.Process.Executable
+			}
+			table.Add(e.Protocol, e.LocalAddr.GetIp(), e.LocalAddr.GetPort(), e.RemoteAddr.GetIp(),
+				e.RemoteAddr.GetPort(), e.SkState, pid, executable)
+		}
+		printFormatted(format, table)
+		return
+	}
 	displayEntries(netstat.Entries)
 }
 
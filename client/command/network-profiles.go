@@ -0,0 +1,143 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func networkProfiles(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	profiles, err := rpc.NetworkProfiles(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(profiles.Profiles) == 0 {
+		fmt.Printf(Info+"No network profiles, create one with `%s` or build with --network-profile\n", consts.NewNetworkProfileStr)
+		return
+	}
+	sort.Slice(profiles.Profiles, func(i, j int) bool {
+		return profiles.Profiles[i].Name < profiles.Profiles[j].Name
+	})
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tPadding\tDummy Queries\tDNS Record Mix\tDummy Domains\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Padding")),
+		strings.Repeat("=", len("Dummy Queries")),
+		strings.Repeat("=", len("DNS Record Mix")),
+		strings.Repeat("=", len("Dummy Domains")))
+	for _, profile := range profiles.Profiles {
+		dummyQueries := "disabled"
+		if 0 < profile.DummyQueryInterval {
+			dummyQueries = fmt.Sprintf("every ~%ds", profile.DummyQueryInterval)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+			profile.Name,
+			fmt.Sprintf("%d-%d bytes", profile.MinPadding, profile.MaxPadding),
+			dummyQueries,
+			formatDNSRecordMix(profile.DNSRecordMix),
+			strings.Join(profile.DummyDomains, ", "),
+		)
+	}
+	table.Flush()
+}
+
+func newNetworkProfile(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	name := ctx.Flags.String("name")
+	if name == "" {
+		fmt.Printf(Warn + "Invalid network profile name\n")
+		return
+	}
+	recordMix, err := parseDNSRecordMix(ctx.Flags.String("dns-mix"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	minPadding := ctx.Flags.Int("min-padding")
+	maxPadding := ctx.Flags.Int("max-padding")
+	dummyInterval := ctx.Flags.Int("dummy-interval")
+	if minPadding < 0 || maxPadding < 0 || dummyInterval < 0 {
+		fmt.Printf(Warn + "Padding and intervals can't be negative\n")
+		return
+	}
+	dummyDomains := []string{}
+	for _, domain := range strings.Split(ctx.Flags.String("dummy-domains"), ",") {
+		if domain = strings.TrimSpace(strings.ToLower(domain)); domain != "" {
+			dummyDomains = append(dummyDomains, domain)
+		}
+	}
+	profile, err := rpc.SaveNetworkProfile(context.Background(), &clientpb.NetworkProfile{
+		Name:               name,
+		MinPadding:         uint32(minPadding),
+		MaxPadding:         uint32(maxPadding),
+		DummyQueryInterval: uint32(dummyInterval),
+		DNSRecordMix:       recordMix,
+		DummyDomains:       dummyDomains,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Saved network profile %s (padding %d-%d bytes, dummy queries every ~%ds, %s)\n",
+		profile.Name, profile.MinPadding, profile.MaxPadding, profile.DummyQueryInterval, formatDNSRecordMix(profile.DNSRecordMix))
+}
+
+// parseDNSRecordMix - Parse a "A:4,AAAA:1,TXT:2" style record mix
+func parseDNSRecordMix(value string) (map[string]uint32, error) {
+	recordMix := map[string]uint32{}
+	if value == "" {
+		return recordMix, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		weight := uint64(1)
+		if len(parts) == 2 {
+			var err error
+			weight, err = strconv.ParseUint(parts[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid weight in DNS record mix %#v", entry)
+			}
+		}
+		recordMix[strings.ToUpper(parts[0])] = uint32(weight)
+	}
+	return recordMix, nil
+}
+
+func formatDNSRecordMix(recordMix map[string]uint32) string {
+	records := []string{}
+	for record, weight := range recordMix {
+		records = append(records, fmt.Sprintf("%s:%d", record, weight))
+	}
+	sort.Strings(records)
+	return strings.Join(records, ",")
+}
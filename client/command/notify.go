@@ -0,0 +1,243 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/bishopfox/sliver/client/assets"
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+
+	"github.com/desertbit/grumble"
+)
+
+const (
+	notifyEventEnvVar  = "SLIVER_EVENT"
+	notifyServerEnvVar = "SLIVER_SERVER"
+)
+
+var (
+	// Events that can trigger a notification, the ones set to true are on by default
+	notifyEvents = map[string]bool{
+		consts.SessionOpenedEvent:    true,
+		consts.SessionClosedEvent:    true,
+		consts.BeaconTaskResultEvent: true,
+		consts.BeaconRegisteredEvent: false,
+		consts.CanaryEvent:           false,
+	}
+)
+
+// Notify - Ring the terminal bell and/or run the operator's notification command for an
+// event, if they opted in, server is the profile the event came from (may be empty)
+func Notify(server string, event *clientpb.Event) {
+	settings := assets.GetSettings().Notify
+	if settings == nil || (!settings.Bell && settings.Command == "") {
+		return
+	}
+	if !notifyEnabled(settings, event.EventType) {
+		return
+	}
+	title, message := notification(event)
+	if title == "" {
+		return
+	}
+	if server != "" {
+		title = fmt.Sprintf("[%s] %s", server, title)
+	}
+	sendNotification(settings, server, event.EventType, title, message)
+}
+
+func notifyEnabled(settings *assets.NotifySettings, eventType string) bool {
+	if len(settings.Events) == 0 {
+		return notifyEvents[eventType]
+	}
+	for _, enabled := range settings.Events {
+		if enabled == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// notification - Title and message for an event, the title is empty for events
+// that don't have a notification
+func notification(event *clientpb.Event) (string, string) {
+	switch event.EventType {
+	case consts.SessionOpenedEvent:
+		session := event.Session
+		return "Session opened", fmt.Sprintf("#%d %s - %s@%s (%s/%s)",
+			session.ID, session.Name, session.Username, session.Hostname, session.OS, session.Arch)
+	case consts.SessionClosedEvent:
+		session := event.Session
+		return "Session lost", fmt.Sprintf("#%d %s - %s@%s", session.ID, session.Name, session.Username, session.Hostname)
+	case consts.BeaconRegisteredEvent:
+		beacon := event.Beacon
+		return "Beacon registered", fmt.Sprintf("%s - %s@%s (%s/%s)",
+			beacon.Name, beacon.Username, beacon.Hostname, beacon.OS, beacon.Arch)
+	case consts.BeaconTaskResultEvent:
+		if event.Beacon == nil || event.BeaconTask == nil {
+			return "", ""
+		}
+		return "Beacon task completed", fmt.Sprintf("%s completed %s (%s)",
+			event.Beacon.Name, event.BeaconTask.ID, event.BeaconTask.Description)
+	case consts.CanaryEvent:
+		return "DNS canary triggered", fmt.Sprintf("%s has been burned", event.Session.Name)
+	}
+	return "", ""
+}
+
+// sendNotification - The command is started in the background, its output is discarded
+func sendNotification(settings *assets.NotifySettings, server string, eventType string, title string, message string) {
+	if settings.Bell {
+		fmt.Print("\a")
+	}
+	if settings.Command == "" {
+		return
+	}
+	args := strings.Fields(settings.Command)
+	notifyCmd := exec.Command(args[0], append(args[1:], title, message)...)
+	notifyCmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", notifyEventEnvVar, eventType),
+		fmt.Sprintf("%s=%s", notifyServerEnvVar, server),
+	)
+	err := notifyCmd.Start()
+	if err != nil {
+		log.Printf("Notification command failed %s", err)
+		return
+	}
+	go notifyCmd.Wait()
+}
+
+// notify - Show or change the notification settings
+func notify(ctx *grumble.Context) {
+	settings := assets.GetSettings()
+	if settings.Notify == nil {
+		settings.Notify = &assets.NotifySettings{}
+	}
+	if len(ctx.Args) < 1 {
+		printNotifySettings(settings.Notify)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "bell":
+		if len(ctx.Args) < 2 || (ctx.Args[1] != "on" && ctx.Args[1] != "off") {
+			fmt.Printf(Warn + "Bell must be 'on' or 'off'\n")
+			return
+		}
+		settings.Notify.Bell = ctx.Args[1] == "on"
+	case "command":
+		if len(ctx.Args) < 2 {
+			fmt.Printf(Warn + "Missing command, see 'help notify'\n")
+			return
+		}
+		settings.Notify.Command = strings.Join(ctx.Args[1:], " ")
+		if settings.Notify.Command == "off" {
+			settings.Notify.Command = ""
+		}
+	case "events":
+		if len(ctx.Args) < 2 {
+			fmt.Printf(Warn + "Missing events, see 'help notify'\n")
+			return
+		}
+		events, err := parseNotifyEvents(ctx.Args[1:])
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		settings.Notify.Events = events
+	case "off":
+		settings.Notify = nil
+	case "test":
+		if !settings.Notify.Bell && settings.Notify.Command == "" {
+			printNotifySettings(settings.Notify)
+			return
+		}
+		sendNotification(settings.Notify, "", "test", "Sliver", "Notifications are working")
+		return
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help notify'")
+		return
+	}
+	err := assets.SaveSettings(settings)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save settings %s\n", err)
+		return
+	}
+	if settings.Notify == nil {
+		fmt.Printf(Info + "Notifications are off\n")
+		return
+	}
+	printNotifySettings(settings.Notify)
+}
+
+// parseNotifyEvents - Comma or space separated event types, "default" restores the defaults
+func parseNotifyEvents(args []string) ([]string, error) {
+	events := []string{}
+	for _, event := range strings.Fields(strings.ReplaceAll(strings.Join(args, " "), ",", " ")) {
+		if event == "default" {
+			return nil, nil
+		}
+		if _, ok := notifyEvents[event]; !ok {
+			return nil, fmt.Errorf("Unknown event %s, must be one of %s", event, strings.Join(notifyEventTypes(), ", "))
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func notifyEventTypes() []string {
+	return []string{
+		consts.SessionOpenedEvent,
+		consts.SessionClosedEvent,
+		consts.BeaconRegisteredEvent,
+		consts.BeaconTaskResultEvent,
+		consts.CanaryEvent,
+	}
+}
+
+func printNotifySettings(settings *assets.NotifySettings) {
+	if !settings.Bell && settings.Command == "" {
+		fmt.Printf(Info + "Notifications are off, turn them on with 'notify bell on' or 'notify command <command>'\n")
+		return
+	}
+	events := settings.Events
+	if len(events) == 0 {
+		for _, event := range notifyEventTypes() {
+			if notifyEvents[event] {
+				events = append(events, event)
+			}
+		}
+	}
+	bell := "off"
+	if settings.Bell {
+		bell = "on"
+	}
+	command := settings.Command
+	if command == "" {
+		command = "(none)"
+	}
+	fmt.Printf(Info+"Bell: %s\n", bell)
+	fmt.Printf(Info+"Command: %s\n", command)
+	fmt.Printf(Info+"Events: %s\n", strings.Join(events, ", "))
+}
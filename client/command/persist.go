@@ -0,0 +1,142 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func persist(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listPersistence(ctx, rpc)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listPersistence(ctx, rpc)
+	case "install":
+		installPersistence(ctx, rpc)
+	case "rm":
+		removePersistence(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help persist'")
+	}
+}
+
+func listPersistence(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	allPersistence, err := rpc.PersistAll(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"Failed to list persistence %s\n", err)
+		return
+	}
+	if len(allPersistence.Persistence) == 0 {
+		fmt.Printf(Info + "No persistence installed\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tHostname\tTechnique\tName\tPath\tCreated\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Hostname")),
+		strings.Repeat("=", len("Technique")),
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Path")),
+		strings.Repeat("=", len("Created")))
+	for _, record := range allPersistence.Persistence {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+			record.ID, record.Hostname, record.Technique, record.Name, record.Path, record.CreatedAt)
+	}
+	table.Flush()
+	if ctx.Flags.Bool("cleanup") {
+		for _, record := range allPersistence.Persistence {
+			printPersistenceCleanup(record)
+		}
+	}
+}
+
+func installPersistence(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	technique := ctx.Flags.String("technique")
+	name := ctx.Flags.String("name")
+	if technique == "" || name == "" {
+		fmt.Println(Warn + "Must specify a --technique and --name, see 'help persist'")
+		return
+	}
+	record, err := rpc.PersistInstall(context.Background(), &sliverpb.PersistInstallReq{
+		Technique: technique,
+		Name:      name,
+		Command:   ctx.Flags.String("command"),
+		Request:   ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Installed %s persistence %s (%s)\n", record.Technique, record.ID, record.Path)
+	printPersistenceCleanup(record)
+}
+
+func removePersistence(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing persistence id, see 'help persist'")
+		return
+	}
+	req := &clientpb.PersistenceRemoveReq{
+		ID:     ctx.Args[1],
+		Forget: ctx.Flags.Bool("forget"),
+	}
+	if !req.Forget {
+		session := ActiveSession.GetInteractive()
+		if session == nil {
+			return
+		}
+		req.Request = ActiveSession.Request(ctx)
+	}
+	_, err := rpc.PersistRemove(context.Background(), req)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if req.Forget {
+		fmt.Printf(Info+"Forgot persistence %s, it was not uninstalled\n", req.ID)
+	} else {
+		fmt.Printf(Info+"Removed persistence %s\n", req.ID)
+	}
+}
+
+func printPersistenceCleanup(record *clientpb.Persistence) {
+	fmt.Printf("\n"+bold+"Cleanup %s (%s on %s):%s\n", record.ID, record.Technique, record.Hostname, normal)
+	for _, step := range record.Cleanup {
+		fmt.Printf("  %s\n", step)
+	}
+}
@@ -21,7 +21,9 @@ package command
 import (
 	"fmt"
 	"context"
+	"time"
 
+	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 
@@ -80,4 +82,74 @@ func tcpListener(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 
 	fmt.Printf(Info+"Listening on tcp://%s", address)
-}
\ No newline at end of file
+}
+
+func pivotGraph(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	timeout := time.Duration(ctx.Flags.Int("timeout")) * time.Second
+	graph, err := rpc.PivotGraph(context.Background(), &clientpb.PivotGraphReq{
+		Latency: ctx.Flags.Bool("latency"),
+		Timeout: int64(timeout),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(graph.Edges) == 0 {
+		fmt.Printf(Info + "No pivots\n")
+		return
+	}
+
+	sessions := map[uint32]*clientpb.Session{}
+	for _, session := range graph.Sessions {
+		sessions[session.ID] = session
+	}
+	children := map[uint32][]*clientpb.PivotEdge{}
+	pivoted := map[uint32]bool{}
+	for _, edge := range graph.Edges {
+		children[edge.ParentID] = append(children[edge.ParentID], edge)
+		pivoted[edge.SessionID] = true
+	}
+	fmt.Println("server")
+	roots := []*clientpb.Session{}
+	for _, session := range graph.Sessions {
+		if !pivoted[session.ID] && 0 < len(children[session.ID]) {
+			roots = append(roots, session)
+		}
+	}
+	for index, session := range roots {
+		last := index == len(roots)-1
+		printPivotNode(session, session.Transport, "", last)
+		printPivotChildren(session.ID, sessions, children, pivotTreeIndent(last))
+	}
+}
+
+func printPivotChildren(parentID uint32, sessions map[uint32]*clientpb.Session, children map[uint32][]*clientpb.PivotEdge, indent string) {
+	for index, edge := range children[parentID] {
+		last := index == len(children[parentID])-1
+		session, ok := sessions[edge.SessionID]
+		if !ok {
+			continue
+		}
+		link := fmt.Sprintf("%s from %s", edge.Transport, edge.RemoteAddress)
+		if 0 <= edge.Latency {
+			link += fmt.Sprintf(", %dms", edge.Latency)
+		}
+		printPivotNode(session, link, indent, last)
+		printPivotChildren(edge.SessionID, sessions, children, indent+pivotTreeIndent(last))
+	}
+}
+
+func printPivotNode(session *clientpb.Session, link string, indent string, last bool) {
+	branch := "├── "
+	if last {
+		branch = "└── "
+	}
+	fmt.Printf("%s%s%d %s (%s) %s@%s\n", indent, branch, session.ID, session.Name, link, session.Username, session.Hostname)
+}
+
+func pivotTreeIndent(last bool) string {
+	if last {
+		return "    "
+	}
+	return "│   "
+}
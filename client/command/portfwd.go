@@ -0,0 +1,106 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func portfwd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listPortfwds()
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listPortfwds()
+	case "add":
+		addPortfwd(ctx, rpc)
+	case "rm":
+		removePortfwd(ctx)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help portfwd'")
+	}
+}
+
+func addPortfwd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	remoteHost, remotePort, err := net.SplitHostPort(ctx.Flags.String("remote"))
+	if err != nil {
+		fmt.Printf(Warn+"Invalid --remote address %s\n", err)
+		return
+	}
+	port, err := strconv.ParseUint(remotePort, 10, 16)
+	if err != nil {
+		fmt.Printf(Warn+"Invalid --remote port %s\n", err)
+		return
+	}
+	bindAddr := ctx.Flags.String("bind")
+	forward, err := core.Portfwds.Start(rpc, ActiveSession.Request(ctx), bindAddr, remoteHost, uint32(port))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Port forwarding %s -> %s\n", forward.BindAddr, forward.RemoteAddr)
+}
+
+func removePortfwd(ctx *grumble.Context) {
+	id := ctx.Flags.Int("id")
+	if id < 1 {
+		fmt.Println(Warn + "Must specify a valid --id")
+		return
+	}
+	if !core.Portfwds.Stop(id) {
+		fmt.Printf(Warn+"No port forward with id %d\n", id)
+		return
+	}
+	fmt.Printf(Info+"Removed port forward %d\n", id)
+}
+
+func listPortfwds() {
+	forwards := core.Portfwds.List()
+	if len(forwards) == 0 {
+		fmt.Printf(Info + "No port forwards\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tSession ID\tLocal Address\tRemote Address\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Session ID")),
+		strings.Repeat("=", len("Local Address")),
+		strings.Repeat("=", len("Remote Address")))
+	for _, forward := range forwards {
+		fmt.Fprintf(table, "%d\t%d\t%s\t%s\t\n", forward.ID, forward.SessionID, forward.BindAddr, forward.RemoteAddr)
+	}
+	table.Flush()
+}
@@ -0,0 +1,116 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bishopfox/sliver/client/spin"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/desertbit/grumble"
+)
+
+const (
+	// Common services on internal networks, used when no ports are given
+	defaultScanPorts = "21,22,23,25,53,80,88,110,111,135,139,143,389,443,445,636,1433,1521,2049,3268,3306,3389,5432,5900,5985,5986,6379,8000,8080,8443,9200,27017"
+
+	// Leave the implant some time to send back partial results before
+	// the server gives up on the request
+	portScanTimeoutMargin = 10
+)
+
+func portScan(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	targets := []string{}
+	for _, arg := range ctx.Args {
+		for _, target := range strings.Split(arg, ",") {
+			if target != "" {
+				targets = append(targets, target)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Printf(Warn + "Missing target(s), see `help portscan`\n")
+		return
+	}
+	timeout := commandTimeout(ctx)
+	scanTimeout := timeout - portScanTimeoutMargin
+	if scanTimeout < 1 {
+		scanTimeout = 1
+	}
+
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Scanning %s ...", strings.Join(targets, ", ")), ctrl)
+	portScan, err := rpc.PortScan(context.Background(), &sliverpb.PortScanReq{
+		Targets:        targets,
+		Ports:          ctx.Flags.String("ports"),
+		Concurrency:    int32(ctx.Flags.Int("concurrency")),
+		Rate:           int32(ctx.Flags.Int("rate")),
+		ConnectTimeout: int64(ctx.Flags.Int("connect-timeout")),
+		Timeout:        int64(scanTimeout),
+		Request:        ActiveSession.Request(ctx),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if portScan.Response != nil && portScan.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", portScan.Response.Err)
+		return
+	}
+
+	elapsed := time.Duration(portScan.Elapsed) * time.Millisecond
+	fmt.Printf(Info+"Scanned %d host(s), %d probe(s) in %s\n", portScan.Hosts, portScan.Probes, elapsed.Round(time.Millisecond))
+	if portScan.Incomplete {
+		fmt.Printf(Warn + "Scan stopped at the timeout, results are partial (see --timeout)\n")
+	}
+	if len(portScan.Ports) == 0 {
+		fmt.Printf(Info + "No open ports found\n")
+		return
+	}
+	fmt.Println()
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Host\tPort\tLatency\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Host")),
+		strings.Repeat("=", len("Port")),
+		strings.Repeat("=", len("Latency")),
+	)
+	lastHost := ""
+	for _, port := range portScan.Ports {
+		host := port.Host
+		if host == lastHost {
+			host = ""
+		}
+		lastHost = port.Host
+		fmt.Fprintf(table, "%s\t%d\t%dms\t\n", host, port.Port, port.Latency)
+	}
+	table.Flush()
+}
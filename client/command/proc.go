@@ -40,11 +40,20 @@ import (
 
 var (
 	// Stylizes known processes in the `ps` command
-	knownProcs = map[string]string{
-		"ccSvcHst.exe":    red, // SEP
-		"cb.exe":          red, // Carbon Black
-		"MsMpEng.exe":     red, // Windows Defender
-		"smartscreen.exe": red, // Windows Defender Smart Screen
+	knownProcs = map[string]bool{
+		"ccSvcHst.exe":    true, // SEP
+		"cb.exe":          true, // Carbon Black
+		"MsMpEng.exe":     true, // Windows Defender
+		"smartscreen.exe": true, // Windows Defender Smart Screen
+	}
+
+	// Stylizes known security product modules in `ps --full`, matched as a
+	// lower case prefix since some include a version number
+	knownModules = []string{
+		"cylancememdef",   // Cylance
+		"inprocessclient", // SentinelOne
+		"umppc",           // CrowdStrike
+		"atcuf",           // Bitdefender
 	}
 )
 
@@ -57,14 +66,44 @@ func ps(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	pidFilter := ctx.Flags.Int("pid")
 	exeFilter := ctx.Flags.String("exe")
 	ownerFilter := ctx.Flags.String("owner")
+	format, err := outputFormat(ctx)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
 
 	ps, err := rpc.Ps(context.Background(), &sliverpb.PsReq{
-		Request: ActiveSession.Request(ctx),
+		FullInfo: ctx.Flags.Bool("full"),
+		Tree:     ctx.Flags.Bool("tree"),
+		Request:  ActiveSession.Request(ctx),
 	})
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 		return
 	}
+	if format != tableFormat {
+		table := &exportTable{Columns: []string{"PID", "PPID", "Executable", "Owner", "IntegrityLevel", "Modules"}}
+		for _, proc := range ps.Processes {
+			if pidFilter != -1 && proc.Pid != int32(pidFilter) {
+				continue
+			}
+			if exeFilter != "" && !strings.HasPrefix(proc.Executable, exeFilter) {
+				continue
+			}
+			if ownerFilter != "" && !strings.HasPrefix(proc.Owner, ownerFilter) {
+				continue
+			}
+			table.Add(proc.Pid, proc.Ppid, proc.Executable, proc.Owner, proc.IntegrityLevel, proc.Modules)
+		}
+		printFormatted(format, table)
+		return
+	}
+	if ctx.Flags.Bool("tree") {
+		for _, root := range ps.Tree {
+			printProcessNode(root, "", "")
+		}
+		return
+	}
 
 	outputBuf := bytes.NewBufferString("")
 	table := tabwriter.NewWriter(outputBuf, 0, 2, 2, ' ', 0)
@@ -117,18 +156,67 @@ func ps(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 
 // printProcInfo - Stylizes the process information
 func printProcInfo(table *tabwriter.Writer, proc *commonpb.Process) string {
+	color := procColor(proc)
+	fmt.Fprintf(table, "%d\t%d\t%s\t%s\t\n", proc.Pid, proc.Ppid, proc.Executable, procDetails(proc))
+	return color
+}
+
+func procColor(proc *commonpb.Process) string {
 	color := normal
-	if modifyColor, ok := knownProcs[proc.Executable]; ok {
-		color = modifyColor
+	if 0 < len(knownProcModules(proc)) {
+		color = red
+	}
+	if knownProcs[proc.Executable] {
+		color = red
 	}
 	session := ActiveSession.GetInteractive()
 	if session != nil && proc.Pid == session.PID {
 		color = green
 	}
-	fmt.Fprintf(table, "%d\t%d\t%s\t%s\t\n", proc.Pid, proc.Ppid, proc.Executable, proc.Owner)
 	return color
 }
 
+// procDetails - Owner plus the integrity level and any known security
+// product modules if we asked for full info
+func procDetails(proc *commonpb.Process) string {
+	details := proc.Owner
+	if proc.IntegrityLevel != "" {
+		details += fmt.Sprintf(" [%s]", proc.IntegrityLevel)
+	}
+	if modules := knownProcModules(proc); 0 < len(modules) {
+		details += fmt.Sprintf(" (%s)", strings.Join(modules, ", "))
+	}
+	return details
+}
+
+func knownProcModules(proc *commonpb.Process) []string {
+	modules := []string{}
+	for _, module := range proc.Modules {
+		for _, known := range knownModules {
+			if strings.HasPrefix(strings.ToLower(module), known) {
+				modules = append(modules, module)
+				break
+			}
+		}
+	}
+	return modules
+}
+
+// printProcessNode - Print a process and its children, prefix is the
+// indentation of the node's own line and childPrefix that of its children
+func printProcessNode(node *sliverpb.ProcessNode, prefix string, childPrefix string) {
+	proc := node.Process
+	color := procColor(proc)
+	fmt.Printf("%s%s%d %s %s%s\n", prefix, color, proc.Pid, proc.Executable, procDetails(proc), normal)
+	for index, child := range node.Children {
+		if index == len(node.Children)-1 {
+			printProcessNode(child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			printProcessNode(child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
 func procdump(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	session := ActiveSession.GetInteractive()
 	if session == nil {
@@ -146,25 +234,47 @@ func procdump(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 
-	if ctx.Flags.Int("timeout") < 1 {
+	if commandTimeout(ctx) < 1 {
 		fmt.Printf(Warn + "Invalid timeout argument\n")
 		return
 	}
 
+	maxSize := ctx.Flags.Int("max-size")
+	if maxSize < 0 {
+		fmt.Printf(Warn + "Invalid max size argument\n")
+		return
+	}
+
+	progress := watchTransfers(session.ID, "recv")
 	ctrl := make(chan bool)
-	go spin.Until("Dumping remote process memory ...", ctrl)
+	go spin.UntilWithStatus("Dumping remote process memory ...", ctrl, progress.Status)
 	dump, err := rpc.ProcessDump(context.Background(), &sliverpb.ProcessDumpReq{
 		Request: ActiveSession.Request(ctx),
 		Pid:     int32(pid),
-		Timeout: int32(ctx.Flags.Int("timeout") - 1),
+		MaxSize: int64(maxSize) * 1024 * 1024,
+		Encrypt: ctx.Flags.Bool("encrypt"),
+		Timeout: int32(commandTimeout(ctx) - 1),
 	})
 	ctrl <- true
 	<-ctrl
+	progress.Stop()
 	if err != nil {
-		fmt.Printf(Warn+"Error %s", err)
+		fmt.Printf(Warn+"Error %s\n", err)
+		return
+	}
+	if dump.Response != nil && dump.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", dump.Response.Err)
+		return
+	}
+	if dump.Truncated {
+		fmt.Printf(Warn+"Dump truncated at the %d MiB size limit\n", dump.Size/1024/1024)
+	}
+	if dump.LootID != "" {
+		fmt.Printf(Info+"Process dump (%d bytes) saved to loot: %s\n", dump.Size, dump.LootID)
 		return
 	}
 
+	// The server couldn't save it to loot, so keep a local copy instead
 	hostname := session.Hostname
 	tmpFileName := path.Base(fmt.Sprintf("procdump_%s_%d_*", hostname, pid))
 	tmpFile, err := ioutil.TempFile("", tmpFileName)
@@ -0,0 +1,140 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/util"
+
+	"github.com/desertbit/grumble"
+)
+
+const progressBarWidth = 20
+
+// transferWatch - Follows the progress events of the next transfer in one
+// direction for a session, the server only reports transfers of large envelopes
+type transferWatch struct {
+	sessionID  uint32
+	direction  string
+	listenerID int
+	transfer   *clientpb.Transfer
+	mutex      sync.Mutex
+}
+
+// watchTransfers - direction is from the server's point of view, "send" for
+// data going to the implant and "recv" for data coming back
+func watchTransfers(sessionID uint32, direction string) *transferWatch {
+	watch := &transferWatch{sessionID: sessionID, direction: direction}
+	if sessionID == 0 {
+		return watch // Beacon tasks are not transferred while we wait
+	}
+	watch.listenerID = ConsoleEvents.AddListener(func(event *clientpb.Event) {
+		if event.EventType != consts.TransferProgressEvent || event.Transfer == nil {
+			return
+		}
+		if event.Transfer.SessionID != watch.sessionID || event.Transfer.Direction != watch.direction {
+			return
+		}
+		watch.mutex.Lock()
+		defer watch.mutex.Unlock()
+		if watch.transfer == nil || watch.transfer.ID <= event.Transfer.ID {
+			watch.transfer = event.Transfer
+		}
+	})
+	return watch
+}
+
+// Status - A progress bar, or an empty string until the first progress event
+func (w *transferWatch) Status() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.transfer == nil {
+		return ""
+	}
+	return progressBar(w.transfer)
+}
+
+// Stop - Stop listening for progress events
+func (w *transferWatch) Stop() {
+	if w.listenerID != 0 {
+		ConsoleEvents.RemoveListener(w.listenerID)
+	}
+}
+
+// progressBar - e.g. [=========>          ] 45% 1.2 MiB / 2.7 MiB (12/30 chunks)
+func progressBar(transfer *clientpb.Transfer) string {
+	percent := int64(0)
+	if 0 < transfer.Total {
+		percent = transfer.Done * 100 / transfer.Total
+	}
+	filled := int(percent * progressBarWidth / 100)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	status := fmt.Sprintf("[%s] %d%% %s / %s", bar, percent,
+		util.ByteCountBinary(transfer.Done), util.ByteCountBinary(transfer.Total))
+	if 1 < transfer.Chunks {
+		status += fmt.Sprintf(" (%d/%d chunks)", transfer.ChunksDone, transfer.Chunks)
+	}
+	return status
+}
+
+// transfers - List the transfers in progress
+func transfers(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	transfers, err := rpc.GetTransfers(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(transfers.Transfers) == 0 {
+		fmt.Printf(Info + "No transfers in progress\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tSession\tTransport\tDirection\tStarted\tProgress\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Session")),
+		strings.Repeat("=", len("Transport")),
+		strings.Repeat("=", len("Direction")),
+		strings.Repeat("=", len("Started")),
+		strings.Repeat("=", len("Progress")))
+	for _, transfer := range transfers.Transfers {
+		fmt.Fprintf(table, "%d\t%d\t%s\t%s\t%s\t%s\t\n",
+			transfer.ID,
+			transfer.SessionID,
+			transfer.Transport,
+			transfer.Direction,
+			transfer.StartedAt,
+			progressBar(transfer),
+		)
+	}
+	table.Flush()
+}
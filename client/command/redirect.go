@@ -0,0 +1,219 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+const (
+	redirectOperator       = ">"
+	appendRedirectOperator = ">>"
+
+	// lootRedirectTarget - `> loot` or `> loot:<name>` saves to the loot store instead of
+	// a local file, use `> ./loot` for a local file named loot
+	lootRedirectTarget = "loot"
+
+	outputLootType = "output"
+)
+
+var (
+	// Output is saved without colors or cursor movement
+	ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+	// Commands whose Run has already been wrapped, see wrapOutputRedirects
+	redirectedCommands = map[*grumble.Command]bool{}
+)
+
+// outputRedirect - Where a command's output is copied to, the output is still printed
+type outputRedirect struct {
+	Path     string
+	Append   bool
+	Loot     bool
+	LootName string
+}
+
+// parseOutputRedirect - Split a trailing `> target` or `>> target` off the arguments
+func parseOutputRedirect(args []string) ([]string, *outputRedirect, error) {
+	for index, arg := range args {
+		if arg != redirectOperator && arg != appendRedirectOperator {
+			continue
+		}
+		if index != len(args)-2 {
+			return nil, nil, fmt.Errorf("%s must be followed by exactly one file path or 'loot' at the end of the command", arg)
+		}
+		target := args[index+1]
+		redirect := &outputRedirect{Append: arg == appendRedirectOperator}
+		if target == lootRedirectTarget || strings.HasPrefix(target, lootRedirectTarget+":") {
+			if redirect.Append {
+				return nil, nil, fmt.Errorf("output can't be appended to loot, use %s", redirectOperator)
+			}
+			redirect.Loot = true
+			redirect.LootName = strings.TrimPrefix(strings.TrimPrefix(target, lootRedirectTarget), ":")
+		} else {
+			redirect.Path = target
+		}
+		return args[:index], redirect, nil
+	}
+	return args, nil, nil
+}
+
+// wrapOutputRedirects - Let every console command redirect its output, grumble doesn't
+// know about the operators so commands that take no arguments have to allow them here
+func wrapOutputRedirects(app *grumble.App, rpc rpcpb.SliverRPCClient) {
+	for _, cmd := range app.Commands().All() {
+		if redirectedCommands[cmd] || cmd.Run == nil {
+			continue
+		}
+		redirectedCommands[cmd] = true
+		run := cmd.Run
+		allowArgs := cmd.AllowArgs
+		name := cmd.Name
+		cmd.AllowArgs = true
+		cmd.Run = func(ctx *grumble.Context) error {
+			args, redirect, err := parseOutputRedirect(ctx.Args)
+			if err != nil {
+				return err
+			}
+			if !allowArgs && 0 < len(args) {
+				return fmt.Errorf("command '%s' requires no arguments, try 'help'", name)
+			}
+			ctx.Args = args
+			if redirect == nil {
+				return run(ctx)
+			}
+			commandLine := strings.TrimSpace(name + " " + strings.Join(args, " "))
+			return redirect.capture(commandLine, rpc, func() error {
+				return run(ctx)
+			})
+		}
+	}
+}
+
+// capture - Run a command while copying everything it prints to stdout
+func (r *outputRedirect) capture(commandLine string, rpc rpcpb.SliverRPCClient, run func() error) error {
+	data, err := captureStdout(true, run)
+	if data == nil {
+		return err // The command never ran
+	}
+	if r.Loot {
+		r.saveToLoot(commandLine, data, rpc)
+	} else {
+		r.saveToFile(data)
+	}
+	return err
+}
+
+// captureStdout - Return what run prints to stdout without colors, echo also prints it.
+// The output is nil if run could not be called.
+func captureStdout(echo bool, run func() error) ([]byte, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	output := &bytes.Buffer{}
+	var dst io.Writer = output
+	if echo {
+		dst = io.MultiWriter(stdout, output)
+	}
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		io.Copy(dst, reader)
+	}()
+
+	os.Stdout = writer
+	runErr := run()
+	os.Stdout = stdout
+	writer.Close()
+	<-copied
+	reader.Close()
+
+	data := ansiEscapePattern.ReplaceAll(output.Bytes(), []byte{})
+	if data == nil {
+		data = []byte{}
+	}
+	return data, runErr
+}
+
+func (r *outputRedirect) saveToFile(data []byte) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if r.Append {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	outFile, err := os.OpenFile(r.Path, flags, 0600)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save output %s\n", err)
+		return
+	}
+	defer outFile.Close()
+	_, err = outFile.Write(data)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save output %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Wrote %d bytes to %s\n", len(data), r.Path)
+}
+
+// saveToLoot - The command line, target, and time are recorded in a header, the
+// loot name defaults to the command line
+func (r *outputRedirect) saveToLoot(commandLine string, data []byte, rpc rpcpb.SliverRPCClient) {
+	now := time.Now()
+	name := r.LootName
+	if name == "" {
+		name = commandLine
+	}
+	header := &bytes.Buffer{}
+	fmt.Fprintf(header, "# Command: %s\n", commandLine)
+	item := &clientpb.Loot{
+		Name:     name,
+		Type:     outputLootType,
+		FileName: fmt.Sprintf("%s_%s.txt", strings.Fields(commandLine)[0], now.Format("20060102150405")),
+	}
+	if session := ActiveSession.Get(); session != nil {
+		item.SessionName = session.Name
+		item.SessionID = session.ID
+		fmt.Fprintf(header, "# Session: %s (%d) %s@%s\n", session.Name, session.ID, session.Username, session.Hostname)
+	}
+	if beacon := ActiveSession.GetBeacon(); beacon != nil {
+		fmt.Fprintf(header, "# Beacon: %s (%s)\n", beacon.Name, beacon.ID)
+	}
+	fmt.Fprintf(header, "# Time: %s\n\n", now.Format(time.RFC1123))
+	item.Data = append(header.Bytes(), data...)
+
+	item, err := rpc.LootAdd(context.Background(), item)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save output %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Saved output to loot %s (%s)\n", item.Name, item.ID)
+}
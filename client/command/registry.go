@@ -0,0 +1,243 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+var (
+	registryHives = []string{
+		"HKCR", "HKCU", "HKLM", "HKU", "HKCC",
+		"HKEY_CLASSES_ROOT", "HKEY_CURRENT_USER", "HKEY_LOCAL_MACHINE", "HKEY_USERS", "HKEY_CURRENT_CONFIG",
+	}
+
+	registryTypes = map[string]string{
+		"string": "REG_SZ",
+		"expand": "REG_EXPAND_SZ",
+		"multi":  "REG_MULTI_SZ",
+		"dword":  "REG_DWORD",
+		"qword":  "REG_QWORD",
+		"binary": "REG_BINARY",
+	}
+)
+
+func registry(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing subcommand or key path, see 'help registry'")
+		return
+	}
+	hive, path := registryPath(ctx.Flags.String("hive"), ctx.Args[1])
+	switch strings.ToLower(ctx.Args[0]) {
+	case "read":
+		registryRead(ctx, rpc, hive, path)
+	case "write":
+		registryWrite(ctx, rpc, hive, path)
+	case "create":
+		registryCreateKey(ctx, rpc, hive, path)
+	case "rm":
+		registryDelete(ctx, rpc, hive, path)
+	case "ls":
+		registryList(ctx, rpc, hive, path)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help registry'")
+	}
+}
+
+// registryPath - Key paths may use / or \ and can start with the hive,
+// which then takes precedence over the --hive flag
+func registryPath(hive string, path string) (string, string) {
+	path = strings.Trim(strings.Replace(path, "/", "\\", -1), "\\")
+	root := strings.SplitN(path, "\\", 2)
+	for _, name := range registryHives {
+		if strings.EqualFold(root[0], name) {
+			if len(root) == 1 {
+				return name, ""
+			}
+			return name, root[1]
+		}
+	}
+	return hive, path
+}
+
+func registryRead(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, hive string, path string) {
+	regRead, err := rpc.RegistryRead(context.Background(), &sliverpb.RegistryReadReq{
+		Hive:    hive,
+		Path:    path,
+		Name:    ctx.Flags.String("name"),
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"%s (%s)\n", registryValueName(regRead.Value), regRead.Value.Type)
+	fmt.Println(registryValueData(regRead.Value))
+}
+
+func registryWrite(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, hive string, path string) {
+	value, err := registryValue(ctx.Flags.String("name"), ctx.Flags.String("type"), ctx.Flags.String("value"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	_, err = rpc.RegistryWrite(context.Background(), &sliverpb.RegistryWriteReq{
+		Hive:    hive,
+		Path:    path,
+		Value:   value,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Wrote %s\\%s %s\n", hive, path, registryValueName(value))
+}
+
+func registryCreateKey(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, hive string, path string) {
+	_, err := rpc.RegistryCreateKey(context.Background(), &sliverpb.RegistryCreateKeyReq{
+		Hive:    hive,
+		Path:    path,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Created %s\\%s\n", hive, path)
+}
+
+func registryDelete(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, hive string, path string) {
+	name := ctx.Flags.String("name")
+	_, err := rpc.RegistryDelete(context.Background(), &sliverpb.RegistryDeleteReq{
+		Hive:    hive,
+		Path:    path,
+		Name:    name,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if name == "" {
+		fmt.Printf(Info+"Deleted key %s\\%s\n", hive, path)
+	} else {
+		fmt.Printf(Info+"Deleted value %s from %s\\%s\n", name, hive, path)
+	}
+}
+
+func registryList(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, hive string, path string) {
+	regList, err := rpc.RegistryList(context.Background(), &sliverpb.RegistryListReq{
+		Hive:    hive,
+		Path:    path,
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf("%s\\%s\n", hive, path)
+	for _, subkey := range regList.Subkeys {
+		fmt.Printf("  %s\\\n", subkey)
+	}
+	if len(regList.Values) == 0 {
+		return
+	}
+	fmt.Println()
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tType\tData\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Type")),
+		strings.Repeat("=", len("Data")))
+	for _, value := range regList.Values {
+		data := registryValueData(value)
+		if value.Type != "REG_DWORD" && value.Type != "REG_QWORD" && value.Type != "REG_SZ" && value.Type != "REG_EXPAND_SZ" {
+			data = strings.Join(strings.Fields(data), " ")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t\n", registryValueName(value), value.Type, data)
+	}
+	table.Flush()
+}
+
+// registryValue - Parse a value from the command line into a typed registry value
+func registryValue(name string, valueType string, data string) (*sliverpb.RegistryValue, error) {
+	regType, ok := registryTypes[strings.ToLower(valueType)]
+	if !ok {
+		return nil, fmt.Errorf("Invalid value type '%s'", valueType)
+	}
+	value := &sliverpb.RegistryValue{Name: name, Type: regType}
+	switch regType {
+	case "REG_SZ", "REG_EXPAND_SZ":
+		value.StringValue = data
+	case "REG_MULTI_SZ":
+		value.StringValues = strings.Split(data, ",")
+	case "REG_DWORD", "REG_QWORD":
+		bitSize := 32
+		if regType == "REG_QWORD" {
+			bitSize = 64
+		}
+		intValue, err := strconv.ParseUint(data, 0, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s value '%s'", valueType, data)
+		}
+		value.IntValue = intValue
+	case "REG_BINARY":
+		byteValue, err := hex.DecodeString(strings.Replace(data, " ", "", -1))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid hex value '%s'", data)
+		}
+		value.ByteValue = byteValue
+	}
+	return value, nil
+}
+
+func registryValueName(value *sliverpb.RegistryValue) string {
+	if value.Name == "" {
+		return "(Default)"
+	}
+	return value.Name
+}
+
+func registryValueData(value *sliverpb.RegistryValue) string {
+	switch value.Type {
+	case "REG_SZ", "REG_EXPAND_SZ":
+		return value.StringValue
+	case "REG_MULTI_SZ":
+		return strings.Join(value.StringValues, "\n")
+	case "REG_DWORD", "REG_QWORD":
+		return fmt.Sprintf("%d (0x%x)", value.IntValue, value.IntValue)
+	}
+	return hex.Dump(value.ByteValue)
+}
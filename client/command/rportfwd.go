@@ -0,0 +1,113 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func rportfwd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		listRportFwds(ctx, rpc)
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listRportFwds(ctx, rpc)
+	case "add":
+		addRportFwd(ctx, rpc)
+	case "rm":
+		removeRportFwd(ctx, rpc)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help rportfwd'")
+	}
+}
+
+func addRportFwd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	forwardAddr := ctx.Flags.String("forward")
+	if forwardAddr == "" {
+		fmt.Println(Warn + "Must specify a --forward address")
+		return
+	}
+	rportfwd, err := rpc.RportFwdStart(context.Background(), &sliverpb.RportFwdStartReq{
+		BindAddress:    ctx.Flags.String("bind"),
+		ForwardAddress: forwardAddr,
+		Request:        ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Reverse port forwarding %s -> %s (id %d)\n",
+		rportfwd.BindAddress, rportfwd.ForwardAddress, rportfwd.ID)
+}
+
+func removeRportFwd(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	id := ctx.Flags.Int("id")
+	if id < 1 {
+		fmt.Println(Warn + "Must specify a valid --id")
+		return
+	}
+	_, err := rpc.RportFwdStop(context.Background(), &sliverpb.RportFwdStopReq{
+		ID:      uint32(id),
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Removed reverse port forward %d\n", id)
+}
+
+func listRportFwds(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	rportfwds, err := rpc.RportFwdList(context.Background(), &sliverpb.RportFwdListReq{
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if len(rportfwds.Forwards) == 0 {
+		fmt.Printf(Info + "No reverse port forwards\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tImplant Address\tForward Address\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Implant Address")),
+		strings.Repeat("=", len("Forward Address")))
+	for _, forward := range rportfwds.Forwards {
+		fmt.Fprintf(table, "%d\t%s\t%s\t\n", forward.ID, forward.BindAddress, forward.ForwardAddress)
+	}
+	table.Flush()
+}
@@ -22,13 +22,26 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
+	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 
 	"github.com/desertbit/grumble"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	// Sessions whose streamed screenshots this client saves, and where
+	followedScreens      = map[uint32]string{}
+	followedScreensMutex = &sync.Mutex{}
 )
 
 func screenshot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
@@ -37,30 +50,155 @@ func screenshot(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 
-	if session.OS != "windows" && session.OS != "linux" {
+	if session.OS != "windows" && session.OS != "linux" && session.OS != "darwin" {
 		fmt.Printf(Warn+"Not implemented for %s\n", session.OS)
 		return
 	}
 
+	display := ctx.Flags.Int("display")
+	if display < 0 {
+		fmt.Printf(Warn + "Invalid display number\n")
+		return
+	}
+	listDisplays := ctx.Flags.Bool("list")
 	screenshot, err := rpc.Screenshot(context.Background(), &sliverpb.ScreenshotReq{
-		Request: ActiveSession.Request(ctx),
+		Display:      uint32(display),
+		ListDisplays: listDisplays,
+		Request:      ActiveSession.Request(ctx),
 	})
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 		return
 	}
+	if listDisplays {
+		displayScreens(screenshot.Displays)
+		return
+	}
 
-	timestamp := time.Now().Format("20060102150405")
-	tmpFileName := path.Base(fmt.Sprintf("screenshot_%s_%d_%s_*.png", session.Name, session.ID, timestamp))
-	tmpFile, err := ioutil.TempFile("", tmpFileName)
+	saveTo := ctx.Flags.String("save")
+	if saveTo == "" {
+		timestamp := time.Now().Format("20060102150405")
+		tmpFileName := path.Base(fmt.Sprintf("screenshot_%s_%d_%s_*.png", session.Name, session.ID, timestamp))
+		tmpFile, err := ioutil.TempFile("", tmpFileName)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		tmpFile.Close()
+		saveTo = tmpFile.Name()
+	}
+	err = ioutil.WriteFile(saveTo, screenshot.Data, 0600)
 	if err != nil {
-		fmt.Printf(Warn+"%s\n", err)
+		fmt.Printf(Warn+"Error writting file: %s\n", err)
+		return
+	}
+	fmt.Printf(bold+"Screenshot written to %s\n", saveTo)
+	if screenshot.LootID != "" {
+		fmt.Printf(Info+"Saved to loot (%s)\n", screenshot.LootID)
+	}
+}
+
+func displayScreens(displays []*sliverpb.Display) {
+	if len(displays) == 0 {
+		fmt.Printf(Info + "No active displays\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Display\tPosition\tResolution\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Display")),
+		strings.Repeat("=", len("Position")),
+		strings.Repeat("=", len("Resolution")))
+	for _, display := range displays {
+		fmt.Fprintf(table, "%d\t%d,%d\t%dx%d\t\n",
+			display.Number, display.X, display.Y, display.Width, display.Height)
+	}
+	table.Flush()
+}
+
+func screenshotWatch(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+
+	req := &sliverpb.ScreenshotWatchReq{Request: ActiveSession.Request(ctx)}
+	operation := ""
+	if 0 < len(ctx.Args) {
+		operation = strings.ToLower(ctx.Args[0])
+	}
+	switch operation {
+	case "", "follow", "unfollow":
+	case "start":
+		req.Start = true
+		req.Interval = uint32(ctx.Flags.Int("interval"))
+		req.Display = uint32(ctx.Flags.Int("display"))
+		req.Quality = uint32(ctx.Flags.Int("quality"))
+		req.Scale = uint32(ctx.Flags.Int("scale"))
+	case "stop":
+		req.Stop = true
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help screenshot-watch'")
 		return
 	}
-	err = ioutil.WriteFile(tmpFile.Name(), screenshot.Data, 0600)
+
+	if operation == "start" || operation == "follow" {
+		outputDir := ctx.Flags.String("output")
+		if outputDir == "" {
+			outputDir = filepath.Join(os.TempDir(), fmt.Sprintf("sliver-screenshots-%s-%d", session.Name, session.ID))
+		}
+		err := os.MkdirAll(outputDir, 0700)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		followedScreensMutex.Lock()
+		followedScreens[session.ID] = outputDir
+		followedScreensMutex.Unlock()
+		fmt.Printf(Info+"Saving frames to %s (latest.jpg is the most recent)\n", outputDir)
+	} else if operation == "stop" || operation == "unfollow" {
+		followedScreensMutex.Lock()
+		delete(followedScreens, session.ID)
+		followedScreensMutex.Unlock()
+	}
+
+	watch, err := rpc.ScreenshotWatch(context.Background(), req)
 	if err != nil {
-		fmt.Printf(Warn+"Error writting file: %s\n", err)
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if watch.Response != nil && watch.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", watch.Response.Err)
+	}
+	if watch.Running {
+		display := "all displays"
+		if watch.Display != 0 {
+			display = fmt.Sprintf("display %d", watch.Display)
+		}
+		fmt.Printf(Info+"Screenshot watch is running, capturing %s every %ds\n", display, watch.Interval)
+	} else {
+		fmt.Printf(Info + "Screenshot watch is not running\n")
+	}
+}
+
+// SaveScreenshotFrame - Write a streamed frame to disk if this client is
+// following the session it came from
+func SaveScreenshotFrame(event *clientpb.Event) {
+	if event.Session == nil {
+		return
+	}
+	followedScreensMutex.Lock()
+	outputDir, ok := followedScreens[event.Session.ID]
+	followedScreensMutex.Unlock()
+	if !ok {
+		return
+	}
+	frame := &sliverpb.ScreenshotFrame{}
+	if proto.Unmarshal(event.Data, frame) != nil {
 		return
 	}
-	fmt.Printf(bold+"Screenshot written to %s\n", tmpFile.Name())
+	timestamp := time.Unix(frame.Timestamp, 0).Format("20060102150405")
+	fileName := fmt.Sprintf("frame_%s_%06d.jpg", timestamp, frame.Sequence)
+	ioutil.WriteFile(filepath.Join(outputDir, fileName), frame.Data, 0600)
+	ioutil.WriteFile(filepath.Join(outputDir, "latest.jpg"), frame.Data, 0600)
 }
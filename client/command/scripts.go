@@ -0,0 +1,233 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+// Scripts are Go text/templates, the console is driven through the functions of
+// scriptFuncs and anything the template prints goes to the console. For example:
+//
+//	{{ range sessions }}{{ if eq .OS "windows" }}
+//	{{ use .Name }}{{ exec "getprivs" }}
+//	{{ end }}{{ end }}
+
+// scriptData - The dot of a running script
+type scriptData struct {
+	Name string
+	Args []string
+}
+
+func scripts(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listScripts()
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listScripts()
+	case "run":
+		if len(ctx.Args) < 2 {
+			fmt.Printf(Warn + "Missing script name or path, see 'help script'\n")
+			return
+		}
+		err := runScript(ctx.App, rpc, ctx.Args[1], ctx.Args[2:])
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+		}
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help script'")
+	}
+}
+
+func listScripts() {
+	scripts := assets.GetScripts()
+	if len(scripts) == 0 {
+		fmt.Printf(Info+"No scripts in %s\n", assets.GetScriptsDir())
+		return
+	}
+	fmt.Printf(Info+"Scripts in %s:\n", assets.GetScriptsDir())
+	for _, script := range scripts {
+		fmt.Printf("\t%s\n", script)
+	}
+}
+
+// scriptCompleter - Complete the operation, then the scripts in the scripts dir
+func scriptCompleter(prefix string, args []string) []string {
+	candidates := map[string]bool{}
+	switch len(args) {
+	case 0:
+		candidates["ls"] = true
+		candidates["run"] = true
+	case 1:
+		for _, script := range assets.GetScripts() {
+			candidates[script] = true
+		}
+	}
+	return matchPrefix(prefix, candidates, false)
+}
+
+// scriptPath - A name in the scripts dir, with or without extension, or a file path
+func scriptPath(name string) string {
+	if _, err := os.Stat(name); err == nil {
+		return name
+	}
+	if filepath.Ext(name) != assets.ScriptFileExt {
+		name += assets.ScriptFileExt
+	}
+	return filepath.Join(assets.GetScriptsDir(), name)
+}
+
+func runScript(app *grumble.App, rpc rpcpb.SliverRPCClient, name string, args []string) error {
+	source, err := ioutil.ReadFile(scriptPath(name))
+	if err != nil {
+		return err
+	}
+	script, err := template.New(name).Funcs(scriptFuncs(app, rpc)).Parse(string(source))
+	if err != nil {
+		return err
+	}
+	return script.Execute(os.Stdout, &scriptData{Name: name, Args: args})
+}
+
+// scriptFuncs - The console as seen by scripts, a function returning an error stops the script
+func scriptFuncs(app *grumble.App, rpc rpcpb.SliverRPCClient) template.FuncMap {
+	return template.FuncMap{
+		// Console commands, `run` returns the output and `exec` prints it
+		"run": func(args ...string) (string, error) {
+			output, err := captureStdout(false, func() error {
+				return app.RunCommand(args)
+			})
+			return string(output), err
+		},
+		"exec": func(args ...string) (string, error) {
+			return "", app.RunCommand(args)
+		},
+		"use": func(target string) (string, error) {
+			return "", app.RunCommand([]string{"use", target})
+		},
+
+		// Sessions and tasks
+		"active": func() *clientpb.Session {
+			return ActiveSession.Get()
+		},
+		"sessions": func() ([]*clientpb.Session, error) {
+			sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
+			if err != nil {
+				return nil, err
+			}
+			return sessions.Sessions, nil
+		},
+		"beacons": func() ([]*clientpb.Beacon, error) {
+			beacons, err := rpc.GetBeacons(context.Background(), &commonpb.Empty{})
+			if err != nil {
+				return nil, err
+			}
+			return beacons.Beacons, nil
+		},
+		"tasks": func(beaconID string) ([]*clientpb.BeaconTask, error) {
+			tasks, err := rpc.GetBeaconTasks(context.Background(), &clientpb.Beacon{ID: beaconID})
+			if err != nil {
+				return nil, err
+			}
+			return tasks.Tasks, nil
+		},
+
+		// Events, e.g. {{ $event := wait "beacon-taskresult" "5m" }}
+		"wait": waitForEvent,
+		"sleep": func(duration string) (string, error) {
+			delay, err := time.ParseDuration(duration)
+			if err != nil {
+				return "", err
+			}
+			time.Sleep(delay)
+			return "", nil
+		},
+
+		// Output
+		"info": func(format string, args ...interface{}) string {
+			fmt.Printf(Info+format+"\n", args...)
+			return ""
+		},
+		"warn": func(format string, args ...interface{}) string {
+			fmt.Printf(Warn+format+"\n", args...)
+			return ""
+		},
+
+		// Strings
+		"contains":  strings.Contains,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"trim":      strings.TrimSpace,
+		"split":     strings.Split,
+		"join":      strings.Join,
+		"fields":    strings.Fields,
+		"lines": func(s string) []string {
+			lines := []string{}
+			for _, line := range strings.Split(s, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+			return lines
+		},
+	}
+}
+
+// waitForEvent - Block until the console receives an event of eventType
+func waitForEvent(eventType string, timeout string) (*clientpb.Event, error) {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan *clientpb.Event, 1)
+	listenerID := ConsoleEvents.AddListener(func(event *clientpb.Event) {
+		if event.EventType != eventType {
+			return
+		}
+		select {
+		case events <- event:
+		default:
+		}
+	})
+	defer ConsoleEvents.RemoveListener(listenerID)
+	select {
+	case event := <-events:
+		return event, nil
+	case <-time.After(duration):
+		return nil, fmt.Errorf("timeout waiting for %s event", eventType)
+	}
+}
@@ -0,0 +1,238 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/client/transport"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+var (
+	// The active target of each server while another one is active, see switchServer
+	serverTargets = map[string]*activeTarget{}
+)
+
+// servers - List, connect to, and switch between teamserver profiles
+func servers(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if core.Servers.Active() == nil {
+		fmt.Printf(Warn + "Server profiles are only available in the client console\n")
+		return
+	}
+	if len(ctx.Args) < 1 {
+		listServers()
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listServers()
+	case "connect":
+		connectServer(ctx, rpc)
+	case "use":
+		useServer(ctx, rpc)
+	case "disconnect":
+		disconnectServer(ctx)
+	case "import":
+		importServer(ctx)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help servers'")
+	}
+}
+
+func listServers() {
+	configs := assets.GetConfigs()
+	profiles := []*assets.ClientConfig{}
+	for _, config := range configs {
+		profiles = append(profiles, config)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Profile < profiles[j].Profile
+	})
+	active := core.Servers.ActiveName()
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Profile\tOperator\tServer\tStatus\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Profile")),
+		strings.Repeat("=", len("Operator")),
+		strings.Repeat("=", len("Server")),
+		strings.Repeat("=", len("Status")))
+	for _, config := range profiles {
+		status := ""
+		if core.Servers.Get(config.Profile) != nil {
+			status = "connected"
+		}
+		if config.Profile == active {
+			status = "active"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n",
+			config.Profile, config.Operator, fmt.Sprintf("%s:%d", config.LHost, config.LPort), status)
+	}
+	table.Flush()
+}
+
+// connectServer - Connect to a profile and make it the active server, the
+// other connections stay open
+func connectServer(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing profile name, see 'help servers'")
+		return
+	}
+	name := ctx.Args[1]
+	if core.Servers.Get(name) == nil {
+		config := assets.GetProfile(name)
+		if config == nil {
+			fmt.Printf(Warn+"No profile named %s, see 'servers ls'\n", name)
+			return
+		}
+		fmt.Printf(Info+"Connecting to %s:%d ...\n", config.LHost, config.LPort)
+		_, conn, err := transport.MTLSConnect(config)
+		if err != nil {
+			fmt.Printf(Warn+"Connection to server failed %s\n", err)
+			return
+		}
+		core.Servers.Add(name, config, conn)
+	}
+	switchServer(name, rpc)
+}
+
+func useServer(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing profile name, see 'help servers'")
+		return
+	}
+	if core.Servers.Get(ctx.Args[1]) == nil {
+		fmt.Printf(Warn+"Not connected to %s, see 'servers connect'\n", ctx.Args[1])
+		return
+	}
+	switchServer(ctx.Args[1], rpc)
+}
+
+func disconnectServer(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing profile name, see 'help servers'")
+		return
+	}
+	name := ctx.Args[1]
+	err := core.Servers.Remove(name)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	delete(serverTargets, name)
+	ActiveSession.notify()
+	fmt.Printf(Info+"Disconnected from %s\n", name)
+}
+
+func importServer(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing config file path, see 'help servers'")
+		return
+	}
+	config, err := assets.ReadConfig(ctx.Args[1])
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	err = assets.SaveConfig(config)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Imported profile %s (%s@%s:%d)\n", config.Profile, config.Operator, config.LHost, config.LPort)
+}
+
+// switchServer - Make name the active server, each server keeps its own active
+// session or beacon which is restored if it's still there
+func switchServer(name string, rpc rpcpb.SliverRPCClient) {
+	previous := core.Servers.ActiveName()
+	if previous == name {
+		fmt.Printf(Info+"Active server %s\n", name)
+		return
+	}
+	err := core.Servers.SetActive(name)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	serverTargets[previous] = ActiveSession.save()
+	DirCache.Clear() // Session IDs are only unique per server
+	target := serverTargets[name]
+	delete(serverTargets, name)
+	if target != nil && target.session != nil && !targetExists(target, rpc) {
+		target = nil
+	}
+	ActiveSession.restore(target)
+	fmt.Printf(Info+"Active server %s\n", name)
+}
+
+// targetExists - Sessions may have been lost while another server was active
+func targetExists(target *activeTarget, rpc rpcpb.SliverRPCClient) bool {
+	if target.beacon != nil {
+		return GetBeacon(target.beacon.ID, rpc) != nil
+	}
+	sessions, err := rpc.GetSessions(context.Background(), &commonpb.Empty{})
+	if err != nil {
+		return false
+	}
+	for _, session := range sessions.Sessions {
+		if session.ID == target.session.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// serverCompleter - Subcommands, then profile names
+func serverCompleter(prefix string, args []string) []string {
+	candidates := map[string]bool{}
+	switch len(args) {
+	case 0:
+		for _, subcommand := range []string{"ls", "connect", "use", "disconnect", "import"} {
+			candidates[subcommand] = true
+		}
+	case 1:
+		if args[0] == "import" {
+			return nil
+		}
+		for _, config := range assets.GetConfigs() {
+			candidates[config.Profile] = true
+		}
+	}
+	return matchPrefix(prefix, candidates, false)
+}
+
+// activeTarget - What was active on a server, see switchServer
+type activeTarget struct {
+	session         *clientpb.Session
+	beacon          *clientpb.Beacon
+	previousSession *clientpb.Session
+	previousBeacon  *clientpb.Beacon
+}
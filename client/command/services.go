@@ -0,0 +1,207 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/util/encoders"
+
+	"github.com/desertbit/grumble"
+)
+
+func services(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		listServices(ctx, rpc)
+		return
+	}
+	op := strings.ToLower(ctx.Args[0])
+	if op == "ls" {
+		listServices(ctx, rpc)
+		return
+	}
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing service name, see 'help services'")
+		return
+	}
+	serviceName := ctx.Args[1]
+	switch op {
+	case "info":
+		queryService(ctx, rpc, serviceName)
+	case "create":
+		createService(ctx, rpc, serviceName)
+	case "start":
+		startExistingService(ctx, rpc, serviceName)
+	case "stop":
+		stopService(ctx, rpc, serviceName)
+	case "rm":
+		removeService(ctx, rpc, serviceName)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help services'")
+	}
+}
+
+func listServices(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	services, err := rpc.ListServices(context.Background(), &sliverpb.ListServicesReq{
+		Hostname: ctx.Flags.String("hostname"),
+		Request:  ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tStatus\tStart Type\tPid\tDisplay Name\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Status")),
+		strings.Repeat("=", len("Start Type")),
+		strings.Repeat("=", len("Pid")),
+		strings.Repeat("=", len("Display Name")))
+	for _, service := range services.Services {
+		pid := ""
+		if service.Pid != 0 {
+			pid = fmt.Sprintf("%d", service.Pid)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t\n",
+			service.Name, service.Status, service.StartType, pid, service.DisplayName)
+	}
+	table.Flush()
+}
+
+func queryService(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, serviceName string) {
+	query, err := rpc.QueryService(context.Background(), &sliverpb.QueryServiceReq{
+		ServiceInfo: &sliverpb.ServiceInfoReq{
+			Hostname:    ctx.Flags.String("hostname"),
+			ServiceName: serviceName,
+		},
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	service := query.Service
+	fmt.Printf(bold+"        Name: %s%s\n", normal, service.Name)
+	fmt.Printf(bold+"Display Name: %s%s\n", normal, service.DisplayName)
+	fmt.Printf(bold+" Description: %s%s\n", normal, service.Description)
+	fmt.Printf(bold+"      Status: %s%s\n", normal, service.Status)
+	if service.Pid != 0 {
+		fmt.Printf(bold+"         Pid: %s%d\n", normal, service.Pid)
+	}
+	fmt.Printf(bold+"  Start Type: %s%s\n", normal, service.StartType)
+	fmt.Printf(bold+"    Bin Path: %s%s\n", normal, service.BinPath)
+	fmt.Printf(bold+"     Account: %s%s\n", normal, service.Account)
+}
+
+func createService(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, serviceName string) {
+	binPath := ctx.Flags.String("binpath")
+	if binPath == "" {
+		fmt.Println(Warn + "Must specify a --binpath")
+		return
+	}
+	if localPath := ctx.Flags.String("upload"); localPath != "" {
+		data, err := ioutil.ReadFile(localPath)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		upload, err := rpc.Upload(context.Background(), &sliverpb.UploadReq{
+			Encoder: "gzip",
+			Data:    new(encoders.Gzip).Encode(data),
+			Path:    binPath,
+			Request: ActiveSession.Request(ctx),
+		})
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		binPath = upload.Path
+		fmt.Printf(Info+"Uploaded %s to %s\n", localPath, binPath)
+	}
+	_, err := rpc.CreateService(context.Background(), &sliverpb.CreateServiceReq{
+		ServiceName:        serviceName,
+		ServiceDescription: ctx.Flags.String("description"),
+		BinPath:            binPath,
+		Hostname:           ctx.Flags.String("hostname"),
+		StartType:          ctx.Flags.String("start-type"),
+		Request:            ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Created service %s (%s)\n", serviceName, binPath)
+}
+
+func startExistingService(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, serviceName string) {
+	_, err := rpc.StartExistingService(context.Background(), &sliverpb.StartExistingServiceReq{
+		ServiceInfo: &sliverpb.ServiceInfoReq{
+			Hostname:    ctx.Flags.String("hostname"),
+			ServiceName: serviceName,
+		},
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Started service %s\n", serviceName)
+}
+
+func stopService(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, serviceName string) {
+	_, err := rpc.StopService(context.Background(), &sliverpb.StopServiceReq{
+		ServiceInfo: &sliverpb.ServiceInfoReq{
+			Hostname:    ctx.Flags.String("hostname"),
+			ServiceName: serviceName,
+		},
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Stopped service %s\n", serviceName)
+}
+
+func removeService(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, serviceName string) {
+	_, err := rpc.RemoveService(context.Background(), &sliverpb.RemoveServiceReq{
+		ServiceInfo: &sliverpb.ServiceInfoReq{
+			Hostname:    ctx.Flags.String("hostname"),
+			ServiceName: serviceName,
+		},
+		Request: ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Deleted service %s\n", serviceName)
+}
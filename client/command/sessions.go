@@ -80,12 +80,30 @@ func sessions(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 			fmt.Printf(Warn+"Invalid session name or session number: %s\n", interact)
 		}
 	} else {
+		filter, err := newListingFilter(ctx)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		format, err := outputFormat(ctx)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		if format != tableFormat {
+			printFormatted(format, sessionsTable(sessions.GetSessions(), filter))
+			return
+		}
 		sessionsMap := map[uint32]*clientpb.Session{}
 		for _, session := range sessions.GetSessions() {
-			sessionsMap[session.ID] = session
+			if filter.Match(sessionListingTarget(session)) {
+				sessionsMap[session.ID] = session
+			}
 		}
 		if 0 < len(sessionsMap) {
 			printSessions(sessionsMap)
+		} else if !filter.IsEmpty() && 0 < len(sessions.GetSessions()) {
+			fmt.Printf(Info+"No sessions match the filters (%d total)\n", len(sessions.GetSessions()))
 		} else {
 			fmt.Printf(Info + "No sessions 🙁\n")
 		}
@@ -96,7 +114,7 @@ func sessions(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	So this method is a little more complex than you'd maybe think,
 	this is because Go's tabwriter aligns columns by counting bytes
 	and since we want to modify the color of the active sliver row
-	the number of bytes per row won't line up. So we render the table
+	and of the health column the number of bytes per row won't line up. So we render the table
 	into a buffer and note which row the active sliver is in. Then we
 	write each line to the term and insert the ANSI codes just before
 	we display the row.
@@ -106,16 +124,18 @@ func printSessions(sessions map[uint32]*clientpb.Session) {
 	table := tabwriter.NewWriter(outputBuf, 0, 2, 2, ' ', 0)
 
 	// Column Headers
-	fmt.Fprintln(table, "ID\tName\tTransport\tRemote Address\tHostname\tUsername\tOperating System\tLast Check-in\t")
-	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+	fmt.Fprintln(table, "ID\tHealth\tName\tTransport\tRemote Address\tHostname\tUsername\tOperating System\tLast Check-in\tTags\t")
+	fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
 		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Health")),
 		strings.Repeat("=", len("Name")),
 		strings.Repeat("=", len("Transport")),
 		strings.Repeat("=", len("Remote Address")),
 		strings.Repeat("=", len("Hostname")),
 		strings.Repeat("=", len("Username")),
 		strings.Repeat("=", len("Operating System")),
-		strings.Repeat("=", len("Last Check-in")))
+		strings.Repeat("=", len("Last Check-in")),
+		strings.Repeat("=", len("Tags")))
 
 	// Sort the keys because maps have a randomized order
 	var keys []int
@@ -125,13 +145,17 @@ func printSessions(sessions map[uint32]*clientpb.Session) {
 	sort.Ints(keys) // Fucking Go can't sort int32's, so we convert to/from int's
 
 	activeIndex := -1
+	healths := []string{}
 	for index, key := range keys {
 		session := sessions[uint32(key)]
 		if ActiveSession.Get() != nil && ActiveSession.Get().ID == session.ID {
 			activeIndex = index + 2 // Two lines for the headers
 		}
-		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+		health := sessionHealthStatus(session)
+		healths = append(healths, health)
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
 			session.ID,
+			health,
 			session.Name,
 			session.Transport,
 			session.RemoteAddress,
@@ -139,44 +163,112 @@ func printSessions(sessions map[uint32]*clientpb.Session) {
 			session.Username,
 			fmt.Sprintf("%s/%s", session.OS, session.Arch),
 			session.LastCheckin,
+			strings.Join(session.Tags, ","),
 		)
 	}
 	table.Flush()
 
-	if activeIndex != -1 {
-		lines := strings.Split(outputBuf.String(), "\n")
-		for lineNumber, line := range lines {
-			if len(line) == 0 {
-				continue
-			}
-			if lineNumber == activeIndex {
-				fmt.Printf("%s%s%s\n", green, line, normal)
-			} else {
-				fmt.Printf("%s\n", line)
-			}
+	lines := strings.Split(outputBuf.String(), "\n")
+	healthColumn := strings.Index(lines[0], "Health")
+	for lineNumber, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		lineColor := ""
+		if lineNumber == activeIndex {
+			lineColor = green
+		}
+		if 2 <= lineNumber {
+			health := healths[lineNumber-2]
+			line = line[:healthColumn] + healthColor(health) + health + normal + lineColor + line[healthColumn+len(health):]
+		}
+		if lineColor != "" {
+			fmt.Printf("%s%s%s\n", lineColor, line, normal)
+		} else {
+			fmt.Printf("%s\n", line)
 		}
-	} else {
-		fmt.Printf(outputBuf.String())
 	}
 }
 
+// sessionHealthStatus - Servers that predate health tracking don't send any
+func sessionHealthStatus(session *clientpb.Session) string {
+	if session.Health == nil || session.Health.Status == "" {
+		return "n/a"
+	}
+	return session.Health.Status
+}
+
+func healthColor(status string) string {
+	switch status {
+	case "green":
+		return green
+	case "yellow":
+		return orange
+	case "red":
+		return red
+	}
+	return normal
+}
+
 func use(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	if len(ctx.Args) == 0 {
 		fmt.Printf(Warn + "Missing sliver name or session number, see `help use`\n")
 		return
 	}
+	if ctx.Args[0] == "-" {
+		usePrevious(rpc)
+		return
+	}
 	session := GetSession(ctx.Args[0], rpc)
 	if session != nil {
 		ActiveSession.Set(session)
 		fmt.Printf(Info+"Active session %s (%d)\n", session.Name, session.ID)
-	} else {
-		fmt.Printf(Warn+"Invalid session name or session number '%s'\n", ctx.Args[0])
+		return
+	}
+	beacon := GetBeacon(ctx.Args[0], rpc)
+	if beacon != nil {
+		ActiveSession.SetBeacon(beacon)
+		fmt.Printf(Info+"Active beacon %s (%s), tasks run at its next check-in\n", beacon.Name, beacon.ID)
+		return
+	}
+	fmt.Printf(Warn+"Invalid session name, session number or beacon ID '%s'\n", ctx.Args[0])
+}
+
+// usePrevious - Switch back to the session or beacon that was active before,
+// it is looked up again since it may have been lost in the meantime
+func usePrevious(rpc rpcpb.SliverRPCClient) {
+	previousSession, previousBeacon := ActiveSession.Previous()
+	if previousSession == nil {
+		fmt.Printf(Warn + "No previous session or beacon\n")
+		return
+	}
+	if previousBeacon != nil {
+		beacon := GetBeacon(previousBeacon.ID, rpc)
+		if beacon == nil {
+			fmt.Printf(Warn+"Beacon %s (%s) no longer exists\n", previousBeacon.Name, previousBeacon.ID)
+			return
+		}
+		ActiveSession.SetBeacon(beacon)
+		fmt.Printf(Info+"Active beacon %s (%s), tasks run at its next check-in\n", beacon.Name, beacon.ID)
+		return
+	}
+	session := GetSession(fmt.Sprintf("%d", previousSession.ID), rpc)
+	if session == nil {
+		fmt.Printf(Warn+"Session %s (%d) has been lost\n", previousSession.Name, previousSession.ID)
+		return
 	}
+	ActiveSession.Set(session)
+	fmt.Printf(Info+"Active session %s (%d)\n", session.Name, session.ID)
 }
 
 func background(ctx *grumble.Context, _ rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		fmt.Printf(Warn + "No active session or beacon\n")
+		return
+	}
 	ActiveSession.Background()
-	fmt.Printf(Info + "Background ...\n")
+	fmt.Printf(Info+"Backgrounded %s, switch back with `use -`\n", session.Name)
 }
 
 func kill(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
@@ -185,12 +277,19 @@ func kill(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		return
 	}
 
-	err := killSession(session, rpc)
+	_, err := rpc.KillSession(context.Background(), &sliverpb.KillSessionReq{
+		Request: ActiveSession.Request(ctx),
+		Force:   ctx.Flags.Bool("force"),
+	})
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
 		return
 	}
-	fmt.Printf(Info+"Killed %s (%d)\n", session.Name, session.ID)
+	if ctx.Flags.Bool("force") {
+		fmt.Printf(Info+"Self-destructed %s, see loot for the report\n", session.Name)
+	} else {
+		fmt.Printf(Info+"Killed %s (%d)\n", session.Name, session.ID)
+	}
 	ActiveSession.Background()
 }
 
@@ -202,7 +301,6 @@ func killSession(session *clientpb.Session, rpc rpcpb.SliverRPCClient) error {
 		Request: &commonpb.Request{
 			SessionID: session.ID,
 		},
-		Force: true,
 	})
 	return err
 }
@@ -0,0 +1,92 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+)
+
+func socks5(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 1 {
+		listSocksProxies()
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listSocksProxies()
+	case "start":
+		startSocksProxy(ctx, rpc)
+	case "stop":
+		stopSocksProxy(ctx)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help socks5'")
+	}
+}
+
+func startSocksProxy(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	proxy, err := core.SocksProxies.Start(rpc, ActiveSession.Request(ctx), ctx.Flags.String("bind"))
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Started SOCKS5 proxy on %s\n", proxy.BindAddr)
+}
+
+func stopSocksProxy(ctx *grumble.Context) {
+	id := ctx.Flags.Int("id")
+	if id < 1 {
+		fmt.Println(Warn + "Must specify a valid --id")
+		return
+	}
+	if !core.SocksProxies.Stop(id) {
+		fmt.Printf(Warn+"No SOCKS5 proxy with id %d\n", id)
+		return
+	}
+	fmt.Printf(Info+"Stopped SOCKS5 proxy %d\n", id)
+}
+
+func listSocksProxies() {
+	proxies := core.SocksProxies.List()
+	if len(proxies) == 0 {
+		fmt.Printf(Info + "No SOCKS5 proxies\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "ID\tSession ID\tLocal Address\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("ID")),
+		strings.Repeat("=", len("Session ID")),
+		strings.Repeat("=", len("Local Address")))
+	for _, proxy := range proxies {
+		fmt.Fprintf(table, "%d\t%d\t%s\t\n", proxy.ID, proxy.SessionID, proxy.BindAddr)
+	}
+	table.Flush()
+}
@@ -0,0 +1,143 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+func runSSHCommand(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "Please specify a host, see 'help ssh'\n")
+		return
+	}
+	username := ctx.Flags.String("login")
+	hostname := ctx.Args[0]
+	if index := strings.LastIndex(hostname, "@"); index != -1 {
+		username = hostname[:index]
+		hostname = hostname[index+1:]
+	}
+	if username == "" {
+		fmt.Printf(Warn + "Please specify a username with --login or user@host\n")
+		return
+	}
+	var privKey []byte
+	if keyPath := ctx.Flags.String("private-key"); keyPath != "" {
+		var err error
+		privKey, err = ioutil.ReadFile(keyPath)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+	}
+	sshReq := &sliverpb.SSHCommandReq{
+		Hostname: hostname,
+		Port:     uint32(ctx.Flags.Int("port")),
+		Username: username,
+		Password: ctx.Flags.String("password"),
+		PrivKey:  privKey,
+		Command:  strings.Join(ctx.Args[1:], " "),
+		Request:  ActiveSession.Request(ctx),
+	}
+	if sshReq.Password == "" && len(sshReq.PrivKey) == 0 {
+		fmt.Printf(Warn + "Please specify a --password and/or a --private-key\n")
+		return
+	}
+
+	if sshReq.Command == "" {
+		runSSHShell(sshReq, rpc)
+		return
+	}
+	resp, err := rpc.RunSSHCommand(context.Background(), sshReq)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if resp.Response != nil && resp.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", resp.Response.Err)
+		return
+	}
+	log.Printf("SSH host key of %s: %s", hostname, resp.HostKey)
+	if resp.StdOut != "" {
+		fmt.Print(resp.StdOut)
+	}
+	if resp.StdErr != "" {
+		fmt.Printf(Warn+"Stderr:\n%s", resp.StdErr)
+	}
+	if resp.ExitCode != 0 {
+		fmt.Printf(Warn+"Exit code %d\n", resp.ExitCode)
+	}
+}
+
+func runSSHShell(sshReq *sliverpb.SSHCommandReq, rpc rpcpb.SliverRPCClient) {
+	fmt.Printf(Info + "Opening SSH tunnel (EOF to exit) ...\n\n")
+	rpcTunnel, err := rpc.CreateTunnel(context.Background(), &sliverpb.Tunnel{
+		SessionID: ActiveSession.Get().ID,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	tunnel := core.Tunnels.Start(rpcTunnel.TunnelID, rpcTunnel.SessionID)
+
+	sshReq.TunnelID = tunnel.ID
+	sshReq.Term = os.Getenv("TERM")
+	if cols, rows, err := terminal.GetSize(0); err == nil {
+		sshReq.Cols, sshReq.Rows = uint32(cols), uint32(rows)
+	}
+	resp, err := rpc.RunSSHCommand(context.Background(), sshReq)
+	if err == nil && resp.Response != nil && resp.Response.Err != "" {
+		err = fmt.Errorf("%s", resp.Response.Err)
+	}
+	if err != nil {
+		core.Tunnels.Close(tunnel.ID)
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Connected to %s (host key %s)\n\n", sshReq.Hostname, resp.HostKey)
+
+	oldState, err := terminal.MakeRaw(0)
+	if err != nil {
+		fmt.Printf(Warn + "Failed to save terminal state")
+		return
+	}
+	go func() {
+		io.Copy(os.Stdout, tunnel)
+	}()
+	io.Copy(tunnel, os.Stdin) // Returns on the first write after the tunnel closes
+	terminal.Restore(0, oldState)
+	fmt.Println("SSH session exited")
+}
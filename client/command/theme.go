@@ -0,0 +1,75 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/client/theme"
+
+	"github.com/desertbit/grumble"
+)
+
+// consoleTheme - List the themes, or apply one and save it for later runs
+func consoleTheme(ctx *grumble.Context) {
+	if len(ctx.Args) < 1 {
+		listThemes()
+		return
+	}
+	if theme.IsColorDisabled() {
+		fmt.Printf(Warn+"Colors are disabled (--no-color, %s, or output is not a terminal)\n", theme.NoColorEnvVar)
+		return
+	}
+	name := ctx.Args[0]
+	err := theme.Apply(name)
+	if err != nil {
+		fmt.Printf(Warn+"%s, see 'theme'\n", err)
+		return
+	}
+	settings := assets.GetSettings()
+	settings.Theme = name
+	err = assets.SaveSettings(settings)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save settings %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Switched to the %s theme\n", name)
+}
+
+func listThemes() {
+	current := theme.Current()
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tDescription\tPreview\t\n")
+	fmt.Fprintf(table, "====\t===========\t=======\t\n")
+	for _, name := range theme.Names() {
+		t := theme.Themes[name]
+		active := ""
+		if t == current {
+			active = " (active)"
+		}
+		// Escape codes have no width in the terminal, keep them out of the padded columns
+		preview := t.Bold + t.Cyan + "[*]" + t.Normal + " " + t.Red + "red" + t.Normal + " " +
+			t.Green + "green" + t.Normal + " " + t.Orange + "orange" + t.Normal + " " + t.Gray + "gray" + t.Normal
+		fmt.Fprintf(table, "%s%s\t%s\t%s\n", name, active, t.Description, preview)
+	}
+	table.Flush()
+}
@@ -0,0 +1,57 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bishopfox/sliver/client/assets"
+
+	"github.com/desertbit/grumble"
+)
+
+// consoleTimeout - Show or change how long commands wait on the implant by default
+func consoleTimeout(ctx *grumble.Context) {
+	settings := assets.GetSettings()
+	if len(ctx.Args) < 1 {
+		if 0 < settings.Timeout {
+			fmt.Printf(Info+"Commands wait %d second(s) for the implant unless run with --timeout\n", settings.Timeout)
+		} else {
+			fmt.Printf(Info+"Commands wait %d second(s) for the implant unless run with --timeout (built-in default)\n", defaultTimeout)
+		}
+		return
+	}
+	timeout, err := strconv.Atoi(ctx.Args[0])
+	if err != nil || timeout < 0 {
+		fmt.Printf(Warn+"Invalid timeout %s, must be a number of seconds\n", ctx.Args[0])
+		return
+	}
+	settings.Timeout = timeout
+	err = assets.SaveSettings(settings)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save settings %s\n", err)
+		return
+	}
+	if timeout == 0 {
+		fmt.Printf(Info+"Reset the default timeout to %d second(s)\n", defaultTimeout)
+	} else {
+		fmt.Printf(Info+"Commands now wait %d second(s) for the implant by default\n", timeout)
+	}
+}
@@ -0,0 +1,315 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/client/assets"
+	consts "github.com/bishopfox/sliver/client/constants"
+
+	"github.com/desertbit/grumble"
+)
+
+const (
+	// userAliasSeparator - Separates the commands of an alias on the command line
+	userAliasSeparator = ";"
+)
+
+var (
+	// Console commands that were added for a user alias, grumble can't remove
+	// commands so a removed alias keeps its command until the console restarts
+	userAliasCommands = map[string]bool{}
+
+	// Aliases currently running, an alias may not (indirectly) run itself
+	runningUserAliases = map[string]bool{}
+)
+
+func userAliases(ctx *grumble.Context) {
+	if len(ctx.Args) < 1 {
+		listUserAliases()
+		return
+	}
+	switch strings.ToLower(ctx.Args[0]) {
+	case "ls":
+		listUserAliases()
+	case "add":
+		addUserAlias(ctx)
+	case "rm":
+		removeUserAlias(ctx)
+	case "export":
+		exportUserAliases(ctx)
+	case "import":
+		importUserAliases(ctx)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help alias'")
+	}
+}
+
+func listUserAliases() {
+	aliases := assets.GetUserAliases()
+	if len(aliases) == 0 {
+		fmt.Printf(Info + "No aliases, see 'help alias'\n")
+		return
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintf(table, "Name\tCommands\tHelp\t\n")
+	fmt.Fprintf(table, "%s\t%s\t%s\t\n",
+		strings.Repeat("=", len("Name")),
+		strings.Repeat("=", len("Commands")),
+		strings.Repeat("=", len("Help")))
+	for _, alias := range aliases {
+		fmt.Fprintf(table, "%s\t%s\t%s\t\n", alias.Name, userAliasCommandLine(alias), alias.Help)
+	}
+	table.Flush()
+}
+
+func addUserAlias(ctx *grumble.Context) {
+	if len(ctx.Args) < 3 {
+		fmt.Printf(Warn + "Missing parameters, see 'help alias'\n")
+		return
+	}
+	alias := &assets.UserAlias{
+		Name:     ctx.Args[1],
+		Help:     ctx.Flags.String("description"),
+		Commands: parseUserAliasCommands(ctx.Args[2:]),
+	}
+	if len(alias.Commands) == 0 {
+		fmt.Printf(Warn + "Missing alias command, see 'help alias'\n")
+		return
+	}
+	for _, command := range alias.Commands {
+		if command[0] == alias.Name {
+			fmt.Printf(Warn+"Alias %s cannot run itself\n", alias.Name)
+			return
+		}
+	}
+	err := addUserAliasCommand(ctx.App, alias)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+
+	aliases := []*assets.UserAlias{}
+	for _, existing := range assets.GetUserAliases() {
+		if existing.Name != alias.Name {
+			aliases = append(aliases, existing)
+		}
+	}
+	err = assets.SaveUserAliases(append(aliases, alias))
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save aliases %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Added alias %s: %s\n", alias.Name, userAliasCommandLine(alias))
+}
+
+func removeUserAlias(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing alias name, see 'help alias'\n")
+		return
+	}
+	aliases := []*assets.UserAlias{}
+	for _, alias := range assets.GetUserAliases() {
+		if alias.Name != ctx.Args[1] {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == len(assets.GetUserAliases()) {
+		fmt.Printf(Warn+"No alias named %s\n", ctx.Args[1])
+		return
+	}
+	err := assets.SaveUserAliases(aliases)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save aliases %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Removed alias %s\n", ctx.Args[1])
+}
+
+// exportUserAliases - Write some or all aliases to a file other operators can import
+func exportUserAliases(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing export file path, see 'help alias'\n")
+		return
+	}
+	names := map[string]bool{}
+	for _, name := range ctx.Args[2:] {
+		names[name] = true
+	}
+	aliases := []*assets.UserAlias{}
+	for _, alias := range assets.GetUserAliases() {
+		if len(names) == 0 || names[alias.Name] {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		fmt.Printf(Warn + "No aliases to export\n")
+		return
+	}
+	err := assets.WriteUserAliases(ctx.Args[1], aliases)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Exported %d alias(es) to %s\n", len(aliases), ctx.Args[1])
+}
+
+// importUserAliases - Add the aliases of a shared file, replacing aliases with the same name
+func importUserAliases(ctx *grumble.Context) {
+	if len(ctx.Args) < 2 {
+		fmt.Printf(Warn + "Missing import file path, see 'help alias'\n")
+		return
+	}
+	if _, err := os.Stat(ctx.Args[1]); err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	imported := map[string]*assets.UserAlias{}
+	for _, alias := range assets.ReadUserAliases(ctx.Args[1]) {
+		if alias.Name == "" || len(alias.Commands) == 0 {
+			continue
+		}
+		err := addUserAliasCommand(ctx.App, alias)
+		if err != nil {
+			fmt.Printf(Warn+"Skipping alias %s: %s\n", alias.Name, err)
+			continue
+		}
+		imported[alias.Name] = alias
+	}
+	if len(imported) == 0 {
+		fmt.Printf(Warn + "No aliases imported\n")
+		return
+	}
+
+	aliases := []*assets.UserAlias{}
+	for _, existing := range assets.GetUserAliases() {
+		if _, ok := imported[existing.Name]; !ok {
+			aliases = append(aliases, existing)
+		}
+	}
+	for _, alias := range imported {
+		aliases = append(aliases, alias)
+	}
+	err := assets.SaveUserAliases(aliases)
+	if err != nil {
+		fmt.Printf(Warn+"Failed to save aliases %s\n", err)
+		return
+	}
+	fmt.Printf(Info+"Imported %d alias(es)\n", len(imported))
+}
+
+// parseUserAliasCommands - Split arguments into commands on ';', which may be
+// its own argument or trail one, e.g. "whoami ; getprivs" or "whoami; getprivs"
+func parseUserAliasCommands(args []string) [][]string {
+	commands := [][]string{}
+	command := []string{}
+	for _, arg := range args {
+		endOfCommand := strings.HasSuffix(arg, userAliasSeparator)
+		if arg = strings.TrimSuffix(arg, userAliasSeparator); arg != "" {
+			command = append(command, arg)
+		}
+		if endOfCommand && 0 < len(command) {
+			commands = append(commands, command)
+			command = []string{}
+		}
+	}
+	if 0 < len(command) {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+func userAliasCommandLine(alias *assets.UserAlias) string {
+	commands := []string{}
+	for _, command := range alias.Commands {
+		commands = append(commands, strings.Join(command, " "))
+	}
+	return strings.Join(commands, userAliasSeparator+" ")
+}
+
+// addUserAliasCommand - Add the console command of an alias, the alias is looked
+// up again each time the command runs so edits apply without a restart
+func addUserAliasCommand(app *grumble.App, alias *assets.UserAlias) error {
+	if userAliasCommands[alias.Name] {
+		return nil
+	}
+	if cmdExists(alias.Name, app) {
+		return fmt.Errorf("%s command already exists", alias.Name)
+	}
+	name := alias.Name
+	app.AddCommand(&grumble.Command{
+		Name:      name,
+		Help:      fmt.Sprintf("[alias] %s", alias.Help),
+		LongHelp:  fmt.Sprintf("Alias for: %s", userAliasCommandLine(alias)),
+		AllowArgs: true,
+		Run: func(ctx *grumble.Context) error {
+			return runUserAlias(ctx, name)
+		},
+		HelpGroup: consts.AliasHelpGroup,
+	})
+	userAliasCommands[name] = true
+	return nil
+}
+
+// runUserAlias - Run the commands of an alias in order, the arguments of the
+// alias are appended to its last command
+func runUserAlias(ctx *grumble.Context, name string) error {
+	var alias *assets.UserAlias
+	for _, userAlias := range assets.GetUserAliases() {
+		if userAlias.Name == name {
+			alias = userAlias
+		}
+	}
+	if alias == nil {
+		fmt.Printf(Warn+"Alias %s has been removed\n", name)
+		return nil
+	}
+	if runningUserAliases[name] {
+		fmt.Printf(Warn+"Alias %s runs itself, stopping\n", name)
+		return nil
+	}
+	runningUserAliases[name] = true
+	defer delete(runningUserAliases, name)
+
+	for index, command := range alias.Commands {
+		args := append([]string{}, command...)
+		if index == len(alias.Commands)-1 {
+			args = append(args, ctx.Args...)
+		}
+		err := ctx.App.RunCommand(args)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUserAliases - Add a console command for each of the operator's aliases
+func loadUserAliases(app *grumble.App) {
+	for _, alias := range assets.GetUserAliases() {
+		err := addUserAliasCommand(app, alias)
+		if err != nil {
+			fmt.Printf(Warn+"Failed to load alias %s: %s\n", alias.Name, err)
+		}
+	}
+}
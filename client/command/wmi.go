@@ -0,0 +1,175 @@
+package command
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/desertbit/grumble"
+)
+
+const (
+	// Results with more properties than this are printed as lists
+	maxWMITableColumns = 5
+)
+
+var (
+	// Win32_Process.Create return values
+	wmiCreateErrors = map[uint32]string{
+		2:  "Access denied",
+		3:  "Insufficient privilege",
+		8:  "Unknown failure",
+		9:  "Path not found",
+		21: "Invalid parameter",
+	}
+)
+
+func wmi(ctx *grumble.Context, rpc rpcpb.SliverRPCClient) {
+	session := ActiveSession.GetInteractive()
+	if session == nil {
+		return
+	}
+	if len(ctx.Args) < 2 {
+		fmt.Println(Warn + "Missing subcommand or argument, see 'help wmi'")
+		return
+	}
+	conn := &sliverpb.WMIConnection{
+		Host:      ctx.Flags.String("host"),
+		Namespace: strings.Replace(ctx.Flags.String("namespace"), "/", "\\", -1),
+		Username:  ctx.Flags.String("username"),
+		Password:  ctx.Flags.String("password"),
+		Domain:    ctx.Flags.String("domain"),
+	}
+	if conn.Username != "" && conn.Host == "" {
+		fmt.Println(Warn + "Credentials can only be used with a remote --host")
+		return
+	}
+	arg := strings.Join(ctx.Args[1:], " ")
+	switch strings.ToLower(ctx.Args[0]) {
+	case "query":
+		wmiQuery(ctx, rpc, conn, arg)
+	case "exec":
+		wmiExec(ctx, rpc, conn, arg)
+	default:
+		fmt.Println(Warn + "Invalid subcommand, see 'help wmi'")
+	}
+}
+
+func wmiQuery(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, conn *sliverpb.WMIConnection, query string) {
+	wmiQuery, err := rpc.WMIQuery(context.Background(), &sliverpb.WMIQueryReq{
+		Connection: conn,
+		Query:      query,
+		Request:    ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if wmiQuery.Response != nil && wmiQuery.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", wmiQuery.Response.Err)
+		return
+	}
+	if len(wmiQuery.Objects) == 0 {
+		fmt.Printf(Info + "No results\n")
+		return
+	}
+	if len(wmiQuery.Objects[0].Properties) <= maxWMITableColumns {
+		printWMITable(wmiQuery.Objects)
+	} else {
+		printWMIList(wmiQuery.Objects)
+	}
+}
+
+// printWMITable - Every object of a query's results has the same properties
+func printWMITable(objects []*sliverpb.WMIObject) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	headers := []string{}
+	underlines := []string{}
+	for _, property := range objects[0].Properties {
+		headers = append(headers, property.Name)
+		underlines = append(underlines, strings.Repeat("=", len(property.Name)))
+	}
+	fmt.Fprintf(table, "%s\t\n", strings.Join(headers, "\t"))
+	fmt.Fprintf(table, "%s\t\n", strings.Join(underlines, "\t"))
+	for _, object := range objects {
+		values := []string{}
+		for _, property := range object.Properties {
+			values = append(values, wmiValue(property))
+		}
+		fmt.Fprintf(table, "%s\t\n", strings.Join(values, "\t"))
+	}
+	table.Flush()
+}
+
+func printWMIList(objects []*sliverpb.WMIObject) {
+	for index, object := range objects {
+		if 0 < index {
+			fmt.Println()
+		}
+		table := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+		for _, property := range object.Properties {
+			fmt.Fprintf(table, "%s\t%s\t\n", property.Name, wmiValue(property))
+		}
+		table.Flush()
+	}
+}
+
+func wmiValue(property *sliverpb.WMIProperty) string {
+	if strings.HasSuffix(property.Type, "[]") {
+		return "{" + strings.Join(property.Values, ", ") + "}"
+	}
+	return strings.Join(property.Values, "")
+}
+
+func wmiExec(ctx *grumble.Context, rpc rpcpb.SliverRPCClient, conn *sliverpb.WMIConnection, commandLine string) {
+	wmiExec, err := rpc.WMIExec(context.Background(), &sliverpb.WMIExecReq{
+		Connection:  conn,
+		CommandLine: commandLine,
+		Directory:   ctx.Flags.String("directory"),
+		Request:     ActiveSession.Request(ctx),
+	})
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+		return
+	}
+	if wmiExec.Response != nil && wmiExec.Response.Err != "" {
+		fmt.Printf(Warn+"%s\n", wmiExec.Response.Err)
+		return
+	}
+	if wmiExec.ReturnValue != 0 {
+		reason, ok := wmiCreateErrors[wmiExec.ReturnValue]
+		if !ok {
+			reason = "Unknown error"
+		}
+		fmt.Printf(Warn+"Win32_Process.Create failed: %s (%d)\n", reason, wmiExec.ReturnValue)
+		return
+	}
+	if conn.Host != "" {
+		fmt.Printf(Info+"Started process %d on %s\n", wmiExec.Pid, conn.Host)
+	} else {
+		fmt.Printf(Info+"Started process %d\n", wmiExec.Pid)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/client/core"
 	"github.com/bishopfox/sliver/client/transport"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
 
@@ -30,6 +31,7 @@ import (
 
 // StartClientConsole - Start the client console
 func StartClientConsole() error {
+	applySavedTheme()
 	configs := assets.GetConfigs()
 	if len(configs) == 0 {
 		fmt.Printf(Warn+"No config files found at %s or -import\n", assets.GetConfigDir())
@@ -41,11 +43,18 @@ func StartClientConsole() error {
 	}
 
 	fmt.Printf(Info+"Connecting to %s:%d ...\n", config.LHost, config.LPort)
-	rpc, ln, err := transport.MTLSConnect(config)
+	_, ln, err := transport.MTLSConnect(config)
 	if err != nil {
 		fmt.Printf(Warn+"Connection to server failed %v", err)
 		return nil
 	}
-	defer ln.Close()
+	core.Servers.Add(config.Profile, config, ln)
+	defer func() {
+		for _, server := range core.Servers.All() {
+			server.Conn.Close()
+		}
+	}()
+	// Commands follow the active server, see the servers command
+	rpc := rpcpb.NewSliverRPCClient(core.Servers)
 	return Start(rpc, func(*grumble.App, rpcpb.SliverRPCClient) {})
 }
@@ -32,10 +32,12 @@ import (
 	cmd "github.com/bishopfox/sliver/client/command"
 	consts "github.com/bishopfox/sliver/client/constants"
 	"github.com/bishopfox/sliver/client/core"
+	"github.com/bishopfox/sliver/client/theme"
 	"github.com/bishopfox/sliver/client/version"
 	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
 
 	"time"
 
@@ -44,46 +46,80 @@ import (
 )
 
 const (
-	// ANSI Colors
-	normal    = "\033[0m"
-	black     = "\033[30m"
-	red       = "\033[31m"
-	green     = "\033[32m"
-	orange    = "\033[33m"
-	blue      = "\033[34m"
-	purple    = "\033[35m"
-	cyan      = "\033[36m"
-	gray      = "\033[37m"
-	bold      = "\033[1m"
-	clearln   = "\r\x1b[2K"
-	upN       = "\033[%dA"
-	downN     = "\033[%dB"
-	underline = "\033[4m"
+	// ANSI cursor controls, these don't change with the theme
+	clearln = "\r\x1b[2K"
+	upN     = "\033[%dA"
+	downN   = "\033[%dB"
+
+	// Number of commands kept in the history file across runs
+	historyLimit = 10000
+)
+
+var (
+	// ANSI Colors, set by the active theme
+	normal    string
+	black     string
+	red       string
+	green     string
+	orange    string
+	blue      string
+	purple    string
+	cyan      string
+	gray      string
+	bold      string
+	underline string
 
 	// Info - Display colorful information
-	Info = bold + cyan + "[*] " + normal
+	Info string
 	// Warn - Warn a user
-	Warn = bold + red + "[!] " + normal
+	Warn string
 	// Debug - Display debug information
-	Debug = bold + purple + "[-] " + normal
+	Debug string
 	// Woot - Display success
-	Woot = bold + green + "[$] " + normal
+	Woot string
 )
 
+func init() {
+	theme.OnApply(loadTheme)
+}
+
+func loadTheme(t *theme.Theme) {
+	normal = t.Normal
+	black = t.Black
+	red = t.Red
+	green = t.Green
+	orange = t.Orange
+	blue = t.Blue
+	purple = t.Purple
+	cyan = t.Cyan
+	gray = t.Gray
+	bold = t.Bold
+	underline = t.Underline
+
+	Info = bold + cyan + "[*] " + normal
+	Warn = bold + red + "[!] " + normal
+	Debug = bold + purple + "[-] " + normal
+	Woot = bold + green + "[$] " + normal
+}
+
 // ExtraCmds - Bind extra commands to the app object
 type ExtraCmds func(*grumble.App, rpcpb.SliverRPCClient)
 
 // Start - Console entrypoint
 func Start(rpc rpcpb.SliverRPCClient, extraCmds ExtraCmds) error {
+	applySavedTheme()
+	color.NoColor = theme.Current().IsPlain()
 	app := grumble.New(&grumble.Config{
 		Name:                  "Sliver",
 		Description:           "Sliver Client",
-		HistoryFile:           path.Join(assets.GetRootAppDir(), "history"),
+		HistoryFile:           path.Join(assets.GetRootAppDir(), assets.HistoryFileName),
+		HistoryLimit:          historyLimit,
 		Prompt:                getPrompt(),
 		PromptColor:           color.New(),
 		HelpHeadlineColor:     color.New(),
 		HelpHeadlineUnderline: true,
 		HelpSubCommands:       true,
+		NoColor:               theme.Current().IsPlain(),
 	})
 	app.SetPrintASCIILogo(func(app *grumble.App) {
 		printLogo(app, rpc)
@@ -95,9 +131,21 @@ func Start(rpc rpcpb.SliverRPCClient, extraCmds ExtraCmds) error {
 	cmd.ActiveSession.AddObserver(func(_ *clientpb.Session) {
 		app.SetPrompt(getPrompt())
 	})
+	theme.OnApply(func(_ *theme.Theme) {
+		app.SetPrompt(getPrompt())
+	})
 
-	go eventLoop(app, rpc)
-	go core.TunnelLoop(rpc)
+	if core.Servers.Active() == nil {
+		// The server's own console has a single connection
+		go eventLoop(app, "", rpc)
+		go core.TunnelLoop("", rpc)
+	} else {
+		core.Servers.OnConnect(func(server *core.ServerConn) {
+			go eventLoop(app, server.Name, server.RPC)
+			go core.TunnelLoop(server.Name, server.RPC)
+			app.SetPrompt(getPrompt())
+		})
+	}
 
 	err := app.Run()
 	if err != nil {
@@ -106,7 +154,24 @@ func Start(rpc rpcpb.SliverRPCClient, extraCmds ExtraCmds) error {
 	return err
 }
 
-func eventLoop(app *grumble.App, rpc rpcpb.SliverRPCClient) {
+// applySavedTheme - SLIVER_THEME overrides the theme saved with the `theme` command
+func applySavedTheme() {
+	name := os.Getenv(theme.ThemeEnvVar)
+	if name == "" {
+		name = assets.GetSettings().Theme
+	}
+	if name == "" {
+		return
+	}
+	err := theme.Apply(name)
+	if err != nil {
+		fmt.Printf(Warn+"%s\n", err)
+	}
+}
+
+// eventLoop - Print the events of a server, events of servers other than the
+// active one are tagged with the server's name and not passed on to commands
+func eventLoop(app *grumble.App, server string, rpc rpcpb.SliverRPCClient) {
 	eventStream, err := rpc.Events(context.Background(), &commonpb.Empty{})
 	if err != nil {
 		fmt.Printf(Warn+"%s\n", err)
@@ -119,12 +184,25 @@ func eventLoop(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 		if err == io.EOF || event == nil {
 			return
 		}
+		background := server != core.Servers.ActiveName()
+		prefix := ""
+		if background {
+			prefix = fmt.Sprintf("%s[%s]%s ", bold, server, normal)
+		} else {
+			cmd.ConsoleEvents.Publish(event)
+		}
+		cmd.Notify(server, event)
 
 		// Trigger event based on type
 		switch event.EventType {
 
+		case consts.ScreenshotFrameEvent:
+			// Frames are saved quietly, no need to redraw the prompt
+			cmd.SaveScreenshotFrame(event)
+			continue
+
 		case consts.CanaryEvent:
-			fmt.Printf(clearln+Warn+bold+"WARNING: %s%s has been burned (DNS Canary)\n", normal, event.Session.Name)
+			fmt.Printf(clearln+Warn+prefix+bold+"WARNING: %s%s has been burned (DNS Canary)\n", normal, event.Session.Name)
 			sessions := cmd.GetSessionsByName(event.Session.Name, rpc)
 			for _, session := range sessions {
 				fmt.Printf(clearln+"\t🔥 Session #%d is affected\n", session.ID)
@@ -132,26 +210,50 @@ func eventLoop(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 			fmt.Println()
 
 		case consts.JoinedEvent:
-			fmt.Printf(clearln+Info+"%s has joined the game\n\n", event.Client.Operator.Name)
+			fmt.Printf(clearln+Info+prefix+"%s has joined the game\n\n", event.Client.Operator.Name)
 		case consts.LeftEvent:
-			fmt.Printf(clearln+Info+"%s left the game\n\n", event.Client.Operator)
+			fmt.Printf(clearln+Info+prefix+"%s left the game\n\n", event.Client.Operator)
 
 		case consts.JobStoppedEvent:
 			job := event.Job
-			fmt.Printf(clearln+Warn+"Job #%d stopped (%s/%s)\n\n", job.ID, job.Protocol, job.Name)
+			fmt.Printf(clearln+Warn+prefix+"Job #%d stopped (%s/%s)\n\n", job.ID, job.Protocol, job.Name)
 
 		case consts.SessionOpenedEvent:
 			session := event.Session
 			currentTime := time.Now().Format(time.RFC1123)
-			fmt.Printf(clearln+Info+"Session #%d %s - %s (%s) - %s/%s - %v\n\n",
+			fmt.Printf(clearln+Info+prefix+"Session #%d %s - %s (%s) - %s/%s - %v\n\n",
 				session.ID, session.Name, session.RemoteAddress, session.Hostname, session.OS, session.Arch, currentTime)
+			if session.ProtocolVersion < sliverpb.ProtocolVersion {
+				fmt.Printf(clearln+Warn+prefix+"Session #%d speaks protocol v%d (current v%d), newer commands will be refused\n\n",
+					session.ID, session.ProtocolVersion, sliverpb.ProtocolVersion)
+			}
+
+		case consts.BeaconRegisteredEvent:
+			beacon := event.Beacon
+			currentTime := time.Now().Format(time.RFC1123)
+			fmt.Printf(clearln+Info+prefix+"Beacon %s %s - %s (%s) - %s/%s - %v\n\n",
+				beacon.ID, beacon.Name, beacon.RemoteAddress, beacon.Hostname, beacon.OS, beacon.Arch, currentTime)
+			if beacon.ProtocolVersion < sliverpb.ProtocolVersion {
+				fmt.Printf(clearln+Warn+prefix+"Beacon %s speaks protocol v%d (current v%d), newer commands will be refused\n\n",
+					beacon.ID, beacon.ProtocolVersion, sliverpb.ProtocolVersion)
+			}
+
+		case consts.BeaconTaskResultEvent:
+			beacon := event.Beacon
+			task := event.BeaconTask
+			fmt.Printf(clearln+Info+prefix+"Beacon %s completed task %s (%s)\n\n", beacon.Name, task.ID, task.Description)
+
+		case consts.SessionMigratedEvent:
+			session := event.Session
+			fmt.Printf(clearln+Info+prefix+"Session #%d %s migrated to pid %d (%s)\n\n",
+				session.ID, session.Name, session.PID, session.Filename)
 
 		case consts.SessionClosedEvent:
 			session := event.Session
-			fmt.Printf(clearln+Warn+"Lost session #%d %s - %s (%s) - %s/%s\n",
+			fmt.Printf(clearln+Warn+prefix+"Lost session #%d %s - %s (%s) - %s/%s\n",
 				session.ID, session.Name, session.RemoteAddress, session.Hostname, session.OS, session.Arch)
 			activeSession := cmd.ActiveSession.Get()
-			if activeSession != nil && activeSession.ID == session.ID {
+			if !background && activeSession != nil && activeSession.ID == session.ID {
 				cmd.ActiveSession.Set(nil)
 				app.SetPrompt(getPrompt())
 				fmt.Printf(Warn + " Active session disconnected\n")
@@ -166,8 +268,15 @@ func eventLoop(app *grumble.App, rpc rpcpb.SliverRPCClient) {
 
 func getPrompt() string {
 	prompt := underline + "sliver" + normal
-	if cmd.ActiveSession.Get() != nil {
-		prompt += fmt.Sprintf(bold+red+" (%s)%s", cmd.ActiveSession.Get().Name, normal)
+	if 1 < len(core.Servers.All()) {
+		prompt += gray + "@" + core.Servers.ActiveName() + normal
+	}
+	if session := cmd.ActiveSession.Get(); session != nil {
+		transport := session.Transport
+		if cmd.ActiveSession.GetBeacon() != nil {
+			transport = "beacon/" + transport
+		}
+		prompt += fmt.Sprintf(bold+red+" (%s)%s %s[%s]%s", session.Name, normal, cyan, transport, normal)
 	}
 	prompt += " > "
 	return prompt
@@ -185,7 +294,8 @@ func printLogo(sliverApp *grumble.App, rpc rpcpb.SliverRPCClient) {
 	serverSemVer := fmt.Sprintf("%d.%d.%d", serverVer.Major, serverVer.Minor, serverVer.Patch)
 
 	insecureRand.Seed(time.Now().Unix())
-	logo := asciiLogos[insecureRand.Intn(len(asciiLogos))]
+	logos := asciiLogos()
+	logo := logos[insecureRand.Intn(len(logos))]
 	fmt.Println(logo)
 	fmt.Println("All hackers gain " + abilities[insecureRand.Intn(len(abilities))])
 	fmt.Printf(Info+"Server v%s - %s%s\n", serverSemVer, serverVer.Commit, dirty)
@@ -252,8 +362,10 @@ var abilities = []string{
 	"jump-start",
 }
 
-var asciiLogos = []string{
-	red + `
+// asciiLogos - Built on demand since the colors depend on the theme
+func asciiLogos() []string {
+	return []string{
+		red + `
  	  ██████  ██▓     ██▓ ██▒   █▓▓█████  ██▀███
 	▒██    ▒ ▓██▒    ▓██▒▓██░   █▒▓█   ▀ ▓██ ▒ ██▒
 	░ ▓██▄   ▒██░    ▒██▒ ▓██  █▒░▒███   ▓██ ░▄█ ▒
@@ -265,7 +377,7 @@ var asciiLogos = []string{
 		  ░      ░  ░ ░        ░     ░  ░   ░
 ` + normal,
 
-	green + `
+		green + `
     ███████╗██╗     ██╗██╗   ██╗███████╗██████╗
     ██╔════╝██║     ██║██║   ██║██╔════╝██╔══██╗
     ███████╗██║     ██║██║   ██║█████╗  ██████╔╝
@@ -273,4 +385,5 @@ var asciiLogos = []string{
     ███████║███████╗██║ ╚████╔╝ ███████╗██║  ██║
     ╚══════╝╚══════╝╚═╝  ╚═══╝  ╚══════╝╚═╝  ╚═╝
 ` + normal,
+	}
 }
@@ -36,6 +36,19 @@ const (
 	SessionOpenedEvent = "connected"
 	// DisconnectedEvent - Sliver disconnected
 	SessionClosedEvent = "disconnected"
+	// SessionMigratedEvent - Sliver moved to another process
+	SessionMigratedEvent = "migrated"
+
+	// BeaconRegisteredEvent - First check-in of a beacon
+	BeaconRegisteredEvent = "beacon-registered"
+	// BeaconTaskResultEvent - A beacon returned the result of a task
+	BeaconTaskResultEvent = "beacon-taskresult"
+
+	// ScreenshotFrameEvent - A session streamed a screenshot frame
+	ScreenshotFrameEvent = "screenshot-frame"
+
+	// TransferProgressEvent - A large envelope is moving between the server and a session
+	TransferProgressEvent = "transfer-progress"
 
 	// JoinedEvent - Player joined the game
 	JoinedEvent = "joined"
@@ -59,19 +72,27 @@ const (
 	MultiplayerModeStr = "multiplayer"
 
 	SessionsStr   = "sessions"
+	TagStr        = "tag"
 	BackgroundStr = "background"
 	InfoStr       = "info"
 	UseStr        = "use"
 
 	GenerateStr        = "generate"
 	RegenerateStr      = "regenerate"
+	PatchStr           = "patch"
 	ProfileGenerateStr = "generate-profile"
 	StagerStr          = "stager"
 	ProfilesStr        = "profiles"
 	NewProfileStr      = "new-profile"
 
+	NetworkProfilesStr   = "network-profiles"
+	NewNetworkProfileStr = "new-network-profile"
+
 	ListSliverBuildsStr = "slivers"
 	ListCanariesStr     = "canaries"
+	BuildersStr         = "builders"
+	OperationalStr      = "operational"
+	FindBuildStr        = "find-build"
 
 	JobsStr        = "jobs"
 	MtlsStr        = "mtls"
@@ -80,6 +101,7 @@ const (
 	HttpsStr       = "https"
 	NamedPipeStr   = "named-pipe"
 	TCPListenerStr = "tcp-pivot"
+	PivotGraphStr  = "pivot-graph"
 
 	MsfStr       = "msf"
 	MsfInjectStr = "msf-inject"
@@ -89,24 +111,36 @@ const (
 	KillStr      = "kill"
 	TerminateStr = "terminate"
 
-	GetPIDStr = "getpid"
-	GetUIDStr = "getuid"
-	GetGIDStr = "getgid"
-	WhoamiStr = "whoami"
+	GetPIDStr   = "getpid"
+	GetUIDStr   = "getuid"
+	GetGIDStr   = "getgid"
+	WhoamiStr   = "whoami"
+	HostInfoStr = "hostinfo"
+	GetPrivsStr = "getprivs"
 
 	ShellStr   = "shell"
+	SSHStr     = "ssh"
 	ExecuteStr = "execute"
 
-	LsStr       = "ls"
-	RmStr       = "rm"
-	MkdirStr    = "mkdir"
-	CdStr       = "cd"
-	PwdStr      = "pwd"
-	CatStr      = "cat"
-	DownloadStr = "download"
-	UploadStr   = "upload"
-	IfconfigStr = "ifconfig"
-	NetstatStr  = "netstat"
+	LsStr        = "ls"
+	SearchStr    = "search"
+	HashStr      = "hash"
+	DrivesStr    = "drives"
+	RmStr        = "rm"
+	MkdirStr     = "mkdir"
+	ChmodStr     = "chmod"
+	ChownStr     = "chown"
+	TimestompStr = "timestomp"
+	CdStr        = "cd"
+	PwdStr       = "pwd"
+	CatStr       = "cat"
+	DownloadStr  = "download"
+	UploadStr    = "upload"
+	IfconfigStr  = "ifconfig"
+	NetstatStr   = "netstat"
+	ArpStr       = "arp"
+	CurlStr      = "curl"
+	PortScanStr  = "portscan"
 
 	ProcdumpStr         = "procdump"
 	ImpersonateStr      = "impersonate"
@@ -114,19 +148,59 @@ const (
 	ElevateStr          = "elevate"
 	GetSystemStr        = "getsystem"
 	RevToSelfStr        = "rev2self"
+	TokensStr           = "tokens"
+	StealTokenStr       = "steal-token"
+	MakeTokenStr        = "make-token"
+	RegistryStr         = "registry"
+	WMIStr              = "wmi"
+	ServicesStr         = "services"
+	PersistStr          = "persist"
+	BeaconsStr          = "beacons"
+	TasksStr            = "tasks"
+	ReconfigStr         = "reconfig"
+	GetEnvStr           = "getenv"
+	SetEnvStr           = "setenv"
+	UnsetEnvStr         = "unsetenv"
 	ExecuteAssemblyStr  = "execute-assembly"
+	PowerShellStr       = "powershell"
 	ExecuteShellcodeStr = "execute-shellcode"
 	MigrateStr          = "migrate"
 	SideloadStr         = "sideload"
 	SpawnDllStr         = "spawndll"
+	ExecutePEStr        = "execute-pe"
+	ExecuteBOFStr       = "execute-bof"
 	LoadExtensionStr    = "load-extension"
+	ArmoryStr           = "armory"
+	AliasStr            = "alias"
+	ThemeStr            = "theme"
+	ScriptStr           = "script"
+	ExportStr           = "export"
+	TimeoutStr          = "timeout"
+	TransfersStr        = "transfers"
+	BroadcastStr        = "broadcast"
+	ServersStr          = "servers"
+	NotifyStr           = "notify"
 	StageListenerStr    = "stage-listener"
 
 	WebsitesStr = "websites"
-
-	ScreenshotStr = "screenshot"
-	PsExecStr     = "psexec"
-	BackdoorStr   = "backdoor"
+	LootStr     = "loot"
+	CredsStr    = "creds"
+
+	ScreenshotStr      = "screenshot"
+	ScreenshotWatchStr = "screenshot-watch"
+	KeyloggerStr       = "keylogger"
+	ClipboardStr       = "clipboard"
+	WifiStr            = "wifi"
+	BrowserStr         = "browser"
+	HashdumpStr        = "hashdump"
+	LsassStr           = "lsass"
+	KerberosStr        = "kerberos"
+	LDAPStr            = "ldap"
+	PortfwdStr         = "portfwd"
+	RportfwdStr        = "rportfwd"
+	Socks5Str          = "socks5"
+	PsExecStr          = "psexec"
+	BackdoorStr        = "backdoor"
 )
 
 // Groups
@@ -136,4 +210,5 @@ const (
 	SliverWinHelpGroup   = "Sliver - Windows:"
 	MultiplayerHelpGroup = "Multiplayer:"
 	ExtensionHelpGroup   = "Sliver - 3rd Party extensions:"
+	AliasHelpGroup       = "Aliases:"
 )
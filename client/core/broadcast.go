@@ -0,0 +1,148 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+var (
+	// Broadcast - Records the request a console command sends to a session so it can
+	// be sent to other sessions, then replays their replies, see BroadcastInterceptor
+	Broadcast = &broadcast{}
+
+	// ErrBroadcastRecorded - Returned to the command instead of sending its request
+	ErrBroadcastRecorded = errors.New("Request recorded for broadcast")
+)
+
+// BroadcastCall - A session request recorded from a console command
+type BroadcastCall struct {
+	Method  string
+	Request proto.Message
+	Reply   proto.Message
+
+	conn *grpc.ClientConn
+	opts []grpc.CallOption
+}
+
+// Send - Send the recorded request to another session and return its reply
+func (c *BroadcastCall) Send(ctx context.Context, sessionID uint32) (proto.Message, error) {
+	req := proto.Clone(c.Request)
+	sessionRequest(req).SessionID = sessionID
+	reply := reflect.New(reflect.TypeOf(c.Reply).Elem()).Interface().(proto.Message)
+	err := c.conn.Invoke(ctx, c.Method, req, reply, c.opts...)
+	return reply, err
+}
+
+type broadcast struct {
+	mutex     sync.Mutex
+	recording bool
+	recorded  *BroadcastCall
+	replay    *broadcastReply
+}
+
+// broadcastReply - Returned once in place of the call to a session
+type broadcastReply struct {
+	method    string
+	sessionID uint32
+	reply     proto.Message
+	err       error
+}
+
+// Record - Call run and return the first session request it makes, none of its
+// session requests are sent. Returns nil if run didn't make one.
+func (b *broadcast) Record(run func()) *BroadcastCall {
+	b.mutex.Lock()
+	b.recording = true
+	b.recorded = nil
+	b.mutex.Unlock()
+	run()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.recording = false
+	return b.recorded
+}
+
+// Replay - Call run, its first call of the recorded method for sessionID returns reply
+// and err instead of being sent, any other request is sent as usual
+func (b *broadcast) Replay(call *BroadcastCall, sessionID uint32, reply proto.Message, err error, run func()) {
+	b.mutex.Lock()
+	b.replay = &broadcastReply{
+		method:    call.Method,
+		sessionID: sessionID,
+		reply:     reply,
+		err:       err,
+	}
+	b.mutex.Unlock()
+	run()
+	b.mutex.Lock()
+	b.replay = nil
+	b.mutex.Unlock()
+}
+
+// BroadcastInterceptor - Must be installed on the console's connection for Broadcast to work
+func BroadcastInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	request := sessionRequest(req)
+	if request == nil || request.SessionID == 0 {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	b := Broadcast
+	b.mutex.Lock()
+	if b.recording {
+		if b.recorded == nil {
+			b.recorded = &BroadcastCall{
+				Method:  method,
+				Request: proto.Clone(req.(proto.Message)),
+				Reply:   reply.(proto.Message),
+				conn:    cc,
+				opts:    opts,
+			}
+		}
+		b.mutex.Unlock()
+		return ErrBroadcastRecorded
+	}
+	replay := b.replay
+	if replay != nil && replay.method == method && replay.sessionID == request.SessionID {
+		b.replay = nil
+		b.mutex.Unlock()
+		if replay.err != nil {
+			return replay.err
+		}
+		reply.(proto.Message).Reset()
+		proto.Merge(reply.(proto.Message), replay.reply)
+		return nil
+	}
+	b.mutex.Unlock()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// sessionRequest - The request metadata of a message sent to a session, or nil
+func sessionRequest(msg interface{}) *commonpb.Request {
+	if req, ok := msg.(interface{ GetRequest() *commonpb.Request }); ok {
+		return req.GetRequest()
+	}
+	return nil
+}
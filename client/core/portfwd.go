@@ -0,0 +1,148 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+var (
+	// Portfwds - Holds refs to all local port forwards
+	Portfwds = &portfwds{
+		forwards: map[int]*Portfwd{},
+		mutex:    &sync.RWMutex{},
+	}
+	portfwdID = 0
+)
+
+// Portfwd - A local listener, each connection is tunneled to RemoteAddr via the implant
+type Portfwd struct {
+	ID         int
+	SessionID  uint32
+	BindAddr   string
+	RemoteAddr string
+
+	listener net.Listener
+}
+
+type portfwds struct {
+	forwards map[int]*Portfwd
+	mutex    *sync.RWMutex
+}
+
+// Start - Listen on bindAddr and forward connections to remoteHost:remotePort via the session
+func (p *portfwds) Start(rpc rpcpb.SliverRPCClient, request *commonpb.Request, bindAddr string, remoteHost string, remotePort uint32) (*Portfwd, error) {
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	portfwdID++
+	portfwd := &Portfwd{
+		ID:         portfwdID,
+		SessionID:  request.SessionID,
+		BindAddr:   listener.Addr().String(),
+		RemoteAddr: net.JoinHostPort(remoteHost, fmt.Sprintf("%d", remotePort)),
+		listener:   listener,
+	}
+	p.forwards[portfwd.ID] = portfwd
+	p.mutex.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				log.Printf("[portfwd] %d accept error %s", portfwd.ID, err)
+				return
+			}
+			go portfwd.forward(rpc, request, conn, remoteHost, remotePort)
+		}
+	}()
+	return portfwd, nil
+}
+
+func (f *Portfwd) forward(rpc rpcpb.SliverRPCClient, request *commonpb.Request, conn net.Conn, remoteHost string, remotePort uint32) {
+	defer conn.Close()
+	rpcTunnel, err := rpc.CreateTunnel(context.Background(), &sliverpb.Tunnel{
+		SessionID: f.SessionID,
+	})
+	if err != nil {
+		log.Printf("[portfwd] %d failed to create tunnel %s", f.ID, err)
+		return
+	}
+	tunnel := Tunnels.Start(rpcTunnel.TunnelID, rpcTunnel.SessionID)
+	_, err = rpc.Portfwd(context.Background(), &sliverpb.PortfwdReq{
+		Request:  request,
+		Host:     remoteHost,
+		Port:     remotePort,
+		TunnelID: tunnel.ID,
+	})
+	if err != nil {
+		log.Printf("[portfwd] %d failed to connect to %s: %s", f.ID, f.RemoteAddr, err)
+		rpc.CloseTunnel(context.Background(), rpcTunnel)
+		return
+	}
+	log.Printf("[portfwd] %d forwarding %s -> %s (tunnel %d)", f.ID, conn.RemoteAddr(), f.RemoteAddr, tunnel.ID)
+
+	go func() {
+		io.Copy(conn, tunnel)
+		conn.Close() // Remote end closed
+	}()
+	io.Copy(tunnel, conn)
+	if Tunnels.Get(tunnel.ID) != nil {
+		rpc.CloseTunnel(context.Background(), rpcTunnel)
+	}
+}
+
+// Stop - Stop listening, existing connections are left open until either end closes
+func (p *portfwds) Stop(id int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	portfwd, ok := p.forwards[id]
+	if !ok {
+		return false
+	}
+	portfwd.listener.Close()
+	delete(p.forwards, id)
+	return true
+}
+
+// List - All active port forwards, ordered by ID
+func (p *portfwds) List() []*Portfwd {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	forwards := []*Portfwd{}
+	for _, portfwd := range p.forwards {
+		forwards = append(forwards, portfwd)
+	}
+	sort.Slice(forwards, func(i, j int) bool {
+		return forwards[i].ID < forwards[j].ID
+	})
+	return forwards
+}
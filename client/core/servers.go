@@ -0,0 +1,175 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"google.golang.org/grpc"
+)
+
+var (
+	// Servers - Connections to teamservers, the console sends its requests to the active one
+	Servers = &servers{
+		conns: map[string]*ServerConn{},
+		mutex: &sync.RWMutex{},
+	}
+
+	// ErrNoServer - There is no active server connection
+	ErrNoServer = errors.New("Not connected to a server")
+	// ErrServerNotConnected - No connection to a server by that name
+	ErrServerNotConnected = errors.New("Not connected to that server")
+)
+
+// ServerConn - A connection to a teamserver, named after the profile it was made with
+type ServerConn struct {
+	Name   string
+	Config *assets.ClientConfig
+	Conn   *grpc.ClientConn
+
+	// RPC - Always talks to this server, unlike the console's client
+	RPC rpcpb.SliverRPCClient
+}
+
+type servers struct {
+	conns     map[string]*ServerConn
+	active    string
+	onConnect []func(*ServerConn)
+	mutex     *sync.RWMutex
+}
+
+// Add - Keep a new connection, the first one becomes the active server
+func (s *servers) Add(name string, config *assets.ClientConfig, conn *grpc.ClientConn) *ServerConn {
+	server := &ServerConn{
+		Name:   name,
+		Config: config,
+		Conn:   conn,
+		RPC:    rpcpb.NewSliverRPCClient(conn),
+	}
+	s.mutex.Lock()
+	s.conns[name] = server
+	if s.active == "" {
+		s.active = name
+	}
+	onConnect := append([]func(*ServerConn){}, s.onConnect...)
+	s.mutex.Unlock()
+	for _, callback := range onConnect {
+		callback(server)
+	}
+	return server
+}
+
+// OnConnect - Call callback for each connection, including the existing ones
+func (s *servers) OnConnect(callback func(*ServerConn)) {
+	s.mutex.Lock()
+	s.onConnect = append(s.onConnect, callback)
+	existing := []*ServerConn{}
+	for _, server := range s.conns {
+		existing = append(existing, server)
+	}
+	s.mutex.Unlock()
+	for _, server := range existing {
+		callback(server)
+	}
+}
+
+// Remove - Close a connection, the active server can't be removed
+func (s *servers) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	server, ok := s.conns[name]
+	if !ok {
+		return ErrServerNotConnected
+	}
+	if name == s.active {
+		return errors.New("Can't disconnect from the active server, switch to another one first")
+	}
+	delete(s.conns, name)
+	return server.Conn.Close()
+}
+
+// Get - A connection by name, nil if there isn't one
+func (s *servers) Get(name string) *ServerConn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.conns[name]
+}
+
+// All - All connections sorted by name
+func (s *servers) All() []*ServerConn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	all := []*ServerConn{}
+	for _, server := range s.conns {
+		all = append(all, server)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Active - The server the console is talking to, nil if not connected
+func (s *servers) Active() *ServerConn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.conns[s.active]
+}
+
+// ActiveName - The name of the active server, a console without connections (e.g.
+// the server's own console) only talks to one server and its name is empty
+func (s *servers) ActiveName() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.active
+}
+
+// SetActive - Send the console's requests to another connection
+func (s *servers) SetActive(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.conns[name]; !ok {
+		return ErrServerNotConnected
+	}
+	s.active = name
+	return nil
+}
+
+// Invoke - Implements grpc.ClientConnInterface, see rpcpb.NewSliverRPCClient
+func (s *servers) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	server := s.Active()
+	if server == nil {
+		return ErrNoServer
+	}
+	return server.Conn.Invoke(ctx, method, args, reply, opts...)
+}
+
+// NewStream - Implements grpc.ClientConnInterface, see rpcpb.NewSliverRPCClient
+func (s *servers) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	server := s.Active()
+	if server == nil {
+		return nil, ErrNoServer
+	}
+	return server.Conn.NewStream(ctx, desc, method, opts...)
+}
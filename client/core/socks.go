@@ -0,0 +1,144 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+var (
+	// SocksProxies - Holds refs to all local SOCKS5 listeners
+	SocksProxies = &socksProxies{
+		proxies: map[int]*SocksProxy{},
+		mutex:   &sync.RWMutex{},
+	}
+	socksProxyID = 0
+)
+
+// SocksProxy - A local listener, each connection gets its own tunnel and the
+// SOCKS5 handshake is answered by the implant
+type SocksProxy struct {
+	ID        int
+	SessionID uint32
+	BindAddr  string
+
+	listener net.Listener
+}
+
+type socksProxies struct {
+	proxies map[int]*SocksProxy
+	mutex   *sync.RWMutex
+}
+
+// Start - Listen on bindAddr and proxy SOCKS5 connections via the session
+func (s *socksProxies) Start(rpc rpcpb.SliverRPCClient, request *commonpb.Request, bindAddr string) (*SocksProxy, error) {
+	listener, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	socksProxyID++
+	proxy := &SocksProxy{
+		ID:        socksProxyID,
+		SessionID: request.SessionID,
+		BindAddr:  listener.Addr().String(),
+		listener:  listener,
+	}
+	s.proxies[proxy.ID] = proxy
+	s.mutex.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				log.Printf("[socks] %d accept error %s", proxy.ID, err)
+				return
+			}
+			go proxy.serve(rpc, request, conn)
+		}
+	}()
+	return proxy, nil
+}
+
+func (p *SocksProxy) serve(rpc rpcpb.SliverRPCClient, request *commonpb.Request, conn net.Conn) {
+	defer conn.Close()
+	rpcTunnel, err := rpc.CreateTunnel(context.Background(), &sliverpb.Tunnel{
+		SessionID: p.SessionID,
+	})
+	if err != nil {
+		log.Printf("[socks] %d failed to create tunnel %s", p.ID, err)
+		return
+	}
+	tunnel := Tunnels.Start(rpcTunnel.TunnelID, rpcTunnel.SessionID)
+	_, err = rpc.Socks(context.Background(), &sliverpb.SocksReq{
+		Request:  request,
+		TunnelID: tunnel.ID,
+	})
+	if err != nil {
+		log.Printf("[socks] %d failed to start: %s", p.ID, err)
+		rpc.CloseTunnel(context.Background(), rpcTunnel)
+		return
+	}
+	log.Printf("[socks] %d proxying %s (tunnel %d)", p.ID, conn.RemoteAddr(), tunnel.ID)
+
+	go func() {
+		io.Copy(conn, tunnel)
+		conn.Close() // Remote end closed
+	}()
+	io.Copy(tunnel, conn)
+	if Tunnels.Get(tunnel.ID) != nil {
+		rpc.CloseTunnel(context.Background(), rpcTunnel)
+	}
+}
+
+// Stop - Stop listening, existing connections are left open until either end closes
+func (s *socksProxies) Stop(id int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	proxy, ok := s.proxies[id]
+	if !ok {
+		return false
+	}
+	proxy.listener.Close()
+	delete(s.proxies, id)
+	return true
+}
+
+// List - All active SOCKS5 listeners, ordered by ID
+func (s *socksProxies) List() []*SocksProxy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	proxies := []*SocksProxy{}
+	for _, proxy := range s.proxies {
+		proxies = append(proxies, proxy)
+	}
+	sort.Slice(proxies, func(i, j int) bool {
+		return proxies[i].ID < proxies[j].ID
+	})
+	return proxies
+}
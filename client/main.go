@@ -27,6 +27,7 @@ import (
 
 	"github.com/bishopfox/sliver/client/assets"
 	"github.com/bishopfox/sliver/client/console"
+	"github.com/bishopfox/sliver/client/theme"
 	"github.com/bishopfox/sliver/client/version"
 )
 
@@ -37,8 +38,13 @@ const (
 func main() {
 	displayVersion := flag.Bool("version", false, "print version number")
 	config := flag.String("import", "", "import config file to ~/.sliver-client/configs")
+	noColor := flag.Bool("no-color", false, "disable console colors")
 	flag.Parse()
 
+	if *noColor {
+		theme.DisableColor()
+	}
+
 	if *displayVersion {
 		fmt.Printf("%s\n", version.FullVersion())
 		os.Exit(0)
@@ -96,12 +96,18 @@ func (s *Spinner) Reset() {
 
 // Until - Spint until ctrl channel signals
 func Until(msg string, ctrl chan bool) {
+	UntilWithStatus(msg, ctrl, func() string { return "" })
+}
+
+// UntilWithStatus - Spin until ctrl channel signals, status is shown after msg
+// (e.g. a progress bar) and may change between frames
+func UntilWithStatus(msg string, ctrl chan bool, status func() string) {
 	defer close(ctrl)
 	s := New()
 	for {
 		select {
 		case <-time.After(100 * time.Millisecond):
-			fmt.Printf(clearln+" %s  %s", s.Next(), msg)
+			fmt.Printf(clearln+" %s  %s %s", s.Next(), msg, status())
 		case <-ctrl:
 			fmt.Printf(clearln)
 			ctrl <- true
@@ -0,0 +1,201 @@
+package theme
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	// DefaultTheme - The theme used unless another one is selected
+	DefaultTheme = "default"
+	// PlainTheme - No colors or styles at all, see DisableColor
+	PlainTheme = "none"
+
+	// ThemeEnvVar - Selects a theme for a single run, overriding the saved theme
+	ThemeEnvVar = "SLIVER_THEME"
+	// NoColorEnvVar - Disables colors when set to any value, see https://no-color.org
+	NoColorEnvVar = "NO_COLOR"
+)
+
+// Theme - The ANSI escape codes used by the console, an empty code prints nothing
+type Theme struct {
+	Name        string
+	Description string
+
+	Normal    string
+	Black     string
+	Red       string
+	Green     string
+	Orange    string
+	Blue      string
+	Purple    string
+	Cyan      string
+	Gray      string
+	Bold      string
+	Underline string
+}
+
+// IsPlain - Does the theme print no escape codes at all
+func (t *Theme) IsPlain() bool {
+	return t.Name == PlainTheme
+}
+
+var (
+	// Themes - All available themes by name
+	Themes = map[string]*Theme{
+		DefaultTheme: {
+			Name:        DefaultTheme,
+			Description: "Colors for dark terminal backgrounds",
+			Normal:      "\033[0m",
+			Black:       "\033[30m",
+			Red:         "\033[31m",
+			Green:       "\033[32m",
+			Orange:      "\033[33m",
+			Blue:        "\033[34m",
+			Purple:      "\033[35m",
+			Cyan:        "\033[36m",
+			Gray:        "\033[37m",
+			Bold:        "\033[1m",
+			Underline:   "\033[4m",
+		},
+		"light": {
+			Name:        "light",
+			Description: "Colors for light terminal backgrounds",
+			Normal:      "\033[0m",
+			Black:       "\033[30m",
+			Red:         "\033[31m",
+			Green:       "\033[32m",
+			Orange:      "\033[35m",
+			Blue:        "\033[34m",
+			Purple:      "\033[35m",
+			Cyan:        "\033[34m",
+			Gray:        "\033[90m",
+			Bold:        "\033[1m",
+			Underline:   "\033[4m",
+		},
+		"high-contrast": {
+			Name:        "high-contrast",
+			Description: "Bright colors, for low contrast or exotic terminals",
+			Normal:      "\033[0m",
+			Black:       "\033[90m",
+			Red:         "\033[91m",
+			Green:       "\033[92m",
+			Orange:      "\033[93m",
+			Blue:        "\033[94m",
+			Purple:      "\033[95m",
+			Cyan:        "\033[96m",
+			Gray:        "\033[97m",
+			Bold:        "\033[1m",
+			Underline:   "\033[4m",
+		},
+		"mono": {
+			Name:        "mono",
+			Description: "Bold and underline only, for terminals without colors",
+			Normal:      "\033[0m",
+			Bold:        "\033[1m",
+			Underline:   "\033[4m",
+		},
+		PlainTheme: {
+			Name:        PlainTheme,
+			Description: "No escape codes, for logs, reports, and pipes",
+		},
+	}
+
+	current    = Themes[DefaultTheme]
+	forcePlain = false
+	observers  = []func(*Theme){}
+	mutex      = &sync.Mutex{}
+)
+
+func init() {
+	// Nothing reads colors from a pipe or a file
+	if os.Getenv(NoColorEnvVar) != "" || !terminal.IsTerminal(int(os.Stdout.Fd())) {
+		DisableColor()
+	}
+}
+
+// OnApply - Call observer with the current theme now and whenever it changes,
+// packages use this to (re)load their color codes
+func OnApply(observer func(*Theme)) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	observers = append(observers, observer)
+	observer(current)
+}
+
+// Current - The active theme
+func Current() *Theme {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return current
+}
+
+// Apply - Switch to a theme by name, this is a no-op if colors are disabled
+func Apply(name string) error {
+	theme, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("Unknown theme '%s'", name)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if forcePlain {
+		return nil
+	}
+	apply(theme)
+	return nil
+}
+
+// DisableColor - Switch to the plain theme for the rest of the run, e.g. --no-color
+func DisableColor() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	forcePlain = true
+	apply(Themes[PlainTheme])
+}
+
+// IsColorDisabled - Was DisableColor called, themes can't be applied if so
+func IsColorDisabled() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return forcePlain
+}
+
+// Names - Sorted names of all themes
+func Names() []string {
+	names := []string{}
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply - Must be called with the lock held
+func apply(theme *Theme) {
+	current = theme
+	for _, observer := range observers {
+		observer(theme)
+	}
+}
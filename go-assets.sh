@@ -20,12 +20,13 @@
 # Creates the static go asset archives
 # You'll need wget, tar, and unzip commands
 
-GO_VER="1.14.4"
+GO_VER="1.20.14"
 GO_ARCH="amd64"
 BLOAT_FILES="AUTHORS CONTRIBUTORS PATENTS VERSION favicon.ico robots.txt CONTRIBUTING.md LICENSE README.md ./doc ./test ./api ./misc"
 
 PROTOBUF_COMMIT=347cf4a86c1cb8d262994d8ef5924d4576c5b331
-GOLANG_SYS_COMMIT=669c56c373c468cbe0f0c12b7939832b26088d33
+GOLANG_SYS_VERSION=v0.14.0 # windows/arm64 support
+GOLANG_CRYPTO_COMMIT=4b2356b1ed79
 
 
 if ! [ -x "$(command -v wget)" ]; then
@@ -125,12 +126,18 @@ mv protobuf-$PROTOBUF_COMMIT protobuf
 zip -r protobuf.zip ./protobuf
 cp -vv protobuf.zip $REPO_DIR/assets/protobuf.zip
 
-wget -O $GOLANG_SYS_COMMIT.tar.gz https://github.com/golang/sys/archive/$GOLANG_SYS_COMMIT.tar.gz
-tar xfv $GOLANG_SYS_COMMIT.tar.gz
-rm -f $GOLANG_SYS_COMMIT.tar.gz
-mv sys-$GOLANG_SYS_COMMIT sys
+wget -O $GOLANG_SYS_VERSION.tar.gz https://github.com/golang/sys/archive/$GOLANG_SYS_VERSION.tar.gz
+tar xfv $GOLANG_SYS_VERSION.tar.gz
+rm -f $GOLANG_SYS_VERSION.tar.gz
+mv sys-* sys
 zip -r $REPO_DIR/assets/golang_x_sys.zip sys
 
+wget -O $GOLANG_CRYPTO_COMMIT.tar.gz https://github.com/golang/crypto/archive/$GOLANG_CRYPTO_COMMIT.tar.gz
+tar xfv $GOLANG_CRYPTO_COMMIT.tar.gz
+rm -f $GOLANG_CRYPTO_COMMIT.tar.gz
+mv crypto-$GOLANG_CRYPTO_COMMIT* crypto
+zip -r $REPO_DIR/assets/golang_x_crypto.zip crypto
+
 # end
 echo -e "clean up: $WORK_DIR"
 rm -rf $WORK_DIR
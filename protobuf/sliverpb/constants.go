@@ -22,6 +22,12 @@ import (
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
+// ProtocolVersion - Compiled into every implant and sent at registration, bump it
+// whenever the server starts sending something an older implant would misread
+// (new message types or envelope fields) and list the new types in the server's
+// protocol version table. Implants built before versioning report zero.
+const ProtocolVersion = uint32(3)
+
 // Message Name Constants
 
 const (
@@ -170,6 +176,180 @@ const (
 	MsgStopServiceReq
 	// MsgRemoveServiceReq - Request to remove a remote service
 	MsgRemoveServiceReq
+	// MsgKeyloggerReq - Start/stop the keylogger or flush its buffer
+	MsgKeyloggerReq
+	// MsgKeylogger - Keylogger status and flushed keystrokes
+	MsgKeylogger
+	// MsgKeylog - Keystrokes periodically flushed by the implant
+	MsgKeylog
+	// MsgPortfwdReq - Request the implant connect a tunnel to a host:port
+	MsgPortfwdReq
+	// MsgPortfwd - Response to a port forward request
+	MsgPortfwd
+	// MsgRportFwdStartReq - Start a reverse port forward listener
+	MsgRportFwdStartReq
+	// MsgRportFwdStopReq - Stop a reverse port forward listener
+	MsgRportFwdStopReq
+	// MsgRportFwdListReq - List reverse port forward listeners
+	MsgRportFwdListReq
+	// MsgRportFwd - Reverse port forward listener
+	MsgRportFwd
+	// MsgRportFwds - List of reverse port forward listeners
+	MsgRportFwds
+	// MsgRportFwdConn - Inbound connection on a reverse port forward listener
+	MsgRportFwdConn
+	// MsgRportFwdConnAck - Server ack of an inbound reverse port forward connection
+	MsgRportFwdConnAck
+	// MsgSocksReq - Start a SOCKS5 server on a tunnel
+	MsgSocksReq
+	// MsgSocks - SOCKS5 tunnel response
+	MsgSocks
+	// MsgListTokensReq - List the tokens held by processes
+	MsgListTokensReq
+	// MsgTokens - Token listing
+	MsgTokens
+	// MsgStealTokenReq - Impersonate the token of a process
+	MsgStealTokenReq
+	// MsgStealToken - Identity of the stolen token
+	MsgStealToken
+	// MsgMakeTokenReq - Create and impersonate a token from credentials
+	MsgMakeTokenReq
+	// MsgMakeToken - Identity of the created token
+	MsgMakeToken
+	// MsgRegistryReadReq - Read a registry value
+	MsgRegistryReadReq
+	// MsgRegistryRead - Registry value
+	MsgRegistryRead
+	// MsgRegistryWriteReq - Write a registry value
+	MsgRegistryWriteReq
+	// MsgRegistryWrite - Registry write result
+	MsgRegistryWrite
+	// MsgRegistryCreateKeyReq - Create a registry key
+	MsgRegistryCreateKeyReq
+	// MsgRegistryCreateKey - Registry key creation result
+	MsgRegistryCreateKey
+	// MsgRegistryDeleteReq - Delete a registry key or value
+	MsgRegistryDeleteReq
+	// MsgRegistryDelete - Registry delete result
+	MsgRegistryDelete
+	// MsgRegistryListReq - List the subkeys and values of a registry key
+	MsgRegistryListReq
+	// MsgRegistryList - Registry subkeys and values
+	MsgRegistryList
+	// MsgListServicesReq - List Windows services
+	MsgListServicesReq
+	// MsgServices - Windows service listing
+	MsgServices
+	// MsgQueryServiceReq - Query a Windows service
+	MsgQueryServiceReq
+	// MsgQueryService - Windows service details
+	MsgQueryService
+	// MsgCreateServiceReq - Create a Windows service without starting it
+	MsgCreateServiceReq
+	// MsgStartExistingServiceReq - Start an installed Windows service
+	MsgStartExistingServiceReq
+	// MsgPersistInstallReq - Install persistence
+	MsgPersistInstallReq
+	// MsgPersistInstall - Installed persistence recipe
+	MsgPersistInstall
+	// MsgPersistRemoveReq - Uninstall persistence
+	MsgPersistRemoveReq
+	// MsgPersistRemove - Persistence removal result
+	MsgPersistRemove
+	// MsgBeaconRegister - Beacon check-in
+	MsgBeaconRegister
+	// MsgBeaconTasks - Queued tasks, or the results of completed tasks
+	MsgBeaconTasks
+	// MsgReconfigReq - Change the implant's reconnect/beacon timing
+	MsgReconfigReq
+	// MsgReconfig - Reconfig result
+	MsgReconfig
+	// MsgSelfDestruct - The implant removed its artifacts and is exiting
+	MsgSelfDestruct
+	// MsgEnvReq - Get one or all environment variables
+	MsgEnvReq
+	// MsgEnvInfo - Environment variables
+	MsgEnvInfo
+	// MsgSetEnvReq - Set an environment variable
+	MsgSetEnvReq
+	// MsgSetEnv - Confirms an environment variable was set
+	MsgSetEnv
+	// MsgUnsetEnvReq - Unset an environment variable
+	MsgUnsetEnvReq
+	// MsgUnsetEnv - Confirms an environment variable was unset
+	MsgUnsetEnv
+	// MsgWhoamiReq - Request the implant's identity
+	MsgWhoamiReq
+	// MsgWhoami - The implant's identity, groups and privileges
+	MsgWhoami
+	// MsgChmodReq - Change the permissions of a file
+	MsgChmodReq
+	// MsgChownReq - Change the owner of a file
+	MsgChownReq
+	// MsgTimestompReq - Change the timestamps of a file
+	MsgTimestompReq
+	// MsgWMIQueryReq - Run a WMI query
+	MsgWMIQueryReq
+	// MsgWMIExecReq - Start a process via WMI
+	MsgWMIExecReq
+	// MsgExecutePEReq - Execute a PE in memory
+	MsgExecutePEReq
+	// MsgExecutePE - Output of an in-memory PE
+	MsgExecutePE
+	// MsgExecuteBOFReq - Run a Beacon Object File
+	MsgExecuteBOFReq
+	// MsgExecuteBOF - Output of a Beacon Object File
+	MsgExecuteBOF
+	// MsgRegisterExtensionReq - Load an extension into the implant
+	MsgRegisterExtensionReq
+	// MsgCallExtensionReq - Call an export of an extension
+	MsgCallExtensionReq
+	// MsgListExtensionsReq - List the loaded extensions
+	MsgListExtensionsReq
+	// MsgSSHCommandReq - Run a command or open a shell on an SSH server
+	MsgSSHCommandReq
+	// MsgSearchReq - Search the filesystem by name and/or content
+	MsgSearchReq
+	// MsgClipboardReq - Read or monitor the clipboard
+	MsgClipboardReq
+	// MsgClipboardLog - Clipboard entries periodically flushed by the implant
+	MsgClipboardLog
+	// MsgWifiReq - List saved wireless profiles and their keys
+	MsgWifiReq
+	// MsgBrowserReq - Collect browser profiles to recover saved logins and cookies
+	MsgBrowserReq
+	// MsgHashdumpReq - Collect the local account hashes
+	MsgHashdumpReq
+	// MsgLsassReq - Request a dump of LSASS
+	MsgLsassReq
+	// MsgScreenshotWatchReq - Start/stop streaming screenshots
+	MsgScreenshotWatchReq
+	// MsgScreenshotFrame - A screenshot frame pushed by the implant
+	MsgScreenshotFrame
+	// MsgHashReq - Hash remote files
+	MsgHashReq
+	// MsgDrivesReq - List logical drives and mount points
+	MsgDrivesReq
+	// MsgHostInfoReq - Request the host information
+	MsgHostInfoReq
+	// MsgPowerShellReq - Run a script in the hosted PowerShell runspace
+	MsgPowerShellReq
+	// MsgCurlReq - Issue an HTTP request from the implant
+	MsgCurlReq
+	// MsgPortScanReq - TCP connect scan from the implant
+	MsgPortScanReq
+	// MsgArpReq - Read the neighbor table
+	MsgArpReq
+	// MsgKerberosTicketsReq - List (and export) cached Kerberos tickets
+	MsgKerberosTicketsReq
+	// MsgKerberosInjectReq - Import a Kerberos ticket
+	MsgKerberosInjectReq
+	// MsgLDAPSearchReq - Run LDAP queries with the implant's token
+	MsgLDAPSearchReq
+	// MsgExecuteSignalReq - Control a streamed process
+	MsgExecuteSignalReq
+	// MsgCancelReq - A request timed out, the implant drops its result
+	MsgCancelReq
 )
 
 // MsgNumber - Get a message number of type
@@ -319,6 +499,178 @@ func MsgNumber(request proto.Message) uint32 {
 	case *RemoveServiceReq:
 		return MsgRemoveServiceReq
 
+	case *KeyloggerReq:
+		return MsgKeyloggerReq
+	case *Keylogger:
+		return MsgKeylogger
+	case *Keylog:
+		return MsgKeylog
+	case *PortfwdReq:
+		return MsgPortfwdReq
+	case *Portfwd:
+		return MsgPortfwd
+	case *RportFwdStartReq:
+		return MsgRportFwdStartReq
+	case *RportFwdStopReq:
+		return MsgRportFwdStopReq
+	case *RportFwdListReq:
+		return MsgRportFwdListReq
+	case *RportFwd:
+		return MsgRportFwd
+	case *RportFwds:
+		return MsgRportFwds
+	case *RportFwdConn:
+		return MsgRportFwdConn
+	case *SocksReq:
+		return MsgSocksReq
+	case *Socks:
+		return MsgSocks
+	case *ListTokensReq:
+		return MsgListTokensReq
+	case *Tokens:
+		return MsgTokens
+	case *StealTokenReq:
+		return MsgStealTokenReq
+	case *StealToken:
+		return MsgStealToken
+	case *MakeTokenReq:
+		return MsgMakeTokenReq
+	case *MakeToken:
+		return MsgMakeToken
+	case *RegistryReadReq:
+		return MsgRegistryReadReq
+	case *RegistryRead:
+		return MsgRegistryRead
+	case *RegistryWriteReq:
+		return MsgRegistryWriteReq
+	case *RegistryWrite:
+		return MsgRegistryWrite
+	case *RegistryCreateKeyReq:
+		return MsgRegistryCreateKeyReq
+	case *RegistryCreateKey:
+		return MsgRegistryCreateKey
+	case *RegistryDeleteReq:
+		return MsgRegistryDeleteReq
+	case *RegistryDelete:
+		return MsgRegistryDelete
+	case *RegistryListReq:
+		return MsgRegistryListReq
+	case *RegistryList:
+		return MsgRegistryList
+	case *ListServicesReq:
+		return MsgListServicesReq
+	case *Services:
+		return MsgServices
+	case *QueryServiceReq:
+		return MsgQueryServiceReq
+	case *QueryService:
+		return MsgQueryService
+	case *CreateServiceReq:
+		return MsgCreateServiceReq
+	case *StartExistingServiceReq:
+		return MsgStartExistingServiceReq
+	case *PersistInstallReq:
+		return MsgPersistInstallReq
+	case *PersistInstall:
+		return MsgPersistInstall
+	case *PersistRemoveReq:
+		return MsgPersistRemoveReq
+	case *PersistRemove:
+		return MsgPersistRemove
+	case *BeaconRegister:
+		return MsgBeaconRegister
+	case *BeaconTasks:
+		return MsgBeaconTasks
+	case *ReconfigReq:
+		return MsgReconfigReq
+	case *Reconfig:
+		return MsgReconfig
+	case *SelfDestruct:
+		return MsgSelfDestruct
+	case *EnvReq:
+		return MsgEnvReq
+	case *EnvInfo:
+		return MsgEnvInfo
+	case *SetEnvReq:
+		return MsgSetEnvReq
+	case *SetEnv:
+		return MsgSetEnv
+	case *UnsetEnvReq:
+		return MsgUnsetEnvReq
+	case *UnsetEnv:
+		return MsgUnsetEnv
+	case *WhoamiReq:
+		return MsgWhoamiReq
+	case *Whoami:
+		return MsgWhoami
+	case *ChmodReq:
+		return MsgChmodReq
+	case *ChownReq:
+		return MsgChownReq
+	case *TimestompReq:
+		return MsgTimestompReq
+	case *WMIQueryReq:
+		return MsgWMIQueryReq
+	case *WMIExecReq:
+		return MsgWMIExecReq
+	case *ExecutePEReq:
+		return MsgExecutePEReq
+	case *ExecutePE:
+		return MsgExecutePE
+	case *ExecuteBOFReq:
+		return MsgExecuteBOFReq
+	case *ExecuteBOF:
+		return MsgExecuteBOF
+	case *RegisterExtensionReq:
+		return MsgRegisterExtensionReq
+	case *CallExtensionReq:
+		return MsgCallExtensionReq
+	case *ListExtensionsReq:
+		return MsgListExtensionsReq
+	case *SSHCommandReq:
+		return MsgSSHCommandReq
+	case *SearchReq:
+		return MsgSearchReq
+	case *ClipboardReq:
+		return MsgClipboardReq
+	case *ClipboardLog:
+		return MsgClipboardLog
+	case *WifiReq:
+		return MsgWifiReq
+	case *BrowserReq:
+		return MsgBrowserReq
+	case *HashdumpReq:
+		return MsgHashdumpReq
+	case *LsassReq:
+		return MsgLsassReq
+	case *ScreenshotWatchReq:
+		return MsgScreenshotWatchReq
+	case *ScreenshotFrame:
+		return MsgScreenshotFrame
+	case *HashReq:
+		return MsgHashReq
+	case *DrivesReq:
+		return MsgDrivesReq
+	case *HostInfoReq:
+		return MsgHostInfoReq
+	case *PowerShellReq:
+		return MsgPowerShellReq
+	case *CurlReq:
+		return MsgCurlReq
+	case *PortScanReq:
+		return MsgPortScanReq
+	case *ArpReq:
+		return MsgArpReq
+	case *KerberosTicketsReq:
+		return MsgKerberosTicketsReq
+	case *KerberosInjectReq:
+		return MsgKerberosInjectReq
+	case *LDAPSearchReq:
+		return MsgLDAPSearchReq
+	case *ExecuteSignalReq:
+		return MsgExecuteSignalReq
+	case *CancelReq:
+		return MsgCancelReq
 	}
 	return uint32(0)
 }
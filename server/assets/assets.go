@@ -212,6 +212,10 @@ func SetupGoPath(goPathSrc string) error {
 	if err != nil {
 		setupLog.Fatalf("Failed to unzip go dependency: %v", err)
 	}
+	err = unzipGoDependency("golang_x_crypto.zip", golangXPath, assetsBox)
+	if err != nil {
+		setupLog.Fatalf("Failed to unzip go dependency: %v", err)
+	}
 
 	return nil
 }
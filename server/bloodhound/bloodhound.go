@@ -0,0 +1,733 @@
+package bloodhound
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// Collections
+const (
+	Domains   = "domains"
+	Users     = "users"
+	Computers = "computers"
+	Groups    = "groups"
+	OUs       = "ous"
+	GPOs      = "gpos"
+
+	// Trusts are collected with domains
+	trusts = "trusts"
+
+	// Data format version of BloodHound 4.x
+	version = 4
+	// CollectionMethod flags: Group | Trusts | Container | ObjectProps
+	methods = 1 | 32 | 128 | 512
+
+	// userAccountControl flags
+	uacAccountDisable           = 0x2
+	uacPasswordNotRequired      = 0x20
+	uacDontExpirePassword       = 0x10000
+	uacTrustedForDelegation     = 0x80000
+	uacNotDelegated             = 0x100000
+	uacDontRequirePreauth       = 0x400000
+	uacTrustedToAuthForDelegate = 0x1000000
+)
+
+// Collections - All supported collections in output order
+var Collections = []string{Domains, Users, Computers, Groups, OUs, GPOs}
+
+var queries = map[string]*sliverpb.LDAPQuery{
+	Domains: {
+		Filter: "(objectClass=*)",
+		Scope:  0,
+		Attributes: []string{"distinguishedName", "objectSid", "objectGUID", "description",
+			"msDS-Behavior-Version", "whenCreated", "gPLink", "gPOptions"},
+	},
+	trusts: {
+		Filter: "(objectClass=trustedDomain)",
+		Scope:  2,
+		Attributes: []string{"trustPartner", "securityIdentifier", "trustDirection",
+			"trustAttributes", "trustType"},
+	},
+	Users: {
+		Filter: "(samAccountType=805306368)",
+		Scope:  2,
+		Attributes: []string{"sAMAccountName", "distinguishedName", "objectSid", "description",
+			"displayName", "mail", "title", "homeDirectory", "adminCount", "userAccountControl",
+			"pwdLastSet", "lastLogon", "lastLogonTimestamp", "whenCreated", "servicePrincipalName",
+			"primaryGroupID", "sIDHistory", "msDS-AllowedToDelegateTo"},
+	},
+	Computers: {
+		Filter: "(samAccountType=805306369)",
+		Scope:  2,
+		Attributes: []string{"sAMAccountName", "dNSHostName", "distinguishedName", "objectSid",
+			"description", "userAccountControl", "pwdLastSet", "lastLogon", "lastLogonTimestamp",
+			"whenCreated", "servicePrincipalName", "operatingSystem", "primaryGroupID", "sIDHistory",
+			"msDS-AllowedToDelegateTo", "ms-Mcs-AdmPwdExpirationTime"},
+	},
+	Groups: {
+		Filter: "(objectClass=group)",
+		Scope:  2,
+		Attributes: []string{"sAMAccountName", "distinguishedName", "objectSid", "description",
+			"adminCount", "whenCreated", "member"},
+	},
+	OUs: {
+		Filter: "(objectClass=organizationalUnit)",
+		Scope:  2,
+		Attributes: []string{"name", "distinguishedName", "objectGUID", "description", "gPLink",
+			"gPOptions", "whenCreated"},
+	},
+	GPOs: {
+		Filter: "(objectClass=groupPolicyContainer)",
+		Scope:  2,
+		Attributes: []string{"displayName", "name", "distinguishedName", "objectGUID",
+			"gPCFileSysPath", "description", "whenCreated"},
+	},
+}
+
+// Groups that BloodHound marks as high value, by SID or by domain RID
+var highValueSIDs = []string{"S-1-5-32-544", "S-1-5-32-548", "S-1-5-32-549", "S-1-5-32-550", "S-1-5-32-551"}
+var highValueRIDs = []string{"-512", "-516", "-519"}
+
+var functionalLevels = map[int64]string{
+	0: "2000 Mixed/Native",
+	1: "2003 Interim",
+	2: "2003",
+	3: "2008",
+	4: "2008 R2",
+	5: "2012",
+	6: "2012 R2",
+	7: "2016",
+}
+
+// Queries - The LDAP queries needed by collections, all when empty. The domain
+// object is always queried since every other collection needs its SID.
+func Queries(collections []string) ([]*sliverpb.LDAPQuery, error) {
+	if len(collections) == 0 {
+		collections = Collections
+	}
+	wanted := map[string]bool{Domains: true}
+	for _, collection := range collections {
+		collection = strings.ToLower(collection)
+		if _, ok := queries[collection]; !ok || collection == trusts {
+			return nil, fmt.Errorf("unknown collection '%s' (valid: %s)", collection, strings.Join(Collections, ", "))
+		}
+		wanted[collection] = true
+	}
+	wanted[trusts] = wanted[Domains]
+	result := []*sliverpb.LDAPQuery{}
+	for _, name := range append(Collections, trusts) {
+		if !wanted[name] {
+			continue
+		}
+		query := *queries[name]
+		query.Name = name
+		result = append(result, &query)
+	}
+	return result, nil
+}
+
+// Data - The output of a collection
+type Data struct {
+	Collection string
+	Count      int
+	Err        string
+	JSON       []byte
+}
+
+type meta struct {
+	Methods int    `json:"methods"`
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Version int    `json:"version"`
+}
+
+type dataFile struct {
+	Data []interface{} `json:"data"`
+	Meta meta          `json:"meta"`
+}
+
+// TypedPrincipal - A reference to another object
+type TypedPrincipal struct {
+	ObjectIdentifier string
+	ObjectType       string
+}
+
+// Link - A GPO linked to a domain or OU
+type Link struct {
+	IsEnforced bool
+	GUID       string
+}
+
+// Trust - A domain trust
+type Trust struct {
+	TargetDomainSid     string
+	TargetDomainName    string
+	IsTransitive        bool
+	SidFilteringEnabled bool
+	TrustDirection      int64
+	TrustType           int64
+}
+
+// GPOChanges - Local group changes made by GPOs, not collected
+type GPOChanges struct {
+	LocalAdmins        []TypedPrincipal
+	RemoteDesktopUsers []TypedPrincipal
+	DcomUsers          []TypedPrincipal
+	PSRemoteUsers      []TypedPrincipal
+	AffectedComputers  []TypedPrincipal
+}
+
+// APIResult - Host based collection results, not collected over LDAP
+type APIResult struct {
+	Results       []TypedPrincipal
+	Collected     bool
+	FailureReason *string
+}
+
+// Base - The fields common to all objects
+type Base struct {
+	Properties       map[string]interface{}
+	Aces             []interface{}
+	ObjectIdentifier string
+	IsDeleted        bool
+	IsACLProtected   bool
+}
+
+// User - users.json
+type User struct {
+	Base
+	AllowedToDelegate []TypedPrincipal
+	SPNTargets        []interface{}
+	PrimaryGroupSID   string
+	HasSIDHistory     []TypedPrincipal
+}
+
+// Computer - computers.json
+type Computer struct {
+	Base
+	AllowedToDelegate  []TypedPrincipal
+	AllowedToAct       []TypedPrincipal
+	PrimaryGroupSID    string
+	HasSIDHistory      []TypedPrincipal
+	Sessions           APIResult
+	PrivilegedSessions APIResult
+	RegistrySessions   APIResult
+	LocalAdmins        APIResult
+	RemoteDesktopUsers APIResult
+	DcomUsers          APIResult
+	PSRemoteUsers      APIResult
+}
+
+// Group - groups.json
+type Group struct {
+	Base
+	Members []TypedPrincipal
+}
+
+// Domain - domains.json
+type Domain struct {
+	Base
+	ChildObjects []TypedPrincipal
+	Trusts       []Trust
+	Links        []Link
+	GPOChanges   GPOChanges
+}
+
+// OU - ous.json
+type OU struct {
+	Base
+	ChildObjects []TypedPrincipal
+	Links        []Link
+	GPOChanges   GPOChanges
+}
+
+// GPO - gpos.json
+type GPO struct {
+	Base
+}
+
+type collector struct {
+	domain    string
+	domainSID string
+	domainDN  string
+	results   map[string]*sliverpb.LDAPResult
+	objects   map[string][]*object
+	byDN      map[string]TypedPrincipal
+	byHost    map[string]TypedPrincipal
+	gpoByCN   map[string]string
+}
+
+// Build - Convert the results of Queries() into BloodHound JSON files
+func Build(search *sliverpb.LDAPSearch) (string, []*Data) {
+	c := &collector{
+		domainDN: search.DefaultNamingContext,
+		domain:   domainName(search.DefaultNamingContext),
+		results:  map[string]*sliverpb.LDAPResult{},
+		objects:  map[string][]*object{},
+		byDN:     map[string]TypedPrincipal{},
+		byHost:   map[string]TypedPrincipal{},
+		gpoByCN:  map[string]string{},
+	}
+	for _, result := range search.Results {
+		c.results[result.Name] = result
+		for _, entry := range result.Entries {
+			c.objects[result.Name] = append(c.objects[result.Name], newObject(entry))
+		}
+	}
+	c.index()
+
+	builders := map[string]func() []interface{}{
+		Domains:   c.domains,
+		Users:     c.users,
+		Computers: c.computers,
+		Groups:    c.groups,
+		OUs:       c.ous,
+		GPOs:      c.gpos,
+	}
+	data := []*Data{}
+	for _, collection := range Collections {
+		result, ok := c.results[collection]
+		if !ok {
+			continue
+		}
+		if result.Err != "" {
+			data = append(data, &Data{Collection: collection, Err: result.Err})
+			continue
+		}
+		objects := builders[collection]()
+		file := dataFile{
+			Data: objects,
+			Meta: meta{Methods: methods, Type: collection, Count: len(objects), Version: version},
+		}
+		buf, err := json.Marshal(file)
+		if err != nil {
+			data = append(data, &Data{Collection: collection, Err: err.Error()})
+			continue
+		}
+		data = append(data, &Data{Collection: collection, Count: len(objects), JSON: buf})
+	}
+	return c.domain, data
+}
+
+// Zip - Archive the collections the way SharpHound does, ready for import
+func Zip(data []*Data, timestamp time.Time) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for _, collection := range data {
+		if collection.JSON == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s_%s.json", timestamp.Format("20060102150405"), collection.Collection)
+		writer, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = writer.Write(collection.JSON); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// index - Resolve the domain SID and build the lookup tables used for
+// references between objects
+func (c *collector) index() {
+	for _, obj := range c.objects[Domains] {
+		c.domainSID = obj.sid("objectSid")
+	}
+	if c.domainSID == "" {
+		for _, name := range []string{Users, Computers, Groups} {
+			for _, obj := range c.objects[name] {
+				if sid := obj.sid("objectSid"); strings.HasPrefix(sid, "S-1-5-21-") {
+					c.domainSID = sid[:strings.LastIndex(sid, "-")]
+					break
+				}
+			}
+			if c.domainSID != "" {
+				break
+			}
+		}
+	}
+	types := map[string]string{Users: "User", Computers: "Computer", Groups: "Group"}
+	for name, objectType := range types {
+		for _, obj := range c.objects[name] {
+			principal := TypedPrincipal{ObjectIdentifier: c.objectSID(obj.sid("objectSid")), ObjectType: objectType}
+			c.byDN[strings.ToLower(obj.DN)] = principal
+			if name != Computers {
+				continue
+			}
+			host := strings.ToLower(obj.str("dNSHostName"))
+			if host != "" {
+				c.byHost[host] = principal
+				c.byHost[strings.SplitN(host, ".", 2)[0]] = principal
+			}
+			c.byHost[strings.ToLower(strings.TrimSuffix(obj.str("sAMAccountName"), "$"))] = principal
+		}
+	}
+	for _, obj := range c.objects[OUs] {
+		c.byDN[strings.ToLower(obj.DN)] = TypedPrincipal{ObjectIdentifier: obj.guid("objectGUID"), ObjectType: "OU"}
+	}
+	for _, obj := range c.objects[GPOs] {
+		guid := obj.guid("objectGUID")
+		c.byDN[strings.ToLower(obj.DN)] = TypedPrincipal{ObjectIdentifier: guid, ObjectType: "GPO"}
+		c.gpoByCN[strings.ToLower(obj.str("name"))] = guid
+	}
+}
+
+// objectSID - Well known SIDs are shared by all domains, BloodHound prefixes
+// them with the domain name
+func (c *collector) objectSID(sid string) string {
+	if sid != "" && !strings.HasPrefix(sid, "S-1-5-21-") {
+		return c.domain + "-" + sid
+	}
+	return sid
+}
+
+func (c *collector) base(obj *object, identifier string, name string) Base {
+	return Base{
+		Properties: map[string]interface{}{
+			"name":              name,
+			"domain":            c.domain,
+			"domainsid":         c.domainSID,
+			"objectid":          identifier,
+			"distinguishedname": strings.ToUpper(obj.DN),
+			"description":       nullable(obj.str("description")),
+			"whencreated":       obj.generalizedTime("whenCreated"),
+			"highvalue":         false,
+		},
+		Aces:             []interface{}{},
+		ObjectIdentifier: identifier,
+	}
+}
+
+func (c *collector) domains() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[Domains] {
+		domain := &Domain{
+			Base:         c.base(obj, c.domainSID, c.domain),
+			ChildObjects: c.children(obj.DN),
+			Trusts:       c.trusts(),
+			Links:        c.links(obj.str("gPLink")),
+			GPOChanges:   emptyGPOChanges(),
+		}
+		domain.Properties["highvalue"] = true
+		domain.Properties["functionallevel"] = functionalLevels[obj.int("msDS-Behavior-Version")]
+		result = append(result, domain)
+	}
+	return result
+}
+
+func (c *collector) trusts() []Trust {
+	result := []Trust{}
+	for _, obj := range c.objects[trusts] {
+		attributes := obj.int("trustAttributes")
+		result = append(result, Trust{
+			TargetDomainSid:  obj.sid("securityIdentifier"),
+			TargetDomainName: strings.ToUpper(obj.str("trustPartner")),
+			// TRUST_ATTRIBUTE_NON_TRANSITIVE
+			IsTransitive: attributes&0x1 == 0,
+			// TRUST_ATTRIBUTE_QUARANTINED_DOMAIN
+			SidFilteringEnabled: attributes&0x4 != 0,
+			TrustDirection:      obj.int("trustDirection"),
+			TrustType:           trustType(attributes),
+		})
+	}
+	return result
+}
+
+// trustType - BloodHound's ParentChild 0, CrossLink 1, Forest 2, External 3
+func trustType(attributes int64) int64 {
+	switch {
+	case attributes&0x20 != 0: // WITHIN_FOREST
+		return 0
+	case attributes&0x8 != 0: // FOREST_TRANSITIVE
+		return 2
+	}
+	return 3
+}
+
+func (c *collector) users() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[Users] {
+		sid := c.objectSID(obj.sid("objectSid"))
+		name := strings.ToUpper(obj.str("sAMAccountName")) + "@" + c.domain
+		uac := obj.int("userAccountControl")
+		spns := obj.strs("servicePrincipalName")
+		user := &User{
+			Base:              c.base(obj, sid, name),
+			AllowedToDelegate: c.delegationTargets(obj),
+			SPNTargets:        []interface{}{},
+			PrimaryGroupSID:   c.primaryGroup(obj),
+			HasSIDHistory:     c.sidHistory(obj),
+		}
+		props := user.Properties
+		props["samaccountname"] = obj.str("sAMAccountName")
+		props["displayname"] = nullable(obj.str("displayName"))
+		props["email"] = nullable(obj.str("mail"))
+		props["title"] = nullable(obj.str("title"))
+		props["homedirectory"] = nullable(obj.str("homeDirectory"))
+		props["admincount"] = obj.int("adminCount") == 1
+		props["enabled"] = uac&uacAccountDisable == 0
+		props["pwdlastset"] = obj.fileTime("pwdLastSet")
+		props["lastlogon"] = obj.fileTime("lastLogon")
+		props["lastlogontimestamp"] = obj.fileTime("lastLogonTimestamp")
+		props["serviceprincipalnames"] = spns
+		props["hasspn"] = 0 < len(spns)
+		props["dontreqpreauth"] = uac&uacDontRequirePreauth != 0
+		props["passwordnotreqd"] = uac&uacPasswordNotRequired != 0
+		props["pwdneverexpires"] = uac&uacDontExpirePassword != 0
+		props["unconstraineddelegation"] = uac&uacTrustedForDelegation != 0
+		props["sensitive"] = uac&uacNotDelegated != 0
+		props["trustedtoauth"] = uac&uacTrustedToAuthForDelegate != 0
+		props["allowedtodelegate"] = obj.strs("msDS-AllowedToDelegateTo")
+		props["sidhistory"] = obj.sids("sIDHistory")
+		result = append(result, user)
+	}
+	return result
+}
+
+func (c *collector) computers() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[Computers] {
+		sid := c.objectSID(obj.sid("objectSid"))
+		name := strings.ToUpper(obj.str("dNSHostName"))
+		if name == "" {
+			name = strings.ToUpper(strings.TrimSuffix(obj.str("sAMAccountName"), "$")) + "." + c.domain
+		}
+		uac := obj.int("userAccountControl")
+		computer := &Computer{
+			Base:               c.base(obj, sid, name),
+			AllowedToDelegate:  c.delegationTargets(obj),
+			AllowedToAct:       []TypedPrincipal{},
+			PrimaryGroupSID:    c.primaryGroup(obj),
+			HasSIDHistory:      c.sidHistory(obj),
+			Sessions:           emptyAPIResult(),
+			PrivilegedSessions: emptyAPIResult(),
+			RegistrySessions:   emptyAPIResult(),
+			LocalAdmins:        emptyAPIResult(),
+			RemoteDesktopUsers: emptyAPIResult(),
+			DcomUsers:          emptyAPIResult(),
+			PSRemoteUsers:      emptyAPIResult(),
+		}
+		props := computer.Properties
+		props["samaccountname"] = obj.str("sAMAccountName")
+		props["enabled"] = uac&uacAccountDisable == 0
+		props["unconstraineddelegation"] = uac&uacTrustedForDelegation != 0
+		props["trustedtoauth"] = uac&uacTrustedToAuthForDelegate != 0
+		props["pwdlastset"] = obj.fileTime("pwdLastSet")
+		props["lastlogon"] = obj.fileTime("lastLogon")
+		props["lastlogontimestamp"] = obj.fileTime("lastLogonTimestamp")
+		props["serviceprincipalnames"] = obj.strs("servicePrincipalName")
+		props["operatingsystem"] = nullable(obj.str("operatingSystem"))
+		props["haslaps"] = obj.has("ms-Mcs-AdmPwdExpirationTime")
+		props["allowedtodelegate"] = obj.strs("msDS-AllowedToDelegateTo")
+		props["sidhistory"] = obj.sids("sIDHistory")
+		result = append(result, computer)
+	}
+	return result
+}
+
+func (c *collector) groups() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[Groups] {
+		sid := c.objectSID(obj.sid("objectSid"))
+		name := strings.ToUpper(obj.str("sAMAccountName")) + "@" + c.domain
+		group := &Group{Base: c.base(obj, sid, name), Members: []TypedPrincipal{}}
+		for _, member := range obj.strs("member") {
+			group.Members = append(group.Members, c.resolve(member))
+		}
+		group.Properties["samaccountname"] = obj.str("sAMAccountName")
+		group.Properties["admincount"] = obj.int("adminCount") == 1
+		group.Properties["highvalue"] = isHighValue(sid)
+		result = append(result, group)
+	}
+	return result
+}
+
+func (c *collector) ous() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[OUs] {
+		guid := obj.guid("objectGUID")
+		name := strings.ToUpper(obj.str("name")) + "@" + c.domain
+		ou := &OU{
+			Base:         c.base(obj, guid, name),
+			ChildObjects: c.children(obj.DN),
+			Links:        c.links(obj.str("gPLink")),
+			GPOChanges:   emptyGPOChanges(),
+		}
+		// GPO_BLOCK_INHERITANCE
+		ou.Properties["blocksinheritance"] = obj.int("gPOptions")&1 != 0
+		result = append(result, ou)
+	}
+	return result
+}
+
+func (c *collector) gpos() []interface{} {
+	result := []interface{}{}
+	for _, obj := range c.objects[GPOs] {
+		guid := obj.guid("objectGUID")
+		name := strings.ToUpper(obj.str("displayName")) + "@" + c.domain
+		gpo := &GPO{Base: c.base(obj, guid, name)}
+		gpo.Properties["gpcpath"] = strings.ToUpper(obj.str("gPCFileSysPath"))
+		result = append(result, gpo)
+	}
+	return result
+}
+
+// resolve - Map a DN to a collected object, foreign security principals are
+// named by their SID
+func (c *collector) resolve(dn string) TypedPrincipal {
+	if principal, ok := c.byDN[strings.ToLower(dn)]; ok {
+		return principal
+	}
+	if strings.Contains(strings.ToUpper(dn), ",CN=FOREIGNSECURITYPRINCIPALS,") {
+		sid := firstRDNValue(dn)
+		return TypedPrincipal{ObjectIdentifier: c.objectSID(sid), ObjectType: "Base"}
+	}
+	return TypedPrincipal{ObjectIdentifier: strings.ToUpper(dn), ObjectType: "Base"}
+}
+
+// children - Collected objects directly below a container
+func (c *collector) children(dn string) []TypedPrincipal {
+	children := []TypedPrincipal{}
+	dn = strings.ToLower(dn)
+	keys := []string{}
+	for key := range c.byDN {
+		if parentDN(key) == dn {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if c.byDN[key].ObjectType != "GPO" {
+			children = append(children, c.byDN[key])
+		}
+	}
+	return children
+}
+
+// links - Parse a gPLink, [LDAP://cn={GUID},cn=policies,...;options] where
+// option 1 disables the link and 2 enforces it
+func (c *collector) links(gpLink string) []Link {
+	links := []Link{}
+	for _, link := range strings.Split(gpLink, "[") {
+		link = strings.TrimSuffix(link, "]")
+		index := strings.LastIndex(link, ";")
+		if index < 0 {
+			continue
+		}
+		options := strings.TrimSpace(link[index+1:])
+		if options == "1" || options == "3" {
+			continue
+		}
+		dn := strings.TrimPrefix(link[:index], "LDAP://")
+		dn = strings.TrimPrefix(dn, "ldap://")
+		cn := strings.ToLower(firstRDNValue(dn))
+		guid, ok := c.gpoByCN[cn]
+		if !ok {
+			guid = strings.ToUpper(strings.Trim(cn, "{}"))
+		}
+		links = append(links, Link{IsEnforced: options == "2", GUID: guid})
+	}
+	return links
+}
+
+func (c *collector) primaryGroup(obj *object) string {
+	if c.domainSID == "" || !obj.has("primaryGroupID") {
+		return ""
+	}
+	return fmt.Sprintf("%s-%d", c.domainSID, obj.int("primaryGroupID"))
+}
+
+func (c *collector) sidHistory(obj *object) []TypedPrincipal {
+	history := []TypedPrincipal{}
+	for _, sid := range obj.sids("sIDHistory") {
+		history = append(history, TypedPrincipal{ObjectIdentifier: c.objectSID(sid), ObjectType: "Base"})
+	}
+	return history
+}
+
+// delegationTargets - Constrained delegation SPNs (service/host[:port]) that
+// resolve to a collected computer
+func (c *collector) delegationTargets(obj *object) []TypedPrincipal {
+	targets := []TypedPrincipal{}
+	seen := map[string]bool{}
+	for _, spn := range obj.strs("msDS-AllowedToDelegateTo") {
+		parts := strings.SplitN(spn, "/", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		host := strings.ToLower(strings.SplitN(parts[1], ":", 2)[0])
+		principal, ok := c.byHost[host]
+		if ok && !seen[principal.ObjectIdentifier] {
+			seen[principal.ObjectIdentifier] = true
+			targets = append(targets, principal)
+		}
+	}
+	return targets
+}
+
+func isHighValue(sid string) bool {
+	for _, suffix := range highValueSIDs {
+		if strings.HasSuffix(sid, suffix) {
+			return true
+		}
+	}
+	if strings.Contains(sid, "S-1-5-21-") {
+		for _, rid := range highValueRIDs {
+			if strings.HasSuffix(sid, rid) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func emptyGPOChanges() GPOChanges {
+	return GPOChanges{
+		LocalAdmins:        []TypedPrincipal{},
+		RemoteDesktopUsers: []TypedPrincipal{},
+		DcomUsers:          []TypedPrincipal{},
+		PSRemoteUsers:      []TypedPrincipal{},
+		AffectedComputers:  []TypedPrincipal{},
+	}
+}
+
+func emptyAPIResult() APIResult {
+	return APIResult{Results: []TypedPrincipal{}}
+}
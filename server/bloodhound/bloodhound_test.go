@@ -0,0 +1,245 @@
+package bloodhound
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	testNC        = "DC=corp,DC=local"
+	testDomainSID = "S-1-5-21-1004336348-1177238915-682003330"
+)
+
+func binarySID(authority byte, subs ...uint32) []byte {
+	sid := []byte{1, byte(len(subs)), 0, 0, 0, 0, 0, authority}
+	for _, sub := range subs {
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, sub)
+		sid = append(sid, buf...)
+	}
+	return sid
+}
+
+func domainSID(rid uint32) []byte {
+	return binarySID(5, 21, 1004336348, 1177238915, 682003330, rid)
+}
+
+func entry(dn string, attrs ...interface{}) *sliverpb.LDAPEntry {
+	result := &sliverpb.LDAPEntry{DN: dn}
+	for index := 0; index < len(attrs); index += 2 {
+		attr := &sliverpb.LDAPAttribute{Name: attrs[index].(string)}
+		switch value := attrs[index+1].(type) {
+		case string:
+			attr.Values = [][]byte{[]byte(value)}
+		case []byte:
+			attr.Values = [][]byte{value}
+		case []string:
+			for _, v := range value {
+				attr.Values = append(attr.Values, []byte(v))
+			}
+		}
+		result.Attributes = append(result.Attributes, attr)
+	}
+	return result
+}
+
+func testSearch() *sliverpb.LDAPSearch {
+	gpoGUID := []byte{0xa2, 0x31, 0xb2, 0x31, 0x6d, 0x01, 0xd2, 0x11, 0x94, 0x5f, 0x00, 0xc0, 0x4f, 0xb9, 0x84, 0xf9}
+	ouGUID := bytes.Repeat([]byte{0x11}, 16)
+	gpoDN := "CN={31B2F340-016D-11D2-945F-00C04FB984F9},CN=Policies,CN=System," + testNC
+	return &sliverpb.LDAPSearch{
+		DefaultNamingContext: testNC,
+		Server:               "dc01.corp.local",
+		Results: []*sliverpb.LDAPResult{
+			{Name: Domains, Entries: []*sliverpb.LDAPEntry{
+				entry(testNC, "objectSid", binarySID(5, 21, 1004336348, 1177238915, 682003330),
+					"msDS-Behavior-Version", "7", "gPLink", "[LDAP://"+gpoDN+";2]"),
+			}},
+			{Name: trusts, Entries: []*sliverpb.LDAPEntry{
+				entry("CN=partner.local,CN=System,"+testNC, "trustPartner", "partner.local",
+					"securityIdentifier", binarySID(5, 21, 1, 2, 3), "trustDirection", "3", "trustAttributes", "8"),
+			}},
+			{Name: Users, Entries: []*sliverpb.LDAPEntry{
+				entry("CN=alice,OU=Staff,"+testNC, "sAMAccountName", "alice", "objectSid", domainSID(1104),
+					"userAccountControl", "21037568", "pwdLastSet", "132579432000000000", "primaryGroupID", "513",
+					"servicePrincipalName", []string{"http/web01.corp.local"},
+					"msDS-AllowedToDelegateTo", []string{"cifs/fs01.corp.local", "cifs/FS01"},
+					"whenCreated", "20210301093000.0Z"),
+			}},
+			{Name: Computers, Entries: []*sliverpb.LDAPEntry{
+				entry("CN=FS01,CN=Computers,"+testNC, "sAMAccountName", "FS01$", "dNSHostName", "fs01.corp.local",
+					"objectSid", domainSID(1105), "userAccountControl", "4096", "primaryGroupID", "515"),
+			}},
+			{Name: Groups, Entries: []*sliverpb.LDAPEntry{
+				entry("CN=Domain Admins,CN=Users,"+testNC, "sAMAccountName", "Domain Admins", "objectSid", domainSID(512),
+					"member", []string{
+						"CN=alice,OU=Staff," + testNC,
+						"CN=S-1-5-21-1-2-3-1000,CN=ForeignSecurityPrincipals," + testNC,
+					}),
+				entry("CN=Administrators,CN=Builtin,"+testNC, "sAMAccountName", "Administrators",
+					"objectSid", binarySID(5, 32, 544)),
+			}},
+			{Name: OUs, Entries: []*sliverpb.LDAPEntry{
+				entry("OU=Staff,"+testNC, "name", "Staff", "objectGUID", ouGUID, "gPOptions", "1"),
+			}},
+			{Name: GPOs, Entries: []*sliverpb.LDAPEntry{
+				entry(gpoDN, "name", "{31B2F340-016D-11D2-945F-00C04FB984F9}", "displayName", "Default Domain Policy",
+					"objectGUID", gpoGUID),
+			}},
+		},
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if sid := FormatSID(domainSID(500)); sid != testDomainSID+"-500" {
+		t.Fatalf("sid %s", sid)
+	}
+	guid := []byte{0xa2, 0x31, 0xb2, 0x31, 0x6d, 0x01, 0xd2, 0x11, 0x94, 0x5f, 0x00, 0xc0, 0x4f, 0xb9, 0x84, 0xf9}
+	if got := FormatGUID(guid); got != "31B231A2-016D-11D2-945F-00C04FB984F9" {
+		t.Fatalf("guid %s", got)
+	}
+	if got := firstRDNValue(`CN=Smith\, John,OU=Staff,DC=corp`); got != "Smith, John" {
+		t.Fatalf("rdn %s", got)
+	}
+	if got := parentDN(`CN=Smith\, John,OU=Staff,DC=corp`); got != "OU=Staff,DC=corp" {
+		t.Fatalf("parent %s", got)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	queries, err := Queries([]string{"users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, query := range queries {
+		names = append(names, query.Name)
+	}
+	if strings.Join(names, ",") != "domains,users,trusts" {
+		t.Fatalf("queries %v", names)
+	}
+	if _, err := Queries([]string{"trusts"}); err == nil {
+		t.Fatal("expected unknown collection error")
+	}
+}
+
+func collection(t *testing.T, data []*Data, name string) []map[string]interface{} {
+	for _, d := range data {
+		if d.Collection != name {
+			continue
+		}
+		file := struct {
+			Data []map[string]interface{}
+			Meta meta
+		}{}
+		if err := json.Unmarshal(d.JSON, &file); err != nil {
+			t.Fatal(err)
+		}
+		if file.Meta.Type != name || file.Meta.Count != len(file.Data) || file.Meta.Version != version {
+			t.Fatalf("%s meta %+v", name, file.Meta)
+		}
+		return file.Data
+	}
+	t.Fatalf("missing %s", name)
+	return nil
+}
+
+func TestBuild(t *testing.T) {
+	domain, data := Build(testSearch())
+	if domain != "CORP.LOCAL" || len(data) != len(Collections) {
+		t.Fatalf("domain %s, %d collections", domain, len(data))
+	}
+
+	domains := collection(t, data, Domains)
+	if domains[0]["ObjectIdentifier"] != testDomainSID {
+		t.Fatalf("domain %v", domains[0]["ObjectIdentifier"])
+	}
+	trust := domains[0]["Trusts"].([]interface{})[0].(map[string]interface{})
+	if trust["TargetDomainSid"] != "S-1-5-21-1-2-3" || trust["TrustType"].(float64) != 2 || trust["IsTransitive"] != true {
+		t.Fatalf("trust %v", trust)
+	}
+	link := domains[0]["Links"].([]interface{})[0].(map[string]interface{})
+	if link["GUID"] != "31B231A2-016D-11D2-945F-00C04FB984F9" || link["IsEnforced"] != true {
+		t.Fatalf("link %v", link)
+	}
+	if children := domains[0]["ChildObjects"].([]interface{}); len(children) != 1 {
+		t.Fatalf("domain children %v", children)
+	}
+
+	user := collection(t, data, Users)[0]
+	props := user["Properties"].(map[string]interface{})
+	if props["name"] != "ALICE@CORP.LOCAL" || props["dontreqpreauth"] != true || props["enabled"] != true ||
+		props["trustedtoauth"] != true || props["hasspn"] != true || props["pwdlastset"].(float64) != 1613469600 {
+		t.Fatalf("user %v", props)
+	}
+	if user["PrimaryGroupSID"] != testDomainSID+"-513" {
+		t.Fatalf("primary group %v", user["PrimaryGroupSID"])
+	}
+	if delegates := user["AllowedToDelegate"].([]interface{}); len(delegates) != 1 {
+		t.Fatalf("delegation %v", delegates)
+	}
+
+	groups := collection(t, data, Groups)
+	members := groups[0]["Members"].([]interface{})
+	if len(members) != 2 || members[0].(map[string]interface{})["ObjectType"] != "User" ||
+		members[1].(map[string]interface{})["ObjectIdentifier"] != "S-1-5-21-1-2-3-1000" {
+		t.Fatalf("members %v", members)
+	}
+	if groups[1]["ObjectIdentifier"] != "CORP.LOCAL-S-1-5-32-544" {
+		t.Fatalf("builtin %v", groups[1]["ObjectIdentifier"])
+	}
+	for _, group := range groups {
+		if group["Properties"].(map[string]interface{})["highvalue"] != true {
+			t.Fatalf("highvalue %v", group["ObjectIdentifier"])
+		}
+	}
+
+	ou := collection(t, data, OUs)[0]
+	if ou["Properties"].(map[string]interface{})["blocksinheritance"] != true {
+		t.Fatal("blocksinheritance")
+	}
+	if children := ou["ChildObjects"].([]interface{}); len(children) != 1 {
+		t.Fatalf("ou children %v", children)
+	}
+}
+
+func TestZip(t *testing.T) {
+	_, data := Build(testSearch())
+	timestamp := time.Date(2021, 3, 1, 9, 30, 0, 0, time.UTC)
+	archive, err := Zip(data, timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reader.File) != len(Collections) || reader.File[1].Name != "20210301093000_users.json" {
+		t.Fatalf("zip %v", reader.File[1].Name)
+	}
+}
@@ -0,0 +1,194 @@
+package bloodhound
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	// FILETIME of "never"
+	maxFileTime = 0x7FFFFFFFFFFFFFFF
+	// Time between 1601 and 1970 in 100ns intervals
+	fileTimeEpoch = 116444736000000000
+)
+
+// object - An LDAP entry with case insensitive attribute lookups
+type object struct {
+	DN    string
+	attrs map[string][][]byte
+}
+
+func newObject(entry *sliverpb.LDAPEntry) *object {
+	obj := &object{DN: entry.DN, attrs: map[string][][]byte{}}
+	for _, attr := range entry.Attributes {
+		name := strings.ToLower(attr.Name)
+		obj.attrs[name] = append(obj.attrs[name], attr.Values...)
+	}
+	return obj
+}
+
+func (o *object) has(name string) bool {
+	return 0 < len(o.attrs[strings.ToLower(name)])
+}
+
+func (o *object) str(name string) string {
+	values := o.attrs[strings.ToLower(name)]
+	if len(values) == 0 {
+		return ""
+	}
+	return string(values[0])
+}
+
+func (o *object) strs(name string) []string {
+	result := []string{}
+	for _, value := range o.attrs[strings.ToLower(name)] {
+		result = append(result, string(value))
+	}
+	return result
+}
+
+func (o *object) int(name string) int64 {
+	value, _ := strconv.ParseInt(o.str(name), 10, 64)
+	return value
+}
+
+func (o *object) sid(name string) string {
+	values := o.attrs[strings.ToLower(name)]
+	if len(values) == 0 {
+		return ""
+	}
+	return FormatSID(values[0])
+}
+
+func (o *object) sids(name string) []string {
+	result := []string{}
+	for _, value := range o.attrs[strings.ToLower(name)] {
+		if sid := FormatSID(value); sid != "" {
+			result = append(result, sid)
+		}
+	}
+	return result
+}
+
+func (o *object) guid(name string) string {
+	values := o.attrs[strings.ToLower(name)]
+	if len(values) == 0 {
+		return ""
+	}
+	return FormatGUID(values[0])
+}
+
+// fileTime - Unix time of a FILETIME attribute, 0 when unset and -1 for never
+func (o *object) fileTime(name string) int64 {
+	value := o.int(name)
+	switch {
+	case value <= 0:
+		return 0
+	case value == maxFileTime:
+		return -1
+	}
+	return (value - fileTimeEpoch) / 10000000
+}
+
+// generalizedTime - Unix time of a GeneralizedTime attribute (whenCreated)
+func (o *object) generalizedTime(name string) int64 {
+	value := o.str(name)
+	if len(value) < len("20060102150405") {
+		return 0
+	}
+	created, err := time.Parse("20060102150405", value[:len("20060102150405")])
+	if err != nil {
+		return 0
+	}
+	return created.Unix()
+}
+
+// FormatSID - Format a binary SID as S-1-5-21-...
+func FormatSID(sid []byte) string {
+	if len(sid) < 8 || len(sid) < 8+4*int(sid[1]) {
+		return ""
+	}
+	authority := uint64(0)
+	for _, b := range sid[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+	result := fmt.Sprintf("S-%d-%d", sid[0], authority)
+	for index := 0; index < int(sid[1]); index++ {
+		result += fmt.Sprintf("-%d", binary.LittleEndian.Uint32(sid[8+4*index:]))
+	}
+	return result
+}
+
+// FormatGUID - Format a binary GUID, the first three groups are little endian
+func FormatGUID(guid []byte) string {
+	if len(guid) != 16 {
+		return ""
+	}
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X",
+		binary.LittleEndian.Uint32(guid[0:4]),
+		binary.LittleEndian.Uint16(guid[4:6]),
+		binary.LittleEndian.Uint16(guid[6:8]),
+		guid[8:10],
+		guid[10:16],
+	)
+}
+
+// domainName - DC=corp,DC=local -> CORP.LOCAL
+func domainName(dn string) string {
+	parts := []string{}
+	for _, rdn := range strings.Split(dn, ",") {
+		rdn = strings.TrimSpace(rdn)
+		if strings.HasPrefix(strings.ToUpper(rdn), "DC=") {
+			parts = append(parts, rdn[3:])
+		}
+	}
+	return strings.ToUpper(strings.Join(parts, "."))
+}
+
+// parentDN - Strip the first RDN, escaped commas are part of the RDN
+func parentDN(dn string) string {
+	for index := 0; index < len(dn); index++ {
+		switch dn[index] {
+		case '\\':
+			index++
+		case ',':
+			return dn[index+1:]
+		}
+	}
+	return ""
+}
+
+// firstRDNValue - CN=Foo\, Bar,OU=... -> Foo, Bar
+func firstRDNValue(dn string) string {
+	rdn := dn
+	if parent := parentDN(dn); parent != "" {
+		rdn = dn[:len(dn)-len(parent)-1]
+	}
+	if index := strings.Index(rdn, "="); 0 <= index {
+		rdn = rdn[index+1:]
+	}
+	return strings.Replace(rdn, "\\", "", -1)
+}
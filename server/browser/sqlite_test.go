@@ -0,0 +1,142 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+)
+
+// The fixtures were created with the sqlite3 library using 1024 byte pages:
+//   rows.sqlite - 500 rows spanning interior pages, row 250 has an overflowing
+//                 blob, and a column added with ALTER TABLE after the fact
+//   wal.sqlite  - A WAL mode database copied before its log was checkpointed
+
+func TestSQLiteRows(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/rows.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database %s", err)
+	}
+	rows, err := db.Rows("items")
+	if err != nil {
+		t.Fatalf("Failed to read rows %s", err)
+	}
+	if len(rows) != 501 {
+		t.Fatalf("Expected 501 rows, got %d", len(rows))
+	}
+	for index, row := range rows[:500] {
+		id := int64(index + 1)
+		if row["id"] != id {
+			t.Fatalf("Row %d has id %v", index, row["id"])
+		}
+		if row["name"] != fmt.Sprintf("item-%d", id) {
+			t.Fatalf("Row %d has name %v", index, row["name"])
+		}
+		size := 8
+		if id == 250 {
+			size = 5000
+		}
+		if !bytes.Equal(row["value"].([]byte), bytes.Repeat([]byte{byte(id % 256)}, size)) {
+			t.Fatalf("Row %d has the wrong blob", index)
+		}
+		if row["count"] != -id*1000003 {
+			t.Fatalf("Row %d has count %v", index, row["count"])
+		}
+		if row["ratio"] != float64(id)/4 {
+			t.Fatalf("Row %d has ratio %v", index, row["ratio"])
+		}
+		if row["note"] != nil {
+			t.Fatalf("Row %d should not have a note", index)
+		}
+	}
+	if rows[500]["name"] != "added" || rows[500]["note"] != "late" || rows[500]["id"] != int64(501) {
+		t.Fatalf("Unexpected last row %v", rows[500])
+	}
+
+	if _, err := db.Rows("missing"); err != ErrTableNotFound {
+		t.Fatalf("Expected table not found, got %v", err)
+	}
+}
+
+func TestSQLiteWAL(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/wal.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wal, err := ioutil.ReadFile("testdata/wal.sqlite-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database %s", err)
+	}
+	rows, err := db.Rows("kv")
+	if err != nil {
+		t.Fatalf("Failed to read rows %s", err)
+	}
+	if len(rows) != 1 || rows[0]["value"] != "before" {
+		t.Fatalf("Unexpected rows without the log %v", rows)
+	}
+
+	db, err = openSQLite(data, wal)
+	if err != nil {
+		t.Fatalf("Failed to open database %s", err)
+	}
+	rows, err = db.Rows("kv")
+	if err != nil {
+		t.Fatalf("Failed to read rows %s", err)
+	}
+	if len(rows) != 2 || rows[0]["value"] != "after" || rows[1]["key"] != "b" {
+		t.Fatalf("Unexpected rows with the log %v", rows)
+	}
+}
+
+func TestSQLiteNotADatabase(t *testing.T) {
+	if _, err := openSQLite([]byte("not a database"), nil); err != ErrNotSQLite {
+		t.Fatalf("Expected not an SQLite database, got %v", err)
+	}
+}
+
+func TestSQLitePayloadSize(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/rows.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openSQLite(data, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database %s", err)
+	}
+	// Payload sizes of 2^30 and 2^64-1 followed by rowid 1
+	for _, cell := range [][]byte{
+		{0x84, 0x80, 0x80, 0x80, 0x00, 0x01},
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	} {
+		if _, _, err := db.leafCell(cell, 0); err == nil {
+			t.Fatalf("Expected an error for payload size of cell %x", cell)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package builder
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"path"
+	"sort"
+
+	"github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/server/generate"
+	"github.com/bishopfox/sliver/server/gogo"
+	"github.com/bishopfox/sliver/server/log"
+)
+
+var (
+	builderLog = log.NamedLogger("builder", "external")
+)
+
+// Config - External builder configuration
+type Config struct {
+	Name     string
+	Targets  []string
+	Operator *assets.ClientConfig
+}
+
+// DefaultTargets - All of the compiler targets sliver supports
+func DefaultTargets() []string {
+	targets := []string{}
+	for target := range gogo.ValidCompilerTargets {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// StartBuilder - Register with the server and build tasks until the connection
+// is closed, artifacts are signed with the operator's private key
+func StartBuilder(rpc rpcpb.SliverRPCClient, config *Config) error {
+	signer, err := operatorSigner(config.Operator)
+	if err != nil {
+		return err
+	}
+	for _, target := range config.Targets {
+		if _, ok := gogo.ValidCompilerTargets[target]; !ok {
+			return errors.New("Invalid compiler target: " + target)
+		}
+	}
+
+	stream, err := rpc.BuilderRegister(context.Background(), &clientpb.Builder{
+		Name:    config.Name,
+		Targets: config.Targets,
+	})
+	if err != nil {
+		return err
+	}
+	builderLog.Infof("Registered builder %s with targets %v", config.Name, config.Targets)
+	for {
+		task, err := stream.Recv()
+		if err != nil {
+			builderLog.Errorf("Builder stream closed: %s", err)
+			return err
+		}
+		go handleBuildTask(rpc, signer, config.Name, task)
+	}
+}
+
+func handleBuildTask(rpc rpcpb.SliverRPCClient, signer crypto.Signer, builderName string, task *clientpb.BuildTask) {
+	builderLog.Infof("Received build task %s", task.ID)
+	result := &clientpb.BuildResult{
+		TaskID:      task.ID,
+		BuilderName: builderName,
+	}
+	err := buildTask(signer, task, result)
+	if err != nil {
+		builderLog.Errorf("Build task %s failed: %s", task.ID, err)
+		result.Err = err.Error()
+	}
+	_, err = rpc.BuilderResult(context.Background(), result)
+	if err != nil {
+		builderLog.Errorf("Failed to return result of build task %s: %s", task.ID, err)
+	}
+}
+
+func buildTask(signer crypto.Signer, task *clientpb.BuildTask, result *clientpb.BuildResult) error {
+	if task.Config == nil {
+		return errors.New("Build task has no implant config")
+	}
+	config := generate.ImplantConfigFromProtobuf(task.Config)
+	fPath, err := generate.SliverImplant(config)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(fPath)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(data)
+	result.Signature, err = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return err
+	}
+	result.Canaries, err = generate.ImplantCanaries(config.Name)
+	if err != nil {
+		return err
+	}
+	result.Config = config.ToProtobuf()
+	result.File = &commonpb.File{
+		Name: path.Base(fPath),
+		Data: data,
+	}
+	return nil
+}
+
+// operatorSigner - The operator's key signs artifacts, the server verifies them
+// with the certificate the builder connected with
+func operatorSigner(operator *assets.ClientConfig) (crypto.Signer, error) {
+	keyPair, err := tls.X509KeyPair([]byte(operator.Certificate), []byte(operator.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := keyPair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("Operator private key cannot sign")
+	}
+	return signer, nil
+}
@@ -0,0 +1,98 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+
+	"github.com/bishopfox/sliver/server/cryptography"
+	"github.com/bishopfox/sliver/util/encoders"
+)
+
+const (
+	// Buffers larger than this are left to the garbage collector, so one large
+	// envelope doesn't stay pinned in the pool
+	maxPooledBufferSize = 256 * 1024
+
+	gcmTagSize = 16
+)
+
+var (
+	// Every DNS query decodes, decrypts and encodes a few transient slices, they're
+	// reused across queries
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, 0, 4*1024)
+			return &buf
+		},
+	}
+)
+
+// getBuffer - An empty buffer with room for at least size bytes, give it back
+// with putBuffer once nothing refers to its contents
+func getBuffer(size int) *[]byte {
+	buf := bufferPool.Get().(*[]byte)
+	if cap(*buf) < size {
+		*buf = make([]byte, 0, size)
+	}
+	*buf = (*buf)[:0]
+	return buf
+}
+
+func putBuffer(buf *[]byte) {
+	if maxPooledBufferSize < cap(*buf) {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// base64EncodeToString - Encodes TXT data through a pooled buffer, so only the
+// string is allocated, see encoders.DNSBase64
+func base64EncodeToString(data []byte) string {
+	buf := getBuffer(encoders.DNSBase64.EncodedLen(len(data)))
+	encoded := (*buf)[:encoders.DNSBase64.EncodedLen(len(data))]
+	encoders.DNSBase64.Encode(encoded, data)
+	value := string(encoded)
+	putBuffer(buf)
+	return value
+}
+
+// gcmEncryptPooled - Encrypts into a pooled buffer, give it back with putBuffer
+func gcmEncryptPooled(key cryptography.AESKey, plaintext []byte) (*[]byte, error) {
+	buf := getBuffer(cryptography.GCMNonceSize + len(plaintext) + gcmTagSize)
+	ciphertext, err := cryptography.GCMEncryptAppend(*buf, key, plaintext)
+	if err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	*buf = ciphertext
+	return buf, nil
+}
+
+// gcmDecryptPooled - Decrypts into a pooled buffer, give it back with putBuffer
+func gcmDecryptPooled(key cryptography.AESKey, ciphertext []byte) (*[]byte, error) {
+	buf := getBuffer(len(ciphertext))
+	plaintext, err := cryptography.GCMDecryptAppend(*buf, key, ciphertext)
+	if err != nil {
+		putBuffer(buf)
+		return nil, err
+	}
+	*buf = plaintext
+	return buf, nil
+}
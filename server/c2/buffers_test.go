@@ -0,0 +1,116 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/bishopfox/sliver/server/cryptography"
+	"github.com/bishopfox/sliver/util/encoders"
+)
+
+func TestPooledEncoding(t *testing.T) {
+	key := cryptography.RandomAESKey()
+	for _, size := range []int{0, 1, 5, byteBlockSize, 4096, 100000} {
+		data := bytes.Repeat([]byte{0xa5}, size)
+
+		if encoded := base64EncodeToString(data); encoded != base64.RawStdEncoding.EncodeToString(data) {
+			t.Errorf("Pooled base64 of %d bytes does not match", size)
+		}
+		decoded, err := dnsDecodeString(dnsEncodeToString(data))
+		if err != nil || !bytes.Equal(data, decoded) {
+			t.Errorf("Base32 round trip of %d bytes failed (%v)", size, err)
+		}
+
+		encrypted, err := gcmEncryptPooled(key, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := cryptography.GCMDecrypt(key, *encrypted)
+		if err != nil || !bytes.Equal(data, plaintext) {
+			t.Errorf("Failed to decrypt pooled ciphertext of %d bytes (%v)", size, err)
+		}
+		decrypted, err := gcmDecryptPooled(key, *encrypted)
+		if err != nil || !bytes.Equal(data, *decrypted) {
+			t.Errorf("Failed to decrypt %d bytes into a pooled buffer (%v)", size, err)
+		}
+		putBuffer(encrypted)
+		putBuffer(decrypted)
+	}
+}
+
+// The benchmarks come in pairs, the allocating version first, see -benchmem
+
+func BenchmarkBase64Encode(b *testing.B) {
+	data := make([]byte, byteBlockSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		base64.RawStdEncoding.EncodeToString(data)
+	}
+}
+
+func BenchmarkBase64EncodePooled(b *testing.B) {
+	data := make([]byte, byteBlockSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		base64EncodeToString(data)
+	}
+}
+
+func BenchmarkBase32Decode(b *testing.B) {
+	encoded := dnsEncodeToString(make([]byte, 4096))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encoders.DNSBase32.DecodeString(encoded)
+	}
+}
+
+func BenchmarkBase32DecodePooled(b *testing.B) {
+	encoded := []byte(dnsEncodeToString(make([]byte, 4096)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		raw := getBuffer(len(encoded) + 8)
+		*raw = append(*raw, encoded...)
+		data := getBuffer(len(encoded) + 8)
+		*data, _ = dnsDecodeAppend(*data, *raw)
+		putBuffer(raw)
+		putBuffer(data)
+	}
+}
+
+func BenchmarkGCMEncrypt(b *testing.B) {
+	key := cryptography.RandomAESKey()
+	data := make([]byte, 4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cryptography.GCMEncrypt(key, data)
+	}
+}
+
+func BenchmarkGCMEncryptPooled(b *testing.B) {
+	key := cryptography.RandomAESKey()
+	data := make([]byte, 4096)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encrypted, _ := gcmEncryptPooled(key, data)
+		putBuffer(encrypted)
+	}
+}
@@ -19,11 +19,28 @@ package c2
 */
 
 import (
+	"bytes"
+	"compress/flate"
+	secureRand "crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/bishopfox/sliver/server/certs"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/cryptography"
+	"github.com/golang/protobuf/proto"
+	"github.com/miekg/dns"
 )
 
 func TestRsaKeyHandler(t *testing.T) {
@@ -50,3 +67,782 @@ func TestRsaKeyHandler(t *testing.T) {
 	}
 
 }
+
+func TestGetHTTPSessionQuery(t *testing.T) {
+	server := &SliverHTTPC2{
+		HTTPSessions: &HTTPSessions{
+			active: &map[string]*HTTPSession{},
+			mutex:  &sync.RWMutex{},
+		},
+	}
+	httpSession := newHTTPSession()
+	httpSession.Session = &core.Session{}
+	server.HTTPSessions.Add(httpSession)
+
+	req := httptest.NewRequest("GET", "/foo.php?"+sessionCookieName+"="+httpSession.ID, nil)
+	if server.getHTTPSession(req) != httpSession {
+		t.Errorf("Failed to find session from query parameter")
+	}
+	req = httptest.NewRequest("GET", "/foo.php?"+sessionCookieName+"=invalid", nil)
+	if server.getHTTPSession(req) != nil {
+		t.Errorf("Found session for an invalid ID")
+	}
+}
+
+func TestDefaultRespHeadersOrigin(t *testing.T) {
+	server := &SliverHTTPC2{Conf: &HTTPServerConfig{}}
+	handler := server.DefaultRespHeaders(http.HandlerFunc(default404Handler))
+
+	req := httptest.NewRequest("GET", "/foo.js", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Unexpected CORS header without an origin")
+	}
+
+	req = httptest.NewRequest("GET", "/foo.js", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+		t.Errorf("Expected origin to be allowed, got %#v", rr.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestDNSSendStream(t *testing.T) {
+	dnsSession := &DNSSession{
+		ID:      dnsSessionID(),
+		Session: &core.Session{ID: core.NextSessionID()},
+		Key:     cryptography.RandomAESKey(),
+	}
+	data := []byte(strings.Repeat("sliver stream segment ", 3*streamSegmentSize/22+1))
+	stream, err := storeSendStream(dnsSession, &sliverpb.Envelope{ID: 1, Type: sliverpb.MsgDownload, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Segments != 4 {
+		t.Fatalf("Expected 4 segments, got %d", stream.Segments)
+	}
+
+	received := []byte{}
+	for index := uint32(0); index < stream.Segments; index++ {
+		header := fetchStreamSegmentHeader(t, dnsSession, stream.ID, index)
+		// Resolvers may repeat a query
+		if retry := fetchStreamSegmentHeader(t, dnsSession, stream.ID, index); retry.ID != header.ID {
+			t.Errorf("Repeated segment request stored a new segment")
+		}
+		txts := dnsSendBlocks(header.ID, "0", fmt.Sprintf("%d", header.Size))
+		encrypted, err := base64.RawStdEncoding.DecodeString(strings.Join(txts, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed, err := cryptography.GCMDecrypt(dnsSession.Key, encrypted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segment, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, segment...)
+	}
+	envelope := &sliverpb.Envelope{}
+	if err := proto.Unmarshal(received, envelope); err != nil || envelope.ID != 1 || !bytes.Equal(data, envelope.Data) {
+		t.Fatalf("Streamed envelope does not match the source (%v)", err)
+	}
+
+	if _, err := dnsStreamSegment(stream.ID, "1"); err == nil {
+		t.Errorf("Expected an error for a segment requested out of order")
+	}
+	if result, err := dnsStreamSegment(stream.ID, fmt.Sprintf("%d", stream.Segments)); err != nil || result[0] != "0" {
+		t.Errorf("Failed to close stream (%v)", err)
+	}
+	sendBlocksMutex.RLock()
+	_, stored := (*sendBlocks)[stream.blockID]
+	sendBlocksMutex.RUnlock()
+	sendStreamsMutex.RLock()
+	_, open := (*sendStreams)[stream.ID]
+	sendStreamsMutex.RUnlock()
+	if stored || open {
+		t.Errorf("Closed stream left data behind")
+	}
+}
+
+// A stream holds the envelope it's read from and one segment, never a copy of
+// the whole envelope
+func TestDNSSendStreamMemory(t *testing.T) {
+	dnsSession := &DNSSession{
+		ID:      dnsSessionID(),
+		Session: &core.Session{ID: core.NextSessionID()},
+		Key:     cryptography.RandomAESKey(),
+	}
+	data := make([]byte, 64*streamSegmentSize)
+	secureRand.Read(data)
+	envelope := &sliverpb.Envelope{ID: 2, Type: sliverpb.MsgDownload, Data: data}
+	heapAlloc := func() int {
+		runtime.GC()
+		stats := &runtime.MemStats{}
+		runtime.ReadMemStats(stats)
+		return int(stats.HeapAlloc)
+	}
+
+	before := heapAlloc()
+	stream, err := storeSendStream(dnsSession, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index := uint32(0); index < 2; index++ {
+		fetchStreamSegmentHeader(t, dnsSession, stream.ID, index)
+	}
+	if held := heapAlloc() - before; 8*streamSegmentSize < held {
+		t.Errorf("Stream of a %d byte envelope holds %d bytes", len(data), held)
+	}
+	dnsStreamSegment(stream.ID, fmt.Sprintf("%d", stream.Segments))
+	runtime.KeepAlive(envelope)
+}
+
+func TestEnvelopeReader(t *testing.T) {
+	for _, envelope := range []*sliverpb.Envelope{
+		{},
+		{ID: 3, Type: sliverpb.MsgPing},
+		{ID: 4, Type: sliverpb.MsgDownload, Data: []byte("data"), Padding: []byte("padding"), Compressed: true, BandwidthLimit: 1024},
+	} {
+		reader, size, err := envelopeReader(envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, _ := ioutil.ReadAll(reader)
+		if len(encoded) != size || size != proto.Size(envelope) {
+			t.Errorf("Expected %d bytes, got %d (size %d)", proto.Size(envelope), len(encoded), size)
+		}
+		decoded := &sliverpb.Envelope{}
+		if err := proto.Unmarshal(encoded, decoded); err != nil || !proto.Equal(envelope, decoded) {
+			t.Errorf("Envelope %v decoded as %v (%v)", envelope, decoded, err)
+		}
+	}
+}
+
+func fetchStreamSegmentHeader(t *testing.T, dnsSession *DNSSession, streamID string, index uint32) *sliverpb.DNSBlockHeader {
+	result, err := dnsStreamSegment(streamID, fmt.Sprintf("%d", index))
+	if err != nil {
+		t.Fatalf("Failed to fetch segment %d (%v)", index, err)
+	}
+	encrypted, _ := base64.RawStdEncoding.DecodeString(strings.Join(result, ""))
+	headerData, err := cryptography.GCMDecrypt(dnsSession.Key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := &sliverpb.DNSBlockHeader{}
+	proto.Unmarshal(headerData, header)
+	return header
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	if negotiateCompression([]string{"zstd", compressionDeflate}) != compressionDeflate {
+		t.Errorf("Failed to pick a supported algorithm")
+	}
+	if negotiateCompression([]string{"zstd"}) != "" {
+		t.Errorf("Picked an unsupported algorithm")
+	}
+
+	// Implants that didn't offer anything expect a bare session ID, and bare
+	// IDs (of any transport) never parse as a response with an ID
+	for _, sessionID := range []string{dnsSessionID(), newHTTPSessionID()} {
+		if resp := sessionInitResp(&sliverpb.SessionInitResp{ID: sessionID}, false); string(resp) != sessionID {
+			t.Errorf("Expected bare session ID, got %v", resp)
+		}
+		initResp := &sliverpb.SessionInitResp{}
+		if proto.Unmarshal([]byte(sessionID), initResp) == nil && initResp.ID != "" {
+			t.Errorf("Bare session ID %s parsed as a response", sessionID)
+		}
+	}
+	initResp := &sliverpb.SessionInitResp{}
+	proto.Unmarshal(sessionInitResp(&sliverpb.SessionInitResp{ID: "_abc", Compression: compressionDeflate}, true), initResp)
+	if initResp.ID != "_abc" || initResp.Compression != compressionDeflate {
+		t.Errorf("Unexpected session init response %v", initResp)
+	}
+}
+
+func TestCompressEnvelope(t *testing.T) {
+	session := &core.Session{ID: core.NextSessionID()}
+	data := []byte(strings.Repeat("compress me ", 1024))
+	envelope := &sliverpb.Envelope{ID: 1, Type: 2, Data: data}
+	if compressEnvelope(session, envelope) != envelope {
+		t.Errorf("Compressed for a session that didn't negotiate it")
+	}
+
+	session.Compression = compressionDeflate
+	small := &sliverpb.Envelope{Data: []byte("too small")}
+	if compressEnvelope(session, small) != small {
+		t.Errorf("Compressed a payload below the threshold")
+	}
+	compressed := compressEnvelope(session, envelope)
+	if !compressed.Compressed || len(data) <= len(compressed.Data) || compressed.ID != envelope.ID {
+		t.Fatalf("Unexpected compressed envelope")
+	}
+	if envelope.Compressed || !bytes.Equal(envelope.Data, data) {
+		t.Errorf("Compression modified the original envelope")
+	}
+	err := decompressEnvelope(session, compressed)
+	if err != nil || compressed.Compressed || !bytes.Equal(compressed.Data, data) {
+		t.Errorf("Round trip failed (%v)", err)
+	}
+	uncompressedBytes, compressedBytes := session.CompressionStats()
+	if uncompressedBytes != uint64(2*len(data)) || uncompressedBytes <= compressedBytes {
+		t.Errorf("Unexpected compression stats %d/%d", compressedBytes, uncompressedBytes)
+	}
+}
+
+func TestDNSEnvelopeBatching(t *testing.T) {
+	dnsSession := &DNSSession{
+		ID: dnsSessionID(),
+		Session: &core.Session{
+			ID:        core.NextSessionID(),
+			Send:      make(chan *sliverpb.Envelope, 4),
+			Resp:      map[uint64]chan *sliverpb.Envelope{},
+			RespMutex: &sync.RWMutex{},
+		},
+		Key:      cryptography.RandomAESKey(),
+		Batching: true,
+		replay:   map[string]bool{},
+	}
+	dnsSessionsMutex.Lock()
+	(*dnsSessions)[dnsSession.ID] = dnsSession
+	dnsSessionsMutex.Unlock()
+	defer func() {
+		dnsSessionsMutex.Lock()
+		delete(*dnsSessions, dnsSession.ID)
+		dnsSessionsMutex.Unlock()
+	}()
+
+	// Poll, small envelopes share a block
+	for id := uint64(1); id <= 3; id++ {
+		dnsSession.Session.Send <- &sliverpb.Envelope{ID: id, Data: []byte("small")}
+	}
+	dnsSession.Session.Send <- &sliverpb.Envelope{ID: 4, Data: make([]byte, batchEnvelopeSize)}
+	result, err := dnsSessionPoll("", []string{"nonce", dnsSession.ID, sessionPollingMsg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedPoll, _ := base64.RawStdEncoding.DecodeString(strings.Join(result, ""))
+	pollData, err := cryptography.GCMDecrypt(dnsSession.Key, encryptedPoll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dnsPoll := &sliverpb.DNSPoll{}
+	proto.Unmarshal(pollData, dnsPoll)
+	if len(dnsPoll.Blocks) != 2 || dnsPoll.Blocks[0].Batch || !dnsPoll.Blocks[1].Batch {
+		t.Fatalf("Expected one envelope and one batch, got %v", dnsPoll.Blocks)
+	}
+	batchBlock := dnsPoll.Blocks[1]
+	txts := dnsSendBlocks(batchBlock.ID, "0", fmt.Sprintf("%d", batchBlock.Size))
+	encryptedBatch, _ := base64.RawStdEncoding.DecodeString(strings.Join(txts, ""))
+	batchData, err := cryptography.GCMDecrypt(dnsSession.Key, encryptedBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := &sliverpb.EnvelopeBatch{}
+	proto.Unmarshal(batchData, batch)
+	if len(batch.Envelopes) != 3 {
+		t.Errorf("Expected a batch of 3 envelopes, got %d", len(batch.Envelopes))
+	}
+
+	// Send, every envelope in a batch reaches its request
+	for id := uint64(1); id <= 2; id++ {
+		dnsSession.Session.Resp[id] = make(chan *sliverpb.Envelope, 1)
+	}
+	batchData, _ = proto.Marshal(&sliverpb.EnvelopeBatch{Envelopes: []*sliverpb.Envelope{{ID: 1}, {ID: 2}}})
+	encryptedBatch, _ = cryptography.GCMEncrypt(dnsSession.Key, batchData)
+	dnsSegmentReassemblerMutex.Lock()
+	(*dnsSegmentReassembler)["batchnonce"] = newDNSReassembly()
+	(*dnsSegmentReassembler)["batchnonce"].add(0, []string{dnsEncodeToString(encryptedBatch)})
+	dnsSegmentReassemblerMutex.Unlock()
+	result, err = dnsSessionEnvelope("", []string{"batchnonce", dnsSession.ID, "_" + sessionBatchMsg})
+	if err != nil || result[0] != "0" {
+		t.Fatalf("Failed to handle batch (%v)", err)
+	}
+	for id := uint64(1); id <= 2; id++ {
+		select {
+		case <-dnsSession.Session.Resp[id]:
+		default:
+			t.Errorf("Envelope %d of the batch was not delivered", id)
+		}
+	}
+}
+
+func TestDNSUniqueIDs(t *testing.T) {
+	// IDs generated back to back used to repeat
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		blockID := generateBlockID()
+		if seen[blockID] {
+			t.Fatalf("Duplicate block id %s after %d ids", blockID, i)
+		}
+		seen[blockID] = true
+	}
+
+	blockIDs := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		blockID, _ := storeSendBlocks([]byte("data"), nil)
+		if blockIDs[blockID] {
+			t.Fatalf("Stored two blocks with id %s", blockID)
+		}
+		blockIDs[blockID] = true
+	}
+	for blockID := range blockIDs {
+		clearSendBlock(blockID)
+	}
+}
+
+func TestSendBlockEviction(t *testing.T) {
+	before := GetSendBlockStats()
+
+	// Expired, an implant stopped reading it
+	staleID, _ := storeSendBlocks([]byte("stale"), nil)
+	freshID, _ := storeSendBlocks([]byte("fresh"), nil)
+	sendBlocksMutex.Lock()
+	(*sendBlocks)[staleID].touch(time.Now().Add(-2 * sendBlockTTL))
+	sendBlocksMutex.Unlock()
+	expireSendBlocks(time.Now())
+	sendBlocksMutex.RLock()
+	_, stale := (*sendBlocks)[staleID]
+	_, fresh := (*sendBlocks)[freshID]
+	sendBlocksMutex.RUnlock()
+	if stale || !fresh {
+		t.Fatalf("Expected only the stale block to expire (stale: %v, fresh: %v)", stale, fresh)
+	}
+	if stats := GetSendBlockStats(); stats.Expired != before.Expired+1 {
+		t.Errorf("Expected one expired block, got %d", stats.Expired-before.Expired)
+	}
+
+	// Evicted, least recently read first
+	dnsSendBlocks(freshID, "0", "1")
+	// Base64 takes 4 bytes for every 3, so the two don't fit together
+	largeID, _ := storeSendBlocks(make([]byte, sendBlocksMaxSize/8*3), nil)
+	sendBlocksMutex.Lock()
+	(*sendBlocks)[largeID].touch(time.Now().Add(-time.Minute))
+	sendBlocksMutex.Unlock()
+	overflowID, _ := storeSendBlocks(make([]byte, sendBlocksMaxSize/8*3+3*1024), nil)
+	defer clearSendBlock(overflowID)
+	defer clearSendBlock(freshID)
+	sendBlocksMutex.RLock()
+	_, evicted := (*sendBlocks)[largeID]
+	_, fresh = (*sendBlocks)[freshID]
+	sendBlocksMutex.RUnlock()
+	if evicted || !fresh {
+		t.Fatalf("Expected the least recently read block to be evicted")
+	}
+	stats := GetSendBlockStats()
+	if stats.Evicted != before.Evicted+1 {
+		t.Errorf("Expected one evicted block, got %d", stats.Evicted-before.Evicted)
+	}
+	if sendBlocksMaxSize < stats.Size {
+		t.Errorf("Send blocks take %d bytes, more than the %d limit", stats.Size, sendBlocksMaxSize)
+	}
+}
+
+func TestDNSSendBlocks(t *testing.T) {
+	data := make([]byte, 3*byteBlockSize+10)
+	for index := range data {
+		data[index] = byte(index)
+	}
+	blockID, size := storeSendBlocks(data, nil)
+	defer clearSendBlock(blockID)
+	if size != 4 {
+		t.Fatalf("Expected 4 blocks, got %d", size)
+	}
+	for index := 0; index < size; index++ {
+		stop := (index + 1) * byteBlockSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		expected := base64.RawStdEncoding.EncodeToString(data[index*byteBlockSize : stop])
+		if block := dnsSendBlocks(blockID, fmt.Sprintf("%d", index), fmt.Sprintf("%d", index+1)); len(block) != 1 || block[0] != expected {
+			t.Errorf("Block %d does not match its own encoding", index)
+		}
+	}
+	if blocks := dnsSendBlocks(blockID, "2", "10"); len(blocks) != 2 {
+		t.Errorf("Expected the range to stop at the last block, got %d block(s)", len(blocks))
+	}
+	if blocks := dnsSendBlocks(blockID, "-1", "2"); len(blocks) != 0 {
+		t.Errorf("Expected no blocks for a negative start, got %d", len(blocks))
+	}
+}
+
+func TestDNSConcurrentBlockFetch(t *testing.T) {
+	data := make([]byte, 1000*byteBlockSize)
+	secureRand.Read(data)
+	blockID, size := storeSendBlocks(data, nil)
+	defer clearSendBlock(blockID)
+
+	// Ranges of 200 blocks, fetched out of order and some of them twice
+	ranges := (size + 199) / 200
+	txts := make([]string, ranges)
+	txtsMutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for _, index := range []int{4, 2, 0, 3, 1, 2, 4} {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			blocks := dnsSendBlocks(blockID, fmt.Sprintf("%d", index*200), fmt.Sprintf("%d", (index+1)*200))
+			txtsMutex.Lock()
+			txts[index] = strings.Join(blocks, "")
+			txtsMutex.Unlock()
+		}(index)
+	}
+	wg.Wait()
+	received, err := base64.RawStdEncoding.DecodeString(strings.Join(txts, ""))
+	if err != nil || !bytes.Equal(data, received) {
+		t.Fatalf("Blocks fetched in parallel do not match the source (%v)", err)
+	}
+}
+
+func TestDNSSegmentReassembly(t *testing.T) {
+	data := make([]byte, 1000)
+	secureRand.Read(data)
+	encoded := dnsEncodeToString(data)
+	segments := [][]string{}
+	for start := 0; start < len(encoded); start += 189 {
+		stop := start + 189
+		if len(encoded) < stop {
+			stop = len(encoded)
+		}
+		segments = append(segments, []string{encoded[start:stop]})
+	}
+	send := func(nonce string, order []int) {
+		for _, index := range order {
+			seq := dnsEncodeToString([]byte{byte(index), 0, 0, 0})
+			fields := append(append([]string{}, segments[index]...), seq, nonce, "_session", sessionEnvelopeMsg)
+			if result, err := dnsSegment(fields); err != nil || result[0] != "0" {
+				t.Fatalf("Failed to store segment %d (%v)", index, err)
+			}
+		}
+	}
+
+	// Out of order, with a repeated segment
+	order := []int{}
+	for index := len(segments) - 1; 0 <= index; index-- {
+		order = append(order, index)
+	}
+	send("reasmnonce1", append(order, 2))
+	reassembled, _, err := dnsSegmentReassemble("reasmnonce1", len(segments))
+	if err != nil || !bytes.Equal(data, *reassembled) {
+		t.Fatalf("Reassembled data does not match (%v)", err)
+	}
+	putBuffer(reassembled)
+
+	// A missing segment is detected, with or without a declared total
+	send("reasmnonce2", order[1:])
+	if _, _, err := dnsSegmentReassemble("reasmnonce2", len(segments)); err == nil {
+		t.Errorf("Expected an error for a missing last segment")
+	}
+	send("reasmnonce3", append(order[:2], order[3:]...))
+	if _, _, err := dnsSegmentReassemble("reasmnonce3", -1); err == nil {
+		t.Errorf("Expected an error for a gap in the segments")
+	}
+}
+
+func TestDNSApexQueries(t *testing.T) {
+	apexIP := net.ParseIP("192.0.2.1")
+	query := func(name string, qtype uint16) *dns.Msg {
+		req := new(dns.Msg)
+		req.SetQuestion(name, qtype)
+		return handleC2("example.com.", apexIP, 1, req)
+	}
+
+	resp := query("example.com.", dns.TypeA)
+	if len(resp.Answer) != 1 || !resp.Answer[0].(*dns.A).A.Equal(apexIP) || !resp.Authoritative {
+		t.Errorf("Expected an authoritative A record for the apex, got %v", resp.Answer)
+	}
+	resp = query("ns1.example.com.", dns.TypeA)
+	if len(resp.Answer) != 1 {
+		t.Errorf("Expected an A record for the name server, got %v", resp.Answer)
+	}
+	resp = query("example.com.", dns.TypeNS)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.NS).Ns != "ns1.example.com." {
+		t.Errorf("Expected the NS record, got %v", resp.Answer)
+	}
+	if resp = query("example.com.", dns.TypeANY); resp.Rcode != dns.RcodeRefused {
+		t.Errorf("Expected ANY to be refused, got rcode %d", resp.Rcode)
+	}
+	resp = query("www.example.com.", dns.TypeAAAA)
+	if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 0 || len(resp.Ns) != 1 {
+		t.Errorf("Expected NODATA with the SOA, got %v", resp)
+	}
+	if _, ok := resp.Ns[0].(*dns.SOA); !ok {
+		t.Errorf("Expected the SOA in the authority section, got %v", resp.Ns[0])
+	}
+
+	// Without an apex IP there's no A record for it either
+	req := new(dns.Msg)
+	req.SetQuestion("example.com.", dns.TypeA)
+	if resp = handleC2("example.com.", nil, 1, req); len(resp.Answer) != 0 || len(resp.Ns) != 1 {
+		t.Errorf("Expected NODATA without an apex IP, got %v", resp)
+	}
+}
+
+func TestDNSRepeatedSegments(t *testing.T) {
+	segment := func(nonce string, index int, data string) ([]string, error) {
+		seq := dnsEncodeToString([]byte{byte(index), 0, 0, 0})
+		return dnsSegment([]string{data, seq, nonce, "_session", sessionEnvelopeMsg})
+	}
+	encoded := dnsEncodeToString([]byte("repeated segments"))
+
+	// Identical repeats are ignored
+	segment("repeatnonce1", 0, encoded[:16])
+	segment("repeatnonce1", 1, encoded[16:])
+	if result, err := segment("repeatnonce1", 0, encoded[:16]); err != nil || result[0] != "0" {
+		t.Errorf("Identical repeat was not ignored (%v)", err)
+	}
+	data, _, err := dnsSegmentReassemble("repeatnonce1", 2)
+	if err != nil || string(*data) != "repeated segments" {
+		t.Fatalf("Failed to reassemble message with a repeated segment (%v)", err)
+	}
+	if _, result, _ := dnsSegmentReassemble("repeatnonce1", 2); result == nil || result[0] != "0" {
+		t.Errorf("Final query repeated while the message is handled was not acknowledged")
+	}
+	dnsCompleteMessage("repeatnonce1", []string{"result"})
+
+	// Late repeats neither error nor start a new message, the final message gets the same result
+	if result, err := segment("repeatnonce1", 1, encoded[16:]); err != nil || result[0] != "0" {
+		t.Errorf("Late repeat returned an error (%v)", err)
+	}
+	dnsSegmentReassemblerMutex.RLock()
+	_, reopened := (*dnsSegmentReassembler)["repeatnonce1"]
+	dnsSegmentReassemblerMutex.RUnlock()
+	if reopened {
+		t.Errorf("Late repeat started a new message")
+	}
+	if _, result, _ := dnsSegmentReassemble("repeatnonce1", 2); result == nil || result[0] != "result" {
+		t.Errorf("Repeated final message did not get the same result")
+	}
+	expireReassemblies(time.Now().Add(dnsCompletedTTL + time.Second))
+	if _, result, _ := dnsSegmentReassemble("repeatnonce1", 2); result != nil {
+		t.Errorf("Completed message did not expire")
+	}
+
+	// Concurrent final queries, only one reassembles the message
+	segment("repeatnonce3", 0, encoded[:16])
+	segment("repeatnonce3", 1, encoded[16:])
+	reassembled := make(chan bool)
+	for index := 0; index < 8; index++ {
+		go func() {
+			data, result, err := dnsSegmentReassemble("repeatnonce3", 2)
+			if err != nil || (data == nil) == (result == nil) {
+				t.Errorf("Concurrent final query failed (%v)", err)
+			}
+			reassembled <- data != nil
+		}()
+	}
+	count := 0
+	for index := 0; index < 8; index++ {
+		if <-reassembled {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected the message to be reassembled once, got %d", count)
+	}
+
+	// Conflicting repeats fail the message
+	segment("repeatnonce2", 0, encoded[:16])
+	if _, err := segment("repeatnonce2", 0, encoded[16:]); err == nil {
+		t.Errorf("Expected an error for a conflicting segment")
+	}
+	segment("repeatnonce2", 1, encoded[16:])
+	if _, _, err := dnsSegmentReassemble("repeatnonce2", 2); err == nil {
+		t.Errorf("Expected an error for a message with conflicting segments")
+	}
+}
+
+// Envelopes are handled without holding the sessions lock, a response can't
+// block other sessions' queries
+func TestDNSEnvelopeUnlocked(t *testing.T) {
+	dnsSession := &DNSSession{
+		ID: dnsSessionID(),
+		Session: &core.Session{
+			ID:        core.NextSessionID(),
+			Resp:      map[uint64]chan *sliverpb.Envelope{},
+			RespMutex: &sync.RWMutex{},
+		},
+		Key:         cryptography.RandomAESKey(),
+		replay:      map[string]bool{},
+		recvStreams: map[string]*RecvStream{},
+	}
+	dnsSessionsMutex.Lock()
+	(*dnsSessions)[dnsSession.ID] = dnsSession
+	dnsSessionsMutex.Unlock()
+	defer func() {
+		dnsSessionsMutex.Lock()
+		delete(*dnsSessions, dnsSession.ID)
+		dnsSessionsMutex.Unlock()
+	}()
+
+	resp := make(chan *sliverpb.Envelope) // Blocks the handler until read
+	dnsSession.Session.Resp[1] = resp
+	data, _ := proto.Marshal(&sliverpb.Envelope{ID: 1})
+	encrypted, _ := cryptography.GCMEncrypt(dnsSession.Key, data)
+	dnsSegmentReassemblerMutex.Lock()
+	(*dnsSegmentReassembler)["unlockednonce"] = newDNSReassembly()
+	(*dnsSegmentReassembler)["unlockednonce"].add(0, []string{dnsEncodeToString(encrypted)})
+	dnsSegmentReassemblerMutex.Unlock()
+	go dnsSessionEnvelope("", []string{"unlockednonce", dnsSession.ID, "_" + sessionEnvelopeMsg})
+
+	locked := make(chan bool)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		dnsSessionsMutex.Lock()
+		dnsSessionsMutex.Unlock()
+		locked <- true
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Errorf("Sessions lock held while the envelope is handled")
+	}
+	<-resp
+}
+
+func TestDNSEnvelopeParts(t *testing.T) {
+	dnsSession := &DNSSession{
+		ID: dnsSessionID(),
+		Session: &core.Session{
+			ID:        core.NextSessionID(),
+			Resp:      map[uint64]chan *sliverpb.Envelope{},
+			RespMutex: &sync.RWMutex{},
+		},
+		Key:         cryptography.RandomAESKey(),
+		Multipart:   true,
+		replay:      map[string]bool{},
+		recvStreams: map[string]*RecvStream{},
+	}
+	dnsSessionsMutex.Lock()
+	(*dnsSessions)[dnsSession.ID] = dnsSession
+	dnsSessionsMutex.Unlock()
+	defer func() {
+		dnsSessionsMutex.Lock()
+		delete(*dnsSessions, dnsSession.ID)
+		dnsSessionsMutex.Unlock()
+	}()
+
+	sendPart := func(nonce string, part *sliverpb.DNSEnvelopePart) ([]string, error) {
+		partData, _ := proto.Marshal(part)
+		encryptedPart, _ := cryptography.GCMEncrypt(dnsSession.Key, partData)
+		dnsSegmentReassemblerMutex.Lock()
+		(*dnsSegmentReassembler)[nonce] = newDNSReassembly()
+		(*dnsSegmentReassembler)[nonce].add(0, []string{dnsEncodeToString(encryptedPart)})
+		dnsSegmentReassemblerMutex.Unlock()
+		return dnsSessionEnvelope("", []string{nonce, dnsSession.ID, "_" + sessionPartMsg})
+	}
+
+	// The envelope is only delivered once its last part arrived
+	sample := make([]byte, 2*envelopePartSize+1024)
+	secureRand.Read(sample)
+	data, _ := proto.Marshal(&sliverpb.Envelope{ID: 1, Data: sample})
+	parts := (len(data) + envelopePartSize - 1) / envelopePartSize
+	resp := make(chan *sliverpb.Envelope, 1)
+	dnsSession.Session.Resp[1] = resp
+	for index := 0; index < parts; index++ {
+		start := index * envelopePartSize
+		stop := start + envelopePartSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		result, err := sendPart(fmt.Sprintf("partnonce%d", index), &sliverpb.DNSEnvelopePart{
+			ID:    "parts1",
+			Index: uint32(index),
+			Parts: uint32(parts),
+			Size:  uint64(len(data)),
+			Data:  data[start:stop],
+		})
+		if err != nil || result[0] != "0" {
+			t.Fatalf("Failed to handle part %d (%v)", index, err)
+		}
+		if index < parts-1 && 0 < len(resp) {
+			t.Fatalf("Envelope delivered after part %d of %d", index+1, parts)
+		}
+	}
+	select {
+	case envelope := <-resp:
+		if !bytes.Equal(envelope.Data, sample) {
+			t.Errorf("Reassembled envelope does not match")
+		}
+	default:
+		t.Fatalf("Envelope was not delivered after its last part")
+	}
+	if len(dnsSession.recvStreams) != 0 {
+		t.Errorf("Completed envelope was not dropped")
+	}
+
+	// Out of order parts and parts larger than the declared size fail the envelope
+	sendPart("partnonce10", &sliverpb.DNSEnvelopePart{ID: "parts2", Index: 0, Parts: 3, Size: 8, Data: []byte("part")})
+	if _, err := sendPart("partnonce11", &sliverpb.DNSEnvelopePart{ID: "parts2", Index: 2, Parts: 3, Size: 8, Data: []byte("part")}); err == nil {
+		t.Errorf("Expected an error for an out of order part")
+	}
+	if _, ok := dnsSession.recvStreams["parts2"]; ok {
+		t.Errorf("Envelope with an out of order part was not dropped")
+	}
+	if _, err := sendPart("partnonce12", &sliverpb.DNSEnvelopePart{ID: "parts3", Index: 0, Parts: 2, Size: 4, Data: []byte("too large")}); err == nil {
+		t.Errorf("Expected an error for a part larger than the envelope")
+	}
+
+	// Envelopes that stop receiving parts expire
+	sendPart("partnonce13", &sliverpb.DNSEnvelopePart{ID: "parts4", Index: 0, Parts: 2, Size: 8, Data: []byte("part")})
+	expireRecvStreams(time.Now().Add(sendBlockTTL + time.Second))
+	if len(dnsSession.recvStreams) != 0 {
+		t.Errorf("Incomplete envelope did not expire")
+	}
+}
+
+func TestDNSCheckin(t *testing.T) {
+	longAgo := time.Now().Add(-time.Hour)
+	dnsSession := &DNSSession{
+		ID: dnsSessionID(),
+		Session: &core.Session{
+			ID:          core.NextSessionID(),
+			Send:        make(chan *sliverpb.Envelope, 1),
+			Resp:        map[uint64]chan *sliverpb.Envelope{},
+			RespMutex:   &sync.RWMutex{},
+			LastCheckin: &longAgo,
+		},
+		lastCheckin: longAgo.UnixNano(),
+		Key:         cryptography.RandomAESKey(),
+		replay:      map[string]bool{},
+		recvStreams: map[string]*RecvStream{},
+	}
+	dnsSessionsMutex.Lock()
+	(*dnsSessions)[dnsSession.ID] = dnsSession
+	dnsSessionsMutex.Unlock()
+	defer func() {
+		dnsSessionsMutex.Lock()
+		delete(*dnsSessions, dnsSession.ID)
+		dnsSessionsMutex.Unlock()
+	}()
+	checkedIn := func() bool {
+		return time.Since(dnsSession.LastCheckin()) < time.Minute &&
+			time.Since(dnsSession.Session.GetLastCheckin()) < time.Minute
+	}
+
+	// A poll that returns nothing still means the implant is alive
+	if _, err := dnsSessionPoll("", []string{"_abcdef", dnsSession.ID, sessionPollingMsg}); err != nil {
+		t.Fatal(err)
+	}
+	if !checkedIn() {
+		t.Errorf("Poll did not update the last check-in")
+	}
+	health := dnsSession.Session.ToProtobuf().Health
+	if time.Since(time.Unix(health.LastCheckin, 0)) > time.Minute {
+		t.Errorf("Session API reports a stale check-in %d", health.LastCheckin)
+	}
+
+	// So does a segment of an envelope
+	atomic.StoreInt64(&dnsSession.lastCheckin, longAgo.UnixNano())
+	dnsSession.Session.LastCheckin = &longAgo
+	seq := dnsEncodeToString([]byte{0, 0, 0, 0})
+	if _, err := dnsSessionEnvelope("", []string{"aaaa", seq, "checkinnonce", dnsSession.ID, sessionEnvelopeMsg}); err != nil {
+		t.Fatal(err)
+	}
+	if !checkedIn() {
+		t.Errorf("Segment did not update the last check-in")
+	}
+}
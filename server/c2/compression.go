@@ -0,0 +1,101 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	compressionDeflate = "deflate"
+
+	// Smaller payloads don't shrink enough to be worth the trouble
+	compressionThreshold = 1024
+)
+
+// negotiateCompression - The first algorithm the implant offers that we support
+func negotiateCompression(offered []string) string {
+	for _, algorithm := range offered {
+		if algorithm == compressionDeflate {
+			return algorithm
+		}
+	}
+	return ""
+}
+
+// sessionInitResp - Implants that offered compression or batching are told
+// what was negotiated, older ones only expect the session ID
+func sessionInitResp(resp *sliverpb.SessionInitResp, offered bool) []byte {
+	if !offered {
+		return []byte(resp.ID)
+	}
+	data, _ := proto.Marshal(resp)
+	return data
+}
+
+// compressEnvelope - Returns a copy of the envelope with its payload
+// compressed, or the envelope as is if it's small, the session didn't
+// negotiate compression, or compressing doesn't help
+func compressEnvelope(session *core.Session, envelope *sliverpb.Envelope) *sliverpb.Envelope {
+	if session.Compression != compressionDeflate || len(envelope.Data) < compressionThreshold {
+		return envelope
+	}
+	buf := &bytes.Buffer{}
+	writer, _ := flate.NewWriter(buf, flate.BestSpeed)
+	writer.Write(envelope.Data)
+	writer.Close()
+	if len(envelope.Data) <= buf.Len() {
+		return envelope
+	}
+	session.RecordCompression(len(envelope.Data), buf.Len())
+	return &sliverpb.Envelope{
+		ID:                 envelope.ID,
+		Type:               envelope.Type,
+		Data:               buf.Bytes(),
+		UnknownMessageType: envelope.UnknownMessageType,
+		BandwidthLimit:     envelope.BandwidthLimit,
+		Padding:            envelope.Padding,
+		Compressed:         true,
+		Err:                envelope.Err,
+	}
+}
+
+// decompressEnvelope - Inflate the payload of an envelope the implant compressed
+func decompressEnvelope(session *core.Session, envelope *sliverpb.Envelope) error {
+	if !envelope.Compressed {
+		return nil
+	}
+	reader := flate.NewReader(bytes.NewReader(envelope.Data))
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	session.RecordCompression(len(data), len(envelope.Data))
+	envelope.Data = data
+	envelope.Compressed = false
+	return nil
+}
@@ -0,0 +1,188 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bishopfox/sliver/util/encoders"
+)
+
+const (
+	// Reasons a query is rejected, see DNSQueryStats
+	dnsRejectMsgType = "msg-type" // Unknown message type
+	dnsRejectLabels  = "labels"   // Wrong number of labels for the message type
+	dnsRejectCharset = "charset"  // A label has characters it can't contain
+	dnsRejectLength  = "length"   // A label is too short or too long
+
+	// The 4 byte sequence numbers are always 7 base32 characters, without padding
+	dnsSeqLength      = 7
+	dnsMaxDataLabels  = 3
+	dnsMaxLabelLength = 63
+)
+
+var (
+	dnsIDCharSet  = encoders.DNSIDCharSet
+	dnsDigits     = "0123456789"
+	dnsNonceLabel = dnsLabel{name: "nonce", charset: dnsIDCharSet, min: 1, max: 16}
+	dnsSeqLabel   = dnsLabel{name: "seq", charset: encoders.DNSBase32Alphabet, min: dnsSeqLength, max: dnsSeqLength}
+	dnsIndexLabel = dnsLabel{name: "index", charset: dnsDigits, min: 1, max: 10}
+	dnsBlockLabel = dnsLabel{name: "block id", charset: dnsIDCharSet, min: blockIDSize, max: blockIDSize}
+	dnsDataLabel  = dnsLabel{name: "data", charset: encoders.DNSBase32Alphabet, min: 1, max: dnsMaxLabelLength}
+
+	// Session init messages are sent with "_" as the session ID
+	dnsSessionLabel = dnsLabel{name: "session id", charset: dnsIDCharSet, min: 1, max: sessionIDSize + 1}
+
+	dnsSegmentLayout = dnsQueryLayout{data: true, labels: []dnsLabel{dnsSeqLabel, dnsNonceLabel, dnsSessionLabel}}
+	dnsFinalLayouts  = []dnsQueryLayout{
+		{labels: []dnsLabel{dnsSeqLabel, dnsNonceLabel, dnsSessionLabel}}, // (total).(nonce).(session id)
+		{labels: []dnsLabel{dnsNonceLabel, dnsSessionLabel}},              // Implants that don't declare a total
+	}
+
+	// dnsQueryLayouts - The labels before the message type, by message type
+	dnsQueryLayouts = map[string][]dnsQueryLayout{
+		domainKeyMsg: {{labels: []dnsLabel{
+			dnsNonceLabel, {name: "selector", charset: dnsIDCharSet, min: 1, max: dnsMaxLabelLength},
+		}}},
+		blockReqMsg:       {{labels: []dnsLabel{dnsNonceLabel, dnsIndexLabel, dnsIndexLabel, dnsBlockLabel}}},
+		clearBlockMsg:     {{labels: []dnsLabel{dnsNonceLabel, dnsBlockLabel}}},
+		streamSegmentMsg:  {{labels: []dnsLabel{dnsNonceLabel, dnsIndexLabel, dnsBlockLabel}}},
+		sessionPollingMsg: {{labels: []dnsLabel{dnsNonceLabel, dnsSessionLabel}}},
+
+		sessionInitMsg:     {dnsSegmentLayout},
+		sessionEnvelopeMsg: {dnsSegmentLayout},
+		sessionBatchMsg:    {dnsSegmentLayout},
+		sessionPartMsg:     {dnsSegmentLayout},
+
+		"_" + clearBlockMsg:      {{labels: []dnsLabel{dnsNonceLabel, dnsBlockLabel}}},
+		"_" + sessionInitMsg:     dnsFinalLayouts,
+		"_" + sessionEnvelopeMsg: dnsFinalLayouts,
+		"_" + sessionBatchMsg:    dnsFinalLayouts,
+		"_" + sessionPartMsg:     dnsFinalLayouts,
+	}
+
+	dnsQueryStatsMutex = &sync.Mutex{}
+	dnsQueryStats      = &DNSQueryStats{Rejected: map[string]uint64{}}
+)
+
+// DNSQueryStats - C2 queries that were parsed, and those rejected as malformed by reason
+type DNSQueryStats struct {
+	Parsed   uint64
+	Rejected map[string]uint64
+}
+
+// dnsLabel - What a label of a query may contain
+type dnsLabel struct {
+	name    string
+	charset string
+	min     int
+	max     int
+}
+
+// dnsQueryLayout - The labels of a message type, messages that carry data start
+// with 1 to dnsMaxDataLabels labels of it
+type dnsQueryLayout struct {
+	data   bool
+	labels []dnsLabel
+}
+
+// dnsQueryError - Why a query was rejected
+type dnsQueryError struct {
+	reason string
+	msg    string
+}
+
+func (e *dnsQueryError) Error() string {
+	return e.msg
+}
+
+// parseDNSQuery - Split a C2 subdomain into its labels, checking that their number,
+// characters and lengths match the message type. The fields are safe to read with
+// the getField helpers.
+func parseDNSQuery(subdomain string) ([]string, error) {
+	fields, err := splitDNSQuery(subdomain)
+	dnsQueryStatsMutex.Lock()
+	defer dnsQueryStatsMutex.Unlock()
+	if err != nil {
+		dnsQueryStats.Rejected[err.(*dnsQueryError).reason]++
+		return nil, err
+	}
+	dnsQueryStats.Parsed++
+	return fields, nil
+}
+
+func splitDNSQuery(subdomain string) ([]string, error) {
+	fields := strings.Split(strings.ToLower(subdomain), ".")
+	msgType := fields[len(fields)-1]
+	layouts, ok := dnsQueryLayouts[msgType]
+	if !ok {
+		return nil, &dnsQueryError{dnsRejectMsgType, fmt.Sprintf("Unknown msg type %#v", msgType)}
+	}
+	labels := fields[:len(fields)-1]
+	var err error
+	for _, layout := range layouts {
+		if err = layout.check(labels); err == nil {
+			return fields, nil
+		}
+	}
+	return nil, err
+}
+
+// check - The error of the first label that doesn't fit the layout
+func (l dnsQueryLayout) check(labels []string) error {
+	dataLabels := len(labels) - len(l.labels)
+	if dataLabels < 0 || (!l.data && dataLabels != 0) || (l.data && (dataLabels < 1 || dnsMaxDataLabels < dataLabels)) {
+		return &dnsQueryError{dnsRejectLabels, fmt.Sprintf("Invalid number of labels %d", len(labels))}
+	}
+	for index, value := range labels {
+		label := dnsDataLabel
+		if dataLabels <= index {
+			label = l.labels[index-dataLabels]
+		}
+		if err := label.check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l dnsLabel) check(value string) error {
+	if len(value) < l.min || l.max < len(value) {
+		return &dnsQueryError{dnsRejectLength, fmt.Sprintf("Invalid %s length %d", l.name, len(value))}
+	}
+	for _, char := range value {
+		if !strings.ContainsRune(l.charset, char) {
+			return &dnsQueryError{dnsRejectCharset, fmt.Sprintf("Invalid character %q in %s", char, l.name)}
+		}
+	}
+	return nil
+}
+
+// GetDNSQueryStats - How many C2 queries were parsed, and rejected by reason
+func GetDNSQueryStats() DNSQueryStats {
+	dnsQueryStatsMutex.Lock()
+	defer dnsQueryStatsMutex.Unlock()
+	stats := DNSQueryStats{Parsed: dnsQueryStats.Parsed, Rejected: map[string]uint64{}}
+	for reason, count := range dnsQueryStats.Rejected {
+		stats.Rejected[reason] = count
+	}
+	return stats
+}
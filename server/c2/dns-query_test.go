@@ -0,0 +1,126 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestParseDNSQuery(t *testing.T) {
+	seq := dnsEncodeToString([]byte{1, 0, 0, 0})
+	data := dnsEncodeToString([]byte("some data sent by the implant"))
+	sessionID := dnsSessionID()
+
+	// Queries as the implant sends them
+	valid := []string{
+		"_abcdef.sliver._domainkey",
+		"_abcdef.0.200.ab1-_z.b",
+		"abcdef.ab1-_z._cb",
+		"_abcdef.3.ab1-_z.ss",
+		"_abcdef." + sessionID + ".sp",
+		fmt.Sprintf("%s.%s.abcdefghij._.si", data, seq),
+		fmt.Sprintf("%s.%s.%s.abcdefghij.%s.se", data, data, seq, sessionID),
+		fmt.Sprintf("%s.abcdefghij._._si", seq),
+		fmt.Sprintf("%s.abcdefghij.%s._sm", seq, sessionID),
+		"abcdefghij." + sessionID + "._se", // No declared total
+		"_ABCDEF." + strings.ToUpper(sessionID) + ".SP",
+	}
+	for _, query := range valid {
+		fields, err := parseDNSQuery(query)
+		if err != nil {
+			t.Errorf("Failed to parse %#v (%v)", query, err)
+			continue
+		}
+		if len(fields) != len(strings.Split(query, ".")) {
+			t.Errorf("Parsed %#v into %d fields", query, len(fields))
+		}
+	}
+
+	// Malformed queries from fuzzing handleTXT, short seq fields and polls of unknown
+	// sessions used to crash the listener
+	invalid := []struct {
+		query  string
+		reason string
+	}{
+		{"", dnsRejectMsgType},
+		{".", dnsRejectMsgType},
+		{"_abcdef.sliver.domainkey", dnsRejectMsgType},
+		{"se", dnsRejectLabels},
+		{"_se", dnsRejectLabels},
+		{"a.aa.abcdefghij._.si", dnsRejectLength},                 // seq that decodes to less than 4 bytes
+		{fmt.Sprintf("%s.abcdefghij._.si", seq), dnsRejectLabels}, // segment without data
+		{fmt.Sprintf("%s.%s.%s.%s.%s.abcdefghij._.se", data, data, data, data, seq), dnsRejectLabels},
+		{fmt.Sprintf("%s!.%s.abcdefghij._.se", data, seq), dnsRejectCharset},
+		{fmt.Sprintf("%s.%s.abcdefghij.%s_toolong.se", data, seq, sessionID), dnsRejectLength},
+		{"_abcdef.0.200.ab1-_.b", dnsRejectLength},
+		{"_abcdef.0.-1.ab1-_z.b", dnsRejectCharset},
+		{"_abcdef.200.ab1-_z.b", dnsRejectLabels},
+		{"_abcdef.ab1-_z.ss", dnsRejectLabels},
+		{"_abc*ef." + sessionID + ".sp", dnsRejectCharset},
+		{"_abcdef..sp", dnsRejectLength},
+		{"abcdefghij._._sp", dnsRejectMsgType},
+	}
+	before := GetDNSQueryStats()
+	for _, test := range invalid {
+		_, err := parseDNSQuery(test.query)
+		if err == nil {
+			t.Errorf("Expected %#v to be rejected", test.query)
+			continue
+		}
+		if reason := err.(*dnsQueryError).reason; reason != test.reason {
+			t.Errorf("Expected %#v to be rejected for %s, got %s (%v)", test.query, test.reason, reason, err)
+		}
+	}
+	stats := GetDNSQueryStats()
+	rejected := uint64(0)
+	for reason, count := range stats.Rejected {
+		rejected += count - before.Rejected[reason]
+	}
+	if rejected != uint64(len(invalid)) {
+		t.Errorf("Expected %d rejected queries in the stats, got %d", len(invalid), rejected)
+	}
+}
+
+func TestHandleTXTMalformed(t *testing.T) {
+	for _, name := range []string{
+		"se.example.com.",
+		"a.aa.abcdefghij._.si.example.com.",
+		"a.b.c._se.example.com.",
+		"_x.cb.example.com.",
+		"1.ss.example.com.",
+	} {
+		req := new(dns.Msg)
+		req.SetQuestion(name, dns.TypeTXT)
+		resp := handleC2("example.com.", nil, 1, req)
+		if len(resp.Answer) != 0 {
+			t.Errorf("Expected no answer for %#v, got %v", name, resp.Answer)
+		}
+	}
+
+	if _, err := getFieldSeq([]string{"aa", "nonce", "_", "se"}); err == nil {
+		t.Errorf("Expected an error for a short seq field")
+	}
+	if _, err := dnsSessionPoll("", []string{"_ja0bcdef", "_eusess", sessionPollingMsg}); err == nil {
+		t.Errorf("Expected an error for a poll of an unknown session")
+	}
+}
@@ -0,0 +1,67 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	throttleChunkSize = 4096
+)
+
+// throttledWriter - Paces writes so the average throughput stays under rate bytes per second
+type throttledWriter struct {
+	writer  io.Writer
+	rate    int64
+	start   time.Time
+	written int64
+}
+
+// envelopeWriter - Returns a writer that honors the envelope's bandwidth limit, if it has one
+func envelopeWriter(writer io.Writer, envelope *sliverpb.Envelope) io.Writer {
+	if envelope.BandwidthLimit <= 0 {
+		return writer
+	}
+	return &throttledWriter{writer: writer, rate: envelope.BandwidthLimit, start: time.Now()}
+}
+
+func (t *throttledWriter) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		stop := written + throttleChunkSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		n, err := t.writer.Write(data[written:stop])
+		written += n
+		t.written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		expected := time.Duration(float64(t.written) / float64(t.rate) * float64(time.Second))
+		if elapsed := time.Since(t.start); elapsed < expected {
+			time.Sleep(expected - elapsed)
+		}
+	}
+	return written, nil
+}
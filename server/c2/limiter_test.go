@@ -0,0 +1,48 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+func TestEnvelopeWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if envelopeWriter(buf, &sliverpb.Envelope{}) != buf {
+		t.Fatal("Expected an uncapped envelope to use the writer directly")
+	}
+
+	data := bytes.Repeat([]byte("A"), 3*throttleChunkSize)
+	writer := envelopeWriter(buf, &sliverpb.Envelope{BandwidthLimit: int64(len(data)) * 4})
+	started := time.Now()
+	n, err := writer.Write(data)
+	if err != nil || n != len(data) {
+		t.Fatalf("Write failed (%d bytes): %v", n, err)
+	}
+	if elapsed := time.Since(started); elapsed < 200*time.Millisecond {
+		t.Fatalf("Expected write to take at least 200ms, took %s", elapsed)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Written data does not match")
+	}
+}
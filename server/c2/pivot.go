@@ -19,7 +19,9 @@ package c2
 */
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/bishopfox/sliver/server/core"
@@ -33,7 +35,7 @@ var (
 
 	// Pivots - holds the pivots, provides atomic access
 	Pivots = &PivotsMap{
-		Pivots: &map[uint32]*core.Session{},
+		Pivots: &map[uint32]*Pivot{},
 		mutex:  &sync.RWMutex{},
 	}
 )
@@ -63,6 +65,7 @@ func HandlePivotData(session *core.Session, data []byte) {
 	pivotLog.Printf("[PIVOT] XXXX: %v\n", envelope)
 	sliverPivoted := Pivots.Session(envi.GetPivotID())
 	handlers := serverHandlers.GetSessionHandlers()
+	sliverPivoted.Checkin()
 	if envelope.ID != 0 {
 		sliverPivoted.RespMutex.RLock()
 		if resp, ok := sliverPivoted.Resp[envelope.ID]; ok {
@@ -103,7 +106,7 @@ func HandlePivotOpen(session *core.Session, data []byte) {
 		ID:            core.NextSessionID(),
 		Transport:     pivotOpen.GetPivotType() + " (PIVOT)",
 		RemoteAddress: pivotOpen.GetRemoteAddress(),
-		Send:          make(chan *sliverpb.Envelope),
+		Send:          core.NewSendQueue(),
 		RespMutex:     &sync.RWMutex{},
 		Resp:          map[uint64]chan *sliverpb.Envelope{},
 		Name: 		   register.Name,
@@ -131,8 +134,16 @@ func HandlePivotOpen(session *core.Session, data []byte) {
 			}
 		}
 	}()
+	sliverPivoted.Checkin() // The pivot open carries the registration
 	core.Sessions.Add(sliverPivoted)
-	Pivots.AddSession(pivotOpen.GetPivotID(), sliverPivoted)
+	Pivots.AddPivot(&Pivot{
+		ID:            pivotOpen.GetPivotID(),
+		Session:       sliverPivoted,
+		Parent:        session,
+		Type:          pivotOpen.GetPivotType(),
+		RemoteAddress: pivotOpen.GetRemoteAddress(),
+		OpenedAt:      time.Now(),
+	})
 }
 
 // HandlePivotClose - Handles a PivotClose message
@@ -146,7 +157,7 @@ func HandlePivotClose(session *core.Session, data []byte) {
 	sliverPivoted := Pivots.Session(pivotClose.GetPivotID())
 	if sliverPivoted != nil {
 		pivotLog.Debugf("Cleaning up for %s", sliverPivoted.Name)
-		core.Sessions.Remove(sliverPivoted.ID)
+		core.Sessions.RemoveSession(sliverPivoted)
 	}
 	Pivots.RemoveSession(pivotClose.GetPivotID())
 	/*core.EventBroker.Publish(core.Event{
@@ -155,24 +166,78 @@ func HandlePivotClose(session *core.Session, data []byte) {
 	})*/
 }
 
+// Pivot - A session relayed to the server by another session
+type Pivot struct {
+	ID            uint32
+	Session       *core.Session // The pivoted session
+	Parent        *core.Session // The session relaying its traffic
+	Type          string
+	RemoteAddress string
+	OpenedAt      time.Time
+}
+
 // PivotsMap - Mananges the pivots, provides atomic access
 type PivotsMap struct {
 	mutex  *sync.RWMutex
-	Pivots *map[uint32]*core.Session
+	Pivots *map[uint32]*Pivot
 }
 
 // Session - Get Session by ID
 func (h *PivotsMap) Session(pivotID uint32) *core.Session {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	return (*h.Pivots)[pivotID]
+	if pivot, ok := (*h.Pivots)[pivotID]; ok {
+		return pivot.Session
+	}
+	return nil
 }
 
-// AddSliver - Add a sliver to the hive (atomically)
-func (h *PivotsMap) AddSession(pivotID uint32, session *core.Session) {
+// AddPivot - Add a pivot to the hive (atomically)
+func (h *PivotsMap) AddPivot(pivot *Pivot) {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
-	(*h.Pivots)[pivotID] = session
+	(*h.Pivots)[pivot.ID] = pivot
+}
+
+// Graph - Get the pivots of the sessions that are still connected, ordered
+// by session ID
+func (h *PivotsMap) Graph() []*Pivot {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	pivots := []*Pivot{}
+	for _, pivot := range *h.Pivots {
+		if core.Sessions.Get(pivot.Session.ID) != nil {
+			pivots = append(pivots, pivot)
+		}
+	}
+	sort.Slice(pivots, func(i, j int) bool {
+		return pivots[i].Session.ID < pivots[j].Session.ID
+	})
+	return pivots
+}
+
+// Route - Get the sessions relaying traffic between the server and a
+// session, starting with the session connected to the server and ending
+// with the session itself
+func (h *PivotsMap) Route(sessionID uint32) []*core.Session {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	parents := map[uint32]*core.Session{}
+	for _, pivot := range *h.Pivots {
+		parents[pivot.Session.ID] = pivot.Parent
+	}
+	session := core.Sessions.Get(sessionID)
+	if session == nil {
+		return []*core.Session{}
+	}
+	route := []*core.Session{session}
+	for parent, ok := parents[session.ID]; ok; parent, ok = parents[parent.ID] {
+		if len(parents) < len(route) {
+			break // Loop in the graph, should never happen
+		}
+		route = append([]*core.Session{parent}, route...)
+	}
+	return route
 }
 
 // RemoveSliver - Remove a session from the hive (atomically)
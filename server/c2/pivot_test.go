@@ -0,0 +1,64 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+func newPivotTestSession() *core.Session {
+	return core.Sessions.Add(&core.Session{
+		ID:        core.NextSessionID(),
+		Send:      make(chan *sliverpb.Envelope, 1),
+		RespMutex: &sync.RWMutex{},
+	})
+}
+
+func TestPivotRoute(t *testing.T) {
+	root := newPivotTestSession()
+	hop := newPivotTestSession()
+	leaf := newPivotTestSession()
+	Pivots.AddPivot(&Pivot{ID: 1001, Session: hop, Parent: root, Type: "named-pipe", OpenedAt: time.Now()})
+	Pivots.AddPivot(&Pivot{ID: 1002, Session: leaf, Parent: hop, Type: "tcp", OpenedAt: time.Now()})
+	defer Pivots.RemoveSession(1001)
+	defer Pivots.RemoveSession(1002)
+
+	route := Pivots.Route(leaf.ID)
+	if len(route) != 3 || route[0] != root || route[1] != hop || route[2] != leaf {
+		t.Fatalf("Unexpected route %v", route)
+	}
+	if route := Pivots.Route(root.ID); len(route) != 1 || route[0] != root {
+		t.Fatalf("Unexpected route %v", route)
+	}
+
+	core.Sessions.Remove(leaf.ID)
+	for _, pivot := range Pivots.Graph() {
+		if pivot.Session == leaf {
+			t.Fatalf("Graph contains a disconnected session")
+		}
+	}
+	if len(Pivots.Graph()) != 1 {
+		t.Fatalf("Expected one pivot, got %d", len(Pivots.Graph()))
+	}
+}
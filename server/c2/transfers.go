@@ -0,0 +1,81 @@
+package c2
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io"
+
+	"github.com/bishopfox/sliver/server/core"
+)
+
+const (
+	// Large writes are split so a transfer's progress moves while one envelope is written
+	transferChunkSize = 32 * 1024
+)
+
+// transferWriter - Counts the bytes written towards a transfer
+type transferWriter struct {
+	writer   io.Writer
+	transfer *core.Transfer
+}
+
+// newTransferWriter - Returns writer as is if the envelope isn't tracked
+func newTransferWriter(writer io.Writer, transfer *core.Transfer) io.Writer {
+	if transfer == nil {
+		return writer
+	}
+	return &transferWriter{writer: writer, transfer: transfer}
+}
+
+func (t *transferWriter) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		stop := written + transferChunkSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		n, err := t.writer.Write(data[written:stop])
+		written += n
+		t.transfer.Add(int64(n))
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// transferReader - Counts the bytes read towards a transfer
+type transferReader struct {
+	reader   io.Reader
+	transfer *core.Transfer
+}
+
+// newTransferReader - Returns reader as is if the envelope isn't tracked
+func newTransferReader(reader io.Reader, transfer *core.Transfer) io.Reader {
+	if transfer == nil {
+		return reader
+	}
+	return &transferReader{reader: reader, transfer: transfer}
+}
+
+func (t *transferReader) Read(data []byte) (int, error) {
+	n, err := t.reader.Read(data)
+	t.transfer.Add(int64(n))
+	return n, err
+}
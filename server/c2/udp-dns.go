@@ -22,16 +22,17 @@ package c2
 */
 
 import (
+	"bytes"
+	"compress/flate"
 	"crypto/sha256"
 	"crypto/x509"
+	"io"
 	"math"
 	"net"
-	"sort"
 
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/bishopfox/sliver/server/generate"
 
-	"encoding/base32"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/pem"
@@ -43,6 +44,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	consts "github.com/bishopfox/sliver/client/constants"
@@ -51,6 +53,7 @@ import (
 	"github.com/bishopfox/sliver/server/cryptography"
 	serverHandlers "github.com/bishopfox/sliver/server/handlers"
 	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/util/encoders"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/miekg/dns"
@@ -66,19 +69,61 @@ const (
 	sessionInitMsg     = "si"
 	sessionPollingMsg  = "sp"
 	sessionEnvelopeMsg = "se"
+	sessionBatchMsg    = "sb"
+	streamSegmentMsg   = "ss"
+	sessionPartMsg     = "sm"
 
-	// Max TXT record is 255, records are b64 so (n*8 + 5) / 6 = ~250
-	byteBlockSize = 185 // Can be as high as n = 187, but we'll leave some slop
+	// Max TXT record is 255, records are b64 so (n*8 + 5) / 6 = ~250, blocks are
+	// encoded separately but decoded joined so n must be a multiple of 3
+	byteBlockSize = 186
 	blockIDSize   = 6
+
+	// Implants poll for envelopes this often
+	dnsPollInterval = time.Second
+
+	// Records that aren't part of the C2 protocol (the apex A record, NS and
+	// SOA) are cached by resolvers for dnsRecordTTL seconds, answers are not
+	dnsRecordTTL = 300
+	dnsNSPrefix  = "ns1."
+
+	// Envelopes larger than one segment are streamed to implants that speak
+	// protocol v3, a stream never holds more than one encoded segment
+	streamSegmentSize        = 64 * 1024
+	envelopeDataKey          = 3<<3 | 2 // Envelope.Data, length delimited
+	streamMinProtocolVersion = 3
+
+	// Envelopes smaller than this are sent together in one block to implants
+	// that negotiated batching, a batch is at most batchMaxSize bytes
+	batchEnvelopeSize = 4 * 1024
+	batchMaxSize      = 32 * 1024
+
+	// Implants that negotiated multipart send envelopes larger than one part as
+	// a series of messages, each part is decoded and decrypted as it arrives so
+	// only the envelope itself grows with its size
+	envelopePartSize = 64 * 1024
+
+	// Send blocks (and streams) an implant hasn't read from in sendBlockTTL are
+	// dropped, and the least recently read blocks are dropped whenever the
+	// encoded blocks would take more than sendBlocksMaxSize bytes
+	sendBlockTTL            = 5 * time.Minute
+	sendBlocksMaxSize       = 64 * 1024 * 1024
+	sendBlocksSweepInterval = time.Minute
+
+	// Resolvers may repeat the queries of a message long after it was complete,
+	// its nonce and result are kept this long so repeats are answered the same way
+	dnsCompletedTTL = 2 * time.Minute
 )
 
 var (
 	dnsLog = log.NamedLogger("c2", "dns")
 
-	dnsCharSet = []rune("abcdefghijklmnopqrstuvwxyz0123456789-_")
+	dnsCharSet = []rune(encoders.DNSIDCharSet)
 
 	sendBlocksMutex = &sync.RWMutex{}
 	sendBlocks      = &map[string]*SendBlock{}
+	sendBlocksSize  = 0 // Encoded bytes in sendBlocks
+	sendBlocksStats = &SendBlockStats{}
+	sendBlocksSweep = &sync.Once{}
 
 	dnsSessionsMutex = &sync.RWMutex{}
 	dnsSessions      = &map[string]*DNSSession{}
@@ -87,22 +132,125 @@ var (
 	blockReassembler      = &map[string][][]byte{}
 
 	dnsSegmentReassemblerMutex = &sync.RWMutex{}
-	dnsSegmentReassembler      = &map[string](*map[int][]string){}
+	dnsSegmentReassembler      = &map[string]*dnsReassembly{}
+	dnsCompletedMessages       = &map[string]*dnsCompletedMessage{} // Also under dnsSegmentReassemblerMutex
+
+	sendStreamsMutex = &sync.RWMutex{}
+	sendStreams      = &map[string]*SendStream{}
+
+	// IDs only need to be unique, but reseeding from the clock on every call
+	// repeats them when called in quick succession
+	idRandMutex = &sync.Mutex{}
+	idRand      = insecureRand.New(insecureRand.NewSource(secureSeed()))
 )
 
-// SendBlock - Data is encoded and split into `Blocks`
+// SendBlock - Data is encoded once and split into `Blocks`, each is a slice of
+// the same string ready to go in a TXT record, so reads only take an RLock.
+// Implants fetch ranges of a block in parallel, see dnsSendBlocks.
 type SendBlock struct {
-	ID   string
-	Data []string
+	ID       string
+	Data     []string
+	Transfer *core.Transfer
+
+	size       int
+	lastAccess int64 // Unix nanoseconds, updated under the RLock so always atomic
+	sent       int64 // Blocks sent, including repeats, only used for progress
+}
+
+func (b *SendBlock) touch(now time.Time) {
+	atomic.StoreInt64(&b.lastAccess, now.UnixNano())
+}
+
+func (b *SendBlock) lastRead() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&b.lastAccess))
+}
+
+// SendBlockStats - Send blocks held in memory and how many were dropped before
+// an implant cleared them
+type SendBlockStats struct {
+	Blocks       int
+	Size         int
+	Expired      uint64 // Not read from in sendBlockTTL
+	Evicted      uint64 // Dropped to stay under sendBlocksMaxSize
+	EvictedBytes uint64 // Expired or evicted
+}
+
+// dnsReassembly - Segments of an upstream message by sequence number, the counts
+// are kept as segments arrive so the final message doesn't have to rescan them
+type dnsReassembly struct {
+	segments map[int][]string
+	received int // Distinct sequence numbers
+	last     int // Highest sequence number
+	size     int // Encoded length of all segments
+	conflict bool
+	updated  time.Time
+}
+
+// dnsCompletedMessage - Result of a message that was reassembled, nil while
+// it's still being handled
+type dnsCompletedMessage struct {
+	result    []string
+	completed time.Time
+}
+
+func newDNSReassembly() *dnsReassembly {
+	return &dnsReassembly{segments: map[int][]string{}, last: -1, updated: time.Now()}
+}
+
+// add - Store a segment, resolvers repeat queries so a segment that was already
+// received is ignored, unless its data differs which fails the whole message
+func (r *dnsReassembly) add(index int, subdata []string) error {
+	r.updated = time.Now()
+	if previous, ok := r.segments[index]; ok {
+		if strings.Join(previous, "") != strings.Join(subdata, "") {
+			r.conflict = true
+			return fmt.Errorf("Conflicting data for segment %d", index)
+		}
+		return nil
+	}
+	r.segments[index] = subdata
+	r.received++
+	if r.last < index {
+		r.last = index
+	}
+	for _, data := range subdata {
+		r.size += len(data)
+	}
+	return nil
+}
+
+// complete - Every segment of total has arrived, if the implant didn't declare
+// a total (-1) there must be no gaps up to the highest sequence number
+func (r *dnsReassembly) complete(total int) bool {
+	if total < 0 {
+		total = r.last + 1
+	}
+	return r.received == total && r.last == total-1
 }
 
 // DNSSession - Holds DNS session information
 type DNSSession struct {
+	lastCheckin int64 // Unix nanoseconds, atomic and first so it's 64-bit aligned
+
 	ID          string
 	Session     *core.Session
 	Key         cryptography.AESKey
-	LastCheckin time.Time
-	replay      map[string]bool // Sessions are mutex 'd
+	Batching    bool            // Negotiated at session init
+	Multipart   bool            // Negotiated at session init
+	replay      map[string]bool // Guarded by mutex
+	recvStreams map[string]*RecvStream
+	mutex       sync.Mutex
+}
+
+// Checkin - Any query naming the session, see dnsCheckin
+func (s *DNSSession) Checkin() {
+	atomic.StoreInt64(&s.lastCheckin, time.Now().UnixNano())
+	s.Session.Checkin()
+}
+
+// LastCheckin - Last query naming the session
+func (s *DNSSession) LastCheckin() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&s.lastCheckin))
 }
 
 func (s *DNSSession) isReplayAttack(ciphertext []byte) bool {
@@ -121,22 +269,33 @@ func (s *DNSSession) isReplayAttack(ciphertext []byte) bool {
 
 // --------------------------- DNS SERVER ---------------------------
 
-// StartDNSListener - Start a DNS listener
-func StartDNSListener(domains []string, canaries bool) *dns.Server {
+// StartDNSListener - Start a DNS listener, apexIP (if any) is the A record of the
+// parent domains and their name server
+func StartDNSListener(domains []string, canaries bool, apexIP net.IP) *dns.Server {
 	StartPivotListener()
-	dnsLog.Infof("Starting DNS listener for %v (canaries: %v) ...", domains, canaries)
+	dnsLog.Infof("Starting DNS listener for %v (canaries: %v, apex: %v) ...", domains, canaries, apexIP)
+	sendBlocksSweep.Do(func() {
+		go func() {
+			for range time.Tick(sendBlocksSweepInterval) {
+				expireSendBlocks(time.Now())
+				expireReassemblies(time.Now())
+				expireRecvStreams(time.Now())
+			}
+		}()
+	})
 
-	dns.HandleFunc(".", func(writer dns.ResponseWriter, req *dns.Msg) {
-		req.Question[0].Name = strings.ToLower(req.Question[0].Name)
-		handleDNSRequest(domains, canaries, writer, req)
+	// Each listener has its own handler, the default mux is shared by all of them
+	serial := uint32(time.Now().Unix())
+	handler := dns.HandlerFunc(func(writer dns.ResponseWriter, req *dns.Msg) {
+		handleDNSRequest(domains, canaries, apexIP, serial, writer, req)
 	})
 
-	server := &dns.Server{Addr: ":53", Net: "udp"}
+	server := &dns.Server{Addr: ":53", Net: "udp", Handler: handler}
 	return server
 }
 
 // DNSRequest -> C2 or canary?
-func handleDNSRequest(domains []string, canaries bool, writer dns.ResponseWriter, req *dns.Msg) {
+func handleDNSRequest(domains []string, canaries bool, apexIP net.IP, serial uint32, writer dns.ResponseWriter, req *dns.Msg) {
 	if req == nil {
 		dnsLog.Info("req can not be nil")
 		return
@@ -146,12 +305,13 @@ func handleDNSRequest(domains []string, canaries bool, writer dns.ResponseWriter
 		dnsLog.Info("No questions in DNS request")
 		return
 	}
+	req.Question[0].Name = strings.ToLower(req.Question[0].Name)
 
 	var resp *dns.Msg
 	isC2, domain := isC2SubDomain(domains, req.Question[0].Name)
 	if isC2 {
 		dnsLog.Debugf("'%s' is subdomain of c2 parent '%s'", req.Question[0].Name, domain)
-		resp = handleC2(domain, req)
+		resp = handleC2(domain, apexIP, serial, req)
 	} else if canaries {
 		dnsLog.Debugf("checking '%s' for DNS canary matches", req.Question[0].Name)
 		resp = handleCanary(req)
@@ -177,19 +337,75 @@ func isC2SubDomain(domains []string, reqDomain string) (bool, string) {
 	return false, ""
 }
 
-// C2 -> Record type?
-func handleC2(domain string, req *dns.Msg) *dns.Msg {
+// C2 -> Record type? Anything that isn't C2 traffic gets the answer of a plain
+// authoritative server, so resolvers and scanners see a coherent zone
+func handleC2(domain string, apexIP net.IP, serial uint32, req *dns.Msg) *dns.Msg {
 	subdomain := req.Question[0].Name[:len(req.Question[0].Name)-len(domain)]
 	if strings.HasSuffix(subdomain, ".") {
 		subdomain = subdomain[:len(subdomain)-1]
 	}
 	dnsLog.Infof("processing req for subdomain = %s", subdomain)
-	switch req.Question[0].Qtype {
-	case dns.TypeTXT:
-		return handleTXT(domain, subdomain, req)
+	q := req.Question[0]
+	zone := dns.Fqdn(domain)
+	nameServer := dnsNSPrefix + zone
+
+	var resp *dns.Msg
+	switch {
+	case q.Qtype == dns.TypeTXT:
+		resp = handleTXT(domain, subdomain, req)
+
+	case q.Qtype == dns.TypeANY:
+		// RFC 8482, don't hand out everything we know in one response
+		resp = new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeRefused)
+
+	case q.Qtype == dns.TypeA && apexIP != nil && (subdomain == "" || q.Name == nameServer):
+		resp = new(dns.Msg)
+		resp.SetReply(req)
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+			A:   apexIP,
+		})
+
+	case q.Qtype == dns.TypeNS && subdomain == "":
+		resp = new(dns.Msg)
+		resp.SetReply(req)
+		resp.Answer = append(resp.Answer, &dns.NS{
+			Hdr: dns.RR_Header{Name: zone, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+			Ns:  nameServer,
+		})
+
+	case q.Qtype == dns.TypeSOA && subdomain == "":
+		resp = new(dns.Msg)
+		resp.SetReply(req)
+		resp.Answer = append(resp.Answer, dnsSOA(zone, serial))
+
 	default:
+		// The name exists (every name under the zone may be C2 traffic) but has
+		// no records of this type, NODATA with the SOA for negative caching
+		resp = new(dns.Msg)
+		resp.SetReply(req)
+	}
+	resp.Authoritative = true
+	if len(resp.Answer) == 0 && resp.Rcode == dns.RcodeSuccess {
+		resp.Ns = append(resp.Ns, dnsSOA(zone, serial))
+	}
+	return resp
+}
+
+// dnsSOA - The zone's SOA record, the minimum TTL is zero so resolvers don't
+// cache negative answers for names an implant is about to query
+func dnsSOA(zone string, serial uint32) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: dnsRecordTTL},
+		Ns:      dnsNSPrefix + zone,
+		Mbox:    "hostmaster." + zone,
+		Serial:  serial,
+		Refresh: 7200,
+		Retry:   3600,
+		Expire:  1209600,
+		Minttl:  0,
 	}
-	return nil
 }
 
 // Canary -> valid? -> trigger alert event
@@ -243,11 +459,16 @@ func handleCanary(req *dns.Msg) *dns.Msg {
 func handleTXT(domain string, subdomain string, req *dns.Msg) *dns.Msg {
 
 	q := req.Question[0]
-	fields := strings.Split(subdomain, ".")
-
 	resp := new(dns.Msg)
 	resp.SetReply(req)
-	msgType := strings.ToLower(fields[len(fields)-1])
+
+	// Every case can rely on the number and format of the fields
+	fields, err := parseDNSQuery(subdomain)
+	if err != nil {
+		dnsLog.Infof("Rejected TXT req %#v: %s", subdomain, err)
+		return resp
+	}
+	msgType := fields[len(fields)-1]
 
 	switch msgType {
 
@@ -263,31 +484,25 @@ func handleTXT(domain string, subdomain string, req *dns.Msg) *dns.Msg {
 		resp.Answer = append(resp.Answer, txt)
 
 	case blockReqMsg: // Get block: _(nonce).(start).(stop).(block id).b.example.com
-		if len(fields) == 5 {
-			startIndex := fields[1]
-			stopIndex := fields[2]
-			blockID := fields[3]
-			txt := &dns.TXT{
-				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
-				Txt: dnsSendBlocks(blockID, startIndex, stopIndex),
-			}
-			resp.Answer = append(resp.Answer, txt)
-		} else {
-			dnsLog.Infof("Block request has invalid number of fields %d expected %d", len(fields), 5)
+		startIndex := fields[1]
+		stopIndex := fields[2]
+		blockID := fields[3]
+		txt := &dns.TXT{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
+			Txt: dnsSendBlocks(blockID, startIndex, stopIndex),
 		}
+		resp.Answer = append(resp.Answer, txt)
 
-	case clearBlockMsg: // Clear block: _(nonce).(block id)._cb.example.com
-		if len(fields) == 3 {
-			result := 0
-			if clearSendBlock(fields[1]) {
-				result = 1
-			}
-			txt := &dns.TXT{
-				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
-				Txt: []string{fmt.Sprintf("%d", result)},
-			}
-			resp.Answer = append(resp.Answer, txt)
+	case "_" + clearBlockMsg, clearBlockMsg: // Clear block: (nonce).(block id)._cb.example.com
+		result := 0
+		if clearSendBlock(fields[1]) {
+			result = 1
 		}
+		txt := &dns.TXT{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
+			Txt: []string{fmt.Sprintf("%d", result)},
+		}
+		resp.Answer = append(resp.Answer, txt)
 
 	case "_" + sessionInitMsg:
 		fallthrough
@@ -302,9 +517,9 @@ func handleTXT(domain string, subdomain string, req *dns.Msg) *dns.Msg {
 		}
 		resp.Answer = append(resp.Answer, txt)
 
-	case "_" + sessionEnvelopeMsg:
+	case "_" + sessionEnvelopeMsg, "_" + sessionBatchMsg, "_" + sessionPartMsg:
 		fallthrough
-	case sessionEnvelopeMsg:
+	case sessionEnvelopeMsg, sessionBatchMsg, sessionPartMsg:
 		result, err := dnsSessionEnvelope(domain, fields)
 		if err != nil {
 			dnsLog.Infof("Error during session init: %v", err)
@@ -315,6 +530,17 @@ func handleTXT(domain string, subdomain string, req *dns.Msg) *dns.Msg {
 		}
 		resp.Answer = append(resp.Answer, txt)
 
+	case streamSegmentMsg: // Stream segment: _(nonce).(index).(stream id).ss.example.com
+		result, err := dnsStreamSegment(fields[2], fields[1])
+		if err != nil {
+			dnsLog.Infof("Error during stream segment: %v", err)
+		}
+		txt := &dns.TXT{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 0},
+			Txt: result,
+		}
+		resp.Answer = append(resp.Answer, txt)
+
 	case sessionPollingMsg:
 		result, err := dnsSessionPoll(domain, fields)
 		if err != nil {
@@ -370,11 +596,27 @@ func getFieldSeq(fields []string) (int, error) {
 		dnsLog.Infof("Failed to decode seq field: %#v", rawSeq)
 		return 0, err
 	}
+	if len(data) < 4 {
+		return -1, fmt.Errorf("Invalid seq field %#v", rawSeq)
+	}
 	index := int(binary.LittleEndian.Uint32(data))
 
 	return index, nil
 }
 
+// getFieldTotal - Implants declare how many segments they sent in the final
+// message of a send, (total).(nonce).(session id)._(msgType), older ones don't (-1)
+func getFieldTotal(fields []string) int {
+	if len(fields) != 4 {
+		return -1
+	}
+	total, err := getFieldSeq(fields)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
 func getFieldSubdata(fields []string) ([]string, error) {
 	if len(fields) < 5 {
 		return []string{}, errors.New("Invalid number of fields in session init message (subdata)")
@@ -387,7 +629,7 @@ func getFieldSubdata(fields []string) ([]string, error) {
 // --------------------------- DNS SESSION START ---------------------------
 
 // Returns an confirmation value (e.g. exit code 0 non-0) and error
-func startDNSSession(domain string, fields []string) ([]string, error) {
+func startDNSSession(domain string, fields []string) (result []string, err error) {
 	dnsLog.Infof("[start session] fields = %#v", fields)
 
 	msgType, err := getFieldMsgType(fields)
@@ -407,10 +649,15 @@ func startDNSSession(domain string, fields []string) ([]string, error) {
 
 	// TODO: We don't have replay protection against the RSA-encrypt
 	// sessionInit messages, but I don't think it's an issue ...
-	encryptedSessionInit, err := dnsSegmentReassemble(nonce)
+	encryptedSessionInit, repeated, err := dnsSegmentReassemble(nonce, getFieldTotal(fields))
+	if repeated != nil {
+		return repeated, nil
+	}
+	defer func() { dnsCompleteMessage(nonce, result) }()
 	if err != nil {
 		return []string{"1"}, err
 	}
+	defer putBuffer(encryptedSessionInit)
 
 	publicKeyPEM, privateKeyPEM, err := certs.GetCertificate(certs.ServerCA, certs.RSAKey, domain)
 	if err != nil {
@@ -426,8 +673,8 @@ func startDNSSession(domain string, fields []string) ([]string, error) {
 		return []string{"1"}, err
 	}
 
-	dnsLog.Debugf("Session Init: %v", encryptedSessionInit)
-	sessionInitData, err := cryptography.RSADecrypt(encryptedSessionInit, privateKey)
+	dnsLog.Debugf("Session Init: %v", *encryptedSessionInit)
+	sessionInitData, err := cryptography.RSADecrypt(*encryptedSessionInit, privateKey)
 	if err != nil {
 		dnsLog.Infof("Failed to decrypt session init msg")
 		return []string{"1"}, err
@@ -443,27 +690,42 @@ func startDNSSession(domain string, fields []string) ([]string, error) {
 		ID:            core.NextSessionID(),
 		Transport:     "dns",
 		RemoteAddress: "n/a",
-		Send:          make(chan *sliverpb.Envelope, 16),
+		Send:          core.NewSendQueue(),
 		RespMutex:     &sync.RWMutex{},
 		Resp:          map[uint64]chan *sliverpb.Envelope{},
 		LastCheckin:   &checkin,
+
+		CheckinInterval: dnsPollInterval,
+		Compression:     negotiateCompression(sessionInit.Compression),
 	}
 
 	aesKey, _ := cryptography.AESKeyFromBytes(sessionInit.Key)
+	dnsSessionsMutex.Lock()
 	sessionID := dnsSessionID()
+	for (*dnsSessions)[sessionID] != nil {
+		sessionID = dnsSessionID()
+	}
 	dnsLog.Infof("Starting new DNS session with id = %s", sessionID)
-	dnsSessionsMutex.Lock()
 	(*dnsSessions)[sessionID] = &DNSSession{
 		ID:          sessionID,
 		Session:     session,
+		lastCheckin: time.Now().UnixNano(),
 		Key:         aesKey,
-		LastCheckin: time.Now(),
+		Batching:    sessionInit.Batching,
+		Multipart:   sessionInit.Multipart,
 		replay:      map[string]bool{},
+		recvStreams: map[string]*RecvStream{},
 	}
 	dnsSessionsMutex.Unlock()
 
-	encryptedSessionID, _ := cryptography.GCMEncrypt(aesKey, []byte(sessionID))
-	result, err := dnsSendOnce(encryptedSessionID)
+	initResp := sessionInitResp(&sliverpb.SessionInitResp{
+		ID:          sessionID,
+		Compression: session.Compression,
+		Batching:    sessionInit.Batching,
+		Multipart:   sessionInit.Multipart,
+	}, 0 < len(sessionInit.Compression) || sessionInit.Batching || sessionInit.Multipart)
+	encryptedSessionID, _ := cryptography.GCMEncrypt(aesKey, initResp)
+	result, err = dnsSendOnce(encryptedSessionID)
 	if err != nil {
 		dnsLog.Infof("Failed to encode message into single result %v", err)
 		return []string{"1"}, err
@@ -474,7 +736,7 @@ func startDNSSession(domain string, fields []string) ([]string, error) {
 
 // --------------------------- DNS SESSION RECV ---------------------------
 
-func dnsSessionEnvelope(domain string, fields []string) ([]string, error) {
+func dnsSessionEnvelope(domain string, fields []string) (result []string, err error) {
 	dnsLog.Infof("[session envelope] fields = %#v", fields)
 
 	msgType, err := getFieldMsgType(fields)
@@ -487,80 +749,280 @@ func dnsSessionEnvelope(domain string, fields []string) ([]string, error) {
 		return []string{"1"}, err
 	}
 
+	if sessionID, err := getFieldSessionID(fields); err == nil {
+		dnsCheckin(sessionID)
+	}
 	if !strings.HasPrefix(msgType, "_") {
 		return dnsSegment(fields)
 	}
 	dnsLog.Infof("Complete envelope received, reassembling ...")
-	encryptedDNSEnvelope, err := dnsSegmentReassemble(nonce)
+	encryptedDNSEnvelope, repeated, err := dnsSegmentReassemble(nonce, getFieldTotal(fields))
+	if repeated != nil {
+		return repeated, nil
+	}
+	defer func() { dnsCompleteMessage(nonce, result) }()
 	if err != nil {
 		return []string{"1"}, errors.New("Failed to reassemble segments")
 	}
+	defer putBuffer(encryptedDNSEnvelope)
 
 	sessionID, err := getFieldSessionID(fields)
 	if err != nil {
 		return []string{"1"}, err
 	}
-	dnsSessionsMutex.Lock()
-	defer dnsSessionsMutex.Unlock()
+	dnsSessionsMutex.RLock()
+	dnsSession, ok := (*dnsSessions)[sessionID]
+	dnsSessionsMutex.RUnlock()
+	if !ok {
+		dnsLog.Infof("Invalid session id '%#v'", sessionID)
+		return []string{"1"}, errors.New("Invalid session ID")
+	}
 
-	if dnsSession, ok := (*dnsSessions)[sessionID]; ok {
-		dnsLog.Infof("Envelope has valid DNS session (%s)", dnsSession.ID)
-		if dnsSession.isReplayAttack(encryptedDNSEnvelope) {
-			dnsLog.Infof("WARNING: Replay attack detected, ignore request")
-			return []string{"1"}, errors.New("Replay attack")
-		}
-		envelopeData, err := cryptography.GCMDecrypt(dnsSession.Key, encryptedDNSEnvelope)
+	dnsLog.Infof("Envelope has valid DNS session (%s)", dnsSession.ID)
+	envelopes, err := dnsSession.openEnvelopes(msgType, *encryptedDNSEnvelope)
+	if err != nil {
+		return []string{"1"}, err
+	}
+	for _, envelope := range envelopes {
+		err = decompressEnvelope(dnsSession.Session, envelope)
 		if err != nil {
-			return []string{"1"}, errors.New("Failed to decrypt DNS envelope")
+			return []string{"1"}, err
+		}
+		dnsLog.Infof("Envelope Type = %#v RespID = %#v", envelope.Type, envelope.ID)
+		handleDNSEnvelope(dnsSession, envelope)
+	}
+	return []string{"0"}, nil
+}
+
+// openEnvelopes - Decrypt a message from the implant, returns the envelopes in it
+// that are complete, the session is only locked while the message is opened
+func (s *DNSSession) openEnvelopes(msgType string, encrypted []byte) ([]*sliverpb.Envelope, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.isReplayAttack(encrypted) {
+		dnsLog.Infof("WARNING: Replay attack detected, ignore request")
+		return nil, errors.New("Replay attack")
+	}
+	envelopeData, err := gcmDecryptPooled(s.Key, encrypted)
+	if err != nil {
+		return nil, errors.New("Failed to decrypt DNS envelope")
+	}
+	defer putBuffer(envelopeData)
+
+	// Unmarshal copies bytes fields, so the buffer can go back right away
+	envelopes := []*sliverpb.Envelope{}
+	switch msgType {
+	case "_" + sessionBatchMsg:
+		batch := &sliverpb.EnvelopeBatch{}
+		err = proto.Unmarshal(*envelopeData, batch)
+		envelopes = batch.Envelopes
+	case "_" + sessionPartMsg:
+		part := &sliverpb.DNSEnvelopePart{}
+		err = proto.Unmarshal(*envelopeData, part)
+		if err == nil {
+			var envelope *sliverpb.Envelope
+			envelope, err = s.recvPart(part)
+			if envelope != nil {
+				envelopes = append(envelopes, envelope)
+			}
 		}
+	default:
 		envelope := &sliverpb.Envelope{}
-		proto.Unmarshal(envelopeData, envelope)
+		err = proto.Unmarshal(*envelopeData, envelope)
+		envelopes = append(envelopes, envelope)
+	}
+	return envelopes, err
+}
 
-		dnsLog.Infof("Envelope Type = %#v RespID = %#v", envelope.Type, envelope.ID)
+// RecvStream - An envelope the implant is sending in parts, parts must arrive
+// in order and are appended to the marshaled envelope as they do
+type RecvStream struct {
+	ID       string
+	Parts    uint32
+	Size     uint64
+	Transfer *core.Transfer
+
+	buf        *bytes.Buffer
+	index      uint32 // Next part
+	lastAccess time.Time
+}
+
+// recvPart - Append a part to its envelope, returns the envelope once the last
+// part arrived and nil until then, must be called with the session's mutex held
+func (s *DNSSession) recvPart(part *sliverpb.DNSEnvelopePart) (*sliverpb.Envelope, error) {
+	stream, ok := s.recvStreams[part.ID]
+	if !ok {
+		if part.Index != 0 || part.Parts < 1 {
+			return nil, fmt.Errorf("Part %d of unknown envelope %#v", part.Index, part.ID)
+		}
+		stream = &RecvStream{
+			ID:       part.ID,
+			Parts:    part.Parts,
+			Size:     part.Size,
+			Transfer: core.Transfers.Start(s.Session, core.TransferRecv, 0, 0, int64(part.Size), part.Parts),
+			buf:      &bytes.Buffer{},
+		}
+		s.recvStreams[part.ID] = stream
+	}
+	stream.lastAccess = time.Now()
+
+	var err error
+	switch {
+	case part.Index != stream.index:
+		err = fmt.Errorf("Envelope %s part %d arrived out of order, expected %d", stream.ID, part.Index, stream.index)
+	case part.Parts != stream.Parts || part.Size != stream.Size:
+		err = fmt.Errorf("Envelope %s part %d has a different header", stream.ID, part.Index)
+	case envelopePartSize < len(part.Data):
+		err = fmt.Errorf("Envelope %s part %d is too large (%d bytes)", stream.ID, part.Index, len(part.Data))
+	case stream.Size < uint64(stream.buf.Len()+len(part.Data)):
+		err = fmt.Errorf("Envelope %s is larger than the declared %d bytes", stream.ID, stream.Size)
+	}
+	if err != nil {
+		stream.close(s, err)
+		return nil, err
+	}
+	stream.buf.Write(part.Data)
+	stream.index++
+	stream.Transfer.Update(int64(stream.buf.Len()), stream.index)
+	if stream.index < stream.Parts {
+		return nil, nil
+	}
+
+	if uint64(stream.buf.Len()) != stream.Size {
+		err = fmt.Errorf("Envelope %s is %d bytes, expected %d", stream.ID, stream.buf.Len(), stream.Size)
+		stream.close(s, err)
+		return nil, err
+	}
+	envelope := &sliverpb.Envelope{}
+	err = proto.Unmarshal(stream.buf.Bytes(), envelope)
+	stream.close(s, err)
+	if err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
 
-		checkin := time.Now()
-		dnsSession.Session.LastCheckin = &checkin
+// close - Drop the stream from its session, must be called with the session's mutex held
+func (r *RecvStream) close(dnsSession *DNSSession, err error) {
+	delete(dnsSession.recvStreams, r.ID)
+	r.buf = nil
+	r.Transfer.Complete(err)
+}
 
-		// Response Envelope or Handler
-		handlers := serverHandlers.GetSessionHandlers()
-		if envelope.ID != 0 {
-			dnsSession.Session.RespMutex.Lock()
-			defer dnsSession.Session.RespMutex.Unlock()
-			if resp, ok := dnsSession.Session.Resp[envelope.ID]; ok {
-				resp <- envelope
+// Drops envelopes that stopped receiving parts in sendBlockTTL
+func expireRecvStreams(now time.Time) {
+	dnsSessionsMutex.RLock()
+	defer dnsSessionsMutex.RUnlock()
+	for _, dnsSession := range *dnsSessions {
+		dnsSession.mutex.Lock()
+		for _, stream := range dnsSession.recvStreams {
+			if sendBlockTTL < now.Sub(stream.lastAccess) {
+				dnsLog.Warnf("Dropping incomplete envelope %s (%d of %d parts)", stream.ID, stream.index, stream.Parts)
+				stream.close(dnsSession, errors.New("recv stream expired"))
 			}
-		} else if handler, ok := handlers[envelope.Type]; ok {
-			handler.(func(*core.Session, []byte))(dnsSession.Session, envelope.Data)
 		}
-		return []string{"0"}, nil
+		dnsSession.mutex.Unlock()
 	}
-	dnsLog.Infof("Invalid session id '%#v'", sessionID)
-	return []string{"1"}, errors.New("Invalid session ID")
 }
 
-// Client should have sent all of the data, attempt to reassemble segments
-func dnsSegmentReassemble(nonce string) ([]byte, error) {
+// dnsCheckin - Every query that names a session means its implant is alive, polls
+// and segments included, returns nil if there's no such session
+func dnsCheckin(sessionID string) *DNSSession {
+	dnsSessionsMutex.RLock()
+	dnsSession, ok := (*dnsSessions)[sessionID]
+	dnsSessionsMutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	dnsSession.Checkin()
+	return dnsSession
+}
+
+// Response Envelope or Handler
+func handleDNSEnvelope(dnsSession *DNSSession, envelope *sliverpb.Envelope) {
+	handlers := serverHandlers.GetSessionHandlers()
+	if envelope.ID != 0 {
+		dnsSession.Session.RespMutex.Lock()
+		defer dnsSession.Session.RespMutex.Unlock()
+		if resp, ok := dnsSession.Session.Resp[envelope.ID]; ok {
+			resp <- envelope
+		}
+	} else if handler, ok := handlers[envelope.Type]; ok {
+		handler.(func(*core.Session, []byte))(dnsSession.Session, envelope.Data)
+	}
+}
+
+// Client should have sent all of the data, attempt to reassemble segments, the
+// data is in a pooled buffer so give it back with putBuffer. Total is the number
+// of segments the implant declared, or -1 if it didn't. A repeated final query
+// gets the result of the message instead, only the first one reassembles it and
+// records its result with dnsCompleteMessage.
+func dnsSegmentReassemble(nonce string, total int) (*[]byte, []string, error) {
 	dnsSegmentReassemblerMutex.Lock()
 	defer dnsSegmentReassemblerMutex.Unlock()
+	if completed, ok := (*dnsCompletedMessages)[nonce]; ok {
+		dnsLog.Infof("Repeated final query for nonce %#v", nonce)
+		if completed.result == nil {
+			// Still being handled, acknowledged without waiting for it
+			return nil, []string{"0"}, nil
+		}
+		return nil, completed.result, nil
+	}
 	if reasm, ok := (*dnsSegmentReassembler)[nonce]; ok {
-		var keys []int
-		for k := range *reasm {
-			keys = append(keys, k)
+		delete((*dnsSegmentReassembler), nonce)
+		(*dnsCompletedMessages)[nonce] = &dnsCompletedMessage{completed: time.Now()}
+		if reasm.conflict {
+			return nil, nil, errors.New("Message has conflicting segments")
+		}
+		if !reasm.complete(total) {
+			dnsLog.Infof("Missing segments, received %d of %d", reasm.received, total)
+			return nil, nil, fmt.Errorf("Incomplete message, received %d segment(s)", reasm.received)
 		}
-		sort.Ints(keys)
-		orderedSubdata := []string{}
-		for _, k := range keys {
-			orderedSubdata = append(orderedSubdata, (*reasm)[k]...)
+		size := reasm.size
+		encoded := getBuffer(size + 8) // Room for padding
+		for index := 0; index < reasm.received; index++ {
+			for _, subdata := range reasm.segments[index] {
+				*encoded = append(*encoded, subdata...)
+			}
 		}
-		data, err := dnsDecodeString(strings.Join(orderedSubdata, ""))
+		data := getBuffer(size + 8) // Same size as encoded, so either can be reused for both
+		var err error
+		*data, err = dnsDecodeAppend(*data, *encoded)
+		putBuffer(encoded)
 		if err != nil {
-			dnsLog.Infof("Failed to decode session init: %v", err)
-			return nil, err
+			putBuffer(data)
+			dnsLog.Infof("Failed to decode message %#v: %v", nonce, err)
+			return nil, nil, err
+		}
+		return data, nil, nil
+	}
+	return nil, nil, fmt.Errorf("Invalid nonce '%#v' (segment reassembler)", nonce)
+}
+
+func dnsCompleteMessage(nonce string, result []string) {
+	dnsSegmentReassemblerMutex.Lock()
+	defer dnsSegmentReassemblerMutex.Unlock()
+	if completed, ok := (*dnsCompletedMessages)[nonce]; ok {
+		completed.result = result
+	}
+}
+
+// Drops completed messages after dnsCompletedTTL, and messages that stopped
+// receiving segments, most likely because the implant that was sending them is gone
+func expireReassemblies(now time.Time) {
+	dnsSegmentReassemblerMutex.Lock()
+	defer dnsSegmentReassemblerMutex.Unlock()
+	for nonce, completed := range *dnsCompletedMessages {
+		if dnsCompletedTTL < now.Sub(completed.completed) {
+			delete(*dnsCompletedMessages, nonce)
+		}
+	}
+	for nonce, reasm := range *dnsSegmentReassembler {
+		if sendBlockTTL < now.Sub(reasm.updated) {
+			dnsLog.Warnf("Dropping incomplete message %#v (%d segments)", nonce, reasm.received)
+			delete(*dnsSegmentReassembler, nonce)
 		}
-		delete((*dnsSegmentReassembler), nonce)
-		return data, nil
 	}
-	return nil, fmt.Errorf("Invalid nonce '%#v' (session init reassembler)", nonce)
 }
 
 // The domain is only a segment of the startDNSSession message, so we just store the data
@@ -577,11 +1039,19 @@ func dnsSegment(fields []string) ([]string, error) {
 	if err != nil {
 		return []string{"1"}, err
 	}
+	if _, ok := (*dnsCompletedMessages)[nonce]; ok {
+		// A resolver repeating a query after the message was complete, it must not
+		// start a new message with the same nonce
+		return []string{"0"}, nil
+	}
 	if _, ok := (*dnsSegmentReassembler)[nonce]; !ok {
-		(*dnsSegmentReassembler)[nonce] = &map[int][]string{}
+		(*dnsSegmentReassembler)[nonce] = newDNSReassembly()
 	}
 	if reasm, ok := (*dnsSegmentReassembler)[nonce]; ok {
-		(*reasm)[index] = subdata
+		if err := reasm.add(index, subdata); err != nil {
+			dnsLog.Warnf("Nonce %#v: %s", nonce, err)
+			return []string{"1"}, err
+		}
 		return []string{"0"}, nil
 	}
 	dnsLog.Infof("Invalid nonce (session segment): %#v", nonce)
@@ -605,10 +1075,10 @@ func getDomainKeyFor(domain string) ([]string, error) {
 
 // Send all response data in a single TXT record, limited to 65535 bytes
 func dnsSendOnce(rawData []byte) ([]string, error) {
-	if 65535 <= base64.RawStdEncoding.EncodedLen(len(rawData)) {
+	if 65535 <= encoders.DNSBase64.EncodedLen(len(rawData)) {
 		return nil, errors.New("Response too large to encode into one TXT record")
 	}
-	data := base64.RawStdEncoding.EncodeToString(rawData)
+	data := base64EncodeToString(rawData)
 	dnsLog.Infof("Encoding single resp: %#v", data)
 	txts := []string{}
 	size := int(math.Ceil(float64(len(data)) / 255.0))
@@ -629,9 +1099,10 @@ func dnsSessionPoll(domain string, fields []string) ([]string, error) {
 	if err != nil {
 		return []string{"1"}, errors.New("invalid session id (session poll)")
 	}
-	dnsSessionsMutex.Lock()
-	dnsSession := (*dnsSessions)[sessionID]
-	dnsSessionsMutex.Unlock()
+	dnsSession := dnsCheckin(sessionID)
+	if dnsSession == nil {
+		return []string{"1"}, fmt.Errorf("Invalid session id '%#v' (session poll)", sessionID)
+	}
 
 	isDrained := false
 	envelopes := []*sliverpb.Envelope{}
@@ -648,42 +1119,94 @@ func dnsSessionPoll(domain string, fields []string) ([]string, error) {
 	if 0 < len(envelopes) {
 		dnsLog.Infof("%d new message(s) for session id %#v", len(envelopes), sessionID)
 		dnsPoll := &sliverpb.DNSPoll{}
+		batch := &sliverpb.EnvelopeBatch{}
+		batchSize := 0
 		for _, envelope := range envelopes {
+			// Streams are read from the envelope and compressed a segment at a time
+			if streamMinProtocolVersion <= dnsSession.Session.ProtocolVersion && streamSegmentSize < proto.Size(envelope) {
+				stream, err := storeSendStream(dnsSession, envelope)
+				if err != nil {
+					dnsLog.Infof("Failed to encode envelope %v", err)
+					continue
+				}
+				dnsPoll.Blocks = append(dnsPoll.Blocks, &sliverpb.DNSBlockHeader{
+					ID:       stream.ID,
+					Segments: stream.Segments,
+				})
+				continue
+			}
+			envelope = compressEnvelope(dnsSession.Session, envelope)
 			data, err := proto.Marshal(envelope)
 			if err != nil {
 				dnsLog.Infof("Failed to encode envelope %v", err)
 				continue
 			}
+			if dnsSession.Batching && len(data) < batchEnvelopeSize {
+				if batchMaxSize < batchSize+len(data) {
+					dnsPoll.Blocks = append(dnsPoll.Blocks, storeSendBatch(dnsSession, batch))
+					batch = &sliverpb.EnvelopeBatch{}
+					batchSize = 0
+				}
+				batch.Envelopes = append(batch.Envelopes, envelope)
+				batchSize += len(data)
+				continue
+			}
 
-			encryptedEnvelopeData, err := cryptography.GCMEncrypt(dnsSession.Key, data)
+			encryptedEnvelopeData, err := gcmEncryptPooled(dnsSession.Key, data)
 			if err != nil {
 				dnsLog.Infof("Failed to encrypt poll data %v", err)
 				return []string{"1"}, errors.New("Failed to encrypt dns poll data")
 			}
 
-			blockID, size := storeSendBlocks(encryptedEnvelopeData)
+			blocks := (len(*encryptedEnvelopeData) + byteBlockSize - 1) / byteBlockSize
+			transfer := core.Transfers.Start(dnsSession.Session, core.TransferSend, envelope.ID, envelope.Type,
+				int64(len(*encryptedEnvelopeData)), uint32(blocks))
+			blockID, size := storeSendBlocks(*encryptedEnvelopeData, transfer)
+			putBuffer(encryptedEnvelopeData)
 			dnsPoll.Blocks = append(dnsPoll.Blocks, &sliverpb.DNSBlockHeader{
 				ID:   blockID,
 				Size: uint32(size),
 			})
 		}
+		if 0 < len(batch.Envelopes) {
+			dnsPoll.Blocks = append(dnsPoll.Blocks, storeSendBatch(dnsSession, batch))
+		}
 		pollData, err := proto.Marshal(dnsPoll)
 		if err != nil {
 			dnsLog.Infof("Failed to encode envelope %v", err)
 			return []string{"1"}, errors.New("Failed to encode dns poll data")
 		}
-		encryptedPollData, err := cryptography.GCMEncrypt(dnsSession.Key, pollData)
+		encryptedPollData, err := gcmEncryptPooled(dnsSession.Key, pollData)
 		if err != nil {
 			dnsLog.Infof("Failed to encrypt poll data %v", err)
 			return []string{"1"}, errors.New("Failed to encrypt dns poll data")
 		}
-		return dnsSendOnce(encryptedPollData)
+		defer putBuffer(encryptedPollData)
+		return dnsSendOnce(*encryptedPollData)
 	}
 	dnsLog.Infof("No new message for session id %#v", sessionID)
 	return []string{"0"}, nil
 }
 
-// Send blocks of data via multiple DNS TXT responses
+// Stores a batch of small envelopes as one block, batches are too small to
+// be worth tracking as transfers
+func storeSendBatch(dnsSession *DNSSession, batch *sliverpb.EnvelopeBatch) *sliverpb.DNSBlockHeader {
+	dnsLog.Infof("Batching %d envelope(s)", len(batch.Envelopes))
+	data, _ := proto.Marshal(batch)
+	encryptedBatchData, _ := gcmEncryptPooled(dnsSession.Key, data)
+	blockID, size := storeSendBlocks(*encryptedBatchData, nil)
+	putBuffer(encryptedBatchData)
+	return &sliverpb.DNSBlockHeader{
+		ID:    blockID,
+		Size:  uint32(size),
+		Batch: true,
+	}
+}
+
+// Send blocks of data via multiple DNS TXT responses, implants request ranges
+// of up to 200 blocks and keep several of them in flight, they may arrive in
+// any order and be repeated by a retry or a resolver, so nothing here depends
+// on what was requested before
 func dnsSendBlocks(blockID string, startIndex string, stopIndex string) []string {
 	start, err := strconv.Atoi(startIndex)
 	if err != nil {
@@ -700,68 +1223,321 @@ func dnsSendBlocks(blockID string, startIndex string, stopIndex string) []string
 
 	dnsLog.Infof("Send blocks %d to %d for ID %s", start, stop, blockID)
 
-	sendBlocksMutex.Lock()
-	defer sendBlocksMutex.Unlock()
-	respBlocks := []string{}
-	if block, ok := (*sendBlocks)[blockID]; ok {
-		for index := start; index < stop; index++ {
-			if index < len(block.Data) {
-				respBlocks = append(respBlocks, block.Data[index])
-			}
-		}
-		dnsLog.Infof("Sending %d response block(s)", len(respBlocks))
-		return respBlocks
+	sendBlocksMutex.RLock()
+	block, ok := (*sendBlocks)[blockID]
+	sendBlocksMutex.RUnlock()
+	if !ok {
+		dnsLog.Infof("Invalid block ID: %#v", blockID)
+		return []string{}
+	}
+	block.touch(time.Now())
+	if len(block.Data) < stop {
+		stop = len(block.Data)
 	}
-	dnsLog.Infof("Invalid block ID: %#v", blockID)
-	return []string{}
+	if start < 0 || stop <= start {
+		return []string{}
+	}
+	// Data is never modified once stored, so the range can be sent as is
+	respBlocks := block.Data[start:stop]
+	sent := int(atomic.AddInt64(&block.sent, int64(len(respBlocks))))
+	if len(block.Data) < sent {
+		sent = len(block.Data)
+	}
+	block.Transfer.Update(int64(sent*byteBlockSize), uint32(sent))
+	dnsLog.Infof("Sending %d response block(s)", len(respBlocks))
+	return respBlocks
 }
 
 // Clear send blocks of data from memory
 func clearSendBlock(blockID string) bool {
 	sendBlocksMutex.Lock()
 	defer sendBlocksMutex.Unlock()
-	if _, ok := (*sendBlocks)[blockID]; ok {
-		delete(*sendBlocks, blockID)
+	if block, ok := (*sendBlocks)[blockID]; ok {
+		block.Transfer.Complete(nil)
+		removeSendBlock(block)
 		return true
 	}
 	return false
 }
 
-// Stores encoded blocks fo data into "sendBlocks"
-func storeSendBlocks(data []byte) (string, int) {
-	blockID := generateBlockID()
+// removeSendBlock - Must be called with sendBlocksMutex held
+func removeSendBlock(block *SendBlock) {
+	delete(*sendBlocks, block.ID)
+	sendBlocksSize -= block.size
+}
+
+// dropSendBlock - An implant will never clear the block, must be called with
+// sendBlocksMutex held
+func dropSendBlock(block *SendBlock, reason error) {
+	dnsLog.Warnf("Dropping send block %s (%d bytes): %s", block.ID, block.size, reason)
+	block.Transfer.Complete(reason)
+	removeSendBlock(block)
+	sendBlocksStats.EvictedBytes += uint64(block.size)
+}
+
+// Drops send blocks and streams that were not read from in sendBlockTTL, most
+// likely because the implant that was reading them is gone
+func expireSendBlocks(now time.Time) {
+	sendBlocksMutex.Lock()
+	for _, block := range *sendBlocks {
+		if sendBlockTTL < now.Sub(block.lastRead()) {
+			dropSendBlock(block, errors.New("send block expired"))
+			sendBlocksStats.Expired++
+		}
+	}
+	sendBlocksMutex.Unlock()
+
+	sendStreamsMutex.RLock()
+	streams := []*SendStream{}
+	for _, stream := range *sendStreams {
+		streams = append(streams, stream)
+	}
+	sendStreamsMutex.RUnlock()
+	for _, stream := range streams {
+		stream.mutex.Lock()
+		if sendBlockTTL < now.Sub(stream.lastAccess) {
+			dnsLog.Warnf("Dropping send stream %s", stream.ID)
+			stream.close(errors.New("send stream expired"))
+		}
+		stream.mutex.Unlock()
+	}
+}
+
+// Drops the least recently read send blocks until size more bytes fit under
+// sendBlocksMaxSize, must be called with sendBlocksMutex held
+func evictSendBlocks(size int) {
+	for sendBlocksMaxSize < sendBlocksSize+size && 0 < len(*sendBlocks) {
+		var oldest *SendBlock
+		for _, block := range *sendBlocks {
+			if oldest == nil || block.lastRead().Before(oldest.lastRead()) {
+				oldest = block
+			}
+		}
+		dropSendBlock(oldest, errors.New("send block evicted"))
+		sendBlocksStats.Evicted++
+	}
+}
+
+// GetSendBlockStats - Memory used by DNS send blocks and eviction counters
+func GetSendBlockStats() SendBlockStats {
+	sendBlocksMutex.RLock()
+	defer sendBlocksMutex.RUnlock()
+	stats := *sendBlocksStats
+	stats.Blocks = len(*sendBlocks)
+	stats.Size = sendBlocksSize
+	return stats
+}
 
+// Stores encoded blocks fo data into "sendBlocks", the transfer (if any) follows
+// the implant fetching them
+func storeSendBlocks(data []byte, transfer *core.Transfer) (string, int) {
+	// byteBlockSize is a multiple of 3, so encoding the data in one go is the
+	// same as encoding each block on its own
+	encoded := base64EncodeToString(data)
+	encodedBlockSize := encoders.DNSBase64.EncodedLen(byteBlockSize)
 	sendBlock := &SendBlock{
-		ID:   blockID,
-		Data: []string{},
+		Data:     make([]string, 0, (len(encoded)+encodedBlockSize-1)/encodedBlockSize),
+		Transfer: transfer,
+		size:     len(encoded),
 	}
-	for index := 0; index < len(data); index += byteBlockSize {
-		start := index
-		stop := index + byteBlockSize
-		if len(data) < stop {
-			stop = len(data)
+	for start := 0; start < len(encoded); start += encodedBlockSize {
+		stop := start + encodedBlockSize
+		if len(encoded) < stop {
+			stop = len(encoded)
 		}
-		encoded := base64.RawStdEncoding.EncodeToString(data[start:stop])
-		dnsLog.Infof("Encoded block is %d bytes", len(encoded))
-		sendBlock.Data = append(sendBlock.Data, encoded)
+		sendBlock.Data = append(sendBlock.Data, encoded[start:stop])
 	}
+	dnsLog.Infof("Encoded %d bytes into %d block(s)", len(data), len(sendBlock.Data))
 	sendBlocksMutex.Lock()
+	evictSendBlocks(sendBlock.size)
+	sendBlock.touch(time.Now())
+	sendBlock.ID = generateBlockID()
+	for (*sendBlocks)[sendBlock.ID] != nil {
+		sendBlock.ID = generateBlockID()
+	}
 	(*sendBlocks)[sendBlock.ID] = sendBlock
+	sendBlocksSize += sendBlock.size
 	sendBlocksMutex.Unlock()
 	return sendBlock.ID, len(sendBlock.Data)
 }
 
+// --------------------------- DNS SESSION STREAM ---------------------------
+
+// SendStream - An envelope sent in segments, a segment is read, compressed and
+// encrypted when the implant asks for it, which drops the previous segment, and
+// asking past the last segment closes the stream
+type SendStream struct {
+	ID       string
+	Segments uint32
+	Transfer *core.Transfer
+
+	key     cryptography.AESKey
+	source  io.Reader
+	buf     []byte
+	index   uint32 // Segment stored in blockID
+	blockID string
+	header  []byte
+	mutex   sync.Mutex
+
+	lastAccess time.Time
+}
+
+func storeSendStream(dnsSession *DNSSession, envelope *sliverpb.Envelope) (*SendStream, error) {
+	source, size, err := envelopeReader(envelope)
+	if err != nil {
+		return nil, err
+	}
+	segments := (size + streamSegmentSize - 1) / streamSegmentSize
+	stream := &SendStream{
+		Segments: uint32(segments),
+		Transfer: core.Transfers.Start(dnsSession.Session, core.TransferSend, envelope.ID, envelope.Type,
+			int64(size), uint32(segments)),
+		key:    dnsSession.Key,
+		source: source,
+		index:  math.MaxUint32, // Nothing stored yet
+
+		lastAccess: time.Now(),
+	}
+	sendStreamsMutex.Lock()
+	stream.ID = generateBlockID()
+	for (*sendStreams)[stream.ID] != nil {
+		stream.ID = generateBlockID()
+	}
+	(*sendStreams)[stream.ID] = stream
+	sendStreamsMutex.Unlock()
+	return stream, nil
+}
+
+// envelopeReader - The wire encoding of an envelope and its size, read from the
+// envelope's payload instead of a copy of it. The payload is encoded last, which
+// decodes the same as proto.Marshal's field order.
+func envelopeReader(envelope *sliverpb.Envelope) (io.Reader, int, error) {
+	head, err := proto.Marshal(&sliverpb.Envelope{
+		ID:                 envelope.ID,
+		Type:               envelope.Type,
+		UnknownMessageType: envelope.UnknownMessageType,
+		BandwidthLimit:     envelope.BandwidthLimit,
+		Padding:            envelope.Padding,
+		Compressed:         envelope.Compressed,
+		Err:                envelope.Err,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if 0 < len(envelope.Data) {
+		head = append(head, envelopeDataKey)
+		head = append(head, proto.EncodeVarint(uint64(len(envelope.Data)))...)
+	}
+	return io.MultiReader(bytes.NewReader(head), bytes.NewReader(envelope.Data)), len(head) + len(envelope.Data), nil
+}
+
+// Returns the encrypted header of the segment's send blocks, resolvers may
+// repeat a query so the current segment can be asked for more than once
+func dnsStreamSegment(streamID string, rawIndex string) ([]string, error) {
+	index, err := strconv.ParseUint(rawIndex, 10, 32)
+	if err != nil {
+		return []string{"1"}, err
+	}
+	sendStreamsMutex.RLock()
+	stream, ok := (*sendStreams)[streamID]
+	sendStreamsMutex.RUnlock()
+	if !ok {
+		return []string{"1"}, fmt.Errorf("Invalid stream ID '%#v'", streamID)
+	}
+
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.lastAccess = time.Now()
+	if uint32(index) == stream.Segments {
+		stream.close(nil)
+		return []string{"0"}, nil
+	}
+	if uint32(index) != stream.index {
+		if uint32(index) != stream.index+1 {
+			return []string{"1"}, fmt.Errorf("Stream %s segment %d requested out of order", stream.ID, index)
+		}
+		err := stream.next()
+		if err != nil {
+			stream.close(err)
+			return []string{"1"}, err
+		}
+	}
+	return dnsSendOnce(stream.header)
+}
+
+// next - Read, compress, encrypt and store the next segment, must be called
+// with the stream's lock held
+func (s *SendStream) next() error {
+	if s.blockID != "" {
+		clearSendBlock(s.blockID)
+	}
+	if s.buf == nil {
+		s.buf = make([]byte, streamSegmentSize)
+	}
+	n, err := io.ReadFull(s.source, s.buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	compressed := &bytes.Buffer{}
+	writer, _ := flate.NewWriter(compressed, flate.BestSpeed)
+	writer.Write(s.buf[:n])
+	writer.Close()
+	encrypted, err := gcmEncryptPooled(s.key, compressed.Bytes())
+	if err != nil {
+		return err
+	}
+	blockID, size := storeSendBlocks(*encrypted, nil)
+	putBuffer(encrypted)
+	headerData, _ := proto.Marshal(&sliverpb.DNSBlockHeader{ID: blockID, Size: uint32(size)})
+	header, err := cryptography.GCMEncrypt(s.key, headerData)
+	if err != nil {
+		return err
+	}
+	s.index++
+	s.blockID = blockID
+	s.header = header
+	done := int64(s.index) * streamSegmentSize
+	s.Transfer.Update(done, s.index)
+	return nil
+}
+
+// close - Drop the stream and its stored segment, must be called with the
+// stream's lock held
+func (s *SendStream) close(err error) {
+	if s.blockID != "" {
+		clearSendBlock(s.blockID)
+		s.blockID = ""
+	}
+	sendStreamsMutex.Lock()
+	delete(*sendStreams, s.ID)
+	sendStreamsMutex.Unlock()
+	s.Transfer.Complete(err)
+}
+
 // --------------------------- HELPERS ---------------------------
 
-// Unique IDs, no need for secure random
+// Unique IDs, no need for secure random, callers check for collisions
 func generateBlockID() string {
-	insecureRand.Seed(time.Now().UnixNano())
-	blockID := []rune{}
-	for i := 0; i < blockIDSize; i++ {
-		index := insecureRand.Intn(len(dnsCharSet))
-		blockID = append(blockID, dnsCharSet[index])
+	return randomID(blockIDSize)
+}
+
+// randomID - Characters from dnsCharSet, idRand is not safe for concurrent use
+func randomID(size int) string {
+	idRandMutex.Lock()
+	defer idRandMutex.Unlock()
+	id := []rune{}
+	for i := 0; i < size; i++ {
+		index := idRand.Intn(len(dnsCharSet))
+		id = append(id, dnsCharSet[index])
 	}
-	return string(blockID)
+	return string(id)
+}
+
+// secureSeed - Seed for idRand, so IDs don't repeat across server restarts
+func secureSeed() int64 {
+	buf := make([]byte, 8)
+	secureRand.Read(buf)
+	return int64(binary.LittleEndian.Uint64(buf))
 }
 
 func fingerprintSHA256(block *pem.Block) string {
@@ -782,37 +1558,39 @@ func randomIP() net.IP {
 
 // --------------------------- ENCODER ---------------------------
 
-var base32Alphabet = "ab1c2d3e4f5g6h7j8k9m0npqrtuvwxyz"
-var sliverBase32 = base32.NewEncoding(base32Alphabet)
-
-// EncodeToString encodes the given byte slice in base32
+// EncodeToString encodes the given byte slice in base32, see encoders.DNSBase32
 func dnsEncodeToString(input []byte) string {
-	return strings.TrimRight(sliverBase32.EncodeToString(input), "=")
+	buf := getBuffer(encoders.DNSBase32.EncodedLen(len(input)))
+	encoded := (*buf)[:encoders.DNSBase32.EncodedLen(len(input))]
+	encoders.DNSBase32.Encode(encoded, input)
+	value := string(encoded)
+	putBuffer(buf)
+	return value
 }
 
 // DecodeString decodes the given base32 encoded bytes
 func dnsDecodeString(raw string) ([]byte, error) {
-	pad := 8 - (len(raw) % 8)
-	padded := []byte(raw)
-	if pad != 8 {
-		padded = make([]byte, len(raw)+pad)
-		copy(padded, raw)
-		for index := 0; index < pad; index++ {
-			padded[len(raw)+index] = '='
-		}
+	return dnsDecodeAppend(nil, []byte(raw))
+}
+
+// dnsDecodeAppend - Decodes raw and appends the result to dst
+func dnsDecodeAppend(dst []byte, raw []byte) ([]byte, error) {
+	size := encoders.DNSBase32.DecodedLen(len(raw))
+	start := len(dst)
+	if cap(dst)-start < size {
+		grown := make([]byte, start, start+size)
+		copy(grown, dst)
+		dst = grown
 	}
-	// dnsLog.Infof("[base32] %#v", string(padded))
-	return sliverBase32.DecodeString(string(padded))
+	n, err := encoders.DNSBase32.Decode(dst[start:start+size], raw)
+	if err != nil {
+		return nil, err
+	}
+	return dst[:start+n], nil
 }
 
 // SessionIDs are public parameters in this use case
 // so it's only important that they're unique
 func dnsSessionID() string {
-	insecureRand.Seed(time.Now().UnixNano())
-	sessionID := []rune{}
-	for i := 0; i < sessionIDSize; i++ {
-		index := insecureRand.Intn(len(dnsCharSet))
-		sessionID = append(sessionID, dnsCharSet[index])
-	}
-	return "_" + string(sessionID)
+	return "_" + randomID(sessionIDSize)
 }
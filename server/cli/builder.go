@@ -0,0 +1,99 @@
+package cli
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	clientAssets "github.com/bishopfox/sliver/client/assets"
+	"github.com/bishopfox/sliver/client/transport"
+	"github.com/bishopfox/sliver/server/assets"
+	"github.com/bishopfox/sliver/server/builder"
+	"github.com/bishopfox/sliver/server/certs"
+	"github.com/spf13/cobra"
+)
+
+var cmdBuilder = &cobra.Command{
+	Use:   "builder",
+	Short: "Start an external builder that builds implants for a server",
+	Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		configPath, err := cmd.Flags().GetString(configFlagStr)
+		if err != nil {
+			fmt.Printf("Failed to parse --%s flag %s\n", configFlagStr, err)
+			os.Exit(1)
+		}
+		if configPath == "" {
+			fmt.Printf("Must specify --%s\n", configFlagStr)
+			os.Exit(1)
+		}
+		operatorConfig, err := clientAssets.ReadConfig(configPath)
+		if err != nil {
+			fmt.Printf("Failed to read operator config %s\n", err)
+			os.Exit(1)
+		}
+
+		name, err := cmd.Flags().GetString(nameFlagStr)
+		if err != nil {
+			fmt.Printf("Failed to parse --%s flag %s\n", nameFlagStr, err)
+			os.Exit(1)
+		}
+		if name == "" {
+			name, _ = os.Hostname()
+		}
+
+		targets := builder.DefaultTargets()
+		rawTargets, err := cmd.Flags().GetString(targetsFlagStr)
+		if err != nil {
+			fmt.Printf("Failed to parse --%s flag %s\n", targetsFlagStr, err)
+			os.Exit(1)
+		}
+		if rawTargets != "" {
+			targets = strings.Split(rawTargets, ",")
+		}
+
+		appDir := assets.GetRootAppDir()
+		logFile := initLogging(appDir)
+		defer logFile.Close()
+
+		assets.Setup(false)
+		certs.SetupCAs()
+
+		rpc, ln, err := transport.MTLSConnect(operatorConfig)
+		if err != nil {
+			fmt.Printf("Connection to server failed %s\n", err)
+			os.Exit(1)
+		}
+		defer ln.Close()
+
+		fmt.Printf("Builder %s connected to %s:%d\n", name, operatorConfig.LHost, operatorConfig.LPort)
+		err = builder.StartBuilder(rpc, &builder.Config{
+			Name:     name,
+			Targets:  targets,
+			Operator: operatorConfig,
+		})
+		if err != nil {
+			fmt.Printf("Builder stopped: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
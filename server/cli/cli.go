@@ -25,11 +25,13 @@ import (
 	"path"
 	"strings"
 
+	"github.com/bishopfox/sliver/client/theme"
 	"github.com/bishopfox/sliver/client/version"
 	"github.com/bishopfox/sliver/server/assets"
 	"github.com/bishopfox/sliver/server/certs"
 	"github.com/bishopfox/sliver/server/configs"
 	"github.com/bishopfox/sliver/server/console"
+	"github.com/bishopfox/sliver/server/core"
 	"github.com/bishopfox/sliver/server/daemon"
 
 	"github.com/spf13/cobra"
@@ -50,10 +52,17 @@ const (
 	lportFlagStr = "lport"
 	saveFlagStr  = "save"
 
+	// Builder flags
+	configFlagStr  = "config"
+	targetsFlagStr = "targets"
+
 	// Cert flags
 	caTypeFlagStr = "type"
 	loadFlagStr   = "load"
 
+	// Console flags
+	noColorFlagStr = "no-color"
+
 	logFileName = "console.log"
 )
 
@@ -70,6 +79,9 @@ func initLogging(appDir string) *os.File {
 
 func init() {
 
+	// Console
+	rootCmd.Flags().Bool(noColorFlagStr, false, "disable console colors")
+
 	// Unpack
 	cmdUnpack.Flags().BoolP(forceFlagStr, "f", false, "Force unpack and overwrite")
 	rootCmd.AddCommand(cmdUnpack)
@@ -92,6 +104,12 @@ func init() {
 		fmt.Sprintf("ca type (%s)", strings.Join(validCATypes(), ", ")))
 	rootCmd.AddCommand(cmdImportCA)
 
+	// Builder
+	cmdBuilder.Flags().StringP(configFlagStr, "c", "", "operator config file used to connect to the server")
+	cmdBuilder.Flags().StringP(nameFlagStr, "n", "", "builder name (default: hostname)")
+	cmdBuilder.Flags().StringP(targetsFlagStr, "t", "", "comma separated goos/goarch targets to build (default: all)")
+	rootCmd.AddCommand(cmdBuilder)
+
 	// Version
 	rootCmd.AddCommand(cmdVersion)
 }
@@ -104,6 +122,10 @@ var rootCmd = &cobra.Command{
 
 		// Root command starts the server normally
 
+		if noColor, _ := cmd.Flags().GetBool(noColorFlagStr); noColor {
+			theme.DisableColor()
+		}
+
 		appDir := assets.GetRootAppDir()
 		logFile := initLogging(appDir)
 		defer logFile.Close()
@@ -112,6 +134,10 @@ var rootCmd = &cobra.Command{
 		certs.SetupCAs()
 
 		serverConfig := configs.GetServerConfig()
+		err := core.SetSendQueue(serverConfig.SendQueue.Size, serverConfig.SendQueue.Policy)
+		if err != nil {
+			fmt.Printf("[!] %s, using the default\n", err)
+		}
 		if serverConfig.DaemonMode {
 			daemon.Start()
 		} else {
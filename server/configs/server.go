@@ -58,11 +58,19 @@ type DaemonConfig struct {
 	Port int    `json:"port"`
 }
 
+// SendQueueConfig - Envelopes queued per session for the transport, and what
+// requests do when the queue is full (block, drop-oldest or fail)
+type SendQueueConfig struct {
+	Size   int    `json:"size"`
+	Policy string `json:"policy"`
+}
+
 // ServerConfig - Server config
 type ServerConfig struct {
-	DaemonMode   bool          `json:"daemon_mode"`
-	DaemonConfig *DaemonConfig `json:"daemon"`
-	Logs         *LogConfig    `json:"logs"`
+	DaemonMode   bool             `json:"daemon_mode"`
+	DaemonConfig *DaemonConfig    `json:"daemon"`
+	Logs         *LogConfig       `json:"logs"`
+	SendQueue    *SendQueueConfig `json:"send_queue"`
 }
 
 // Save - Save config file to disk
@@ -125,5 +133,9 @@ func getDefaultServerConfig() *ServerConfig {
 			GRPCUnaryPayloads:  true,
 			GRPCStreamPayloads: true,
 		},
+		SendQueue: &SendQueueConfig{
+			Size:   16,
+			Policy: "block",
+		},
 	}
 }
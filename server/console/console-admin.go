@@ -29,6 +29,7 @@ import (
 	"regexp"
 
 	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/client/theme"
 	"github.com/bishopfox/sliver/server/certs"
 	"github.com/bishopfox/sliver/server/core"
 	"github.com/bishopfox/sliver/server/transport"
@@ -37,32 +38,59 @@ import (
 )
 
 const (
-	// ANSI Colors
-	normal    = "\033[0m"
-	black     = "\033[30m"
-	red       = "\033[31m"
-	green     = "\033[32m"
-	orange    = "\033[33m"
-	blue      = "\033[34m"
-	purple    = "\033[35m"
-	cyan      = "\033[36m"
-	gray      = "\033[37m"
-	bold      = "\033[1m"
-	clearln   = "\r\x1b[2K"
-	upN       = "\033[%dA"
-	downN     = "\033[%dB"
-	underline = "\033[4m"
+	// ANSI cursor controls, these don't change with the theme
+	clearln = "\r\x1b[2K"
+	upN     = "\033[%dA"
+	downN   = "\033[%dB"
+)
+
+var (
+	// ANSI Colors, set by the active theme
+	normal    string
+	black     string
+	red       string
+	green     string
+	orange    string
+	blue      string
+	purple    string
+	cyan      string
+	gray      string
+	bold      string
+	underline string
 
 	// Info - Display colorful information
-	Info = bold + cyan + "[*] " + normal
+	Info string
 	// Warn - Warn a user
-	Warn = bold + red + "[!] " + normal
+	Warn string
 	// Debug - Display debug information
-	Debug = bold + purple + "[-] " + normal
+	Debug string
 	// Woot - Display success
-	Woot = bold + green + "[$] " + normal
+	Woot string
 )
 
+func init() {
+	theme.OnApply(loadTheme)
+}
+
+func loadTheme(t *theme.Theme) {
+	normal = t.Normal
+	black = t.Black
+	red = t.Red
+	green = t.Green
+	orange = t.Orange
+	blue = t.Blue
+	purple = t.Purple
+	cyan = t.Cyan
+	gray = t.Gray
+	bold = t.Bold
+	underline = t.Underline
+
+	Info = bold + cyan + "[*] " + normal
+	Warn = bold + red + "[!] " + normal
+	Debug = bold + purple + "[-] " + normal
+	Woot = bold + green + "[$] " + normal
+}
+
 var (
 	namePattern = regexp.MustCompile("^[a-zA-Z0-9_]*$") // Only allow alphanumeric chars
 )
@@ -27,6 +27,7 @@ import (
 
 	clientconsole "github.com/bishopfox/sliver/client/console"
 	consts "github.com/bishopfox/sliver/client/constants"
+	clientcore "github.com/bishopfox/sliver/client/core"
 	"github.com/bishopfox/sliver/client/help"
 	clienttransport "github.com/bishopfox/sliver/client/transport"
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
@@ -45,6 +46,7 @@ func Start() {
 		ctxDialer,
 		grpc.WithInsecure(), // This is an in-memory listener, no need for secure transport
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(clienttransport.ClientMaxReceiveMessageSize)),
+		grpc.WithUnaryInterceptor(clientcore.BroadcastInterceptor),
 	}
 	conn, err := grpc.DialContext(context.Background(), "bufnet", options...)
 	if err != nil {
@@ -0,0 +1,435 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+)
+
+const (
+	// BeaconTaskPending - Queued, waiting for the next check-in
+	BeaconTaskPending = "pending"
+	// BeaconTaskSent - Sent to the beacon, waiting for the result
+	BeaconTaskSent = "sent"
+	// BeaconTaskCompleted - The beacon returned a result
+	BeaconTaskCompleted = "completed"
+	// BeaconTaskCancelled - Removed from the queue before it was sent
+	BeaconTaskCancelled = "cancelled"
+)
+
+var (
+	// Beacons - Manages beacons, which unlike sessions outlive their connections
+	Beacons = &beacons{
+		beacons: &map[string]*Beacon{},
+		mutex:   &sync.RWMutex{},
+	}
+
+	// ErrBeaconTaskNotFound - No task with that ID
+	ErrBeaconTaskNotFound = errors.New("Beacon task not found")
+	// ErrBeaconTaskNotPending - Tasks can only be cancelled or moved until they are sent
+	ErrBeaconTaskNotPending = errors.New("Beacon task was already sent")
+)
+
+// Beacon - An implant that periodically checks in to pull queued tasks
+type Beacon struct {
+	ID            string
+	Name          string
+	Hostname      string
+	Username      string
+	UID           string
+	GID           string
+	Os            string
+	Version       string
+	Arch          string
+	Transport     string
+	RemoteAddress string
+	PID           int32
+	Filename      string
+	ActiveC2      string
+	Groups        []string
+	Privileges    []string
+	HostInfo      *sliverpb.HostInfo
+	Interval      int64
+	Jitter        int64
+	LastCheckin   time.Time
+	NextCheckin   time.Time
+
+	// ProtocolVersion - Negotiated at check-in, see NegotiateProtocolVersion
+	ProtocolVersion uint32
+
+	// Tags - Set by operators, see Tag
+	Tags []string
+
+	tasks []*BeaconTask
+	mutex *sync.RWMutex
+}
+
+// BeaconTask - A request queued for a beacon, the ID is used as the envelope ID
+type BeaconTask struct {
+	ID          uint64
+	BeaconID    string
+	Description string
+	MsgType     uint32
+	Request     []byte
+	Response    []byte
+	Err         string
+	State       string
+	CreatedAt   time.Time
+	SentAt      time.Time
+	CompletedAt time.Time
+
+	done chan struct{}
+}
+
+// ToProtobuf - Get the protobuf version of the object
+func (b *Beacon) ToProtobuf() *clientpb.Beacon {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	completed := 0
+	for _, task := range b.tasks {
+		if task.State == BeaconTaskCompleted {
+			completed++
+		}
+	}
+	return &clientpb.Beacon{
+		ID:                  b.ID,
+		Name:                b.Name,
+		Hostname:            b.Hostname,
+		Username:            b.Username,
+		UID:                 b.UID,
+		GID:                 b.GID,
+		OS:                  b.Os,
+		Arch:                b.Arch,
+		Transport:           b.Transport,
+		RemoteAddress:       b.RemoteAddress,
+		PID:                 b.PID,
+		Filename:            b.Filename,
+		ActiveC2:            b.ActiveC2,
+		Version:             b.Version,
+		Interval:            b.Interval,
+		Jitter:              b.Jitter,
+		LastCheckin:         b.LastCheckin.Format(time.RFC1123),
+		NextCheckin:         b.NextCheckin.Format(time.RFC1123),
+		TasksCount:          uint32(len(b.tasks)),
+		TasksCountCompleted: uint32(completed),
+		Groups:              b.Groups,
+		Privileges:          b.Privileges,
+		HostInfo:            b.HostInfo,
+		ProtocolVersion:     b.ProtocolVersion,
+		Tags:                b.Tags,
+	}
+}
+
+// ToProtobuf - Get the protobuf version of the object, without the response
+func (t *BeaconTask) ToProtobuf() *clientpb.BeaconTask {
+	task := &clientpb.BeaconTask{
+		ID:          fmt.Sprintf("%d", t.ID),
+		BeaconID:    t.BeaconID,
+		State:       t.State,
+		Description: t.Description,
+		MsgType:     t.MsgType,
+		CreatedAt:   t.CreatedAt.Format(time.RFC1123),
+		Err:         t.Err,
+	}
+	if !t.SentAt.IsZero() {
+		task.SentAt = t.SentAt.Format(time.RFC1123)
+	}
+	if !t.CompletedAt.IsZero() {
+		task.CompletedAt = t.CompletedAt.Format(time.RFC1123)
+	}
+	return task
+}
+
+// Request - Queue a request for the next check-in and wait for the result,
+// on timeout the task stays queued and its result can be fetched later
+func (b *Beacon) Request(description string, msgType uint32, timeout time.Duration, data []byte) ([]byte, error) {
+	task := b.AddTask(description, msgType, data)
+	select {
+	case <-task.done:
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("Task %d queued, the beacon will pick it up at its next check-in (%s)",
+			task.ID, b.GetNextCheckin().Format(time.RFC1123))
+	}
+	if task.Err != "" {
+		return nil, errors.New(task.Err)
+	}
+	return task.Response, nil
+}
+
+// AddTask - Queue a request for the next check-in, a message type the beacon's
+// protocol version predates is completed right away with an error instead
+func (b *Beacon) AddTask(description string, msgType uint32, data []byte) *BeaconTask {
+	task := &BeaconTask{
+		ID:          EnvelopeID(),
+		BeaconID:    b.ID,
+		Description: description,
+		MsgType:     msgType,
+		Request:     data,
+		State:       BeaconTaskPending,
+		CreatedAt:   time.Now(),
+		done:        make(chan struct{}),
+	}
+	b.mutex.Lock()
+	if err := checkProtocolVersion(b.ProtocolVersion, msgType); err != nil {
+		task.Err = err.Error()
+		task.State = BeaconTaskCompleted
+		task.CompletedAt = task.CreatedAt
+		close(task.done)
+	}
+	b.tasks = append(b.tasks, task)
+	b.mutex.Unlock()
+	return task
+}
+
+// Tasks - All tasks, oldest first
+func (b *Beacon) Tasks() []*BeaconTask {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return append([]*BeaconTask{}, b.tasks...)
+}
+
+// Task - Get a task by ID
+func (b *Beacon) Task(taskID uint64) (*BeaconTask, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, task := range b.tasks {
+		if task.ID == taskID {
+			return task, nil
+		}
+	}
+	return nil, ErrBeaconTaskNotFound
+}
+
+// PendingTasks - Envelopes of the tasks that have not been sent yet, they
+// are marked as sent
+func (b *Beacon) PendingTasks() []*sliverpb.Envelope {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	envelopes := []*sliverpb.Envelope{}
+	for _, task := range b.tasks {
+		if task.State != BeaconTaskPending {
+			continue
+		}
+		task.State = BeaconTaskSent
+		task.SentAt = time.Now()
+		envelopes = append(envelopes, &sliverpb.Envelope{
+			ID:   task.ID,
+			Type: task.MsgType,
+			Data: task.Request,
+		})
+	}
+	return envelopes
+}
+
+// CompleteTask - Save the result of a task the beacon executed
+func (b *Beacon) CompleteTask(envelope *sliverpb.Envelope) (*BeaconTask, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, task := range b.tasks {
+		if task.ID != envelope.ID {
+			continue
+		}
+		if task.State == BeaconTaskCompleted {
+			return task, nil // Duplicate
+		}
+		task.Response = envelope.Data
+		if envelope.UnknownMessageType {
+			task.Err = unknownMessageTypeErr(b.ProtocolVersion).Error()
+		} else if envelope.Err != "" {
+			task.Err = envelope.Err
+		}
+		task.State = BeaconTaskCompleted
+		task.CompletedAt = time.Now()
+		close(task.done)
+		return task, nil
+	}
+	return nil, ErrBeaconTaskNotFound
+}
+
+// CancelTask - Drop a pending task, a request waiting for its result gets an error
+func (b *Beacon) CancelTask(taskID uint64) (*BeaconTask, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, task := range b.tasks {
+		if task.ID != taskID {
+			continue
+		}
+		if task.State != BeaconTaskPending {
+			return nil, ErrBeaconTaskNotPending
+		}
+		task.Err = "Task cancelled"
+		task.State = BeaconTaskCancelled
+		task.CompletedAt = time.Now()
+		close(task.done)
+		return task, nil
+	}
+	return nil, ErrBeaconTaskNotFound
+}
+
+// MoveTask - Change when a pending task is sent, position is its place among
+// the pending tasks starting at 1, positions past the end move it last
+func (b *Beacon) MoveTask(taskID uint64, position int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	slots := []int{}
+	pending := []*BeaconTask{}
+	var moved *BeaconTask
+	for index, task := range b.tasks {
+		if task.ID == taskID {
+			if task.State != BeaconTaskPending {
+				return ErrBeaconTaskNotPending
+			}
+			moved = task
+		}
+		if task.State == BeaconTaskPending {
+			slots = append(slots, index)
+			if task.ID != taskID {
+				pending = append(pending, task)
+			}
+		}
+	}
+	if moved == nil {
+		return ErrBeaconTaskNotFound
+	}
+	if position < 1 {
+		position = 1
+	}
+	if len(pending) < position {
+		position = len(pending) + 1
+	}
+	pending = append(pending[:position-1], append([]*BeaconTask{moved}, pending[position-1:]...)...)
+	// Pending tasks trade places, the others keep theirs
+	for index, slot := range slots {
+		b.tasks[slot] = pending[index]
+	}
+	return nil
+}
+
+// SetIdentity - Cache the groups and enabled privileges from a whoami
+func (b *Beacon) SetIdentity(groups []string, privileges []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.Groups = groups
+	b.Privileges = privileges
+}
+
+// SetHostInfo - Cache the result of a hostinfo task
+func (b *Beacon) SetHostInfo(hostInfo *sliverpb.HostInfo) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.HostInfo = hostInfo
+}
+
+// GetNextCheckin - When the beacon is expected to check in next
+func (b *Beacon) GetNextCheckin() time.Time {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.NextCheckin
+}
+
+// beacons - Manages the beacons, provides atomic access
+type beacons struct {
+	mutex   *sync.RWMutex
+	beacons *map[string]*Beacon
+}
+
+// All - Return a list of all beacons, sorted by ID
+func (b *beacons) All() []*Beacon {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	all := []*Beacon{}
+	for _, beacon := range *b.beacons {
+		all = append(all, beacon)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	return all
+}
+
+// Get - Get a beacon by ID
+func (b *beacons) Get(beaconID string) *Beacon {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return (*b.beacons)[beaconID]
+}
+
+// Checkin - Add or update a beacon from its check-in, session is the
+// connection the beacon checked in over
+func (b *beacons) Checkin(register *sliverpb.BeaconRegister, session *Session) *Beacon {
+	b.mutex.Lock()
+	beacon, ok := (*b.beacons)[register.ID]
+	if !ok {
+		beacon = &Beacon{
+			ID:    register.ID,
+			tasks: []*BeaconTask{},
+			mutex: &sync.RWMutex{},
+		}
+		(*b.beacons)[register.ID] = beacon
+	}
+	b.mutex.Unlock()
+
+	beacon.mutex.Lock()
+	info := register.Register
+	if info != nil {
+		beacon.Name = info.Name
+		beacon.Hostname = info.Hostname
+		beacon.Username = info.Username
+		beacon.UID = info.Uid
+		beacon.GID = info.Gid
+		beacon.Os = info.Os
+		beacon.Arch = info.Arch
+		beacon.PID = info.Pid
+		beacon.Filename = info.Filename
+		beacon.ActiveC2 = info.ActiveC2
+		beacon.Version = info.Version
+		beacon.ProtocolVersion = NegotiateProtocolVersion(info.ProtocolVersion)
+	}
+	beacon.Transport = session.Transport
+	beacon.RemoteAddress = session.RemoteAddress
+	beacon.Interval = register.Interval
+	beacon.Jitter = register.Jitter
+	beacon.LastCheckin = time.Now()
+	beacon.NextCheckin = time.Unix(register.NextCheckin, 0)
+	beacon.mutex.Unlock()
+
+	if !ok {
+		EventBroker.Publish(Event{
+			EventType: consts.BeaconRegisteredEvent,
+			Beacon:    beacon,
+		})
+	}
+	return beacon
+}
+
+// Remove - Forget about a beacon and its tasks, it is not told to exit
+func (b *beacons) Remove(beaconID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(*b.beacons, beaconID)
+}
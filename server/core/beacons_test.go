@@ -0,0 +1,136 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+func TestBeaconRequest(t *testing.T) {
+	beacon := Beacons.Checkin(&sliverpb.BeaconRegister{
+		ID:       "beacon-request-test",
+		Interval: 1,
+		Register: &sliverpb.Register{Name: "test"},
+	}, newTestSession("beacon-test", 100))
+	defer Beacons.Remove(beacon.ID)
+
+	result := make(chan []byte)
+	go func() {
+		data, err := beacon.Request("PingReq", sliverpb.MsgPing, time.Second, []byte("ping"))
+		if err != nil {
+			t.Errorf("Request failed %s", err)
+		}
+		result <- data
+	}()
+
+	var tasks []*sliverpb.Envelope
+	for len(tasks) == 0 {
+		time.Sleep(10 * time.Millisecond)
+		tasks = beacon.PendingTasks()
+	}
+	if len(tasks) != 1 || !bytes.Equal(tasks[0].Data, []byte("ping")) {
+		t.Fatalf("Unexpected pending tasks %v", tasks)
+	}
+	if 0 < len(beacon.PendingTasks()) {
+		t.Errorf("Tasks should only be sent once")
+	}
+	_, err := beacon.CompleteTask(&sliverpb.Envelope{ID: tasks[0].ID, Data: []byte("pong")})
+	if err != nil {
+		t.Fatalf("Failed to complete task %s", err)
+	}
+	if data := <-result; !bytes.Equal(data, []byte("pong")) {
+		t.Errorf("Expected pong, got %v", data)
+	}
+}
+
+func TestBeaconTaskErr(t *testing.T) {
+	beacon := Beacons.Checkin(&sliverpb.BeaconRegister{ID: "beacon-err-test"}, newTestSession("beacon-test", 100))
+	defer Beacons.Remove(beacon.ID)
+
+	go func() {
+		var tasks []*sliverpb.Envelope
+		for len(tasks) == 0 {
+			time.Sleep(10 * time.Millisecond)
+			tasks = beacon.PendingTasks()
+		}
+		beacon.CompleteTask(&sliverpb.Envelope{ID: tasks[0].ID, Err: "Task did not return a result"})
+	}()
+	_, err := beacon.Request("PingReq", sliverpb.MsgPing, time.Second, []byte{})
+	if err == nil || err.Error() != "Task did not return a result" {
+		t.Errorf("Expected the implant's error, got %v", err)
+	}
+}
+
+func TestBeaconRequestTimeout(t *testing.T) {
+	beacon := Beacons.Checkin(&sliverpb.BeaconRegister{ID: "beacon-timeout-test"}, newTestSession("beacon-test", 100))
+	defer Beacons.Remove(beacon.ID)
+
+	_, err := beacon.Request("PingReq", sliverpb.MsgPing, 10*time.Millisecond, []byte{})
+	if err == nil {
+		t.Fatalf("Expected the request to time out")
+	}
+	tasks := beacon.Tasks()
+	if len(tasks) != 1 || tasks[0].State != BeaconTaskPending {
+		t.Fatalf("Task should still be queued %v", tasks)
+	}
+
+	// The result of a late task is kept
+	pending := beacon.PendingTasks()
+	beacon.CompleteTask(&sliverpb.Envelope{ID: pending[0].ID, Data: []byte("late")})
+	task, err := beacon.Task(pending[0].ID)
+	if err != nil || task.State != BeaconTaskCompleted || !bytes.Equal(task.Response, []byte("late")) {
+		t.Errorf("Unexpected task %v (%v)", task, err)
+	}
+}
+
+func TestBeaconTaskQueue(t *testing.T) {
+	beacon := Beacons.Checkin(&sliverpb.BeaconRegister{ID: "beacon-queue-test"}, newTestSession("beacon-test", 100))
+	defer Beacons.Remove(beacon.ID)
+
+	first := beacon.AddTask("PingReq", sliverpb.MsgPing, []byte("first"))
+	second := beacon.AddTask("PingReq", sliverpb.MsgPing, []byte("second"))
+	third := beacon.AddTask("PingReq", sliverpb.MsgPing, []byte("third"))
+
+	if err := beacon.MoveTask(third.ID, 1); err != nil {
+		t.Fatalf("Failed to move task %s", err)
+	}
+	if _, err := beacon.CancelTask(first.ID); err != nil {
+		t.Fatalf("Failed to cancel task %s", err)
+	}
+	select {
+	case <-first.done:
+	default:
+		t.Errorf("Cancelling should complete the task")
+	}
+
+	pending := beacon.PendingTasks()
+	if len(pending) != 2 || pending[0].ID != third.ID || pending[1].ID != second.ID {
+		t.Fatalf("Unexpected pending tasks %v", pending)
+	}
+	if _, err := beacon.CancelTask(second.ID); err != ErrBeaconTaskNotPending {
+		t.Errorf("Sent tasks can't be cancelled, got %v", err)
+	}
+	if err := beacon.MoveTask(second.ID, 1); err != ErrBeaconTaskNotPending {
+		t.Errorf("Sent tasks can't be moved, got %v", err)
+	}
+}
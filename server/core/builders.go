@@ -0,0 +1,220 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/google/uuid"
+)
+
+const (
+	// AnyBuilder - Builder name that selects the least busy builder for a target
+	AnyBuilder = "any"
+)
+
+var (
+	// Builders - Holds pointers to all the connected external builders
+	Builders = &builders{
+		active:  &map[string]*Builder{},
+		pending: &map[string]chan *clientpb.BuildResult{},
+		mutex:   &sync.RWMutex{},
+	}
+
+	// ErrBuilderNotFound - No connected builder with that name
+	ErrBuilderNotFound = errors.New("Builder not found")
+	// ErrNoBuilderForTarget - No connected builder supports the target
+	ErrNoBuilderForTarget = errors.New("No builder supports this target")
+	// ErrBuilderDisconnected - The builder disconnected before returning a result
+	ErrBuilderDisconnected = errors.New("Builder disconnected")
+	// ErrBuildTimeout - The builder did not return a result in time
+	ErrBuildTimeout = errors.New("Build timed out")
+)
+
+// Builder - An external build machine connected to the server
+type Builder struct {
+	Name         string
+	OperatorName string
+	Targets      []string
+	Tasks        chan *clientpb.BuildTask
+	Done         chan bool
+
+	activeTasks int32
+}
+
+// ToProtobuf - Get the protobuf version of the object
+func (b *Builder) ToProtobuf() *clientpb.Builder {
+	return &clientpb.Builder{
+		Name:         b.Name,
+		OperatorName: b.OperatorName,
+		Targets:      b.Targets,
+		ActiveTasks:  uint32(atomic.LoadInt32(&b.activeTasks)),
+	}
+}
+
+// Supports - Returns true if the builder can build for the goos/goarch target
+func (b *Builder) Supports(target string) bool {
+	for _, supported := range b.Targets {
+		if supported == target {
+			return true
+		}
+	}
+	return false
+}
+
+// builders - Holds refs to all connected builders and the tasks waiting on them
+type builders struct {
+	active  *map[string]*Builder
+	pending *map[string]chan *clientpb.BuildResult
+	mutex   *sync.RWMutex
+}
+
+// Add - Add a builder, names must be unique
+func (b *builders) Add(builder *Builder) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := (*b.active)[builder.Name]; ok {
+		return errors.New("A builder with that name is already connected")
+	}
+	builder.Tasks = make(chan *clientpb.BuildTask)
+	builder.Done = make(chan bool)
+	(*b.active)[builder.Name] = builder
+	return nil
+}
+
+// Remove - Remove a builder, any task waiting on it fails
+func (b *builders) Remove(name string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if builder, ok := (*b.active)[name]; ok {
+		close(builder.Done)
+		delete((*b.active), name)
+	}
+}
+
+// Get - Get a builder by name
+func (b *builders) Get(name string) *Builder {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return (*b.active)[name]
+}
+
+// All - Return a list of all builders sorted by name
+func (b *builders) All() []*Builder {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	all := []*Builder{}
+	for _, builder := range *b.active {
+		all = append(all, builder)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Select - Get the named builder, or the least busy builder that supports the
+// target if the name is AnyBuilder
+func (b *builders) Select(name string, target string) (*Builder, error) {
+	if name != AnyBuilder {
+		builder := b.Get(name)
+		if builder == nil {
+			return nil, ErrBuilderNotFound
+		}
+		if !builder.Supports(target) {
+			return nil, ErrNoBuilderForTarget
+		}
+		return builder, nil
+	}
+	var selected *Builder
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, builder := range *b.active {
+		if !builder.Supports(target) {
+			continue
+		}
+		if selected == nil || atomic.LoadInt32(&builder.activeTasks) < atomic.LoadInt32(&selected.activeTasks) {
+			selected = builder
+		}
+	}
+	if selected == nil {
+		return nil, ErrNoBuilderForTarget
+	}
+	return selected, nil
+}
+
+// Dispatch - Send a build task to a builder and wait for its result
+func (b *builders) Dispatch(builder *Builder, config *clientpb.ImplantConfig, timeout time.Duration) (*clientpb.BuildResult, error) {
+	task := &clientpb.BuildTask{
+		ID:     uuid.New().String(),
+		Config: config,
+	}
+	resultCh := make(chan *clientpb.BuildResult, 1)
+	b.mutex.Lock()
+	(*b.pending)[task.ID] = resultCh
+	b.mutex.Unlock()
+	atomic.AddInt32(&builder.activeTasks, 1)
+	defer func() {
+		b.mutex.Lock()
+		delete((*b.pending), task.ID)
+		b.mutex.Unlock()
+		atomic.AddInt32(&builder.activeTasks, -1)
+	}()
+
+	deadline := time.After(timeout)
+	select {
+	case builder.Tasks <- task:
+	case <-builder.Done:
+		return nil, ErrBuilderDisconnected
+	case <-deadline:
+		return nil, ErrBuildTimeout
+	}
+	select {
+	case result := <-resultCh:
+		if result.BuilderName != builder.Name {
+			return nil, errors.New("Build result from the wrong builder")
+		}
+		return result, nil
+	case <-builder.Done:
+		return nil, ErrBuilderDisconnected
+	case <-deadline:
+		return nil, ErrBuildTimeout
+	}
+}
+
+// Resolve - Deliver a result to the task waiting on it
+func (b *builders) Resolve(result *clientpb.BuildResult) error {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	resultCh, ok := (*b.pending)[result.TaskID]
+	if !ok {
+		return errors.New("No pending task with that ID")
+	}
+	select {
+	case resultCh <- result:
+		return nil
+	default:
+		return errors.New("Task already has a result")
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+func TestBuilderSelect(t *testing.T) {
+	linux := &Builder{Name: "select-linux", Targets: []string{"linux/amd64"}}
+	multi := &Builder{Name: "select-multi", Targets: []string{"linux/amd64", "windows/amd64"}}
+	for _, builder := range []*Builder{linux, multi} {
+		if err := Builders.Add(builder); err != nil {
+			t.Fatalf("Failed to add builder %s", err)
+		}
+		defer Builders.Remove(builder.Name)
+	}
+	if err := Builders.Add(&Builder{Name: linux.Name}); err == nil {
+		t.Fatalf("Expected duplicate builder name to fail")
+	}
+
+	selected, err := Builders.Select(AnyBuilder, "windows/amd64")
+	if err != nil || selected != multi {
+		t.Fatalf("Expected %s to be selected for windows, got %v (%v)", multi.Name, selected, err)
+	}
+	if _, err := Builders.Select(linux.Name, "windows/amd64"); err != ErrNoBuilderForTarget {
+		t.Fatalf("Expected %v, got %v", ErrNoBuilderForTarget, err)
+	}
+	if _, err := Builders.Select("select-missing", "linux/amd64"); err != ErrBuilderNotFound {
+		t.Fatalf("Expected %v, got %v", ErrBuilderNotFound, err)
+	}
+
+	// The least busy builder is picked
+	multi.activeTasks = 0
+	linux.activeTasks = 2
+	selected, _ = Builders.Select(AnyBuilder, "linux/amd64")
+	if selected != multi {
+		t.Fatalf("Expected least busy builder %s, got %s", multi.Name, selected.Name)
+	}
+	linux.activeTasks = 0
+}
+
+func TestBuilderDispatch(t *testing.T) {
+	builder := &Builder{Name: "dispatch", Targets: []string{"linux/amd64"}}
+	if err := Builders.Add(builder); err != nil {
+		t.Fatalf("Failed to add builder %s", err)
+	}
+	defer Builders.Remove(builder.Name)
+
+	go func() {
+		task := <-builder.Tasks
+		Builders.Resolve(&clientpb.BuildResult{
+			TaskID:      task.ID,
+			BuilderName: builder.Name,
+			Config:      task.Config,
+		})
+	}()
+	config := &clientpb.ImplantConfig{Name: "DISPATCH_TEST"}
+	result, err := Builders.Dispatch(builder, config, time.Second)
+	if err != nil {
+		t.Fatalf("Dispatch failed %s", err)
+	}
+	if result.Config.Name != config.Name {
+		t.Fatalf("Expected result for %s, got %s", config.Name, result.Config.Name)
+	}
+	if err := Builders.Resolve(&clientpb.BuildResult{TaskID: "unknown"}); err == nil {
+		t.Fatalf("Expected result for unknown task to fail")
+	}
+}
+
+func TestBuilderDisconnect(t *testing.T) {
+	builder := &Builder{Name: "disconnect", Targets: []string{"linux/amd64"}}
+	if err := Builders.Add(builder); err != nil {
+		t.Fatalf("Failed to add builder %s", err)
+	}
+	go func() {
+		<-builder.Tasks
+		Builders.Remove(builder.Name)
+	}()
+	_, err := Builders.Dispatch(builder, &clientpb.ImplantConfig{}, time.Second)
+	if err != ErrBuilderDisconnected {
+		t.Fatalf("Expected %v, got %v", ErrBuilderDisconnected, err)
+	}
+	if Builders.Get(builder.Name) != nil {
+		t.Fatalf("Expected builder to be removed")
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+)
+
+var (
+	// Sessions have no lock of their own, compression stats are updated by
+	// the transports for every envelope
+	sessionCompressionMutex = &sync.RWMutex{}
+)
+
+type compressionStats struct {
+	uncompressed uint64
+	compressed   uint64
+}
+
+// RecordCompression - An envelope payload of uncompressed bytes went over
+// the wire as compressed bytes
+func (s *Session) RecordCompression(uncompressed int, compressed int) {
+	sessionCompressionMutex.Lock()
+	defer sessionCompressionMutex.Unlock()
+	s.compression.uncompressed += uint64(uncompressed)
+	s.compression.compressed += uint64(compressed)
+}
+
+// CompressionStats - Total payload bytes before and after compression
+func (s *Session) CompressionStats() (uint64, uint64) {
+	sessionCompressionMutex.RLock()
+	defer sessionCompressionMutex.RUnlock()
+	return s.compression.uncompressed, s.compression.compressed
+}
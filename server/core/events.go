@@ -26,9 +26,12 @@ const (
 // Event - An event is fired when there's a state change involving a
 //         session, job, or client.
 type Event struct {
-	Session *Session
-	Job     *Job
-	Client  *Client
+	Session    *Session
+	Job        *Job
+	Client     *Client
+	Beacon     *Beacon
+	BeaconTask *BeaconTask
+	Transfer   *Transfer
 
 	EventType string
 
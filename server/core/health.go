@@ -0,0 +1,155 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+const (
+	// HealthGreen - The session checks in on time and answers requests
+	HealthGreen = "green"
+	// HealthYellow - The session is late, slow, or some recent requests failed
+	HealthYellow = "yellow"
+	// HealthRed - The session missed several check-ins or most recent requests failed
+	HealthRed = "red"
+
+	// Number of recent requests the error rate and RTT are computed over
+	healthWindow = 20
+
+	// Round trips slower than this (on top of the check-in interval) are degraded
+	healthSlowRTT = 5 * time.Second
+)
+
+var (
+	// Sessions have no lock of their own, health is updated from the
+	// transports and from every request
+	sessionHealthMutex = &sync.RWMutex{}
+)
+
+// sessionHealth - Outcome of the most recent requests, a ring of healthWindow samples
+type sessionHealth struct {
+	samples []healthSample
+	next    int
+}
+
+type healthSample struct {
+	rtt    time.Duration
+	failed bool
+}
+
+// Checkin - The implant sent us something, transports call this for every
+// envelope they receive
+func (s *Session) Checkin() {
+	sessionHealthMutex.Lock()
+	defer sessionHealthMutex.Unlock()
+	checkin := time.Now()
+	s.LastCheckin = &checkin
+}
+
+// GetLastCheckin - Zero if the implant never checked in
+func (s *Session) GetLastCheckin() time.Time {
+	sessionHealthMutex.RLock()
+	defer sessionHealthMutex.RUnlock()
+	if s.LastCheckin == nil {
+		return time.Time{}
+	}
+	return *s.LastCheckin
+}
+
+// recordRequest - Called once per request with its round trip time, only
+// implant timeouts count as failures, an unsupported message is not the
+// connection's fault
+func (s *Session) recordRequest(rtt time.Duration, err error) {
+	sample := healthSample{rtt: rtt, failed: errors.Is(err, ErrImplantTimeout)}
+	sessionHealthMutex.Lock()
+	defer sessionHealthMutex.Unlock()
+	if len(s.health.samples) < healthWindow {
+		s.health.samples = append(s.health.samples, sample)
+		return
+	}
+	s.health.samples[s.health.next] = sample
+	s.health.next = (s.health.next + 1) % healthWindow
+}
+
+// Health - Compare the time since the last check-in with the interval the
+// transport checks in at, and look at the error rate and average RTT of the
+// recent requests
+func (s *Session) Health() *clientpb.SessionHealth {
+	sessionHealthMutex.RLock()
+	defer sessionHealthMutex.RUnlock()
+
+	health := &clientpb.SessionHealth{
+		Status:          HealthGreen,
+		CheckinInterval: int64(s.CheckinInterval / time.Second),
+	}
+	var sinceCheckin time.Duration
+	if s.LastCheckin != nil {
+		health.LastCheckin = s.LastCheckin.Unix()
+		sinceCheckin = time.Since(*s.LastCheckin)
+	}
+
+	var total time.Duration
+	var answered int64
+	for _, sample := range s.health.samples {
+		health.Requests++
+		if sample.failed {
+			health.Errors++
+			continue
+		}
+		total += sample.rtt
+		answered++
+	}
+	if 0 < answered {
+		health.AvgRTT = int64(total/time.Duration(answered)) / int64(time.Millisecond)
+	}
+
+	// Stateful connections (mtls, pivots) only speak when spoken to, so
+	// there's no interval to be late for
+	if 0 < s.CheckinInterval {
+		if 4*s.CheckinInterval < sinceCheckin {
+			health.Status = HealthRed
+		} else if 2*s.CheckinInterval < sinceCheckin {
+			health.Status = worseHealth(health.Status, HealthYellow)
+		}
+	}
+	if 0 < health.Requests {
+		errorRate := float64(health.Errors) / float64(health.Requests)
+		if 0.5 <= errorRate {
+			health.Status = HealthRed
+		} else if 0.2 <= errorRate {
+			health.Status = worseHealth(health.Status, HealthYellow)
+		}
+	}
+	if s.CheckinInterval+healthSlowRTT < time.Duration(health.AvgRTT)*time.Millisecond {
+		health.Status = worseHealth(health.Status, HealthYellow)
+	}
+	return health
+}
+
+func worseHealth(current string, status string) string {
+	if current == HealthRed {
+		return current
+	}
+	return status
+}
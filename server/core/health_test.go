@@ -0,0 +1,81 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckin(t *testing.T) {
+	session := newTestSession("health-test", 100)
+	session.CheckinInterval = time.Second
+	session.Checkin()
+	if status := session.Health().Status; status != HealthGreen {
+		t.Errorf("Expected %s after check-in, got %s", HealthGreen, status)
+	}
+
+	late := time.Now().Add(-3 * time.Second)
+	session.LastCheckin = &late
+	if status := session.Health().Status; status != HealthYellow {
+		t.Errorf("Expected %s after a late check-in, got %s", HealthYellow, status)
+	}
+	late = time.Now().Add(-5 * time.Second)
+	if status := session.Health().Status; status != HealthRed {
+		t.Errorf("Expected %s after missed check-ins, got %s", HealthRed, status)
+	}
+
+	// Stateful sessions are never late
+	session.CheckinInterval = 0
+	if status := session.Health().Status; status != HealthGreen {
+		t.Errorf("Expected %s for a stateful session, got %s", HealthGreen, status)
+	}
+}
+
+func TestHealthRequests(t *testing.T) {
+	session := newTestSession("health-test", 200)
+	for i := 0; i < healthWindow; i++ {
+		session.recordRequest(100*time.Millisecond, nil)
+	}
+	health := session.Health()
+	if health.Status != HealthGreen || health.AvgRTT != 100 || health.Requests != healthWindow {
+		t.Errorf("Unexpected health %v", health)
+	}
+
+	// Unsupported messages are not the connection's fault
+	session.recordRequest(0, ErrUnknownMessateType)
+	if health := session.Health(); health.Errors != 0 {
+		t.Errorf("Expected no errors, got %d", health.Errors)
+	}
+
+	for i := 0; i < healthWindow/4; i++ {
+		session.recordRequest(time.Second, ErrImplantTimeout)
+	}
+	if status := session.Health().Status; status != HealthYellow {
+		t.Errorf("Expected %s with some timeouts, got %s", HealthYellow, status)
+	}
+	for i := 0; i < healthWindow; i++ {
+		session.recordRequest(time.Second, fmt.Errorf("%w after 1s", ErrImplantTimeout))
+	}
+	health = session.Health()
+	if health.Status != HealthRed || health.Requests != healthWindow {
+		t.Errorf("Expected %s over a window of %d, got %v", HealthRed, healthWindow, health)
+	}
+}
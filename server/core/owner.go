@@ -0,0 +1,61 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+)
+
+// Owner - The session or beacon a request was sent to, loot and credentials
+// recovered from the response are recorded under it
+type Owner struct {
+	Name      string
+	SessionID uint32 // Zero for beacons
+	BeaconID  string
+	Hostname  string
+	Os        string
+}
+
+// Key - Unique across sessions and beacons
+func (o *Owner) Key() string {
+	if o.BeaconID != "" {
+		return o.BeaconID
+	}
+	return fmt.Sprintf("%d", o.SessionID)
+}
+
+// Owner - Owner of the requests sent to the session
+func (s *Session) Owner() *Owner {
+	return &Owner{
+		Name:      s.Name,
+		SessionID: s.ID,
+		Hostname:  s.Hostname,
+		Os:        s.Os,
+	}
+}
+
+// Owner - Owner of the tasks sent to the beacon
+func (b *Beacon) Owner() *Owner {
+	return &Owner{
+		Name:     b.Name,
+		BeaconID: b.ID,
+		Hostname: b.Hostname,
+		Os:       b.Os,
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+var (
+	// ErrProtocolVersion - The implant's protocol predates the message type
+	ErrProtocolVersion = errors.New("Implant protocol version too old")
+
+	// msgProtocolVersions - Message types added after protocol version 1, mapped
+	// to the version that introduced them. They're never sent to an implant that
+	// negotiated a lower version, it would reply with an unknown message type at
+	// best or misread the envelope at worst.
+	msgProtocolVersions = map[uint32]uint32{
+		sliverpb.MsgCancelReq: 2,
+	}
+)
+
+// NegotiateProtocolVersion - The highest version both the server and the implant
+// speak, an implant newer than the server is only sent what the server knows
+func NegotiateProtocolVersion(implantVersion uint32) uint32 {
+	if sliverpb.ProtocolVersion < implantVersion {
+		return sliverpb.ProtocolVersion
+	}
+	return implantVersion
+}
+
+// ProtocolVersionWarning - A message for the operator when the versions differ,
+// empty if they match
+func ProtocolVersionWarning(implantVersion uint32) string {
+	switch {
+	case implantVersion == 0:
+		return fmt.Sprintf("implant predates protocol versioning (server speaks v%d), rebuild it to use newer commands",
+			sliverpb.ProtocolVersion)
+	case implantVersion < sliverpb.ProtocolVersion:
+		return fmt.Sprintf("implant speaks protocol v%d, older than the server's v%d, rebuild it to use newer commands",
+			implantVersion, sliverpb.ProtocolVersion)
+	case sliverpb.ProtocolVersion < implantVersion:
+		return fmt.Sprintf("implant speaks protocol v%d, newer than the server's v%d, falling back to v%d",
+			implantVersion, sliverpb.ProtocolVersion, sliverpb.ProtocolVersion)
+	}
+	return ""
+}
+
+// checkProtocolVersion - Refuse message types newer than the negotiated version
+func checkProtocolVersion(version uint32, msgType uint32) error {
+	if required, ok := msgProtocolVersions[msgType]; ok && version < required {
+		return fmt.Errorf("%w (message type %d needs v%d, implant speaks v%d)", ErrProtocolVersion, msgType, required, version)
+	}
+	return nil
+}
+
+// unknownMessageTypeErr - An implant with an older protocol is the likely
+// reason it didn't understand a message, say so
+func unknownMessageTypeErr(version uint32) error {
+	if version < sliverpb.ProtocolVersion {
+		return fmt.Errorf("%w (implant speaks protocol v%d, the server v%d, rebuild the implant)",
+			ErrUnknownMessateType, version, sliverpb.ProtocolVersion)
+	}
+	return ErrUnknownMessateType
+}
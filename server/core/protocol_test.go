@@ -0,0 +1,75 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+	if version := NegotiateProtocolVersion(0); version != 0 {
+		t.Fatalf("Legacy implant negotiated v%d", version)
+	}
+	if version := NegotiateProtocolVersion(sliverpb.ProtocolVersion); version != sliverpb.ProtocolVersion {
+		t.Fatalf("Current implant negotiated v%d", version)
+	}
+	if version := NegotiateProtocolVersion(sliverpb.ProtocolVersion + 1); version != sliverpb.ProtocolVersion {
+		t.Fatalf("Newer implant negotiated v%d, expected the server's", version)
+	}
+	if ProtocolVersionWarning(sliverpb.ProtocolVersion) != "" {
+		t.Fatalf("Warning for matching versions")
+	}
+	if ProtocolVersionWarning(0) == "" || ProtocolVersionWarning(sliverpb.ProtocolVersion+1) == "" {
+		t.Fatalf("No warning for mismatched versions")
+	}
+}
+
+func TestRequestProtocolVersion(t *testing.T) {
+	msgType := uint32(0xffff)
+	msgProtocolVersions[msgType] = sliverpb.ProtocolVersion + 1
+	defer delete(msgProtocolVersions, msgType)
+
+	session := newTestSession("protocol-test", 100)
+	session.ProtocolVersion = sliverpb.ProtocolVersion
+	_, err := session.Request(msgType, time.Second, []byte{})
+	if !errors.Is(err, ErrProtocolVersion) {
+		t.Fatalf("Expected %v, got %v", ErrProtocolVersion, err)
+	}
+	if len(session.Send) != 0 {
+		t.Fatalf("Envelope was sent to an implant that can't read it")
+	}
+
+	beacon := Beacons.Checkin(&sliverpb.BeaconRegister{
+		ID:       "beacon-protocol-test",
+		Interval: 1,
+		Register: &sliverpb.Register{Name: "test", ProtocolVersion: sliverpb.ProtocolVersion},
+	}, session)
+	defer Beacons.Remove(beacon.ID)
+	task := beacon.AddTask("Test", msgType, []byte{})
+	if task.State != BeaconTaskCompleted || task.Err == "" {
+		t.Fatalf("Task for a newer message type was queued")
+	}
+	if pending := beacon.PendingTasks(); len(pending) != 0 {
+		t.Fatalf("Expected no pending tasks, got %d", len(pending))
+	}
+}
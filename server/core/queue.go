@@ -0,0 +1,158 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	// SendPolicyBlock - Requests wait for room in the queue, up to their timeout
+	SendPolicyBlock = "block"
+	// SendPolicyDropOldest - The oldest queued envelope is dropped to make room
+	SendPolicyDropOldest = "drop-oldest"
+	// SendPolicyFail - Requests fail right away if the queue is full
+	SendPolicyFail = "fail"
+
+	defaultSendQueueSize = 16
+)
+
+var (
+	// Set once at startup from the server config, see SetSendQueue
+	sendQueueSize   = defaultSendQueueSize
+	sendQueuePolicy = SendPolicyBlock
+
+	// Sessions have no lock of their own, the transports drain Send while
+	// requests are queued
+	sessionQueueMutex = &sync.Mutex{}
+
+	// ErrSendQueueFull - The session's send queue is full and its policy is SendPolicyFail
+	ErrSendQueueFull = errors.New("Send queue is full")
+
+	// ErrSendQueueDropped - The request was dropped from a full send queue to
+	// make room for a newer one
+	ErrSendQueueDropped = errors.New("Request dropped from a full send queue")
+)
+
+// SetSendQueue - Size and policy of the send queue of new sessions, an unknown
+// policy is an error and leaves the current one in place
+func SetSendQueue(size int, policy string) error {
+	switch policy {
+	case SendPolicyBlock, SendPolicyDropOldest, SendPolicyFail:
+	default:
+		return errors.New("Unknown send queue policy " + policy)
+	}
+	if size < 1 {
+		size = defaultSendQueueSize
+	}
+	sendQueueSize = size
+	sendQueuePolicy = policy
+	return nil
+}
+
+// NewSendQueue - The Send channel of a new session
+func NewSendQueue() chan *sliverpb.Envelope {
+	return make(chan *sliverpb.Envelope, sendQueueSize)
+}
+
+// SendQueueDepth - Envelopes waiting for the transport and the queue's capacity
+func (s *Session) SendQueueDepth() (int, int) {
+	return len(s.Send), cap(s.Send)
+}
+
+// SendQueueDropped - Envelopes dropped or requests failed because the queue was full
+func (s *Session) SendQueueDropped() uint64 {
+	sessionQueueMutex.Lock()
+	defer sessionQueueMutex.Unlock()
+	return s.queueDropped
+}
+
+func (s *Session) sendPolicy() string {
+	if s.SendPolicy == "" {
+		return sendQueuePolicy
+	}
+	return s.SendPolicy
+}
+
+// enqueue - Apply the session's policy when the queue is full, returns false
+// if the caller should block on Send
+func (s *Session) enqueue(envelope *sliverpb.Envelope) (bool, error) {
+	select {
+	case s.Send <- envelope:
+		return true, nil
+	default:
+	}
+	switch s.sendPolicy() {
+	case SendPolicyFail:
+		s.countDropped()
+		return true, ErrSendQueueFull
+	case SendPolicyDropOldest:
+		// The transport may drain the queue in the meantime, then nothing is dropped.
+		// If other requests take the free slot first, block like they would have.
+		select {
+		case oldest := <-s.Send:
+			s.countDropped()
+			s.dropped(oldest)
+		default:
+		}
+		select {
+		case s.Send <- envelope:
+			return true, nil
+		default:
+		}
+	}
+	return false, nil
+}
+
+// enqueueTimeout - Queue an envelope nobody waits on, when the policy blocks
+// a connection that doesn't drain Send within timeout is given up on
+func (s *Session) enqueueTimeout(envelope *sliverpb.Envelope, timeout time.Duration) {
+	if queued, _ := s.enqueue(envelope); queued {
+		return
+	}
+	go func() {
+		select {
+		case s.Send <- envelope:
+		case <-time.After(timeout):
+		}
+	}()
+}
+
+func (s *Session) countDropped() {
+	sessionQueueMutex.Lock()
+	s.queueDropped++
+	sessionQueueMutex.Unlock()
+}
+
+// dropped - Best effort to fail the request of a dropped envelope right away,
+// otherwise it times out
+func (s *Session) dropped(envelope *sliverpb.Envelope) {
+	s.RespMutex.RLock()
+	defer s.RespMutex.RUnlock()
+	if resp, ok := s.Resp[envelope.ID]; ok {
+		select {
+		case resp <- nil:
+		default:
+		}
+	}
+}
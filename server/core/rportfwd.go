@@ -0,0 +1,70 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+var (
+	// RportFwds - Reverse port forwards, the forward address is only ever
+	// kept on the server so an implant cannot pick what the server dials
+	RportFwds = rportfwds{
+		forwards: &map[uint32]map[uint32]*sliverpb.RportFwd{},
+		mutex:    &sync.RWMutex{},
+	}
+)
+
+type rportfwds struct {
+	forwards *map[uint32]map[uint32]*sliverpb.RportFwd
+	mutex    *sync.RWMutex
+}
+
+// Add - Track a reverse port forward listener started on a session
+func (r *rportfwds) Add(sessionID uint32, rportfwd *sliverpb.RportFwd) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := (*r.forwards)[sessionID]; !ok {
+		(*r.forwards)[sessionID] = map[uint32]*sliverpb.RportFwd{}
+	}
+	(*r.forwards)[sessionID][rportfwd.ID] = rportfwd
+}
+
+// Get - Get a session's reverse port forward by listener ID
+func (r *rportfwds) Get(sessionID uint32, id uint32) *sliverpb.RportFwd {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return (*r.forwards)[sessionID][id]
+}
+
+// Remove - Stop tracking a reverse port forward
+func (r *rportfwds) Remove(sessionID uint32, id uint32) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete((*r.forwards)[sessionID], id)
+}
+
+// RemoveSession - Stop tracking all reverse port forwards of a session
+func (r *rportfwds) RemoveSession(sessionID uint32) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(*r.forwards, sessionID)
+}
@@ -20,11 +20,13 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/golang/protobuf/proto"
 
 	consts "github.com/bishopfox/sliver/client/constants"
 )
@@ -32,8 +34,9 @@ import (
 var (
 	// Sessions - Manages implant connections
 	Sessions = &sessions{
-		sessions: &map[uint32]*Session{},
-		mutex:    &sync.RWMutex{},
+		sessions:   &map[uint32]*Session{},
+		migrations: &map[string]*migration{},
+		mutex:      &sync.RWMutex{},
 	}
 	hiveID = new(uint32)
 
@@ -51,6 +54,9 @@ type Session struct {
 	Name          string
 	Hostname      string
 	Username      string
+	EffectiveUser string
+	Groups        []string
+	Privileges    []string
 	UID           string
 	GID           string
 	Os            string
@@ -65,21 +71,42 @@ type Session struct {
 	Resp          map[uint64]chan *sliverpb.Envelope
 	RespMutex     *sync.RWMutex
 	ActiveC2      string
+	HostInfo      *sliverpb.HostInfo
+
+	// ProtocolVersion - Negotiated at registration, see NegotiateProtocolVersion
+	ProtocolVersion uint32
+	// Tags - Set by operators, see Tag
+	Tags []string
+	// CheckinInterval - How often the transport polls, zero for stateful connections
+	CheckinInterval time.Duration
+	// Compression - Negotiated at session init, see RecordCompression
+	Compression string
+	// SendPolicy - What requests do when Send is full, the server's default if empty
+	SendPolicy string
+
+	health       sessionHealth
+	compression  compressionStats
+	queueDropped uint64
 }
 
 // ToProtobuf - Get the protobuf version of the object
 func (s *Session) ToProtobuf() *clientpb.Session {
+	// Every transport checks in before registering, so this is only empty for a
+	// session that never sent anything
 	var lastCheckin string
-	if s.LastCheckin == nil {
-		lastCheckin = time.Now().Format(time.RFC1123) // Stateful connections have a nil .LastCheckin
-	} else {
-		lastCheckin = s.LastCheckin.Format(time.RFC1123)
+	if checkin := s.GetLastCheckin(); !checkin.IsZero() {
+		lastCheckin = checkin.Format(time.RFC1123)
 	}
+	uncompressed, compressed := s.CompressionStats()
+	queueDepth, queueSize := s.SendQueueDepth()
 	return &clientpb.Session{
 		ID:            uint32(s.ID),
 		Name:          s.Name,
 		Hostname:      s.Hostname,
 		Username:      s.Username,
+		EffectiveUser: s.EffectiveUser,
+		Groups:        s.Groups,
+		Privileges:    s.Privileges,
 		UID:           s.UID,
 		GID:           s.GID,
 		OS:            s.Os,
@@ -91,12 +118,41 @@ func (s *Session) ToProtobuf() *clientpb.Session {
 		Filename:      s.Filename,
 		LastCheckin:   lastCheckin,
 		ActiveC2:      s.ActiveC2,
+		HostInfo:      s.HostInfo,
+
+		ProtocolVersion: s.ProtocolVersion,
+		Tags:            s.GetTags(),
+		Health:          s.Health(),
+
+		Compression:       s.Compression,
+		UncompressedBytes: uncompressed,
+		CompressedBytes:   compressed,
+
+		SendQueueDepth:   uint32(queueDepth),
+		SendQueueSize:    uint32(queueSize),
+		SendQueuePolicy:  s.sendPolicy(),
+		SendQueueDropped: s.SendQueueDropped(),
 	}
 }
 
 // Request - Sends a protobuf request to the active sliver and returns the response
 func (s *Session) Request(msgType uint32, timeout time.Duration, data []byte) ([]byte, error) {
+	return s.RequestWithBandwidthLimit(msgType, timeout, 0, data)
+}
+
+// RequestWithBandwidthLimit - Sends a request whose envelope, and the implant's response to it,
+// are capped at limit bytes per second (zero is uncapped)
+func (s *Session) RequestWithBandwidthLimit(msgType uint32, timeout time.Duration, limit int64, data []byte) ([]byte, error) {
+	if err := checkProtocolVersion(s.ProtocolVersion, msgType); err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	data, err := s.request(msgType, timeout, limit, data)
+	s.recordRequest(time.Since(started), err)
+	return data, err
+}
 
+func (s *Session) request(msgType uint32, timeout time.Duration, limit int64, data []byte) ([]byte, error) {
 	resp := make(chan *sliverpb.Envelope)
 	reqID := EnvelopeID()
 	s.RespMutex.Lock()
@@ -108,30 +164,74 @@ func (s *Session) Request(msgType uint32, timeout time.Duration, data []byte) ([
 		// close(resp)
 		delete(s.Resp, reqID)
 	}()
-	s.Send <- &sliverpb.Envelope{
-		ID:   reqID,
-		Type: msgType,
-		Data: data,
+	// A dead connection stops draining Send, so the timeout covers queuing too
+	deadline := time.After(timeout)
+	envelope := &sliverpb.Envelope{
+		ID:             reqID,
+		Type:           msgType,
+		Data:           data,
+		BandwidthLimit: limit,
+	}
+	queued, err := s.enqueue(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if !queued {
+		select {
+		case s.Send <- envelope:
+		case <-deadline:
+			return nil, fmt.Errorf("%w after %s, the request was never sent", ErrImplantTimeout, timeout)
+		}
 	}
 
 	var respEnvelope *sliverpb.Envelope
 	select {
 	case respEnvelope = <-resp:
-	case <-time.After(timeout):
-		return nil, ErrImplantTimeout
+	case <-deadline:
+		s.cancel(reqID, timeout)
+		return nil, fmt.Errorf("%w after %s", ErrImplantTimeout, timeout)
+	}
+	if respEnvelope == nil {
+		return nil, ErrSendQueueDropped
 	}
 	if respEnvelope.UnknownMessageType {
-		return nil, ErrUnknownMessateType
+		return nil, unknownMessageTypeErr(s.ProtocolVersion)
+	}
+	if respEnvelope.Err != "" {
+		return nil, errors.New(respEnvelope.Err)
 	}
 	return respEnvelope.Data, nil
 }
 
+// cancel - Tell the implant the result of a request is no longer needed, this is
+// best effort and gives up on a connection that doesn't drain Send within timeout
+func (s *Session) cancel(reqID uint64, timeout time.Duration) {
+	if checkProtocolVersion(s.ProtocolVersion, sliverpb.MsgCancelReq) != nil {
+		return
+	}
+	data, _ := proto.Marshal(&sliverpb.CancelReq{EnvelopeID: reqID})
+	s.enqueueTimeout(&sliverpb.Envelope{Type: sliverpb.MsgCancelReq, Data: data}, timeout)
+}
+
 // sessions - Manages the slivers, provides atomic access
 type sessions struct {
-	mutex    *sync.RWMutex
-	sessions *map[uint32]*Session
+	mutex      *sync.RWMutex
+	sessions   *map[uint32]*Session
+	migrations *map[string]*migration
+}
+
+// migration - A session we expect to reconnect from another process
+type migration struct {
+	SessionID uint32
+	Expires   time.Time
 }
 
+const (
+	migrationTimeout = 5 * time.Minute
+	// The old process is left alone if its connection is dead
+	migrationKillTimeout = 30 * time.Second
+)
+
 // All - Return a list of all sessions
 func (s *sessions) All() []*Session {
 	s.mutex.RLock()
@@ -150,10 +250,14 @@ func (s *sessions) Get(sessionID uint32) *Session {
 	return (*s.sessions)[sessionID]
 }
 
-// Add - Add a sliver to the hive (atomically)
+// Add - Add a sliver to the hive (atomically), if the session is the
+// target of a migration it takes over the ID of the migrating session
 func (s *sessions) Add(session *Session) *Session {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	if s.completeMigration(session) {
+		return session
+	}
 	(*s.sessions)[session.ID] = session
 	EventBroker.Publish(Event{
 		EventType: consts.SessionOpenedEvent,
@@ -162,12 +266,91 @@ func (s *sessions) Add(session *Session) *Session {
 	return session
 }
 
+// ExpectMigration - The next session to register from pid on the same host
+// as sessionID replaces it, and the old process is told to exit
+func (s *sessions) ExpectMigration(sessionID uint32, pid uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session := (*s.sessions)[sessionID]
+	if session == nil {
+		return
+	}
+	(*s.migrations)[migrationKey(session.Hostname, int32(pid))] = &migration{
+		SessionID: sessionID,
+		Expires:   time.Now().Add(migrationTimeout),
+	}
+}
+
+// CancelMigration - Forget about a migration that failed
+func (s *sessions) CancelMigration(sessionID uint32, pid uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session := (*s.sessions)[sessionID]
+	if session == nil {
+		return
+	}
+	delete(*s.migrations, migrationKey(session.Hostname, int32(pid)))
+}
+
+// completeMigration - Must be called with the lock held
+func (s *sessions) completeMigration(session *Session) bool {
+	key := migrationKey(session.Hostname, session.PID)
+	pending, ok := (*s.migrations)[key]
+	if !ok {
+		return false
+	}
+	delete(*s.migrations, key)
+	old := (*s.sessions)[pending.SessionID]
+	if old == nil || time.Now().After(pending.Expires) {
+		return false
+	}
+	// Shells, socks and port forwards were running in the old process
+	RportFwds.RemoveSession(old.ID)
+	Tunnels.RemoveSession(old.ID)
+	session.ID = old.ID
+	session.Tag(old.GetTags(), false)
+	// The old session keeps its ID, the old connection's cleanup goes through
+	// RemoveSession which leaves the new session in place
+	(*s.sessions)[session.ID] = session
+	EventBroker.Publish(Event{
+		EventType: consts.SessionMigratedEvent,
+		Session:   session,
+	})
+	data, _ := proto.Marshal(&sliverpb.KillSessionReq{Force: false})
+	old.enqueueTimeout(&sliverpb.Envelope{Type: sliverpb.MsgKillSessionReq, Data: data}, migrationKillTimeout)
+	return true
+}
+
+func migrationKey(hostname string, pid int32) string {
+	return fmt.Sprintf("%s:%d", hostname, pid)
+}
+
 // Remove - Remove a sliver from the hive (atomically)
 func (s *sessions) Remove(sessionID uint32) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	session := (*s.sessions)[sessionID]
-	delete((*s.sessions), sessionID)
+	session, ok := (*s.sessions)[sessionID]
+	if !ok {
+		return
+	}
+	s.remove(session)
+}
+
+// RemoveSession - Remove a session when its connection closes, unless another
+// process has taken over its ID since
+func (s *sessions) RemoveSession(session *Session) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if (*s.sessions)[session.ID] != session {
+		return
+	}
+	s.remove(session)
+}
+
+// remove - Must be called with the lock held
+func (s *sessions) remove(session *Session) {
+	delete((*s.sessions), session.ID)
+	RportFwds.RemoveSession(session.ID)
 	EventBroker.Publish(Event{
 		EventType: consts.SessionClosedEvent,
 		Session:   session,
@@ -0,0 +1,232 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/golang/protobuf/proto"
+)
+
+func newTestSession(hostname string, pid int32) *Session {
+	return &Session{
+		ID:        NextSessionID(),
+		Hostname:  hostname,
+		PID:       pid,
+		Send:      make(chan *sliverpb.Envelope, 1),
+		RespMutex: &sync.RWMutex{},
+	}
+}
+
+func TestMigration(t *testing.T) {
+	old := Sessions.Add(newTestSession("migration-test", 100))
+	oldID := old.ID
+	tunnel := Tunnels.Create(oldID)
+	Sessions.ExpectMigration(oldID, 200)
+
+	unrelated := Sessions.Add(newTestSession("migration-test", 300))
+	if unrelated.ID == oldID {
+		t.Fatalf("Unrelated session took over migrating session")
+	}
+
+	migrated := Sessions.Add(newTestSession("migration-test", 200))
+	if migrated.ID != oldID {
+		t.Fatalf("Expected migrated session to have id %d, got %d", oldID, migrated.ID)
+	}
+	if Sessions.Get(oldID) != migrated {
+		t.Fatalf("Session %d was not replaced", oldID)
+	}
+	if Tunnels.Get(tunnel.ID) != nil {
+		t.Errorf("Tunnel of the old process was not closed")
+	}
+
+	select {
+	case envelope := <-old.Send:
+		if envelope.Type != sliverpb.MsgKillSessionReq {
+			t.Errorf("Expected kill request, got message type %d", envelope.Type)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Old session was not told to exit")
+	}
+
+	// Cleanup of the old connection must not remove the new session
+	Sessions.RemoveSession(old)
+	if Sessions.Get(oldID) != migrated {
+		t.Errorf("Old session cleanup removed the migrated session")
+	}
+	Sessions.RemoveSession(migrated)
+	if Sessions.Get(oldID) != nil {
+		t.Errorf("Migrated session was not removed")
+	}
+	Sessions.Remove(unrelated.ID)
+}
+
+// The kill request waits for room in the old session's queue, without holding up
+// the new session
+func TestMigrationKillQueued(t *testing.T) {
+	old := Sessions.Add(newTestSession("kill-test", 100))
+	old.SendPolicy = SendPolicyBlock
+	old.Send <- &sliverpb.Envelope{Type: sliverpb.MsgPing}
+	Sessions.ExpectMigration(old.ID, 200)
+	migrated := Sessions.Add(newTestSession("kill-test", 200))
+	if migrated.ID != old.ID {
+		t.Fatalf("Expected migrated session to have id %d, got %d", old.ID, migrated.ID)
+	}
+	<-old.Send
+	select {
+	case envelope := <-old.Send:
+		if envelope.Type != sliverpb.MsgKillSessionReq {
+			t.Errorf("Expected kill request, got message type %d", envelope.Type)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Old session was not told to exit")
+	}
+	Sessions.RemoveSession(migrated)
+}
+
+func TestCancelMigration(t *testing.T) {
+	old := Sessions.Add(newTestSession("cancel-test", 100))
+	Sessions.ExpectMigration(old.ID, 200)
+	Sessions.CancelMigration(old.ID, 200)
+	session := Sessions.Add(newTestSession("cancel-test", 200))
+	if session.ID == old.ID {
+		t.Errorf("Cancelled migration still took over session %d", old.ID)
+	}
+	Sessions.Remove(old.ID)
+	Sessions.Remove(session.ID)
+}
+
+func TestRequestTimeoutNotSent(t *testing.T) {
+	session := newTestSession("timeout-test", 100)
+	session.Resp = map[uint64]chan *sliverpb.Envelope{}
+	session.Send <- &sliverpb.Envelope{} // Nothing drains Send, like a dead connection
+	_, err := session.Request(sliverpb.MsgPing, 50*time.Millisecond, []byte{})
+	if !errors.Is(err, ErrImplantTimeout) {
+		t.Fatalf("Expected implant timeout, got %v", err)
+	}
+}
+
+func TestRequestTimeoutCancel(t *testing.T) {
+	session := newTestSession("cancel-request-test", 100)
+	session.Resp = map[uint64]chan *sliverpb.Envelope{}
+	session.ProtocolVersion = sliverpb.ProtocolVersion
+	_, err := session.Request(sliverpb.MsgPing, 50*time.Millisecond, []byte{})
+	if !errors.Is(err, ErrImplantTimeout) {
+		t.Fatalf("Expected implant timeout, got %v", err)
+	}
+	request := <-session.Send
+	select {
+	case envelope := <-session.Send:
+		if envelope.Type != sliverpb.MsgCancelReq {
+			t.Fatalf("Expected cancel request, got message type %d", envelope.Type)
+		}
+		cancel := &sliverpb.CancelReq{}
+		proto.Unmarshal(envelope.Data, cancel)
+		if cancel.EnvelopeID != request.ID {
+			t.Errorf("Cancelled envelope %d, expected %d", cancel.EnvelopeID, request.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timed out request was not cancelled")
+	}
+
+	// Implants that predate cancellation are not sent one
+	session.ProtocolVersion = 1
+	session.Request(sliverpb.MsgPing, 50*time.Millisecond, []byte{})
+	<-session.Send
+	select {
+	case envelope := <-session.Send:
+		t.Errorf("Sent message type %d to an implant that predates cancellation", envelope.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSendQueuePolicies(t *testing.T) {
+	session := newTestSession("queue-test", 100)
+	session.Resp = map[uint64]chan *sliverpb.Envelope{}
+
+	session.SendPolicy = SendPolicyFail
+	session.Send <- &sliverpb.Envelope{}
+	_, err := session.Request(sliverpb.MsgPing, time.Second, []byte{})
+	if !errors.Is(err, ErrSendQueueFull) {
+		t.Fatalf("Expected full queue error, got %v", err)
+	}
+	if dropped := session.SendQueueDropped(); dropped != 1 {
+		t.Errorf("Expected 1 dropped, got %d", dropped)
+	}
+
+	// The queued request fails right away instead of timing out
+	session.SendPolicy = SendPolicyDropOldest
+	<-session.Send
+	oldest := make(chan error)
+	go func() {
+		_, err := session.Request(sliverpb.MsgPing, 5*time.Second, []byte{})
+		oldest <- err
+	}()
+	for depth, _ := session.SendQueueDepth(); depth == 0; depth, _ = session.SendQueueDepth() {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // Until it waits for the response
+	go session.Request(sliverpb.MsgNetstatReq, 100*time.Millisecond, []byte{})
+	select {
+	case err := <-oldest:
+		if !errors.Is(err, ErrSendQueueDropped) {
+			t.Errorf("Expected dropped request error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Dropped request did not fail")
+	}
+	if envelope := <-session.Send; envelope.Type != sliverpb.MsgNetstatReq {
+		t.Errorf("Expected the newest request to be queued, got message type %d", envelope.Type)
+	}
+	if dropped := session.SendQueueDropped(); dropped != 2 {
+		t.Errorf("Expected 2 dropped, got %d", dropped)
+	}
+}
+
+// Only one envelope makes room for the new one, however large the queue is
+func TestSendQueueDropOne(t *testing.T) {
+	session := newTestSession("queue-test", 100)
+	session.Send = make(chan *sliverpb.Envelope, 4)
+	session.Resp = map[uint64]chan *sliverpb.Envelope{}
+	session.SendPolicy = SendPolicyDropOldest
+	for id := uint64(1); id <= 4; id++ {
+		session.Send <- &sliverpb.Envelope{ID: id}
+	}
+	for id := uint64(5); id < 100; id++ {
+		queued, err := session.enqueue(&sliverpb.Envelope{ID: id})
+		if !queued || err != nil {
+			t.Fatalf("Envelope %d was not queued (%v)", id, err)
+		}
+		if depth, _ := session.SendQueueDepth(); depth != 4 {
+			t.Fatalf("Expected a full queue after envelope %d, got depth %d", id, depth)
+		}
+		if dropped := session.SendQueueDropped(); dropped != id-4 {
+			t.Fatalf("Expected %d dropped after envelope %d, got %d", id-4, id, dropped)
+		}
+	}
+	for id := uint64(96); id < 100; id++ {
+		if envelope := <-session.Send; envelope.ID != id {
+			t.Fatalf("Expected envelope %d, got %d", id, envelope.ID)
+		}
+	}
+}
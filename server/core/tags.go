@@ -0,0 +1,76 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// Sessions have no lock of their own, beacons use theirs
+	sessionTagsMutex = &sync.RWMutex{}
+)
+
+// UpdateTags - Add or remove tags, tags are lower cased, de-duplicated, and sorted
+func UpdateTags(tags []string, changes []string, remove bool) []string {
+	set := map[string]bool{}
+	for _, tag := range tags {
+		set[tag] = true
+	}
+	for _, tag := range changes {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if remove {
+			delete(set, tag)
+		} else {
+			set[tag] = true
+		}
+	}
+	updated := []string{}
+	for tag := range set {
+		updated = append(updated, tag)
+	}
+	sort.Strings(updated)
+	return updated
+}
+
+// Tag - Add or remove operator tags of the session
+func (s *Session) Tag(changes []string, remove bool) {
+	sessionTagsMutex.Lock()
+	defer sessionTagsMutex.Unlock()
+	s.Tags = UpdateTags(s.Tags, changes, remove)
+}
+
+// GetTags - Operator tags of the session
+func (s *Session) GetTags() []string {
+	sessionTagsMutex.RLock()
+	defer sessionTagsMutex.RUnlock()
+	return s.Tags
+}
+
+// Tag - Add or remove operator tags of the beacon, tags are kept across check-ins
+func (b *Beacon) Tag(changes []string, remove bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.Tags = UpdateTags(b.Tags, changes, remove)
+}
@@ -0,0 +1,51 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdateTags(t *testing.T) {
+	tags := UpdateTags(nil, []string{"DC", " web ", "dc", ""}, false)
+	if !reflect.DeepEqual(tags, []string{"dc", "web"}) {
+		t.Fatalf("Unexpected tags %v", tags)
+	}
+	tags = UpdateTags(tags, []string{"Web", "missing"}, true)
+	if !reflect.DeepEqual(tags, []string{"dc"}) {
+		t.Fatalf("Unexpected tags after removal %v", tags)
+	}
+	tags = UpdateTags(tags, []string{"dc"}, true)
+	if len(tags) != 0 {
+		t.Fatalf("Expected no tags, got %v", tags)
+	}
+}
+
+func TestMigrationKeepsTags(t *testing.T) {
+	old := Sessions.Add(newTestSession("tags-test", 300))
+	old.Tag([]string{"pwned"}, false)
+	Sessions.ExpectMigration(old.ID, 400)
+	migrated := Sessions.Add(newTestSession("tags-test", 400))
+	if !reflect.DeepEqual(migrated.GetTags(), []string{"pwned"}) {
+		t.Errorf("Migrated session lost its tags, got %v", migrated.GetTags())
+	}
+	Sessions.Remove(migrated.ID)
+	Sessions.Remove(old.ID)
+}
@@ -0,0 +1,203 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"sync"
+	"time"
+
+	consts "github.com/bishopfox/sliver/client/constants"
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+const (
+	// TransferSend - From the server to the implant
+	TransferSend = "send"
+	// TransferRecv - From the implant to the server
+	TransferRecv = "recv"
+
+	// Smaller envelopes arrive before anyone could watch a progress bar
+	transferMinSize = 64 * 1024
+
+	// Progress events of a transfer are published at most this often
+	transferEventInterval = 500 * time.Millisecond
+)
+
+var (
+	// Transfers - Large envelopes moving between the server and sessions
+	Transfers = &transfers{
+		active: map[uint64]*Transfer{},
+		mutex:  &sync.RWMutex{},
+	}
+	transferID = new(uint64)
+)
+
+// Transfer - Progress of one envelope, the transports report bytes and chunks as they
+// go. A nil *Transfer is valid and ignores updates, see Start.
+type Transfer struct {
+	ID         uint64
+	SessionID  uint32
+	Transport  string
+	Direction  string
+	EnvelopeID uint64
+	MsgType    uint32
+	Total      int64
+	Chunks     uint32
+	StartedAt  time.Time
+
+	done       int64
+	chunksDone uint32
+	completed  bool
+	err        string
+	lastEvent  time.Time
+	mutex      sync.Mutex
+}
+
+type transfers struct {
+	active map[uint64]*Transfer
+	mutex  *sync.RWMutex
+}
+
+// Start - Track an envelope of total bytes, in chunks if the transport splits it, returns
+// nil for envelopes too small to be worth tracking
+func (t *transfers) Start(session *Session, direction string, envelopeID uint64, msgType uint32, total int64, chunks uint32) *Transfer {
+	if session == nil || total < transferMinSize {
+		return nil
+	}
+	t.mutex.Lock()
+	*transferID++
+	transfer := &Transfer{
+		ID:         *transferID,
+		SessionID:  session.ID,
+		Transport:  session.Transport,
+		Direction:  direction,
+		EnvelopeID: envelopeID,
+		MsgType:    msgType,
+		Total:      total,
+		Chunks:     chunks,
+		StartedAt:  time.Now(),
+	}
+	t.active[transfer.ID] = transfer
+	t.mutex.Unlock()
+	transfer.publish(true)
+	return transfer
+}
+
+// All - The transfers in progress
+func (t *transfers) All() []*Transfer {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	all := []*Transfer{}
+	for _, transfer := range t.active {
+		all = append(all, transfer)
+	}
+	return all
+}
+
+// Add - Count bytes moved, for transports that don't chunk
+func (t *Transfer) Add(n int64) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	t.done += n
+	t.mutex.Unlock()
+	t.publish(false)
+}
+
+// Update - Set the bytes and chunks moved so far, chunks may be re-sent so the
+// progress never goes backwards
+func (t *Transfer) Update(done int64, chunksDone uint32) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	if t.Total < done {
+		done = t.Total
+	}
+	if t.done < done {
+		t.done = done
+	}
+	if t.chunksDone < chunksDone {
+		t.chunksDone = chunksDone
+	}
+	t.mutex.Unlock()
+	t.publish(false)
+}
+
+// Complete - The envelope arrived, or the transfer failed if err is not nil
+func (t *Transfer) Complete(err error) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	if t.completed {
+		t.mutex.Unlock()
+		return
+	}
+	t.completed = true
+	if err != nil {
+		t.err = err.Error()
+	} else {
+		t.done = t.Total
+		t.chunksDone = t.Chunks
+	}
+	t.mutex.Unlock()
+
+	Transfers.mutex.Lock()
+	delete(Transfers.active, t.ID)
+	Transfers.mutex.Unlock()
+	t.publish(true)
+}
+
+// publish - Progress events are rate limited, unless force is set
+func (t *Transfer) publish(force bool) {
+	t.mutex.Lock()
+	if !force && time.Since(t.lastEvent) < transferEventInterval {
+		t.mutex.Unlock()
+		return
+	}
+	t.lastEvent = time.Now()
+	t.mutex.Unlock()
+	EventBroker.Publish(Event{
+		EventType: consts.TransferProgressEvent,
+		Transfer:  t,
+	})
+}
+
+// ToProtobuf - Get the protobuf version of the object
+func (t *Transfer) ToProtobuf() *clientpb.Transfer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return &clientpb.Transfer{
+		ID:         t.ID,
+		SessionID:  t.SessionID,
+		Transport:  t.Transport,
+		Direction:  t.Direction,
+		EnvelopeID: t.EnvelopeID,
+		MsgType:    t.MsgType,
+		Total:      t.Total,
+		Done:       t.done,
+		Chunks:     t.Chunks,
+		ChunksDone: t.chunksDone,
+		StartedAt:  t.StartedAt.Format(time.RFC1123),
+		Completed:  t.completed,
+		Err:        t.err,
+	}
+}
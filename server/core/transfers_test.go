@@ -0,0 +1,81 @@
+package core
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransferProgress(t *testing.T) {
+	session := newTestSession("transfer-test", 100)
+	if Transfers.Start(session, TransferSend, 1, 0, transferMinSize-1, 0) != nil {
+		t.Fatalf("Tracked an envelope smaller than %d bytes", transferMinSize)
+	}
+
+	transfer := Transfers.Start(session, TransferSend, 1, 0, transferMinSize, 4)
+	if transfer == nil {
+		t.Fatalf("Did not track a %d byte envelope", transferMinSize)
+	}
+	transfer.Update(transferMinSize/2, 2)
+	transfer.Update(transferMinSize/4, 1) // Re-sent chunks
+	progress := transfer.ToProtobuf()
+	if progress.Done != transferMinSize/2 || progress.ChunksDone != 2 {
+		t.Errorf("Progress went backwards, %d bytes and %d chunks done", progress.Done, progress.ChunksDone)
+	}
+	transfer.Update(transferMinSize*2, 4)
+	if progress := transfer.ToProtobuf(); progress.Done != transferMinSize {
+		t.Errorf("Progress went past the total, %d bytes done", progress.Done)
+	}
+
+	found := false
+	for _, active := range Transfers.All() {
+		found = found || active == transfer
+	}
+	if !found {
+		t.Fatalf("Transfer in progress is not listed")
+	}
+	transfer.Complete(nil)
+	for _, active := range Transfers.All() {
+		if active == transfer {
+			t.Fatalf("Completed transfer is still listed")
+		}
+	}
+	if progress := transfer.ToProtobuf(); !progress.Completed || progress.Err != "" {
+		t.Errorf("Expected completed transfer, got %v", progress)
+	}
+}
+
+func TestTransferFailed(t *testing.T) {
+	session := newTestSession("transfer-failed-test", 100)
+	transfer := Transfers.Start(session, TransferRecv, 0, 0, transferMinSize, 0)
+	transfer.Add(10)
+	transfer.Complete(errors.New("connection reset"))
+	transfer.Complete(nil)
+	progress := transfer.ToProtobuf()
+	if progress.Err != "connection reset" || progress.Done != 10 {
+		t.Errorf("Expected failed transfer after 10 bytes, got %v", progress)
+	}
+
+	// Untracked envelopes are nil and ignore updates
+	var untracked *Transfer
+	untracked.Add(10)
+	untracked.Update(10, 1)
+	untracked.Complete(nil)
+}
@@ -25,8 +25,6 @@ import (
 	"sync"
 
 	"github.com/bishopfox/sliver/protobuf/rpcpb"
-	"github.com/bishopfox/sliver/protobuf/sliverpb"
-	"github.com/golang/protobuf/proto"
 )
 
 var (
@@ -49,6 +47,43 @@ type Tunnel struct {
 	ToImplant   chan []byte
 	FromImplant chan []byte
 	Client      rpcpb.SliverRPC_TunnelDataServer
+
+	// Messages from the implant are handled concurrently so they may arrive
+	// out of order, sequence numbers are used to pass them on in order
+	fromImplantSequence uint64
+	pending             map[uint64][]byte
+	closeSequence       *uint64
+	mutex               *sync.Mutex
+}
+
+// SendFromImplant - Pass data from the implant to the client in sequence order,
+// returns true if the tunnel should now be closed
+func (t *Tunnel) SendFromImplant(sequence uint64, data []byte) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if sequence < t.fromImplantSequence {
+		return false // Duplicate
+	}
+	t.pending[sequence] = data
+	for {
+		next, ok := t.pending[t.fromImplantSequence]
+		if !ok {
+			break
+		}
+		delete(t.pending, t.fromImplantSequence)
+		t.fromImplantSequence++
+		t.FromImplant <- next
+	}
+	return t.closeSequence != nil && *t.closeSequence <= t.fromImplantSequence
+}
+
+// CloseFromImplant - Returns true if all data sent by the implant before it
+// closed the tunnel has been passed on, otherwise the close is deferred
+func (t *Tunnel) CloseFromImplant(sequence uint64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.closeSequence = &sequence
+	return sequence <= t.fromImplantSequence
 }
 
 type tunnels struct {
@@ -59,12 +94,7 @@ type tunnels struct {
 func (t *tunnels) Create(sessionID uint32) *Tunnel {
 	tunnelID := NewTunnelID()
 	session := Sessions.Get(sessionID)
-	tunnel := &Tunnel{
-		ID:          tunnelID,
-		SessionID:   session.ID,
-		ToImplant:   make(chan []byte),
-		FromImplant: make(chan []byte),
-	}
+	tunnel := newTunnel(tunnelID, session.ID)
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 	(*t.tunnels)[tunnel.ID] = tunnel
@@ -72,6 +102,31 @@ func (t *tunnels) Create(sessionID uint32) *Tunnel {
 	return tunnel
 }
 
+// Add - Create a tunnel with an ID picked by the implant
+func (t *tunnels) Add(tunnelID uint64, sessionID uint32) (*Tunnel, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := (*t.tunnels)[tunnelID]; ok {
+		return nil, ErrInvalidTunnelID
+	}
+	tunnel := newTunnel(tunnelID, sessionID)
+	(*t.tunnels)[tunnel.ID] = tunnel
+	return tunnel, nil
+}
+
+func newTunnel(tunnelID uint64, sessionID uint32) *Tunnel {
+	return &Tunnel{
+		ID:          tunnelID,
+		SessionID:   sessionID,
+		ToImplant:   make(chan []byte),
+		FromImplant: make(chan []byte),
+		pending:     map[uint64][]byte{},
+		mutex:       &sync.Mutex{},
+	}
+}
+
+// Close - Close a tunnel, the in-band close is sent to the implant once all
+// pending data has been sent (see rpc.TunnelData)
 func (t *tunnels) Close(tunnelID uint64) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
@@ -80,28 +135,26 @@ func (t *tunnels) Close(tunnelID uint64) error {
 	if tunnel == nil {
 		return ErrInvalidTunnelID
 	}
-	tunnelClose, err := proto.Marshal(&sliverpb.TunnelData{
-		TunnelID:  tunnel.ID,
-		SessionID: tunnel.SessionID,
-		Closed:    true,
-	})
-	if err != nil {
-		return err
-	}
-	data, err := proto.Marshal(&sliverpb.Envelope{
-		Type: sliverpb.MsgTunnelClose,
-		Data: tunnelClose,
-	})
-	if err != nil {
-		return err
-	}
-	tunnel.ToImplant <- data // Send an in-band close to implant
 	delete(*t.tunnels, tunnelID)
 	close(tunnel.ToImplant)
 	close(tunnel.FromImplant)
 	return nil
 }
 
+// RemoveSession - Close all tunnels of a session
+func (t *tunnels) RemoveSession(sessionID uint32) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for tunnelID, tunnel := range *t.tunnels {
+		if tunnel.SessionID != sessionID {
+			continue
+		}
+		delete(*t.tunnels, tunnelID)
+		close(tunnel.ToImplant)
+		close(tunnel.FromImplant)
+	}
+}
+
 // Get - Get a tunnel
 func (t *tunnels) Get(tunnelID uint64) *Tunnel {
 	t.mutex.Lock()
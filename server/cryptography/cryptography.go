@@ -96,27 +96,41 @@ func RSADecrypt(ciphertext []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
 
 // GCMEncrypt - Encrypt using AES GCM
 func GCMEncrypt(key AESKey, plaintext []byte) ([]byte, error) {
+	return GCMEncryptAppend(nil, key, plaintext)
+}
+
+// GCMEncryptAppend - Like GCMEncrypt but appends the nonce and ciphertext to dst,
+// so callers can reuse a buffer
+func GCMEncryptAppend(dst []byte, key AESKey, plaintext []byte) ([]byte, error) {
 	block, _ := aes.NewCipher(key[:])
-	nonce := make([]byte, GCMNonceSize)
-	if _, err := io.ReadFull(secureRand.Reader, nonce); err != nil {
-		return nil, err
-	}
 	aesgcm, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
-	ciphertext := aesgcm.Seal(nil, nonce, plaintext, nil)
-
 	// Prepend nonce to ciphertext
-	ciphertext = append(nonce, ciphertext...)
-	return ciphertext, nil
+	start := len(dst)
+	dst = append(dst, make([]byte, GCMNonceSize)...)
+	nonce := dst[start:]
+	if _, err := io.ReadFull(secureRand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aesgcm.Seal(dst, nonce, plaintext, nil), nil
 }
 
 // GCMDecrypt - Decrypt GCM ciphertext
 func GCMDecrypt(key AESKey, ciphertext []byte) ([]byte, error) {
+	return GCMDecryptAppend(nil, key, ciphertext)
+}
+
+// GCMDecryptAppend - Like GCMDecrypt but appends the plaintext to dst, so callers
+// can reuse a buffer
+func GCMDecryptAppend(dst []byte, key AESKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < GCMNonceSize {
+		return nil, errors.New("GCM ciphertext too short")
+	}
 	block, _ := aes.NewCipher(key[:])
 	aesgcm, _ := cipher.NewGCM(block)
-	plaintext, err := aesgcm.Open(nil, ciphertext[:GCMNonceSize], ciphertext[GCMNonceSize:], nil)
+	plaintext, err := aesgcm.Open(dst, ciphertext[:GCMNonceSize], ciphertext[GCMNonceSize:], nil)
 	if err != nil {
 		return nil, err
 	}
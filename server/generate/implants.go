@@ -19,10 +19,12 @@ package generate
 */
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -40,6 +42,7 @@ const (
 	implantConfigNamespace   = "config"
 	implantFileNamespace     = "file"
 	implantDatetimeNamespace = "datetime"
+	implantSHA256Namespace   = "sha256"
 )
 
 var (
@@ -97,26 +100,54 @@ func ImplantConfigSave(config *ImplantConfig) error {
 	return bucket.Set(fmt.Sprintf("%s.%s", implantConfigNamespace, config.Name), rawConfig)
 }
 
-// ImplantFileSave - Saves a binary file into the database
-func ImplantFileSave(name, fPath string) error {
-	bucket, err := db.GetBucket(implantBucketName)
-	if err != nil {
-		return err
-	}
-
+// ImplantFileSave - Saves a binary file into the database, returns its SHA256
+func ImplantFileSave(name, fPath string) (string, error) {
 	rootAppDir, _ := filepath.Abs(assets.GetRootAppDir())
 	fPath, _ = filepath.Abs(fPath)
 	if !strings.HasPrefix(fPath, rootAppDir) {
-		return fmt.Errorf("Invalid path '%s' is not a subdirectory of '%s'", fPath, rootAppDir)
+		return "", fmt.Errorf("Invalid path '%s' is not a subdirectory of '%s'", fPath, rootAppDir)
 	}
 
 	data, err := ioutil.ReadFile(fPath)
 	if err != nil {
-		return err
+		return "", err
 	}
-	storageLog.Infof("Saved '%s' file to database %d byte(s)", name, len(data))
+	return ImplantFileDataSave(name, data)
+}
+
+// ImplantFileDataSave - Saves a binary file's contents into the database, the
+// SHA256 is indexed so a recovered sample can be matched to the build
+func ImplantFileDataSave(name string, data []byte) (string, error) {
+	bucket, err := db.GetBucket(implantBucketName)
+	if err != nil {
+		return "", err
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256(data))
+	storageLog.Infof("Saved '%s' file to database %d byte(s) (sha256 %s)", name, len(data), digest)
 	bucket.Set(fmt.Sprintf("%s.%s", implantDatetimeNamespace, name), []byte(time.Now().Format(time.RFC1123)))
-	return bucket.Set(fmt.Sprintf("%s.%s", implantFileNamespace, name), data)
+	err = bucket.Set(fmt.Sprintf("%s.%s", implantSHA256Namespace, digest), []byte(name))
+	if err != nil {
+		return "", err
+	}
+	return digest, bucket.Set(fmt.Sprintf("%s.%s", implantFileNamespace, name), data)
+}
+
+// ImplantConfigBySHA256 - Get the config of the build an artifact came from
+func ImplantConfigBySHA256(digest string) (*ImplantConfig, error) {
+	bucket, err := db.GetBucket(implantBucketName)
+	if err != nil {
+		return nil, err
+	}
+	name, err := bucket.Get(fmt.Sprintf("%s.%s", implantSHA256Namespace, strings.ToLower(digest)))
+	if err != nil {
+		return nil, ErrImplantNotFound
+	}
+	return ImplantConfigByName(string(name))
+}
+
+// ImplantCleanBuildDir - Remove the files of a previous build of an implant
+func ImplantCleanBuildDir(config *ImplantConfig) error {
+	return os.RemoveAll(filepath.Join(GetSliversDir(), config.GOOS, config.GOARCH, config.Name))
 }
 
 // ImplantFileByName - Saves a binary file into the database
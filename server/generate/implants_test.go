@@ -0,0 +1,61 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestImplantConfigBySHA256(t *testing.T) {
+	config := &ImplantConfig{
+		Name:     "HASHED_SAMPLE",
+		GOOS:     "windows",
+		GOARCH:   "amd64",
+		Operator: "alice",
+	}
+	data := []byte("not really an implant")
+	digest, err := ImplantFileDataSave(config.Name, data)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if digest != fmt.Sprintf("%x", sha256.Sum256(data)) {
+		t.Errorf("Wrong digest %s", digest)
+	}
+	config.SHA256 = digest
+	err = ImplantConfigSave(config)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	found, err := ImplantConfigBySHA256(strings.ToUpper(digest))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if found.Name != config.Name || found.Operator != config.Operator {
+		t.Errorf("Found the wrong build %v", found)
+	}
+
+	_, err = ImplantConfigBySHA256(fmt.Sprintf("%x", sha256.Sum256([]byte("unknown"))))
+	if err != ErrImplantNotFound {
+		t.Errorf("Expected %v, got %v", ErrImplantNotFound, err)
+	}
+}
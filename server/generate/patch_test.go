@@ -0,0 +1,116 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// decodePatchRegion - Same as the implant's parsePatchRegion
+func decodePatchRegion(t *testing.T, region string) []string {
+	key := region[:patchKeySize]
+	data, err := base64.StdEncoding.DecodeString(strings.TrimRight(region[patchKeySize:], " "))
+	if err != nil {
+		t.Fatalf("Invalid region %s", err)
+	}
+	for index := range data {
+		data[index] ^= key[index%len(key)]
+	}
+	fields := []string{}
+	for _, field := range strings.Split(string(data), "\n") {
+		value, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			t.Fatalf("Invalid field %s", err)
+		}
+		fields = append(fields, string(value))
+	}
+	return fields
+}
+
+func TestPatchImplant(t *testing.T) {
+	config := &ImplantConfig{
+		Name:              "BLANK",
+		Patchable:         true,
+		Key:               "key",
+		Cert:              "cert",
+		CACert:            "ca cert",
+		C2:                []ImplantC2{{URL: "mtls://1.example.com"}},
+		ReconnectInterval: 60,
+	}
+	renderConfig, region, err := patchRenderConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(region) != patchRegionSize || !strings.HasPrefix(region, config.PatchMarker) {
+		t.Fatalf("Invalid patch region")
+	}
+	if renderConfig.Key != "" || renderConfig.Name != "" || len(renderConfig.C2) != 0 || config.Key != "key" {
+		t.Fatalf("Patched values must only be rendered in the region")
+	}
+	if fields := decodePatchRegion(t, region); fields[1] != "key" {
+		t.Fatalf("Wrong fields %v", fields)
+	}
+
+	data := []byte("header" + region + "footer")
+	variant := *config
+	variant.Name = "VARIANT"
+	variant.Key = "variant key"
+	variant.C2 = []ImplantC2{{URL: "mtls://2.example.com"}}
+	patched, err := PatchImplant(data, config, &variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patched) != len(data) || !bytes.HasPrefix(patched, []byte("header")) || !bytes.HasSuffix(patched, []byte("footer")) {
+		t.Fatalf("Patch changed the layout of the file")
+	}
+	if bytes.Contains(patched, []byte(config.PatchMarker)) || variant.PatchMarker == config.PatchMarker {
+		t.Fatalf("Variant must get a new key")
+	}
+	fields := decodePatchRegion(t, string(patched[len("header"):len(patched)-len("footer")]))
+	if fields[0] != "VARIANT" || fields[1] != "variant key" || fields[5] != "60" {
+		t.Fatalf("Wrong fields %v", fields)
+	}
+
+	_, err = PatchImplant([]byte("no region"), config, &variant)
+	if err != ErrPatchRegionNotFound {
+		t.Fatalf("Expected %v, got %v", ErrPatchRegionNotFound, err)
+	}
+	_, err = PatchImplant(data, &ImplantConfig{}, &variant)
+	if err != ErrNotPatchable {
+		t.Fatalf("Expected %v, got %v", ErrNotPatchable, err)
+	}
+	variant.Cert = strings.Repeat("A", patchRegionSize)
+	_, err = PatchImplant(data, config, &variant)
+	if err != ErrPatchTooLarge {
+		t.Fatalf("Expected %v, got %v", ErrPatchTooLarge, err)
+	}
+}
+
+func TestPatchC2Compiled(t *testing.T) {
+	config := &ImplantConfig{MTLSc2Enabled: true, HTTPc2Enabled: true}
+	if !patchC2Compiled(config, []ImplantC2{{URL: "https://a.example.com"}, {URL: "mtls://b.example.com"}}) {
+		t.Fatalf("Compiled schemes were refused")
+	}
+	if patchC2Compiled(config, []ImplantC2{{URL: "dns://a.example.com"}}) {
+		t.Fatalf("A scheme that isn't compiled in was allowed")
+	}
+}
@@ -26,9 +26,11 @@ package generate
 var (
 	srcFiles = []string{
 		"constants/constants.go",
+		"constants/patch.go",
 
 		"encoders/base64.go",
 		"encoders/combos.go",
+		"encoders/dns.go",
 		"encoders/encoders.go",
 		"encoders/english-words.go",
 		"encoders/english.go",
@@ -78,12 +80,48 @@ var (
 
 		"ps/ps.go",
 		"ps/ps_windows.go",
+		"ps/details_windows.go",
 		"ps/ps_linux.go",
 		"ps/ps_darwin.go",
 
+		"persist/persist.go",
+		"persist/persist_windows.go",
+		"persist/persist_darwin.go",
+		"persist/persist_linux.go",
+
+		"registry/registry_windows.go",
+
 		"service/service.go",
 		"service/service_windows.go",
 
+		"ssh/ssh.go",
+
+		"search/search.go",
+
+		"checksum/checksum.go",
+
+		"arp/arp.go",
+		"arp/arp_darwin.go",
+		"arp/arp_linux.go",
+		"arp/arp_windows.go",
+
+		"curl/curl.go",
+
+		"kerberos/kerberos_windows.go",
+		"ldap/ldap_windows.go",
+
+		"portscan/portscan.go",
+
+		"drives/drives.go",
+		"drives/drives_darwin.go",
+		"drives/drives_linux.go",
+		"drives/drives_windows.go",
+
+		"hostinfo/hostinfo.go",
+		"hostinfo/hostinfo_darwin.go",
+		"hostinfo/hostinfo_linux.go",
+		"hostinfo/hostinfo_windows.go",
+
 		"shell/shell.go",
 		"shell/shell_windows.go",
 		"shell/shell_darwin.go",
@@ -97,18 +135,45 @@ var (
 		"shell/pty/types.go",
 		"shell/pty/ztypes_386.go",
 		"shell/pty/ztypes_amd64.go",
+		"shell/pty/ztypes_arm64.go",
 		"shell/pty/ioctl.go",
 		"shell/pty/ioctl_bsd.go",
 		"shell/pty/ioctl_darwin.go",
 		"shell/pty/pty_unsupported.go",
 
+		"socks/socks5.go",
+
+		"timestomp/timestomp.go",
+		"timestomp/timestomp_windows.go",
+		"timestomp/timestomp_linux.go",
+		"timestomp/timestomp_darwin.go",
+
+		"wmi/wmi_windows.go",
+
+		"powershell/clr_windows.go",
+		"powershell/powershell_windows.go",
+
+		"extension/extension_windows.go",
+
+		"bof/beacon_windows.go",
+		"bof/bof_windows.go",
+
 		"taskrunner/task.go",
+		"taskrunner/dll_windows.go",
+		"taskrunner/pe_windows.go",
 		"taskrunner/task_windows.go",
 		"taskrunner/task_darwin.go",
 		"taskrunner/task_linux.go",
+		"taskrunner/ptrace_linux_amd64.go",
+		"taskrunner/ptrace_unsupported_linux.go",
 
 		"priv/priv.go",
 		"priv/priv_windows.go",
+		"priv/tokens_windows.go",
+		"priv/identity.go",
+		"priv/identity_windows.go",
+		"priv/runas.go",
+		"priv/runas_windows.go",
 
 		"pivots/named-pipe.go",
 		"pivots/named-pipe_windows.go",
@@ -119,18 +184,54 @@ var (
 		"sc/screenshot_linux.go",
 		"sc/screenshot_windows.go",
 		"sc/screenshot.go",
+		"sc/watch.go",
+
+		"clipboard/clipboard.go",
+		"clipboard/clipboard_darwin.go",
+		"clipboard/clipboard_linux.go",
+		"clipboard/clipboard_windows.go",
+
+		"browser/browser.go",
+		"browser/browser_darwin.go",
+		"browser/browser_linux.go",
+		"browser/browser_windows.go",
+
+		"hashdump/hashdump.go",
+		"hashdump/hashdump_darwin.go",
+		"hashdump/hashdump_linux.go",
+		"hashdump/hashdump_windows.go",
+
+		"lsass/lsass.go",
+		"lsass/minidump.go",
+		"lsass/msv.go",
+		"lsass/dump_windows.go",
+
+		"wifi/wifi.go",
+		"wifi/wifi_darwin.go",
+		"wifi/wifi_linux.go",
+		"wifi/wifi_windows.go",
+
+		"keylogger/keylogger.go",
+		"keylogger/keylogger_darwin.go",
+		"keylogger/keylogger_linux.go",
+		"keylogger/keylogger_windows.go",
 
 		"syscalls/syscalls.go",
 		"syscalls/syscalls_windows.go",
 		"syscalls/types_windows.go",
 		"syscalls/zsyscalls_windows.go",
 
+		"transports/compression.go",
 		"transports/crypto.go",
 		"transports/tcp-mtls.go",
 		"transports/tcp-http.go",
 		"transports/udp-dns.go",
 		"transports/named-pipe.go",
+		"transports/sleep-mask.go",
 		"transports/tcp-pivot.go",
+		"transports/limiter.go",
+		"transports/decoys.go",
+		"transports/shaping.go",
 		"transports/transports.go",
 
 		"version/version.go",
@@ -211,6 +312,7 @@ var (
 		"3rdparty/gen2brain/shm/shm_darwin.go",
 		"3rdparty/gen2brain/shm/shm_solaris.go",
 		"3rdparty/gen2brain/shm/shm_linux_amd64.go",
+		"3rdparty/gen2brain/shm/shm_linux_arm64.go",
 
 		"3rdparty/kbinani/screenshot/screenshot_openbsd.go",
 		"3rdparty/kbinani/screenshot/screenshot_darwin.go",
@@ -234,4 +336,52 @@ var (
 		// "go.mod",
 
 	}
+
+	// screenshotSrcDirs - Only rendered when screenshots are compiled in, the
+	// third party packages are X11 bindings only used for screenshots
+	screenshotSrcDirs = []string{
+		"sc/",
+		"3rdparty/kbinani/",
+		"3rdparty/BurntSushi/",
+		"3rdparty/gen2brain/",
+	}
+
+	// keyloggerSrcDirs - Only rendered when the keylogger is compiled in
+	keyloggerSrcDirs = []string{
+		"keylogger/",
+	}
+
+	// wasmSrcFiles - The experimental js/wasm implant is just the HTTP(S)
+	// transport and a small entrypoint, none of the OS specific packages
+	// above can be compiled for it
+	wasmSrcFiles = []string{
+		"constants/constants.go",
+		"constants/patch.go",
+
+		"encoders/base64.go",
+		"encoders/combos.go",
+		"encoders/dns.go",
+		"encoders/encoders.go",
+		"encoders/english-words.go",
+		"encoders/english.go",
+		"encoders/gzip.go",
+		"encoders/hex.go",
+		"encoders/images.go",
+
+		"proxy/provider_js.go",
+		"proxy/provider.go",
+		"proxy/proxy.go",
+		"proxy/url.go",
+
+		"transports/compression.go",
+		"transports/crypto.go",
+		"transports/tcp-http.go",
+		"transports/sleep-mask.go",
+		"transports/limiter.go",
+		"transports/decoys.go",
+		"transports/shaping.go",
+		"transports/transports.go",
+
+		"sliver_js.go",
+	}
 )
@@ -740,7 +740,10 @@ package generate
 */
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"math"
 	"path"
@@ -748,6 +751,11 @@ import (
 	"strings"
 )
 
+const (
+	peMachineI386  = 0x14c
+	peMachineAMD64 = 0x8664
+)
+
 // ShellcodeRDIToFile generates a sRDI shellcode and writes it to a file
 func ShellcodeRDIToFile(dllPath string, functionName string) (shellcodePath string, err error) {
 	shellcode, err := ShellcodeRDI(dllPath, functionName, "")
@@ -789,9 +797,8 @@ func ShellcodeRDI(dllPath string, functionName string, userdata string) (shellco
 	if userDataStr != "" {
 		userData = []byte(userDataStr)
 	}
-	shellcode = convertToShellcode(dllBytes, hashFunction, userData, flags)
 	//	err = os.RemoveAll(path.Clean(path.Dir(dllPath) + "/../"))
-	return shellcode, nil
+	return convertToShellcode(dllBytes, hashFunction, userData, flags)
 
 }
 
@@ -818,19 +825,26 @@ func ShellcodeRDIFromBytes(data []byte, functionName string, arguments string) (
 	if userDataStr != "" {
 		userData = []byte(userDataStr)
 	}
-	shellcode = convertToShellcode(data, hashFunction, userData, flags)
-	return shellcode, nil
+	return convertToShellcode(data, hashFunction, userData, flags)
 }
 
-func convertToShellcode(dllBytes, functionHash, userData []byte, flags int) []byte {
+func convertToShellcode(dllBytes, functionHash, userData []byte, flags int) ([]byte, error) {
 
 	if userData == nil {
 		userData = []byte("None")
 	}
 
+	machine, err := peMachine(dllBytes)
+	if err != nil {
+		return nil, err
+	}
+	if machine != peMachineAMD64 && machine != peMachineI386 {
+		return nil, fmt.Errorf("Unsupported DLL architecture (machine 0x%x), only x86 and x64 DLLs can be converted", machine)
+	}
+
 	var final []byte
 
-	if is64BitDLL(dllBytes) {
+	if machine == peMachineAMD64 {
 		// do 64 bit things
 
 		bootstrapSize := 64
@@ -962,7 +976,7 @@ func convertToShellcode(dllBytes, functionHash, userData []byte, flags int) []by
 		final = append(final, userData...)
 	}
 
-	return final
+	return final, nil
 
 }
 
@@ -994,16 +1008,14 @@ func ror(val uint32, rBits uint32, maxBits uint32) uint32 {
 	return ((val & exp) >> (rBits % maxBits)) | (val << (maxBits - (rBits % maxBits)) & exp)
 }
 
-func is64BitDLL(dllBytes []byte) bool {
-	machineIA64 := uint16(512)
-	machineAMD64 := uint16(34404)
-
-	headerOffset := binary.LittleEndian.Uint32(dllBytes[60:64])
-	machine := binary.LittleEndian.Uint16(dllBytes[headerOffset+4 : headerOffset+4+2])
-
-	// 64 bit
-	if machine == machineIA64 || machine == machineAMD64 {
-		return true
+// peMachine - Read the machine type from the PE header of a DLL
+func peMachine(dllBytes []byte) (uint16, error) {
+	if len(dllBytes) < 64 || dllBytes[0] != 'M' || dllBytes[1] != 'Z' {
+		return 0, errors.New("Not a PE file (missing MZ header)")
+	}
+	headerOffset := int64(binary.LittleEndian.Uint32(dllBytes[60:64]))
+	if int64(len(dllBytes)) < headerOffset+6 || !bytes.Equal(dllBytes[headerOffset:headerOffset+4], []byte("PE\x00\x00")) {
+		return 0, errors.New("Not a PE file (invalid PE header offset)")
 	}
-	return false
+	return binary.LittleEndian.Uint16(dllBytes[headerOffset+4 : headerOffset+6]), nil
 }
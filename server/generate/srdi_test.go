@@ -0,0 +1,89 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// fakeDLL - Just enough of a PE header for the sRDI conversion
+func fakeDLL(machine uint16) []byte {
+	dll := make([]byte, 256)
+	copy(dll, "MZ")
+	binary.LittleEndian.PutUint32(dll[60:64], 128)
+	copy(dll[128:], "PE\x00\x00")
+	binary.LittleEndian.PutUint16(dll[132:134], machine)
+	return dll
+}
+
+func TestHashFunctionName(t *testing.T) {
+	if hash := hashFunctionName("HelloWorld"); hash != 3571859646 {
+		t.Fatalf("Expected hash 3571859646, got %d", hash)
+	}
+}
+
+func TestShellcodeRDIFromBytes(t *testing.T) {
+	for _, sample := range []struct {
+		machine uint16
+		stub    []byte
+		size    int
+	}{
+		{peMachineAMD64, rdiShellcode64, 64},
+		{peMachineI386, rdiShellcode32, 45},
+	} {
+		dll := fakeDLL(sample.machine)
+		shellcode, err := ShellcodeRDIFromBytes(dll, "RunSliver", "")
+		if err != nil {
+			t.Fatalf("Conversion failed (machine 0x%x): %s", sample.machine, err)
+		}
+		if shellcode[0] != 0xe8 {
+			t.Fatalf("Expected shellcode to start with a call, got 0x%x", shellcode[0])
+		}
+		offset := sample.size + len(sample.stub)
+		if !bytes.Equal(shellcode[sample.size:offset], sample.stub) {
+			t.Fatalf("Loader stub not found after bootstrap (machine 0x%x)", sample.machine)
+		}
+		if !bytes.Equal(shellcode[offset:offset+len(dll)], dll) {
+			t.Fatalf("DLL not found after loader stub (machine 0x%x)", sample.machine)
+		}
+		if !bytes.HasSuffix(shellcode, []byte("None")) {
+			t.Fatalf("Expected default user data at the end of the shellcode")
+		}
+	}
+}
+
+func TestShellcodeRDIInvalid(t *testing.T) {
+	_, err := ShellcodeRDIFromBytes([]byte("not a dll"), "RunSliver", "")
+	if err == nil {
+		t.Fatal("Expected an error for a non-PE file")
+	}
+
+	truncated := fakeDLL(peMachineAMD64)[:100]
+	_, err = ShellcodeRDIFromBytes(truncated, "RunSliver", "")
+	if err == nil {
+		t.Fatal("Expected an error for a truncated PE file")
+	}
+
+	_, err = ShellcodeRDIFromBytes(fakeDLL(0xaa64), "RunSliver", "")
+	if err == nil {
+		t.Fatal("Expected an error for an arm64 DLL")
+	}
+}
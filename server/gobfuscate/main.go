@@ -1,8 +1,11 @@
 package gobfuscate
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"go/build"
+	insecureRand "math/rand"
 	"os"
 	"path"
 	"strings"
@@ -17,8 +20,9 @@ var (
 	obfuscateLog = log.NamedLogger("gobfuscate", "obfuscator")
 )
 
-// Gobfuscate - Obfuscate Go code
-func Gobfuscate(config gogo.GoConfig, encKey string, pkgName string, outPath string, symbols bool) (string, error) {
+// Gobfuscate - Obfuscate Go code, all names and string masks are derived from
+// the seed so obfuscating the same code with the same seed gives the same output
+func Gobfuscate(config gogo.GoConfig, seed string, pkgName string, outPath string, symbols bool) (string, error) {
 
 	ctx := build.Default
 	ctx.GOOS = config.GOOS
@@ -55,7 +59,7 @@ func Gobfuscate(config gogo.GoConfig, encKey string, pkgName string, outPath str
 	obfuscateLog.Infof("Copying GOPATH (%s) ...\n", ctx.GOPATH)
 
 	newPkgName := "github.com/bishopfox/sliver"
-	enc := &Encrypter{Key: encKey}
+	enc := &Encrypter{Key: seed}
 
 	if !CopyGopath(ctx, pkgName, newGopath, false) {
 		return "", errors.New("Failed to copy GOPATH")
@@ -67,11 +71,17 @@ func Gobfuscate(config gogo.GoConfig, encKey string, pkgName string, outPath str
 	}
 
 	obfuscateLog.Info("Obfuscating strings ...")
-	if err := ObfuscateStrings(newGopath); err != nil {
+	if err := ObfuscateStrings(newGopath, seededRand(seed)); err != nil {
 		obfuscateLog.Errorf("Failed to obfuscate strings: %v", err)
 		return "", err
 	}
 
+	obfuscateLog.Info("Obfuscating protobuf tags ...")
+	if err := ObfuscateProtobufTags(newGopath); err != nil {
+		obfuscateLog.Errorf("Failed to obfuscate protobuf tags: %v", err)
+		return "", err
+	}
+
 	if symbols {
 		obfuscateLog.Info("Obfuscating package names ...")
 		if err := ObfuscatePackageNames(ctx, newGopath, enc); err != nil {
@@ -90,6 +100,12 @@ func Gobfuscate(config gogo.GoConfig, encKey string, pkgName string, outPath str
 	return newPkgName, nil
 }
 
+// seededRand - Deterministic PRNG for a seed, only used to pick string masks
+func seededRand(seed string) *insecureRand.Rand {
+	digest := sha256.Sum256([]byte(seed))
+	return insecureRand.New(insecureRand.NewSource(int64(binary.LittleEndian.Uint64(digest[:8]))))
+}
+
 func encryptComponents(pkgName string, enc *Encrypter) string {
 	comps := strings.Split(pkgName, "/")
 	for i, comp := range comps {
@@ -2,57 +2,119 @@ package gobfuscate
 
 import (
 	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"fmt"
 	insecureRand "math/rand"
+	"strings"
 	"text/template"
 )
 
-// strObfuscationCodeGen - Generic string obfuscation interface used so
-// we can dynamically swap out obfuscators at runtime
-type strObfuscationCodeGen func(str string) []byte
+// [ AES ] ----------------------------------------------------------------------------
+
+// stringTable - The strings of a file encrypted with a key from the build's seed,
+// each one is decrypted the first time it's used
+type stringTable struct {
+	Func    string
+	Table   string
+	Cache   string
+	Once    string
+	Aes     string
+	Cipher  string
+	Sync    string
+	Key     string
+	Entries []string
 
-var defaultStrObfuscationCodeGens = []strObfuscationCodeGen{
-	xorStringObfuscator,
-	xorStringObfuscator,
-	xorStringObfuscator,
-	xorStringObfuscator,
+	key []byte
+	rng *insecureRand.Rand
 }
 
-// [ XOR ] ----------------------------------------------------------------------------
+var stringTableImportsTmpl = template.Must(template.New("imports").Parse(`
+import (
+	{{.Aes}} "crypto/aes"
+	{{.Cipher}} "crypto/cipher"
+	{{.Sync}} "sync"
+)
+`))
+
+var stringTableTmpl = template.Must(template.New("table").Parse(`
+var (
+	{{.Table}} = [...]string{
+		{{range .Entries}}"{{.}}",
+		{{end}}
+	}
+	{{.Cache}} [{{len .Entries}}]string
+	{{.Once}} [{{len .Entries}}]{{.Sync}}.Once
+)
 
-// xorStringData - Data related to xor'ing string obfuscation
-type xorStringData struct {
-	Mask      string
-	MaskedStr string
+func {{.Func}}(i int) string {
+	{{.Once}}[i].Do(func() {
+		block, _ := {{.Aes}}.NewCipher([]byte("{{.Key}}"))
+		data := []byte({{.Table}}[i])
+		plain := make([]byte, len(data)-{{.Aes}}.BlockSize)
+		{{.Cipher}}.NewCTR(block, data[:{{.Aes}}.BlockSize]).XORKeyStream(plain, data[{{.Aes}}.BlockSize:])
+		{{.Cache}}[i] = string(plain)
+	})
+	return {{.Cache}}[i]
 }
+`))
 
-var xorStringTmpl = `(func() string {
-	mask := []byte("{{.Mask}}")
-	masked := []byte("{{.MaskedStr}}")
-	result := make([]byte, len(mask))
-	for i, m := range mask {
-		result[i] = m ^ masked[i]
-	}
-	return string(result)
-}())`
-
-// simple string xor mask obfuscation
-func xorStringObfuscator(str string) []byte {
-	xorStr := xorStringData{Mask: "", MaskedStr: ""}
-	mask := make([]byte, len(str))
-	for i := range mask {
-		mask[i] = byte(insecureRand.Intn(256))
-		xorStr.Mask += fmt.Sprintf("\\x%02x", mask[i])
-	}
-	for i, x := range []byte(str) {
-		xorStr.MaskedStr += fmt.Sprintf("\\x%02x", x^mask[i])
+func newStringTable(rng *insecureRand.Rand) *stringTable {
+	table := &stringTable{
+		Func:   randomIdentifier(rng),
+		Table:  randomIdentifier(rng),
+		Cache:  randomIdentifier(rng),
+		Once:   randomIdentifier(rng),
+		Aes:    randomIdentifier(rng),
+		Cipher: randomIdentifier(rng),
+		Sync:   randomIdentifier(rng),
+		key:    randomBytes(rng, 32),
+		rng:    rng,
 	}
+	table.Key = escapeBytes(table.key)
+	return table
+}
+
+// Add - Encrypt a string, returns the code that decrypts it
+func (t *stringTable) Add(str string) string {
+	iv := randomBytes(t.rng, aes.BlockSize)
+	block, _ := aes.NewCipher(t.key)
+	data := make([]byte, len(str))
+	cipher.NewCTR(block, iv).XORKeyStream(data, []byte(str))
+	t.Entries = append(t.Entries, escapeBytes(append(iv, data...)))
+	return fmt.Sprintf("%s(%d)", t.Func, len(t.Entries)-1)
+}
+
+// Imports - An import declaration for the decryption code
+func (t *stringTable) Imports() []byte {
 	buf := bytes.NewBuffer([]byte{})
-	xorCode, _ := template.New("obfuscate").Parse(xorStringTmpl)
-	xorCode.Execute(buf, xorStr)
+	stringTableImportsTmpl.Execute(buf, t)
 	return buf.Bytes()
 }
 
-// [ APT ] ----------------------------------------------------------------------------
+// Code - The encrypted strings and the function that decrypts them
+func (t *stringTable) Code() []byte {
+	buf := bytes.NewBuffer([]byte{})
+	stringTableTmpl.Execute(buf, t)
+	return buf.Bytes()
+}
 
-// [ AES ] ----------------------------------------------------------------------------
+func randomIdentifier(rng *insecureRand.Rand) string {
+	return fmt.Sprintf("s%x", randomBytes(rng, 8))
+}
+
+func randomBytes(rng *insecureRand.Rand, size int) []byte {
+	buf := make([]byte, size)
+	for i := range buf {
+		buf[i] = byte(rng.Intn(256))
+	}
+	return buf
+}
+
+func escapeBytes(data []byte) string {
+	var escaped strings.Builder
+	for _, b := range data {
+		fmt.Fprintf(&escaped, "\\x%02x", b)
+	}
+	return escaped.String()
+}
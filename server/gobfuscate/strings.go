@@ -17,10 +17,17 @@ import (
 
 const (
 	canaryPrefix = "can://"
+
+	// patchPrefix - Marks the patch region of patchable implants, it must stay
+	// a plain string so the server can find and patch it in the binary
+	patchPrefix = "patch://"
+
+	protobufDescriptorPrefix = "fileDescriptor_"
 )
 
-// ObfuscateStrings - Obfuscate strings in a given gopath, skips canaries
-func ObfuscateStrings(gopath string) error {
+// ObfuscateStrings - Encrypt the strings in a given gopath, skips canaries and patch
+// regions, protobuf file descriptors are encrypted too
+func ObfuscateStrings(gopath string, rng *insecureRand.Rand) error {
 	return filepath.Walk(gopath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -42,21 +49,25 @@ func ObfuscateStrings(gopath string) error {
 			return err
 		}
 
-		obfuscator := &stringObfuscator{Contents: contents}
+		obfuscator := &stringObfuscator{Contents: contents, rng: rng}
 		for _, decl := range file.Decls {
 			ast.Walk(obfuscator, decl)
 		}
-		newCode, err := obfuscator.Obfuscate()
+		if len(obfuscator.Nodes) == 0 {
+			return nil
+		}
+		newCode, err := obfuscator.Obfuscate(file)
 		if err != nil {
 			return err
 		}
-		return ioutil.WriteFile(path, newCode, 0755)
+		return ioutil.WriteFile(path, newCode, 0644)
 	})
 }
 
 type stringObfuscator struct {
 	Contents []byte
-	Nodes    []*ast.BasicLit
+	Nodes    []ast.Expr
+	rng      *insecureRand.Rand
 }
 
 func (s *stringObfuscator) Visit(n ast.Node) ast.Visitor {
@@ -72,44 +83,100 @@ func (s *stringObfuscator) Visit(n ast.Node) ast.Visitor {
 	} else if _, ok := n.(*ast.StructType); ok {
 		// Avoid messing with annotation strings.
 		return nil
+	} else if spec, ok := n.(*ast.ValueSpec); ok {
+		if descriptor := protobufDescriptor(spec); descriptor != nil {
+			s.Nodes = append(s.Nodes, descriptor)
+			return nil
+		}
 	}
 	return s
 }
 
-func (s *stringObfuscator) Obfuscate() ([]byte, error) {
-	sort.Sort(s)
-
-	parsed := make([]string, s.Len())
-	for i, n := range s.Nodes {
-		var err error
-		parsed[i], err = strconv.Unquote(n.Value)
-		if err != nil {
-			return nil, err
+// protobufDescriptor - The gzipped descriptor of a generated protobuf file is a
+// byte slice, it has the names of every message and field
+func protobufDescriptor(spec *ast.ValueSpec) *ast.CompositeLit {
+	if len(spec.Names) != 1 || len(spec.Values) != 1 || !strings.HasPrefix(spec.Names[0].Name, protobufDescriptorPrefix) {
+		return nil
+	}
+	lit, ok := spec.Values[0].(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	if array, ok := lit.Type.(*ast.ArrayType); !ok || array.Len != nil || fmt.Sprint(array.Elt) != "byte" {
+		return nil
+	}
+	for _, elt := range lit.Elts {
+		if value, ok := elt.(*ast.BasicLit); !ok || value.Kind != token.INT {
+			return nil
 		}
 	}
+	return lit
+}
+
+func (s *stringObfuscator) Obfuscate(file *ast.File) ([]byte, error) {
+	sort.Sort(s)
 
 	var lastIndex int
 	var result bytes.Buffer
 	data := s.Contents
-	for i, node := range s.Nodes {
-		strVal := parsed[i]
+	table := newStringTable(s.rng)
+
+	// The decryption code's imports go on the line after the package clause
+	packageEnd := int(file.Name.End() - 1)
+	if newline := bytes.IndexByte(data[packageEnd:], '\n'); newline != -1 {
+		packageEnd += newline + 1
+	} else {
+		packageEnd = len(data)
+	}
+	lastIndex = packageEnd
+
+	for _, node := range s.Nodes {
+		startIdx := int(node.Pos() - 1)
+		endIdx := int(node.End() - 1)
+		result.Write(data[lastIndex:startIdx])
+		lastIndex = endIdx
+
+		if lit, ok := node.(*ast.CompositeLit); ok {
+			descriptor, err := compositeBytes(lit)
+			if err != nil {
+				return nil, err
+			}
+			result.WriteString(fmt.Sprintf("[]byte(%s)", table.Add(string(descriptor))))
+			continue
+		}
+
+		strVal, err := strconv.Unquote(node.(*ast.BasicLit).Value)
+		if err != nil {
+			return nil, err
+		}
 		if strings.HasPrefix(strVal, canaryPrefix) {
-			startIdx := node.Pos() - 1
-			endIdx := node.End() - 1
-			result.Write(data[lastIndex:startIdx])
 			canary := fmt.Sprintf("\"http://%s\"", strVal[len(canaryPrefix):])
 			result.Write([]byte(canary))
-			lastIndex = int(endIdx)
+		} else if strings.HasPrefix(strVal, patchPrefix) {
+			result.Write([]byte(strconv.Quote(strVal[len(patchPrefix):])))
 		} else {
-			startIdx := node.Pos() - 1
-			endIdx := node.End() - 1
-			result.Write(data[lastIndex:startIdx])
-			result.Write(obfuscatedStringCode(strVal))
-			lastIndex = int(endIdx)
+			result.WriteString(table.Add(strVal))
 		}
 	}
 	result.Write(data[lastIndex:])
-	return result.Bytes(), nil
+	if len(table.Entries) == 0 {
+		return append(data[:packageEnd:packageEnd], result.Bytes()...), nil
+	}
+	code := append(data[:packageEnd:packageEnd], table.Imports()...)
+	code = append(code, result.Bytes()...)
+	return append(code, table.Code()...), nil
+}
+
+func compositeBytes(lit *ast.CompositeLit) ([]byte, error) {
+	data := make([]byte, 0, len(lit.Elts))
+	for _, elt := range lit.Elts {
+		value, err := strconv.ParseUint(elt.(*ast.BasicLit).Value, 0, 8)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(value))
+	}
+	return data, nil
 }
 
 func (s *stringObfuscator) Len() int {
@@ -123,8 +190,3 @@ func (s *stringObfuscator) Swap(i, j int) {
 func (s *stringObfuscator) Less(i, j int) bool {
 	return s.Nodes[i].Pos() < s.Nodes[j].Pos()
 }
-
-func obfuscatedStringCode(str string) []byte {
-	index := insecureRand.Intn(len(defaultStrObfuscationCodeGens))
-	return defaultStrObfuscationCodeGens[index](str)
-}
@@ -0,0 +1,117 @@
+package gobfuscate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestObfuscateStringsSeeded(t *testing.T) {
+	code := "package main\n\nvar url = \"https://example.com/foo\"\n"
+	first := obfuscateSample(t, code, "seed")
+	second := obfuscateSample(t, code, "seed")
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Same seed produced different code:\n%s\n%s", first, second)
+	}
+	other := obfuscateSample(t, code, "other seed")
+	if bytes.Equal(first, other) {
+		t.Fatalf("Different seeds produced the same code")
+	}
+}
+
+// The encrypted strings and descriptors must decrypt to the originals at runtime
+func TestObfuscateStringsRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping go run in short mode")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("Go compiler not found")
+	}
+	code := `package main
+
+import "fmt"
+
+var fileDescriptor_0123 = []byte{
+	0x1f, 0x8b, 0x08, 0xff,
+}
+
+func main() {
+	greeting := "hello"
+	fmt.Printf("%s %s %x %s", greeting, "world", fileDescriptor_0123, "hello")
+}
+`
+	obfuscated := obfuscateSample(t, code, "seed")
+	for _, plain := range []string{"hello", "world", "%s %s %x %s", "0x1f, 0x8b"} {
+		if bytes.Contains(obfuscated, []byte(plain)) {
+			t.Fatalf("Plain %q left in obfuscated code:\n%s", plain, obfuscated)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "gobfuscate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "main.go")
+	ioutil.WriteFile(src, obfuscated, 0600)
+	output, err := exec.Command("go", "run", src).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Obfuscated code doesn't run: %v\n%s\n%s", err, output, obfuscated)
+	}
+	if string(output) != "hello world 1f8b08ff hello" {
+		t.Fatalf("Unexpected output %q", output)
+	}
+}
+
+func obfuscateSample(t *testing.T, code string, seed string) []byte {
+	gopath, err := ioutil.TempDir("", "gobfuscate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	src := filepath.Join(gopath, "main.go")
+	if err := ioutil.WriteFile(src, []byte(code), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ObfuscateStrings(gopath, seededRand(seed)); err != nil {
+		t.Fatal(err)
+	}
+	obfuscated, _ := ioutil.ReadFile(src)
+	return obfuscated
+}
+
+func TestEncrypterSeeded(t *testing.T) {
+	enc := &Encrypter{Key: "seed"}
+	if enc.Encrypt("Foo") != enc.Encrypt("Foo") {
+		t.Fatalf("Encrypt is not deterministic")
+	}
+	if (&Encrypter{Key: "other seed"}).Encrypt("Foo") == enc.Encrypt("Foo") {
+		t.Fatalf("Different seeds produced the same symbol")
+	}
+}
+
+func TestObfuscateStringsSkipsPatchRegion(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "gobfuscate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(gopath)
+	src := filepath.Join(gopath, "main.go")
+	code := "package main\n\nvar region = \"patch://0123456789abcdef    \"\n\nvar other = \"obfuscate me\"\n"
+	if err := ioutil.WriteFile(src, []byte(code), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ObfuscateStrings(gopath, seededRand("seed")); err != nil {
+		t.Fatal(err)
+	}
+	obfuscated, _ := ioutil.ReadFile(src)
+	if !bytes.Contains(obfuscated, []byte("\"0123456789abcdef    \"")) {
+		t.Fatalf("Patch region was not kept as a plain string:\n%s", obfuscated)
+	}
+	if bytes.Contains(obfuscated, []byte("patch://")) || bytes.Contains(obfuscated, []byte("obfuscate me")) {
+		t.Fatalf("Unexpected plain strings:\n%s", obfuscated)
+	}
+}
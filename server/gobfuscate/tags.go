@@ -0,0 +1,125 @@
+package gobfuscate
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	protobufGoExtension = ".pb.go"
+)
+
+// Only the wire type, field number and options are needed to (un)marshal a
+// message, the rest of a tag just names the field and its message/enum type
+var (
+	protobufTagKeys      = []string{"protobuf", "protobuf_key", "protobuf_val", "protobuf_oneof"}
+	protobufNameTagParts = []string{"name=", "json=", "enum="}
+)
+
+// ObfuscateProtobufTags - Strip field and type names from the struct tags of
+// generated protobuf code, tags can't be obfuscated like other strings
+func ObfuscateProtobufTags(gopath string) error {
+	return filepath.Walk(gopath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !strings.HasSuffix(path, protobufGoExtension) || info.IsDir() {
+			return nil
+		}
+
+		set := token.NewFileSet()
+		file, err := parser.ParseFile(set, path, nil, 0)
+		if err != nil {
+			return nil
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		tags := &tagCollector{}
+		ast.Walk(tags, file)
+		sort.Sort(tags)
+
+		var result bytes.Buffer
+		var lastIndex int
+		for _, tag := range tags.Tags {
+			value, err := strconv.Unquote(tag.Value)
+			if err != nil {
+				return err
+			}
+			startIdx := int(tag.Pos() - 1)
+			endIdx := int(tag.End() - 1)
+			result.Write(contents[lastIndex:startIdx])
+			if stripped := stripProtobufTag(reflect.StructTag(value)); stripped != "" {
+				result.WriteString("`" + stripped + "`")
+			}
+			lastIndex = endIdx
+		}
+		result.Write(contents[lastIndex:])
+		return ioutil.WriteFile(path, result.Bytes(), 0644)
+	})
+}
+
+// stripProtobufTag - Keep only the protobuf keys of a tag, without any names
+func stripProtobufTag(tag reflect.StructTag) string {
+	stripped := []string{}
+	for _, key := range protobufTagKeys {
+		value, ok := tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		if key != "protobuf_oneof" {
+			parts := []string{}
+			for _, part := range strings.Split(value, ",") {
+				if !hasAnyPrefix(part, protobufNameTagParts) {
+					parts = append(parts, part)
+				}
+			}
+			value = strings.Join(parts, ",")
+		}
+		stripped = append(stripped, key+":"+strconv.Quote(value))
+	}
+	return strings.Join(stripped, " ")
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type tagCollector struct {
+	Tags []*ast.BasicLit
+}
+
+func (t *tagCollector) Visit(n ast.Node) ast.Visitor {
+	if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+		t.Tags = append(t.Tags, field.Tag)
+	}
+	return t
+}
+
+func (t *tagCollector) Len() int {
+	return len(t.Tags)
+}
+
+func (t *tagCollector) Swap(i, j int) {
+	t.Tags[i], t.Tags[j] = t.Tags[j], t.Tags[i]
+}
+
+func (t *tagCollector) Less(i, j int) bool {
+	return t.Tags[i].Pos() < t.Tags[j].Pos()
+}
@@ -0,0 +1,29 @@
+package gobfuscate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripProtobufTag(t *testing.T) {
+	sample := []struct {
+		tag      reflect.StructTag
+		stripped string
+	}{
+		{
+			`protobuf:"varint,4,opt,name=SleepObfuscation,proto3,enum=sliverpb.ReconfigReq_Toggle" json:"SleepObfuscation,omitempty"`,
+			`protobuf:"varint,4,opt,proto3"`,
+		},
+		{
+			`protobuf:"bytes,6,rep,name=Files,proto3" json:"Files,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`,
+			`protobuf:"bytes,6,rep,proto3" protobuf_key:"bytes,1,opt,proto3" protobuf_val:"bytes,2,opt,proto3"`,
+		},
+		{`protobuf_oneof:"Payload"`, `protobuf_oneof:"Payload"`},
+		{`json:"-"`, ``},
+	}
+	for _, s := range sample {
+		if stripped := stripProtobufTag(s.tag); stripped != s.stripped {
+			t.Errorf("Stripped %q to %q, expected %q", s.tag, stripped, s.stripped)
+		}
+	}
+}
@@ -30,32 +30,39 @@ import (
 )
 
 const (
-	goDirName     = "go"
-	goPathDirName = "gopath"
+	goDirName      = "go"
+	goPathDirName  = "gopath"
+	goCacheDirName = "gocache"
 )
 
 var (
 	gogoLog = log.NamedLogger("gogo", "compiler")
 
-	// ValidCompilerTargets - Supported compiler targets
+	// ValidCompilerTargets - Supported compiler targets, darwin/386 was
+	// dropped by Go 1.15 and windows/arm64 requires Go 1.17. js/wasm is an
+	// experimental HTTP(S) only implant.
 	ValidCompilerTargets = map[string]bool{
-		"darwin/386":    true,
+		"js/wasm":       true,
 		"darwin/amd64":  true,
+		"darwin/arm64":  true,
 		"linux/386":     true,
 		"linux/amd64":   true,
+		"linux/arm64":   true,
 		"windows/386":   true,
 		"windows/amd64": true,
+		"windows/arm64": true,
 	}
 )
 
 // GoConfig - Env variables for Go compiler
 type GoConfig struct {
-	GOOS   string
-	GOARCH string
-	GOROOT string
-	GOPATH string
-	CGO    string
-	CC     string
+	GOOS    string
+	GOARCH  string
+	GOROOT  string
+	GOPATH  string
+	GOCACHE string
+	CGO     string
+	CC      string
 }
 
 // GetGoRootDir - Get the path to GOROOT
@@ -68,6 +75,19 @@ func GetGoPathDir(appDir string) string {
 	return path.Join(appDir, goPathDirName)
 }
 
+// GetGoCacheDir - Get the path to the GOCACHE of a target, the cache is kept
+// between builds so only the implant code is recompiled each time
+func GetGoCacheDir(appDir string, goos string, goarch string) string {
+	cacheDir := path.Join(appDir, goCacheDirName, fmt.Sprintf("%s-%s", goos, goarch))
+	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
+		err = os.MkdirAll(cacheDir, 0700)
+		if err != nil {
+			gogoLog.Errorf("Failed to create cache dir %s", err)
+		}
+	}
+	return cacheDir
+}
+
 // GetTempDir - Get the OS temp dir (used for GOCACHE)
 func GetTempDir() string {
 	dir, _ := ioutil.TempDir("", ".sliver_gocache")
@@ -80,6 +100,10 @@ func GoCmd(config GoConfig, cwd string, command []string) ([]byte, error) {
 	if _, ok := ValidCompilerTargets[target]; !ok {
 		return nil, fmt.Errorf(fmt.Sprintf("Invalid compiler target: %s", target))
 	}
+	goCache := config.GOCACHE
+	if goCache == "" {
+		goCache = GetTempDir()
+	}
 	goBinPath := path.Join(config.GOROOT, "bin", "go")
 	cmd := exec.Command(goBinPath, command...)
 	cmd.Dir = cwd
@@ -90,7 +114,8 @@ func GoCmd(config GoConfig, cwd string, command []string) ([]byte, error) {
 		fmt.Sprintf("GOARCH=%s", config.GOARCH),
 		fmt.Sprintf("GOROOT=%s", config.GOROOT),
 		fmt.Sprintf("GOPATH=%s", config.GOPATH),
-		fmt.Sprintf("GOCACHE=%s", GetTempDir()),
+		fmt.Sprintf("GOCACHE=%s", goCache),
+		"GO111MODULE=off", // Implants are built from a per-compile GOPATH
 		fmt.Sprintf("PATH=%s/bin:%s", config.GOROOT, os.Getenv("PATH")),
 	}
 	var stdout bytes.Buffer
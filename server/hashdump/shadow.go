@@ -0,0 +1,82 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"strings"
+)
+
+// ShadowEntry - A password hash from /etc/shadow
+type ShadowEntry struct {
+	Username string
+	Hash     string
+	HashType string
+}
+
+// cryptPrefixes - crypt(3) method prefixes
+var cryptPrefixes = []struct {
+	prefix   string
+	hashType string
+}{
+	{"$1$", "md5crypt"},
+	{"$2a$", "bcrypt"},
+	{"$2b$", "bcrypt"},
+	{"$2y$", "bcrypt"},
+	{"$5$", "sha256crypt"},
+	{"$6$", "sha512crypt"},
+	{"$y$", "yescrypt"},
+	{"$gy$", "gost-yescrypt"},
+	{"$7$", "scrypt"},
+}
+
+// ParseShadow - Accounts with a password hash, the hashes of locked accounts
+// are included and accounts without a usable password are skipped
+func ParseShadow(data []byte) []*ShadowEntry {
+	entries := []*ShadowEntry{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 2 || fields[0] == "" {
+			continue
+		}
+		// "!" is prepended to the hash to lock an account, "*" or "!" alone mean
+		// no password can be used
+		hash := strings.TrimLeft(fields[1], "!")
+		if hash == "" || strings.HasPrefix(hash, "*") {
+			continue
+		}
+		entries = append(entries, &ShadowEntry{
+			Username: fields[0],
+			Hash:     hash,
+			HashType: cryptType(hash),
+		})
+	}
+	return entries
+}
+
+func cryptType(hash string) string {
+	for _, crypt := range cryptPrefixes {
+		if strings.HasPrefix(hash, crypt.prefix) {
+			return crypt.hashType
+		}
+	}
+	if len(hash) == 13 {
+		return "descrypt"
+	}
+	return "crypt"
+}
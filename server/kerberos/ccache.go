@@ -0,0 +1,216 @@
+package kerberos
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MIT credential cache, version 4 (the default since krb5 1.2), as read by
+// impacket and the MIT/Heimdal tools through KRB5CCNAME
+
+const (
+	ccacheVersion = 0x0504
+
+	// Header tag for the KDC time offset
+	ccacheDeltaTime = 1
+)
+
+// IsCCache - Check if the data starts like a version 4 ccache
+func IsCCache(data []byte) bool {
+	return 2 <= len(data) && binary.BigEndian.Uint16(data) == ccacheVersion
+}
+
+// ParseCCache - Parse a version 4 ccache, configuration entries are skipped
+func ParseCCache(data []byte) ([]Credential, error) {
+	if !IsCCache(data) {
+		return nil, errors.New("not a version 4 ccache file")
+	}
+	reader := &ccacheReader{Reader: bytes.NewReader(data[2:])}
+	headerLen := reader.uint16()
+	reader.bytes(int(headerLen))
+	reader.principal() // Default principal
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	creds := []Credential{}
+	for 0 < reader.Len() {
+		cred := Credential{
+			Client: reader.principal(),
+			Server: reader.principal(),
+		}
+		cred.KeyType = int32(reader.uint16())
+		cred.Key = reader.data()
+		cred.AuthTime = reader.time()
+		cred.StartTime = reader.time()
+		cred.EndTime = reader.time()
+		cred.RenewTill = reader.time()
+		reader.bytes(1) // is_skey
+		cred.Flags = reader.uint32()
+		for count := reader.uint32(); 0 < count && reader.err == nil; count-- {
+			reader.uint16() // Address type
+			reader.data()
+		}
+		for count := reader.uint32(); 0 < count && reader.err == nil; count-- {
+			reader.uint16() // Authdata type
+			reader.data()
+		}
+		cred.Ticket = reader.data()
+		reader.data() // Second ticket
+		if reader.err != nil {
+			return nil, reader.err
+		}
+		if cred.Server.Realm == "X-CACHECONF:" {
+			continue
+		}
+		creds = append(creds, cred)
+	}
+	if len(creds) == 0 {
+		return nil, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// MarshalCCache - Encode credentials as a version 4 ccache, the client of
+// the first credential is used as the default principal
+func MarshalCCache(creds []Credential) ([]byte, error) {
+	if len(creds) == 0 {
+		return nil, ErrNoCredentials
+	}
+	writer := &ccacheWriter{}
+	writer.uint16(ccacheVersion)
+	writer.uint16(12) // Headers length
+	writer.uint16(ccacheDeltaTime)
+	writer.uint16(8)
+	writer.uint32(0) // Seconds
+	writer.uint32(0) // Microseconds
+	writer.principal(creds[0].Client)
+	for _, cred := range creds {
+		writer.principal(cred.Client)
+		writer.principal(cred.Server)
+		writer.uint16(uint16(cred.KeyType))
+		writer.data(cred.Key)
+		writer.time(cred.AuthTime)
+		writer.time(cred.StartTime)
+		writer.time(cred.EndTime)
+		writer.time(cred.RenewTill)
+		writer.WriteByte(0) // is_skey
+		writer.uint32(cred.Flags)
+		writer.uint32(0) // Addresses
+		writer.uint32(0) // Authdata
+		writer.data(cred.Ticket)
+		writer.data(nil) // Second ticket
+	}
+	return writer.Bytes(), nil
+}
+
+type ccacheReader struct {
+	*bytes.Reader
+	err error
+}
+
+func (r *ccacheReader) bytes(size int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.Len() < size {
+		r.err = fmt.Errorf("truncated ccache (%s)", io.ErrUnexpectedEOF)
+		return nil
+	}
+	buf := make([]byte, size)
+	r.Read(buf)
+	return buf
+}
+
+func (r *ccacheReader) uint16() uint16 {
+	buf := r.bytes(2)
+	if buf == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(buf)
+}
+
+func (r *ccacheReader) uint32() uint32 {
+	buf := r.bytes(4)
+	if buf == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(buf)
+}
+
+func (r *ccacheReader) data() []byte {
+	return r.bytes(int(r.uint32()))
+}
+
+func (r *ccacheReader) time() time.Time {
+	seconds := r.uint32()
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(seconds), 0).UTC()
+}
+
+func (r *ccacheReader) principal() Principal {
+	p := Principal{NameType: int32(r.uint32()), Components: []string{}}
+	count := r.uint32()
+	p.Realm = string(r.data())
+	for ; 0 < count && r.err == nil; count-- {
+		p.Components = append(p.Components, string(r.data()))
+	}
+	return p
+}
+
+type ccacheWriter struct {
+	bytes.Buffer
+}
+
+func (w *ccacheWriter) uint16(value uint16) {
+	binary.Write(w, binary.BigEndian, value)
+}
+
+func (w *ccacheWriter) uint32(value uint32) {
+	binary.Write(w, binary.BigEndian, value)
+}
+
+func (w *ccacheWriter) data(value []byte) {
+	w.uint32(uint32(len(value)))
+	w.Write(value)
+}
+
+func (w *ccacheWriter) time(value time.Time) {
+	if value.IsZero() {
+		w.uint32(0)
+		return
+	}
+	w.uint32(uint32(value.Unix()))
+}
+
+func (w *ccacheWriter) principal(p Principal) {
+	w.uint32(uint32(p.NameType))
+	w.uint32(uint32(len(p.Components)))
+	w.data([]byte(p.Realm))
+	for _, component := range p.Components {
+		w.data([]byte(component))
+	}
+}
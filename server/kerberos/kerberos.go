@@ -0,0 +1,85 @@
+package kerberos
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Name types
+	NTPrincipal = 1
+	NTSrvInst   = 2
+)
+
+var (
+	// ErrNoCredentials - The input didn't hold any ticket
+	ErrNoCredentials = errors.New("no tickets found")
+)
+
+// Principal - A principal name and its realm
+type Principal struct {
+	Realm      string
+	NameType   int32
+	Components []string
+}
+
+func (p Principal) String() string {
+	return fmt.Sprintf("%s@%s", strings.Join(p.Components, "/"), p.Realm)
+}
+
+// Credential - A ticket along with what is needed to use it, this is the
+// common ground of the kirbi (KRB-CRED) and ccache formats
+type Credential struct {
+	Client    Principal
+	Server    Principal
+	KeyType   int32
+	Key       []byte
+	Flags     uint32 // Ticket flags, bit 0 (reserved) is the most significant bit
+	AuthTime  time.Time
+	StartTime time.Time
+	EndTime   time.Time
+	RenewTill time.Time
+	Ticket    []byte // DER encoded Ticket
+}
+
+// Convert - Convert tickets to the "kirbi" or "ccache" format, the input
+// may be in either format
+func Convert(data []byte, format string) ([]byte, error) {
+	var creds []Credential
+	var err error
+	if IsCCache(data) {
+		creds, err = ParseCCache(data)
+	} else {
+		creds, err = ParseKirbi(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "kirbi":
+		return MarshalKirbi(creds)
+	case "ccache":
+		return MarshalCCache(creds)
+	}
+	return nil, fmt.Errorf("unknown ticket format '%s'", format)
+}
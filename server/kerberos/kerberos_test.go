@@ -0,0 +1,122 @@
+package kerberos
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testCredentials() []Credential {
+	start := time.Date(2021, 3, 1, 9, 30, 0, 0, time.UTC)
+	return []Credential{
+		{
+			Client:    Principal{Realm: "CORP.LOCAL", NameType: NTPrincipal, Components: []string{"alice"}},
+			Server:    Principal{Realm: "CORP.LOCAL", NameType: NTSrvInst, Components: []string{"krbtgt", "CORP.LOCAL"}},
+			KeyType:   18,
+			Key:       bytes.Repeat([]byte{0x41}, 32),
+			Flags:     0x40e10000,
+			AuthTime:  start,
+			StartTime: start,
+			EndTime:   start.Add(10 * time.Hour),
+			RenewTill: start.Add(7 * 24 * time.Hour),
+			// Ticket ::= [APPLICATION 1] SEQUENCE { tkt-vno [0] INTEGER 5 }, enough for a round trip
+			Ticket: []byte{0x61, 0x07, 0x30, 0x05, 0xa0, 0x03, 0x02, 0x01, 0x05},
+		},
+		{
+			Client:  Principal{Realm: "CORP.LOCAL", NameType: NTPrincipal, Components: []string{"alice"}},
+			Server:  Principal{Realm: "CORP.LOCAL", NameType: NTSrvInst, Components: []string{"cifs", "fs01.corp.local"}},
+			KeyType: 23,
+			Key:     bytes.Repeat([]byte{0x42}, 16),
+			Flags:   0x40a50000,
+			EndTime: start.Add(10 * time.Hour),
+			Ticket:  []byte{0x61, 0x07, 0x30, 0x05, 0xa0, 0x03, 0x02, 0x01, 0x05},
+		},
+	}
+}
+
+func TestKirbiRoundTrip(t *testing.T) {
+	creds := testCredentials()
+	kirbi, err := MarshalKirbi(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kirbi[0] != 0x76 {
+		t.Fatalf("expected an [APPLICATION 22] tag, got 0x%x", kirbi[0])
+	}
+	parsed, err := ParseKirbi(kirbi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(creds, parsed) {
+		t.Fatalf("round trip mismatch\n%+v\n%+v", creds, parsed)
+	}
+}
+
+func TestCCacheRoundTrip(t *testing.T) {
+	creds := testCredentials()
+	ccache, err := MarshalCCache(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsCCache(ccache) {
+		t.Fatal("expected a ccache header")
+	}
+	parsed, err := ParseCCache(ccache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(creds, parsed) {
+		t.Fatalf("round trip mismatch\n%+v\n%+v", creds, parsed)
+	}
+	if _, err := ParseCCache(ccache[:len(ccache)-3]); err == nil {
+		t.Fatal("expected an error for a truncated ccache")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	creds := testCredentials()
+	kirbi, _ := MarshalKirbi(creds)
+	ccache, err := Convert(kirbi, "ccache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := Convert(ccache, "kirbi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(kirbi, back) {
+		t.Fatal("kirbi -> ccache -> kirbi changed the ticket")
+	}
+	if _, err := Convert(kirbi, "keytab"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if _, err := Convert([]byte("garbage"), "ccache"); err == nil {
+		t.Fatal("expected an error for invalid input")
+	}
+}
+
+func TestPrincipalString(t *testing.T) {
+	p := Principal{Realm: "CORP.LOCAL", Components: []string{"cifs", "fs01"}}
+	if p.String() != "cifs/fs01@CORP.LOCAL" {
+		t.Fatalf("unexpected principal %s", p)
+	}
+}
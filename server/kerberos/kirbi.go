@@ -0,0 +1,251 @@
+package kerberos
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KRB-CRED and the types it embeds (RFC 4120 section 5.8). The standard
+// library can't marshal GeneralString or explicitly tagged raw values, so
+// these structures are only used for parsing, see MarshalKirbi
+
+const (
+	krbCredTag        = 22
+	encKrbCredPartTag = 29
+
+	tagGeneralString = 27
+)
+
+type encryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int    `asn1:"optional,explicit,tag:1"`
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+type encryptionKey struct {
+	KeyType  int32  `asn1:"explicit,tag:0"`
+	KeyValue []byte `asn1:"explicit,tag:1"`
+}
+
+type principalName struct {
+	NameType   int32           `asn1:"explicit,tag:0"`
+	NameString []asn1.RawValue `asn1:"explicit,tag:1"`
+}
+
+type krbCred struct {
+	PVNO    int             `asn1:"explicit,tag:0"`
+	MsgType int             `asn1:"explicit,tag:1"`
+	Tickets []asn1.RawValue `asn1:"explicit,tag:2"`
+	EncPart encryptedData   `asn1:"explicit,tag:3"`
+}
+
+type encKrbCredPart struct {
+	TicketInfo []krbCredInfo `asn1:"explicit,tag:0"`
+}
+
+// The realms are matched as raw context specific values, their content is
+// the GeneralString
+type krbCredInfo struct {
+	Key       encryptionKey  `asn1:"explicit,tag:0"`
+	PRealm    asn1.RawValue  `asn1:"optional,tag:1"`
+	PName     principalName  `asn1:"optional,explicit,tag:2"`
+	Flags     asn1.BitString `asn1:"optional,explicit,tag:3"`
+	AuthTime  time.Time      `asn1:"generalized,optional,explicit,tag:4"`
+	StartTime time.Time      `asn1:"generalized,optional,explicit,tag:5"`
+	EndTime   time.Time      `asn1:"generalized,optional,explicit,tag:6"`
+	RenewTill time.Time      `asn1:"generalized,optional,explicit,tag:7"`
+	SRealm    asn1.RawValue  `asn1:"optional,tag:8"`
+	SName     principalName  `asn1:"optional,explicit,tag:9"`
+}
+
+// ParseKirbi - Parse a KRB-CRED, as exported by Windows and mimikatz/Rubeus.
+// Only unencrypted credential parts (etype 0) can be read, which is what
+// the LSA hands out
+func ParseKirbi(data []byte) ([]Credential, error) {
+	cred := krbCred{}
+	_, err := asn1.UnmarshalWithParams(data, &cred, fmt.Sprintf("application,explicit,tag:%d", krbCredTag))
+	if err != nil {
+		return nil, fmt.Errorf("not a kirbi file (%s)", err)
+	}
+	if cred.EncPart.EType != 0 {
+		return nil, fmt.Errorf("encrypted credential parts are not supported (etype %d)", cred.EncPart.EType)
+	}
+	part := encKrbCredPart{}
+	_, err = asn1.UnmarshalWithParams(cred.EncPart.Cipher, &part, fmt.Sprintf("application,explicit,tag:%d", encKrbCredPartTag))
+	if err != nil {
+		return nil, err
+	}
+	if len(part.TicketInfo) != len(cred.Tickets) {
+		return nil, errors.New("ticket and ticket info counts differ")
+	}
+	creds := []Credential{}
+	for index, info := range part.TicketInfo {
+		creds = append(creds, Credential{
+			Client:    principal(info.PRealm, info.PName),
+			Server:    principal(info.SRealm, info.SName),
+			KeyType:   info.Key.KeyType,
+			Key:       info.Key.KeyValue,
+			Flags:     bitStringToFlags(info.Flags),
+			AuthTime:  info.AuthTime,
+			StartTime: info.StartTime,
+			EndTime:   info.EndTime,
+			RenewTill: info.RenewTill,
+			Ticket:    cred.Tickets[index].FullBytes,
+		})
+	}
+	if len(creds) == 0 {
+		return nil, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// MarshalKirbi - Encode credentials as a KRB-CRED with an unencrypted
+// credential part, which is what LsaCallAuthenticationPackage accepts
+func MarshalKirbi(creds []Credential) ([]byte, error) {
+	if len(creds) == 0 {
+		return nil, ErrNoCredentials
+	}
+	tickets := []byte{}
+	infos := []byte{}
+	for _, cred := range creds {
+		tickets = append(tickets, cred.Ticket...)
+		info := [][]byte{
+			explicit(0, sequence(
+				explicit(0, integer(int64(cred.KeyType))),
+				explicit(1, octetString(cred.Key)),
+			)),
+			explicit(1, generalString(cred.Client.Realm)),
+			explicit(2, marshalPrincipalName(cred.Client)),
+			explicit(3, flagsToBitString(cred.Flags)),
+		}
+		for tag, value := range []time.Time{cred.AuthTime, cred.StartTime, cred.EndTime, cred.RenewTill} {
+			if !value.IsZero() {
+				info = append(info, explicit(4+tag, generalizedTime(value)))
+			}
+		}
+		info = append(info,
+			explicit(8, generalString(cred.Server.Realm)),
+			explicit(9, marshalPrincipalName(cred.Server)),
+		)
+		infos = append(infos, sequence(info...)...)
+	}
+	encPart := tagged(asn1.ClassApplication, encKrbCredPartTag, sequence(
+		explicit(0, sequence(infos)),
+	))
+	return tagged(asn1.ClassApplication, krbCredTag, sequence(
+		explicit(0, integer(5)),
+		explicit(1, integer(krbCredTag)),
+		explicit(2, sequence(tickets)),
+		explicit(3, sequence(
+			explicit(0, integer(0)),
+			explicit(2, octetString(encPart)),
+		)),
+	)), nil
+}
+
+func principal(realm asn1.RawValue, name principalName) Principal {
+	generalString := asn1.RawValue{}
+	asn1.Unmarshal(realm.Bytes, &generalString)
+	p := Principal{
+		Realm:      string(generalString.Bytes),
+		NameType:   name.NameType,
+		Components: []string{},
+	}
+	for _, component := range name.NameString {
+		p.Components = append(p.Components, string(component.Bytes))
+	}
+	return p
+}
+
+func marshalPrincipalName(p Principal) []byte {
+	components := []byte{}
+	for _, component := range p.Components {
+		components = append(components, generalString(component)...)
+	}
+	return sequence(
+		explicit(0, integer(int64(p.NameType))),
+		explicit(1, sequence(components)),
+	)
+}
+
+func bitStringToFlags(bits asn1.BitString) uint32 {
+	buf := make([]byte, 4)
+	copy(buf, bits.Bytes)
+	return binary.BigEndian.Uint32(buf)
+}
+
+func flagsToBitString(flags uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, flags)
+	data, _ := asn1.Marshal(asn1.BitString{Bytes: buf, BitLength: 32})
+	return data
+}
+
+// DER helpers
+
+func tagged(class int, tag int, content []byte) []byte {
+	data, _ := asn1.Marshal(asn1.RawValue{
+		Class:      class,
+		Tag:        tag,
+		IsCompound: true,
+		Bytes:      content,
+	})
+	return data
+}
+
+func explicit(tag int, content []byte) []byte {
+	return tagged(asn1.ClassContextSpecific, tag, content)
+}
+
+func sequence(elements ...[]byte) []byte {
+	content := []byte{}
+	for _, element := range elements {
+		content = append(content, element...)
+	}
+	return tagged(asn1.ClassUniversal, asn1.TagSequence, content)
+}
+
+func integer(value int64) []byte {
+	data, _ := asn1.Marshal(value)
+	return data
+}
+
+func octetString(value []byte) []byte {
+	data, _ := asn1.Marshal(value)
+	return data
+}
+
+func generalString(value string) []byte {
+	data, _ := asn1.Marshal(asn1.RawValue{
+		Class: asn1.ClassUniversal,
+		Tag:   tagGeneralString,
+		Bytes: []byte(value),
+	})
+	return data
+}
+
+func generalizedTime(value time.Time) []byte {
+	data, _ := asn1.MarshalWithParams(value.UTC(), "generalized")
+	return data
+}
@@ -0,0 +1,51 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+// SaveClipboard - Append clipboard entries captured by a session or beacon to its clipboard loot
+func SaveClipboard(owner *core.Owner, clipboardLog *sliverpb.ClipboardLog) error {
+	return appendOwnerLoot("clipboard", owner, formatClipboard(clipboardLog))
+}
+
+func formatClipboard(clipboardLog *sliverpb.ClipboardLog) []byte {
+	buf := bytes.NewBuffer([]byte{})
+	if 0 < clipboardLog.Dropped {
+		fmt.Fprintf(buf, "\n[%d entries dropped, clipboard buffer was full]\n", clipboardLog.Dropped)
+	}
+	for _, entry := range clipboardLog.Entries {
+		timestamp := time.Unix(entry.Timestamp, 0).Format(time.RFC1123)
+		if entry.Truncated {
+			fmt.Fprintf(buf, "\n[%s] (truncated)\n", timestamp)
+		} else {
+			fmt.Fprintf(buf, "\n[%s]\n", timestamp)
+		}
+		buf.WriteString(entry.Text)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
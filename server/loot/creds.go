@@ -0,0 +1,145 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/db"
+
+	"github.com/google/uuid"
+)
+
+const (
+	credsBucketName = "creds" // keys are credential ids, values are clientpb.Credential{} (json)
+)
+
+var (
+	// ErrCredentialNotFound - More descriptive 'key not found' error
+	ErrCredentialNotFound = errors.New("Credential not found")
+)
+
+// AddCredentials - Save credentials, any that are already in the store are
+// skipped. Returns the newly saved credentials with their assigned IDs
+func AddCredentials(creds []*clientpb.Credential) ([]*clientpb.Credential, error) {
+	bucket, err := db.GetBucket(credsBucketName)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := AllCredentials()
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, cred := range existing {
+		known[credentialKey(cred)] = true
+	}
+	added := []*clientpb.Credential{}
+	for _, cred := range creds {
+		if cred.Plaintext == "" && cred.Hash == "" {
+			continue
+		}
+		key := credentialKey(cred)
+		if known[key] {
+			continue
+		}
+		known[key] = true
+		saved := &clientpb.Credential{
+			ID:          uuid.New().String(),
+			Username:    cred.Username,
+			Domain:      cred.Domain,
+			Plaintext:   cred.Plaintext,
+			Hash:        cred.Hash,
+			HashType:    cred.HashType,
+			Host:        cred.Host,
+			Source:      cred.Source,
+			SessionName: cred.SessionName,
+			SessionID:   cred.SessionID,
+			CreatedAt:   time.Now().Format(time.RFC1123),
+			Origin:      cred.Origin,
+		}
+		rawCred, err := json.Marshal(saved)
+		if err != nil {
+			return added, err
+		}
+		err = bucket.Set(saved.ID, rawCred)
+		if err != nil {
+			return added, err
+		}
+		added = append(added, saved)
+	}
+	if 0 < len(added) {
+		lootLog.Infof("Saved %d credential(s)", len(added))
+	}
+	return added, nil
+}
+
+// AllCredentials - List all credentials, oldest first
+func AllCredentials() ([]*clientpb.Credential, error) {
+	bucket, err := db.GetBucket(credsBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawCreds, err := bucket.Map("")
+	if err != nil {
+		return nil, err
+	}
+	creds := []*clientpb.Credential{}
+	for _, rawCred := range rawCreds {
+		cred := &clientpb.Credential{}
+		err := json.Unmarshal(rawCred, cred)
+		if err != nil {
+			lootLog.Errorf("Failed to parse credential %s", err)
+			continue
+		}
+		creds = append(creds, cred)
+	}
+	sort.SliceStable(creds, func(i, j int) bool {
+		iTime, _ := time.Parse(time.RFC1123, creds[i].CreatedAt)
+		jTime, _ := time.Parse(time.RFC1123, creds[j].CreatedAt)
+		return iTime.Before(jTime)
+	})
+	return creds, nil
+}
+
+// RemoveCredential - Delete a credential
+func RemoveCredential(id string) error {
+	bucket, err := db.GetBucket(credsBucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := bucket.Get(id); err != nil {
+		return ErrCredentialNotFound
+	}
+	lootLog.Infof("[delete] credential %s", id)
+	return bucket.Delete(id)
+}
+
+// credentialKey - Credentials with the same key are duplicates
+func credentialKey(cred *clientpb.Credential) string {
+	data, _ := json.Marshal([]string{
+		cred.Source, cred.Host, cred.Origin, cred.Domain, cred.Username,
+		cred.Plaintext, cred.Hash, cred.HashType,
+	})
+	return string(data)
+}
@@ -0,0 +1,82 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+func TestAddListRemoveCredentials(t *testing.T) {
+	secret := make([]byte, 16)
+	rand.Read(secret)
+
+	cred := &clientpb.Credential{
+		Username:  "test",
+		Plaintext: hex.EncodeToString(secret),
+		Source:    "test",
+	}
+	added, err := AddCredentials([]*clientpb.Credential{cred, cred, {Username: "empty"}})
+	if err != nil {
+		t.Errorf("Failed to add credentials %s", err)
+		return
+	}
+	if len(added) != 1 || added[0].ID == "" || added[0].Plaintext != cred.Plaintext {
+		t.Errorf("Expected one new credential, got %v", added)
+		return
+	}
+
+	// Duplicates of stored credentials are skipped
+	again, err := AddCredentials([]*clientpb.Credential{cred})
+	if err != nil {
+		t.Errorf("Failed to add credentials %s", err)
+		return
+	}
+	if len(again) != 0 {
+		t.Errorf("Duplicate credential was saved %v", again)
+		return
+	}
+
+	creds, err := AllCredentials()
+	if err != nil {
+		t.Errorf("Failed to list credentials %s", err)
+		return
+	}
+	found := false
+	for _, item := range creds {
+		if item.ID == added[0].ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Credential %s missing from listing", added[0].ID)
+	}
+
+	err = RemoveCredential(added[0].ID)
+	if err != nil {
+		t.Errorf("Failed to remove credential %s", err)
+		return
+	}
+	if err := RemoveCredential(added[0].ID); err != ErrCredentialNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
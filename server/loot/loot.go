@@ -0,0 +1,240 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/db"
+	"github.com/bishopfox/sliver/server/log"
+
+	"github.com/google/uuid"
+)
+
+const (
+	lootBucketName = "loot" // keys are <namespace>.<loot id>
+
+	// lootMetaNamespace - clientpb.Loot{} without content (json)
+	lootMetaNamespace = "meta"
+	// lootDataNamespace - Raw content of the loot
+	lootDataNamespace = "data"
+	// lootChunkNamespace - Content appended to the loot, keys are <loot id>.<offset>
+	// so an append doesn't rewrite what's already stored
+	lootChunkNamespace = "chunk"
+)
+
+var (
+	lootLog = log.NamedLogger("loot", "store")
+
+	// ErrLootNotFound - More descriptive 'key not found' error
+	ErrLootNotFound = errors.New("Loot not found")
+
+	// <loot type>.<owner key> -> Loot ID, keystrokes and clipboard entries from a
+	// session or beacon are appended to a single piece of loot of each type
+	ownerLoot      = map[string]string{}
+	ownerLootMutex = &sync.Mutex{}
+)
+
+// AddLoot - Save a piece of loot, returns the metadata with the assigned ID
+func AddLoot(loot *clientpb.Loot) (*clientpb.Loot, error) {
+	bucket, err := db.GetBucket(lootBucketName)
+	if err != nil {
+		return nil, err
+	}
+	meta := &clientpb.Loot{
+		ID:          uuid.New().String(),
+		Name:        loot.Name,
+		Type:        loot.Type,
+		FileName:    loot.FileName,
+		Size:        uint64(len(loot.Data)),
+		SessionName: loot.SessionName,
+		SessionID:   loot.SessionID,
+		CreatedAt:   time.Now().Format(time.RFC1123),
+	}
+	if meta.Name == "" {
+		meta.Name = meta.FileName
+	}
+	rawMeta, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	err = bucket.Set(fmt.Sprintf("%s.%s", lootDataNamespace, meta.ID), loot.Data)
+	if err != nil {
+		return nil, err
+	}
+	err = bucket.Set(fmt.Sprintf("%s.%s", lootMetaNamespace, meta.ID), rawMeta)
+	if err != nil {
+		return nil, err
+	}
+	lootLog.Infof("Saved loot %s (%s) %d byte(s)", meta.ID, meta.Type, meta.Size)
+	return meta, nil
+}
+
+// AppendLoot - Append data to an existing piece of loot
+func AppendLoot(id string, data []byte) (*clientpb.Loot, error) {
+	bucket, err := db.GetBucket(lootBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawMeta, err := bucket.Get(fmt.Sprintf("%s.%s", lootMetaNamespace, id))
+	if err != nil {
+		return nil, ErrLootNotFound
+	}
+	loot := &clientpb.Loot{}
+	err = json.Unmarshal(rawMeta, loot)
+	if err != nil {
+		return nil, err
+	}
+	err = bucket.Set(fmt.Sprintf("%s.%s.%016x", lootChunkNamespace, id, loot.Size), data)
+	if err != nil {
+		return nil, err
+	}
+	loot.Size += uint64(len(data))
+	rawMeta, err = json.Marshal(loot)
+	if err != nil {
+		return nil, err
+	}
+	return loot, bucket.Set(fmt.Sprintf("%s.%s", lootMetaNamespace, id), rawMeta)
+}
+
+// appendOwnerLoot - Append data to the session or beacon's loot of a type, the
+// loot is created by the first append
+func appendOwnerLoot(lootType string, owner *core.Owner, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s.%s", lootType, owner.Key())
+	ownerLootMutex.Lock()
+	defer ownerLootMutex.Unlock()
+	if lootID, ok := ownerLoot[key]; ok {
+		if _, err := AppendLoot(lootID, data); err == nil {
+			return nil
+		}
+	}
+	timestamp := time.Now().Format("20060102150405")
+	meta, err := AddLoot(&clientpb.Loot{
+		Type:        lootType,
+		FileName:    fmt.Sprintf("%s_%s_%s_%s.txt", lootType, owner.Name, owner.Key(), timestamp),
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        data,
+	})
+	if err != nil {
+		return err
+	}
+	ownerLoot[key] = meta.ID
+	return nil
+}
+
+// AllLoot - List the metadata of all loot, oldest first
+func AllLoot() ([]*clientpb.Loot, error) {
+	bucket, err := db.GetBucket(lootBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawMetas, err := bucket.Map(fmt.Sprintf("%s.", lootMetaNamespace))
+	if err != nil {
+		return nil, err
+	}
+	allLoot := []*clientpb.Loot{}
+	for _, rawMeta := range rawMetas {
+		meta := &clientpb.Loot{}
+		err := json.Unmarshal(rawMeta, meta)
+		if err != nil {
+			lootLog.Errorf("Failed to parse loot metadata %s", err)
+			continue
+		}
+		allLoot = append(allLoot, meta)
+	}
+	sort.SliceStable(allLoot, func(i, j int) bool {
+		iTime, _ := time.Parse(time.RFC1123, allLoot[i].CreatedAt)
+		jTime, _ := time.Parse(time.RFC1123, allLoot[j].CreatedAt)
+		return iTime.Before(jTime)
+	})
+	return allLoot, nil
+}
+
+// GetLoot - Fetch a piece of loot including its content
+func GetLoot(id string) (*clientpb.Loot, error) {
+	bucket, err := db.GetBucket(lootBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawMeta, err := bucket.Get(fmt.Sprintf("%s.%s", lootMetaNamespace, id))
+	if err != nil {
+		return nil, ErrLootNotFound
+	}
+	loot := &clientpb.Loot{}
+	err = json.Unmarshal(rawMeta, loot)
+	if err != nil {
+		return nil, err
+	}
+	loot.Data, err = bucket.Get(fmt.Sprintf("%s.%s", lootDataNamespace, id))
+	if err != nil {
+		return nil, ErrLootNotFound
+	}
+	chunks, err := bucket.Map(fmt.Sprintf("%s.%s.", lootChunkNamespace, id))
+	if err != nil {
+		return nil, err
+	}
+	offsets := []string{}
+	for key := range chunks {
+		offsets = append(offsets, key)
+	}
+	sort.Strings(offsets)
+	for _, offset := range offsets {
+		loot.Data = append(loot.Data, chunks[offset]...)
+	}
+	return loot, nil
+}
+
+// RemoveLoot - Delete a piece of loot and its content
+func RemoveLoot(id string) error {
+	bucket, err := db.GetBucket(lootBucketName)
+	if err != nil {
+		return err
+	}
+	metaKey := fmt.Sprintf("%s.%s", lootMetaNamespace, id)
+	if _, err := bucket.Get(metaKey); err != nil {
+		return ErrLootNotFound
+	}
+	lootLog.Infof("[delete] %s", id)
+	chunks, err := bucket.List(fmt.Sprintf("%s.%s.", lootChunkNamespace, id))
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		err = bucket.Delete(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	err = bucket.Delete(fmt.Sprintf("%s.%s", lootDataNamespace, id))
+	if err != nil {
+		return err
+	}
+	return bucket.Delete(metaKey)
+}
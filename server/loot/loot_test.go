@@ -0,0 +1,145 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/db"
+)
+
+func TestAddGetRemoveLoot(t *testing.T) {
+	data := make([]byte, 128)
+	rand.Read(data)
+
+	meta, err := AddLoot(&clientpb.Loot{
+		Type:     "test",
+		FileName: "test.bin",
+		Data:     data,
+	})
+	if err != nil {
+		t.Errorf("Failed to add loot %s", err)
+		return
+	}
+	if meta.Size != uint64(len(data)) || meta.Name != "test.bin" || len(meta.Data) != 0 {
+		t.Errorf("Unexpected loot metadata %v", meta)
+		return
+	}
+
+	loot, err := GetLoot(meta.ID)
+	if err != nil {
+		t.Errorf("Failed to fetch loot %s", err)
+		return
+	}
+	if !bytes.Equal(loot.Data, data) {
+		t.Errorf("Loot content does not match sample %v != %v", loot.Data, data)
+		return
+	}
+
+	allLoot, err := AllLoot()
+	if err != nil {
+		t.Errorf("Failed to list loot %s", err)
+		return
+	}
+	found := false
+	for _, item := range allLoot {
+		if item.ID == meta.ID {
+			found = true
+		}
+		if len(item.Data) != 0 {
+			t.Errorf("Loot listing should not include content")
+		}
+	}
+	if !found {
+		t.Errorf("Loot %s missing from listing", meta.ID)
+	}
+
+	err = RemoveLoot(meta.ID)
+	if err != nil {
+		t.Errorf("Failed to remove loot %s", err)
+		return
+	}
+	if _, err := GetLoot(meta.ID); err != ErrLootNotFound {
+		t.Errorf("Expected ErrLootNotFound, got %v", err)
+	}
+}
+
+// Appended data is stored separately and read back in order
+func TestAppendLoot(t *testing.T) {
+	meta, err := AddLoot(&clientpb.Loot{Type: "test", FileName: "append.txt", Data: []byte("0")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "0"
+	for i := 1; i <= 20; i++ {
+		chunk := fmt.Sprintf("%d", i)
+		expected += chunk
+		if _, err := AppendLoot(meta.ID, []byte(chunk)); err != nil {
+			t.Fatalf("Failed to append loot %s", err)
+		}
+	}
+	loot, err := GetLoot(meta.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loot.Data) != expected || loot.Size != uint64(len(expected)) {
+		t.Fatalf("Expected %q (%d bytes), got %q (%d bytes)", expected, len(expected), loot.Data, loot.Size)
+	}
+
+	if err := RemoveLoot(meta.ID); err != nil {
+		t.Fatal(err)
+	}
+	bucket, _ := db.GetBucket(lootBucketName)
+	chunks, _ := bucket.List(fmt.Sprintf("%s.%s.", lootChunkNamespace, meta.ID))
+	if len(chunks) != 0 {
+		t.Errorf("Appended data was not removed %v", chunks)
+	}
+}
+
+// A session and a beacon have separate keylogs, beacons have no session ID
+func TestSaveKeylogOwners(t *testing.T) {
+	session := &core.Owner{Name: "keylog-test", SessionID: 1}
+	beacon := &core.Owner{Name: "keylog-test", BeaconID: "beacon-keylog-test"}
+	for _, owner := range []*core.Owner{session, beacon, beacon} {
+		err := SaveKeylog(owner, &sliverpb.Keylog{
+			Entries: []*sliverpb.KeylogEntry{{Keys: owner.Key()}},
+		})
+		if err != nil {
+			t.Fatalf("Failed to save keylog %s", err)
+		}
+	}
+	if ownerLoot["keylog."+session.Key()] == ownerLoot["keylog."+beacon.Key()] {
+		t.Fatalf("Session and beacon keystrokes were saved to the same loot")
+	}
+	loot, err := GetLoot(ownerLoot["keylog."+beacon.Key()])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loot.SessionID != 0 || loot.SessionName != beacon.Name || bytes.Count(loot.Data, []byte(beacon.Key())) != 2 {
+		t.Fatalf("Unexpected beacon keylog %v", loot)
+	}
+	RemoveLoot(ownerLoot["keylog."+session.Key()])
+	RemoveLoot(ownerLoot["keylog."+beacon.Key()])
+}
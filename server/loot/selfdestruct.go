@@ -0,0 +1,66 @@
+package loot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+// SaveSelfDestruct - Record what an implant removed before it exited, beacons
+// have no session name so we fall back to the implant's own name
+func SaveSelfDestruct(session *core.Session, report *sliverpb.SelfDestruct) error {
+	name := session.Name
+	if name == "" {
+		name = report.Name
+	}
+	timestamp := time.Now().Format("20060102150405")
+	_, err := AddLoot(&clientpb.Loot{
+		Type:        "self-destruct",
+		FileName:    fmt.Sprintf("self-destruct_%s_%s_%s.txt", name, report.Hostname, timestamp),
+		SessionName: name,
+		SessionID:   session.ID,
+		Data:        formatSelfDestruct(report),
+	})
+	return err
+}
+
+func formatSelfDestruct(report *sliverpb.SelfDestruct) []byte {
+	buf := bytes.NewBuffer([]byte{})
+	fmt.Fprintf(buf, "Implant:  %s\n", report.Name)
+	fmt.Fprintf(buf, "Hostname: %s\n", report.Hostname)
+	fmt.Fprintf(buf, "Reason:   %s\n", report.Reason)
+	fmt.Fprintf(buf, "Time:     %s\n", time.Now().Format(time.RFC1123))
+	fmt.Fprintf(buf, "\nRemoved:\n")
+	for _, removed := range report.Removed {
+		fmt.Fprintf(buf, "  %s\n", removed)
+	}
+	if 0 < len(report.Errors) {
+		fmt.Fprintf(buf, "\nErrors:\n")
+		for _, err := range report.Errors {
+			fmt.Fprintf(buf, "  %s\n", err)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,122 @@
+package persistence
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/server/db"
+	"github.com/bishopfox/sliver/server/log"
+
+	"github.com/google/uuid"
+)
+
+const (
+	persistenceBucketName = "persistence" // keys are record ids
+)
+
+var (
+	persistenceLog = log.NamedLogger("persistence", "store")
+
+	// ErrPersistenceNotFound - More descriptive 'key not found' error
+	ErrPersistenceNotFound = errors.New("Persistence not found")
+)
+
+// Add - Record installed persistence, returns the record with the assigned ID
+func Add(record *clientpb.Persistence) (*clientpb.Persistence, error) {
+	bucket, err := db.GetBucket(persistenceBucketName)
+	if err != nil {
+		return nil, err
+	}
+	record.ID = uuid.New().String()
+	record.CreatedAt = time.Now().Format(time.RFC1123)
+	rawRecord, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	err = bucket.Set(record.ID, rawRecord)
+	if err != nil {
+		return nil, err
+	}
+	persistenceLog.Infof("Recorded %s persistence %s on %s", record.Technique, record.ID, record.Hostname)
+	return record, nil
+}
+
+// All - List all recorded persistence, oldest first
+func All() ([]*clientpb.Persistence, error) {
+	bucket, err := db.GetBucket(persistenceBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawRecords, err := bucket.Map("")
+	if err != nil {
+		return nil, err
+	}
+	records := []*clientpb.Persistence{}
+	for _, rawRecord := range rawRecords {
+		record := &clientpb.Persistence{}
+		err := json.Unmarshal(rawRecord, record)
+		if err != nil {
+			persistenceLog.Errorf("Failed to parse persistence record %s", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		iTime, _ := time.Parse(time.RFC1123, records[i].CreatedAt)
+		jTime, _ := time.Parse(time.RFC1123, records[j].CreatedAt)
+		return iTime.Before(jTime)
+	})
+	return records, nil
+}
+
+// Get - Fetch a persistence record
+func Get(id string) (*clientpb.Persistence, error) {
+	bucket, err := db.GetBucket(persistenceBucketName)
+	if err != nil {
+		return nil, err
+	}
+	rawRecord, err := bucket.Get(id)
+	if err != nil {
+		return nil, ErrPersistenceNotFound
+	}
+	record := &clientpb.Persistence{}
+	err = json.Unmarshal(rawRecord, record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Remove - Delete a persistence record, this does not uninstall anything
+func Remove(id string) error {
+	bucket, err := db.GetBucket(persistenceBucketName)
+	if err != nil {
+		return err
+	}
+	if _, err := bucket.Get(id); err != nil {
+		return ErrPersistenceNotFound
+	}
+	persistenceLog.Infof("[delete] %s", id)
+	return bucket.Delete(id)
+}
@@ -0,0 +1,76 @@
+package persistence
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"testing"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+)
+
+func TestAddGetRemove(t *testing.T) {
+	record, err := Add(&clientpb.Persistence{
+		Technique: "cron",
+		Name:      "test",
+		Hostname:  "localhost",
+		Cleanup:   []string{"crontab -e"},
+	})
+	if err != nil {
+		t.Errorf("Failed to add persistence %s", err)
+		return
+	}
+	if record.ID == "" || record.CreatedAt == "" {
+		t.Errorf("Record is missing id or timestamp %v", record)
+		return
+	}
+
+	fetched, err := Get(record.ID)
+	if err != nil {
+		t.Errorf("Failed to fetch persistence %s", err)
+		return
+	}
+	if fetched.Technique != "cron" || len(fetched.Cleanup) != 1 {
+		t.Errorf("Fetched record does not match %v != %v", fetched, record)
+		return
+	}
+
+	records, err := All()
+	if err != nil {
+		t.Errorf("Failed to list persistence %s", err)
+		return
+	}
+	found := false
+	for _, item := range records {
+		if item.ID == record.ID {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Persistence %s missing from listing", record.ID)
+	}
+
+	err = Remove(record.ID)
+	if err != nil {
+		t.Errorf("Failed to remove persistence %s", err)
+		return
+	}
+	if _, err := Get(record.ID); err != ErrPersistenceNotFound {
+		t.Errorf("Expected ErrPersistenceNotFound, got %v", err)
+	}
+}
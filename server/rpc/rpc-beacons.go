@@ -0,0 +1,129 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+// GetBeacons - Get a list of beacons
+func (rpc *Server) GetBeacons(ctx context.Context, _ *commonpb.Empty) (*clientpb.Beacons, error) {
+	resp := &clientpb.Beacons{
+		Beacons: []*clientpb.Beacon{},
+	}
+	for _, beacon := range core.Beacons.All() {
+		resp.Beacons = append(resp.Beacons, beacon.ToProtobuf())
+	}
+	return resp, nil
+}
+
+// RmBeacon - Forget about a beacon and its tasks
+func (rpc *Server) RmBeacon(ctx context.Context, req *clientpb.Beacon) (*commonpb.Empty, error) {
+	if core.Beacons.Get(req.ID) == nil {
+		return nil, ErrInvalidBeaconID
+	}
+	core.Beacons.Remove(req.ID)
+	return &commonpb.Empty{}, nil
+}
+
+// GetBeaconTasks - List the tasks of a beacon, without their content
+func (rpc *Server) GetBeaconTasks(ctx context.Context, req *clientpb.Beacon) (*clientpb.BeaconTasks, error) {
+	beacon := core.Beacons.Get(req.ID)
+	if beacon == nil {
+		return nil, ErrInvalidBeaconID
+	}
+	resp := &clientpb.BeaconTasks{
+		BeaconID: beacon.ID,
+		Tasks:    []*clientpb.BeaconTask{},
+	}
+	for _, task := range beacon.Tasks() {
+		resp.Tasks = append(resp.Tasks, task.ToProtobuf())
+	}
+	return resp, nil
+}
+
+// GetBeaconTaskContent - Get a task including the implant's response
+func (rpc *Server) GetBeaconTaskContent(ctx context.Context, req *clientpb.BeaconTask) (*clientpb.BeaconTask, error) {
+	beacon := core.Beacons.Get(req.BeaconID)
+	if beacon == nil {
+		return nil, ErrInvalidBeaconID
+	}
+	taskID, err := strconv.ParseUint(req.ID, 10, 64)
+	if err != nil {
+		return nil, core.ErrBeaconTaskNotFound
+	}
+	task, err := beacon.Task(taskID)
+	if err != nil {
+		return nil, err
+	}
+	resp := task.ToProtobuf()
+	resp.Response = task.Response
+	return resp, nil
+}
+
+// CancelBeaconTask - Drop a task that has not been sent yet
+func (rpc *Server) CancelBeaconTask(ctx context.Context, req *clientpb.BeaconTask) (*clientpb.BeaconTask, error) {
+	beacon := core.Beacons.Get(req.BeaconID)
+	if beacon == nil {
+		return nil, ErrInvalidBeaconID
+	}
+	taskID, err := strconv.ParseUint(req.ID, 10, 64)
+	if err != nil {
+		return nil, core.ErrBeaconTaskNotFound
+	}
+	task, err := beacon.CancelTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return task.ToProtobuf(), nil
+}
+
+// MoveBeaconTask - Change when a task that has not been sent yet is sent, returns the
+// tasks in their new order
+func (rpc *Server) MoveBeaconTask(ctx context.Context, req *clientpb.BeaconTaskMoveReq) (*clientpb.BeaconTasks, error) {
+	beacon := core.Beacons.Get(req.BeaconID)
+	if beacon == nil {
+		return nil, ErrInvalidBeaconID
+	}
+	taskID, err := strconv.ParseUint(req.TaskID, 10, 64)
+	if err != nil {
+		return nil, core.ErrBeaconTaskNotFound
+	}
+	err = beacon.MoveTask(taskID, int(req.Position))
+	if err != nil {
+		return nil, err
+	}
+	return rpc.GetBeaconTasks(ctx, &clientpb.Beacon{ID: beacon.ID})
+}
+
+// Reconfig - Change the reconnect interval, or beacon interval and jitter
+func (rpc *Server) Reconfig(ctx context.Context, req *sliverpb.ReconfigReq) (*sliverpb.Reconfig, error) {
+	resp := &sliverpb.Reconfig{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
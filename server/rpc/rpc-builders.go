@@ -0,0 +1,207 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/rpcpb"
+	"github.com/bishopfox/sliver/server/certs"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/generate"
+	"github.com/bishopfox/sliver/server/log"
+)
+
+const (
+	// builderTimeout - Max time to wait for an external build, obfuscated
+	// builds can take a while
+	builderTimeout = time.Duration(30 * time.Minute)
+)
+
+var (
+	rpcBuildersLog = log.NamedLogger("rpc", "builders")
+
+	// ErrInvalidBuildSignature - The artifact's signature does not match the builder's certificate
+	ErrInvalidBuildSignature = errors.New("Invalid build signature")
+)
+
+// BuilderRegister - Register an external builder and stream build tasks to it
+func (rpc *Server) BuilderRegister(req *clientpb.Builder, stream rpcpb.SliverRPC_BuilderRegisterServer) error {
+	operatorName := rpc.getClientCommonName(stream.Context())
+	if operatorName == "" {
+		return errors.New("Builders must connect with an operator certificate")
+	}
+	if req.Name == "" || req.Name == core.AnyBuilder {
+		return fmt.Errorf("Invalid builder name '%s'", req.Name)
+	}
+	builder := &core.Builder{
+		Name:         req.Name,
+		OperatorName: operatorName,
+		Targets:      req.Targets,
+	}
+	err := core.Builders.Add(builder)
+	if err != nil {
+		return err
+	}
+	defer core.Builders.Remove(builder.Name)
+	rpcBuildersLog.Infof("Builder %s (%s) connected with targets %v", builder.Name, operatorName, builder.Targets)
+
+	for {
+		select {
+		case task := <-builder.Tasks:
+			err := stream.Send(task)
+			if err != nil {
+				rpcBuildersLog.Warnf("Failed to send task to builder %s: %s", builder.Name, err)
+				return err
+			}
+		case <-stream.Context().Done():
+			rpcBuildersLog.Infof("Builder %s disconnected", builder.Name)
+			return nil
+		}
+	}
+}
+
+// BuilderResult - An external builder returns the result of a build task
+func (rpc *Server) BuilderResult(ctx context.Context, result *clientpb.BuildResult) (*commonpb.Empty, error) {
+	builder := core.Builders.Get(result.BuilderName)
+	if builder == nil {
+		return nil, core.ErrBuilderNotFound
+	}
+	cert := rpc.getClientCertificate(ctx)
+	if cert == nil || cert.Subject.CommonName != builder.OperatorName {
+		return nil, errors.New("Build results must come from the builder's operator")
+	}
+	if result.Err == "" {
+		if result.File == nil || result.Config == nil {
+			return nil, errors.New("Build result is missing the file or config")
+		}
+		err := verifyBuildSignature(cert.PublicKey, result.File.Data, result.Signature)
+		if err != nil {
+			rpcBuildersLog.Warnf("Builder %s returned an invalid signature for task %s", builder.Name, result.TaskID)
+			return nil, err
+		}
+	}
+	return &commonpb.Empty{}, core.Builders.Resolve(result)
+}
+
+// Builders - List connected external builders
+func (rpc *Server) Builders(ctx context.Context, _ *commonpb.Empty) (*clientpb.Builders, error) {
+	builders := &clientpb.Builders{Builders: []*clientpb.Builder{}}
+	for _, builder := range core.Builders.All() {
+		builders.Builders = append(builders.Builders, builder.ToProtobuf())
+	}
+	return builders, nil
+}
+
+// generateOnBuilder - The server issues the implant's name and certificates, the
+// builder only compiles it, the build is then saved like a local one
+func (rpc *Server) generateOnBuilder(req *clientpb.GenerateReq) (*clientpb.Generate, error) {
+	if req.Config == nil {
+		return nil, errors.New("Invalid implant config")
+	}
+	config := req.Config
+	builder, err := core.Builders.Select(req.Builder, fmt.Sprintf("%s/%s", config.GOOS, config.GOARCH))
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Name == "" {
+		config.Name = generate.GetCodename()
+	}
+	serverCACert, _, err := certs.GetCertificateAuthorityPEM(certs.ServerCA)
+	if err != nil {
+		return nil, err
+	}
+	sliverCert, sliverKey, err := certs.SliverGenerateECCCertificate(config.Name)
+	if err != nil {
+		return nil, err
+	}
+	config.CACert = string(serverCACert)
+	config.Cert = string(sliverCert)
+	config.Key = string(sliverKey)
+
+	rpcBuildersLog.Infof("Building %s on builder %s", config.Name, builder.Name)
+	result, err := core.Builders.Dispatch(builder, config, builderTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if result.Err != "" {
+		return nil, fmt.Errorf("Builder %s: %s", builder.Name, result.Err)
+	}
+
+	// Don't trust the builder with the name or certificates
+	built := generate.ImplantConfigFromProtobuf(result.Config)
+	built.Name = config.Name
+	built.CACert = config.CACert
+	built.Cert = config.Cert
+	built.Key = config.Key
+	built.Operator = config.Operator
+	err = generate.SaveCanaries(built, result.Canaries)
+	if err != nil {
+		rpcBuildersLog.Errorf("Failed to save canaries from builder %s: %s", builder.Name, err)
+	}
+	digest, saveFileErr := generate.ImplantFileDataSave(built.Name, result.File.Data)
+	built.SHA256 = digest
+	saveCfgErr := generate.ImplantConfigSave(built)
+	if saveFileErr != nil || saveCfgErr != nil {
+		rpcBuildersLog.Errorf("Failed to save file to db %s %s", saveFileErr, saveCfgErr)
+	}
+
+	return &clientpb.Generate{
+		File: &commonpb.File{
+			Name: built.FileName,
+			Data: result.File.Data,
+		},
+	}, nil
+}
+
+// verifyBuildSignature - Verify the signature of the SHA256 digest of the data
+func verifyBuildSignature(publicKey interface{}, data []byte, signature []byte) error {
+	digest := sha256.Sum256(data)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+			return ErrInvalidBuildSignature
+		}
+		if sig.R == nil || sig.S == nil || !ecdsa.Verify(key, digest[:], sig.R, sig.S) {
+			return ErrInvalidBuildSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+			return ErrInvalidBuildSignature
+		}
+		return nil
+	}
+	return errors.New("Unsupported builder key type")
+}
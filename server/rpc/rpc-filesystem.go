@@ -34,6 +34,36 @@ func (rpc *Server) Ls(ctx context.Context, req *sliverpb.LsReq) (*sliverpb.Ls, e
 	return resp, nil
 }
 
+// Search - Search the remote filesystem by file name and/or content
+func (rpc *Server) Search(ctx context.Context, req *sliverpb.SearchReq) (*sliverpb.Search, error) {
+	resp := &sliverpb.Search{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Hash - Hash remote files, only the hashes are returned
+func (rpc *Server) Hash(ctx context.Context, req *sliverpb.HashReq) (*sliverpb.Hash, error) {
+	resp := &sliverpb.Hash{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Drives - List logical drives and mount points
+func (rpc *Server) Drives(ctx context.Context, req *sliverpb.DrivesReq) (*sliverpb.Drives, error) {
+	resp := &sliverpb.Drives{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // Rm - Remove file or directory
 func (rpc *Server) Rm(ctx context.Context, req *sliverpb.RmReq) (*sliverpb.Rm, error) {
 	resp := &sliverpb.Rm{}
@@ -54,6 +84,36 @@ func (rpc *Server) Mkdir(ctx context.Context, req *sliverpb.MkdirReq) (*sliverpb
 	return resp, nil
 }
 
+// Chmod - Change the permissions of a remote file
+func (rpc *Server) Chmod(ctx context.Context, req *sliverpb.ChmodReq) (*sliverpb.Chmod, error) {
+	resp := &sliverpb.Chmod{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Chown - Change the owner of a remote file
+func (rpc *Server) Chown(ctx context.Context, req *sliverpb.ChownReq) (*sliverpb.Chown, error) {
+	resp := &sliverpb.Chown{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Timestomp - Change the timestamps of a remote file
+func (rpc *Server) Timestomp(ctx context.Context, req *sliverpb.TimestompReq) (*sliverpb.Timestomp, error) {
+	resp := &sliverpb.Timestomp{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // Cd - Change directory
 func (rpc *Server) Cd(ctx context.Context, req *sliverpb.CdReq) (*sliverpb.Pwd, error) {
 	resp := &sliverpb.Pwd{}
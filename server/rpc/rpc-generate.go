@@ -29,24 +29,28 @@ import (
 	"github.com/bishopfox/sliver/server/generate"
 )
 
+// localOperatorName - Operator recorded for builds from the server console
+const localOperatorName = "server"
+
 // Generate - Generate a new implant
 func (rpc *Server) Generate(ctx context.Context, req *clientpb.GenerateReq) (*clientpb.Generate, error) {
 	var fPath string
 	var err error
-	config := generate.ImplantConfigFromProtobuf(req.Config)
-	if config == nil {
+	if req.Config == nil {
 		return nil, errors.New("Invalid implant config")
 	}
-	switch req.Config.Format {
-	case clientpb.ImplantConfig_SERVICE:
-		fallthrough
-	case clientpb.ImplantConfig_EXECUTABLE:
-		fPath, err = generate.SliverExecutable(config)
-		break
-	case clientpb.ImplantConfig_SHARED_LIB:
-		fPath, err = generate.SliverSharedLibrary(config)
-	case clientpb.ImplantConfig_SHELLCODE:
-		fPath, err = generate.SliverShellcode(config)
+	req.Config.Operator = rpc.getOperatorName(ctx)
+	config := generate.ImplantConfigFromProtobuf(req.Config)
+	err = generate.CheckOperational(config, req.AllowDebug)
+	if err != nil {
+		return nil, err
+	}
+	if req.Builder != "" {
+		return rpc.generateOnBuilder(req)
+	}
+	fPath, err = generate.SliverImplant(config)
+	if err != nil {
+		return nil, err
 	}
 
 	filename := path.Base(fPath)
@@ -72,8 +76,19 @@ func (rpc *Server) Regenerate(ctx context.Context, req *clientpb.RegenerateReq)
 	}
 
 	fileData, err := generate.ImplantFileByName(req.ImplantName)
-	if err != nil {
-		return nil, err
+	if err != nil || req.Rebuild {
+		// The name, certificates and obfuscation seed come from the saved config
+		// so the new binary is interchangeable with the original one
+		rpcLog.Infof("Rebuilding implant %s from its saved config", config.Name)
+		generate.ImplantCleanBuildDir(config)
+		fPath, err := generate.SliverImplant(config)
+		if err != nil {
+			return nil, err
+		}
+		fileData, err = ioutil.ReadFile(fPath)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &clientpb.Generate{
@@ -84,6 +99,56 @@ func (rpc *Server) Regenerate(ctx context.Context, req *clientpb.RegenerateReq)
 	}, nil
 }
 
+// ImplantOperational - Mark an implant build as operational (or not)
+func (rpc *Server) ImplantOperational(ctx context.Context, req *clientpb.OperationalReq) (*clientpb.ImplantConfig, error) {
+	config, err := generate.ImplantConfigByName(req.ImplantName)
+	if err != nil {
+		return nil, generate.ErrImplantNotFound
+	}
+	config.Operational = req.Operational
+	err = generate.CheckOperational(config, req.AllowDebug)
+	if err != nil {
+		return nil, err
+	}
+	err = generate.ImplantConfigSave(config)
+	if err != nil {
+		return nil, err
+	}
+	return config.ToProtobuf(), nil
+}
+
+// PatchImplant - Create a variant of a patchable implant without compiling it
+func (rpc *Server) PatchImplant(ctx context.Context, req *clientpb.PatchReq) (*clientpb.Generate, error) {
+	variant, data, err := generate.PatchVariant(req, rpc.getOperatorName(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &clientpb.Generate{
+		File: &commonpb.File{
+			Name: variant.FileName,
+			Data: data,
+		},
+	}, nil
+}
+
+// getOperatorName - The operator builds are recorded under
+func (rpc *Server) getOperatorName(ctx context.Context) string {
+	operator := rpc.getClientCommonName(ctx)
+	if operator == "" {
+		return localOperatorName
+	}
+	return operator
+}
+
+// ImplantByHash - Find the build an artifact came from by its SHA256
+func (rpc *Server) ImplantByHash(ctx context.Context, req *clientpb.ImplantHashReq) (*clientpb.ImplantConfig, error) {
+	config, err := generate.ImplantConfigBySHA256(req.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return config.ToProtobuf(), nil
+}
+
 // ImplantBuilds - List existing implant builds
 func (rpc *Server) ImplantBuilds(ctx context.Context, _ *commonpb.Empty) (*clientpb.ImplantBuilds, error) {
 	configs, err := generate.ImplantConfigMap()
@@ -146,6 +211,37 @@ func (rpc *Server) SaveImplantProfile(ctx context.Context, profile *clientpb.Imp
 	return nil, errors.New("Invalid profile name")
 }
 
+// NetworkProfiles - List network profiles
+func (rpc *Server) NetworkProfiles(ctx context.Context, _ *commonpb.Empty) (*clientpb.NetworkProfiles, error) {
+	networkProfiles := &clientpb.NetworkProfiles{
+		Profiles: []*clientpb.NetworkProfile{},
+	}
+	for _, profile := range generate.NetworkProfiles() {
+		networkProfiles.Profiles = append(networkProfiles.Profiles, profile.ToProtobuf())
+	}
+	return networkProfiles, nil
+}
+
+// SaveNetworkProfile - Save a network profile, parameters that aren't set are randomly derived
+func (rpc *Server) SaveNetworkProfile(ctx context.Context, req *clientpb.NetworkProfile) (*clientpb.NetworkProfile, error) {
+	profile := generate.NetworkProfileFromProtobuf(req)
+	profile.Name = path.Base(profile.Name)
+	if len(profile.Name) == 0 || profile.Name == "." {
+		return nil, errors.New("Invalid network profile name")
+	}
+	generate.DeriveNetworkProfile(profile)
+	err := generate.ValidateNetworkProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	rpcLog.Infof("Saving network profile with name %#v", profile.Name)
+	err = generate.NetworkProfileSave(profile)
+	if err != nil {
+		return nil, err
+	}
+	return profile.ToProtobuf(), nil
+}
+
 // ShellcodeRDI - Generates a RDI shellcode from a given DLL
 func (rpc *Server) ShellcodeRDI(ctx context.Context, req *clientpb.ShellcodeRDIReq) (*clientpb.ShellcodeRDI, error) {
 	shellcode, err := generate.ShellcodeRDIFromBytes(req.GetData(), req.GetFunctionName(), req.GetArguments())
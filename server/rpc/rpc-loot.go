@@ -0,0 +1,61 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/server/loot"
+)
+
+var (
+	rpcLootLog = log.NamedLogger("rpc", "loot")
+)
+
+// LootAll - List the metadata of all loot
+func (rpc *Server) LootAll(ctx context.Context, _ *commonpb.Empty) (*clientpb.AllLoot, error) {
+	allLoot, err := loot.AllLoot()
+	if err != nil {
+		rpcLootLog.Warnf("Failed to list loot %s", err)
+		return nil, err
+	}
+	return &clientpb.AllLoot{Loot: allLoot}, nil
+}
+
+// LootContent - Get a piece of loot including its content
+func (rpc *Server) LootContent(ctx context.Context, req *clientpb.Loot) (*clientpb.Loot, error) {
+	return loot.GetLoot(req.ID)
+}
+
+// LootAdd - Add operator supplied content to the loot store
+func (rpc *Server) LootAdd(ctx context.Context, req *clientpb.Loot) (*clientpb.Loot, error) {
+	return loot.AddLoot(req)
+}
+
+// LootRm - Remove a piece of loot
+func (rpc *Server) LootRm(ctx context.Context, req *clientpb.Loot) (*commonpb.Empty, error) {
+	err := loot.RemoveLoot(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &commonpb.Empty{}, nil
+}
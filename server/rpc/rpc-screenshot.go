@@ -20,16 +20,64 @@ package rpc
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/bishopfox/sliver/protobuf/clientpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/loot"
+	"github.com/bishopfox/sliver/util/encoders"
 )
 
-// Screenshot - Take a screenshot of the remote system
+// Screenshot - Take a screenshot of the remote system, the image is saved as loot
 func (rpc *Server) Screenshot(ctx context.Context, req *sliverpb.ScreenshotReq) (*sliverpb.Screenshot, error) {
 	resp := &sliverpb.Screenshot{}
 	err := rpc.GenericHandler(req, resp)
 	if err != nil {
 		return nil, err
 	}
+	if req.ListDisplays || len(resp.Data) == 0 {
+		return resp, nil
+	}
+	if resp.Encoder == "gzip" {
+		resp.Data, err = new(encoders.Gzip).Decode(resp.Data)
+		if err != nil {
+			return nil, err
+		}
+		resp.Encoder = ""
+	}
+
+	owner := getRequestOwner(req.Request)
+	if owner == nil {
+		return resp, nil
+	}
+	timestamp := time.Now().Format("20060102150405")
+	fileName := fmt.Sprintf("screenshot_%s_%s_%s.png", owner.Name, owner.Key(), timestamp)
+	if req.Display != 0 {
+		fileName = fmt.Sprintf("screenshot_%s_%s_display%d_%s.png", owner.Name, owner.Key(), req.Display, timestamp)
+	}
+	meta, err := loot.AddLoot(&clientpb.Loot{
+		Type:        "screenshot",
+		FileName:    fileName,
+		SessionName: owner.Name,
+		SessionID:   owner.SessionID,
+		Data:        resp.Data,
+	})
+	if err != nil {
+		rpcLog.Errorf("Failed to save screenshot to loot %s", err)
+	} else {
+		resp.LootID = meta.ID
+	}
+	return resp, nil
+}
+
+// ScreenshotWatch - Start/stop streaming screenshots, frames arrive as
+// ScreenshotFrame messages and are relayed to clients as events
+func (rpc *Server) ScreenshotWatch(ctx context.Context, req *sliverpb.ScreenshotWatchReq) (*sliverpb.ScreenshotWatch, error) {
+	resp := &sliverpb.ScreenshotWatch{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
 	return resp, nil
 }
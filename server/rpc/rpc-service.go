@@ -35,3 +35,43 @@ func (rpc *Server) RemoveService(ctx context.Context, req *sliverpb.RemoveServic
 	}
 	return resp, nil
 }
+
+// ListServices lists the services of a remote host
+func (rpc *Server) ListServices(ctx context.Context, req *sliverpb.ListServicesReq) (*sliverpb.Services, error) {
+	resp := &sliverpb.Services{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// QueryService gets the configuration and status of a remote service
+func (rpc *Server) QueryService(ctx context.Context, req *sliverpb.QueryServiceReq) (*sliverpb.QueryService, error) {
+	resp := &sliverpb.QueryService{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateService creates a Windows service on a remote host without starting it
+func (rpc *Server) CreateService(ctx context.Context, req *sliverpb.CreateServiceReq) (*sliverpb.ServiceInfo, error) {
+	resp := &sliverpb.ServiceInfo{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StartExistingService starts an installed service on a remote host
+func (rpc *Server) StartExistingService(ctx context.Context, req *sliverpb.StartExistingServiceReq) (*sliverpb.ServiceInfo, error) {
+	resp := &sliverpb.ServiceInfo{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
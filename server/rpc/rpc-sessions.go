@@ -40,17 +40,53 @@ func (rpc *Server) GetSessions(ctx context.Context, _ *commonpb.Empty) (*clientp
 	return resp, nil
 }
 
+// TagSession - Add or remove operator tags of a session or beacon
+func (rpc *Server) TagSession(ctx context.Context, req *clientpb.TagReq) (*commonpb.Empty, error) {
+	if req.BeaconID != "" {
+		beacon := core.Beacons.Get(req.BeaconID)
+		if beacon == nil {
+			return nil, ErrInvalidBeaconID
+		}
+		beacon.Tag(req.Tags, req.Remove)
+		return &commonpb.Empty{}, nil
+	}
+	session := core.Sessions.Get(req.SessionID)
+	if session == nil {
+		return nil, ErrInvalidSessionID
+	}
+	session.Tag(req.Tags, req.Remove)
+	return &commonpb.Empty{}, nil
+}
+
+// GetTransfers - Progress of the envelopes moving between the server and sessions
+func (rpc *Server) GetTransfers(ctx context.Context, _ *commonpb.Empty) (*clientpb.Transfers, error) {
+	transfers := &clientpb.Transfers{}
+	for _, transfer := range core.Transfers.All() {
+		transfers.Transfers = append(transfers.Transfers, transfer.ToProtobuf())
+	}
+	return transfers, nil
+}
+
 // KillSession - Kill a session
 func (rpc *Server) KillSession(ctx context.Context, kill *sliverpb.KillSessionReq) (*commonpb.Empty, error) {
+	data, err := proto.Marshal(kill)
+	if err != nil {
+		return nil, err
+	}
+	if kill.Request.GetBeaconID() != "" {
+		beacon := core.Beacons.Get(kill.Request.BeaconID)
+		if beacon == nil {
+			return &commonpb.Empty{}, ErrInvalidBeaconID
+		}
+		// There won't be a result, the beacon exits at its next check-in
+		beacon.AddTask("KillSessionReq", sliverpb.MsgNumber(kill), data)
+		return &commonpb.Empty{}, nil
+	}
 	session := core.Sessions.Get(kill.Request.SessionID)
 	if session == nil {
 		return &commonpb.Empty{}, ErrInvalidSessionID
 	}
 	core.Sessions.Remove(session.ID)
-	data, err := proto.Marshal(kill)
-	if err != nil {
-		return nil, err
-	}
 	timeout := time.Duration(kill.Request.GetTimeout())
 	session.Request(sliverpb.MsgNumber(kill), timeout, data)
 	return &commonpb.Empty{}, nil
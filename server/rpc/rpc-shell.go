@@ -54,3 +54,31 @@ func (s *Server) Shell(ctx context.Context, req *sliverpb.ShellReq) (*sliverpb.S
 	err = proto.Unmarshal(data, shell)
 	return shell, err
 }
+
+// PowerShell - Run a script in the implant's hosted runspace, or stream its
+// output over a tunnel
+func (s *Server) PowerShell(ctx context.Context, req *sliverpb.PowerShellReq) (*sliverpb.PowerShell, error) {
+	if req.TunnelID != 0 && core.Tunnels.Get(req.TunnelID) == nil {
+		return nil, core.ErrInvalidTunnelID
+	}
+	resp := &sliverpb.PowerShell{}
+	err := s.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RunSSHCommand - Run a command on an SSH server from the implant, or bind
+// an SSH shell to a tunnel
+func (s *Server) RunSSHCommand(ctx context.Context, req *sliverpb.SSHCommandReq) (*sliverpb.SSHCommand, error) {
+	if req.TunnelID != 0 && core.Tunnels.Get(req.TunnelID) == nil {
+		return nil, core.ErrInvalidTunnelID
+	}
+	resp := &sliverpb.SSHCommand{}
+	err := s.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -76,9 +76,12 @@ func (rpc *Server) Migrate(ctx context.Context, req *clientpb.MigrateReq) (*sliv
 	if err != nil {
 		return nil, err
 	}
+	// The new process may register before we get a response
+	core.Sessions.ExpectMigration(session.ID, req.Pid)
 	timeout := rpc.getTimeout(req)
 	respData, err := session.Request(sliverpb.MsgInvokeMigrateReq, timeout, reqData)
 	if err != nil {
+		core.Sessions.CancelMigration(session.ID, req.Pid)
 		return nil, err
 	}
 	resp := &sliverpb.Migrate{}
@@ -86,6 +89,9 @@ func (rpc *Server) Migrate(ctx context.Context, req *clientpb.MigrateReq) (*sliv
 	if err != nil {
 		return nil, err
 	}
+	if !resp.Success {
+		core.Sessions.CancelMigration(session.ID, req.Pid)
+	}
 	return resp, nil
 }
 
@@ -154,6 +160,7 @@ func (rpc *Server) Sideload(ctx context.Context, req *sliverpb.SideloadReq) (*sl
 			Request:     req.Request,
 			Data:        shellcode,
 			ProcessName: req.ProcessName,
+			Pid:         req.Pid,
 		})
 		if err != nil {
 			return nil, err
@@ -192,6 +199,26 @@ func (rpc *Server) SpawnDll(ctx context.Context, req *sliverpb.SpawnDllReq) (*sl
 	return resp, nil
 }
 
+// ExecutePE - Run an executable in memory on the remote system (Windows only)
+func (rpc *Server) ExecutePE(ctx context.Context, req *sliverpb.ExecutePEReq) (*sliverpb.ExecutePE, error) {
+	resp := &sliverpb.ExecutePE{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ExecuteBOF - Run a Beacon Object File on the remote system (Windows only)
+func (rpc *Server) ExecuteBOF(ctx context.Context, req *sliverpb.ExecuteBOFReq) (*sliverpb.ExecuteBOF, error) {
+	resp := &sliverpb.ExecuteBOF{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // Utility functions
 func getSliverShellcode(name string) ([]byte, error) {
 	var data []byte
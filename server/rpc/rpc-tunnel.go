@@ -109,14 +109,17 @@ func (s *Server) TunnelData(stream rpcpb.SliverRPC_TunnelDataServer) error {
 
 			go func() {
 				session := core.Sessions.Get(tunnel.SessionID)
+				sequence := uint64(0)
 				for data := range tunnel.ToImplant {
 					tunnelLog.Debugf("Tunnel %d: To implant %d byte(s)", tunnel.ID, len(data))
 					data, _ := proto.Marshal(&sliverpb.TunnelData{
 						TunnelID:  tunnel.ID,
 						SessionID: tunnel.SessionID,
+						Sequence:  sequence,
 						Data:      data,
 						Closed:    false,
 					})
+					sequence++
 					session.Send <- &sliverpb.Envelope{
 						Type: sliverpb.MsgTunnelData,
 						Data: data,
@@ -126,11 +129,12 @@ func (s *Server) TunnelData(stream rpcpb.SliverRPC_TunnelDataServer) error {
 				data, _ := proto.Marshal(&sliverpb.TunnelData{
 					TunnelID:  tunnel.ID,
 					SessionID: tunnel.SessionID,
+					Sequence:  sequence,
 					Data:      make([]byte, 0),
 					Closed:    true,
 				})
 				session.Send <- &sliverpb.Envelope{
-					Type: sliverpb.MsgTunnelData,
+					Type: sliverpb.MsgTunnelClose,
 					Data: data,
 				}
 			}()
@@ -0,0 +1,58 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/server/core"
+)
+
+// Whoami - Get the identity of the remote process and cache its groups and
+// privileges in the session/beacon info
+func (rpc *Server) Whoami(ctx context.Context, req *sliverpb.WhoamiReq) (*sliverpb.Whoami, error) {
+	resp := &sliverpb.Whoami{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Response != nil && resp.Response.Err != "" {
+		return resp, nil
+	}
+	privileges := []string{}
+	for _, privilege := range resp.Privileges {
+		if privilege.Enabled {
+			privileges = append(privileges, privilege.Name)
+		}
+	}
+	if req.Request.BeaconID != "" {
+		beacon := core.Beacons.Get(req.Request.BeaconID)
+		if beacon != nil {
+			beacon.SetIdentity(resp.Groups, privileges)
+		}
+	} else {
+		session := core.Sessions.Get(req.Request.SessionID)
+		if session != nil {
+			session.Groups = resp.Groups
+			session.Privileges = privileges
+		}
+	}
+	return resp, nil
+}
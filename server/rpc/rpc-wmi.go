@@ -0,0 +1,45 @@
+package rpc
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// WMIQuery - Run a WQL query on the remote system, or a host it can reach
+func (rpc *Server) WMIQuery(ctx context.Context, req *sliverpb.WMIQueryReq) (*sliverpb.WMIQuery, error) {
+	resp := &sliverpb.WMIQuery{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WMIExec - Start a process via WMI on the remote system, or a host it can reach
+func (rpc *Server) WMIExec(ctx context.Context, req *sliverpb.WMIExecReq) (*sliverpb.WMIExec, error) {
+	resp := &sliverpb.WMIExec{}
+	err := rpc.GenericHandler(req, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
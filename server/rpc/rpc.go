@@ -20,8 +20,11 @@ package rpc
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
+	"fmt"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/bishopfox/sliver/client/version"
@@ -40,6 +43,8 @@ var (
 
 	// ErrInvalidSessionID - Invalid Session ID in request
 	ErrInvalidSessionID = errors.New("Invalid session ID")
+	// ErrInvalidBeaconID - Invalid Beacon ID in request
+	ErrInvalidBeaconID = errors.New("Invalid beacon ID")
 	// ErrMissingRequestField - Returned when a request does not contain a commonpb.Request
 	ErrMissingRequestField = errors.New("Missing session request field")
 )
@@ -93,17 +98,26 @@ func (rpc *Server) GenericHandler(req GenericRequest, resp proto.Message) error
 	if request == nil {
 		return ErrMissingRequestField
 	}
-	session := core.Sessions.Get(request.SessionID)
-	if session == nil {
-		return ErrInvalidSessionID
-	}
-
 	reqData, err := proto.Marshal(req)
 	if err != nil {
 		return err
 	}
 
-	data, err := session.Request(sliverpb.MsgNumber(req), rpc.getTimeout(req), reqData)
+	var data []byte
+	if request.BeaconID != "" {
+		beacon := core.Beacons.Get(request.BeaconID)
+		if beacon == nil {
+			return ErrInvalidBeaconID
+		}
+		description := strings.TrimPrefix(fmt.Sprintf("%T", req), "*sliverpb.")
+		data, err = beacon.Request(description, sliverpb.MsgNumber(req), rpc.getTimeout(req), reqData)
+	} else {
+		session := core.Sessions.Get(request.SessionID)
+		if session == nil {
+			return ErrInvalidSessionID
+		}
+		data, err = session.RequestWithBandwidthLimit(sliverpb.MsgNumber(req), rpc.getTimeout(req), request.BandwidthLimit, reqData)
+	}
 	if err != nil {
 		return err
 	}
@@ -114,22 +128,46 @@ func (rpc *Server) GenericHandler(req GenericRequest, resp proto.Message) error
 	return rpc.getError(resp.(GenericResponse))
 }
 
+// getRequestOwner - The session or beacon GenericHandler routes a request to,
+// nil if it's gone
+func getRequestOwner(request *commonpb.Request) *core.Owner {
+	if request == nil {
+		return nil
+	}
+	if request.BeaconID != "" {
+		if beacon := core.Beacons.Get(request.BeaconID); beacon != nil {
+			return beacon.Owner()
+		}
+		return nil
+	}
+	if session := core.Sessions.Get(request.SessionID); session != nil {
+		return session.Owner()
+	}
+	return nil
+}
+
 func (rpc *Server) getClientCommonName(ctx context.Context) string {
+	cert := rpc.getClientCertificate(ctx)
+	if cert == nil {
+		return ""
+	}
+	return cert.Subject.CommonName
+}
+
+// getClientCertificate - Get the client's verified certificate, nil for local connections
+func (rpc *Server) getClientCertificate(ctx context.Context) *x509.Certificate {
 	client, ok := peer.FromContext(ctx)
 	if !ok {
-		return ""
+		return nil
 	}
 	tlsAuth, ok := client.AuthInfo.(credentials.TLSInfo)
 	if !ok {
-		return ""
+		return nil
 	}
 	if len(tlsAuth.State.VerifiedChains) == 0 || len(tlsAuth.State.VerifiedChains[0]) == 0 {
-		return ""
-	}
-	if tlsAuth.State.VerifiedChains[0][0].Subject.CommonName != "" {
-		return tlsAuth.State.VerifiedChains[0][0].Subject.CommonName
+		return nil
 	}
-	return ""
+	return tlsAuth.State.VerifiedChains[0][0]
 }
 
 // getTimeout - Get the specified timeout from the request or the default
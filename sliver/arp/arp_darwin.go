@@ -0,0 +1,80 @@
+package arp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"net"
+	"syscall"
+)
+
+// list - Walk the routing table for the link layer entries (RTF_LLINFO),
+// which is how arp(8) and ndp(8) read the neighbor cache
+func list() ([]Entry, error) {
+	data, err := syscall.RouteRIB(syscall.NET_RT_FLAGS, syscall.RTF_LLINFO)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseRoutingMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, msg := range msgs {
+		routeMsg, ok := msg.(*syscall.RouteMessage)
+		if !ok {
+			continue
+		}
+		addrs, err := syscall.ParseRoutingSockaddr(routeMsg)
+		if err != nil || len(addrs) <= syscall.RTAX_GATEWAY {
+			continue
+		}
+		entry := Entry{}
+		switch dst := addrs[syscall.RTAX_DST].(type) {
+		case *syscall.SockaddrInet4:
+			entry.IP = net.IP(dst.Addr[:]).String()
+		case *syscall.SockaddrInet6:
+			entry.IP = net.IP(dst.Addr[:]).String()
+		default:
+			continue
+		}
+		link, ok := addrs[syscall.RTAX_GATEWAY].(*syscall.SockaddrDatalink)
+		if !ok {
+			continue
+		}
+		entry.Interface = interfaceName(int(link.Index))
+		start, end := int(link.Nlen), int(link.Nlen)+int(link.Alen)
+		if 0 < link.Alen && end <= len(link.Data) {
+			mac := make(net.HardwareAddr, 0, link.Alen)
+			for _, b := range link.Data[start:end] {
+				mac = append(mac, byte(b))
+			}
+			entry.MAC = mac.String()
+		}
+		switch {
+		case entry.MAC == "":
+			entry.State = StateIncomplete
+		case routeMsg.Header.Flags&syscall.RTF_STATIC != 0:
+			entry.State = StatePermanent
+		default:
+			entry.State = StateReachable
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,164 @@
+package arp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ndaDst    = 1
+	ndaLLAddr = 2
+
+	ndmsgSize = 12
+)
+
+// ndmsg - struct ndmsg from linux/neighbour.h
+type ndmsg struct {
+	Family  uint8
+	Pad1    uint8
+	Pad2    uint16
+	Ifindex int32
+	State   uint16
+	Flags   uint8
+	Type    uint8
+}
+
+var nudStates = []struct {
+	flag  uint16
+	state string
+}{
+	{0x80, StatePermanent},
+	{0x40, StateNoArp},
+	{0x02, StateReachable},
+	{0x04, StateStale},
+	{0x08, StateDelay},
+	{0x10, StateProbe},
+	{0x20, StateFailed},
+	{0x01, StateIncomplete},
+}
+
+// list - Dump the neighbor table over netlink, which covers IPv4 and IPv6,
+// and fall back to /proc/net/arp (IPv4 only) if that is not allowed
+func list() ([]Entry, error) {
+	entries, err := netlinkNeighbors()
+	if err == nil {
+		return entries, nil
+	}
+	file, procErr := os.Open("/proc/net/arp")
+	if procErr != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseProcARP(file)
+}
+
+func netlinkNeighbors() ([]Entry, error) {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWNEIGH || len(msg.Data) < ndmsgSize {
+			continue
+		}
+		nd := (*ndmsg)(unsafe.Pointer(&msg.Data[0]))
+		entry := Entry{
+			Interface: interfaceName(int(nd.Ifindex)),
+			State:     nudState(nd.State),
+		}
+		attrs := msg.Data[ndmsgSize:]
+		for syscall.SizeofRtAttr <= len(attrs) {
+			attr := (*syscall.RtAttr)(unsafe.Pointer(&attrs[0]))
+			if int(attr.Len) < syscall.SizeofRtAttr || len(attrs) < int(attr.Len) {
+				break
+			}
+			value := attrs[syscall.SizeofRtAttr:attr.Len]
+			switch attr.Type {
+			case ndaDst:
+				entry.IP = net.IP(value).String()
+			case ndaLLAddr:
+				entry.MAC = net.HardwareAddr(value).String()
+			}
+			aligned := (int(attr.Len) + syscall.NLMSG_ALIGNTO - 1) & ^(syscall.NLMSG_ALIGNTO - 1)
+			if len(attrs) < aligned {
+				break
+			}
+			attrs = attrs[aligned:]
+		}
+		if entry.IP == "" || entry.Interface == "lo" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func nudState(state uint16) string {
+	for _, nud := range nudStates {
+		if state&nud.flag != 0 {
+			return nud.state
+		}
+	}
+	return ""
+}
+
+// parseProcARP - Parse the /proc/net/arp format
+// IP address       HW type     Flags       HW address            Mask     Device
+// 192.168.1.1      0x1         0x2         00:11:22:33:44:55     *        eth0
+func parseProcARP(reader io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(strings.TrimPrefix(fields[2], "0x"), 16, 32)
+		entry := Entry{
+			IP:        fields[0],
+			Interface: fields[5],
+		}
+		switch {
+		case flags&0x04 != 0: // ATF_PERM
+			entry.State = StatePermanent
+			entry.MAC = fields[3]
+		case flags&0x02 != 0: // ATF_COM
+			entry.State = StateReachable
+			entry.MAC = fields[3]
+		default:
+			entry.State = StateIncomplete
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
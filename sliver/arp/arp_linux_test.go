@@ -0,0 +1,72 @@
+package arp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"strings"
+	"testing"
+)
+
+const procARP = `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         00:11:22:33:44:55     *        eth0
+192.168.1.20     0x1         0x0         00:00:00:00:00:00     *        eth0
+10.0.0.1         0x1         0x6         66:77:88:99:aa:bb     *        eth1
+`
+
+func TestParseProcARP(t *testing.T) {
+	entries, err := parseProcARP(strings.NewReader(procARP))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Entry{
+		{IP: "192.168.1.1", MAC: "00:11:22:33:44:55", Interface: "eth0", State: StateReachable},
+		{IP: "192.168.1.20", Interface: "eth0", State: StateIncomplete},
+		{IP: "10.0.0.1", MAC: "66:77:88:99:aa:bb", Interface: "eth1", State: StatePermanent},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries got %d", len(expected), len(entries))
+	}
+	for index := range expected {
+		if entries[index] != expected[index] {
+			t.Errorf("expected %+v got %+v", expected[index], entries[index])
+		}
+	}
+}
+
+func TestNudState(t *testing.T) {
+	if state := nudState(0x02); state != StateReachable {
+		t.Errorf("expected %s got %s", StateReachable, state)
+	}
+	if state := nudState(0x80); state != StatePermanent {
+		t.Errorf("expected %s got %s", StatePermanent, state)
+	}
+}
+
+func TestList(t *testing.T) {
+	_, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLessIP(t *testing.T) {
+	if !lessIP("10.0.0.2", "10.0.0.10") || lessIP("fe80::1", "10.0.0.1") {
+		t.Fatal("unexpected ordering")
+	}
+}
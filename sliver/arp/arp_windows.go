@@ -0,0 +1,76 @@
+package arp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/binary"
+	"net"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+)
+
+const (
+	afUnspec = 0
+	afInet   = 2
+	afInet6  = 23
+)
+
+var nlnsStates = map[uint32]string{
+	syscalls.NlnsUnreachable: StateFailed,
+	syscalls.NlnsIncomplete:  StateIncomplete,
+	syscalls.NlnsProbe:       StateProbe,
+	syscalls.NlnsDelay:       StateDelay,
+	syscalls.NlnsStale:       StateStale,
+	syscalls.NlnsReachable:   StateReachable,
+	syscalls.NlnsPermanent:   StatePermanent,
+}
+
+// list - GetIpNetTable2 returns both the ARP and NDP caches
+func list() ([]Entry, error) {
+	var table *syscalls.MibIpNetTable2
+	err := syscalls.GetIpNetTable2(afUnspec, &table)
+	if err != nil {
+		return nil, err
+	}
+	defer syscalls.FreeMibTable(unsafe.Pointer(table))
+
+	rows := (*[1 << 20]syscalls.MibIpNetRow2)(unsafe.Pointer(&table.Table[0]))[:table.NumEntries:table.NumEntries]
+	entries := []Entry{}
+	for _, row := range rows {
+		entry := Entry{
+			Interface: interfaceName(int(row.InterfaceIndex)),
+			State:     nlnsStates[row.State],
+		}
+		switch binary.LittleEndian.Uint16(row.Address[0:2]) {
+		case afInet:
+			entry.IP = net.IP(row.Address[4:8]).String()
+		case afInet6:
+			entry.IP = net.IP(row.Address[8:24]).String()
+		default:
+			continue
+		}
+		length := row.PhysicalAddressLength
+		if 0 < length && int(length) <= len(row.PhysicalAddress) {
+			entry.MAC = net.HardwareAddr(row.PhysicalAddress[:length]).String()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
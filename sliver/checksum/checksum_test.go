@@ -0,0 +1,117 @@
+package checksum
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	helloMD5    = "5d41402abc4b2a76b9719d911017c592"
+	helloSHA1   = "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+)
+
+func setupTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "checksum-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.txt":          "hello",
+		"b.bin":          "world",
+		"sub/c.txt":      "hello",
+		"sub/deep/d.txt": "something else entirely",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestHashFile(t *testing.T) {
+	root := setupTree(t)
+	defer os.RemoveAll(root)
+
+	results, summary, err := Hash(filepath.Join(root, "a.txt"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || summary.Hashed != 1 {
+		t.Fatalf("expected one result, got %d (%+v)", len(results), summary)
+	}
+	hashes := results[0].Hashes
+	if hashes[MD5] != helloMD5 || hashes[SHA1] != helloSHA1 || hashes[SHA256] != helloSHA256 {
+		t.Errorf("unexpected hashes %v", hashes)
+	}
+	if results[0].Size != 5 {
+		t.Errorf("expected size 5, got %d", results[0].Size)
+	}
+
+	results, _, err = Hash(filepath.Join(root, "a.txt"), Options{Algorithms: []string{"SHA256"}})
+	if err != nil || len(results[0].Hashes) != 1 || results[0].Hashes[SHA256] != helloSHA256 {
+		t.Errorf("expected only sha256, got %v (%v)", results, err)
+	}
+	if _, _, err := Hash(filepath.Join(root, "a.txt"), Options{Algorithms: []string{"crc32"}}); err == nil {
+		t.Error("expected an unsupported algorithm to fail")
+	}
+}
+
+func TestHashTree(t *testing.T) {
+	root := setupTree(t)
+	defer os.RemoveAll(root)
+
+	if _, _, err := Hash(root, Options{}); err == nil {
+		t.Error("expected a directory without recursion to fail")
+	}
+	results, summary, err := Hash(root, Options{Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || summary.Hashed != 4 {
+		t.Errorf("expected 4 files, got %d (%+v)", len(results), summary)
+	}
+
+	results, _, _ = Hash(root, Options{Recursive: true, NameGlob: "*.txt", MaxDepth: 2})
+	if len(results) != 2 {
+		t.Errorf("expected 2 txt files within depth 2, got %d", len(results))
+	}
+
+	results, summary, _ = Hash(root, Options{Recursive: true, Match: []string{helloMD5}})
+	if len(results) != 2 || summary.Hashed != 4 {
+		t.Errorf("expected 2 of 4 files to match, got %d (%+v)", len(results), summary)
+	}
+
+	results, summary, _ = Hash(root, Options{Recursive: true, MaxFiles: 1})
+	if len(results) != 1 || !summary.Truncated {
+		t.Errorf("expected the walk to stop at 1 file, got %d (%+v)", len(results), summary)
+	}
+
+	_, summary, _ = Hash(root, Options{Recursive: true, MaxFileSize: 5})
+	if summary.Hashed != 3 || summary.Skipped != 1 {
+		t.Errorf("expected the large file to be skipped, got %+v", summary)
+	}
+}
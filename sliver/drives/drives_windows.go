@@ -0,0 +1,170 @@
+package drives
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"strings"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
+const (
+	maxPath       = windows.MAX_PATH + 1
+	enumBufferLen = 16 * 1024
+)
+
+var (
+	driveTypes = map[uint32]string{
+		windows.DRIVE_FIXED:     Fixed,
+		windows.DRIVE_REMOVABLE: Removable,
+		windows.DRIVE_REMOTE:    Remote,
+		windows.DRIVE_CDROM:     CDROM,
+		windows.DRIVE_RAMDISK:   RAMDisk,
+	}
+)
+
+// list - Logical drives, plus connected network shares that are not mapped
+// to a drive letter, pseudo filesystems don't apply here
+func list(all bool) ([]Drive, error) {
+	buf := make([]uint16, 512)
+	n, err := windows.GetLogicalDriveStrings(uint32(len(buf)), &buf[0])
+	if err != nil {
+		return nil, err
+	}
+	drives := []Drive{}
+	mapped := map[string]bool{}
+	for _, root := range splitMultiString(buf[:n]) {
+		drive := volume(root)
+		if drive.RemotePath != "" {
+			mapped[strings.ToLower(drive.RemotePath)] = true
+		}
+		drives = append(drives, drive)
+	}
+	for _, share := range connectedShares() {
+		if mapped[strings.ToLower(share)] {
+			continue
+		}
+		drive := volume(share + `\`)
+		drive.Path = share
+		drive.RemotePath = share
+		drive.Type = Remote
+		drives = append(drives, drive)
+	}
+	return drives, nil
+}
+
+// volume - Errors are ignored, an empty card reader or disconnected share
+// is still listed without its details
+func volume(root string) Drive {
+	drive := Drive{Path: root, Type: Unknown}
+	rootPtr, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return drive
+	}
+	if driveType, ok := driveTypes[windows.GetDriveType(rootPtr)]; ok {
+		drive.Type = driveType
+	}
+
+	label := make([]uint16, maxPath)
+	fsType := make([]uint16, maxPath)
+	var serial, maxComponent, flags uint32
+	err = windows.GetVolumeInformation(rootPtr, &label[0], uint32(len(label)), &serial, &maxComponent, &flags, &fsType[0], uint32(len(fsType)))
+	if err == nil {
+		drive.Label = windows.UTF16ToString(label)
+		drive.FsType = windows.UTF16ToString(fsType)
+		drive.ReadOnly = flags&windows.FILE_READ_ONLY_VOLUME != 0
+	}
+	// {{if .Debug}}
+	if err != nil {
+		log.Printf("[drives] volume information of %s: %s", root, err)
+	}
+	// {{end}}
+
+	var free, total, totalFree uint64
+	if windows.GetDiskFreeSpaceEx(rootPtr, &free, &total, &totalFree) == nil {
+		drive.Total = total
+		drive.Free = free
+	}
+
+	volumeName := make([]uint16, maxPath)
+	if windows.GetVolumeNameForVolumeMountPoint(rootPtr, &volumeName[0], uint32(len(volumeName))) == nil {
+		drive.Device = windows.UTF16ToString(volumeName)
+	}
+
+	if drive.Type == Remote {
+		localName, _ := windows.UTF16PtrFromString(strings.TrimSuffix(root, `\`))
+		remoteName := make([]uint16, maxPath)
+		length := uint32(len(remoteName))
+		if syscalls.WNetGetConnection(localName, &remoteName[0], &length) == nil {
+			drive.RemotePath = windows.UTF16ToString(remoteName)
+		}
+	}
+	return drive
+}
+
+// connectedShares - UNC paths of the current user's disk connections
+func connectedShares() []string {
+	var handle windows.Handle
+	err := syscalls.WNetOpenEnum(syscalls.RESOURCE_CONNECTED, syscalls.RESOURCETYPE_DISK, 0, nil, &handle)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[drives] failed to enumerate connections: %s", err)
+		// {{end}}
+		return nil
+	}
+	defer syscalls.WNetCloseEnum(handle)
+
+	shares := []string{}
+	buf := make([]byte, enumBufferLen)
+	for {
+		count := ^uint32(0)
+		size := uint32(len(buf))
+		err = syscalls.WNetEnumResource(handle, &count, &buf[0], &size)
+		if err != nil {
+			break
+		}
+		resources := (*[enumBufferLen / unsafe.Sizeof(syscalls.NetResource{})]syscalls.NetResource)(unsafe.Pointer(&buf[0]))[:count:count]
+		for _, resource := range resources {
+			if resource.RemoteName != nil {
+				shares = append(shares, windows.UTF16PtrToString(resource.RemoteName))
+			}
+		}
+	}
+	return shares
+}
+
+func splitMultiString(buf []uint16) []string {
+	values := []string{}
+	start := 0
+	for index, char := range buf {
+		if char == 0 {
+			if start < index {
+				values = append(values, windows.UTF16ToString(buf[start:index]))
+			}
+			start = index + 1
+		}
+	}
+	return values
+}
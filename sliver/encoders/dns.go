@@ -0,0 +1,43 @@
+package encoders
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+)
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2019  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// The DNS C2 encodings, the server and implant must agree on these. Labels of a
+// name may only hold letters, digits and hyphens (underscores by convention), are
+// case insensitive, and can't hold padding. TXT records hold any printable string.
+const (
+	// DNSBase32Alphabet - Lower case, and without the letters that look like digits
+	DNSBase32Alphabet = "ab1c2d3e4f5g6h7j8k9m0npqrtuvwxyz"
+
+	// DNSIDCharSet - Nonces, session IDs and block IDs
+	DNSIDCharSet = "abcdefghijklmnopqrstuvwxyz0123456789-_"
+)
+
+var (
+	// DNSBase32 - Data sent in the labels of a name
+	DNSBase32 = base32.NewEncoding(DNSBase32Alphabet).WithPadding(base32.NoPadding)
+
+	// DNSBase64 - Data returned in TXT records
+	DNSBase64 = base64.RawStdEncoding
+)
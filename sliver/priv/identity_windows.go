@@ -0,0 +1,115 @@
+package priv
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/sliver/ps"
+	"github.com/bishopfox/sliver/sliver/syscalls"
+
+	"golang.org/x/sys/windows"
+)
+
+// Whoami - The identity of the token we're using, the impersonation token if
+// there is one otherwise the process token
+func Whoami() (*sliverpb.Whoami, error) {
+	token := CurrentToken
+	if token == 0 {
+		var err error
+		token, err = windows.OpenCurrentProcessToken()
+		if err != nil {
+			return nil, err
+		}
+		defer token.Close()
+	}
+
+	tokenUser, err := token.GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	username, err := tokenUsername(token)
+	if err != nil {
+		username = tokenUser.User.Sid.String()
+	}
+	whoami := &sliverpb.Whoami{
+		Username:       username,
+		UID:            tokenUser.User.Sid.String(),
+		Groups:         []string{},
+		Privileges:     tokenPrivileges(token),
+		IntegrityLevel: ps.IntegrityLevel(token),
+	}
+	if primaryGroup, err := token.GetTokenPrimaryGroup(); err == nil {
+		whoami.GID = primaryGroup.PrimaryGroup.String()
+	}
+	if groups, err := token.GetTokenGroups(); err == nil {
+		for _, group := range groups.AllGroups() {
+			// Skip disabled (deny only) groups and the logon session SID
+			if group.Attributes&windows.SE_GROUP_ENABLED == 0 || group.Attributes&windows.SE_GROUP_LOGON_ID == windows.SE_GROUP_LOGON_ID {
+				continue
+			}
+			whoami.Groups = append(whoami.Groups, sidName(group.Sid))
+		}
+	}
+	return whoami, nil
+}
+
+func sidName(sid *windows.SID) string {
+	account, domain, _, err := sid.LookupAccount("")
+	if err != nil {
+		return sid.String()
+	}
+	if domain == "" {
+		return account
+	}
+	return fmt.Sprintf("%s\\%s", domain, account)
+}
+
+func tokenPrivileges(token windows.Token) []*sliverpb.Privilege {
+	privileges := []*sliverpb.Privilege{}
+	n := uint32(256)
+	var buf []byte
+	for {
+		buf = make([]byte, n)
+		err := windows.GetTokenInformation(token, windows.TokenPrivileges, &buf[0], uint32(len(buf)), &n)
+		if err == nil {
+			break
+		}
+		if err != windows.ERROR_INSUFFICIENT_BUFFER || n <= uint32(len(buf)) {
+			return privileges
+		}
+	}
+	tokenPrivileges := (*windows.Tokenprivileges)(unsafe.Pointer(&buf[0]))
+	for _, privilege := range tokenPrivileges.AllPrivileges() {
+		name := make([]uint16, 64)
+		size := uint32(len(name))
+		err := syscalls.LookupPrivilegeName(nil, &privilege.Luid, &name[0], &size)
+		if err != nil {
+			continue
+		}
+		privileges = append(privileges, &sliverpb.Privilege{
+			Name:             windows.UTF16ToString(name[:size]),
+			Enabled:          privilege.Attributes&windows.SE_PRIVILEGE_ENABLED != 0,
+			EnabledByDefault: privilege.Attributes&windows.SE_PRIVILEGE_ENABLED_BY_DEFAULT != 0,
+		})
+	}
+	return privileges
+}
@@ -17,3 +17,30 @@ package priv
 	You should have received a copy of the GNU General Public License
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
+
+// RunAsOptions - A process to start as another user
+type RunAsOptions struct {
+	Username string
+	Domain   string
+	Password string
+	Process  string
+	Args     string
+
+	// Windows, only use the credentials for network access
+	NetOnly bool
+	// Windows, use CurrentToken instead of a process owned by Username
+	UseToken bool
+	// Unix, sudo or su
+	Method string
+
+	// Return as soon as the process is started
+	Background bool
+}
+
+// RunAsResult - The identity of the new process and, unless it runs in the
+// background, its output
+type RunAsResult struct {
+	Output   string
+	Pid      int
+	Username string
+}
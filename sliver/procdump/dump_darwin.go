@@ -28,7 +28,11 @@ func (d *DarwinDump) Data() []byte {
 	return d.data
 }
 
-func dumpProcess(pid int32) (ProcessDump, error) {
+func (d *DarwinDump) Truncated() bool {
+	return false
+}
+
+func dumpProcess(pid int32, maxSize int64) (ProcessDump, error) {
 	dump := &DarwinDump{}
 	return dump, nil
 }
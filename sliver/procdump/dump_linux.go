@@ -20,10 +20,27 @@ package procdump
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
 // LinuxDump - Structure implementing the ProcessDump
 // interface for linux processes
 type LinuxDump struct {
-	data []byte
+	data      []byte
+	truncated bool
 }
 
 // Data - Returns the byte array corresponding to a process memory
@@ -31,7 +48,121 @@ func (d *LinuxDump) Data() []byte {
 	return d.data
 }
 
-func dumpProcess(pid int32) (ProcessDump, error) {
-	res := &LinuxDump{}
-	return res, nil
+// Truncated - Returns true if we stopped reading regions at the size limit
+func (d *LinuxDump) Truncated() bool {
+	return d.truncated
+}
+
+type memoryRegion struct {
+	start uint64
+	end   uint64
+	perms string
+	path  string
+}
+
+// Name - Tar entry name for the region, the addresses are kept so the
+// regions can be mapped back to the original address space
+func (r *memoryRegion) Name() string {
+	name := fmt.Sprintf("%016x-%016x_%s", r.start, r.end, r.perms)
+	if r.path != "" {
+		name += "_" + strings.Trim(filepath.Base(r.path), "[]")
+	}
+	return name
+}
+
+// dumpProcess - Reads the readable regions listed in /proc/<pid>/maps out of
+// /proc/<pid>/mem and packs them into a tar archive, along with the maps file
+func dumpProcess(pid int32, maxSize int64) (ProcessDump, error) {
+	dump := &LinuxDump{}
+	maps, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return dump, err
+	}
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", pid))
+	if err != nil {
+		return dump, err
+	}
+	defer mem.Close()
+
+	buf := bytes.NewBuffer([]byte{})
+	archive := tar.NewWriter(buf)
+	err = writeTarEntry(archive, "maps", maps)
+	if err != nil {
+		return dump, err
+	}
+	size := int64(len(maps))
+	for _, region := range parseMaps(maps) {
+		length := int64(region.end - region.start)
+		if maxSize < size+length {
+			// Skip it, smaller regions further on may still fit
+			dump.truncated = true
+			continue
+		}
+		data := make([]byte, length)
+		n, err := mem.ReadAt(data, int64(region.start))
+		if n == 0 {
+			// Guard pages and device mappings often can't be read
+			// {{if .Debug}}
+			log.Printf("Skipping region %s: %v", region.Name(), err)
+			// {{end}}
+			continue
+		}
+		err = writeTarEntry(archive, region.Name(), data[:n])
+		if err != nil {
+			return dump, err
+		}
+		size += int64(n)
+	}
+	err = archive.Close()
+	if err != nil {
+		return dump, err
+	}
+	dump.data = buf.Bytes()
+	return dump, nil
+}
+
+func parseMaps(maps []byte) []*memoryRegion {
+	regions := []*memoryRegion{}
+	scanner := bufio.NewScanner(bytes.NewReader(maps))
+	for scanner.Scan() {
+		// address perms offset dev inode [path]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 || !strings.HasPrefix(fields[1], "r") {
+			continue
+		}
+		bounds := strings.SplitN(fields[0], "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		start, err := strconv.ParseUint(bounds[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseUint(bounds[1], 16, 64)
+		if err != nil || end <= start || (1<<63) <= end {
+			continue // [vsyscall] sits above what ReadAt can address
+		}
+		region := &memoryRegion{start: start, end: end, perms: fields[1]}
+		if 5 < len(fields) {
+			region.path = strings.Join(fields[5:], " ")
+		}
+		if region.path == "[vvar]" {
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+func writeTarEntry(archive *tar.Writer, name string, data []byte) error {
+	err := archive.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0400,
+		Size: int64(len(data)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = archive.Write(data)
+	return err
 }
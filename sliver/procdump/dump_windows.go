@@ -50,7 +50,12 @@ func (d *WindowsDump) Data() []byte {
 	return d.data
 }
 
-func dumpProcess(pid int32) (ProcessDump, error) {
+// Truncated - Minidumps cannot be partially read, so these are never truncated
+func (d *WindowsDump) Truncated() bool {
+	return false
+}
+
+func dumpProcess(pid int32, maxSize int64) (ProcessDump, error) {
 	res := &WindowsDump{}
 	if err := priv.SePrivEnable("SeDebugPrivilege"); err != nil {
 		return res, fmt.Errorf("Could not set SeDebugPrivilege on", pid)
@@ -61,12 +66,12 @@ func dumpProcess(pid int32) (ProcessDump, error) {
 		return res, err
 	}
 	if hProc != 0 {
-		return minidump(uint32(pid), hProc)
+		return minidump(uint32(pid), hProc, maxSize)
 	}
 	return res, fmt.Errorf("Could not dump process memory")
 }
 
-func minidump(pid uint32, proc windows.Handle) (ProcessDump, error) {
+func minidump(pid uint32, proc windows.Handle, maxSize int64) (ProcessDump, error) {
 	dump := &WindowsDump{}
 	// {{if eq .GOARCH "amd64"}}
 	// Hotfix for #66 - need to dig deeper
@@ -89,12 +94,19 @@ func minidump(pid uint32, proc windows.Handle) (ProcessDump, error) {
 		return dump, err
 	}
 
-	if err != nil {
-		return dump, err
-	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
 	stdOutHandle := f.Fd()
 	err = syscalls.MiniDumpWriteDump(proc, pid, stdOutHandle, 3, 0, 0, 0)
 	if err == nil {
+		stat, err := f.Stat()
+		if err != nil {
+			return dump, err
+		}
+		if maxSize < stat.Size() {
+			return dump, fmt.Errorf("dump is %d bytes, which exceeds the %d byte limit", stat.Size(), maxSize)
+		}
 		data, err := ioutil.ReadFile(f.Name())
 		dump.data = data
 		if err != nil {
@@ -103,7 +115,6 @@ func minidump(pid uint32, proc windows.Handle) (ProcessDump, error) {
 			//{{end}}
 			return dump, err
 		}
-		os.Remove(f.Name())
 	} else {
 		//{{if .Debug}}
 		log.Println("Minidump syscall failed:", err)
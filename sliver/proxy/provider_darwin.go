@@ -17,7 +17,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+
+	// {{if .Debug}}
 	"log"
+	// {{end}}
+
 	"net/url"
 	"regexp"
 	"strings"
@@ -137,6 +141,7 @@ Returns:
 func (p *providerDarwin) readDarwinNetworkSettingProxy(protocol string, targetUrl *url.URL) Proxy {
 	proxy, err := p.parseScutildata(protocol, targetUrl, scUtilBinary, scUtilBinaryArgument)
 	if err != nil {
+		// {{if .Debug}}
 		if isNotFound(err) {
 			log.Printf("[proxy.Provider.readDarwinNetworkSettingProxy]: %s proxy is not enabled.\n", protocol)
 		} else if isTimedOut(err) {
@@ -144,6 +149,7 @@ func (p *providerDarwin) readDarwinNetworkSettingProxy(protocol string, targetUr
 		} else {
 			log.Printf("[proxy.Provider.readDarwinNetworkSettingProxy]: Failed to parse Scutil data, %s\n", err)
 		}
+		// {{end}}
 	}
 	return proxy
 }
@@ -257,7 +263,9 @@ func (p *providerDarwin) parseScutildata(protocol string, targetUrl *url.URL, na
 	}
 	if proxyBypass != "" {
 		bypass := p.isProxyBypass(targetUrl, proxyBypass, ",")
+		// {{if .Debug}}
 		log.Printf("[proxy.Provider.parseProxyInfo]: ProxyBypass=\"%s\", targetUrl=%s, bypass=%t", proxyBypass, targetUrl, bypass)
+		// {{end}}
 		if bypass {
 			return nil, nil
 		}
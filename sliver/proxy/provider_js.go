@@ -0,0 +1,68 @@
+// Copyright 2018, Rapid7, Inc.
+// License: BSD-3-clause
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+// * Redistributions of source code must retain the above copyright notice,
+// this list of conditions and the following disclaimer.
+// * Redistributions in binary form must reproduce the above copyright
+// notice, this list of conditions and the following disclaimer in the
+// documentation and/or other materials provided with the distribution.
+// * Neither the name of the copyright holder nor the names of its contributors
+// may be used to endorse or promote products derived from this software
+// without specific prior written permission.
+package proxy
+
+/*
+The js/wasm runtime sends HTTP requests with the host's fetch() API, which
+applies the browser's (or edge runtime's) own proxy settings. There is no
+proxy for us to find, so every lookup returns nil.
+*/
+type providerJs struct {
+	provider
+}
+
+/*
+Create a new Provider which is used to retrieve Proxy configurations.
+Params:
+	configFile: Ignored, the host runtime handles proxies.
+*/
+func NewProvider(configFile string) Provider {
+	c := new(providerJs)
+	c.init(configFile)
+	return c
+}
+
+/*
+Always returns nil, see providerJs.
+*/
+func (p *providerJs) GetProxy(protocol string, targetUrlStr string) Proxy {
+	return nil
+}
+
+/*
+Always returns nil, see providerJs.
+*/
+func (p *providerJs) GetHTTPProxy(targetUrl string) Proxy {
+	return nil
+}
+
+/*
+Always returns nil, see providerJs.
+*/
+func (p *providerJs) GetHTTPSProxy(targetUrl string) Proxy {
+	return nil
+}
+
+/*
+Always returns nil, see providerJs.
+*/
+func (p *providerJs) GetFTPProxy(targetUrl string) Proxy {
+	return nil
+}
+
+/*
+Always returns nil, see providerJs.
+*/
+func (p *providerJs) GetSOCKSProxy(targetUrl string) Proxy {
+	return nil
+}
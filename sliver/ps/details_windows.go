@@ -0,0 +1,98 @@
+package ps
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"unsafe"
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+
+	"golang.org/x/sys/windows"
+)
+
+// IntegrityLevel - The integrity level of a token, e.g. "Medium"
+func IntegrityLevel(token windows.Token) string {
+	n := uint32(64)
+	for {
+		buf := make([]byte, n)
+		err := windows.GetTokenInformation(token, windows.TokenIntegrityLevel, &buf[0], uint32(len(buf)), &n)
+		if err == nil {
+			label := (*windows.Tokenmandatorylabel)(unsafe.Pointer(&buf[0]))
+			count := label.Label.Sid.SubAuthorityCount()
+			if count == 0 {
+				return ""
+			}
+			return integrityLevelName(label.Label.Sid.SubAuthority(uint32(count) - 1))
+		}
+		if err != windows.ERROR_INSUFFICIENT_BUFFER || n <= uint32(len(buf)) {
+			return ""
+		}
+	}
+}
+
+func integrityLevelName(rid uint32) string {
+	switch {
+	case rid < 0x1000:
+		return "Untrusted"
+	case rid < 0x2000:
+		return "Low"
+	case rid < 0x3000:
+		return "Medium"
+	case rid < 0x4000:
+		return "High"
+	default:
+		return "System"
+	}
+}
+
+// ProcessIntegrityLevel - Integrity level of a process' primary token, empty
+// if we can't open the process
+func ProcessIntegrityLevel(pid int) string {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(handle)
+	var token windows.Token
+	err = windows.OpenProcessToken(handle, windows.TOKEN_QUERY, &token)
+	if err != nil {
+		return ""
+	}
+	defer token.Close()
+	return IntegrityLevel(token)
+}
+
+// ProcessModules - Names of the modules (exe and DLLs) loaded by a process
+func ProcessModules(pid int) ([]string, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPMODULE|windows.TH32CS_SNAPMODULE32, uint32(pid))
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(snapshot)
+
+	modules := []string{}
+	var entry syscalls.ModuleEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	err = syscalls.Module32First(snapshot, &entry)
+	for err == nil {
+		modules = append(modules, windows.UTF16ToString(entry.Module[:]))
+		err = syscalls.Module32Next(snapshot, &entry)
+	}
+	return modules, nil
+}
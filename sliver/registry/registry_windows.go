@@ -0,0 +1,201 @@
+// +build windows
+
+package registry
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+var (
+	hives = map[string]registry.Key{
+		"HKCR": registry.CLASSES_ROOT,
+		"HKCU": registry.CURRENT_USER,
+		"HKLM": registry.LOCAL_MACHINE,
+		"HKU":  registry.USERS,
+		"HKCC": registry.CURRENT_CONFIG,
+
+		"HKEY_CLASSES_ROOT":   registry.CLASSES_ROOT,
+		"HKEY_CURRENT_USER":   registry.CURRENT_USER,
+		"HKEY_LOCAL_MACHINE":  registry.LOCAL_MACHINE,
+		"HKEY_USERS":          registry.USERS,
+		"HKEY_CURRENT_CONFIG": registry.CURRENT_CONFIG,
+	}
+
+	valueTypes = map[uint32]string{
+		registry.SZ:        "REG_SZ",
+		registry.EXPAND_SZ: "REG_EXPAND_SZ",
+		registry.MULTI_SZ:  "REG_MULTI_SZ",
+		registry.DWORD:     "REG_DWORD",
+		registry.QWORD:     "REG_QWORD",
+		registry.BINARY:    "REG_BINARY",
+	}
+
+	// ErrUnsupportedType - The value type can't be written
+	ErrUnsupportedType = errors.New("Unsupported registry value type")
+)
+
+func getHive(hive string) (registry.Key, error) {
+	root, ok := hives[strings.ToUpper(hive)]
+	if !ok {
+		return 0, fmt.Errorf("Unknown registry hive '%s'", hive)
+	}
+	return root, nil
+}
+
+func openKey(hive string, path string, access uint32) (registry.Key, error) {
+	root, err := getHive(hive)
+	if err != nil {
+		return 0, err
+	}
+	return registry.OpenKey(root, path, access)
+}
+
+// ReadValue - Read the value name of the key at path
+func ReadValue(hive string, path string, name string) (*sliverpb.RegistryValue, error) {
+	key, err := openKey(hive, path, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	return readValue(key, name)
+}
+
+func readValue(key registry.Key, name string) (*sliverpb.RegistryValue, error) {
+	size, valType, err := key.GetValue(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	value := &sliverpb.RegistryValue{Name: name, Type: valueTypes[valType]}
+	switch valType {
+	case registry.SZ, registry.EXPAND_SZ:
+		value.StringValue, _, err = key.GetStringValue(name)
+	case registry.MULTI_SZ:
+		value.StringValues, _, err = key.GetStringsValue(name)
+	case registry.DWORD, registry.QWORD:
+		value.IntValue, _, err = key.GetIntegerValue(name)
+	case registry.BINARY:
+		value.ByteValue, _, err = key.GetBinaryValue(name)
+	default:
+		// Return anything else as raw bytes
+		value.Type = fmt.Sprintf("%d", valType)
+		value.ByteValue = make([]byte, size)
+		_, _, err = key.GetValue(name, value.ByteValue)
+	}
+	return value, err
+}
+
+// WriteValue - Create or replace a value of the key at path
+func WriteValue(hive string, path string, value *sliverpb.RegistryValue) error {
+	key, err := openKey(hive, path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+	switch value.Type {
+	case "REG_SZ":
+		return key.SetStringValue(value.Name, value.StringValue)
+	case "REG_EXPAND_SZ":
+		return key.SetExpandStringValue(value.Name, value.StringValue)
+	case "REG_MULTI_SZ":
+		return key.SetStringsValue(value.Name, value.StringValues)
+	case "REG_DWORD":
+		return key.SetDWordValue(value.Name, uint32(value.IntValue))
+	case "REG_QWORD":
+		return key.SetQWordValue(value.Name, value.IntValue)
+	case "REG_BINARY":
+		return key.SetBinaryValue(value.Name, value.ByteValue)
+	}
+	return ErrUnsupportedType
+}
+
+// CreateKey - Create the key at path, succeeds if the key already exists
+func CreateKey(hive string, path string) error {
+	root, err := getHive(hive)
+	if err != nil {
+		return err
+	}
+	key, _, err := registry.CreateKey(root, path, registry.CREATE_SUB_KEY)
+	if err != nil {
+		return err
+	}
+	return key.Close()
+}
+
+// Delete - Delete the value name, or if name is empty the key at path,
+// which must not have any subkeys
+func Delete(hive string, path string, name string) error {
+	if name != "" {
+		key, err := openKey(hive, path, registry.SET_VALUE)
+		if err != nil {
+			return err
+		}
+		defer key.Close()
+		return key.DeleteValue(name)
+	}
+	path = strings.TrimRight(path, "\\")
+	parentPath := ""
+	keyName := path
+	if index := strings.LastIndex(path, "\\"); index != -1 {
+		parentPath = path[:index]
+		keyName = path[index+1:]
+	}
+	if keyName == "" {
+		return errors.New("Refusing to delete a hive")
+	}
+	parent, err := openKey(hive, parentPath, registry.ENUMERATE_SUB_KEYS|registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+	return registry.DeleteKey(parent, keyName)
+}
+
+// List - List the subkeys and values of the key at path
+func List(hive string, path string) ([]string, []*sliverpb.RegistryValue, error) {
+	key, err := openKey(hive, path, registry.ENUMERATE_SUB_KEYS|registry.QUERY_VALUE)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer key.Close()
+	subkeys, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := key.ReadValueNames(-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := []*sliverpb.RegistryValue{}
+	for _, name := range names {
+		value, err := readValue(key, name)
+		if err != nil {
+			continue
+		}
+		values = append(values, value)
+	}
+	return subkeys, values, nil
+}
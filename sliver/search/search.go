@@ -0,0 +1,201 @@
+package search
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
+const (
+	// DefaultMaxResults - Used when a search does not set a limit
+	DefaultMaxResults = 1000
+
+	maxMatchesPerFile = 100
+	maxLineLength     = 256
+	sniffLength       = 512
+)
+
+var (
+	errMaxResults = errors.New("max results")
+)
+
+// Options - What to search for, zero values disable a limit
+type Options struct {
+	NameGlob     string
+	ContentRegex string
+	IgnoreCase   bool
+	MaxDepth     int
+	MaxFileSize  int64
+	MaxResults   int
+}
+
+// Match - A line matching the content regex
+type Match struct {
+	Line uint32
+	Text string
+}
+
+// Result - A file matching the search
+type Result struct {
+	Path    string
+	Info    os.FileInfo
+	Matches []Match
+}
+
+// Search - Walk root and return the files matching the options, the search
+// stops once MaxResults is reached. Unreadable files are skipped and counted.
+func Search(root string, opts Options) ([]Result, bool, int, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	glob := opts.NameGlob
+	if opts.IgnoreCase {
+		glob = strings.ToLower(glob)
+	}
+	if _, err := filepath.Match(glob, ""); err != nil {
+		return nil, false, 0, err
+	}
+	var content *regexp.Regexp
+	if opts.ContentRegex != "" {
+		expr := opts.ContentRegex
+		if opts.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+		content, err = regexp.Compile(expr)
+		if err != nil {
+			return nil, false, 0, err
+		}
+	}
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultMaxResults
+	}
+
+	results := []Result{}
+	skipped := 0
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			skipped++
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		// Directories at the max depth are matched but not descended into
+		next := error(nil)
+		if info.IsDir() && 0 < opts.MaxDepth && opts.MaxDepth <= depth(root, path) {
+			next = filepath.SkipDir
+		}
+		if glob != "" {
+			name := info.Name()
+			if opts.IgnoreCase {
+				name = strings.ToLower(name)
+			}
+			if matched, _ := filepath.Match(glob, name); !matched {
+				return next
+			}
+		}
+		result := Result{Path: path, Info: info}
+		if content != nil {
+			if !info.Mode().IsRegular() || (0 < opts.MaxFileSize && opts.MaxFileSize < info.Size()) {
+				return next
+			}
+			result.Matches, err = grep(path, content)
+			if err != nil {
+				skipped++
+				return next
+			}
+			if len(result.Matches) == 0 {
+				return next
+			}
+		}
+		results = append(results, result)
+		if maxResults <= len(results) {
+			return errMaxResults
+		}
+		return next
+	})
+	if err == errMaxResults {
+		return results, true, skipped, nil
+	}
+	return results, false, skipped, err
+}
+
+// depth - Number of path elements between root and path
+func depth(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return len(strings.Split(rel, string(os.PathSeparator)))
+}
+
+// grep - Lines of a text file matching the regex, binary files have no matches
+func grep(path string, content *regexp.Regexp) ([]Match, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	head, err := reader.Peek(sniffLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		return []Match{}, nil
+	}
+
+	matches := []Match{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := uint32(0)
+	for scanner.Scan() && len(matches) < maxMatchesPerFile {
+		line++
+		if !content.Match(scanner.Bytes()) {
+			continue
+		}
+		text := scanner.Text()
+		if maxLineLength < len(text) {
+			text = text[:maxLineLength]
+		}
+		matches = append(matches, Match{Line: line, Text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		// {{if .Debug}}
+		log.Printf("[search] %s: %s", path, err)
+		// {{end}}
+	}
+	return matches, nil
+}
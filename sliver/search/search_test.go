@@ -0,0 +1,108 @@
+package search
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupSearchTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "search-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"a.txt":          "hello\npassword=secret\n",
+		"b.log":          "nothing here\n",
+		"sub/c.txt":      "PASSWORD=other\n",
+		"sub/deep/d.txt": "password=deep\n",
+		"bin.dat":        "password\x00binary",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func resultNames(root string, results []Result) map[string]Result {
+	names := map[string]Result{}
+	for _, result := range results {
+		rel, _ := filepath.Rel(root, result.Path)
+		names[filepath.ToSlash(rel)] = result
+	}
+	return names
+}
+
+func TestSearchName(t *testing.T) {
+	root := setupSearchTree(t)
+	defer os.RemoveAll(root)
+
+	results, truncated, _, err := Search(root, Options{NameGlob: "*.txt"})
+	if err != nil || truncated {
+		t.Fatalf("Unexpected error %v (truncated %v)", err, truncated)
+	}
+	names := resultNames(root, results)
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 results, got %v", names)
+	}
+
+	results, _, _, _ = Search(root, Options{NameGlob: "*.txt", MaxDepth: 2})
+	names = resultNames(root, results)
+	if _, ok := names["sub/deep/d.txt"]; ok || len(names) != 2 {
+		t.Fatalf("Max depth not applied %v", names)
+	}
+
+	results, truncated, _, _ = Search(root, Options{NameGlob: "*.txt", MaxResults: 1})
+	if len(results) != 1 || !truncated {
+		t.Fatalf("Max results not applied")
+	}
+}
+
+func TestSearchContent(t *testing.T) {
+	root := setupSearchTree(t)
+	defer os.RemoveAll(root)
+
+	results, _, _, err := Search(root, Options{ContentRegex: "password=", IgnoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := resultNames(root, results)
+	if len(names) != 3 {
+		t.Fatalf("Expected 3 results, got %v", names)
+	}
+	if _, ok := names["bin.dat"]; ok {
+		t.Fatalf("Binary file matched")
+	}
+	match := names["a.txt"].Matches
+	if len(match) != 1 || match[0].Line != 2 || match[0].Text != "password=secret" {
+		t.Fatalf("Unexpected matches %v", match)
+	}
+
+	results, _, _, _ = Search(root, Options{ContentRegex: "password=", MaxFileSize: 10})
+	if len(results) != 0 {
+		t.Fatalf("Max file size not applied")
+	}
+}
@@ -2,18 +2,42 @@ package service
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 )
 
+var (
+	serviceStates = map[svc.State]string{
+		svc.Stopped:         "Stopped",
+		svc.StartPending:    "Start Pending",
+		svc.StopPending:     "Stop Pending",
+		svc.Running:         "Running",
+		svc.ContinuePending: "Continue Pending",
+		svc.PausePending:    "Pause Pending",
+		svc.Paused:          "Paused",
+	}
+
+	startTypes = map[uint32]string{
+		windows.SERVICE_BOOT_START:   "Boot",
+		windows.SERVICE_SYSTEM_START: "System",
+		mgr.StartAutomatic:           "Automatic",
+		mgr.StartManual:              "Manual",
+		mgr.StartDisabled:            "Disabled",
+	}
+)
+
 func StartService(hostname string, binPath string, arguments string, serviceName string, serviceDesc string) error {
 	manager, err := mgr.ConnectRemote(hostname)
 	if err != nil {
 		return err
 	}
+	defer manager.Disconnect()
 
 	service, err := manager.CreateService(serviceName, binPath, mgr.Config{
 		ErrorControl:   mgr.ErrorNormal,
@@ -27,6 +51,7 @@ func StartService(hostname string, binPath string, arguments string, serviceName
 	if err != nil {
 		return err
 	}
+	defer service.Close()
 	err = service.Start()
 	if err != nil {
 		return err
@@ -39,10 +64,12 @@ func StopService(hostname string, serviceName string) error {
 	if err != nil {
 		return err
 	}
+	defer manager.Disconnect()
 	service, err := manager.OpenService(serviceName)
 	if err != nil {
 		return err
 	}
+	defer service.Close()
 	status, err := service.Control(svc.Stop)
 	if err != nil {
 		return err
@@ -68,10 +95,123 @@ func RemoveService(hostname string, serviceName string) error {
 	if err != nil {
 		return err
 	}
+	defer manager.Disconnect()
 	service, err := manager.OpenService(serviceName)
 	if err != nil {
 		return err
 	}
+	defer service.Close()
 	err = service.Delete()
 	return err
 }
+
+// ListServices - List the Win32 services of hostname, an empty hostname is the local machine
+func ListServices(hostname string) ([]*sliverpb.ServiceDetails, error) {
+	manager, err := mgr.ConnectRemote(hostname)
+	if err != nil {
+		return nil, err
+	}
+	defer manager.Disconnect()
+	names, err := manager.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	services := []*sliverpb.ServiceDetails{}
+	for _, name := range names {
+		details, err := queryService(manager, name)
+		if err != nil {
+			// Services we're not allowed to query are still listed
+			details = &sliverpb.ServiceDetails{Name: name}
+		}
+		services = append(services, details)
+	}
+	return services, nil
+}
+
+// QueryService - Get the configuration and status of a service
+func QueryService(hostname string, serviceName string) (*sliverpb.ServiceDetails, error) {
+	manager, err := mgr.ConnectRemote(hostname)
+	if err != nil {
+		return nil, err
+	}
+	defer manager.Disconnect()
+	return queryService(manager, serviceName)
+}
+
+func queryService(manager *mgr.Mgr, serviceName string) (*sliverpb.ServiceDetails, error) {
+	service, err := manager.OpenService(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	defer service.Close()
+	config, err := service.Config()
+	if err != nil {
+		return nil, err
+	}
+	status, err := service.Query()
+	if err != nil {
+		return nil, err
+	}
+	startType := startTypes[config.StartType]
+	if config.StartType == mgr.StartAutomatic && config.DelayedAutoStart {
+		startType = "Automatic (Delayed)"
+	}
+	return &sliverpb.ServiceDetails{
+		Name:        serviceName,
+		DisplayName: config.DisplayName,
+		Description: config.Description,
+		Status:      serviceStates[status.State],
+		StartType:   startType,
+		BinPath:     config.BinaryPathName,
+		Account:     config.ServiceStartName,
+		Pid:         status.ProcessId,
+	}, nil
+}
+
+// CreateService - Create a service without starting it, startType is one of
+// auto, manual (the default) or disabled
+func CreateService(hostname string, binPath string, serviceName string, serviceDesc string, startType string) error {
+	var start uint32
+	switch strings.ToLower(startType) {
+	case "auto", "automatic":
+		start = mgr.StartAutomatic
+	case "", "manual":
+		start = mgr.StartManual
+	case "disabled":
+		start = mgr.StartDisabled
+	default:
+		return fmt.Errorf("invalid start type '%s'", startType)
+	}
+	manager, err := mgr.ConnectRemote(hostname)
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+	service, err := manager.CreateService(serviceName, binPath, mgr.Config{
+		ErrorControl:   mgr.ErrorNormal,
+		BinaryPathName: binPath,
+		Description:    serviceDesc,
+		DisplayName:    serviceName,
+		ServiceType:    windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:      start,
+	})
+	if err != nil {
+		return err
+	}
+	return service.Close()
+}
+
+// StartExistingService - Start a service that is already installed
+func StartExistingService(hostname string, serviceName string) error {
+	manager, err := mgr.ConnectRemote(hostname)
+	if err != nil {
+		return err
+	}
+	defer manager.Disconnect()
+	service, err := manager.OpenService(serviceName)
+	if err != nil {
+		return err
+	}
+	defer service.Close()
+	return service.Start()
+}
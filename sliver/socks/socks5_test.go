@@ -0,0 +1,119 @@
+package socks
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestServeConnect(t *testing.T) {
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen %s", err)
+	}
+	defer echo.Close()
+	go func() {
+		conn, err := echo.Accept()
+		if err != nil {
+			return
+		}
+		io.Copy(conn, conn)
+		conn.Close()
+	}()
+
+	client, server := net.Pipe()
+	defer client.Close()
+	dialed := ""
+	go Serve(server, func(network string, address string) (net.Conn, error) {
+		dialed = address
+		return net.Dial(network, echo.Addr().String())
+	})
+
+	client.Write([]byte{socks5Version, 1, methodNoAuth})
+	method := make([]byte, 2)
+	io.ReadFull(client, method)
+	if !bytes.Equal(method, []byte{socks5Version, methodNoAuth}) {
+		t.Fatalf("Unexpected method selection %v", method)
+	}
+	request := []byte{socks5Version, cmdConnect, 0x00, atypDomain, byte(len("example.com"))}
+	request = append(request, []byte("example.com")...)
+	request = append(request, 0x01, 0xbb) // 443
+	client.Write(request)
+	reply := make([]byte, 10)
+	io.ReadFull(client, reply)
+	if reply[1] != replySucceeded {
+		t.Fatalf("Unexpected reply %v", reply)
+	}
+	if dialed != "example.com:443" {
+		t.Errorf("Dialed wrong address %s", dialed)
+	}
+
+	sample := []byte("hello world")
+	client.Write(sample)
+	recv := make([]byte, len(sample))
+	io.ReadFull(client, recv)
+	if !bytes.Equal(sample, recv) {
+		t.Errorf("Echo mismatch %v != %v", sample, recv)
+	}
+}
+
+func TestServeUnsupported(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- Serve(server, net.Dial)
+	}()
+
+	client.Write([]byte{socks5Version, 1, methodNoAuth})
+	method := make([]byte, 2)
+	io.ReadFull(client, method)
+	// The server replies before reading the whole request
+	go client.Write([]byte{socks5Version, 0x02, 0x00, atypIPv4, 127, 0, 0, 1, 0x00, 0x50}) // BIND
+	reply := make([]byte, 10)
+	io.ReadFull(client, reply)
+	if reply[1] != replyCmdNotSupported {
+		t.Errorf("Expected command not supported reply, got %v", reply)
+	}
+	if err := <-errs; err != ErrCommandNotSupported {
+		t.Errorf("Expected ErrCommandNotSupported, got %v", err)
+	}
+}
+
+func TestServeNoAcceptableMethod(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- Serve(server, net.Dial)
+	}()
+
+	client.Write([]byte{socks5Version, 1, 0x02}) // Username/password only
+	method := make([]byte, 2)
+	io.ReadFull(client, method)
+	if method[1] != methodNoAcceptable {
+		t.Errorf("Expected no acceptable methods, got %v", method)
+	}
+	if err := <-errs; err != ErrNoAcceptableMethod {
+		t.Errorf("Expected ErrNoAcceptableMethod, got %v", err)
+	}
+}
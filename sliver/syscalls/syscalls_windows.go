@@ -12,13 +12,28 @@ package syscalls
 //sys VirtualProtectEx(hProcess windows.Handle, lpAddress uintptr, dwSize uintptr, flNewProtect uint32, lpflOldProtect *uint32) (err error) = kernel32.VirtualProtectEx
 //sys QueueUserAPC(pfnAPC uintptr, hThread windows.Handle, dwData uintptr) (err error) = kernel32.QueueUserAPC
 //sys DeleteProcThreadAttributeList(lpAttributeList *PROC_THREAD_ATTRIBUTE_LIST) = kernel32.DeleteProcThreadAttributeList
+//sys Module32First(snapshot windows.Handle, moduleEntry *ModuleEntry32) (err error) = kernel32.Module32FirstW
+//sys Module32Next(snapshot windows.Handle, moduleEntry *ModuleEntry32) (err error) = kernel32.Module32NextW
 //sys HeapFree(hHeap windows.Handle, dwFlags uint32, lpMem uintptr) (err error) = kernel32.HeapFree
 //sys CreateRemoteThread(hProcess windows.Handle, lpThreadAttributes *windows.SecurityAttributes, dwStackSize uint32, lpStartAddress uintptr, lpParameter uintptr, dwCreationFlags uint32, lpThreadId *uint32)(threadHandle windows.Handle, err error) = kernel32.CreateRemoteThread
 //sys CreateThread(lpThreadAttributes *windows.SecurityAttributes, dwStackSize uint32, lpStartAddress uintptr, lpParameter uintptr, dwCreationFlags uint32, lpThreadId *uint32)(threadHandle windows.Handle, err error) = kernel32.CreateThread
 //sys GetExitCodeThread(hTread windows.Handle, lpExitCode *uint32) (err error) = kernel32.GetExitCodeThread
+//sys TerminateThread(hThread windows.Handle, exitCode uint32) (err error) = kernel32.TerminateThread
+//sys ReadProcessMemory(hProcess windows.Handle, lpBaseAddress uintptr, lpBuffer *byte, nSize uintptr, lpNumberOfBytesRead *uintptr) (err error) = kernel32.ReadProcessMemory
+//sys NtQueryInformationProcess(hProcess windows.Handle, infoClass uint32, info *ProcessBasicInformation, infoLen uint32, retLen *uint32) (status uint32) = ntdll.NtQueryInformationProcess
+//sys RtlAddFunctionTable(functionTable uintptr, entryCount uint32, baseAddress uintptr) (ok bool) = kernel32.RtlAddFunctionTable
+//sys NtQuerySystemInformation(infoClass uint32, info *byte, infoLen uint32, retLen *uint32) (status uint32) = ntdll.NtQuerySystemInformation
+//sys PssCaptureSnapshot(process windows.Handle, captureFlags uint32, threadContextFlags uint32, snapshot *windows.Handle) (ret error) = kernel32.PssCaptureSnapshot
+//sys PssFreeSnapshot(process windows.Handle, snapshot windows.Handle) (ret error) = kernel32.PssFreeSnapshot
 
 //sys MiniDumpWriteDump(hProcess windows.Handle, pid uint32, hFile uintptr, dumpType uint32, exceptionParam uintptr, userStreamParam uintptr, callbackParam uintptr) (err error) = DbgHelp.MiniDumpWriteDump
 //sys ImpersonateLoggedOnUser(hToken windows.Token) (err error) = advapi32.ImpersonateLoggedOnUser
+//sys LogonUser(username *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, outToken *windows.Token) (err error) = advapi32.LogonUserW
+//sys CreateProcessWithLogon(username *uint16, domain *uint16, password *uint16, logonFlags uint32, appName *uint16, commandLine *uint16, creationFlags uint32, env *uint16, currentDir *uint16, startupInfo *windows.StartupInfo, outProcInfo *windows.ProcessInformation) (err error) = advapi32.CreateProcessWithLogonW
+//sys LookupPrivilegeName(systemName *uint16, luid *windows.LUID, buffer *uint16, size *uint32) (err error) = advapi32.LookupPrivilegeNameW
+//sys ImpersonateNamedPipeClient(hNamedPipe windows.Handle) (err error) = advapi32.ImpersonateNamedPipeClient
+//sys CreateNamedPipe(name *uint16, openMode uint32, pipeMode uint32, maxInstances uint32, outBufferSize uint32, inBufferSize uint32, defaultTimeout uint32, sa *windows.SecurityAttributes) (handle windows.Handle, err error) [failretval==windows.InvalidHandle] = kernel32.CreateNamedPipeW
+//sys ConnectNamedPipe(hNamedPipe windows.Handle, overlapped *windows.Overlapped) (err error) = kernel32.ConnectNamedPipe
 
 //sys GetDC(HWND windows.Handle) (HDC windows.Handle, err error) = User32.GetDC
 //sys ReleaseDC(hWnd windows.Handle, hDC windows.Handle) (int uint32, err error) = User32.ReleaseDC
@@ -34,3 +49,87 @@ package syscalls
 //sys SelectObject(hdc windows.Handle, h windows.Handle) (HGDIOBJ windows.Handle, err error) = Gdi32.SelectObject
 //sys BitBlt(hdc windows.Handle, x uint32, y uint32, cx uint32, cy uint32, hdcSrc windows.Handle, x1 uint32, y1 uint32, rop int32) (BOOL int, err error) = Gdi32.BitBlt
 //sys GetDIBits(hdc windows.Handle, hbm windows.Handle, start uint32, cLines uint32, lpvBits uintptr, lpbmi uintptr, usage int) (ret int, err error) = Gdi32.GetDIBits
+
+//sys GetAsyncKeyState(vKey int32) (state int16) = User32.GetAsyncKeyState
+//sys GetKeyState(vKey int32) (state int16) = User32.GetKeyState
+//sys GetForegroundWindow() (hwnd windows.Handle) = User32.GetForegroundWindow
+//sys GetWindowText(hwnd windows.Handle, lpString *uint16, nMaxCount int32) (length int32) = User32.GetWindowTextW
+//sys MapVirtualKey(uCode uint32, uMapType uint32) (code uint32) = User32.MapVirtualKeyW
+//sys ToUnicode(wVirtKey uint32, wScanCode uint32, lpKeyState *byte, pwszBuff *uint16, cchBuff int32, wFlags uint32) (ret int32) = User32.ToUnicode
+
+//sys WNetGetConnection(localName *uint16, remoteName *uint16, length *uint32) (ret error) = mpr.WNetGetConnectionW
+//sys WNetOpenEnum(scope uint32, resourceType uint32, usage uint32, resource *NetResource, handle *windows.Handle) (ret error) = mpr.WNetOpenEnumW
+//sys WNetEnumResource(handle windows.Handle, count *uint32, buffer *byte, bufferSize *uint32) (ret error) = mpr.WNetEnumResourceW
+//sys WNetCloseEnum(handle windows.Handle) (ret error) = mpr.WNetCloseEnum
+
+//sys LsaConnectUntrusted(handle *windows.Handle) (status uint32) = secur32.LsaConnectUntrusted
+//sys LsaLookupAuthenticationPackage(handle windows.Handle, packageName *LsaString, authPackage *uint32) (status uint32) = secur32.LsaLookupAuthenticationPackage
+//sys LsaCallAuthenticationPackage(handle windows.Handle, authPackage uint32, submitBuffer unsafe.Pointer, submitBufferLength uint32, returnBuffer *uintptr, returnBufferLength *uint32, protocolStatus *uint32) (status uint32) = secur32.LsaCallAuthenticationPackage
+//sys LsaFreeReturnBuffer(buffer uintptr) (status uint32) = secur32.LsaFreeReturnBuffer
+//sys LsaDeregisterLogonProcess(handle windows.Handle) (status uint32) = secur32.LsaDeregisterLogonProcess
+//sys LsaNtStatusToWinError(status uint32) (code uint32) = advapi32.LsaNtStatusToWinError
+
+//sys LdapInit(hostName *uint16, portNumber uint32) (ld uintptr) = wldap32.ldap_initW
+//sys LdapSetOption(ld uintptr, option int32, inValue unsafe.Pointer) (ret uint32) = wldap32.ldap_set_optionW
+//sys LdapGetOption(ld uintptr, option int32, outValue unsafe.Pointer) (ret uint32) = wldap32.ldap_get_optionW
+//sys LdapConnect(ld uintptr, timeout *LdapTimeval) (ret uint32) = wldap32.ldap_connect
+//sys LdapBindS(ld uintptr, dn *uint16, cred *uint16, method uint32) (ret uint32) = wldap32.ldap_bind_sW
+//sys LdapUnbind(ld uintptr) (ret uint32) = wldap32.ldap_unbind
+//sys LdapSearchExtS(ld uintptr, base *uint16, scope uint32, filter *uint16, attrs **uint16, attrsOnly uint32, serverControls **LdapControl, clientControls **LdapControl, timeout *LdapTimeval, sizeLimit uint32, res *uintptr) (ret uint32) = wldap32.ldap_search_ext_sW
+//sys LdapCreatePageControl(ld uintptr, pageSize uint32, cookie *Berval, isCritical uint8, control **LdapControl) (ret uint32) = wldap32.ldap_create_page_controlW
+//sys LdapParseResult(ld uintptr, res uintptr, returnCode *uint32, matchedDNs **uint16, errorMessage **uint16, referrals uintptr, serverControls ***LdapControl, freeIt uint8) (ret uint32) = wldap32.ldap_parse_resultW
+//sys LdapParsePageControl(ld uintptr, serverControls **LdapControl, totalCount *uint32, cookie **Berval) (ret uint32) = wldap32.ldap_parse_page_controlW
+//sys LdapControlFree(control *LdapControl) (ret uint32) = wldap32.ldap_control_freeW
+//sys LdapControlsFree(controls **LdapControl) (ret uint32) = wldap32.ldap_controls_freeW
+//sys LdapFirstEntry(ld uintptr, res uintptr) (entry uintptr) = wldap32.ldap_first_entry
+//sys LdapNextEntry(ld uintptr, entry uintptr) (next uintptr) = wldap32.ldap_next_entry
+//sys LdapGetDN(ld uintptr, entry uintptr) (dn *uint16) = wldap32.ldap_get_dnW
+//sys LdapFirstAttribute(ld uintptr, entry uintptr, ber *uintptr) (attr *uint16) = wldap32.ldap_first_attributeW
+//sys LdapNextAttribute(ld uintptr, entry uintptr, ber uintptr) (attr *uint16) = wldap32.ldap_next_attributeW
+//sys LdapGetValuesLen(ld uintptr, entry uintptr, attr *uint16) (values **Berval) = wldap32.ldap_get_values_lenW
+//sys LdapCountValuesLen(values **Berval) (count uint32) = wldap32.ldap_count_values_len
+//sys LdapValueFreeLen(values **Berval) (ret uint32) = wldap32.ldap_value_free_len
+//sys LdapMemFree(block *uint16) = wldap32.ldap_memfreeW
+//sys LdapMsgFree(res uintptr) (ret uint32) = wldap32.ldap_msgfree
+//sys BerFree(ber uintptr, freeBuf int32) = wldap32.ber_free
+//sys BerBvFree(bv *Berval) = wldap32.ber_bvfree
+//sys LdapErr2String(err uint32) (str *uint16) = wldap32.ldap_err2stringW
+
+//sys GetIpNetTable2(family uint16, table **MibIpNetTable2) (ret error) = iphlpapi.GetIpNetTable2
+//sys FreeMibTable(memory unsafe.Pointer) = iphlpapi.FreeMibTable
+
+//sys GetTickCount64() (ticks uint64) = kernel32.GetTickCount64
+//sys GetUserDefaultLocaleName(localeName *uint16, length int32) (n int32) = kernel32.GetUserDefaultLocaleName
+
+//sys OpenClipboard(hwnd windows.Handle) (err error) = User32.OpenClipboard
+//sys CloseClipboard() (err error) = User32.CloseClipboard
+//sys GetClipboardData(format uint32) (handle windows.Handle, err error) = User32.GetClipboardData
+//sys GetClipboardSequenceNumber() (seq uint32) = User32.GetClipboardSequenceNumber
+
+//sys WlanOpenHandle(clientVersion uint32, reserved uintptr, negotiatedVersion *uint32, clientHandle *windows.Handle) (ret error) = wlanapi.WlanOpenHandle
+//sys WlanCloseHandle(clientHandle windows.Handle, reserved uintptr) (ret error) = wlanapi.WlanCloseHandle
+//sys WlanEnumInterfaces(clientHandle windows.Handle, reserved uintptr, interfaceList **WlanInterfaceInfoList) (ret error) = wlanapi.WlanEnumInterfaces
+//sys WlanGetProfileList(clientHandle windows.Handle, interfaceGUID *windows.GUID, reserved uintptr, profileList **WlanProfileInfoList) (ret error) = wlanapi.WlanGetProfileList
+//sys WlanGetProfile(clientHandle windows.Handle, interfaceGUID *windows.GUID, profileName *uint16, reserved uintptr, profileXML **uint16, flags *uint32, grantedAccess *uint32) (ret error) = wlanapi.WlanGetProfile
+//sys WlanFreeMemory(memory uintptr) = wlanapi.WlanFreeMemory
+
+//sys RegCreateKeyEx(key windows.Handle, subkey *uint16, reserved uint32, class *uint16, options uint32, desiredAccess uint32, sa *windows.SecurityAttributes, result *windows.Handle, disposition *uint32) (regerrno error) = advapi32.RegCreateKeyExW
+//sys RegSaveKeyEx(key windows.Handle, file *uint16, sa *windows.SecurityAttributes, flags uint32) (regerrno error) = advapi32.RegSaveKeyExW
+//sys CryptUnprotectData(dataIn *DataBlob, name **uint16, optionalEntropy *DataBlob, reserved uintptr, promptStruct uintptr, flags uint32, dataOut *DataBlob) (err error) = crypt32.CryptUnprotectData
+
+//sys CoInitializeEx(reserved uintptr, coInit uint32) (ret error) = ole32.CoInitializeEx
+//sys CoUninitialize() = ole32.CoUninitialize
+//sys CoInitializeSecurity(secDesc uintptr, authSvcCount int32, authSvc uintptr, reserved1 uintptr, authnLevel uint32, impLevel uint32, authList uintptr, capabilities uint32, reserved3 uintptr) (ret error) = ole32.CoInitializeSecurity
+//sys CoCreateInstance(clsid *windows.GUID, outer uintptr, clsContext uint32, iid *windows.GUID, object unsafe.Pointer) (ret error) = ole32.CoCreateInstance
+//sys CoSetProxyBlanket(proxy unsafe.Pointer, authnSvc uint32, authzSvc uint32, serverPrincName *uint16, authnLevel uint32, impLevel uint32, authInfo *COAuthIdentity, capabilities uint32) (ret error) = ole32.CoSetProxyBlanket
+//sys SysAllocString(str *uint16) (bstr *uint16) = oleaut32.SysAllocString
+//sys SysFreeString(bstr *uint16) = oleaut32.SysFreeString
+//sys VariantClear(variant *Variant) (ret error) = oleaut32.VariantClear
+//sys VariantChangeType(dest *Variant, src *Variant, flags uint16, vt uint16) (ret error) = oleaut32.VariantChangeType
+//sys SafeArrayGetLBound(array uintptr, dim uint32, bound *int32) (ret error) = oleaut32.SafeArrayGetLBound
+//sys SafeArrayGetUBound(array uintptr, dim uint32, bound *int32) (ret error) = oleaut32.SafeArrayGetUBound
+//sys SafeArrayGetElement(array uintptr, index *int32, value unsafe.Pointer) (ret error) = oleaut32.SafeArrayGetElement
+//sys SafeArrayCreateVector(vt uint16, lowerBound int32, elements uint32) (array uintptr) = oleaut32.SafeArrayCreateVector
+//sys SafeArrayPutElement(array uintptr, index *int32, value unsafe.Pointer) (ret error) = oleaut32.SafeArrayPutElement
+//sys SafeArrayDestroy(array uintptr) (ret error) = oleaut32.SafeArrayDestroy
+//sys CLRCreateInstance(clsid *windows.GUID, iid *windows.GUID, object unsafe.Pointer) (ret error) = mscoree.CLRCreateInstance
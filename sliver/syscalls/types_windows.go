@@ -102,4 +102,233 @@ const (
 	GMEM_MOVEABLE = 0x0002
 	GMEM_ZEROINIT = 0x0040
 	GPTR          = GMEM_FIXED | GMEM_ZEROINIT
-)
\ No newline at end of file
+)
+
+// ModuleEntry32 - MODULEENTRY32W
+type ModuleEntry32 struct {
+	Size         uint32
+	ModuleID     uint32
+	ProcessID    uint32
+	GlblcntUsage uint32
+	ProccntUsage uint32
+	ModBaseAddr  uintptr
+	ModBaseSize  uint32
+	ModuleHandle windows.Handle
+	Module       [256]uint16
+	ExePath      [260]uint16
+}
+
+// Variant - VARIANT, the value is a union of up to 8 bytes (16 on 64-bit
+// for records, which we never read)
+type Variant struct {
+	VT        uint16
+	Reserved1 uint16
+	Reserved2 uint16
+	Reserved3 uint16
+	Val       int64
+	_         uintptr
+}
+
+// COAuthIdentity - COAUTHIDENTITY
+type COAuthIdentity struct {
+	User           *uint16
+	UserLength     uint32
+	Domain         *uint16
+	DomainLength   uint32
+	Password       *uint16
+	PasswordLength uint32
+	Flags          uint32
+}
+
+// ProcessBasicInformation - PROCESS_BASIC_INFORMATION
+type ProcessBasicInformation struct {
+	ExitStatus                   uintptr
+	PebBaseAddress               uintptr
+	AffinityMask                 uintptr
+	BasePriority                 uintptr
+	UniqueProcessID              uintptr
+	InheritedFromUniqueProcessID uintptr
+}
+
+// WlanGetProfile flags
+const (
+	WLAN_PROFILE_GET_PLAINTEXT_KEY = 0x00000004
+)
+
+// WlanInterfaceInfo - WLAN_INTERFACE_INFO
+type WlanInterfaceInfo struct {
+	InterfaceGUID windows.GUID
+	Description   [256]uint16
+	State         uint32
+}
+
+// WlanInterfaceInfoList - WLAN_INTERFACE_INFO_LIST, the interfaces follow the header
+type WlanInterfaceInfoList struct {
+	NumberOfItems uint32
+	Index         uint32
+}
+
+// WlanProfileInfo - WLAN_PROFILE_INFO
+type WlanProfileInfo struct {
+	ProfileName [256]uint16
+	Flags       uint32
+}
+
+// WlanProfileInfoList - WLAN_PROFILE_INFO_LIST, the profiles follow the header
+type WlanProfileInfoList struct {
+	NumberOfItems uint32
+	Index         uint32
+}
+
+// DataBlob - DATA_BLOB
+type DataBlob struct {
+	Size uint32
+	Data *byte
+}
+
+// SystemExtendedHandleInformation - NtQuerySystemInformation class
+const (
+	SystemExtendedHandleInformation = 64
+	STATUS_INFO_LENGTH_MISMATCH     = 0xC0000004
+)
+
+// SystemHandleTableEntryInfoEx - SYSTEM_HANDLE_TABLE_ENTRY_INFO_EX, the entries
+// follow a header of two ULONG_PTRs (NumberOfHandles, Reserved)
+type SystemHandleTableEntryInfoEx struct {
+	Object                uintptr
+	UniqueProcessID       uintptr
+	HandleValue           uintptr
+	GrantedAccess         uint32
+	CreatorBackTraceIndex uint16
+	ObjectTypeIndex       uint16
+	HandleAttributes      uint32
+	Reserved              uint32
+}
+
+// PssCaptureSnapshot flags
+const (
+	PSS_CAPTURE_VA_CLONE = 0x00000001
+	PSS_CAPTURE_THREADS  = 0x00000080
+)
+
+// MiniDumpWriteDump types and callbacks
+const (
+	MiniDumpWithFullMemory = 0x00000002
+
+	IoStartCallback           = 11
+	IoWriteAllCallback        = 12
+	IoFinishCallback          = 13
+	IsProcessSnapshotCallback = 16
+)
+
+// MinidumpCallbackInformation - MINIDUMP_CALLBACK_INFORMATION
+type MinidumpCallbackInformation struct {
+	CallbackRoutine uintptr
+	CallbackParam   uintptr
+}
+
+// Registry options
+const (
+	REG_OPTION_BACKUP_RESTORE = 0x00000004
+	REG_LATEST_FORMAT         = 2
+)
+
+// NetResource - NETRESOURCEW
+type NetResource struct {
+	Scope       uint32
+	Type        uint32
+	DisplayType uint32
+	Usage       uint32
+	LocalName   *uint16
+	RemoteName  *uint16
+	Comment     *uint16
+	Provider    *uint16
+}
+
+// WNetOpenEnum scopes and types
+const (
+	RESOURCE_CONNECTED = 0x00000001
+	RESOURCETYPE_DISK  = 0x00000001
+)
+
+// MibIpNetRow2 - MIB_IPNET_ROW2, Address is a SOCKADDR_INET
+type MibIpNetRow2 struct {
+	Address               [28]byte
+	InterfaceIndex        uint32
+	InterfaceLuid         uint64
+	PhysicalAddress       [32]byte
+	PhysicalAddressLength uint32
+	State                 uint32
+	Flags                 uint8
+	ReachabilityTime      uint32
+}
+
+// MibIpNetTable2 - MIB_IPNET_TABLE2, the explicit padding keeps the rows
+// 8 byte aligned on 32-bit too
+type MibIpNetTable2 struct {
+	NumEntries uint32
+	_          uint32
+	Table      [1]MibIpNetRow2
+}
+
+// NL_NEIGHBOR_STATE values
+const (
+	NlnsUnreachable = 0
+	NlnsIncomplete  = 1
+	NlnsProbe       = 2
+	NlnsDelay       = 3
+	NlnsStale       = 4
+	NlnsReachable   = 5
+	NlnsPermanent   = 6
+)
+
+// LsaString - LSA_STRING (ANSI)
+type LsaString struct {
+	Length        uint16
+	MaximumLength uint16
+	Buffer        *byte
+}
+
+// UnicodeString - UNICODE_STRING
+type UnicodeString struct {
+	Length        uint16
+	MaximumLength uint16
+	Buffer        *uint16
+}
+
+// Berval - berval, a length prefixed (binary) value
+type Berval struct {
+	Len uint32
+	Val *byte
+}
+
+// LdapControl - LDAPControlW
+type LdapControl struct {
+	Oid        *uint16
+	Value      Berval
+	IsCritical uint8
+}
+
+// LdapTimeval - l_timeval
+type LdapTimeval struct {
+	Sec  int32
+	Usec int32
+}
+
+// wldap32 options and values
+const (
+	LDAP_OPT_REFERRALS        = 0x08
+	LDAP_OPT_PROTOCOL_VERSION = 0x11
+	LDAP_OPT_HOST_NAME        = 0x30
+	LDAP_OPT_SIGN             = 0x95
+	LDAP_OPT_ENCRYPT          = 0x96
+
+	LDAP_VERSION3           = 3
+	LDAP_AUTH_NEGOTIATE     = 0x0486
+	LDAP_SUCCESS            = 0x00
+	LDAP_SIZELIMIT_EXCEEDED = 0x04
+
+	LDAP_SCOPE_BASE     = 0
+	LDAP_SCOPE_ONELEVEL = 1
+	LDAP_SCOPE_SUBTREE  = 2
+)
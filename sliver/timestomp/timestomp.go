@@ -0,0 +1,59 @@
+package timestomp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"time"
+)
+
+// Times - The timestamps of a file, Created is only set on platforms that
+// track a file's creation (birth) time
+type Times struct {
+	Accessed time.Time
+	Modified time.Time
+	Created  time.Time
+}
+
+// Stat - Get the timestamps of a file
+func Stat(path string) (*Times, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return fileTimes(info), nil
+}
+
+// Set - Set the timestamps of a file, zero times are left unchanged
+func Set(path string, times *Times) error {
+	current, err := Stat(path)
+	if err != nil {
+		return err
+	}
+	if times.Accessed.IsZero() {
+		times.Accessed = current.Accessed
+	}
+	if times.Modified.IsZero() {
+		times.Modified = current.Modified
+	}
+	if times.Created.IsZero() {
+		times.Created = current.Created
+	}
+	return setTimes(path, times)
+}
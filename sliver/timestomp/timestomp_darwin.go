@@ -0,0 +1,41 @@
+// +build darwin
+
+package timestomp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func fileTimes(info os.FileInfo) *Times {
+	times := &Times{Modified: info.ModTime(), Accessed: info.ModTime()}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		times.Accessed = time.Unix(stat.Atimespec.Sec, stat.Atimespec.Nsec)
+		times.Created = time.Unix(stat.Birthtimespec.Sec, stat.Birthtimespec.Nsec)
+	}
+	return times
+}
+
+// The birth time can't be set directly, only the access and modify times
+func setTimes(path string, times *Times) error {
+	return os.Chtimes(path, times.Accessed, times.Modified)
+}
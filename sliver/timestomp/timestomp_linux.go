@@ -0,0 +1,41 @@
+// +build linux
+
+package timestomp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func fileTimes(info os.FileInfo) *Times {
+	times := &Times{Modified: info.ModTime(), Accessed: info.ModTime()}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		times.Accessed = time.Unix(int64(stat.Atim.Sec), int64(stat.Atim.Nsec))
+	}
+	return times
+}
+
+// Linux doesn't expose a way to set a file's change or birth time, so only
+// the access and modify times can be set
+func setTimes(path string, times *Times) error {
+	return os.Chtimes(path, times.Accessed, times.Modified)
+}
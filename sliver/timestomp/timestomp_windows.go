@@ -0,0 +1,58 @@
+// +build windows
+
+package timestomp
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func fileTimes(info os.FileInfo) *Times {
+	times := &Times{Modified: info.ModTime(), Accessed: info.ModTime()}
+	if data, ok := info.Sys().(*syscall.Win32FileAttributeData); ok {
+		times.Accessed = time.Unix(0, data.LastAccessTime.Nanoseconds())
+		times.Created = time.Unix(0, data.CreationTime.Nanoseconds())
+	}
+	return times
+}
+
+// setTimes - Unlike os.Chtimes this also sets the creation time, backup
+// semantics are needed to open a handle to a directory
+func setTimes(path string, times *Times) error {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateFile(pathPtr, windows.FILE_WRITE_ATTRIBUTES,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+	created := windows.NsecToFiletime(times.Created.UnixNano())
+	accessed := windows.NsecToFiletime(times.Accessed.UnixNano())
+	modified := windows.NsecToFiletime(times.Modified.UnixNano())
+	return windows.SetFileTime(handle, &created, &accessed, &modified)
+}
@@ -0,0 +1,88 @@
+package transports
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// {{if or .HTTPc2Enabled .DNSc2Enabled}}
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+
+	pb "github.com/bishopfox/sliver/protobuf/sliverpb"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	compressionDeflate = "deflate"
+
+	// Smaller payloads don't shrink enough to be worth the trouble
+	compressionThreshold = 1024
+)
+
+var (
+	// Offered at session init, preferred first
+	supportedCompression = []string{compressionDeflate}
+)
+
+// parseSessionInitResp - Servers that predate compression reply with a bare
+// session ID, which never contains the tag byte of the ID field
+func parseSessionInitResp(data []byte) *pb.SessionInitResp {
+	resp := &pb.SessionInitResp{}
+	err := proto.Unmarshal(data, resp)
+	if err != nil || resp.ID == "" {
+		return &pb.SessionInitResp{ID: string(data)}
+	}
+	return resp
+}
+
+// compressEnvelope - Compress the payload in place if it's worth it
+func compressEnvelope(compression string, envelope *pb.Envelope) {
+	if compression != compressionDeflate || len(envelope.Data) < compressionThreshold {
+		return
+	}
+	buf := &bytes.Buffer{}
+	writer, _ := flate.NewWriter(buf, flate.BestSpeed)
+	writer.Write(envelope.Data)
+	writer.Close()
+	if len(envelope.Data) <= buf.Len() {
+		return
+	}
+	envelope.Data = buf.Bytes()
+	envelope.Compressed = true
+}
+
+// decompressEnvelope - Inflate the payload of an envelope the server compressed
+func decompressEnvelope(envelope *pb.Envelope) error {
+	if !envelope.Compressed {
+		return nil
+	}
+	reader := flate.NewReader(bytes.NewReader(envelope.Data))
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	envelope.Data = data
+	envelope.Compressed = false
+	return nil
+}
+
+// {{end}} -HTTPc2Enabled || DNSc2Enabled
@@ -0,0 +1,36 @@
+package transports
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"runtime"
+)
+
+// decoyServers - Canary domains dressed up as fallback servers, the implant never
+// contacts them so a lookup means someone pulled them out of the binary
+var decoyServers = []string{
+	// {{range $index, $value := .CanaryDecoys}}
+	"{{$value}}", // {{$index}}
+	// {{end}}
+}
+
+func init() {
+	// Unreferenced data is dropped by the linker
+	runtime.KeepAlive(decoyServers)
+}
@@ -0,0 +1,160 @@
+package transports
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	pb "github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+const (
+	// Writes are broken into chunks of this size so a capped transfer is
+	// spread out over time rather than sent in a single burst
+	throttleChunkSize = 4096
+)
+
+var (
+	sessionLimiter = newLimiter(0)
+)
+
+// limiter - A token bucket that allows up to rate bytes per second
+type limiter struct {
+	mutex  *sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate int64) *limiter {
+	return &limiter{
+		mutex: &sync.Mutex{},
+		rate:  rate,
+		last:  time.Now(),
+	}
+}
+
+// Wait - Blocks until n bytes can be sent without exceeding the rate,
+// a rate of zero (or less) never blocks
+func (l *limiter) Wait(n int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.rate <= 0 {
+		return
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if float64(l.rate) < l.tokens {
+		l.tokens = float64(l.rate) // Burst at most one second worth of data
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens < 0 {
+		delay := time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+		time.Sleep(delay)
+		l.last = l.last.Add(delay)
+		l.tokens = 0
+	}
+}
+
+// SetRate - Change the rate of the limiter
+func (l *limiter) SetRate(rate int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.rate = rate
+	l.tokens = 0
+	l.last = time.Now()
+}
+
+// Rate - The current rate of the limiter
+func (l *limiter) Rate() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.rate
+}
+
+// SetBandwidthLimit - Cap the session throughput in bytes per second, zero removes the cap
+func SetBandwidthLimit(rate int64) {
+	// {{if .Debug}}
+	log.Printf("[limiter] session bandwidth limit %d bytes/sec", rate)
+	// {{end}}
+	sessionLimiter.SetRate(rate)
+}
+
+// GetBandwidthLimit - The session throughput cap in bytes per second
+func GetBandwidthLimit() int64 {
+	return sessionLimiter.Rate()
+}
+
+// envelopeLimiters - The session limiter plus a limiter for the envelope's own cap, if any
+func envelopeLimiters(envelope *pb.Envelope) []*limiter {
+	limiters := []*limiter{sessionLimiter}
+	if 0 < envelope.BandwidthLimit {
+		limiters = append(limiters, newLimiter(envelope.BandwidthLimit))
+	}
+	return limiters
+}
+
+// throttledWriter - Writes in chunks, waiting on each limiter before every chunk
+type throttledWriter struct {
+	writer   io.Writer
+	limiters []*limiter
+}
+
+func (t *throttledWriter) Write(data []byte) (int, error) {
+	written := 0
+	for written < len(data) {
+		stop := written + throttleChunkSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		for _, l := range t.limiters {
+			l.Wait(stop - written)
+		}
+		n, err := t.writer.Write(data[written:stop])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// throttledReader - Reads in chunks, waiting on each limiter before every chunk
+type throttledReader struct {
+	reader   io.Reader
+	limiters []*limiter
+}
+
+func (t *throttledReader) Read(data []byte) (int, error) {
+	if throttleChunkSize < len(data) {
+		data = data[:throttleChunkSize]
+	}
+	n, err := t.reader.Read(data)
+	for _, l := range t.limiters {
+		l.Wait(n)
+	}
+	return n, err
+}
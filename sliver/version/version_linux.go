@@ -2,7 +2,11 @@ package version
 
 import (
 	"fmt"
+
+	// {{if .Debug}}
 	"log"
+	// {{end}}
+
 	"strings"
 	"syscall"
 )
@@ -23,7 +27,10 @@ func getString(input [65]int8) string {
 func GetVersion() string {
 	var uname syscall.Utsname
 	if err := syscall.Uname(&uname); err != nil {
-		log.Fatal(err)
+		// {{if .Debug}}
+		log.Printf("uname failed %s", err)
+		// {{end}}
+		return ""
 	}
 	return fmt.Sprintf("%s %s %s", getString(uname.Sysname), getString(uname.Nodename), getString(uname.Release))
 }
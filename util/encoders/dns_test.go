@@ -0,0 +1,128 @@
+package encoders
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	implantEncoders "github.com/bishopfox/sliver/sliver/encoders"
+
+	"github.com/miekg/dns"
+)
+
+/*
+	Sliver Implant Framework
+	Copyright (C) 2019  Bishop Fox
+
+	This program is free software: you can redistribute it and/or modify
+	it under the terms of the GNU General Public License as published by
+	the Free Software Foundation, either version 3 of the License, or
+	(at your option) any later version.
+
+	This program is distributed in the hope that it will be useful,
+	but WITHOUT ANY WARRANTY; without even the implied warranty of
+	MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+	GNU General Public License for more details.
+
+	You should have received a copy of the GNU General Public License
+	along with this program.  If not, see <https://www.gnu.org/licenses/>.
+*/
+
+func TestDNSEncodingsMatchImplant(t *testing.T) {
+	if DNSBase32Alphabet != implantEncoders.DNSBase32Alphabet || DNSIDCharSet != implantEncoders.DNSIDCharSet {
+		t.Fatalf("Server and implant DNS alphabets differ")
+	}
+	sample := randomData()
+	if DNSBase32.EncodeToString(sample) != implantEncoders.DNSBase32.EncodeToString(sample) {
+		t.Errorf("Server and implant base32 differ")
+	}
+	if DNSBase64.EncodeToString(sample) != implantEncoders.DNSBase64.EncodeToString(sample) {
+		t.Errorf("Server and implant base64 differ")
+	}
+}
+
+// Data sent by the implant, up to 117 bytes in labels of at most 63 characters
+// under a parent domain
+func TestDNSBase32Names(t *testing.T) {
+	for size := 0; size <= 117; size++ {
+		sample := make([]byte, size)
+		rand.Read(sample)
+		encoded := DNSBase32.EncodeToString(sample)
+		if strings.ContainsRune(encoded, '=') {
+			t.Fatalf("Encoding of %d bytes is padded: %s", size, encoded)
+		}
+		for _, char := range encoded {
+			if !strings.ContainsRune(DNSIDCharSet, char) || char == '-' || char == '_' {
+				t.Fatalf("Encoding of %d bytes has %q, which isn't a letter or digit", size, char)
+			}
+		}
+
+		labels := []string{}
+		for start := 0; start < len(encoded); start += 63 {
+			stop := start + 63
+			if len(encoded) < stop {
+				stop = len(encoded)
+			}
+			labels = append(labels, encoded[start:stop])
+		}
+		name := dns.Fqdn(strings.Join(append(labels, "example", "com"), "."))
+		if _, ok := dns.IsDomainName(name); !ok {
+			t.Fatalf("Encoding of %d bytes is not a valid name: %s", size, name)
+		}
+
+		// Through the wire format, with the case changed like resolvers using 0x20 do
+		req := new(dns.Msg)
+		req.SetQuestion(strings.ToUpper(name), dns.TypeTXT)
+		wire, err := req.Pack()
+		if err != nil {
+			t.Fatalf("Failed to pack %s (%v)", name, err)
+		}
+		received := new(dns.Msg)
+		if err := received.Unpack(wire); err != nil {
+			t.Fatal(err)
+		}
+		receivedLabels := dns.SplitDomainName(strings.ToLower(received.Question[0].Name))
+		data, err := DNSBase32.DecodeString(strings.Join(receivedLabels[:len(labels)], ""))
+		if err != nil || !bytes.Equal(sample, data) {
+			t.Fatalf("Round trip of %d bytes failed (%v)", size, err)
+		}
+	}
+}
+
+// Data sent by the server, in TXT strings of at most 255 characters
+func TestDNSBase64TXT(t *testing.T) {
+	sample := make([]byte, 4096)
+	rand.Read(sample)
+	encoded := DNSBase64.EncodeToString(sample)
+	if strings.ContainsRune(encoded, '=') {
+		t.Fatalf("TXT encoding is padded")
+	}
+	txts := []string{}
+	for start := 0; start < len(encoded); start += 248 {
+		stop := start + 248
+		if len(encoded) < stop {
+			stop = len(encoded)
+		}
+		txts = append(txts, encoded[start:stop])
+	}
+
+	resp := new(dns.Msg)
+	resp.SetQuestion("example.com.", dns.TypeTXT)
+	resp.Answer = append(resp.Answer, &dns.TXT{
+		Hdr: dns.RR_Header{Name: "example.com.", Rrtype: dns.TypeTXT, Class: dns.ClassINET},
+		Txt: txts,
+	})
+	wire, err := resp.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := new(dns.Msg)
+	if err := received.Unpack(wire); err != nil {
+		t.Fatal(err)
+	}
+	data, err := DNSBase64.DecodeString(strings.Join(received.Answer[0].(*dns.TXT).Txt, ""))
+	if err != nil || !bytes.Equal(sample, data) {
+		t.Fatalf("TXT round trip failed (%v)", err)
+	}
+}